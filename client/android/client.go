@@ -155,6 +155,7 @@ func (c *Client) PeersList() *PeerInfoArray {
 			p.IP,
 			p.FQDN,
 			p.ConnStatus.String(),
+			p.ConnPhase.String(),
 		}
 		peerInfos[n] = pi
 	}
@@ -82,11 +82,21 @@ func (a *Auth) SaveConfigIfSSOSupported(listener SSOListener) {
 }
 
 func (a *Auth) saveConfigIfSSOSupported() (bool, error) {
+	tlsConfig, err := a.config.TLSConfig()
+	if err != nil {
+		return false, err
+	}
+
+	proxyDialer, err := a.config.ProxyDialer()
+	if err != nil {
+		return false, err
+	}
+
 	supportsSSO := true
-	err := a.withBackOff(a.ctx, func() (err error) {
-		_, err = internal.GetPKCEAuthorizationFlowInfo(a.ctx, a.config.PrivateKey, a.config.ManagementURL)
+	err = a.withBackOff(a.ctx, func() (err error) {
+		_, err = internal.GetPKCEAuthorizationFlowInfo(a.ctx, a.config.PrivateKey, a.config.ManagementURL, tlsConfig, proxyDialer)
 		if s, ok := gstatus.FromError(err); ok && (s.Code() == codes.NotFound || s.Code() == codes.Unimplemented) {
-			_, err = internal.GetDeviceAuthorizationFlowInfo(a.ctx, a.config.PrivateKey, a.config.ManagementURL)
+			_, err = internal.GetDeviceAuthorizationFlowInfo(a.ctx, a.config.PrivateKey, a.config.ManagementURL, tlsConfig, proxyDialer)
 			s, ok := gstatus.FromError(err)
 			if !ok {
 				return err
@@ -160,9 +170,19 @@ func (a *Auth) Login(resultListener ErrListener, urlOpener URLOpener) {
 func (a *Auth) login(urlOpener URLOpener) error {
 	var needsLogin bool
 
+	tlsConfig, err := a.config.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	proxyDialer, err := a.config.ProxyDialer()
+	if err != nil {
+		return err
+	}
+
 	// check if we need to generate JWT token
-	err := a.withBackOff(a.ctx, func() (err error) {
-		needsLogin, err = internal.IsLoginRequired(a.ctx, a.config.PrivateKey, a.config.ManagementURL, a.config.SSHKey)
+	err = a.withBackOff(a.ctx, func() (err error) {
+		needsLogin, err = internal.IsLoginRequired(a.ctx, a.config.PrivateKey, a.config.ManagementURL, a.config.SSHKey, a.config.SystemMetaPrivacy, tlsConfig, proxyDialer)
 		return
 	})
 	if err != nil {
@@ -5,6 +5,8 @@ type PeerInfo struct {
 	IP         string
 	FQDN       string
 	ConnStatus string // Todo replace to enum
+	// ConnPhase is the last phase the connection establishment reached, e.g. gathering candidates
+	ConnPhase string
 }
 
 // PeerInfoCollection made for Java layer to get non default types as collection
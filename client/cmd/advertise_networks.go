@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/util"
+)
+
+var advertiseNetworksClear bool
+
+var advertiseNetworksCmd = &cobra.Command{
+	Use:   "advertise-networks [CIDR...]",
+	Short: "set the local networks the peer offers to route",
+	Long: "Sets the networks of the local network the peer offers to route to the other peers, e.g. 192.168.1.0/24, " +
+		"and reports them to the Management Service. The administrators of the account create the routes through the peer " +
+		"for the peers allowed to reach them. The networks can't overlap the NetBird network. " +
+		"The client is reconnected to report the networks if it is up. Without arguments, the advertised networks are shown.",
+	RunE: advertiseNetworksFunc,
+}
+
+func init() {
+	advertiseNetworksCmd.Flags().BoolVar(&advertiseNetworksClear, "clear", false, "stop advertising the networks")
+}
+
+func advertiseNetworksFunc(cmd *cobra.Command, args []string) error {
+	SetFlagsFromEnvVars(rootCmd)
+
+	cmd.SetOut(cmd.OutOrStdout())
+
+	if advertiseNetworksClear && len(args) > 0 {
+		return fmt.Errorf("the networks can't be set together with the --clear flag")
+	}
+
+	err := util.InitLog(logLevel, "console")
+	if err != nil {
+		return fmt.Errorf("failed initializing log %v", err)
+	}
+
+	conn, err := DialClientGRPCServer(cmd.Context(), daemonAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon error: %v\n"+
+			"If the daemon is not running please run: "+
+			"\nnetbird service install \nnetbird service start\n", err)
+	}
+	defer conn.Close()
+
+	client := proto.NewDaemonServiceClient(conn)
+
+	if len(args) == 0 && !advertiseNetworksClear {
+		resp, err := client.GetConfig(cmd.Context(), &proto.GetConfigRequest{})
+		if err != nil {
+			return fmt.Errorf("get config failed: %v", status.Convert(err).Message())
+		}
+		printAdvertisedNetworks(cmd, resp.GetAdvertisedNetworks())
+		return nil
+	}
+
+	resp, err := client.SetAdvertisedNetworks(cmd.Context(), &proto.SetAdvertisedNetworksRequest{Networks: args})
+	if err != nil {
+		return fmt.Errorf("set advertised networks failed: %v", status.Convert(err).Message())
+	}
+
+	printAdvertisedNetworks(cmd, resp.GetNetworks())
+	if resp.GetReconnected() {
+		cmd.Println("Reconnected to report the networks")
+	}
+	return nil
+}
+
+func printAdvertisedNetworks(cmd *cobra.Command, networks []string) {
+	if len(networks) == 0 {
+		cmd.Println("No networks are advertised")
+		return
+	}
+	cmd.Printf("Advertised networks: %s\n", strings.Join(networks, ", "))
+}
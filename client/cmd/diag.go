@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/util"
+)
+
+// maxBundleMsgSize limits the size of the debug bundle received from the daemon
+const maxBundleMsgSize = 64 << 20
+
+var diagOutput string
+
+var diagCmd = &cobra.Command{
+	Use:   "diag",
+	Short: "collect a self-diagnostic bundle for the support tickets",
+	Long: "Collects the redacted config, the status and its recent events, the WireGuard interface, the DNS resolver, " +
+		"the firewall and the system state of the client into a zip archive to attach to the support tickets. " +
+		"The keys and the setup keys are scrubbed from the archive.",
+	RunE: diagFunc,
+}
+
+func init() {
+	diagCmd.Flags().StringVarP(&diagOutput, "output", "o", "", "path of the archive, netbird-diag-<time>.zip in the current directory by default")
+}
+
+func diagFunc(cmd *cobra.Command, _ []string) error {
+	SetFlagsFromEnvVars(rootCmd)
+
+	cmd.SetOut(cmd.OutOrStdout())
+
+	err := util.InitLog(logLevel, "console")
+	if err != nil {
+		return fmt.Errorf("failed initializing log %v", err)
+	}
+
+	conn, err := DialClientGRPCServer(cmd.Context(), daemonAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon error: %v\n"+
+			"If the daemon is not running please run: "+
+			"\nnetbird service install \nnetbird service start\n", err)
+	}
+	defer conn.Close()
+
+	resp, err := proto.NewDaemonServiceClient(conn).DebugBundle(cmd.Context(), &proto.DebugBundleRequest{},
+		grpc.MaxCallRecvMsgSize(maxBundleMsgSize))
+	if err != nil {
+		return fmt.Errorf("collect the debug bundle failed: %v", status.Convert(err).Message())
+	}
+
+	output := diagOutput
+	if output == "" {
+		output = fmt.Sprintf("netbird-diag-%s.zip", time.Now().Format("20060102-150405"))
+	}
+
+	if err := os.WriteFile(output, resp.GetArchive(), 0600); err != nil {
+		return fmt.Errorf("write the debug bundle: %v", err)
+	}
+
+	cmd.Printf("Debug bundle written to %s\n", output)
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/util"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "follow the connection events of the client",
+	Long: "Prints the peer connection, the Management and Signal connection and the local address changes of the " +
+		"client as they happen, until interrupted.",
+	RunE: eventsFunc,
+}
+
+func eventsFunc(cmd *cobra.Command, _ []string) error {
+	SetFlagsFromEnvVars(rootCmd)
+
+	cmd.SetOut(cmd.OutOrStdout())
+
+	err := util.InitLog(logLevel, "console")
+	if err != nil {
+		return fmt.Errorf("failed initializing log %v", err)
+	}
+
+	conn, err := DialClientGRPCServer(cmd.Context(), daemonAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon error: %v\n"+
+			"If the daemon is not running please run: "+
+			"\nnetbird service install \nnetbird service start\n", err)
+	}
+	defer conn.Close()
+
+	stream, err := proto.NewDaemonServiceClient(conn).SubscribeEvents(cmd.Context(), &proto.SubscribeEventsRequest{})
+	if err != nil {
+		return fmt.Errorf("subscribing to the events failed: %v", status.Convert(err).Message())
+	}
+
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("receiving the events failed: %v", status.Convert(err).Message())
+		}
+		cmd.Println(formatConnectionEvent(event))
+	}
+}
+
+func formatConnectionEvent(event *proto.ConnectionEvent) string {
+	eventTime := event.GetTime().AsTime().Local().Format(time.RFC3339)
+
+	switch event.GetType() {
+	case "peer":
+		peerState := event.GetPeer()
+		name := peerState.GetFqdn()
+		if name == "" {
+			name = peerState.GetPubKey()
+		}
+		return fmt.Sprintf("%s peer %s (%s): %s", eventTime, name, peerState.GetIP(), peerState.GetConnStatus())
+	case "address":
+		return fmt.Sprintf("%s local address: %s %s", eventTime, event.GetFqdn(), event.GetIP())
+	default:
+		state := "disconnected"
+		if event.GetConnected() {
+			state = "connected"
+		}
+		if event.GetError() != "" {
+			state += ": " + event.GetError()
+		}
+		return fmt.Sprintf("%s %s %s", eventTime, event.GetType(), state)
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/util"
+)
+
+var firewallCmd = &cobra.Command{
+	Use:   "firewall",
+	Short: "inspect the firewall of the client",
+}
+
+var firewallCountersCmd = &cobra.Command{
+	Use:   "counters",
+	Short: "show the packets and bytes matched by the ACL firewall rules",
+	Long: "Shows the packets and bytes accepted and dropped by the ACL firewall rules and by the rules dropping " +
+		"the traffic not allowed by the policies. Requires the FirewallFlowLogging option of the config.",
+	RunE: firewallCountersFunc,
+}
+
+func init() {
+	firewallCmd.AddCommand(firewallCountersCmd)
+}
+
+func firewallCountersFunc(cmd *cobra.Command, _ []string) error {
+	SetFlagsFromEnvVars(rootCmd)
+
+	cmd.SetOut(cmd.OutOrStdout())
+
+	err := util.InitLog(logLevel, "console")
+	if err != nil {
+		return fmt.Errorf("failed initializing log %v", err)
+	}
+
+	conn, err := DialClientGRPCServer(cmd.Context(), daemonAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon error: %v\n"+
+			"If the daemon is not running please run: "+
+			"\nnetbird service install \nnetbird service start\n", err)
+	}
+	defer conn.Close()
+
+	resp, err := proto.NewDaemonServiceClient(conn).GetFirewallCounters(cmd.Context(), &proto.GetFirewallCountersRequest{})
+	if err != nil {
+		return fmt.Errorf("get firewall counters failed: %v", status.Convert(err).Message())
+	}
+
+	cmd.Print(parseFirewallCounters(resp))
+	return nil
+}
+
+func parseFirewallCounters(resp *proto.GetFirewallCountersResponse) string {
+	if len(resp.GetCounters()) == 0 {
+		return "No firewall rules counted\n"
+	}
+
+	var b strings.Builder
+	for _, counter := range resp.GetCounters() {
+		fmt.Fprintf(&b, "%s %s %s: %d packets, %d bytes\n  %s\n",
+			counter.GetId(), counter.GetChain(), strings.ToUpper(counter.GetAction()),
+			counter.GetPackets(), counter.GetBytes(), counter.GetRule())
+	}
+	return b.String()
+}
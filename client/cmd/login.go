@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/skratchdot/open-golang/open"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc/codes"
@@ -101,18 +102,18 @@ var loginCmd = &cobra.Command{
 			if s, ok := gstatus.FromError(backOffErr); ok && (s.Code() == codes.InvalidArgument ||
 				s.Code() == codes.PermissionDenied ||
 				s.Code() == codes.NotFound ||
-				s.Code() == codes.Unimplemented) {
+				s.Code() == codes.Unimplemented) || isPermanentError(backOffErr) {
 				loginErr = backOffErr
 				return nil
 			}
 			return backOffErr
 		})
 		if err != nil {
-			return fmt.Errorf("login backoff cycle failed: %v", err)
+			return fmt.Errorf("login backoff cycle failed: %v", withErrorGuidance(err))
 		}
 
 		if loginErr != nil {
-			return fmt.Errorf("login failed: %v", loginErr)
+			return fmt.Errorf("login failed: %v", withErrorGuidance(loginErr))
 		}
 
 		if loginResp.NeedsSSOLogin {
@@ -139,6 +140,9 @@ func foregroundLogin(ctx context.Context, cmd *cobra.Command, config *internal.C
 			needsLogin = true
 			return nil
 		}
+		if isPermanentError(err) {
+			return backoff.Permanent(err)
+		}
 		return err
 	})
 	if err != nil {
@@ -162,15 +166,18 @@ func foregroundLogin(ctx context.Context, cmd *cobra.Command, config *internal.C
 			lastError = err
 			return nil
 		}
+		if isPermanentError(err) {
+			return backoff.Permanent(err)
+		}
 		return err
 	})
 
 	if lastError != nil {
-		return fmt.Errorf("login failed: %v", lastError)
+		return fmt.Errorf("login failed: %v", withErrorGuidance(lastError))
 	}
 
 	if err != nil {
-		return fmt.Errorf("backoff cycle failed: %v", err)
+		return fmt.Errorf("backoff cycle failed: %v", withErrorGuidance(err))
 	}
 
 	return nil
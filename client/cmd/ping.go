@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/util"
+)
+
+var (
+	pingTimeout   time.Duration
+	pingReconnect bool
+)
+
+var pingCmd = &cobra.Command{
+	Use:   "ping <peer>",
+	Short: "test the connection to a peer",
+	Long: "Waits for the connection to a peer and shows its path, the ICE candidates and the latency without disrupting the tunnel. " +
+		"A peer which isn't connected gets a new connection attempt right away and the time it took to connect is shown. " +
+		"With --reconnect the established connection is dropped to measure a new attempt. " +
+		"The peer can be given by its WireGuard public key, FQDN, host name or IP address.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("requires a peer argument")
+		}
+		return nil
+	},
+	RunE: pingFunc,
+}
+
+func init() {
+	pingCmd.Flags().DurationVar(&pingTimeout, "timeout", 30*time.Second, "how long to wait for the connection to the peer")
+	pingCmd.Flags().BoolVar(&pingReconnect, "reconnect", false, "drop the established connection to measure a new connection attempt, the tunnel to the peer is down meanwhile")
+}
+
+func pingFunc(cmd *cobra.Command, args []string) error {
+	SetFlagsFromEnvVars(rootCmd)
+
+	cmd.SetOut(cmd.OutOrStdout())
+
+	err := util.InitLog(logLevel, "console")
+	if err != nil {
+		return fmt.Errorf("failed initializing log %v", err)
+	}
+
+	// leave the daemon the time to answer once the connection wait timed out
+	ctx, cancel := context.WithTimeout(cmd.Context(), pingTimeout+5*time.Second)
+	defer cancel()
+
+	conn, err := DialClientGRPCServer(ctx, daemonAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon error: %v\n"+
+			"If the daemon is not running please run: "+
+			"\nnetbird service install \nnetbird service start\n", err)
+	}
+	defer conn.Close()
+
+	resp, err := proto.NewDaemonServiceClient(conn).PingPeer(ctx, &proto.PingPeerRequest{
+		Peer:      args[0],
+		Timeout:   durationpb.New(pingTimeout),
+		Reconnect: pingReconnect,
+	})
+	if err != nil {
+		return fmt.Errorf("ping failed: %v", status.Convert(err).Message())
+	}
+
+	cmd.Print(parsePingResponse(resp))
+	return nil
+}
+
+func parsePingResponse(resp *proto.PingPeerResponse) string {
+	path := "Relayed"
+	if resp.GetDirect() {
+		path = "Direct"
+	}
+
+	latency := resp.GetLatency().AsDuration().String()
+	if resp.GetLatency() == nil {
+		latency = fmt.Sprintf("not measured, %s", resp.GetLatencyError())
+	}
+
+	iceSetup := "not measured, the established connection was used"
+	if resp.GetIceSetup() != nil {
+		iceSetup = resp.GetIceSetup().AsDuration().String()
+	}
+
+	return fmt.Sprintf(
+		"Peer: %s (%s)\n"+
+			"  NetBird IP: %s\n"+
+			"  Public key: %s\n"+
+			"  Connection type: %s\n"+
+			"  ICE candidate (Local/Remote): %s/%s\n"+
+			"  ICE candidate endpoints (Local/Remote): %s/%s\n"+
+			"  Connection setup: %s\n"+
+			"  Latency: %s\n",
+		resp.GetFqdn(), resp.GetIP(),
+		resp.GetIP(),
+		resp.GetPubKey(),
+		path,
+		resp.GetLocalIceCandidateType(), resp.GetRemoteIceCandidateType(),
+		resp.GetLocalIceCandidateEndpoint(), resp.GetRemoteIceCandidateEndpoint(),
+		iceSetup,
+		latency,
+	)
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/util"
+)
+
+var (
+	profileInterfaceName string
+	profileWireguardPort uint16
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "manage the profiles connecting the daemon to several NetBird networks",
+	Long: "Manages the profiles of the daemon. Each profile connects to a NetBird network with its own config, " +
+		"WireGuard interface and port. The login, up, down and status commands apply to the active profile.",
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "add a profile",
+	Long: "Adds a profile connecting to the Management Service of the --management-url flag. The interface name and " +
+		"the WireGuard port are picked if not set, so they don't collide with the ones of the other profiles. " +
+		"Switch to the profile and run login to connect it.",
+	Args: cobra.ExactArgs(1),
+	RunE: profileAddFunc,
+}
+
+var profileSwitchCmd = &cobra.Command{
+	Use:   "switch <name>",
+	Short: "make a profile the active one",
+	Long: "Makes the profile the active one the other commands apply to. The previous active profile keeps running, " +
+		"only one profile can be up at a time.",
+	Args: cobra.ExactArgs(1),
+	RunE: profileSwitchFunc,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the profiles with their state",
+	RunE:  profileListFunc,
+}
+
+func init() {
+	profileAddCmd.Flags().StringVar(&profileInterfaceName, interfaceNameFlag, "", "Wireguard interface name of the profile, picked if not set")
+	profileAddCmd.Flags().Uint16Var(&profileWireguardPort, wireguardPortFlag, 0, "Wireguard interface listening port of the profile, picked if not set")
+	profileCmd.AddCommand(profileAddCmd, profileSwitchCmd, profileListCmd)
+}
+
+func profileAddFunc(cmd *cobra.Command, args []string) error {
+	if profileInterfaceName != "" {
+		if err := parseInterfaceName(profileInterfaceName); err != nil {
+			return err
+		}
+	}
+
+	return withDaemonClient(cmd, func(ctx context.Context, client proto.DaemonServiceClient) error {
+		resp, err := client.AddProfile(ctx, &proto.AddProfileRequest{
+			Name:          args[0],
+			ManagementUrl: managementURL,
+			AdminURL:      adminURL,
+			InterfaceName: profileInterfaceName,
+			WireguardPort: int64(profileWireguardPort),
+		})
+		if err != nil {
+			return fmt.Errorf("add profile failed: %v", status.Convert(err).Message())
+		}
+		cmd.Printf("Profile %s added\n", resp.GetProfile().GetName())
+		cmd.Print(parseProfiles([]*proto.ProfileState{resp.GetProfile()}))
+		return nil
+	})
+}
+
+func profileSwitchFunc(cmd *cobra.Command, args []string) error {
+	return withDaemonClient(cmd, func(ctx context.Context, client proto.DaemonServiceClient) error {
+		if _, err := client.SwitchProfile(ctx, &proto.SwitchProfileRequest{Name: args[0]}); err != nil {
+			return fmt.Errorf("switch profile failed: %v", status.Convert(err).Message())
+		}
+		cmd.Printf("Switched to the profile %s\n", args[0])
+		return nil
+	})
+}
+
+func profileListFunc(cmd *cobra.Command, _ []string) error {
+	return withDaemonClient(cmd, func(ctx context.Context, client proto.DaemonServiceClient) error {
+		resp, err := client.ListProfiles(ctx, &proto.ListProfilesRequest{})
+		if err != nil {
+			return fmt.Errorf("list profiles failed: %v", status.Convert(err).Message())
+		}
+		cmd.Print(parseProfiles(resp.GetProfiles()))
+		return nil
+	})
+}
+
+// withDaemonClient connects to the daemon and runs the function with its client
+func withDaemonClient(cmd *cobra.Command, f func(ctx context.Context, client proto.DaemonServiceClient) error) error {
+	SetFlagsFromEnvVars(rootCmd)
+
+	cmd.SetOut(cmd.OutOrStdout())
+
+	err := util.InitLog(logLevel, "console")
+	if err != nil {
+		return fmt.Errorf("failed initializing log %v", err)
+	}
+
+	conn, err := DialClientGRPCServer(cmd.Context(), daemonAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon error: %v\n"+
+			"If the daemon is not running please run: "+
+			"\nnetbird service install \nnetbird service start\n", err)
+	}
+	defer conn.Close()
+
+	return f(cmd.Context(), proto.NewDaemonServiceClient(conn))
+}
+
+func parseProfiles(profiles []*proto.ProfileState) string {
+	var b strings.Builder
+	for _, profile := range profiles {
+		marker := " "
+		if profile.GetActive() {
+			marker = "*"
+		}
+		fmt.Fprintf(&b, "%s %s: %s\n", marker, profile.GetName(), profile.GetStatus())
+		if profile.GetManagementUrl() != "" {
+			fmt.Fprintf(&b, "    Management: %s\n", profile.GetManagementUrl())
+		}
+		if profile.GetInterfaceName() != "" {
+			fmt.Fprintf(&b, "    Interface: %s, port %d\n", profile.GetInterfaceName(), profile.GetWireguardPort())
+		}
+		fmt.Fprintf(&b, "    Config: %s\n", profile.GetConfigFile())
+	}
+	return b.String()
+}
@@ -22,6 +22,9 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/client/proto"
+	mgm "github.com/netbirdio/netbird/management/client"
+	"github.com/netbirdio/netbird/util"
 )
 
 const (
@@ -31,6 +34,7 @@ const (
 	preSharedKeyFlag    = "preshared-key"
 	interfaceNameFlag   = "interface-name"
 	wireguardPortFlag   = "wireguard-port"
+	excludeRouteFlag    = "exclude-route"
 )
 
 var (
@@ -45,6 +49,7 @@ var (
 	oldDefaultLogFileDir    string
 	oldDefaultLogFile       string
 	logFile                 string
+	logOptions              util.LogOptions
 	daemonAddr              string
 	managementURL           string
 	adminURL                string
@@ -52,6 +57,7 @@ var (
 	hostName                string
 	preSharedKey            string
 	natExternalIPs          []string
+	excludedRoutes          []string
 	customDNSAddress        string
 	rosenpassEnabled        bool
 	interfaceName           string
@@ -100,7 +106,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", defaultConfigPath, "Netbird config file location")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "info", "sets Netbird log level")
 	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", defaultLogFile, "sets Netbird log path. If console is specified the log will be output to stdout")
-	rootCmd.PersistentFlags().StringVarP(&setupKey, "setup-key", "k", "", "Setup key obtained from the Management Service Dashboard (used to register peer)")
+	logOptions.RegisterFlags(rootCmd.PersistentFlags())
+	rootCmd.PersistentFlags().StringVarP(&setupKey, "setup-key", "k", "", "Setup key obtained from the Management Service Dashboard (used to register peer), or an org key followed by the ID of the account to register into, e.g. <org key>:<account ID>")
 	rootCmd.PersistentFlags().StringVar(&preSharedKey, preSharedKeyFlag, "", "Sets Wireguard PreSharedKey property. If set, then only peers that have the same key can communicate.")
 	rootCmd.PersistentFlags().StringVarP(&hostName, "hostname", "n", "", "Sets a custom hostname for the device")
 	rootCmd.AddCommand(serviceCmd)
@@ -110,6 +117,14 @@ func init() {
 	rootCmd.AddCommand(loginCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(sshCmd)
+	rootCmd.AddCommand(pingCmd)
+	rootCmd.AddCommand(firewallCmd)
+	rootCmd.AddCommand(diagCmd)
+	rootCmd.AddCommand(rotateKeyCmd)
+	rootCmd.AddCommand(checkSetupKeyCmd)
+	rootCmd.AddCommand(advertiseNetworksCmd)
+	rootCmd.AddCommand(eventsCmd)
+	rootCmd.AddCommand(profileCmd)
 	serviceCmd.AddCommand(runCmd, startCmd, stopCmd, restartCmd) // service control commands are subcommands of service
 	serviceCmd.AddCommand(installCmd, uninstallCmd)              // service installer commands are subcommands of service
 	upCmd.PersistentFlags().StringSliceVar(&natExternalIPs, externalIPMapFlag, nil,
@@ -119,6 +134,12 @@ func init() {
 			`E.g. --external-ip-map 12.34.56.78/10.0.0.1 or --external-ip-map 12.34.56.200,12.34.56.78/10.0.0.1,12.34.56.80/eth1 `+
 			`or --external-ip-map ""`,
 	)
+	upCmd.PersistentFlags().StringSliceVar(&excludedRoutes, excludeRouteFlag, nil,
+		`Sets the IPs and the networks routed outside the tunnel through the gateway of the host, `+
+			`even when a NetBird route covers them. You can specify a comma-separated list of IPs and CIDRs. `+
+			`An empty string "" clears the previous configuration. `+
+			`E.g. --exclude-route 192.168.1.20,10.10.0.0/16 or --exclude-route ""`,
+	)
 	upCmd.PersistentFlags().StringVar(&customDNSAddress, dnsResolverAddress, "",
 		`Sets a custom address for NetBird's local DNS resolver. `+
 			`If set, the agent won't attempt to discover the best ip and port to listen on. `+
@@ -128,22 +149,51 @@ func init() {
 	upCmd.PersistentFlags().BoolVar(&rosenpassEnabled, enableRosenpassFlag, false, "[Experimental] Enable Rosenpass feature. If enabled, the connection will be post-quantum secured via Rosenpass.")
 }
 
-// SetupCloseHandler handles SIGTERM signal and exits with success
-func SetupCloseHandler(ctx context.Context, cancel context.CancelFunc) {
+// SetupCloseHandler handles SIGTERM signal and exits with success.
+// When reload is set, it is called on every SIGHUP signal until the context is done.
+func SetupCloseHandler(ctx context.Context, cancel context.CancelFunc, reload func()) {
 	termCh := make(chan os.Signal, 1)
 	signal.Notify(termCh, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+
+	reloadCh := make(chan os.Signal, 1)
+	if reload != nil {
+		signal.Notify(reloadCh, syscall.SIGHUP)
+	}
+
 	go func() {
+		defer signal.Stop(reloadCh)
+
 		done := ctx.Done()
-		select {
-		case <-done:
-		case <-termCh:
-		}
+		for {
+			select {
+			case <-done:
+			case <-termCh:
+			case <-reloadCh:
+				log.Info("reload signal received")
+				reload()
+				continue
+			}
 
-		log.Info("shutdown signal received")
-		cancel()
+			log.Info("shutdown signal received")
+			cancel()
+			return
+		}
 	}()
 }
 
+// reloadConfigFile re-reads the config file and applies the changed settings to the client running in the context
+func reloadConfigFile(ctx context.Context) {
+	config, err := internal.ReadConfig(configPath)
+	if err != nil {
+		log.Errorf("failed reloading config file %s: %v", configPath, err)
+		return
+	}
+
+	if _, err := internal.ReloadConfig(ctx, config); err != nil {
+		log.Errorf("failed reloading config: %v", err)
+	}
+}
+
 // SetFlagsFromEnvVars reads and updates flag values from environment variables with prefix WT_
 func SetFlagsFromEnvVars(cmd *cobra.Command) {
 	flags := cmd.PersistentFlags()
@@ -197,14 +247,47 @@ func WithBackOff(bf func() error) error {
 }
 
 // CLIBackOffSettings is default backoff settings for CLI commands.
-var CLIBackOffSettings = &backoff.ExponentialBackOff{
-	InitialInterval:     time.Second,
-	RandomizationFactor: backoff.DefaultRandomizationFactor,
-	Multiplier:          backoff.DefaultMultiplier,
-	MaxInterval:         10 * time.Second,
-	MaxElapsedTime:      30 * time.Second,
-	Stop:                backoff.Stop,
-	Clock:               backoff.SystemClock,
+// It shares the settings of the daemon and gives up after 30 seconds.
+var CLIBackOffSettings = mgm.NewBackOff(30 * time.Second)
+
+// withErrorGuidance appends an actionable guidance to the classified client and daemon errors
+func withErrorGuidance(err error) error {
+	guidance := errorGuidance(internal.ErrorDetailsFromError(err))
+	if guidance == "" {
+		return err
+	}
+	return fmt.Errorf("%w\n%s", err, guidance)
+}
+
+func errorGuidance(details *proto.ErrorDetails) string {
+	switch details.GetCode() {
+	case proto.ErrorCode_CONFIG_INVALID:
+		return "Please check the provided flags and the client configuration file."
+	case proto.ErrorCode_MANAGEMENT_UNREACHABLE:
+		return fmt.Sprintf("Please check that the Management Service %s is reachable from this host "+
+			"and that no firewall or proxy blocks the connection.", details.GetManagementUrl())
+	case proto.ErrorCode_SIGNAL_UNREACHABLE:
+		return fmt.Sprintf("Please check that the Signal Service %s, received from the Management Service %s, "+
+			"is reachable from this host and that no firewall or proxy blocks the connection.",
+			details.GetSignalUrl(), details.GetManagementUrl())
+	case proto.ErrorCode_AUTH_REQUIRED:
+		return "Please login with a valid setup key using the --setup-key flag, or without it to login with SSO."
+	case proto.ErrorCode_INTERFACE_CREATION_FAILED:
+		return "Please check that NetBird runs with administrator privileges " +
+			"and that the interface name and the WireGuard port are not used by another process."
+	default:
+		return ""
+	}
+}
+
+// isPermanentError reports whether the daemon error is not solved by retrying the call
+func isPermanentError(err error) bool {
+	switch internal.ErrorDetailsFromError(err).GetCode() {
+	case proto.ErrorCode_CONFIG_INVALID, proto.ErrorCode_AUTH_REQUIRED, proto.ErrorCode_INTERFACE_CREATION_FAILED:
+		return true
+	default:
+		return false
+	}
 }
 
 func handleRebrand(cmd *cobra.Command) error {
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/util"
+)
+
+var rotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "replace the WireGuard key of the peer",
+	Long: "Generates a new WireGuard key and replaces the key of the peer on the Management Service with it. " +
+		"The peer keeps its IP, name and groups, the old key can't be used to log in anymore. " +
+		"The client is reconnected with the new key if it is up.",
+	RunE: rotateKeyFunc,
+}
+
+func rotateKeyFunc(cmd *cobra.Command, _ []string) error {
+	SetFlagsFromEnvVars(rootCmd)
+
+	cmd.SetOut(cmd.OutOrStdout())
+
+	err := util.InitLog(logLevel, "console")
+	if err != nil {
+		return fmt.Errorf("failed initializing log %v", err)
+	}
+
+	conn, err := DialClientGRPCServer(cmd.Context(), daemonAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon error: %v\n"+
+			"If the daemon is not running please run: "+
+			"\nnetbird service install \nnetbird service start\n", err)
+	}
+	defer conn.Close()
+
+	resp, err := proto.NewDaemonServiceClient(conn).RotateKey(cmd.Context(), &proto.RotateKeyRequest{})
+	if err != nil {
+		return fmt.Errorf("key rotation failed: %v", status.Convert(err).Message())
+	}
+
+	cmd.Printf("Rotated the WireGuard key, the new public key is %s\n", resp.GetPubKey())
+	if resp.GetReconnected() {
+		cmd.Println("Reconnected with the new key")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"runtime"
+	"sync"
 
 	"github.com/kardianos/service"
 	log "github.com/sirupsen/logrus"
@@ -10,12 +11,17 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/client/proto"
+	"github.com/netbirdio/netbird/client/server"
 )
 
 type program struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 	serv   *grpc.Server
+
+	mu     sync.Mutex
+	daemon *server.Server
 }
 
 func newProgram(ctx context.Context, cancel context.CancelFunc) *program {
@@ -23,6 +29,23 @@ func newProgram(ctx context.Context, cancel context.CancelFunc) *program {
 	return &program{ctx: ctx, cancel: cancel}
 }
 
+// reloadConfig reloads the config of the daemon, so the daemon keeps the reloaded config like with the ReloadConfig
+// request
+func (p *program) reloadConfig() {
+	p.mu.Lock()
+	daemon := p.daemon
+	p.mu.Unlock()
+
+	if daemon == nil {
+		log.Warn("daemon is not started yet, ignoring the reload signal")
+		return
+	}
+
+	if _, err := daemon.ReloadConfig(p.ctx, &proto.ReloadConfigRequest{}); err != nil {
+		log.Errorf("failed reloading config: %v", err)
+	}
+}
+
 func newSVCConfig() *service.Config {
 	name := "netbird"
 	if runtime.GOOS == "windows" {
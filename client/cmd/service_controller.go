@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 
+	"github.com/netbirdio/netbird/client/internal"
 	"github.com/netbirdio/netbird/client/proto"
 	"github.com/netbirdio/netbird/client/server"
 	"github.com/netbirdio/netbird/util"
@@ -60,6 +61,9 @@ func (p *program) Start(svc service.Service) error {
 			log.Fatalf("failed to start daemon: %v", err)
 		}
 		proto.RegisterDaemonServiceServer(p.serv, serverInstance)
+		p.mu.Lock()
+		p.daemon = serverInstance
+		p.mu.Unlock()
 
 		log.Printf("started daemon server: %v", split[1])
 		if err := p.serv.Serve(listen); err != nil {
@@ -94,15 +98,20 @@ var runCmd = &cobra.Command{
 			return err
 		}
 
-		err = util.InitLog(logLevel, logFile)
+		err = util.InitLogWithOptions(logLevel, logFile, logOptions)
 		if err != nil {
 			return fmt.Errorf("failed initializing log %v", err)
 		}
 
+		if err := enterConfiguredNetworkNamespace(); err != nil {
+			return err
+		}
+
 		ctx, cancel := context.WithCancel(cmd.Context())
-		SetupCloseHandler(ctx, cancel)
+		prg := newProgram(ctx, cancel)
+		SetupCloseHandler(ctx, cancel, prg.reloadConfig)
 
-		s, err := newSVC(newProgram(ctx, cancel), newSVCConfig())
+		s, err := newSVC(prg, newSVCConfig())
 		if err != nil {
 			return err
 		}
@@ -127,7 +136,7 @@ var startCmd = &cobra.Command{
 			return err
 		}
 
-		err = util.InitLog(logLevel, logFile)
+		err = util.InitLogWithOptions(logLevel, logFile, logOptions)
 		if err != nil {
 			return err
 		}
@@ -162,7 +171,7 @@ var stopCmd = &cobra.Command{
 			return err
 		}
 
-		err = util.InitLog(logLevel, logFile)
+		err = util.InitLogWithOptions(logLevel, logFile, logOptions)
 		if err != nil {
 			return fmt.Errorf("failed initializing log %v", err)
 		}
@@ -195,7 +204,7 @@ var restartCmd = &cobra.Command{
 			return err
 		}
 
-		err = util.InitLog(logLevel, logFile)
+		err = util.InitLogWithOptions(logLevel, logFile, logOptions)
 		if err != nil {
 			return fmt.Errorf("failed initializing log %v", err)
 		}
@@ -214,3 +223,17 @@ var restartCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// enterConfiguredNetworkNamespace re-executes the daemon in the network namespace of the existing config,
+// before it starts serving, so the daemon runs in the namespace as a whole
+func enterConfiguredNetworkNamespace() error {
+	if _, err := os.Stat(configPath); err != nil {
+		return nil
+	}
+
+	config, err := internal.ReadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("read config file: %v", err)
+	}
+	return internal.EnterNetworkNamespace(config.NetworkNamespace)
+}
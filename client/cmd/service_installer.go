@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 
 	"github.com/spf13/cobra"
 )
@@ -41,6 +42,13 @@ var installCmd = &cobra.Command{
 			svcConfig.Arguments = append(svcConfig.Arguments, "--log-file", logFile)
 		}
 
+		svcConfig.Arguments = append(svcConfig.Arguments,
+			"--log-format", logOptions.Format,
+			"--log-max-size", strconv.Itoa(logOptions.MaxSizeMB),
+			"--log-max-backups", strconv.Itoa(logOptions.MaxBackups),
+			"--log-max-age", strconv.Itoa(logOptions.MaxAgeDays),
+		)
+
 		if runtime.GOOS == "linux" {
 			// Respected only by systemd systems
 			svcConfig.Dependencies = []string{"After=network.target syslog.target"}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/util"
+)
+
+var checkSetupKeyCmd = &cobra.Command{
+	Use:   "check-setup-key",
+	Short: "checks whether a setup key can register a peer, without using it",
+	Long: "Checks whether the setup key passed with --setup-key can register a peer in the Management Service, " +
+		"e.g. before provisioning a machine. It prints the remaining uses and the expiration of the key " +
+		"and fails if the key is not valid.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		SetFlagsFromEnvVars(rootCmd)
+
+		cmd.SetOut(cmd.OutOrStdout())
+
+		err := util.InitLog(logLevel, "console")
+		if err != nil {
+			return fmt.Errorf("failed initializing log %v", err)
+		}
+
+		if setupKey == "" {
+			return fmt.Errorf("setup key is required, please set it with --setup-key")
+		}
+
+		mgmURL, config, err := checkSetupKeyTarget()
+		if err != nil {
+			return err
+		}
+
+		tlsConfig, err := config.TLSConfig()
+		if err != nil {
+			return err
+		}
+
+		proxyDialer, err := config.ProxyDialer()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		state, err := internal.CheckSetupKey(ctx, mgmURL, setupKey, tlsConfig, proxyDialer)
+		if err != nil {
+			return fmt.Errorf("failed checking the setup key: %v", err)
+		}
+
+		if !state.GetValid() {
+			return fmt.Errorf("setup key is not valid")
+		}
+
+		cmd.Println("Setup key is valid")
+		if state.GetRemainingUses() < 0 {
+			cmd.Println("Remaining uses: unlimited")
+		} else {
+			cmd.Printf("Remaining uses: %d\n", state.GetRemainingUses())
+		}
+		if state.GetExpiresAt() != nil {
+			cmd.Printf("Expires at: %s\n", state.GetExpiresAt().AsTime().Local().Format(time.RFC1123))
+		}
+
+		return nil
+	},
+}
+
+// checkSetupKeyTarget returns the Management Service URL of the flag, of the existing config or the default one.
+// The existing config also provides the CA certificates and the proxy, it isn't created when missing.
+func checkSetupKeyTarget() (*url.URL, *internal.Config, error) {
+	config := &internal.Config{}
+	if _, err := os.Stat(configPath); err == nil {
+		config, err = internal.ReadConfig(configPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read config file: %v", err)
+		}
+	}
+
+	target := managementURL
+	if target == "" && config.ManagementURL != nil {
+		return config.ManagementURL, config, nil
+	}
+	if target == "" {
+		target = internal.DefaultManagementURL
+	}
+
+	mgmURL, err := url.ParseRequestURI(target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid Management Service URL %s: %v", target, err)
+	}
+	return mgmURL, config, nil
+}
@@ -34,12 +34,19 @@ type peerStateDetailOutput struct {
 	LastWireguardHandshake time.Time        `json:"lastWireguardHandshake" yaml:"lastWireguardHandshake"`
 	TransferReceived       int64            `json:"transferReceived" yaml:"transferReceived"`
 	TransferSent           int64            `json:"transferSent" yaml:"transferSent"`
+	ConnPhase              string           `json:"connectionPhase" yaml:"connectionPhase"`
+	ConnPhaseUpdate        time.Time        `json:"connectionPhaseUpdate" yaml:"connectionPhaseUpdate"`
+	RelayFallback          bool             `json:"relayFallback" yaml:"relayFallback"`
+	RelayByPolicy          bool             `json:"relayByPolicy" yaml:"relayByPolicy"`
 }
 
 type peersStateOutput struct {
-	Total     int                     `json:"total" yaml:"total"`
-	Connected int                     `json:"connected" yaml:"connected"`
-	Details   []peerStateDetailOutput `json:"details" yaml:"details"`
+	Total        int                     `json:"total" yaml:"total"`
+	Connected    int                     `json:"connected" yaml:"connected"`
+	Direct       int                     `json:"direct" yaml:"direct"`
+	Relayed      int                     `json:"relayed" yaml:"relayed"`
+	Disconnected int                     `json:"disconnected" yaml:"disconnected"`
+	Details      []peerStateDetailOutput `json:"details" yaml:"details"`
 }
 
 type signalStateOutput struct {
@@ -92,6 +99,7 @@ var (
 	ipsFilter            []string
 	prefixNamesFilter    []string
 	statusFilter         string
+	pathFilter           string
 	ipsFilterMap         map[string]struct{}
 	prefixNamesFilterMap map[string]struct{}
 )
@@ -113,6 +121,7 @@ func init() {
 	statusCmd.PersistentFlags().StringSliceVar(&ipsFilter, "filter-by-ips", []string{}, "filters the detailed output by a list of one or more IPs, e.g., --filter-by-ips 100.64.0.100,100.64.0.200")
 	statusCmd.PersistentFlags().StringSliceVar(&prefixNamesFilter, "filter-by-names", []string{}, "filters the detailed output by a list of one or more peer FQDN or hostnames, e.g., --filter-by-names peer-a,peer-b.netbird.cloud")
 	statusCmd.PersistentFlags().StringVar(&statusFilter, "filter-by-status", "", "filters the detailed output by connection status(connected|disconnected), e.g., --filter-by-status connected")
+	statusCmd.PersistentFlags().StringVar(&pathFilter, "filter-by-path", "", "filters the detailed output by connection path(direct|relayed|disconnected), e.g., --filter-by-path relayed")
 }
 
 func statusFunc(cmd *cobra.Command, args []string) error {
@@ -186,7 +195,10 @@ func getStatus(ctx context.Context, cmd *cobra.Command) (*proto.StatusResponse,
 	}
 	defer conn.Close()
 
-	resp, err := proto.NewDaemonServiceClient(conn).Status(cmd.Context(), &proto.StatusRequest{GetFullPeerStatus: true})
+	resp, err := proto.NewDaemonServiceClient(conn).Status(cmd.Context(), &proto.StatusRequest{
+		GetFullPeerStatus: true,
+		PathFilter:        pathFilter,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("status failed: %v", status.Convert(err).Message())
 	}
@@ -205,6 +217,13 @@ func parseFilters() error {
 		return fmt.Errorf("wrong status filter, should be one of connected|disconnected, got: %s", statusFilter)
 	}
 
+	if pathFilter != "" {
+		if _, err := peer.ParseConnPath(pathFilter); err != nil {
+			return fmt.Errorf("wrong path filter: %v", err)
+		}
+		enableDetailFlagWhenFilterFlag()
+	}
+
 	if len(ipsFilter) > 0 {
 		for _, addr := range ipsFilter {
 			_, err := netip.ParseAddr(addr)
@@ -251,6 +270,9 @@ func convertToStatusOutputOverview(resp *proto.StatusResponse) statusOutputOverv
 
 	relayOverview := mapRelays(pbFullStatus.GetRelays())
 	peersOverview := mapPeers(resp.GetFullStatus().GetPeers())
+	peersOverview.Direct = int(pbFullStatus.GetDirectPeers())
+	peersOverview.Relayed = int(pbFullStatus.GetRelayedPeers())
+	peersOverview.Disconnected = int(pbFullStatus.GetDisconnectedPeers())
 
 	overview := statusOutputOverview{
 		Peers:           peersOverview,
@@ -306,12 +328,18 @@ func mapPeers(peers []*proto.PeerState) peersStateOutput {
 	transferReceived := int64(0)
 	transferSent := int64(0)
 	for _, pbPeerState := range peers {
-		isPeerConnected := pbPeerState.ConnStatus == peer.StatusConnected.String()
+		// stale peers are still connected, but their WireGuard session doesn't work
+		isPeerStale := pbPeerState.ConnStatus == peer.StatusStale.String()
+		isPeerConnected := pbPeerState.ConnStatus == peer.StatusConnected.String() || isPeerStale
 		if skipDetailByFilters(pbPeerState, isPeerConnected) {
 			continue
 		}
+		connPhase := ""
+		connPhaseUpdate := time.Time{}
 		if isPeerConnected {
-			peersConnected++
+			if !isPeerStale {
+				peersConnected++
+			}
 
 			localICE = pbPeerState.GetLocalIceCandidateType()
 			remoteICE = pbPeerState.GetRemoteIceCandidateType()
@@ -324,6 +352,9 @@ func mapPeers(peers []*proto.PeerState) peersStateOutput {
 			lastHandshake = pbPeerState.GetLastWireguardHandshake().AsTime().Local()
 			transferReceived = pbPeerState.GetBytesRx()
 			transferSent = pbPeerState.GetBytesTx()
+		} else {
+			connPhase = pbPeerState.GetConnPhase()
+			connPhaseUpdate = pbPeerState.GetConnPhaseUpdate().AsTime().Local()
 		}
 
 		timeLocal := pbPeerState.GetConnStatusUpdate().AsTime().Local()
@@ -346,6 +377,10 @@ func mapPeers(peers []*proto.PeerState) peersStateOutput {
 			LastWireguardHandshake: lastHandshake,
 			TransferReceived:       transferReceived,
 			TransferSent:           transferSent,
+			ConnPhase:              connPhase,
+			ConnPhaseUpdate:        connPhaseUpdate,
+			RelayFallback:          pbPeerState.GetRelayFallback(),
+			RelayByPolicy:          pbPeerState.GetRelayByPolicy(),
 		}
 
 		peersStateDetail = append(peersStateDetail, peerState)
@@ -450,6 +485,8 @@ func parseGeneralSummary(overview statusOutputOverview, showURL bool, showRelays
 	}
 
 	peersCountString := fmt.Sprintf("%d/%d Connected", overview.Peers.Connected, overview.Peers.Total)
+	peersByPathString := fmt.Sprintf("%d direct, %d relayed, %d disconnected",
+		overview.Peers.Direct, overview.Peers.Relayed, overview.Peers.Disconnected)
 
 	summary := fmt.Sprintf(
 		"Daemon version: %s\n"+
@@ -460,7 +497,8 @@ func parseGeneralSummary(overview statusOutputOverview, showURL bool, showRelays
 			"FQDN: %s\n"+
 			"NetBird IP: %s\n"+
 			"Interface type: %s\n"+
-			"Peers count: %s\n",
+			"Peers count: %s\n"+
+			"Peers by path: %s\n",
 		overview.DaemonVersion,
 		version.NetbirdVersion(),
 		managementConnString,
@@ -470,23 +508,70 @@ func parseGeneralSummary(overview statusOutputOverview, showURL bool, showRelays
 		interfaceIP,
 		interfaceTypeString,
 		peersCountString,
+		peersByPathString,
 	)
 	return summary
 }
 
 func parseToFullDetailSummary(overview statusOutputOverview) string {
 	parsedPeersString := parsePeers(overview.Peers)
+	parsedPeersByPathString := parsePeersByPath(overview.Peers)
 	summary := parseGeneralSummary(overview, true, true)
 
 	return fmt.Sprintf(
 		"Peers detail:"+
+			"%s\n"+
+			"Peers by path:"+
 			"%s\n"+
 			"%s",
 		parsedPeersString,
+		parsedPeersByPathString,
 		summary,
 	)
 }
 
+// parsePeersByPath lists the peers of the detailed output grouped by the path the traffic to them takes, with the
+// selected candidate types of the connected peers
+func parsePeersByPath(peers peersStateOutput) string {
+	paths := []peer.ConnPath{peer.ConnPathDirect, peer.ConnPathRelayed, peer.ConnPathDisconnected}
+	lists := make(map[peer.ConnPath][]string, len(paths))
+	for _, peerState := range peers.Details {
+		path := detailConnPath(peerState)
+		entry := fmt.Sprintf("  %s (%s)", peerState.FQDN, peerState.IP)
+		if path != peer.ConnPathDisconnected {
+			localICE, remoteICE := "-", "-"
+			if peerState.IceCandidateType.Local != "" {
+				localICE = peerState.IceCandidateType.Local
+			}
+			if peerState.IceCandidateType.Remote != "" {
+				remoteICE = peerState.IceCandidateType.Remote
+			}
+			entry += fmt.Sprintf(", candidates %s/%s", localICE, remoteICE)
+		}
+		lists[path] = append(lists[path], entry)
+	}
+
+	var pathsString string
+	for _, path := range paths {
+		pathsString += fmt.Sprintf("\n %s (%d):", path, len(lists[path]))
+		for _, entry := range lists[path] {
+			pathsString += "\n" + entry
+		}
+	}
+	return pathsString + "\n"
+}
+
+// detailConnPath returns the connection path of a peer of the detailed output, see peer.State.Path
+func detailConnPath(peerState peerStateDetailOutput) peer.ConnPath {
+	if peerState.Status != peer.StatusConnected.String() && peerState.Status != peer.StatusStale.String() {
+		return peer.ConnPathDisconnected
+	}
+	if peerState.ConnType == "Relayed" {
+		return peer.ConnPathRelayed
+	}
+	return peer.ConnPathDirect
+}
+
 func parsePeers(peers peersStateOutput) string {
 	var (
 		peersString = ""
@@ -523,6 +608,11 @@ func parsePeers(peers peersStateOutput) string {
 			lastWireguardHandshake = peerState.LastWireguardHandshake.Format("2006-01-02 15:04:05")
 		}
 
+		connPhase := "-"
+		if peerState.ConnPhase != "" && !peerState.ConnPhaseUpdate.IsZero() && peerState.ConnPhaseUpdate != time.Unix(0, 0) {
+			connPhase = fmt.Sprintf("%s (%s)", peerState.ConnPhase, time.Since(peerState.ConnPhaseUpdate).Round(time.Second))
+		}
+
 		peerString := fmt.Sprintf(
 			"\n %s:\n"+
 				"  NetBird IP: %s\n"+
@@ -535,7 +625,10 @@ func parsePeers(peers peersStateOutput) string {
 				"  ICE candidate endpoints (Local/Remote): %s/%s\n"+
 				"  Last connection update: %s\n"+
 				"  Last Wireguard handshake: %s\n"+
-				"  Transfer status (received/sent) %s/%s\n",
+				"  Transfer status (received/sent) %s/%s\n"+
+				"  Connection phase: %s\n"+
+				"  Relay fallback: %t\n"+
+				"  Relay by policy: %t\n",
 			peerState.FQDN,
 			peerState.IP,
 			peerState.PubKey,
@@ -550,6 +643,9 @@ func parsePeers(peers peersStateOutput) string {
 			lastWireguardHandshake,
 			toIEC(peerState.TransferReceived),
 			toIEC(peerState.TransferSent),
+			connPhase,
+			peerState.RelayFallback,
+			peerState.RelayByPolicy,
 		)
 
 		peersString += peerString
@@ -58,6 +58,7 @@ var resp = &proto.StatusResponse{
 				LastWireguardHandshake:     timestamppb.New(time.Date(2002, time.Month(2), 2, 2, 2, 3, 0, time.UTC)),
 				BytesRx:                    2000,
 				BytesTx:                    1000,
+				RelayByPolicy:              true,
 			},
 		},
 		ManagementState: &proto.ManagementState{
@@ -88,6 +89,8 @@ var resp = &proto.StatusResponse{
 			KernelInterface: true,
 			Fqdn:            "some-localhost.awesome-domain.com",
 		},
+		DirectPeers:  1,
+		RelayedPeers: 1,
 	},
 	DaemonVersion: "0.14.1",
 }
@@ -96,6 +99,8 @@ var overview = statusOutputOverview{
 	Peers: peersStateOutput{
 		Total:     2,
 		Connected: 2,
+		Direct:    1,
+		Relayed:   1,
 		Details: []peerStateDetailOutput{
 			{
 				IP:               "192.168.178.101",
@@ -136,6 +141,7 @@ var overview = statusOutputOverview{
 				LastWireguardHandshake: time.Date(2002, 2, 2, 2, 2, 3, 0, time.UTC),
 				TransferReceived:       2000,
 				TransferSent:           1000,
+				RelayByPolicy:          true,
 			},
 		},
 	},
@@ -212,6 +218,9 @@ func TestParsingToJSON(t *testing.T) {
           "peers": {
             "total": 2,
             "connected": 2,
+            "direct": 1,
+            "relayed": 1,
+            "disconnected": 0,
             "details": [
               {
                 "fqdn": "peer-1.awesome-domain.com",
@@ -231,7 +240,11 @@ func TestParsingToJSON(t *testing.T) {
                 },
                 "lastWireguardHandshake": "2001-01-01T01:01:02Z",
                 "transferReceived": 200,
-                "transferSent": 100
+                "transferSent": 100,
+                "connectionPhase": "",
+                "connectionPhaseUpdate": "0001-01-01T00:00:00Z",
+                "relayFallback": false,
+                "relayByPolicy": false
               },
               {
                 "fqdn": "peer-2.awesome-domain.com",
@@ -251,7 +264,11 @@ func TestParsingToJSON(t *testing.T) {
                 },
                 "lastWireguardHandshake": "2002-02-02T02:02:03Z",
                 "transferReceived": 2000,
-                "transferSent": 1000
+                "transferSent": 1000,
+                "connectionPhase": "",
+                "connectionPhaseUpdate": "0001-01-01T00:00:00Z",
+                "relayFallback": false,
+                "relayByPolicy": true
               }
             ]
           },
@@ -303,6 +320,9 @@ func TestParsingToYAML(t *testing.T) {
 		`peers:
     total: 2
     connected: 2
+    direct: 1
+    relayed: 1
+    disconnected: 0
     details:
         - fqdn: peer-1.awesome-domain.com
           netbirdIp: 192.168.178.101
@@ -320,6 +340,10 @@ func TestParsingToYAML(t *testing.T) {
           lastWireguardHandshake: 2001-01-01T01:01:02Z
           transferReceived: 200
           transferSent: 100
+          connectionPhase: ""
+          connectionPhaseUpdate: 0001-01-01T00:00:00Z
+          relayFallback: false
+          relayByPolicy: false
         - fqdn: peer-2.awesome-domain.com
           netbirdIp: 192.168.178.102
           publicKey: Pubkey2
@@ -336,6 +360,10 @@ func TestParsingToYAML(t *testing.T) {
           lastWireguardHandshake: 2002-02-02T02:02:03Z
           transferReceived: 2000
           transferSent: 1000
+          connectionPhase: ""
+          connectionPhaseUpdate: 0001-01-01T00:00:00Z
+          relayFallback: false
+          relayByPolicy: true
 cliVersion: development
 daemonVersion: 0.14.1
 management:
@@ -382,6 +410,9 @@ func TestParsingToDetail(t *testing.T) {
   Last connection update: 2001-01-01 01:01:01
   Last Wireguard handshake: 2001-01-01 01:01:02
   Transfer status (received/sent) 200 B/100 B
+  Connection phase: -
+  Relay fallback: false
+  Relay by policy: false
 
  peer-2.awesome-domain.com:
   NetBird IP: 192.168.178.102
@@ -395,6 +426,16 @@ func TestParsingToDetail(t *testing.T) {
   Last connection update: 2002-02-02 02:02:02
   Last Wireguard handshake: 2002-02-02 02:02:03
   Transfer status (received/sent) 2.0 KiB/1000 B
+  Connection phase: -
+  Relay fallback: false
+  Relay by policy: true
+
+Peers by path:
+ Direct (1):
+  peer-1.awesome-domain.com (192.168.178.101), candidates -/-
+ Relayed (1):
+  peer-2.awesome-domain.com (192.168.178.102), candidates relay/prflx
+ Disconnected (0):
 
 Daemon version: 0.14.1
 CLI version: development
@@ -407,6 +448,7 @@ FQDN: some-localhost.awesome-domain.com
 NetBird IP: 192.168.178.100/16
 Interface type: Kernel
 Peers count: 2/2 Connected
+Peers by path: 1 direct, 1 relayed, 0 disconnected
 `
 
 	assert.Equal(t, expectedDetail, detail)
@@ -425,6 +467,7 @@ FQDN: some-localhost.awesome-domain.com
 NetBird IP: 192.168.178.100/16
 Interface type: Kernel
 Peers count: 2/2 Connected
+Peers by path: 1 direct, 1 relayed, 0 disconnected
 `
 
 	assert.Equal(t, expectedString, shortVersion)
@@ -48,7 +48,7 @@ func upFunc(cmd *cobra.Command, args []string) error {
 
 	cmd.SetOut(cmd.OutOrStdout())
 
-	err := util.InitLog(logLevel, "console")
+	err := util.InitLogWithOptions(logLevel, "console", logOptions)
 	if err != nil {
 		return fmt.Errorf("failed initializing log %v", err)
 	}
@@ -58,6 +58,10 @@ func upFunc(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if _, err := internal.ParseExcludedRoutes(excludedRoutes); err != nil {
+		return err
+	}
+
 	ctx := internal.CtxInitState(cmd.Context())
 
 	if hostName != "" {
@@ -88,6 +92,7 @@ func runInForegroundMode(ctx context.Context, cmd *cobra.Command) error {
 		ConfigPath:       configPath,
 		NATExternalIPs:   natExternalIPs,
 		CustomDNSAddress: customDNSAddressConverted,
+		ExcludedRoutes:   excludedRoutes,
 	}
 
 	if cmd.Flag(enableRosenpassFlag).Changed {
@@ -115,6 +120,10 @@ func runInForegroundMode(ctx context.Context, cmd *cobra.Command) error {
 		return fmt.Errorf("get config file: %v", err)
 	}
 
+	if err := internal.EnterNetworkNamespace(config.NetworkNamespace); err != nil {
+		return err
+	}
+
 	config, _ = internal.UpdateOldManagementURL(ctx, config, configPath)
 
 	err = foregroundLogin(ctx, cmd, config, setupKey)
@@ -124,8 +133,8 @@ func runInForegroundMode(ctx context.Context, cmd *cobra.Command) error {
 
 	var cancel context.CancelFunc
 	ctx, cancel = context.WithCancel(ctx)
-	SetupCloseHandler(ctx, cancel)
-	return internal.RunClient(ctx, config, peer.NewRecorder(config.ManagementURL.String()))
+	SetupCloseHandler(ctx, cancel, func() { reloadConfigFile(ctx) })
+	return withErrorGuidance(internal.RunClient(ctx, config, peer.NewRecorder(config.ManagementURL.String())))
 }
 
 func runInDaemonMode(ctx context.Context, cmd *cobra.Command) error {
@@ -167,6 +176,8 @@ func runInDaemonMode(ctx context.Context, cmd *cobra.Command) error {
 		AdminURL:             adminURL,
 		NatExternalIPs:       natExternalIPs,
 		CleanNATExternalIPs:  natExternalIPs != nil && len(natExternalIPs) == 0,
+		ExcludedRoutes:       excludedRoutes,
+		CleanExcludedRoutes:  excludedRoutes != nil && len(excludedRoutes) == 0,
 		CustomDNSAddress:     customDNSAddressConverted,
 		IsLinuxDesktopClient: isLinuxRunningDesktop(),
 		Hostname:             hostName,
@@ -202,18 +213,18 @@ func runInDaemonMode(ctx context.Context, cmd *cobra.Command) error {
 		if s, ok := gstatus.FromError(backOffErr); ok && (s.Code() == codes.InvalidArgument ||
 			s.Code() == codes.PermissionDenied ||
 			s.Code() == codes.NotFound ||
-			s.Code() == codes.Unimplemented) {
+			s.Code() == codes.Unimplemented) || isPermanentError(backOffErr) {
 			loginErr = backOffErr
 			return nil
 		}
 		return backOffErr
 	})
 	if err != nil {
-		return fmt.Errorf("login backoff cycle failed: %v", err)
+		return fmt.Errorf("login backoff cycle failed: %v", withErrorGuidance(err))
 	}
 
 	if loginErr != nil {
-		return fmt.Errorf("login failed: %v", loginErr)
+		return fmt.Errorf("login failed: %v", withErrorGuidance(loginErr))
 	}
 
 	if loginResp.NeedsSSOLogin {
@@ -227,7 +238,7 @@ func runInDaemonMode(ctx context.Context, cmd *cobra.Command) error {
 	}
 
 	if _, err := client.Up(ctx, &proto.UpRequest{}); err != nil {
-		return fmt.Errorf("call service up method: %v", err)
+		return fmt.Errorf("call service up method: %v", withErrorGuidance(err))
 	}
 	cmd.Println("Connected")
 	return nil
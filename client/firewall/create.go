@@ -13,12 +13,16 @@ import (
 	"github.com/netbirdio/netbird/client/firewall/uspfilter"
 )
 
-// NewFirewall creates a firewall manager instance
-func NewFirewall(context context.Context, iface IFaceMapper) (firewall.Manager, error) {
+// NewFirewall creates a firewall manager instance. The flow logging is not supported on this OS.
+func NewFirewall(context context.Context, iface IFaceMapper, flowLogging bool) (firewall.Manager, error) {
 	if !iface.IsUserspaceBind() {
 		return nil, fmt.Errorf("not implemented for this OS: %s", runtime.GOOS)
 	}
 
+	if flowLogging {
+		log.Warnf("the firewall flow logging is not supported on %s", runtime.GOOS)
+	}
+
 	// use userspace packet filtering firewall
 	fm, err := uspfilter.Create(iface)
 	if err != nil {
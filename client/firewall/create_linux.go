@@ -32,7 +32,8 @@ const SKIP_NFTABLES_ENV = "NB_SKIP_NFTABLES_CHECK"
 // FWType is the type for the firewall type
 type FWType int
 
-func NewFirewall(context context.Context, iface IFaceMapper) (firewall.Manager, error) {
+// NewFirewall creates the firewall manager. The flow logging is supported by the iptables manager only.
+func NewFirewall(context context.Context, iface IFaceMapper, flowLogging bool) (firewall.Manager, error) {
 	// on the linux system we try to user nftables or iptables
 	// in any case, because we need to allow netbird interface traffic
 	// so we use AllowNetbird traffic from these firewall managers
@@ -43,12 +44,15 @@ func NewFirewall(context context.Context, iface IFaceMapper) (firewall.Manager,
 	switch check() {
 	case IPTABLES:
 		log.Debug("creating an iptables firewall manager")
-		fm, errFw = nbiptables.Create(context, iface)
+		fm, errFw = nbiptables.Create(context, iface, flowLogging)
 		if errFw != nil {
 			log.Errorf("failed to create iptables manager: %s", errFw)
 		}
 	case NFTABLES:
 		log.Debug("creating an nftables firewall manager")
+		if flowLogging {
+			log.Warn("the firewall flow logging is not supported by the nftables firewall manager")
+		}
 		fm, errFw = nbnftables.Create(context, iface)
 		if errFw != nil {
 			log.Errorf("failed to create nftables manager: %s", errFw)
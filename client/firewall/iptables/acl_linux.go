@@ -30,13 +30,16 @@ type aclManager struct {
 
 	entries    map[string][][]string
 	ipsetStore *ipsetStore
+
+	flowLogging bool
 }
 
-func newAclManager(iptablesClient *iptables.IPTables, wgIface iFaceMapper, routeingFwChainName string) (*aclManager, error) {
+func newAclManager(iptablesClient *iptables.IPTables, wgIface iFaceMapper, routeingFwChainName string, flowLogging bool) (*aclManager, error) {
 	m := &aclManager{
 		iptablesClient:      iptablesClient,
 		wgIface:             wgIface,
 		routeingFwChainName: routeingFwChainName,
+		flowLogging:         flowLogging,
 
 		entries:    make(map[string][][]string),
 		ipsetStore: newIpsetStore(),
@@ -70,6 +73,10 @@ func (m *aclManager) AddFiltering(
 	action firewall.Action,
 	ipsetName string,
 ) ([]firewall.Rule, error) {
+	if err := protocol.Validate(); err != nil {
+		return nil, err
+	}
+
 	var dPortVal, sPortVal string
 	if dPort != nil && dPort.Values != nil {
 		// TODO: we support only one port per rule in current implementation of ACLs
@@ -87,7 +94,22 @@ func (m *aclManager) AddFiltering(
 	}
 
 	ipsetName = transformIPsetName(ipsetName, sPortVal, dPortVal)
-	specs := filterRuleSpecs(ip, string(protocol), sPortVal, dPortVal, direction, action, ipsetName)
+
+	// a protocol set is one iptables rule per protocol, managed together as a single Rule
+	var protocolSpecs []ruleSpecs
+	for _, p := range protocol.Protocols() {
+		pSPort, pDPort := sPortVal, dPortVal
+		if p.Base() == firewall.ProtocolICMP {
+			pSPort, pDPort = "", ""
+		}
+		specs := filterRuleSpecs(ip, p, pSPort, pDPort, direction, action, ipsetName)
+		var logSpecs []string
+		if m.flowLogging {
+			specs, logSpecs = tagRuleSpecs(specs, action)
+		}
+		protocolSpecs = append(protocolSpecs, ruleSpecs{specs: specs, logSpecs: logSpecs})
+	}
+
 	if ipsetName != "" {
 		if ipList, ipsetExists := m.ipsetStore.ipset(ipsetName); ipsetExists {
 			if err := ipset.Add(ipsetName, ip.String()); err != nil {
@@ -101,7 +123,9 @@ func (m *aclManager) AddFiltering(
 				ipsetName: ipsetName,
 				ip:        ip.String(),
 				chain:     chain,
-				specs:     specs,
+				specs:     protocolSpecs[0].specs,
+				logSpecs:  protocolSpecs[0].logSpecs,
+				setSpecs:  protocolSpecs[1:],
 			}}, nil
 		}
 
@@ -119,35 +143,58 @@ func (m *aclManager) AddFiltering(
 		m.ipsetStore.addIpList(ipsetName, ipList)
 	}
 
-	ok, err := m.iptablesClient.Exists("filter", chain, specs...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check rule: %w", err)
-	}
-	if ok {
-		return nil, fmt.Errorf("rule already exists")
+	for _, rs := range protocolSpecs {
+		ok, err := m.iptablesClient.Exists("filter", chain, rs.specs...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check rule: %w", err)
+		}
+		if ok {
+			return nil, fmt.Errorf("rule already exists")
+		}
 	}
 
-	if err := m.iptablesClient.Insert("filter", chain, 1, specs...); err != nil {
-		return nil, err
+	for i := range protocolSpecs {
+		rs := &protocolSpecs[i]
+		if err := m.iptablesClient.Insert("filter", chain, 1, rs.specs...); err != nil {
+			// don't leave a part of the protocol set behind
+			for _, inserted := range protocolSpecs[:i] {
+				m.deleteSpecs("filter", chain, inserted)
+			}
+			return nil, err
+		}
+
+		// inserted after the rule, so the packets are logged right before they are dropped
+		if rs.logSpecs != nil {
+			if err := m.iptablesClient.Insert("filter", chain, 1, rs.logSpecs...); err != nil {
+				log.Errorf("failed to add the LOG rule of the drop rule %v: %s", rs.specs, err)
+				rs.logSpecs = nil
+			}
+		}
 	}
 
 	rule := &Rule{
 		ruleID:    uuid.New().String(),
-		specs:     specs,
+		specs:     protocolSpecs[0].specs,
+		logSpecs:  protocolSpecs[0].logSpecs,
+		setSpecs:  protocolSpecs[1:],
 		ipsetName: ipsetName,
 		ip:        ip.String(),
 		chain:     chain,
 	}
 
-	if !shouldAddToPrerouting(protocol, dPort, direction) {
-		return []firewall.Rule{rule}, nil
-	}
+	rules := []firewall.Rule{rule}
+	for _, p := range protocol.Protocols() {
+		if !shouldAddToPrerouting(p, dPort, direction) {
+			continue
+		}
 
-	rulePrerouting, err := m.addPreroutingFilter(ipsetName, string(protocol), dPortVal, ip)
-	if err != nil {
-		return []firewall.Rule{rule}, err
+		rulePrerouting, err := m.addPreroutingFilter(ipsetName, string(p), dPortVal, ip)
+		if err != nil {
+			return rules, err
+		}
+		rules = append(rules, rulePrerouting)
 	}
-	return []firewall.Rule{rule, rulePrerouting}, nil
+	return rules, nil
 }
 
 // DeleteRule from the firewall by rule definition
@@ -192,6 +239,16 @@ DELETERULE:
 	} else {
 		table = "filter"
 	}
+	for _, rs := range r.setSpecs {
+		m.deleteSpecs(table, r.chain, rs)
+	}
+
+	if r.logSpecs != nil {
+		if err := m.iptablesClient.DeleteIfExists(table, r.chain, r.logSpecs...); err != nil {
+			log.Debugf("failed to delete LOG rule, %s, %v: %s", r.chain, r.logSpecs, err)
+		}
+	}
+
 	err := m.iptablesClient.Delete(table, r.chain, r.specs...)
 	if err != nil {
 		log.Debugf("failed to delete rule, %s, %v: %s", r.chain, r.specs, err)
@@ -199,6 +256,18 @@ DELETERULE:
 	return err
 }
 
+// deleteSpecs deletes a rule of a protocol set with its LOG rule
+func (m *aclManager) deleteSpecs(table, chain string, rs ruleSpecs) {
+	if rs.logSpecs != nil {
+		if err := m.iptablesClient.DeleteIfExists(table, chain, rs.logSpecs...); err != nil {
+			log.Debugf("failed to delete LOG rule, %s, %v: %s", chain, rs.logSpecs, err)
+		}
+	}
+	if err := m.iptablesClient.DeleteIfExists(table, chain, rs.specs...); err != nil {
+		log.Debugf("failed to delete rule, %s, %v: %s", chain, rs.specs, err)
+	}
+}
+
 func (m *aclManager) Reset() error {
 	return m.cleanChains()
 }
@@ -366,7 +435,7 @@ func (m *aclManager) seedInitialEntries() {
 	m.appendToEntries("INPUT",
 		[]string{"-i", m.wgIface.Name(), "-s", m.wgIface.Address().String(), "-d", m.wgIface.Address().String(), "-j", chainNameInputRules})
 
-	m.appendToEntries("INPUT", []string{"-i", m.wgIface.Name(), "-j", "DROP"})
+	m.appendDefaultDrop("INPUT", "-i")
 
 	m.appendToEntries("OUTPUT",
 		[]string{"-o", m.wgIface.Name(), "!", "-s", m.wgIface.Address().String(), "-d", m.wgIface.Address().String(), "-j", "ACCEPT"})
@@ -377,9 +446,9 @@ func (m *aclManager) seedInitialEntries() {
 	m.appendToEntries("OUTPUT",
 		[]string{"-o", m.wgIface.Name(), "-s", m.wgIface.Address().String(), "-d", m.wgIface.Address().String(), "-j", chainNameOutputRules})
 
-	m.appendToEntries("OUTPUT", []string{"-o", m.wgIface.Name(), "-j", "DROP"})
+	m.appendDefaultDrop("OUTPUT", "-o")
 
-	m.appendToEntries("FORWARD", []string{"-i", m.wgIface.Name(), "-j", "DROP"})
+	m.appendDefaultDrop("FORWARD", "-i")
 	m.appendToEntries("FORWARD", []string{"-i", m.wgIface.Name(), "-j", chainNameInputRules})
 	m.appendToEntries("FORWARD",
 		[]string{"-o", m.wgIface.Name(), "-m", "mark", "--mark", postRoutingMark, "-j", "ACCEPT"})
@@ -396,9 +465,29 @@ func (m *aclManager) appendToEntries(chainName string, spec []string) {
 	m.entries[chainName] = append(m.entries[chainName], spec)
 }
 
+// appendDefaultDrop appends the rule dropping the traffic of the interface not accepted before, preceded by its
+// LOG rule with the flow logging
+func (m *aclManager) appendDefaultDrop(chainName, ifaceFlag string) {
+	specs := []string{ifaceFlag, m.wgIface.Name(), "-j", "DROP"}
+	if !m.flowLogging {
+		m.appendToEntries(chainName, specs)
+		return
+	}
+
+	specs, logSpecs := tagRuleSpecs(specs, firewall.ActionDrop)
+	if chainName == "FORWARD" {
+		// the FORWARD entries are inserted at the same position, so they end up in the reverse order
+		m.appendToEntries(chainName, specs)
+		m.appendToEntries(chainName, logSpecs)
+		return
+	}
+	m.appendToEntries(chainName, logSpecs)
+	m.appendToEntries(chainName, specs)
+}
+
 // filterRuleSpecs returns the specs of a filtering rule
 func filterRuleSpecs(
-	ip net.IP, protocol string, sPort, dPort string, direction firewall.RuleDirection, action firewall.Action, ipsetName string,
+	ip net.IP, protocol firewall.Protocol, sPort, dPort string, direction firewall.RuleDirection, action firewall.Action, ipsetName string,
 ) (specs []string) {
 	matchByIP := true
 	// don't use IP matching if IP is ip 0.0.0.0
@@ -423,8 +512,11 @@ func filterRuleSpecs(
 			}
 		}
 	}
-	if protocol != "all" {
-		specs = append(specs, "-p", protocol)
+	if protocol != firewall.ProtocolALL {
+		specs = append(specs, "-p", string(protocol.Base()))
+	}
+	if icmpType := protocol.ICMPType(); icmpType != "" {
+		specs = append(specs, "--icmp-type", icmpType)
 	}
 	if sPort != "" {
 		specs = append(specs, "--sport", sPort)
@@ -458,7 +550,8 @@ func transformIPsetName(ipsetName string, sPort, dPort string) string {
 }
 
 func shouldAddToPrerouting(proto firewall.Protocol, dPort *firewall.Port, direction firewall.RuleDirection) bool {
-	if proto == "all" {
+	// the ports only apply to TCP and UDP
+	if !proto.HasPorts() {
 		return false
 	}
 
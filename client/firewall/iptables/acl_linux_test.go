@@ -0,0 +1,45 @@
+package iptables
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	firewall "github.com/netbirdio/netbird/client/firewall/manager"
+)
+
+func TestFilterRuleSpecs(t *testing.T) {
+	ip := net.ParseIP("100.64.0.2")
+
+	specs := filterRuleSpecs(ip, firewall.ProtocolICMPEchoRequest, "", "", firewall.RuleDirectionIN, firewall.ActionAccept, "")
+	require.Equal(t, []string{"-s", "100.64.0.2", "-p", "icmp", "--icmp-type", "echo-request", "-j", "ACCEPT"}, specs)
+
+	specs = filterRuleSpecs(ip, firewall.ProtocolICMP, "", "", firewall.RuleDirectionOUT, firewall.ActionDrop, "")
+	require.Equal(t, []string{"-d", "100.64.0.2", "-p", "icmp", "-j", "DROP"}, specs)
+
+	specs = filterRuleSpecs(net.ParseIP("0.0.0.0"), firewall.ProtocolALL, "", "", firewall.RuleDirectionIN, firewall.ActionAccept, "")
+	require.Equal(t, []string{"-j", "ACCEPT"}, specs)
+
+	proto := firewall.ProtocolSet(firewall.ProtocolTCP, firewall.ProtocolICMPEchoRequest, firewall.ProtocolTCP)
+	require.Equal(t, firewall.Protocol("icmp/echo-request,tcp"), proto)
+	require.NoError(t, proto.Validate())
+
+	var protocolSpecs [][]string
+	for _, p := range proto.Protocols() {
+		dPort := "22"
+		if !p.HasPorts() {
+			dPort = ""
+		}
+		protocolSpecs = append(protocolSpecs, filterRuleSpecs(ip, p, "", dPort, firewall.RuleDirectionIN, firewall.ActionAccept, "nb0000001"))
+	}
+	require.Equal(t, [][]string{
+		{"-m", "set", "--set", "nb0000001", "src", "-p", "icmp", "--icmp-type", "echo-request", "-j", "ACCEPT"},
+		{"-m", "set", "--set", "nb0000001", "src", "-p", "tcp", "--dport", "22", "-j", "ACCEPT"},
+	}, protocolSpecs)
+
+	require.Error(t, firewall.ICMPProtocol("bogus").Validate())
+	require.Error(t, firewall.ProtocolSet(firewall.ProtocolALL, firewall.ProtocolUDP).Validate())
+	require.Error(t, firewall.Protocol("tcp/echo-request").Validate())
+	require.Error(t, firewall.Protocol("").Validate())
+}
@@ -0,0 +1,125 @@
+package iptables
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	firewall "github.com/netbirdio/netbird/client/firewall/manager"
+)
+
+const (
+	// ruleTagPrefix prefixes the comment tagging the rules counted by the flow logging
+	ruleTagPrefix = "netbird-"
+	// logRateLimit limits the packets logged per rule, so a flood doesn't fill the kernel log
+	logRateLimit = "10/minute"
+	logRateBurst = "20"
+	// logPrefix prefixes the kernel log lines of the dropped packets
+	logPrefix = "nb-drop"
+)
+
+var errFlowLoggingDisabled = errors.New("firewall flow logging is disabled")
+
+// countedChains are the filter chains holding the rules tagged by the flow logging
+var countedChains = []string{chainNameInputRules, chainNameOutputRules, "INPUT", "OUTPUT", "FORWARD"}
+
+// tagRuleSpecs tags the rule with a comment derived from its specs, so its counters can be found, and returns the
+// specs of the rate-limited LOG rule to insert before it if the rule drops the packets
+func tagRuleSpecs(specs []string, action firewall.Action) ([]string, []string) {
+	match, target := specs[:len(specs)-2], specs[len(specs)-2:]
+	tag := ruleTag(specs)
+
+	tagged := append(append(append([]string{}, match...), "-m", "comment", "--comment", tag), target...)
+	if action != firewall.ActionDrop {
+		return tagged, nil
+	}
+
+	logSpecs := append(append([]string{}, match...),
+		"-m", "comment", "--comment", tag,
+		"-m", "limit", "--limit", logRateLimit, "--limit-burst", logRateBurst,
+		"-j", "LOG", "--log-prefix", fmt.Sprintf("%s %s: ", logPrefix, tag[len(ruleTagPrefix):]),
+	)
+	return tagged, logSpecs
+}
+
+// ruleTag returns the tag of the rule, stable across the restarts of the client
+func ruleTag(specs []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(specs, " ")))
+	return ruleTagPrefix + hex.EncodeToString(sum[:])[:12]
+}
+
+func (m *aclManager) ruleCounters() ([]firewall.RuleCounter, error) {
+	var counters []firewall.RuleCounter
+	for _, chain := range countedChains {
+		lines, err := m.iptablesClient.ListWithCounters("filter", chain)
+		if err != nil {
+			return nil, fmt.Errorf("list the rules of the chain %s: %w", chain, err)
+		}
+
+		for _, line := range lines {
+			counter, ok := parseRuleCounter(line)
+			if ok {
+				counters = append(counters, counter)
+			}
+		}
+	}
+	return counters, nil
+}
+
+// parseRuleCounter parses a rule tagged by the flow logging listed with its counters, e.g.
+// -A NETBIRD-ACL-INPUT -s 100.64.0.2/32 -p tcp -m tcp --dport 22 -m comment --comment netbird-2c26b46b68ff -c 12 720 -j ACCEPT
+// The LOG rules and the rules not tagged are skipped.
+func parseRuleCounter(line string) (firewall.RuleCounter, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "-A" {
+		return firewall.RuleCounter{}, false
+	}
+
+	counter := firewall.RuleCounter{Chain: fields[1]}
+	var rule []string
+	var target string
+	for i := 2; i < len(fields); i++ {
+		switch {
+		case fields[i] == "--comment" && i+1 < len(fields):
+			counter.ID = strings.Trim(fields[i+1], `"`)
+			rule = append(rule, fields[i], fields[i+1])
+			i++
+		case fields[i] == "-c" && i+2 < len(fields):
+			packets, err := strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return firewall.RuleCounter{}, false
+			}
+			bytes, err := strconv.ParseUint(fields[i+2], 10, 64)
+			if err != nil {
+				return firewall.RuleCounter{}, false
+			}
+			counter.Packets, counter.Bytes = packets, bytes
+			i += 2
+		case fields[i] == "-j" && i+1 < len(fields):
+			target = fields[i+1]
+			rule = append(rule, fields[i], fields[i+1])
+			i++
+		default:
+			rule = append(rule, fields[i])
+		}
+	}
+
+	if !strings.HasPrefix(counter.ID, ruleTagPrefix) {
+		return firewall.RuleCounter{}, false
+	}
+
+	switch target {
+	case "ACCEPT":
+		counter.Action = firewall.ActionAccept
+	case "DROP":
+		counter.Action = firewall.ActionDrop
+	default:
+		return firewall.RuleCounter{}, false
+	}
+
+	counter.Rule = strings.Join(rule, " ")
+	return counter, true
+}
@@ -0,0 +1,91 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	firewall "github.com/netbirdio/netbird/client/firewall/manager"
+)
+
+func TestTagRuleSpecs(t *testing.T) {
+	specs := []string{"-s", "100.64.0.2", "-p", "tcp", "--dport", "22", "-j", "DROP"}
+	tag := ruleTag(specs)
+	require.Len(t, tag, len(ruleTagPrefix)+12)
+	require.Equal(t, tag, ruleTag(append([]string{}, specs...)), "the tag should be stable")
+
+	tagged, logSpecs := tagRuleSpecs(specs, firewall.ActionDrop)
+	require.Equal(t, []string{"-s", "100.64.0.2", "-p", "tcp", "--dport", "22", "-m", "comment", "--comment", tag, "-j", "DROP"}, tagged)
+	require.Equal(t, []string{
+		"-s", "100.64.0.2", "-p", "tcp", "--dport", "22", "-m", "comment", "--comment", tag,
+		"-m", "limit", "--limit", logRateLimit, "--limit-burst", logRateBurst,
+		"-j", "LOG", "--log-prefix", "nb-drop " + tag[len(ruleTagPrefix):] + ": ",
+	}, logSpecs)
+	require.LessOrEqual(t, len(logSpecs[len(logSpecs)-1]), 29, "the LOG prefix is limited to 29 characters")
+	require.Equal(t, "DROP", specs[len(specs)-1], "the specs shouldn't be modified")
+
+	tagged, logSpecs = tagRuleSpecs([]string{"-p", "all", "-j", "ACCEPT"}, firewall.ActionAccept)
+	require.Equal(t, []string{"-p", "all", "-m", "comment", "--comment", ruleTag([]string{"-p", "all", "-j", "ACCEPT"}), "-j", "ACCEPT"}, tagged)
+	require.Nil(t, logSpecs, "the accepted packets shouldn't be logged")
+}
+
+func TestParseRuleCounter(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected *firewall.RuleCounter
+	}{
+		{
+			name: "accept rule",
+			line: "-A NETBIRD-ACL-INPUT -s 100.64.0.2/32 -p tcp -m tcp --dport 22 -m comment --comment netbird-2c26b46b68ff -c 12 720 -j ACCEPT",
+			expected: &firewall.RuleCounter{
+				ID:      "netbird-2c26b46b68ff",
+				Chain:   "NETBIRD-ACL-INPUT",
+				Rule:    "-s 100.64.0.2/32 -p tcp -m tcp --dport 22 -m comment --comment netbird-2c26b46b68ff -j ACCEPT",
+				Action:  firewall.ActionAccept,
+				Packets: 12,
+				Bytes:   720,
+			},
+		},
+		{
+			name: "default drop rule with a quoted comment",
+			line: `-A INPUT -i wt0 -m comment --comment "netbird-9f86d081884c" -c 3 180 -j DROP`,
+			expected: &firewall.RuleCounter{
+				ID:      "netbird-9f86d081884c",
+				Chain:   "INPUT",
+				Rule:    `-i wt0 -m comment --comment "netbird-9f86d081884c" -j DROP`,
+				Action:  firewall.ActionDrop,
+				Packets: 3,
+				Bytes:   180,
+			},
+		},
+		{
+			name: "log rule",
+			line: `-A INPUT -i wt0 -m comment --comment netbird-9f86d081884c -m limit --limit 10/min --limit-burst 20 -c 3 180 -j LOG --log-prefix "nb-drop 9f86d081884c: "`,
+		},
+		{
+			name: "untagged rule",
+			line: "-A INPUT -i wt0 -c 5 300 -j ACCEPT",
+		},
+		{
+			name: "chain policy",
+			line: "-P INPUT ACCEPT -c 100 6000",
+		},
+		{
+			name: "invalid counter",
+			line: "-A INPUT -i wt0 -m comment --comment netbird-9f86d081884c -c x 180 -j DROP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := parseRuleCounter(tt.line)
+			if tt.expected == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, *tt.expected, counter)
+		})
+	}
+}
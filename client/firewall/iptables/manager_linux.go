@@ -22,6 +22,8 @@ type Manager struct {
 	ipv4Client *iptables.IPTables
 	aclMgr     *aclManager
 	router     *routerManager
+
+	flowLogging bool
 }
 
 // iFaceMapper defines subset methods of interface required for manager
@@ -31,16 +33,18 @@ type iFaceMapper interface {
 	IsUserspaceBind() bool
 }
 
-// Create iptables firewall manager
-func Create(context context.Context, wgIface iFaceMapper) (*Manager, error) {
+// Create iptables firewall manager. The flow logging logs the dropped packets with the LOG target, rate-limited,
+// and tags the rules so their counters can be reported.
+func Create(context context.Context, wgIface iFaceMapper, flowLogging bool) (*Manager, error) {
 	iptablesClient, err := iptables.NewWithProtocol(iptables.ProtocolIPv4)
 	if err != nil {
 		return nil, fmt.Errorf("iptables is not installed in the system or not supported")
 	}
 
 	m := &Manager{
-		wgIface:    wgIface,
-		ipv4Client: iptablesClient,
+		wgIface:     wgIface,
+		ipv4Client:  iptablesClient,
+		flowLogging: flowLogging,
 	}
 
 	m.router, err = newRouterManager(context, iptablesClient)
@@ -48,7 +52,7 @@ func Create(context context.Context, wgIface iFaceMapper) (*Manager, error) {
 		log.Debugf("failed to initialize route related chains: %s", err)
 		return nil, err
 	}
-	m.aclMgr, err = newAclManager(iptablesClient, wgIface, m.router.RouteingFwChainName())
+	m.aclMgr, err = newAclManager(iptablesClient, wgIface, m.router.RouteingFwChainName(), flowLogging)
 	if err != nil {
 		log.Debugf("failed to initialize ACL manager: %s", err)
 		return nil, err
@@ -76,6 +80,19 @@ func (m *Manager) AddFiltering(
 	return m.aclMgr.AddFiltering(ip, protocol, sPort, dPort, direction, action, ipsetName)
 }
 
+// RuleCounters returns the counters of the filtering rules and of the rules dropping the traffic of the
+// WireGuard interface by default. It requires the flow logging.
+func (m *Manager) RuleCounters() ([]firewall.RuleCounter, error) {
+	if !m.flowLogging {
+		return nil, errFlowLoggingDisabled
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.aclMgr.ruleCounters()
+}
+
 // DeleteRule from the firewall by rule definition
 func (m *Manager) DeleteRule(rule firewall.Rule) error {
 	m.mutex.Lock()
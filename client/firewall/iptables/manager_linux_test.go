@@ -56,7 +56,7 @@ func TestIptablesManager(t *testing.T) {
 	}
 
 	// just check on the local interface
-	manager, err := Create(context.Background(), mock)
+	manager, err := Create(context.Background(), mock, false)
 	require.NoError(t, err)
 
 	time.Sleep(time.Second)
@@ -115,6 +115,24 @@ func TestIptablesManager(t *testing.T) {
 		require.Empty(t, manager.aclMgr.ipsetStore.ipsets, "rulesets index after removed second rule must be empty")
 	})
 
+	t.Run("add and delete ICMP and SSH rule", func(t *testing.T) {
+		ip := net.ParseIP("10.20.0.4")
+		port := &fw.Port{Values: []int{22}}
+		proto := fw.ProtocolSet(fw.ProtocolICMPEchoRequest, fw.ProtocolTCP)
+		rules, err := manager.AddFiltering(ip, proto, nil, port, fw.RuleDirectionIN, fw.ActionAccept, "", "accept ping and SSH traffic")
+		require.NoError(t, err, "failed to add rule")
+		require.Len(t, rules, 1, "a protocol set should be returned as a single rule")
+
+		rule := rules[0].(*Rule)
+		require.Len(t, rule.setSpecs, 1, "expected the specs of the second protocol")
+		checkRuleSpecs(t, ipv4Client, rule.chain, true, rule.specs...)
+		checkRuleSpecs(t, ipv4Client, rule.chain, true, rule.setSpecs[0].specs...)
+
+		require.NoError(t, manager.DeleteRule(rule), "failed to delete rule")
+		checkRuleSpecs(t, ipv4Client, rule.chain, false, rule.specs...)
+		checkRuleSpecs(t, ipv4Client, rule.chain, false, rule.setSpecs[0].specs...)
+	})
+
 	t.Run("reset check", func(t *testing.T) {
 		// add second rule
 		ip := net.ParseIP("10.20.0.3")
@@ -154,7 +172,7 @@ func TestIptablesManagerIPSet(t *testing.T) {
 	}
 
 	// just check on the local interface
-	manager, err := Create(context.Background(), mock)
+	manager, err := Create(context.Background(), mock, false)
 	require.NoError(t, err)
 
 	time.Sleep(time.Second)
@@ -251,7 +269,7 @@ func TestIptablesCreatePerformance(t *testing.T) {
 	for _, testMax := range []int{10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 200, 300, 400, 500, 600, 700, 800, 900, 1000} {
 		t.Run(fmt.Sprintf("Testing %d rules", testMax), func(t *testing.T) {
 			// just check on the local interface
-			manager, err := Create(context.Background(), mock)
+			manager, err := Create(context.Background(), mock, false)
 			require.NoError(t, err)
 			time.Sleep(time.Second)
 
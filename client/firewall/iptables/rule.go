@@ -6,8 +6,20 @@ type Rule struct {
 	ipsetName string
 
 	specs []string
-	ip    string
-	chain string
+	// logSpecs are the specs of the companion LOG rule of a drop rule, empty without the flow logging
+	logSpecs []string
+	ip       string
+	chain    string
+
+	// setSpecs are the specs of the rules of the other protocols of a protocol set,
+	// added and deleted together with the rule
+	setSpecs []ruleSpecs
+}
+
+// ruleSpecs are the specs of an iptables rule with its companion LOG rule
+type ruleSpecs struct {
+	specs    []string
+	logSpecs []string
 }
 
 // GetRuleID returns the rule id
@@ -41,6 +41,26 @@ const (
 	ActionDrop
 )
 
+// RuleCounter is the number of packets and bytes matched by a rule of the firewall
+type RuleCounter struct {
+	// ID identifies the rule in the firewall, the rules sharing an ipset share their counter
+	ID string
+	// Chain the rule is in
+	Chain string
+	// Rule is the rule in the syntax of the firewall
+	Rule    string
+	Action  Action
+	Packets uint64
+	Bytes   uint64
+}
+
+// FlowAccounting is implemented by the firewall managers counting the packets matched by their rules, so the flows
+// dropped by the policies can be told apart from the accepted ones
+type FlowAccounting interface {
+	// RuleCounters returns the counters of the filtering rules and of the rules dropping the traffic by default
+	RuleCounters() ([]RuleCounter, error)
+}
+
 // Manager is the high level abstraction of a firewall manager
 //
 // It declares methods which handle actions required by the
@@ -1,7 +1,10 @@
 package manager
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 // Protocol is the protocol of the port
@@ -22,8 +25,116 @@ const (
 
 	// ProtocolUnknown unknown protocol
 	ProtocolUnknown Protocol = "unknown"
+
+	// ProtocolICMPEchoRequest is the ICMP protocol restricted to the echo requests (ping)
+	ProtocolICMPEchoRequest Protocol = "icmp/echo-request"
+
+	// ProtocolICMPEchoReply is the ICMP protocol restricted to the echo replies
+	ProtocolICMPEchoReply Protocol = "icmp/echo-reply"
+)
+
+const (
+	protocolSetSeparator = ","
+	icmpTypeSeparator    = "/"
 )
 
+// icmpTypes are the ICMP types a rule can be restricted to, by their iptables names
+var icmpTypes = map[string]uint8{
+	"echo-reply":              0,
+	"destination-unreachable": 3,
+	"echo-request":            8,
+	"time-exceeded":           11,
+}
+
+// ProtocolSet returns the protocol matching any of the given protocols, e.g. ICMP and TCP with a single rule.
+// The ports of a rule only apply to its TCP and UDP protocols.
+func ProtocolSet(protocols ...Protocol) Protocol {
+	unique := make(map[Protocol]struct{}, len(protocols))
+	var members []string
+	for _, protocol := range protocols {
+		for _, member := range protocol.Protocols() {
+			if _, ok := unique[member]; ok {
+				continue
+			}
+			unique[member] = struct{}{}
+			members = append(members, string(member))
+		}
+	}
+	sort.Strings(members)
+	return Protocol(strings.Join(members, protocolSetSeparator))
+}
+
+// ICMPProtocol returns the ICMP protocol restricted to the given ICMP type, e.g. echo-request
+func ICMPProtocol(icmpType string) Protocol {
+	return ProtocolICMP + Protocol(icmpTypeSeparator+icmpType)
+}
+
+// Protocols returns the protocols of a protocol set, or the protocol itself
+func (p Protocol) Protocols() []Protocol {
+	var protocols []Protocol
+	for _, member := range strings.Split(string(p), protocolSetSeparator) {
+		if member = strings.TrimSpace(member); member != "" {
+			protocols = append(protocols, Protocol(member))
+		}
+	}
+	return protocols
+}
+
+// Base returns the protocol without the ICMP type restriction, e.g. icmp for icmp/echo-request
+func (p Protocol) Base() Protocol {
+	base, _, _ := strings.Cut(string(p), icmpTypeSeparator)
+	return Protocol(base)
+}
+
+// ICMPType returns the name of the ICMP type an ICMP protocol is restricted to, empty if not restricted
+func (p Protocol) ICMPType() string {
+	_, icmpType, _ := strings.Cut(string(p), icmpTypeSeparator)
+	return icmpType
+}
+
+// ICMPTypeCode returns the code of the ICMP type an ICMP protocol is restricted to,
+// false if not restricted or the type is unknown
+func (p Protocol) ICMPTypeCode() (uint8, bool) {
+	code, ok := icmpTypes[p.ICMPType()]
+	return code, ok
+}
+
+// HasPorts returns true if the ports of a rule apply to the protocol
+func (p Protocol) HasPorts() bool {
+	return p == ProtocolTCP || p == ProtocolUDP
+}
+
+// Validate returns an error if the protocol or a member of the protocol set isn't supported
+func (p Protocol) Validate() error {
+	protocols := p.Protocols()
+	if len(protocols) == 0 {
+		return fmt.Errorf("empty protocol")
+	}
+
+	for _, protocol := range protocols {
+		switch protocol.Base() {
+		case ProtocolTCP, ProtocolUDP:
+		case ProtocolICMP:
+			if protocol.ICMPType() == "" {
+				continue
+			}
+			if _, ok := protocol.ICMPTypeCode(); !ok {
+				return fmt.Errorf("unsupported ICMP type: %s", protocol.ICMPType())
+			}
+		case ProtocolALL:
+			if len(protocols) > 1 {
+				return fmt.Errorf("protocol %s can't be part of a protocol set", ProtocolALL)
+			}
+		default:
+			return fmt.Errorf("unsupported protocol: %s", protocol)
+		}
+		if protocol.ICMPType() != "" && protocol.Base() != ProtocolICMP {
+			return fmt.Errorf("ICMP type on the non ICMP protocol: %s", protocol)
+		}
+	}
+	return nil
+}
+
 // Port of the address for firewall rule
 type Port struct {
 	// IsRange is true Values contains two values, the first is the start port, the second is the end port
@@ -104,6 +104,10 @@ func (m *AclManager) AddFiltering(
 	ipsetName string,
 	comment string,
 ) ([]firewall.Rule, error) {
+	if err := proto.Validate(); err != nil {
+		return nil, err
+	}
+
 	var ipset *nftables.Set
 	if ipsetName != "" {
 		var err error
@@ -113,22 +117,30 @@ func (m *AclManager) AddFiltering(
 		}
 	}
 
+	// a protocol set is added as a rule per protocol
 	newRules := make([]firewall.Rule, 0, 2)
-	ioRule, err := m.addIOFiltering(ip, proto, sPort, dPort, direction, action, ipset, comment)
-	if err != nil {
-		return nil, err
-	}
+	for _, p := range proto.Protocols() {
+		pSPort, pDPort := sPort, dPort
+		if p.Base() == firewall.ProtocolICMP {
+			pSPort, pDPort = nil, nil
+		}
 
-	newRules = append(newRules, ioRule)
-	if !shouldAddToPrerouting(proto, dPort, direction) {
-		return newRules, nil
-	}
+		ioRule, err := m.addIOFiltering(ip, p, pSPort, pDPort, direction, action, ipset, comment)
+		if err != nil {
+			return newRules, err
+		}
+		newRules = append(newRules, ioRule)
 
-	preroutingRule, err := m.addPreroutingFiltering(ipset, proto, dPort, ip)
-	if err != nil {
-		return newRules, err
+		if !shouldAddToPrerouting(p, pDPort, direction) {
+			continue
+		}
+
+		preroutingRule, err := m.addPreroutingFiltering(ipset, p, pDPort, ip)
+		if err != nil {
+			return newRules, err
+		}
+		newRules = append(newRules, preroutingRule)
 	}
-	newRules = append(newRules, preroutingRule)
 	return newRules, nil
 }
 
@@ -298,7 +310,7 @@ func (m *AclManager) Flush() error {
 }
 
 func (m *AclManager) addIOFiltering(ip net.IP, proto firewall.Protocol, sPort *firewall.Port, dPort *firewall.Port, direction firewall.RuleDirection, action firewall.Action, ipset *nftables.Set, comment string) (*Rule, error) {
-	ruleId := generateRuleId(ip, sPort, dPort, direction, action, ipset)
+	ruleId := generateRuleId(ip, proto, sPort, dPort, direction, action, ipset)
 	if r, ok := m.rules[ruleId]; ok {
 		return &Rule{
 			r.nftRule,
@@ -330,7 +342,7 @@ func (m *AclManager) addIOFiltering(ip net.IP, proto firewall.Protocol, sPort *f
 		})
 
 		var protoData []byte
-		switch proto {
+		switch proto.Base() {
 		case firewall.ProtocolTCP:
 			protoData = []byte{unix.IPPROTO_TCP}
 		case firewall.ProtocolUDP:
@@ -347,6 +359,23 @@ func (m *AclManager) addIOFiltering(ip net.IP, proto firewall.Protocol, sPort *f
 		})
 	}
 
+	if icmpType, ok := proto.ICMPTypeCode(); ok {
+		// the ICMP type is the first byte of the ICMP header
+		expressions = append(expressions,
+			&expr.Payload{
+				DestRegister: 1,
+				Base:         expr.PayloadBaseTransportHeader,
+				Offset:       0,
+				Len:          1,
+			},
+			&expr.Cmp{
+				Op:       expr.CmpOpEq,
+				Register: 1,
+				Data:     []byte{icmpType},
+			},
+		)
+	}
+
 	rawIP := ip.To4()
 	// check if rawIP contains zeroed IPv4 0.0.0.0 value
 	// in that case not add IP match expression into the rule definition
@@ -455,7 +484,7 @@ func (m *AclManager) addIOFiltering(ip net.IP, proto firewall.Protocol, sPort *f
 
 func (m *AclManager) addPreroutingFiltering(ipset *nftables.Set, proto firewall.Protocol, port *firewall.Port, ip net.IP) (*Rule, error) {
 	var protoData []byte
-	switch proto {
+	switch proto.Base() {
 	case firewall.ProtocolTCP:
 		protoData = []byte{unix.IPPROTO_TCP}
 	case firewall.ProtocolUDP:
@@ -1134,13 +1163,14 @@ func (m *AclManager) refreshRuleHandles(chain *nftables.Chain) error {
 
 func generateRuleId(
 	ip net.IP,
+	proto firewall.Protocol,
 	sPort *firewall.Port,
 	dPort *firewall.Port,
 	direction firewall.RuleDirection,
 	action firewall.Action,
 	ipset *nftables.Set,
 ) string {
-	rulesetID := ":" + strconv.Itoa(int(direction)) + ":"
+	rulesetID := ":" + string(proto) + ":" + strconv.Itoa(int(direction)) + ":"
 	if sPort != nil {
 		rulesetID += sPort.String()
 	}
@@ -1177,7 +1207,7 @@ func shouldAddToPrerouting(proto firewall.Protocol, dPort *firewall.Port, direct
 		return false
 	}
 
-	if dPort == nil && proto != firewall.ProtocolICMP {
+	if dPort == nil && proto.Base() != firewall.ProtocolICMP {
 		return false
 	}
 	return true
@@ -21,7 +21,12 @@ type Rule struct {
 	drop       bool
 	comment    string
 
+	// icmpType restricts an ICMP rule to a single ICMP type if matchICMPType is set
+	icmpType      uint8
+	matchICMPType bool
+
 	udpHook func([]byte) bool
+	tcpHook func([]byte) bool
 }
 
 // GetRuleID returns the rule id
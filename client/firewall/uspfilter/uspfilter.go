@@ -132,6 +132,32 @@ func (m *Manager) AddFiltering(
 	ipsetName string,
 	comment string,
 ) ([]firewall.Rule, error) {
+	if err := proto.Validate(); err != nil {
+		return nil, err
+	}
+
+	// a protocol set is added as a rule per protocol
+	var rules []firewall.Rule
+	for _, p := range proto.Protocols() {
+		pSPort, pDPort := sPort, dPort
+		if p.Base() == firewall.ProtocolICMP {
+			pSPort, pDPort = nil, nil
+		}
+		r := m.addFiltering(ip, p, pSPort, pDPort, direction, action, comment)
+		rules = append(rules, &r)
+	}
+	return rules, nil
+}
+
+func (m *Manager) addFiltering(
+	ip net.IP,
+	proto firewall.Protocol,
+	sPort *firewall.Port,
+	dPort *firewall.Port,
+	direction firewall.RuleDirection,
+	action firewall.Action,
+	comment string,
+) Rule {
 	r := Rule{
 		id:        uuid.New().String(),
 		ip:        ip,
@@ -158,7 +184,7 @@ func (m *Manager) AddFiltering(
 		r.dPort = uint16(dPort.Values[0])
 	}
 
-	switch proto {
+	switch proto.Base() {
 	case firewall.ProtocolTCP:
 		r.protoLayer = layers.LayerTypeTCP
 	case firewall.ProtocolUDP:
@@ -168,6 +194,7 @@ func (m *Manager) AddFiltering(
 		if r.ipLayer == layers.LayerTypeIPv6 {
 			r.protoLayer = layers.LayerTypeICMPv6
 		}
+		r.icmpType, r.matchICMPType = proto.ICMPTypeCode()
 	case firewall.ProtocolALL:
 		r.protoLayer = layerTypeAll
 	}
@@ -185,7 +212,7 @@ func (m *Manager) AddFiltering(
 		m.outgoingRules[r.ip.String()][r.id] = r
 	}
 	m.mutex.Unlock()
-	return []firewall.Rule{&r}, nil
+	return r
 }
 
 // DeleteRule from the firewall by rule definition
@@ -312,6 +339,15 @@ func validateRule(ip net.IP, packetData []byte, rules map[string]Rule, d *decode
 
 		switch payloadLayer {
 		case layers.LayerTypeTCP:
+			// unlike the UDP hooks, the TCP hooks only take the packets of their port,
+			// the rest of the TCP traffic to the address is left to the other rules
+			if rule.tcpHook != nil {
+				if rule.dPort != 0 && rule.dPort != uint16(d.tcp.DstPort) {
+					continue
+				}
+				return rule.tcpHook(packetData), true
+			}
+
 			if rule.sPort == 0 && rule.dPort == 0 {
 				return rule.drop, true
 			}
@@ -338,7 +374,16 @@ func validateRule(ip net.IP, packetData []byte, rules map[string]Rule, d *decode
 				return rule.drop, true
 			}
 			return rule.drop, true
-		case layers.LayerTypeICMPv4, layers.LayerTypeICMPv6:
+		case layers.LayerTypeICMPv4:
+			if rule.matchICMPType && d.icmp4.TypeCode.Type() != rule.icmpType {
+				continue
+			}
+			return rule.drop, true
+		case layers.LayerTypeICMPv6:
+			// the ICMP type codes are the ICMPv4 ones, an ICMPv6 packet doesn't match a rule restricted to a type
+			if rule.matchICMPType {
+				continue
+			}
 			return rule.drop, true
 		}
 	}
@@ -361,16 +406,39 @@ func (m *Manager) AddUDPPacketHook(
 		ip:         ip,
 		protoLayer: layers.LayerTypeUDP,
 		dPort:      dPort,
-		ipLayer:    layers.LayerTypeIPv6,
-		direction:  firewall.RuleDirectionOUT,
 		comment:    fmt.Sprintf("UDP Hook direction: %v, ip:%v, dport:%d", in, ip, dPort),
 		udpHook:    hook,
 	}
 
-	if ip.To4() != nil {
+	return m.addPacketHook(in, r)
+}
+
+// AddTCPPacketHook calls hook when TCP packet from given direction matched
+//
+// Hook function returns flag which indicates should be the matched package dropped or not
+func (m *Manager) AddTCPPacketHook(
+	in bool, ip net.IP, dPort uint16, hook func([]byte) bool,
+) string {
+	r := Rule{
+		id:         uuid.New().String(),
+		ip:         ip,
+		protoLayer: layers.LayerTypeTCP,
+		dPort:      dPort,
+		comment:    fmt.Sprintf("TCP Hook direction: %v, ip:%v, dport:%d", in, ip, dPort),
+		tcpHook:    hook,
+	}
+
+	return m.addPacketHook(in, r)
+}
+
+func (m *Manager) addPacketHook(in bool, r Rule) string {
+	r.ipLayer = layers.LayerTypeIPv6
+	if r.ip.To4() != nil {
 		r.ipLayer = layers.LayerTypeIPv4
 	}
 
+	r.direction = firewall.RuleDirectionOUT
+
 	m.mutex.Lock()
 	if in {
 		r.direction = firewall.RuleDirectionIN
@@ -234,6 +234,123 @@ func TestAddUDPPacketHook(t *testing.T) {
 	}
 }
 
+func TestAddTCPPacketHook(t *testing.T) {
+	manager := &Manager{
+		incomingRules: map[string]RuleSet{},
+		outgoingRules: map[string]RuleSet{},
+		wgNetwork: &net.IPNet{
+			IP:   net.ParseIP("100.10.0.0"),
+			Mask: net.CIDRMask(16, 32),
+		},
+	}
+	manager.decoders.New = func() any {
+		d := &decoder{decoded: []gopacket.LayerType{}}
+		d.parser = gopacket.NewDecodingLayerParser(
+			layers.LayerTypeIPv4,
+			&d.eth, &d.ip4, &d.ip6, &d.icmp4, &d.icmp6, &d.tcp, &d.udp,
+		)
+		d.parser.IgnoreUnsupported = true
+		return d
+	}
+
+	var hooked int
+	manager.AddTCPPacketHook(false, net.ParseIP("100.10.0.100"), 53, func([]byte) bool {
+		hooked++
+		return true
+	})
+
+	for _, dPort := range []layers.TCPPort{53, 80} {
+		ipv4 := &layers.IPv4{
+			TTL:      64,
+			Version:  4,
+			SrcIP:    net.ParseIP("100.10.0.1"),
+			DstIP:    net.ParseIP("100.10.0.100"),
+			Protocol: layers.IPProtocolTCP,
+		}
+		tcp := &layers.TCP{
+			SrcPort: 51334,
+			DstPort: dPort,
+			SYN:     true,
+		}
+		require.NoError(t, tcp.SetNetworkLayerForChecksum(ipv4))
+
+		buf := gopacket.NewSerializeBuffer()
+		opts := gopacket.SerializeOptions{
+			ComputeChecksums: true,
+			FixLengths:       true,
+		}
+		require.NoError(t, gopacket.SerializeLayers(buf, opts, ipv4, tcp))
+
+		manager.dropFilter(buf.Bytes(), manager.outgoingRules, false)
+	}
+
+	if hooked != 1 {
+		t.Errorf("expected the hook to be called only for its port, called %d times", hooked)
+	}
+}
+
+func TestManagerAddFilteringICMPAndProtocolSet(t *testing.T) {
+	ifaceMock := &IFaceMock{
+		SetFilterFunc: func(iface.PacketFilter) error { return nil },
+	}
+
+	m, err := Create(ifaceMock)
+	require.NoError(t, err)
+	m.wgNetwork = &net.IPNet{
+		IP:   net.ParseIP("100.10.0.0"),
+		Mask: net.CIDRMask(16, 32),
+	}
+
+	_, err = m.AddFiltering(net.ParseIP("100.10.0.1"), fw.ICMPProtocol("bogus"), nil, nil, fw.RuleDirectionIN, fw.ActionAccept, "", "")
+	require.Error(t, err, "unknown ICMP types should be rejected")
+
+	_, err = m.AddFiltering(net.ParseIP("100.10.0.1"), fw.ProtocolSet(fw.ProtocolALL, fw.ProtocolTCP), nil, nil, fw.RuleDirectionIN, fw.ActionAccept, "", "")
+	require.Error(t, err, "all can't be part of a protocol set")
+
+	// allow ping and SSH from the peer with a single call
+	proto := fw.ProtocolSet(fw.ProtocolICMPEchoRequest, fw.ProtocolTCP)
+	rules, err := m.AddFiltering(net.ParseIP("100.10.0.1"), proto, nil, &fw.Port{Values: []int{22}}, fw.RuleDirectionIN, fw.ActionAccept, "", "")
+	require.NoError(t, err)
+	require.Len(t, rules, 2, "expected a rule per protocol of the set")
+
+	serialize := func(layer gopacket.SerializableLayer, protocol layers.IPProtocol) []byte {
+		ipv4 := &layers.IPv4{
+			TTL:      64,
+			Version:  4,
+			SrcIP:    net.ParseIP("100.10.0.1"),
+			DstIP:    net.ParseIP("100.10.0.100"),
+			Protocol: protocol,
+		}
+		if tcp, ok := layer.(*layers.TCP); ok {
+			require.NoError(t, tcp.SetNetworkLayerForChecksum(ipv4))
+		}
+
+		buf := gopacket.NewSerializeBuffer()
+		opts := gopacket.SerializeOptions{
+			ComputeChecksums: true,
+			FixLengths:       true,
+		}
+		require.NoError(t, gopacket.SerializeLayers(buf, opts, ipv4, layer))
+		return buf.Bytes()
+	}
+
+	echoRequest := serialize(&layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeEchoRequest, 0)}, layers.IPProtocolICMPv4)
+	echoReply := serialize(&layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeEchoReply, 0)}, layers.IPProtocolICMPv4)
+	ssh := serialize(&layers.TCP{SrcPort: 51334, DstPort: 22, SYN: true}, layers.IPProtocolTCP)
+	web := serialize(&layers.TCP{SrcPort: 51334, DstPort: 80, SYN: true}, layers.IPProtocolTCP)
+
+	require.False(t, m.dropFilter(echoRequest, m.incomingRules, true), "echo request should be accepted")
+	require.True(t, m.dropFilter(echoReply, m.incomingRules, true), "echo reply should be dropped")
+	require.False(t, m.dropFilter(ssh, m.incomingRules, true), "SSH should be accepted")
+	require.True(t, m.dropFilter(web, m.incomingRules, true), "HTTP should be dropped")
+
+	for _, r := range rules {
+		require.NoError(t, m.DeleteRule(r))
+	}
+	require.True(t, m.dropFilter(echoRequest, m.incomingRules, true), "echo request should be dropped once the rules are deleted")
+	require.True(t, m.dropFilter(ssh, m.incomingRules, true), "SSH should be dropped once the rules are deleted")
+}
+
 func TestManagerReset(t *testing.T) {
 	ifaceMock := &IFaceMock{
 		SetFilterFunc: func(iface.PacketFilter) error { return nil },
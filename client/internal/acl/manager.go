@@ -19,24 +19,30 @@ import (
 // Manager is a ACL rules manager
 type Manager interface {
 	ApplyFiltering(networkMap *mgmProto.NetworkMap)
+	Stop()
 }
 
 // DefaultManager uses firewall manager to handle
 type DefaultManager struct {
 	firewall     firewall.Manager
 	ipsetCounter int
-	rulesPairs   map[string][]firewall.Rule
-	mutex        sync.Mutex
+	// ipsetBySelector keeps the ipset names of the rule selectors between the updates,
+	// so the rules added to an existing selector join the ipset of the installed ones
+	ipsetBySelector map[string]string
+	rulesPairs      map[string][]firewall.Rule
+	mutex           sync.Mutex
 }
 
 func NewDefaultManager(fm firewall.Manager) *DefaultManager {
 	return &DefaultManager{
-		firewall:   fm,
-		rulesPairs: make(map[string][]firewall.Rule),
+		firewall:        fm,
+		ipsetBySelector: make(map[string]string),
+		rulesPairs:      make(map[string][]firewall.Rule),
 	}
 }
 
 // ApplyFiltering firewall rules to the local firewall manager processed by ACL policy.
+// Only the rules changed since the previous network map are added to or deleted from the firewall.
 //
 // If allowByDefault is true it appends allow ALL traffic rules to input and output chains.
 func (d *DefaultManager) ApplyFiltering(networkMap *mgmProto.NetworkMap) {
@@ -110,48 +116,81 @@ func (d *DefaultManager) ApplyFiltering(networkMap *mgmProto.NetworkMap) {
 		)
 	}
 
+	// the rules installed by the previous updates are kept, only the added and the removed rules change
 	newRulePairs := make(map[string][]firewall.Rule)
-	ipsetByRuleSelectors := make(map[string]string)
+	addedRulePairs := make(map[string][]firewall.Rule)
+	selectors := make(map[string]struct{})
 
 	for _, r := range rules {
 		// if this rule is member of rule selection with more than DefaultIPsCountForSet
 		// it's IP address can be used in the ipset for firewall manager which supports it
 		selector := d.getRuleGroupingSelector(r)
-		ipsetName, ok := ipsetByRuleSelectors[selector]
+		ipsetName, ok := d.ipsetBySelector[selector]
 		if !ok {
 			d.ipsetCounter++
 			ipsetName = fmt.Sprintf("nb%07d", d.ipsetCounter)
-			ipsetByRuleSelectors[selector] = ipsetName
+			d.ipsetBySelector[selector] = ipsetName
 		}
-		pairID, rulePair, err := d.protoRuleToFirewallRule(r, ipsetName)
+		selectors[selector] = struct{}{}
+
+		pairID, rulePair, err := d.protoRuleToFirewallRule(r, ipsetName, newRulePairs)
 		if err != nil {
 			log.Errorf("failed to apply firewall rule: %+v, %v", r, err)
-			d.rollBack(newRulePairs)
-			break
+			d.rollBack(addedRulePairs)
+			return
 		}
-		if len(rules) > 0 {
-			d.rulesPairs[pairID] = rulePair
-			newRulePairs[pairID] = rulePair
+		_, installed := d.rulesPairs[pairID]
+		_, seen := newRulePairs[pairID]
+		if !installed && !seen {
+			addedRulePairs[pairID] = rulePair
 		}
+		newRulePairs[pairID] = rulePair
 	}
 
+	removed := 0
 	for pairID, rules := range d.rulesPairs {
 		if _, ok := newRulePairs[pairID]; !ok {
-			for _, rule := range rules {
-				if err := d.firewall.DeleteRule(rule); err != nil {
-					log.Errorf("failed to delete firewall rule: %v", err)
-					continue
-				}
-			}
-			delete(d.rulesPairs, pairID)
+			d.deleteRules(rules)
+			removed++
 		}
 	}
 	d.rulesPairs = newRulePairs
+
+	for selector := range d.ipsetBySelector {
+		if _, ok := selectors[selector]; !ok {
+			delete(d.ipsetBySelector, selector)
+		}
+	}
+
+	log.Debugf("ACL rules updated, added: %d, removed: %d", len(addedRulePairs), removed)
 }
 
+// Stop deletes the firewall rules installed by the ACL manager
+func (d *DefaultManager) Stop() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.firewall == nil {
+		return
+	}
+
+	for _, rules := range d.rulesPairs {
+		d.deleteRules(rules)
+	}
+	d.rulesPairs = make(map[string][]firewall.Rule)
+	d.ipsetBySelector = make(map[string]string)
+
+	if err := d.firewall.Flush(); err != nil {
+		log.Error("failed to flush firewall rules: ", err)
+	}
+}
+
+// protoRuleToFirewallRule adds the firewall rules of the ACL rule unless they are already installed or were added
+// for an identical rule of the same update
 func (d *DefaultManager) protoRuleToFirewallRule(
 	r *mgmProto.FirewallRule,
 	ipsetName string,
+	newRulePairs map[string][]firewall.Rule,
 ) (string, []firewall.Rule, error) {
 	ip := net.ParseIP(r.PeerIP)
 	if ip == nil {
@@ -183,6 +222,9 @@ func (d *DefaultManager) protoRuleToFirewallRule(
 	if rulesPair, ok := d.rulesPairs[ruleID]; ok {
 		return ruleID, rulesPair, nil
 	}
+	if rulesPair, ok := newRulePairs[ruleID]; ok {
+		return ruleID, rulesPair, nil
+	}
 
 	var rules []firewall.Rule
 	switch r.Direction {
@@ -412,13 +454,18 @@ func (d *DefaultManager) getRuleGroupingSelector(rule *mgmProto.FirewallRule) st
 	return fmt.Sprintf("%v:%v:%v:%s", strconv.Itoa(int(rule.Direction)), rule.Action, rule.Protocol, rule.Port)
 }
 
-func (d *DefaultManager) rollBack(newRulePairs map[string][]firewall.Rule) {
+// rollBack deletes the rules added by a failed update, the rules installed before stay in place
+func (d *DefaultManager) rollBack(addedRulePairs map[string][]firewall.Rule) {
 	log.Debugf("rollback ACL to previous state")
-	for _, rules := range newRulePairs {
-		for _, rule := range rules {
-			if err := d.firewall.DeleteRule(rule); err != nil {
-				log.Errorf("failed to delete new firewall rule (id: %v) during rollback: %v", rule.GetRuleID(), err)
-			}
+	for _, rules := range addedRulePairs {
+		d.deleteRules(rules)
+	}
+}
+
+func (d *DefaultManager) deleteRules(rules []firewall.Rule) {
+	for _, rule := range rules {
+		if err := d.firewall.DeleteRule(rule); err != nil {
+			log.Errorf("failed to delete firewall rule (id: %v): %v", rule.GetRuleID(), err)
 		}
 	}
 }
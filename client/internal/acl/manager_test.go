@@ -3,9 +3,12 @@ package acl
 import (
 	"context"
 	"net"
+	"strconv"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/netbirdio/netbird/client/firewall"
 	"github.com/netbirdio/netbird/client/firewall/manager"
@@ -52,7 +55,7 @@ func TestDefaultManager(t *testing.T) {
 	}).AnyTimes()
 
 	// we receive one rule from the management so for testing purposes ignore it
-	fw, err := firewall.NewFirewall(context.Background(), ifaceMock)
+	fw, err := firewall.NewFirewall(context.Background(), ifaceMock, false)
 	if err != nil {
 		t.Errorf("create firewall: %v", err)
 		return
@@ -345,7 +348,7 @@ func TestDefaultManagerEnableSSHRules(t *testing.T) {
 	}).AnyTimes()
 
 	// we receive one rule from the management so for testing purposes ignore it
-	fw, err := firewall.NewFirewall(context.Background(), ifaceMock)
+	fw, err := firewall.NewFirewall(context.Background(), ifaceMock, false)
 	if err != nil {
 		t.Errorf("create firewall: %v", err)
 		return
@@ -362,3 +365,156 @@ func TestDefaultManagerEnableSSHRules(t *testing.T) {
 		return
 	}
 }
+
+// fakeFirewall records the rules installed by the ACL manager
+type fakeFirewall struct {
+	manager.Manager
+	nextID    int
+	installed map[string]*fakeRule
+	added     int
+	deleted   int
+}
+
+type fakeRule struct {
+	id        string
+	ip        string
+	ipsetName string
+}
+
+func (r *fakeRule) GetRuleID() string {
+	return r.id
+}
+
+func newFakeFirewall() *fakeFirewall {
+	return &fakeFirewall{installed: make(map[string]*fakeRule)}
+}
+
+func (f *fakeFirewall) AddFiltering(ip net.IP, _ manager.Protocol, _ *manager.Port, _ *manager.Port,
+	_ manager.RuleDirection, _ manager.Action, ipsetName string, _ string) ([]manager.Rule, error) {
+	f.nextID++
+	rule := &fakeRule{id: strconv.Itoa(f.nextID), ip: ip.String(), ipsetName: ipsetName}
+	f.installed[rule.id] = rule
+	f.added++
+	return []manager.Rule{rule}, nil
+}
+
+func (f *fakeFirewall) DeleteRule(rule manager.Rule) error {
+	delete(f.installed, rule.GetRuleID())
+	f.deleted++
+	return nil
+}
+
+func (f *fakeFirewall) Flush() error {
+	return nil
+}
+
+func (f *fakeFirewall) resetCounters() {
+	f.added = 0
+	f.deleted = 0
+}
+
+func (f *fakeFirewall) ipsetOf(ip string) string {
+	for _, rule := range f.installed {
+		if rule.ip == ip {
+			return rule.ipsetName
+		}
+	}
+	return ""
+}
+
+func TestDefaultManagerMinimalRuleChanges(t *testing.T) {
+	ruleA := &mgmProto.FirewallRule{
+		PeerIP:    "10.93.0.1",
+		Direction: mgmProto.FirewallRule_IN,
+		Action:    mgmProto.FirewallRule_ACCEPT,
+		Protocol:  mgmProto.FirewallRule_TCP,
+		Port:      "80",
+	}
+	ruleB := &mgmProto.FirewallRule{
+		PeerIP:    "10.93.0.2",
+		Direction: mgmProto.FirewallRule_IN,
+		Action:    mgmProto.FirewallRule_ACCEPT,
+		Protocol:  mgmProto.FirewallRule_UDP,
+		Port:      "53",
+	}
+	ruleC := &mgmProto.FirewallRule{
+		PeerIP:    "10.93.0.3",
+		Direction: mgmProto.FirewallRule_IN,
+		Action:    mgmProto.FirewallRule_DROP,
+		Protocol:  mgmProto.FirewallRule_ICMP,
+	}
+	// same selector as ruleA
+	ruleD := &mgmProto.FirewallRule{
+		PeerIP:    "10.93.0.4",
+		Direction: mgmProto.FirewallRule_IN,
+		Action:    mgmProto.FirewallRule_ACCEPT,
+		Protocol:  mgmProto.FirewallRule_TCP,
+		Port:      "80",
+	}
+
+	fw := newFakeFirewall()
+	acl := NewDefaultManager(fw)
+
+	t.Run("initial network map", func(t *testing.T) {
+		acl.ApplyFiltering(&mgmProto.NetworkMap{FirewallRules: []*mgmProto.FirewallRule{ruleA, ruleB}})
+		assert.Equal(t, 4, fw.added, "port rules should be added with their inverted rules")
+		assert.Equal(t, 0, fw.deleted)
+		assert.Len(t, fw.installed, 4)
+	})
+
+	t.Run("same network map", func(t *testing.T) {
+		fw.resetCounters()
+		acl.ApplyFiltering(&mgmProto.NetworkMap{FirewallRules: []*mgmProto.FirewallRule{ruleB, ruleA}})
+		assert.Equal(t, 0, fw.added, "unchanged rules should not be added again")
+		assert.Equal(t, 0, fw.deleted, "unchanged rules should not be deleted")
+	})
+
+	t.Run("replace a rule", func(t *testing.T) {
+		fw.resetCounters()
+		acl.ApplyFiltering(&mgmProto.NetworkMap{FirewallRules: []*mgmProto.FirewallRule{ruleA, ruleC, ruleC}})
+		assert.Equal(t, 1, fw.added, "only the new rule should be added once")
+		assert.Equal(t, 2, fw.deleted, "only the removed rule should be deleted")
+		assert.Len(t, fw.installed, 3)
+	})
+
+	t.Run("add a rule to an installed selector", func(t *testing.T) {
+		fw.resetCounters()
+		ipsetName := fw.ipsetOf(ruleA.PeerIP)
+		acl.ApplyFiltering(&mgmProto.NetworkMap{FirewallRules: []*mgmProto.FirewallRule{ruleA, ruleC, ruleD}})
+		assert.Equal(t, 2, fw.added)
+		assert.Equal(t, 0, fw.deleted)
+		assert.Equal(t, ipsetName, fw.ipsetOf(ruleD.PeerIP), "rule should join the ipset of the installed rules")
+	})
+
+	t.Run("roll back a failed update", func(t *testing.T) {
+		fw.resetCounters()
+		invalid := &mgmProto.FirewallRule{
+			PeerIP:    "invalid",
+			Direction: mgmProto.FirewallRule_IN,
+			Action:    mgmProto.FirewallRule_ACCEPT,
+			Protocol:  mgmProto.FirewallRule_ALL,
+		}
+		acl.ApplyFiltering(&mgmProto.NetworkMap{FirewallRules: []*mgmProto.FirewallRule{ruleA, ruleB, invalid}})
+		assert.Equal(t, 2, fw.added)
+		assert.Equal(t, 2, fw.deleted, "only the rules added by the failed update should be deleted")
+		assert.Len(t, fw.installed, 5, "rules installed before the failed update should stay")
+		assert.Len(t, acl.rulesPairs, 3)
+	})
+
+	t.Run("deny by default", func(t *testing.T) {
+		fw.resetCounters()
+		acl.ApplyFiltering(&mgmProto.NetworkMap{FirewallRulesIsEmpty: true})
+		assert.Equal(t, 0, fw.added)
+		assert.Empty(t, fw.installed, "all rules should be deleted when the management sends no rules")
+		assert.Empty(t, acl.rulesPairs)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		acl.ApplyFiltering(&mgmProto.NetworkMap{FirewallRules: []*mgmProto.FirewallRule{ruleA, ruleC}})
+		require.NotEmpty(t, fw.installed)
+
+		acl.Stop()
+		assert.Empty(t, fw.installed, "rules should be deleted when stopping")
+		assert.Empty(t, acl.rulesPairs)
+	})
+}
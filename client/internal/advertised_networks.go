@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"fmt"
+	"net/netip"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+// ParseAdvertisedNetworks validates the networks of the local network the peer offers to route and returns them
+// masked and without duplicates, e.g. 192.168.1.10/24 as 192.168.1.0/24. The networks can't overlap the overlay
+// network the peer addresses are allocated from, it is checked only if the overlay network is valid.
+func ParseAdvertisedNetworks(networks []string, overlay netip.Prefix) ([]string, error) {
+	parsed := make([]string, 0, len(networks))
+	for _, network := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid advertised network %q, expecting a CIDR, e.g. 192.168.1.0/24", network)
+		}
+		prefix = prefix.Masked()
+
+		if overlay.IsValid() && prefix.Bits() > 0 && prefix.Overlaps(overlay) {
+			return nil, fmt.Errorf("advertised network %s overlaps with the overlay network %s", prefix, overlay)
+		}
+
+		if !slices.Contains(parsed, prefix.String()) {
+			parsed = append(parsed, prefix.String())
+		}
+	}
+	return parsed, nil
+}
+
+// advertisedNetworksOutsideOverlay returns the advertised networks without the ones overlapping with the overlay
+// network of the peer address, e.g. 100.64.0.5/16. The overlay network is known only after the login.
+func advertisedNetworksOutsideOverlay(networks []string, address string) []string {
+	overlay, err := netip.ParsePrefix(address)
+	if err != nil {
+		return networks
+	}
+
+	var valid []string
+	for _, network := range networks {
+		if _, err := ParseAdvertisedNetworks([]string{network}, overlay.Masked()); err != nil {
+			log.Warnf("not advertising the network: %v", err)
+			continue
+		}
+		valid = append(valid, network)
+	}
+	return valid
+}
+
+// validateAdvertisedNetworks checks the advertised networks of the config are valid CIDRs
+func validateAdvertisedNetworks(config *Config) error {
+	_, err := ParseAdvertisedNetworks(config.AdvertisedNetworks, netip.Prefix{})
+	return err
+}
+
+// OverlayNetwork returns the overlay network the peer addresses are allocated from, e.g. 100.64.0.0/16
+func (e *Engine) OverlayNetwork() netip.Prefix {
+	prefix, err := netip.ParsePrefix(e.config.WgAddr)
+	if err != nil {
+		return netip.Prefix{}
+	}
+	return prefix.Masked()
+}
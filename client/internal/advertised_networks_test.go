@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"net/netip"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAdvertisedNetworks(t *testing.T) {
+	overlay := netip.MustParsePrefix("100.64.0.0/16")
+
+	testCases := []struct {
+		name     string
+		networks []string
+		overlay  netip.Prefix
+		expected []string
+		fail     bool
+	}{
+		{
+			name:     "Masked And Deduplicated",
+			networks: []string{"192.168.1.10/24", "192.168.1.0/24", "fd00:1::/64"},
+			overlay:  overlay,
+			expected: []string{"192.168.1.0/24", "fd00:1::/64"},
+		},
+		{
+			name:     "Empty",
+			networks: nil,
+			overlay:  overlay,
+			expected: []string{},
+		},
+		{
+			name:     "Invalid CIDR",
+			networks: []string{"192.168.1.0"},
+			overlay:  overlay,
+			fail:     true,
+		},
+		{
+			name:     "Overlapping Overlay",
+			networks: []string{"100.64.0.0/10"},
+			overlay:  overlay,
+			fail:     true,
+		},
+		{
+			name:     "Unknown Overlay",
+			networks: []string{"100.64.0.0/10"},
+			expected: []string{"100.64.0.0/10"},
+		},
+		{
+			name:     "Default Route",
+			networks: []string{"0.0.0.0/0"},
+			overlay:  overlay,
+			expected: []string{"0.0.0.0/0"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			networks, err := ParseAdvertisedNetworks(testCase.networks, testCase.overlay)
+			if testCase.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, networks)
+		})
+	}
+}
+
+func TestAdvertisedNetworksOutsideOverlay(t *testing.T) {
+	networks := []string{"192.168.1.0/24", "100.64.5.0/24"}
+
+	assert.Equal(t, []string{"192.168.1.0/24"}, advertisedNetworksOutsideOverlay(networks, "100.64.0.5/16"))
+	assert.Equal(t, networks, advertisedNetworksOutsideOverlay(networks, ""), "unknown overlay should keep the networks")
+}
+
+func TestUpdateConfigAdvertisedNetworks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	_, err := UpdateOrCreateConfig(ConfigInput{ConfigPath: path})
+	require.NoError(t, err)
+
+	config, err := UpdateConfig(ConfigInput{ConfigPath: path, AdvertisedNetworks: []string{"192.168.1.10/24"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.0/24"}, config.AdvertisedNetworks)
+
+	config, err = ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.0/24"}, config.AdvertisedNetworks, "networks should be persisted")
+
+	config, err = UpdateConfig(ConfigInput{ConfigPath: path})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.0/24"}, config.AdvertisedNetworks, "networks should be kept when not set")
+
+	_, err = UpdateConfig(ConfigInput{ConfigPath: path, AdvertisedNetworks: []string{"invalid"}})
+	assert.Error(t, err)
+
+	config, err = UpdateConfig(ConfigInput{ConfigPath: path, AdvertisedNetworks: []string{}})
+	require.NoError(t, err)
+	assert.Empty(t, config.AdvertisedNetworks)
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/util"
 )
 
 // HostedGrantType grant type for device flow on Hosted
@@ -50,10 +51,11 @@ type TokenRequestResponse struct {
 	TokenInfo
 }
 
-// NewDeviceAuthorizationFlow returns device authorization flow client
-func NewDeviceAuthorizationFlow(config internal.DeviceAuthProviderConfig) (*DeviceAuthorizationFlow, error) {
+// NewDeviceAuthorizationFlow returns device authorization flow client sending the IdP requests through the proxy of the proxyDialer
+func NewDeviceAuthorizationFlow(config internal.DeviceAuthProviderConfig, proxyDialer *util.ProxyDialer) (*DeviceAuthorizationFlow, error) {
 	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
 	httpTransport.MaxIdleConns = 5
+	httpTransport.Proxy = proxyDialer.Proxy
 
 	httpClient := &http.Client{
 		Timeout:   10 * time.Second,
@@ -19,6 +19,7 @@ import (
 
 	"github.com/netbirdio/netbird/client/internal"
 	"github.com/netbirdio/netbird/client/internal/templates"
+	"github.com/netbirdio/netbird/util"
 )
 
 var _ OAuthFlow = &PKCEAuthorizationFlow{}
@@ -38,10 +39,12 @@ type PKCEAuthorizationFlow struct {
 	state          string
 	codeVerifier   string
 	oAuthConfig    *oauth2.Config
+	// httpClient sends the token request to the IdP
+	httpClient *http.Client
 }
 
-// NewPKCEAuthorizationFlow returns new PKCE authorization code flow.
-func NewPKCEAuthorizationFlow(config internal.PKCEAuthProviderConfig) (*PKCEAuthorizationFlow, error) {
+// NewPKCEAuthorizationFlow returns new PKCE authorization code flow sending the IdP requests through the proxy of the proxyDialer.
+func NewPKCEAuthorizationFlow(config internal.PKCEAuthProviderConfig, proxyDialer *util.ProxyDialer) (*PKCEAuthorizationFlow, error) {
 	var availableRedirectURL string
 
 	// find the first available redirect URL
@@ -67,9 +70,13 @@ func NewPKCEAuthorizationFlow(config internal.PKCEAuthProviderConfig) (*PKCEAuth
 		Scopes:      strings.Split(config.Scope, " "),
 	}
 
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	httpTransport.Proxy = proxyDialer.Proxy
+
 	return &PKCEAuthorizationFlow{
 		providerConfig: config,
 		oAuthConfig:    cfg,
+		httpClient:     &http.Client{Transport: httpTransport},
 	}, nil
 }
 
@@ -179,7 +186,7 @@ func (p *PKCEAuthorizationFlow) handleRequest(req *http.Request) (*oauth2.Token,
 	}
 
 	return p.oAuthConfig.Exchange(
-		req.Context(),
+		context.WithValue(req.Context(), oauth2.HTTPClient, p.httpClient),
 		code,
 		oauth2.SetAuthURLParam("code_verifier", p.codeVerifier),
 	)
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const pemBeginPrefix = "-----BEGIN"
+
+// TLSConfig returns the TLS config for the Management and Signal Service connections,
+// trusting the configured CA certificates in addition to the system ones.
+// It returns nil when no CA certificates are configured, so the system CA certificates are used.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if c.CACertificates == "" {
+		return nil, nil
+	}
+
+	certs, err := parseCACertificates(c.CACertificates)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		log.Warnf("failed loading the system CA certificates, trusting only the configured ones: %v", err)
+		pool = x509.NewCertPool()
+	}
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// parseCACertificates parses the CA certificates given either inline in a PEM format or as a path to a PEM file.
+// Unlike x509.CertPool.AppendCertsFromPEM it fails on any malformed block, so a broken configuration is reported.
+func parseCACertificates(caCertificates string) ([]*x509.Certificate, error) {
+	data := []byte(caCertificates)
+	source := "inline CA certificates"
+	if !strings.HasPrefix(strings.TrimSpace(caCertificates), pemBeginPrefix) {
+		source = fmt.Sprintf("CA certificates file %s", caCertificates)
+		var err error
+		data, err = os.ReadFile(caCertificates)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading %s: %w", source, err)
+		}
+	}
+
+	var certs []*x509.Certificate
+	rest := bytes.TrimSpace(data)
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("invalid %s: certificate %d is not PEM encoded", source, len(certs)+1)
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("invalid %s: unexpected PEM block %s, expected CERTIFICATE", source, block.Type)
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: failed parsing certificate %d: %w", source, len(certs)+1, err)
+		}
+		certs = append(certs, cert)
+		rest = bytes.TrimSpace(rest)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("invalid %s: no certificates found", source)
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/netbirdio/netbird/client/internal/configcrypt"
+)
+
+// redactedSecret replaces the secrets in the redacted config
+const redactedSecret = "[REDACTED]"
+
+// secretFields returns the config fields encrypted at rest
+func secretFields(config *Config) []*string {
+	return []*string{&config.PrivateKey, &config.PreSharedKey, &config.SSHKey}
+}
+
+// decryptSecrets decrypts the secrets of the config read from the path. The plaintext secrets are kept as is.
+func decryptSecrets(path string, config *Config) error {
+	if err := configcrypt.ValidateMode(config.SecretsEncryption); err != nil {
+		return err
+	}
+
+	if !hasEncryptedSecrets(config) {
+		return nil
+	}
+
+	cipher, err := configcrypt.NewForMode(decryptionMode(config), path)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range secretFields(config) {
+		value, err := cipher.Decrypt(*field)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+
+	return nil
+}
+
+// encryptSecrets returns a copy of the config with the secrets encrypted if the encryption is enabled
+func encryptSecrets(path string, config *Config) (*Config, error) {
+	if config.SecretsEncryption == "" {
+		return config, nil
+	}
+
+	cipher, err := configcrypt.NewForMode(config.SecretsEncryption, path)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := *config
+	for _, field := range secretFields(&encrypted) {
+		value, err := cipher.Encrypt(*field)
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+
+	return &encrypted, nil
+}
+
+// decryptionMode returns the mode the secrets were encrypted with. When the encryption has been disabled, the key
+// of the environment is used if it is set and the keyring otherwise.
+func decryptionMode(config *Config) string {
+	if config.SecretsEncryption != "" {
+		return config.SecretsEncryption
+	}
+	if os.Getenv(configcrypt.EnvKey) != "" || os.Getenv(configcrypt.EnvKeyFile) != "" {
+		return configcrypt.ModeKey
+	}
+	return configcrypt.ModeKeyring
+}
+
+func hasEncryptedSecrets(config *Config) bool {
+	for _, field := range secretFields(config) {
+		if configcrypt.IsEncrypted(*field) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretsNeedMigration returns true if the secrets of the config read from the disk don't match the encryption setting
+func secretsNeedMigration(config *Config) bool {
+	for _, field := range secretFields(config) {
+		if *field != "" && configcrypt.IsEncrypted(*field) != (config.SecretsEncryption != "") {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactedConfig returns a copy of the config with the secrets and the URL credentials replaced, for the diagnostics
+func RedactedConfig(config *Config) *Config {
+	redacted := *config
+	for _, field := range secretFields(&redacted) {
+		if *field != "" {
+			*field = redactedSecret
+		}
+	}
+
+	redacted.ManagementURL = redactURL(config.ManagementURL)
+	redacted.AdminURL = redactURL(config.AdminURL)
+	if proxyURL, err := url.Parse(config.ProxyURL); err == nil && proxyURL.User != nil {
+		redacted.ProxyURL = redactURL(proxyURL).String()
+	}
+	return &redacted
+}
+
+// redactURL returns a copy of the URL without the user credentials
+func redactURL(u *url.URL) *url.URL {
+	if u == nil || u.User == nil {
+		return u
+	}
+	redacted := *u
+	redacted.User = url.User(redactedSecret)
+	return &redacted
+}
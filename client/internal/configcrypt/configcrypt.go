@@ -0,0 +1,230 @@
+// Package configcrypt encrypts the secrets of the client config at rest with a key supplied by the user or kept in
+// the keyring of the operating system.
+package configcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// ModeKey encrypts with the key of the NB_CONFIG_ENCRYPTION_KEY or the NB_CONFIG_ENCRYPTION_KEY_FILE environment
+	// variables, so the daemon can start unattended with the key set in its service environment
+	ModeKey = "key"
+	// ModeKeyring encrypts with a random key kept in the keyring of the operating system: the Secret Service on Linux,
+	// the Keychain on macOS and a DPAPI protected file next to the config on Windows
+	ModeKeyring = "keyring"
+
+	// EnvKey is the environment variable holding the user supplied key
+	EnvKey = "NB_CONFIG_ENCRYPTION_KEY"
+	// EnvKeyFile is the environment variable holding the path to a file containing the user supplied key
+	EnvKeyFile = "NB_CONFIG_ENCRYPTION_KEY_FILE"
+
+	// prefix marks the encrypted values, the values without it are read as plaintext
+	prefix = "enc:v1:"
+
+	saltSize = 16
+	keySize  = 32
+)
+
+// Cipher encrypts and decrypts the config values with a key derived from a secret
+type Cipher struct {
+	secret []byte
+
+	mu sync.Mutex
+	// salt of the key the values are encrypted with
+	salt []byte
+	// keys derived by salt, the values written by another Cipher may have a different one
+	keys map[string]cipher.AEAD
+}
+
+// New returns a Cipher deriving its keys from the secret
+func New(secret []byte) *Cipher {
+	return &Cipher{
+		secret: secret,
+		keys:   make(map[string]cipher.AEAD),
+	}
+}
+
+// NewForMode returns a Cipher with the secret of the encryption mode. The name identifies the keyring entry of the
+// ModeKeyring secret, usually the config path.
+func NewForMode(mode, name string) (*Cipher, error) {
+	secret, err := LoadSecret(mode, name)
+	if err != nil {
+		return nil, err
+	}
+	return New(secret), nil
+}
+
+// LoadSecret returns the secret of the encryption mode, creating it in the keyring on the first use of ModeKeyring
+func LoadSecret(mode, name string) ([]byte, error) {
+	switch mode {
+	case ModeKey:
+		return secretFromEnv()
+	case ModeKeyring:
+		absName, err := filepath.Abs(name)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := keyringSecret(absName)
+		if err != nil {
+			return nil, fmt.Errorf("get the config encryption key from the keyring: %w, use the %q mode with the %s environment variable when the keyring isn't available",
+				err, ModeKey, EnvKey)
+		}
+		return secret, nil
+	default:
+		return nil, fmt.Errorf("unknown config encryption mode %q, expected %q or %q", mode, ModeKey, ModeKeyring)
+	}
+}
+
+// ValidateMode returns an error if the mode isn't empty or a known encryption mode
+func ValidateMode(mode string) error {
+	switch mode {
+	case "", ModeKey, ModeKeyring:
+		return nil
+	default:
+		return fmt.Errorf("unknown config encryption mode %q, expected %q or %q", mode, ModeKey, ModeKeyring)
+	}
+}
+
+// IsEncrypted returns true if the value has been encrypted by a Cipher
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt returns the encrypted value. Empty and already encrypted values are returned as is.
+func (c *Cipher) Encrypt(value string) (string, error) {
+	if value == "" || IsEncrypted(value) {
+		return value, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.salt == nil {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		c.salt = salt
+	}
+
+	aead, err := c.aead(c.salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := make([]byte, 0, saltSize+len(nonce)+len(value)+aead.Overhead())
+	sealed = append(sealed, c.salt...)
+	sealed = append(sealed, nonce...)
+	sealed = aead.Seal(sealed, nonce, []byte(value), nil)
+
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the decrypted value. The values which aren't encrypted are returned as is, so the plaintext
+// configs written before the encryption was enabled can be read.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil {
+		return "", fmt.Errorf("decode the encrypted value: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(sealed) < saltSize {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	aead, err := c.aead(sealed[:saltSize])
+	if err != nil {
+		return "", err
+	}
+
+	sealed = sealed[saltSize:]
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("decrypt the value, the config encryption key is wrong or the value is corrupted")
+	}
+
+	return string(plaintext), nil
+}
+
+// aead returns the AES-GCM cipher of the key derived with the salt. The caller must hold the lock.
+func (c *Cipher) aead(salt []byte) (cipher.AEAD, error) {
+	if aead, ok := c.keys[string(salt)]; ok {
+		return aead, nil
+	}
+
+	key, err := scrypt.Key(c.secret, salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	c.keys[string(salt)] = aead
+	return aead, nil
+}
+
+func secretFromEnv() ([]byte, error) {
+	if key := os.Getenv(EnvKey); key != "" {
+		return []byte(key), nil
+	}
+
+	path := os.Getenv(EnvKeyFile)
+	if path == "" {
+		return nil, fmt.Errorf("config encryption key isn't set, set the %s or the %s environment variable", EnvKey, EnvKeyFile)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read the config encryption key file: %w", err)
+	}
+
+	key := strings.TrimSpace(string(content))
+	if key == "" {
+		return nil, fmt.Errorf("config encryption key file %s is empty", path)
+	}
+	return []byte(key), nil
+}
+
+func newSecret() ([]byte, error) {
+	secret := make([]byte, keySize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
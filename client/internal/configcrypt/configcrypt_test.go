@@ -0,0 +1,68 @@
+package configcrypt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipher_EncryptDecrypt(t *testing.T) {
+	cipher := New([]byte("secret"))
+
+	encrypted, err := cipher.Encrypt("private key")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, "private key")
+
+	again, err := cipher.Encrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, encrypted, again, "an encrypted value shouldn't be encrypted twice")
+
+	empty, err := cipher.Encrypt("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	// a new cipher with the same secret derives the key from the salt of the value
+	decrypted, err := New([]byte("secret")).Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "private key", decrypted)
+
+	_, err = New([]byte("other secret")).Decrypt(encrypted)
+	assert.Error(t, err)
+
+	plaintext, err := cipher.Decrypt("plaintext key")
+	require.NoError(t, err)
+	assert.Equal(t, "plaintext key", plaintext, "a plaintext value should be read as is")
+
+	_, err = cipher.Decrypt(prefix + "AAAA")
+	assert.Error(t, err)
+}
+
+func TestLoadSecret_Env(t *testing.T) {
+	t.Setenv(EnvKey, "")
+	t.Setenv(EnvKeyFile, "")
+
+	_, err := LoadSecret(ModeKey, "config.json")
+	assert.Error(t, err, "the key mode should fail without a key")
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("file secret\n"), 0600))
+	t.Setenv(EnvKeyFile, keyFile)
+
+	secret, err := LoadSecret(ModeKey, "config.json")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("file secret"), secret)
+
+	t.Setenv(EnvKey, "env secret")
+	secret, err = LoadSecret(ModeKey, "config.json")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("env secret"), secret, "the key of the environment should take precedence")
+
+	_, err = LoadSecret("plaintext", "config.json")
+	assert.Error(t, err)
+	assert.Error(t, ValidateMode("plaintext"))
+	assert.NoError(t, ValidateMode(""))
+}
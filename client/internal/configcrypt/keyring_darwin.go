@@ -0,0 +1,48 @@
+//go:build !ios
+
+package configcrypt
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	keychainService = "NetBird config encryption key"
+	// keychainItemNotFound is the exit code of the security tool when the item doesn't exist
+	keychainItemNotFound = 44
+)
+
+// keyringSecret returns the secret kept in the default keychain of the user running the client, the System keychain
+// for the daemon, creating it if it doesn't exist
+func keyringSecret(name string) ([]byte, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", name, "-w").Output()
+	if err == nil {
+		secret, err := hex.DecodeString(strings.TrimSpace(string(out)))
+		if err != nil || len(secret) != keySize {
+			return nil, errors.New("keychain item has an unexpected format")
+		}
+		return secret, nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != keychainItemNotFound {
+		return nil, fmt.Errorf("find the keychain item: %w", err)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err = exec.Command("security", "add-generic-password", "-s", keychainService, "-a", name,
+		"-w", hex.EncodeToString(secret)).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("add the keychain item: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,111 @@
+//go:build !android
+
+package configcrypt
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServiceName         = "org.freedesktop.secrets"
+	secretServicePath         = "/org/freedesktop/secrets"
+	secretServiceCollection   = "/org/freedesktop/secrets/aliases/default"
+	secretServiceInterface    = "org.freedesktop.Secret.Service"
+	secretSessionInterface    = "org.freedesktop.Secret.Session"
+	secretItemInterface       = "org.freedesktop.Secret.Item"
+	secretCollectionInterface = "org.freedesktop.Secret.Collection"
+
+	// noPrompt is the prompt path returned when the Secret Service doesn't have to ask the user
+	noPrompt = dbus.ObjectPath("/")
+)
+
+var errKeyringLocked = errors.New("keyring is locked")
+
+// secretServiceSecret is the Secret struct of the Secret Service API
+type secretServiceSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// keyringSecret returns the secret kept in the default collection of the Secret Service of the session bus,
+// creating it if it doesn't exist. The collection has to be unlocked, the user isn't prompted.
+func keyringSecret(name string) ([]byte, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to the session bus: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	service := conn.Object(secretServiceName, secretServicePath)
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err = service.Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session)
+	if err != nil {
+		return nil, fmt.Errorf("open a Secret Service session: %w", err)
+	}
+	defer conn.Object(secretServiceName, session).Call(secretSessionInterface+".Close", 0)
+
+	attributes := map[string]string{
+		"application": "netbird",
+		"config":      name,
+	}
+
+	var unlocked, locked []dbus.ObjectPath
+	err = service.Call(secretServiceInterface+".SearchItems", 0, attributes).Store(&unlocked, &locked)
+	if err != nil {
+		return nil, fmt.Errorf("search the Secret Service items: %w", err)
+	}
+
+	if len(unlocked) > 0 {
+		var secret secretServiceSecret
+		err = conn.Object(secretServiceName, unlocked[0]).Call(secretItemInterface+".GetSecret", 0, session).Store(&secret)
+		if err != nil {
+			return nil, fmt.Errorf("get the Secret Service item secret: %w", err)
+		}
+		if len(secret.Value) != keySize {
+			return nil, fmt.Errorf("keyring secret has an unexpected size of %d bytes", len(secret.Value))
+		}
+		return secret.Value, nil
+	}
+
+	if len(locked) > 0 {
+		return nil, errKeyringLocked
+	}
+
+	value, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	properties := map[string]dbus.Variant{
+		secretItemInterface + ".Label":      dbus.MakeVariant("NetBird config encryption key"),
+		secretItemInterface + ".Attributes": dbus.MakeVariant(attributes),
+	}
+	secret := secretServiceSecret{
+		Session:     session,
+		Parameters:  []byte{},
+		Value:       value,
+		ContentType: "application/octet-stream",
+	}
+
+	var item, prompt dbus.ObjectPath
+	err = conn.Object(secretServiceName, secretServiceCollection).
+		Call(secretCollectionInterface+".CreateItem", 0, properties, secret, true).
+		Store(&item, &prompt)
+	if err != nil {
+		return nil, fmt.Errorf("create the Secret Service item: %w", err)
+	}
+	if prompt != noPrompt {
+		return nil, errKeyringLocked
+	}
+
+	return value, nil
+}
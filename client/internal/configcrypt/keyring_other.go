@@ -0,0 +1,11 @@
+//go:build android || ios || !(linux || darwin || windows)
+
+package configcrypt
+
+import (
+	"errors"
+)
+
+func keyringSecret(string) ([]byte, error) {
+	return nil, errors.New("not supported on this platform")
+}
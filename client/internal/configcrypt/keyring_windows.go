@@ -0,0 +1,80 @@
+package configcrypt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// keyringSecret returns the secret kept next to the config in a file protected with DPAPI, creating it if it
+// doesn't exist. The file can only be decrypted by the account which created it, the LocalSystem account for the
+// daemon.
+func keyringSecret(name string) ([]byte, error) {
+	path := name + ".key"
+
+	protected, err := os.ReadFile(path)
+	if err == nil {
+		secret, err := dpapiUnprotect(protected)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt the key file %s: %w", path, err)
+		}
+		if len(secret) != keySize {
+			return nil, fmt.Errorf("key file %s has an unexpected size", path)
+		}
+		return secret, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	protected, err = dpapiProtect(secret)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt the key: %w", err)
+	}
+
+	if err := os.WriteFile(path, protected, 0600); err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+func dpapiProtect(data []byte) ([]byte, error) {
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	err := windows.CryptProtectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	if err != nil {
+		return nil, err
+	}
+	return takeDataBlob(&out), nil
+}
+
+func dpapiUnprotect(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty data")
+	}
+
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	err := windows.CryptUnprotectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	if err != nil {
+		return nil, err
+	}
+	return takeDataBlob(&out), nil
+}
+
+// takeDataBlob copies the data allocated by DPAPI and frees it
+func takeDataBlob(blob *windows.DataBlob) []byte {
+	defer func() {
+		_, _ = windows.LocalFree(windows.Handle(unsafe.Pointer(blob.Data)))
+	}()
+	return append([]byte{}, unsafe.Slice(blob.Data, blob.Size)...)
+}
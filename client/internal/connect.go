@@ -2,12 +2,16 @@ package internal
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/pion/stun/v2"
 	log "github.com/sirupsen/logrus"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 	"google.golang.org/grpc/codes"
@@ -15,17 +19,27 @@ import (
 
 	"github.com/netbirdio/netbird/client/internal/dns"
 	"github.com/netbirdio/netbird/client/internal/listener"
+	"github.com/netbirdio/netbird/client/internal/nattype"
 	"github.com/netbirdio/netbird/client/internal/peer"
+	"github.com/netbirdio/netbird/client/internal/portmap"
 	"github.com/netbirdio/netbird/client/internal/stdnet"
+	"github.com/netbirdio/netbird/client/internal/telemetry"
 	"github.com/netbirdio/netbird/client/ssh"
-	"github.com/netbirdio/netbird/client/system"
 	"github.com/netbirdio/netbird/iface"
 	mgm "github.com/netbirdio/netbird/management/client"
 	mgmProto "github.com/netbirdio/netbird/management/proto"
 	signal "github.com/netbirdio/netbird/signal/client"
+	"github.com/netbirdio/netbird/util"
 	"github.com/netbirdio/netbird/version"
 )
 
+// natDetectionTimeout bounds the NAT type detection delaying the engine start when the STUN servers don't respond
+const natDetectionTimeout = 5 * time.Second
+
+// clockSkewWarningThreshold is the clock skew with the Management Service the user is warned about. The TURN
+// credentials and the key expiration times issued by the Management Service rely on the clocks being in sync.
+const clockSkewWarningThreshold = time.Minute
+
 // RunClient with main logic.
 func RunClient(ctx context.Context, config *Config, statusRecorder *peer.Status) error {
 	return runClient(ctx, config, statusRecorder, MobileDependency{}, nil, nil, nil, nil)
@@ -100,16 +114,12 @@ func runClient(
 		log.Errorf("checking unclean shutdown error: %s", err)
 	}
 
-	backOff := &backoff.ExponentialBackOff{
-		InitialInterval:     time.Second,
-		RandomizationFactor: 1,
-		Multiplier:          1.7,
-		MaxInterval:         15 * time.Second,
-		MaxElapsedTime:      3 * 30 * 24 * time.Hour, // 3 months
-		Stop:                backoff.Stop,
-		Clock:               backoff.SystemClock,
+	if err := CheckNetworkNamespace(config.NetworkNamespace); err != nil {
+		return NewConfigInvalidError(err)
 	}
 
+	backOff := mgm.NewBackOff(3 * 30 * 24 * time.Hour) // 3 months
+
 	state := CtxGetState(ctx)
 	defer func() {
 		s, err := state.Status()
@@ -122,7 +132,7 @@ func runClient(
 	myPrivateKey, err := wgtypes.ParseKey(config.PrivateKey)
 	if err != nil {
 		log.Errorf("failed parsing Wireguard key %s: [%s]", config.PrivateKey, err.Error())
-		return wrapErr(err)
+		return wrapErr(NewConfigInvalidError(err))
 	}
 
 	var mgmTlsEnabled bool
@@ -132,10 +142,47 @@ func runClient(
 
 	publicSSHKey, err := ssh.GeneratePublicKey([]byte(config.SSHKey))
 	if err != nil {
-		return err
+		return wrapErr(NewConfigInvalidError(err))
+	}
+
+	tlsConfig, err := config.TLSConfig()
+	if err != nil {
+		return wrapErr(NewConfigInvalidError(err))
+	}
+
+	proxyDialer, err := config.ProxyDialer()
+	if err != nil {
+		return wrapErr(NewConfigInvalidError(err))
+	}
+
+	// the key is pinned on first use when not configured and kept across the reconnects
+	networkMapVerifyKey, err := config.PinnedNetworkMapVerifyKey()
+	if err != nil {
+		return wrapErr(NewConfigInvalidError(err))
+	}
+	if config.ProxyURL != "" {
+		log.Infof("the Management and Signal Service connections go through the proxy %s, the peer connections "+
+			"don't and need UDP access to the other peers or to the relay servers", proxyDialer)
 	}
 
 	defer statusRecorder.ClientStop()
+
+	if config.MetricsPort > 0 {
+		exporter, err := telemetry.NewExporter(config.MetricsPort, statusRecorder)
+		if err != nil {
+			log.Errorf("failed to expose the client metrics: %v", err)
+		} else {
+			defer func() {
+				if err := exporter.Close(); err != nil {
+					log.Errorf("failed to stop exposing the client metrics: %v", err)
+				}
+			}()
+		}
+	}
+
+	// the NAT type is kept across the reconnects and classified again only when the local network changes
+	natDetector := nattype.NewDetector()
+
 	operation := func() error {
 		// if context cancelled we not start new backoff cycle
 		select {
@@ -154,12 +201,15 @@ func runClient(
 		}()
 
 		log.Debugf("connecting to the Management service %s", config.ManagementURL.Host)
-		mgmClient, err := mgm.NewClient(engineCtx, config.ManagementURL.Host, myPrivateKey, mgmTlsEnabled)
+		mgmClient, err := mgm.NewClient(engineCtx, config.ManagementURL.Host, myPrivateKey, mgmTlsEnabled, tlsConfig, proxyDialer)
 		if err != nil {
-			return wrapErr(gstatus.Errorf(codes.FailedPrecondition, "failed connecting to Management Service : %s", err))
+			err = gstatus.Errorf(codes.FailedPrecondition, "failed connecting to Management Service : %s", err)
+			return wrapErr(NewManagementUnreachableError(config.ManagementURL.String(), err))
 		}
 		mgmNotifier := statusRecorderToMgmConnStateNotifier(statusRecorder)
 		mgmClient.SetConnStateListener(mgmNotifier)
+		mgmClient.SetStrictNetworkMapSignature(config.StrictNetworkMapSignature)
+		mgmClient.PinNetworkMapVerifyKey(networkMapVerifyKey)
 
 		log.Debugf("connected to the Management service %s", config.ManagementURL.Host)
 		defer func() {
@@ -169,17 +219,42 @@ func runClient(
 			}
 		}()
 
+		wgClaim, err := claimWgInterface(config.WgIface, config.WgPort, config.WgPortRangeEnd)
+		if err != nil {
+			log.Error(err)
+			return wrapErr(err)
+		}
+		defer wgClaim.release()
+
+		var mappedAddress string
+		var portMapper *portmap.Manager
+		if config.PortMapping {
+			var mapping *portmap.Mapping
+			portMapper, mapping = startPortMapping(wgClaim.port)
+			if portMapper != nil {
+				defer func() {
+					if err := portMapper.Close(); err != nil {
+						log.Warnf("failed to remove the port mapping: %v", err)
+					}
+				}()
+			}
+			if mapping != nil {
+				mappedAddress = mapping.External.String()
+			}
+		}
+
 		// connect (just a connection, no stream yet) and login to Management Service to get an initial global Wiretrustee config
-		loginResp, err := loginToManagement(engineCtx, mgmClient, publicSSHKey)
+		loginResp, err := loginToManagement(engineCtx, mgmClient, config, publicSSHKey, mappedAddress, natDetector.Type())
 		if err != nil {
 			log.Debug(err)
 			if s, ok := gstatus.FromError(err); ok && (s.Code() == codes.PermissionDenied) {
 				state.Set(StatusNeedsLogin)
-				return backoff.Permanent(wrapErr(err)) // unrecoverable error
+				return backoff.Permanent(wrapErr(NewAuthRequiredError(err))) // unrecoverable error
 			}
 			return wrapErr(err)
 		}
 		statusRecorder.MarkManagementConnected()
+		networkMapVerifyKey = mgmClient.NetworkMapVerifyKey()
 
 		localPeerState := peer.LocalPeerState{
 			IP:              loginResp.GetPeerConfig().GetAddress(),
@@ -190,10 +265,7 @@ func runClient(
 
 		statusRecorder.UpdateLocalPeerState(localPeerState)
 
-		signalURL := fmt.Sprintf("%s://%s",
-			strings.ToLower(loginResp.GetWiretrusteeConfig().GetSignal().GetProtocol().String()),
-			loginResp.GetWiretrusteeConfig().GetSignal().GetUri(),
-		)
+		signalURL := signalAddress(loginResp.GetWiretrusteeConfig().GetSignal())
 
 		statusRecorder.UpdateSignalAddress(signalURL)
 
@@ -203,10 +275,10 @@ func runClient(
 		}()
 
 		// with the global Wiretrustee config in hand connect (just a connection, no stream yet) Signal
-		signalClient, err := connectToSignal(engineCtx, loginResp.GetWiretrusteeConfig(), myPrivateKey)
+		signalClient, err := connectToSignal(engineCtx, loginResp.GetWiretrusteeConfig(), config.SignalFallbackURLs, myPrivateKey, tlsConfig, proxyDialer)
 		if err != nil {
 			log.Error(err)
-			return wrapErr(err)
+			return wrapErr(NewSignalUnreachableError(config.ManagementURL.String(), signalURL, err))
 		}
 		defer func() {
 			err = signalClient.Close()
@@ -218,6 +290,8 @@ func runClient(
 		signalNotifier := statusRecorderToSignalConnStateNotifier(statusRecorder)
 		signalClient.SetConnStateListener(signalNotifier)
 
+		// the Signal Service of the Management Service might have been unreachable and a fallback one connected
+		statusRecorder.UpdateSignalAddress(signalClient.ActiveEndpoint().URL())
 		statusRecorder.MarkSignalConnected()
 
 		peerConfig := loginResp.GetPeerConfig()
@@ -225,10 +299,19 @@ func runClient(
 		engineConfig, err := createEngineConfig(myPrivateKey, config, peerConfig)
 		if err != nil {
 			log.Error(err)
-			return wrapErr(err)
+			return wrapErr(NewConfigInvalidError(err))
 		}
 
+		engineConfig.WgPort = wgClaim.port
+		engineConfig.SignalAddress = signalURL
+		engineConfig.PortMapper = portMapper
+		engineConfig.NATType = detectNATType(engineCtx, natDetector, loginResp.GetWiretrusteeConfig().GetStuns())
+
 		engine := NewEngineWithProbes(engineCtx, cancel, signalClient, mgmClient, engineConfig, mobileDependency, statusRecorder, mgmProbe, signalProbe, relayProbe, wgProbe)
+		signalClient.SetFailoverListener(func(endpoint signal.Endpoint) {
+			statusRecorder.UpdateSignalAddress(endpoint.URL())
+			engine.renegotiatePeers()
+		})
 		err = engine.Start()
 		if err != nil {
 			log.Errorf("error while starting Netbird Connection Engine: %s", err)
@@ -237,11 +320,17 @@ func runClient(
 
 		log.Print("Netbird engine started, my IP is: ", peerConfig.Address)
 		state.Set(StatusConnected)
+		state.SetEngine(engine)
+		engineStartedAt := time.Now()
 
 		<-engineCtx.Done()
+		state.SetEngine(nil)
 		statusRecorder.ClientTeardown()
 
-		backOff.Reset()
+		// an engine failing right after the start is restarted with the growing interval
+		if time.Since(engineStartedAt) >= mgm.StableConnectionDuration {
+			backOff.Reset()
+		}
 
 		err = engine.Stop()
 		if err != nil {
@@ -270,19 +359,67 @@ func runClient(
 	return nil
 }
 
+// ReloadConfig applies the reloadable settings of the config to the engine of the client running in the context,
+// without reconnecting the peers. It returns the names of the reloaded subsystems.
+func ReloadConfig(ctx context.Context, config *Config) ([]string, error) {
+	engine := CtxGetState(ctx).Engine()
+	if engine == nil {
+		return nil, fmt.Errorf("client is not running")
+	}
+
+	return engine.ReloadConfig(&EngineConfig{
+		IFaceBlackList:   config.IFaceBlackList,
+		NATExternalIPs:   config.NATExternalIPs,
+		CustomDNSAddress: config.CustomDNSAddress,
+	})
+}
+
+// signalAddress returns the address of the Signal service, e.g. https://signal.netbird.io:443
+func signalAddress(signal *mgmProto.HostConfig) string {
+	return fmt.Sprintf("%s://%s", strings.ToLower(signal.GetProtocol().String()), signal.GetUri())
+}
+
 // createEngineConfig converts configuration received from Management Service to EngineConfig
 func createEngineConfig(key wgtypes.Key, config *Config, peerConfig *mgmProto.PeerConfig) (*EngineConfig, error) {
 	engineConf := &EngineConfig{
 		WgIfaceName:          config.WgIface,
 		WgAddr:               peerConfig.Address,
+		MTU:                  int(peerConfig.GetMtu()),
 		IFaceBlackList:       config.IFaceBlackList,
 		DisableIPv6Discovery: config.DisableIPv6Discovery,
 		WgPrivateKey:         key,
 		WgPort:               config.WgPort,
+		WgFwmark:             config.WgFwmark,
 		SSHKey:               []byte(config.SSHKey),
 		NATExternalIPs:       config.NATExternalIPs,
 		CustomDNSAddress:     config.CustomDNSAddress,
+		DNSExcludedDomains:   config.DNSExcludedDomains,
 		RosenpassEnabled:     config.RosenpassEnabled,
+		AdvertisedNetworks:   advertisedNetworksOutsideOverlay(config.AdvertisedNetworks, peerConfig.Address),
+		ExcludedRoutes:       excludedRoutesOutsideOverlay(config.ExcludedRoutes, peerConfig.Address),
+	}
+
+	engineConf.StaleHandshakeThreshold = DefaultStaleHandshakeThreshold
+	if config.StaleHandshakeThreshold != nil {
+		engineConf.StaleHandshakeThreshold = config.StaleHandshakeThreshold.Duration
+	}
+	engineConf.StaleHandshakeReconnect = config.StaleHandshakeReconnect
+	engineConf.FirewallFlowLogging = config.FirewallFlowLogging
+
+	connOrdering, err := peer.ParseConnOrdering(config.ConnectionOrdering)
+	if err != nil {
+		log.Warnf("ignoring the connection ordering: %v", err)
+	}
+	engineConf.ConnOrdering = connOrdering
+
+	engineConf.RelayFallbackAttempts = peer.DefaultRelayFallbackAttempts
+	if config.RelayFallbackAttempts != nil {
+		engineConf.RelayFallbackAttempts = *config.RelayFallbackAttempts
+	}
+
+	engineConf.MaxConcurrentNegotiations = peer.DefaultMaxConcurrentNegotiations
+	if config.MaxConcurrentNegotiations != nil {
+		engineConf.MaxConcurrentNegotiations = *config.MaxConcurrentNegotiations
 	}
 
 	if config.PreSharedKey != "" {
@@ -296,16 +433,15 @@ func createEngineConfig(key wgtypes.Key, config *Config, peerConfig *mgmProto.Pe
 	return engineConf, nil
 }
 
-// connectToSignal creates Signal Service client and established a connection
-func connectToSignal(ctx context.Context, wtConfig *mgmProto.WiretrusteeConfig, ourPrivateKey wgtypes.Key) (*signal.GrpcClient, error) {
-	var sigTLSEnabled bool
-	if wtConfig.Signal.Protocol == mgmProto.HostConfig_HTTPS {
-		sigTLSEnabled = true
-	} else {
-		sigTLSEnabled = false
+// connectToSignal creates Signal Service client and established a connection to the Signal Service of the
+// Management Service, or to the first reachable fallback one
+func connectToSignal(ctx context.Context, wtConfig *mgmProto.WiretrusteeConfig, fallbackURLs []string, ourPrivateKey wgtypes.Key, tlsConfig *tls.Config, proxyDialer *util.ProxyDialer) (*signal.FailoverClient, error) {
+	endpoints, err := signalEndpoints(wtConfig.GetSignal(), fallbackURLs)
+	if err != nil {
+		return nil, gstatus.Errorf(codes.FailedPrecondition, "failed connecting to Signal Service : %s", err)
 	}
 
-	signalClient, err := signal.NewClient(ctx, wtConfig.Signal.Uri, ourPrivateKey, sigTLSEnabled)
+	signalClient, err := signal.NewFailoverClient(ctx, endpoints, ourPrivateKey, tlsConfig, proxyDialer)
 	if err != nil {
 		log.Errorf("error while connecting to the Signal Exchange Service %s: %s", wtConfig.Signal.Uri, err)
 		return nil, gstatus.Errorf(codes.FailedPrecondition, "failed connecting to Signal Service : %s", err)
@@ -315,14 +451,19 @@ func connectToSignal(ctx context.Context, wtConfig *mgmProto.WiretrusteeConfig,
 }
 
 // loginToManagement creates Management Services client, establishes a connection, logs-in and gets a global Wiretrustee config (signal, turn, stun hosts, etc)
-func loginToManagement(ctx context.Context, client mgm.Client, pubSSHKey []byte) (*mgmProto.LoginResponse, error) {
+func loginToManagement(ctx context.Context, client mgm.Client, config *Config, pubSSHKey []byte, mappedAddress string, natType nattype.Type) (*mgmProto.LoginResponse, error) {
 
 	serverPublicKey, err := client.GetServerPublicKey()
 	if err != nil {
-		return nil, gstatus.Errorf(codes.FailedPrecondition, "failed while getting Management Service public key: %s", err)
+		err = gstatus.Errorf(codes.FailedPrecondition, "failed while getting Management Service public key: %s", err)
+		return nil, NewManagementUnreachableError(config.ManagementURL.String(), err)
 	}
+	warnClockSkew(client.ClockSkew())
 
-	sysInfo := system.GetInfo(ctx)
+	sysInfo := getSystemInfo(ctx, config.PrivateKey, config.SystemMetaPrivacy)
+	sysInfo.NATMappedAddress = mappedAddress
+	sysInfo.AdvertisedNetworks = config.AdvertisedNetworks
+	sysInfo.NATType = string(natType)
 	loginResp, err := client.Login(*serverPublicKey, sysInfo, pubSSHKey)
 	if err != nil {
 		return nil, err
@@ -331,6 +472,57 @@ func loginToManagement(ctx context.Context, client mgm.Client, pubSSHKey []byte)
 	return loginResp, nil
 }
 
+// warnClockSkew warns the user when the local clock is too far off the clock of the Management Service
+func warnClockSkew(skew time.Duration) {
+	if skew.Abs() < clockSkewWarningThreshold {
+		return
+	}
+	direction := "behind"
+	if skew < 0 {
+		direction = "ahead of"
+	}
+	log.Warnf("the local clock is %s %s the clock of the Management Service, the TURN relayed connections may fail, "+
+		"please synchronize the system clock", skew.Abs().Round(time.Second), direction)
+}
+
+// detectNATType classifies the NAT with the UDP STUN servers of the Management service. The detector returns the
+// cached type without probing while the local network doesn't change.
+func detectNATType(ctx context.Context, detector *nattype.Detector, stuns []*mgmProto.HostConfig) nattype.Type {
+	var servers []string
+	for _, s := range stuns {
+		uri, err := stun.ParseURI(s.GetUri())
+		if err != nil {
+			log.Debugf("skipping the STUN server %s for the NAT type detection: %v", s.GetUri(), err)
+			continue
+		}
+		if uri.Scheme != stun.SchemeTypeSTUN || uri.Proto != stun.ProtoTypeUDP {
+			continue
+		}
+		servers = append(servers, net.JoinHostPort(uri.Host, strconv.Itoa(uri.Port)))
+	}
+	if len(servers) == 0 {
+		return nattype.Unknown
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, natDetectionTimeout)
+	defer cancel()
+	return detector.Detect(ctx, servers)
+}
+
+// startPortMapping maps the WireGuard port on the gateway. The returned manager keeps retrying in the background
+// when the port couldn't be mapped yet, it is nil only if the gateway wasn't found.
+func startPortMapping(wgPort int) (*portmap.Manager, *portmap.Mapping) {
+	manager, err := portmap.NewManager(wgPort)
+	if err != nil {
+		log.Warnf("port mapping is disabled: %v", err)
+		return nil, nil
+	}
+	if err := manager.Start(); err != nil {
+		log.Warnf("failed to map the WireGuard port %d on the gateway: %v", wgPort, err)
+	}
+	return manager, manager.Mapping()
+}
+
 func statusRecorderToMgmConnStateNotifier(statusRecorder *peer.Status) mgm.ConnStateNotifier {
 	var sri interface{} = statusRecorder
 	mgmNotifier, _ := sri.(mgm.ConnStateNotifier)
@@ -0,0 +1,161 @@
+// Package diag builds the self-diagnostic bundle of the client attached to the support tickets
+package diag
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// commandTimeout limits the time a command listing the system state can run
+	commandTimeout = 10 * time.Second
+	// redactedValue replaces the secrets in the bundle
+	redactedValue = "[REDACTED]"
+	// maxConfigFileSize limits the size of the host configuration files added to the bundle
+	maxConfigFileSize = 1 << 20
+)
+
+// Section is a file of the bundle
+type Section struct {
+	// Name of the file in the archive, e.g. "firewall/iptables-save.txt"
+	Name string
+	// Collect returns the content of the file
+	Collect func(ctx context.Context) ([]byte, error)
+}
+
+var (
+	// setupKeyPattern matches the setup keys, UUIDs
+	setupKeyPattern = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	// privateKeyPattern matches the private and preshared keys in the key=value and the JSON formats
+	privateKeyPattern = regexp.MustCompile(`(?i)("?(?:private_?key|pre_?shared_?key|ssh_?key)"?\s*[=:]\s*"?)[^"\s,}]+`)
+)
+
+// Redactor scrubs the secrets from the content of the bundle
+type Redactor struct {
+	secrets []string
+}
+
+// NewRedactor returns a redactor replacing the given secrets, along with anything looking like a setup key or
+// a private key, wherever they appear
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{}
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r
+}
+
+// Redact returns the content with the secrets replaced
+func (r *Redactor) Redact(content []byte) []byte {
+	for _, secret := range r.secrets {
+		content = bytes.ReplaceAll(content, []byte(secret), []byte(redactedValue))
+	}
+	content = setupKeyPattern.ReplaceAll(content, []byte(redactedValue))
+	return privateKeyPattern.ReplaceAll(content, []byte("${1}"+redactedValue))
+}
+
+// Bundle collects the sections into a zip archive with their secrets redacted. The sections which can't be collected,
+// e.g. the firewall chains on a host without iptables, are replaced by a file explaining the error, so the bundle
+// is built even when some pieces are unavailable.
+func Bundle(ctx context.Context, sections []Section, redactor *Redactor) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, section := range sections {
+		name := section.Name
+		content, err := section.Collect(ctx)
+		if err != nil {
+			log.Debugf("failed to collect the diagnostic %s: %v", section.Name, err)
+			name += ".error"
+			content = append(content, []byte(fmt.Sprintf("\nfailed to collect: %v\n", err))...)
+		}
+
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return nil, fmt.Errorf("add %s to the bundle: %w", name, err)
+		}
+		if _, err := w.Write(redactor.Redact(content)); err != nil {
+			return nil, fmt.Errorf("write %s to the bundle: %w", name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// JSONSection returns a section with the value returned by get in the JSON format
+func JSONSection(name string, get func(ctx context.Context) (any, error)) Section {
+	return Section{Name: name, Collect: func(ctx context.Context) ([]byte, error) {
+		value, err := get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(value, "", "  ")
+	}}
+}
+
+// CommandSection returns a section with the output of the command
+func CommandSection(name, command string, args ...string) Section {
+	return Section{Name: name, Collect: func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+		return exec.CommandContext(ctx, command, args...).CombinedOutput()
+	}}
+}
+
+// FileTailSection returns a section with the end of the file, at most limit bytes
+func FileTailSection(name, path string, limit int64) Section {
+	return Section{Name: name, Collect: func(context.Context) ([]byte, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() > limit {
+			if _, err := f.Seek(-limit, io.SeekEnd); err != nil {
+				return nil, err
+			}
+		}
+		return io.ReadAll(f)
+	}}
+}
+
+// ErrorSection returns a section which can't be collected for the given reason
+func ErrorSection(name string, err error) Section {
+	return Section{Name: name, Collect: func(context.Context) ([]byte, error) {
+		return nil, err
+	}}
+}
+
+// TextSection returns a section with the text returned by get
+func TextSection(name string, get func(ctx context.Context) (string, error)) Section {
+	return Section{Name: name, Collect: func(ctx context.Context) ([]byte, error) {
+		text, err := get(ctx)
+		return []byte(text), err
+	}}
+}
+
+// joinLines formats the lines as a text file
+func joinLines(lines []string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,104 @@
+package diag
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	redactor := NewRedactor("wgPrivateKeyValue=", "", "sshKeyValue")
+
+	content := []byte(`{"PrivateKey": "wgPrivateKeyValue=", "SSHKey": "sshKeyValue", "Name": "peer"}
+setup key 0A1B2C3D-4E5F-6789-ABCD-EF0123456789 used
+private_key=abcdef0123 preshared_key=fedcba
+public_key=kept`)
+
+	redacted := string(redactor.Redact(content))
+
+	for _, secret := range []string{"wgPrivateKeyValue", "sshKeyValue", "0A1B2C3D", "abcdef0123", "fedcba"} {
+		assert.NotContains(t, redacted, secret, "secret should be redacted")
+	}
+	assert.Contains(t, redacted, `"Name": "peer"`)
+	assert.Contains(t, redacted, "public_key=kept")
+}
+
+func TestBundle(t *testing.T) {
+	sections := []Section{
+		TextSection("status.txt", func(context.Context) (string, error) {
+			return "connected with key secretKey", nil
+		}),
+		ErrorSection("firewall/iptables-save.txt", errors.New("iptables not found")),
+	}
+
+	archive, err := Bundle(context.Background(), sections, NewRedactor("secretKey"))
+	require.NoError(t, err)
+
+	files := readArchive(t, archive)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "connected with key "+redactedValue, files["status.txt"])
+	assert.Contains(t, files["firewall/iptables-save.txt.error"], "iptables not found")
+}
+
+func TestFormatDevice(t *testing.T) {
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peerKey, err := wgtypes.GenerateKey()
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, allowedIP, _ := net.ParseCIDR("100.64.0.2/32")
+	dev := &wgtypes.Device{
+		Name:       "wt0",
+		Type:       wgtypes.Userspace,
+		PrivateKey: key,
+		PublicKey:  key.PublicKey(),
+		ListenPort: 51820,
+		Peers: []wgtypes.Peer{{
+			PublicKey:         peerKey,
+			Endpoint:          &net.UDPAddr{IP: net.ParseIP("192.168.1.2"), Port: 51820},
+			AllowedIPs:        []net.IPNet{*allowedIP},
+			LastHandshakeTime: now.Add(-time.Minute),
+			ReceiveBytes:      10,
+			TransmitBytes:     20,
+		}},
+	}
+
+	out := FormatDevice(dev, now)
+
+	assert.NotContains(t, out, key.String(), "private key should not be printed")
+	assert.Contains(t, out, "interface: wt0")
+	assert.Contains(t, out, "peer: "+peerKey.String())
+	assert.Contains(t, out, "endpoint: 192.168.1.2:51820")
+	assert.Contains(t, out, "allowed ips: 100.64.0.2/32")
+	assert.Contains(t, out, "(1m0s ago)")
+	assert.Contains(t, out, "transfer: 10 B received, 20 B sent")
+}
+
+func readArchive(t *testing.T, archive []byte) map[string]string {
+	t.Helper()
+
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		_ = rc.Close()
+		files[f.Name] = strings.TrimSpace(string(content))
+	}
+	return files
+}
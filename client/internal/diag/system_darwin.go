@@ -0,0 +1,14 @@
+//go:build !ios
+
+package diag
+
+// SystemSections returns the sections listing the firewall, the DNS and the network configuration of the host
+func SystemSections() []Section {
+	return []Section{
+		CommandSection("firewall/pf-rules.txt", "pfctl", "-a", "*", "-s", "rules"),
+		CommandSection("dns/scutil-dns.txt", "scutil", "--dns"),
+		FileTailSection("dns/resolv.conf", "/etc/resolv.conf", maxConfigFileSize),
+		CommandSection("network/interfaces.txt", "ifconfig"),
+		CommandSection("network/routes.txt", "netstat", "-rn"),
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !android
+
+package diag
+
+// SystemSections returns the sections listing the firewall, the DNS and the network configuration of the host
+func SystemSections() []Section {
+	return []Section{
+		CommandSection("firewall/iptables-save.txt", "iptables-save"),
+		CommandSection("firewall/ip6tables-save.txt", "ip6tables-save"),
+		CommandSection("firewall/nft-ruleset.txt", "nft", "list", "ruleset"),
+		FileTailSection("dns/resolv.conf", "/etc/resolv.conf", maxConfigFileSize),
+		CommandSection("dns/resolvectl-status.txt", "resolvectl", "status"),
+		CommandSection("network/addresses.txt", "ip", "address", "show"),
+		CommandSection("network/routes.txt", "ip", "route", "show", "table", "all"),
+		CommandSection("network/rules.txt", "ip", "rule", "show"),
+	}
+}
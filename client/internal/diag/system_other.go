@@ -0,0 +1,8 @@
+//go:build android || ios || !(linux || darwin || windows)
+
+package diag
+
+// SystemSections returns no sections, the host configuration isn't available on this platform
+func SystemSections() []Section {
+	return nil
+}
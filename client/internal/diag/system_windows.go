@@ -0,0 +1,11 @@
+package diag
+
+// SystemSections returns the sections listing the firewall, the DNS and the network configuration of the host
+func SystemSections() []Section {
+	return []Section{
+		CommandSection("firewall/netsh-rules.txt", "netsh", "advfirewall", "firewall", "show", "rule", "name=all"),
+		CommandSection("dns/dns-servers.txt", "netsh", "interface", "ip", "show", "dnsservers"),
+		CommandSection("network/ipconfig.txt", "ipconfig", "/all"),
+		CommandSection("network/routes.txt", "route", "print"),
+	}
+}
@@ -0,0 +1,47 @@
+package diag
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// FormatDevice formats the state of the WireGuard interface like wg show does. The private and preshared keys
+// are never printed.
+func FormatDevice(dev *wgtypes.Device, now time.Time) string {
+	lines := []string{
+		fmt.Sprintf("interface: %s", dev.Name),
+		fmt.Sprintf("  type: %s", dev.Type),
+		fmt.Sprintf("  public key: %s", dev.PublicKey),
+		fmt.Sprintf("  listening port: %d", dev.ListenPort),
+	}
+	if dev.FirewallMark != 0 {
+		lines = append(lines, fmt.Sprintf("  fwmark: 0x%x", dev.FirewallMark))
+	}
+
+	for _, peer := range dev.Peers {
+		lines = append(lines, "", fmt.Sprintf("peer: %s", peer.PublicKey))
+		if peer.Endpoint != nil {
+			lines = append(lines, fmt.Sprintf("  endpoint: %s", peer.Endpoint))
+		}
+
+		allowedIPs := make([]string, 0, len(peer.AllowedIPs))
+		for _, allowedIP := range peer.AllowedIPs {
+			allowedIPs = append(allowedIPs, allowedIP.String())
+		}
+		lines = append(lines, fmt.Sprintf("  allowed ips: %s", strings.Join(allowedIPs, ", ")))
+
+		if !peer.LastHandshakeTime.IsZero() {
+			lines = append(lines, fmt.Sprintf("  latest handshake: %s (%s ago)",
+				peer.LastHandshakeTime.UTC().Format(time.RFC3339), now.Sub(peer.LastHandshakeTime).Round(time.Second)))
+		}
+		lines = append(lines, fmt.Sprintf("  transfer: %d B received, %d B sent", peer.ReceiveBytes, peer.TransmitBytes))
+		if peer.PersistentKeepaliveInterval != 0 {
+			lines = append(lines, fmt.Sprintf("  persistent keepalive: every %s", peer.PersistentKeepaliveInterval))
+		}
+	}
+
+	return joinLines(lines)
+}
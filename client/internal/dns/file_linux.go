@@ -26,11 +26,20 @@ const (
 type fileConfigurator struct {
 	repair *repair
 
-	originalPerms os.FileMode
+	originalPerms       os.FileMode
+	originalNameServers []string
 }
 
 func newFileConfigurator() (hostManager, error) {
-	fc := &fileConfigurator{}
+	// the backup keeps the original file while it is managed by NetBird, e.g. after an unclean shutdown
+	nameServersFile := defaultResolvConfPath
+	if _, err := os.Stat(fileDefaultResolvConfBackupLocation); err == nil {
+		nameServersFile = fileDefaultResolvConfBackupLocation
+	}
+
+	fc := &fileConfigurator{
+		originalNameServers: readNameServers(nameServersFile),
+	}
 	fc.repair = newRepair(defaultResolvConfPath, fc.updateConfig)
 	return fc, nil
 }
@@ -110,6 +119,10 @@ func (f *fileConfigurator) updateConfig(nbSearchDomains []string, nbNameserverIP
 	return nil
 }
 
+func (f *fileConfigurator) hostNameServers() []string {
+	return f.originalNameServers
+}
+
 func (f *fileConfigurator) restoreHostDNS() error {
 	f.repair.stopWatchFileChanges()
 	return f.restore()
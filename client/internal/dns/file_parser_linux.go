@@ -12,6 +12,8 @@ import (
 
 const (
 	defaultResolvConfPath = "/etc/resolv.conf"
+	// systemdResolvConfPath lists the uplink nameservers of systemd-resolved, bypassing its local stub resolver
+	systemdResolvConfPath = "/run/systemd/resolve/resolv.conf"
 )
 
 type resolvConf struct {
@@ -32,6 +34,17 @@ func parseBackupResolvConf() (*resolvConf, error) {
 	return parseResolvConfFile(fileDefaultResolvConfBackupLocation)
 }
 
+// readNameServers returns the nameservers of the resolv.conf file. The errors are logged only, as the nameservers
+// are used for the domains excluded from the NetBird resolver only
+func readNameServers(resolvConfFile string) []string {
+	rconf, err := parseResolvConfFile(resolvConfFile)
+	if err != nil {
+		log.Warnf("could not read the host nameservers from %s: %s", resolvConfFile, err)
+		return nil
+	}
+	return rconf.nameServers
+}
+
 func parseResolvConfFile(resolvConfFile string) (*resolvConf, error) {
 	rconf := &resolvConf{
 		searchDomains: make([]string, 0),
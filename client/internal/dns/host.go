@@ -13,6 +13,8 @@ type hostManager interface {
 	restoreHostDNS() error
 	supportCustomPort() bool
 	restoreUncleanShutdownDNS(storedDNSAddress *netip.Addr) error
+	// hostNameServers returns the nameservers the host used before NetBird changed its DNS configuration
+	hostNameServers() []string
 }
 
 type HostDNSConfig struct {
@@ -33,6 +35,7 @@ type mockHostConfigurator struct {
 	restoreHostDNSFunc            func() error
 	supportCustomPortFunc         func() bool
 	restoreUncleanShutdownDNSFunc func(*netip.Addr) error
+	hostNameServersFunc           func() []string
 }
 
 func (m *mockHostConfigurator) applyDNSConfig(config HostDNSConfig) error {
@@ -63,6 +66,13 @@ func (m *mockHostConfigurator) restoreUncleanShutdownDNS(storedDNSAddress *netip
 	return fmt.Errorf("method restoreUncleanShutdownDNS is not implemented")
 }
 
+func (m *mockHostConfigurator) hostNameServers() []string {
+	if m.hostNameServersFunc != nil {
+		return m.hostNameServersFunc()
+	}
+	return nil
+}
+
 func newNoopHostMocker() hostManager {
 	return &mockHostConfigurator{
 		applyDNSConfigFunc:            func(config HostDNSConfig) error { return nil },
@@ -89,7 +99,7 @@ func dnsConfigToHostDNSConfig(dnsConfig nbdns.Config, ip string, port int) HostD
 		for _, domain := range nsConfig.Domains {
 			config.Domains = append(config.Domains, DomainConfig{
 				Domain:    strings.TrimSuffix(domain, "."),
-				MatchOnly: !nsConfig.SearchDomainsEnabled,
+				MatchOnly: nsConfig.MatchOnly(),
 			})
 		}
 	}
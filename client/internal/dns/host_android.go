@@ -24,3 +24,8 @@ func (a androidHostManager) supportCustomPort() bool {
 func (a androidHostManager) restoreUncleanShutdownDNS(*netip.Addr) error {
 	return nil
 }
+
+// hostNameServers returns nil, the host nameservers are provided by the Android app instead
+func (a androidHostManager) hostNameServers() []string {
+	return nil
+}
@@ -18,6 +18,7 @@ import (
 const (
 	netbirdDNSStateKeyFormat            = "State:/Network/Service/NetBird-%s/DNS"
 	globalIPv4State                     = "State:/Network/Global/IPv4"
+	globalDNSState                      = "State:/Network/Global/DNS"
 	primaryServiceSetupKeyFormat        = "Setup:/Network/Service/%s/DNS"
 	keySupplementalMatchDomains         = "SupplementalMatchDomains"
 	keySupplementalMatchDomainsNoSearch = "SupplementalMatchDomainsNoSearch"
@@ -32,13 +33,20 @@ const (
 
 type systemConfigurator struct {
 	// primaryServiceID primary interface in the system. AKA the interface with the default route
-	primaryServiceID string
-	createdKeys      map[string]struct{}
+	primaryServiceID    string
+	createdKeys         map[string]struct{}
+	originalNameServers []string
 }
 
 func newHostManager() (hostManager, error) {
+	nameServers, err := getGlobalNameServers()
+	if err != nil {
+		log.Warnf("could not read the host nameservers: %s", err)
+	}
+
 	return &systemConfigurator{
-		createdKeys: make(map[string]struct{}),
+		createdKeys:         make(map[string]struct{}),
+		originalNameServers: nameServers,
 	}, nil
 }
 
@@ -252,6 +260,56 @@ func (s *systemConfigurator) addDNSSetup(setupKey, dnsServer string, port int, e
 	return nil
 }
 
+func (s *systemConfigurator) hostNameServers() []string {
+	return s.originalNameServers
+}
+
+// getGlobalNameServers returns the nameservers of the global DNS state, i.e. the resolvers the system is using
+func getGlobalNameServers() ([]string, error) {
+	line := buildCommandLine("show", globalDNSState, "")
+	stdinCommands := wrapCommand(line)
+
+	b, err := runSystemConfigCommand(stdinCommands)
+	if err != nil {
+		return nil, fmt.Errorf("sending the command: %w", err)
+	}
+
+	return parseServerAddresses(b)
+}
+
+// parseServerAddresses parses the ServerAddresses array of the scutil output, e.g.
+//
+//	ServerAddresses : <array> {
+//	  0 : 192.168.1.1
+//	}
+func parseServerAddresses(output []byte) ([]string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	var nameServers []string
+	inServerAddresses := false
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(text, keyServerAddresses):
+			inServerAddresses = true
+		case inServerAddresses && text == "}":
+			inServerAddresses = false
+		case inServerAddresses:
+			fields := strings.SplitN(text, ":", 2)
+			if len(fields) != 2 {
+				continue
+			}
+			if addr, err := netip.ParseAddr(strings.TrimSpace(fields[1])); err == nil {
+				nameServers = append(nameServers, addr.String())
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		return nameServers, fmt.Errorf("scan: %w", err)
+	}
+
+	return nameServers, nil
+}
+
 func (s *systemConfigurator) restoreUncleanShutdownDNS(*netip.Addr) error {
 	if err := s.restoreHostDNS(); err != nil {
 		return fmt.Errorf("restoring dns via scutil: %w", err)
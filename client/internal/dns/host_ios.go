@@ -41,3 +41,7 @@ func (a iosHostManager) supportCustomPort() bool {
 func (a iosHostManager) restoreUncleanShutdownDNS(*netip.Addr) error {
 	return nil
 }
+
+func (a iosHostManager) hostNameServers() []string {
+	return nil
+}
@@ -23,12 +23,15 @@ const (
 const (
 	interfaceConfigPath          = `SYSTEM\CurrentControlSet\Services\Tcpip\Parameters\Interfaces`
 	interfaceConfigNameServerKey = "NameServer"
-	interfaceConfigSearchListKey = "SearchList"
+	// interfaceConfigDhcpNameServerKey holds the nameservers assigned by DHCP, the static NameServer takes precedence
+	interfaceConfigDhcpNameServerKey = "DhcpNameServer"
+	interfaceConfigSearchListKey     = "SearchList"
 )
 
 type registryConfigurator struct {
-	guid       string
-	routingAll bool
+	guid                string
+	routingAll          bool
+	originalNameServers []string
 }
 
 func newHostManager(wgInterface WGIface) (hostManager, error) {
@@ -40,8 +43,14 @@ func newHostManager(wgInterface WGIface) (hostManager, error) {
 }
 
 func newHostManagerWithGuid(guid string) (hostManager, error) {
+	nameServers, err := getInterfacesNameServers(guid)
+	if err != nil {
+		log.Warnf("could not read the host nameservers: %s", err)
+	}
+
 	return &registryConfigurator{
-		guid: guid,
+		guid:                guid,
+		originalNameServers: nameServers,
 	}, nil
 }
 
@@ -228,6 +237,66 @@ func (r *registryConfigurator) restoreUncleanShutdownDNS(*netip.Addr) error {
 	return nil
 }
 
+func (r *registryConfigurator) hostNameServers() []string {
+	return r.originalNameServers
+}
+
+// getInterfacesNameServers returns the nameservers configured on the interfaces other than the NetBird one
+func getInterfacesNameServers(excludedGUID string) ([]string, error) {
+	interfacesKey, err := registry.OpenKey(registry.LOCAL_MACHINE, interfaceConfigPath, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the interfaces registry key, key: HKEY_LOCAL_MACHINE\\%s, error: %w", interfaceConfigPath, err)
+	}
+	defer closer(interfacesKey)
+
+	guids, err := interfacesKey.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the interfaces registry key names: %w", err)
+	}
+
+	var nameServers []string
+	seen := make(map[string]struct{})
+	for _, guid := range guids {
+		if strings.EqualFold(guid, excludedGUID) {
+			continue
+		}
+
+		for _, nameServer := range readInterfaceNameServers(guid) {
+			if _, ok := seen[nameServer]; ok {
+				continue
+			}
+			seen[nameServer] = struct{}{}
+			nameServers = append(nameServers, nameServer)
+		}
+	}
+
+	return nameServers, nil
+}
+
+func readInterfaceNameServers(guid string) []string {
+	regKey, err := registry.OpenKey(registry.LOCAL_MACHINE, interfaceConfigPath+"\\"+guid, registry.QUERY_VALUE)
+	if err != nil {
+		return nil
+	}
+	defer closer(regKey)
+
+	for _, valueName := range []string{interfaceConfigNameServerKey, interfaceConfigDhcpNameServerKey} {
+		value, _, err := regKey.GetStringValue(valueName)
+		if err != nil || strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		var nameServers []string
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if addr, err := netip.ParseAddr(field); err == nil {
+				nameServers = append(nameServers, addr.String())
+			}
+		}
+		return nameServers
+	}
+	return nil
+}
+
 func removeRegistryKeyFromDNSPolicyConfig(regKeyPath string) error {
 	k, err := registry.OpenKey(registry.LOCAL_MACHINE, regKeyPath, registry.QUERY_VALUE)
 	if err == nil {
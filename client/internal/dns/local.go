@@ -2,6 +2,7 @@ package dns
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/miekg/dns"
@@ -39,14 +40,38 @@ func (d *localResolver) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	}
 }
 
+// lookupRecord returns the record of the question name, or the record of the most specific wildcard
+// matching it when no record with the exact name exists
 func (d *localResolver) lookupRecord(r *dns.Msg) dns.RR {
 	question := r.Question[0]
 	record, found := d.records.Load(buildRecordKey(question.Name, question.Qclass, question.Qtype))
-	if !found {
-		return nil
+	if found {
+		return record.(dns.RR)
 	}
 
-	return record.(dns.RR)
+	for _, wildcard := range wildcardNames(question.Name) {
+		record, found = d.records.Load(buildRecordKey(wildcard, question.Qclass, question.Qtype))
+		if !found {
+			continue
+		}
+		// the answer has the name of the question, not of the wildcard
+		answer := dns.Copy(record.(dns.RR))
+		answer.Header().Name = question.Name
+		return answer
+	}
+
+	return nil
+}
+
+// wildcardNames returns the wildcard names matching the name, the most specific first,
+// e.g. *.b.svc.internal., *.svc.internal. and *.internal. for a.b.svc.internal.
+func wildcardNames(name string) []string {
+	labels := dns.SplitDomainName(dns.CanonicalName(name))
+	wildcards := make([]string, 0, len(labels))
+	for i := 1; i < len(labels); i++ {
+		wildcards = append(wildcards, dns.Fqdn(strings.Join(append([]string{"*"}, labels[i:]...), ".")))
+	}
+	return wildcards
 }
 
 func (d *localResolver) registerRecord(record nbdns.SimpleRecord) error {
@@ -64,11 +89,13 @@ func (d *localResolver) registerRecord(record nbdns.SimpleRecord) error {
 }
 
 func (d *localResolver) deleteRecord(recordKey string) {
-	d.records.Delete(dns.Fqdn(recordKey))
+	d.records.Delete(recordKey)
 }
 
+// buildRecordKey returns the key of the record of the name, class and type. The name is canonicalized,
+// as the DNS names are case-insensitive, and a wildcard name is kept as is, e.g. *.svc.internal.
 func buildRecordKey(name string, class, qType uint16) string {
-	key := fmt.Sprintf("%s_%d_%d", name, class, qType)
+	key := fmt.Sprintf("%s_%d_%d", dns.CanonicalName(name), class, qType)
 	return key
 }
 
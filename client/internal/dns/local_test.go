@@ -86,3 +86,129 @@ func TestLocalResolver_ServeDNS(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalResolver_ServeDNSWildcard(t *testing.T) {
+	resolver := &localResolver{
+		registeredMap: make(registrationMap),
+	}
+	records := []nbdns.SimpleRecord{
+		{Name: "*.svc.internal.", Type: 1, Class: nbdns.DefaultClass, TTL: 300, RData: "10.0.0.1"},
+		{Name: "*.db.svc.internal.", Type: 1, Class: nbdns.DefaultClass, TTL: 300, RData: "10.0.0.2"},
+		{Name: "api.svc.internal.", Type: 1, Class: nbdns.DefaultClass, TTL: 300, RData: "10.0.0.3"},
+	}
+	for _, record := range records {
+		if err := resolver.registerRecord(record); err != nil {
+			t.Fatalf("failed registering record %s: %v", record.String(), err)
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		question         string
+		questionType     uint16
+		expectedResponse string
+	}{
+		{
+			name:             "Should Resolve Wildcard",
+			question:         "web.svc.internal.",
+			questionType:     dns.TypeA,
+			expectedResponse: "10.0.0.1",
+		},
+		{
+			name:             "Should Resolve Wildcard For Nested Names",
+			question:         "a.b.svc.internal.",
+			questionType:     dns.TypeA,
+			expectedResponse: "10.0.0.1",
+		},
+		{
+			name:             "Should Prefer The Most Specific Wildcard",
+			question:         "main.db.svc.internal.",
+			questionType:     dns.TypeA,
+			expectedResponse: "10.0.0.2",
+		},
+		{
+			name:             "Should Prefer Exact Record Over Wildcard",
+			question:         "api.svc.internal.",
+			questionType:     dns.TypeA,
+			expectedResponse: "10.0.0.3",
+		},
+		{
+			name:             "Should Match Names Case-Insensitively",
+			question:         "Web.SVC.internal.",
+			questionType:     dns.TypeA,
+			expectedResponse: "10.0.0.1",
+		},
+		{
+			name:         "Should Not Match The Wildcard Parent",
+			question:     "svc.internal.",
+			questionType: dns.TypeA,
+		},
+		{
+			name:         "Should Not Match Other Types",
+			question:     "web.svc.internal.",
+			questionType: dns.TypeAAAA,
+		},
+		{
+			name:         "Should Not Match Other Domains",
+			question:     "web.svc.example.",
+			questionType: dns.TypeA,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var responseMSG *dns.Msg
+			responseWriter := &mockResponseWriter{
+				WriteMsgFunc: func(m *dns.Msg) error {
+					responseMSG = m
+					return nil
+				},
+			}
+
+			resolver.ServeDNS(responseWriter, new(dns.Msg).SetQuestion(testCase.question, testCase.questionType))
+
+			if responseMSG == nil {
+				t.Fatalf("should write a response message")
+			}
+			if testCase.expectedResponse == "" {
+				if len(responseMSG.Answer) != 0 {
+					t.Fatalf("should not answer, got %s", responseMSG.Answer[0].String())
+				}
+				if responseMSG.Rcode != dns.RcodeSuccess {
+					t.Fatalf("should reply with success, got %s", dns.RcodeToString[responseMSG.Rcode])
+				}
+				return
+			}
+
+			if len(responseMSG.Answer) != 1 {
+				t.Fatalf("should answer with one record, got %d", len(responseMSG.Answer))
+			}
+			answer, ok := responseMSG.Answer[0].(*dns.A)
+			if !ok {
+				t.Fatalf("should answer with an A record, got %s", responseMSG.Answer[0].String())
+			}
+			if answer.Hdr.Name != testCase.question {
+				t.Fatalf("answer should have the name of the question: \nWant: %s\nGot:%s", testCase.question, answer.Hdr.Name)
+			}
+			if answer.A.String() != testCase.expectedResponse {
+				t.Fatalf("answer doesn't contain the expected address: \nWant: %s\nGot:%s", testCase.expectedResponse, answer.A.String())
+			}
+		})
+	}
+}
+
+func TestLocalResolver_DeleteRecord(t *testing.T) {
+	resolver := &localResolver{
+		registeredMap: make(registrationMap),
+	}
+	record := nbdns.SimpleRecord{Name: "*.svc.internal", Type: 1, Class: nbdns.DefaultClass, TTL: 300, RData: "10.0.0.1"}
+	if err := resolver.registerRecord(record); err != nil {
+		t.Fatalf("failed registering record %s: %v", record.String(), err)
+	}
+
+	resolver.deleteRecord(buildRecordKey(record.Name, dns.ClassINET, dns.TypeA))
+
+	if rr := resolver.lookupRecord(new(dns.Msg).SetQuestion("web.svc.internal.", dns.TypeA)); rr != nil {
+		t.Fatalf("deleted record should not be resolved, got %s", rr.String())
+	}
+}
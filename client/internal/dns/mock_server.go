@@ -3,6 +3,7 @@ package dns
 import (
 	"fmt"
 
+	"github.com/netbirdio/netbird/client/internal/peer"
 	nbdns "github.com/netbirdio/netbird/dns"
 )
 
@@ -52,3 +53,13 @@ func (m *MockServer) SearchDomains() []string {
 // ProbeAvailability mocks implementation of ProbeAvailability from the Server interface
 func (m *MockServer) ProbeAvailability() {
 }
+
+// QueryStats mocks implementation of QueryStats from the Server interface
+func (m *MockServer) QueryStats() peer.DNSStats {
+	return peer.DNSStats{}
+}
+
+// ResolverState mocks implementation of ResolverState from the Server interface
+func (m *MockServer) ResolverState() ResolverState {
+	return ResolverState{}
+}
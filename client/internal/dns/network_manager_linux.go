@@ -51,8 +51,9 @@ var supportedNetworkManagerVersionConstraints = []string{
 }
 
 type networkManagerDbusConfigurator struct {
-	dbusLinkObject dbus.ObjectPath
-	routingAll     bool
+	dbusLinkObject      dbus.ObjectPath
+	routingAll          bool
+	originalNameServers []string
 }
 
 // the types below are based on dbus specification, each field is mapped to a dbus type
@@ -92,7 +93,8 @@ func newNetworkManagerDbusConfigurator(wgInterface string) (hostManager, error)
 	log.Debugf("got network manager dbus Link Object: %s from net interface %s", s, wgInterface)
 
 	return &networkManagerDbusConfigurator{
-		dbusLinkObject: dbus.ObjectPath(s),
+		dbusLinkObject:      dbus.ObjectPath(s),
+		originalNameServers: readNameServers(defaultResolvConfPath),
 	}, nil
 }
 
@@ -245,6 +247,10 @@ func (n *networkManagerDbusConfigurator) deleteConnectionSettings() error {
 	return nil
 }
 
+func (n *networkManagerDbusConfigurator) hostNameServers() []string {
+	return n.originalNameServers
+}
+
 func (n *networkManagerDbusConfigurator) restoreUncleanShutdownDNS(*netip.Addr) error {
 	if err := n.restoreHostDNS(); err != nil {
 		return fmt.Errorf("restoring dns via network-manager: %w", err)
@@ -0,0 +1,178 @@
+package dns
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+)
+
+const (
+	// maxTrackedDomains caps the per-domain counters, queries for new domains above the limit are not tracked
+	maxTrackedDomains = 1000
+
+	handlerKindLocal    = "local"
+	handlerKindUpstream = "upstream"
+)
+
+// queryStats collects counters of the queries handled by the registered handlers and, when enabled,
+// logs every query. It is shared by all handlers and may be used concurrently by both the listener
+// and the packet filter hook of the userspace service.
+type queryStats struct {
+	logQueries       func() bool
+	queries          atomic.Uint64
+	localRecordHits  atomic.Uint64
+	upstreamFailures atomic.Uint64
+	// upstreamResponses and upstreamLatency measure the latency of the queries answered by the upstream servers
+	upstreamResponses atomic.Uint64
+	upstreamLatency   atomic.Int64
+
+	domainsMu     sync.Mutex
+	domainQueries map[string]uint64
+}
+
+func newQueryStats(logQueries func() bool) *queryStats {
+	return &queryStats{
+		logQueries:    logQueries,
+		domainQueries: make(map[string]uint64),
+	}
+}
+
+// isQueryLogEnabled returns true if the client runs with the trace log level, e.g. with --log-level trace.
+// The level is checked for every query, so the logging follows the level changes at runtime.
+// Queries are not logged at the lower levels because the names reveal the browsing activity of the peer's users.
+func isQueryLogEnabled() bool {
+	return log.IsLevelEnabled(log.TraceLevel)
+}
+
+// wrap returns a handler that serves the queries with the given handler and records them
+func (q *queryStats) wrap(pattern string, handler dns.Handler) dns.Handler {
+	kind := handlerKindUpstream
+	if _, ok := handler.(*localResolver); ok {
+		kind = handlerKindLocal
+	}
+
+	return &recordingHandler{
+		pattern: pattern,
+		kind:    kind,
+		handler: handler,
+		stats:   q,
+	}
+}
+
+// snapshot returns a copy of the current counters
+func (q *queryStats) snapshot() peer.DNSStats {
+	stats := peer.DNSStats{
+		Queries:           q.queries.Load(),
+		LocalRecordHits:   q.localRecordHits.Load(),
+		UpstreamFailures:  q.upstreamFailures.Load(),
+		UpstreamResponses: q.upstreamResponses.Load(),
+		UpstreamLatency:   time.Duration(q.upstreamLatency.Load()),
+	}
+
+	q.domainsMu.Lock()
+	defer q.domainsMu.Unlock()
+
+	if len(q.domainQueries) > 0 {
+		stats.DomainQueries = make(map[string]uint64, len(q.domainQueries))
+		for domain, count := range q.domainQueries {
+			stats.DomainQueries[domain] = count
+		}
+	}
+
+	return stats
+}
+
+func (q *queryStats) record(h *recordingHandler, r *dns.Msg, w *recordingResponseWriter, duration time.Duration) {
+	q.queries.Add(1)
+
+	switch {
+	case h.kind == handlerKindLocal && w.msg != nil && len(w.msg.Answer) > 0:
+		q.localRecordHits.Add(1)
+	case h.kind == handlerKindUpstream && w.msg == nil:
+		// the upstream resolver doesn't write a response when all its nameservers failed
+		q.upstreamFailures.Add(1)
+	case h.kind == handlerKindUpstream:
+		q.upstreamResponses.Add(1)
+		q.upstreamLatency.Add(int64(duration))
+	}
+
+	if !q.logQueries() || len(r.Question) == 0 {
+		return
+	}
+
+	question := r.Question[0]
+	q.countDomain(strings.ToLower(question.Name))
+
+	rcode := "NO_RESPONSE"
+	if w.msg != nil {
+		rcode = dns.RcodeToString[w.msg.Rcode]
+	}
+
+	fields := log.Fields{
+		"qname":    question.Name,
+		"qtype":    dns.TypeToString[question.Qtype],
+		"handler":  h.kind,
+		"zone":     h.pattern,
+		"rcode":    rcode,
+		"duration": duration,
+	}
+	if w.upstream != "" {
+		fields["upstream"] = w.upstream
+	}
+	log.WithFields(fields).Info("served DNS query")
+}
+
+func (q *queryStats) countDomain(domain string) {
+	q.domainsMu.Lock()
+	defer q.domainsMu.Unlock()
+
+	if _, ok := q.domainQueries[domain]; !ok && len(q.domainQueries) >= maxTrackedDomains {
+		return
+	}
+	q.domainQueries[domain]++
+}
+
+// recordingHandler serves the queries with the wrapped handler and records them in the query stats
+type recordingHandler struct {
+	pattern string
+	kind    string
+	handler dns.Handler
+	stats   *queryStats
+}
+
+// ServeDNS handles a DNS request
+func (h *recordingHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	start := time.Now()
+	writer := &recordingResponseWriter{ResponseWriter: w}
+	h.handler.ServeDNS(writer, r)
+	h.stats.record(h, r, writer, time.Since(start))
+}
+
+// recordingResponseWriter keeps the response written by a handler and the upstream that provided it
+type recordingResponseWriter struct {
+	dns.ResponseWriter
+	msg      *dns.Msg
+	upstream string
+}
+
+// WriteMsg writes a reply back to the client
+func (w *recordingResponseWriter) WriteMsg(msg *dns.Msg) error {
+	w.msg = msg
+	return w.ResponseWriter.WriteMsg(msg)
+}
+
+// setUpstream records the upstream nameserver that answered the query
+func (w *recordingResponseWriter) setUpstream(upstream string) {
+	w.upstream = upstream
+}
+
+// upstreamRecorder is implemented by response writers interested in the upstream answering a query
+type upstreamRecorder interface {
+	setUpstream(upstream string)
+}
@@ -0,0 +1,135 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+)
+
+func TestQueryStats(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		logQueries               bool
+		expectedDomainQueries    map[string]uint64
+		expectedQueries          uint64
+		expectedLocalRecordHits  uint64
+		expectedUpstreamFailures uint64
+	}{
+		{
+			name:                     "Domains Are Not Counted When Logging Is Disabled",
+			expectedQueries:          4,
+			expectedLocalRecordHits:  1,
+			expectedUpstreamFailures: 1,
+		},
+		{
+			name:       "Domains Are Counted When Logging Is Enabled",
+			logQueries: true,
+			expectedDomainQueries: map[string]uint64{
+				"peera.netbird.cloud.": 2,
+				"example.com.":         2,
+			},
+			expectedQueries:          4,
+			expectedLocalRecordHits:  1,
+			expectedUpstreamFailures: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			stats := newQueryStats(func() bool { return testCase.logQueries })
+
+			resolver := &localResolver{registeredMap: make(registrationMap)}
+			err := resolver.registerRecord(nbdns.SimpleRecord{
+				Name:  "peera.netbird.cloud.",
+				Type:  1,
+				Class: nbdns.DefaultClass,
+				TTL:   300,
+				RData: "1.2.3.4",
+			})
+			if err != nil {
+				t.Fatalf("register record: %v", err)
+			}
+			local := stats.wrap("netbird.cloud", resolver)
+
+			answer := new(dns.Msg)
+			answer.Response = true
+			succeeding := newUpstreamResolverBase(context.Background())
+			succeeding.upstreamServers = []string{"8.8.8.8:53"}
+			succeeding.upstreamClient = &mockUpstreamResolver{r: answer}
+			failing := newUpstreamResolverBase(context.Background())
+			failing.upstreamServers = []string{"8.8.4.4:53"}
+			failing.upstreamClient = &mockUpstreamResolver{err: fmt.Errorf("connection refused")}
+			failing.deactivate = func() {}
+
+			writer := &mockResponseWriter{}
+			local.ServeDNS(writer, new(dns.Msg).SetQuestion("peera.netbird.cloud.", dns.TypeA))
+			local.ServeDNS(writer, new(dns.Msg).SetQuestion("PeerA.netbird.cloud.", dns.TypeAAAA))
+			stats.wrap(nbdns.RootZone, succeeding).ServeDNS(writer, new(dns.Msg).SetQuestion("example.com.", dns.TypeA))
+			stats.wrap(nbdns.RootZone, failing).ServeDNS(writer, new(dns.Msg).SetQuestion("example.com.", dns.TypeA))
+
+			snapshot := stats.snapshot()
+			if snapshot.Queries != testCase.expectedQueries {
+				t.Errorf("expected %d queries, got %d", testCase.expectedQueries, snapshot.Queries)
+			}
+			if snapshot.LocalRecordHits != testCase.expectedLocalRecordHits {
+				t.Errorf("expected %d local record hits, got %d", testCase.expectedLocalRecordHits, snapshot.LocalRecordHits)
+			}
+			if snapshot.UpstreamFailures != testCase.expectedUpstreamFailures {
+				t.Errorf("expected %d upstream failures, got %d", testCase.expectedUpstreamFailures, snapshot.UpstreamFailures)
+			}
+			if snapshot.UpstreamResponses != 1 {
+				t.Errorf("expected 1 upstream response, got %d", snapshot.UpstreamResponses)
+			}
+			if len(snapshot.DomainQueries) != len(testCase.expectedDomainQueries) {
+				t.Fatalf("expected domain queries %v, got %v", testCase.expectedDomainQueries, snapshot.DomainQueries)
+			}
+			for domain, count := range testCase.expectedDomainQueries {
+				if snapshot.DomainQueries[domain] != count {
+					t.Errorf("expected %d queries for %s, got %d", count, domain, snapshot.DomainQueries[domain])
+				}
+			}
+		})
+	}
+}
+
+func TestQueryStatsRecordsUpstream(t *testing.T) {
+	stats := newQueryStats(func() bool { return true })
+
+	answer := new(dns.Msg)
+	answer.Response = true
+	upstream := newUpstreamResolverBase(context.Background())
+	upstream.upstreamServers = []string{"8.8.8.8:53"}
+	upstream.upstreamClient = &mockUpstreamResolver{r: answer}
+
+	handler := stats.wrap(nbdns.RootZone, upstream).(*recordingHandler)
+	writer := &recordingResponseWriter{ResponseWriter: &mockResponseWriter{}}
+	handler.handler.ServeDNS(writer, new(dns.Msg).SetQuestion("example.com.", dns.TypeA))
+
+	if writer.upstream != "8.8.8.8:53" {
+		t.Errorf("expected the upstream answering the query to be recorded, got %q", writer.upstream)
+	}
+	if handler.kind != handlerKindUpstream {
+		t.Errorf("expected handler kind %s, got %s", handlerKindUpstream, handler.kind)
+	}
+}
+
+func TestQueryStatsTrackedDomainsLimit(t *testing.T) {
+	stats := newQueryStats(func() bool { return true })
+
+	for i := 0; i < maxTrackedDomains+10; i++ {
+		stats.countDomain(fmt.Sprintf("host%d.example.com.", i))
+	}
+	stats.countDomain("host0.example.com.")
+
+	snapshot := stats.snapshot()
+	if len(snapshot.DomainQueries) != maxTrackedDomains {
+		t.Errorf("expected %d tracked domains, got %d", maxTrackedDomains, len(snapshot.DomainQueries))
+	}
+	if snapshot.DomainQueries["host0.example.com."] != 2 {
+		t.Errorf("expected already tracked domains to keep being counted, got %d", snapshot.DomainQueries["host0.example.com."])
+	}
+}
@@ -98,6 +98,10 @@ func (r *resolvconf) applyConfig(content bytes.Buffer) error {
 	return nil
 }
 
+func (r *resolvconf) hostNameServers() []string {
+	return r.originalNameServers
+}
+
 func (r *resolvconf) restoreUncleanShutdownDNS(*netip.Addr) error {
 	if err := r.restoreHostDNS(); err != nil {
 		return fmt.Errorf("restoring dns for interface %s: %w", r.ifaceName, err)
@@ -15,6 +15,9 @@ type responseWriter struct {
 	remote net.Addr
 	packet gopacket.Packet
 	device tun.Device
+	// udpSize is the largest response the client accepts over UDP, the bigger
+	// responses are truncated with the TC bit set so the client retries over TCP
+	udpSize int
 }
 
 // LocalAddr returns the net.Addr of the server
@@ -29,6 +32,10 @@ func (r *responseWriter) RemoteAddr() net.Addr {
 
 // WriteMsg writes a reply back to the client.
 func (r *responseWriter) WriteMsg(msg *dns.Msg) error {
+	if r.udpSize > 0 {
+		msg.Truncate(r.udpSize)
+	}
+
 	buff, err := msg.Pack()
 	if err != nil {
 		return fmt.Errorf("pack: %w", err)
@@ -104,3 +111,11 @@ func (r *responseWriter) TsigTimersOnly(bool) {
 // After a call to Hijack(), the DNS package will not do anything with the connection.
 func (r *responseWriter) Hijack() {
 }
+
+// udpSizeOf returns the UDP payload size the client of the request accepts
+func udpSizeOf(request *dns.Msg) int {
+	if opt := request.IsEdns0(); opt != nil && int(opt.UDPSize()) > dns.MinMsgSize {
+		return int(opt.UDPSize())
+	}
+	return dns.MinMsgSize
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/netip"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/miekg/dns"
@@ -11,6 +13,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/netbirdio/netbird/client/internal/listener"
+	"github.com/netbirdio/netbird/client/internal/peer"
 	nbdns "github.com/netbirdio/netbird/dns"
 )
 
@@ -33,6 +36,22 @@ type Server interface {
 	OnUpdatedHostDNSServer(strings []string)
 	SearchDomains() []string
 	ProbeAvailability()
+	QueryStats() peer.DNSStats
+	ResolverState() ResolverState
+}
+
+// ResolverState describes the configuration of the local resolver and of the host DNS for the diagnostics
+type ResolverState struct {
+	// Serial of the network map the configuration was applied from
+	Serial uint64
+	// HostConfig is the configuration applied to the host DNS
+	HostConfig HostDNSConfig
+	// HostNameServers are the nameservers of the host captured before NetBird changed its DNS configuration
+	HostNameServers []string
+	// ExcludedDomains are resolved by the host nameservers
+	ExcludedDomains []string
+	// Handlers are the domains the resolver has handlers registered for
+	Handlers []string
 }
 
 type registeredHandlerMap map[string]handlerWithStop
@@ -50,6 +69,12 @@ type DefaultServer struct {
 	updateSerial       uint64
 	previousConfigHash uint64
 	currentConfig      HostDNSConfig
+	queryStats         *queryStats
+	// excludedDomains are resolved by the nameservers the host used before NetBird changed its DNS configuration
+	excludedDomains []string
+	// routeAllDisabled keeps the primary nameserver groups from routing all the DNS queries of the host, as set by the
+	// management for the operating system of the peer
+	routeAllDisabled bool
 
 	// permanent related properties
 	permanent        bool
@@ -72,8 +97,9 @@ type muxUpdate struct {
 	handler handlerWithStop
 }
 
-// NewDefaultServer returns a new dns server
-func NewDefaultServer(ctx context.Context, wgInterface WGIface, customAddress string) (*DefaultServer, error) {
+// NewDefaultServer returns a new dns server. The queries for the excluded domains and their subdomains are forwarded
+// to the nameservers of the host instead of the ones configured by the management service
+func NewDefaultServer(ctx context.Context, wgInterface WGIface, customAddress string, excludedDomains []string) (*DefaultServer, error) {
 	var addrPort *netip.AddrPort
 	if customAddress != "" {
 		parsedAddrPort, err := netip.ParseAddrPort(customAddress)
@@ -90,7 +116,9 @@ func NewDefaultServer(ctx context.Context, wgInterface WGIface, customAddress st
 		dnsService = newServiceViaListener(wgInterface, addrPort)
 	}
 
-	return newDefaultServer(ctx, wgInterface, dnsService), nil
+	ds := newDefaultServer(ctx, wgInterface, dnsService)
+	ds.excludedDomains = normalizeExcludedDomains(excludedDomains)
+	return ds, nil
 }
 
 // NewDefaultServerPermanentUpstream returns a new dns server. It optimized for mobile systems
@@ -125,6 +153,7 @@ func newDefaultServer(ctx context.Context, wgInterface WGIface, dnsService servi
 			registeredMap: make(registrationMap),
 		},
 		wgInterface: wgInterface,
+		queryStats:  newQueryStats(isQueryLogEnabled),
 	}
 
 	return defaultServer
@@ -261,6 +290,29 @@ func (s *DefaultServer) ProbeAvailability() {
 	}
 }
 
+// QueryStats returns the counters of the queries served by the resolver
+func (s *DefaultServer) QueryStats() peer.DNSStats {
+	return s.queryStats.snapshot()
+}
+
+// ResolverState returns the current configuration of the resolver
+func (s *DefaultServer) ResolverState() ResolverState {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	state := ResolverState{
+		Serial:          s.updateSerial,
+		HostConfig:      s.currentConfig,
+		HostNameServers: s.hostNameServers(),
+		ExcludedDomains: s.excludedDomains,
+	}
+	for domain := range s.dnsMuxMap {
+		state.Handlers = append(state.Handlers, domain)
+	}
+	sort.Strings(state.Handlers)
+	return state
+}
+
 func (s *DefaultServer) applyConfiguration(update nbdns.Config) error {
 	// is the service should be Disabled, we stop the listener or fake resolver
 	// and proceed with a regular update to clean up the handlers and records
@@ -279,11 +331,19 @@ func (s *DefaultServer) applyConfiguration(update nbdns.Config) error {
 		return fmt.Errorf("not applying dns update, error: %v", err)
 	}
 	muxUpdates := append(localMuxUpdates, upstreamMuxUpdates...) //nolint:gocritic
+	muxUpdates = s.excludeDomains(muxUpdates)
 
 	s.updateMux(muxUpdates)
 	s.updateLocalResolver(localRecords)
 	s.currentConfig = dnsConfigToHostDNSConfig(update, s.service.RuntimeIP(), s.service.RuntimePort())
 
+	s.routeAllDisabled = update.RouteAllDisabled
+	if s.routeAllDisabled && s.currentConfig.RouteAll {
+		log.Infof("the primary DNS setup is disabled for this operating system by the management, " +
+			"only the queries of the NetBird domains are routed to the NetBird resolver")
+		s.currentConfig.RouteAll = false
+	}
+
 	hostUpdate := s.currentConfig
 	if s.service.RuntimePort() != defaultPort && !s.hostManager.supportCustomPort() {
 		log.Warnf("the DNS manager of this peer doesn't support custom port. Disabling primary DNS setup. " +
@@ -323,6 +383,12 @@ func (s *DefaultServer) buildLocalHandlerUpdate(customZones []nbdns.CustomZone)
 				return nil, nil, fmt.Errorf("received an invalid class type: %s", record.Class)
 			}
 			key := buildRecordKey(record.Name, class, uint16(record.Type))
+			// the local resolver answers with a single record per name and type, the records of the DNS views are
+			// resolved by the management for the peer, so a duplicate is a stale or a conflicting record
+			if _, exists := localRecords[key]; exists {
+				log.Warnf("ignoring the duplicated DNS record %s", record.String())
+				continue
+			}
 			localRecords[key] = record
 		}
 	}
@@ -401,7 +467,7 @@ func (s *DefaultServer) updateMux(muxUpdates []muxUpdate) {
 	var isContainRootUpdate bool
 
 	for _, update := range muxUpdates {
-		s.service.RegisterMux(update.domain, update.handler)
+		s.registerMux(update.domain, update.handler)
 		muxUpdateMap[update.domain] = update.handler
 		if existingHandler, ok := s.dnsMuxMap[update.domain]; ok {
 			existingHandler.stop()
@@ -498,13 +564,13 @@ func (s *DefaultServer) upstreamCallbacks(
 				continue
 			}
 			s.currentConfig.Domains[i].Disabled = false
-			s.service.RegisterMux(domain, handler)
+			s.registerMux(domain, handler)
 		}
 
 		l := log.WithField("nameservers", nsGroup.NameServers)
 		l.Debug("reactivate temporary disabled nameserver group")
 
-		if nsGroup.Primary {
+		if nsGroup.Primary && !s.routeAllDisabled {
 			s.currentConfig.RouteAll = true
 		}
 		if err := s.hostManager.applyDNSConfig(s.currentConfig); err != nil {
@@ -515,13 +581,23 @@ func (s *DefaultServer) upstreamCallbacks(
 }
 
 func (s *DefaultServer) addHostRootZone() {
-	handler, err := newUpstreamResolver(s.ctx, s.wgInterface.Name(), s.wgInterface.Address().IP, s.wgInterface.Address().Network)
+	handler, err := s.newHostResolver(s.hostsDnsList)
 	if err != nil {
 		log.Errorf("unable to create a new upstream resolver, error: %v", err)
 		return
 	}
-	handler.upstreamServers = make([]string, len(s.hostsDnsList))
-	for n, ua := range s.hostsDnsList {
+	s.registerMux(nbdns.RootZone, handler)
+}
+
+// newHostResolver returns an upstream resolver forwarding the queries to the given nameservers of the host.
+// The resolver is never deactivated as there are no other nameservers to fall back to.
+func (s *DefaultServer) newHostResolver(nameServers []string) (handlerWithStop, error) {
+	handler, err := newUpstreamResolver(s.ctx, s.wgInterface.Name(), s.wgInterface.Address().IP, s.wgInterface.Address().Network)
+	if err != nil {
+		return nil, err
+	}
+	handler.upstreamServers = make([]string, len(nameServers))
+	for n, ua := range nameServers {
 		a, err := netip.ParseAddr(ua)
 		if err != nil {
 			log.Errorf("invalid upstream IP address: %s, error: %s", ua, err)
@@ -537,5 +613,78 @@ func (s *DefaultServer) addHostRootZone() {
 	}
 	handler.deactivate = func() {}
 	handler.reactivate = func() {}
-	s.service.RegisterMux(nbdns.RootZone, handler)
+	return handler, nil
+}
+
+// excludeDomains replaces the handlers of the excluded domains with resolvers forwarding the queries to the
+// nameservers the host used before NetBird changed its DNS configuration
+func (s *DefaultServer) excludeDomains(muxUpdates []muxUpdate) []muxUpdate {
+	if len(s.excludedDomains) == 0 {
+		return muxUpdates
+	}
+
+	nameServers := s.hostNameServers()
+	if len(nameServers) == 0 {
+		log.Warnf("not excluding the domains %v from the NetBird resolver, the host nameservers are unknown", s.excludedDomains)
+		return muxUpdates
+	}
+
+	excluded := make(map[string]struct{}, len(s.excludedDomains))
+	for _, domain := range s.excludedDomains {
+		excluded[domain] = struct{}{}
+	}
+
+	filtered := make([]muxUpdate, 0, len(muxUpdates)+len(s.excludedDomains))
+	for _, update := range muxUpdates {
+		if _, ok := excluded[strings.ToLower(strings.Trim(update.domain, "."))]; ok {
+			log.Debugf("domain %s is excluded from the NetBird resolver, ignoring its management configuration", update.domain)
+			continue
+		}
+		filtered = append(filtered, update)
+	}
+
+	for _, domain := range s.excludedDomains {
+		handler, err := s.newHostResolver(nameServers)
+		if err != nil {
+			log.Errorf("unable to create the resolver of the excluded domain %s, error: %v", domain, err)
+			continue
+		}
+		filtered = append(filtered, muxUpdate{
+			domain:  domain,
+			handler: handler,
+		})
+	}
+	return filtered
+}
+
+// hostNameServers returns the nameservers of the host captured before NetBird changed its DNS configuration.
+// On mobile the nameservers are provided by the app.
+func (s *DefaultServer) hostNameServers() []string {
+	if s.permanent {
+		s.hostsDnsListLock.Lock()
+		defer s.hostsDnsListLock.Unlock()
+		return s.hostsDnsList
+	}
+
+	if s.hostManager == nil {
+		return nil
+	}
+	return s.hostManager.hostNameServers()
+}
+
+func normalizeExcludedDomains(domains []string) []string {
+	var normalized []string
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+		if domain == "" {
+			continue
+		}
+		normalized = append(normalized, domain)
+	}
+	return normalized
+}
+
+// registerMux registers the handler for the domain in the dns service, recording the queries it serves
+func (s *DefaultServer) registerMux(domain string, handler dns.Handler) {
+	s.service.RegisterMux(domain, s.queryStats.wrap(domain, handler))
 }
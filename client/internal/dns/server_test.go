@@ -6,11 +6,13 @@ import (
 	"net"
 	"net/netip"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/miekg/dns"
 	log "github.com/sirupsen/logrus"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 
@@ -274,7 +276,7 @@ func TestUpdateDNSServer(t *testing.T) {
 					t.Log(err)
 				}
 			}()
-			dnsServer, err := NewDefaultServer(context.Background(), wgIface, "")
+			dnsServer, err := NewDefaultServer(context.Background(), wgIface, "", nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -367,7 +369,8 @@ func TestDNSFakeResolverHandleUpdates(t *testing.T) {
 	packetfilter := pfmock.NewMockPacketFilter(ctrl)
 	packetfilter.EXPECT().DropOutgoing(gomock.Any()).AnyTimes()
 	packetfilter.EXPECT().AddUDPPacketHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
-	packetfilter.EXPECT().RemovePacketHook(gomock.Any())
+	packetfilter.EXPECT().AddTCPPacketHook(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+	packetfilter.EXPECT().RemovePacketHook(gomock.Any()).Times(2)
 	packetfilter.EXPECT().SetNetwork(ipNet)
 
 	if err := wgIface.SetFilter(packetfilter); err != nil {
@@ -375,7 +378,7 @@ func TestDNSFakeResolverHandleUpdates(t *testing.T) {
 		return
 	}
 
-	dnsServer, err := NewDefaultServer(context.Background(), wgIface, "")
+	dnsServer, err := NewDefaultServer(context.Background(), wgIface, "", nil)
 	if err != nil {
 		t.Errorf("create DNS server: %v", err)
 		return
@@ -470,7 +473,7 @@ func TestDNSServerStartStop(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			dnsServer, err := NewDefaultServer(context.Background(), &mocWGIface{}, testCase.addrPort)
+			dnsServer, err := NewDefaultServer(context.Background(), &mocWGIface{}, testCase.addrPort, nil)
 			if err != nil {
 				t.Fatalf("%v", err)
 			}
@@ -534,6 +537,7 @@ func TestDNSServerUpstreamDeactivateCallback(t *testing.T) {
 			registeredMap: make(registrationMap),
 		},
 		hostManager: hostManager,
+		queryStats:  newQueryStats(isQueryLogEnabled),
 		currentConfig: HostDNSConfig{
 			Domains: []DomainConfig{
 				{false, "domain0", false},
@@ -777,6 +781,142 @@ func TestDNSPermanent_matchOnly(t *testing.T) {
 	}
 }
 
+// mocNamedWGIface is a mocWGIface with a name, for the tests creating upstream resolvers
+type mocNamedWGIface struct {
+	mocWGIface
+	name string
+}
+
+func (w *mocNamedWGIface) Name() string {
+	return w.name
+}
+
+type mockMuxService struct {
+	mux *dns.ServeMux
+}
+
+func (m *mockMuxService) Listen() error { return nil }
+
+func (m *mockMuxService) Stop() {}
+
+func (m *mockMuxService) RegisterMux(domain string, handler dns.Handler) {
+	m.mux.Handle(domain, handler)
+}
+
+func (m *mockMuxService) DeregisterMux(key string) {
+	m.mux.HandleRemove(key)
+}
+
+func (m *mockMuxService) RuntimePort() int { return defaultPort }
+
+func (m *mockMuxService) RuntimeIP() string { return "100.66.100.254" }
+
+func TestDNSServerMatchOnlyDomains(t *testing.T) {
+	nameServers := []nbdns.NameServer{
+		{
+			IP:     netip.MustParseAddr("8.8.8.8"),
+			NSType: nbdns.UDPNameServerType,
+			Port:   53,
+		},
+	}
+
+	svc := &mockMuxService{mux: dns.NewServeMux()}
+	dnsServer := newDefaultServer(context.Background(), &mocNamedWGIface{name: "utun2301"}, svc)
+	dnsServer.hostManager = newNoopHostMocker()
+	defer dnsServer.Stop()
+
+	update := nbdns.Config{
+		ServiceEnable: true,
+		NameServerGroups: []*nbdns.NameServerGroup{
+			{
+				NameServers: nameServers,
+				Domains:     []string{"match.example"},
+			},
+			{
+				NameServers:          nameServers,
+				Domains:              []string{"search.example"},
+				SearchDomainsEnabled: true,
+			},
+		},
+	}
+
+	if !update.NameServerGroups[0].MatchOnly() || update.NameServerGroups[1].MatchOnly() {
+		t.Fatalf("unexpected match only state of the nameserver groups")
+	}
+
+	err := dnsServer.UpdateDNSServer(1, update)
+	if err != nil {
+		t.Fatalf("update dns server should not fail, got error: %v", err)
+	}
+
+	// answer from each group with a record pointing to its domain, so we can tell which group served the query
+	for domain, handler := range dnsServer.dnsMuxMap {
+		upstream, ok := handler.(*upstreamResolverNonIOS)
+		if !ok {
+			t.Fatalf("handler of %s should be an upstream resolver", domain)
+		}
+		answer := new(dns.Msg)
+		answer.Response = true
+		answer.Answer = []dns.RR{&dns.PTR{Hdr: dns.RR_Header{Name: domain, Rrtype: dns.TypePTR}, Ptr: domain}}
+		upstream.upstreamClient = &mockUpstreamResolver{r: answer}
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		expectedMatch string
+	}{
+		{
+			name:          "Query Under Match Only Domain Should Route To Its Group",
+			query:         "host.match.example.",
+			expectedMatch: "match.example",
+		},
+		{
+			name:          "Query Under Search Domain Should Route To Its Group",
+			query:         "host.search.example.",
+			expectedMatch: "search.example",
+		},
+		{
+			name:  "Query Outside Of Group Domains Should Not Be Routed",
+			query: "host.other.example.",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var response *dns.Msg
+			writer := &mockResponseWriter{
+				WriteMsgFunc: func(m *dns.Msg) error {
+					response = m
+					return nil
+				},
+			}
+
+			svc.mux.ServeDNS(writer, new(dns.Msg).SetQuestion(testCase.query, dns.TypeA))
+
+			if response == nil {
+				t.Fatalf("query %s should have a response", testCase.query)
+			}
+
+			if testCase.expectedMatch == "" {
+				if response.Rcode != dns.RcodeRefused {
+					t.Fatalf("query %s should be refused, got rcode %d", testCase.query, response.Rcode)
+				}
+				return
+			}
+
+			if len(response.Answer) != 1 || response.Answer[0].(*dns.PTR).Ptr != testCase.expectedMatch {
+				t.Fatalf("query %s should be served by the group of %s, got %v", testCase.query, testCase.expectedMatch, response.Answer)
+			}
+		})
+	}
+
+	searchDomains := dnsServer.SearchDomains()
+	if len(searchDomains) != 1 || searchDomains[0] != "search.example" {
+		t.Fatalf("only the search enabled domain should be in the search list, got %v", searchDomains)
+	}
+}
+
 func createWgInterfaceWithBind(t *testing.T) (*iface.WGIface, error) {
 	t.Helper()
 	ov := os.Getenv("NB_WG_KERNEL_DISABLED")
@@ -829,3 +969,147 @@ func newDnsResolver(ip string, port int) *net.Resolver {
 		},
 	}
 }
+
+func TestDNSServerExcludedDomains(t *testing.T) {
+	nameServers := []nbdns.NameServer{
+		{
+			IP:     netip.MustParseAddr("8.8.8.8"),
+			NSType: nbdns.UDPNameServerType,
+			Port:   53,
+		},
+	}
+	update := nbdns.Config{
+		ServiceEnable: true,
+		NameServerGroups: []*nbdns.NameServerGroup{
+			{
+				NameServers: nameServers,
+				Primary:     true,
+			},
+			{
+				NameServers: nameServers,
+				Domains:     []string{"corp.example", "captive.example"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name              string
+		hostNameServers   []string
+		expectedUpstreams map[string][]string
+	}{
+		{
+			name:            "Excluded Domains Should Use Host Nameservers",
+			hostNameServers: []string{"192.168.1.1", "fd00::1"},
+			expectedUpstreams: map[string][]string{
+				nbdns.RootZone:        {"8.8.8.8:53"},
+				"corp.example":        {"8.8.8.8:53"},
+				"portal.corp.example": {"192.168.1.1:53", "[fd00::1]:53"},
+				"captive.example":     {"192.168.1.1:53", "[fd00::1]:53"},
+			},
+		},
+		{
+			name: "Unknown Host Nameservers Should Keep Management Config",
+			expectedUpstreams: map[string][]string{
+				nbdns.RootZone:    {"8.8.8.8:53"},
+				"corp.example":    {"8.8.8.8:53"},
+				"captive.example": {"8.8.8.8:53"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var restored bool
+			dnsServer := newDefaultServer(context.Background(), &mocNamedWGIface{name: "utun2301"}, &mockMuxService{mux: dns.NewServeMux()})
+			dnsServer.excludedDomains = normalizeExcludedDomains([]string{"portal.corp.example", " Captive.Example. ", ""})
+			hostManager := newNoopHostMocker().(*mockHostConfigurator)
+			hostManager.hostNameServersFunc = func() []string { return testCase.hostNameServers }
+			hostManager.restoreHostDNSFunc = func() error {
+				restored = true
+				return nil
+			}
+			dnsServer.hostManager = hostManager
+
+			err := dnsServer.UpdateDNSServer(1, update)
+			if err != nil {
+				t.Fatalf("update dns server should not fail, got error: %v", err)
+			}
+
+			if len(dnsServer.dnsMuxMap) != len(testCase.expectedUpstreams) {
+				t.Fatalf("expected handlers for %v, got %v", testCase.expectedUpstreams, dnsServer.dnsMuxMap)
+			}
+			for domain, expectedUpstreams := range testCase.expectedUpstreams {
+				handler, ok := dnsServer.dnsMuxMap[domain].(*upstreamResolverNonIOS)
+				if !ok {
+					t.Fatalf("domain %s should have an upstream resolver, got %v", domain, dnsServer.dnsMuxMap[domain])
+				}
+				if !reflect.DeepEqual(handler.upstreamServers, expectedUpstreams) {
+					t.Errorf("domain %s should be resolved by %v, got %v", domain, expectedUpstreams, handler.upstreamServers)
+				}
+			}
+
+			dnsServer.Stop()
+			if !restored {
+				t.Errorf("host DNS config should be restored on stop")
+			}
+		})
+	}
+}
+
+func TestDNSServerRouteAllDisabled(t *testing.T) {
+	primaryGroup := &nbdns.NameServerGroup{
+		NameServers: []nbdns.NameServer{
+			{IP: netip.MustParseAddr("8.8.8.8"), NSType: nbdns.UDPNameServerType, Port: 53},
+		},
+		Primary: true,
+	}
+
+	testCases := []struct {
+		name             string
+		routeAllDisabled bool
+		expectedRouteAll bool
+	}{
+		{
+			name:             "Primary Nameserver Should Route All",
+			expectedRouteAll: true,
+		},
+		{
+			name:             "Route All Disabled By Management",
+			routeAllDisabled: true,
+			expectedRouteAll: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			dnsServer := newDefaultServer(context.Background(), &mocNamedWGIface{name: "utun2301"}, &mockMuxService{mux: dns.NewServeMux()})
+			hostManager := newNoopHostMocker().(*mockHostConfigurator)
+			var applied HostDNSConfig
+			hostManager.applyDNSConfigFunc = func(config HostDNSConfig) error {
+				applied = config
+				return nil
+			}
+			dnsServer.hostManager = hostManager
+
+			update := nbdns.Config{
+				ServiceEnable:    true,
+				NameServerGroups: []*nbdns.NameServerGroup{primaryGroup},
+				RouteAllDisabled: testCase.routeAllDisabled,
+			}
+			if err := dnsServer.UpdateDNSServer(1, update); err != nil {
+				t.Fatalf("update dns server should not fail, got error: %v", err)
+			}
+			if applied.RouteAll != testCase.expectedRouteAll {
+				t.Errorf("expected route all %t, got %t", testCase.expectedRouteAll, applied.RouteAll)
+			}
+
+			// a primary group coming back after a timeout keeps the setting
+			deactivate, reactivate := dnsServer.upstreamCallbacks(primaryGroup, nil)
+			deactivate()
+			reactivate()
+			if applied.RouteAll != testCase.expectedRouteAll {
+				t.Errorf("expected route all %t after reactivation, got %t", testCase.expectedRouteAll, applied.RouteAll)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ type serviceViaMemory struct {
 	runtimeIP         string
 	runtimePort       int
 	udpFilterHookID   string
+	tcpFilterHookID   string
 	listenerIsRunning bool
 	listenerFlagLock  sync.Mutex
 }
@@ -46,6 +47,14 @@ func (s *serviceViaMemory) Listen() error {
 	if err != nil {
 		return fmt.Errorf("filter dns traffice: %w", err)
 	}
+
+	s.tcpFilterHookID, err = s.filterTCPDNSTraffic()
+	if err != nil {
+		if err := s.wgInterface.GetFilter().RemovePacketHook(s.udpFilterHookID); err != nil {
+			log.Errorf("unable to remove DNS packet hook: %s", err)
+		}
+		return fmt.Errorf("filter tcp dns traffic: %w", err)
+	}
 	s.listenerIsRunning = true
 
 	log.Debugf("dns service listening on: %s", s.RuntimeIP())
@@ -64,6 +73,10 @@ func (s *serviceViaMemory) Stop() {
 		log.Errorf("unable to remove DNS packet hook: %s", err)
 	}
 
+	if err := s.wgInterface.GetFilter().RemovePacketHook(s.tcpFilterHookID); err != nil {
+		log.Errorf("unable to remove DNS TCP packet hook: %s", err)
+	}
+
 	s.listenerIsRunning = false
 }
 
@@ -109,8 +122,9 @@ func (s *serviceViaMemory) filterDNSTraffic() (string, error) {
 		}
 
 		writer := responseWriter{
-			packet: packet,
-			device: s.wgInterface.GetDevice().Device,
+			packet:  packet,
+			device:  s.wgInterface.GetDevice().Device,
+			udpSize: udpSizeOf(msg),
 		}
 		go s.dnsMux.ServeDNS(&writer, msg)
 		return true
@@ -119,6 +133,23 @@ func (s *serviceViaMemory) filterDNSTraffic() (string, error) {
 	return filter.AddUDPPacketHook(false, net.ParseIP(s.runtimeIP), uint16(s.runtimePort), hook), nil
 }
 
+// filterTCPDNSTraffic answers the DNS over TCP queries, used by the clients for the responses
+// not fitting into UDP, from the packet hook
+func (s *serviceViaMemory) filterTCPDNSTraffic() (string, error) {
+	filter := s.wgInterface.GetFilter()
+	if filter == nil {
+		return "", fmt.Errorf("can't set DNS filter, filter not initialized")
+	}
+
+	firstLayerDecoder := layers.LayerTypeIPv4
+	if s.wgInterface.Address().Network.IP.To4() == nil {
+		firstLayerDecoder = layers.LayerTypeIPv6
+	}
+
+	handler := newTCPHandler(s.wgInterface.GetDevice().Device, s.dnsMux, firstLayerDecoder)
+	return filter.AddTCPPacketHook(false, net.ParseIP(s.runtimeIP), uint16(s.runtimePort), handler.handlePacket), nil
+}
+
 func getLastIPFromNetwork(network *net.IPNet, fromEnd int) string {
 	// Calculate the last IP in the CIDR range
 	var endIP net.IP
@@ -0,0 +1,416 @@
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/tun"
+)
+
+const (
+	// tcpDefaultMSS is used when the client doesn't advertise its MSS
+	tcpDefaultMSS = 536
+	tcpWindowSize = 65535
+	// tcpIdleTimeout after which the connections the clients didn't close are forgotten
+	tcpIdleTimeout = time.Minute
+	// tcpMaxMessageSize is the largest DNS message accepted over TCP, as its length is a 2 bytes prefix
+	tcpMaxMessageSize = 65535
+)
+
+// tcpHandler serves DNS over TCP from the packet hook of the userspace filter.
+// It implements the minimum of TCP a DNS exchange needs, the handshake, the length
+// prefixed messages spread over the segments and the close, as the packets never
+// leave the host there is no retransmission nor congestion control.
+type tcpHandler struct {
+	device     tun.Device
+	handler    dns.Handler
+	firstLayer gopacket.LayerType
+
+	mu    sync.Mutex
+	conns map[string]*tcpConn
+}
+
+// tcpConn is the state of a single DNS over TCP connection
+type tcpConn struct {
+	handler *tcpHandler
+
+	local      net.Addr
+	remote     net.Addr
+	localIP    net.IP
+	remoteIP   net.IP
+	localPort  layers.TCPPort
+	remotePort layers.TCPPort
+	mss        int
+
+	mu       sync.Mutex
+	isn      uint32
+	seq      uint32
+	ack      uint32
+	buf      []byte
+	finSent  bool
+	lastSeen time.Time
+}
+
+func newTCPHandler(device tun.Device, handler dns.Handler, firstLayer gopacket.LayerType) *tcpHandler {
+	return &tcpHandler{
+		device:     device,
+		handler:    handler,
+		firstLayer: firstLayer,
+		conns:      make(map[string]*tcpConn),
+	}
+}
+
+// handlePacket processes a TCP segment sent to the DNS service. It always returns true as
+// the segments are consumed here and must not reach the wireguard device.
+func (h *tcpHandler) handlePacket(packetData []byte) bool {
+	packet := gopacket.NewPacket(packetData, h.firstLayer, gopacket.Default)
+
+	tcpLayer := packet.Layer(layers.LayerTypeTCP)
+	if tcpLayer == nil {
+		return true
+	}
+	segment := tcpLayer.(*layers.TCP)
+
+	var srcIP, dstIP net.IP
+	if ipv4Layer := packet.Layer(layers.LayerTypeIPv4); ipv4Layer != nil {
+		ipv4 := ipv4Layer.(*layers.IPv4)
+		srcIP, dstIP = ipv4.SrcIP, ipv4.DstIP
+	} else if ipv6Layer := packet.Layer(layers.LayerTypeIPv6); ipv6Layer != nil {
+		ipv6 := ipv6Layer.(*layers.IPv6)
+		srcIP, dstIP = ipv6.SrcIP, ipv6.DstIP
+	} else {
+		return true
+	}
+
+	key := net.JoinHostPort(srcIP.String(), segment.SrcPort.String())
+
+	switch {
+	case segment.RST:
+		h.removeConn(key)
+	case segment.SYN && !segment.ACK:
+		h.accept(key, srcIP, dstIP, segment)
+	default:
+		conn := h.getConn(key)
+		if conn == nil {
+			h.reset(srcIP, dstIP, segment)
+			return true
+		}
+		if conn.handleSegment(segment) {
+			h.removeConn(key)
+		}
+	}
+
+	return true
+}
+
+// accept answers the SYN of a new connection, or of one we already answered when the client retries
+func (h *tcpHandler) accept(key string, srcIP, dstIP net.IP, segment *layers.TCP) {
+	h.mu.Lock()
+	h.removeIdleConns()
+	conn, ok := h.conns[key]
+	if !ok {
+		isn := rand.Uint32()
+		conn = &tcpConn{
+			handler:    h,
+			local:      &net.TCPAddr{IP: dstIP, Port: int(segment.DstPort)},
+			remote:     &net.TCPAddr{IP: srcIP, Port: int(segment.SrcPort)},
+			localIP:    dstIP,
+			remoteIP:   srcIP,
+			localPort:  segment.DstPort,
+			remotePort: segment.SrcPort,
+			mss:        h.mssOf(segment, srcIP),
+			isn:        isn,
+			seq:        isn + 1,
+			ack:        segment.Seq + 1,
+		}
+		h.conns[key] = conn
+	}
+	h.mu.Unlock()
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.lastSeen = time.Now()
+	conn.reply(conn.isn, &layers.TCP{SYN: true, ACK: true})
+}
+
+// mssOf returns the segment size to use with the client, bound to the device MTU
+func (h *tcpHandler) mssOf(segment *layers.TCP, srcIP net.IP) int {
+	mss := tcpDefaultMSS
+	for _, opt := range segment.Options {
+		if opt.OptionType == layers.TCPOptionKindMSS && len(opt.OptionData) == 2 {
+			mss = int(binary.BigEndian.Uint16(opt.OptionData))
+		}
+	}
+
+	mtu, err := h.device.MTU()
+	if err != nil || mtu <= 0 {
+		return mss
+	}
+
+	headers := 40
+	if srcIP.To4() == nil {
+		headers = 60
+	}
+	if mtu-headers < mss {
+		mss = mtu - headers
+	}
+	return mss
+}
+
+// reset answers the segments of unknown connections
+func (h *tcpHandler) reset(srcIP, dstIP net.IP, segment *layers.TCP) {
+	if segment.RST {
+		return
+	}
+
+	conn := &tcpConn{
+		handler:    h,
+		localIP:    dstIP,
+		remoteIP:   srcIP,
+		localPort:  segment.DstPort,
+		remotePort: segment.SrcPort,
+		ack:        segment.Seq + uint32(len(segment.Payload)),
+	}
+	conn.reply(segment.Ack, &layers.TCP{RST: true, ACK: true})
+}
+
+func (h *tcpHandler) getConn(key string) *tcpConn {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.conns[key]
+}
+
+func (h *tcpHandler) removeConn(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, key)
+}
+
+// removeIdleConns forgets the connections the clients left open, the caller must hold the lock
+func (h *tcpHandler) removeIdleConns() {
+	for key, conn := range h.conns {
+		conn.mu.Lock()
+		idle := time.Since(conn.lastSeen) > tcpIdleTimeout
+		conn.mu.Unlock()
+		if idle {
+			delete(h.conns, key)
+		}
+	}
+}
+
+// handleSegment processes a segment of an established connection and returns true once the connection is closed
+func (c *tcpConn) handleSegment(segment *layers.TCP) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastSeen = time.Now()
+
+	if c.finSent && segment.ACK && segment.Ack == c.seq {
+		return true
+	}
+
+	if len(segment.Payload) == 0 && !segment.FIN {
+		return false
+	}
+
+	// retransmitted or out of order data, we only accept the next expected one
+	if segment.Seq != c.ack {
+		c.reply(c.seq, &layers.TCP{ACK: true})
+		return false
+	}
+
+	if len(segment.Payload) > 0 {
+		c.ack += uint32(len(segment.Payload))
+		c.buf = append(c.buf, segment.Payload...)
+		if err := c.serveMessages(); err != nil {
+			log.Debugf("failed to handle DNS over TCP from %s: %v", c.remote, err)
+			c.reply(c.seq, &layers.TCP{RST: true, ACK: true})
+			return true
+		}
+	}
+
+	if segment.FIN {
+		c.ack++
+		c.reply(c.seq, &layers.TCP{FIN: true, ACK: true})
+		c.seq++
+		c.finSent = true
+		return false
+	}
+
+	c.reply(c.seq, &layers.TCP{ACK: true})
+	return false
+}
+
+// serveMessages hands over every complete message of the buffer to the DNS handler
+func (c *tcpConn) serveMessages() error {
+	for len(c.buf) >= 2 {
+		length := int(binary.BigEndian.Uint16(c.buf))
+		if length == 0 {
+			return fmt.Errorf("empty DNS message")
+		}
+		if len(c.buf) < 2+length {
+			return nil
+		}
+
+		msg := new(dns.Msg)
+		if err := msg.Unpack(c.buf[2 : 2+length]); err != nil {
+			return fmt.Errorf("parse DNS request: %w", err)
+		}
+		c.buf = c.buf[2+length:]
+
+		go c.handler.handler.ServeDNS(&tcpResponseWriter{conn: c}, msg)
+	}
+	return nil
+}
+
+// write sends the data to the client in segments of the negotiated size
+func (c *tcpConn) write(data []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.finSent {
+		return 0, fmt.Errorf("connection closed")
+	}
+
+	for offset := 0; offset < len(data); offset += c.mss {
+		end := offset + c.mss
+		if end > len(data) {
+			end = len(data)
+		}
+
+		flags := &layers.TCP{ACK: true, PSH: end == len(data)}
+		if err := c.send(c.seq, flags, data[offset:end]); err != nil {
+			return offset, err
+		}
+		c.seq += uint32(end - offset)
+	}
+	return len(data), nil
+}
+
+// reply sends a segment without payload, like the handshake or the acknowledgements
+func (c *tcpConn) reply(seq uint32, tcp *layers.TCP) {
+	if err := c.send(seq, tcp, nil); err != nil {
+		log.Debugf("failed to reply to DNS over TCP client %s: %v", c.remote, err)
+	}
+}
+
+// send writes a segment with the given flags and payload to the client
+func (c *tcpConn) send(seq uint32, tcp *layers.TCP, payload []byte) error {
+	tcp.SrcPort = c.localPort
+	tcp.DstPort = c.remotePort
+	tcp.Seq = seq
+	tcp.Ack = c.ack
+	tcp.Window = tcpWindowSize
+
+	var ip gopacket.SerializableLayer
+	if c.remoteIP.To4() != nil {
+		ip = &layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: layers.IPProtocolTCP,
+			SrcIP:    c.localIP,
+			DstIP:    c.remoteIP,
+		}
+	} else {
+		ip = &layers.IPv6{
+			Version:    6,
+			HopLimit:   64,
+			NextHeader: layers.IPProtocolTCP,
+			SrcIP:      c.localIP,
+			DstIP:      c.remoteIP,
+		}
+	}
+
+	if err := tcp.SetNetworkLayerForChecksum(ip.(gopacket.NetworkLayer)); err != nil {
+		return fmt.Errorf("failed to set network layer for checksum: %v", err)
+	}
+
+	buffer := gopacket.NewSerializeBuffer()
+	options := gopacket.SerializeOptions{
+		ComputeChecksums: true,
+		FixLengths:       true,
+	}
+	if err := gopacket.SerializeLayers(buffer, options, ip, tcp, gopacket.Payload(payload)); err != nil {
+		return fmt.Errorf("failed to serialize packet: %v", err)
+	}
+
+	send := buffer.Bytes()
+	sendBuffer := make([]byte, 40, len(send)+40)
+	sendBuffer = append(sendBuffer, send...)
+
+	if _, err := c.handler.device.Write([][]byte{sendBuffer}, 40); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+// tcpResponseWriter writes the length prefixed DNS responses to a TCP connection
+type tcpResponseWriter struct {
+	conn *tcpConn
+}
+
+// LocalAddr returns the net.Addr of the server
+func (r *tcpResponseWriter) LocalAddr() net.Addr {
+	return r.conn.local
+}
+
+// RemoteAddr returns the net.Addr of the client that sent the current request.
+func (r *tcpResponseWriter) RemoteAddr() net.Addr {
+	return r.conn.remote
+}
+
+// WriteMsg writes a reply back to the client.
+func (r *tcpResponseWriter) WriteMsg(msg *dns.Msg) error {
+	buff, err := msg.Pack()
+	if err != nil {
+		return fmt.Errorf("pack: %w", err)
+	}
+
+	if _, err := r.Write(buff); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+// Write writes a raw DNS message back to the client, prefixed with its length.
+func (r *tcpResponseWriter) Write(data []byte) (int, error) {
+	if len(data) > tcpMaxMessageSize {
+		return 0, fmt.Errorf("message too large: %d bytes", len(data))
+	}
+
+	framed := make([]byte, 2, len(data)+2)
+	binary.BigEndian.PutUint16(framed, uint16(len(data)))
+	framed = append(framed, data...)
+
+	if _, err := r.conn.write(framed); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Close closes the connection.
+func (r *tcpResponseWriter) Close() error {
+	return nil
+}
+
+// TsigStatus returns the status of the Tsig.
+func (r *tcpResponseWriter) TsigStatus() error {
+	return nil
+}
+
+// TsigTimersOnly sets the tsig timers only boolean.
+func (r *tcpResponseWriter) TsigTimersOnly(bool) {
+}
+
+// Hijack lets the caller take over the connection.
+// After a call to Hijack(), the DNS package will not do anything with the connection.
+func (r *tcpResponseWriter) Hijack() {
+}
@@ -0,0 +1,240 @@
+package dns
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/iface/mocks"
+)
+
+var (
+	testClientIP  = net.IPv4(100, 64, 0, 1).To4()
+	testServiceIP = net.IPv4(100, 64, 255, 254).To4()
+)
+
+// largeResponseHandler answers with enough records to not fit into a plain UDP response
+var largeResponseHandler = dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+	reply := new(dns.Msg)
+	reply.SetReply(r)
+	for i := 0; i < 100; i++ {
+		reply.Answer = append(reply.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.IPv4(10, 0, byte(i/250), byte(i%250+1)),
+		})
+	}
+	_ = w.WriteMsg(reply)
+})
+
+func newCapturingDevice(t *testing.T) (*mocks.MockDevice, chan gopacket.Packet) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	packets := make(chan gopacket.Packet, 100)
+	device := mocks.NewMockDevice(ctrl)
+	device.EXPECT().MTU().Return(1280, nil).AnyTimes()
+	device.EXPECT().Write(gomock.Any(), gomock.Any()).DoAndReturn(func(bufs [][]byte, offset int) (int, error) {
+		data := append([]byte{}, bufs[0][offset:]...)
+		packets <- gopacket.NewPacket(data, layers.LayerTypeIPv4, gopacket.Default)
+		return len(bufs), nil
+	}).AnyTimes()
+
+	return device, packets
+}
+
+func nextPacket(t *testing.T, packets chan gopacket.Packet) gopacket.Packet {
+	t.Helper()
+
+	select {
+	case packet := <-packets:
+		return packet
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a packet")
+		return nil
+	}
+}
+
+func nextSegment(t *testing.T, packets chan gopacket.Packet) *layers.TCP {
+	t.Helper()
+
+	packet := nextPacket(t, packets)
+	ipv4 := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	assert.True(t, ipv4.SrcIP.Equal(testServiceIP), "wrong source IP %s", ipv4.SrcIP)
+	assert.True(t, ipv4.DstIP.Equal(testClientIP), "wrong destination IP %s", ipv4.DstIP)
+
+	tcpLayer := packet.Layer(layers.LayerTypeTCP)
+	require.NotNil(t, tcpLayer, "expected a TCP segment")
+	return tcpLayer.(*layers.TCP)
+}
+
+func serializeTCP(t *testing.T, tcp *layers.TCP, payload []byte) []byte {
+	t.Helper()
+
+	ipv4 := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    testClientIP,
+		DstIP:    testServiceIP,
+	}
+	tcp.SrcPort = 40000
+	tcp.DstPort = 53
+	tcp.Window = 65535
+	require.NoError(t, tcp.SetNetworkLayerForChecksum(ipv4))
+
+	buffer := gopacket.NewSerializeBuffer()
+	options := gopacket.SerializeOptions{ComputeChecksums: true, FixLengths: true}
+	require.NoError(t, gopacket.SerializeLayers(buffer, options, ipv4, tcp, gopacket.Payload(payload)))
+	return buffer.Bytes()
+}
+
+func TestResponseWriterTruncatesLargeResponses(t *testing.T) {
+	testCases := []struct {
+		name      string
+		edns      uint16
+		truncated bool
+	}{
+		{name: "Plain UDP", truncated: true},
+		{name: "EDNS Buffer Large Enough", edns: 4096},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			device, packets := newCapturingDevice(t)
+
+			request := new(dns.Msg).SetQuestion("large.netbird.cloud.", dns.TypeA)
+			if testCase.edns > 0 {
+				request.SetEdns0(testCase.edns, false)
+			}
+			requestData, err := request.Pack()
+			require.NoError(t, err)
+
+			ipv4 := &layers.IPv4{
+				Version:  4,
+				TTL:      64,
+				Protocol: layers.IPProtocolUDP,
+				SrcIP:    testClientIP,
+				DstIP:    testServiceIP,
+			}
+			udp := &layers.UDP{SrcPort: 40000, DstPort: 53}
+			require.NoError(t, udp.SetNetworkLayerForChecksum(ipv4))
+			buffer := gopacket.NewSerializeBuffer()
+			options := gopacket.SerializeOptions{ComputeChecksums: true, FixLengths: true}
+			require.NoError(t, gopacket.SerializeLayers(buffer, options, ipv4, udp, gopacket.Payload(requestData)))
+
+			rw := &responseWriter{
+				packet:  gopacket.NewPacket(buffer.Bytes(), layers.LayerTypeIPv4, gopacket.Default),
+				device:  device,
+				udpSize: udpSizeOf(request),
+			}
+			largeResponseHandler.ServeDNS(rw, request)
+
+			packet := nextPacket(t, packets)
+			udpLayer := packet.Layer(layers.LayerTypeUDP)
+			require.NotNil(t, udpLayer)
+			payload := udpLayer.(*layers.UDP).Payload
+
+			reply := new(dns.Msg)
+			require.NoError(t, reply.Unpack(payload))
+			assert.Equal(t, testCase.truncated, reply.Truncated, "unexpected TC bit")
+			if testCase.truncated {
+				assert.LessOrEqual(t, len(payload), dns.MinMsgSize)
+				assert.Less(t, len(reply.Answer), 100)
+			} else {
+				assert.Len(t, reply.Answer, 100)
+			}
+		})
+	}
+}
+
+func TestTCPHandlerServesLargeResponses(t *testing.T) {
+	device, packets := newCapturingDevice(t)
+	handler := newTCPHandler(device, largeResponseHandler, layers.LayerTypeIPv4)
+
+	clientSeq := uint32(1000)
+
+	// handshake, the client advertises an MSS larger than the device MTU allows
+	mss := make([]byte, 2)
+	binary.BigEndian.PutUint16(mss, 1460)
+	handler.handlePacket(serializeTCP(t, &layers.TCP{
+		SYN: true,
+		Seq: clientSeq,
+		Options: []layers.TCPOption{
+			{OptionType: layers.TCPOptionKindMSS, OptionLength: 4, OptionData: mss},
+		},
+	}, nil))
+
+	synAck := nextSegment(t, packets)
+	require.True(t, synAck.SYN && synAck.ACK, "expected SYN-ACK")
+	assert.Equal(t, clientSeq+1, synAck.Ack)
+	clientSeq++
+	serverSeq := synAck.Seq + 1
+
+	handler.handlePacket(serializeTCP(t, &layers.TCP{ACK: true, Seq: clientSeq, Ack: serverSeq}, nil))
+
+	// the query is length prefixed and split over two segments
+	request := new(dns.Msg).SetQuestion("large.netbird.cloud.", dns.TypeA)
+	requestData, err := request.Pack()
+	require.NoError(t, err)
+	framed := make([]byte, 2, len(requestData)+2)
+	binary.BigEndian.PutUint16(framed, uint16(len(requestData)))
+	framed = append(framed, requestData...)
+
+	for _, part := range [][]byte{framed[:5], framed[5:]} {
+		handler.handlePacket(serializeTCP(t, &layers.TCP{ACK: true, PSH: true, Seq: clientSeq, Ack: serverSeq}, part))
+		clientSeq += uint32(len(part))
+	}
+
+	// collect the acknowledgements and the response segments until the whole message is received
+	var stream []byte
+	for len(stream) < 2 || len(stream) < 2+int(binary.BigEndian.Uint16(stream)) {
+		segment := nextSegment(t, packets)
+		require.False(t, segment.RST, "unexpected reset")
+		if len(segment.Payload) == 0 {
+			continue
+		}
+		require.Equal(t, serverSeq, segment.Seq, "unexpected sequence number")
+		assert.LessOrEqual(t, len(segment.Payload), 1280-40, "segment larger than the MTU")
+		serverSeq += uint32(len(segment.Payload))
+		stream = append(stream, segment.Payload...)
+	}
+
+	length := int(binary.BigEndian.Uint16(stream))
+	require.Len(t, stream, 2+length)
+	assert.Greater(t, length, dns.MinMsgSize, "the response should not fit into plain UDP")
+
+	reply := new(dns.Msg)
+	require.NoError(t, reply.Unpack(stream[2:]))
+	assert.False(t, reply.Truncated)
+	assert.Equal(t, request.Id, reply.Id)
+	assert.Len(t, reply.Answer, 100)
+
+	// the client closes the connection
+	handler.handlePacket(serializeTCP(t, &layers.TCP{ACK: true, FIN: true, Seq: clientSeq, Ack: serverSeq}, nil))
+	var finAck *layers.TCP
+	for finAck == nil {
+		segment := nextSegment(t, packets)
+		if segment.FIN {
+			finAck = segment
+		}
+	}
+	assert.Equal(t, clientSeq+1, finAck.Ack)
+
+	handler.handlePacket(serializeTCP(t, &layers.TCP{ACK: true, Seq: clientSeq + 1, Ack: finAck.Seq + 1}, nil))
+	assert.Empty(t, handler.conns, "the connection should be forgotten once closed")
+
+	// segments of unknown connections are reset
+	handler.handlePacket(serializeTCP(t, &layers.TCP{ACK: true, Seq: clientSeq + 1, Ack: finAck.Seq + 1}, []byte{0, 1}))
+	rst := nextSegment(t, packets)
+	assert.True(t, rst.RST, "expected reset")
+}
@@ -36,8 +36,9 @@ const (
 )
 
 type systemdDbusConfigurator struct {
-	dbusLinkObject dbus.ObjectPath
-	routingAll     bool
+	dbusLinkObject      dbus.ObjectPath
+	routingAll          bool
+	originalNameServers []string
 }
 
 // the types below are based on dbus specification, each field is mapped to a dbus type
@@ -76,7 +77,8 @@ func newSystemdDbusConfigurator(wgInterface string) (hostManager, error) {
 	log.Debugf("got dbus Link interface: %s from net interface %s and index %d", s, iface.Name, iface.Index)
 
 	return &systemdDbusConfigurator{
-		dbusLinkObject: dbus.ObjectPath(s),
+		dbusLinkObject:      dbus.ObjectPath(s),
+		originalNameServers: readNameServers(systemdResolvConfPath),
 	}, nil
 }
 
@@ -221,6 +223,10 @@ func (s *systemdDbusConfigurator) callLinkMethod(method string, value any) error
 	return nil
 }
 
+func (s *systemdDbusConfigurator) hostNameServers() []string {
+	return s.originalNameServers
+}
+
 func (s *systemdDbusConfigurator) restoreUncleanShutdownDNS(*netip.Addr) error {
 	if err := s.restoreHostDNS(); err != nil {
 		return fmt.Errorf("restoring dns via systemd: %w", err)
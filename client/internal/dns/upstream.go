@@ -115,6 +115,10 @@ func (u *upstreamResolverBase) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 
 		log.Tracef("took %s to query the upstream %s", t, upstream)
 
+		if recorder, ok := w.(upstreamRecorder); ok {
+			recorder.setUpstream(upstream)
+		}
+
 		err = w.WriteMsg(rm)
 		if err != nil {
 			log.WithError(err).Error("got an error while writing the upstream resolver response")
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net"
@@ -21,12 +22,15 @@ import (
 	"github.com/netbirdio/netbird/client/firewall/manager"
 	"github.com/netbirdio/netbird/client/internal/acl"
 	"github.com/netbirdio/netbird/client/internal/dns"
+	"github.com/netbirdio/netbird/client/internal/nattype"
 	"github.com/netbirdio/netbird/client/internal/peer"
+	"github.com/netbirdio/netbird/client/internal/portmap"
 	"github.com/netbirdio/netbird/client/internal/relay"
 	"github.com/netbirdio/netbird/client/internal/rosenpass"
 	"github.com/netbirdio/netbird/client/internal/routemanager"
 	"github.com/netbirdio/netbird/client/internal/wgproxy"
 	nbssh "github.com/netbirdio/netbird/client/ssh"
+	"github.com/netbirdio/netbird/client/system"
 	nbdns "github.com/netbirdio/netbird/dns"
 	"github.com/netbirdio/netbird/iface"
 	"github.com/netbirdio/netbird/iface/bind"
@@ -45,6 +49,9 @@ import (
 const (
 	PeerConnectionTimeoutMax = 45000 // ms
 	PeerConnectionTimeoutMin = 30000 // ms
+
+	// wgStatsInterval is the interval of reading the peers' transfer counters from the WireGuard interface
+	wgStatsInterval = 10 * time.Second
 )
 
 var ErrResetConnection = fmt.Errorf("reset connection")
@@ -57,13 +64,27 @@ type EngineConfig struct {
 	// WgAddr is a Wireguard local address (Netbird Network IP)
 	WgAddr string
 
+	// MTU of the Wireguard interface set by the management from the groups of the peer, iface.DefaultMTU when 0
+	MTU int
+
 	// WgPrivateKey is a Wireguard private key of our peer (it MUST never leave the machine)
 	WgPrivateKey wgtypes.Key
 
+	// WgFwmark marks the packets encrypted by the Wireguard interface and isolates the client routes in the routing
+	// table of the same number, 0 keeps the routes in the main table
+	WgFwmark int
+
 	// IFaceBlackList is a list of network interfaces to ignore when discovering connection candidates (ICE related)
 	IFaceBlackList       []string
 	DisableIPv6Discovery bool
 
+	// ConnOrdering biases the selection between the direct and the relayed peer connections
+	ConnOrdering peer.ConnOrdering
+	// RelayFallbackAttempts is how many times a failed direct peer connection attempt is retried with relays only
+	RelayFallbackAttempts int
+	// MaxConcurrentNegotiations is how many peer connections negotiate at the same time, 0 doesn't limit them
+	MaxConcurrentNegotiations int
+
 	PreSharedKey *wgtypes.Key
 
 	// UDPMuxPort default value 0 - the system will pick an available port
@@ -79,7 +100,35 @@ type EngineConfig struct {
 
 	CustomDNSAddress string
 
+	// DNSExcludedDomains are resolved by the nameservers of the host instead of the NetBird resolver upstreams
+	DNSExcludedDomains []string
+
 	RosenpassEnabled bool
+
+	// StaleHandshakeThreshold is how long the WireGuard handshake and the received traffic of a connected peer
+	// can be missing before the peer is reported as stale, 0 disables the detection
+	StaleHandshakeThreshold time.Duration
+	// StaleHandshakeReconnect negotiates the connection again when a peer becomes stale
+	StaleHandshakeReconnect bool
+
+	// FirewallFlowLogging logs the dropped packets and counts the packets matched by the firewall rules
+	FirewallFlowLogging bool
+
+	// SignalAddress is the address of the Signal service the engine is connected to, e.g. https://signal.netbird.io:443
+	SignalAddress string
+
+	// AdvertisedNetworks are the networks of the local network the peer offers to route, reported on the sync
+	AdvertisedNetworks []string
+
+	// NATType is the type of the NAT the peer is behind, two peers behind symmetric NATs connect through a relay
+	NATType nattype.Type
+
+	// PortMapper maps the WireGuard port on the gateway, its current mapping is reported on the sync.
+	// Nil when the port mapping is disabled.
+	PortMapper *portmap.Manager
+
+	// ExcludedRoutes are routed outside the tunnel through the gateway of the host, taking precedence over the routes
+	ExcludedRoutes []netip.Prefix
 }
 
 // Engine is a mechanism responsible for reacting on Signal and Management stream events and managing connections to the remote peers.
@@ -90,6 +139,12 @@ type Engine struct {
 	mgmClient mgm.Client
 	// peerConns is a map that holds all the peers that are known to this peer
 	peerConns map[string]*peer.Conn
+	// connWorkers holds a channel per peer key, closed when the worker of the latest Conn of the peer exits.
+	// The worker of a Conn superseding a removed one waits for the previous worker to clean up,
+	// so there is at most one live Conn per peer even if updates from management add and remove a peer quickly
+	connWorkers map[string]chan struct{}
+	// negotiationLimiter bounds how many of the peer connections negotiate at the same time
+	negotiationLimiter *peer.NegotiationLimiter
 	// rpManager is a Rosenpass manager
 	rpManager *rosenpass.Manager
 
@@ -103,11 +158,23 @@ type Engine struct {
 	STUNs []*stun.URI
 	// TURNs is a list of STUN servers used by ICE
 	TURNs []*stun.URI
+	// turnTiers holds the TURNs grouped by priority, the most preferred first
+	turnTiers [][]*stun.URI
+	// connectionTimeout is the timeout of the peer connection attempts pushed by the Management service, zero for
+	// the randomized default of the client
+	connectionTimeout time.Duration
 
 	cancel context.CancelFunc
 
 	ctx context.Context
 
+	// wgStatsCancel stops the periodic collection of the WireGuard peer stats
+	wgStatsCancel context.CancelFunc
+	// staleDetector detects the connected peers with a stale WireGuard handshake from the collected stats
+	staleDetector *staleHandshakeDetector
+	// wgWatchdogCancel stops the watchdog of the WireGuard interface
+	wgWatchdogCancel context.CancelFunc
+
 	wgInterface    *iface.WGIface
 	wgProxyFactory *wgproxy.Factory
 
@@ -126,6 +193,8 @@ type Engine struct {
 	acl          acl.Manager
 
 	dnsServer dns.Server
+	// latestDNSConfig is the latest DNS config received from the Management service, re-applied when the DNS server is replaced
+	latestDNSConfig *nbdns.Config
 
 	mgmProbe    *Probe
 	signalProbe *Probe
@@ -179,24 +248,27 @@ func NewEngineWithProbes(
 	wgProbe *Probe,
 ) *Engine {
 	return &Engine{
-		ctx:            ctx,
-		cancel:         cancel,
-		signal:         signalClient,
-		mgmClient:      mgmClient,
-		peerConns:      make(map[string]*peer.Conn),
-		syncMsgMux:     &sync.Mutex{},
-		config:         config,
-		mobileDep:      mobileDep,
-		STUNs:          []*stun.URI{},
-		TURNs:          []*stun.URI{},
-		networkSerial:  0,
-		sshServerFunc:  nbssh.DefaultSSHServer,
-		statusRecorder: statusRecorder,
-		wgProxyFactory: wgproxy.NewFactory(config.WgPort),
-		mgmProbe:       mgmProbe,
-		signalProbe:    signalProbe,
-		relayProbe:     relayProbe,
-		wgProbe:        wgProbe,
+		ctx:                ctx,
+		cancel:             cancel,
+		signal:             signalClient,
+		mgmClient:          mgmClient,
+		peerConns:          make(map[string]*peer.Conn),
+		connWorkers:        make(map[string]chan struct{}),
+		syncMsgMux:         &sync.Mutex{},
+		negotiationLimiter: peer.NewNegotiationLimiter(config.MaxConcurrentNegotiations),
+		config:             config,
+		mobileDep:          mobileDep,
+		STUNs:              []*stun.URI{},
+		TURNs:              []*stun.URI{},
+		networkSerial:      0,
+		sshServerFunc:      nbssh.DefaultSSHServer,
+		statusRecorder:     statusRecorder,
+		wgProxyFactory:     wgproxy.NewFactory(config.WgPort),
+		mgmProbe:           mgmProbe,
+		signalProbe:        signalProbe,
+		relayProbe:         relayProbe,
+		wgProbe:            wgProbe,
+		staleDetector:      newStaleHandshakeDetector(config.StaleHandshakeThreshold),
 	}
 }
 
@@ -228,7 +300,7 @@ func (e *Engine) Start() error {
 	wgIface, err := e.newWgIface()
 	if err != nil {
 		log.Errorf("failed creating wireguard interface instance %s: [%s]", e.config.WgIfaceName, err.Error())
-		return err
+		return NewInterfaceCreationError(err)
 	}
 	e.wgInterface = wgIface
 
@@ -250,6 +322,7 @@ func (e *Engine) Start() error {
 		return err
 	}
 	e.dnsServer = dnsServer
+	e.statusRecorder.SetDNSStatsGetter(dnsServer.QueryStats)
 
 	e.routeManager = routemanager.NewManager(e.ctx, e.config.WgPrivateKey.PublicKey().String(), e.wgInterface, e.statusRecorder, initialRoutes)
 	e.routeManager.SetRouteChangeListener(e.mobileDep.NetworkChangeListener)
@@ -258,10 +331,10 @@ func (e *Engine) Start() error {
 	if err != nil {
 		log.Errorf("failed creating tunnel interface %s: [%s]", e.config.WgIfaceName, err.Error())
 		e.close()
-		return err
+		return NewInterfaceCreationError(err)
 	}
 
-	e.firewall, err = firewall.NewFirewall(e.ctx, e.wgInterface)
+	e.firewall, err = firewall.NewFirewall(e.ctx, e.wgInterface, e.config.FirewallFlowLogging)
 	if err != nil {
 		log.Errorf("failed creating firewall manager: %s", err)
 	}
@@ -278,7 +351,19 @@ func (e *Engine) Start() error {
 	if err != nil {
 		log.Errorf("failed to pull up wgInterface [%s]: %s", e.wgInterface.Name(), err.Error())
 		e.close()
-		return err
+		return NewInterfaceCreationError(err)
+	}
+
+	if e.config.WgFwmark != 0 {
+		if err := e.enableRouteIsolation(); err != nil {
+			e.close()
+			return err
+		}
+	}
+
+	// installed after the route isolation, to the routing table of the client routes
+	if err := e.routeManager.ExcludeRoutes(e.config.ExcludedRoutes); err != nil {
+		log.Errorf("failed to exclude the routes from the tunnel: %v", err)
 	}
 
 	if e.firewall != nil {
@@ -294,6 +379,8 @@ func (e *Engine) Start() error {
 	e.receiveSignalEvents()
 	e.receiveManagementEvents()
 	e.receiveProbeEvents()
+	e.startWGStatsCollector()
+	e.startWgWatchdog()
 
 	return nil
 }
@@ -307,7 +394,8 @@ func (e *Engine) modifyPeers(peersUpdate []*mgmProto.RemotePeerConfig) error {
 	for _, p := range peersUpdate {
 		peerPubKey := p.GetWgPubKey()
 		if peerConn, ok := e.peerConns[peerPubKey]; ok {
-			if peerConn.WgConfig().AllowedIps != strings.Join(p.AllowedIps, ",") {
+			if peerConn.WgConfig().AllowedIps != peerAllowedIPs(p) || peerConn.GetConf().ForceRelay != e.relayOnly(p) ||
+				peerConn.WgConfig().Keepalive != peerKeepalive(p) {
 				modified = append(modified, p)
 				continue
 			}
@@ -463,7 +551,17 @@ func (e *Engine) handleSync(update *mgmProto.SyncResponse) error {
 			return err
 		}
 
-		// todo update signal
+		// the updates without a Signal service, like the refreshed TURN credentials, don't carry the timeout
+		if update.GetWiretrusteeConfig().GetSignal() != nil {
+			e.updateConnectionTimeout(time.Duration(update.GetWiretrusteeConfig().GetConnectionTimeout()) * time.Millisecond)
+		}
+
+		if e.signalChanged(update.GetWiretrusteeConfig().GetSignal()) {
+			// the client connects to the new Signal service with the config of the next login
+			_ = CtxGetState(e.ctx).Wrap(ErrResetConnection)
+			e.cancel()
+			return nil
+		}
 	}
 
 	if update.GetNetworkMap() != nil {
@@ -477,6 +575,46 @@ func (e *Engine) handleSync(update *mgmProto.SyncResponse) error {
 	return nil
 }
 
+// signalChanged returns true if the Management service moved the peer to another Signal service. The updates
+// without a Signal service, like the refreshed TURN credentials, don't change it.
+func (e *Engine) signalChanged(signal *mgmProto.HostConfig) bool {
+	if signal == nil || e.config.SignalAddress == "" {
+		return false
+	}
+
+	address := signalAddress(signal)
+	if address == e.config.SignalAddress {
+		return false
+	}
+
+	log.Infof("the Management service moved the peer from the Signal service %s to %s, reconnecting",
+		e.config.SignalAddress, address)
+	return true
+}
+
+// updateConnectionTimeout applies the connection timeout of the Management service to the next connection attempts
+// of the peers, the attempts in progress keep their timeout. Zero restores the default of the client.
+func (e *Engine) updateConnectionTimeout(timeout time.Duration) {
+	if timeout == e.connectionTimeout {
+		return
+	}
+
+	log.Infof("the Management service changed the peer connection timeout from %s to %s", e.connectionTimeout, timeout)
+	e.connectionTimeout = timeout
+	for _, conn := range e.peerConns {
+		conn.UpdateTimeout(e.peerConnectionTimeout())
+	}
+}
+
+// peerConnectionTimeout returns the timeout of a connection attempt to a remote peer, the one of the Management
+// service if set, otherwise a randomized one so the peers don't retry at the same time
+func (e *Engine) peerConnectionTimeout() time.Duration {
+	if e.connectionTimeout > 0 {
+		return e.connectionTimeout
+	}
+	return time.Duration(rand.Intn(PeerConnectionTimeoutMax-PeerConnectionTimeoutMin)+PeerConnectionTimeoutMin) * time.Millisecond
+}
+
 func isNil(server nbssh.Server) bool {
 	return server == nil || reflect.ValueOf(server).IsNil()
 }
@@ -534,6 +672,23 @@ func (e *Engine) updateConfig(conf *mgmProto.PeerConfig) error {
 		log.Infof("updated peer address from %s to %s", oldAddr, conf.Address)
 	}
 
+	if e.wgInterface.Address().StringV6() != conf.GetAddressV6() {
+		oldAddr := e.wgInterface.Address().StringV6()
+		log.Debugf("updating peer IPv6 address from %q to %q", oldAddr, conf.GetAddressV6())
+		err := e.wgInterface.UpdateAddrV6(conf.GetAddressV6())
+		if err != nil {
+			return err
+		}
+		log.Infof("updated peer IPv6 address from %q to %q", oldAddr, conf.GetAddressV6())
+	}
+
+	if mtu := int(conf.GetMtu()); mtu != e.config.MTU {
+		// the interface keeps its MTU until it is recreated, the login of the next connection gets the new MTU
+		log.Infof("MTU of the interface changed from %d to %d by the management, it will be applied on the next connection",
+			e.config.MTU, mtu)
+		e.config.MTU = mtu
+	}
+
 	if conf.GetSshConfig() != nil {
 		err := e.updateSSH(conf.GetSshConfig())
 		if err != nil {
@@ -551,11 +706,23 @@ func (e *Engine) updateConfig(conf *mgmProto.PeerConfig) error {
 	return nil
 }
 
+// syncSystemInfo returns the system info sent on the management sync, which carries the advertised networks, the
+// NAT type and the address the WireGuard port is mapped to only. The port may have been mapped after the login.
+func (e *Engine) syncSystemInfo() *system.Info {
+	info := &system.Info{AdvertisedNetworks: e.config.AdvertisedNetworks, NATType: string(e.config.NATType)}
+	if e.config.PortMapper != nil {
+		if mapping := e.config.PortMapper.Mapping(); mapping != nil {
+			info.NATMappedAddress = mapping.External.String()
+		}
+	}
+	return info
+}
+
 // receiveManagementEvents connects to the Management Service event stream to receive updates from the management service
 // E.g. when a new peer has been registered and we are allowed to connect to it.
 func (e *Engine) receiveManagementEvents() {
 	go func() {
-		err := e.mgmClient.Sync(e.handleSync)
+		err := e.mgmClient.Sync(e.syncSystemInfo(), e.handleSync)
 		if err != nil {
 			// happens if management is unavailable for a long time.
 			// We want to cancel the operation of the whole client
@@ -590,7 +757,7 @@ func (e *Engine) updateTURNs(turns []*mgmProto.ProtectedHostConfig) error {
 	if len(turns) == 0 {
 		return nil
 	}
-	var newTURNs []*stun.URI
+	var servers []peer.TURNServer
 	log.Debugf("got TURNs update from Management Service, updating")
 	for _, turn := range turns {
 		url, err := stun.ParseURI(turn.HostConfig.Uri)
@@ -599,7 +766,14 @@ func (e *Engine) updateTURNs(turns []*mgmProto.ProtectedHostConfig) error {
 		}
 		url.Username = turn.User
 		url.Password = turn.Password
-		newTURNs = append(newTURNs, url)
+		servers = append(servers, peer.TURNServer{URL: url, Priority: turn.GetPriority(), Weight: turn.GetWeight()})
+	}
+
+	// the TURNs are ordered by preference, so the probes and ICE try the preferred servers first
+	e.turnTiers = peer.GroupTURNsByPreference(servers)
+	var newTURNs []*stun.URI
+	for _, tier := range e.turnTiers {
+		newTURNs = append(newTURNs, tier...)
 	}
 	e.TURNs = newTURNs
 
@@ -667,7 +841,8 @@ func (e *Engine) updateNetworkMap(networkMap *mgmProto.NetworkMap) error {
 	if protoRoutes == nil {
 		protoRoutes = []*mgmProto.Route{}
 	}
-	err := e.routeManager.UpdateRoutes(serial, toRoutes(protoRoutes))
+	routes := toRoutesWithStaticRoutes(protoRoutes, e.OverlayNetwork(), e.config.WgPrivateKey.PublicKey().String())
+	err := e.routeManager.UpdateRoutes(serial, routes)
 	if err != nil {
 		log.Errorf("failed to update routes, err: %v", err)
 	}
@@ -677,7 +852,9 @@ func (e *Engine) updateNetworkMap(networkMap *mgmProto.NetworkMap) error {
 		protoDNSConfig = &mgmProto.DNSConfig{}
 	}
 
-	err = e.dnsServer.UpdateDNSServer(serial, toDNSConfig(protoDNSConfig))
+	dnsConfig := toDNSConfig(protoDNSConfig)
+	e.latestDNSConfig = &dnsConfig
+	err = e.dnsServer.UpdateDNSServer(serial, dnsConfig)
 	if err != nil {
 		log.Errorf("failed to update dns server, err: %v", err)
 	}
@@ -715,6 +892,7 @@ func toRoutes(protoRoutes []*mgmProto.Route) []*route.Route {
 func toDNSConfig(protoDNSConfig *mgmProto.DNSConfig) nbdns.Config {
 	dnsUpdate := nbdns.Config{
 		ServiceEnable:    protoDNSConfig.GetServiceEnable(),
+		RouteAllDisabled: protoDNSConfig.GetRouteAllDisabled(),
 		CustomZones:      make([]nbdns.CustomZone, 0),
 		NameServerGroups: make([]*nbdns.NameServerGroup, 0),
 	}
@@ -784,9 +962,13 @@ func (e *Engine) addNewPeers(peersUpdate []*mgmProto.RemotePeerConfig) error {
 // addNewPeer add peer if connection doesn't exist
 func (e *Engine) addNewPeer(peerConfig *mgmProto.RemotePeerConfig) error {
 	peerKey := peerConfig.GetWgPubKey()
-	peerIPs := peerConfig.GetAllowedIps()
-	if _, ok := e.peerConns[peerKey]; !ok {
-		conn, err := e.createPeerConn(peerKey, strings.Join(peerIPs, ","))
+	observedIP := net.ParseIP(peerConfig.GetObservedIP())
+	mappedAddress := parseMappedAddress(peerConfig.GetMappedAddress())
+	if conn, ok := e.peerConns[peerKey]; ok {
+		conn.UpdateObservedIP(observedIP)
+		conn.UpdateMappedAddress(mappedAddress)
+	} else {
+		conn, err := e.createPeerConn(peerKey, peerAllowedIPs(peerConfig), peerKeepalive(peerConfig), observedIP, mappedAddress, e.relayOnly(peerConfig))
 		if err != nil {
 			return err
 		}
@@ -797,12 +979,69 @@ func (e *Engine) addNewPeer(peerConfig *mgmProto.RemotePeerConfig) error {
 			log.Warnf("error adding peer %s to status recorder, got error: %v", peerKey, err)
 		}
 
-		go e.connWorker(conn, peerKey)
+		err = e.statusRecorder.UpdatePeerRelayByPolicy(peerKey, peerConfig.GetForceRelay())
+		if err != nil {
+			log.Warnf("error updating peer's %s relay by policy in the status recorder, got error: %v", peerKey, err)
+		}
+
+		previous := e.connWorkers[peerKey]
+		done := make(chan struct{})
+		e.connWorkers[peerKey] = done
+		go e.connWorker(conn, peerKey, previous, done)
 	}
 	return nil
 }
 
-func (e *Engine) connWorker(conn *peer.Conn, peerKey string) {
+// relayOnly returns true if the connection to the remote peer skips the direct candidates, either forced by the
+// management or because both peers are behind symmetric NATs which rarely allow a direct connection
+func (e *Engine) relayOnly(peerConfig *mgmProto.RemotePeerConfig) bool {
+	if peerConfig.GetForceRelay() {
+		return true
+	}
+	return e.config.NATType == nattype.Symmetric && nattype.Type(peerConfig.GetNatType()) == nattype.Symmetric
+}
+
+// peerKeepalive returns the interval of the WireGuard keepalives the management resolved from the groups of the remote
+// peer, 0 for the default interval
+func peerKeepalive(peerConfig *mgmProto.RemotePeerConfig) time.Duration {
+	return time.Duration(peerConfig.GetPersistentKeepalive()) * time.Second
+}
+
+// peerAllowedIPs returns the comma separated WireGuard allowed IPs of the remote peer. The IPv6 address of the peer
+// is left out until the firewall managers filter the IPv6 traffic, it would bypass the ACLs otherwise.
+func peerAllowedIPs(peerConfig *mgmProto.RemotePeerConfig) string {
+	return strings.Join(peerConfig.GetAllowedIps(), ",")
+}
+
+// parseMappedAddress returns the port mapping address of the remote peer, invalid if not set or malformed
+func parseMappedAddress(address string) netip.AddrPort {
+	if address == "" {
+		return netip.AddrPort{}
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		log.Debugf("ignoring invalid mapped address %q: %v", address, err)
+		return netip.AddrPort{}
+	}
+	return addrPort
+}
+
+// connWorker opens the connection to the peer until it is closed or superseded by another Conn of the peer.
+// It starts once the worker of the previous Conn of the peer, if any, has exited and closes done when it exits
+func (e *Engine) connWorker(conn *peer.Conn, peerKey string, previous <-chan struct{}, done chan struct{}) {
+	defer func() {
+		e.syncMsgMux.Lock()
+		if e.connWorkers[peerKey] == done {
+			delete(e.connWorkers, peerKey)
+		}
+		e.syncMsgMux.Unlock()
+		close(done)
+	}()
+
+	if previous != nil {
+		<-previous
+	}
+
 	for {
 
 		// randomize starting time a bit
@@ -810,8 +1049,8 @@ func (e *Engine) connWorker(conn *peer.Conn, peerKey string) {
 		max := 2000
 		time.Sleep(time.Duration(rand.Intn(max-min)+min) * time.Millisecond)
 
-		// if peer has been removed -> give up
-		if !e.peerExists(peerKey) {
+		// if peer has been removed or its connection superseded -> give up
+		if !e.isPeerConn(peerKey, conn) {
 			log.Debugf("peer %s doesn't exist anymore, won't retry connection", peerKey)
 			return
 		}
@@ -823,7 +1062,7 @@ func (e *Engine) connWorker(conn *peer.Conn, peerKey string) {
 
 		// we might have received new STUN and TURN servers meanwhile, so update them
 		e.syncMsgMux.Lock()
-		conn.UpdateStunTurn(append(e.STUNs, e.TURNs...))
+		conn.UpdateStunTurn(append(e.STUNs, e.TURNs...), e.turnTiers)
 		e.syncMsgMux.Unlock()
 
 		err := conn.Open()
@@ -839,14 +1078,15 @@ func (e *Engine) connWorker(conn *peer.Conn, peerKey string) {
 	}
 }
 
-func (e *Engine) peerExists(peerKey string) bool {
+// isPeerConn returns true if the Conn is the current connection of the peer
+func (e *Engine) isPeerConn(peerKey string, conn *peer.Conn) bool {
 	e.syncMsgMux.Lock()
 	defer e.syncMsgMux.Unlock()
-	_, ok := e.peerConns[peerKey]
-	return ok
+	current, ok := e.peerConns[peerKey]
+	return ok && current == conn
 }
 
-func (e *Engine) createPeerConn(pubKey string, allowedIPs string) (*peer.Conn, error) {
+func (e *Engine) createPeerConn(pubKey string, allowedIPs string, keepalive time.Duration, observedIP net.IP, mappedAddress netip.AddrPort, forceRelay bool) (*peer.Conn, error) {
 	log.Debugf("creating peer connection %s", pubKey)
 	var stunTurn []*stun.URI
 	stunTurn = append(stunTurn, e.STUNs...)
@@ -858,6 +1098,7 @@ func (e *Engine) createPeerConn(pubKey string, allowedIPs string) (*peer.Conn, e
 		WgInterface:  e.wgInterface,
 		AllowedIps:   allowedIPs,
 		PreSharedKey: e.config.PreSharedKey,
+		Keepalive:    keepalive,
 	}
 
 	if e.config.RosenpassEnabled {
@@ -880,23 +1121,28 @@ func (e *Engine) createPeerConn(pubKey string, allowedIPs string) (*peer.Conn, e
 		wgConfig.PreSharedKey = &key
 	}
 
-	// randomize connection timeout
-	timeout := time.Duration(rand.Intn(PeerConnectionTimeoutMax-PeerConnectionTimeoutMin)+PeerConnectionTimeoutMin) * time.Millisecond
 	config := peer.ConnConfig{
-		Key:                  pubKey,
-		LocalKey:             e.config.WgPrivateKey.PublicKey().String(),
-		StunTurn:             stunTurn,
-		InterfaceBlackList:   e.config.IFaceBlackList,
-		DisableIPv6Discovery: e.config.DisableIPv6Discovery,
-		Timeout:              timeout,
-		UDPMux:               e.udpMux.UDPMuxDefault,
-		UDPMuxSrflx:          e.udpMux,
-		WgConfig:             wgConfig,
-		LocalWgPort:          e.config.WgPort,
-		NATExternalIPs:       e.parseNATExternalIPMappings(),
-		UserspaceBind:        e.wgInterface.IsUserspaceBind(),
-		RosenpassPubKey:      e.getRosenpassPubKey(),
-		RosenpassAddr:        e.getRosenpassAddr(),
+		Key:                   pubKey,
+		LocalKey:              e.config.WgPrivateKey.PublicKey().String(),
+		StunTurn:              stunTurn,
+		TURNTiers:             e.turnTiers,
+		InterfaceBlackList:    e.config.IFaceBlackList,
+		DisableIPv6Discovery:  e.config.DisableIPv6Discovery,
+		ConnOrdering:          e.config.ConnOrdering,
+		RelayFallbackAttempts: e.config.RelayFallbackAttempts,
+		ForceRelay:            forceRelay,
+		Timeout:               e.peerConnectionTimeout(),
+		UDPMux:                e.udpMux.UDPMuxDefault,
+		UDPMuxSrflx:           e.udpMux,
+		WgConfig:              wgConfig,
+		LocalWgPort:           e.config.WgPort,
+		NATExternalIPs:        e.parseNATExternalIPMappings(),
+		UserspaceBind:         e.wgInterface.IsUserspaceBind(),
+		RosenpassPubKey:       e.getRosenpassPubKey(),
+		RosenpassAddr:         e.getRosenpassAddr(),
+		ObservedIP:            observedIP,
+		MappedAddress:         mappedAddress,
+		NegotiationLimiter:    e.negotiationLimiter,
 	}
 
 	peerConn, err := peer.NewConn(config, e.statusRecorder, e.wgProxyFactory, e.mobileDep.TunAdapter, e.mobileDep.IFaceDiscover)
@@ -1076,6 +1322,15 @@ func (e *Engine) parseNATExternalIPMappings() []string {
 }
 
 func (e *Engine) close() {
+	if e.wgStatsCancel != nil {
+		e.wgStatsCancel()
+	}
+
+	// the watchdog is stopped before the interface is removed, so it doesn't recover it
+	if e.wgWatchdogCancel != nil {
+		e.wgWatchdogCancel()
+	}
+
 	if err := e.wgProxyFactory.Free(); err != nil {
 		log.Errorf("failed closing ebpf proxy: %s", err)
 	}
@@ -1103,6 +1358,10 @@ func (e *Engine) close() {
 		e.routeManager.Stop()
 	}
 
+	if e.acl != nil {
+		e.acl.Stop()
+	}
+
 	if e.firewall != nil {
 		err := e.firewall.Reset()
 		if err != nil {
@@ -1116,11 +1375,11 @@ func (e *Engine) close() {
 }
 
 func (e *Engine) readInitialSettings() ([]*route.Route, *nbdns.Config, error) {
-	netMap, err := e.mgmClient.GetNetworkMap()
+	netMap, err := e.mgmClient.GetNetworkMap(e.syncSystemInfo())
 	if err != nil {
 		return nil, nil, err
 	}
-	routes := toRoutes(netMap.GetRoutes())
+	routes := toRoutesWithStaticRoutes(netMap.GetRoutes(), e.OverlayNetwork(), e.config.WgPrivateKey.PublicKey().String())
 	dnsCfg := toDNSConfig(netMap.GetDNSConfig())
 	return routes, &dnsCfg, nil
 }
@@ -1145,7 +1404,12 @@ func (e *Engine) newWgIface() (*iface.WGIface, error) {
 	default:
 	}
 
-	return iface.NewWGIFace(e.config.WgIfaceName, e.config.WgAddr, e.config.WgPort, e.config.WgPrivateKey.String(), iface.DefaultMTU, transportNet, mArgs)
+	mtu := iface.DefaultMTU
+	if e.config.MTU > 0 {
+		mtu = e.config.MTU
+	}
+
+	return iface.NewWGIFace(e.config.WgIfaceName, e.config.WgAddr, e.config.WgPort, e.config.WgPrivateKey.String(), mtu, transportNet, mArgs)
 }
 
 func (e *Engine) wgInterfaceCreate() (err error) {
@@ -1161,6 +1425,18 @@ func (e *Engine) wgInterfaceCreate() (err error) {
 	return err
 }
 
+// enableRouteIsolation marks the packets encrypted by the Wireguard interface and installs the client routes to the
+// routing table of the mark, so the overlay routes never apply to the tunnel traffic
+func (e *Engine) enableRouteIsolation() error {
+	if err := e.wgInterface.SetFwmark(e.config.WgFwmark); err != nil {
+		return fmt.Errorf("set the firewall mark of the interface: %w", err)
+	}
+	if err := e.routeManager.EnableRouteIsolation(e.config.WgFwmark); err != nil {
+		return fmt.Errorf("isolate the client routes: %w", err)
+	}
+	return nil
+}
+
 func (e *Engine) newDnsServer() ([]*route.Route, dns.Server, error) {
 	// due to tests where we are using a mocked version of the DNS server
 	if e.dnsServer != nil {
@@ -1179,7 +1455,7 @@ func (e *Engine) newDnsServer() ([]*route.Route, dns.Server, error) {
 		dnsServer := dns.NewDefaultServerIos(e.ctx, e.wgInterface, e.mobileDep.DnsManager)
 		return nil, dnsServer, nil
 	default:
-		dnsServer, err := dns.NewDefaultServer(e.ctx, e.wgInterface, e.config.CustomDNSAddress)
+		dnsServer, err := dns.NewDefaultServer(e.ctx, e.wgInterface, e.config.CustomDNSAddress, e.config.DNSExcludedDomains)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -1262,21 +1538,91 @@ func (e *Engine) receiveProbeEvents() {
 	if e.wgProbe != nil {
 		go e.wgProbe.Receive(e.ctx, func() bool {
 			log.Debug("received wg probe request")
+			e.updateWGStats()
+			return true
+		})
+	}
+}
 
-			for _, peer := range e.peerConns {
-				key := peer.GetKey()
-				wgStats, err := peer.GetConf().WgConfig.WgInterface.GetStats(key)
-				if err != nil {
-					log.Debugf("failed to get wg stats for peer %s: %s", key, err)
-				}
-				// wgStats could be zero value, in which case we just reset the stats
-				if err := e.statusRecorder.UpdateWireguardPeerState(key, wgStats); err != nil {
-					log.Debugf("failed to update wg stats for peer %s: %s", key, err)
-				}
+// startWGStatsCollector periodically records the WireGuard stats of the peers in the status recorder,
+// so the transfer totals and rates are up-to-date even without status requests. The collector is stopped
+// when the engine is closed.
+func (e *Engine) startWGStatsCollector() {
+	ctx, cancel := context.WithCancel(e.ctx)
+	e.wgStatsCancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(wgStatsInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.updateWGStats()
 			}
+		}
+	}()
+}
 
-			return true
-		})
+// updateWGStats reads the transfer counters and the last handshake of the peers from the WireGuard interface
+// and records them in the status recorder. It works the same for kernel and userspace interfaces.
+func (e *Engine) updateWGStats() {
+	e.syncMsgMux.Lock()
+	wgInterface := e.wgInterface
+	keys := make([]string, 0, len(e.peerConns))
+	for key := range e.peerConns {
+		keys = append(keys, key)
+	}
+	e.syncMsgMux.Unlock()
+
+	if wgInterface == nil {
+		return
+	}
+
+	for _, key := range keys {
+		wgStats, err := wgInterface.GetStats(key)
+		if err != nil {
+			log.Debugf("failed to get wg stats for peer %s: %s", key, err)
+		}
+		// wgStats could be zero value, in which case we just reset the stats
+		if err := e.statusRecorder.UpdateWireguardPeerState(key, wgStats); err != nil {
+			log.Debugf("failed to update wg stats for peer %s: %s", key, err)
+		}
+		e.checkStaleHandshake(key, wgStats)
+	}
+}
+
+// checkStaleHandshake marks the connected peer as stale in the status recorder when its WireGuard handshake and
+// traffic stopped and reconnects the peer if configured
+func (e *Engine) checkStaleHandshake(peerKey string, wgStats iface.WGStats) {
+	state, err := e.statusRecorder.GetPeer(peerKey)
+	if err != nil || (state.ConnStatus != peer.StatusConnected && state.ConnStatus != peer.StatusStale) {
+		e.staleDetector.remove(peerKey)
+		return
+	}
+
+	wasStale := e.staleDetector.isStale(peerKey)
+	stale := e.staleDetector.update(peerKey, wgStats, time.Now())
+	if _, err := e.statusRecorder.UpdatePeerStaleness(peerKey, stale); err != nil {
+		log.Debugf("failed to update staleness of peer %s: %s", peerKey, err)
+	}
+
+	if !stale || wasStale {
+		return
+	}
+
+	log.Warnf("peer %s is stale, last WireGuard handshake: %s", peerKey, wgStats.LastHandshake)
+	if !e.config.StaleHandshakeReconnect {
+		return
+	}
+
+	e.syncMsgMux.Lock()
+	conn, ok := e.peerConns[peerKey]
+	e.syncMsgMux.Unlock()
+	if ok {
+		conn.Reconnect()
 	}
 }
 
@@ -1287,3 +1633,41 @@ func (e *Engine) probeSTUNs() []relay.ProbeResult {
 func (e *Engine) probeTURNs() []relay.ProbeResult {
 	return relay.ProbeAll(e.ctx, relay.ProbeTURN, e.TURNs)
 }
+
+// FirewallRuleCounters returns the counters of the ACL firewall rules. The firewall has to count the packets matched
+// by its rules, see EngineConfig.FirewallFlowLogging.
+func (e *Engine) FirewallRuleCounters() ([]manager.RuleCounter, error) {
+	e.syncMsgMux.Lock()
+	fw := e.firewall
+	e.syncMsgMux.Unlock()
+
+	accounting, ok := fw.(manager.FlowAccounting)
+	if !ok {
+		return nil, errors.New("the firewall doesn't support the flow accounting")
+	}
+	return accounting.RuleCounters()
+}
+
+// WGDeviceState returns the state of the WireGuard interface without its private and preshared keys
+func (e *Engine) WGDeviceState() (*wgtypes.Device, error) {
+	e.syncMsgMux.Lock()
+	wgInterface := e.wgInterface
+	e.syncMsgMux.Unlock()
+
+	if wgInterface == nil {
+		return nil, errors.New("the WireGuard interface is not created")
+	}
+	return wgInterface.DeviceState()
+}
+
+// DNSResolverState returns the configuration of the local DNS resolver
+func (e *Engine) DNSResolverState() (dns.ResolverState, error) {
+	e.syncMsgMux.Lock()
+	dnsServer := e.dnsServer
+	e.syncMsgMux.Unlock()
+
+	if dnsServer == nil {
+		return dns.ResolverState{}, errors.New("the DNS resolver is not started")
+	}
+	return dnsServer.ResolverState(), nil
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/client/internal/dns"
+)
+
+const (
+	reloadSubsystemDNS             = "dns"
+	reloadSubsystemInterfaceFilter = "interface blacklist"
+	reloadSubsystemNATExternalIPs  = "nat external ips"
+)
+
+// ReloadConfig applies the changed reloadable settings of the config to the running engine:
+// the custom DNS address, the interface blacklist and the NAT external IPs.
+// The peer connections are kept, the new ICE candidate filters are used when a connection is re-established.
+// It returns the names of the reloaded subsystems.
+func (e *Engine) ReloadConfig(config *EngineConfig) ([]string, error) {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	var reloaded, unchanged []string
+
+	blackListChanged := !slices.Equal(e.config.IFaceBlackList, config.IFaceBlackList)
+	natExternalIPsChanged := !slices.Equal(e.config.NATExternalIPs, config.NATExternalIPs)
+	if blackListChanged {
+		e.config.IFaceBlackList = config.IFaceBlackList
+		reloaded = append(reloaded, reloadSubsystemInterfaceFilter)
+	} else {
+		unchanged = append(unchanged, reloadSubsystemInterfaceFilter)
+	}
+	if natExternalIPsChanged {
+		e.config.NATExternalIPs = config.NATExternalIPs
+		reloaded = append(reloaded, reloadSubsystemNATExternalIPs)
+	} else {
+		unchanged = append(unchanged, reloadSubsystemNATExternalIPs)
+	}
+
+	if blackListChanged || natExternalIPsChanged {
+		natExternalIPs := e.parseNATExternalIPMappings()
+		for _, conn := range e.peerConns {
+			conn.UpdateCandidateFilters(e.config.IFaceBlackList, natExternalIPs)
+		}
+		log.Debugf("updated the ICE candidate filters of %d peer connections", len(e.peerConns))
+	}
+
+	if e.config.CustomDNSAddress != config.CustomDNSAddress {
+		if err := e.reloadDNSServer(config.CustomDNSAddress); err != nil {
+			return reloaded, fmt.Errorf("reload dns server: %w", err)
+		}
+		reloaded = append(reloaded, reloadSubsystemDNS)
+	} else {
+		unchanged = append(unchanged, reloadSubsystemDNS)
+	}
+
+	log.Infof("reloaded config, reloaded: %v, no change: %v", reloaded, unchanged)
+	return reloaded, nil
+}
+
+// reloadDNSServer replaces the DNS server with one listening on the new custom address
+// and applies the latest DNS config received from the Management service to it
+func (e *Engine) reloadDNSServer(customDNSAddress string) error {
+	switch runtime.GOOS {
+	case "android", "ios":
+		return fmt.Errorf("custom DNS address is not supported on %s", runtime.GOOS)
+	}
+
+	dnsServer, err := dns.NewDefaultServer(e.ctx, e.wgInterface, customDNSAddress, e.config.DNSExcludedDomains)
+	if err != nil {
+		return err
+	}
+
+	if e.dnsServer != nil {
+		e.dnsServer.Stop()
+	}
+
+	if err := dnsServer.Initialize(); err != nil {
+		return err
+	}
+
+	e.dnsServer = dnsServer
+	e.config.CustomDNSAddress = customDNSAddress
+	e.statusRecorder.SetDNSStatsGetter(dnsServer.QueryStats)
+
+	if e.latestDNSConfig != nil {
+		if err := e.dnsServer.UpdateDNSServer(e.networkSerial, *e.latestDNSConfig); err != nil {
+			log.Errorf("failed to apply the dns config to the reloaded dns server: %v", err)
+		}
+		e.dnsServer.ProbeAvailability()
+	}
+
+	return nil
+}
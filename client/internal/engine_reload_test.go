@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+	mgmt "github.com/netbirdio/netbird/management/client"
+	signal "github.com/netbirdio/netbird/signal/client"
+)
+
+func TestEngine_ReloadConfig(t *testing.T) {
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine := NewEngine(ctx, cancel, &signal.MockClient{}, &mgmt.MockClient{}, &EngineConfig{
+		WgIfaceName:    "utun102",
+		WgAddr:         "100.64.0.1/24",
+		WgPrivateKey:   key,
+		WgPort:         33100,
+		IFaceBlackList: []string{"wt0"},
+	}, MobileDependency{}, peer.NewRecorder("https://mgm"))
+
+	conn, err := peer.NewConn(peer.ConnConfig{
+		Key:                "remote",
+		InterfaceBlackList: []string{"wt0"},
+	}, engine.statusRecorder, nil, nil, nil)
+	require.NoError(t, err)
+	engine.peerConns["remote"] = conn
+
+	reloaded, err := engine.ReloadConfig(&EngineConfig{IFaceBlackList: []string{"wt0"}})
+	require.NoError(t, err)
+	assert.Empty(t, reloaded, "nothing should be reloaded without changes")
+
+	reloaded, err = engine.ReloadConfig(&EngineConfig{
+		IFaceBlackList: []string{"wt0", "docker0"},
+		NATExternalIPs: []string{"1.1.1.1"},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{reloadSubsystemInterfaceFilter, reloadSubsystemNATExternalIPs}, reloaded)
+
+	assert.Equal(t, []string{"wt0", "docker0"}, engine.config.IFaceBlackList)
+	assert.Equal(t, []string{"1.1.1.1"}, engine.config.NATExternalIPs)
+	assert.Equal(t, []string{"wt0", "docker0"}, conn.GetConf().InterfaceBlackList)
+	assert.Equal(t, []string{"1.1.1.1"}, conn.GetConf().NATExternalIPs)
+}
+
+func TestReloadConfig_NotRunning(t *testing.T) {
+	ctx := CtxInitState(context.Background())
+
+	_, err := ReloadConfig(ctx, &Config{})
+	assert.Error(t, err)
+}
@@ -22,6 +22,7 @@ import (
 	"google.golang.org/grpc/keepalive"
 
 	"github.com/netbirdio/netbird/client/internal/dns"
+	"github.com/netbirdio/netbird/client/internal/nattype"
 	"github.com/netbirdio/netbird/client/internal/peer"
 	"github.com/netbirdio/netbird/client/internal/routemanager"
 	"github.com/netbirdio/netbird/client/ssh"
@@ -256,6 +257,18 @@ func TestEngine_UpdateNetworkMap(t *testing.T) {
 		AllowedIps: []string{"100.64.0.20/24"},
 	}
 
+	forcedRelayPeer2 := &mgmtProto.RemotePeerConfig{
+		WgPubKey:   "LLHf3Ma6z6mdLbriAJbqhX7+nM/B71lgw2+91q3LfhU=",
+		AllowedIps: []string{"100.64.0.11/24"},
+		ForceRelay: true,
+	}
+
+	keepalivePeer3 := &mgmtProto.RemotePeerConfig{
+		WgPubKey:            "GGHf3Ma6z6mdLbriAJbqhX7+nM/B71lgw2+91q3LfhU=",
+		AllowedIps:          []string{"100.64.0.20/24"},
+		PersistentKeepalive: 10,
+	}
+
 	case1 := testCase{
 		name: "input with a new peer to add",
 		networkMap: &mgmtProto.NetworkMap{
@@ -333,6 +346,36 @@ func TestEngine_UpdateNetworkMap(t *testing.T) {
 	}
 
 	case6 := testCase{
+		name: "input with one peer forced to relay",
+		networkMap: &mgmtProto.NetworkMap{
+			Serial:     4,
+			PeerConfig: nil,
+			RemotePeers: []*mgmtProto.RemotePeerConfig{
+				modifiedPeer3, forcedRelayPeer2,
+			},
+			RemotePeersIsEmpty: false,
+		},
+		expectedLen:    2,
+		expectedPeers:  []*mgmtProto.RemotePeerConfig{forcedRelayPeer2, modifiedPeer3},
+		expectedSerial: 4,
+	}
+
+	case7 := testCase{
+		name: "input with one peer with a keepalive interval",
+		networkMap: &mgmtProto.NetworkMap{
+			Serial:     4,
+			PeerConfig: nil,
+			RemotePeers: []*mgmtProto.RemotePeerConfig{
+				keepalivePeer3, forcedRelayPeer2,
+			},
+			RemotePeersIsEmpty: false,
+		},
+		expectedLen:    2,
+		expectedPeers:  []*mgmtProto.RemotePeerConfig{forcedRelayPeer2, keepalivePeer3},
+		expectedSerial: 4,
+	}
+
+	case8 := testCase{
 		name: "input with all peers to remove",
 		networkMap: &mgmtProto.NetworkMap{
 			Serial:             5,
@@ -345,7 +388,7 @@ func TestEngine_UpdateNetworkMap(t *testing.T) {
 		expectedSerial: 5,
 	}
 
-	for _, c := range []testCase{case1, case2, case3, case4, case5, case6} {
+	for _, c := range []testCase{case1, case2, case3, case4, case5, case6, case7, case8} {
 		t.Run(c.name, func(t *testing.T) {
 			err = engine.updateNetworkMap(c.networkMap)
 			if err != nil {
@@ -371,11 +414,102 @@ func TestEngine_UpdateNetworkMap(t *testing.T) {
 					t.Errorf("expecting peer %s to have AllowedIPs= %s, got %s", p.GetWgPubKey(),
 						expectedAllowedIPs, conn.WgConfig().AllowedIps)
 				}
+				if conn.GetConf().ForceRelay != p.GetForceRelay() {
+					t.Errorf("expecting peer %s to have ForceRelay= %t, got %t", p.GetWgPubKey(),
+						p.GetForceRelay(), conn.GetConf().ForceRelay)
+				}
+				expectedKeepalive := time.Duration(p.GetPersistentKeepalive()) * time.Second
+				if conn.WgConfig().Keepalive != expectedKeepalive {
+					t.Errorf("expecting peer %s to have Keepalive= %s, got %s", p.GetWgPubKey(),
+						expectedKeepalive, conn.WgConfig().Keepalive)
+				}
 			}
 		})
 	}
 }
 
+func TestEngine_UpdateNetworkMapReAddedPeer(t *testing.T) {
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the signal client is never ready, so the connection workers don't open the connections
+	engine := NewEngine(ctx, cancel, &signal.MockClient{}, &mgmt.MockClient{}, &EngineConfig{
+		WgIfaceName:  "utun120",
+		WgAddr:       "100.64.0.1/24",
+		WgPrivateKey: key,
+		WgPort:       33100,
+	}, MobileDependency{}, peer.NewRecorder("https://mgm"))
+	newNet, err := stdnet.NewNet()
+	require.NoError(t, err)
+	engine.wgInterface, err = iface.NewWGIFace("utun120", "100.64.0.1/24", engine.config.WgPort, key.String(), iface.DefaultMTU, newNet, nil)
+	require.NoError(t, err)
+	engine.routeManager = routemanager.NewManager(ctx, key.PublicKey().String(), engine.wgInterface, engine.statusRecorder, nil)
+	engine.dnsServer = &dns.MockServer{
+		UpdateDNSServerFunc: func(serial uint64, update nbdns.Config) error { return nil },
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	require.NoError(t, err)
+	engine.udpMux = bind.NewUniversalUDPMuxDefault(bind.UniversalUDPMuxParams{UDPConn: conn})
+
+	var peers []*mgmtProto.RemotePeerConfig
+	for i := 1; i <= 5; i++ {
+		peerKey, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peers = append(peers, &mgmtProto.RemotePeerConfig{
+			WgPubKey:   peerKey.PublicKey().String(),
+			AllowedIps: []string{fmt.Sprintf("100.64.0.%d/32", 10+i)},
+		})
+	}
+	peer4 := peers[3].GetWgPubKey()
+
+	update := func(serial uint64, remotePeers []*mgmtProto.RemotePeerConfig) {
+		t.Helper()
+		engine.syncMsgMux.Lock()
+		defer engine.syncMsgMux.Unlock()
+		require.NoError(t, engine.updateNetworkMap(&mgmtProto.NetworkMap{Serial: serial, RemotePeers: remotePeers}))
+	}
+
+	update(1, peers[:4])
+	engine.syncMsgMux.Lock()
+	firstConn := engine.peerConns[peer4]
+	firstWorker := engine.connWorkers[peer4]
+	engine.syncMsgMux.Unlock()
+	require.NotNil(t, firstConn)
+	require.NotNil(t, firstWorker)
+
+	update(2, peers[:3])
+	update(3, peers)
+
+	engine.syncMsgMux.Lock()
+	assert.Len(t, engine.peerConns, 5)
+	secondConn := engine.peerConns[peer4]
+	secondWorker := engine.connWorkers[peer4]
+	engine.syncMsgMux.Unlock()
+	require.NotNil(t, secondConn)
+	assert.NotSame(t, firstConn, secondConn, "the re-added peer should get a new connection")
+
+	var closedErr *peer.ConnectionClosedError
+	assert.ErrorAs(t, firstConn.Open(), &closedErr, "the connection of the removed peer shouldn't be opened")
+
+	select {
+	case <-firstWorker:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the worker of the superseded connection should exit")
+	}
+
+	select {
+	case <-secondWorker:
+		t.Fatal("the worker of the current connection shouldn't exit")
+	default:
+	}
+	engine.syncMsgMux.Lock()
+	assert.Len(t, engine.connWorkers, 5, "there should be a single live connection per peer")
+	engine.syncMsgMux.Unlock()
+}
+
 func TestEngine_Sync(t *testing.T) {
 	key, err := wgtypes.GeneratePrivateKey()
 	if err != nil {
@@ -389,7 +523,7 @@ func TestEngine_Sync(t *testing.T) {
 	// feed updates to Engine via mocked Management client
 	updates := make(chan *mgmtProto.SyncResponse)
 	defer close(updates)
-	syncFunc := func(msgHandler func(msg *mgmtProto.SyncResponse) error) error {
+	syncFunc := func(_ *system.Info, msgHandler func(msg *mgmtProto.SyncResponse) error) error {
 		for msg := range updates {
 			err := msgHandler(msg)
 			if err != nil {
@@ -892,6 +1026,62 @@ loop:
 	}
 }
 
+func TestEngine_UpdateTURNsByPriority(t *testing.T) {
+	engine := &Engine{}
+	turn := func(uri string, priority, weight int32) *mgmtProto.ProtectedHostConfig {
+		return &mgmtProto.ProtectedHostConfig{
+			HostConfig: &mgmtProto.HostConfig{Uri: uri},
+			User:       "user",
+			Password:   "password",
+			Priority:   priority,
+			Weight:     weight,
+		}
+	}
+
+	err := engine.updateTURNs([]*mgmtProto.ProtectedHostConfig{
+		turn("turn:us.turn.netbird.io:3478", 1, 0),
+		turn("turn:eu.turn.netbird.io:3478", 0, 0),
+		turn("turn:eu2.turn.netbird.io:3478", 0, 10),
+	})
+	require.NoError(t, err)
+
+	var hosts []string
+	for _, url := range engine.TURNs {
+		hosts = append(hosts, url.Host)
+		assert.Equal(t, "user", url.Username)
+	}
+	assert.Equal(t, []string{"eu2.turn.netbird.io", "eu.turn.netbird.io", "us.turn.netbird.io"}, hosts)
+	require.Len(t, engine.turnTiers, 3, "the lighter server of the same priority should be a fallback tier")
+	assert.Equal(t, "eu2.turn.netbird.io", engine.turnTiers[0][0].Host)
+	assert.Equal(t, "eu.turn.netbird.io", engine.turnTiers[1][0].Host)
+	assert.Equal(t, "us.turn.netbird.io", engine.turnTiers[2][0].Host)
+}
+
+func TestEngine_SignalChanged(t *testing.T) {
+	engine := &Engine{config: &EngineConfig{SignalAddress: "https://signal.netbird.io:443"}}
+
+	assert.False(t, engine.signalChanged(nil), "an update without a Signal service shouldn't change it")
+	assert.False(t, engine.signalChanged(&mgmtProto.HostConfig{Uri: "signal.netbird.io:443", Protocol: mgmtProto.HostConfig_HTTPS}))
+	assert.True(t, engine.signalChanged(&mgmtProto.HostConfig{Uri: "signal.eu.netbird.io:443", Protocol: mgmtProto.HostConfig_HTTPS}))
+	assert.True(t, engine.signalChanged(&mgmtProto.HostConfig{Uri: "signal.netbird.io:443", Protocol: mgmtProto.HostConfig_HTTP}))
+}
+
+func TestEngine_UpdateConnectionTimeout(t *testing.T) {
+	conn, err := peer.NewConn(peer.ConnConfig{Key: "remote", Timeout: 40 * time.Second}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	engine := &Engine{peerConns: map[string]*peer.Conn{"remote": conn}}
+
+	engine.updateConnectionTimeout(20 * time.Second)
+	assert.Equal(t, 20*time.Second, engine.peerConnectionTimeout(), "the new connections should use the timeout of the Management service")
+	assert.Equal(t, 20*time.Second, conn.GetConf().Timeout, "the next attempts of the existing connections should use it")
+
+	engine.updateConnectionTimeout(0)
+	timeout := engine.peerConnectionTimeout()
+	assert.GreaterOrEqual(t, timeout, PeerConnectionTimeoutMin*time.Millisecond, "the default of the client should be restored")
+	assert.Less(t, timeout, PeerConnectionTimeoutMax*time.Millisecond, "the default of the client should be restored")
+	assert.NotEqual(t, 20*time.Second, conn.GetConf().Timeout)
+}
+
 func Test_ParseNATExternalIPMappings(t *testing.T) {
 	ifaceList, err := net.Interfaces()
 	if err != nil {
@@ -965,11 +1155,11 @@ func createEngine(ctx context.Context, cancel context.CancelFunc, setupKey strin
 	if err != nil {
 		return nil, err
 	}
-	mgmtClient, err := mgmt.NewClient(ctx, mgmtAddr, key, false)
+	mgmtClient, err := mgmt.NewClient(ctx, mgmtAddr, key, false, nil, nil)
 	if err != nil {
 		return nil, err
 	}
-	signalClient, err := signal.NewClient(ctx, signalAddr, key, false)
+	signalClient, err := signal.NewClient(ctx, signalAddr, key, false, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1088,3 +1278,80 @@ func getPeers(e *Engine) int {
 
 	return len(e.peerConns)
 }
+
+func TestPeerAllowedIPs(t *testing.T) {
+	testCases := []struct {
+		name       string
+		peerConfig *mgmtProto.RemotePeerConfig
+		expected   string
+	}{
+		{
+			name:       "IPv4 Only",
+			peerConfig: &mgmtProto.RemotePeerConfig{AllowedIps: []string{"100.64.0.10/32"}},
+			expected:   "100.64.0.10/32",
+		},
+		{
+			name: "IPv6 Left Out Until Filtered",
+			peerConfig: &mgmtProto.RemotePeerConfig{
+				AllowedIps: []string{"100.64.0.10/32"},
+				AddressV6:  "fd00:1234:5678:9abc::10/128",
+			},
+			expected: "100.64.0.10/32",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			allowedIPs := peerAllowedIPs(testCase.peerConfig)
+			if allowedIPs != testCase.expected {
+				t.Errorf("expected allowed IPs %s, got %s", testCase.expected, allowedIPs)
+			}
+			if len(testCase.peerConfig.AllowedIps) != 1 {
+				t.Errorf("peer config allowed IPs should not be modified")
+			}
+		})
+	}
+}
+
+func TestEngine_RelayOnly(t *testing.T) {
+	testCases := []struct {
+		name       string
+		natType    nattype.Type
+		peerConfig *mgmtProto.RemotePeerConfig
+		expected   bool
+	}{
+		{
+			name:       "Forced By Management",
+			natType:    nattype.Cone,
+			peerConfig: &mgmtProto.RemotePeerConfig{ForceRelay: true},
+			expected:   true,
+		},
+		{
+			name:       "Both Symmetric",
+			natType:    nattype.Symmetric,
+			peerConfig: &mgmtProto.RemotePeerConfig{NatType: string(nattype.Symmetric)},
+			expected:   true,
+		},
+		{
+			name:       "Remote Cone",
+			natType:    nattype.Symmetric,
+			peerConfig: &mgmtProto.RemotePeerConfig{NatType: string(nattype.Cone)},
+			expected:   false,
+		},
+		{
+			name:       "Local Unknown",
+			natType:    nattype.Unknown,
+			peerConfig: &mgmtProto.RemotePeerConfig{NatType: string(nattype.Symmetric)},
+			expected:   false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			engine := &Engine{config: &EngineConfig{NATType: testCase.natType}}
+			if relayOnly := engine.relayOnly(testCase.peerConfig); relayOnly != testCase.expected {
+				t.Errorf("expected relay only %t, got %t", testCase.expected, relayOnly)
+			}
+		})
+	}
+}
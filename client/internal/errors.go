@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	gstatus "google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+// ClientError classifies the errors of the login and of the engine start, so the user can get an actionable guidance.
+// It carries the gRPC code of the wrapped error and attaches the proto.ErrorDetails to it,
+// so the error keeps its gRPC code and its classification when returned by the daemon.
+type ClientError struct {
+	Code proto.ErrorCode
+	// ManagementURL is set when the Management Service or the Signal Service is unreachable
+	ManagementURL string
+	// SignalURL is set when the Signal Service is unreachable
+	SignalURL string
+	Err       error
+}
+
+// NewConfigInvalidError returns a ClientError for an invalid client configuration
+func NewConfigInvalidError(err error) *ClientError {
+	return &ClientError{Code: proto.ErrorCode_CONFIG_INVALID, Err: err}
+}
+
+// NewManagementUnreachableError returns a ClientError for an unreachable Management Service
+func NewManagementUnreachableError(managementURL string, err error) *ClientError {
+	return &ClientError{Code: proto.ErrorCode_MANAGEMENT_UNREACHABLE, ManagementURL: managementURL, Err: err}
+}
+
+// NewSignalUnreachableError returns a ClientError for an unreachable Signal Service
+func NewSignalUnreachableError(managementURL, signalURL string, err error) *ClientError {
+	return &ClientError{Code: proto.ErrorCode_SIGNAL_UNREACHABLE, ManagementURL: managementURL, SignalURL: signalURL, Err: err}
+}
+
+// NewAuthRequiredError returns a ClientError for a peer which has to login or register first
+func NewAuthRequiredError(err error) *ClientError {
+	return &ClientError{Code: proto.ErrorCode_AUTH_REQUIRED, Err: err}
+}
+
+// NewInterfaceCreationError returns a ClientError for a failed creation of the WireGuard interface
+func NewInterfaceCreationError(err error) *ClientError {
+	return &ClientError{Code: proto.ErrorCode_INTERFACE_CREATION_FAILED, Err: err}
+}
+
+func (e *ClientError) Error() string {
+	switch e.Code {
+	case proto.ErrorCode_CONFIG_INVALID:
+		return fmt.Sprintf("invalid configuration: %v", e.Err)
+	case proto.ErrorCode_MANAGEMENT_UNREACHABLE:
+		return fmt.Sprintf("management service %s is unreachable: %v", e.ManagementURL, e.Err)
+	case proto.ErrorCode_SIGNAL_UNREACHABLE:
+		return fmt.Sprintf("signal service %s (received from management service %s) is unreachable: %v",
+			e.SignalURL, e.ManagementURL, e.Err)
+	case proto.ErrorCode_AUTH_REQUIRED:
+		return fmt.Sprintf("authentication required: %v", e.Err)
+	case proto.ErrorCode_INTERFACE_CREATION_FAILED:
+		return fmt.Sprintf("failed creating the WireGuard interface: %v", e.Err)
+	default:
+		return e.Err.Error()
+	}
+}
+
+func (e *ClientError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus returns the status of the wrapped error with the attached proto.ErrorDetails.
+// Errors without a gRPC status get the code matching the classification.
+func (e *ClientError) GRPCStatus() *gstatus.Status {
+	code := e.defaultGRPCCode()
+	if s, ok := gstatus.FromError(e.Err); ok && s != nil {
+		code = s.Code()
+	}
+
+	s := gstatus.New(code, e.Error())
+	detailed, err := s.WithDetails(&proto.ErrorDetails{
+		Code:          e.Code,
+		ManagementUrl: e.ManagementURL,
+		SignalUrl:     e.SignalURL,
+	})
+	if err != nil {
+		return s
+	}
+	return detailed
+}
+
+func (e *ClientError) defaultGRPCCode() codes.Code {
+	switch e.Code {
+	case proto.ErrorCode_CONFIG_INVALID:
+		return codes.FailedPrecondition
+	case proto.ErrorCode_MANAGEMENT_UNREACHABLE, proto.ErrorCode_SIGNAL_UNREACHABLE:
+		return codes.Unavailable
+	case proto.ErrorCode_AUTH_REQUIRED:
+		return codes.PermissionDenied
+	case proto.ErrorCode_INTERFACE_CREATION_FAILED:
+		return codes.Internal
+	default:
+		return codes.Unknown
+	}
+}
+
+// ErrorDetailsFromError returns the classification of the error, either a ClientError or a daemon call error,
+// it returns nil for the not classified errors
+func ErrorDetailsFromError(err error) *proto.ErrorDetails {
+	s, ok := gstatus.FromError(err)
+	if !ok || s == nil {
+		return nil
+	}
+
+	for _, detail := range s.Details() {
+		if details, ok := detail.(*proto.ErrorDetails); ok {
+			return details
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	gstatus "google.golang.org/grpc/status"
+
+	"github.com/netbirdio/netbird/client/proto"
+)
+
+func TestClientError_GRPCStatus(t *testing.T) {
+	testCases := []struct {
+		name         string
+		err          *ClientError
+		expectedCode codes.Code
+	}{
+		{
+			name:         "Config Invalid",
+			err:          NewConfigInvalidError(errors.New("invalid key")),
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			name:         "Management Unreachable Keeps Code",
+			err:          NewManagementUnreachableError("https://api.netbird.io:443", gstatus.Error(codes.DeadlineExceeded, "timeout")),
+			expectedCode: codes.DeadlineExceeded,
+		},
+		{
+			name:         "Signal Unreachable",
+			err:          NewSignalUnreachableError("https://api.netbird.io:443", "https://signal.netbird.io:443", errors.New("timeout")),
+			expectedCode: codes.Unavailable,
+		},
+		{
+			name:         "Auth Required Keeps Code",
+			err:          NewAuthRequiredError(gstatus.Error(codes.InvalidArgument, "invalid setup key")),
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Interface Creation Failed",
+			err:          NewInterfaceCreationError(errors.New("operation not permitted")),
+			expectedCode: codes.Internal,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// wrapped like the errors stored in the state and returned by the daemon
+			err := fmt.Errorf("run client: %w", testCase.err)
+
+			s, ok := gstatus.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, testCase.expectedCode, s.Code())
+			assert.Contains(t, s.Message(), testCase.err.Error())
+
+			// the details have to survive the conversion to the wire status received by the CLI
+			details := ErrorDetailsFromError(gstatus.ErrorProto(s.Proto()))
+			require.NotNil(t, details)
+			assert.Equal(t, testCase.err.Code, details.Code)
+			assert.Equal(t, testCase.err.ManagementURL, details.ManagementUrl)
+			assert.Equal(t, testCase.err.SignalURL, details.SignalUrl)
+		})
+	}
+}
+
+func TestClientError_Error(t *testing.T) {
+	err := NewSignalUnreachableError("https://api.netbird.io:443", "https://signal.netbird.io:443", errors.New("timeout"))
+	assert.Contains(t, err.Error(), "https://api.netbird.io:443")
+	assert.Contains(t, err.Error(), "https://signal.netbird.io:443")
+	assert.ErrorIs(t, NewConfigInvalidError(ErrResetConnection), ErrResetConnection)
+}
+
+func TestErrorDetailsFromError(t *testing.T) {
+	assert.Nil(t, ErrorDetailsFromError(nil))
+	assert.Nil(t, ErrorDetailsFromError(errors.New("plain error")))
+	assert.Nil(t, ErrorDetailsFromError(gstatus.Error(codes.PermissionDenied, "denied")))
+	assert.Equal(t, proto.ErrorCode_AUTH_REQUIRED, ErrorDetailsFromError(NewAuthRequiredError(errors.New("denied"))).GetCode())
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"fmt"
+	"net/netip"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+// ParseExcludedRoutes validates the destinations excluded from the tunnel and returns them as masked prefixes without
+// duplicates. A single IP is excluded as a host prefix, e.g. 192.168.1.20 as 192.168.1.20/32. The default route can't
+// be excluded, it would bypass the tunnel for all the traffic.
+func ParseExcludedRoutes(routes []string) ([]netip.Prefix, error) {
+	parsed := make([]netip.Prefix, 0, len(routes))
+	for _, route := range routes {
+		prefix, err := netip.ParsePrefix(route)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(route)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid excluded route %q, expecting an IP or a CIDR, e.g. 192.168.1.20 or 10.10.0.0/16", route)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()).Masked()
+
+		if prefix.Bits() == 0 {
+			return nil, fmt.Errorf("excluded route %s is a default route, which can't be excluded from the tunnel", prefix)
+		}
+
+		if !slices.Contains(parsed, prefix) {
+			parsed = append(parsed, prefix)
+		}
+	}
+	return parsed, nil
+}
+
+// excludedRouteStrings returns the excluded routes validated and normalized the way they are kept in the config
+func excludedRouteStrings(routes []string) ([]string, error) {
+	prefixes, err := ParseExcludedRoutes(routes)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		normalized = append(normalized, prefix.String())
+	}
+	return normalized, nil
+}
+
+// excludedRoutesOutsideOverlay returns the excluded routes without the ones overlapping with the overlay network of
+// the peer address, e.g. 100.64.0.5/16, routing them outside the tunnel would cut the peer off the other peers
+func excludedRoutesOutsideOverlay(routes []string, address string) []netip.Prefix {
+	prefixes, err := ParseExcludedRoutes(routes)
+	if err != nil {
+		log.Warnf("not excluding any routes from the tunnel: %v", err)
+		return nil
+	}
+
+	overlay, err := netip.ParsePrefix(address)
+	if err != nil {
+		return prefixes
+	}
+
+	var valid []netip.Prefix
+	for _, prefix := range prefixes {
+		if prefix.Overlaps(overlay.Masked()) {
+			log.Warnf("not excluding %s from the tunnel, it overlaps with the overlay network %s", prefix, overlay.Masked())
+			continue
+		}
+		valid = append(valid, prefix)
+	}
+	return valid
+}
+
+// validateExcludedRoutes checks the excluded routes of the config are valid IPs or CIDRs
+func validateExcludedRoutes(config *Config) error {
+	_, err := ParseExcludedRoutes(config.ExcludedRoutes)
+	return err
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"net/netip"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExcludedRoutes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		routes   []string
+		expected []netip.Prefix
+		fail     bool
+	}{
+		{
+			name:   "IPs And CIDRs Masked And Deduplicated",
+			routes: []string{"192.168.1.20", "10.10.5.1/16", "10.10.0.0/16", "fd00:1::5"},
+			expected: []netip.Prefix{
+				netip.MustParsePrefix("192.168.1.20/32"),
+				netip.MustParsePrefix("10.10.0.0/16"),
+				netip.MustParsePrefix("fd00:1::5/128"),
+			},
+		},
+		{
+			name:     "Empty",
+			routes:   nil,
+			expected: []netip.Prefix{},
+		},
+		{
+			name:   "Invalid",
+			routes: []string{"printer.local"},
+			fail:   true,
+		},
+		{
+			name:   "Default Route",
+			routes: []string{"0.0.0.0/0"},
+			fail:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			routes, err := ParseExcludedRoutes(testCase.routes)
+			if testCase.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, routes)
+		})
+	}
+}
+
+func TestExcludedRoutesOutsideOverlay(t *testing.T) {
+	routes := []string{"192.168.1.20/32", "100.64.5.0/24"}
+
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("192.168.1.20/32")}, excludedRoutesOutsideOverlay(routes, "100.64.0.5/16"))
+	assert.Len(t, excludedRoutesOutsideOverlay(routes, ""), 2, "unknown overlay should keep the routes")
+}
+
+func TestUpdateConfigExcludedRoutes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	_, err := UpdateOrCreateConfig(ConfigInput{ConfigPath: path})
+	require.NoError(t, err)
+
+	config, err := UpdateConfig(ConfigInput{ConfigPath: path, ExcludedRoutes: []string{"192.168.1.20", "10.10.5.1/16"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.20/32", "10.10.0.0/16"}, config.ExcludedRoutes)
+
+	config, err = ReadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.20/32", "10.10.0.0/16"}, config.ExcludedRoutes, "routes should be persisted")
+
+	config, err = UpdateConfig(ConfigInput{ConfigPath: path})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.20/32", "10.10.0.0/16"}, config.ExcludedRoutes, "routes should be kept when not set")
+
+	_, err = UpdateConfig(ConfigInput{ConfigPath: path, ExcludedRoutes: []string{"invalid"}})
+	assert.Error(t, err)
+
+	config, err = UpdateConfig(ConfigInput{ConfigPath: path, ExcludedRoutes: []string{}})
+	require.NoError(t, err)
+	assert.Empty(t, config.ExcludedRoutes)
+}
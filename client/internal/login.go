@@ -2,7 +2,10 @@ package internal
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"net/url"
+	"strings"
 
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
@@ -14,11 +17,15 @@ import (
 	"github.com/netbirdio/netbird/client/system"
 	mgm "github.com/netbirdio/netbird/management/client"
 	mgmProto "github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/util"
 )
 
+// orgKeySeparator separates an org key from the ID of the account the peer registers into
+const orgKeySeparator = ":"
+
 // IsLoginRequired check that the server is support SSO or not
-func IsLoginRequired(ctx context.Context, privateKey string, mgmURL *url.URL, sshKey string) (bool, error) {
-	mgmClient, err := getMgmClient(ctx, privateKey, mgmURL)
+func IsLoginRequired(ctx context.Context, privateKey string, mgmURL *url.URL, sshKey string, metaPrivacy map[string]string, tlsConfig *tls.Config, proxyDialer *util.ProxyDialer) (bool, error) {
+	mgmClient, err := getMgmClient(ctx, privateKey, mgmURL, tlsConfig, proxyDialer)
 	if err != nil {
 		return false, err
 	}
@@ -35,10 +42,10 @@ func IsLoginRequired(ctx context.Context, privateKey string, mgmURL *url.URL, ss
 
 	pubSSHKey, err := ssh.GeneratePublicKey([]byte(sshKey))
 	if err != nil {
-		return false, err
+		return false, NewConfigInvalidError(err)
 	}
 
-	_, err = doMgmLogin(ctx, mgmClient, pubSSHKey)
+	_, err = doMgmLogin(ctx, mgmClient, mgmURL, pubSSHKey, getSystemInfo(ctx, privateKey, metaPrivacy))
 	if isLoginNeeded(err) {
 		return true, nil
 	}
@@ -47,7 +54,17 @@ func IsLoginRequired(ctx context.Context, privateKey string, mgmURL *url.URL, ss
 
 // Login or register the client
 func Login(ctx context.Context, config *Config, setupKey string, jwtToken string) error {
-	mgmClient, err := getMgmClient(ctx, config.PrivateKey, config.ManagementURL)
+	tlsConfig, err := config.TLSConfig()
+	if err != nil {
+		return NewConfigInvalidError(err)
+	}
+
+	proxyDialer, err := config.ProxyDialer()
+	if err != nil {
+		return NewConfigInvalidError(err)
+	}
+
+	mgmClient, err := getMgmClient(ctx, config.PrivateKey, config.ManagementURL, tlsConfig, proxyDialer)
 	if err != nil {
 		return err
 	}
@@ -64,25 +81,56 @@ func Login(ctx context.Context, config *Config, setupKey string, jwtToken string
 
 	pubSSHKey, err := ssh.GeneratePublicKey([]byte(config.SSHKey))
 	if err != nil {
-		return err
+		return NewConfigInvalidError(err)
 	}
 
-	serverKey, err := doMgmLogin(ctx, mgmClient, pubSSHKey)
+	sysInfo := getSystemInfo(ctx, config.PrivateKey, config.SystemMetaPrivacy)
+	sysInfo.AdvertisedNetworks = config.AdvertisedNetworks
+	serverKey, err := doMgmLogin(ctx, mgmClient, config.ManagementURL, pubSSHKey, sysInfo)
 	if isRegistrationNeeded(err) {
 		log.Debugf("peer registration required")
-		_, err = registerPeer(ctx, *serverKey, mgmClient, setupKey, jwtToken, pubSSHKey)
-		return err
+		_, err = registerPeer(*serverKey, mgmClient, setupKey, jwtToken, pubSSHKey, sysInfo)
 	}
 
+	if isLoginNeeded(err) {
+		return NewAuthRequiredError(err)
+	}
 	return err
 }
 
-func getMgmClient(ctx context.Context, privateKey string, mgmURL *url.URL) (*mgm.GrpcClient, error) {
+// CheckSetupKey returns whether the setup key can register a peer in the Management service, without using it.
+// The request is signed with a throwaway WireGuard key, so it doesn't need the peer's config.
+func CheckSetupKey(ctx context.Context, mgmURL *url.URL, setupKey string, tlsConfig *tls.Config, proxyDialer *util.ProxyDialer) (*mgmProto.SetupKeyCheckResponse, error) {
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	mgmClient, err := getMgmClient(ctx, key.String(), mgmURL, tlsConfig, proxyDialer)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = mgmClient.Close()
+		if err != nil {
+			log.Warnf("failed to close the Management service client, err: %v", err)
+		}
+	}()
+
+	serverKey, err := mgmClient.GetServerPublicKey()
+	if err != nil {
+		return nil, NewManagementUnreachableError(mgmURL.String(), err)
+	}
+
+	return mgmClient.CheckSetupKey(*serverKey, setupKey)
+}
+
+func getMgmClient(ctx context.Context, privateKey string, mgmURL *url.URL, tlsConfig *tls.Config, proxyDialer *util.ProxyDialer) (*mgm.GrpcClient, error) {
 	// validate our peer's Wireguard PRIVATE key
 	myPrivateKey, err := wgtypes.ParseKey(privateKey)
 	if err != nil {
 		log.Errorf("failed parsing Wireguard key %s: [%s]", privateKey, err.Error())
-		return nil, err
+		return nil, NewConfigInvalidError(err)
 	}
 
 	var mgmTlsEnabled bool
@@ -91,39 +139,37 @@ func getMgmClient(ctx context.Context, privateKey string, mgmURL *url.URL) (*mgm
 	}
 
 	log.Debugf("connecting to the Management service %s", mgmURL.String())
-	mgmClient, err := mgm.NewClient(ctx, mgmURL.Host, myPrivateKey, mgmTlsEnabled)
+	mgmClient, err := mgm.NewClient(ctx, mgmURL.Host, myPrivateKey, mgmTlsEnabled, tlsConfig, proxyDialer)
 	if err != nil {
 		log.Errorf("failed connecting to the Management service %s %v", mgmURL.String(), err)
-		return nil, err
+		return nil, NewManagementUnreachableError(mgmURL.String(), err)
 	}
 	return mgmClient, err
 }
 
-func doMgmLogin(ctx context.Context, mgmClient *mgm.GrpcClient, pubSSHKey []byte) (*wgtypes.Key, error) {
+func doMgmLogin(ctx context.Context, mgmClient *mgm.GrpcClient, mgmURL *url.URL, pubSSHKey []byte, sysInfo *system.Info) (*wgtypes.Key, error) {
 	serverKey, err := mgmClient.GetServerPublicKey()
 	if err != nil {
 		log.Errorf("failed while getting Management Service public key: %v", err)
-		return nil, err
+		return nil, NewManagementUnreachableError(mgmURL.String(), err)
 	}
 
-	sysInfo := system.GetInfo(ctx)
 	_, err = mgmClient.Login(*serverKey, sysInfo, pubSSHKey)
 	return serverKey, err
 }
 
 // registerPeer checks whether setupKey was provided via cmd line and if not then it prompts user to enter a key.
 // Otherwise tries to register with the provided setupKey via command line.
-func registerPeer(ctx context.Context, serverPublicKey wgtypes.Key, client *mgm.GrpcClient, setupKey string, jwtToken string, pubSSHKey []byte) (*mgmProto.LoginResponse, error) {
-	validSetupKey, err := uuid.Parse(setupKey)
+func registerPeer(serverPublicKey wgtypes.Key, client *mgm.GrpcClient, setupKey string, jwtToken string, pubSSHKey []byte, info *system.Info) (*mgmProto.LoginResponse, error) {
+	validSetupKey, err := parseSetupKey(setupKey)
 	if err != nil && jwtToken == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid setup-key or no sso information provided, err: %v", err)
 	}
 
 	log.Debugf("sending peer registration request to Management Service")
-	info := system.GetInfo(ctx)
-	loginResp, err := client.Register(serverPublicKey, validSetupKey.String(), jwtToken, info, pubSSHKey)
+	loginResp, err := client.Register(serverPublicKey, validSetupKey, jwtToken, info, pubSSHKey)
 	if err != nil {
-		log.Errorf("failed registering peer %v,%s", err, validSetupKey.String())
+		log.Errorf("failed registering peer %v,%s", err, validSetupKey)
 		return nil, err
 	}
 
@@ -132,6 +178,30 @@ func registerPeer(ctx context.Context, serverPublicKey wgtypes.Key, client *mgm.
 	return loginResp, nil
 }
 
+// parseSetupKey validates the setup key, or the org key followed by the ID of the account to register into, e.g.
+// "A2C8E62B-38F5-4553-B31E-DD66C696CEBB:cn1knqv7dcmc73a2vlm0". An invalid key is returned as the nil UUID.
+func parseSetupKey(setupKey string) (string, error) {
+	key, accountID, isOrgKey := strings.Cut(setupKey, orgKeySeparator)
+	parsed, err := uuid.Parse(key)
+	if err != nil {
+		return uuid.Nil.String(), err
+	}
+	if !isOrgKey {
+		return parsed.String(), nil
+	}
+	if accountID == "" {
+		return uuid.Nil.String(), fmt.Errorf("org key %s has no account ID", parsed.String())
+	}
+	return parsed.String() + orgKeySeparator + accountID, nil
+}
+
+// getSystemInfo returns the system info reported to the Management Service with the privacy settings applied
+func getSystemInfo(ctx context.Context, privateKey string, metaPrivacy map[string]string) *system.Info {
+	info := system.GetInfo(ctx)
+	info.ApplyMetaPrivacy(metaPrivacy, privateKey)
+	return info
+}
+
 func isLoginNeeded(err error) bool {
 	if err == nil {
 		return false
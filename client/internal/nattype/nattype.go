@@ -0,0 +1,297 @@
+// Package nattype classifies the NAT the peer is behind with STUN. The public addresses a single local socket is
+// mapped to for two different STUN servers tell whether the NAT keeps the mapping for all the destinations (cone)
+// or maps every destination separately (symmetric). Two peers behind symmetric NATs rarely connect directly.
+package nattype
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/stun/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// Type is the type of the NAT the peer is behind
+type Type string
+
+const (
+	// Unknown is a NAT which couldn't be classified, e.g. the STUN servers are unreachable
+	Unknown Type = ""
+	// Open is a peer with a public address, not behind a NAT
+	Open Type = "open"
+	// Cone is a NAT mapping a local address to the same public address for all the destinations
+	Cone Type = "cone"
+	// Symmetric is a NAT mapping a local address to a different public address for every destination
+	Symmetric Type = "symmetric"
+
+	// requestTimeout is how long a binding request is waited for before it is retransmitted
+	requestTimeout = 1500 * time.Millisecond
+	// requestAttempts is how many times a binding request is sent to a STUN server
+	requestAttempts = 2
+
+	// minRetryInterval is how long a failed classification is kept before the NAT is probed again on the same network
+	minRetryInterval = 30 * time.Second
+	// maxRetryInterval bounds the retry interval doubled after every failed classification
+	maxRetryInterval = 15 * time.Minute
+)
+
+// Detector classifies the NAT and caches the result until the network of the peer changes
+type Detector struct {
+	mu      sync.Mutex
+	natType Type
+	// network identifies the local addresses the NAT type was classified with
+	network string
+	// retryInterval is doubled after every classification failed in a row on the network
+	retryInterval time.Duration
+	// retryAt is when a failed classification is retried
+	retryAt time.Time
+
+	// localNetwork returns the identity of the local network, it changes with the local addresses
+	localNetwork func() (string, error)
+	// probe classifies the NAT with the STUN servers
+	probe func(ctx context.Context, servers []string) (Type, error)
+	now   func() time.Time
+}
+
+// NewDetector returns a Detector probing the NAT from a new UDP socket
+func NewDetector() *Detector {
+	return &Detector{
+		localNetwork: localNetwork,
+		probe:        probe,
+		now:          time.Now,
+	}
+}
+
+// Type returns the cached NAT type, Unknown if it wasn't classified yet or the network changed since
+func (d *Detector) Type() Type {
+	network, err := d.localNetwork()
+	if err != nil {
+		return Unknown
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if network != d.network {
+		return Unknown
+	}
+	return d.natType
+}
+
+// Detect returns the cached NAT type if the network hasn't changed since it was classified, otherwise it classifies
+// the NAT again with the STUN servers, given as host:port. A failed classification is retried on the same network
+// only after a backoff, so the unreachable STUN servers don't delay every reconnection.
+func (d *Detector) Detect(ctx context.Context, servers []string) Type {
+	network, err := d.localNetwork()
+	if err != nil {
+		log.Debugf("failed to read the local addresses: %v", err)
+		return Unknown
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if network == d.network && (d.natType != Unknown || d.now().Before(d.retryAt)) {
+		return d.natType
+	}
+	if network != d.network {
+		d.retryInterval = 0
+	}
+
+	natType, err := d.probe(ctx, servers)
+	if err != nil {
+		d.retryInterval = min(max(2*d.retryInterval, minRetryInterval), maxRetryInterval)
+		d.retryAt = d.now().Add(d.retryInterval)
+		log.Infof("failed to classify the NAT type, retrying in %s: %v", d.retryInterval, err)
+	} else {
+		d.retryInterval = 0
+		log.Infof("classified the NAT type as %s", natType)
+	}
+	d.natType = natType
+	d.network = network
+	return natType
+}
+
+// localNetwork returns the sorted addresses of the interfaces, the NAT is classified again when they change
+func localNetwork() (string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+
+	var network []string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		network = append(network, ipNet.String())
+	}
+	sort.Strings(network)
+	return strings.Join(network, ","), nil
+}
+
+// probe sends the binding requests from a single socket to the STUN servers and compares the mapped addresses.
+// The second destination is the alternate address of the first server if it has one, otherwise another server.
+func probe(ctx context.Context, servers []string) (Type, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return Unknown, fmt.Errorf("listen: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Debugf("failed to close the NAT probe socket: %v", err)
+		}
+	}()
+
+	var first *net.UDPAddr
+	var firstMapped, other *net.UDPAddr
+	var errs []error
+	for _, server := range servers {
+		addr, err := net.ResolveUDPAddr("udp4", server)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("resolve %s: %w", server, err))
+			continue
+		}
+
+		if first != nil && addr.IP.Equal(first.IP) {
+			continue
+		}
+
+		mapped, otherAddr, err := bindingRequest(ctx, conn, addr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("binding request to %s: %w", server, err))
+			continue
+		}
+
+		if first == nil {
+			if isLocalAddress(mapped, conn) {
+				return Open, nil
+			}
+			first, firstMapped, other = addr, mapped, otherAddr
+			if other == nil || other.IP.Equal(first.IP) {
+				continue
+			}
+
+			mapped, _, err = bindingRequest(ctx, conn, other)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("binding request to the alternate address %s of %s: %w", other, server, err))
+				continue
+			}
+		}
+
+		log.Debugf("NAT mapped the probe socket to %s and %s for two destinations", firstMapped, mapped)
+		if mapped.IP.Equal(firstMapped.IP) && mapped.Port == firstMapped.Port {
+			return Cone, nil
+		}
+		return Symmetric, nil
+	}
+
+	if first != nil {
+		errs = append(errs, fmt.Errorf("a second STUN server with another IP is needed to classify the NAT mapping"))
+	}
+	return Unknown, errors.Join(errs...)
+}
+
+// bindingRequest returns the mapped address the STUN server observed the request from and its alternate address,
+// nil if the server doesn't have one
+func bindingRequest(ctx context.Context, conn *net.UDPConn, server *net.UDPAddr) (*net.UDPAddr, *net.UDPAddr, error) {
+	request, err := stun.Build(stun.TransactionID, stun.BindingRequest, stun.Fingerprint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf := make([]byte, 1500)
+	for attempt := 0; attempt < requestAttempts; attempt++ {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+
+		if _, err := conn.WriteToUDP(request.Raw, server); err != nil {
+			return nil, nil, err
+		}
+
+		deadline := time.Now().Add(requestTimeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, nil, err
+		}
+
+		mapped, other, err := readResponse(conn, server, request.TransactionID, buf)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			continue
+		}
+		return mapped, other, err
+	}
+	return nil, nil, fmt.Errorf("no response in %s", requestTimeout*requestAttempts)
+}
+
+// readResponse reads until the response of the transaction arrives from the server, other packets are skipped
+func readResponse(conn *net.UDPConn, server *net.UDPAddr, transactionID [stun.TransactionIDSize]byte, buf []byte) (*net.UDPAddr, *net.UDPAddr, error) {
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !from.IP.Equal(server.IP) || from.Port != server.Port || !stun.IsMessage(buf[:n]) {
+			continue
+		}
+
+		response := &stun.Message{Raw: append([]byte{}, buf[:n]...)}
+		if err := response.Decode(); err != nil || response.TransactionID != transactionID {
+			continue
+		}
+		if response.Type != stun.BindingSuccess {
+			return nil, nil, fmt.Errorf("unexpected response %s", response.Type)
+		}
+
+		return mappedAddress(response), otherAddress(response), nil
+	}
+}
+
+func mappedAddress(response *stun.Message) *net.UDPAddr {
+	var xorAddr stun.XORMappedAddress
+	if err := xorAddr.GetFrom(response); err == nil {
+		return &net.UDPAddr{IP: xorAddr.IP, Port: xorAddr.Port}
+	}
+
+	var addr stun.MappedAddress
+	if err := addr.GetFrom(response); err == nil {
+		return &net.UDPAddr{IP: addr.IP, Port: addr.Port}
+	}
+	return &net.UDPAddr{}
+}
+
+func otherAddress(response *stun.Message) *net.UDPAddr {
+	var addr stun.OtherAddress
+	if err := addr.GetFrom(response); err != nil {
+		return nil
+	}
+	return &net.UDPAddr{IP: addr.IP, Port: addr.Port}
+}
+
+// isLocalAddress returns true if the mapped address is the local address of the socket, the peer isn't behind a NAT
+func isLocalAddress(mapped *net.UDPAddr, conn *net.UDPConn) bool {
+	local, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok || mapped.Port != local.Port {
+		return false
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(mapped.IP) {
+			return true
+		}
+	}
+	return false
+}
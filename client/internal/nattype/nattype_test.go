@@ -0,0 +1,187 @@
+package nattype
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/stun/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startSTUNServer answers the binding requests on the loopback IP with the mapped address returned for the observed
+// address, and with the alternate address if other isn't nil
+func startSTUNServer(t *testing.T, ip net.IP, mapped func(from *net.UDPAddr) *net.UDPAddr, other *net.UDPAddr) *net.UDPAddr {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: ip})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+
+			request := &stun.Message{Raw: append([]byte{}, buf[:n]...)}
+			if err := request.Decode(); err != nil {
+				continue
+			}
+
+			addr := mapped(from)
+			setters := []stun.Setter{request, stun.BindingSuccess, &stun.XORMappedAddress{IP: addr.IP, Port: addr.Port}}
+			if other != nil {
+				setters = append(setters, &stun.OtherAddress{IP: other.IP, Port: other.Port})
+			}
+			response, err := stun.Build(append(setters, stun.Fingerprint)...)
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteToUDP(response.Raw, from)
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestProbe(t *testing.T) {
+	firstIP := net.IPv4(127, 0, 0, 1)
+	secondIP := net.IPv4(127, 0, 0, 2)
+	public := net.IPv4(203, 0, 113, 10)
+	fixedPort := func(port int) func(*net.UDPAddr) *net.UDPAddr {
+		return func(*net.UDPAddr) *net.UDPAddr { return &net.UDPAddr{IP: public, Port: port} }
+	}
+	observed := func(from *net.UDPAddr) *net.UDPAddr { return from }
+
+	testCases := []struct {
+		name     string
+		servers  func(t *testing.T) []string
+		expected Type
+		err      bool
+	}{
+		{
+			name: "same mapping for both servers is a cone NAT",
+			servers: func(t *testing.T) []string {
+				return []string{
+					startSTUNServer(t, firstIP, fixedPort(40000), nil).String(),
+					startSTUNServer(t, secondIP, fixedPort(40000), nil).String(),
+				}
+			},
+			expected: Cone,
+		},
+		{
+			name: "different mapping for both servers is a symmetric NAT",
+			servers: func(t *testing.T) []string {
+				return []string{
+					startSTUNServer(t, firstIP, fixedPort(40000), nil).String(),
+					startSTUNServer(t, secondIP, fixedPort(40001), nil).String(),
+				}
+			},
+			expected: Symmetric,
+		},
+		{
+			name: "alternate address of the first server is the second destination",
+			servers: func(t *testing.T) []string {
+				alternate := startSTUNServer(t, secondIP, fixedPort(40001), nil)
+				return []string{startSTUNServer(t, firstIP, fixedPort(40000), alternate).String()}
+			},
+			expected: Symmetric,
+		},
+		{
+			name: "mapped address equal to the local address is open",
+			servers: func(t *testing.T) []string {
+				return []string{startSTUNServer(t, firstIP, observed, nil).String()}
+			},
+			expected: Open,
+		},
+		{
+			name: "single server can't classify the NAT",
+			servers: func(t *testing.T) []string {
+				return []string{startSTUNServer(t, firstIP, fixedPort(40000), nil).String()}
+			},
+			expected: Unknown,
+			err:      true,
+		},
+		{
+			name: "unresolvable servers can't classify the NAT",
+			servers: func(t *testing.T) []string {
+				return []string{"invalid host:3478"}
+			},
+			expected: Unknown,
+			err:      true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			natType, err := probe(context.Background(), testCase.servers(t))
+			if testCase.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.expected, natType)
+		})
+	}
+}
+
+func TestDetector_Detect(t *testing.T) {
+	network := "10.0.0.2/24"
+	probes := 0
+	probeType := Symmetric
+	detector := &Detector{
+		localNetwork: func() (string, error) { return network, nil },
+		probe: func(context.Context, []string) (Type, error) {
+			probes++
+			return probeType, nil
+		},
+	}
+
+	assert.Equal(t, Unknown, detector.Type(), "type should be unknown before the first detection")
+
+	assert.Equal(t, Symmetric, detector.Detect(context.Background(), nil))
+	assert.Equal(t, Symmetric, detector.Detect(context.Background(), nil))
+	assert.Equal(t, Symmetric, detector.Type())
+	assert.Equal(t, 1, probes, "the NAT should be probed once while the network doesn't change")
+
+	network = "192.168.1.5/24"
+	probeType = Cone
+	assert.Equal(t, Unknown, detector.Type(), "cached type should be dropped when the network changes")
+	assert.Equal(t, Cone, detector.Detect(context.Background(), nil))
+	assert.Equal(t, 2, probes, "the NAT should be probed again when the network changes")
+}
+
+func TestDetector_DetectRetriesUnknown(t *testing.T) {
+	network := "10.0.0.2/24"
+	now := time.Now()
+	probes := 0
+	detector := &Detector{
+		localNetwork: func() (string, error) { return network, nil },
+		probe: func(context.Context, []string) (Type, error) {
+			probes++
+			return Unknown, assert.AnError
+		},
+		now: func() time.Time { return now },
+	}
+
+	assert.Equal(t, Unknown, detector.Detect(context.Background(), nil))
+	assert.Equal(t, Unknown, detector.Detect(context.Background(), nil))
+	assert.Equal(t, 1, probes, "a failed classification shouldn't be retried before the backoff")
+
+	now = now.Add(minRetryInterval)
+	assert.Equal(t, Unknown, detector.Detect(context.Background(), nil))
+	assert.Equal(t, 2, probes, "a failed classification should be retried after the backoff")
+
+	now = now.Add(minRetryInterval)
+	assert.Equal(t, Unknown, detector.Detect(context.Background(), nil))
+	assert.Equal(t, 2, probes, "the backoff should double after every failure")
+
+	network = "192.168.1.5/24"
+	assert.Equal(t, Unknown, detector.Detect(context.Background(), nil))
+	assert.Equal(t, 3, probes, "the NAT should be probed again right away when the network changes")
+}
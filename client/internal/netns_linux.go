@@ -0,0 +1,118 @@
+//go:build linux && !android
+
+package internal
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// netnsEnv marks the process re-executed in the network namespace, to detect a namespace that can't be entered
+const netnsEnv = "NB_NETNS_ENTERED"
+
+// EnterNetworkNamespace re-executes the process in the network namespace, which is either a name of "ip netns"
+// or a path to a namespace file. It returns only when the process already runs in the namespace or on error.
+// A process can't move all its threads to another namespace, so the re-execution is the only way for the Go runtime
+// and the sockets of the client to end up in it.
+func EnterNetworkNamespace(netns string) error {
+	if netns == "" {
+		return nil
+	}
+
+	path := networkNamespacePath(netns)
+	inside, err := inNetworkNamespace(path)
+	if err != nil {
+		return err
+	}
+	if inside {
+		return setLoopbackUp()
+	}
+
+	if os.Getenv(netnsEnv) != "" {
+		return fmt.Errorf("network namespace %s wasn't entered after the re-execution", netns)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("get executable: %w", err)
+	}
+
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("open network namespace %s: %w", netns, err)
+	}
+
+	// the namespace applies to the current thread only, the thread is never unlocked so it isn't reused on error
+	runtime.LockOSThread()
+	if err := unix.Setns(fd, unix.CLONE_NEWNET); err != nil {
+		_ = unix.Close(fd)
+		return fmt.Errorf("enter network namespace %s: %w", netns, err)
+	}
+	_ = unix.Close(fd)
+
+	log.Infof("restarting in network namespace %s", netns)
+	env := append(os.Environ(), netnsEnv+"="+netns)
+	err = syscall.Exec(executable, os.Args, env)
+	return fmt.Errorf("re-execute in network namespace %s: %w", netns, err)
+}
+
+// CheckNetworkNamespace returns an error if the process doesn't run in the network namespace,
+// e.g. because the namespace was configured after the daemon started
+func CheckNetworkNamespace(netns string) error {
+	if netns == "" {
+		return nil
+	}
+
+	inside, err := inNetworkNamespace(networkNamespacePath(netns))
+	if err != nil {
+		return err
+	}
+	if !inside {
+		return fmt.Errorf("not running in network namespace %s, restart the NetBird service to enter it", netns)
+	}
+	return nil
+}
+
+// networkNamespacePath returns the path of the namespace file of the namespace created by "ip netns add"
+func networkNamespacePath(netns string) string {
+	if strings.Contains(netns, "/") {
+		return netns
+	}
+	return "/var/run/netns/" + netns
+}
+
+// inNetworkNamespace returns true if the current thread runs in the network namespace of the namespace file
+func inNetworkNamespace(path string) (bool, error) {
+	var target, current unix.Stat_t
+	if err := unix.Stat(path, &target); err != nil {
+		return false, fmt.Errorf("network namespace %s: %w", path, err)
+	}
+	if err := unix.Stat("/proc/thread-self/ns/net", &current); err != nil {
+		return false, fmt.Errorf("current network namespace: %w", err)
+	}
+	return target.Dev == current.Dev && target.Ino == current.Ino, nil
+}
+
+// setLoopbackUp brings up the loopback interface, which is down in a new namespace. The DNS resolver listens on it
+// when the address of the WireGuard interface isn't available.
+func setLoopbackUp() error {
+	lo, err := netlink.LinkByName("lo")
+	if err != nil {
+		return fmt.Errorf("get loopback interface: %w", err)
+	}
+	if lo.Attrs().Flags&net.FlagUp != 0 {
+		return nil
+	}
+	if err := netlink.LinkSetUp(lo); err != nil {
+		return fmt.Errorf("bring up loopback interface: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux || android
+
+package internal
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// EnterNetworkNamespace fails when a network namespace is set, namespaces are supported on Linux only
+func EnterNetworkNamespace(netns string) error {
+	return CheckNetworkNamespace(netns)
+}
+
+// CheckNetworkNamespace fails when a network namespace is set, namespaces are supported on Linux only
+func CheckNetworkNamespace(netns string) error {
+	if netns == "" {
+		return nil
+	}
+	return fmt.Errorf("network namespaces are not supported on %s", runtime.GOOS)
+}
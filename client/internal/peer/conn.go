@@ -2,8 +2,10 @@ package peer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/netip"
 	"runtime"
 	"strings"
 	"sync"
@@ -12,6 +14,7 @@ import (
 	"github.com/pion/ice/v3"
 	"github.com/pion/stun/v2"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 
 	"github.com/netbirdio/netbird/client/internal/stdnet"
@@ -24,8 +27,15 @@ import (
 )
 
 const (
+	// DefaultRelayFallbackAttempts is the default number of relay only retries of a failed direct connection attempt
+	DefaultRelayFallbackAttempts = 1
+
 	iceKeepAliveDefault           = 4 * time.Second
 	iceDisconnectedTimeoutDefault = 6 * time.Second
+	iceFailedTimeout              = 6 * time.Second
+
+	// maxRemoteCandidates bounds the remote candidates kept for the current agent or buffered until there is one
+	maxRemoteCandidates = 100
 
 	defaultWgKeepAlive = 25 * time.Second
 )
@@ -36,6 +46,8 @@ type WgConfig struct {
 	WgInterface  *iface.WGIface
 	AllowedIps   string
 	PreSharedKey *wgtypes.Key
+	// Keepalive is the interval of the WireGuard keepalives sent to the remote peer, the default interval when 0
+	Keepalive time.Duration
 }
 
 // ConnConfig is a peer Connection configuration
@@ -48,6 +60,9 @@ type ConnConfig struct {
 
 	// StunTurn is a list of STUN and TURN URLs
 	StunTurn []*stun.URI
+	// TURNTiers holds the TURN URLs of StunTurn grouped by priority, the most preferred first. With several tiers
+	// ICE is given the TURN URLs of one tier at a time, the next one is used when the connectivity checks fail.
+	TURNTiers [][]*stun.URI
 
 	// InterfaceBlackList is a list of machine interfaces that should be filtered out by ICE Candidate gathering
 	// (e.g. if eth0 is in the list, host candidate of this interface won't be used)
@@ -72,6 +87,31 @@ type ConnConfig struct {
 	RosenpassPubKey []byte
 	// RosenpassPubKey is this peer's RosenpassAddr server address (IP:port)
 	RosenpassAddr string
+
+	// ConnOrdering biases the selection between the direct and the relayed connection
+	ConnOrdering ConnOrdering
+	// ConnOrderingDelay is how long the not preferred connection is held back, DefaultConnOrderingDelay if zero
+	ConnOrderingDelay time.Duration
+
+	// RelayFallbackAttempts is how many times a connection attempt which failed or timed out during the ICE
+	// connectivity checks is retried with the relay candidates only before the attempt is given up
+	RelayFallbackAttempts int
+
+	// ForceRelay makes the connection gather the relay candidates only, it is set by the management service when
+	// either of the peers is configured to always go through a relay
+	ForceRelay bool
+
+	// ObservedIP is the public IP the management service observed the remote peer connecting from.
+	// It is the source of the remote peer's management (TCP) connection, so it is only used as an additional
+	// server reflexive candidate hint and never as the WireGuard endpoint directly.
+	ObservedIP net.IP
+
+	// MappedAddress is the public address the remote peer mapped its WireGuard port to on its gateway with NAT-PMP or
+	// UPnP. It is added as a server reflexive candidate of the remote peer.
+	MappedAddress netip.AddrPort
+
+	// NegotiationLimiter bounds the connections of the engine negotiating at the same time, nil doesn't limit them
+	NegotiationLimiter *NegotiationLimiter
 }
 
 // OfferAnswer represents a session establishment offer or answer
@@ -114,13 +154,22 @@ type Conn struct {
 	// remoteOffersCh is a channel used to wait for remote credentials to proceed with the connection
 	remoteOffersCh chan OfferAnswer
 	// remoteAnswerCh is a channel used to wait for remote credentials answer (confirmation of our offer) to proceed with the connection
-	remoteAnswerCh     chan OfferAnswer
-	closeCh            chan struct{}
+	remoteAnswerCh chan OfferAnswer
+	closeCh        chan struct{}
+	closed         bool // set by Close, a closed Conn can't be opened again
+	// renegotiateCh interrupts the negotiation of the current attempt, so the next attempt sends a new offer
+	renegotiateCh      chan struct{}
 	ctx                context.Context
 	notifyDisconnected context.CancelFunc
 
 	agent  *ice.Agent
 	status ConnStatus
+	// phase is the last phase the connection establishment reached
+	phase ConnPhase
+	// relayFallback is set when the current attempt fell back to relay candidates only
+	relayFallback bool
+	// attemptStarted is when the current attempt got its negotiation slot, only Open uses it
+	attemptStarted time.Time
 
 	statusRecorder *Status
 
@@ -130,6 +179,15 @@ type Conn struct {
 	remoteModeCh chan ModeMessage
 	meta         meta
 
+	// observedIPCandidates holds the hint candidates already added to the current agent
+	observedIPCandidates map[string]struct{}
+	// remoteCandidates are the candidates the remote peer signaled for the current agent, they are added again
+	// when the agent re-gathers. Without an agent they are buffered and added to the next one.
+	remoteCandidates []ice.Candidate
+
+	// turnTier is the index of the TURN servers tier given to the ICE agent
+	turnTier int
+
 	adapter       iface.TunAdapter
 	iFaceDiscover stdnet.ExternalIFaceDiscover
 }
@@ -156,8 +214,40 @@ func (conn *Conn) WgConfig() WgConfig {
 }
 
 // UpdateStunTurn update the turn and stun addresses
-func (conn *Conn) UpdateStunTurn(turnStun []*stun.URI) {
+func (conn *Conn) UpdateStunTurn(turnStun []*stun.URI, turnTiers [][]*stun.URI) {
 	conn.config.StunTurn = turnStun
+	conn.config.TURNTiers = turnTiers
+}
+
+// UpdateObservedIP updates the remote peer's observed IP provided by the management service
+func (conn *Conn) UpdateObservedIP(ip net.IP) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.config.ObservedIP = ip
+}
+
+// UpdateMappedAddress updates the remote peer's port mapping address provided by the management service
+func (conn *Conn) UpdateMappedAddress(address netip.AddrPort) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.config.MappedAddress = address
+}
+
+// UpdateCandidateFilters updates the interface blacklist and the NAT external IPs used for the ICE candidates gathering.
+// The established connection is kept, the new values are used the next time the ICE agent is created.
+func (conn *Conn) UpdateCandidateFilters(interfaceBlackList []string, natExternalIPs []string) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.config.InterfaceBlackList = interfaceBlackList
+	conn.config.NATExternalIPs = natExternalIPs
+}
+
+// UpdateTimeout updates the timeout of the connection attempts. An attempt in progress keeps the timeout it started
+// with, the new one applies to the next attempts.
+func (conn *Conn) UpdateTimeout(timeout time.Duration) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.config.Timeout = timeout
 }
 
 // NewConn creates a new not opened Conn to the remote peer.
@@ -168,6 +258,7 @@ func NewConn(config ConnConfig, statusRecorder *Status, wgProxyFactory *wgproxy.
 		mu:             sync.Mutex{},
 		status:         StatusDisconnected,
 		closeCh:        make(chan struct{}),
+		renegotiateCh:  make(chan struct{}, 1),
 		remoteOffersCh: make(chan OfferAnswer),
 		remoteAnswerCh: make(chan OfferAnswer),
 		statusRecorder: statusRecorder,
@@ -178,11 +269,22 @@ func NewConn(config ConnConfig, statusRecorder *Status, wgProxyFactory *wgproxy.
 	}, nil
 }
 
-func (conn *Conn) reCreateAgent() error {
+// reCreateAgent closes the agent of the previous attempt if any and creates a new one.
+// A relay only agent gathers and checks the relay candidates only.
+func (conn *Conn) reCreateAgent(relayOnly bool) error {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
 
-	failedTimeout := 6 * time.Second
+	if conn.agent != nil {
+		if err := conn.agent.Close(); err != nil {
+			log.Warnf("failed to close the ICE agent of peer %s: %v", conn.config.Key, err)
+		}
+		conn.agent = nil
+		// the candidates of the previous session don't apply to the new agent
+		conn.remoteCandidates = nil
+	}
+
+	failedTimeout := iceFailedTimeout
 
 	var err error
 	transportNet, err := conn.newStdNet()
@@ -196,8 +298,8 @@ func (conn *Conn) reCreateAgent() error {
 	agentConfig := &ice.AgentConfig{
 		MulticastDNSMode:    ice.MulticastDNSModeDisabled,
 		NetworkTypes:        []ice.NetworkType{ice.NetworkTypeUDP4, ice.NetworkTypeUDP6},
-		Urls:                conn.config.StunTurn,
-		CandidateTypes:      conn.candidateTypes(),
+		Urls:                conn.iceURLs(),
+		CandidateTypes:      conn.candidateTypes(relayOnly),
 		FailedTimeout:       &failedTimeout,
 		InterfaceFilter:     stdnet.InterfaceFilter(conn.config.InterfaceBlackList),
 		UDPMux:              conn.config.UDPMux,
@@ -212,12 +314,18 @@ func (conn *Conn) reCreateAgent() error {
 		agentConfig.NetworkTypes = []ice.NetworkType{ice.NetworkTypeUDP4}
 	}
 
+	if !relayOnly {
+		applyConnOrdering(agentConfig, conn.config.ConnOrdering, conn.config.ConnOrderingDelay, failedTimeout, conn.config.Timeout)
+	}
+
 	conn.agent, err = ice.NewAgent(agentConfig)
 
 	if err != nil {
 		return err
 	}
 
+	conn.observedIPCandidates = make(map[string]struct{})
+
 	err = conn.agent.OnCandidate(conn.onICECandidate)
 	if err != nil {
 		return err
@@ -233,11 +341,14 @@ func (conn *Conn) reCreateAgent() error {
 		return err
 	}
 
+	// the candidates received while there was no agent
+	conn.addRemoteCandidates()
+
 	return nil
 }
 
-func (conn *Conn) candidateTypes() []ice.CandidateType {
-	if hasICEForceRelayConn() {
+func (conn *Conn) candidateTypes(relayOnly bool) []ice.CandidateType {
+	if relayOnly || conn.config.ForceRelay || hasICEForceRelayConn() {
 		return []ice.CandidateType{ice.CandidateTypeRelay}
 	}
 	// TODO: remove this once we have refactored userspace proxy into the bind package
@@ -251,6 +362,10 @@ func (conn *Conn) candidateTypes() []ice.CandidateType {
 // Blocks until connection has been closed or connection timeout.
 // ConnStatus will be set accordingly
 func (conn *Conn) Open() error {
+	if conn.isClosed() {
+		return NewConnectionClosedError(conn.config.Key)
+	}
+
 	log.Debugf("trying to connect to peer %s", conn.config.Key)
 
 	peerState := State{
@@ -272,48 +387,125 @@ func (conn *Conn) Open() error {
 		}
 	}()
 
-	err = conn.reCreateAgent()
+	remoteConn, remoteOfferAnswer, err := conn.negotiate()
 	if err != nil {
+		conn.recordAttempt(err)
 		return err
 	}
 
-	err = conn.sendOffer()
+	// dynamically set remote WireGuard port is other side specified a different one from the default one
+	remoteWgPort := iface.DefaultWgPort
+	if remoteOfferAnswer.WgListenPort != 0 {
+		remoteWgPort = remoteOfferAnswer.WgListenPort
+	}
+	// the ice connection has been established successfully so we are ready to start the proxy
+	remoteAddr, err := conn.configureConnection(remoteConn, remoteWgPort, remoteOfferAnswer.RosenpassPubKey,
+		remoteOfferAnswer.RosenpassAddr)
 	if err != nil {
+		conn.recordAttempt(err)
 		return err
 	}
+	conn.setPhase(ConnPhaseProxyStarted)
+	conn.recordAttempt(nil)
 
-	log.Debugf("connection offer sent to peer %s, waiting for the confirmation", conn.config.Key)
+	log.Infof("connected to peer %s, endpoint address: %s", conn.config.Key, remoteAddr.String())
 
-	// Only continue once we got a connection confirmation from the remote peer.
-	// The connection timeout could have happened before a confirmation received from the remote.
-	// The connection could have also been closed externally (e.g. when we received an update from the management that peer shouldn't be connected)
-	var remoteOfferAnswer OfferAnswer
+	// wait until connection disconnected or has been closed externally (upper layer, e.g. engine)
 	select {
-	case remoteOfferAnswer = <-conn.remoteOffersCh:
-		// received confirmation from the remote peer -> ready to proceed
-		err = conn.sendAnswer()
-		if err != nil {
-			return err
-		}
-	case remoteOfferAnswer = <-conn.remoteAnswerCh:
-	case <-time.After(conn.config.Timeout):
-		return NewConnectionTimeoutError(conn.config.Key, conn.config.Timeout)
 	case <-conn.closeCh:
 		// closed externally
 		return NewConnectionClosedError(conn.config.Key)
+	case <-conn.ctx.Done():
+		// disconnected from the remote peer, the next attempt starts with the most preferred TURN servers again
+		conn.turnTier = 0
+		return NewConnectionDisconnectedError(conn.config.Key)
+	}
+}
+
+// negotiate establishes the ICE connection, retrying with the relay candidates only if the direct attempt fails.
+// It waits for a slot of the NegotiationLimiter first and holds it until the negotiation is over or the remote peer
+// doesn't reply to the offer. An offer the remote peer sends while the connection waits for a slot is answered
+// right away.
+func (conn *Conn) negotiate() (*ice.Conn, OfferAnswer, error) {
+	conn.setPhase(ConnPhaseQueued)
+	acquired, remoteOffer := conn.config.NegotiationLimiter.acquireOrOffer(conn.closeCh, conn.remoteOffersCh)
+	if !acquired && remoteOffer == nil {
+		return nil, OfferAnswer{}, NewConnectionClosedError(conn.config.Key)
+	}
+
+	var releaseOnce sync.Once
+	releaseSlot := func() {
+		if acquired {
+			releaseOnce.Do(conn.config.NegotiationLimiter.release)
+		}
+	}
+	defer releaseSlot()
+
+	if remoteOffer != nil {
+		log.Debugf("peer %s sent an offer while waiting for a negotiation slot, answering it", conn.config.Key)
+	}
+
+	// the time spent waiting for the slot is left out, it depends on the other peers
+	conn.attemptStarted = time.Now()
+	conn.setRelayFallback(false)
+
+	remoteConn, remoteOfferAnswer, err := conn.establish(conn.config.ForceRelay, remoteOffer, releaseSlot)
+	for attempt := 0; err != nil && !isRenegotiateError(err) && conn.canFallbackToRelay(attempt); attempt++ {
+		log.Infof("failed to connect to peer %s directly: %v, retrying with relay candidates only, attempt %d of %d",
+			conn.config.Key, err, attempt+1, conn.config.RelayFallbackAttempts)
+		conn.setRelayFallback(true)
+		remoteConn, remoteOfferAnswer, err = conn.establish(true, nil, releaseSlot)
+	}
+	return remoteConn, remoteOfferAnswer, err
+}
+
+// establish negotiates a new ICE session with the remote peer and blocks until the ICE connectivity checks succeed.
+// The checks are given up after ConnConfig.Timeout, when ICE fails or when the Conn is closed externally.
+// A non-nil remoteOffer received before the attempt started is answered instead of sending a new offer.
+func (conn *Conn) establish(relayOnly bool, remoteOffer *OfferAnswer, releaseSlot func()) (*ice.Conn, OfferAnswer, error) {
+	var remoteOfferAnswer OfferAnswer
+
+	// the timeout is read once, an update during the attempt applies to the next one
+	conn.mu.Lock()
+	timeout := conn.config.Timeout
+	conn.mu.Unlock()
+
+	err := conn.reCreateAgent(relayOnly)
+	if err != nil {
+		return nil, remoteOfferAnswer, err
+	}
+
+	// a renegotiation requested before the offer is sent is fulfilled by this attempt
+	select {
+	case <-conn.renegotiateCh:
+	default:
+	}
+
+	if remoteOffer != nil {
+		remoteOfferAnswer = *remoteOffer
+		err = conn.sendAnswer()
+	} else {
+		remoteOfferAnswer, err = conn.offerAndWait(timeout, releaseSlot)
+	}
+	if err != nil {
+		return nil, remoteOfferAnswer, err
 	}
 
 	log.Debugf("received connection confirmation from peer %s running version %s and with remote WireGuard listen port %d",
 		conn.config.Key, remoteOfferAnswer.Version, remoteOfferAnswer.WgListenPort)
+	conn.setPhase(ConnPhaseAnswerReceived)
 
 	// at this point we received offer/answer and we are ready to gather candidates
 	conn.mu.Lock()
 	conn.status = StatusConnecting
+	if conn.notifyDisconnected != nil {
+		// release the context of the previous attempt
+		conn.notifyDisconnected()
+	}
 	conn.ctx, conn.notifyDisconnected = context.WithCancel(context.Background())
-	defer conn.notifyDisconnected()
 	conn.mu.Unlock()
 
-	peerState = State{
+	peerState := State{
 		PubKey:           conn.config.Key,
 		ConnStatus:       conn.status,
 		ConnStatusUpdate: time.Now(),
@@ -323,10 +515,34 @@ func (conn *Conn) Open() error {
 		log.Warnf("error while updating the state of peer %s,err: %v", conn.config.Key, err)
 	}
 
+	conn.setPhase(ConnPhaseGatheringCandidates)
 	err = conn.agent.GatherCandidates()
 	if err != nil {
-		return err
+		return nil, remoteOfferAnswer, err
+	}
+
+	conn.mu.Lock()
+	agent := conn.agent
+	if !relayOnly {
+		conn.addMappedAddressCandidate()
 	}
+	conn.mu.Unlock()
+
+	// the dial is canceled once the connection timeout is reached, the Conn is closed externally or renegotiated
+	dialCtx, cancel := context.WithTimeout(conn.ctx, timeout)
+	defer cancel()
+	renegotiated := make(chan struct{})
+	go func() {
+		select {
+		case <-conn.closeCh:
+			cancel()
+		case <-conn.renegotiateCh:
+			close(renegotiated)
+			cancel()
+		case <-dialCtx.Done():
+		}
+	}()
+	go conn.regatherOnStall(dialCtx, agent, remoteOfferAnswer.IceCredentials, relayOnly)
 
 	// will block until connection succeeded
 	// but it won't release if ICE Agent went into Disconnected or Failed state,
@@ -334,36 +550,227 @@ func (conn *Conn) Open() error {
 	isControlling := conn.config.LocalKey > conn.config.Key
 	var remoteConn *ice.Conn
 	if isControlling {
-		remoteConn, err = conn.agent.Dial(conn.ctx, remoteOfferAnswer.IceCredentials.UFrag, remoteOfferAnswer.IceCredentials.Pwd)
+		conn.setPhase(ConnPhaseDialing)
+		remoteConn, err = conn.agent.Dial(dialCtx, remoteOfferAnswer.IceCredentials.UFrag, remoteOfferAnswer.IceCredentials.Pwd)
 	} else {
-		remoteConn, err = conn.agent.Accept(conn.ctx, remoteOfferAnswer.IceCredentials.UFrag, remoteOfferAnswer.IceCredentials.Pwd)
+		conn.setPhase(ConnPhaseAccepting)
+		remoteConn, err = conn.agent.Accept(dialCtx, remoteOfferAnswer.IceCredentials.UFrag, remoteOfferAnswer.IceCredentials.Pwd)
 	}
 	if err != nil {
-		return err
+		if conn.isClosed() {
+			return nil, remoteOfferAnswer, NewConnectionClosedError(conn.config.Key)
+		}
+		select {
+		case <-renegotiated:
+			return nil, remoteOfferAnswer, NewConnectionRenegotiateError(conn.config.Key, conn.Phase())
+		default:
+		}
+		conn.nextTURNTier()
+		if errors.Is(dialCtx.Err(), context.DeadlineExceeded) {
+			return nil, remoteOfferAnswer, NewConnectionTimeoutError(conn.config.Key, timeout, conn.Phase())
+		}
+		return nil, remoteOfferAnswer, err
 	}
 
-	// dynamically set remote WireGuard port is other side specified a different one from the default one
-	remoteWgPort := iface.DefaultWgPort
-	if remoteOfferAnswer.WgListenPort != 0 {
-		remoteWgPort = remoteOfferAnswer.WgListenPort
+	return remoteConn, remoteOfferAnswer, nil
+}
+
+// offerAndWait sends the offer to the remote peer and waits for its offer or answer confirming the connection.
+// The negotiation slot is released if the remote peer doesn't reply within negotiationAnswerWait, so an offline peer
+// doesn't hold it until the connection timeout.
+func (conn *Conn) offerAndWait(timeout time.Duration, releaseSlot func()) (OfferAnswer, error) {
+	var remoteOfferAnswer OfferAnswer
+
+	err := conn.sendOffer()
+	if err != nil {
+		return remoteOfferAnswer, err
 	}
-	// the ice connection has been established successfully so we are ready to start the proxy
-	remoteAddr, err := conn.configureConnection(remoteConn, remoteWgPort, remoteOfferAnswer.RosenpassPubKey,
-		remoteOfferAnswer.RosenpassAddr)
+
+	log.Debugf("connection offer sent to peer %s, waiting for the confirmation", conn.config.Key)
+	conn.setPhase(ConnPhaseOfferSent)
+
+	timeoutTimer := time.NewTimer(timeout)
+	defer timeoutTimer.Stop()
+	slotTimer := time.NewTimer(negotiationAnswerWait)
+	defer slotTimer.Stop()
+
+	// Only continue once we got a connection confirmation from the remote peer.
+	// The connection timeout could have happened before a confirmation received from the remote.
+	// The connection could have also been closed externally (e.g. when we received an update from the management that peer shouldn't be connected)
+	for {
+		select {
+		case remoteOfferAnswer = <-conn.remoteOffersCh:
+			// received confirmation from the remote peer -> ready to proceed
+			return remoteOfferAnswer, conn.sendAnswer()
+		case remoteOfferAnswer = <-conn.remoteAnswerCh:
+			return remoteOfferAnswer, nil
+		case <-slotTimer.C:
+			log.Debugf("peer %s didn't reply to the offer in %s, releasing the negotiation slot",
+				conn.config.Key, negotiationAnswerWait)
+			releaseSlot()
+		case <-timeoutTimer.C:
+			return remoteOfferAnswer, NewConnectionTimeoutError(conn.config.Key, timeout, conn.Phase())
+		case <-conn.closeCh:
+			// closed externally
+			return remoteOfferAnswer, NewConnectionClosedError(conn.config.Key)
+		case <-conn.renegotiateCh:
+			return remoteOfferAnswer, NewConnectionRenegotiateError(conn.config.Key, conn.Phase())
+		}
+	}
+}
+
+// regatherDelay is how long the ICE connectivity checks run without success before the local candidates are
+// gathered again. It is shorter than the time the agent takes to give up checking.
+func regatherDelay() time.Duration {
+	return (iceDisconnectedTimeout() + iceFailedTimeout) / 2
+}
+
+// regatherOnStall re-gathers the local candidates once if the connectivity checks haven't succeeded after
+// regatherDelay. The remote peer adds the newly signaled candidates to its running checks, so a candidate that
+// was missed or a NAT binding that wasn't ready during the first gathering gets another chance without a new
+// offer/answer round.
+func (conn *Conn) regatherOnStall(ctx context.Context, agent *ice.Agent, remoteCredentials IceCredentials, relayOnly bool) {
+	timer := time.NewTimer(regatherDelay())
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+
+	phase := conn.Phase()
+	if phase != ConnPhaseDialing && phase != ConnPhaseAccepting {
+		return
+	}
+
+	log.Infof("connectivity checks with peer %s stalled, gathering the local candidates again", conn.config.Key)
+	if err := conn.regatherCandidates(agent, remoteCredentials, relayOnly); err != nil {
+		log.Warnf("failed to gather the local candidates for peer %s again: %v", conn.config.Key, err)
+	}
+}
+
+// regatherCandidates restarts the agent keeping the ICE credentials of both peers, adds the remote candidates
+// back and gathers the local candidates again. Nothing is done if the agent was replaced or the Conn closed.
+func (conn *Conn) regatherCandidates(agent *ice.Agent, remoteCredentials IceCredentials, relayOnly bool) error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.closed || agent == nil || conn.agent != agent {
+		return nil
+	}
+
+	localUfrag, localPwd, err := agent.GetLocalUserCredentials()
 	if err != nil {
 		return err
 	}
 
-	log.Infof("connected to peer %s, endpoint address: %s", conn.config.Key, remoteAddr.String())
+	// the restart drops the local and the remote candidates, the credentials are kept so the session goes on
+	if err := agent.Restart(localUfrag, localPwd); err != nil {
+		return err
+	}
+	if err := agent.SetRemoteCredentials(remoteCredentials.UFrag, remoteCredentials.Pwd); err != nil {
+		return err
+	}
 
-	// wait until connection disconnected or has been closed externally (upper layer, e.g. engine)
-	select {
-	case <-conn.closeCh:
-		// closed externally
-		return NewConnectionClosedError(conn.config.Key)
-	case <-conn.ctx.Done():
-		// disconnected from the remote peer
-		return NewConnectionDisconnectedError(conn.config.Key)
+	conn.observedIPCandidates = make(map[string]struct{})
+	conn.addRemoteCandidates()
+	if !relayOnly {
+		conn.addMappedAddressCandidate()
+	}
+
+	return agent.GatherCandidates()
+}
+
+// canFallbackToRelay returns true if the failed attempt can be retried with relay candidates only.
+// Only the attempts which reached the ICE connectivity checks are retried, a remote peer not confirming
+// the offer won't be reached over a relay either.
+func (conn *Conn) canFallbackToRelay(attempt int) bool {
+	if attempt >= conn.config.RelayFallbackAttempts || conn.isClosed() {
+		return false
+	}
+
+	// the agents are relay only already or the relay candidates aren't supported on the platform
+	if conn.config.ForceRelay || hasICEForceRelayConn() || !slices.Contains(conn.candidateTypes(false), ice.CandidateTypeRelay) {
+		return false
+	}
+
+	if !hasTurnURL(conn.config.StunTurn) {
+		return false
+	}
+
+	phase := conn.Phase()
+	return phase == ConnPhaseDialing || phase == ConnPhaseAccepting
+}
+
+func hasTurnURL(urls []*stun.URI) bool {
+	for _, url := range urls {
+		if url.Scheme == stun.SchemeTypeTURN || url.Scheme == stun.SchemeTypeTURNS {
+			return true
+		}
+	}
+	return false
+}
+
+func (conn *Conn) setRelayFallback(fallback bool) {
+	conn.mu.Lock()
+	conn.relayFallback = fallback
+	conn.mu.Unlock()
+
+	err := conn.statusRecorder.UpdatePeerRelayFallback(conn.config.Key, fallback)
+	if err != nil {
+		log.Debugf("error while updating the relay fallback of peer %s, err: %v", conn.config.Key, err)
+	}
+}
+
+// recordAttempt records the outcome of the attempt that got a negotiation slot in the status recorder.
+// The attempts aborted because the Conn was closed or renegotiated are not counted, they say nothing about the
+// connectivity.
+func (conn *Conn) recordAttempt(err error) {
+	if conn.attemptStarted.IsZero() {
+		return
+	}
+	duration := time.Since(conn.attemptStarted)
+	conn.attemptStarted = time.Time{}
+
+	var closedErr *ConnectionClosedError
+	var timeoutErr *ConnectionTimeoutError
+	var outcome ConnAttemptOutcome
+	switch {
+	case errors.As(err, &closedErr) || conn.isClosed() || isRenegotiateError(err):
+		return
+	case errors.As(err, &timeoutErr):
+		outcome = ConnAttemptTimedOut
+	case err != nil:
+		outcome = ConnAttemptFailed
+	default:
+		conn.mu.Lock()
+		relayFallback := conn.relayFallback
+		conn.mu.Unlock()
+		outcome = ConnAttemptSucceeded
+		if relayFallback {
+			outcome = ConnAttemptRelayFallback
+		}
+	}
+
+	conn.statusRecorder.RecordConnAttempt(conn.config.Key, outcome, duration)
+}
+
+// Phase returns the last phase the connection establishment reached
+func (conn *Conn) Phase() ConnPhase {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.phase
+}
+
+func (conn *Conn) setPhase(phase ConnPhase) {
+	conn.mu.Lock()
+	conn.phase = phase
+	conn.mu.Unlock()
+
+	err := conn.statusRecorder.UpdatePeerConnPhase(conn.config.Key, phase)
+	if err != nil {
+		log.Debugf("error while updating the connection phase of peer %s, err: %v", conn.config.Key, err)
 	}
 }
 
@@ -397,7 +804,12 @@ func (conn *Conn) configureConnection(remoteConn net.Conn, remoteWgPort int, rem
 
 	endpointUdpAddr, _ := net.ResolveUDPAddr(endpoint.Network(), endpoint.String())
 
-	err = conn.config.WgConfig.WgInterface.UpdatePeer(conn.config.WgConfig.RemoteKey, conn.config.WgConfig.AllowedIps, defaultWgKeepAlive, endpointUdpAddr, conn.config.WgConfig.PreSharedKey)
+	keepalive := conn.config.WgConfig.Keepalive
+	if keepalive == 0 {
+		keepalive = defaultWgKeepAlive
+	}
+
+	err = conn.config.WgConfig.WgInterface.UpdatePeer(conn.config.WgConfig.RemoteKey, conn.config.WgConfig.AllowedIps, keepalive, endpointUdpAddr, conn.config.WgConfig.PreSharedKey)
 	if err != nil {
 		if conn.wgProxy != nil {
 			_ = conn.wgProxy.CloseConn()
@@ -426,7 +838,8 @@ func (conn *Conn) configureConnection(remoteConn net.Conn, remoteWgPort int, rem
 		log.Warnf("unable to save peer's state, got error: %v", err)
 	}
 
-	_, ipNet, err := net.ParseCIDR(conn.config.WgConfig.AllowedIps)
+	// the allowed IPs might include the IPv6 address of the peer after its IPv4 address
+	_, ipNet, err := net.ParseCIDR(strings.Split(conn.config.WgConfig.AllowedIps, ",")[0])
 	if err != nil {
 		return nil, err
 	}
@@ -471,6 +884,7 @@ func (conn *Conn) cleanup() error {
 			conn.agent = nil
 		}
 	}
+	conn.remoteCandidates = nil
 
 	if conn.wgProxy != nil {
 		err2 = conn.wgProxy.CloseConn()
@@ -620,28 +1034,54 @@ func (conn *Conn) sendOffer() error {
 	return nil
 }
 
-// Close closes this peer Conn issuing a close event to the Conn closeCh
+// Close closes this peer Conn closing the Conn closeCh.
+// A Conn closed before it has been opened, e.g. when the peer is removed by an update from management right after
+// it has been added, returns ConnectionClosedError from Open, so it never connects
 func (conn *Conn) Close() error {
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
+	if conn.closed {
+		log.Warnf("connection has been already closed %s", conn.config.Key)
+		return NewConnectionAlreadyClosed(conn.config.Key)
+	}
+	conn.closed = true
+	close(conn.closeCh)
+	return nil
+}
+
+func (conn *Conn) isClosed() bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.closed
+}
+
+// Reconnect drops the established connection, so it is negotiated again with the remote peer.
+// It does nothing when the connection isn't established.
+func (conn *Conn) Reconnect() {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.status != StatusConnected || conn.notifyDisconnected == nil {
+		return
+	}
+	log.Infof("reconnecting to peer %s", conn.config.Key)
+	conn.notifyDisconnected()
+}
+
+// Renegotiate gives up the negotiation of the current attempt if the connection isn't established, so the next
+// attempt sends a new offer, e.g. after the Signal client failed over to another Signal Service and the offer, the
+// answer or the candidates sent through the previous one might have been lost. The established connection is kept.
+func (conn *Conn) Renegotiate() {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.closed || conn.status == StatusConnected {
+		return
+	}
 	select {
-	case conn.closeCh <- struct{}{}:
-		return nil
+	case conn.renegotiateCh <- struct{}{}:
+		log.Debugf("renegotiating the connection to peer %s", conn.config.Key)
 	default:
-		// probably could happen when peer has been added and removed right after not even starting to connect
-		// todo further investigate
-		// this really happens due to unordered messages coming from management
-		// more importantly it causes inconsistency -> 2 Conn objects for the same peer
-		// e.g. this flow:
-		// update from management has peers: [1,2,3,4]
-		// engine creates a Conn for peers:  [1,2,3,4] and schedules Open in ~1sec
-		// before conn.Open() another update from management arrives with peers: [1,2,3]
-		// engine removes peer 4 and calls conn.Close() which does nothing (this default clause)
-		// before conn.Open() another update from management arrives with peers: [1,2,3,4,5]
-		// engine adds a new Conn for 4 and 5
-		// therefore peer 4 has 2 Conn objects
-		log.Warnf("connection has been already closed or attempted closing not started coonection %s", conn.config.Key)
-		return NewConnectionAlreadyClosed(conn.config.Key)
 	}
 }
 
@@ -689,18 +1129,147 @@ func (conn *Conn) OnRemoteCandidate(candidate ice.Candidate) {
 		conn.mu.Lock()
 		defer conn.mu.Unlock()
 
-		if conn.agent == nil {
+		if conn.closed {
 			return
 		}
 
-		err := conn.agent.AddRemoteCandidate(candidate)
-		if err != nil {
-			log.Errorf("error while handling remote candidate from peer %s", conn.config.Key)
+		if len(conn.remoteCandidates) >= maxRemoteCandidates {
+			log.Warnf("dropping remote candidate from peer %s, it sent more than %d candidates", conn.config.Key, maxRemoteCandidates)
 			return
 		}
+		conn.remoteCandidates = append(conn.remoteCandidates, candidate)
+
+		// the candidate can arrive while the agent is being replaced, it is added to the next agent
+		if conn.agent == nil {
+			log.Debugf("buffering remote candidate from peer %s until the agent is created", conn.config.Key)
+			return
+		}
+
+		conn.addRemoteCandidate(candidate)
 	}()
 }
 
+// addRemoteCandidates adds the remote candidates of the current session to the agent.
+// Should be called with conn.mu locked.
+func (conn *Conn) addRemoteCandidates() {
+	for _, candidate := range conn.remoteCandidates {
+		conn.addRemoteCandidate(candidate)
+	}
+}
+
+// addRemoteCandidate adds the candidate and its observed IP hint to the agent. The agent accepts remote candidates
+// during the connectivity checks as well. Should be called with conn.mu locked.
+func (conn *Conn) addRemoteCandidate(candidate ice.Candidate) {
+	err := conn.agent.AddRemoteCandidate(candidate)
+	if err != nil {
+		log.Errorf("error while handling remote candidate from peer %s", conn.config.Key)
+		return
+	}
+
+	conn.addObservedIPCandidate(candidate)
+}
+
+// addObservedIPCandidate adds a server reflexive candidate built from the remote peer's observed IP and the port of
+// the given remote candidate. It helps when the remote peer couldn't discover its public address via STUN, but
+// its NAT preserves ports. Should be called with conn.mu locked.
+func (conn *Conn) addObservedIPCandidate(candidate ice.Candidate) {
+	hint, err := observedIPCandidate(conn.config.ObservedIP, candidate)
+	if err != nil {
+		log.Debugf("failed to create observed IP candidate for peer %s: %s", conn.config.Key, err)
+		return
+	}
+	if hint == nil {
+		return
+	}
+
+	hintKey := fmt.Sprintf("%s:%d", hint.Address(), hint.Port())
+	if _, ok := conn.observedIPCandidates[hintKey]; ok {
+		return
+	}
+	conn.observedIPCandidates[hintKey] = struct{}{}
+
+	log.Debugf("adding observed IP candidate %s for peer %s", hint.String(), conn.config.Key)
+	err = conn.agent.AddRemoteCandidate(hint)
+	if err != nil {
+		log.Errorf("error while adding observed IP candidate for peer %s: %s", conn.config.Key, err)
+	}
+}
+
+// addMappedAddressCandidate adds the port mapping of the remote peer as its server reflexive candidate.
+// Should be called with conn.mu locked.
+func (conn *Conn) addMappedAddressCandidate() {
+	hint, err := mappedAddressCandidate(conn.config.MappedAddress)
+	if err != nil {
+		log.Debugf("failed to create mapped address candidate for peer %s: %s", conn.config.Key, err)
+		return
+	}
+	if hint == nil || conn.agent == nil {
+		return
+	}
+
+	hintKey := fmt.Sprintf("%s:%d", hint.Address(), hint.Port())
+	if _, ok := conn.observedIPCandidates[hintKey]; ok {
+		return
+	}
+	conn.observedIPCandidates[hintKey] = struct{}{}
+
+	log.Debugf("adding mapped address candidate %s for peer %s", hint.String(), conn.config.Key)
+	err = conn.agent.AddRemoteCandidate(hint)
+	if err != nil {
+		log.Errorf("error while adding mapped address candidate for peer %s: %s", conn.config.Key, err)
+	}
+}
+
+// mappedAddressCandidate returns a UDP server reflexive candidate of the mapped address, nil if it isn't set
+func mappedAddressCandidate(address netip.AddrPort) (ice.Candidate, error) {
+	if !address.IsValid() || address.Port() == 0 {
+		return nil, nil
+	}
+
+	network := ice.NetworkTypeUDP4
+	if address.Addr().Unmap().Is6() {
+		network = ice.NetworkTypeUDP6
+	}
+
+	return ice.NewCandidateServerReflexive(&ice.CandidateServerReflexiveConfig{
+		Network:   network.NetworkShort(),
+		Address:   address.Addr().Unmap().String(),
+		Port:      int(address.Port()),
+		Component: 1,
+	})
+}
+
+// observedIPCandidate returns a server reflexive candidate with the observed IP and the port of the remote UDP
+// host or server reflexive candidate. Returns nil if no hint applies, e.g. the candidate already uses the observed IP.
+func observedIPCandidate(observedIP net.IP, candidate ice.Candidate) (ice.Candidate, error) {
+	if observedIP == nil || candidate.NetworkType().IsTCP() {
+		return nil, nil
+	}
+
+	if candidate.Type() != ice.CandidateTypeHost && candidate.Type() != ice.CandidateTypeServerReflexive {
+		return nil, nil
+	}
+
+	candidateIP := net.ParseIP(candidate.Address())
+	if candidateIP == nil || candidateIP.Equal(observedIP) {
+		return nil, nil
+	}
+
+	// don't mix address families
+	if (candidateIP.To4() == nil) != (observedIP.To4() == nil) {
+		return nil, nil
+	}
+
+	return ice.NewCandidateServerReflexive(&ice.CandidateServerReflexiveConfig{
+		Network:   candidate.NetworkType().NetworkShort(),
+		Address:   observedIP.String(),
+		Port:      candidate.Port(),
+		Component: candidate.Component(),
+		RelAddr:   candidate.Address(),
+		RelPort:   candidate.Port(),
+	})
+}
+
 func (conn *Conn) GetKey() string {
 	return conn.config.Key
 }
@@ -0,0 +1,126 @@
+package peer
+
+import (
+	"sort"
+	"time"
+)
+
+// ConnAttemptOutcome is the outcome of a connection establishment attempt to a remote peer
+type ConnAttemptOutcome int
+
+const (
+	// ConnAttemptSucceeded is an attempt connected without falling back to relay candidates
+	ConnAttemptSucceeded ConnAttemptOutcome = iota
+	// ConnAttemptRelayFallback is an attempt connected after the direct connection failed and it fell back to relay
+	// candidates only
+	ConnAttemptRelayFallback
+	// ConnAttemptTimedOut is an attempt given up after the connection timeout
+	ConnAttemptTimedOut
+	// ConnAttemptFailed is an attempt that failed for another reason, e.g. the ICE agent or the proxy failed
+	ConnAttemptFailed
+)
+
+func (o ConnAttemptOutcome) String() string {
+	switch o {
+	case ConnAttemptSucceeded:
+		return "Succeeded"
+	case ConnAttemptRelayFallback:
+		return "RelayFallback"
+	case ConnAttemptTimedOut:
+		return "TimedOut"
+	case ConnAttemptFailed:
+		return "Failed"
+	default:
+		return "Unknown"
+	}
+}
+
+// connAttemptSamples is the number of the latest establishment times the median is calculated from
+const connAttemptSamples = 32
+
+// ConnAttemptStats contains the counters of the connection establishment attempts
+type ConnAttemptStats struct {
+	Attempts       uint64
+	Succeeded      uint64
+	RelayFallbacks uint64
+	TimedOut       uint64
+	Failed         uint64
+	// MedianEstablishment is the median time the latest successful attempts took to connect
+	MedianEstablishment time.Duration
+}
+
+// SuccessRate returns the ratio of the connected attempts, including the relay fallbacks, to all the attempts
+func (s ConnAttemptStats) SuccessRate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Succeeded+s.RelayFallbacks) / float64(s.Attempts)
+}
+
+// connAttempts holds the attempts of a single peer
+type connAttempts struct {
+	stats ConnAttemptStats
+	// durations are the establishment times of the latest successful attempts, the oldest one is overwritten
+	durations []time.Duration
+	next      int
+}
+
+func (a *connAttempts) record(outcome ConnAttemptOutcome, duration time.Duration) {
+	a.stats.Attempts++
+	switch outcome {
+	case ConnAttemptSucceeded:
+		a.stats.Succeeded++
+	case ConnAttemptRelayFallback:
+		a.stats.RelayFallbacks++
+	case ConnAttemptTimedOut:
+		a.stats.TimedOut++
+		return
+	default:
+		a.stats.Failed++
+		return
+	}
+
+	if len(a.durations) < connAttemptSamples {
+		a.durations = append(a.durations, duration)
+		return
+	}
+	a.durations[a.next] = duration
+	a.next = (a.next + 1) % connAttemptSamples
+}
+
+func (a *connAttempts) snapshot() ConnAttemptStats {
+	stats := a.stats
+	stats.MedianEstablishment = medianDuration(a.durations)
+	return stats
+}
+
+// aggregateConnAttempts sums up the counters of all the peers, the median is taken from the samples of all the peers
+func aggregateConnAttempts(attempts map[string]*connAttempts) ConnAttemptStats {
+	var total ConnAttemptStats
+	var durations []time.Duration
+	for _, a := range attempts {
+		total.Attempts += a.stats.Attempts
+		total.Succeeded += a.stats.Succeeded
+		total.RelayFallbacks += a.stats.RelayFallbacks
+		total.TimedOut += a.stats.TimedOut
+		total.Failed += a.stats.Failed
+		durations = append(durations, a.durations...)
+	}
+	total.MedianEstablishment = medianDuration(durations)
+	return total
+}
+
+func medianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,69 @@
+package peer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/ice/v3"
+)
+
+// ConnOrdering biases the selection between the direct and the relayed connection to a remote peer
+type ConnOrdering string
+
+const (
+	// ConnOrderingAuto lets ICE select the connection with its default preferences
+	ConnOrderingAuto ConnOrdering = ""
+	// ConnOrderingRelayLast holds the relayed connection back, so the direct connection gets more time to succeed
+	ConnOrderingRelayLast ConnOrdering = "relay-last"
+	// ConnOrderingRelayFirst holds the direct connection back, so the relayed connection is used as soon as it works
+	ConnOrderingRelayFirst ConnOrdering = "relay-first"
+
+	// DefaultConnOrderingDelay is how long the not preferred connection is held back by default
+	DefaultConnOrderingDelay = 3 * time.Second
+)
+
+// ParseConnOrdering returns the ConnOrdering of the given name, an empty name stands for ConnOrderingAuto
+func ParseConnOrdering(name string) (ConnOrdering, error) {
+	switch ordering := ConnOrdering(name); ordering {
+	case ConnOrderingAuto, ConnOrderingRelayLast, ConnOrderingRelayFirst:
+		return ordering, nil
+	default:
+		return ConnOrderingAuto, fmt.Errorf("unsupported connection ordering %q, expected %q or %q",
+			name, ConnOrderingRelayLast, ConnOrderingRelayFirst)
+	}
+}
+
+// applyConnOrdering sets the minimum time ICE waits before nominating a candidate pair of each type.
+// All candidate types are still gathered and checked, the not preferred ones are only nominated after the delay
+// if the preferred ones didn't succeed meanwhile. Only the controlling agent nominates, so its ordering decides.
+// The delay is capped to half of the ICE failed timeout and of the connection timeout, so the not preferred
+// connection is still established before the attempt is given up.
+func applyConnOrdering(agentConfig *ice.AgentConfig, ordering ConnOrdering, delay, failedTimeout, timeout time.Duration) {
+	if ordering == ConnOrderingAuto {
+		return
+	}
+
+	if delay <= 0 {
+		delay = DefaultConnOrderingDelay
+	}
+	if maxDelay := failedTimeout / 2; delay > maxDelay {
+		delay = maxDelay
+	}
+	if maxDelay := timeout / 2; timeout > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+
+	var noWait time.Duration
+	switch ordering {
+	case ConnOrderingRelayLast:
+		agentConfig.HostAcceptanceMinWait = &noWait
+		agentConfig.SrflxAcceptanceMinWait = &noWait
+		agentConfig.PrflxAcceptanceMinWait = &noWait
+		agentConfig.RelayAcceptanceMinWait = &delay
+	case ConnOrderingRelayFirst:
+		agentConfig.HostAcceptanceMinWait = &delay
+		agentConfig.SrflxAcceptanceMinWait = &delay
+		agentConfig.PrflxAcceptanceMinWait = &delay
+		agentConfig.RelayAcceptanceMinWait = &noWait
+	}
+}
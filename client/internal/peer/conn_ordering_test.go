@@ -0,0 +1,99 @@
+package peer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConnOrdering(t *testing.T) {
+	for _, name := range []string{"", "relay-last", "relay-first"} {
+		ordering, err := ParseConnOrdering(name)
+		require.NoError(t, err)
+		assert.Equal(t, ConnOrdering(name), ordering)
+	}
+
+	ordering, err := ParseConnOrdering("direct-only")
+	assert.Error(t, err)
+	assert.Equal(t, ConnOrderingAuto, ordering)
+}
+
+func TestApplyConnOrdering(t *testing.T) {
+	testCases := []struct {
+		name          string
+		ordering      ConnOrdering
+		delay         time.Duration
+		timeout       time.Duration
+		expectedDelay time.Duration
+	}{
+		{
+			name:          "Relay Last",
+			ordering:      ConnOrderingRelayLast,
+			delay:         2 * time.Second,
+			timeout:       30 * time.Second,
+			expectedDelay: 2 * time.Second,
+		},
+		{
+			name:          "Relay First",
+			ordering:      ConnOrderingRelayFirst,
+			delay:         2 * time.Second,
+			timeout:       30 * time.Second,
+			expectedDelay: 2 * time.Second,
+		},
+		{
+			name:          "Default Delay",
+			ordering:      ConnOrderingRelayLast,
+			timeout:       30 * time.Second,
+			expectedDelay: DefaultConnOrderingDelay,
+		},
+		{
+			name:          "Delay Capped By Failed Timeout",
+			ordering:      ConnOrderingRelayLast,
+			delay:         time.Minute,
+			timeout:       time.Hour,
+			expectedDelay: 3 * time.Second,
+		},
+		{
+			name:          "Delay Capped By Connection Timeout",
+			ordering:      ConnOrderingRelayFirst,
+			delay:         3 * time.Second,
+			timeout:       4 * time.Second,
+			expectedDelay: 2 * time.Second,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			agentConfig := &ice.AgentConfig{}
+			applyConnOrdering(agentConfig, testCase.ordering, testCase.delay, 6*time.Second, testCase.timeout)
+
+			require.NotNil(t, agentConfig.HostAcceptanceMinWait)
+			require.NotNil(t, agentConfig.SrflxAcceptanceMinWait)
+			require.NotNil(t, agentConfig.PrflxAcceptanceMinWait)
+			require.NotNil(t, agentConfig.RelayAcceptanceMinWait)
+
+			directDelay, relayDelay := testCase.expectedDelay, time.Duration(0)
+			if testCase.ordering == ConnOrderingRelayLast {
+				directDelay, relayDelay = 0, testCase.expectedDelay
+			}
+
+			assert.Equal(t, directDelay, *agentConfig.HostAcceptanceMinWait)
+			assert.Equal(t, directDelay, *agentConfig.SrflxAcceptanceMinWait)
+			assert.Equal(t, directDelay, *agentConfig.PrflxAcceptanceMinWait)
+			assert.Equal(t, relayDelay, *agentConfig.RelayAcceptanceMinWait)
+		})
+	}
+}
+
+func TestApplyConnOrderingAuto(t *testing.T) {
+	agentConfig := &ice.AgentConfig{}
+	applyConnOrdering(agentConfig, ConnOrderingAuto, time.Second, 6*time.Second, 30*time.Second)
+
+	assert.Nil(t, agentConfig.HostAcceptanceMinWait, "ICE defaults should be kept")
+	assert.Nil(t, agentConfig.SrflxAcceptanceMinWait, "ICE defaults should be kept")
+	assert.Nil(t, agentConfig.PrflxAcceptanceMinWait, "ICE defaults should be kept")
+	assert.Nil(t, agentConfig.RelayAcceptanceMinWait, "ICE defaults should be kept")
+}
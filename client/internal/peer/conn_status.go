@@ -1,6 +1,11 @@
 package peer
 
-import log "github.com/sirupsen/logrus"
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
 
 const (
 	// StatusConnected indicate the peer is in connected state
@@ -9,6 +14,8 @@ const (
 	StatusConnecting
 	// StatusDisconnected indicate the peer is in disconnected state
 	StatusDisconnected
+	// StatusStale indicate the peer is connected but its WireGuard handshake and traffic are stale
+	StatusStale
 )
 
 // ConnStatus describe the status of a peer's connection
@@ -22,8 +29,92 @@ func (s ConnStatus) String() string {
 		return "Connected"
 	case StatusDisconnected:
 		return "Disconnected"
+	case StatusStale:
+		return "Stale"
 	default:
 		log.Errorf("unknown status: %d", s)
 		return "INVALID_PEER_CONNECTION_STATUS"
 	}
 }
+
+const (
+	// ConnPhaseIdle indicate the connection establishment hasn't started yet
+	ConnPhaseIdle ConnPhase = iota
+	// ConnPhaseQueued indicate the connection waits for the other connections to finish negotiating
+	ConnPhaseQueued
+	// ConnPhaseOfferSent indicate the connection offer has been sent and the remote peer has to confirm it
+	ConnPhaseOfferSent
+	// ConnPhaseAnswerReceived indicate the remote peer confirmed the connection with an offer or an answer
+	ConnPhaseAnswerReceived
+	// ConnPhaseGatheringCandidates indicate the ICE candidates are being gathered
+	ConnPhaseGatheringCandidates
+	// ConnPhaseDialing indicate the controlling side is waiting for the ICE connectivity checks to succeed
+	ConnPhaseDialing
+	// ConnPhaseAccepting indicate the controlled side is waiting for the ICE connectivity checks to succeed
+	ConnPhaseAccepting
+	// ConnPhaseProxyStarted indicate the ICE connection is established and the WireGuard traffic is proxied
+	ConnPhaseProxyStarted
+)
+
+// ConnPhase describe the last step a peer's connection establishment reached
+type ConnPhase int
+
+func (p ConnPhase) String() string {
+	switch p {
+	case ConnPhaseIdle:
+		return "idle"
+	case ConnPhaseQueued:
+		return "queued"
+	case ConnPhaseOfferSent:
+		return "offer sent"
+	case ConnPhaseAnswerReceived:
+		return "answer received"
+	case ConnPhaseGatheringCandidates:
+		return "gathering candidates"
+	case ConnPhaseDialing:
+		return "dialing"
+	case ConnPhaseAccepting:
+		return "accepting"
+	case ConnPhaseProxyStarted:
+		return "proxy started"
+	default:
+		log.Errorf("unknown connection phase: %d", p)
+		return "INVALID_PEER_CONNECTION_PHASE"
+	}
+}
+
+const (
+	// ConnPathDisconnected indicate there is no connection to the peer
+	ConnPathDisconnected ConnPath = iota
+	// ConnPathDirect indicate the traffic to the peer goes over a peer-to-peer connection
+	ConnPathDirect
+	// ConnPathRelayed indicate the traffic to the peer goes through a relay server
+	ConnPathRelayed
+)
+
+// ConnPath describe the path the traffic to a peer takes
+type ConnPath int
+
+func (p ConnPath) String() string {
+	switch p {
+	case ConnPathDisconnected:
+		return "Disconnected"
+	case ConnPathDirect:
+		return "Direct"
+	case ConnPathRelayed:
+		return "Relayed"
+	default:
+		log.Errorf("unknown connection path: %d", p)
+		return "INVALID_PEER_CONNECTION_PATH"
+	}
+}
+
+// ParseConnPath returns the connection path of the case-insensitive name
+func ParseConnPath(name string) (ConnPath, error) {
+	for _, path := range []ConnPath{ConnPathDisconnected, ConnPathDirect, ConnPathRelayed} {
+		if strings.EqualFold(name, path.String()) {
+			return path, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown connection path %q, should be one of direct|relayed|disconnected", name)
+}
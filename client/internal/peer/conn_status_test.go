@@ -15,6 +15,7 @@ func TestConnStatus_String(t *testing.T) {
 		{"StatusConnected", StatusConnected, "Connected"},
 		{"StatusDisconnected", StatusDisconnected, "Disconnected"},
 		{"StatusConnecting", StatusConnecting, "Connecting"},
+		{"StatusStale", StatusStale, "Stale"},
 	}
 
 	for _, table := range tables {
@@ -25,3 +26,28 @@ func TestConnStatus_String(t *testing.T) {
 	}
 
 }
+
+func TestConnPhase_String(t *testing.T) {
+
+	tables := []struct {
+		name  string
+		phase ConnPhase
+		want  string
+	}{
+		{"ConnPhaseIdle", ConnPhaseIdle, "idle"},
+		{"ConnPhaseQueued", ConnPhaseQueued, "queued"},
+		{"ConnPhaseOfferSent", ConnPhaseOfferSent, "offer sent"},
+		{"ConnPhaseAnswerReceived", ConnPhaseAnswerReceived, "answer received"},
+		{"ConnPhaseGatheringCandidates", ConnPhaseGatheringCandidates, "gathering candidates"},
+		{"ConnPhaseDialing", ConnPhaseDialing, "dialing"},
+		{"ConnPhaseAccepting", ConnPhaseAccepting, "accepting"},
+		{"ConnPhaseProxyStarted", ConnPhaseProxyStarted, "proxy started"},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			got := table.phase.String()
+			assert.Equal(t, got, table.want, "they should be equal")
+		})
+	}
+}
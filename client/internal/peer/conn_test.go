@@ -1,11 +1,14 @@
 package peer
 
 import (
+	"net"
+	"net/netip"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/magiconair/properties/assert"
+	"github.com/pion/ice/v3"
 	"github.com/pion/stun/v2"
 
 	"github.com/netbirdio/netbird/client/internal/stdnet"
@@ -182,3 +185,317 @@ func TestConn_Close(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestConn_CanFallbackToRelay(t *testing.T) {
+	turnURL, err := stun.ParseURI("turn:turn.netbird.io:3478?transport=udp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stunURL, err := stun.ParseURI("stun:stun.netbird.io:3478")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables := []struct {
+		name       string
+		urls       []*stun.URI
+		attempts   int
+		attempt    int
+		phase      ConnPhase
+		closed     bool
+		forceRelay bool
+		want       bool
+	}{
+		{name: "ICE checks failed", urls: []*stun.URI{stunURL, turnURL}, attempts: 1, phase: ConnPhaseDialing, want: true},
+		{name: "ICE checks failed on the controlled side", urls: []*stun.URI{turnURL}, attempts: 1, phase: ConnPhaseAccepting, want: true},
+		{name: "attempts exhausted", urls: []*stun.URI{turnURL}, attempts: 1, attempt: 1, phase: ConnPhaseDialing, want: false},
+		{name: "fallback disabled", urls: []*stun.URI{turnURL}, attempts: 0, phase: ConnPhaseDialing, want: false},
+		{name: "no TURN server", urls: []*stun.URI{stunURL}, attempts: 1, phase: ConnPhaseDialing, want: false},
+		{name: "offer not confirmed", urls: []*stun.URI{turnURL}, attempts: 1, phase: ConnPhaseOfferSent, want: false},
+		{name: "closed", urls: []*stun.URI{turnURL}, attempts: 1, phase: ConnPhaseDialing, closed: true, want: false},
+		{name: "relay forced", urls: []*stun.URI{turnURL}, attempts: 1, phase: ConnPhaseDialing, forceRelay: true, want: false},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			conf := connConf
+			conf.StunTurn = table.urls
+			conf.RelayFallbackAttempts = table.attempts
+			conf.ForceRelay = table.forceRelay
+			conn := &Conn{config: conf, phase: table.phase, closed: table.closed}
+
+			assert.Equal(t, conn.canFallbackToRelay(table.attempt), table.want)
+		})
+	}
+}
+
+func TestConn_CandidateTypesRelayOnly(t *testing.T) {
+	conn := &Conn{config: connConf}
+	assert.Equal(t, conn.candidateTypes(true), []ice.CandidateType{ice.CandidateTypeRelay})
+}
+
+func TestConn_CandidateTypesForceRelay(t *testing.T) {
+	conf := connConf
+	conf.ForceRelay = true
+	conn := &Conn{config: conf}
+	assert.Equal(t, conn.candidateTypes(false), []ice.CandidateType{ice.CandidateTypeRelay})
+}
+
+func TestObservedIPCandidate(t *testing.T) {
+	hostCandidate, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+		Network:   "udp",
+		Address:   "192.168.1.10",
+		Port:      51820,
+		Component: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relayCandidate, err := ice.NewCandidateRelay(&ice.CandidateRelayConfig{
+		Network:   "udp",
+		Address:   "203.0.113.20",
+		Port:      3478,
+		Component: 1,
+		RelAddr:   "192.168.1.10",
+		RelPort:   51820,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("no observed IP", func(t *testing.T) {
+		hint, err := observedIPCandidate(nil, hostCandidate)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, hint, nil)
+	})
+
+	t.Run("relay candidate is ignored", func(t *testing.T) {
+		hint, err := observedIPCandidate(net.ParseIP("198.51.100.10"), relayCandidate)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, hint, nil)
+	})
+
+	t.Run("candidate already uses observed IP", func(t *testing.T) {
+		hint, err := observedIPCandidate(net.ParseIP("192.168.1.10"), hostCandidate)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, hint, nil)
+	})
+
+	t.Run("different address family", func(t *testing.T) {
+		hint, err := observedIPCandidate(net.ParseIP("2001:db8::1"), hostCandidate)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, hint, nil)
+	})
+
+	t.Run("host candidate", func(t *testing.T) {
+		hint, err := observedIPCandidate(net.ParseIP("198.51.100.10"), hostCandidate)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, hint.Type(), ice.CandidateTypeServerReflexive)
+		assert.Equal(t, hint.Address(), "198.51.100.10")
+		assert.Equal(t, hint.Port(), 51820)
+		assert.Equal(t, hint.RelatedAddress().Address, "192.168.1.10")
+	})
+}
+
+func TestMappedAddressCandidate(t *testing.T) {
+	t.Run("not set", func(t *testing.T) {
+		hint, err := mappedAddressCandidate(netip.AddrPort{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, hint, nil)
+	})
+
+	t.Run("IPv4 mapping", func(t *testing.T) {
+		hint, err := mappedAddressCandidate(netip.MustParseAddrPort("[::ffff:203.0.113.7]:51820"))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, hint.Type(), ice.CandidateTypeServerReflexive)
+		assert.Equal(t, hint.NetworkType(), ice.NetworkTypeUDP4)
+		assert.Equal(t, hint.Address(), "203.0.113.7")
+		assert.Equal(t, hint.Port(), 51820)
+	})
+}
+
+func TestConn_RemoteCandidateWithoutAgent(t *testing.T) {
+	wgProxyFactory := wgproxy.NewFactory(connConf.LocalWgPort)
+	defer func() {
+		_ = wgProxyFactory.Free()
+	}()
+	conn, err := NewConn(connConf, NewRecorder("https://mgm"), wgProxyFactory, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	candidate, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+		Network:   "udp",
+		Address:   "192.168.1.10",
+		Port:      51820,
+		Component: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn.OnRemoteCandidate(candidate)
+	waitRemoteCandidates(t, conn, 1)
+
+	err = conn.reCreateAgent(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.agent.Close()
+	}()
+
+	assert.Equal(t, agentRemoteCandidates(t, conn.agent, 1), 1, "the buffered candidate should be added to the new agent")
+
+	// a new agent is a new session, the candidates of the previous one don't apply to it
+	err = conn.reCreateAgent(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, agentRemoteCandidates(t, conn.agent, 0), 0)
+}
+
+func TestConn_RegatherCandidates(t *testing.T) {
+	wgProxyFactory := wgproxy.NewFactory(connConf.LocalWgPort)
+	defer func() {
+		_ = wgProxyFactory.Free()
+	}()
+	conn, err := NewConn(connConf, NewRecorder("https://mgm"), wgProxyFactory, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetSignalCandidate(func(candidate ice.Candidate) error {
+		return nil
+	})
+
+	err = conn.reCreateAgent(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.agent.Close()
+	}()
+	agent := conn.agent
+
+	candidate, err := ice.NewCandidateHost(&ice.CandidateHostConfig{
+		Network:   "udp",
+		Address:   "192.168.1.10",
+		Port:      51820,
+		Component: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.OnRemoteCandidate(candidate)
+	waitRemoteCandidates(t, conn, 1)
+
+	localUfrag, localPwd, err := agent.GetLocalUserCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = agent.GatherCandidates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = conn.regatherCandidates(agent, IceCredentials{UFrag: "remoteufrag", Pwd: "remotepwdremotepwdremotepwd"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	regatheredUfrag, regatheredPwd, err := agent.GetLocalUserCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, regatheredUfrag, localUfrag, "the local credentials should be kept")
+	assert.Equal(t, regatheredPwd, localPwd, "the local credentials should be kept")
+
+	assert.Equal(t, agentRemoteCandidates(t, agent, 1), 1, "the remote candidates should be added again")
+
+	err = conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.regatherCandidates(agent, IceCredentials{}, false)
+	assert.Equal(t, err, nil, "a closed Conn shouldn't re-gather")
+}
+
+func waitRemoteCandidates(t *testing.T, conn *Conn, count int) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		conn.mu.Lock()
+		received := len(conn.remoteCandidates)
+		conn.mu.Unlock()
+		if received == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d remote candidates", count)
+}
+
+// agentRemoteCandidates returns the number of the remote candidates of the agent once it reaches the expected one or
+// after a second, the agent adds the remote candidates asynchronously
+func agentRemoteCandidates(t *testing.T, agent *ice.Agent, expected int) int {
+	t.Helper()
+	var count int
+	for i := 0; i < 100; i++ {
+		candidates, err := agent.GetRemoteCandidates()
+		if err != nil {
+			t.Fatal(err)
+		}
+		count = len(candidates)
+		if count == expected {
+			return count
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return count
+}
+
+func TestConn_Renegotiate(t *testing.T) {
+	wgProxyFactory := wgproxy.NewFactory(connConf.LocalWgPort)
+	defer func() {
+		_ = wgProxyFactory.Free()
+	}()
+	conf := connConf
+	conf.Timeout = 10 * time.Second
+	conn, err := NewConn(conf, NewRecorder("https://mgm"), wgProxyFactory, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetSignalOffer(func(OfferAnswer) error {
+		return nil
+	})
+
+	conn.status = StatusConnected
+	conn.Renegotiate()
+	assert.Equal(t, len(conn.renegotiateCh), 0, "an established connection shouldn't be renegotiated")
+	conn.status = StatusDisconnected
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := conn.establish(false, nil, func() {})
+		errCh <- err
+	}()
+	defer func() {
+		_ = conn.agent.Close()
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for conn.Phase() != ConnPhaseOfferSent {
+		if time.Now().After(deadline) {
+			t.Fatal("the offer wasn't sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	conn.Renegotiate()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, isRenegotiateError(err), true, "the attempt should be given up for a new offer")
+	case <-time.After(2 * time.Second):
+		t.Fatal("the attempt waiting for the answer wasn't renegotiated")
+	}
+}
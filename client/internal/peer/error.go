@@ -1,6 +1,7 @@
 package peer
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
@@ -9,17 +10,24 @@ import (
 type ConnectionTimeoutError struct {
 	peer    string
 	timeout time.Duration
+	phase   ConnPhase
 }
 
 func (e *ConnectionTimeoutError) Error() string {
-	return fmt.Sprintf("connection to peer %s timed out after %s", e.peer, e.timeout.String())
+	return fmt.Sprintf("connection to peer %s timed out after %s, last phase reached: %s", e.peer, e.timeout.String(), e.phase)
+}
+
+// Phase returns the last phase the connection establishment reached before the timeout
+func (e *ConnectionTimeoutError) Phase() ConnPhase {
+	return e.phase
 }
 
 // NewConnectionTimeoutError creates a new ConnectionTimeoutError error
-func NewConnectionTimeoutError(peer string, timeout time.Duration) error {
+func NewConnectionTimeoutError(peer string, timeout time.Duration, phase ConnPhase) error {
 	return &ConnectionTimeoutError{
 		peer:    peer,
 		timeout: timeout,
+		phase:   phase,
 	}
 }
 
@@ -70,3 +78,27 @@ func NewConnectionAlreadyClosed(peer string) error {
 		peer: peer,
 	}
 }
+
+// ConnectionRenegotiateError is an error indicating that the negotiation of a peer Conn has been given up, so the
+// next attempt starts with a new offer
+type ConnectionRenegotiateError struct {
+	peer  string
+	phase ConnPhase
+}
+
+func (e *ConnectionRenegotiateError) Error() string {
+	return fmt.Sprintf("negotiation of the connection to peer %s has been restarted, last phase reached: %s", e.peer, e.phase)
+}
+
+// NewConnectionRenegotiateError creates a new ConnectionRenegotiateError error
+func NewConnectionRenegotiateError(peer string, phase ConnPhase) error {
+	return &ConnectionRenegotiateError{
+		peer:  peer,
+		phase: phase,
+	}
+}
+
+func isRenegotiateError(err error) bool {
+	var renegotiateErr *ConnectionRenegotiateError
+	return errors.As(err, &renegotiateErr)
+}
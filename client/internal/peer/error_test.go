@@ -17,8 +17,9 @@ func TestNewConnectionDisconnectedError(t *testing.T) {
 }
 
 func TestNewConnectionTimeoutErrorC(t *testing.T) {
-	err := NewConnectionTimeoutError("X", time.Second)
-	assert.Equal(t, &ConnectionTimeoutError{peer: "X", timeout: time.Second}, err)
+	err := NewConnectionTimeoutError("X", time.Second, ConnPhaseOfferSent)
+	assert.Equal(t, &ConnectionTimeoutError{peer: "X", timeout: time.Second, phase: ConnPhaseOfferSent}, err)
+	assert.Equal(t, "connection to peer X timed out after 1s, last phase reached: offer sent", err.Error())
 }
 
 func TestNewConnectionAlreadyClosed(t *testing.T) {
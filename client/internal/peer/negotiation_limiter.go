@@ -0,0 +1,73 @@
+package peer
+
+import "time"
+
+// DefaultMaxConcurrentNegotiations is the default number of peer connections negotiating ICE at the same time
+const DefaultMaxConcurrentNegotiations = 32
+
+// negotiationAnswerWait is how long a connection holds its negotiation slot waiting for the remote peer to reply to
+// the offer. The Signal round trip is much shorter, a peer not replying by then is likely offline.
+var negotiationAnswerWait = 5 * time.Second
+
+// NegotiationLimiter bounds how many connections negotiate ICE at the same time, so joining a large network
+// doesn't flood the Signal and TURN servers and the connections are established in waves.
+// A slot is only held for the negotiation, a connected peer doesn't occupy one and neither does a peer which doesn't
+// reply to the offer. The offers of the remote peers are answered without waiting for a slot.
+// A nil NegotiationLimiter doesn't limit anything.
+type NegotiationLimiter struct {
+	slots chan struct{}
+}
+
+// NewNegotiationLimiter returns a limiter allowing max simultaneous negotiations, nil if max isn't positive
+func NewNegotiationLimiter(max int) *NegotiationLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &NegotiationLimiter{slots: make(chan struct{}, max)}
+}
+
+// acquire waits for a free slot. It returns false without a slot if cancel is closed meanwhile,
+// so removing a peer never leaves its connection waiting for a slot forever
+func (l *NegotiationLimiter) acquire(cancel <-chan struct{}) bool {
+	acquired, _ := l.acquireOrOffer(cancel, nil)
+	return acquired
+}
+
+// acquireOrOffer waits for a free slot like acquire, unless the remote peer sends an offer meanwhile. The offer is
+// returned without a slot then, the remote peer is negotiating already and its attempt fails if it isn't answered.
+func (l *NegotiationLimiter) acquireOrOffer(cancel <-chan struct{}, offers <-chan OfferAnswer) (bool, *OfferAnswer) {
+	if l == nil {
+		return true, nil
+	}
+
+	select {
+	case <-cancel:
+		return false, nil
+	default:
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return true, nil
+	case offer := <-offers:
+		return false, &offer
+	case <-cancel:
+		return false, nil
+	}
+}
+
+// release frees a slot taken by a successful acquire
+func (l *NegotiationLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
+
+// InUse returns how many negotiations are running
+func (l *NegotiationLimiter) InUse() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.slots)
+}
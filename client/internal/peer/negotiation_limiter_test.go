@@ -0,0 +1,193 @@
+package peer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/internal/wgproxy"
+)
+
+func TestNegotiationLimiter(t *testing.T) {
+	limiter := NewNegotiationLimiter(2)
+	cancel := make(chan struct{})
+
+	require.True(t, limiter.acquire(cancel))
+	require.True(t, limiter.acquire(cancel))
+	assert.Equal(t, 2, limiter.InUse())
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- limiter.acquire(cancel)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquired a slot over the limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	limiter.release()
+	select {
+	case ok := <-acquired:
+		assert.True(t, ok, "expected the released slot to be taken")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the released slot")
+	}
+
+	go func() {
+		acquired <- limiter.acquire(cancel)
+	}()
+	close(cancel)
+	select {
+	case ok := <-acquired:
+		assert.False(t, ok, "a canceled wait shouldn't take a slot")
+	case <-time.After(time.Second):
+		t.Fatal("canceled wait didn't return")
+	}
+	assert.Equal(t, 2, limiter.InUse())
+	assert.False(t, limiter.acquire(cancel), "a canceled acquire shouldn't take a free slot")
+}
+
+func TestNegotiationLimiter_Unlimited(t *testing.T) {
+	limiter := NewNegotiationLimiter(0)
+	assert.Nil(t, limiter)
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, limiter.acquire(nil))
+	}
+	limiter.release()
+	assert.Equal(t, 0, limiter.InUse())
+}
+
+func TestConn_NegotiateQueuedClose(t *testing.T) {
+	wgProxyFactory := wgproxy.NewFactory(connConf.LocalWgPort)
+	defer func() {
+		_ = wgProxyFactory.Free()
+	}()
+
+	config := connConf
+	config.NegotiationLimiter = NewNegotiationLimiter(1)
+	require.True(t, config.NegotiationLimiter.acquire(nil), "the only slot should be free")
+
+	conn, err := NewConn(config, NewRecorder("https://mgm"), wgProxyFactory, nil, nil)
+	require.NoError(t, err)
+
+	opened := make(chan error)
+	go func() {
+		_, _, err := conn.negotiate()
+		opened <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return conn.Phase() == ConnPhaseQueued
+	}, time.Second, 10*time.Millisecond, "the connection should wait for a slot")
+
+	require.NoError(t, conn.Close())
+
+	select {
+	case err := <-opened:
+		var closedErr *ConnectionClosedError
+		assert.True(t, errors.As(err, &closedErr), "unexpected error %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("closing a queued connection didn't stop its negotiation")
+	}
+	assert.Equal(t, 1, config.NegotiationLimiter.InUse(), "the queued connection shouldn't take a slot")
+}
+
+func TestConn_NegotiateQueuedAnswersOffer(t *testing.T) {
+	wgProxyFactory := wgproxy.NewFactory(connConf.LocalWgPort)
+	defer func() {
+		_ = wgProxyFactory.Free()
+	}()
+
+	config := connConf
+	config.NegotiationLimiter = NewNegotiationLimiter(1)
+	require.True(t, config.NegotiationLimiter.acquire(nil), "the only slot should be free")
+
+	conn, err := NewConn(config, NewRecorder("https://mgm"), wgProxyFactory, nil, nil)
+	require.NoError(t, err)
+
+	answered := make(chan struct{}, 1)
+	conn.SetSignalAnswer(func(OfferAnswer) error {
+		answered <- struct{}{}
+		return nil
+	})
+	conn.SetSignalOffer(func(OfferAnswer) error {
+		t.Error("the queued connection should answer the remote offer rather than send its own")
+		return nil
+	})
+	conn.SetSignalCandidate(func(ice.Candidate) error {
+		return nil
+	})
+
+	opened := make(chan error, 1)
+	go func() {
+		_, _, err := conn.negotiate()
+		opened <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return conn.Phase() == ConnPhaseQueued
+	}, time.Second, 10*time.Millisecond, "the connection should wait for a slot")
+
+	require.Eventually(t, func() bool {
+		return conn.OnRemoteOffer(OfferAnswer{IceCredentials: IceCredentials{UFrag: "ufrag", Pwd: "pwd"}})
+	}, time.Second, 10*time.Millisecond, "the queued connection should accept the remote offer")
+
+	select {
+	case <-answered:
+	case <-time.After(time.Second):
+		t.Fatal("the queued connection didn't answer the remote offer")
+	}
+	assert.Equal(t, 1, config.NegotiationLimiter.InUse(), "answering the remote offer shouldn't take a slot")
+
+	require.NoError(t, conn.Close())
+	<-opened
+}
+
+func TestConn_NegotiateReleasesSlotWithoutAnswer(t *testing.T) {
+	answerWait := negotiationAnswerWait
+	negotiationAnswerWait = 100 * time.Millisecond
+	defer func() {
+		negotiationAnswerWait = answerWait
+	}()
+
+	wgProxyFactory := wgproxy.NewFactory(connConf.LocalWgPort)
+	defer func() {
+		_ = wgProxyFactory.Free()
+	}()
+
+	config := connConf
+	config.Timeout = 5 * time.Second
+	config.NegotiationLimiter = NewNegotiationLimiter(1)
+
+	conn, err := NewConn(config, NewRecorder("https://mgm"), wgProxyFactory, nil, nil)
+	require.NoError(t, err)
+	conn.SetSignalOffer(func(OfferAnswer) error {
+		return nil
+	})
+
+	opened := make(chan error, 1)
+	go func() {
+		_, _, err := conn.negotiate()
+		opened <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return conn.Phase() == ConnPhaseOfferSent && config.NegotiationLimiter.InUse() == 1
+	}, time.Second, 10*time.Millisecond, "the connection should hold the slot while waiting for the reply")
+
+	require.Eventually(t, func() bool {
+		return config.NegotiationLimiter.InUse() == 0
+	}, time.Second, 10*time.Millisecond, "the slot should be released when the remote peer doesn't reply")
+	assert.Equal(t, ConnPhaseOfferSent, conn.Phase(), "the connection should keep waiting for the reply")
+
+	require.NoError(t, conn.Close())
+	<-opened
+	assert.Equal(t, 0, config.NegotiationLimiter.InUse(), "the released slot shouldn't be released twice")
+}
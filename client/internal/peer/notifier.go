@@ -2,6 +2,9 @@ package peer
 
 import (
 	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -18,6 +21,8 @@ type notifier struct {
 	currentClientState bool
 	lastNotification   int
 	lastNumberOfPeers  int
+	// subscriptions receive the connection events, publishing to them never blocks
+	subscriptions map[*Subscription]struct{}
 }
 
 func newNotifier() *notifier {
@@ -143,3 +148,46 @@ func (n *notifier) localAddressChanged(fqdn, address string) {
 	}
 	n.listener.OnAddressChanged(fqdn, address)
 }
+
+func (n *notifier) subscribe() *Subscription {
+	n.listenersLock.Lock()
+	defer n.listenersLock.Unlock()
+
+	if n.subscriptions == nil {
+		n.subscriptions = make(map[*Subscription]struct{})
+	}
+
+	sub := &Subscription{
+		notifier: n,
+		events:   make(chan Event, subscriptionBufferSize),
+		dropped:  make(chan struct{}),
+	}
+	n.subscriptions[sub] = struct{}{}
+	return sub
+}
+
+func (n *notifier) unsubscribe(sub *Subscription) {
+	n.listenersLock.Lock()
+	defer n.listenersLock.Unlock()
+	delete(n.subscriptions, sub)
+}
+
+// publish sends the event to the subscriptions, a subscription that can't take the event is dropped
+func (n *notifier) publish(event Event) {
+	n.listenersLock.Lock()
+	defer n.listenersLock.Unlock()
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	for sub := range n.subscriptions {
+		select {
+		case sub.events <- event:
+		default:
+			log.Warnf("dropping the connection event subscription, the subscriber is too slow")
+			delete(n.subscriptions, sub)
+			close(sub.dropped)
+		}
+	}
+}
@@ -25,6 +25,35 @@ type State struct {
 	LastWireguardHandshake     time.Time
 	BytesTx                    int64
 	BytesRx                    int64
+	// BytesTxRate and BytesRxRate are the rough transfer rates in bytes per second,
+	// calculated from the WireGuard counters between two stats updates
+	BytesTxRate int64
+	BytesRxRate int64
+	// ConnPhase is the last phase the connection establishment reached and ConnPhaseUpdate is when it was reached
+	ConnPhase       ConnPhase
+	ConnPhaseUpdate time.Time
+	// RelayFallback indicates the direct connection attempt failed and the connection fell back to relay candidates only
+	RelayFallback bool
+	// RelayByPolicy indicates the management service configured the connection to go through a relay only
+	RelayByPolicy bool
+	// ConnAttempts are the connection establishment attempts to the peer since the client started
+	ConnAttempts ConnAttemptStats
+
+	// the WireGuard counters the transfer rates are calculated from
+	rateSampleTime time.Time
+	rateSampleTx   int64
+	rateSampleRx   int64
+}
+
+// Path returns the path the traffic to the peer takes, a stale connection keeps the path it was established with
+func (s State) Path() ConnPath {
+	if s.ConnStatus != StatusConnected && s.ConnStatus != StatusStale {
+		return ConnPathDisconnected
+	}
+	if s.Relayed {
+		return ConnPathRelayed
+	}
+	return ConnPathDirect
 }
 
 // LocalPeerState contains the latest state of the local peer
@@ -33,6 +62,11 @@ type LocalPeerState struct {
 	PubKey          string
 	KernelInterface bool
 	FQDN            string
+	// InterfaceRecoveries is the number of times the WireGuard interface was recreated after it was removed
+	// or lost its configuration while the client was running
+	InterfaceRecoveries int
+	// LastInterfaceRecovery is the time of the latest recovery of the WireGuard interface
+	LastInterfaceRecovery time.Time
 }
 
 // SignalState contains the latest state of a signal connection
@@ -49,6 +83,23 @@ type ManagementState struct {
 	Error     error
 }
 
+// DNSStats contains the query counters of the local DNS resolver
+type DNSStats struct {
+	// Queries is the number of queries handled by the resolver
+	Queries uint64
+	// LocalRecordHits is the number of queries answered from the local records of the NetBird zones without
+	// reaching an upstream, the resolver has no cache
+	LocalRecordHits uint64
+	// UpstreamFailures is the number of queries that none of the upstream nameservers answered
+	UpstreamFailures uint64
+	// UpstreamResponses is the number of queries answered by an upstream nameserver
+	UpstreamResponses uint64
+	// UpstreamLatency is the total time spent serving the queries answered by an upstream nameserver
+	UpstreamLatency time.Duration
+	// DomainQueries is the number of queries per domain name, collected only when query logging is enabled
+	DomainQueries map[string]uint64
+}
+
 // FullStatus contains the full state held by the Status instance
 type FullStatus struct {
 	Peers           []State
@@ -56,8 +107,14 @@ type FullStatus struct {
 	SignalState     SignalState
 	LocalPeerState  LocalPeerState
 	Relays          []relay.ProbeResult
+	DNSStats        DNSStats
+	// ConnAttempts are the connection establishment attempts to all the peers since the client started
+	ConnAttempts ConnAttemptStats
 }
 
+// minRateSampleInterval is the minimum interval between the samples the transfer rates are calculated from
+const minRateSampleInterval = time.Second
+
 // Status holds a state of peers, signal, management connections and relays
 type Status struct {
 	mux             sync.Mutex
@@ -73,6 +130,13 @@ type Status struct {
 	mgmAddress      string
 	signalAddress   string
 	notifier        *notifier
+	dnsStatsGetter  func() DNSStats
+	events          []StatusEvent
+	// the recoveries of the interface are counted across the engine restarts the recoveries cause
+	interfaceRecoveries   int
+	lastInterfaceRecovery time.Time
+	// connAttempts are the connection attempts by peer key, they are kept across the reconnects until the peer is removed
+	connAttempts map[string]*connAttempts
 
 	// To reduce the number of notification invocation this bool will be true when need to call the notification
 	// Some Peer actions mostly used by in a batch when the network map has been synchronized. In these type of events
@@ -143,6 +207,7 @@ func (d *Status) RemovePeer(peerPubKey string) error {
 	}
 
 	delete(d.peers, peerPubKey)
+	delete(d.connAttempts, peerPubKey)
 	d.peerListChangedForNotification = true
 	return nil
 }
@@ -164,6 +229,7 @@ func (d *Status) UpdatePeerState(receivedState State) error {
 	skipNotification := shouldSkipNotify(receivedState, peerState)
 
 	if receivedState.ConnStatus != peerState.ConnStatus {
+		d.recordEvent("peer %s: %s", peerState.name(), receivedState.ConnStatus)
 		peerState.ConnStatus = receivedState.ConnStatus
 		peerState.ConnStatusUpdate = receivedState.ConnStatusUpdate
 		peerState.Direct = receivedState.Direct
@@ -172,6 +238,7 @@ func (d *Status) UpdatePeerState(receivedState State) error {
 		peerState.RemoteIceCandidateType = receivedState.RemoteIceCandidateType
 		peerState.LocalIceCandidateEndpoint = receivedState.LocalIceCandidateEndpoint
 		peerState.RemoteIceCandidateEndpoint = receivedState.RemoteIceCandidateEndpoint
+		d.notifier.publish(Event{Type: EventPeer, Time: peerState.ConnStatusUpdate, Peer: peerState})
 	}
 
 	d.peers[receivedState.PubKey] = peerState
@@ -203,12 +270,128 @@ func (d *Status) UpdateWireguardPeerState(pubKey string, wgStats iface.WGStats)
 	peerState.LastWireguardHandshake = wgStats.LastHandshake
 	peerState.BytesRx = wgStats.RxBytes
 	peerState.BytesTx = wgStats.TxBytes
+	updateTransferRates(&peerState, time.Now())
+
+	d.peers[pubKey] = peerState
+
+	return nil
+}
+
+// UpdatePeerStaleness marks a connected peer as stale or marks a stale peer as connected again.
+// Peers in other states are left untouched. Returns true if the state has been changed.
+func (d *Status) UpdatePeerStaleness(pubKey string, stale bool) (bool, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	peerState, ok := d.peers[pubKey]
+	if !ok {
+		return false, errors.New("peer doesn't exist")
+	}
+
+	switch {
+	case stale && peerState.ConnStatus == StatusConnected:
+		peerState.ConnStatus = StatusStale
+	case !stale && peerState.ConnStatus == StatusStale:
+		peerState.ConnStatus = StatusConnected
+	default:
+		return false, nil
+	}
+	peerState.ConnStatusUpdate = time.Now()
+	d.peers[pubKey] = peerState
+
+	ch, found := d.changeNotify[pubKey]
+	if found && ch != nil {
+		close(ch)
+		d.changeNotify[pubKey] = nil
+	}
 
+	d.notifyPeerListChanged()
+	return true, nil
+}
+
+// UpdatePeerConnPhase updates the connection establishment phase of the peer
+func (d *Status) UpdatePeerConnPhase(pubKey string, phase ConnPhase) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	peerState, ok := d.peers[pubKey]
+	if !ok {
+		return errors.New("peer doesn't exist")
+	}
+
+	peerState.ConnPhase = phase
+	peerState.ConnPhaseUpdate = time.Now()
+	d.peers[pubKey] = peerState
+
+	return nil
+}
+
+// UpdatePeerRelayFallback records whether the connection establishment of the peer fell back to relay candidates only
+func (d *Status) UpdatePeerRelayFallback(pubKey string, fallback bool) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	peerState, ok := d.peers[pubKey]
+	if !ok {
+		return errors.New("peer doesn't exist")
+	}
+
+	peerState.RelayFallback = fallback
 	d.peers[pubKey] = peerState
 
 	return nil
 }
 
+// RecordConnAttempt records the outcome of a connection establishment attempt to the peer and the time it took
+func (d *Status) RecordConnAttempt(pubKey string, outcome ConnAttemptOutcome, duration time.Duration) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if d.connAttempts == nil {
+		d.connAttempts = make(map[string]*connAttempts)
+	}
+	attempts, ok := d.connAttempts[pubKey]
+	if !ok {
+		attempts = &connAttempts{}
+		d.connAttempts[pubKey] = attempts
+	}
+	attempts.record(outcome, duration)
+}
+
+// updateTransferRates calculates the transfer rates of the peer from the counters of the previous sample.
+// Samples taken less than minRateSampleInterval apart are skipped to keep the rates meaningful
+// when the stats are updated by both the periodic collector and a status request.
+func updateTransferRates(peerState *State, now time.Time) {
+	if peerState.rateSampleTime.IsZero() {
+		peerState.rateSampleTime = now
+		peerState.rateSampleTx = peerState.BytesTx
+		peerState.rateSampleRx = peerState.BytesRx
+		return
+	}
+
+	elapsed := now.Sub(peerState.rateSampleTime)
+	if elapsed < minRateSampleInterval {
+		return
+	}
+
+	peerState.BytesTxRate = transferRate(peerState.rateSampleTx, peerState.BytesTx, elapsed)
+	peerState.BytesRxRate = transferRate(peerState.rateSampleRx, peerState.BytesRx, elapsed)
+	peerState.rateSampleTime = now
+	peerState.rateSampleTx = peerState.BytesTx
+	peerState.rateSampleRx = peerState.BytesRx
+}
+
+// transferRate returns the bytes per second transferred between two counter values. WireGuard resets the counters
+// when the peer is removed from the interface, e.g. on reconnection or tunnel restart, a lower counter value
+// means the transfer started over from zero.
+func transferRate(previous, current int64, elapsed time.Duration) int64 {
+	transferred := current - previous
+	if current < previous {
+		transferred = current
+	}
+	return int64(float64(transferred) / elapsed.Seconds())
+}
+
 func shouldSkipNotify(received, curr State) bool {
 	switch {
 	case received.ConnStatus == StatusConnecting:
@@ -238,6 +421,22 @@ func (d *Status) UpdatePeerFQDN(peerPubKey, fqdn string) error {
 	return nil
 }
 
+// UpdatePeerRelayByPolicy updates the peer's relay by policy flag
+func (d *Status) UpdatePeerRelayByPolicy(peerPubKey string, relayByPolicy bool) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	peerState, ok := d.peers[peerPubKey]
+	if !ok {
+		return errors.New("peer doesn't exist")
+	}
+
+	peerState.RelayByPolicy = relayByPolicy
+	d.peers[peerPubKey] = peerState
+
+	return nil
+}
+
 // FinishPeerListModifications this event invoke the notification
 func (d *Status) FinishPeerListModifications() {
 	d.mux.Lock()
@@ -269,8 +468,22 @@ func (d *Status) UpdateLocalPeerState(localPeerState LocalPeerState) {
 	d.mux.Lock()
 	defer d.mux.Unlock()
 
+	addressChanged := d.localPeer.FQDN != localPeerState.FQDN || d.localPeer.IP != localPeerState.IP
 	d.localPeer = localPeerState
 	d.notifyAddressChanged()
+	if addressChanged {
+		d.publishAddressEvent()
+	}
+}
+
+// MarkInterfaceRecovery records a recovery of the WireGuard interface, it is reported in the local peer state
+func (d *Status) MarkInterfaceRecovery() {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.interfaceRecoveries++
+	d.lastInterfaceRecovery = time.Now()
+	d.recordEvent("WireGuard interface recovery")
 }
 
 // CleanLocalPeerState cleans local peer status
@@ -278,8 +491,12 @@ func (d *Status) CleanLocalPeerState() {
 	d.mux.Lock()
 	defer d.mux.Unlock()
 
+	addressChanged := d.localPeer.FQDN != "" || d.localPeer.IP != ""
 	d.localPeer = LocalPeerState{}
 	d.notifyAddressChanged()
+	if addressChanged {
+		d.publishAddressEvent()
+	}
 }
 
 // MarkManagementDisconnected sets ManagementState to disconnected
@@ -288,6 +505,9 @@ func (d *Status) MarkManagementDisconnected(err error) {
 	defer d.mux.Unlock()
 	defer d.onConnectionChanged()
 
+	if d.recordServerEvent("management", d.managementState, false, d.managementError, err) {
+		d.publishServerEvent(EventManagement, false, err)
+	}
 	d.managementState = false
 	d.managementError = err
 }
@@ -298,6 +518,9 @@ func (d *Status) MarkManagementConnected() {
 	defer d.mux.Unlock()
 	defer d.onConnectionChanged()
 
+	if d.recordServerEvent("management", d.managementState, true, d.managementError, nil) {
+		d.publishServerEvent(EventManagement, true, nil)
+	}
 	d.managementState = true
 	d.managementError = nil
 }
@@ -322,6 +545,9 @@ func (d *Status) MarkSignalDisconnected(err error) {
 	defer d.mux.Unlock()
 	defer d.onConnectionChanged()
 
+	if d.recordServerEvent("signal", d.signalState, false, d.signalError, err) {
+		d.publishServerEvent(EventSignal, false, err)
+	}
 	d.signalState = false
 	d.signalError = err
 }
@@ -332,6 +558,9 @@ func (d *Status) MarkSignalConnected() {
 	defer d.mux.Unlock()
 	defer d.onConnectionChanged()
 
+	if d.recordServerEvent("signal", d.signalState, true, d.signalError, nil) {
+		d.publishServerEvent(EventSignal, true, nil)
+	}
 	d.signalState = true
 	d.signalError = nil
 }
@@ -342,6 +571,13 @@ func (d *Status) UpdateRelayStates(relayResults []relay.ProbeResult) {
 	d.relayStates = relayResults
 }
 
+// SetDNSStatsGetter sets the source of the DNS resolver stats reported in the full status
+func (d *Status) SetDNSStatsGetter(getter func() DNSStats) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.dnsStatsGetter = getter
+}
+
 func (d *Status) GetManagementState() ManagementState {
 	return ManagementState{
 		d.mgmAddress,
@@ -373,10 +609,20 @@ func (d *Status) GetFullStatus() FullStatus {
 		LocalPeerState:  d.localPeer,
 		Relays:          d.GetRelayStates(),
 	}
+	fullStatus.LocalPeerState.InterfaceRecoveries = d.interfaceRecoveries
+	fullStatus.LocalPeerState.LastInterfaceRecovery = d.lastInterfaceRecovery
+
+	if d.dnsStatsGetter != nil {
+		fullStatus.DNSStats = d.dnsStatsGetter()
+	}
 
 	for _, status := range d.peers {
+		if attempts, ok := d.connAttempts[status.PubKey]; ok {
+			status.ConnAttempts = attempts.snapshot()
+		}
 		fullStatus.Peers = append(fullStatus.Peers, status)
 	}
+	fullStatus.ConnAttempts = aggregateConnAttempts(d.connAttempts)
 
 	fullStatus.Peers = append(fullStatus.Peers, d.offlinePeers...)
 
@@ -385,11 +631,17 @@ func (d *Status) GetFullStatus() FullStatus {
 
 // ClientStart will notify all listeners about the new service state
 func (d *Status) ClientStart() {
+	d.mux.Lock()
+	d.recordEvent("client started")
+	d.mux.Unlock()
 	d.notifier.clientStart()
 }
 
 // ClientStop will notify all listeners about the new service state
 func (d *Status) ClientStop() {
+	d.mux.Lock()
+	d.recordEvent("client stopped")
+	d.mux.Unlock()
 	d.notifier.clientStop()
 }
 
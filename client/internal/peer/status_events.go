@@ -0,0 +1,57 @@
+package peer
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxStatusEvents is the number of the recent status events kept for the diagnostics
+const maxStatusEvents = 200
+
+// StatusEvent is a change of the connections of the client recorded for the diagnostics
+type StatusEvent struct {
+	Time    time.Time
+	Message string
+}
+
+// recordEvent records a status event, dropping the oldest one when the limit is reached. The caller holds the lock.
+func (d *Status) recordEvent(format string, args ...any) {
+	d.events = append(d.events, StatusEvent{Time: time.Now(), Message: fmt.Sprintf(format, args...)})
+	if len(d.events) > maxStatusEvents {
+		d.events = d.events[len(d.events)-maxStatusEvents:]
+	}
+}
+
+// GetEvents returns the recent status events, the oldest first
+func (d *Status) GetEvents() []StatusEvent {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	events := make([]StatusEvent, len(d.events))
+	copy(events, d.events)
+	return events
+}
+
+// name returns the name of the peer in the status events
+func (s State) name() string {
+	if s.FQDN == "" {
+		return s.PubKey
+	}
+	return s.FQDN + " (" + s.PubKey + ")"
+}
+
+// recordServerEvent records the change of the connection to the management or the signal server and returns whether
+// the connection state changed
+func (d *Status) recordServerEvent(server string, wasConnected, connected bool, prevErr, err error) bool {
+	switch {
+	case connected && !wasConnected:
+		d.recordEvent("%s connected", server)
+	case !connected && err != nil && (wasConnected || prevErr == nil || prevErr.Error() != err.Error()):
+		d.recordEvent("%s disconnected: %v", server, err)
+	case !connected && wasConnected:
+		d.recordEvent("%s disconnected", server)
+	default:
+		return false
+	}
+	return true
+}
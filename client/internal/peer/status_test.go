@@ -3,8 +3,11 @@ package peer
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/iface"
 )
 
 func TestAddPeer(t *testing.T) {
@@ -56,6 +59,21 @@ func TestUpdatePeerState(t *testing.T) {
 	assert.Equal(t, ip, state.IP, "ip should be equal")
 }
 
+func TestState_Path(t *testing.T) {
+	assert.Equal(t, ConnPathDirect, State{ConnStatus: StatusConnected}.Path())
+	assert.Equal(t, ConnPathRelayed, State{ConnStatus: StatusConnected, Relayed: true}.Path())
+	assert.Equal(t, ConnPathRelayed, State{ConnStatus: StatusStale, Relayed: true}.Path(), "stale connection should keep its path")
+	assert.Equal(t, ConnPathDisconnected, State{ConnStatus: StatusConnecting, Relayed: true}.Path())
+	assert.Equal(t, ConnPathDisconnected, State{ConnStatus: StatusDisconnected}.Path())
+
+	path, err := ParseConnPath("relayed")
+	assert.NoError(t, err, "shouldn't return error")
+	assert.Equal(t, ConnPathRelayed, path)
+
+	_, err = ParseConnPath("p2p")
+	assert.Error(t, err, "should return error on an unknown path")
+}
+
 func TestStatus_UpdatePeerFQDN(t *testing.T) {
 	key := "abc"
 	fqdn := "peer-a.netbird.local"
@@ -147,6 +165,21 @@ func TestCleanLocalPeerState(t *testing.T) {
 	assert.Equal(t, emptyLocalPeerState, status.localPeer, "local peer status should be empty")
 }
 
+func TestMarkInterfaceRecovery(t *testing.T) {
+	status := NewRecorder("https://mgm")
+	status.UpdateLocalPeerState(LocalPeerState{IP: "10.10.10.10", PubKey: "abc"})
+
+	status.MarkInterfaceRecovery()
+	// the engine restarted by the recovery cleans and updates the local peer state again
+	status.CleanLocalPeerState()
+	status.UpdateLocalPeerState(LocalPeerState{IP: "10.10.10.10", PubKey: "abc"})
+	status.MarkInterfaceRecovery()
+
+	localPeer := status.GetFullStatus().LocalPeerState
+	assert.Equal(t, 2, localPeer.InterfaceRecoveries, "recoveries should be counted across the engine restarts")
+	assert.WithinDuration(t, time.Now(), localPeer.LastInterfaceRecovery, time.Minute)
+}
+
 func TestUpdateSignalState(t *testing.T) {
 	url := "https://signal"
 	var tests = []struct {
@@ -236,3 +269,222 @@ func TestGetFullStatus(t *testing.T) {
 	assert.Equal(t, signalState, fullStatus.SignalState, "signal status should be equal")
 	assert.ElementsMatch(t, []State{peerState1, peerState2}, fullStatus.Peers, "peers states should match")
 }
+
+func TestUpdateWireguardPeerState(t *testing.T) {
+	key := "abc"
+	status := NewRecorder("https://mgm")
+	err := status.AddPeer(key, "abc.netbird")
+	assert.NoError(t, err, "shouldn't return error")
+
+	err = status.UpdateWireguardPeerState(key, iface.WGStats{TxBytes: 100, RxBytes: 200})
+	assert.NoError(t, err, "shouldn't return error")
+
+	state, err := status.GetPeer(key)
+	assert.NoError(t, err, "shouldn't return error on getting peer")
+	assert.Equal(t, int64(100), state.BytesTx, "tx bytes should be equal")
+	assert.Equal(t, int64(200), state.BytesRx, "rx bytes should be equal")
+	assert.Zero(t, state.BytesTxRate, "tx rate needs two samples")
+
+	err = status.UpdateWireguardPeerState("non_existing_key", iface.WGStats{})
+	assert.Error(t, err, "should return error when peer doesn't exist")
+}
+
+func TestUpdatePeerStaleness(t *testing.T) {
+	key := "abc"
+	status := NewRecorder("https://mgm")
+	err := status.AddPeer(key, "abc.netbird")
+	assert.NoError(t, err, "shouldn't return error")
+
+	changed, err := status.UpdatePeerStaleness(key, true)
+	assert.NoError(t, err, "shouldn't return error")
+	assert.False(t, changed, "disconnected peer shouldn't become stale")
+
+	err = status.UpdatePeerState(State{PubKey: key, ConnStatus: StatusConnected})
+	assert.NoError(t, err, "shouldn't return error")
+
+	notifier := status.GetPeerStateChangeNotifier(key)
+	changed, err = status.UpdatePeerStaleness(key, true)
+	assert.NoError(t, err, "shouldn't return error")
+	assert.True(t, changed, "connected peer should become stale")
+
+	select {
+	case <-notifier:
+	default:
+		t.Errorf("channel wasn't closed after the peer became stale")
+	}
+
+	state, err := status.GetPeer(key)
+	assert.NoError(t, err, "shouldn't return error on getting peer")
+	assert.Equal(t, StatusStale, state.ConnStatus, "peer should be stale")
+
+	changed, err = status.UpdatePeerStaleness(key, false)
+	assert.NoError(t, err, "shouldn't return error")
+	assert.True(t, changed, "stale peer should become connected")
+
+	state, err = status.GetPeer(key)
+	assert.NoError(t, err, "shouldn't return error on getting peer")
+	assert.Equal(t, StatusConnected, state.ConnStatus, "peer should be connected")
+
+	_, err = status.UpdatePeerStaleness("non_existing_key", true)
+	assert.Error(t, err, "should return error when peer doesn't exist")
+}
+
+func TestUpdatePeerConnPhase(t *testing.T) {
+	key := "abc"
+	status := NewRecorder("https://mgm")
+	err := status.AddPeer(key, "abc.netbird")
+	assert.NoError(t, err, "shouldn't return error")
+
+	err = status.UpdatePeerConnPhase(key, ConnPhaseGatheringCandidates)
+	assert.NoError(t, err, "shouldn't return error")
+
+	state, err := status.GetPeer(key)
+	assert.NoError(t, err, "shouldn't return error on getting peer")
+	assert.Equal(t, ConnPhaseGatheringCandidates, state.ConnPhase, "phase should be equal")
+	assert.False(t, state.ConnPhaseUpdate.IsZero(), "phase update time should be set")
+
+	err = status.UpdatePeerConnPhase("non_existing_key", ConnPhaseDialing)
+	assert.Error(t, err, "should return error when peer doesn't exist")
+}
+
+func TestUpdatePeerRelayFallback(t *testing.T) {
+	key := "abc"
+	status := NewRecorder("https://mgm")
+	err := status.AddPeer(key, "abc.netbird")
+	assert.NoError(t, err, "shouldn't return error")
+
+	err = status.UpdatePeerRelayFallback(key, true)
+	assert.NoError(t, err, "shouldn't return error")
+
+	state, err := status.GetPeer(key)
+	assert.NoError(t, err, "shouldn't return error on getting peer")
+	assert.True(t, state.RelayFallback, "relay fallback should be recorded")
+
+	err = status.UpdatePeerRelayFallback("non_existing_key", true)
+	assert.Error(t, err, "should return error when peer doesn't exist")
+}
+
+func TestUpdatePeerRelayByPolicy(t *testing.T) {
+	key := "abc"
+	status := NewRecorder("https://mgm")
+	err := status.AddPeer(key, "abc.netbird")
+	assert.NoError(t, err, "shouldn't return error")
+
+	err = status.UpdatePeerRelayByPolicy(key, true)
+	assert.NoError(t, err, "shouldn't return error")
+
+	state, err := status.GetPeer(key)
+	assert.NoError(t, err, "shouldn't return error on getting peer")
+	assert.True(t, state.RelayByPolicy, "relay by policy should be recorded")
+
+	err = status.UpdatePeerRelayByPolicy("non_existing_key", true)
+	assert.Error(t, err, "should return error when peer doesn't exist")
+}
+
+func TestUpdateTransferRates(t *testing.T) {
+	now := time.Now()
+	state := State{BytesTx: 1000, BytesRx: 5000}
+
+	updateTransferRates(&state, now)
+	assert.Zero(t, state.BytesTxRate, "first sample shouldn't set a rate")
+
+	state.BytesTx, state.BytesRx = 3000, 25000
+	updateTransferRates(&state, now.Add(10*time.Second))
+	assert.Equal(t, int64(200), state.BytesTxRate, "unexpected tx rate")
+	assert.Equal(t, int64(2000), state.BytesRxRate, "unexpected rx rate")
+
+	// a sample too close to the previous one keeps the rates
+	state.BytesTx, state.BytesRx = 3500, 25000
+	updateTransferRates(&state, now.Add(10*time.Second+100*time.Millisecond))
+	assert.Equal(t, int64(200), state.BytesTxRate, "rate shouldn't change for a close sample")
+
+	// the counters were reset by WireGuard, e.g. after a tunnel restart
+	state.BytesTx, state.BytesRx = 500, 0
+	updateTransferRates(&state, now.Add(20*time.Second))
+	assert.Equal(t, int64(50), state.BytesTxRate, "rate should be calculated from zero after a reset")
+	assert.Equal(t, int64(0), state.BytesRxRate, "unexpected rx rate after a reset")
+}
+
+func TestStatus_GetEvents(t *testing.T) {
+	status := NewRecorder("https://mgm")
+	status.peers["abc"] = State{PubKey: "abc", FQDN: "peer-a.netbird.cloud", ConnStatus: StatusDisconnected}
+
+	status.MarkManagementConnected()
+	status.MarkManagementConnected()
+	status.MarkManagementDisconnected(errors.New("connection reset"))
+	status.MarkManagementDisconnected(errors.New("connection reset"))
+	err := status.UpdatePeerState(State{PubKey: "abc", ConnStatus: StatusConnected})
+	assert.NoError(t, err)
+
+	var messages []string
+	for _, event := range status.GetEvents() {
+		messages = append(messages, event.Message)
+	}
+	assert.Equal(t, []string{
+		"management connected",
+		"management disconnected: connection reset",
+		"peer peer-a.netbird.cloud (abc): Connected",
+	}, messages, "repeated states should be recorded once")
+
+	for i := 0; i < maxStatusEvents+10; i++ {
+		status.ClientStart()
+	}
+	assert.Len(t, status.GetEvents(), maxStatusEvents, "the oldest events should be dropped")
+}
+
+func TestRecordConnAttempt(t *testing.T) {
+	status := NewRecorder("https://mgm")
+	assert.NoError(t, status.AddPeer("abc", "peer-a.netbird.cloud"))
+	assert.NoError(t, status.AddPeer("def", "peer-b.netbird.cloud"))
+
+	status.RecordConnAttempt("abc", ConnAttemptTimedOut, 10*time.Second)
+	status.RecordConnAttempt("abc", ConnAttemptSucceeded, time.Second)
+	status.RecordConnAttempt("abc", ConnAttemptRelayFallback, 3*time.Second)
+	status.RecordConnAttempt("def", ConnAttemptFailed, time.Second)
+
+	// the counters survive the reconnects, the connection of the peer keeps recording its attempts
+	status.RecordConnAttempt("abc", ConnAttemptSucceeded, 2*time.Second)
+
+	fullStatus := status.GetFullStatus()
+	peerStats := make(map[string]ConnAttemptStats)
+	for _, state := range fullStatus.Peers {
+		peerStats[state.PubKey] = state.ConnAttempts
+	}
+
+	assert.Equal(t, ConnAttemptStats{
+		Attempts:            4,
+		Succeeded:           2,
+		RelayFallbacks:      1,
+		TimedOut:            1,
+		MedianEstablishment: 2 * time.Second,
+	}, peerStats["abc"], "the timed out attempt shouldn't be in the median")
+	assert.Equal(t, 0.75, peerStats["abc"].SuccessRate())
+	assert.Equal(t, ConnAttemptStats{Attempts: 1, Failed: 1}, peerStats["def"])
+
+	assert.Equal(t, ConnAttemptStats{
+		Attempts:            5,
+		Succeeded:           2,
+		RelayFallbacks:      1,
+		TimedOut:            1,
+		Failed:              1,
+		MedianEstablishment: 2 * time.Second,
+	}, fullStatus.ConnAttempts)
+
+	assert.NoError(t, status.RemovePeer("abc"))
+	assert.NotContains(t, status.connAttempts, "abc", "the counters of a removed peer should be dropped")
+	assert.Equal(t, ConnAttemptStats{Attempts: 1, Failed: 1}, status.GetFullStatus().ConnAttempts)
+}
+
+func TestConnAttempts_MedianOfLatestSamples(t *testing.T) {
+	attempts := &connAttempts{}
+	for i := 0; i < connAttemptSamples; i++ {
+		attempts.record(ConnAttemptSucceeded, time.Minute)
+	}
+	for i := 0; i < connAttemptSamples/2+1; i++ {
+		attempts.record(ConnAttemptSucceeded, time.Second)
+	}
+
+	stats := attempts.snapshot()
+	assert.Equal(t, uint64(connAttemptSamples*3/2+1), stats.Attempts)
+	assert.Equal(t, time.Second, stats.MedianEstablishment, "the oldest samples should be overwritten")
+}
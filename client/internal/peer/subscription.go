@@ -0,0 +1,84 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// subscriptionBufferSize is the number of events a subscriber can fall behind before its subscription is dropped
+const subscriptionBufferSize = 100
+
+// ErrSubscriptionDropped is returned by Subscription.Next when the subscriber didn't keep up with the events.
+// The subscriber should subscribe again and read the full status to catch up.
+var ErrSubscriptionDropped = errors.New("subscription dropped because the subscriber is too slow")
+
+// EventType is the kind of change of an Event
+type EventType string
+
+const (
+	// EventPeer is a change of the connection status of a peer
+	EventPeer EventType = "peer"
+	// EventManagement is a change of the connection to the management server
+	EventManagement EventType = "management"
+	// EventSignal is a change of the connection to the signal server
+	EventSignal EventType = "signal"
+	// EventAddress is a change of the local address of the client
+	EventAddress EventType = "address"
+)
+
+// Event is a change of the connections of the client published to the subscriptions of the status recorder
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Peer is the state of the peer of an EventPeer event
+	Peer State
+	// Connected and Error are the state of the connection of an EventManagement or an EventSignal event
+	Connected bool
+	Error     error
+	// FQDN and IP are the local address of an EventAddress event
+	FQDN string
+	IP   string
+}
+
+// Subscription receives the connection events of the status recorder as they happen
+type Subscription struct {
+	notifier *notifier
+	events   chan Event
+	// dropped is closed when the subscription is removed because the subscriber is too slow
+	dropped chan struct{}
+}
+
+// Next blocks until the next event is available and returns it.
+// It returns ErrSubscriptionDropped when the subscriber is too slow and the context error when the context is done.
+func (s *Subscription) Next(ctx context.Context) (Event, error) {
+	select {
+	case <-ctx.Done():
+		return Event{}, ctx.Err()
+	case event := <-s.events:
+		return event, nil
+	case <-s.dropped:
+		return Event{}, ErrSubscriptionDropped
+	}
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.notifier.unsubscribe(s)
+}
+
+// Subscribe subscribes to the connection events of the client. The subscription should be closed by the caller.
+// The current state isn't replayed, the subscriber should read the full status after subscribing.
+func (d *Status) Subscribe() *Subscription {
+	return d.notifier.subscribe()
+}
+
+// publishServerEvent publishes the change of the connection to the management or the signal server
+func (d *Status) publishServerEvent(eventType EventType, connected bool, err error) {
+	d.notifier.publish(Event{Type: eventType, Connected: connected, Error: err})
+}
+
+// publishAddressEvent publishes the local address of the client
+func (d *Status) publishAddressEvent() {
+	d.notifier.publish(Event{Type: EventAddress, FQDN: d.localPeer.FQDN, IP: d.localPeer.IP})
+}
@@ -0,0 +1,98 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus_Subscribe(t *testing.T) {
+	status := NewRecorder("https://mgm")
+	status.peers["abc"] = State{PubKey: "abc", IP: "100.64.0.2", FQDN: "peer-a.netbird.cloud", ConnStatus: StatusDisconnected}
+
+	first := status.Subscribe()
+	defer first.Close()
+	second := status.Subscribe()
+	defer second.Close()
+
+	status.MarkManagementConnected()
+	status.MarkManagementConnected()
+	status.MarkSignalDisconnected(errors.New("connection reset"))
+	require.NoError(t, status.UpdatePeerState(State{PubKey: "abc", ConnStatus: StatusConnected, Direct: true}))
+	require.NoError(t, status.UpdatePeerState(State{PubKey: "abc", ConnStatus: StatusConnected, Direct: true}))
+	status.UpdateLocalPeerState(LocalPeerState{IP: "100.64.0.1/16", FQDN: "local.netbird.cloud"})
+	status.UpdateLocalPeerState(LocalPeerState{IP: "100.64.0.1/16", FQDN: "local.netbird.cloud", KernelInterface: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for _, sub := range []*Subscription{first, second} {
+		event, err := sub.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, EventManagement, event.Type)
+		assert.True(t, event.Connected)
+		assert.False(t, event.Time.IsZero(), "the event time should be set")
+
+		event, err = sub.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, EventSignal, event.Type)
+		assert.False(t, event.Connected)
+		assert.EqualError(t, event.Error, "connection reset")
+
+		event, err = sub.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, EventPeer, event.Type)
+		assert.Equal(t, "peer-a.netbird.cloud", event.Peer.FQDN)
+		assert.Equal(t, "100.64.0.2", event.Peer.IP)
+		assert.Equal(t, StatusConnected, event.Peer.ConnStatus)
+
+		event, err = sub.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, EventAddress, event.Type)
+		assert.Equal(t, "local.netbird.cloud", event.FQDN)
+		assert.Equal(t, "100.64.0.1/16", event.IP)
+	}
+
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer shortCancel()
+	_, err := first.Next(shortCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the unchanged states shouldn't be published")
+
+	second.Close()
+	status.MarkManagementDisconnected(nil)
+	event, err := first.Next(ctx)
+	require.NoError(t, err, "the other subscriptions should keep receiving the events")
+	assert.Equal(t, EventManagement, event.Type)
+}
+
+func TestStatus_SubscribeSlowSubscriber(t *testing.T) {
+	status := NewRecorder("https://mgm")
+
+	slow := status.Subscribe()
+	defer slow.Close()
+	fast := status.Subscribe()
+	defer fast.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < subscriptionBufferSize+1; i++ {
+		status.MarkSignalConnected()
+		status.MarkSignalDisconnected(nil)
+
+		for j := 0; j < 2; j++ {
+			_, err := fast.Next(ctx)
+			require.NoError(t, err, "the fast subscriber shouldn't be blocked by the slow one")
+		}
+	}
+
+	var err error
+	for i := 0; i <= subscriptionBufferSize && err == nil; i++ {
+		_, err = slow.Next(ctx)
+	}
+	assert.ErrorIs(t, err, ErrSubscriptionDropped, "the slow subscriber should be dropped")
+}
@@ -0,0 +1,67 @@
+package peer
+
+import (
+	"sort"
+
+	"github.com/pion/stun/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// TURNServer is a TURN URL with the preference the management service assigned to it
+type TURNServer struct {
+	URL *stun.URI
+	// Priority of the server, the servers of the lowest priority are tried first
+	Priority int32
+	// Weight of the server within its priority, the heavier servers are tried before the lighter ones, which are their
+	// fallback. The servers of the same priority and weight are tried together.
+	Weight int32
+}
+
+// GroupTURNsByPreference orders the TURN servers by priority and weight and groups them in tiers of the same priority
+// and weight, the most preferred tier first
+func GroupTURNsByPreference(servers []TURNServer) [][]*stun.URI {
+	ordered := append([]TURNServer{}, servers...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority < ordered[j].Priority
+		}
+		return ordered[i].Weight > ordered[j].Weight
+	})
+
+	var tiers [][]*stun.URI
+	for i, server := range ordered {
+		if i == 0 || server.Priority != ordered[i-1].Priority || server.Weight != ordered[i-1].Weight {
+			tiers = append(tiers, nil)
+		}
+		tiers[len(tiers)-1] = append(tiers[len(tiers)-1], server.URL)
+	}
+	return tiers
+}
+
+// iceURLs returns the STUN and TURN URLs given to the ICE agent. When the TURN servers have several tiers, only
+// the TURN URLs of the current tier are given, so ICE doesn't relay through a less preferred server while a preferred
+// one works.
+func (conn *Conn) iceURLs() []*stun.URI {
+	if len(conn.config.TURNTiers) < 2 {
+		return conn.config.StunTurn
+	}
+
+	var urls []*stun.URI
+	for _, url := range conn.config.StunTurn {
+		if url.Scheme == stun.SchemeTypeSTUN || url.Scheme == stun.SchemeTypeSTUNS {
+			urls = append(urls, url)
+		}
+	}
+	return append(urls, conn.config.TURNTiers[conn.turnTier%len(conn.config.TURNTiers)]...)
+}
+
+// nextTURNTier moves to the TURN servers of the next tier after the connectivity checks failed, going back to the
+// most preferred ones after the last tier
+func (conn *Conn) nextTURNTier() {
+	if len(conn.config.TURNTiers) < 2 {
+		return
+	}
+	conn.turnTier = (conn.turnTier + 1) % len(conn.config.TURNTiers)
+	log.Infof("connectivity checks with peer %s failed, trying the TURN servers of tier %d next",
+		conn.config.Key, conn.turnTier)
+}
@@ -0,0 +1,66 @@
+package peer
+
+import (
+	"testing"
+
+	"github.com/pion/stun/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseURI(t *testing.T, raw string) *stun.URI {
+	t.Helper()
+	url, err := stun.ParseURI(raw)
+	require.NoError(t, err)
+	return url
+}
+
+func TestGroupTURNsByPreference(t *testing.T) {
+	primary := mustParseURI(t, "turn:eu.turn.netbird.io:3478")
+	primaryLight := mustParseURI(t, "turn:eu2.turn.netbird.io:3478")
+	secondary := mustParseURI(t, "turn:us.turn.netbird.io:3478")
+
+	primaryPeer := mustParseURI(t, "turn:eu3.turn.netbird.io:3478")
+
+	tiers := GroupTURNsByPreference([]TURNServer{
+		{URL: secondary, Priority: 10},
+		{URL: primaryLight, Priority: 0, Weight: 1},
+		{URL: primary, Priority: 0, Weight: 5},
+		{URL: primaryPeer, Priority: 0, Weight: 5},
+	})
+
+	assert.Equal(t, [][]*stun.URI{{primary, primaryPeer}, {primaryLight}, {secondary}}, tiers,
+		"the lighter server should be the fallback of the heavier ones of the same priority")
+
+	tiers = GroupTURNsByPreference([]TURNServer{
+		{URL: secondary, Priority: 10},
+		{URL: primary},
+		{URL: primaryLight},
+	})
+	assert.Equal(t, [][]*stun.URI{{primary, primaryLight}, {secondary}}, tiers,
+		"the servers of the same priority without a weight should be tried together")
+	assert.Nil(t, GroupTURNsByPreference(nil))
+}
+
+func TestConn_TURNTierFallback(t *testing.T) {
+	stunURL := mustParseURI(t, "stun:stun.netbird.io:3478")
+	primary := mustParseURI(t, "turn:eu.turn.netbird.io:3478")
+	secondary := mustParseURI(t, "turn:us.turn.netbird.io:3478")
+
+	conf := connConf
+	conf.StunTurn = []*stun.URI{stunURL, primary, secondary}
+	conf.TURNTiers = [][]*stun.URI{{primary}, {secondary}}
+	conn := &Conn{config: conf}
+
+	assert.Equal(t, []*stun.URI{stunURL, primary}, conn.iceURLs(), "the preferred relay should be tried first")
+
+	conn.nextTURNTier()
+	assert.Equal(t, []*stun.URI{stunURL, secondary}, conn.iceURLs(), "the secondary relay should be used when the preferred one fails")
+
+	conn.nextTURNTier()
+	assert.Equal(t, []*stun.URI{stunURL, primary}, conn.iceURLs(), "the preferred relay should be tried again after the last tier")
+
+	conn.nextTURNTier()
+	conn.UpdateStunTurn([]*stun.URI{stunURL, primary}, [][]*stun.URI{{primary}})
+	assert.Equal(t, []*stun.URI{stunURL, primary}, conn.iceURLs(), "a single tier should give all the URLs")
+}
@@ -0,0 +1,218 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+)
+
+// peerPingLatencyTimeout is how long to wait for the reply to the ICMP echo sent to a connected peer
+const peerPingLatencyTimeout = 2 * time.Second
+
+// PeerPingResult is the result of a connectivity test to a peer
+type PeerPingResult struct {
+	// State is the state of the peer once connected, with the path and the ICE candidates of the connection
+	State peer.State
+	// ICESetup is how long the connection attempt forced by the ping took to establish the connection, zero when
+	// the established connection was used
+	ICESetup time.Duration
+	// Latency is the round trip time of an ICMP echo to the peer IP, zero when LatencyErr is set
+	Latency    time.Duration
+	LatencyErr error
+}
+
+// PingPeer forces a new connection attempt to the peer if it isn't connected, instead of waiting for the current one
+// to time out, waits until the connection is established or the context is done and measures the latency to the peer.
+// The established connection is kept unless reconnect is set, so the tunnel to the peer isn't disrupted.
+// The peer is the WireGuard public key, the FQDN, the host name or the IP address of a remote peer.
+func (e *Engine) PingPeer(ctx context.Context, peerID string, reconnect bool) (*PeerPingResult, error) {
+	peerKey, err := e.findPeerKey(peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var probeStarted time.Time
+	if conn := e.getPeerConn(peerKey); conn != nil {
+		probeStarted = probePeerConn(conn, reconnect)
+	}
+
+	state, err := e.waitPeerConnected(ctx, peerKey, probeStarted)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PeerPingResult{State: state}
+	if !probeStarted.IsZero() {
+		result.ICESetup = state.ConnStatusUpdate.Sub(probeStarted)
+	}
+
+	latencyCtx, cancel := context.WithTimeout(ctx, peerPingLatencyTimeout)
+	defer cancel()
+	result.Latency, result.LatencyErr = icmpEcho(latencyCtx, net.ParseIP(state.IP))
+	if result.LatencyErr != nil {
+		log.Debugf("failed measuring the latency to peer %s: %v", peerKey, result.LatencyErr)
+	}
+	return result, nil
+}
+
+// probePeerConn starts a new connection attempt to the peer and returns when it was started. A connection which
+// isn't established gives up its current negotiation and sends a new offer, an established one is dropped only
+// when reconnect is set. The zero time is returned when the established connection is kept.
+func probePeerConn(conn *peer.Conn, reconnect bool) time.Time {
+	started := time.Now()
+	switch {
+	case conn.Status() != peer.StatusConnected:
+		conn.Renegotiate()
+	case reconnect:
+		conn.Reconnect()
+	default:
+		return time.Time{}
+	}
+	return started
+}
+
+// getPeerConn returns the connection of the remote peer, nil if there is none
+func (e *Engine) getPeerConn(peerKey string) *peer.Conn {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+	return e.peerConns[peerKey]
+}
+
+// findPeerKey returns the key of the remote peer matching the key, the FQDN, the host name or the IP address
+func (e *Engine) findPeerKey(peerID string) (string, error) {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	if _, ok := e.peerConns[peerID]; ok {
+		return peerID, nil
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(peerID, "."))
+	for peerKey := range e.peerConns {
+		state, err := e.statusRecorder.GetPeer(peerKey)
+		if err != nil {
+			continue
+		}
+		fqdn := strings.ToLower(strings.TrimSuffix(state.FQDN, "."))
+		hostname, _, _ := strings.Cut(fqdn, ".")
+		if name == fqdn || name == hostname || peerID == state.IP {
+			return peerKey, nil
+		}
+	}
+	return "", fmt.Errorf("peer %s not found", peerID)
+}
+
+// waitPeerConnected returns the state of the peer once connected. When since isn't zero, the connection has to be
+// established after it, so an attempt forced at that time is waited for.
+func (e *Engine) waitPeerConnected(ctx context.Context, peerKey string, since time.Time) (peer.State, error) {
+	for {
+		// get the notifier first not to miss a change between the state check and the wait
+		changed := e.statusRecorder.GetPeerStateChangeNotifier(peerKey)
+		state, err := e.statusRecorder.GetPeer(peerKey)
+		if err != nil {
+			return peer.State{}, fmt.Errorf("peer %s has been removed", peerKey)
+		}
+		// a stale peer is still connected, the ping tells whether it answers
+		connected := state.ConnStatus == peer.StatusConnected || state.ConnStatus == peer.StatusStale
+		if connected && !state.ConnStatusUpdate.Before(since) {
+			return state, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return peer.State{}, fmt.Errorf("peer %s is unreachable, the connection hasn't been established, last phase %s: %w",
+				peerKey, state.ConnPhase, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// icmpEcho sends an ICMP echo request to the IP and returns the round trip time of the reply.
+// It falls back to the unprivileged ICMP sockets when raw sockets aren't permitted.
+func icmpEcho(ctx context.Context, ip net.IP) (time.Duration, error) {
+	if ip.To4() == nil {
+		return 0, fmt.Errorf("invalid peer IP %s", ip)
+	}
+
+	var dst net.Addr = &net.IPAddr{IP: ip}
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		conn, err = icmp.ListenPacket("udp4", "0.0.0.0")
+		if err != nil {
+			return 0, fmt.Errorf("listen ICMP: %w", err)
+		}
+		dst = &net.UDPAddr{IP: ip}
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Debugf("failed closing the ICMP socket: %v", err)
+		}
+	}()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return 0, fmt.Errorf("set ICMP deadline: %w", err)
+		}
+	}
+
+	// the kernel replaces the ID of the unprivileged sockets, the replies are matched by the sequence and the source
+	seq := rand.Intn(0xffff)
+	request := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: os.Getpid() & 0xffff, Seq: seq, Data: []byte("netbird ping")},
+	}
+	payload, err := request.Marshal(nil)
+	if err != nil {
+		return 0, fmt.Errorf("marshal ICMP echo: %w", err)
+	}
+
+	start := time.Now()
+	if _, err := conn.WriteTo(payload, dst); err != nil {
+		return 0, fmt.Errorf("send ICMP echo: %w", err)
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return 0, fmt.Errorf("no ICMP echo reply from %s", ip)
+			}
+			return 0, fmt.Errorf("read ICMP echo reply: %w", err)
+		}
+		rtt := time.Since(start)
+
+		reply, err := icmp.ParseMessage(ipv4.ICMPTypeEchoReply.Protocol(), buf[:n])
+		if err != nil || reply.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.Seq != seq || !addrIP(from).Equal(ip) {
+			continue
+		}
+		return rtt, nil
+	}
+}
+
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	default:
+		return nil
+	}
+}
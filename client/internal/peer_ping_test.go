@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+)
+
+func TestEngine_PingPeer(t *testing.T) {
+	const (
+		connectedKey    = "RRHf3Ma6z6mdLbriAJbqhX7+nM/B71lgw2+91q3LfhU="
+		disconnectedKey = "LLHf3Ma6z6mdLbriAJbqhX7+nM/B71lgw2+91q3LfhU="
+	)
+
+	recorder := peer.NewRecorder("https://mgm")
+	engine := &Engine{
+		syncMsgMux:     &sync.Mutex{},
+		statusRecorder: recorder,
+		peerConns: map[string]*peer.Conn{
+			connectedKey:    nil,
+			disconnectedKey: nil,
+		},
+	}
+
+	require.NoError(t, recorder.AddPeer(connectedKey, "peer-a.netbird.cloud"))
+	require.NoError(t, recorder.UpdatePeerState(peer.State{
+		PubKey:                 connectedKey,
+		IP:                     "100.64.0.10",
+		ConnStatus:             peer.StatusConnected,
+		Direct:                 true,
+		LocalIceCandidateType:  "host",
+		RemoteIceCandidateType: "srflx",
+	}))
+	require.NoError(t, recorder.AddPeer(disconnectedKey, "peer-b.netbird.cloud"))
+	require.NoError(t, recorder.UpdatePeerState(peer.State{
+		PubKey:     disconnectedKey,
+		IP:         "100.64.0.11",
+		ConnStatus: peer.StatusConnecting,
+	}))
+
+	t.Run("find the peer by key, FQDN, host name or IP", func(t *testing.T) {
+		for _, peerID := range []string{connectedKey, "peer-a.netbird.cloud", "Peer-A.netbird.cloud.", "peer-a", "100.64.0.10"} {
+			key, err := engine.findPeerKey(peerID)
+			require.NoError(t, err, peerID)
+			assert.Equal(t, connectedKey, key, peerID)
+		}
+
+		_, err := engine.findPeerKey("peer-c")
+		assert.Error(t, err)
+	})
+
+	t.Run("connected peer", func(t *testing.T) {
+		result, err := engine.PingPeer(context.Background(), "peer-a", false)
+		require.NoError(t, err)
+		assert.Equal(t, connectedKey, result.State.PubKey)
+		assert.True(t, result.State.Direct)
+		assert.Equal(t, "host", result.State.LocalIceCandidateType)
+		assert.Equal(t, "srflx", result.State.RemoteIceCandidateType)
+		assert.Zero(t, result.ICESetup, "the established connection should be used")
+	})
+
+	t.Run("wait for the connection established after a forced attempt", func(t *testing.T) {
+		since := time.Now()
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_ = recorder.UpdatePeerState(peer.State{
+				PubKey:           connectedKey,
+				IP:               "100.64.0.10",
+				ConnStatus:       peer.StatusConnecting,
+				ConnStatusUpdate: time.Now(),
+			})
+			_ = recorder.UpdatePeerState(peer.State{
+				PubKey:           connectedKey,
+				IP:               "100.64.0.10",
+				ConnStatus:       peer.StatusConnected,
+				ConnStatusUpdate: time.Now(),
+				Relayed:          true,
+			})
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		state, err := engine.waitPeerConnected(ctx, connectedKey, since)
+		require.NoError(t, err)
+		assert.True(t, state.Relayed, "the connection established before the attempt should be ignored")
+		assert.False(t, state.ConnStatusUpdate.Before(since))
+	})
+
+	t.Run("unreachable peer times out", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err := engine.PingPeer(ctx, "peer-b", false)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("peer connecting while waiting", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_ = recorder.UpdatePeerState(peer.State{
+				PubKey:     disconnectedKey,
+				IP:         "100.64.0.11",
+				ConnStatus: peer.StatusConnected,
+				Relayed:    true,
+			})
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, err := engine.PingPeer(ctx, "peer-b", false)
+		require.NoError(t, err)
+		assert.True(t, result.State.Relayed)
+	})
+}
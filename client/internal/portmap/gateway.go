@@ -0,0 +1,30 @@
+//go:build !android && !ios
+
+package portmap
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/libp2p/go-netroute"
+)
+
+// defaultGateway returns the IPv4 gateway of the default route, the port mapping protocols are IPv4 only
+func defaultGateway() (netip.Addr, error) {
+	r, err := netroute.New()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	_, gateway, _, err := r.Route(net.IPv4zero)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	addr, ok := netip.AddrFromSlice(gateway.To4())
+	if !ok || !addr.IsPrivate() {
+		return netip.Addr{}, fmt.Errorf("default route has no private IPv4 gateway")
+	}
+	return addr, nil
+}
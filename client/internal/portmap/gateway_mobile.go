@@ -0,0 +1,12 @@
+//go:build android || ios
+
+package portmap
+
+import (
+	"errors"
+	"net/netip"
+)
+
+func defaultGateway() (netip.Addr, error) {
+	return netip.Addr{}, errors.New("not supported on this platform")
+}
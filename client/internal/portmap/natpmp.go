@@ -0,0 +1,132 @@
+package portmap
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+const (
+	// natPMPPort is the UDP port the gateway listens on for NAT-PMP requests (RFC 6886)
+	natPMPPort = 5351
+
+	natPMPVersion           = 0
+	natPMPOpExternalAddress = 0
+	natPMPOpMapUDP          = 1
+	natPMPResponseOffset    = 128
+
+	// natPMPInitialTimeout is the timeout of the first request attempt, doubled on each retransmission
+	natPMPInitialTimeout = 250 * time.Millisecond
+	natPMPAttempts       = 4
+)
+
+// natPMP maps the ports with the NAT-PMP protocol of the gateway
+type natPMP struct {
+	gateway netip.AddrPort
+}
+
+func newNATPMP(gateway netip.Addr) *natPMP {
+	return &natPMP{gateway: netip.AddrPortFrom(gateway, natPMPPort)}
+}
+
+func (n *natPMP) name() string {
+	return ProtocolNATPMP
+}
+
+// addMapping asks the gateway to map the UDP internal port, preferably to the same external port
+func (n *natPMP) addMapping(ctx context.Context, internalPort, externalPort int, lifetime time.Duration) (*Mapping, error) {
+	resp, err := n.request(ctx, []byte{natPMPVersion, natPMPOpExternalAddress}, 12)
+	if err != nil {
+		return nil, fmt.Errorf("get external address: %w", err)
+	}
+	externalIP := netip.AddrFrom4([4]byte(resp[8:12]))
+
+	resp, err = n.request(ctx, natPMPMapRequest(internalPort, externalPort, lifetime), 16)
+	if err != nil {
+		return nil, fmt.Errorf("map port: %w", err)
+	}
+
+	mappedPort := binary.BigEndian.Uint16(resp[10:12])
+	mappedLifetime := time.Duration(binary.BigEndian.Uint32(resp[12:16])) * time.Second
+	if mappedPort == 0 || mappedLifetime == 0 {
+		return nil, errors.New("gateway returned an empty mapping")
+	}
+
+	return &Mapping{
+		Protocol:     ProtocolNATPMP,
+		InternalPort: internalPort,
+		External:     netip.AddrPortFrom(externalIP, mappedPort),
+		Lifetime:     mappedLifetime,
+	}, nil
+}
+
+// deleteMapping removes the mapping of the internal port, a request with a zero lifetime deletes it
+func (n *natPMP) deleteMapping(ctx context.Context, mapping *Mapping) error {
+	_, err := n.request(ctx, natPMPMapRequest(mapping.InternalPort, 0, 0), 16)
+	return err
+}
+
+func natPMPMapRequest(internalPort, externalPort int, lifetime time.Duration) []byte {
+	req := make([]byte, 12)
+	req[0] = natPMPVersion
+	req[1] = natPMPOpMapUDP
+	binary.BigEndian.PutUint16(req[4:6], uint16(internalPort))
+	binary.BigEndian.PutUint16(req[6:8], uint16(externalPort))
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime/time.Second))
+	return req
+}
+
+// request sends the request to the gateway and returns its response, retransmitting it with a growing timeout
+func (n *natPMP) request(ctx context.Context, req []byte, respSize int) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, net.UDPAddrFromAddrPort(n.gateway))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	resp := make([]byte, 16)
+	timeout := natPMPInitialTimeout
+	for attempt := 0; attempt < natPMPAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+
+		deadline := time.Now().Add(timeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		timeout *= 2
+
+		size, err := conn.Read(resp)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return nil, err
+		}
+
+		if size < respSize || resp[0] != natPMPVersion || resp[1] != req[1]+natPMPResponseOffset {
+			// not a response to our request
+			continue
+		}
+
+		if code := binary.BigEndian.Uint16(resp[2:4]); code != 0 {
+			return nil, fmt.Errorf("gateway returned result code %d", code)
+		}
+		return resp[:size], nil
+	}
+
+	return nil, errors.New("no response from the gateway")
+}
@@ -0,0 +1,98 @@
+package portmap
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNATPMPGateway answers the NAT-PMP requests, the mapped ports are offset by 1000
+func fakeNATPMPGateway(t *testing.T, requests chan<- []byte) netip.AddrPort {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			size, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			req := append([]byte{}, buf[:size]...)
+			if requests != nil {
+				requests <- req
+			}
+
+			var resp []byte
+			switch req[1] {
+			case natPMPOpExternalAddress:
+				resp = make([]byte, 12)
+				copy(resp[8:12], net.IPv4(203, 0, 113, 7).To4())
+			case natPMPOpMapUDP:
+				resp = make([]byte, 16)
+				copy(resp[8:10], req[4:6])
+				lifetime := binary.BigEndian.Uint32(req[8:12])
+				if lifetime > 0 {
+					binary.BigEndian.PutUint16(resp[10:12], binary.BigEndian.Uint16(req[4:6])+1000)
+				}
+				binary.BigEndian.PutUint32(resp[12:16], lifetime)
+			}
+			resp[1] = req[1] + natPMPResponseOffset
+			_, _ = conn.WriteToUDP(resp, from)
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).AddrPort()
+}
+
+func TestNATPMP_AddAndDeleteMapping(t *testing.T) {
+	requests := make(chan []byte, 10)
+	client := &natPMP{gateway: fakeNATPMPGateway(t, requests)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mapping, err := client.addMapping(ctx, 51820, 0, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, &Mapping{
+		Protocol:     ProtocolNATPMP,
+		InternalPort: 51820,
+		External:     netip.MustParseAddrPort("203.0.113.7:52820"),
+		Lifetime:     time.Hour,
+	}, mapping)
+
+	<-requests
+	mapReq := <-requests
+	assert.Equal(t, []byte{0, natPMPOpMapUDP, 0, 0, 0xca, 0x6c, 0, 0, 0, 0, 0x0e, 0x10}, mapReq)
+
+	err = client.deleteMapping(ctx, mapping)
+	require.NoError(t, err)
+	deleteReq := <-requests
+	assert.Equal(t, []byte{0, natPMPOpMapUDP, 0, 0, 0xca, 0x6c, 0, 0, 0, 0, 0, 0}, deleteReq,
+		"the delete request should have zero external port and lifetime")
+}
+
+func TestNATPMP_NoGateway(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := &natPMP{gateway: conn.LocalAddr().(*net.UDPAddr).AddrPort()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	_, err = client.addMapping(ctx, 51820, 0, time.Hour)
+	assert.Error(t, err, "a gateway not answering shouldn't map the port")
+}
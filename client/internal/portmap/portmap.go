@@ -0,0 +1,205 @@
+// Package portmap maps the WireGuard port of the peer on the gateway of its network with NAT-PMP or UPnP,
+// so the remote peers can connect to it directly behind a home router.
+package portmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ProtocolNATPMP is the NAT Port Mapping Protocol (RFC 6886)
+	ProtocolNATPMP = "nat-pmp"
+	// ProtocolUPnP is the WAN connection service of a UPnP Internet Gateway Device
+	ProtocolUPnP = "upnp"
+
+	// DefaultLifetime is the lifetime requested for the mappings, they are refreshed when half of it passed
+	DefaultLifetime = 2 * time.Hour
+
+	requestTimeout = 5 * time.Second
+	// retryInterval is how often a failed mapping or refresh is retried
+	retryInterval = time.Minute
+)
+
+// Mapping is a UDP port mapping on the gateway
+type Mapping struct {
+	// Protocol the mapping was created with, ProtocolNATPMP or ProtocolUPnP
+	Protocol string
+	// InternalPort is the local port the gateway forwards the traffic to
+	InternalPort int
+	// External is the public address and port of the mapping
+	External netip.AddrPort
+	// Lifetime of the mapping granted by the gateway
+	Lifetime time.Duration
+}
+
+type mapper interface {
+	name() string
+	// addMapping maps the internal port, preferably to the external port. A zero external port lets the gateway choose.
+	addMapping(ctx context.Context, internalPort, externalPort int, lifetime time.Duration) (*Mapping, error)
+	deleteMapping(ctx context.Context, mapping *Mapping) error
+}
+
+// Manager maps a port on the gateway and keeps the mapping alive until it is closed
+type Manager struct {
+	internalPort int
+	lifetime     time.Duration
+
+	mappers []mapper
+
+	mu sync.Mutex
+	// mapper is the one which created the current mapping
+	mapper    mapper
+	mapping   *Mapping
+	expiresAt time.Time
+	refreshAt time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager returns a Manager mapping the UDP internal port with NAT-PMP or UPnP of the default gateway
+func NewManager(internalPort int) (*Manager, error) {
+	gateway, err := defaultGateway()
+	if err != nil {
+		return nil, fmt.Errorf("find default gateway: %w", err)
+	}
+
+	return newManager(internalPort, DefaultLifetime, newNATPMP(gateway), newUPnP(gateway)), nil
+}
+
+func newManager(internalPort int, lifetime time.Duration, mappers ...mapper) *Manager {
+	return &Manager{
+		internalPort: internalPort,
+		lifetime:     lifetime,
+		mappers:      mappers,
+	}
+}
+
+// Start maps the port and refreshes the mapping in the background before it expires.
+// An error is returned if the port couldn't be mapped, the mapping is still retried in the background until closed.
+func (m *Manager) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	err := m.refresh(ctx)
+
+	m.wg.Add(1)
+	go m.keepAlive(ctx)
+
+	return err
+}
+
+// Mapping returns a copy of the current mapping, nil if the port isn't mapped
+func (m *Manager) Mapping() *Mapping {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.mapping == nil {
+		return nil
+	}
+	mapping := *m.mapping
+	return &mapping
+}
+
+// Close stops refreshing the mapping and removes it from the gateway
+func (m *Manager) Close() error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.mapping == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	err := m.mapper.deleteMapping(ctx, m.mapping)
+	m.mapping = nil
+	if err != nil {
+		return fmt.Errorf("remove %s mapping: %w", m.mapper.name(), err)
+	}
+	return nil
+}
+
+func (m *Manager) keepAlive(ctx context.Context) {
+	defer m.wg.Done()
+
+	for {
+		m.mu.Lock()
+		wait := time.Until(m.refreshAt)
+		m.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := m.refresh(ctx); err != nil {
+			log.Warnf("failed to map port %d on the gateway: %v", m.internalPort, err)
+		}
+	}
+}
+
+// refresh renews the current mapping with the mapper which created it, keeping its external port.
+// Without a mapping, the mappers are tried in order until one maps the port. The mapping is refreshed again at
+// half of its lifetime, a failed attempt is retried after retryInterval until the mapping expires.
+func (m *Manager) refresh(ctx context.Context) error {
+	m.mu.Lock()
+	mappers := m.mappers
+	externalPort := 0
+	if m.mapping != nil {
+		mappers = []mapper{m.mapper}
+		externalPort = int(m.mapping.External.Port())
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, mp := range mappers {
+		reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		mapping, err := mp.addMapping(reqCtx, m.internalPort, externalPort, m.lifetime)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", mp.name(), err))
+			continue
+		}
+
+		m.mu.Lock()
+		if m.mapping == nil || m.mapping.External != mapping.External {
+			log.Infof("mapped port %d to %s on the gateway with %s", m.internalPort, mapping.External, mp.name())
+		}
+		now := time.Now()
+		m.mapper = mp
+		m.mapping = mapping
+		m.expiresAt = now.Add(mapping.Lifetime)
+		m.refreshAt = now.Add(max(mapping.Lifetime/2, retryInterval))
+		m.mu.Unlock()
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.refreshAt = now.Add(retryInterval)
+	if m.mapping != nil && now.After(m.expiresAt) {
+		log.Infof("mapping of port %d to %s on the gateway expired", m.internalPort, m.mapping.External)
+		m.mapping = nil
+		m.mapper = nil
+	}
+	return errors.Join(errs...)
+}
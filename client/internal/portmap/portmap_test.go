@@ -0,0 +1,105 @@
+package portmap
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMapper struct {
+	mu       sync.Mutex
+	protocol string
+	fail     bool
+	added    []int
+	deleted  int
+}
+
+func (f *fakeMapper) name() string {
+	return f.protocol
+}
+
+func (f *fakeMapper) addMapping(_ context.Context, internalPort, externalPort int, lifetime time.Duration) (*Mapping, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.fail {
+		return nil, errors.New("mapping failed")
+	}
+	f.added = append(f.added, externalPort)
+	if externalPort == 0 {
+		externalPort = internalPort + 1
+	}
+	return &Mapping{
+		Protocol:     f.protocol,
+		InternalPort: internalPort,
+		External:     netip.AddrPortFrom(netip.MustParseAddr("203.0.113.7"), uint16(externalPort)),
+		Lifetime:     lifetime,
+	}, nil
+}
+
+func (f *fakeMapper) deleteMapping(context.Context, *Mapping) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted++
+	return nil
+}
+
+func TestManager_FallsBackToNextMapper(t *testing.T) {
+	natpmp := &fakeMapper{protocol: ProtocolNATPMP, fail: true}
+	upnp := &fakeMapper{protocol: ProtocolUPnP}
+	manager := newManager(51820, time.Hour, natpmp, upnp)
+
+	err := manager.Start()
+	require.NoError(t, err)
+
+	mapping := manager.Mapping()
+	require.NotNil(t, mapping)
+	assert.Equal(t, ProtocolUPnP, mapping.Protocol)
+	assert.Equal(t, netip.MustParseAddrPort("203.0.113.7:51821"), mapping.External)
+
+	// the refresh keeps the external port and uses the mapper which created the mapping
+	natpmp.fail = false
+	err = manager.refresh(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 51821}, upnp.added)
+	assert.Empty(t, natpmp.added)
+
+	require.NoError(t, manager.Close())
+	assert.Equal(t, 1, upnp.deleted, "the mapping should be removed on close")
+	assert.Nil(t, manager.Mapping())
+}
+
+func TestManager_KeepsMappingUntilExpired(t *testing.T) {
+	mapper := &fakeMapper{protocol: ProtocolNATPMP}
+	manager := newManager(51820, time.Hour, mapper)
+
+	require.NoError(t, manager.refresh(context.Background()))
+
+	mapper.fail = true
+	err := manager.refresh(context.Background())
+	assert.Error(t, err)
+	assert.NotNil(t, manager.Mapping(), "a failed refresh shouldn't drop the mapping before it expires")
+
+	manager.expiresAt = time.Now().Add(-time.Second)
+	err = manager.refresh(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, manager.Mapping(), "an expired mapping should be dropped")
+
+	assert.NoError(t, manager.Close())
+	assert.Zero(t, mapper.deleted, "a dropped mapping shouldn't be removed")
+}
+
+func TestManager_StartFails(t *testing.T) {
+	manager := newManager(51820, time.Hour, &fakeMapper{protocol: ProtocolNATPMP, fail: true})
+
+	err := manager.Start()
+	assert.Error(t, err)
+	assert.Nil(t, manager.Mapping())
+	assert.NoError(t, manager.Close())
+}
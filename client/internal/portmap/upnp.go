@@ -0,0 +1,362 @@
+package portmap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ssdpAddr         = "239.255.255.250:1900"
+	ssdpSearchTarget = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+	ssdpSearchWait   = 2 * time.Second
+
+	upnpMappingDescription = "NetBird"
+	// upnpMaxResponseSize bounds the device description and the SOAP responses read from the gateway
+	upnpMaxResponseSize = 1 << 20
+)
+
+// upnpServiceTypes are the WAN connection services of an Internet Gateway Device able to map ports, by preference
+var upnpServiceTypes = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+// upnp maps the ports with the WAN connection service of the UPnP Internet Gateway Device of the network
+type upnp struct {
+	gateway netip.Addr
+	client  *http.Client
+
+	// controlURL and serviceType are the service the SOAP actions are sent to, discovered on the first mapping
+	controlURL  string
+	serviceType string
+	// localIP is the address of this host the gateway forwards the mapped port to
+	localIP string
+}
+
+func newUPnP(gateway netip.Addr) *upnp {
+	return &upnp{
+		gateway: gateway,
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (u *upnp) name() string {
+	return ProtocolUPnP
+}
+
+// addMapping maps the UDP internal port of this host to the external port of the gateway
+func (u *upnp) addMapping(ctx context.Context, internalPort, externalPort int, lifetime time.Duration) (*Mapping, error) {
+	if u.controlURL == "" {
+		location, err := u.discover(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("discover gateway: %w", err)
+		}
+		if err := u.loadService(ctx, location); err != nil {
+			return nil, fmt.Errorf("load gateway description: %w", err)
+		}
+	}
+
+	resp, err := u.soapRequest(ctx, "GetExternalIPAddress", nil)
+	if err != nil {
+		return nil, fmt.Errorf("get external address: %w", err)
+	}
+	externalIP, err := netip.ParseAddr(strings.TrimSpace(resp["NewExternalIPAddress"]))
+	if err != nil {
+		return nil, fmt.Errorf("parse external address: %w", err)
+	}
+
+	if externalPort == 0 {
+		externalPort = internalPort
+	}
+
+	err = u.addPortMapping(ctx, internalPort, externalPort, lifetime)
+	if err != nil && lifetime > 0 {
+		// some gateways support only the permanent mappings, they are removed on close as well
+		err = u.addPortMapping(ctx, internalPort, externalPort, 0)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("map port: %w", err)
+	}
+
+	return &Mapping{
+		Protocol:     ProtocolUPnP,
+		InternalPort: internalPort,
+		External:     netip.AddrPortFrom(externalIP.Unmap(), uint16(externalPort)),
+		Lifetime:     lifetime,
+	}, nil
+}
+
+func (u *upnp) addPortMapping(ctx context.Context, internalPort, externalPort int, lifetime time.Duration) error {
+	_, err := u.soapRequest(ctx, "AddPortMapping", [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(externalPort)},
+		{"NewProtocol", "UDP"},
+		{"NewInternalPort", strconv.Itoa(internalPort)},
+		{"NewInternalClient", u.localIP},
+		{"NewEnabled", "1"},
+		{"NewPortMappingDescription", upnpMappingDescription},
+		{"NewLeaseDuration", strconv.Itoa(int(lifetime / time.Second))},
+	})
+	return err
+}
+
+// deleteMapping removes the mapping of the external port from the gateway
+func (u *upnp) deleteMapping(ctx context.Context, mapping *Mapping) error {
+	if u.controlURL == "" {
+		return errors.New("gateway wasn't discovered")
+	}
+
+	_, err := u.soapRequest(ctx, "DeletePortMapping", [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(int(mapping.External.Port()))},
+		{"NewProtocol", "UDP"},
+	})
+	return err
+}
+
+// discover searches the Internet Gateway Device with SSDP and returns the URL of its description.
+// Only the device of the gateway of this host is accepted.
+func (u *upnp) discover(ctx context.Context) (string, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	dst, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return "", err
+	}
+
+	search := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddr + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 1\r\n" +
+		"ST: " + ssdpSearchTarget + "\r\n\r\n"
+	if _, err := conn.WriteTo([]byte(search), dst); err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(ssdpSearchWait)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 2048)
+	for {
+		size, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return "", fmt.Errorf("no Internet Gateway Device responded: %w", err)
+		}
+
+		fromAddr, ok := from.(*net.UDPAddr)
+		if !ok || !fromAddr.IP.Equal(u.gateway.AsSlice()) {
+			continue
+		}
+
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:size])), nil)
+		if err != nil {
+			continue
+		}
+		_ = resp.Body.Close()
+
+		if location := resp.Header.Get("Location"); location != "" {
+			return location, nil
+		}
+	}
+}
+
+type upnpDevice struct {
+	Services []upnpService `xml:"serviceList>service"`
+	Devices  []upnpDevice  `xml:"deviceList>device"`
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+type upnpDescription struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+// loadService reads the device description of the gateway and picks its WAN connection service
+func (u *upnp) loadService(ctx context.Context, location string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var desc upnpDescription
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, upnpMaxResponseSize)).Decode(&desc); err != nil {
+		return err
+	}
+
+	services := make(map[string]string)
+	collectUPnPServices(desc.Device, services)
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	if desc.URLBase != "" {
+		if base, err = url.Parse(desc.URLBase); err != nil {
+			return err
+		}
+	}
+
+	for _, serviceType := range upnpServiceTypes {
+		controlURL, ok := services[serviceType]
+		if !ok {
+			continue
+		}
+		ref, err := url.Parse(controlURL)
+		if err != nil {
+			return err
+		}
+		u.controlURL = base.ResolveReference(ref).String()
+		u.serviceType = serviceType
+		break
+	}
+	if u.controlURL == "" {
+		return errors.New("gateway has no WAN connection service")
+	}
+
+	// the gateway forwards the mapped port to the address this host reaches it from
+	localIP, err := localAddrTo(base.Host)
+	if err != nil {
+		return err
+	}
+	u.localIP = localIP
+	return nil
+}
+
+func collectUPnPServices(device upnpDevice, services map[string]string) {
+	for _, service := range device.Services {
+		if _, ok := services[service.ServiceType]; !ok {
+			services[service.ServiceType] = service.ControlURL
+		}
+	}
+	for _, child := range device.Devices {
+		collectUPnPServices(child, services)
+	}
+}
+
+// localAddrTo returns the local IP of the route to the host, no packet is sent
+func localAddrTo(host string) (string, error) {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "80")
+	}
+	conn, err := net.Dial("udp", host)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return "", fmt.Errorf("unexpected local address %s", conn.LocalAddr())
+	}
+	return addr.IP.String(), nil
+}
+
+// soapRequest calls the action of the WAN connection service and returns the arguments of the response
+func (u *upnp) soapRequest(ctx context.Context, action string, args [][2]string) (map[string]string, error) {
+	var body strings.Builder
+	body.WriteString(`<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:` + action + ` xmlns:u="` + u.serviceType + `">`)
+	for _, arg := range args {
+		body.WriteString("<" + arg[0] + ">")
+		if err := xml.EscapeText(&body, []byte(arg[1])); err != nil {
+			return nil, err
+		}
+		body.WriteString("</" + arg[0] + ">")
+	}
+	body.WriteString(`</u:` + action + `></s:Body></s:Envelope>`)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.controlURL, strings.NewReader(body.String()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+u.serviceType+"#"+action+`"`)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s failed with status %s", action, resp.Status)
+	}
+
+	return parseSOAPResponse(io.LimitReader(resp.Body, upnpMaxResponseSize), action+"Response")
+}
+
+// parseSOAPResponse returns the arguments of the response element of the SOAP envelope by name
+func parseSOAPResponse(r io.Reader, responseName string) (map[string]string, error) {
+	decoder := xml.NewDecoder(r)
+	args := make(map[string]string)
+	inResponse := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local == responseName {
+			inResponse = true
+			continue
+		}
+		if !inResponse {
+			continue
+		}
+
+		var value string
+		if err := decoder.DecodeElement(&value, &start); err != nil {
+			return nil, err
+		}
+		args[start.Name.Local] = value
+	}
+
+	if !inResponse {
+		return nil, fmt.Errorf("response doesn't contain %s", responseName)
+	}
+	return args, nil
+}
@@ -0,0 +1,120 @@
+package portmap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUPnPDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+            <serviceList>
+              <service>
+                <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+                <controlURL>/ctl/IPConn</controlURL>
+              </service>
+            </serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+func TestUPnP_AddAndDeleteMapping(t *testing.T) {
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rootDesc.xml":
+			_, _ = io.WriteString(w, testUPnPDescription)
+		case "/ctl/IPConn":
+			body, _ := io.ReadAll(r.Body)
+			action := strings.Trim(r.Header.Get("SOAPAction"), `"`)
+			actions = append(actions, action)
+
+			switch action {
+			case "urn:schemas-upnp-org:service:WANIPConnection:1#GetExternalIPAddress":
+				_, _ = io.WriteString(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+					`<u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">`+
+					`<NewExternalIPAddress>203.0.113.7</NewExternalIPAddress></u:GetExternalIPAddressResponse></s:Body></s:Envelope>`)
+			case "urn:schemas-upnp-org:service:WANIPConnection:1#AddPortMapping":
+				assert.Contains(t, string(body), "<NewInternalPort>51820</NewInternalPort>")
+				assert.Contains(t, string(body), "<NewProtocol>UDP</NewProtocol>")
+				if strings.Contains(string(body), "<NewLeaseDuration>3600</NewLeaseDuration>") {
+					// only the permanent mappings are supported
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				_, _ = io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+					`<u:AddPortMappingResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1"/></s:Body></s:Envelope>`)
+			case "urn:schemas-upnp-org:service:WANIPConnection:1#DeletePortMapping":
+				assert.Contains(t, string(body), "<NewExternalPort>51820</NewExternalPort>")
+				_, _ = io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+					`<u:DeletePortMappingResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1"/></s:Body></s:Envelope>`)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newUPnP(netip.MustParseAddr("127.0.0.1"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := client.loadService(ctx, server.URL+"/rootDesc.xml")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/ctl/IPConn", client.controlURL)
+	assert.Equal(t, "urn:schemas-upnp-org:service:WANIPConnection:1", client.serviceType)
+	assert.Equal(t, "127.0.0.1", client.localIP)
+
+	mapping, err := client.addMapping(ctx, 51820, 0, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, &Mapping{
+		Protocol:     ProtocolUPnP,
+		InternalPort: 51820,
+		External:     netip.MustParseAddrPort("203.0.113.7:51820"),
+		Lifetime:     time.Hour,
+	}, mapping)
+
+	err = client.deleteMapping(ctx, mapping)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"urn:schemas-upnp-org:service:WANIPConnection:1#GetExternalIPAddress",
+		"urn:schemas-upnp-org:service:WANIPConnection:1#AddPortMapping",
+		"urn:schemas-upnp-org:service:WANIPConnection:1#AddPortMapping",
+		"urn:schemas-upnp-org:service:WANIPConnection:1#DeletePortMapping",
+	}, actions, "the mapping should be retried as permanent")
+}
+
+func TestParseSOAPResponse(t *testing.T) {
+	args, err := parseSOAPResponse(strings.NewReader(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+		`<u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">`+
+		`<NewExternalIPAddress>203.0.113.7</NewExternalIPAddress></u:GetExternalIPAddressResponse></s:Body></s:Envelope>`),
+		"GetExternalIPAddressResponse")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"NewExternalIPAddress": "203.0.113.7"}, args)
+
+	_, err = parseSOAPResponse(strings.NewReader(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+		`<s:Fault><faultstring>UPnPError</faultstring></s:Fault></s:Body></s:Envelope>`), "AddPortMappingResponse")
+	assert.Error(t, err)
+}
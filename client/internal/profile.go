@@ -0,0 +1,299 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	"github.com/netbirdio/netbird/iface"
+	"github.com/netbirdio/netbird/util"
+)
+
+// DefaultProfileName is the name of the profile of the config the daemon was started with
+const DefaultProfileName = "default"
+
+const (
+	// profilesFileName is the file the profiles are stored in, next to the config of the default profile
+	profilesFileName = "profiles.json"
+	// profilesDirName is the directory the configs of the profiles are created in, next to the config of the default
+	// profile
+	profilesDirName = "profiles"
+	// maxProfileResourceAttempts limits the interface names and the ports tried when picking them for a new profile
+	maxProfileResourceAttempts = 100
+)
+
+var profileNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,31}$`)
+
+var (
+	// ErrProfileExists is returned when adding a profile with the name of an existing one
+	ErrProfileExists = errors.New("profile already exists")
+	// ErrProfileNotFound is returned when a profile with the name doesn't exist
+	ErrProfileNotFound = errors.New("profile not found")
+)
+
+// Profile is a named config of the daemon connecting to a NetBird network with its own interface and port
+type Profile struct {
+	Name       string
+	ConfigPath string
+}
+
+// Profiles are the profiles of the daemon and the name of the active one the requests apply to
+type Profiles struct {
+	Active string
+	// Profiles starts with the default profile, which isn't stored as its config path is the one of the daemon
+	Profiles []Profile
+}
+
+// Get returns the profile with the name
+func (p *Profiles) Get(name string) (Profile, bool) {
+	for _, profile := range p.Profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return Profile{}, false
+}
+
+// ReadProfiles reads the profiles stored next to the config of the default profile. The default profile is active
+// when no profiles were added or the active one doesn't exist anymore.
+func ReadProfiles(defaultConfigPath string) (*Profiles, error) {
+	stored := &Profiles{}
+	if configFileIsExists(profilesFilePath(defaultConfigPath)) {
+		if _, err := util.ReadJson(profilesFilePath(defaultConfigPath), stored); err != nil {
+			return nil, fmt.Errorf("read profiles: %w", err)
+		}
+	}
+
+	profiles := &Profiles{
+		Active:   stored.Active,
+		Profiles: append([]Profile{{Name: DefaultProfileName, ConfigPath: defaultConfigPath}}, stored.Profiles...),
+	}
+	if _, ok := profiles.Get(profiles.Active); !ok {
+		if profiles.Active != "" {
+			log.Warnf("the active profile %s doesn't exist, using the %s profile", profiles.Active, DefaultProfileName)
+		}
+		profiles.Active = DefaultProfileName
+	}
+	return profiles, nil
+}
+
+// SetActiveProfile stores the profile with the name as the active one
+func SetActiveProfile(defaultConfigPath, name string) error {
+	profiles, err := ReadProfiles(defaultConfigPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := profiles.Get(name); !ok {
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	profiles.Active = name
+	return writeProfiles(defaultConfigPath, profiles)
+}
+
+// AddProfile creates the config of a new profile and stores the profile. The interface name and the WireGuard port
+// of the input are picked if not set, so they don't collide with the ones of the other profiles.
+func AddProfile(defaultConfigPath, name string, input ConfigInput) (*Profile, *Config, error) {
+	if !profileNameRegexp.MatchString(name) {
+		return nil, nil, fmt.Errorf("invalid profile name %q, expecting up to 32 letters, digits, - and _", name)
+	}
+
+	profiles, err := ReadProfiles(defaultConfigPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := profiles.Get(name); ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrProfileExists, name)
+	}
+
+	resources, err := readProfileResources(profiles, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if input.InterfaceName == nil {
+		interfaceName, err := pickInterfaceName(resources)
+		if err != nil {
+			return nil, nil, err
+		}
+		input.InterfaceName = &interfaceName
+	}
+	if input.WireguardPort == nil {
+		port, err := pickWireguardPort(resources)
+		if err != nil {
+			return nil, nil, err
+		}
+		input.WireguardPort = &port
+	}
+	if err := checkProfileResources(resources, profileResource{
+		iface:      *input.InterfaceName,
+		portStart:  *input.WireguardPort,
+		portEnd:    *input.WireguardPort,
+		dnsAddress: string(input.CustomDNSAddress),
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	input.ConfigPath = filepath.Join(filepath.Dir(defaultConfigPath), profilesDirName, name+".json")
+	if configFileIsExists(input.ConfigPath) {
+		return nil, nil, fmt.Errorf("the config %s of the profile already exists", input.ConfigPath)
+	}
+	config, err := UpdateOrCreateConfig(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	profile := Profile{Name: name, ConfigPath: input.ConfigPath}
+	profiles.Profiles = append(profiles.Profiles, profile)
+	if err := writeProfiles(defaultConfigPath, profiles); err != nil {
+		return nil, nil, err
+	}
+	return &profile, config, nil
+}
+
+// CheckProfileCollisions checks the interface name, the WireGuard port and the DNS resolver address of the input
+// don't collide with the ones of the other profiles. The settings not set in the input are taken from its config.
+func CheckProfileCollisions(defaultConfigPath, name string, input ConfigInput) error {
+	profiles, err := ReadProfiles(defaultConfigPath)
+	if err != nil {
+		return err
+	}
+	resources, err := readProfileResources(profiles, name)
+	if err != nil {
+		return err
+	}
+
+	resource := profileResource{name: name, iface: iface.WgInterfaceDefault, portStart: iface.DefaultWgPort}
+	if configFileIsExists(input.ConfigPath) {
+		config, err := ReadConfig(input.ConfigPath)
+		if err != nil {
+			return err
+		}
+		resource = newProfileResource(name, config)
+	}
+	if input.InterfaceName != nil {
+		resource.iface = *input.InterfaceName
+	}
+	if input.WireguardPort != nil {
+		resource.portStart = *input.WireguardPort
+		resource.portEnd = max(resource.portEnd, resource.portStart)
+	}
+	if input.CustomDNSAddress != nil {
+		resource.dnsAddress = string(input.CustomDNSAddress)
+	}
+	return checkProfileResources(resources, resource)
+}
+
+// profileResource is the interface name, the WireGuard port range and the DNS resolver address used by a profile
+type profileResource struct {
+	name       string
+	iface      string
+	portStart  int
+	portEnd    int
+	dnsAddress string
+}
+
+func newProfileResource(name string, config *Config) profileResource {
+	return profileResource{
+		name:       name,
+		iface:      config.WgIface,
+		portStart:  config.WgPort,
+		portEnd:    max(config.WgPortRangeEnd, config.WgPort),
+		dnsAddress: config.CustomDNSAddress,
+	}
+}
+
+// readProfileResources returns the resources of the profiles other than the one with the skipped name. The config of
+// a profile not logged in yet doesn't exist and the defaults are assumed for it.
+func readProfileResources(profiles *Profiles, skip string) ([]profileResource, error) {
+	var resources []profileResource
+	for _, profile := range profiles.Profiles {
+		if profile.Name == skip {
+			continue
+		}
+		if !configFileIsExists(profile.ConfigPath) {
+			resources = append(resources, profileResource{
+				name:      profile.Name,
+				iface:     iface.WgInterfaceDefault,
+				portStart: iface.DefaultWgPort,
+				portEnd:   iface.DefaultWgPort,
+			})
+			continue
+		}
+		config, err := ReadConfig(profile.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("read the config of the profile %s: %w", profile.Name, err)
+		}
+		resources = append(resources, newProfileResource(profile.Name, config))
+	}
+	return resources, nil
+}
+
+func checkProfileResources(resources []profileResource, resource profileResource) error {
+	for _, other := range resources {
+		if other.iface == resource.iface {
+			return fmt.Errorf("interface %s is used by the profile %s", resource.iface, other.name)
+		}
+		if other.usesPort(resource.portStart, max(resource.portEnd, resource.portStart)) {
+			return fmt.Errorf("WireGuard port %d is used by the profile %s", resource.portStart, other.name)
+		}
+		if resource.dnsAddress != "" && other.dnsAddress == resource.dnsAddress {
+			return fmt.Errorf("DNS resolver address %s is used by the profile %s", resource.dnsAddress, other.name)
+		}
+	}
+	return nil
+}
+
+// usesPort returns true if the port range of the profile overlaps the range from start to end
+func (r profileResource) usesPort(start, end int) bool {
+	return start <= r.portEnd && r.portStart <= end
+}
+
+// pickInterfaceName returns the first interface name after the default one not used by the profiles,
+// e.g. wt1 or utun101 on macOS
+func pickInterfaceName(resources []profileResource) (string, error) {
+	prefix := strings.TrimRight(iface.WgInterfaceDefault, "0123456789")
+	base, _ := strconv.Atoi(strings.TrimPrefix(iface.WgInterfaceDefault, prefix))
+
+	for i := 1; i <= maxProfileResourceAttempts; i++ {
+		name := prefix + strconv.Itoa(base+i)
+		if !slices.ContainsFunc(resources, func(r profileResource) bool { return r.iface == name }) {
+			return name, nil
+		}
+	}
+	return "", errors.New("no free interface name for the profile, set one")
+}
+
+// pickWireguardPort returns the first port after the default one not used by the profiles
+func pickWireguardPort(resources []profileResource) (int, error) {
+	for i := 1; i <= maxProfileResourceAttempts; i++ {
+		port := iface.DefaultWgPort + i
+		if !slices.ContainsFunc(resources, func(r profileResource) bool { return r.usesPort(port, port) }) {
+			return port, nil
+		}
+	}
+	return 0, errors.New("no free WireGuard port for the profile, set one")
+}
+
+func profilesFilePath(defaultConfigPath string) string {
+	return filepath.Join(filepath.Dir(defaultConfigPath), profilesFileName)
+}
+
+// writeProfiles stores the profiles without the default one
+func writeProfiles(defaultConfigPath string, profiles *Profiles) error {
+	stored := &Profiles{Active: profiles.Active}
+	for _, profile := range profiles.Profiles {
+		if profile.Name != DefaultProfileName {
+			stored.Profiles = append(stored.Profiles, profile)
+		}
+	}
+	if err := util.WriteJson(profilesFilePath(defaultConfigPath), stored); err != nil {
+		return fmt.Errorf("write profiles: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/iface"
+)
+
+func TestAddProfile(t *testing.T) {
+	dir := t.TempDir()
+	defaultConfigPath := filepath.Join(dir, "config.json")
+	_, err := UpdateOrCreateConfig(ConfigInput{ConfigPath: defaultConfigPath})
+	require.NoError(t, err)
+
+	profiles, err := ReadProfiles(defaultConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfileName, profiles.Active, "the default profile should be active without profiles")
+	assert.Equal(t, []Profile{{Name: DefaultProfileName, ConfigPath: defaultConfigPath}}, profiles.Profiles)
+
+	profile, config, err := AddProfile(defaultConfigPath, "work", ConfigInput{ManagementURL: "https://work.example.com:443"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "profiles", "work.json"), profile.ConfigPath)
+	assert.Equal(t, "https://work.example.com:443", config.ManagementURL.String())
+	assert.NotEqual(t, iface.WgInterfaceDefault, config.WgIface, "the interface of the default profile shouldn't be used")
+	assert.Equal(t, iface.DefaultWgPort+1, config.WgPort)
+
+	_, second, err := AddProfile(defaultConfigPath, "lab", ConfigInput{})
+	require.NoError(t, err)
+	assert.NotEqual(t, config.WgIface, second.WgIface, "the interfaces of the profiles shouldn't collide")
+	assert.Equal(t, iface.DefaultWgPort+2, second.WgPort)
+
+	_, _, err = AddProfile(defaultConfigPath, "work", ConfigInput{})
+	assert.ErrorIs(t, err, ErrProfileExists)
+	_, _, err = AddProfile(defaultConfigPath, DefaultProfileName, ConfigInput{})
+	assert.ErrorIs(t, err, ErrProfileExists)
+	_, _, err = AddProfile(defaultConfigPath, "../work", ConfigInput{})
+	assert.Error(t, err, "the name shouldn't be a path")
+
+	port := iface.DefaultWgPort + 1
+	_, _, err = AddProfile(defaultConfigPath, "home", ConfigInput{WireguardPort: &port})
+	assert.Error(t, err, "the port of the work profile shouldn't be used")
+	interfaceName := config.WgIface
+	_, _, err = AddProfile(defaultConfigPath, "home", ConfigInput{InterfaceName: &interfaceName})
+	assert.Error(t, err, "the interface of the work profile shouldn't be used")
+
+	profiles, err = ReadProfiles(defaultConfigPath)
+	require.NoError(t, err)
+	assert.Len(t, profiles.Profiles, 3)
+}
+
+func TestCheckProfileCollisions(t *testing.T) {
+	dir := t.TempDir()
+	defaultConfigPath := filepath.Join(dir, "config.json")
+	_, err := UpdateOrCreateConfig(ConfigInput{ConfigPath: defaultConfigPath, CustomDNSAddress: []byte("127.0.0.1:5053")})
+	require.NoError(t, err)
+	profile, config, err := AddProfile(defaultConfigPath, "work", ConfigInput{})
+	require.NoError(t, err)
+
+	input := ConfigInput{ConfigPath: profile.ConfigPath}
+	assert.NoError(t, CheckProfileCollisions(defaultConfigPath, "work", input), "the own settings shouldn't collide")
+
+	input.CustomDNSAddress = []byte("127.0.0.1:5053")
+	assert.Error(t, CheckProfileCollisions(defaultConfigPath, "work", input), "the DNS address of the default profile is used")
+
+	port := iface.DefaultWgPort
+	input = ConfigInput{ConfigPath: profile.ConfigPath, WireguardPort: &port}
+	assert.Error(t, CheckProfileCollisions(defaultConfigPath, "work", input), "the port of the default profile is used")
+
+	input = ConfigInput{ConfigPath: defaultConfigPath, InterfaceName: &config.WgIface}
+	assert.Error(t, CheckProfileCollisions(defaultConfigPath, DefaultProfileName, input), "the interface of the work profile is used")
+}
+
+func TestSetActiveProfile(t *testing.T) {
+	dir := t.TempDir()
+	defaultConfigPath := filepath.Join(dir, "config.json")
+	_, _, err := AddProfile(defaultConfigPath, "work", ConfigInput{})
+	require.NoError(t, err)
+
+	require.NoError(t, SetActiveProfile(defaultConfigPath, "work"))
+	profiles, err := ReadProfiles(defaultConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, "work", profiles.Active)
+
+	assert.ErrorIs(t, SetActiveProfile(defaultConfigPath, "home"), ErrProfileNotFound)
+
+	require.NoError(t, SetActiveProfile(defaultConfigPath, DefaultProfileName))
+	profiles, err = ReadProfiles(defaultConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfileName, profiles.Active)
+}
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RotatePeerKey generates a new WireGuard key, replaces the key of the peer on the Management Service with it and
+// writes it to the config. The peer keeps its IP, name and groups. A running client has to be restarted to use the
+// new key, the old one can't log in anymore.
+func RotatePeerKey(ctx context.Context, config *Config, configPath string) (*Config, error) {
+	tlsConfig, err := config.TLSConfig()
+	if err != nil {
+		return nil, NewConfigInvalidError(err)
+	}
+
+	proxyDialer, err := config.ProxyDialer()
+	if err != nil {
+		return nil, NewConfigInvalidError(err)
+	}
+
+	mgmClient, err := getMgmClient(ctx, config.PrivateKey, config.ManagementURL, tlsConfig, proxyDialer)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = mgmClient.Close()
+		if err != nil {
+			cStatus, ok := status.FromError(err)
+			if !ok || ok && cStatus.Code() != codes.Canceled {
+				log.Warnf("failed to close the Management service client, err: %v", err)
+			}
+		}
+	}()
+
+	serverKey, err := mgmClient.GetServerPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	newKey, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	err = mgmClient.RotatePeerKey(*serverKey, newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated := *config
+	rotated.PrivateKey = newKey.String()
+	if err := WriteOutConfig(configPath, &rotated); err != nil {
+		return nil, fmt.Errorf("the peer key was rotated but the config could not be written, the peer has to be registered again: %w", err)
+	}
+
+	log.Infof("rotated the WireGuard key, the new public key is %s", newKey.PublicKey().String())
+	return &rotated, nil
+}
@@ -16,8 +16,10 @@ const minRangeBits = 7
 
 type routerPeerStatus struct {
 	connected bool
-	relayed   bool
-	direct    bool
+	// stale is set for the connected peers with a stale WireGuard handshake
+	stale   bool
+	relayed bool
+	direct  bool
 }
 
 type routesUpdate struct {
@@ -64,7 +66,8 @@ func (c *clientNetwork) getRouterPeerStatuses() map[string]routerPeerStatus {
 			continue
 		}
 		routePeerStatuses[r.ID] = routerPeerStatus{
-			connected: peerStatus.ConnStatus == peer.StatusConnected,
+			connected: peerStatus.ConnStatus == peer.StatusConnected || peerStatus.ConnStatus == peer.StatusStale,
+			stale:     peerStatus.ConnStatus == peer.StatusStale,
 			relayed:   peerStatus.Relayed,
 			direct:    peerStatus.Direct,
 		}
@@ -81,10 +84,19 @@ func (c *clientNetwork) getBestRouteFromStatuses(routePeerStatuses map[string]ro
 		currID = c.chosenRoute.ID
 	}
 
+	// the routes of the stale peers are kept only while no other routing peer has a fresh handshake
+	hasFreshRoute := false
+	for _, status := range routePeerStatuses {
+		if status.connected && !status.stale {
+			hasFreshRoute = true
+			break
+		}
+	}
+
 	for _, r := range c.routes {
 		tempScore := 0
 		peerStatus, found := routePeerStatuses[r.ID]
-		if !found || !peerStatus.connected {
+		if !found || !peerStatus.connected || (peerStatus.stale && hasFreshRoute) {
 			continue
 		}
 
@@ -160,7 +172,7 @@ func (c *clientNetwork) removeRouteFromWireguardPeer(peerKey string) error {
 	if err != nil {
 		return err
 	}
-	if state.ConnStatus != peer.StatusConnected {
+	if state.ConnStatus != peer.StatusConnected && state.ConnStatus != peer.StatusStale {
 		return nil
 	}
 
@@ -179,6 +179,70 @@ func TestGetBestrouteFromStatuses(t *testing.T) {
 			currentRoute:    nil,
 			expectedRouteID: "route1",
 		},
+		{
+			name: "stale peer is kept while no other peer is connected",
+			statuses: map[string]routerPeerStatus{
+				"route1": {
+					connected: true,
+					stale:     true,
+					direct:    true,
+				},
+				"route2": {
+					connected: false,
+				},
+			},
+			existingRoutes: map[string]*route.Route{
+				"route1": {
+					ID:     "route1",
+					Metric: route.MaxMetric,
+					Peer:   "peer1",
+				},
+				"route2": {
+					ID:     "route2",
+					Metric: route.MaxMetric,
+					Peer:   "peer2",
+				},
+			},
+			currentRoute: &route.Route{
+				ID:     "route1",
+				Metric: route.MaxMetric,
+				Peer:   "peer1",
+			},
+			expectedRouteID: "route1",
+		},
+		{
+			name: "stale peer is replaced by a peer with a fresh handshake",
+			statuses: map[string]routerPeerStatus{
+				"route1": {
+					connected: true,
+					stale:     true,
+					direct:    true,
+				},
+				"route2": {
+					connected: true,
+					relayed:   true,
+					direct:    true,
+				},
+			},
+			existingRoutes: map[string]*route.Route{
+				"route1": {
+					ID:     "route1",
+					Metric: 9000,
+					Peer:   "peer1",
+				},
+				"route2": {
+					ID:     "route2",
+					Metric: route.MaxMetric,
+					Peer:   "peer2",
+				},
+			},
+			currentRoute: &route.Route{
+				ID:     "route1",
+				Metric: 9000,
+				Peer:   "peer1",
+			},
+			expectedRouteID: "route2",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,240 @@
+//go:build !android && !ios
+
+package routemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// exclusionRefreshInterval is how often the default gateway is checked, the excluded routes follow it when the host
+// moves to another network
+const exclusionRefreshInterval = 30 * time.Second
+
+// excludedRoutes keeps the destinations excluded from the tunnel routed through the gateway the host used before the
+// client routes were added. The excluded routes are more specific than the client routes covering them, so they take
+// precedence.
+type excludedRoutes struct {
+	mux      sync.Mutex
+	prefixes []netip.Prefix
+	// overlay is the network of the WireGuard interface, a next hop in it belongs to a client route
+	overlay netip.Prefix
+	// nextHops are the next hops of the installed routes by their prefix
+	nextHops map[netip.Prefix]routeNextHop
+	// defaultGateways are the default gateways the routes were installed with
+	defaultGateways string
+}
+
+// routeNextHop is the gateway an excluded prefix is routed via, or the interface of an on-link prefix
+type routeNextHop struct {
+	// gateway is invalid for an on-link prefix
+	gateway netip.Addr
+	// intf is the interface the prefix is routed through
+	intf *net.Interface
+}
+
+func (h routeNextHop) String() string {
+	if h.gateway.IsValid() {
+		return h.gateway.String()
+	}
+	return h.intf.Name
+}
+
+func (h routeNextHop) add(prefix netip.Prefix) error {
+	if h.gateway.IsValid() {
+		return addToRouteTable(prefix, h.gateway.String())
+	}
+	return addToRouteTableOnLink(prefix, h.intf)
+}
+
+func (h routeNextHop) remove(prefix netip.Prefix) error {
+	if h.gateway.IsValid() {
+		return removeFromRouteTable(prefix, h.gateway.String())
+	}
+	return removeFromRouteTableOnLink(prefix, h.intf)
+}
+
+func newExcludedRoutes(prefixes []netip.Prefix, overlay netip.Prefix) *excludedRoutes {
+	return &excludedRoutes{
+		prefixes: prefixes,
+		overlay:  overlay.Masked(),
+		nextHops: make(map[netip.Prefix]routeNextHop),
+	}
+}
+
+// install adds the routes of the excluded prefixes through the current gateway of each prefix
+func (e *excludedRoutes) install() error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return e.installLocked()
+}
+
+func (e *excludedRoutes) installLocked() error {
+	e.defaultGateways = e.currentDefaultGateways()
+
+	var merr error
+	for _, prefix := range e.prefixes {
+		nextHop, err := e.nextHop(prefix)
+		if err != nil {
+			merr = errors.Join(merr, fmt.Errorf("find the gateway of the excluded route %s: %w", prefix, err))
+			continue
+		}
+
+		if err := nextHop.add(prefix); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("add the excluded route %s via %s: %w", prefix, nextHop, err))
+			continue
+		}
+		e.nextHops[prefix] = nextHop
+		log.Infof("excluded %s from the tunnel, it is routed via %s", prefix, nextHop)
+	}
+	return merr
+}
+
+// nextHop returns the gateway the prefix is routed through outside the tunnel, or the interface of an on-link prefix,
+// e.g. a printer on the local network. When a client route already covers the prefix the default route is used
+// instead.
+func (e *excludedRoutes) nextHop(prefix netip.Prefix) (routeNextHop, error) {
+	if nextHop, err := e.ribNextHop(prefix); err == nil {
+		return nextHop, nil
+	}
+
+	nextHop, err := e.ribNextHop(defaultRoute(prefix))
+	if err != nil {
+		return routeNextHop{}, err
+	}
+	// the client route hides the on-link route of a prefix on the network of the default interface
+	if nextHop.gateway.IsValid() && nextHop.intf != nil && onLink(nextHop.intf, prefix) {
+		return routeNextHop{intf: nextHop.intf}, nil
+	}
+	return nextHop, nil
+}
+
+// onLink reports whether the prefix is in a network of the interface
+func onLink(intf *net.Interface, prefix netip.Prefix) bool {
+	addrs, err := intf.Addrs()
+	if err != nil {
+		log.Debugf("failed to read the addresses of the interface %s: %v", intf.Name, err)
+		return false
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		network, err := netip.ParsePrefix(ipNet.String())
+		if err != nil {
+			continue
+		}
+		if network.Bits() <= prefix.Bits() && network.Masked().Contains(prefix.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ribNextHop returns the next hop of the route of the prefix, an error if the route goes through the tunnel
+func (e *excludedRoutes) ribNextHop(prefix netip.Prefix) (routeNextHop, error) {
+	intf, gateway, preferredSrc, err := getRIBNextHop(prefix)
+	if err != nil {
+		return routeNextHop{}, err
+	}
+
+	if gateway == nil {
+		src, ok := netip.AddrFromSlice(preferredSrc)
+		if intf == nil || !ok || e.overlay.Contains(src.Unmap()) {
+			return routeNextHop{}, errRouteNotFound
+		}
+		return routeNextHop{intf: intf}, nil
+	}
+
+	addr, ok := netip.AddrFromSlice(gateway)
+	if !ok || e.overlay.Contains(addr.Unmap()) {
+		return routeNextHop{}, errRouteNotFound
+	}
+	return routeNextHop{gateway: addr.Unmap(), intf: intf}, nil
+}
+
+// remove deletes the installed routes
+func (e *excludedRoutes) remove() error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return e.removeLocked()
+}
+
+func (e *excludedRoutes) removeLocked() error {
+	var merr error
+	for prefix, nextHop := range e.nextHops {
+		if err := nextHop.remove(prefix); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("remove the excluded route %s via %s: %w", prefix, nextHop, err))
+		}
+		delete(e.nextHops, prefix)
+	}
+	return merr
+}
+
+// refresh installs the routes again when the default gateway changed, e.g. the host joined another network
+func (e *excludedRoutes) refresh() {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	if e.currentDefaultGateways() == e.defaultGateways {
+		return
+	}
+
+	log.Infof("default gateway changed, installing the excluded routes again")
+	if err := e.removeLocked(); err != nil {
+		log.Warnf("failed to remove the excluded routes: %v", err)
+	}
+	if err := e.installLocked(); err != nil {
+		log.Warnf("failed to install the excluded routes: %v", err)
+	}
+}
+
+// watch refreshes the routes until the context is done
+func (e *excludedRoutes) watch(ctx context.Context) {
+	ticker := time.NewTicker(exclusionRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.refresh()
+		}
+	}
+}
+
+// currentDefaultGateways returns the default gateways of the address families of the excluded prefixes
+func (e *excludedRoutes) currentDefaultGateways() string {
+	var gateways string
+	seen := make(map[netip.Prefix]bool)
+	for _, prefix := range e.prefixes {
+		defaultPrefix := defaultRoute(prefix)
+		if seen[defaultPrefix] {
+			continue
+		}
+		seen[defaultPrefix] = true
+
+		if gateway, err := getExistingRIBRouteGateway(defaultPrefix); err == nil && gateway != nil {
+			gateways += gateway.String()
+		}
+		gateways += ","
+	}
+	return gateways
+}
+
+// defaultRoute returns the default route of the address family of the prefix
+func defaultRoute(prefix netip.Prefix) netip.Prefix {
+	if prefix.Addr().Unmap().Is4() {
+		return netip.MustParsePrefix("0.0.0.0/0")
+	}
+	return netip.MustParsePrefix("::/0")
+}
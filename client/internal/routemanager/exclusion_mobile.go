@@ -0,0 +1,33 @@
+//go:build android || ios
+
+package routemanager
+
+import (
+	"context"
+	"net/netip"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// excludedRoutes is a no-op on the mobile platforms, the routes of the tunnel are set up by the VPN service of the OS
+type excludedRoutes struct {
+	prefixes []netip.Prefix
+}
+
+func newExcludedRoutes(prefixes []netip.Prefix, _ netip.Prefix) *excludedRoutes {
+	return &excludedRoutes{prefixes: prefixes}
+}
+
+func (e *excludedRoutes) install() error {
+	if len(e.prefixes) > 0 {
+		log.Warnf("excluding routes from the tunnel is not supported on this platform, ignoring %v", e.prefixes)
+	}
+	return nil
+}
+
+func (e *excludedRoutes) remove() error {
+	return nil
+}
+
+func (e *excludedRoutes) watch(context.Context) {
+}
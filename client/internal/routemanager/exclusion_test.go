@@ -0,0 +1,178 @@
+//go:build !android && !ios
+
+package routemanager
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/pion/transport/v3/stdnet"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/iface"
+)
+
+func TestExcludedRoutes(t *testing.T) {
+	defaultGateway, err := getExistingRIBRouteGateway(netip.MustParsePrefix("0.0.0.0/0"))
+	require.NoError(t, err, "should find the default gateway")
+
+	testCases := []struct {
+		name string
+		// clientRoute is the client route covering the excluded prefix
+		clientRoute netip.Prefix
+		excluded    netip.Prefix
+		// clientRouteFirst adds the client route before the exclusion, so the next hop falls back to the default gateway
+		clientRouteFirst bool
+	}{
+		{
+			name:        "Host Excluded From A Client Route",
+			clientRoute: netip.MustParsePrefix("198.51.100.0/24"),
+			excluded:    netip.MustParsePrefix("198.51.100.10/32"),
+		},
+		{
+			name:             "Management Server Excluded After A Client Route Captured It",
+			clientRoute:      netip.MustParsePrefix("203.0.113.0/24"),
+			excluded:         netip.MustParsePrefix("203.0.113.20/32"),
+			clientRouteFirst: true,
+		},
+	}
+
+	for n, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			peerPrivateKey, _ := wgtypes.GeneratePrivateKey()
+			newNet, err := stdnet.NewNet()
+			require.NoError(t, err)
+			wgInterface, err := iface.NewWGIFace(fmt.Sprintf("utun54%d", n), "100.65.76.2/24", 33100, peerPrivateKey.String(), iface.DefaultMTU, newNet, nil)
+			require.NoError(t, err, "should create testing WGIface interface")
+			defer wgInterface.Close()
+			require.NoError(t, wgInterface.Create(), "should create testing wireguard interface")
+
+			wgIP := wgInterface.Address().IP.String()
+			overlay := netip.MustParsePrefix(wgInterface.Address().Network.String())
+			excluded := newExcludedRoutes([]netip.Prefix{testCase.excluded}, overlay)
+
+			if testCase.clientRouteFirst {
+				require.NoError(t, addToRouteTableIfNoExists(testCase.clientRoute, wgIP))
+			}
+			require.NoError(t, excluded.install(), "should install the excluded route")
+			if !testCase.clientRouteFirst {
+				require.NoError(t, addToRouteTableIfNoExists(testCase.clientRoute, wgIP))
+			}
+			defer func() {
+				_ = removeFromRouteTableIfNonSystem(testCase.clientRoute, wgIP)
+			}()
+
+			gateway, err := getExistingRIBRouteGateway(testCase.excluded)
+			require.NoError(t, err)
+			require.Equal(t, defaultGateway.String(), gateway.String(), "excluded route should bypass the tunnel")
+
+			gateway, err = getExistingRIBRouteGateway(netip.PrefixFrom(testCase.clientRoute.Addr().Next(), 32))
+			require.NoError(t, err)
+			require.Equal(t, wgIP, gateway.String(), "the rest of the client route should go through the tunnel")
+
+			require.NoError(t, excluded.remove(), "should remove the excluded route")
+
+			gateway, err = getExistingRIBRouteGateway(testCase.excluded)
+			require.NoError(t, err)
+			require.Equal(t, wgIP, gateway.String(), "removed excluded route should go through the tunnel again")
+		})
+	}
+}
+
+func TestExcludedRoutes_RefreshKeepsGateway(t *testing.T) {
+	excluded := newExcludedRoutes([]netip.Prefix{netip.MustParsePrefix("198.51.100.30/32")}, netip.MustParsePrefix("100.65.77.0/24"))
+	require.NoError(t, excluded.install())
+	defer func() {
+		require.NoError(t, excluded.remove())
+	}()
+
+	installed := excluded.nextHops[netip.MustParsePrefix("198.51.100.30/32")]
+	require.NotEmpty(t, installed)
+
+	excluded.refresh()
+	require.Equal(t, installed, excluded.nextHops[netip.MustParsePrefix("198.51.100.30/32")], "unchanged gateway should keep the route")
+}
+
+func TestExcludedRoutes_ControlPlaneReachable(t *testing.T) {
+	defaultGateway, err := getExistingRIBRouteGateway(netip.MustParsePrefix("0.0.0.0/0"))
+	require.NoError(t, err, "should find the default gateway")
+	defaultIntf, _, hostIP, err := getRIBNextHop(netip.MustParsePrefix("0.0.0.0/0"))
+	require.NoError(t, err, "should find the default interface")
+
+	// the Signal server is on the local network of the host, the Management server behind the default gateway
+	var localNetwork netip.Prefix
+	addrs, err := defaultIntf.Addrs()
+	require.NoError(t, err)
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(hostIP) {
+			localNetwork = netip.MustParsePrefix(ipNet.String()).Masked()
+		}
+	}
+	require.True(t, localNetwork.IsValid(), "should find the local network")
+	if localNetwork.Bits() > 29 {
+		t.Skipf("the local network %s is too small", localNetwork)
+	}
+	// the upper half of the local network is captured by a client route, it mustn't hold the host or the gateway
+	capturedLocal := netip.PrefixFrom(lastAddr(localNetwork), localNetwork.Bits()+1).Masked()
+	gatewayAddr, _ := netip.AddrFromSlice(defaultGateway)
+	hostAddr, _ := netip.AddrFromSlice(hostIP)
+	if capturedLocal.Contains(gatewayAddr.Unmap()) || capturedLocal.Contains(hostAddr.Unmap()) {
+		t.Skipf("the host or the gateway is in the upper half of the local network %s", localNetwork)
+	}
+	signal := netip.PrefixFrom(lastAddr(localNetwork).Prev(), 32)
+	management := netip.MustParsePrefix("203.0.113.40/32")
+
+	peerPrivateKey, _ := wgtypes.GeneratePrivateKey()
+	newNet, err := stdnet.NewNet()
+	require.NoError(t, err)
+	wgInterface, err := iface.NewWGIFace("utun549", "100.65.78.2/24", 33100, peerPrivateKey.String(), iface.DefaultMTU, newNet, nil)
+	require.NoError(t, err, "should create testing WGIface interface")
+	defer wgInterface.Close()
+	require.NoError(t, wgInterface.Create(), "should create testing wireguard interface")
+
+	wgIP := wgInterface.Address().IP.String()
+	overlay := netip.MustParsePrefix(wgInterface.Address().Network.String())
+
+	// the client routes capture the Management and the Signal servers
+	clientRoutes := []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24"), capturedLocal}
+	for _, clientRoute := range clientRoutes {
+		require.NoError(t, addToRouteTableIfNoExists(clientRoute, wgIP))
+		defer func(clientRoute netip.Prefix) {
+			_ = removeFromRouteTableIfNonSystem(clientRoute, wgIP)
+		}(clientRoute)
+	}
+
+	excluded := newExcludedRoutes([]netip.Prefix{management, signal}, overlay)
+	require.NoError(t, excluded.install(), "should install the excluded routes")
+	defer func() {
+		require.NoError(t, excluded.remove(), "should remove the excluded routes")
+	}()
+
+	gateway, err := getExistingRIBRouteGateway(management)
+	require.NoError(t, err)
+	require.Equal(t, defaultGateway.String(), gateway.String(), "the Management server should be reached through the default gateway")
+
+	intf, gateway, _, err := getRIBNextHop(signal)
+	require.NoError(t, err)
+	require.Nil(t, gateway, "the Signal server should be reached on the local network, not via a gateway")
+	require.Equal(t, defaultIntf.Name, intf.Name, "the Signal server should be reached through the local interface")
+
+	gateway, err = getExistingRIBRouteGateway(netip.PrefixFrom(signal.Addr().Prev(), 32))
+	require.NoError(t, err)
+	require.Equal(t, wgIP, gateway.String(), "the rest of the client route should go through the tunnel")
+}
+
+// lastAddr returns the last address of the prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Masked().Addr().As4()
+	hostBits := 32 - prefix.Bits()
+	for i := 3; i >= 0 && hostBits > 0; i-- {
+		bits := min(hostBits, 8)
+		addr[i] |= byte(1<<bits - 1)
+		hostBits -= bits
+	}
+	return netip.AddrFrom4(addr)
+}
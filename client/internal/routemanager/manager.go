@@ -2,6 +2,7 @@ package routemanager
 
 import (
 	"context"
+	"net/netip"
 	"runtime"
 	"sync"
 
@@ -21,6 +22,8 @@ type Manager interface {
 	SetRouteChangeListener(listener listener.NetworkChangeListener)
 	InitialRouteRange() []string
 	EnableServerRouter(firewall firewall.Manager) error
+	EnableRouteIsolation(fwmark int) error
+	ExcludeRoutes(prefixes []netip.Prefix) error
 	Stop()
 }
 
@@ -35,6 +38,8 @@ type DefaultManager struct {
 	wgInterface    *iface.WGIface
 	pubKey         string
 	notifier       *notifier
+	// excluded are the routes of the destinations bypassing the tunnel, nil if none are excluded
+	excluded *excludedRoutes
 }
 
 func NewManager(ctx context.Context, pubKey string, wgInterface *iface.WGIface, statusRecorder *peer.Status, initialRoutes []*route.Route) *DefaultManager {
@@ -65,12 +70,48 @@ func (m *DefaultManager) EnableServerRouter(firewall firewall.Manager) error {
 	return nil
 }
 
+// EnableRouteIsolation installs the client routes to a dedicated routing table used by the traffic without the
+// WireGuard firewall mark. It must be called before the first routes are applied.
+func (m *DefaultManager) EnableRouteIsolation(fwmark int) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return setupRouting(fwmark)
+}
+
+// ExcludeRoutes routes the prefixes outside the tunnel through the gateway the host uses for them, taking precedence
+// over the client routes. The routes follow the default gateway when it changes and are removed when the manager stops.
+// It must be called after EnableRouteIsolation, so the routes are installed to the table of the client routes.
+func (m *DefaultManager) ExcludeRoutes(prefixes []netip.Prefix) error {
+	if len(prefixes) == 0 {
+		return nil
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	var overlay netip.Prefix
+	if network := m.wgInterface.Address().Network; network != nil {
+		overlay, _ = netip.ParsePrefix(network.String())
+	}
+	m.excluded = newExcludedRoutes(prefixes, overlay)
+	go m.excluded.watch(m.ctx)
+	return m.excluded.install()
+}
+
 // Stop stops the manager watchers and clean firewall rules
 func (m *DefaultManager) Stop() {
 	m.stop()
 	if m.serverRouter != nil {
 		m.serverRouter.cleanUp()
 	}
+	if m.excluded != nil {
+		if err := m.excluded.remove(); err != nil {
+			log.Errorf("failed to remove the excluded routes: %v", err)
+		}
+	}
+	if err := cleanupRouting(); err != nil {
+		log.Errorf("failed to clean up the routing table: %v", err)
+	}
 	m.ctx = nil
 }
 
@@ -3,6 +3,7 @@ package routemanager
 import (
 	"context"
 	"fmt"
+	"net/netip"
 
 	firewall "github.com/netbirdio/netbird/client/firewall/manager"
 	"github.com/netbirdio/netbird/client/internal/listener"
@@ -42,6 +43,16 @@ func (m *MockManager) EnableServerRouter(firewall firewall.Manager) error {
 	panic("implement me")
 }
 
+// EnableRouteIsolation mock implementation of EnableRouteIsolation from Manager interface
+func (m *MockManager) EnableRouteIsolation(fwmark int) error {
+	return nil
+}
+
+// ExcludeRoutes mock implementation of ExcludeRoutes from Manager interface
+func (m *MockManager) ExcludeRoutes(prefixes []netip.Prefix) error {
+	return nil
+}
+
 // Stop mock implementation of Stop from Manager interface
 func (m *MockManager) Stop() {
 	if m.StopFunc != nil {
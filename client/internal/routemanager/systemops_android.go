@@ -2,6 +2,8 @@ package routemanager
 
 import (
 	"net/netip"
+
+	log "github.com/sirupsen/logrus"
 )
 
 func addToRouteTableIfNoExists(prefix netip.Prefix, addr string) error {
@@ -11,3 +13,12 @@ func addToRouteTableIfNoExists(prefix netip.Prefix, addr string) error {
 func removeFromRouteTableIfNonSystem(prefix netip.Prefix, addr string) error {
 	return nil
 }
+
+func setupRouting(fwmark int) error {
+	log.Warnf("routing table isolation with fwmark %d is not supported on android", fwmark)
+	return nil
+}
+
+func cleanupRouting() error {
+	return nil
+}
@@ -3,12 +3,15 @@
 package routemanager
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"net/netip"
 	"os"
 	"syscall"
 	"unsafe"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 )
 
@@ -30,6 +33,97 @@ type routeInfoInMemory struct {
 
 const ipv4ForwardingPath = "/proc/sys/net/ipv4/ip_forward"
 
+const (
+	// suppressMainRulePriority and routingTableRulePriority are the priorities of the ip rules isolating the client
+	// routes in their own table. They are evaluated before the main table rule (32766) but after the local table rule,
+	// so the traffic to the local addresses, e.g. the DNS service bound to the interface address, stays local.
+	suppressMainRulePriority = 32764
+	routingTableRulePriority = 32765
+)
+
+// routingTable is the routing table the client routes are installed to, the main table unless isolated by setupRouting
+var routingTable = syscall.RT_TABLE_MAIN
+
+// setupRouting isolates the client routes in the routing table numbered after the WireGuard firewall mark.
+// The packets without the mark are looked up in the table, so the traffic encrypted by WireGuard,
+// which is marked, never loops back into the tunnel. The main table is still looked up first for all
+// its routes but the default one, the same way as wg-quick does it.
+func setupRouting(fwmark int) error {
+	// remove the rules left over by a client which didn't stop cleanly
+	for _, rule := range routingRules(fwmark) {
+		_ = netlink.RuleDel(rule)
+	}
+
+	var added []*netlink.Rule
+	for _, rule := range routingRules(fwmark) {
+		if err := netlink.RuleAdd(rule); err != nil && !errors.Is(err, syscall.EEXIST) {
+			if rule.Family == netlink.FAMILY_V6 {
+				log.Warnf("failed to add the IPv6 ip rule of routing table %d, the IPv6 routes aren't isolated: %v", fwmark, err)
+				continue
+			}
+			for _, r := range added {
+				_ = netlink.RuleDel(r)
+			}
+			return fmt.Errorf("add ip rule for routing table %d: %w", fwmark, err)
+		}
+		added = append(added, rule)
+	}
+
+	routingTable = fwmark
+	log.Infof("client routes are isolated in routing table %d", fwmark)
+	return nil
+}
+
+// cleanupRouting removes the ip rules added by setupRouting and the routes left in the routing table
+func cleanupRouting() error {
+	if routingTable == syscall.RT_TABLE_MAIN {
+		return nil
+	}
+	fwmark := routingTable
+	routingTable = syscall.RT_TABLE_MAIN
+
+	var merr error
+	for _, rule := range routingRules(fwmark) {
+		if err := netlink.RuleDel(rule); err != nil && !errors.Is(err, syscall.ENOENT) {
+			merr = errors.Join(merr, fmt.Errorf("delete ip rule for routing table %d: %w", fwmark, err))
+		}
+	}
+
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: fwmark}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return errors.Join(merr, fmt.Errorf("list routes of routing table %d: %w", fwmark, err))
+	}
+	for i := range routes {
+		if err := netlink.RouteDel(&routes[i]); err != nil && !errors.Is(err, syscall.ESRCH) {
+			merr = errors.Join(merr, fmt.Errorf("delete route %s from routing table %d: %w", routes[i].Dst, fwmark, err))
+		}
+	}
+	return merr
+}
+
+// routingRules returns the ip rules of the routing table isolation for both address families:
+// the main table without its default route first, then the table for the packets without the firewall mark
+func routingRules(fwmark int) []*netlink.Rule {
+	var rules []*netlink.Rule
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		suppressMain := netlink.NewRule()
+		suppressMain.Family = family
+		suppressMain.Table = syscall.RT_TABLE_MAIN
+		suppressMain.SuppressPrefixlen = 0
+		suppressMain.Priority = suppressMainRulePriority
+
+		notMarked := netlink.NewRule()
+		notMarked.Family = family
+		notMarked.Table = fwmark
+		notMarked.Mark = fwmark
+		notMarked.Invert = true
+		notMarked.Priority = routingTableRulePriority
+
+		rules = append(rules, suppressMain, notMarked)
+	}
+	return rules
+}
+
 func addToRouteTable(prefix netip.Prefix, addr string) error {
 	_, ipNet, err := net.ParseCIDR(prefix.String())
 	if err != nil {
@@ -50,6 +144,7 @@ func addToRouteTable(prefix netip.Prefix, addr string) error {
 		Scope: netlink.SCOPE_UNIVERSE,
 		Dst:   ipNet,
 		Gw:    ip,
+		Table: routingTable,
 	}
 
 	err = netlink.RouteAdd(route)
@@ -60,6 +155,40 @@ func addToRouteTable(prefix netip.Prefix, addr string) error {
 	return nil
 }
 
+// addToRouteTableOnLink routes the prefix directly through the interface, for the destinations on the local network
+func addToRouteTableOnLink(prefix netip.Prefix, intf *net.Interface) error {
+	_, ipNet, err := net.ParseCIDR(prefix.String())
+	if err != nil {
+		return err
+	}
+
+	route := &netlink.Route{
+		Scope:     netlink.SCOPE_LINK,
+		Dst:       ipNet,
+		LinkIndex: intf.Index,
+		Table:     routingTable,
+	}
+
+	return netlink.RouteAdd(route)
+}
+
+// removeFromRouteTableOnLink removes the route of the prefix through the interface
+func removeFromRouteTableOnLink(prefix netip.Prefix, intf *net.Interface) error {
+	_, ipNet, err := net.ParseCIDR(prefix.String())
+	if err != nil {
+		return err
+	}
+
+	route := &netlink.Route{
+		Scope:     netlink.SCOPE_LINK,
+		Dst:       ipNet,
+		LinkIndex: intf.Index,
+		Table:     routingTable,
+	}
+
+	return netlink.RouteDel(route)
+}
+
 func removeFromRouteTable(prefix netip.Prefix, addr string) error {
 	_, ipNet, err := net.ParseCIDR(prefix.String())
 	if err != nil {
@@ -80,6 +209,7 @@ func removeFromRouteTable(prefix netip.Prefix, addr string) error {
 		Scope: netlink.SCOPE_UNIVERSE,
 		Dst:   ipNet,
 		Gw:    ip,
+		Table: routingTable,
 	}
 
 	err = netlink.RouteDel(route)
@@ -0,0 +1,32 @@
+//go:build !android
+
+package routemanager
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+)
+
+func TestRoutingRules(t *testing.T) {
+	rules := routingRules(51820)
+	require.Len(t, rules, 4, "should have a suppress and a table rule per address family")
+
+	for i, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		suppressMain := rules[i*2]
+		assert.Equal(t, family, suppressMain.Family)
+		assert.Equal(t, syscall.RT_TABLE_MAIN, suppressMain.Table)
+		assert.Equal(t, 0, suppressMain.SuppressPrefixlen, "main table should be looked up for all but the default route")
+		assert.Equal(t, suppressMainRulePriority, suppressMain.Priority)
+
+		notMarked := rules[i*2+1]
+		assert.Equal(t, family, notMarked.Family)
+		assert.Equal(t, 51820, notMarked.Table)
+		assert.Equal(t, 51820, notMarked.Mark)
+		assert.True(t, notMarked.Invert, "table should be looked up by the packets without the mark only")
+		assert.Equal(t, routingTableRulePriority, notMarked.Priority)
+	}
+}
@@ -101,6 +101,21 @@ func removeFromRouteTableIfNonSystem(prefix netip.Prefix, addr string) error {
 	return removeFromRouteTable(prefix, addr)
 }
 
+// getRIBNextHop returns the interface and the gateway of the route of the prefix and the source address the host
+// prefers for it, the gateway is nil for an on-link destination
+func getRIBNextHop(prefix netip.Prefix) (*net.Interface, net.IP, net.IP, error) {
+	r, err := netroute.New()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	intf, gateway, preferredSrc, err := r.Route(prefix.Addr().AsSlice())
+	if err != nil {
+		log.Errorf("getting routes returned an error: %v", err)
+		return nil, nil, nil, errRouteNotFound
+	}
+	return intf, gateway, preferredSrc, nil
+}
+
 func getExistingRIBRouteGateway(prefix netip.Prefix) (net.IP, error) {
 	r, err := netroute.New()
 	if err != nil {
@@ -4,9 +4,11 @@
 package routemanager
 
 import (
+	"net"
 	"net/netip"
 	"os/exec"
 	"runtime"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -35,7 +37,47 @@ func removeFromRouteTable(prefix netip.Prefix, addr string) error {
 	return nil
 }
 
+// addToRouteTableOnLink routes the prefix directly through the interface, for the destinations on the local network
+func addToRouteTableOnLink(prefix netip.Prefix, intf *net.Interface) error {
+	args := []string{"add", prefix.String(), "-interface", intf.Name}
+	if runtime.GOOS == "windows" {
+		// the unspecified gateway makes the route on-link
+		args = []string{"add", prefix.String(), "0.0.0.0", "IF", strconv.Itoa(intf.Index)}
+	}
+	cmd := exec.Command("route", args...)
+	out, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	log.Debugf(string(out))
+	return nil
+}
+
+// removeFromRouteTableOnLink removes the route of the prefix through the interface
+func removeFromRouteTableOnLink(prefix netip.Prefix, intf *net.Interface) error {
+	args := []string{"delete", prefix.String()}
+	if runtime.GOOS == "darwin" {
+		args = append(args, "-interface", intf.Name)
+	}
+	cmd := exec.Command("route", args...)
+	out, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	log.Debugf(string(out))
+	return nil
+}
+
 func enableIPForwarding() error {
 	log.Infof("enable IP forwarding is not implemented on %s", runtime.GOOS)
 	return nil
 }
+
+func setupRouting(fwmark int) error {
+	log.Warnf("routing table isolation with fwmark %d is not supported on %s", fwmark, runtime.GOOS)
+	return nil
+}
+
+func cleanupRouting() error {
+	return nil
+}
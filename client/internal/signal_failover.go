@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+
+	"golang.org/x/exp/slices"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+	signal "github.com/netbirdio/netbird/signal/client"
+)
+
+// parseSignalURL parses a Signal Service URL, e.g. https://signal.netbird.io:443
+func parseSignalURL(signalURL string) (signal.Endpoint, error) {
+	u, err := url.Parse(signalURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.Port() == "" {
+		return signal.Endpoint{}, fmt.Errorf("invalid Signal Service URL %q, expecting [http|https]://[host]:[port]", signalURL)
+	}
+	return signal.Endpoint{Addr: u.Host, TLSEnabled: u.Scheme == "https"}, nil
+}
+
+// signalEndpoints returns the Signal Service of the Management Service followed by the fallback ones of the config,
+// without duplicates
+func signalEndpoints(primary *mgmProto.HostConfig, fallbackURLs []string) ([]signal.Endpoint, error) {
+	endpoints := []signal.Endpoint{{
+		Addr:       primary.GetUri(),
+		TLSEnabled: primary.GetProtocol() == mgmProto.HostConfig_HTTPS,
+	}}
+	for _, fallbackURL := range fallbackURLs {
+		endpoint, err := parseSignalURL(fallbackURL)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(endpoints, endpoint) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+// validateSignalFallbackURLs checks the fallback Signal Service URLs of the config are valid
+func validateSignalFallbackURLs(config *Config) error {
+	for _, fallbackURL := range config.SignalFallbackURLs {
+		if _, err := parseSignalURL(fallbackURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renegotiatePeers restarts the negotiation of the peer connections not established yet, the offers, the answers and
+// the candidates sent through the previous Signal Service might have been lost. The established connections are kept.
+func (e *Engine) renegotiatePeers() {
+	e.syncMsgMux.Lock()
+	defer e.syncMsgMux.Unlock()
+
+	for _, conn := range e.peerConns {
+		conn.Renegotiate()
+	}
+}
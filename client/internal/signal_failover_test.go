@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+	signal "github.com/netbirdio/netbird/signal/client"
+)
+
+func TestSignalEndpoints(t *testing.T) {
+	primary := &mgmProto.HostConfig{Uri: "signal.netbird.io:443", Protocol: mgmProto.HostConfig_HTTPS}
+
+	endpoints, err := signalEndpoints(primary, []string{
+		"https://signal2.example.com:443",
+		"http://10.0.0.5:10000",
+		"https://signal.netbird.io:443",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []signal.Endpoint{
+		{Addr: "signal.netbird.io:443", TLSEnabled: true},
+		{Addr: "signal2.example.com:443", TLSEnabled: true},
+		{Addr: "10.0.0.5:10000"},
+	}, endpoints, "the Signal Service of the Management Service should be first and not repeated")
+
+	for _, invalid := range []string{"signal2.example.com:443", "https://signal2.example.com", "ftp://signal2.example.com:21"} {
+		_, err = signalEndpoints(primary, []string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
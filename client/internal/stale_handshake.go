@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"sync"
+	"time"
+
+	"github.com/netbirdio/netbird/iface"
+)
+
+// DefaultStaleHandshakeThreshold is used when the threshold isn't configured. WireGuard renews the session of an
+// active peer every 2 minutes and the persistent keepalive keeps idle peers active, so a healthy peer always
+// handshakes within the 3 minutes WireGuard rejects an old session after.
+const DefaultStaleHandshakeThreshold = 3 * time.Minute
+
+// staleHandshakeDetector detects the connected peers whose WireGuard session stopped working, e.g. because the
+// remote process hangs while the peer is still reported as connected.
+// A peer is stale when both its last handshake and the last received traffic are older than the threshold.
+// The received traffic includes the keepalive packets, so idle peers with a late handshake are not reported.
+type staleHandshakeDetector struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	peers     map[string]*handshakeActivity
+}
+
+type handshakeActivity struct {
+	rxBytes      int64
+	lastActivity time.Time
+	stale        bool
+}
+
+func newStaleHandshakeDetector(threshold time.Duration) *staleHandshakeDetector {
+	return &staleHandshakeDetector{
+		threshold: threshold,
+		peers:     make(map[string]*handshakeActivity),
+	}
+}
+
+// update records the WireGuard stats of a connected peer and returns true if the peer is stale.
+// A zero threshold disables the detection.
+func (d *staleHandshakeDetector) update(peerKey string, stats iface.WGStats, now time.Time) bool {
+	if d.threshold <= 0 {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	activity, ok := d.peers[peerKey]
+	if !ok {
+		// give the newly connected peers the full threshold to handshake
+		activity = &handshakeActivity{rxBytes: stats.RxBytes, lastActivity: now}
+		d.peers[peerKey] = activity
+	}
+
+	// the counters are reset when the peer is re-added to the interface, so any change means received traffic
+	if stats.RxBytes != activity.rxBytes {
+		activity.rxBytes = stats.RxBytes
+		activity.lastActivity = now
+	}
+
+	handshakeStale := now.Sub(stats.LastHandshake) > d.threshold
+	activity.stale = handshakeStale && now.Sub(activity.lastActivity) > d.threshold
+	return activity.stale
+}
+
+// isStale returns the result of the last update of the peer
+func (d *staleHandshakeDetector) isStale(peerKey string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	activity, ok := d.peers[peerKey]
+	return ok && activity.stale
+}
+
+// remove forgets the peer, e.g. when it isn't connected anymore
+func (d *staleHandshakeDetector) remove(peerKey string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.peers, peerKey)
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/iface"
+)
+
+func TestStaleHandshakeDetector(t *testing.T) {
+	threshold := 3 * time.Minute
+	start := time.Now()
+
+	t.Run("recent handshake", func(t *testing.T) {
+		d := newStaleHandshakeDetector(threshold)
+		assert.False(t, d.update("peer", iface.WGStats{LastHandshake: start}, start))
+		assert.False(t, d.update("peer", iface.WGStats{LastHandshake: start}, start.Add(threshold)))
+	})
+
+	t.Run("stale handshake without traffic", func(t *testing.T) {
+		d := newStaleHandshakeDetector(threshold)
+		stats := iface.WGStats{LastHandshake: start, RxBytes: 100}
+		assert.False(t, d.update("peer", stats, start))
+		assert.False(t, d.update("peer", stats, start.Add(threshold)))
+		assert.True(t, d.update("peer", stats, start.Add(threshold+time.Second)))
+		assert.True(t, d.isStale("peer"))
+	})
+
+	t.Run("idle peer receiving keepalives", func(t *testing.T) {
+		d := newStaleHandshakeDetector(threshold)
+		stats := iface.WGStats{LastHandshake: start, RxBytes: 100}
+		assert.False(t, d.update("peer", stats, start))
+
+		// the keepalive packets are counted as received traffic
+		stats.RxBytes += 32
+		assert.False(t, d.update("peer", stats, start.Add(threshold)))
+		assert.False(t, d.update("peer", stats, start.Add(threshold+time.Second)))
+	})
+
+	t.Run("peer recovers", func(t *testing.T) {
+		d := newStaleHandshakeDetector(threshold)
+		stats := iface.WGStats{LastHandshake: start, RxBytes: 100}
+		d.update("peer", stats, start)
+		assert.True(t, d.update("peer", stats, start.Add(2*threshold)))
+
+		now := start.Add(2*threshold + time.Second)
+		assert.False(t, d.update("peer", iface.WGStats{LastHandshake: now, RxBytes: 200}, now))
+		assert.False(t, d.isStale("peer"))
+	})
+
+	t.Run("never handshaked peer gets the full threshold", func(t *testing.T) {
+		d := newStaleHandshakeDetector(threshold)
+		assert.False(t, d.update("peer", iface.WGStats{}, start))
+		assert.True(t, d.update("peer", iface.WGStats{}, start.Add(threshold+time.Second)))
+
+		d.remove("peer")
+		assert.False(t, d.isStale("peer"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		d := newStaleHandshakeDetector(0)
+		assert.False(t, d.update("peer", iface.WGStats{}, start))
+		assert.False(t, d.update("peer", iface.WGStats{}, start.Add(time.Hour)))
+	})
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"net/netip"
+
+	log "github.com/sirupsen/logrus"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/route"
+)
+
+// toRoutesWithStaticRoutes converts the shared and the static routes of the network map to the routes of the route
+// manager. A static route overlapping with the network of the WireGuard interface is dropped: the remote peers and the
+// DNS resolver of the client are reached through it. A static route the client installs takes precedence over the
+// shared routes of the same network, unless the client routes the network itself.
+func toRoutesWithStaticRoutes(protoRoutes []*mgmProto.Route, overlay netip.Prefix, ownKey string) []*route.Route {
+	var sharedRoutes, staticRoutes []*mgmProto.Route
+	for _, protoRoute := range protoRoutes {
+		if protoRoute.GetStatic() {
+			staticRoutes = append(staticRoutes, protoRoute)
+			continue
+		}
+		sharedRoutes = append(sharedRoutes, protoRoute)
+	}
+
+	routes := make([]*route.Route, 0, len(protoRoutes))
+	staticNetworks := make(map[netip.Prefix]struct{})
+	for _, r := range toRoutes(staticRoutes) {
+		if overlay.IsValid() && r.Network.Overlaps(overlay) {
+			log.Warnf("skipping static route %s, its network %s overlaps with the network %s of the interface",
+				r.ID, r.Network, overlay)
+			continue
+		}
+		if r.Peer != ownKey {
+			staticNetworks[r.Network] = struct{}{}
+		}
+		routes = append(routes, r)
+	}
+
+	for _, r := range toRoutes(sharedRoutes) {
+		if _, ok := staticNetworks[r.Network]; ok && r.Peer != ownKey {
+			log.Debugf("route %s to %s is replaced by a static route of the same network", r.ID, r.Network)
+			continue
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mgmProto "github.com/netbirdio/netbird/management/proto"
+)
+
+func TestToRoutesWithStaticRoutes(t *testing.T) {
+	overlay := netip.MustParsePrefix("100.64.0.0/16")
+	protoRoutes := []*mgmProto.Route{
+		{ID: "shared-billing", NetID: "billing", Network: "10.0.8.0/24", Peer: "router-a"},
+		{ID: "shared-office", NetID: "office", Network: "192.168.1.0/24", Peer: "router-a"},
+		{ID: "shared-own", NetID: "own", Network: "10.0.9.0/24", Peer: "own-key"},
+		{ID: "static-billing", NetID: "static-billing", Network: "10.0.8.0/24", Peer: "router-b", Static: true},
+		{ID: "static-overlay", NetID: "static-overlay", Network: "100.64.0.254/32", Peer: "router-b", Static: true},
+		{ID: "static-routed", NetID: "static-routed", Network: "10.0.9.0/24", Peer: "own-key", Static: true},
+	}
+
+	routes := toRoutesWithStaticRoutes(protoRoutes, overlay, "own-key")
+
+	var ids []string
+	for _, r := range routes {
+		ids = append(ids, r.ID)
+	}
+	assert.ElementsMatch(t, []string{"shared-office", "shared-own", "static-billing", "static-routed"}, ids,
+		"the static routes should replace the shared routes of the same network and skip the overlay network")
+
+	routes = toRoutesWithStaticRoutes(protoRoutes[:2], netip.Prefix{}, "own-key")
+	assert.Len(t, routes, 2, "the shared routes should be kept without static routes")
+}
@@ -0,0 +1,98 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"time"
+
+	prometheus2 "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+)
+
+const (
+	defaultEndpoint = "/metrics"
+	shutdownTimeout = 5 * time.Second
+)
+
+// Exporter exposes the client metrics in the Prometheus format https://prometheus.io/ on the local host.
+// The metrics are based on OpenTelemetry https://opentelemetry.io/ and are read from the status recorder on every scrape.
+type Exporter struct {
+	provider *metric.MeterProvider
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewExporter creates the client metrics and exposes them on the given port of the loopback interface
+func NewExporter(port int, statusRecorder *peer.Status) (*Exporter, error) {
+	// a dedicated registry keeps the metrics of the previous client runs out of the new exporter
+	registry := prometheus2.NewRegistry()
+	exporter, err := prometheus.New(prometheus.WithRegisterer(registry), prometheus.WithoutUnits())
+	if err != nil {
+		return nil, err
+	}
+
+	provider := metric.NewMeterProvider(metric.WithReader(exporter))
+	pkg := reflect.TypeOf(defaultEndpoint).PkgPath()
+	meter := provider.Meter(pkg)
+
+	err = registerStatusMetrics(meter, statusRecorder)
+	if err != nil {
+		_ = provider.Shutdown(context.Background())
+		return nil, fmt.Errorf("failed registering metrics: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		_ = provider.Shutdown(context.Background())
+		return nil, fmt.Errorf("failed listening for metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(defaultEndpoint, promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("failed serving metrics: %v", err)
+		}
+	}()
+
+	log.Infof("exposing client metrics on http://%s%s", listener.Addr().String(), defaultEndpoint)
+
+	return &Exporter{
+		provider: provider,
+		server:   server,
+		listener: listener,
+	}, nil
+}
+
+// Address returns the address the metrics are exposed on
+func (e *Exporter) Address() string {
+	return e.listener.Addr().String()
+}
+
+// Close stops exposing the metrics and releases the listener
+func (e *Exporter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	serverErr := e.server.Shutdown(ctx)
+	providerErr := e.provider.Shutdown(ctx)
+	if serverErr != nil {
+		return fmt.Errorf("failed stopping metrics server: %w", serverErr)
+	}
+	return providerErr
+}
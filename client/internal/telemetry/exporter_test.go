@@ -0,0 +1,77 @@
+package telemetry
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+)
+
+func TestExporter(t *testing.T) {
+	statusRecorder := peer.NewRecorder("https://mgm")
+	for key, fqdn := range map[string]string{"key-a": "peer-a.netbird.cloud", "key-b": "peer-b.netbird.cloud", "key-c": "peer-c.netbird.cloud", "key-d": "peer-d.netbird.cloud"} {
+		require.NoError(t, statusRecorder.AddPeer(key, fqdn))
+	}
+	require.NoError(t, statusRecorder.UpdatePeerState(peer.State{PubKey: "key-a", IP: "100.64.0.1", ConnStatus: peer.StatusConnected}))
+	require.NoError(t, statusRecorder.UpdatePeerState(peer.State{PubKey: "key-b", IP: "100.64.0.2", ConnStatus: peer.StatusConnected, Relayed: true}))
+	require.NoError(t, statusRecorder.UpdatePeerState(peer.State{PubKey: "key-d", IP: "100.64.0.4", ConnStatus: peer.StatusStale}))
+	statusRecorder.SetDNSStatsGetter(func() peer.DNSStats {
+		return peer.DNSStats{
+			Queries:           4,
+			LocalRecordHits:   1,
+			UpstreamFailures:  1,
+			UpstreamResponses: 2,
+			UpstreamLatency:   500 * time.Millisecond,
+		}
+	})
+
+	statusRecorder.RecordConnAttempt("key-a", peer.ConnAttemptSucceeded, time.Second)
+	statusRecorder.RecordConnAttempt("key-b", peer.ConnAttemptRelayFallback, 3*time.Second)
+	statusRecorder.RecordConnAttempt("key-c", peer.ConnAttemptTimedOut, 5*time.Second)
+	statusRecorder.RecordConnAttempt("key-c", peer.ConnAttemptFailed, time.Second)
+
+	exporter, err := NewExporter(0, statusRecorder)
+	require.NoError(t, err)
+
+	host, _, err := net.SplitHostPort(exporter.Address())
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", host, "metrics should be exposed on the loopback interface only")
+
+	resp, err := http.Get("http://" + exporter.Address() + defaultEndpoint)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+
+	metrics := string(body)
+	assert.Contains(t, metrics, `netbird_peer_connected{ip="100.64.0.1",peer="peer-a.netbird.cloud"} 1`)
+	assert.Contains(t, metrics, `netbird_peer_connected{ip="",peer="peer-c.netbird.cloud"} 0`)
+	assert.Contains(t, metrics, `netbird_peer_connections{type="direct"} 1`)
+	assert.Contains(t, metrics, `netbird_peer_connections{type="relayed"} 1`)
+	assert.Contains(t, metrics, `netbird_peer_connected{ip="100.64.0.4",peer="peer-d.netbird.cloud"} 1`, "a stale peer is still connected")
+	assert.Contains(t, metrics, `netbird_peer_stale{ip="100.64.0.4",peer="peer-d.netbird.cloud"} 1`)
+	assert.Contains(t, metrics, `netbird_peer_stale{ip="100.64.0.1",peer="peer-a.netbird.cloud"} 0`)
+	assert.Contains(t, metrics, `netbird_peer_connections{type="stale"} 1`)
+	assert.Contains(t, metrics, `netbird_dns_queries_total 4`)
+	assert.Contains(t, metrics, `netbird_dns_local_hit_ratio 0.25`)
+	assert.Contains(t, metrics, `netbird_dns_upstream_failures_total 1`)
+	assert.Contains(t, metrics, `netbird_dns_upstream_responses_total 2`)
+	assert.Contains(t, metrics, `netbird_dns_upstream_latency_seconds_total 0.5`)
+	assert.Contains(t, metrics, `netbird_peer_conn_attempts_total 4`)
+	assert.Contains(t, metrics, `netbird_peer_conn_relay_fallbacks_total 1`)
+	assert.Contains(t, metrics, `netbird_peer_conn_timeouts_total 1`)
+	assert.Contains(t, metrics, `netbird_peer_conn_failures_total 1`)
+	assert.Contains(t, metrics, `netbird_peer_conn_success_ratio 0.5`)
+	assert.Contains(t, metrics, `netbird_peer_conn_establishment_median_seconds 2`)
+
+	require.NoError(t, exporter.Close())
+
+	_, err = net.DialTimeout("tcp", exporter.Address(), time.Second)
+	assert.Error(t, err, "metrics listener should be closed")
+}
@@ -0,0 +1,174 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+
+	"github.com/netbirdio/netbird/client/internal/peer"
+)
+
+const (
+	connectionTypeDirect  = "direct"
+	connectionTypeRelayed = "relayed"
+	connectionTypeStale   = "stale"
+)
+
+// registerStatusMetrics creates the peer and DNS resolver metrics observed from the full status of the status recorder
+func registerStatusMetrics(meter metric.Meter, statusRecorder *peer.Status) error {
+	peerConnected, err := meter.AsyncInt64().Gauge("netbird.peer.connected",
+		instrument.WithDescription("Connection status of the remote peer, 1 when connected and 0 otherwise"))
+	if err != nil {
+		return err
+	}
+
+	peerStale, err := meter.AsyncInt64().Gauge("netbird.peer.stale",
+		instrument.WithDescription("Handshake status of the connected remote peer, 1 when its WireGuard handshake is stale and 0 otherwise"))
+	if err != nil {
+		return err
+	}
+
+	peerConnections, err := meter.AsyncInt64().Gauge("netbird.peer.connections",
+		instrument.WithDescription("Number of the connected remote peers by the connection type, direct, relayed or stale"))
+	if err != nil {
+		return err
+	}
+
+	dnsQueries, err := meter.AsyncInt64().Counter("netbird.dns.queries",
+		instrument.WithDescription("Number of the queries handled by the local DNS resolver"))
+	if err != nil {
+		return err
+	}
+
+	dnsLocalHits, err := meter.AsyncInt64().Counter("netbird.dns.local.hits",
+		instrument.WithDescription("Number of the queries answered from the local records without reaching an upstream"))
+	if err != nil {
+		return err
+	}
+
+	dnsLocalHitRatio, err := meter.AsyncFloat64().Gauge("netbird.dns.local.hit.ratio",
+		instrument.WithDescription("Ratio of the queries answered from the local records to all the handled queries"))
+	if err != nil {
+		return err
+	}
+
+	dnsUpstreamFailures, err := meter.AsyncInt64().Counter("netbird.dns.upstream.failures",
+		instrument.WithDescription("Number of the queries that none of the upstream nameservers answered"))
+	if err != nil {
+		return err
+	}
+
+	dnsUpstreamResponses, err := meter.AsyncInt64().Counter("netbird.dns.upstream.responses",
+		instrument.WithDescription("Number of the queries answered by an upstream nameserver"))
+	if err != nil {
+		return err
+	}
+
+	dnsUpstreamLatency, err := meter.AsyncFloat64().Counter("netbird.dns.upstream.latency.seconds",
+		instrument.WithDescription("Total time spent serving the queries answered by an upstream nameserver"))
+	if err != nil {
+		return err
+	}
+
+	connAttempts, err := meter.AsyncInt64().Counter("netbird.peer.conn.attempts",
+		instrument.WithDescription("Number of the connection establishment attempts to the remote peers"))
+	if err != nil {
+		return err
+	}
+
+	connRelayFallbacks, err := meter.AsyncInt64().Counter("netbird.peer.conn.relay.fallbacks",
+		instrument.WithDescription("Number of the connection attempts connected after falling back to relay candidates only"))
+	if err != nil {
+		return err
+	}
+
+	connTimeouts, err := meter.AsyncInt64().Counter("netbird.peer.conn.timeouts",
+		instrument.WithDescription("Number of the connection attempts given up after the connection timeout"))
+	if err != nil {
+		return err
+	}
+
+	connFailures, err := meter.AsyncInt64().Counter("netbird.peer.conn.failures",
+		instrument.WithDescription("Number of the connection attempts failed for another reason than the timeout"))
+	if err != nil {
+		return err
+	}
+
+	connSuccessRatio, err := meter.AsyncFloat64().Gauge("netbird.peer.conn.success.ratio",
+		instrument.WithDescription("Ratio of the connected attempts, including the relay fallbacks, to all the connection attempts"))
+	if err != nil {
+		return err
+	}
+
+	connEstablishmentMedian, err := meter.AsyncFloat64().Gauge("netbird.peer.conn.establishment.median.seconds",
+		instrument.WithDescription("Median time the latest successful connection attempts took to connect"))
+	if err != nil {
+		return err
+	}
+
+	instruments := []instrument.Asynchronous{
+		peerConnected,
+		peerStale,
+		peerConnections,
+		dnsQueries,
+		dnsLocalHits,
+		dnsLocalHitRatio,
+		dnsUpstreamFailures,
+		dnsUpstreamResponses,
+		dnsUpstreamLatency,
+		connAttempts,
+		connRelayFallbacks,
+		connTimeouts,
+		connFailures,
+		connSuccessRatio,
+		connEstablishmentMedian,
+	}
+
+	return meter.RegisterCallback(instruments, func(ctx context.Context) {
+		fullStatus := statusRecorder.GetFullStatus()
+
+		var direct, relayed, stale int64
+		for _, state := range fullStatus.Peers {
+			var connected, isStale int64
+			switch {
+			case state.ConnStatus == peer.StatusStale:
+				connected, isStale = 1, 1
+				stale++
+			case state.ConnStatus != peer.StatusConnected:
+			case state.Relayed:
+				connected = 1
+				relayed++
+			default:
+				connected = 1
+				direct++
+			}
+			peerConnected.Observe(ctx, connected, attribute.String("peer", state.FQDN), attribute.String("ip", state.IP))
+			peerStale.Observe(ctx, isStale, attribute.String("peer", state.FQDN), attribute.String("ip", state.IP))
+		}
+		peerConnections.Observe(ctx, direct, attribute.String("type", connectionTypeDirect))
+		peerConnections.Observe(ctx, relayed, attribute.String("type", connectionTypeRelayed))
+		peerConnections.Observe(ctx, stale, attribute.String("type", connectionTypeStale))
+
+		dnsStats := fullStatus.DNSStats
+		dnsQueries.Observe(ctx, int64(dnsStats.Queries))
+		dnsLocalHits.Observe(ctx, int64(dnsStats.LocalRecordHits))
+		var hitRatio float64
+		if dnsStats.Queries > 0 {
+			hitRatio = float64(dnsStats.LocalRecordHits) / float64(dnsStats.Queries)
+		}
+		dnsLocalHitRatio.Observe(ctx, hitRatio)
+		dnsUpstreamFailures.Observe(ctx, int64(dnsStats.UpstreamFailures))
+		dnsUpstreamResponses.Observe(ctx, int64(dnsStats.UpstreamResponses))
+		dnsUpstreamLatency.Observe(ctx, dnsStats.UpstreamLatency.Seconds())
+
+		attempts := fullStatus.ConnAttempts
+		connAttempts.Observe(ctx, int64(attempts.Attempts))
+		connRelayFallbacks.Observe(ctx, int64(attempts.RelayFallbacks))
+		connTimeouts.Observe(ctx, int64(attempts.TimedOut))
+		connFailures.Observe(ctx, int64(attempts.Failed))
+		connSuccessRatio.Observe(ctx, attempts.SuccessRate())
+		connEstablishmentMedian.Observe(ctx, attempts.MedianEstablishment.Seconds())
+	})
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+)
+
+// wgInterfaceClaim reserves the WireGuard interface name and listen port of the client while the engine runs,
+// so two clients sharing a network namespace can't pick the same ones. Otherwise, the second client would remove
+// the interface of the first one when creating its own.
+type wgInterfaceClaim struct {
+	port     int
+	releases []func()
+}
+
+// claimWgInterface reserves the interface name and the first free port from port to portRangeEnd.
+// A portRangeEnd lower than port reserves the port only, without checking whether it is bound already,
+// because a leftover interface of an unclean shutdown binds it until the engine recreates the interface.
+func claimWgInterface(ifaceName string, port, portRangeEnd int) (*wgInterfaceClaim, error) {
+	claim := &wgInterfaceClaim{}
+
+	release, err := claimName("wg-iface/" + ifaceName)
+	if err != nil {
+		return nil, fmt.Errorf("WireGuard interface %s is used by another NetBird client in this network namespace: %w", ifaceName, err)
+	}
+	claim.releases = append(claim.releases, release)
+
+	if portRangeEnd <= port {
+		release, err := claimName(fmt.Sprintf("wg-port/%d", port))
+		if err != nil {
+			claim.release()
+			return nil, fmt.Errorf("WireGuard port %d is used by another NetBird client in this network namespace: %w", port, err)
+		}
+		claim.releases = append(claim.releases, release)
+		claim.port = port
+		return claim, nil
+	}
+
+	for p := port; p <= portRangeEnd; p++ {
+		release, err := claimName(fmt.Sprintf("wg-port/%d", p))
+		if err != nil {
+			continue
+		}
+		if !udpPortIsFree(p) {
+			release()
+			continue
+		}
+		claim.releases = append(claim.releases, release)
+		claim.port = p
+		return claim, nil
+	}
+
+	claim.release()
+	return nil, fmt.Errorf("no free WireGuard port from %d to %d", port, portRangeEnd)
+}
+
+// release frees the interface name and the port for the other clients
+func (c *wgInterfaceClaim) release() {
+	for _, release := range c.releases {
+		release()
+	}
+	c.releases = nil
+}
+
+func udpPortIsFree(port int) bool {
+	l, err := net.ListenPacket("udp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	_ = l.Close()
+	return true
+}
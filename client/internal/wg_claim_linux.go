@@ -0,0 +1,19 @@
+//go:build linux && !android
+
+package internal
+
+import (
+	"net"
+)
+
+// claimName reserves the name with an abstract unix socket, which belongs to the network namespace and is released
+// by the kernel when the process exits, so a crashed client doesn't keep the name reserved
+func claimName(name string) (func(), error) {
+	l, err := net.Listen("unix", "@netbird/"+name)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = l.Close()
+	}, nil
+}
@@ -0,0 +1,51 @@
+//go:build linux && !android
+
+package internal
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimWgInterface(t *testing.T) {
+	first, err := claimWgInterface("wt-claim-test", 51820, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 51820, first.port)
+
+	_, err = claimWgInterface("wt-claim-test", 51900, 0)
+	assert.Error(t, err, "the interface name of another client shouldn't be claimed")
+
+	_, err = claimWgInterface("wt-claim-other", 51820, 0)
+	assert.Error(t, err, "the port of another client shouldn't be claimed")
+
+	first.release()
+	second, err := claimWgInterface("wt-claim-test", 51820, 0)
+	require.NoError(t, err, "a released interface should be claimed again")
+	second.release()
+}
+
+func TestClaimWgInterface_PortRange(t *testing.T) {
+	// find a free port with a free successor for the range
+	probe, err := net.ListenPacket("udp", ":0")
+	require.NoError(t, err)
+	port := probe.LocalAddr().(*net.UDPAddr).Port
+	require.NoError(t, probe.Close())
+	if port >= 65535 {
+		t.Skip("no port after the probed one")
+	}
+
+	first, err := claimWgInterface("wt-range-1", port, port+1)
+	require.NoError(t, err)
+	defer first.release()
+
+	second, err := claimWgInterface("wt-range-2", port, port+1)
+	require.NoError(t, err)
+	defer second.release()
+	assert.NotEqual(t, first.port, second.port, "the clients should get different ports of the range")
+
+	_, err = claimWgInterface("wt-range-3", port, port+1)
+	assert.Error(t, err, "the range should be exhausted")
+}
@@ -0,0 +1,8 @@
+//go:build !linux || android
+
+package internal
+
+// claimName doesn't reserve anything, several clients on the same host are supported on Linux only
+func claimName(string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/iface/netstack"
+)
+
+const (
+	// wgWatchdogInterval is the interval of checking that the WireGuard interface exists and is configured
+	wgWatchdogInterval = 10 * time.Second
+	// wgWatchdogFailures is the number of consecutive failed checks the interface is recovered after.
+	// A single failed check can be a transient one, e.g. while the address of the interface is updated.
+	wgWatchdogFailures = 3
+)
+
+// wgWatchdog periodically checks the WireGuard interface and recovers it once the checks keep failing,
+// e.g. when NetworkManager or a reboot race deleted the interface from under the running client
+type wgWatchdog struct {
+	interval time.Duration
+	failures int
+	check    func() error
+	recover  func(reason error)
+}
+
+// run checks the interface until the context is done or the interface is recovered.
+// The recovery is triggered once, the watchdog of the next engine takes over after it.
+func (w *wgWatchdog) run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	failed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := w.check()
+		// the interface is removed on a legitimate shutdown as well, it must not be recovered then
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			failed = 0
+			continue
+		}
+
+		failed++
+		log.Debugf("WireGuard interface check %d/%d failed: %v", failed, w.failures, err)
+		if failed >= w.failures {
+			w.recover(err)
+			return
+		}
+	}
+}
+
+// wgWatchdogSupported returns false when the interface is managed by the OS, like on mobile, or when there is no
+// interface of the OS at all with the netstack
+func wgWatchdogSupported() bool {
+	if runtime.GOOS == "android" || runtime.GOOS == "ios" {
+		return false
+	}
+	return !netstack.IsEnabled()
+}
+
+// startWgWatchdog starts watching the WireGuard interface, the watchdog is stopped when the engine is closed
+func (e *Engine) startWgWatchdog() {
+	if !wgWatchdogSupported() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(e.ctx)
+	e.wgWatchdogCancel = cancel
+
+	watchdog := &wgWatchdog{
+		interval: wgWatchdogInterval,
+		failures: wgWatchdogFailures,
+		check:    e.checkWgInterface,
+		recover:  e.recoverWgInterface,
+	}
+	go watchdog.run(ctx)
+}
+
+// checkWgInterface returns an error if the WireGuard interface was removed or lost its configuration
+func (e *Engine) checkWgInterface() error {
+	e.syncMsgMux.Lock()
+	wgInterface := e.wgInterface
+	e.syncMsgMux.Unlock()
+
+	if wgInterface == nil {
+		return nil
+	}
+
+	// the userspace device keeps working without the interface of the OS, so the interface is looked up as well
+	if _, err := net.InterfaceByName(wgInterface.Name()); err != nil {
+		return fmt.Errorf("find interface %s: %w", wgInterface.Name(), err)
+	}
+
+	device, err := wgInterface.DeviceState()
+	if err != nil {
+		return fmt.Errorf("read WireGuard device %s: %w", wgInterface.Name(), err)
+	}
+
+	if device.PublicKey != e.config.WgPrivateKey.PublicKey() {
+		return fmt.Errorf("WireGuard device %s has an unexpected public key %s", wgInterface.Name(), device.PublicKey)
+	}
+
+	return nil
+}
+
+// recoverWgInterface restarts the engine, which creates the WireGuard interface again and applies the peers,
+// routes and DNS of the next sync. The restart goes through the back-off of the client, so an interface that is
+// removed again right away is not recreated in a loop.
+func (e *Engine) recoverWgInterface(reason error) {
+	if e.ctx.Err() != nil {
+		return
+	}
+
+	log.Warnf("recreating WireGuard interface %s: %v", e.config.WgIfaceName, reason)
+	e.statusRecorder.MarkInterfaceRecovery()
+
+	_ = CtxGetState(e.ctx).Wrap(ErrResetConnection)
+	e.cancel()
+}
@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWgWatchdog(t *testing.T) {
+	errMissing := errors.New("interface not found")
+
+	tt := []struct {
+		name              string
+		checks            []error
+		expectedRecovered bool
+		expectedChecks    int
+	}{
+		{
+			name:              "healthy interface",
+			checks:            []error{nil, nil, nil, nil},
+			expectedRecovered: false,
+			expectedChecks:    4,
+		},
+		{
+			name:              "transient failures",
+			checks:            []error{errMissing, errMissing, nil, errMissing, errMissing, nil},
+			expectedRecovered: false,
+			expectedChecks:    6,
+		},
+		{
+			name:              "missing interface",
+			checks:            []error{nil, errMissing, errMissing, errMissing, nil},
+			expectedRecovered: true,
+			expectedChecks:    4,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			checks := 0
+			var recovered error
+
+			watchdog := &wgWatchdog{
+				interval: time.Millisecond,
+				failures: 3,
+				check: func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					if checks == len(tc.checks) {
+						cancel()
+						return nil
+					}
+					checks++
+					return tc.checks[checks-1]
+				},
+				recover: func(reason error) {
+					mu.Lock()
+					defer mu.Unlock()
+					recovered = reason
+				},
+			}
+
+			done := make(chan struct{})
+			go func() {
+				watchdog.run(ctx)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for the watchdog to stop")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, tc.expectedChecks, checks)
+			if tc.expectedRecovered {
+				assert.ErrorIs(t, recovered, errMissing)
+			} else {
+				assert.NoError(t, recovered)
+			}
+		})
+	}
+}
+
+func TestWgWatchdog_Shutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	recovered := false
+	watchdog := &wgWatchdog{
+		interval: time.Millisecond,
+		failures: 1,
+		check: func() error {
+			// the interface is removed while the engine is being stopped
+			cancel()
+			return errors.New("interface not found")
+		},
+		recover: func(error) {
+			recovered = true
+		},
+	}
+
+	watchdog.run(ctx)
+	assert.False(t, recovered, "the interface shouldn't be recovered on shutdown")
+}
@@ -136,6 +136,7 @@ func (c *Client) GetStatusDetails() *StatusDetails {
 			p.IP,
 			p.FQDN,
 			p.ConnStatus.String(),
+			p.ConnPhase.String(),
 		}
 		peerInfos[n] = pi
 	}
@@ -168,7 +169,9 @@ func (c *Client) IsLoginRequired() bool {
 		ConfigPath: c.cfgFile,
 	})
 
-	needsLogin, _ := internal.IsLoginRequired(ctx, cfg.PrivateKey, cfg.ManagementURL, cfg.SSHKey)
+	tlsConfig, _ := cfg.TLSConfig()
+	proxyDialer, _ := cfg.ProxyDialer()
+	needsLogin, _ := internal.IsLoginRequired(ctx, cfg.PrivateKey, cfg.ManagementURL, cfg.SSHKey, cfg.SystemMetaPrivacy, tlsConfig, proxyDialer)
 	return needsLogin
 }
 
@@ -70,11 +70,21 @@ func NewAuthWithConfig(ctx context.Context, config *internal.Config) *Auth {
 // If it returns a flow info than save the configuration and return true. If it gets a codes.NotFound, it means that SSO
 // is not supported and returns false without saving the configuration. For other errors return false.
 func (a *Auth) SaveConfigIfSSOSupported() (bool, error) {
+	tlsConfig, err := a.config.TLSConfig()
+	if err != nil {
+		return false, err
+	}
+
+	proxyDialer, err := a.config.ProxyDialer()
+	if err != nil {
+		return false, err
+	}
+
 	supportsSSO := true
-	err := a.withBackOff(a.ctx, func() (err error) {
-		_, err = internal.GetDeviceAuthorizationFlowInfo(a.ctx, a.config.PrivateKey, a.config.ManagementURL)
+	err = a.withBackOff(a.ctx, func() (err error) {
+		_, err = internal.GetDeviceAuthorizationFlowInfo(a.ctx, a.config.PrivateKey, a.config.ManagementURL, tlsConfig, proxyDialer)
 		if s, ok := gstatus.FromError(err); ok && (s.Code() == codes.NotFound || s.Code() == codes.Unimplemented) {
-			_, err = internal.GetPKCEAuthorizationFlowInfo(a.ctx, a.config.PrivateKey, a.config.ManagementURL)
+			_, err = internal.GetPKCEAuthorizationFlowInfo(a.ctx, a.config.PrivateKey, a.config.ManagementURL, tlsConfig, proxyDialer)
 			if s, ok := gstatus.FromError(err); ok && (s.Code() == codes.NotFound || s.Code() == codes.Unimplemented) {
 				supportsSSO = false
 				err = nil
@@ -121,9 +131,19 @@ func (a *Auth) LoginWithSetupKeyAndSaveConfig(setupKey string, deviceName string
 func (a *Auth) Login() error {
 	var needsLogin bool
 
+	tlsConfig, err := a.config.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	proxyDialer, err := a.config.ProxyDialer()
+	if err != nil {
+		return err
+	}
+
 	// check if we need to generate JWT token
-	err := a.withBackOff(a.ctx, func() (err error) {
-		needsLogin, err = internal.IsLoginRequired(a.ctx, a.config.PrivateKey, a.config.ManagementURL, a.config.SSHKey)
+	err = a.withBackOff(a.ctx, func() (err error) {
+		needsLogin, err = internal.IsLoginRequired(a.ctx, a.config.PrivateKey, a.config.ManagementURL, a.config.SSHKey, a.config.SystemMetaPrivacy, tlsConfig, proxyDialer)
 		return
 	})
 	if err != nil {
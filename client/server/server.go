@@ -2,7 +2,11 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/netip"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,12 +14,17 @@ import (
 	"github.com/netbirdio/netbird/client/system"
 
 	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	gstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	firewall "github.com/netbirdio/netbird/client/firewall/manager"
 	"github.com/netbirdio/netbird/client/internal"
+	"github.com/netbirdio/netbird/client/internal/diag"
 	"github.com/netbirdio/netbird/client/internal/peer"
 	"github.com/netbirdio/netbird/client/proto"
 	"github.com/netbirdio/netbird/version"
@@ -23,20 +32,45 @@ import (
 
 const probeThreshold = time.Second * 5
 
+// clientStopTimeout is how long to wait for the client connection to stop before reconnecting with a new config
+const clientStopTimeout = 30 * time.Second
+
+// maxBundleLogSize limits the tail of the log file added to the debug bundle
+const maxBundleLogSize = 2 << 20
+
+// defaultPeerPingTimeout is how long PingPeer waits for the connection to the peer when the request has no timeout
+const defaultPeerPingTimeout = 30 * time.Second
+
 // Server for service control.
 type Server struct {
+	// profileSession is the session of the active profile, the requests other than the profile ones apply to it
+	*profileSession
+	// sessions are the sessions of the profiles in the order they were added, the default one first
+	sessions []*profileSession
+
+	ctx               context.Context
+	defaultConfigPath string
+
+	logFile string
+
+	mutex sync.Mutex
+	proto.UnimplementedDaemonServiceServer
+}
+
+// profileSession is the client connection of a profile with its own config, state and status recorder
+type profileSession struct {
+	name string
+
 	rootCtx   context.Context
 	actCancel context.CancelFunc
+	// clientDone is closed when the client connection started by Start or Up stops
+	clientDone chan struct{}
 
 	latestConfigInput internal.ConfigInput
 
-	logFile string
-
 	oauthAuthFlow oauthAuthFlow
 
-	mutex  sync.Mutex
 	config *internal.Config
-	proto.UnimplementedDaemonServiceServer
 
 	statusRecorder *peer.Status
 
@@ -56,12 +90,24 @@ type oauthAuthFlow struct {
 
 // New server instance constructor.
 func New(ctx context.Context, configPath, logFile string) *Server {
+	session := newProfileSession(ctx, internal.DefaultProfileName, configPath)
 	return &Server{
+		profileSession:    session,
+		sessions:          []*profileSession{session},
+		ctx:               ctx,
+		defaultConfigPath: configPath,
+		logFile:           logFile,
+	}
+}
+
+// newProfileSession creates the session of a profile, the state of the context is the one of the session
+func newProfileSession(ctx context.Context, name, configPath string) *profileSession {
+	return &profileSession{
+		name:    name,
 		rootCtx: ctx,
 		latestConfigInput: internal.ConfigInput{
 			ConfigPath: configPath,
 		},
-		logFile:     logFile,
 		mgmProbe:    internal.NewProbe(),
 		signalProbe: internal.NewProbe(),
 		relayProbe:  internal.NewProbe(),
@@ -69,9 +115,30 @@ func New(ctx context.Context, configPath, logFile string) *Server {
 	}
 }
 
+// Start creates the sessions of the profiles and starts the client connection of the active one
 func (s *Server) Start() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
+
+	profiles, err := internal.ReadProfiles(s.defaultConfigPath)
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles.Profiles {
+		if s.session(profile.Name) != nil {
+			continue
+		}
+		// the default session uses the state of the daemon context, the others have their own
+		session := newProfileSession(internal.CtxInitState(s.ctx), profile.Name, profile.ConfigPath)
+		if _, err := os.Stat(profile.ConfigPath); err == nil {
+			if session.config, err = internal.ReadConfig(profile.ConfigPath); err != nil {
+				log.Warnf("failed reading the config of the profile %s: %v", profile.Name, err)
+			}
+		}
+		s.sessions = append(s.sessions, session)
+	}
+	s.profileSession = s.session(profiles.Active)
+
 	state := internal.CtxGetState(s.rootCtx)
 
 	// if current state contains any error, return it
@@ -116,17 +183,36 @@ func (s *Server) Start() error {
 		s.statusRecorder.UpdateManagementAddress(config.ManagementURL.String())
 	}
 
+	s.runClient(ctx, config)
+
+	return nil
+}
+
+// session returns the session of the profile with the name or nil
+func (s *Server) session(name string) *profileSession {
+	for _, session := range s.sessions {
+		if session.name == name {
+			return session
+		}
+	}
+	return nil
+}
+
+// runClient runs the client connection in the background until the context is done
+func (s *profileSession) runClient(ctx context.Context, config *internal.Config) {
+	done := make(chan struct{})
+	s.clientDone = done
+
 	go func() {
+		defer close(done)
 		if err := internal.RunClientWithProbes(ctx, config, s.statusRecorder, s.mgmProbe, s.signalProbe, s.relayProbe, s.wgProbe); err != nil {
-			log.Errorf("init connections: %v", err)
+			log.Errorf("run client connection: %v", err)
 		}
 	}()
-
-	return nil
 }
 
 // loginAttempt attempts to login using the provided information. it returns a status in case something fails
-func (s *Server) loginAttempt(ctx context.Context, setupKey, jwtToken string) (internal.StatusType, error) {
+func (s *profileSession) loginAttempt(ctx context.Context, setupKey, jwtToken string) (internal.StatusType, error) {
 	var status internal.StatusType
 	err := internal.Login(ctx, s.config, setupKey, jwtToken)
 	if err != nil {
@@ -145,17 +231,19 @@ func (s *Server) loginAttempt(ctx context.Context, setupKey, jwtToken string) (i
 // Login uses setup key to prepare configuration for the daemon.
 func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*proto.LoginResponse, error) {
 	s.mutex.Lock()
-	if s.actCancel != nil {
-		s.actCancel()
+	// the session is captured once, a concurrent profile switch doesn't mix the profiles of the request
+	session := s.profileSession
+	if session.actCancel != nil {
+		session.actCancel()
 	}
-	ctx, cancel := context.WithCancel(s.rootCtx)
+	ctx, cancel := context.WithCancel(session.rootCtx)
 
 	md, ok := metadata.FromIncomingContext(callerCtx)
 	if ok {
 		ctx = metadata.NewOutgoingContext(ctx, md)
 	}
 
-	s.actCancel = cancel
+	session.actCancel = cancel
 	s.mutex.Unlock()
 
 	state := internal.CtxGetState(ctx)
@@ -167,31 +255,39 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 	}()
 
 	s.mutex.Lock()
-	inputConfig := s.latestConfigInput
+	inputConfig := session.latestConfigInput
 
 	if msg.ManagementUrl != "" {
 		inputConfig.ManagementURL = msg.ManagementUrl
-		s.latestConfigInput.ManagementURL = msg.ManagementUrl
+		session.latestConfigInput.ManagementURL = msg.ManagementUrl
 	}
 
 	if msg.AdminURL != "" {
 		inputConfig.AdminURL = msg.AdminURL
-		s.latestConfigInput.AdminURL = msg.AdminURL
+		session.latestConfigInput.AdminURL = msg.AdminURL
 	}
 
 	if msg.CleanNATExternalIPs {
 		inputConfig.NATExternalIPs = make([]string, 0)
-		s.latestConfigInput.NATExternalIPs = nil
+		session.latestConfigInput.NATExternalIPs = nil
 	} else if msg.NatExternalIPs != nil {
 		inputConfig.NATExternalIPs = msg.NatExternalIPs
-		s.latestConfigInput.NATExternalIPs = msg.NatExternalIPs
+		session.latestConfigInput.NATExternalIPs = msg.NatExternalIPs
+	}
+
+	if msg.CleanExcludedRoutes {
+		inputConfig.ExcludedRoutes = make([]string, 0)
+		session.latestConfigInput.ExcludedRoutes = nil
+	} else if msg.ExcludedRoutes != nil {
+		inputConfig.ExcludedRoutes = msg.ExcludedRoutes
+		session.latestConfigInput.ExcludedRoutes = msg.ExcludedRoutes
 	}
 
 	inputConfig.CustomDNSAddress = msg.CustomDNSAddress
-	s.latestConfigInput.CustomDNSAddress = msg.CustomDNSAddress
+	session.latestConfigInput.CustomDNSAddress = msg.CustomDNSAddress
 	if string(msg.CustomDNSAddress) == "empty" {
 		inputConfig.CustomDNSAddress = []byte{}
-		s.latestConfigInput.CustomDNSAddress = []byte{}
+		session.latestConfigInput.CustomDNSAddress = []byte{}
 	}
 
 	if msg.Hostname != "" {
@@ -201,18 +297,25 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 
 	if msg.RosenpassEnabled != nil {
 		inputConfig.RosenpassEnabled = msg.RosenpassEnabled
-		s.latestConfigInput.RosenpassEnabled = msg.RosenpassEnabled
+		session.latestConfigInput.RosenpassEnabled = msg.RosenpassEnabled
 	}
 
 	if msg.InterfaceName != nil {
 		inputConfig.InterfaceName = msg.InterfaceName
-		s.latestConfigInput.InterfaceName = msg.InterfaceName
+		session.latestConfigInput.InterfaceName = msg.InterfaceName
 	}
 
 	if msg.WireguardPort != nil {
 		port := int(*msg.WireguardPort)
 		inputConfig.WireguardPort = &port
-		s.latestConfigInput.WireguardPort = &port
+		session.latestConfigInput.WireguardPort = &port
+	}
+
+	if inputConfig.InterfaceName != nil || inputConfig.WireguardPort != nil || len(inputConfig.CustomDNSAddress) > 0 {
+		if err := internal.CheckProfileCollisions(s.defaultConfigPath, session.name, inputConfig); err != nil {
+			s.mutex.Unlock()
+			return nil, gstatus.Errorf(codes.InvalidArgument, "%v", err)
+		}
 	}
 
 	s.mutex.Unlock()
@@ -223,20 +326,21 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 
 	config, err := internal.UpdateOrCreateConfig(inputConfig)
 	if err != nil {
-		return nil, err
+		return nil, internal.NewConfigInvalidError(err)
 	}
 
 	if msg.ManagementUrl == "" {
-		config, _ = internal.UpdateOldManagementURL(ctx, config, s.latestConfigInput.ConfigPath)
-		s.config = config
-		s.latestConfigInput.ManagementURL = config.ManagementURL.String()
+		config, _ = internal.UpdateOldManagementURL(ctx, config, inputConfig.ConfigPath)
 	}
 
 	s.mutex.Lock()
-	s.config = config
+	session.config = config
+	if msg.ManagementUrl == "" {
+		session.latestConfigInput.ManagementURL = config.ManagementURL.String()
+	}
 	s.mutex.Unlock()
 
-	if _, err := s.loginAttempt(ctx, "", ""); err == nil {
+	if _, err := session.loginAttempt(ctx, "", ""); err == nil {
 		state.Set(internal.StatusIdle)
 		return &proto.LoginResponse{}, nil
 	}
@@ -250,19 +354,19 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 			return nil, err
 		}
 
-		if s.oauthAuthFlow.flow != nil && s.oauthAuthFlow.flow.GetClientID(ctx) == oAuthFlow.GetClientID(context.TODO()) {
-			if s.oauthAuthFlow.expiresAt.After(time.Now().Add(90 * time.Second)) {
+		if session.oauthAuthFlow.flow != nil && session.oauthAuthFlow.flow.GetClientID(ctx) == oAuthFlow.GetClientID(context.TODO()) {
+			if session.oauthAuthFlow.expiresAt.After(time.Now().Add(90 * time.Second)) {
 				log.Debugf("using previous oauth flow info")
 				return &proto.LoginResponse{
 					NeedsSSOLogin:           true,
-					VerificationURI:         s.oauthAuthFlow.info.VerificationURI,
-					VerificationURIComplete: s.oauthAuthFlow.info.VerificationURIComplete,
-					UserCode:                s.oauthAuthFlow.info.UserCode,
+					VerificationURI:         session.oauthAuthFlow.info.VerificationURI,
+					VerificationURIComplete: session.oauthAuthFlow.info.VerificationURIComplete,
+					UserCode:                session.oauthAuthFlow.info.UserCode,
 				}, nil
 			} else {
 				log.Warnf("canceling previous waiting execution")
-				if s.oauthAuthFlow.waitCancel != nil {
-					s.oauthAuthFlow.waitCancel()
+				if session.oauthAuthFlow.waitCancel != nil {
+					session.oauthAuthFlow.waitCancel()
 				}
 			}
 		}
@@ -274,9 +378,9 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 		}
 
 		s.mutex.Lock()
-		s.oauthAuthFlow.flow = oAuthFlow
-		s.oauthAuthFlow.info = authInfo
-		s.oauthAuthFlow.expiresAt = time.Now().Add(time.Duration(authInfo.ExpiresIn) * time.Second)
+		session.oauthAuthFlow.flow = oAuthFlow
+		session.oauthAuthFlow.info = authInfo
+		session.oauthAuthFlow.expiresAt = time.Now().Add(time.Duration(authInfo.ExpiresIn) * time.Second)
 		s.mutex.Unlock()
 
 		state.Set(internal.StatusNeedsLogin)
@@ -289,7 +393,7 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 		}, nil
 	}
 
-	if loginStatus, err := s.loginAttempt(ctx, msg.SetupKey, ""); err != nil {
+	if loginStatus, err := session.loginAttempt(ctx, msg.SetupKey, ""); err != nil {
 		state.Set(loginStatus)
 		return nil, err
 	}
@@ -301,10 +405,12 @@ func (s *Server) Login(callerCtx context.Context, msg *proto.LoginRequest) (*pro
 // waits for the user to continue with the login on a browser
 func (s *Server) WaitSSOLogin(callerCtx context.Context, msg *proto.WaitSSOLoginRequest) (*proto.WaitSSOLoginResponse, error) {
 	s.mutex.Lock()
-	if s.actCancel != nil {
-		s.actCancel()
+	// the session is captured once, a concurrent profile switch doesn't mix the profiles of the request
+	session := s.profileSession
+	if session.actCancel != nil {
+		session.actCancel()
 	}
-	ctx, cancel := context.WithCancel(s.rootCtx)
+	ctx, cancel := context.WithCancel(session.rootCtx)
 
 	md, ok := metadata.FromIncomingContext(callerCtx)
 	if ok {
@@ -316,10 +422,11 @@ func (s *Server) WaitSSOLogin(callerCtx context.Context, msg *proto.WaitSSOLogin
 		ctx = context.WithValue(ctx, system.DeviceNameCtxKey, msg.Hostname)
 	}
 
-	s.actCancel = cancel
+	session.actCancel = cancel
+	oauthAuthFlow := session.oauthAuthFlow
 	s.mutex.Unlock()
 
-	if s.oauthAuthFlow.flow == nil {
+	if oauthAuthFlow.flow == nil {
 		return nil, gstatus.Errorf(codes.Internal, "oauth flow is not initialized")
 	}
 
@@ -333,34 +440,31 @@ func (s *Server) WaitSSOLogin(callerCtx context.Context, msg *proto.WaitSSOLogin
 
 	state.Set(internal.StatusConnecting)
 
-	s.mutex.Lock()
-	flowInfo := s.oauthAuthFlow.info
-	s.mutex.Unlock()
-
+	flowInfo := oauthAuthFlow.info
 	if flowInfo.UserCode != msg.UserCode {
 		state.Set(internal.StatusLoginFailed)
 		return nil, gstatus.Errorf(codes.InvalidArgument, "sso user code is invalid")
 	}
 
-	if s.oauthAuthFlow.waitCancel != nil {
-		s.oauthAuthFlow.waitCancel()
+	if oauthAuthFlow.waitCancel != nil {
+		oauthAuthFlow.waitCancel()
 	}
 
-	waitTimeout := time.Until(s.oauthAuthFlow.expiresAt)
+	waitTimeout := time.Until(oauthAuthFlow.expiresAt)
 	waitCTX, cancel := context.WithTimeout(ctx, waitTimeout)
 	defer cancel()
 
 	s.mutex.Lock()
-	s.oauthAuthFlow.waitCancel = cancel
+	session.oauthAuthFlow.waitCancel = cancel
 	s.mutex.Unlock()
 
-	tokenInfo, err := s.oauthAuthFlow.flow.WaitToken(waitCTX, flowInfo)
+	tokenInfo, err := oauthAuthFlow.flow.WaitToken(waitCTX, flowInfo)
 	if err != nil {
 		if err == context.Canceled {
 			return nil, nil //nolint:nilnil
 		}
 		s.mutex.Lock()
-		s.oauthAuthFlow.expiresAt = time.Now()
+		session.oauthAuthFlow.expiresAt = time.Now()
 		s.mutex.Unlock()
 		state.Set(internal.StatusLoginFailed)
 		log.Errorf("waiting for browser login failed: %v", err)
@@ -368,10 +472,10 @@ func (s *Server) WaitSSOLogin(callerCtx context.Context, msg *proto.WaitSSOLogin
 	}
 
 	s.mutex.Lock()
-	s.oauthAuthFlow.expiresAt = time.Now()
+	session.oauthAuthFlow.expiresAt = time.Now()
 	s.mutex.Unlock()
 
-	if loginStatus, err := s.loginAttempt(ctx, "", tokenInfo.GetTokenToUse()); err != nil {
+	if loginStatus, err := session.loginAttempt(ctx, "", tokenInfo.GetTokenToUse()); err != nil {
 		state.Set(loginStatus)
 		return nil, err
 	}
@@ -397,6 +501,18 @@ func (s *Server) Up(callerCtx context.Context, _ *proto.UpRequest) (*proto.UpRes
 		return nil, fmt.Errorf("up already in progress: current status %s", status)
 	}
 
+	// the firewall rules and the host DNS settings of the client aren't separated by interface,
+	// so only one profile can be up at a time
+	for _, session := range s.sessions {
+		if session == s.profileSession {
+			continue
+		}
+		if up, err := session.isClientUp(); err == nil && up {
+			return nil, gstatus.Errorf(codes.FailedPrecondition,
+				"the profile %s is up, bring it down before bringing up the profile %s", session.name, s.name)
+		}
+	}
+
 	// it should be nil here, but .
 	if s.actCancel != nil {
 		s.actCancel()
@@ -420,12 +536,7 @@ func (s *Server) Up(callerCtx context.Context, _ *proto.UpRequest) (*proto.UpRes
 		s.statusRecorder.UpdateManagementAddress(s.config.ManagementURL.String())
 	}
 
-	go func() {
-		if err := internal.RunClientWithProbes(ctx, s.config, s.statusRecorder, s.mgmProbe, s.signalProbe, s.relayProbe, s.wgProbe); err != nil {
-			log.Errorf("run client connection: %v", err)
-			return
-		}
-	}()
+	s.runClient(ctx, s.config)
 
 	return &proto.UpResponse{}, nil
 }
@@ -445,6 +556,289 @@ func (s *Server) Down(_ context.Context, _ *proto.DownRequest) (*proto.DownRespo
 	return &proto.DownResponse{}, nil
 }
 
+// ReloadConfig re-reads the config file and applies the changed settings to the running engine
+// without reconnecting the peers.
+func (s *Server) ReloadConfig(_ context.Context, _ *proto.ReloadConfigRequest) (*proto.ReloadConfigResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	config, err := internal.ReadConfig(s.latestConfigInput.ConfigPath)
+	if err != nil {
+		return nil, internal.NewConfigInvalidError(err)
+	}
+
+	reloaded, err := internal.ReloadConfig(s.rootCtx, config)
+	if err != nil {
+		return nil, err
+	}
+	s.config = config
+
+	return &proto.ReloadConfigResponse{Reloaded: reloaded}, nil
+}
+
+// PingPeer waits for the connection to a peer, forcing a new attempt if it isn't connected, and reports its path,
+// the time the forced attempt took and the latency
+func (s *Server) PingPeer(callerCtx context.Context, msg *proto.PingPeerRequest) (*proto.PingPeerResponse, error) {
+	s.mutex.Lock()
+	engine := internal.CtxGetState(s.rootCtx).Engine()
+	s.mutex.Unlock()
+	if engine == nil {
+		return nil, gstatus.Errorf(codes.FailedPrecondition, "client is not running")
+	}
+
+	timeout := defaultPeerPingTimeout
+	if msg.GetTimeout() != nil {
+		timeout = msg.GetTimeout().AsDuration()
+	}
+	ctx, cancel := context.WithTimeout(callerCtx, timeout)
+	defer cancel()
+
+	result, err := engine.PingPeer(ctx, msg.GetPeer(), msg.GetReconnect())
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, gstatus.Errorf(codes.DeadlineExceeded, "%v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &proto.PingPeerResponse{
+		PubKey:                     result.State.PubKey,
+		Fqdn:                       result.State.FQDN,
+		IP:                         result.State.IP,
+		Relayed:                    result.State.Relayed,
+		Direct:                     result.State.Direct,
+		LocalIceCandidateType:      result.State.LocalIceCandidateType,
+		RemoteIceCandidateType:     result.State.RemoteIceCandidateType,
+		LocalIceCandidateEndpoint:  result.State.LocalIceCandidateEndpoint,
+		RemoteIceCandidateEndpoint: result.State.RemoteIceCandidateEndpoint,
+	}
+	if result.ICESetup != 0 {
+		resp.IceSetup = durationpb.New(result.ICESetup)
+	}
+	if result.LatencyErr != nil {
+		resp.LatencyError = result.LatencyErr.Error()
+	} else {
+		resp.Latency = durationpb.New(result.Latency)
+	}
+	return resp, nil
+}
+
+// RotateKey replaces the WireGuard key of the peer and reconnects the client with the new key if it is up
+func (s *Server) RotateKey(callerCtx context.Context, _ *proto.RotateKeyRequest) (*proto.RotateKeyResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.config == nil {
+		return nil, gstatus.Errorf(codes.FailedPrecondition, "config is not defined, please call login command first")
+	}
+
+	wasUp, err := s.isClientUp()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := internal.RotatePeerKey(callerCtx, s.config, s.latestConfigInput.ConfigPath)
+	if err != nil {
+		return nil, gstatus.Errorf(codes.FailedPrecondition, "rotate key: %v", err)
+	}
+	s.config = config
+
+	privateKey, err := wgtypes.ParseKey(config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	resp := &proto.RotateKeyResponse{PubKey: privateKey.PublicKey().String()}
+	if !wasUp {
+		return resp, nil
+	}
+
+	// the engine is stopped before starting again, so the WireGuard interface is recreated with the new key
+	s.restartClient(config)
+	resp.Reconnected = true
+
+	return resp, nil
+}
+
+// SetAdvertisedNetworks stores the networks of the local network the peer offers to route and reconnects the client
+// if it is up, so the login reports them to the Management Service
+func (s *Server) SetAdvertisedNetworks(_ context.Context, msg *proto.SetAdvertisedNetworksRequest) (*proto.SetAdvertisedNetworksResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.config == nil {
+		return nil, gstatus.Errorf(codes.FailedPrecondition, "config is not defined, please call login command first")
+	}
+
+	var overlay netip.Prefix
+	if engine := internal.CtxGetState(s.rootCtx).Engine(); engine != nil {
+		overlay = engine.OverlayNetwork()
+	}
+	networks, err := internal.ParseAdvertisedNetworks(msg.GetNetworks(), overlay)
+	if err != nil {
+		return nil, gstatus.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	wasUp, err := s.isClientUp()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := internal.UpdateConfig(internal.ConfigInput{
+		ConfigPath:         s.latestConfigInput.ConfigPath,
+		AdvertisedNetworks: networks,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.config = config
+
+	resp := &proto.SetAdvertisedNetworksResponse{Networks: config.AdvertisedNetworks}
+	if !wasUp {
+		return resp, nil
+	}
+
+	s.restartClient(config)
+	resp.Reconnected = true
+
+	return resp, nil
+}
+
+// isClientUp returns true if the client is connected or connecting to the Management Service
+func (s *profileSession) isClientUp() (bool, error) {
+	status, err := internal.CtxGetState(s.rootCtx).Status()
+	if err != nil {
+		return false, err
+	}
+	return s.actCancel != nil && (status == internal.StatusConnected || status == internal.StatusConnecting), nil
+}
+
+// restartClient stops the running client and starts it again with the config
+func (s *profileSession) restartClient(config *internal.Config) {
+	s.actCancel()
+	if s.clientDone != nil {
+		select {
+		case <-s.clientDone:
+		case <-time.After(clientStopTimeout):
+			log.Warnf("the client didn't stop in %s, starting it with the new config anyway", clientStopTimeout)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(s.rootCtx)
+	s.actCancel = cancel
+	s.runClient(ctx, config)
+}
+
+// GetFirewallCounters returns the counters of the ACL firewall rules
+func (s *Server) GetFirewallCounters(_ context.Context, _ *proto.GetFirewallCountersRequest) (*proto.GetFirewallCountersResponse, error) {
+	s.mutex.Lock()
+	engine := internal.CtxGetState(s.rootCtx).Engine()
+	s.mutex.Unlock()
+	if engine == nil {
+		return nil, gstatus.Errorf(codes.FailedPrecondition, "client is not running")
+	}
+
+	counters, err := engine.FirewallRuleCounters()
+	if err != nil {
+		return nil, gstatus.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+
+	resp := &proto.GetFirewallCountersResponse{}
+	for _, counter := range counters {
+		action := "accept"
+		if counter.Action == firewall.ActionDrop {
+			action = "drop"
+		}
+		resp.Counters = append(resp.Counters, &proto.FirewallRuleCounter{
+			Id:      counter.ID,
+			Chain:   counter.Chain,
+			Rule:    counter.Rule,
+			Action:  action,
+			Packets: counter.Packets,
+			Bytes:   counter.Bytes,
+		})
+	}
+	return resp, nil
+}
+
+// DebugBundle collects the self-diagnostic bundle of the client with its secrets redacted
+func (s *Server) DebugBundle(ctx context.Context, _ *proto.DebugBundleRequest) (*proto.DebugBundleResponse, error) {
+	s.mutex.Lock()
+	config := s.config
+	statusRecorder := s.statusRecorder
+	engine := internal.CtxGetState(s.rootCtx).Engine()
+	logFile := s.logFile
+	s.mutex.Unlock()
+
+	errNotRunning := errors.New("client is not running")
+	var secrets []string
+	sections := []diag.Section{
+		diag.JSONSection("system.json", func(ctx context.Context) (any, error) {
+			return system.GetInfo(ctx), nil
+		}),
+	}
+
+	if config != nil {
+		secrets = append(secrets, config.PrivateKey, config.PreSharedKey, config.SSHKey)
+		sections = append(sections, diag.JSONSection("config.json", func(context.Context) (any, error) {
+			return internal.RedactedConfig(config), nil
+		}))
+	} else {
+		sections = append(sections, diag.ErrorSection("config.json", errors.New("config is not loaded")))
+	}
+
+	if statusRecorder != nil {
+		sections = append(sections,
+			diag.TextSection("status.json", func(context.Context) (string, error) {
+				status, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(toProtoFullStatus(statusRecorder.GetFullStatus()))
+				return string(status), err
+			}),
+			diag.TextSection("events.txt", func(context.Context) (string, error) {
+				return formatStatusEvents(statusRecorder.GetEvents()), nil
+			}),
+		)
+	} else {
+		sections = append(sections, diag.ErrorSection("status.json", errNotRunning), diag.ErrorSection("events.txt", errNotRunning))
+	}
+
+	if engine != nil {
+		sections = append(sections,
+			diag.TextSection("wireguard.txt", func(context.Context) (string, error) {
+				dev, err := engine.WGDeviceState()
+				if err != nil {
+					return "", err
+				}
+				return diag.FormatDevice(dev, time.Now()), nil
+			}),
+			diag.JSONSection("dns.json", func(context.Context) (any, error) {
+				return engine.DNSResolverState()
+			}),
+		)
+	} else {
+		sections = append(sections, diag.ErrorSection("wireguard.txt", errNotRunning), diag.ErrorSection("dns.json", errNotRunning))
+	}
+
+	if logFile != "" && logFile != "console" {
+		sections = append(sections, diag.FileTailSection("netbird.log", logFile, maxBundleLogSize))
+	}
+
+	sections = append(sections, diag.SystemSections()...)
+
+	archive, err := diag.Bundle(ctx, sections, diag.NewRedactor(secrets...))
+	if err != nil {
+		return nil, gstatus.Errorf(codes.Internal, "build the debug bundle: %v", err)
+	}
+
+	return &proto.DebugBundleResponse{Archive: archive}, nil
+}
+
+func formatStatusEvents(events []peer.StatusEvent) string {
+	var b strings.Builder
+	for _, event := range events {
+		fmt.Fprintf(&b, "%s %s\n", event.Time.Format(time.RFC3339), event.Message)
+	}
+	return b.String()
+}
+
 // Status returns the daemon status
 func (s *Server) Status(
 	_ context.Context,
@@ -458,7 +852,11 @@ func (s *Server) Status(
 		return nil, err
 	}
 
-	statusResponse := proto.StatusResponse{Status: string(status), DaemonVersion: version.NetbirdVersion()}
+	statusResponse := proto.StatusResponse{
+		Status:        string(status),
+		DaemonVersion: version.NetbirdVersion(),
+		Profiles:      s.toProtoProfileStates(),
+	}
 
 	if s.statusRecorder == nil {
 		s.statusRecorder = peer.NewRecorder(s.config.ManagementURL.String())
@@ -471,13 +869,163 @@ func (s *Server) Status(
 
 		fullStatus := s.statusRecorder.GetFullStatus()
 		pbFullStatus := toProtoFullStatus(fullStatus)
+		if msg.GetPathFilter() != "" {
+			path, err := peer.ParseConnPath(msg.GetPathFilter())
+			if err != nil {
+				return nil, gstatus.Errorf(codes.InvalidArgument, "%v", err)
+			}
+			pbFullStatus.Peers = filterPeersByPath(fullStatus.Peers, pbFullStatus.Peers, path)
+		}
 		statusResponse.FullStatus = pbFullStatus
 	}
 
 	return &statusResponse, nil
 }
 
-func (s *Server) runProbes() {
+// AddProfile creates a profile connecting to another NetBird network with its own config. The interface name and the
+// WireGuard port are picked if not set, so they don't collide with the ones of the other profiles.
+func (s *Server) AddProfile(_ context.Context, msg *proto.AddProfileRequest) (*proto.AddProfileResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	input := internal.ConfigInput{
+		ManagementURL: msg.GetManagementUrl(),
+		AdminURL:      msg.GetAdminURL(),
+	}
+	if msg.GetInterfaceName() != "" {
+		interfaceName := msg.GetInterfaceName()
+		input.InterfaceName = &interfaceName
+	}
+	if msg.GetWireguardPort() < 0 || msg.GetWireguardPort() > 65535 {
+		return nil, gstatus.Errorf(codes.InvalidArgument, "invalid WireGuard port %d", msg.GetWireguardPort())
+	}
+	if msg.GetWireguardPort() != 0 {
+		port := int(msg.GetWireguardPort())
+		input.WireguardPort = &port
+	}
+
+	profile, config, err := internal.AddProfile(s.defaultConfigPath, msg.GetName(), input)
+	if errors.Is(err, internal.ErrProfileExists) {
+		return nil, gstatus.Errorf(codes.AlreadyExists, "%v", err)
+	}
+	if err != nil {
+		return nil, gstatus.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	session := newProfileSession(internal.CtxInitState(s.ctx), profile.Name, profile.ConfigPath)
+	session.config = config
+	s.sessions = append(s.sessions, session)
+	log.Infof("added the profile %s with the config %s", profile.Name, profile.ConfigPath)
+
+	return &proto.AddProfileResponse{Profile: toProtoProfileState(session, false)}, nil
+}
+
+// SwitchProfile makes the profile the active one the other requests apply to. The client connection of the previous
+// active profile keeps running.
+func (s *Server) SwitchProfile(_ context.Context, msg *proto.SwitchProfileRequest) (*proto.SwitchProfileResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session := s.session(msg.GetName())
+	if session == nil {
+		return nil, gstatus.Errorf(codes.NotFound, "profile %s not found", msg.GetName())
+	}
+
+	if session.config == nil {
+		config, err := internal.ReadConfig(session.latestConfigInput.ConfigPath)
+		if err != nil {
+			return nil, internal.NewConfigInvalidError(err)
+		}
+		session.config = config
+	}
+
+	if err := internal.SetActiveProfile(s.defaultConfigPath, session.name); err != nil {
+		return nil, err
+	}
+	s.profileSession = session
+	log.Infof("switched to the profile %s", session.name)
+
+	return &proto.SwitchProfileResponse{}, nil
+}
+
+// ListProfiles returns the profiles of the daemon with their state
+func (s *Server) ListProfiles(_ context.Context, _ *proto.ListProfilesRequest) (*proto.ListProfilesResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return &proto.ListProfilesResponse{Profiles: s.toProtoProfileStates()}, nil
+}
+
+// toProtoProfileStates converts the states of the sessions of the profiles
+func (s *Server) toProtoProfileStates() []*proto.ProfileState {
+	states := make([]*proto.ProfileState, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		states = append(states, toProtoProfileState(session, session == s.profileSession))
+	}
+	return states
+}
+
+// toProtoProfileState converts the state of the session of a profile, the settings are known only if its config exists
+func toProtoProfileState(session *profileSession, active bool) *proto.ProfileState {
+	state := &proto.ProfileState{
+		Name:       session.name,
+		Active:     active,
+		ConfigFile: session.latestConfigInput.ConfigPath,
+	}
+
+	status, err := internal.CtxGetState(session.rootCtx).Status()
+	if err != nil {
+		state.Status = err.Error()
+	} else {
+		state.Status = string(status)
+	}
+
+	if session.config != nil {
+		if session.config.ManagementURL != nil {
+			state.ManagementUrl = session.config.ManagementURL.String()
+		}
+		state.InterfaceName = session.config.WgIface
+		state.WireguardPort = int64(session.config.WgPort)
+	}
+	return state
+}
+
+// SubscribeEvents streams the connection events of the client until the subscriber disconnects. A subscriber that
+// falls behind is disconnected with codes.ResourceExhausted, it should subscribe again and read the full status.
+func (s *Server) SubscribeEvents(_ *proto.SubscribeEventsRequest, srv proto.DaemonService_SubscribeEventsServer) error {
+	s.mutex.Lock()
+	// the subscribers may connect before the config is loaded, the address is updated when the client starts
+	if s.statusRecorder == nil {
+		var mgmAddress string
+		if s.config != nil {
+			mgmAddress = s.config.ManagementURL.String()
+		}
+		s.statusRecorder = peer.NewRecorder(mgmAddress)
+	}
+	sub := s.statusRecorder.Subscribe()
+	s.mutex.Unlock()
+	defer sub.Close()
+
+	log.Debugf("subscriber connected to the connection events")
+
+	for {
+		event, err := sub.Next(srv.Context())
+		if errors.Is(err, peer.ErrSubscriptionDropped) {
+			return gstatus.Error(codes.ResourceExhausted, err.Error())
+		}
+		if err != nil {
+			log.Debugf("connection events subscriber disconnected")
+			return srv.Context().Err()
+		}
+
+		if err := srv.Send(toProtoConnectionEvent(event)); err != nil {
+			log.Debugf("failed sending the connection event to the subscriber: %v", err)
+			return err
+		}
+	}
+}
+
+func (s *profileSession) runProbes() {
 	if time.Since(s.lastProbe) > probeThreshold {
 		managementHealthy := s.mgmProbe.Probe()
 		signalHealthy := s.signalProbe.Probe()
@@ -499,6 +1047,7 @@ func (s *Server) GetConfig(_ context.Context, _ *proto.GetConfigRequest) (*proto
 	managementURL := s.latestConfigInput.ManagementURL
 	adminURL := s.latestConfigInput.AdminURL
 	preSharedKey := ""
+	var advertisedNetworks []string
 
 	if s.config != nil {
 		if managementURL == "" && s.config.ManagementURL != nil {
@@ -514,17 +1063,31 @@ func (s *Server) GetConfig(_ context.Context, _ *proto.GetConfigRequest) (*proto
 			preSharedKey = "**********"
 		}
 
+		advertisedNetworks = s.config.AdvertisedNetworks
 	}
 
 	return &proto.GetConfigResponse{
-		ManagementUrl: managementURL,
-		AdminURL:      adminURL,
-		ConfigFile:    s.latestConfigInput.ConfigPath,
-		LogFile:       s.logFile,
-		PreSharedKey:  preSharedKey,
+		ManagementUrl:      managementURL,
+		AdminURL:           adminURL,
+		ConfigFile:         s.latestConfigInput.ConfigPath,
+		LogFile:            s.logFile,
+		PreSharedKey:       preSharedKey,
+		AdvertisedNetworks: advertisedNetworks,
 	}, nil
 }
 
+// filterPeersByPath returns the converted peer states of the peers with the connection path, the converted states are
+// in the same order with the peer states
+func filterPeersByPath(peerStates []peer.State, pbPeerStates []*proto.PeerState, path peer.ConnPath) []*proto.PeerState {
+	filtered := []*proto.PeerState{}
+	for i, peerState := range peerStates {
+		if peerState.Path() == path {
+			filtered = append(filtered, pbPeerStates[i])
+		}
+	}
+	return filtered
+}
+
 func toProtoFullStatus(fullStatus peer.FullStatus) *proto.FullStatus {
 	pbFullStatus := proto.FullStatus{
 		ManagementState: &proto.ManagementState{},
@@ -550,25 +1113,22 @@ func toProtoFullStatus(fullStatus peer.FullStatus) *proto.FullStatus {
 	pbFullStatus.LocalPeerState.PubKey = fullStatus.LocalPeerState.PubKey
 	pbFullStatus.LocalPeerState.KernelInterface = fullStatus.LocalPeerState.KernelInterface
 	pbFullStatus.LocalPeerState.Fqdn = fullStatus.LocalPeerState.FQDN
+	pbFullStatus.LocalPeerState.InterfaceRecoveries = int32(fullStatus.LocalPeerState.InterfaceRecoveries)
+	if !fullStatus.LocalPeerState.LastInterfaceRecovery.IsZero() {
+		pbFullStatus.LocalPeerState.LastInterfaceRecovery = timestamppb.New(fullStatus.LocalPeerState.LastInterfaceRecovery)
+	}
 
 	for _, peerState := range fullStatus.Peers {
-		pbPeerState := &proto.PeerState{
-			IP:                         peerState.IP,
-			PubKey:                     peerState.PubKey,
-			ConnStatus:                 peerState.ConnStatus.String(),
-			ConnStatusUpdate:           timestamppb.New(peerState.ConnStatusUpdate),
-			Relayed:                    peerState.Relayed,
-			Direct:                     peerState.Direct,
-			LocalIceCandidateType:      peerState.LocalIceCandidateType,
-			RemoteIceCandidateType:     peerState.RemoteIceCandidateType,
-			LocalIceCandidateEndpoint:  peerState.LocalIceCandidateEndpoint,
-			RemoteIceCandidateEndpoint: peerState.RemoteIceCandidateEndpoint,
-			Fqdn:                       peerState.FQDN,
-			LastWireguardHandshake:     timestamppb.New(peerState.LastWireguardHandshake),
-			BytesRx:                    peerState.BytesRx,
-			BytesTx:                    peerState.BytesTx,
-		}
-		pbFullStatus.Peers = append(pbFullStatus.Peers, pbPeerState)
+		pbFullStatus.Peers = append(pbFullStatus.Peers, toProtoPeerState(peerState))
+
+		switch peerState.Path() {
+		case peer.ConnPathDirect:
+			pbFullStatus.DirectPeers++
+		case peer.ConnPathRelayed:
+			pbFullStatus.RelayedPeers++
+		default:
+			pbFullStatus.DisconnectedPeers++
+		}
 	}
 
 	for _, relayState := range fullStatus.Relays {
@@ -582,5 +1142,69 @@ func toProtoFullStatus(fullStatus peer.FullStatus) *proto.FullStatus {
 		pbFullStatus.Relays = append(pbFullStatus.Relays, pbRelayState)
 	}
 
+	pbFullStatus.DnsState = &proto.DNSState{
+		Queries:          fullStatus.DNSStats.Queries,
+		LocalRecordHits:  fullStatus.DNSStats.LocalRecordHits,
+		UpstreamFailures: fullStatus.DNSStats.UpstreamFailures,
+		DomainQueries:    fullStatus.DNSStats.DomainQueries,
+	}
+	pbFullStatus.ConnAttempts = toProtoConnAttempts(fullStatus.ConnAttempts)
+
 	return &pbFullStatus
 }
+
+func toProtoPeerState(peerState peer.State) *proto.PeerState {
+	return &proto.PeerState{
+		IP:                         peerState.IP,
+		PubKey:                     peerState.PubKey,
+		ConnStatus:                 peerState.ConnStatus.String(),
+		ConnStatusUpdate:           timestamppb.New(peerState.ConnStatusUpdate),
+		Relayed:                    peerState.Relayed,
+		Direct:                     peerState.Direct,
+		LocalIceCandidateType:      peerState.LocalIceCandidateType,
+		RemoteIceCandidateType:     peerState.RemoteIceCandidateType,
+		LocalIceCandidateEndpoint:  peerState.LocalIceCandidateEndpoint,
+		RemoteIceCandidateEndpoint: peerState.RemoteIceCandidateEndpoint,
+		Fqdn:                       peerState.FQDN,
+		LastWireguardHandshake:     timestamppb.New(peerState.LastWireguardHandshake),
+		BytesRx:                    peerState.BytesRx,
+		BytesTx:                    peerState.BytesTx,
+		BytesRxRate:                peerState.BytesRxRate,
+		BytesTxRate:                peerState.BytesTxRate,
+		ConnPhase:                  peerState.ConnPhase.String(),
+		ConnPhaseUpdate:            timestamppb.New(peerState.ConnPhaseUpdate),
+		RelayFallback:              peerState.RelayFallback,
+		RelayByPolicy:              peerState.RelayByPolicy,
+		ConnAttempts:               toProtoConnAttempts(peerState.ConnAttempts),
+	}
+}
+
+// toProtoConnectionEvent converts a connection event of the status recorder, only the peer events carry a peer state
+func toProtoConnectionEvent(event peer.Event) *proto.ConnectionEvent {
+	pbEvent := &proto.ConnectionEvent{
+		Type:      string(event.Type),
+		Time:      timestamppb.New(event.Time),
+		Connected: event.Connected,
+		Fqdn:      event.FQDN,
+		IP:        event.IP,
+	}
+	if event.Type == peer.EventPeer {
+		pbEvent.Peer = toProtoPeerState(event.Peer)
+	}
+	if event.Error != nil {
+		pbEvent.Error = event.Error.Error()
+	}
+	return pbEvent
+}
+
+func toProtoConnAttempts(stats peer.ConnAttemptStats) *proto.ConnAttempts {
+	return &proto.ConnAttempts{
+		Attempts:            stats.Attempts,
+		Succeeded:           stats.Succeeded,
+		RelayFallbacks:      stats.RelayFallbacks,
+		TimedOut:            stats.TimedOut,
+		Failed:              stats.Failed,
+		SuccessRate:         stats.SuccessRate(),
+		MedianEstablishment: durationpb.New(stats.MedianEstablishment),
+	}
+}
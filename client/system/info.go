@@ -31,6 +31,12 @@ type Info struct {
 	CPUs               int
 	WiretrusteeVersion string
 	UIVersion          string
+	// NATMappedAddress is the public IP:port the WireGuard port is mapped to on the gateway, empty if not mapped
+	NATMappedAddress string
+	// AdvertisedNetworks are the networks of the local network the peer offers to route, e.g. 192.168.1.0/24
+	AdvertisedNetworks []string
+	// NATType is the type of the NAT the peer is behind classified with STUN, empty if unknown
+	NATType string
 }
 
 // extractUserAgent extracts Netbird's agent (client) name and version from the outgoing context
@@ -0,0 +1,80 @@
+package system
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// MetaPrivacyOmit sends the field empty, the Management Service keeps the value reported before, if any
+	MetaPrivacyOmit = "omit"
+	// MetaPrivacyAnonymize sends a pseudonym of the field, stable for the peer but unrelated to the value of other peers
+	MetaPrivacyAnonymize = "anonymize"
+)
+
+// metaPrivacyFields are the system info fields the privacy settings apply to, by the name used in the config.
+// The OS type and the versions of the client aren't included because the Management Service relies on them.
+var metaPrivacyFields = map[string]func(info *Info) *string{
+	"hostname":   func(info *Info) *string { return &info.Hostname },
+	"kernel":     func(info *Info) *string { return &info.Kernel },
+	"os_version": func(info *Info) *string { return &info.OSVersion },
+	"platform":   func(info *Info) *string { return &info.Platform },
+	"os":         func(info *Info) *string { return &info.OS },
+}
+
+// ValidateMetaPrivacy checks the privacy settings map known field names to MetaPrivacyOmit or MetaPrivacyAnonymize
+func ValidateMetaPrivacy(privacy map[string]string) error {
+	for field, mode := range privacy {
+		if _, ok := metaPrivacyFields[field]; !ok {
+			fields := make([]string, 0, len(metaPrivacyFields))
+			for name := range metaPrivacyFields {
+				fields = append(fields, name)
+			}
+			sort.Strings(fields)
+			return fmt.Errorf("unsupported system meta field %q, expecting one of %s", field, strings.Join(fields, ", "))
+		}
+		if mode != MetaPrivacyOmit && mode != MetaPrivacyAnonymize {
+			return fmt.Errorf("unsupported privacy mode %q of system meta field %s, expecting %s or %s",
+				mode, field, MetaPrivacyOmit, MetaPrivacyAnonymize)
+		}
+	}
+	return nil
+}
+
+// ApplyMetaPrivacy omits or anonymizes the fields of the info according to the privacy settings.
+// The pseudonyms are keyed with the secret, e.g. the WireGuard private key of the peer, so they can't be reversed
+// by hashing the likely values and don't change between the logins of the peer.
+// Unknown fields and modes are ignored, they are rejected by ValidateMetaPrivacy when the config is read.
+func (i *Info) ApplyMetaPrivacy(privacy map[string]string, secret string) {
+	for field, mode := range privacy {
+		getField, ok := metaPrivacyFields[field]
+		if !ok {
+			continue
+		}
+
+		value := getField(i)
+		switch mode {
+		case MetaPrivacyOmit:
+			*value = ""
+		case MetaPrivacyAnonymize:
+			*value = anonymize(field, *value, secret)
+		}
+	}
+}
+
+// anonymize returns a pseudonym of the value usable as a host name, e.g. "peer-3f2a9c1b" for the hostname
+// and "kernel-0d41e6a7" for the kernel
+func anonymize(field, value, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(field + "\x00" + value))
+
+	prefix := strings.ReplaceAll(field, "_", "-")
+	if field == "hostname" {
+		prefix = "peer"
+	}
+	return prefix + "-" + hex.EncodeToString(mac.Sum(nil))[:8]
+}
@@ -0,0 +1,46 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMetaPrivacy(t *testing.T) {
+	assert.NoError(t, ValidateMetaPrivacy(nil))
+	assert.NoError(t, ValidateMetaPrivacy(map[string]string{"hostname": MetaPrivacyAnonymize, "kernel": MetaPrivacyOmit}))
+	assert.Error(t, ValidateMetaPrivacy(map[string]string{"goos": MetaPrivacyOmit}), "the OS type is required")
+	assert.Error(t, ValidateMetaPrivacy(map[string]string{"hostname": "hide"}))
+}
+
+func TestInfo_ApplyMetaPrivacy(t *testing.T) {
+	newInfo := func() *Info {
+		return &Info{
+			GoOS:               "linux",
+			Kernel:             "Linux",
+			OSVersion:          "6.1.0",
+			Platform:           "x86_64",
+			OS:                 "debian",
+			Hostname:           "alice-laptop",
+			WiretrusteeVersion: "0.24.0",
+		}
+	}
+	privacy := map[string]string{"hostname": MetaPrivacyAnonymize, "os_version": MetaPrivacyOmit}
+
+	info := newInfo()
+	info.ApplyMetaPrivacy(privacy, "secret")
+	assert.Empty(t, info.OSVersion)
+	assert.Regexp(t, `^peer-[0-9a-f]{8}$`, info.Hostname)
+	assert.Equal(t, "Linux", info.Kernel, "fields without settings should be reported")
+	assert.Equal(t, "linux", info.GoOS)
+	assert.Equal(t, "0.24.0", info.WiretrusteeVersion)
+
+	again := newInfo()
+	again.ApplyMetaPrivacy(privacy, "secret")
+	require.Equal(t, info.Hostname, again.Hostname, "the pseudonym should be stable")
+
+	other := newInfo()
+	other.ApplyMetaPrivacy(privacy, "other secret")
+	assert.NotEqual(t, info.Hostname, other.Hostname, "the pseudonym should depend on the secret")
+}
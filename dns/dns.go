@@ -31,6 +31,9 @@ type Config struct {
 	NameServerGroups []*NameServerGroup
 	// CustomZones contains a list of custom zone
 	CustomZones []CustomZone
+	// RouteAllDisabled keeps the DNS queries of the host to the domains of the nameserver groups and the custom zones
+	// even if a primary nameserver group is set, for the operating systems the account disables the primary DNS for
+	RouteAllDisabled bool
 }
 
 // CustomZone represents a custom zone to be resolved by the dns server
@@ -43,7 +46,8 @@ type CustomZone struct {
 
 // SimpleRecord provides a simple DNS record specification for CNAME, A and AAAA records
 type SimpleRecord struct {
-	// Name domain name
+	// Name domain name. A name with a leading "*" label is a wildcard, e.g. *.svc.internal, answering the queries
+	// of any name under the rest of the name with no record of its own, the most specific wildcard first
 	Name string
 	// Type of record, 1 for A, 5 for CNAME, 28 for AAAA. see https://pkg.go.dev/github.com/miekg/dns@v1.1.41#pkg-constants
 	Type int
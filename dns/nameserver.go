@@ -81,6 +81,12 @@ type NameServer struct {
 	Port int
 }
 
+// MatchOnly returns true when the group domains are used only to route queries for
+// names under them and are never added to the host search domain list
+func (g *NameServerGroup) MatchOnly() bool {
+	return !g.Primary && !g.SearchDomainsEnabled
+}
+
 // EventMeta returns activity event meta related to the nameserver group
 func (g *NameServerGroup) EventMeta() map[string]any {
 	return map[string]any{"name": g.Name}
@@ -0,0 +1,65 @@
+package encryption
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"google.golang.org/protobuf/proto"
+)
+
+// networkMapSignatureContext separates the network map signatures from other signatures of the same key
+const networkMapSignatureContext = "netbird network map v1\x00"
+
+// ErrInvalidSignature is returned when a signature doesn't match the signed message
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// SignNetworkMap signs the network map sent to the peer with the signing key of the server. The signature covers the
+// WireGuard public key of the peer, the serial and the hash of the network map, so a map can't be replayed to another
+// peer or with another serial.
+func SignNetworkMap(signingKey ed25519.PrivateKey, peerKey wgtypes.Key, serial uint64, networkMap proto.Message) ([]byte, error) {
+	payload, err := networkMapSignaturePayload(peerKey, serial, networkMap)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.Sign(signingKey, payload), nil
+}
+
+// VerifyNetworkMap checks the signature of the network map received by the peer was made with the signing key of
+// the server, see SignNetworkMap
+func VerifyNetworkMap(verifyKey ed25519.PublicKey, peerKey wgtypes.Key, serial uint64, networkMap proto.Message, signature []byte) error {
+	if len(verifyKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid signing key size %d", len(verifyKey))
+	}
+
+	payload, err := networkMapSignaturePayload(peerKey, serial, networkMap)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(verifyKey, payload, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// networkMapSignaturePayload returns the signed bytes of the network map. The map is marshalled deterministically, the
+// fields unknown to an older peer are marshalled after the known ones, which keeps the order of the server as long as
+// the new fields get the highest numbers of their message.
+func networkMapSignaturePayload(peerKey wgtypes.Key, serial uint64, networkMap proto.Message) ([]byte, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(networkMap)
+	if err != nil {
+		return nil, fmt.Errorf("marshal the network map: %w", err)
+	}
+	hash := sha256.Sum256(data)
+
+	payload := make([]byte, 0, len(networkMapSignatureContext)+len(peerKey)+8+len(hash))
+	payload = append(payload, networkMapSignatureContext...)
+	payload = append(payload, peerKey[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, serial)
+	payload = append(payload, hash[:]...)
+	return payload, nil
+}
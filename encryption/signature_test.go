@@ -0,0 +1,71 @@
+package encryption_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/encryption"
+	"github.com/netbirdio/netbird/encryption/testprotos"
+)
+
+var _ = Describe("Network map signature", func() {
+
+	var (
+		verifyKey  ed25519.PublicKey
+		signingKey ed25519.PrivateKey
+		peerKey    wgtypes.Key
+		networkMap *testprotos.TestMessage
+		signature  []byte
+	)
+
+	BeforeEach(func() {
+		var err error
+		verifyKey, signingKey, err = ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		peerKey, err = wgtypes.GenerateKey()
+		Expect(err).NotTo(HaveOccurred())
+
+		networkMap = &testprotos.TestMessage{Body: "network map"}
+		signature, err = encryption.SignNetworkMap(signingKey, peerKey.PublicKey(), 5, networkMap)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("verifying a signed network map", func() {
+		Specify("should be successful", func() {
+			err := encryption.VerifyNetworkMap(verifyKey, peerKey.PublicKey(), 5, networkMap, signature)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("verifying a tampered network map", func() {
+		Specify("should fail when the map was changed", func() {
+			tampered := &testprotos.TestMessage{Body: "other network map"}
+			err := encryption.VerifyNetworkMap(verifyKey, peerKey.PublicKey(), 5, tampered, signature)
+			Expect(err).To(MatchError(encryption.ErrInvalidSignature))
+		})
+
+		Specify("should fail when the serial was changed", func() {
+			err := encryption.VerifyNetworkMap(verifyKey, peerKey.PublicKey(), 4, networkMap, signature)
+			Expect(err).To(MatchError(encryption.ErrInvalidSignature))
+		})
+
+		Specify("should fail when the map was signed for another peer", func() {
+			otherPeerKey, err := wgtypes.GenerateKey()
+			Expect(err).NotTo(HaveOccurred())
+			err = encryption.VerifyNetworkMap(verifyKey, otherPeerKey.PublicKey(), 5, networkMap, signature)
+			Expect(err).To(MatchError(encryption.ErrInvalidSignature))
+		})
+
+		Specify("should fail with another signing key", func() {
+			otherVerifyKey, _, err := ed25519.GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			err = encryption.VerifyNetworkMap(otherVerifyKey, peerKey.PublicKey(), 5, networkMap, signature)
+			Expect(err).To(MatchError(encryption.ErrInvalidSignature))
+		})
+	})
+
+})
@@ -0,0 +1,19 @@
+package formatter
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewJSONFormatter creates a JSON formatter for the logs ingestion. The caller is reported
+// in the source field added by the ContextHook only, like with the TextFormatter.
+func NewJSONFormatter() *logrus.JSONFormatter {
+	return &logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+		CallerPrettyfier: func(*runtime.Frame) (string, string) {
+			return "", ""
+		},
+	}
+}
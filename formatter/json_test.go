@@ -0,0 +1,33 @@
+package formatter
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLogMessageFormat(t *testing.T) {
+	someEntry := &logrus.Entry{
+		Data:    logrus.Fields{"att1": 1, "source": "some/fancy/path.go:46"},
+		Time:    time.Date(2021, time.Month(2), 21, 1, 10, 30, 0, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "Some Message",
+	}
+
+	result, err := NewJSONFormatter().Format(someEntry)
+	require.NoError(t, err)
+
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal(result, &parsed))
+	assert.Equal(t, map[string]any{
+		"att1":   float64(1),
+		"source": "some/fancy/path.go:46",
+		"time":   "2021-02-21T01:10:30Z",
+		"level":  "warning",
+		"msg":    "Some Message",
+	}, parsed)
+}
@@ -15,3 +15,10 @@ func SetLogcatFormatter(logger *logrus.Logger) {
 	logger.ReportCaller = true
 	logger.AddHook(NewContextHook())
 }
+
+// SetJSONFormatter set the JSON formatter for given logger.
+func SetJSONFormatter(logger *logrus.Logger) {
+	logger.Formatter = NewJSONFormatter()
+	logger.ReportCaller = true
+	logger.AddHook(NewContextHook())
+}
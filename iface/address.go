@@ -9,6 +9,9 @@ import (
 type WGAddress struct {
 	IP      net.IP
 	Network *net.IPNet
+	// IPv6 is an optional IPv6 address of the interface, nil when the network has no IPv6 allocation
+	IPv6      net.IP
+	NetworkV6 *net.IPNet
 }
 
 // parseWGAddress parse a string ("1.2.3.4/24") address to WG Address
@@ -27,3 +30,12 @@ func (addr WGAddress) String() string {
 	maskSize, _ := addr.Network.Mask.Size()
 	return fmt.Sprintf("%s/%d", addr.IP.String(), maskSize)
 }
+
+// StringV6 returns the IPv6 address with the prefix length, e.g. fd00:1234::1/64, or an empty string if there is none
+func (addr WGAddress) StringV6() string {
+	if addr.IPv6 == nil || addr.NetworkV6 == nil {
+		return ""
+	}
+	maskSize, _ := addr.NetworkV6.Mask.Size()
+	return fmt.Sprintf("%s/%d", addr.IPv6.String(), maskSize)
+}
@@ -67,6 +67,35 @@ func (w *WGIface) UpdateAddr(newAddr string) error {
 		return err
 	}
 
+	current := w.tun.WgAddress()
+	addr.IPv6 = current.IPv6
+	addr.NetworkV6 = current.NetworkV6
+
+	return w.tun.UpdateAddr(addr)
+}
+
+// UpdateAddrV6 updates the IPv6 address of the interface, an empty address removes it.
+// The IPv6 address is assigned to the interface on Linux, macOS and Windows only.
+func (w *WGIface) UpdateAddrV6(newAddr string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	addr := w.tun.WgAddress()
+	addr.IPv6 = nil
+	addr.NetworkV6 = nil
+
+	if newAddr != "" {
+		addrV6, err := parseWGAddress(newAddr)
+		if err != nil {
+			return err
+		}
+		if addrV6.IP.To4() != nil {
+			return fmt.Errorf("address %s is not an IPv6 address", newAddr)
+		}
+		addr.IPv6 = addrV6.IP
+		addr.NetworkV6 = addrV6.Network
+	}
+
 	return w.tun.UpdateAddr(addr)
 }
 
@@ -107,6 +136,16 @@ func (w *WGIface) RemoveAllowedIP(peerKey string, allowedIP string) error {
 	return w.configurer.removeAllowedIP(peerKey, allowedIP)
 }
 
+// SetFwmark sets the firewall mark of the packets the interface sends to the peers, 0 clears it.
+// Configuring the interface resets the mark, so it is set once the interface is up.
+func (w *WGIface) SetFwmark(mark int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	log.Debugf("setting firewall mark of interface %s to 0x%x", w.tun.DeviceName(), mark)
+	return w.configurer.setFwmark(mark)
+}
+
 // Close closes the tunnel interface
 func (w *WGIface) Close() error {
 	w.mu.Lock()
@@ -146,6 +185,21 @@ func (w *WGIface) GetDevice() *DeviceWrapper {
 	return w.tun.Wrapper()
 }
 
+// DeviceState returns the configuration and the state of the interface and its peers, like wg show does.
+// The private and preshared keys are left out.
+func (w *WGIface) DeviceState() (*wgtypes.Device, error) {
+	dev, err := w.configurer.getDevice()
+	if err != nil {
+		return nil, err
+	}
+
+	dev.PrivateKey = wgtypes.Key{}
+	for i := range dev.Peers {
+		dev.Peers[i].PresharedKey = wgtypes.Key{}
+	}
+	return dev, nil
+}
+
 // GetStats returns the last handshake time, rx and tx bytes for the given peer
 func (w *WGIface) GetStats(peerKey string) (WGStats, error) {
 	return w.configurer.getStats(peerKey)
@@ -83,6 +83,86 @@ func TestWGIface_UpdateAddr(t *testing.T) {
 
 }
 
+func TestWGIface_UpdateAddrV6(t *testing.T) {
+	ifaceName := fmt.Sprintf("utun%d", WgIntNumber+5)
+	addr := "100.64.0.1/8"
+	addrV6 := "fd00:1234:5678::1/64"
+	wgPort := 33100
+	newNet, err := stdnet.NewNet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iface, err := NewWGIFace(ifaceName, addr, wgPort, key, DefaultMTU, newNet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = iface.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = iface.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	_, err = iface.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = iface.UpdateAddrV6("100.64.0.2/8")
+	assert.Error(t, err, "IPv4 address should be rejected")
+
+	err = iface.UpdateAddrV6(addrV6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, addrV6, iface.Address().StringV6())
+
+	addrs, err := getIfaceAddrs(ifaceName)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Contains(t, addrStrings(addrs), addr)
+	assert.Contains(t, addrStrings(addrs), addrV6)
+
+	// updating the IPv4 address keeps the IPv6 address
+	addr = "100.64.0.2/8"
+	err = iface.UpdateAddr(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addrs, err = getIfaceAddrs(ifaceName)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Contains(t, addrStrings(addrs), addr)
+	assert.Contains(t, addrStrings(addrs), addrV6)
+
+	err = iface.UpdateAddrV6("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addrs, err = getIfaceAddrs(ifaceName)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.NotContains(t, addrStrings(addrs), addrV6)
+}
+
+func addrStrings(addrs []net.Addr) []string {
+	var result []string
+	for _, addr := range addrs {
+		result = append(result, addr.String())
+	}
+	return result
+}
+
 func getIfaceAddrs(ifaceName string) ([]net.Addr, error) {
 	ief, err := net.InterfaceByName(ifaceName)
 	if err != nil {
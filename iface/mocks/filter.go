@@ -34,6 +34,20 @@ func (m *MockPacketFilter) EXPECT() *MockPacketFilterMockRecorder {
 	return m.recorder
 }
 
+// AddTCPPacketHook mocks base method.
+func (m *MockPacketFilter) AddTCPPacketHook(arg0 bool, arg1 net.IP, arg2 uint16, arg3 func([]byte) bool) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTCPPacketHook", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// AddTCPPacketHook indicates an expected call of AddTCPPacketHook.
+func (mr *MockPacketFilterMockRecorder) AddTCPPacketHook(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTCPPacketHook", reflect.TypeOf((*MockPacketFilter)(nil).AddTCPPacketHook), arg0, arg1, arg2, arg3)
+}
+
 // AddUDPPacketHook mocks base method.
 func (m *MockPacketFilter) AddUDPPacketHook(arg0 bool, arg1 net.IP, arg2 uint16, arg3 func([]byte) bool) string {
 	m.ctrl.T.Helper()
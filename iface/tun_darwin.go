@@ -5,6 +5,7 @@ package iface
 
 import (
 	"os/exec"
+	"strconv"
 
 	"github.com/pion/transport/v3"
 	log "github.com/sirupsen/logrus"
@@ -130,5 +131,16 @@ func (t *tunDevice) assignAddr() error {
 		log.Printf(`adding route command "%v" failed with output %s and error: `, routeCmd.String(), out)
 		return err
 	}
+
+	if t.address.IPv6 == nil || t.address.NetworkV6 == nil {
+		return nil
+	}
+
+	prefixLen, _ := t.address.NetworkV6.Mask.Size()
+	cmd = exec.Command("ifconfig", t.name, "inet6", t.address.IPv6.String(), "prefixlen", strconv.Itoa(prefixLen), "alias")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.Infof(`adding address command "%v" failed with output %s and error: `, cmd.String(), out)
+		return err
+	}
 	return nil
 }
@@ -203,6 +203,18 @@ func (t *tunKernelDevice) assignAddr() error {
 	} else if err != nil {
 		return err
 	}
+
+	if addrV6 := t.address.StringV6(); addrV6 != "" {
+		log.Debugf("adding address %s to interface: %s", addrV6, t.name)
+		addr, _ := netlink.ParseAddr(addrV6)
+		err = netlink.AddrAdd(link, addr)
+		if os.IsExist(err) {
+			log.Infof("interface %s already has the address: %s", t.name, addrV6)
+		} else if err != nil {
+			return err
+		}
+	}
+
 	// On linux, the link must be brought up
 	err = netlink.LinkSetUp(link)
 	return err
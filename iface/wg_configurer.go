@@ -2,6 +2,7 @@ package iface
 
 import (
 	"net"
+	"strings"
 	"time"
 
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
@@ -9,10 +10,25 @@ import (
 
 type wgConfigurer interface {
 	configureInterface(privateKey string, port int) error
+	setFwmark(mark int) error
 	updatePeer(peerKey string, allowedIps string, keepAlive time.Duration, endpoint *net.UDPAddr, preSharedKey *wgtypes.Key) error
 	removePeer(peerKey string) error
 	addAllowedIP(peerKey string, allowedIP string) error
 	removeAllowedIP(peerKey string, allowedIP string) error
 	close()
 	getStats(peerKey string) (WGStats, error)
+	getDevice() (*wgtypes.Device, error)
+}
+
+// parseAllowedIPs parses a comma separated list of allowed IPs, e.g. "100.64.0.1/32,fd00:1234::1/128"
+func parseAllowedIPs(allowedIps string) ([]net.IPNet, error) {
+	var ipNets []net.IPNet
+	for _, allowedIP := range strings.Split(allowedIps, ",") {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(allowedIP))
+		if err != nil {
+			return nil, err
+		}
+		ipNets = append(ipNets, *ipNet)
+	}
+	return ipNets, nil
 }
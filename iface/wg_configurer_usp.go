@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net"
+	"net/netip"
 	"os"
 	"runtime"
 	"strconv"
@@ -48,9 +49,13 @@ func (c *wgUSPConfigurer) configureInterface(privateKey string, port int) error
 	return c.device.IpcSet(toWgUserspaceString(config))
 }
 
+func (c *wgUSPConfigurer) setFwmark(mark int) error {
+	return c.device.IpcSet(toWgUserspaceString(wgtypes.Config{FirewallMark: &mark}))
+}
+
 func (c *wgUSPConfigurer) updatePeer(peerKey string, allowedIps string, keepAlive time.Duration, endpoint *net.UDPAddr, preSharedKey *wgtypes.Key) error {
 	// parse allowed ips
-	_, ipNet, err := net.ParseCIDR(allowedIps)
+	ipNets, err := parseAllowedIPs(allowedIps)
 	if err != nil {
 		return err
 	}
@@ -62,7 +67,7 @@ func (c *wgUSPConfigurer) updatePeer(peerKey string, allowedIps string, keepAliv
 	peer := wgtypes.PeerConfig{
 		PublicKey:                   peerKeyParsed,
 		ReplaceAllowedIPs:           true,
-		AllowedIPs:                  []net.IPNet{*ipNet},
+		AllowedIPs:                  ipNets,
 		PersistentKeepaliveInterval: &keepAlive,
 		PresharedKey:                preSharedKey,
 		Endpoint:                    endpoint,
@@ -248,6 +253,107 @@ func (t *wgUSPConfigurer) getStats(peerKey string) (WGStats, error) {
 	}, nil
 }
 
+func (t *wgUSPConfigurer) getDevice() (*wgtypes.Device, error) {
+	ipc, err := t.device.IpcGet()
+	if err != nil {
+		return nil, fmt.Errorf("ipc get: %w", err)
+	}
+
+	dev, err := parseDeviceInfo(ipc)
+	if err != nil {
+		return nil, err
+	}
+	dev.Name = t.deviceName
+	dev.Type = wgtypes.Userspace
+	return dev, nil
+}
+
+// parseDeviceInfo parses the device configuration returned by the userspace IPC get operation, see
+// https://www.wireguard.com/xplatform/#configuration-protocol
+func parseDeviceInfo(ipcInput string) (*wgtypes.Device, error) {
+	dev := &wgtypes.Device{}
+	var peer *wgtypes.Peer
+	for _, line := range strings.Split(ipcInput, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "private_key":
+			dev.PrivateKey, err = parseHexKey(value)
+			if err == nil {
+				dev.PublicKey = dev.PrivateKey.PublicKey()
+			}
+		case "listen_port":
+			dev.ListenPort, err = strconv.Atoi(value)
+		case "fwmark":
+			dev.FirewallMark, err = strconv.Atoi(value)
+		case "public_key":
+			dev.Peers = append(dev.Peers, wgtypes.Peer{})
+			peer = &dev.Peers[len(dev.Peers)-1]
+			peer.PublicKey, err = parseHexKey(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", key, err)
+		}
+
+		if peer == nil {
+			continue
+		}
+
+		switch key {
+		case "preshared_key":
+			peer.PresharedKey, err = parseHexKey(value)
+		case "endpoint":
+			var addrPort netip.AddrPort
+			addrPort, err = netip.ParseAddrPort(value)
+			peer.Endpoint = net.UDPAddrFromAddrPort(addrPort)
+		case "persistent_keepalive_interval":
+			var interval int
+			interval, err = strconv.Atoi(value)
+			peer.PersistentKeepaliveInterval = time.Duration(interval) * time.Second
+		case "last_handshake_time_sec":
+			var sec int64
+			sec, err = strconv.ParseInt(value, 10, 64)
+			if sec != 0 {
+				peer.LastHandshakeTime = time.Unix(sec, 0)
+			}
+		case "last_handshake_time_nsec":
+			var nsec int64
+			nsec, err = strconv.ParseInt(value, 10, 64)
+			if !peer.LastHandshakeTime.IsZero() {
+				peer.LastHandshakeTime = time.Unix(peer.LastHandshakeTime.Unix(), nsec)
+			}
+		case "rx_bytes":
+			peer.ReceiveBytes, err = strconv.ParseInt(value, 10, 64)
+		case "tx_bytes":
+			peer.TransmitBytes, err = strconv.ParseInt(value, 10, 64)
+		case "allowed_ip":
+			var ipNet *net.IPNet
+			_, ipNet, err = net.ParseCIDR(value)
+			if err == nil {
+				peer.AllowedIPs = append(peer.AllowedIPs, *ipNet)
+			}
+		case "protocol_version":
+			peer.ProtocolVersion, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", key, err)
+		}
+	}
+	return dev, nil
+}
+
+func parseHexKey(value string) (wgtypes.Key, error) {
+	raw, err := hex.DecodeString(value)
+	if err != nil {
+		return wgtypes.Key{}, err
+	}
+	return wgtypes.NewKey(raw)
+}
+
 func findPeerInfo(ipcInput string, peerKey string, searchConfigKeys []string) (map[string]string, error) {
 	peerKeyParsed, err := wgtypes.ParseKey(peerKey)
 	if err != nil {
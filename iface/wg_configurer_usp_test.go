@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -102,3 +103,30 @@ func Test_findPeerInfo(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseDeviceInfo(t *testing.T) {
+	dev, err := parseDeviceInfo(ipcFixture)
+	require.NoError(t, err)
+
+	assert.Equal(t, 12912, dev.ListenPort)
+	assert.NotEqual(t, wgtypes.Key{}, dev.PrivateKey)
+	assert.Equal(t, dev.PrivateKey.PublicKey(), dev.PublicKey)
+	require.Len(t, dev.Peers, 3)
+
+	first := dev.Peers[0]
+	assert.Equal(t, "b85996fecc9c7f1fc6d2572a76eda11d59bcd20be8e543b15ce4bd85a8e75a33", hex.EncodeToString(first.PublicKey[:]))
+	assert.NotEqual(t, wgtypes.Key{}, first.PresharedKey)
+	assert.Equal(t, "[abcd:23::33%2]:51820", first.Endpoint.String())
+	require.Len(t, first.AllowedIPs, 1)
+	assert.Equal(t, "192.168.4.4/32", first.AllowedIPs[0].String())
+
+	second := dev.Peers[1]
+	assert.Equal(t, int64(38333), second.TransmitBytes)
+	assert.Equal(t, int64(2224), second.ReceiveBytes)
+	assert.Equal(t, 111*time.Second, second.PersistentKeepaliveInterval)
+
+	third := dev.Peers[2]
+	assert.Len(t, third.AllowedIPs, 2)
+	assert.Equal(t, 1, third.ProtocolVersion)
+	assert.True(t, third.LastHandshakeTime.IsZero())
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	gstatus "google.golang.org/grpc/status"
+)
+
+// StableConnectionDuration is how long a connection has to last to reset the reconnection backoff.
+// A connection dropped before is retried with the growing interval, so a server accepting and closing
+// the connections right away isn't flooded with reconnections.
+const StableConnectionDuration = 30 * time.Second
+
+// NewBackOff returns the exponential backoff shared by the clients of the Management Service,
+// the daemon, the foreground login of the CLI and the Sync reconnection loop.
+// The interval grows up to 15 seconds and every wait is randomized between zero and twice the interval,
+// so the peers reconnecting after a restart of the Management Service are spread over time.
+// A zero maxElapsedTime retries forever.
+func NewBackOff(maxElapsedTime time.Duration) *backoff.ExponentialBackOff {
+	return &backoff.ExponentialBackOff{
+		InitialInterval:     time.Second,
+		RandomizationFactor: 1,
+		Multiplier:          1.7,
+		MaxInterval:         15 * time.Second,
+		MaxElapsedTime:      maxElapsedTime,
+		Stop:                backoff.Stop,
+		Clock:               backoff.SystemClock,
+	}
+}
+
+// reconnectBackOff waits for the delay the server asked for before the next reconnection instead of the backoff interval
+type reconnectBackOff struct {
+	*backoff.ExponentialBackOff
+	reconnectAfter time.Duration
+}
+
+// NextBackOff returns the delay asked by the server once and the backoff interval otherwise
+func (b *reconnectBackOff) NextBackOff() time.Duration {
+	if b.reconnectAfter > 0 {
+		delay := b.reconnectAfter
+		b.reconnectAfter = 0
+		return delay
+	}
+	return b.ExponentialBackOff.NextBackOff()
+}
+
+// Reset resets the backoff interval and drops the delay asked by the server
+func (b *reconnectBackOff) Reset() {
+	b.reconnectAfter = 0
+	b.ExponentialBackOff.Reset()
+}
+
+// reconnectAfterHint returns the delay the server asked to wait before reconnecting in the retry info of the error
+func reconnectAfterHint(err error) (time.Duration, bool) {
+	s, ok := gstatus.FromError(err)
+	if !ok {
+		return 0, false
+	}
+
+	for _, detail := range s.Details() {
+		if retryInfo, ok := detail.(*errdetails.RetryInfo); ok && retryInfo.GetRetryDelay() != nil {
+			delay := retryInfo.GetRetryDelay().AsDuration()
+			if delay <= 0 {
+				return 0, false
+			}
+			return delay, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	gstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestReconnectAfterHint(t *testing.T) {
+	withHint, err := gstatus.New(codes.Unavailable, "shutting down").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(7 * time.Second)})
+	require.NoError(t, err)
+
+	delay, ok := reconnectAfterHint(withHint.Err())
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, delay)
+
+	_, ok = reconnectAfterHint(gstatus.Error(codes.Unavailable, "connection reset"))
+	assert.False(t, ok, "status without retry info shouldn't have a hint")
+
+	_, ok = reconnectAfterHint(errors.New("EOF"))
+	assert.False(t, ok, "non gRPC error shouldn't have a hint")
+}
+
+func TestReconnectBackOff(t *testing.T) {
+	b := &reconnectBackOff{ExponentialBackOff: NewBackOff(0)}
+	b.Reset()
+
+	b.reconnectAfter = 20 * time.Second
+	assert.Equal(t, 20*time.Second, b.NextBackOff(), "the server hint should be used first")
+
+	for i := 0; i < 20; i++ {
+		next := b.NextBackOff()
+		assert.LessOrEqual(t, next, 2*b.MaxInterval, "the jittered interval should be capped")
+	}
+
+	b.reconnectAfter = 5 * time.Second
+	b.Reset()
+	assert.LessOrEqual(t, b.NextBackOff(), 2*b.InitialInterval, "reset should drop the hint and the grown interval")
+}
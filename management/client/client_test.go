@@ -2,6 +2,8 @@ package client
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"net"
 	"path/filepath"
 	"sync"
@@ -14,6 +16,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/netbirdio/netbird/encryption"
 	mgmtProto "github.com/netbirdio/netbird/management/proto"
@@ -24,6 +27,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/netbirdio/netbird/util"
 )
@@ -133,7 +137,7 @@ func TestClient_GetServerPublicKey(t *testing.T) {
 	s, listener := startManagement(t)
 	defer closeManagementSilently(s, listener)
 
-	client, err := NewClient(ctx, listener.Addr().String(), testKey, false)
+	client, err := NewClient(ctx, listener.Addr().String(), testKey, false, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -147,6 +151,148 @@ func TestClient_GetServerPublicKey(t *testing.T) {
 	}
 }
 
+func TestClient_GetServerPublicKeyCached(t *testing.T) {
+	s, lis, mgmtMockServer, serverKey := startMockManagement(t)
+	defer s.GracefulStop()
+
+	var mux sync.Mutex
+	requests := 0
+	expiresAt := time.Now().Add(time.Hour)
+	mgmtMockServer.GetServerKeyFunc = func(context.Context, *mgmtProto.Empty) (*mgmtProto.ServerKeyResponse, error) {
+		mux.Lock()
+		defer mux.Unlock()
+		requests++
+		return &mgmtProto.ServerKeyResponse{
+			Key:       serverKey.PublicKey().String(),
+			ExpiresAt: timestamppb.New(expiresAt),
+		}, nil
+	}
+	requestCount := func() int {
+		mux.Lock()
+		defer mux.Unlock()
+		return requests
+	}
+
+	testKey, err := wgtypes.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(context.Background(), lis.Addr().String(), testKey, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		key, err := client.GetServerPublicKey()
+		assert.NoError(t, err)
+		assert.Equal(t, serverKey.PublicKey(), *key)
+	}
+	assert.Equal(t, 1, requestCount(), "the key should be cached until it expires")
+
+	client.dropServerKeyOnError(status.Error(codes.InvalidArgument, "invalid request message"))
+	_, err = client.GetServerPublicKey()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requestCount(), "the key should be fetched again when the server couldn't decrypt a request")
+
+	mux.Lock()
+	expiresAt = time.Now().Add(-time.Second)
+	mux.Unlock()
+	client.dropServerKeyOnError(status.Error(codes.InvalidArgument, "invalid request message"))
+	for i := 0; i < 2; i++ {
+		_, err = client.GetServerPublicKey()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 4, requestCount(), "the expired key should not be cached")
+}
+
+func TestClient_ClockSkew(t *testing.T) {
+	s, lis, mgmtMockServer, serverKey := startMockManagement(t)
+	defer s.GracefulStop()
+
+	testKey, err := wgtypes.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(context.Background(), lis.Addr().String(), testKey, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetServerPublicKey()
+	require.NoError(t, err)
+	assert.Zero(t, client.ClockSkew(), "the skew should be unknown when the server doesn't report its time")
+
+	skew := 10 * time.Minute
+	mgmtMockServer.GetServerKeyFunc = func(context.Context, *mgmtProto.Empty) (*mgmtProto.ServerKeyResponse, error) {
+		serverTime := time.Now().Add(skew)
+		return &mgmtProto.ServerKeyResponse{
+			Key:        serverKey.PublicKey().String(),
+			ExpiresAt:  timestamppb.New(serverTime.Add(time.Hour)),
+			ServerTime: timestamppb.New(serverTime),
+		}, nil
+	}
+	client.dropServerKeyOnError(status.Error(codes.InvalidArgument, "invalid request message"))
+
+	_, err = client.GetServerPublicKey()
+	require.NoError(t, err)
+	assert.InDelta(t, skew, client.ClockSkew(), float64(time.Second), "the skew of the server clock should be detected")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), client.serverKeyExpiresAt, time.Second,
+		"the key expiration should be converted to the local clock")
+}
+
+func TestClient_PinNetworkMapVerifyKey(t *testing.T) {
+	s, lis, mgmtMockServer, serverKey := startMockManagement(t)
+	defer s.GracefulStop()
+
+	firstSigningKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	swappedSigningKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	signingKey := firstSigningKey
+	mgmtMockServer.GetServerKeyFunc = func(context.Context, *mgmtProto.Empty) (*mgmtProto.ServerKeyResponse, error) {
+		return &mgmtProto.ServerKeyResponse{
+			Key:                  serverKey.PublicKey().String(),
+			ExpiresAt:            timestamppb.New(time.Now().Add(-time.Second)),
+			NetworkMapSigningKey: signingKey,
+		}, nil
+	}
+
+	testKey, err := wgtypes.GenerateKey()
+	require.NoError(t, err)
+
+	client, err := NewClient(context.Background(), lis.Addr().String(), testKey, false, nil, nil)
+	require.NoError(t, err)
+
+	_, err = client.GetServerPublicKey()
+	require.NoError(t, err)
+	assert.Equal(t, firstSigningKey, client.NetworkMapVerifyKey(), "the signing key should be pinned on first use")
+
+	signingKey = swappedSigningKey
+	_, err = client.GetServerPublicKey()
+	require.NoError(t, err, "a changed signing key should be accepted in the non-strict mode")
+	assert.Equal(t, firstSigningKey, client.NetworkMapVerifyKey(), "a changed signing key shouldn't replace the pinned key")
+
+	client.SetStrictNetworkMapSignature(true)
+	_, err = client.GetServerPublicKey()
+	assert.Error(t, err, "a changed signing key should be rejected in the strict mode")
+
+	client.PinNetworkMapVerifyKey(swappedSigningKey)
+	_, err = client.GetServerPublicKey()
+	assert.NoError(t, err, "the signing key matching the configured key should be accepted")
+}
+
+func TestClockSkew(t *testing.T) {
+	requestedAt := time.Unix(1700000000, 0)
+	respondedAt := requestedAt.Add(2 * time.Second)
+
+	assert.Equal(t, time.Duration(0), clockSkew(requestedAt.Add(time.Second), requestedAt, respondedAt))
+	assert.Equal(t, time.Minute, clockSkew(requestedAt.Add(time.Minute+time.Second), requestedAt, respondedAt))
+	assert.Equal(t, -time.Minute, clockSkew(requestedAt.Add(-time.Minute+time.Second), requestedAt, respondedAt))
+}
+
 func TestClient_LoginUnregistered_ShouldThrow_401(t *testing.T) {
 	testKey, err := wgtypes.GenerateKey()
 	if err != nil {
@@ -156,7 +302,7 @@ func TestClient_LoginUnregistered_ShouldThrow_401(t *testing.T) {
 	s, listener := startManagement(t)
 	defer closeManagementSilently(s, listener)
 
-	client, err := NewClient(ctx, listener.Addr().String(), testKey, false)
+	client, err := NewClient(ctx, listener.Addr().String(), testKey, false, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -183,7 +329,7 @@ func TestClient_LoginRegistered(t *testing.T) {
 	s, listener := startManagement(t)
 	defer closeManagementSilently(s, listener)
 
-	client, err := NewClient(ctx, listener.Addr().String(), testKey, false)
+	client, err := NewClient(ctx, listener.Addr().String(), testKey, false, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -212,7 +358,7 @@ func TestClient_Sync(t *testing.T) {
 	s, listener := startManagement(t)
 	defer closeManagementSilently(s, listener)
 
-	client, err := NewClient(ctx, listener.Addr().String(), testKey, false)
+	client, err := NewClient(ctx, listener.Addr().String(), testKey, false, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -233,7 +379,7 @@ func TestClient_Sync(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	remoteClient, err := NewClient(context.TODO(), listener.Addr().String(), remoteKey, false)
+	remoteClient, err := NewClient(context.TODO(), listener.Addr().String(), remoteKey, false, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -244,10 +390,13 @@ func TestClient_Sync(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// the network maps of the server are signed, the strict mode accepts them
+	client.SetStrictNetworkMapSignature(true)
+
 	ch := make(chan *mgmtProto.SyncResponse, 1)
 
 	go func() {
-		err = client.Sync(func(msg *mgmtProto.SyncResponse) error {
+		err = client.Sync(info, func(msg *mgmtProto.SyncResponse) error {
 			ch <- msg
 			return nil
 		})
@@ -264,6 +413,9 @@ func TestClient_Sync(t *testing.T) {
 		if resp.GetWiretrusteeConfig() == nil {
 			t.Error("expecting non nil WiretrusteeConfig got nil")
 		}
+		if len(resp.GetNetworkMapSignature()) == 0 {
+			t.Error("expecting a signed NetworkMap")
+		}
 		if len(resp.GetRemotePeers()) != 1 {
 			t.Errorf("expecting RemotePeers size %d got %d", 1, len(resp.GetRemotePeers()))
 			return
@@ -291,7 +443,7 @@ func Test_SystemMetaDataFromClient(t *testing.T) {
 	serverAddr := lis.Addr().String()
 	ctx := context.Background()
 
-	testClient, err := NewClient(ctx, serverAddr, testKey, false)
+	testClient, err := NewClient(ctx, serverAddr, testKey, false, nil, nil)
 	if err != nil {
 		t.Fatalf("error while creating testClient: %v", err)
 	}
@@ -370,7 +522,7 @@ func Test_GetDeviceAuthorizationFlow(t *testing.T) {
 	serverAddr := lis.Addr().String()
 	ctx := context.Background()
 
-	client, err := NewClient(ctx, serverAddr, testKey, false)
+	client, err := NewClient(ctx, serverAddr, testKey, false, nil, nil)
 	if err != nil {
 		t.Fatalf("error while creating testClient: %v", err)
 	}
@@ -414,7 +566,7 @@ func Test_GetPKCEAuthorizationFlow(t *testing.T) {
 	serverAddr := lis.Addr().String()
 	ctx := context.Background()
 
-	client, err := NewClient(ctx, serverAddr, testKey, false)
+	client, err := NewClient(ctx, serverAddr, testKey, false, nil, nil)
 	if err != nil {
 		t.Fatalf("error while creating testClient: %v", err)
 	}
@@ -447,3 +599,66 @@ func Test_GetPKCEAuthorizationFlow(t *testing.T) {
 	assert.Equal(t, expectedFlowInfo.ProviderConfig.ClientID, flowInfo.ProviderConfig.ClientID, "provider configured client ID should match")
 	assert.Equal(t, expectedFlowInfo.ProviderConfig.ClientSecret, flowInfo.ProviderConfig.ClientSecret, "provider configured client secret should match")
 }
+
+func TestClient_VerifyNetworkMap(t *testing.T) {
+	peerKey, err := wgtypes.GenerateKey()
+	require.NoError(t, err)
+	verifyKey, signingKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	networkMap := &mgmtProto.NetworkMap{Serial: 5, RemotePeersIsEmpty: true}
+	signature, err := encryption.SignNetworkMap(signingKey, peerKey.PublicKey(), networkMap.GetSerial(), networkMap)
+	require.NoError(t, err)
+
+	tampered := &mgmtProto.NetworkMap{Serial: 5, FirewallRulesIsEmpty: true}
+
+	testCases := []struct {
+		name      string
+		verifyKey ed25519.PublicKey
+		resp      *mgmtProto.SyncResponse
+		strictErr bool
+	}{
+		{
+			name:      "valid signature",
+			verifyKey: verifyKey,
+			resp:      &mgmtProto.SyncResponse{NetworkMap: networkMap, NetworkMapSignature: signature},
+		},
+		{
+			name:      "response without a network map",
+			verifyKey: verifyKey,
+			resp:      &mgmtProto.SyncResponse{WiretrusteeConfig: &mgmtProto.WiretrusteeConfig{}},
+		},
+		{
+			name:      "tampered network map",
+			verifyKey: verifyKey,
+			resp:      &mgmtProto.SyncResponse{NetworkMap: tampered, NetworkMapSignature: signature},
+			strictErr: true,
+		},
+		{
+			name:      "unsigned network map",
+			verifyKey: verifyKey,
+			resp:      &mgmtProto.SyncResponse{NetworkMap: networkMap},
+			strictErr: true,
+		},
+		{
+			name:      "server not signing the network maps",
+			resp:      &mgmtProto.SyncResponse{NetworkMap: networkMap},
+			strictErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := &GrpcClient{key: peerKey, networkMapVerifyKey: testCase.verifyKey}
+			assert.NoError(t, client.verifyNetworkMap(testCase.resp), "non-strict mode should accept the network map")
+
+			client.SetStrictNetworkMapSignature(true)
+			err := client.verifyNetworkMap(testCase.resp)
+			if testCase.strictErr {
+				assert.Error(t, err, "strict mode should reject the network map")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
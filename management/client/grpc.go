@@ -1,11 +1,15 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc/codes"
@@ -24,6 +28,7 @@ import (
 	"github.com/netbirdio/netbird/client/system"
 	"github.com/netbirdio/netbird/encryption"
 	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/util"
 )
 
 // ConnStateNotifier is a wrapper interface of the status recorders
@@ -39,14 +44,34 @@ type GrpcClient struct {
 	conn                  *grpc.ClientConn
 	connStateCallback     ConnStateNotifier
 	connStateCallbackLock sync.RWMutex
+
+	// serverKey is the cached public key of the server, it is fetched again once it expires
+	serverKey          *wgtypes.Key
+	serverKeyExpiresAt time.Time
+	// networkMapVerifyKey is the key the server signs the network maps with, empty when the server doesn't sign them.
+	// It is pinned from the config or on first use, a server key response can't replace it.
+	networkMapVerifyKey ed25519.PublicKey
+	// clockSkew is how far the server clock is ahead of the local clock, measured when fetching the server key
+	clockSkew    time.Duration
+	serverKeyMux sync.Mutex
+
+	// strictNetworkMapSignature rejects the network maps without a valid signature of the server
+	strictNetworkMapSignature atomic.Bool
 }
 
-// NewClient creates a new client to Management service
-func NewClient(ctx context.Context, addr string, ourPrivateKey wgtypes.Key, tlsEnabled bool) (*GrpcClient, error) {
+// NewClient creates a new client to Management service.
+// The tlsConfig customizes the TLS connection when tlsEnabled, a nil tlsConfig trusts the system CA certificates.
+// The connection goes through the proxy of the proxyDialer, a nil proxyDialer uses the proxy environment variables.
+func NewClient(ctx context.Context, addr string, ourPrivateKey wgtypes.Key, tlsEnabled bool, tlsConfig *tls.Config, proxyDialer *util.ProxyDialer) (*GrpcClient, error) {
 	transportOption := grpc.WithTransportCredentials(insecure.NewCredentials())
+	scheme := "http"
 
 	if tlsEnabled {
-		transportOption = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		transportOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+		scheme = "https"
 	}
 
 	mgmCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -56,6 +81,9 @@ func NewClient(ctx context.Context, addr string, ourPrivateKey wgtypes.Key, tlsE
 		addr,
 		transportOption,
 		grpc.WithBlock(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return proxyDialer.DialContext(ctx, scheme, addr)
+		}),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:    30 * time.Second,
 			Timeout: 10 * time.Second,
@@ -88,17 +116,25 @@ func (c *GrpcClient) SetConnStateListener(notifier ConnStateNotifier) {
 	c.connStateCallback = notifier
 }
 
-// defaultBackoff is a basic backoff mechanism for general issues
-func defaultBackoff(ctx context.Context) backoff.BackOff {
-	return backoff.WithContext(&backoff.ExponentialBackOff{
-		InitialInterval:     800 * time.Millisecond,
-		RandomizationFactor: 1,
-		Multiplier:          1.7,
-		MaxInterval:         10 * time.Second,
-		MaxElapsedTime:      3 * 30 * 24 * time.Hour, // 3 months
-		Stop:                backoff.Stop,
-		Clock:               backoff.SystemClock,
-	}, ctx)
+// SetStrictNetworkMapSignature rejects the network maps without a valid signature of the server when strict.
+// Otherwise a missing or an invalid signature is only logged, so the servers not signing the network maps still work.
+func (c *GrpcClient) SetStrictNetworkMapSignature(strict bool) {
+	c.strictNetworkMapSignature.Store(strict)
+}
+
+// PinNetworkMapVerifyKey sets the key the network maps have to be signed with, instead of trusting the key the server
+// returns first. An empty key is pinned on first use.
+func (c *GrpcClient) PinNetworkMapVerifyKey(key ed25519.PublicKey) {
+	c.serverKeyMux.Lock()
+	defer c.serverKeyMux.Unlock()
+	c.networkMapVerifyKey = key
+}
+
+// NetworkMapVerifyKey returns the pinned key the network maps are verified with, empty if none is pinned yet
+func (c *GrpcClient) NetworkMapVerifyKey() ed25519.PublicKey {
+	c.serverKeyMux.Lock()
+	defer c.serverKeyMux.Unlock()
+	return c.networkMapVerifyKey
 }
 
 // ready indicates whether the client is okay and ready to be used
@@ -108,9 +144,11 @@ func (c *GrpcClient) ready() bool {
 }
 
 // Sync wraps the real client's Sync endpoint call and takes care of retries and encryption/decryption of messages
+// The networks advertised by the peer are taken from the sysInfo, the other system info isn't sent on sync
 // Blocking request. The result will be sent via msgHandler callback function
-func (c *GrpcClient) Sync(msgHandler func(msg *proto.SyncResponse) error) error {
-	backOff := defaultBackoff(c.ctx)
+func (c *GrpcClient) Sync(sysInfo *system.Info, msgHandler func(msg *proto.SyncResponse) error) error {
+	reconnect := &reconnectBackOff{ExponentialBackOff: NewBackOff(3 * 30 * 24 * time.Hour)} // 3 months
+	backOff := backoff.WithContext(reconnect, c.ctx)
 
 	operation := func() error {
 		log.Debugf("management connection state %v", c.conn.GetState())
@@ -131,9 +169,10 @@ func (c *GrpcClient) Sync(msgHandler func(msg *proto.SyncResponse) error) error
 
 		ctx, cancelStream := context.WithCancel(c.ctx)
 		defer cancelStream()
-		stream, err := c.connectToStream(ctx, *serverPubKey)
+		stream, err := c.connectToStream(ctx, *serverPubKey, sysInfo)
 		if err != nil {
 			log.Debugf("failed to open Management Service stream: %s", err)
+			c.dropServerKeyOnError(err)
 			if s, ok := gstatus.FromError(err); ok && s.Code() == codes.PermissionDenied {
 				return backoff.Permanent(err) // unrecoverable error, propagate to the upper layer
 			}
@@ -142,9 +181,11 @@ func (c *GrpcClient) Sync(msgHandler func(msg *proto.SyncResponse) error) error
 
 		log.Infof("connected to the Management Service stream")
 		c.notifyConnected()
+		connectedAt := time.Now()
 		// blocking until error
 		err = c.receiveEvents(stream, *serverPubKey, msgHandler)
 		if err != nil {
+			c.dropServerKeyOnError(err)
 			s, _ := gstatus.FromError(err)
 			switch s.Code() {
 			case codes.PermissionDenied:
@@ -153,7 +194,13 @@ func (c *GrpcClient) Sync(msgHandler func(msg *proto.SyncResponse) error) error
 				log.Debugf("management connection context has been canceled, this usually indicates shutdown")
 				return nil
 			default:
-				backOff.Reset() // reset backoff counter after successful connection
+				if time.Since(connectedAt) >= StableConnectionDuration {
+					backOff.Reset() // reset backoff counter after a stable connection
+				}
+				if delay, ok := reconnectAfterHint(err); ok {
+					reconnect.reconnectAfter = delay
+					log.Infof("the Management service asked to reconnect in %s", delay)
+				}
 				c.notifyDisconnected(err)
 				log.Warnf("disconnected from the Management service but will retry silently. Reason: %v", err)
 				return err
@@ -173,7 +220,7 @@ func (c *GrpcClient) Sync(msgHandler func(msg *proto.SyncResponse) error) error
 }
 
 // GetNetworkMap return with the network map
-func (c *GrpcClient) GetNetworkMap() (*proto.NetworkMap, error) {
+func (c *GrpcClient) GetNetworkMap(sysInfo *system.Info) (*proto.NetworkMap, error) {
 	serverPubKey, err := c.GetServerPublicKey()
 	if err != nil {
 		log.Debugf("failed getting Management Service public key: %s", err)
@@ -182,7 +229,7 @@ func (c *GrpcClient) GetNetworkMap() (*proto.NetworkMap, error) {
 
 	ctx, cancelStream := context.WithCancel(c.ctx)
 	defer cancelStream()
-	stream, err := c.connectToStream(ctx, *serverPubKey)
+	stream, err := c.connectToStream(ctx, *serverPubKey, sysInfo)
 	if err != nil {
 		log.Debugf("failed to open Management Service stream: %s", err)
 		return nil, err
@@ -198,6 +245,7 @@ func (c *GrpcClient) GetNetworkMap() (*proto.NetworkMap, error) {
 	}
 	if err != nil {
 		log.Debugf("disconnected from Management Service sync stream: %v", err)
+		c.dropServerKeyOnError(err)
 		return nil, err
 	}
 
@@ -212,11 +260,19 @@ func (c *GrpcClient) GetNetworkMap() (*proto.NetworkMap, error) {
 		return nil, fmt.Errorf("invalid msg, required network map")
 	}
 
+	if err := c.verifyNetworkMap(decryptedResp); err != nil {
+		return nil, err
+	}
+
 	return decryptedResp.GetNetworkMap(), nil
 }
 
-func (c *GrpcClient) connectToStream(ctx context.Context, serverPubKey wgtypes.Key) (proto.ManagementService_SyncClient, error) {
-	req := &proto.SyncRequest{}
+func (c *GrpcClient) connectToStream(ctx context.Context, serverPubKey wgtypes.Key, sysInfo *system.Info) (proto.ManagementService_SyncClient, error) {
+	req := &proto.SyncRequest{
+		AdvertisedNetworks: advertisedNetworks(sysInfo),
+		NatType:            natType(sysInfo),
+		NatMappedAddress:   natMappedAddress(sysInfo),
+	}
 
 	myPrivateKey := c.key
 	myPublicKey := myPrivateKey.PublicKey()
@@ -254,6 +310,11 @@ func (c *GrpcClient) receiveEvents(stream proto.ManagementService_SyncClient, se
 			return err
 		}
 
+		if err := c.verifyNetworkMap(decryptedResp); err != nil {
+			log.Errorf("ignoring the update message from Management Service: %v", err)
+			continue
+		}
+
 		err = msgHandler(decryptedResp)
 		if err != nil {
 			log.Errorf("failed handling an update message received from Management Service: %v", err.Error())
@@ -262,14 +323,24 @@ func (c *GrpcClient) receiveEvents(stream proto.ManagementService_SyncClient, se
 	}
 }
 
-// GetServerPublicKey returns server's WireGuard public key (used later for encrypting messages sent to the server)
+// GetServerPublicKey returns server's WireGuard public key (used later for encrypting messages sent to the server).
+// The key is cached until the expiration time returned by the server.
 func (c *GrpcClient) GetServerPublicKey() (*wgtypes.Key, error) {
 	if !c.ready() {
 		return nil, fmt.Errorf("no connection to management")
 	}
 
+	c.serverKeyMux.Lock()
+	defer c.serverKeyMux.Unlock()
+
+	if c.serverKey != nil && time.Now().Before(c.serverKeyExpiresAt) {
+		serverKey := *c.serverKey
+		return &serverKey, nil
+	}
+
 	mgmCtx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
 	defer cancel()
+	requestedAt := time.Now()
 	resp, err := c.realClient.GetServerKey(mgmCtx, &proto.Empty{})
 	if err != nil {
 		return nil, err
@@ -280,9 +351,97 @@ func (c *GrpcClient) GetServerPublicKey() (*wgtypes.Key, error) {
 		return nil, err
 	}
 
+	// the signing key comes with the unauthenticated server key, a changed key may be swapped by a man in the middle
+	signingKey := resp.GetNetworkMapSigningKey()
+	if len(c.networkMapVerifyKey) > 0 && !bytes.Equal(c.networkMapVerifyKey, signingKey) {
+		if c.strictNetworkMapSignature.Load() {
+			return nil, fmt.Errorf("the network map signing key of the Management Service doesn't match the pinned key")
+		}
+		log.Warnf("the network map signing key of the Management Service doesn't match the pinned key, keeping the pinned key")
+	}
+
+	// the older servers don't return their time, the skew is unknown then
+	c.clockSkew = 0
+	if resp.GetServerTime() != nil {
+		c.clockSkew = clockSkew(resp.GetServerTime().AsTime(), requestedAt, time.Now())
+	}
+
+	// the older servers return an expiration time in the past, their key is never cached. The expiration time is
+	// converted to the local clock.
+	c.serverKey = &serverKey
+	c.serverKeyExpiresAt = resp.GetExpiresAt().AsTime().Add(-c.clockSkew)
+	if len(c.networkMapVerifyKey) == 0 {
+		c.networkMapVerifyKey = signingKey
+	}
+
 	return &serverKey, nil
 }
 
+// ClockSkew returns how far the clock of the server is ahead of the local clock, a negative duration when it is behind.
+// The skew is measured when fetching the server key and is zero when the server doesn't report its time.
+func (c *GrpcClient) ClockSkew() time.Duration {
+	c.serverKeyMux.Lock()
+	defer c.serverKeyMux.Unlock()
+	return c.clockSkew
+}
+
+// clockSkew estimates the skew of the server clock, assuming the server time was taken halfway through the request
+func clockSkew(serverTime, requestedAt, respondedAt time.Time) time.Duration {
+	localTime := requestedAt.Add(respondedAt.Sub(requestedAt) / 2)
+	return serverTime.Sub(localTime)
+}
+
+// verifyNetworkMap checks the signature of the network map of the response, the responses without a network map pass.
+// An unsigned or an invalid network map is an error in the strict mode only.
+func (c *GrpcClient) verifyNetworkMap(resp *proto.SyncResponse) error {
+	networkMap := resp.GetNetworkMap()
+	if networkMap == nil {
+		return nil
+	}
+
+	c.serverKeyMux.Lock()
+	verifyKey := c.networkMapVerifyKey
+	c.serverKeyMux.Unlock()
+
+	var err error
+	switch {
+	case len(verifyKey) == 0:
+		err = fmt.Errorf("the Management Service doesn't sign the network maps")
+	case len(resp.GetNetworkMapSignature()) == 0:
+		err = fmt.Errorf("network map with serial %d isn't signed", networkMap.GetSerial())
+	default:
+		err = encryption.VerifyNetworkMap(verifyKey, c.key.PublicKey(), networkMap.GetSerial(), networkMap, resp.GetNetworkMapSignature())
+		if err != nil {
+			err = fmt.Errorf("verify the signature of the network map with serial %d: %w", networkMap.GetSerial(), err)
+		}
+	}
+
+	if err == nil {
+		return nil
+	}
+	if c.strictNetworkMapSignature.Load() {
+		return err
+	}
+	if len(verifyKey) > 0 && len(resp.GetNetworkMapSignature()) > 0 {
+		log.Warnf("accepting the network map in the non-strict mode: %v", err)
+	} else {
+		log.Debugf("accepting the network map in the non-strict mode: %v", err)
+	}
+	return nil
+}
+
+// dropServerKeyOnError removes the cached server key when the server couldn't decrypt the request,
+// e.g. when the key was rotated or the server restarted with a new key, so the next request fetches it again
+func (c *GrpcClient) dropServerKeyOnError(err error) {
+	if s, ok := gstatus.FromError(err); !ok || s.Code() != codes.InvalidArgument {
+		return
+	}
+
+	c.serverKeyMux.Lock()
+	defer c.serverKeyMux.Unlock()
+	c.serverKey = nil
+}
+
 // IsHealthy probes the gRPC connection and returns false on errors
 func (c *GrpcClient) IsHealthy() bool {
 	switch c.conn.GetState() {
@@ -325,6 +484,7 @@ func (c *GrpcClient) login(serverKey wgtypes.Key, req *proto.LoginRequest) (*pro
 		Body:     loginReq,
 	})
 	if err != nil {
+		c.dropServerKeyOnError(err)
 		return nil, err
 	}
 
@@ -346,7 +506,7 @@ func (c *GrpcClient) Register(serverKey wgtypes.Key, setupKey string, jwtToken s
 		SshPubKey: pubSSHKey,
 		WgPubKey:  []byte(c.key.PublicKey().String()),
 	}
-	return c.login(serverKey, &proto.LoginRequest{SetupKey: setupKey, Meta: infoToMetaData(sysInfo), JwtToken: jwtToken, PeerKeys: keys})
+	return c.login(serverKey, &proto.LoginRequest{SetupKey: setupKey, Meta: infoToMetaData(sysInfo), JwtToken: jwtToken, PeerKeys: keys, AdvertisedNetworks: advertisedNetworks(sysInfo)})
 }
 
 // Login attempts login to Management Server. Takes care of encrypting and decrypting messages.
@@ -355,7 +515,12 @@ func (c *GrpcClient) Login(serverKey wgtypes.Key, sysInfo *system.Info, pubSSHKe
 		SshPubKey: pubSSHKey,
 		WgPubKey:  []byte(c.key.PublicKey().String()),
 	}
-	return c.login(serverKey, &proto.LoginRequest{Meta: infoToMetaData(sysInfo), PeerKeys: keys})
+	return c.login(serverKey, &proto.LoginRequest{
+		Meta:               infoToMetaData(sysInfo),
+		PeerKeys:           keys,
+		NatMappedAddress:   natMappedAddress(sysInfo),
+		AdvertisedNetworks: advertisedNetworks(sysInfo),
+	})
 }
 
 // GetDeviceAuthorizationFlow returns a device authorization flow information.
@@ -378,6 +543,7 @@ func (c *GrpcClient) GetDeviceAuthorizationFlow(serverKey wgtypes.Key) (*proto.D
 		Body:     encryptedMSG},
 	)
 	if err != nil {
+		c.dropServerKeyOnError(err)
 		return nil, err
 	}
 
@@ -412,6 +578,7 @@ func (c *GrpcClient) GetPKCEAuthorizationFlow(serverKey wgtypes.Key) (*proto.PKC
 		Body:     encryptedMSG,
 	})
 	if err != nil {
+		c.dropServerKeyOnError(err)
 		return nil, err
 	}
 
@@ -426,6 +593,83 @@ func (c *GrpcClient) GetPKCEAuthorizationFlow(serverKey wgtypes.Key) (*proto.PKC
 	return flowInfoResp, nil
 }
 
+// CheckSetupKey returns whether the setup key can register a peer, without using it.
+// It also takes care of encrypting and decrypting messages.
+func (c *GrpcClient) CheckSetupKey(serverKey wgtypes.Key, setupKey string) (*proto.SetupKeyCheckResponse, error) {
+	if !c.ready() {
+		return nil, fmt.Errorf("no connection to management in order to check the setup key")
+	}
+	mgmCtx, cancel := context.WithTimeout(c.ctx, time.Second*2)
+	defer cancel()
+
+	message := &proto.SetupKeyCheckRequest{SetupKey: setupKey}
+	encryptedMSG, err := encryption.EncryptMessage(serverKey, c.key, message)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.realClient.CheckSetupKey(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG},
+	)
+	if err != nil {
+		c.dropServerKeyOnError(err)
+		return nil, err
+	}
+
+	checkResp := &proto.SetupKeyCheckResponse{}
+	err = encryption.DecryptMessage(serverKey, c.key, resp.Body, checkResp)
+	if err != nil {
+		errWithMSG := fmt.Errorf("failed to decrypt setup key check message: %s", err)
+		log.Error(errWithMSG)
+		return nil, errWithMSG
+	}
+
+	return checkResp, nil
+}
+
+// RotatePeerKey replaces the WireGuard key of the peer on the Management Service with the new key.
+// The client key is the old one, the client has to be recreated with the new key afterwards.
+func (c *GrpcClient) RotatePeerKey(serverKey wgtypes.Key, newKey wgtypes.Key) error {
+	if !c.ready() {
+		return fmt.Errorf("no connection to management in order to rotate the peer key")
+	}
+	mgmCtx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
+	defer cancel()
+
+	proof, err := encryption.EncryptMessage(serverKey, newKey, &proto.RotatePeerKeyProof{OldPubKey: c.key.PublicKey().String()})
+	if err != nil {
+		return err
+	}
+
+	message := &proto.RotatePeerKeyRequest{
+		NewPubKey:   newKey.PublicKey().String(),
+		NewKeyProof: proof,
+	}
+	encryptedMSG, err := encryption.EncryptMessage(serverKey, c.key, message)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.realClient.RotatePeerKey(mgmCtx, &proto.EncryptedMessage{
+		WgPubKey: c.key.PublicKey().String(),
+		Body:     encryptedMSG},
+	)
+	if err != nil {
+		c.dropServerKeyOnError(err)
+		return err
+	}
+
+	err = encryption.DecryptMessage(serverKey, newKey, resp.Body, &proto.RotatePeerKeyResponse{})
+	if err != nil {
+		errWithMSG := fmt.Errorf("failed to decrypt key rotation message: %s", err)
+		log.Error(errWithMSG)
+		return errWithMSG
+	}
+
+	return nil
+}
+
 func (c *GrpcClient) notifyDisconnected(err error) {
 	c.connStateCallbackLock.RLock()
 	defer c.connStateCallbackLock.RUnlock()
@@ -446,6 +690,27 @@ func (c *GrpcClient) notifyConnected() {
 	c.connStateCallback.MarkManagementConnected()
 }
 
+func natMappedAddress(info *system.Info) string {
+	if info == nil {
+		return ""
+	}
+	return info.NATMappedAddress
+}
+
+func advertisedNetworks(info *system.Info) []string {
+	if info == nil {
+		return nil
+	}
+	return info.AdvertisedNetworks
+}
+
+func natType(info *system.Info) string {
+	if info == nil {
+		return ""
+	}
+	return info.NATType
+}
+
 func infoToMetaData(info *system.Info) *proto.PeerSystemMeta {
 	if info == nil {
 		return nil
@@ -459,5 +724,6 @@ func infoToMetaData(info *system.Info) *proto.PeerSystemMeta {
 		Kernel:             info.Kernel,
 		WiretrusteeVersion: info.WiretrusteeVersion,
 		UiVersion:          info.UIVersion,
+		NatType:            info.NATType,
 	}
 }
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"time"
+
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 
 	"github.com/netbirdio/netbird/client/system"
@@ -9,12 +11,15 @@ import (
 
 type MockClient struct {
 	CloseFunc                      func() error
-	SyncFunc                       func(msgHandler func(msg *proto.SyncResponse) error) error
+	SyncFunc                       func(sysInfo *system.Info, msgHandler func(msg *proto.SyncResponse) error) error
 	GetServerPublicKeyFunc         func() (*wgtypes.Key, error)
+	ClockSkewFunc                  func() time.Duration
 	RegisterFunc                   func(serverKey wgtypes.Key, setupKey string, jwtToken string, info *system.Info, sshKey []byte) (*proto.LoginResponse, error)
 	LoginFunc                      func(serverKey wgtypes.Key, info *system.Info, sshKey []byte) (*proto.LoginResponse, error)
 	GetDeviceAuthorizationFlowFunc func(serverKey wgtypes.Key) (*proto.DeviceAuthorizationFlow, error)
 	GetPKCEAuthorizationFlowFunc   func(serverKey wgtypes.Key) (*proto.PKCEAuthorizationFlow, error)
+	CheckSetupKeyFunc              func(serverKey wgtypes.Key, setupKey string) (*proto.SetupKeyCheckResponse, error)
+	RotatePeerKeyFunc              func(serverKey wgtypes.Key, newKey wgtypes.Key) error
 }
 
 func (m *MockClient) IsHealthy() bool {
@@ -28,11 +33,11 @@ func (m *MockClient) Close() error {
 	return m.CloseFunc()
 }
 
-func (m *MockClient) Sync(msgHandler func(msg *proto.SyncResponse) error) error {
+func (m *MockClient) Sync(sysInfo *system.Info, msgHandler func(msg *proto.SyncResponse) error) error {
 	if m.SyncFunc == nil {
 		return nil
 	}
-	return m.SyncFunc(msgHandler)
+	return m.SyncFunc(sysInfo, msgHandler)
 }
 
 func (m *MockClient) GetServerPublicKey() (*wgtypes.Key, error) {
@@ -42,6 +47,13 @@ func (m *MockClient) GetServerPublicKey() (*wgtypes.Key, error) {
 	return m.GetServerPublicKeyFunc()
 }
 
+func (m *MockClient) ClockSkew() time.Duration {
+	if m.ClockSkewFunc == nil {
+		return 0
+	}
+	return m.ClockSkewFunc()
+}
+
 func (m *MockClient) Register(serverKey wgtypes.Key, setupKey string, jwtToken string, info *system.Info, sshKey []byte) (*proto.LoginResponse, error) {
 	if m.RegisterFunc == nil {
 		return nil, nil
@@ -70,7 +82,21 @@ func (m *MockClient) GetPKCEAuthorizationFlow(serverKey wgtypes.Key) (*proto.PKC
 	return m.GetPKCEAuthorizationFlow(serverKey)
 }
 
+func (m *MockClient) CheckSetupKey(serverKey wgtypes.Key, setupKey string) (*proto.SetupKeyCheckResponse, error) {
+	if m.CheckSetupKeyFunc == nil {
+		return nil, nil
+	}
+	return m.CheckSetupKeyFunc(serverKey, setupKey)
+}
+
+func (m *MockClient) RotatePeerKey(serverKey wgtypes.Key, newKey wgtypes.Key) error {
+	if m.RotatePeerKeyFunc == nil {
+		return nil
+	}
+	return m.RotatePeerKeyFunc(serverKey, newKey)
+}
+
 // GetNetworkMap mock implementation of GetNetworkMap from mgm.Client interface
-func (m *MockClient) GetNetworkMap() (*proto.NetworkMap, error) {
+func (m *MockClient) GetNetworkMap(_ *system.Info) (*proto.NetworkMap, error) {
 	return nil, nil
 }
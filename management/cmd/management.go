@@ -73,7 +73,14 @@ var (
 		Short: "start NetBird Management Server",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			flag.Parse()
-			err := util.InitLog(logLevel, logFile)
+
+			// the old log directory has to be migrated before the log file is created in the new one
+			err := handleRebrand(cmd)
+			if err != nil {
+				return fmt.Errorf("failed to migrate files %v", err)
+			}
+
+			err = util.InitLogWithOptions(logLevel, logFile, logOptions)
 			if err != nil {
 				return fmt.Errorf("failed initializing log %v", err)
 			}
@@ -112,12 +119,7 @@ var (
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := handleRebrand(cmd)
-			if err != nil {
-				return fmt.Errorf("failed to migrate files %v", err)
-			}
-
-			if _, err = os.Stat(config.Datadir); os.IsNotExist(err) {
+			if _, err := os.Stat(config.Datadir); os.IsNotExist(err) {
 				err = os.MkdirAll(config.Datadir, 0755)
 				if err != nil {
 					return fmt.Errorf("failed creating datadir: %s: %v", config.Datadir, err)
@@ -168,6 +170,41 @@ var (
 				return fmt.Errorf("failed to build default manager: %v", err)
 			}
 
+			setupKeyExpiryInterval := server.DefaultSetupKeyExpirySweepInterval
+			var setupKeyExpiryNotifier server.SetupKeyExpiryNotifier
+			if config.SetupKeyExpiry != nil {
+				if config.SetupKeyExpiry.SweepInterval.Duration > 0 {
+					setupKeyExpiryInterval = config.SetupKeyExpiry.SweepInterval.Duration
+				}
+				if config.SetupKeyExpiry.WebhookURL != "" {
+					setupKeyExpiryNotifier = server.NewSetupKeyExpiryWebhook(config.SetupKeyExpiry.WebhookURL)
+				}
+			}
+			accountManager.StartSetupKeyExpirySweep(setupKeyExpiryInterval, setupKeyExpiryNotifier)
+			accountManager.StartPeerInactivitySweep(server.DefaultPeerInactivitySweepInterval)
+
+			if config.IPv6Prefix != "" {
+				if err = accountManager.SetIPv6Prefix(config.IPv6Prefix); err != nil {
+					return fmt.Errorf("failed to enable IPv6 allocation: %v", err)
+				}
+			}
+
+			idpStaleTTL := server.DefaultIdpStaleTTL
+			idpStrict := false
+			if config.IdpOutage != nil {
+				if config.IdpOutage.StaleTTL.Duration > 0 {
+					idpStaleTTL = config.IdpOutage.StaleTTL.Duration
+				}
+				idpStrict = config.IdpOutage.Strict
+			}
+			accountManager.SetIdpOutagePolicy(idpStrict, idpStaleTTL)
+
+			var webhookDispatcher *server.WebhookDispatcher
+			if config.Webhooks != nil {
+				webhookDispatcher = server.NewWebhookDispatcher(config.Webhooks.MaxAttempts)
+				accountManager.SetWebhookDispatcher(webhookDispatcher)
+			}
+
 			turnManager := server.NewTimeBasedAuthSecretsManager(peersUpdateManager, config.TURNConfig)
 
 			trustedPeers := config.TrustedHTTPProxies
@@ -244,6 +281,11 @@ var (
 				return err
 			}
 
+			peerVersionsCtx, cancelPeerVersions := context.WithCancel(context.Background())
+			defer cancelPeerVersions()
+			peerVersionsWorker := metrics.NewPeerVersionsWorker(peerVersionsCtx, store, peersUpdateManager, appMetrics.PeerMetrics())
+			go peerVersionsWorker.Run()
+
 			if !disableMetrics {
 				ctx, cancel := context.WithCancel(context.Background())
 				defer cancel()
@@ -266,7 +308,14 @@ var (
 				log.Infof("running gRPC backward compatibility server: %s", compatListener.Addr().String())
 			}
 
-			rootHandler := handlerFunc(gRPCAPIHandler, httpAPIHandler)
+			basePath := normalizeBasePath(config.HttpConfig.BasePath)
+			if basePath != "" {
+				log.Infof("serving HTTP API and gRPC services under the base path %s", basePath)
+			}
+
+			// the LetsEncrypt challenge handler wraps the root handler, so the challenge is still served at
+			// the well-known path of the domain root regardless of the base path
+			rootHandler := handlerFunc(gRPCAPIHandler, httpAPIHandler, basePath)
 			var listener net.Listener
 			if certManager != nil {
 				// a call to certManager.Listener() always creates a new listener so we do it once
@@ -302,11 +351,17 @@ var (
 
 			<-stopCh
 			ephemeralManager.Stop()
+			accountManager.StopSetupKeyExpirySweep()
+			accountManager.StopPeerInactivitySweep()
+			if webhookDispatcher != nil {
+				webhookDispatcher.Stop()
+			}
 			_ = appMetrics.Close()
 			_ = listener.Close()
 			if certManager != nil {
 				_ = certManager.Listener().Close()
 			}
+			srv.CloseSyncStreams(5 * time.Second)
 			gRPCAPIHandler.Stop()
 			_ = store.Close()
 			_ = eventStore.Close()
@@ -388,8 +443,14 @@ func serveGRPCWithHTTP(listener net.Listener, handler http.Handler, tlsEnabled b
 	}()
 }
 
-func handlerFunc(gRPCHandler *grpc.Server, httpHandler http.Handler) http.Handler {
+// handlerFunc routes the requests to the gRPC or HTTP API handler. When a base path is set, it is stripped
+// from the request path so both handlers keep serving their routes as if they were at the domain root.
+// Requests outside the base path are served as well, for proxies that strip the prefix on their own and
+// gRPC clients that can't dial a path.
+func handlerFunc(gRPCHandler *grpc.Server, httpHandler http.Handler, basePath string) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		request = stripBasePath(request, basePath)
+
 		grpcHeader := strings.HasPrefix(request.Header.Get("Content-Type"), "application/grpc") ||
 			strings.HasPrefix(request.Header.Get("Content-Type"), "application/grpc+proto")
 		if request.ProtoMajor == 2 && grpcHeader {
@@ -400,12 +461,58 @@ func handlerFunc(gRPCHandler *grpc.Server, httpHandler http.Handler) http.Handle
 	})
 }
 
+// normalizeBasePath returns the base path with a leading slash and without a trailing one,
+// or an empty string when the service is served at the domain root
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(strings.TrimSpace(basePath), "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
+// stripBasePath returns a shallow copy of the request with the base path removed from the URL path,
+// or the request itself if the path is not under the base path
+func stripBasePath(request *http.Request, basePath string) *http.Request {
+	if basePath == "" {
+		return request
+	}
+
+	path := request.URL.Path
+	if path != basePath && !strings.HasPrefix(path, basePath+"/") {
+		return request
+	}
+
+	stripped := request.Clone(request.Context())
+	stripped.URL.Path = strings.TrimPrefix(path, basePath)
+	if stripped.URL.Path == "" {
+		stripped.URL.Path = "/"
+	}
+	stripped.URL.RawPath = ""
+	if request.URL.RawPath != "" {
+		stripped.URL.RawPath = strings.TrimPrefix(request.URL.RawPath, basePath)
+	}
+	stripped.RequestURI = stripped.URL.RequestURI()
+	return stripped
+}
+
 func loadMgmtConfig(mgmtConfigPath string) (*server.Config, error) {
 	loadedConfig := &server.Config{}
-	_, err := util.ReadJson(mgmtConfigPath, loadedConfig)
+	_, err := util.ReadJsonStrict(mgmtConfigPath, loadedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading management config file %s: %v", mgmtConfigPath, err)
+	}
+
+	err = loadedConfig.ResolveSecrets()
 	if err != nil {
 		return nil, err
 	}
+
+	err = loadedConfig.Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	if mgmtLetsencryptDomain != "" {
 		loadedConfig.HttpConfig.LetsEncryptDomain = mgmtLetsencryptDomain
 	}
@@ -467,11 +574,40 @@ func loadMgmtConfig(mgmtConfigPath string) (*server.Config, error) {
 		}
 	}
 
+	logMgmtConfig(loadedConfig)
+
 	return loadedConfig, err
 }
 
+// logMgmtConfig logs the loaded config with the secrets redacted
+func logMgmtConfig(config *server.Config) {
+	redacted, err := config.Redacted()
+	if err != nil {
+		log.Warnf("failed redacting management config for logging: %v", err)
+		return
+	}
+
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		log.Warnf("failed marshaling management config for logging: %v", err)
+		return
+	}
+	log.Infof("loaded management config: %s", data)
+}
+
 func updateMgmtConfig(path string, config *server.Config) error {
-	return util.DirectWriteJson(path, config)
+	if !config.HasSecretReferences() {
+		return util.DirectWriteJson(path, config)
+	}
+
+	// write the key to the file as read to keep the secret references instead of the resolved secrets
+	fileConfig := &server.Config{}
+	_, err := util.ReadJsonStrict(path, fileConfig)
+	if err != nil {
+		return fmt.Errorf("failed reading management config file %s: %v", path, err)
+	}
+	fileConfig.DataStoreEncryptionKey = config.DataStoreEncryptionKey
+	return util.DirectWriteJson(path, fileConfig)
 }
 
 // OIDCConfigResponse used for parsing OIDC config response
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestNormalizeBasePath(t *testing.T) {
+	assert.Equal(t, "", normalizeBasePath(""))
+	assert.Equal(t, "", normalizeBasePath("/"))
+	assert.Equal(t, "/netbird", normalizeBasePath("netbird"))
+	assert.Equal(t, "/netbird", normalizeBasePath("/netbird/"))
+	assert.Equal(t, "/apps/netbird", normalizeBasePath(" /apps/netbird/ "))
+}
+
+func TestHandlerFuncBasePath(t *testing.T) {
+	httpRouter := mux.NewRouter()
+	httpRouter.HandleFunc("/api/peers", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+
+	grpcServer := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, health.NewServer())
+
+	testCases := []struct {
+		name               string
+		basePath           string
+		path               string
+		grpc               bool
+		expectedHTTPStatus int
+		expectedGRPCStatus string
+	}{
+		{
+			name:               "HTTP Route At Root",
+			path:               "/api/peers",
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "HTTP Route Under Base Path",
+			basePath:           "/netbird",
+			path:               "/netbird/api/peers",
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "HTTP Route At Root With Base Path",
+			basePath:           "/netbird",
+			path:               "/api/peers",
+			expectedHTTPStatus: http.StatusOK,
+		},
+		{
+			name:               "HTTP Route Under Unknown Prefix",
+			basePath:           "/netbird",
+			path:               "/netbirdx/api/peers",
+			expectedHTTPStatus: http.StatusNotFound,
+		},
+		{
+			name:               "HTTP Route Under Prefix Without Base Path",
+			path:               "/netbird/api/peers",
+			expectedHTTPStatus: http.StatusNotFound,
+		},
+		{
+			name:               "gRPC Method At Root",
+			path:               "/grpc.health.v1.Health/Check",
+			grpc:               true,
+			expectedGRPCStatus: "0",
+		},
+		{
+			name:               "gRPC Method Under Base Path",
+			basePath:           "/netbird",
+			path:               "/netbird/grpc.health.v1.Health/Check",
+			grpc:               true,
+			expectedGRPCStatus: "0",
+		},
+		{
+			name:               "gRPC Method At Root With Base Path",
+			basePath:           "/netbird",
+			path:               "/grpc.health.v1.Health/Check",
+			grpc:               true,
+			expectedGRPCStatus: "0",
+		},
+		{
+			name:               "gRPC Method Under Prefix Without Base Path",
+			path:               "/netbird/grpc.health.v1.Health/Check",
+			grpc:               true,
+			expectedGRPCStatus: codes.Unimplemented.String(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := handlerFunc(grpcServer, httpRouter, testCase.basePath)
+			recorder := httptest.NewRecorder()
+
+			if !testCase.grpc {
+				handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, testCase.path, nil))
+				assert.Equal(t, testCase.expectedHTTPStatus, recorder.Code, "unexpected HTTP status code")
+				return
+			}
+
+			// an empty health check request message: uncompressed flag followed by a zero length
+			req := httptest.NewRequest(http.MethodPost, testCase.path, bytes.NewReader([]byte{0, 0, 0, 0, 0}))
+			req.ProtoMajor = 2
+			req.ProtoMinor = 0
+			req.Header.Set("Content-Type", "application/grpc")
+			req.Header.Set("TE", "trailers")
+			handler.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+			grpcStatus := res.Trailer.Get("Grpc-Status")
+			if testCase.expectedGRPCStatus != "0" {
+				require.NotEqual(t, "0", grpcStatus, "gRPC call should fail")
+				return
+			}
+			assert.Equal(t, testCase.expectedGRPCStatus, grpcStatus, "unexpected gRPC status, message: %s", res.Trailer.Get("Grpc-Message"))
+		})
+	}
+}
+
+func TestHandlerFuncBasePathLetsEncryptChallenge(t *testing.T) {
+	var apiCalled bool
+	httpHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		apiCalled = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	certManager := &autocert.Manager{
+		Prompt: autocert.AcceptTOS,
+		Cache:  autocert.DirCache(t.TempDir()),
+	}
+	handler := certManager.HTTPHandler(handlerFunc(grpc.NewServer(), httpHandler, "/netbird"))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/acme-challenge/token", nil))
+	assert.False(t, apiCalled, "challenge request should be handled by the cert manager")
+	assert.Equal(t, http.StatusNotFound, recorder.Code, "unknown challenge token should not be found")
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/netbird/api/peers", nil))
+	assert.True(t, apiCalled, "API request should be handled by the API handler")
+}
+
+func TestLoadMgmtConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        string
+		expectedError string
+	}{
+		{
+			name: "Valid Config",
+			config: `{
+				"Stuns": [{"Proto": "udp", "URI": "stun:stun.netbird.io:3478"}],
+				"TURNConfig": {"TimeBasedCredentials": true, "Secret": "secret", "Turns": [{"Proto": "udp", "URI": "turn:turn.netbird.io:3478"}]},
+				"Signal": {"Proto": "https", "URI": "signal.netbird.io:443"},
+				"HttpConfig": {"Address": "0.0.0.0:33073", "AuthIssuer": "https://issuer.netbird.io", "AuthKeysLocation": "https://issuer.netbird.io/keys"}
+			}`,
+		},
+		{
+			name: "Unknown Field",
+			config: `{
+				"TURNConfig": {"TimeBasedCredentials": true, "Secrett": "secret"},
+				"Signal": {"Proto": "https", "URI": "signal.netbird.io:443"},
+				"HttpConfig": {}
+			}`,
+			expectedError: `json: unknown field "Secrett"`,
+		},
+		{
+			name: "Issuer Without Keys Location",
+			config: `{
+				"Signal": {"Proto": "https", "URI": "signal.netbird.io:443"},
+				"HttpConfig": {"AuthIssuer": "https://issuer.netbird.io"}
+			}`,
+			expectedError: "invalid management config: HttpConfig.AuthKeysLocation is required when HttpConfig.AuthIssuer is set",
+		},
+		{
+			name: "Multiple Problems",
+			config: `{
+				"Stuns": [{"Proto": "stun", "URI": "stun:stun.netbird.io:3478"}],
+				"TURNConfig": {"TimeBasedCredentials": true},
+				"Signal": {"Proto": "https"},
+				"HttpConfig": {"CertFile": "/etc/certs/cert.pem"}
+			}`,
+			expectedError: `invalid management config: Stuns[0].Proto "stun" is not one of udp, dtls, tcp, http, https; ` +
+				"TURNConfig.Secret is required when TURNConfig.TimeBasedCredentials is enabled; " +
+				"Signal.URI is required; " +
+				"HttpConfig.CertFile and HttpConfig.CertKey should be set together",
+		},
+		{
+			name: "Negative Skew Tolerance",
+			config: `{
+				"TURNConfig": {"TimeBasedCredentials": true, "Secret": "secret", "CredentialsTTL": "1h", "CredentialsSkewTolerance": "-1m"},
+				"Signal": {"Proto": "https", "URI": "signal.netbird.io:443"},
+				"HttpConfig": {}
+			}`,
+			expectedError: "invalid management config: TURNConfig.CredentialsSkewTolerance should not be negative",
+		},
+		{
+			name: "Missing Sections",
+			config: `{
+				"Datadir": "/var/lib/netbird"
+			}`,
+			expectedError: "invalid management config: Signal is required; HttpConfig is required",
+		},
+		{
+			name: "Unresolved Secret Reference",
+			config: `{
+				"TURNConfig": {"TimeBasedCredentials": true, "Secret": "env://NB_TEST_UNSET_TURN_SECRET"},
+				"Signal": {"Proto": "https", "URI": "signal.netbird.io:443"},
+				"HttpConfig": {}
+			}`,
+			expectedError: "failed resolving TURNConfig.Secret: failed resolving env://NB_TEST_UNSET_TURN_SECRET",
+		},
+		{
+			name: "Invalid OIDC Config Endpoint",
+			config: `{
+				"Signal": {"Proto": "https", "URI": "signal.netbird.io:443"},
+				"HttpConfig": {"AuthIssuer": "https://issuer.netbird.io", "OIDCConfigEndpoint": "issuer.netbird.io"}
+			}`,
+			expectedError: `invalid management config: HttpConfig.OIDCConfigEndpoint "issuer.netbird.io" is not a valid URL`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "management.json")
+			require.NoError(t, os.WriteFile(configPath, []byte(testCase.config), 0600))
+
+			config, err := loadMgmtConfig(configPath)
+			if testCase.expectedError == "" {
+				require.NoError(t, err)
+				assert.NotNil(t, config.HttpConfig)
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), testCase.expectedError)
+		})
+	}
+}
+
+func TestUpdateMgmtConfigKeepsSecretReferences(t *testing.T) {
+	t.Setenv("NB_TEST_TURN_SECRET", "turn-secret")
+
+	configPath := filepath.Join(t.TempDir(), "management.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{
+		"TURNConfig": {"TimeBasedCredentials": true, "Secret": "env://NB_TEST_TURN_SECRET"},
+		"Signal": {"Proto": "https", "URI": "signal.netbird.io:443"},
+		"HttpConfig": {}
+	}`), 0600))
+
+	config, err := loadMgmtConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "turn-secret", config.TURNConfig.Secret)
+
+	config.DataStoreEncryptionKey = "store-key"
+	require.NoError(t, updateMgmtConfig(configPath, config))
+
+	written, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(written), "env://NB_TEST_TURN_SECRET")
+	assert.Contains(t, string(written), "store-key")
+	assert.NotContains(t, string(written), "turn-secret")
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/util"
+)
+
+var shortPostgresUp = "Migrate JSON file store to PostgreSQL store. Please make a backup of the JSON file before running this command."
+
+var postgresMigrationCmd = &cobra.Command{
+	Use:          "postgres-migration",
+	Short:        "Contains sub-commands to perform JSON file store to PostgreSQL store migration",
+	Long:         "",
+	SilenceUsage: true,
+}
+
+var postgresUpCmd = &cobra.Command{
+	Use:     "upgrade [--datadir directory] [--log-file console]",
+	Aliases: []string{"up"},
+	Short:   shortPostgresUp,
+	Long: shortPostgresUp +
+		"\n\n" +
+		"This command reads the content of {datadir}/store.json and imports it into the PostgreSQL database " +
+		"of the DSN in the NETBIRD_STORE_ENGINE_POSTGRES_DSN environment variable. The database must not contain accounts yet.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flag.Parse()
+		err := util.InitLogWithOptions(logLevel, logFile, logOptions)
+		if err != nil {
+			return fmt.Errorf("failed initializing log %v", err)
+		}
+
+		fileStorePath := path.Join(mgmtDataDir, "store.json")
+		if _, err := os.Stat(fileStorePath); errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s doesn't exist, couldn't continue the operation", fileStorePath)
+		}
+
+		dsn, err := server.PostgresDSNFromEnv()
+		if err != nil {
+			return err
+		}
+
+		// importing into a database in use would mix the accounts of two installations
+		existing, err := server.NewPostgresqlStore(dsn, nil)
+		if err != nil {
+			return fmt.Errorf("failed connecting to the PostgreSQL store: %v", err)
+		}
+		existingAccounts := len(existing.GetAllAccounts())
+		_ = existing.Close()
+		if existingAccounts > 0 {
+			return fmt.Errorf("the PostgreSQL store already contains %d accounts, couldn't continue the operation", existingAccounts)
+		}
+
+		fstore, err := server.NewFileStore(mgmtDataDir, nil)
+		if err != nil {
+			return fmt.Errorf("failed creating file store: %s: %v", mgmtDataDir, err)
+		}
+
+		fsStoreAccounts := len(fstore.GetAllAccounts())
+		log.Infof("%d account will be migrated from file store %s to the PostgreSQL store", fsStoreAccounts, fileStorePath)
+
+		store, err := server.NewPostgresqlStoreFromFileStore(fstore, dsn, nil)
+		if err != nil {
+			return fmt.Errorf("failed migrating file store %s to the PostgreSQL store: %v", mgmtDataDir, err)
+		}
+		defer store.Close() //nolint
+
+		postgresStoreAccounts := len(store.GetAllAccounts())
+		if fsStoreAccounts != postgresStoreAccounts {
+			return fmt.Errorf("failed to migrate accounts from file to PostgreSQL. Expected accounts: %d, got: %d",
+				fsStoreAccounts, postgresStoreAccounts)
+		}
+
+		log.Info("Migration finished successfully")
+
+		return nil
+	},
+}
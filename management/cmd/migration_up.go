@@ -24,7 +24,7 @@ var upCmd = &cobra.Command{
 		"This command reads the content of {datadir}/store.json and migrates it to {datadir}/store.db that can be used by SQLite store driver.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		flag.Parse()
-		err := util.InitLog(logLevel, logFile)
+		err := util.InitLogWithOptions(logLevel, logFile, logOptions)
 		if err != nil {
 			return fmt.Errorf("failed initializing log %v", err)
 		}
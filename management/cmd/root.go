@@ -7,6 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/netbirdio/netbird/util"
 	"github.com/netbirdio/netbird/version"
 )
 
@@ -22,6 +23,7 @@ var (
 	mgmtConfig               string
 	logLevel                 string
 	logFile                  string
+	logOptions               util.LogOptions
 	disableMetrics           bool
 	disableSingleAccMode     bool
 	idpSignKeyRefreshEnabled bool
@@ -69,6 +71,7 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "")
 	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", defaultLogFile, "sets Netbird log path. If console is specified the log will be output to stdout")
+	logOptions.RegisterFlags(rootCmd.PersistentFlags())
 	rootCmd.AddCommand(mgmtCmd)
 
 	migrationCmd.PersistentFlags().StringVar(&mgmtDataDir, "datadir", defaultMgmtDataDir, "server data directory location")
@@ -78,6 +81,13 @@ func init() {
 	migrationCmd.AddCommand(downCmd)
 
 	rootCmd.AddCommand(migrationCmd)
+
+	postgresMigrationCmd.PersistentFlags().StringVar(&mgmtDataDir, "datadir", defaultMgmtDataDir, "server data directory location")
+	postgresMigrationCmd.MarkFlagRequired("datadir") //nolint
+
+	postgresMigrationCmd.AddCommand(postgresUpCmd)
+
+	rootCmd.AddCommand(postgresMigrationCmd)
 }
 
 // SetupCloseHandler handles SIGTERM signal and exits with success
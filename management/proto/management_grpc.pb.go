@@ -43,6 +43,21 @@ type ManagementServiceClient interface {
 	// EncryptedMessage of the request has a body of PKCEAuthorizationFlowRequest.
 	// EncryptedMessage of the response has a body of PKCEAuthorizationFlow.
 	GetPKCEAuthorizationFlow(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error)
+	// StreamEvents streams the activity events of the caller's account as they are stored.
+	// The caller is authenticated with a JWT passed in the "authorization" metadata as "Bearer <token>"
+	// and should be allowed to view the account events.
+	// A consumer that falls behind is disconnected with codes.ResourceExhausted,
+	// it should reconnect with the resume token of the last received event to get the missed events.
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (ManagementService_StreamEventsClient, error)
+	// CheckSetupKey returns whether a setup key can register a peer without using it.
+	// The requests are rate limited per source IP to prevent the setup key enumeration.
+	// EncryptedMessage of the request has a body of SetupKeyCheckRequest.
+	// EncryptedMessage of the response has a body of SetupKeyCheckResponse.
+	CheckSetupKey(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error)
+	// RotatePeerKey replaces the WireGuard key of the peer, keeping its IP, name and groups. The old key can't log in anymore.
+	// EncryptedMessage of the request is encrypted with the old key and has a body of RotatePeerKeyRequest.
+	// EncryptedMessage of the response has a body of RotatePeerKeyResponse.
+	RotatePeerKey(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error)
 }
 
 type managementServiceClient struct {
@@ -130,6 +145,56 @@ func (c *managementServiceClient) GetPKCEAuthorizationFlow(ctx context.Context,
 	return out, nil
 }
 
+func (c *managementServiceClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (ManagementService_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ManagementService_ServiceDesc.Streams[1], "/management.ManagementService/StreamEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managementServiceStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ManagementService_StreamEventsClient interface {
+	Recv() (*AccountEvent, error)
+	grpc.ClientStream
+}
+
+type managementServiceStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *managementServiceStreamEventsClient) Recv() (*AccountEvent, error) {
+	m := new(AccountEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managementServiceClient) CheckSetupKey(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error) {
+	out := new(EncryptedMessage)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/CheckSetupKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managementServiceClient) RotatePeerKey(ctx context.Context, in *EncryptedMessage, opts ...grpc.CallOption) (*EncryptedMessage, error) {
+	out := new(EncryptedMessage)
+	err := c.cc.Invoke(ctx, "/management.ManagementService/RotatePeerKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ManagementServiceServer is the server API for ManagementService service.
 // All implementations must embed UnimplementedManagementServiceServer
 // for forward compatibility
@@ -159,6 +224,21 @@ type ManagementServiceServer interface {
 	// EncryptedMessage of the request has a body of PKCEAuthorizationFlowRequest.
 	// EncryptedMessage of the response has a body of PKCEAuthorizationFlow.
 	GetPKCEAuthorizationFlow(context.Context, *EncryptedMessage) (*EncryptedMessage, error)
+	// StreamEvents streams the activity events of the caller's account as they are stored.
+	// The caller is authenticated with a JWT passed in the "authorization" metadata as "Bearer <token>"
+	// and should be allowed to view the account events.
+	// A consumer that falls behind is disconnected with codes.ResourceExhausted,
+	// it should reconnect with the resume token of the last received event to get the missed events.
+	StreamEvents(*StreamEventsRequest, ManagementService_StreamEventsServer) error
+	// CheckSetupKey returns whether a setup key can register a peer without using it.
+	// The requests are rate limited per source IP to prevent the setup key enumeration.
+	// EncryptedMessage of the request has a body of SetupKeyCheckRequest.
+	// EncryptedMessage of the response has a body of SetupKeyCheckResponse.
+	CheckSetupKey(context.Context, *EncryptedMessage) (*EncryptedMessage, error)
+	// RotatePeerKey replaces the WireGuard key of the peer, keeping its IP, name and groups. The old key can't log in anymore.
+	// EncryptedMessage of the request is encrypted with the old key and has a body of RotatePeerKeyRequest.
+	// EncryptedMessage of the response has a body of RotatePeerKeyResponse.
+	RotatePeerKey(context.Context, *EncryptedMessage) (*EncryptedMessage, error)
 	mustEmbedUnimplementedManagementServiceServer()
 }
 
@@ -184,6 +264,15 @@ func (UnimplementedManagementServiceServer) GetDeviceAuthorizationFlow(context.C
 func (UnimplementedManagementServiceServer) GetPKCEAuthorizationFlow(context.Context, *EncryptedMessage) (*EncryptedMessage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPKCEAuthorizationFlow not implemented")
 }
+func (UnimplementedManagementServiceServer) StreamEvents(*StreamEventsRequest, ManagementService_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedManagementServiceServer) CheckSetupKey(context.Context, *EncryptedMessage) (*EncryptedMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSetupKey not implemented")
+}
+func (UnimplementedManagementServiceServer) RotatePeerKey(context.Context, *EncryptedMessage) (*EncryptedMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotatePeerKey not implemented")
+}
 func (UnimplementedManagementServiceServer) mustEmbedUnimplementedManagementServiceServer() {}
 
 // UnsafeManagementServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -308,6 +397,63 @@ func _ManagementService_GetPKCEAuthorizationFlow_Handler(srv interface{}, ctx co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ManagementService_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagementServiceServer).StreamEvents(m, &managementServiceStreamEventsServer{stream})
+}
+
+type ManagementService_StreamEventsServer interface {
+	Send(*AccountEvent) error
+	grpc.ServerStream
+}
+
+type managementServiceStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *managementServiceStreamEventsServer) Send(m *AccountEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ManagementService_CheckSetupKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).CheckSetupKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/CheckSetupKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).CheckSetupKey(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagementService_RotatePeerKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagementServiceServer).RotatePeerKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/management.ManagementService/RotatePeerKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagementServiceServer).RotatePeerKey(ctx, req.(*EncryptedMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ManagementService_ServiceDesc is the grpc.ServiceDesc for ManagementService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -335,6 +481,14 @@ var ManagementService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPKCEAuthorizationFlow",
 			Handler:    _ManagementService_GetPKCEAuthorizationFlow_Handler,
 		},
+		{
+			MethodName: "CheckSetupKey",
+			Handler:    _ManagementService_CheckSetupKey_Handler,
+		},
+		{
+			MethodName: "RotatePeerKey",
+			Handler:    _ManagementService_RotatePeerKey_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -342,6 +496,11 @@ var ManagementService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _ManagementService_Sync_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _ManagementService_StreamEvents_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "management.proto",
 }
@@ -20,6 +20,7 @@ import (
 	gocache "github.com/patrickmn/go-cache"
 	"github.com/rs/xid"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 
 	"github.com/netbirdio/management-integrations/additions"
 
@@ -58,6 +59,9 @@ type AccountManager interface {
 	CreateSetupKey(accountID string, keyName string, keyType SetupKeyType, expiresIn time.Duration,
 		autoGroups []string, usageLimit int, userID string, ephemeral bool) (*SetupKey, error)
 	SaveSetupKey(accountID string, key *SetupKey, userID string) (*SetupKey, error)
+	CheckSetupKey(setupKey string) (*SetupKeyState, error)
+	ApproveSetupKey(accountID, userID, keyID string) (*SetupKey, error)
+	GetPendingSetupKeys(accountID, userID string) ([]*SetupKey, error)
 	CreateUser(accountID, initiatorUserID string, key *UserInfo) (*UserInfo, error)
 	DeleteUser(accountID, initiatorUserID string, targetUserID string) error
 	InviteUser(accountID string, initiatorUserID string, targetUserID string) error
@@ -74,13 +78,31 @@ type AccountManager interface {
 	GetUser(claims jwtclaims.AuthorizationClaims) (*User, error)
 	ListUsers(accountID string) ([]*User, error)
 	GetPeers(accountID, userID string) ([]*nbpeer.Peer, error)
-	MarkPeerConnected(peerKey string, connected bool) error
+	MarkPeerConnected(peerKey string, connected bool, realIP net.IP) error
 	DeletePeer(accountID, peerID, userID string) error
+	GetPendingPeers(accountID, userID string) ([]*nbpeer.Peer, error)
+	ApprovePeer(accountID, userID, peerID string) (*nbpeer.Peer, error)
+	RejectPeer(accountID, userID, peerID string) error
 	UpdatePeer(accountID, userID string, peer *nbpeer.Peer) (*nbpeer.Peer, error)
 	GetNetworkMap(peerID string) (*NetworkMap, error)
+	GetPeerNetworkMapPreview(accountID, peerID, userID string) (*NetworkMap, error)
 	GetPeerNetwork(peerID string) (*Network, error)
+	GetNetwork(accountID, userID string) (*Network, error)
+	UpdateNetworkRange(accountID, userID, networkRange string) (*Network, error)
+	GetMaintenanceWindow(accountID, userID string) (*MaintenanceWindow, error)
+	StartMaintenanceWindow(accountID, userID string) (*MaintenanceWindow, error)
+	StopMaintenanceWindow(accountID, userID string) error
+	RotateWebhookSecret(accountID, userID string) (string, error)
+	GetAccountForExport(accountID, userID string) (*Account, error)
+	GetDefaultDenyReport(accountID, userID string) (*DefaultDenyReport, error)
+	GetEffectiveACL(accountID, userID, peerID string) (*EffectiveACL, error)
+	SimulatePolicy(accountID, userID string, request *PolicySimulationRequest) (*PolicySimulation, error)
+	ApplyBatch(accountID, userID string, operations []*BatchOperation) error
+	GetInactivePeers(accountID, userID string, threshold time.Duration) ([]*InactivePeer, error)
+	GetOutdatedPeers(accountID, userID, minVersion string) ([]*OutdatedPeer, error)
+	RotatePeerKey(oldPeerKey, newPeerKey string) (*nbpeer.Peer, error)
 	AddPeer(setupKey, userID string, peer *nbpeer.Peer) (*nbpeer.Peer, *NetworkMap, error)
-	CreatePAT(accountID string, initiatorUserID string, targetUserID string, tokenName string, expiresIn int) (*PersonalAccessTokenGenerated, error)
+	CreatePAT(accountID string, initiatorUserID string, targetUserID string, tokenName string, expiresIn int, role UserRole) (*PersonalAccessTokenGenerated, error)
 	DeletePAT(accountID string, initiatorUserID string, targetUserID string, tokenID string) error
 	GetPAT(accountID string, initiatorUserID string, targetUserID string, tokenID string) (*PersonalAccessToken, error)
 	GetAllPATs(accountID string, initiatorUserID string, targetUserID string) ([]*PersonalAccessToken, error)
@@ -107,9 +129,38 @@ type AccountManager interface {
 	SaveNameServerGroup(accountID, userID string, nsGroupToSave *nbdns.NameServerGroup) error
 	DeleteNameServerGroup(accountID, nsGroupID, userID string) error
 	ListNameServerGroups(accountID string, userID string) ([]*nbdns.NameServerGroup, error)
+	GetDNSRecord(accountID, userID, recordID string) (*DNSRecord, error)
+	CreateDNSRecord(accountID, userID string, recordToCreate *DNSRecord) (*DNSRecord, error)
+	SaveDNSRecord(accountID, userID string, recordToSave *DNSRecord) error
+	DeleteDNSRecord(accountID, recordID, userID string) error
+	ListDNSRecords(accountID, userID string) ([]*DNSRecord, error)
+	GetNetworkResource(accountID, userID, resourceID string) (*NetworkResource, error)
+	CreateNetworkResource(accountID, userID string, resourceToCreate *NetworkResource) (*NetworkResource, error)
+	SaveNetworkResource(accountID, userID string, resourceToSave *NetworkResource) error
+	DeleteNetworkResource(accountID, resourceID, userID string) error
+	ListNetworkResources(accountID, userID string) ([]*NetworkResource, error)
+	GetStaticRoute(accountID, userID, routeID string) (*StaticRoute, error)
+	CreateStaticRoute(accountID, userID string, routeToCreate *StaticRoute) (*StaticRoute, error)
+	SaveStaticRoute(accountID, userID string, routeToSave *StaticRoute) error
+	DeleteStaticRoute(accountID, routeID, userID string) error
+	ListStaticRoutes(accountID, userID string) ([]*StaticRoute, error)
+	ListSyncSessions(accountID, userID string) ([]SyncSession, error)
+	CloseSyncSession(accountID, userID, peerID string) error
+	ListIPReservations(accountID, userID string) ([]*IPReservation, error)
+	SaveIPReservation(accountID, userID string, reservationToSave *IPReservation) (*IPReservation, error)
+	DeleteIPReservation(accountID, userID, reservationID string) error
+	ListOrgKeys(accountID, userID string) ([]*OrgKey, error)
+	CreateOrgKey(accountID, userID, name string, expiresIn time.Duration, linkedAccounts []string) (*OrgKey, error)
+	SaveOrgKey(accountID, userID string, keyToSave *OrgKey) (*OrgKey, error)
+	GetPostureCheck(accountID, userID, checkID string) (*PostureCheck, error)
+	ListPostureChecks(accountID, userID string) ([]*PostureCheck, error)
+	SavePostureCheck(accountID, userID string, checkToSave *PostureCheck) (*PostureCheck, error)
+	DeletePostureCheck(accountID, userID, checkID string) error
+	GetNonCompliantPeers(accountID, userID string) ([]*NonCompliantPeer, error)
 	GetDNSDomain() string
 	StoreEvent(initiatorID, targetID, accountID string, activityID activity.Activity, meta map[string]any)
 	GetEvents(accountID, userID string) ([]*activity.Event, error)
+	SubscribeToEvents(accountID, userID, resumeToken string) (*EventSubscription, error)
 	GetDNSSettings(accountID string, userID string) (*DNSSettings, error)
 	SaveDNSSettings(accountID string, userID string, dnsSettingsToSave *DNSSettings) error
 	GetPeer(accountID, peerID, userID string) (*nbpeer.Peer, error)
@@ -143,9 +194,33 @@ type DefaultAccountManager struct {
 	// dnsDomain is used for peer resolution. This is appended to the peer's name
 	dnsDomain       string
 	peerLoginExpiry Scheduler
+	// setupKeyExpiry runs the job revoking the expired setup keys
+	setupKeyExpiry Scheduler
+	// peerInactivity runs the job cleaning up the inactive peers
+	peerInactivity Scheduler
+	// policySchedules runs the jobs updating the peers at the transitions of the scheduled policy rules, by account ID
+	policySchedules Scheduler
+
+	// ipv6Prefix is the unique local prefix the account networks take their IPv6 subnet from, nil disables IPv6
+	ipv6Prefix *net.IPNet
+
+	// webhookDispatcher delivers the account lifecycle events to the account webhooks, nil disables the delivery
+	webhookDispatcher *WebhookDispatcher
 
 	// userDeleteFromIDPEnabled allows to delete user from IDP when user is deleted from account
 	userDeleteFromIDPEnabled bool
+
+	// maintenance keeps the accounts whose peer updates are paused
+	maintenance maintenanceWindows
+
+	// eventStreams delivers the stored activity events to the account event subscriptions
+	eventStreams accountEventStreams
+
+	// idpOutage keeps the IdP user metadata last loaded and decides how the logins behave while the IdP is unavailable
+	idpOutage idpOutagePolicy
+
+	// setupKeyCreationLimiter limits the rate of the setup key creations of each account, by account ID
+	setupKeyCreationLimiter *keyedRateLimiter
 }
 
 // Settings represents Account settings structure that can be modified via API and Dashboard
@@ -170,6 +245,70 @@ type Settings struct {
 	// JWTAllowGroups list of groups to which users are allowed access
 	JWTAllowGroups []string `gorm:"serializer:json"`
 
+	// WebhookURL is an endpoint notified about the account lifecycle events, empty disables the notifications
+	WebhookURL string
+
+	// WebhookSecret signs the payloads posted to the WebhookURL, generated when the webhook is set
+	WebhookSecret string
+
+	// AllowedDomains list of user email domains allowed to join the account and to register and login peers with a JWT,
+	// empty allows any domain
+	AllowedDomains []string `gorm:"serializer:json"`
+
+	// PeerApprovalRequired quarantines the newly registered peers until an administrator approves them.
+	// Disabling it doesn't approve the peers already waiting for an approval.
+	PeerApprovalRequired bool
+
+	// DefaultDenyEnabled connects the peers only through the policies naming the groups allowed to connect.
+	// The accept rules from the All group to the All group, like the one of the Default policy, are ignored.
+	DefaultDenyEnabled bool
+
+	// LinkedOrgKeys list of IDs of the org keys of other accounts allowed to register peers into the account
+	LinkedOrgKeys []string `gorm:"serializer:json"`
+
+	// PeerInactivityCleanupEnabled enables the periodic cleanup of the peers that haven't connected to the management
+	// service for longer than PeerInactivityThreshold
+	PeerInactivityCleanupEnabled bool
+
+	// PeerInactivityThreshold is how long a peer has to be disconnected before the cleanup applies to it
+	PeerInactivityThreshold time.Duration
+
+	// PeerInactivityAction is what the cleanup does with the inactive peers, PeerInactivityActionFlag or PeerInactivityActionDelete
+	PeerInactivityAction string
+
+	// MinClientVersion is the oldest NetBird client version the peers are allowed to run, empty disables the check
+	MinClientVersion string
+
+	// MinClientVersionAction is what happens to the peers running an older client, MinClientVersionActionReject
+	// or MinClientVersionActionFlag
+	MinClientVersionAction string
+
+	// MinClientVersionAllowUnknown lets in the peers reporting a development or an unparsable client version
+	MinClientVersionAllowUnknown bool
+
+	// SignalURI is the signal server the peers of the account use instead of the one of the management config,
+	// e.g. the server of the region closest to the account. Empty uses the signal server of the config.
+	SignalURI string
+
+	// SignalProtocol is the protocol of the SignalURI server, e.g. https
+	SignalProtocol string
+
+	// PeerUpdateDebounceWindow is how long the network map updates of a peer following its previous update are
+	// held back and coalesced. Nil uses DefaultPeerUpdateDebounceWindow, zero pushes every update right away.
+	PeerUpdateDebounceWindow *time.Duration
+
+	// PeerConnectionTimeout is the timeout of the connection attempts of the peers to their remote peers, it
+	// overrides the default of the clients. Nil lets the clients use their default.
+	PeerConnectionTimeout *time.Duration
+
+	// SetupKeyApprovalRequired creates the new setup keys pending until an admin other than their creator
+	// approves them, the pending keys can't register peers
+	SetupKeyApprovalRequired bool
+
+	// PeerIPAllocation is how the IPs of the new peers are picked from the account network, PeerIPAllocationRandom
+	// or PeerIPAllocationSequential. Empty uses PeerIPAllocationRandom. The IPs of the existing peers don't change.
+	PeerIPAllocation string
+
 	// Extra is a dictionary of Account settings
 	Extra *account.ExtraSettings `gorm:"embedded;embeddedPrefix:extra_"`
 }
@@ -183,6 +322,35 @@ func (s *Settings) Copy() *Settings {
 		JWTGroupsClaimName:         s.JWTGroupsClaimName,
 		GroupsPropagationEnabled:   s.GroupsPropagationEnabled,
 		JWTAllowGroups:             s.JWTAllowGroups,
+		WebhookURL:                 s.WebhookURL,
+		WebhookSecret:              s.WebhookSecret,
+		AllowedDomains:             s.AllowedDomains,
+		PeerApprovalRequired:       s.PeerApprovalRequired,
+		DefaultDenyEnabled:         s.DefaultDenyEnabled,
+		LinkedOrgKeys:              s.LinkedOrgKeys,
+
+		PeerInactivityCleanupEnabled: s.PeerInactivityCleanupEnabled,
+		PeerInactivityThreshold:      s.PeerInactivityThreshold,
+		PeerInactivityAction:         s.PeerInactivityAction,
+
+		MinClientVersion:             s.MinClientVersion,
+		MinClientVersionAction:       s.MinClientVersionAction,
+		MinClientVersionAllowUnknown: s.MinClientVersionAllowUnknown,
+
+		SignalURI:      s.SignalURI,
+		SignalProtocol: s.SignalProtocol,
+
+		PeerIPAllocation: s.PeerIPAllocation,
+
+		SetupKeyApprovalRequired: s.SetupKeyApprovalRequired,
+	}
+	if s.PeerUpdateDebounceWindow != nil {
+		window := *s.PeerUpdateDebounceWindow
+		settings.PeerUpdateDebounceWindow = &window
+	}
+	if s.PeerConnectionTimeout != nil {
+		timeout := *s.PeerConnectionTimeout
+		settings.PeerConnectionTimeout = &timeout
 	}
 	if s.Extra != nil {
 		settings.Extra = s.Extra.Copy()
@@ -216,6 +384,18 @@ type Account struct {
 	RoutesG                []route.Route                     `json:"-" gorm:"foreignKey:AccountID;references:id"`
 	NameServerGroups       map[string]*nbdns.NameServerGroup `gorm:"-"`
 	NameServerGroupsG      []nbdns.NameServerGroup           `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	DNSRecords             map[string]*DNSRecord             `gorm:"-"`
+	DNSRecordsG            []DNSRecord                       `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	IPReservations         map[string]*IPReservation         `gorm:"-"`
+	IPReservationsG        []IPReservation                   `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	OrgKeys                map[string]*OrgKey                `gorm:"-"`
+	OrgKeysG               []OrgKey                          `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	NetworkResources       map[string]*NetworkResource       `gorm:"-"`
+	NetworkResourcesG      []NetworkResource                 `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	PostureChecks          map[string]*PostureCheck          `gorm:"-"`
+	PostureChecksG         []PostureCheck                    `json:"-" gorm:"foreignKey:AccountID;references:id"`
+	StaticRoutes           map[string]*StaticRoute           `gorm:"-"`
+	StaticRoutesG          []StaticRoute                     `json:"-" gorm:"foreignKey:AccountID;references:id"`
 	DNSSettings            DNSSettings                       `gorm:"embedded;embeddedPrefix:dns_settings_"`
 	// Settings is a dictionary of Account settings
 	Settings *Settings `gorm:"embedded;embeddedPrefix:settings_"`
@@ -238,6 +418,7 @@ type UserInfo struct {
 
 // getRoutesToSync returns the enabled routes for the peer ID and the routes
 // from the ACL peers that have distribution groups associated with the peer ID.
+// The routes within network resources are returned only if the policies permit the peer to access them.
 // Please mind, that the returned route.Route objects will contain Peer.Key instead of Peer.ID.
 func (a *Account) getRoutesToSync(peerID string, aclPeers []*nbpeer.Peer) []*route.Route {
 	routes, peerDisabledRoutes := a.getRoutingPeerRoutes(peerID)
@@ -247,10 +428,12 @@ func (a *Account) getRoutesToSync(peerID string, aclPeers []*nbpeer.Peer) []*rou
 	}
 
 	groupListMap := a.getPeerGroups(peerID)
+	permittedResources := a.getPeerPermittedResources(peerID)
 	for _, peer := range aclPeers {
 		activeRoutes, _ := a.getRoutingPeerRoutes(peer.ID)
 		groupFilteredRoutes := a.filterRoutesByGroups(activeRoutes, groupListMap)
-		filteredRoutes := a.filterRoutesFromPeersOfSameHAGroup(groupFilteredRoutes, peerRoutesMembership)
+		resourceFilteredRoutes := a.filterRoutesByResources(groupFilteredRoutes, permittedResources)
+		filteredRoutes := a.filterRoutesFromPeersOfSameHAGroup(resourceFilteredRoutes, peerRoutesMembership)
 		routes = append(routes, filteredRoutes...)
 	}
 
@@ -366,13 +549,17 @@ func (a *Account) GetPeerNetworkMap(peerID, dnsDomain string) *NetworkMap {
 	peer := a.Peers[peerID]
 	if peer == nil {
 		return &NetworkMap{
-			Network: a.Network.Copy(),
+			Network:           a.Network.Copy(),
+			Signal:            a.Settings.signalHost(),
+			ConnectionTimeout: a.Settings.GetPeerConnectionTimeout(),
 		}
 	}
 	validatedPeers := additions.ValidatePeers([]*nbpeer.Peer{peer})
-	if len(validatedPeers) == 0 {
+	if len(validatedPeers) == 0 || peer.IsQuarantined() {
 		return &NetworkMap{
-			Network: a.Network.Copy(),
+			Network:           a.Network.Copy(),
+			Signal:            a.Settings.signalHost(),
+			ConnectionTimeout: a.Settings.GetPeerConnectionTimeout(),
 		}
 	}
 	aclPeers, firewallRules := a.getPeerConnectionResources(peerID)
@@ -397,21 +584,26 @@ func (a *Account) GetPeerNetworkMap(peerID, dnsDomain string) *NetworkMap {
 
 	if dnsManagementStatus {
 		var zones []nbdns.CustomZone
-		peersCustomZone := getPeersCustomZone(a, dnsDomain)
+		peersCustomZone := getPeersCustomZone(a, peerID, dnsDomain)
 		if peersCustomZone.Domain != "" {
 			zones = append(zones, peersCustomZone)
 		}
 		dnsUpdate.CustomZones = zones
 		dnsUpdate.NameServerGroups = getPeerNSGroups(a, peerID)
+		dnsUpdate.RouteAllDisabled = a.DNSSettings.primaryDNSDisabled(peer.Meta)
 	}
 
 	return &NetworkMap{
-		Peers:         peersToConnect,
-		Network:       a.Network.Copy(),
-		Routes:        routesUpdate,
-		DNSConfig:     dnsUpdate,
-		OfflinePeers:  expiredPeers,
-		FirewallRules: firewallRules,
+		Peers:             peersToConnect,
+		Network:           a.Network.Copy(),
+		Routes:            routesUpdate,
+		StaticRoutes:      a.getPeerStaticRoutes(peerID, peersToConnect),
+		DNSConfig:         dnsUpdate,
+		OfflinePeers:      expiredPeers,
+		FirewallRules:     firewallRules,
+		Signal:            a.Settings.signalHost(),
+		ConnectionTimeout: a.Settings.GetPeerConnectionTimeout(),
+		TunnelSettings:    a.getPeersTunnelSettings(append([]*nbpeer.Peer{peer}, aclPeers...)),
 	}
 }
 
@@ -505,6 +697,12 @@ func (a *Account) DeletePeer(peerID string) {
 		}
 	}
 
+	for id, r := range a.StaticRoutes {
+		if r.PeerID == peerID || r.RoutingPeerID == peerID {
+			delete(a.StaticRoutes, id)
+		}
+	}
+
 	delete(a.Peers, peerID)
 	a.Network.IncSerial()
 }
@@ -604,6 +802,17 @@ func (a *Account) getTakenIPs() []net.IP {
 	return takenIps
 }
 
+func (a *Account) getTakenIPv6s() []net.IP {
+	var takenIps []net.IP
+	for _, existingPeer := range a.Peers {
+		if existingPeer.IPv6 != nil {
+			takenIps = append(takenIps, existingPeer.IPv6)
+		}
+	}
+
+	return takenIps
+}
+
 func (a *Account) getPeerDNSLabels() lookupMap {
 	existingLabels := make(lookupMap)
 	for _, peer := range a.Peers {
@@ -614,6 +823,15 @@ func (a *Account) getPeerDNSLabels() lookupMap {
 	return existingLabels
 }
 
+// getTakenDNSLabels returns the peer DNS labels and the names of custom DNS records, so new peer labels don't clash with them
+func (a *Account) getTakenDNSLabels() lookupMap {
+	takenLabels := a.getPeerDNSLabels()
+	for _, record := range a.DNSRecords {
+		takenLabels[record.Name] = struct{}{}
+	}
+	return takenLabels
+}
+
 func (a *Account) Copy() *Account {
 	peers := map[string]*nbpeer.Peer{}
 	for id, peer := range a.Peers {
@@ -655,6 +873,36 @@ func (a *Account) Copy() *Account {
 		nsGroups[id] = nsGroup.Copy()
 	}
 
+	dnsRecords := map[string]*DNSRecord{}
+	for id, record := range a.DNSRecords {
+		dnsRecords[id] = record.Copy()
+	}
+
+	ipReservations := map[string]*IPReservation{}
+	for id, reservation := range a.IPReservations {
+		ipReservations[id] = reservation.Copy()
+	}
+
+	orgKeys := map[string]*OrgKey{}
+	for id, key := range a.OrgKeys {
+		orgKeys[id] = key.Copy()
+	}
+
+	networkResources := map[string]*NetworkResource{}
+	for id, resource := range a.NetworkResources {
+		networkResources[id] = resource.Copy()
+	}
+
+	postureChecks := map[string]*PostureCheck{}
+	for id, check := range a.PostureChecks {
+		postureChecks[id] = check.Copy()
+	}
+
+	staticRoutes := map[string]*StaticRoute{}
+	for id, r := range a.StaticRoutes {
+		staticRoutes[id] = r.Copy()
+	}
+
 	dnsSettings := a.DNSSettings.Copy()
 
 	var settings *Settings
@@ -677,6 +925,12 @@ func (a *Account) Copy() *Account {
 		Policies:               policies,
 		Routes:                 routes,
 		NameServerGroups:       nsGroups,
+		DNSRecords:             dnsRecords,
+		IPReservations:         ipReservations,
+		OrgKeys:                orgKeys,
+		NetworkResources:       networkResources,
+		PostureChecks:          postureChecks,
+		StaticRoutes:           staticRoutes,
 		DNSSettings:            dnsSettings,
 		Settings:               settings,
 	}
@@ -816,7 +1070,12 @@ func BuildManager(store Store, peersUpdateManager *PeersUpdateManager, idpManage
 		dnsDomain:                dnsDomain,
 		eventStore:               eventStore,
 		peerLoginExpiry:          NewDefaultScheduler(),
+		setupKeyExpiry:           NewDefaultScheduler(),
+		setupKeyCreationLimiter:  newKeyedRateLimiter(setupKeyCreationInterval, setupKeyCreationBurst),
+		peerInactivity:           NewDefaultScheduler(),
+		policySchedules:          NewDefaultScheduler(),
 		userDeleteFromIDPEnabled: userDeleteFromIDPEnabled,
+		idpOutage:                idpOutagePolicy{staleTTL: DefaultIdpStaleTTL},
 	}
 	allAccounts := store.GetAllAccounts()
 	// enable single account mode only if configured by user and number of existing accounts is not grater than 1
@@ -851,6 +1110,8 @@ func BuildManager(store Store, peersUpdateManager *PeersUpdateManager, idpManage
 				return nil, err
 			}
 		}
+
+		am.checkAndSchedulePolicySchedules(account)
 	}
 
 	goCacheClient := gocache.New(CacheExpirationMax, 30*time.Minute)
@@ -894,6 +1155,40 @@ func (am *DefaultAccountManager) UpdateAccountSettings(accountID, userID string,
 		return nil, status.Errorf(status.InvalidArgument, "peer login expiration can't be smaller than one hour")
 	}
 
+	if err := validateWebhookURL(newSettings.WebhookURL); err != nil {
+		return nil, err
+	}
+
+	for _, domain := range newSettings.AllowedDomains {
+		if !isDomainValid(strings.ToLower(domain)) {
+			return nil, status.Errorf(status.InvalidArgument, "invalid allowed domain %s", domain)
+		}
+	}
+
+	if err := validatePeerInactivitySettings(newSettings); err != nil {
+		return nil, err
+	}
+
+	if err := validateMinClientVersionSettings(newSettings); err != nil {
+		return nil, err
+	}
+
+	if err := validateSignalSettings(newSettings); err != nil {
+		return nil, err
+	}
+
+	if err := validatePeerUpdateDebounceWindow(newSettings); err != nil {
+		return nil, err
+	}
+
+	if err := validatePeerConnectionTimeout(newSettings); err != nil {
+		return nil, err
+	}
+
+	if err := validatePeerIPAllocationSettings(newSettings); err != nil {
+		return nil, err
+	}
+
 	unlock := am.Store.AcquireAccountLock(accountID)
 	defer unlock()
 
@@ -912,11 +1207,19 @@ func (am *DefaultAccountManager) UpdateAccountSettings(accountID, userID string,
 		return nil, err
 	}
 
-	if !user.HasAdminPower() {
+	if !user.HasPermission(ResourceAccounts, OperationWrite) {
 		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to update account")
 	}
 
 	oldSettings := account.Settings
+	newSettings.WebhookSecret = oldSettings.WebhookSecret
+	if newSettings.WebhookURL != "" && newSettings.WebhookSecret == "" {
+		newSettings.WebhookSecret, err = generateWebhookSecret()
+		if err != nil {
+			return nil, status.Errorf(status.Internal, "failed to generate the webhook secret: %v", err)
+		}
+	}
+
 	if oldSettings.PeerLoginExpirationEnabled != newSettings.PeerLoginExpirationEnabled {
 		event := activity.AccountPeerLoginExpirationEnabled
 		if !newSettings.PeerLoginExpirationEnabled {
@@ -933,13 +1236,108 @@ func (am *DefaultAccountManager) UpdateAccountSettings(accountID, userID string,
 		am.checkAndSchedulePeerLoginExpiration(account)
 	}
 
+	if oldSettings.PeerApprovalRequired != newSettings.PeerApprovalRequired {
+		event := activity.AccountPeerApprovalEnabled
+		if !newSettings.PeerApprovalRequired {
+			event = activity.AccountPeerApprovalDisabled
+		}
+		am.StoreEvent(userID, accountID, accountID, event, nil)
+	}
+
+	if !slices.Equal(oldSettings.LinkedOrgKeys, newSettings.LinkedOrgKeys) {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountLinkedOrgKeysUpdated, nil)
+	}
+
+	if oldSettings.PeerInactivityCleanupEnabled != newSettings.PeerInactivityCleanupEnabled ||
+		oldSettings.PeerInactivityThreshold != newSettings.PeerInactivityThreshold ||
+		oldSettings.PeerInactivityAction != newSettings.PeerInactivityAction {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPeerInactivityCleanupUpdated, map[string]any{
+			"enabled":   newSettings.PeerInactivityCleanupEnabled,
+			"threshold": newSettings.PeerInactivityThreshold.String(),
+			"action":    newSettings.PeerInactivityAction,
+		})
+	}
+
+	if oldSettings.MinClientVersion != newSettings.MinClientVersion ||
+		oldSettings.MinClientVersionAction != newSettings.MinClientVersionAction ||
+		oldSettings.MinClientVersionAllowUnknown != newSettings.MinClientVersionAllowUnknown {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountMinClientVersionUpdated, map[string]any{
+			"min_version":   newSettings.MinClientVersion,
+			"action":        newSettings.MinClientVersionAction,
+			"allow_unknown": newSettings.MinClientVersionAllowUnknown,
+		})
+	}
+
+	signalChanged := oldSettings.SignalURI != newSettings.SignalURI || oldSettings.SignalProtocol != newSettings.SignalProtocol
+	if signalChanged {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountSignalServerUpdated, map[string]any{
+			"uri":      newSettings.SignalURI,
+			"protocol": newSettings.SignalProtocol,
+		})
+	}
+
+	if oldSettings.GetPeerUpdateDebounceWindow() != newSettings.GetPeerUpdateDebounceWindow() {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPeerUpdateDebounceWindowUpdated, map[string]any{
+			"window": newSettings.GetPeerUpdateDebounceWindow().String(),
+		})
+	}
+
+	connectionTimeoutChanged := oldSettings.GetPeerConnectionTimeout() != newSettings.GetPeerConnectionTimeout()
+	if connectionTimeoutChanged {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPeerConnectionTimeoutUpdated, map[string]any{
+			"timeout": newSettings.GetPeerConnectionTimeout().String(),
+		})
+	}
+
+	if oldSettings.SetupKeyApprovalRequired != newSettings.SetupKeyApprovalRequired {
+		event := activity.AccountSetupKeyApprovalEnabled
+		if !newSettings.SetupKeyApprovalRequired {
+			event = activity.AccountSetupKeyApprovalDisabled
+		}
+		am.StoreEvent(userID, accountID, accountID, event, nil)
+	}
+
+	if oldSettings.PeerIPAllocation != newSettings.PeerIPAllocation {
+		am.StoreEvent(userID, accountID, accountID, activity.AccountPeerIPAllocationUpdated, map[string]any{
+			"allocation": newSettings.PeerIPAllocation,
+		})
+	}
+
+	defaultDenyChanged := oldSettings.DefaultDenyEnabled != newSettings.DefaultDenyEnabled
+	if defaultDenyChanged {
+		event := activity.AccountDefaultDenyEnabled
+		if !newSettings.DefaultDenyEnabled {
+			event = activity.AccountDefaultDenyDisabled
+		}
+		am.StoreEvent(userID, accountID, accountID, event, nil)
+		account.Network.IncSerial()
+	}
+
 	updatedAccount := account.UpdateSettings(newSettings)
 
+	// the posture checks with a minimum client version honor the unknown client versions setting
+	complianceChanged := false
+	if oldSettings.MinClientVersionAllowUnknown != newSettings.MinClientVersionAllowUnknown {
+		complianceChanged = am.refreshPeersCompliance(account)
+		if complianceChanged && !defaultDenyChanged {
+			account.Network.IncSerial()
+		}
+	}
+
 	err = am.Store.SaveAccount(account)
 	if err != nil {
 		return nil, err
 	}
 
+	if defaultDenyChanged || complianceChanged {
+		am.updateAccountPeers(account)
+	}
+
+	// the network map updates don't carry the global config of the peers, they pick it up with the initial sync
+	if signalChanged || connectionTimeoutChanged {
+		am.disconnectAccountPeers(account)
+	}
+
 	return updatedAccount, nil
 }
 
@@ -1035,6 +1433,7 @@ func (am *DefaultAccountManager) warmupIDPCache() error {
 		if err != nil {
 			return err
 		}
+		am.idpOutage.loaded(accountID, users)
 	}
 	log.Infof("warmed up IDP cache with %d entries", len(userData))
 	return nil
@@ -1054,7 +1453,7 @@ func (am *DefaultAccountManager) DeleteAccount(accountID, userID string) error {
 		return err
 	}
 
-	if !user.HasAdminPower() {
+	if !user.HasPermission(ResourceAccounts, OperationWrite) {
 		return status.Errorf(status.PermissionDenied, "user is not allowed to delete account")
 	}
 
@@ -1089,6 +1488,7 @@ func (am *DefaultAccountManager) DeleteAccount(accountID, userID string) error {
 	}
 	// cancel peer login expiry job
 	am.peerLoginExpiry.Cancel([]string{account.Id})
+	am.policySchedules.Cancel([]string{account.Id})
 
 	log.Debugf("account %s deleted", accountID)
 	return nil
@@ -1105,7 +1505,7 @@ func (am *DefaultAccountManager) GetAccountByUserOrAccountID(userID, accountID,
 			return nil, status.Errorf(status.NotFound, "account not found using user id: %s", userID)
 		}
 		err = am.addAccountIDToIDPAppMeta(userID, account)
-		if err != nil {
+		if err = am.deferIdpLookup(userID, err); err != nil {
 			return nil, err
 		}
 		return account, nil
@@ -1159,7 +1559,7 @@ func (am *DefaultAccountManager) loadAccount(_ context.Context, accountID interf
 
 	userData, err := am.idpManager.GetAccount(accountIDString)
 	if err != nil {
-		return nil, err
+		return nil, &idpUnavailableError{err: err}
 	}
 	log.Debugf("%d entries received from IdP management", len(userData))
 
@@ -1180,6 +1580,7 @@ func (am *DefaultAccountManager) loadAccount(_ context.Context, accountID interf
 		}
 		matchedUserData = append(matchedUserData, datum)
 	}
+	am.idpOutage.loaded(accountIDString, matchedUserData)
 	return matchedUserData, nil
 }
 
@@ -1268,7 +1669,11 @@ func (am *DefaultAccountManager) getAccountFromCache(accountID string, forceRelo
 			}
 		}
 
-		return am.cacheManager.Get(am.ctx, accountID)
+		data, err := am.cacheManager.Get(am.ctx, accountID)
+		if err != nil {
+			return am.cacheStaleUserData(accountID, err)
+		}
+		return data, nil
 	}
 	am.cacheMux.Unlock()
 
@@ -1385,7 +1790,7 @@ func (am *DefaultAccountManager) handleExistingUserAccount(
 
 	// we should register the account ID to this user's metadata in our IDP manager
 	err = am.addAccountIDToIDPAppMeta(claims.UserId, existingAcc)
-	if err != nil {
+	if err = am.deferIdpLookup(claims.UserId, err); err != nil {
 		return err
 	}
 
@@ -1423,7 +1828,7 @@ func (am *DefaultAccountManager) handleNewUserAccount(domainAcc *Account, claims
 	}
 
 	err = am.addAccountIDToIDPAppMeta(claims.UserId, account)
-	if err != nil {
+	if err = am.deferIdpLookup(claims.UserId, err); err != nil {
 		return nil, err
 	}
 
@@ -1442,10 +1847,15 @@ func (am *DefaultAccountManager) redeemInvite(account *Account, userID string) e
 
 	user, err := am.lookupUserInCache(userID, account)
 	if err != nil {
-		return err
+		return am.deferIdpLookup(userID, err)
 	}
 
 	if user == nil {
+		if am.idpOutage.isDegraded(account.Id) {
+			// the user may have been invited after the IdP data was loaded, the invite is redeemed on the next login
+			log.Warnf("user %s not found in the IdP data loaded before the IdP outage, deferring the invite", userID)
+			return nil
+		}
 		return status.Errorf(status.NotFound, "user %s not found in the IdP", userID)
 	}
 
@@ -1720,6 +2130,12 @@ func (am *DefaultAccountManager) getAccountWithAuthorizationClaims(claims jwtcla
 		}
 		return account, nil
 	} else if s, ok := status.FromError(err); ok && s.Type() == status.NotFound {
+		// the user joining the account of the domain has to be allowed before being added to it
+		if domainAccount != nil {
+			if err := checkUserDomainAllowed(domainAccount, claims); err != nil {
+				return nil, err
+			}
+		}
 		return am.handleNewUserAccount(domainAccount, claims)
 	} else {
 		// other error
@@ -1781,6 +2197,50 @@ func (am *DefaultAccountManager) CheckUserAccessByJWTGroups(claims jwtclaims.Aut
 	return nil
 }
 
+// checkUserDomainAllowed checks if the user domain is allowed to register and login peers in the account.
+// It is opt-in, any domain is allowed when the account has no allowed domains.
+func checkUserDomainAllowed(account *Account, claims jwtclaims.AuthorizationClaims) error {
+	if account.Settings == nil || len(account.Settings.AllowedDomains) == 0 {
+		return nil
+	}
+
+	domain := userDomainFromClaims(claims)
+	if domain == "" {
+		return status.Errorf(status.PermissionDenied, "user domain is unknown, the account allows only specific domains")
+	}
+
+	for _, allowed := range account.Settings.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return nil
+		}
+	}
+
+	return status.Errorf(status.PermissionDenied, "user domain %s is not allowed in the account", domain)
+}
+
+// userDomainFromClaims returns the domain claim of the user or, when the IdP doesn't set it, the domain of the user email
+// if the IdP verified it
+func userDomainFromClaims(claims jwtclaims.AuthorizationClaims) string {
+	if claims.Domain != "" {
+		return claims.Domain
+	}
+
+	email, ok := claims.Raw["email"].(string)
+	if !ok {
+		return ""
+	}
+
+	if verified, _ := claims.Raw["email_verified"].(bool); !verified {
+		return ""
+	}
+
+	_, domain, found := strings.Cut(email, "@")
+	if !found {
+		return ""
+	}
+	return domain
+}
+
 // addAllGroup to account object if it doesn't exists
 func addAllGroup(account *Account) error {
 	if len(account.Groups) == 0 {
@@ -1824,6 +2284,12 @@ func newAccountWithId(accountID, userID, domain string) *Account {
 	routes := make(map[string]*route.Route)
 	setupKeys := map[string]*SetupKey{}
 	nameServersGroups := make(map[string]*nbdns.NameServerGroup)
+	dnsRecords := make(map[string]*DNSRecord)
+	ipReservations := make(map[string]*IPReservation)
+	orgKeys := make(map[string]*OrgKey)
+	networkResources := make(map[string]*NetworkResource)
+	postureChecks := make(map[string]*PostureCheck)
+	staticRoutes := make(map[string]*StaticRoute)
 	users[userID] = NewOwnerUser(userID)
 	dnsSettings := DNSSettings{
 		DisabledManagementGroups: make([]string, 0),
@@ -1840,6 +2306,12 @@ func newAccountWithId(accountID, userID, domain string) *Account {
 		Domain:           domain,
 		Routes:           routes,
 		NameServerGroups: nameServersGroups,
+		DNSRecords:       dnsRecords,
+		IPReservations:   ipReservations,
+		OrgKeys:          orgKeys,
+		NetworkResources: networkResources,
+		PostureChecks:    postureChecks,
+		StaticRoutes:     staticRoutes,
 		DNSSettings:      dnsSettings,
 		Settings: &Settings{
 			PeerLoginExpirationEnabled: true,
@@ -0,0 +1,24 @@
+package server
+
+// exportResources are the resources of the account export, the user has to be able to read all of them
+var exportResources = []Resource{ResourceGroups, ResourcePolicies, ResourceSetupKeys, ResourceDNS}
+
+// GetAccountForExport returns a copy of the account whose groups, policies, setup keys and DNS settings are exported,
+// e.g. as the configuration of the netbird Terraform provider
+func (am *DefaultAccountManager) GetAccountForExport(accountID, userID string) (*Account, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range exportResources {
+		if err := checkPermission(account, userID, resource, OperationRead); err != nil {
+			return nil, err
+		}
+	}
+
+	return account.Copy(), nil
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_GetAccountForExport(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	regularUser := NewRegularUser("regular_user")
+	auditor := NewUser("auditor", UserRoleAuditor, false, false, "", []string{}, UserIssuedAPI)
+	account.Users[regularUser.Id] = regularUser
+	account.Users[auditor.Id] = auditor
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	exported, err := manager.GetAccountForExport(account.Id, auditor.Id)
+	require.NoError(t, err, "users allowed to read the exported resources should export the account")
+	assert.Equal(t, account.Id, exported.Id)
+	assert.Len(t, exported.Groups, len(account.Groups))
+
+	_, err = manager.GetAccountForExport(account.Id, regularUser.Id)
+	assertStatusType(t, err, status.PermissionDenied)
+
+	_, err = manager.GetAccountForExport("other_account", userID)
+	assertStatusType(t, err, status.NotFound)
+}
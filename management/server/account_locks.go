@@ -0,0 +1,63 @@
+package server
+
+import (
+	"sort"
+	"sync"
+)
+
+// accountLocks is a keyed lock of the accounts. The operations of an account are serialized, while the operations of
+// different accounts run in parallel without contending on a lock shared by the whole store.
+type accountLocks struct {
+	// locks holds a *sync.RWMutex per account ID, created on the first use
+	locks sync.Map
+}
+
+// get returns the lock of the account
+func (l *accountLocks) get(accountID string) *sync.RWMutex {
+	if value, ok := l.locks.Load(accountID); ok {
+		return value.(*sync.RWMutex)
+	}
+	value, _ := l.locks.LoadOrStore(accountID, &sync.RWMutex{})
+	return value.(*sync.RWMutex)
+}
+
+// lock acquires the lock of the account for writing and returns a function that releases it
+func (l *accountLocks) lock(accountID string) (unlock func()) {
+	mtx := l.get(accountID)
+	mtx.Lock()
+	return mtx.Unlock
+}
+
+// rLock acquires the lock of the account for reading and returns a function that releases it
+func (l *accountLocks) rLock(accountID string) (unlock func()) {
+	mtx := l.get(accountID)
+	mtx.RLock()
+	return mtx.RUnlock
+}
+
+// acquireAccountLocks acquires the locks of the distinct accounts one by one in the order of their IDs and returns a
+// function that releases them in the reverse order. Two operations locking the same accounts always wait for them in
+// the same order, so neither can hold a lock the other one is waiting for while waiting for a lock the other one holds.
+func acquireAccountLocks(acquire func(accountID string) (unlock func()), accountIDs []string) (unlock func()) {
+	ids := make([]string, 0, len(accountIDs))
+	seen := make(map[string]struct{}, len(accountIDs))
+	for _, id := range accountIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	unlocks := make([]func(), 0, len(ids))
+	for _, id := range ids {
+		unlocks = append(unlocks, acquire(id))
+	}
+
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountLocks_DifferentAccounts(t *testing.T) {
+	var locks accountLocks
+
+	unlock := locks.lock("account-a")
+	defer unlock()
+
+	locked := make(chan struct{})
+	go func() {
+		unlockOther := locks.lock("account-b")
+		unlockOther()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("lock of another account should not wait for the held one")
+	}
+}
+
+func TestAccountLocks_SameAccount(t *testing.T) {
+	var locks accountLocks
+
+	unlock := locks.lock("account-a")
+
+	locked := make(chan struct{})
+	go func() {
+		unlockAgain := locks.lock("account-a")
+		unlockAgain()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("lock of the same account should wait until released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("lock of the account should be acquired after it was released")
+	}
+}
+
+func TestAcquireAccountLocks_NoDeadlock(t *testing.T) {
+	var locks accountLocks
+
+	var wg sync.WaitGroup
+	orders := [][]string{{"account-a", "account-b"}, {"account-b", "account-a"}, {"account-b", "account-a", "account-b"}}
+	for _, order := range orders {
+		wg.Add(1)
+		go func(order []string) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				unlock := acquireAccountLocks(locks.lock, order)
+				unlock()
+			}
+		}(order)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("locking the same accounts in different orders deadlocked")
+	}
+}
+
+func TestAcquireAccountLocks_Order(t *testing.T) {
+	var acquired []string
+	var released []string
+	acquire := func(accountID string) func() {
+		acquired = append(acquired, accountID)
+		return func() { released = append(released, accountID) }
+	}
+
+	unlock := acquireAccountLocks(acquire, []string{"account-c", "account-a", "account-c", "account-b"})
+	assert.Equal(t, []string{"account-a", "account-b", "account-c"}, acquired, "locks should be acquired once in the order of the IDs")
+
+	unlock()
+	assert.Equal(t, []string{"account-c", "account-b", "account-a"}, released, "locks should be released in the reverse order")
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// signalHost returns the signal server the account pins its peers to, nil if the peers use the signal server of
+// the management config
+func (s *Settings) signalHost() *Host {
+	if s == nil || s.SignalURI == "" {
+		return nil
+	}
+	return &Host{Proto: Protocol(s.SignalProtocol), URI: s.SignalURI}
+}
+
+func validateSignalSettings(settings *Settings) error {
+	if settings.SignalURI == "" {
+		if settings.SignalProtocol != "" {
+			return status.Errorf(status.InvalidArgument, "signal protocol requires a signal URI")
+		}
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(settings.SignalURI)
+	if err != nil || host == "" {
+		return status.Errorf(status.InvalidArgument, "invalid signal URI %q, expected host:port", settings.SignalURI)
+	}
+	if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+		return status.Errorf(status.InvalidArgument, "invalid signal URI port %q", port)
+	}
+
+	if _, err := toResponseProto(Protocol(settings.SignalProtocol)); err != nil {
+		return status.Errorf(status.InvalidArgument, "invalid signal protocol %q", settings.SignalProtocol)
+	}
+
+	return nil
+}
+
+// disconnectAccountPeers closes the sync streams of the account peers, the peers receive the updated config with
+// the initial sync of their next stream
+func (am *DefaultAccountManager) disconnectAccountPeers(account *Account) {
+	peerIDs := make([]string, 0, len(account.Peers))
+	for id := range account.Peers {
+		peerIDs = append(peerIDs, id)
+	}
+	am.peersUpdateManager.CloseChannels(peerIDs)
+}
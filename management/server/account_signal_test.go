@@ -0,0 +1,84 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/proto"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestValidateSignalSettings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		uri      string
+		protocol string
+		valid    bool
+	}{
+		{name: "default signal server", valid: true},
+		{name: "signal server", uri: "signal.eu.netbird.io:443", protocol: "https", valid: true},
+		{name: "protocol without URI", protocol: "https"},
+		{name: "URI without port", uri: "signal.eu.netbird.io", protocol: "https"},
+		{name: "invalid port", uri: "signal.eu.netbird.io:0", protocol: "https"},
+		{name: "URI without protocol", uri: "signal.eu.netbird.io:443"},
+		{name: "unknown protocol", uri: "signal.eu.netbird.io:443", protocol: "quic"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateSignalSettings(&Settings{SignalURI: testCase.uri, SignalProtocol: testCase.protocol})
+			if testCase.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assertStatusType(t, err, status.InvalidArgument)
+		})
+	}
+}
+
+func TestDefaultAccountManager_UpdateAccountSettings_Signal(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	key, err := wgtypes.GenerateKey()
+	require.NoError(t, err, "unable to generate WireGuard key")
+	peer, _, err := manager.AddPeer("", userID, &nbpeer.Peer{
+		Key:  key.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-peer"},
+	})
+	require.NoError(t, err, "unable to add peer")
+
+	updates := manager.peersUpdateManager.CreateChannel(peer.ID)
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		SignalURI:           "signal.eu.netbird.io:443",
+		SignalProtocol:      string(HTTPS),
+	})
+	require.NoError(t, err, "unable to update the signal server")
+
+	select {
+	case _, open := <-updates:
+		assert.False(t, open, "the sync stream of the peer should be closed to pick up the signal server")
+	case <-time.After(time.Second):
+		t.Fatal("the sync stream of the peer wasn't closed")
+	}
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	networkMap := account.GetPeerNetworkMap(peer.ID, "netbird.io")
+	assert.Equal(t, &Host{Proto: HTTPS, URI: "signal.eu.netbird.io:443"}, networkMap.Signal)
+
+	config := &Config{Signal: &Host{Proto: HTTP, URI: "signal.netbird.io:10000"}, TURNConfig: &TURNConfig{}}
+	assert.Equal(t, &proto.HostConfig{Uri: "signal.eu.netbird.io:443", Protocol: proto.HostConfig_HTTPS},
+		toWiretrusteeConfig(config, nil, networkMap.Signal, 0).GetSignal())
+	assert.Equal(t, &proto.HostConfig{Uri: "signal.netbird.io:10000", Protocol: proto.HostConfig_HTTP},
+		toWiretrusteeConfig(config, nil, nil, 0).GetSignal(), "the configured signal server should be used by default")
+}
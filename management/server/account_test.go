@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/netip"
 	"reflect"
 	"sync"
 	"testing"
@@ -23,6 +24,7 @@ import (
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 
 	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
 )
 
 func verifyCanAddPeerToAccount(t *testing.T, manager AccountManager, account *Account, userID string) {
@@ -1558,7 +1560,51 @@ func TestAccount_Copy(t *testing.T) {
 			},
 		},
 		DNSSettings: DNSSettings{DisabledManagementGroups: []string{}},
-		Settings:    &Settings{},
+		DNSRecords: map[string]*DNSRecord{
+			"record1": {
+				ID: "record1",
+			},
+		},
+		IPReservations: map[string]*IPReservation{
+			"reservation1": {
+				ID: "reservation1",
+				IP: net.IP{100, 64, 0, 10},
+			},
+		},
+		OrgKeys: map[string]*OrgKey{
+			"orgkey1": {
+				ID:             "orgkey1",
+				Key:            "ORGKEY1",
+				LinkedAccounts: []string{"account2"},
+			},
+		},
+		NetworkResources: map[string]*NetworkResource{
+			"resource1": {
+				ID:     "resource1",
+				Name:   "prod-db",
+				Prefix: netip.MustParsePrefix("10.0.5.0/24"),
+			},
+		},
+		StaticRoutes: map[string]*StaticRoute{
+			"static1": {
+				ID:            "static1",
+				Network:       netip.MustParsePrefix("10.0.8.15/32"),
+				PeerID:        "peer1",
+				RoutingPeerID: "peer2",
+				PolicyID:      "policy1",
+			},
+		},
+		PostureChecks: map[string]*PostureCheck{
+			"check1": {
+				ID:             "check1",
+				Name:           "Supported OS",
+				Action:         PostureCheckActionQuarantine,
+				AllowedOS:      []string{"linux"},
+				AllowedKernels: []string{},
+				MinOSVersions:  map[string]string{},
+			},
+		},
+		Settings: &Settings{},
 	}
 	err := hasNilField(account)
 	if err != nil {
@@ -1630,7 +1676,7 @@ func TestDefaultAccountManager_UpdatePeer_PeerLoginExpiration(t *testing.T) {
 		LoginExpirationEnabled: true,
 	})
 	require.NoError(t, err, "unable to add peer")
-	err = manager.MarkPeerConnected(key.PublicKey().String(), true)
+	err = manager.MarkPeerConnected(key.PublicKey().String(), true, nil)
 	require.NoError(t, err, "unable to mark peer connected")
 	account, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
 		PeerLoginExpiration:        time.Hour,
@@ -1697,7 +1743,7 @@ func TestDefaultAccountManager_MarkPeerConnected_PeerLoginExpiration(t *testing.
 	}
 
 	// when we mark peer as connected, the peer login expiration routine should trigger
-	err = manager.MarkPeerConnected(key.PublicKey().String(), true)
+	err = manager.MarkPeerConnected(key.PublicKey().String(), true, nil)
 	require.NoError(t, err, "unable to mark peer connected")
 
 	failed := waitTimeout(wg, time.Second)
@@ -1720,7 +1766,7 @@ func TestDefaultAccountManager_UpdateAccountSettings_PeerLoginExpiration(t *test
 		LoginExpirationEnabled: true,
 	})
 	require.NoError(t, err, "unable to add peer")
-	err = manager.MarkPeerConnected(key.PublicKey().String(), true)
+	err = manager.MarkPeerConnected(key.PublicKey().String(), true, nil)
 	require.NoError(t, err, "unable to mark peer connected")
 
 	wg := &sync.WaitGroup{}
@@ -1790,6 +1836,109 @@ func TestDefaultAccountManager_UpdateAccountSettings(t *testing.T) {
 		PeerLoginExpirationEnabled: false,
 	})
 	require.Error(t, err, "expecting to fail when providing PeerLoginExpiration more than 180 days")
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		AllowedDomains:      []string{"netbird.io", "not a domain"},
+	})
+	require.Error(t, err, "expecting to fail when providing an invalid allowed domain")
+}
+
+func TestDefaultAccountManager_GetAccountFromTokenAllowedDomains(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := createAccount(manager, "account_id", "account_creator", "netbird.io")
+	require.NoError(t, err)
+	account.DomainCategory = PrivateCategory
+	account.IsDomainPrimaryAccount = true
+	account.Settings.AllowedDomains = []string{"example.com"}
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, _, err = manager.GetAccountFromToken(jwtclaims.AuthorizationClaims{
+		UserId:         "new_user",
+		Domain:         "netbird.io",
+		DomainCategory: PrivateCategory,
+	})
+	require.Error(t, err, "a user with a domain not allowed should be rejected")
+	sErr, ok := status.FromError(err)
+	require.True(t, ok, "expecting a status error")
+	assert.Equal(t, status.PermissionDenied, sErr.Type())
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.NotContains(t, account.Users, "new_user", "a user with a domain not allowed should not join the account")
+
+	account.Settings.AllowedDomains = []string{"netbird.io"}
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	joined, _, err := manager.GetAccountFromToken(jwtclaims.AuthorizationClaims{
+		UserId:         "new_user",
+		Domain:         "netbird.io",
+		DomainCategory: PrivateCategory,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, account.Id, joined.Id)
+}
+
+func TestCheckUserDomainAllowed(t *testing.T) {
+	testCases := []struct {
+		name           string
+		allowedDomains []string
+		claims         jwtclaims.AuthorizationClaims
+		expectAllowed  bool
+	}{
+		{
+			name:          "no allowed domains allows any domain",
+			claims:        jwtclaims.AuthorizationClaims{Domain: "example.com"},
+			expectAllowed: true,
+		},
+		{
+			name:           "allowed domain claim",
+			allowedDomains: []string{"netbird.io"},
+			claims:         jwtclaims.AuthorizationClaims{Domain: "NetBird.io", DomainCategory: PrivateCategory},
+			expectAllowed:  true,
+		},
+		{
+			name:           "not allowed domain claim",
+			allowedDomains: []string{"netbird.io"},
+			claims:         jwtclaims.AuthorizationClaims{Domain: "example.com", DomainCategory: PrivateCategory},
+			expectAllowed:  false,
+		},
+		{
+			name:           "allowed verified email domain without domain claim",
+			allowedDomains: []string{"netbird.io"},
+			claims:         jwtclaims.AuthorizationClaims{Raw: jwt.MapClaims{"email": "user@netbird.io", "email_verified": true}},
+			expectAllowed:  true,
+		},
+		{
+			name:           "allowed unverified email domain without domain claim",
+			allowedDomains: []string{"netbird.io"},
+			claims:         jwtclaims.AuthorizationClaims{Raw: jwt.MapClaims{"email": "user@netbird.io"}},
+			expectAllowed:  false,
+		},
+		{
+			name:           "unknown domain",
+			allowedDomains: []string{"netbird.io"},
+			claims:         jwtclaims.AuthorizationClaims{Raw: jwt.MapClaims{}},
+			expectAllowed:  false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			account := &Account{Settings: &Settings{AllowedDomains: testCase.allowedDomains}}
+			err := checkUserDomainAllowed(account, testCase.claims)
+			if testCase.expectAllowed {
+				assert.NoError(t, err)
+				return
+			}
+
+			sErr, ok := status.FromError(err)
+			require.True(t, ok, "expecting a status error")
+			assert.Equal(t, status.PermissionDenied, sErr.Type())
+		})
+	}
 }
 
 func TestAccount_GetExpiredPeers(t *testing.T) {
@@ -130,6 +130,98 @@ const (
 	PeerApprovalRevoked
 	// TransferredOwnerRole indicates that the user transferred the owner role of the account
 	TransferredOwnerRole
+	// DNSRecordCreated indicates that a user created a custom DNS record
+	DNSRecordCreated
+	// DNSRecordUpdated indicates that a user updated a custom DNS record
+	DNSRecordUpdated
+	// DNSRecordDeleted indicates that a user deleted a custom DNS record
+	DNSRecordDeleted
+	// SetupKeyExpired indicates that the system revoked an expired setup key
+	SetupKeyExpired
+	// PeerRejected indicates that a user rejected a peer waiting for approval and the peer has been deleted
+	PeerRejected
+	// AccountNetworkRangeUpdated indicates that a user changed the network range of the account and the peer IPs were re-allocated
+	AccountNetworkRangeUpdated
+	// AccountMaintenanceStarted indicates that a user started a maintenance window pausing the updates of the account peers
+	AccountMaintenanceStarted
+	// AccountMaintenanceStopped indicates that a user stopped a maintenance window and the account peers were updated
+	AccountMaintenanceStopped
+	// AccountDefaultDenyEnabled indicates that the user enabled the default deny for the account
+	AccountDefaultDenyEnabled
+	// AccountDefaultDenyDisabled indicates that the user disabled the default deny for the account
+	AccountDefaultDenyDisabled
+	// IPReservationCreated indicates that a user reserved an IP for a peer
+	IPReservationCreated
+	// IPReservationUpdated indicates that a user changed the IP reserved for a peer
+	IPReservationUpdated
+	// IPReservationDeleted indicates that a user deleted the IP reservation of a peer
+	IPReservationDeleted
+	// OrgKeyCreated indicates that a user created an org key
+	OrgKeyCreated
+	// OrgKeyUpdated indicates that a user updated an org key
+	OrgKeyUpdated
+	// OrgKeyRevoked indicates that a user revoked an org key
+	OrgKeyRevoked
+	// PeerAddedWithOrgKey indicates that a new peer joined the account with an org key of another account
+	PeerAddedWithOrgKey
+	// AccountLinkedOrgKeysUpdated indicates that a user changed the org keys allowed to register peers into the account
+	AccountLinkedOrgKeysUpdated
+	// AccountPeerInactivityCleanupUpdated indicates that a user changed the inactive peers cleanup settings of the account
+	AccountPeerInactivityCleanupUpdated
+	// PeerFlaggedInactive indicates that the system flagged a peer that hasn't connected for longer than the account threshold
+	PeerFlaggedInactive
+	// PeerRemovedForInactivity indicates that the system removed a peer that hasn't connected for longer than the account threshold
+	PeerRemovedForInactivity
+	// AccountMinClientVersionUpdated indicates that a user changed the minimum client version required by the account
+	AccountMinClientVersionUpdated
+	// PeerFlaggedOutdatedClient indicates that the system flagged a peer running an older client than the account requires
+	PeerFlaggedOutdatedClient
+	// PeerKeyRotated indicates that a peer replaced its WireGuard key
+	PeerKeyRotated
+	// NetworkResourceCreated indicates that a user created a network resource
+	NetworkResourceCreated
+	// NetworkResourceUpdated indicates that a user updated a network resource
+	NetworkResourceUpdated
+	// NetworkResourceDeleted indicates that a user deleted a network resource
+	NetworkResourceDeleted
+	// PeerSyncSessionClosed indicates that a user terminated the sync session of a peer
+	PeerSyncSessionClosed
+	// AccountSignalServerUpdated indicates that a user changed the signal server the peers of the account use
+	AccountSignalServerUpdated
+	// PostureCheckCreated indicates that a user created a posture check
+	PostureCheckCreated
+	// PostureCheckUpdated indicates that a user updated a posture check
+	PostureCheckUpdated
+	// PostureCheckDeleted indicates that a user deleted a posture check
+	PostureCheckDeleted
+	// PeerQuarantinedByPostureCheck indicates that the system quarantined a peer failing a posture check
+	PeerQuarantinedByPostureCheck
+	// PrimaryDNSDisabledOSUpdated indicates that a user updated the operating systems of the DNS setting Primary DNS disabled
+	PrimaryDNSDisabledOSUpdated
+	// PeerForceRelayEnabled indicates that a user forced the connections of a peer through a relay
+	PeerForceRelayEnabled
+	// PeerForceRelayDisabled indicates that a user allowed the direct connections of a peer again
+	PeerForceRelayDisabled
+	// AccountPeerUpdateDebounceWindowUpdated indicates that a user changed the debounce window of the peer updates
+	AccountPeerUpdateDebounceWindowUpdated
+	// StaticRouteCreated indicates that a user created a static route of a peer
+	StaticRouteCreated
+	// StaticRouteUpdated indicates that a user updated a static route of a peer
+	StaticRouteUpdated
+	// StaticRouteDeleted indicates that a user deleted a static route of a peer
+	StaticRouteDeleted
+	// AccountPeerIPAllocationUpdated indicates that a user changed how the IPs of the new peers are allocated
+	AccountPeerIPAllocationUpdated
+	// AccountPeerConnectionTimeoutUpdated indicates that a user changed the connection timeout of the peers
+	AccountPeerConnectionTimeoutUpdated
+	// SetupKeyApproved indicates that a user approved a setup key pending approval
+	SetupKeyApproved
+	// AccountSetupKeyApprovalEnabled indicates that a user required the approval of the new setup keys
+	AccountSetupKeyApprovalEnabled
+	// AccountSetupKeyApprovalDisabled indicates that a user no longer required the approval of the new setup keys
+	AccountSetupKeyApprovalDisabled
+	// AccountWebhookSecretRotated indicates that a user rotated the secret signing the webhook payloads of the account
+	AccountWebhookSecretRotated
 )
 
 var activityMap = map[Activity]Code{
@@ -193,6 +285,52 @@ var activityMap = map[Activity]Code{
 	PeerApproved:                              {"Peer approved", "peer.approve"},
 	PeerApprovalRevoked:                       {"Peer approval revoked", "peer.approval.revoke"},
 	TransferredOwnerRole:                      {"Transferred owner role", "transferred.owner.role"},
+	DNSRecordCreated:                          {"DNS record created", "dns.record.add"},
+	DNSRecordUpdated:                          {"DNS record updated", "dns.record.update"},
+	DNSRecordDeleted:                          {"DNS record deleted", "dns.record.delete"},
+	SetupKeyExpired:                           {"Setup key expired", "setupkey.expire"},
+	PeerRejected:                              {"Peer rejected", "peer.reject"},
+	AccountNetworkRangeUpdated:                {"Account network range updated", "account.network.range.update"},
+	AccountMaintenanceStarted:                 {"Account maintenance window started", "account.maintenance.start"},
+	AccountMaintenanceStopped:                 {"Account maintenance window stopped", "account.maintenance.stop"},
+	AccountDefaultDenyEnabled:                 {"Account default deny enabled", "account.setting.default.deny.enable"},
+	AccountDefaultDenyDisabled:                {"Account default deny disabled", "account.setting.default.deny.disable"},
+	IPReservationCreated:                      {"Peer IP reserved", "peer.ip.reservation.add"},
+	IPReservationUpdated:                      {"Peer IP reservation updated", "peer.ip.reservation.update"},
+	IPReservationDeleted:                      {"Peer IP reservation deleted", "peer.ip.reservation.delete"},
+	OrgKeyCreated:                             {"Org key created", "orgkey.add"},
+	OrgKeyUpdated:                             {"Org key updated", "orgkey.update"},
+	OrgKeyRevoked:                             {"Org key revoked", "orgkey.revoke"},
+	PeerAddedWithOrgKey:                       {"Peer added", "orgkey.peer.add"},
+	AccountLinkedOrgKeysUpdated:               {"Account linked org keys updated", "account.setting.linked.orgkeys.update"},
+	AccountPeerInactivityCleanupUpdated:       {"Account inactive peers cleanup updated", "account.setting.peer.inactivity.cleanup.update"},
+	PeerFlaggedInactive:                       {"Peer flagged inactive", "peer.inactivity.flag"},
+	PeerRemovedForInactivity:                  {"Peer removed for inactivity", "peer.inactivity.delete"},
+	AccountMinClientVersionUpdated:            {"Account minimum client version updated", "account.setting.client.version.update"},
+	PeerFlaggedOutdatedClient:                 {"Peer flagged for an outdated client", "peer.client.outdated.flag"},
+	PeerKeyRotated:                            {"Peer WireGuard key rotated", "peer.key.rotate"},
+	NetworkResourceCreated:                    {"Network resource created", "network.resource.add"},
+	NetworkResourceUpdated:                    {"Network resource updated", "network.resource.update"},
+	NetworkResourceDeleted:                    {"Network resource deleted", "network.resource.delete"},
+	PeerSyncSessionClosed:                     {"Peer sync session terminated", "peer.session.terminate"},
+	AccountSignalServerUpdated:                {"Account signal server updated", "account.setting.signal.update"},
+	PostureCheckCreated:                       {"Posture check created", "posture.check.add"},
+	PostureCheckUpdated:                       {"Posture check updated", "posture.check.update"},
+	PostureCheckDeleted:                       {"Posture check deleted", "posture.check.delete"},
+	PeerQuarantinedByPostureCheck:             {"Peer quarantined by a posture check", "peer.posture.quarantine"},
+	PrimaryDNSDisabledOSUpdated:               {"Primary DNS disabled operating systems updated", "dns.setting.primary.disabled.os.update"},
+	PeerForceRelayEnabled:                     {"Peer relay forced", "peer.relay.force.enable"},
+	PeerForceRelayDisabled:                    {"Peer relay no longer forced", "peer.relay.force.disable"},
+	AccountPeerUpdateDebounceWindowUpdated:    {"Account peer update debounce window updated", "account.setting.peer.update.debounce.update"},
+	StaticRouteCreated:                        {"Static route created", "peer.static.route.add"},
+	StaticRouteUpdated:                        {"Static route updated", "peer.static.route.update"},
+	StaticRouteDeleted:                        {"Static route deleted", "peer.static.route.delete"},
+	AccountPeerIPAllocationUpdated:            {"Account peer IP allocation updated", "account.setting.peer.ip.allocation.update"},
+	AccountPeerConnectionTimeoutUpdated:       {"Account peer connection timeout updated", "account.setting.peer.connection.timeout.update"},
+	SetupKeyApproved:                          {"Setup key approved", "setupkey.approve"},
+	AccountSetupKeyApprovalEnabled:            {"Account setup key approval enabled", "account.setting.setupkey.approval.enable"},
+	AccountSetupKeyApprovalDisabled:           {"Account setup key approval disabled", "account.setting.setupkey.approval.disable"},
+	AccountWebhookSecretRotated:               {"Account webhook secret rotated", "account.setting.webhook.secret.rotate"},
 }
 
 // StringCode returns a string code of the activity
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// maxBatchOperations bounds the operations of a batch
+const maxBatchOperations = 100
+
+// BatchOperation is a single change of a batch, exactly one of its fields is set
+type BatchOperation struct {
+	// Group is created or updated
+	Group *Group
+	// Policy is created or updated
+	Policy *Policy
+	// GroupPeer changes the membership of a peer in a group managed manually
+	GroupPeer *BatchGroupPeer
+}
+
+// BatchGroupPeer adds a peer to a group or removes it from the group
+type BatchGroupPeer struct {
+	GroupID string
+	PeerID  string
+	Remove  bool
+}
+
+// Resource returns the resource the operation changes
+func (o *BatchOperation) Resource() (Resource, error) {
+	switch {
+	case o.Group != nil && o.Policy == nil && o.GroupPeer == nil:
+		return ResourceGroups, nil
+	case o.Policy != nil && o.Group == nil && o.GroupPeer == nil:
+		return ResourcePolicies, nil
+	case o.GroupPeer != nil && o.Group == nil && o.Policy == nil:
+		return ResourceGroups, nil
+	default:
+		return "", status.Errorf(status.InvalidArgument, "a batch operation should change exactly one resource")
+	}
+}
+
+// ApplyBatch applies the operations to the account in their order, so an operation can reference the resources
+// created by the previous ones. The batch is atomic: the account is saved once after all the operations succeeded and
+// nothing is saved when any of them fails. The peers get a single network map update for the whole batch.
+func (am *DefaultAccountManager) ApplyBatch(accountID, userID string, operations []*BatchOperation) error {
+	if len(operations) == 0 {
+		return status.Errorf(status.InvalidArgument, "the batch should contain at least one operation")
+	}
+	if len(operations) > maxBatchOperations {
+		return status.Errorf(status.InvalidArgument, "the batch should contain at most %d operations", maxBatchOperations)
+	}
+
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	// the account is a copy of the stored one, the changes of a failed batch are dropped with it
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return err
+	}
+
+	var events []func()
+	var policiesChanged bool
+	for i, operation := range operations {
+		resource, err := operation.Resource()
+		if err != nil {
+			return err
+		}
+		if !user.HasPermission(resource, OperationWrite) {
+			return status.Errorf(status.PermissionDenied, "user is not allowed to change the %s", resource)
+		}
+
+		event, err := am.applyBatchOperation(account, userID, operation)
+		if err != nil {
+			return batchOperationError(i, err)
+		}
+		events = append(events, event)
+		policiesChanged = policiesChanged || operation.Policy != nil
+	}
+
+	account.Network.IncSerial()
+	if err = am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		event()
+	}
+
+	am.updateAccountPeers(account)
+	if policiesChanged {
+		am.checkAndSchedulePolicySchedules(account)
+	}
+
+	return nil
+}
+
+// batchOperationError returns the error of the failed operation with its index, keeping the type of the error
+func batchOperationError(index int, err error) error {
+	errorType := status.Internal
+	if e, ok := status.FromError(err); ok {
+		errorType = e.Type()
+	}
+	return status.Errorf(errorType, "operation %d of the batch failed: %s", index, err)
+}
+
+// applyBatchOperation applies the operation to the account, it returns the function storing the events of the
+// operation once the batch is saved
+func (am *DefaultAccountManager) applyBatchOperation(account *Account, userID string, operation *BatchOperation) (func(), error) {
+	switch {
+	case operation.Group != nil:
+		group := operation.Group
+		oldGroup, err := account.saveGroup(group)
+		if err != nil {
+			return nil, err
+		}
+		// the later operations of the batch may change the peers of the group
+		saved := group.Copy()
+		return func() {
+			am.storeGroupEvents(account, userID, oldGroup, saved)
+		}, nil
+	case operation.Policy != nil:
+		policy := operation.Policy
+		if err := account.validatePolicy(policy); err != nil {
+			return nil, err
+		}
+		action := activity.PolicyAdded
+		if am.savePolicy(account, policy) {
+			action = activity.PolicyUpdated
+		}
+		return func() {
+			am.StoreEvent(userID, policy.ID, account.Id, action, policy.EventMeta())
+		}, nil
+	default:
+		return am.applyBatchGroupPeer(account, userID, operation.GroupPeer)
+	}
+}
+
+func (am *DefaultAccountManager) applyBatchGroupPeer(account *Account, userID string, groupPeer *BatchGroupPeer) (func(), error) {
+	peer := account.GetPeer(groupPeer.PeerID)
+	if peer == nil {
+		return nil, status.Errorf(status.NotFound, "peer with ID %s not found", groupPeer.PeerID)
+	}
+
+	action := activity.GroupAddedToPeer
+	if groupPeer.Remove {
+		action = activity.GroupRemovedFromPeer
+		if _, err := account.removePeerFromGroup(groupPeer.GroupID, groupPeer.PeerID); err != nil {
+			return nil, err
+		}
+	} else if err := account.addPeerToGroup(groupPeer.GroupID, groupPeer.PeerID); err != nil {
+		return nil, err
+	}
+
+	group := account.Groups[groupPeer.GroupID]
+	meta := map[string]any{
+		"group": group.Name, "group_id": group.ID, "peer_ip": peer.IP.String(),
+		"peer_fqdn": peer.FQDN(am.GetDNSDomain()),
+	}
+	return func() {
+		am.StoreEvent(userID, peer.ID, account.Id, action, meta)
+	}, nil
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_ApplyBatch(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	account.Peers["peerA"] = &nbpeer.Peer{ID: "peerA", Key: "peerA-key", IP: net.IP{100, 64, 0, 1}, Status: &nbpeer.PeerStatus{}}
+	account.Peers["peerB"] = &nbpeer.Peer{ID: "peerB", Key: "peerB-key", IP: net.IP{100, 64, 0, 2}, Status: &nbpeer.PeerStatus{}}
+	account.Users["regular_user"] = NewRegularUser("regular_user")
+	require.NoError(t, manager.Store.SaveAccount(account))
+	serial := account.Network.CurrentSerial()
+
+	updates := manager.peersUpdateManager.CreateChannel("peerA")
+	defer manager.peersUpdateManager.CloseChannel("peerA")
+
+	newBatch := func(peerID string) []*BatchOperation {
+		return []*BatchOperation{
+			{Group: &Group{ID: "web", Name: "Web", Issued: GroupIssuedAPI}},
+			{GroupPeer: &BatchGroupPeer{GroupID: "web", PeerID: "peerA"}},
+			{GroupPeer: &BatchGroupPeer{GroupID: "web", PeerID: peerID}},
+			{Policy: &Policy{
+				ID:      "web-policy",
+				Name:    "Web",
+				Enabled: true,
+				Rules: []*PolicyRule{{
+					ID:            "web-rule",
+					Enabled:       true,
+					Action:        PolicyTrafficActionAccept,
+					Protocol:      PolicyRuleProtocolTCP,
+					Ports:         []string{"443"},
+					Bidirectional: true,
+					Sources:       []string{"web"},
+					Destinations:  []string{"web"},
+				}},
+			}},
+		}
+	}
+
+	hasPolicy := func(account *Account, policyID string) bool {
+		for _, policy := range account.Policies {
+			if policy.ID == policyID {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("regular user is not allowed", func(t *testing.T) {
+		err := manager.ApplyBatch(account.Id, "regular_user", newBatch("peerB"))
+		assertStatusType(t, err, status.PermissionDenied)
+	})
+
+	t.Run("failed operation rolls back the batch", func(t *testing.T) {
+		err := manager.ApplyBatch(account.Id, userID, newBatch("unknown"))
+		assertStatusType(t, err, status.NotFound)
+
+		stored, err := manager.Store.GetAccount(account.Id)
+		require.NoError(t, err)
+		assert.NotContains(t, stored.Groups, "web", "the group of a failed batch shouldn't be saved")
+		assert.False(t, hasPolicy(stored, "web-policy"), "the policy of a failed batch shouldn't be saved")
+		assert.Equal(t, serial, stored.Network.CurrentSerial())
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		err := manager.ApplyBatch(account.Id, userID, nil)
+		assertStatusType(t, err, status.InvalidArgument)
+	})
+
+	t.Run("operation changing several resources", func(t *testing.T) {
+		err := manager.ApplyBatch(account.Id, userID, []*BatchOperation{{
+			Group:  &Group{ID: "web", Name: "Web", Issued: GroupIssuedAPI},
+			Policy: &Policy{ID: "web-policy"},
+		}})
+		assertStatusType(t, err, status.InvalidArgument)
+	})
+
+	require.NoError(t, manager.ApplyBatch(account.Id, userID, newBatch("peerB")))
+
+	stored, err := manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	require.Contains(t, stored.Groups, "web")
+	assert.ElementsMatch(t, []string{"peerA", "peerB"}, stored.Groups["web"].Peers)
+	assert.True(t, hasPolicy(stored, "web-policy"))
+	assert.Equal(t, serial+1, stored.Network.CurrentSerial(), "the batch should be saved with a single serial")
+
+	select {
+	case <-updates:
+	case <-time.After(time.Second):
+		t.Fatal("the peers should be updated after the batch")
+	}
+	select {
+	case <-updates:
+		t.Fatal("the peers should get a single update for the batch")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/hashicorp/go-version"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// MinClientVersionActionReject rejects the registration and the login of the peers running an older client
+	MinClientVersionActionReject = "reject"
+	// MinClientVersionActionFlag lets in the peers running an older client and marks them with PeerStatus.OutdatedClient
+	MinClientVersionActionFlag = "flag"
+)
+
+// OutdatedPeer is a peer running an older client than the minimum version of the account
+type OutdatedPeer struct {
+	Peer *nbpeer.Peer
+	// UnknownVersion indicates that the peer reports a development or an unparsable client version
+	UnknownVersion bool
+}
+
+// GetOutdatedPeers returns the peers of the account running an older client than the minimum version, the connected
+// peers first. A non-empty minVersion overrides the one of the account settings, allowing to preview a version
+// before it is saved.
+func (am *DefaultAccountManager) GetOutdatedPeers(accountID, userID, minVersion string) ([]*OutdatedPeer, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourcePeers, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view the outdated peers")
+	}
+
+	settings := account.Settings.Copy()
+	if minVersion != "" {
+		settings.MinClientVersion = minVersion
+	}
+	if settings.MinClientVersion == "" {
+		return nil, status.Errorf(status.InvalidArgument, "minimum client version isn't set")
+	}
+
+	floor, err := version.NewVersion(settings.MinClientVersion)
+	if err != nil {
+		return nil, status.Errorf(status.InvalidArgument, "invalid minimum client version %q", settings.MinClientVersion)
+	}
+
+	return account.getOutdatedPeers(floor, settings.MinClientVersionAllowUnknown), nil
+}
+
+// getOutdatedPeers returns the peers running an older client than floor, the connected peers first.
+// The peers reporting an unknown version are listed unless they are allowed.
+func (a *Account) getOutdatedPeers(floor *version.Version, allowUnknown bool) []*OutdatedPeer {
+	var outdated []*OutdatedPeer
+	for _, peer := range a.Peers {
+		unknown := false
+		peerVersion, err := parseClientVersion(peer.Meta.WtVersion)
+		if err != nil {
+			if allowUnknown {
+				continue
+			}
+			unknown = true
+		} else if !peerVersion.LessThan(floor.Core()) {
+			continue
+		}
+
+		peerCopy := peer.Copy()
+		if peerCopy.Status == nil {
+			peerCopy.Status = &nbpeer.PeerStatus{}
+		}
+		outdated = append(outdated, &OutdatedPeer{Peer: peerCopy, UnknownVersion: unknown})
+	}
+
+	sort.Slice(outdated, func(i, j int) bool {
+		if outdated[i].Peer.Status.Connected != outdated[j].Peer.Status.Connected {
+			return outdated[i].Peer.Status.Connected
+		}
+		if outdated[i].Peer.Name != outdated[j].Peer.Name {
+			return outdated[i].Peer.Name < outdated[j].Peer.Name
+		}
+		return outdated[i].Peer.ID < outdated[j].Peer.ID
+	})
+
+	return outdated
+}
+
+// checkClientVersion returns a PreconditionFailed error telling the user to upgrade when the client version doesn't
+// meet the minimum version of the account. The pre-release and metadata parts of the versions are ignored, so the
+// development builds of a release pass.
+func checkClientVersion(settings *Settings, clientVersion string) error {
+	if settings.MinClientVersion == "" {
+		return nil
+	}
+
+	floor, err := version.NewVersion(settings.MinClientVersion)
+	if err != nil {
+		// validated on update, an invalid version stored before doesn't lock the peers out
+		log.Warnf("ignoring invalid minimum client version %q: %v", settings.MinClientVersion, err)
+		return nil
+	}
+
+	peerVersion, err := parseClientVersion(clientVersion)
+	if err != nil {
+		if settings.MinClientVersionAllowUnknown {
+			return nil
+		}
+		return status.Errorf(status.PreconditionFailed,
+			"NetBird client version %q can't be checked against the minimum version %s required by the account, please upgrade",
+			clientVersion, floor.Core())
+	}
+
+	if peerVersion.LessThan(floor.Core()) {
+		return status.Errorf(status.PreconditionFailed,
+			"NetBird client version %s is older than the minimum version %s required by the account, please upgrade",
+			clientVersion, floor.Core())
+	}
+
+	return nil
+}
+
+// parseClientVersion returns the core version of the client version reported by a peer
+func parseClientVersion(clientVersion string) (*version.Version, error) {
+	v, err := version.NewVersion(clientVersion)
+	if err != nil {
+		return nil, err
+	}
+	return v.Core(), nil
+}
+
+// applyClientVersionCheck rejects the login of the peer or updates its outdated client flag depending on the account
+// settings. It returns true when the flag changed and the account has to be saved.
+func (am *DefaultAccountManager) applyClientVersionCheck(account *Account, peer *nbpeer.Peer, clientVersion string) (bool, error) {
+	err := checkClientVersion(account.Settings, clientVersion)
+	if err != nil && account.Settings.MinClientVersionAction != MinClientVersionActionFlag {
+		log.Debugf("rejecting peer %s with client version %q: %v", peer.Key, clientVersion, err)
+		return false, err
+	}
+
+	outdated := err != nil
+	if peer.Status == nil || peer.Status.OutdatedClient == outdated {
+		return false, nil
+	}
+
+	newStatus := peer.Status.Copy()
+	newStatus.OutdatedClient = outdated
+	peer.Status = newStatus
+	account.UpdatePeer(peer)
+
+	if outdated {
+		am.storePeerFlaggedOutdatedEvent(account, peer, clientVersion)
+	}
+
+	return true, nil
+}
+
+func (am *DefaultAccountManager) storePeerFlaggedOutdatedEvent(account *Account, peer *nbpeer.Peer, clientVersion string) {
+	meta := peer.EventMeta(am.GetDNSDomain())
+	meta["version"] = clientVersion
+	meta["min_version"] = account.Settings.MinClientVersion
+	am.StoreEvent(activity.SystemInitiator, peer.ID, account.Id, activity.PeerFlaggedOutdatedClient, meta)
+}
+
+func validateMinClientVersionSettings(settings *Settings) error {
+	if settings.MinClientVersion == "" {
+		return nil
+	}
+
+	if _, err := version.NewVersion(settings.MinClientVersion); err != nil {
+		return status.Errorf(status.InvalidArgument, "invalid minimum client version %q", settings.MinClientVersion)
+	}
+
+	switch settings.MinClientVersionAction {
+	case MinClientVersionActionReject, MinClientVersionActionFlag:
+		return nil
+	default:
+		return status.Errorf(status.InvalidArgument, "invalid minimum client version action %q, expected %s or %s",
+			settings.MinClientVersionAction, MinClientVersionActionReject, MinClientVersionActionFlag)
+	}
+}
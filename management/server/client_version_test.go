@@ -0,0 +1,206 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestCheckClientVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		settings      *Settings
+		clientVersion string
+		expectErr     bool
+	}{
+		{
+			name:          "check disabled",
+			settings:      &Settings{},
+			clientVersion: "0.1.0",
+		},
+		{
+			name:          "same version",
+			settings:      &Settings{MinClientVersion: "0.27.0"},
+			clientVersion: "0.27.0",
+		},
+		{
+			name:          "newer version",
+			settings:      &Settings{MinClientVersion: "0.27.0"},
+			clientVersion: "0.28.1",
+		},
+		{
+			name:          "older version",
+			settings:      &Settings{MinClientVersion: "0.27.0"},
+			clientVersion: "0.26.9",
+			expectErr:     true,
+		},
+		{
+			name:          "pre-release of the minimum version",
+			settings:      &Settings{MinClientVersion: "0.27.0"},
+			clientVersion: "0.27.0-rc1",
+		},
+		{
+			name:          "development version denied",
+			settings:      &Settings{MinClientVersion: "0.27.0"},
+			clientVersion: "development",
+			expectErr:     true,
+		},
+		{
+			name:          "development version allowed",
+			settings:      &Settings{MinClientVersion: "0.27.0", MinClientVersionAllowUnknown: true},
+			clientVersion: "development",
+		},
+		{
+			name:          "missing version denied",
+			settings:      &Settings{MinClientVersion: "0.27.0"},
+			clientVersion: "",
+			expectErr:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkClientVersion(tc.settings, tc.clientVersion)
+			if !tc.expectErr {
+				assert.NoError(t, err)
+				return
+			}
+			assertStatusType(t, err, status.PreconditionFailed)
+			assert.Contains(t, err.Error(), "please upgrade")
+		})
+	}
+}
+
+func TestDefaultAccountManager_MinClientVersion(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	oldKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	oldPeer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  oldKey.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "old", WtVersion: "0.25.0"},
+	})
+	require.NoError(t, err)
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:    time.Hour,
+		MinClientVersion:       "latest",
+		MinClientVersionAction: MinClientVersionActionReject,
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:    time.Hour,
+		MinClientVersion:       "0.27.0",
+		MinClientVersionAction: "block",
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:    time.Hour,
+		MinClientVersion:       "0.27.0",
+		MinClientVersionAction: MinClientVersionActionReject,
+	})
+	require.NoError(t, err)
+
+	outdated, err := manager.GetOutdatedPeers(account.Id, userID, "")
+	require.NoError(t, err)
+	if assert.Len(t, outdated, 1) {
+		assert.Equal(t, oldPeer.ID, outdated[0].Peer.ID)
+		assert.False(t, outdated[0].UnknownVersion)
+	}
+
+	outdated, err = manager.GetOutdatedPeers(account.Id, userID, "0.20.0")
+	require.NoError(t, err)
+	assert.Empty(t, outdated, "the previewed version should be used instead of the account one")
+
+	_, _, err = manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: oldKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "old", WtVersion: "0.25.0"},
+	})
+	assertStatusType(t, err, status.PreconditionFailed)
+
+	newKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	_, _, err = manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  newKey.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "new", WtVersion: "development"},
+	})
+	assertStatusType(t, err, status.PreconditionFailed)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Len(t, account.Peers, 1, "the rejected peer shouldn't be added")
+	assert.Equal(t, 1, account.SetupKeys[setupKey.Key].UsedTimes, "the rejected peer shouldn't use the setup key")
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:    time.Hour,
+		MinClientVersion:       "0.27.0",
+		MinClientVersionAction: MinClientVersionActionFlag,
+	})
+	require.NoError(t, err)
+
+	loggedIn, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: oldKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "old", WtVersion: "0.25.0"},
+	})
+	require.NoError(t, err)
+	assert.True(t, loggedIn.Status.OutdatedClient, "the outdated peer should be flagged")
+
+	added, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  newKey.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "new", WtVersion: "development"},
+	})
+	require.NoError(t, err)
+	assert.True(t, added.Status.OutdatedClient, "the peer with an unknown version should be flagged")
+
+	loggedIn, _, err = manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: oldKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "old", WtVersion: "0.27.1"},
+	})
+	require.NoError(t, err)
+	assert.False(t, loggedIn.Status.OutdatedClient, "the flag should be cleared after the upgrade")
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.False(t, account.Peers[oldPeer.ID].Status.OutdatedClient)
+
+	outdated, err = manager.GetOutdatedPeers(account.Id, userID, "")
+	require.NoError(t, err)
+	if assert.Len(t, outdated, 1) {
+		assert.Equal(t, added.ID, outdated[0].Peer.ID)
+		assert.True(t, outdated[0].UnknownVersion)
+		assert.True(t, outdated[0].Peer.Status.OutdatedClient)
+	}
+}
+
+func TestDefaultAccountManager_GetOutdatedPeersPermissions(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := createAccount(manager, "test_account", "account_creator", "")
+	require.NoError(t, err)
+
+	account.Users["regular_user"] = NewRegularUser("regular_user")
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, err = manager.GetOutdatedPeers(account.Id, "regular_user", "0.27.0")
+	assertStatusType(t, err, status.PermissionDenied)
+
+	_, err = manager.GetOutdatedPeers(account.Id, "account_creator", "")
+	assertStatusType(t, err, status.InvalidArgument)
+}
@@ -1,8 +1,10 @@
 package server
 
 import (
+	"fmt"
 	"net/netip"
 	"net/url"
+	"strings"
 
 	"github.com/netbirdio/netbird/management/server/idp"
 	"github.com/netbirdio/netbird/util"
@@ -50,6 +52,49 @@ type Config struct {
 	PKCEAuthorizationFlow *PKCEAuthorizationFlow
 
 	StoreConfig StoreConfig
+
+	SetupKeyExpiry *SetupKeyExpiryConfig
+
+	Webhooks *WebhooksConfig
+
+	IdpOutage *IdpOutageConfig
+
+	// IPv6Prefix is a unique local IPv6 prefix, e.g. fd00:1234::/48, the account networks take a random /64 subnet from
+	// to allocate an IPv6 address to every peer. The IPv6 allocation is disabled when empty.
+	// Note that the firewall rules of the access control policies are applied to the IPv4 addresses only.
+	IPv6Prefix string
+
+	// ServerKeyLifetime is how long a WireGuard key of the server is used to encrypt the messages before it is rotated.
+	// Defaults to 24 hours
+	ServerKeyLifetime util.Duration
+
+	// secretReferences is true when some of the secrets have been resolved from the references to config sources
+	secretReferences bool
+}
+
+// WebhooksConfig is a config of the delivery of the account lifecycle events to the webhooks set in the account settings.
+// The delivery is disabled when the config isn't set. The payloads are signed with the webhook secret of each account.
+type WebhooksConfig struct {
+	// MaxAttempts is the number of attempts to deliver an event before dropping it. Defaults to 5
+	MaxAttempts int
+}
+
+// IdpOutageConfig is a config of the JWT logins while the IdP manager is unavailable
+type IdpOutageConfig struct {
+	// Strict fails the JWT logins needing the IdP user metadata while the IdP manager is unavailable. Otherwise a token
+	// validated with the JWT keys is enough, the IdP lookups are deferred to the next login.
+	Strict bool
+	// StaleTTL is how long the IdP user metadata last loaded is used while the IdP manager is unavailable.
+	// Defaults to 24 hours
+	StaleTTL util.Duration
+}
+
+// SetupKeyExpiryConfig is a config of the background revocation of expired setup keys
+type SetupKeyExpiryConfig struct {
+	// SweepInterval is how often the setup keys are checked for expiration. Defaults to 1 hour
+	SweepInterval util.Duration
+	// WebhookURL is an optional endpoint notified with a JSON POST request about the revoked setup keys
+	WebhookURL string
 }
 
 // GetAuthAudiences returns the audience from the http config and device authorization flow config
@@ -67,8 +112,12 @@ func (c Config) GetAuthAudiences() []string {
 type TURNConfig struct {
 	TimeBasedCredentials bool
 	CredentialsTTL       util.Duration
-	Secret               string
-	Turns                []*Host
+	// CredentialsSkewTolerance extends the validity of the time-based credentials beyond CredentialsTTL, so the
+	// credentials of the peers keep being accepted by a TURN server whose clock is ahead by up to this duration.
+	// The credentials are still refreshed according to CredentialsTTL.
+	CredentialsSkewTolerance util.Duration
+	Secret                   string
+	Turns                    []*Host
 }
 
 // HttpServerConfig is a config of the HTTP Management service server
@@ -90,6 +139,12 @@ type HttpServerConfig struct {
 	OIDCConfigEndpoint string
 	// IdpSignKeyRefreshEnabled identifies the signing key is currently being rotated or not
 	IdpSignKeyRefreshEnabled bool
+	// BasePath is the path prefix the HTTP API and gRPC services are served under, e.g. /netbird when
+	// the management service is mounted at a sub path behind a reverse proxy. Empty means the domain root
+	BasePath string
+	// Address is not used, the listening address is set with the --port flag.
+	// Deprecated. Kept to accept the config files generated by the setup scripts
+	Address string
 }
 
 // Host represents a Wiretrustee host (e.g. STUN, TURN, Signal)
@@ -99,6 +154,12 @@ type Host struct {
 	URI      string
 	Username string
 	Password string
+	// Priority of the TURN server, the clients try the servers of the lowest priority first and fall back to the
+	// next priority if they fail. Zero is the most preferred.
+	Priority int32
+	// Weight of the TURN server within its priority, the clients try the heavier servers first and fall back to the
+	// lighter ones if they fail
+	Weight int32
 }
 
 // DeviceAuthorizationFlow represents Device Authorization Flow information
@@ -143,9 +204,90 @@ type ProviderConfig struct {
 
 // StoreConfig contains Store configuration
 type StoreConfig struct {
+	// Engine is the store backend: jsonfile, sqlite or postgres. The postgres engine reads the DSN of the database
+	// from the NETBIRD_STORE_ENGINE_POSTGRES_DSN environment variable.
 	Engine StoreEngine
 }
 
+// Validate checks the required combinations of the config fields and returns an error listing all the problems found
+func (c *Config) Validate() error {
+	var problems []string
+
+	for i, stun := range c.Stuns {
+		problems = append(problems, validateHost(fmt.Sprintf("Stuns[%d]", i), stun)...)
+	}
+
+	if c.TURNConfig != nil {
+		if c.TURNConfig.TimeBasedCredentials && c.TURNConfig.Secret == "" {
+			problems = append(problems, "TURNConfig.Secret is required when TURNConfig.TimeBasedCredentials is enabled")
+		}
+		if c.TURNConfig.CredentialsSkewTolerance.Duration < 0 {
+			problems = append(problems, "TURNConfig.CredentialsSkewTolerance should not be negative")
+		}
+		for i, turn := range c.TURNConfig.Turns {
+			name := fmt.Sprintf("TURNConfig.Turns[%d]", i)
+			problems = append(problems, validateHost(name, turn)...)
+			if turn != nil && (turn.Priority < 0 || turn.Weight < 0) {
+				problems = append(problems, fmt.Sprintf("%s.Priority and %s.Weight should not be negative", name, name))
+			}
+		}
+	}
+
+	if c.Signal == nil {
+		problems = append(problems, "Signal is required")
+	} else {
+		problems = append(problems, validateHost("Signal", c.Signal)...)
+	}
+
+	if c.HttpConfig == nil {
+		problems = append(problems, "HttpConfig is required")
+	} else {
+		problems = append(problems, c.HttpConfig.validate()...)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid management config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (c *HttpServerConfig) validate() []string {
+	var problems []string
+
+	// the OIDC configuration endpoint overrides the issuer and the keys location after the validation
+	if c.OIDCConfigEndpoint != "" {
+		if !validateURL(c.OIDCConfigEndpoint) {
+			problems = append(problems, fmt.Sprintf("HttpConfig.OIDCConfigEndpoint %q is not a valid URL", c.OIDCConfigEndpoint))
+		}
+	} else if c.AuthIssuer != "" && c.AuthKeysLocation == "" {
+		problems = append(problems, "HttpConfig.AuthKeysLocation is required when HttpConfig.AuthIssuer is set")
+	}
+
+	if (c.CertFile == "") != (c.CertKey == "") {
+		problems = append(problems, "HttpConfig.CertFile and HttpConfig.CertKey should be set together")
+	}
+
+	return problems
+}
+
+func validateHost(name string, host *Host) []string {
+	if host == nil {
+		return []string{fmt.Sprintf("%s is empty", name)}
+	}
+
+	var problems []string
+	switch host.Proto {
+	case UDP, DTLS, TCP, HTTP, HTTPS:
+	default:
+		problems = append(problems, fmt.Sprintf("%s.Proto %q is not one of udp, dtls, tcp, http, https", name, host.Proto))
+	}
+
+	if host.URI == "" {
+		problems = append(problems, fmt.Sprintf("%s.URI is required", name))
+	}
+	return problems
+}
+
 // validateURL validates input http url
 func validateURL(httpURL string) bool {
 	_, err := url.ParseRequestURI(httpURL)
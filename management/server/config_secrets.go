@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/netbirdio/netbird/management/server/configsource"
+)
+
+// redactedSecret replaces the secrets in the redacted config
+const redactedSecret = "[REDACTED]"
+
+// idpExtraSecrets are the keys of the IdP manager extra config holding secrets, the other keys hold URLs and IDs
+var idpExtraSecrets = []string{"Password", "ApiToken", "ServiceAccountKey"}
+
+type configSecret struct {
+	name  string
+	value *string
+}
+
+// secretFields returns the config fields holding secrets, the IdP manager extra config is handled separately
+func (c *Config) secretFields() []configSecret {
+	fields := []configSecret{{"DataStoreEncryptionKey", &c.DataStoreEncryptionKey}}
+
+	hosts := func(name string, hosts []*Host) {
+		for i, host := range hosts {
+			if host != nil {
+				fields = append(fields, configSecret{fmt.Sprintf("%s[%d].Password", name, i), &host.Password})
+			}
+		}
+	}
+	hosts("Stuns", c.Stuns)
+	if c.Signal != nil {
+		fields = append(fields, configSecret{"Signal.Password", &c.Signal.Password})
+	}
+	if c.TURNConfig != nil {
+		fields = append(fields, configSecret{"TURNConfig.Secret", &c.TURNConfig.Secret})
+		hosts("TURNConfig.Turns", c.TURNConfig.Turns)
+	}
+
+	if idpConfig := c.IdpManagerConfig; idpConfig != nil {
+		if idpConfig.ClientConfig != nil {
+			fields = append(fields, configSecret{"IdpManagerConfig.ClientConfig.ClientSecret", &idpConfig.ClientConfig.ClientSecret})
+		}
+		if idpConfig.Auth0ClientCredentials != nil {
+			fields = append(fields, configSecret{"IdpManagerConfig.Auth0ClientCredentials.ClientSecret", &idpConfig.Auth0ClientCredentials.ClientSecret})
+		}
+		if idpConfig.AzureClientCredentials != nil {
+			fields = append(fields, configSecret{"IdpManagerConfig.AzureClientCredentials.ClientSecret", &idpConfig.AzureClientCredentials.ClientSecret})
+		}
+		if idpConfig.KeycloakClientCredentials != nil {
+			fields = append(fields, configSecret{"IdpManagerConfig.KeycloakClientCredentials.ClientSecret", &idpConfig.KeycloakClientCredentials.ClientSecret})
+		}
+		if idpConfig.ZitadelClientCredentials != nil {
+			fields = append(fields, configSecret{"IdpManagerConfig.ZitadelClientCredentials.ClientSecret", &idpConfig.ZitadelClientCredentials.ClientSecret})
+		}
+	}
+
+	if c.DeviceAuthorizationFlow != nil {
+		fields = append(fields, configSecret{"DeviceAuthorizationFlow.ProviderConfig.ClientSecret", &c.DeviceAuthorizationFlow.ProviderConfig.ClientSecret})
+	}
+	if c.PKCEAuthorizationFlow != nil {
+		fields = append(fields, configSecret{"PKCEAuthorizationFlow.ProviderConfig.ClientSecret", &c.PKCEAuthorizationFlow.ProviderConfig.ClientSecret})
+	}
+
+	return fields
+}
+
+// ResolveSecrets replaces the secrets referencing a config source, e.g. env://TURN_SECRET, with the resolved values.
+// It fails on the first reference that can't be resolved. The secrets set inline are kept as is.
+func (c *Config) ResolveSecrets() error {
+	for _, field := range c.secretFields() {
+		if !configsource.IsReference(*field.value) {
+			continue
+		}
+		value, err := configsource.Resolve(*field.value)
+		if err != nil {
+			return fmt.Errorf("failed resolving %s: %w", field.name, err)
+		}
+		*field.value = value
+		c.secretReferences = true
+	}
+
+	if c.IdpManagerConfig == nil {
+		return nil
+	}
+
+	for _, key := range idpExtraSecrets {
+		reference, ok := c.IdpManagerConfig.ExtraConfig[key]
+		if !ok || !configsource.IsReference(reference) {
+			continue
+		}
+		value, err := configsource.Resolve(reference)
+		if err != nil {
+			return fmt.Errorf("failed resolving IdpManagerConfig.ExtraConfig.%s: %w", key, err)
+		}
+		c.IdpManagerConfig.ExtraConfig[key] = value
+		c.secretReferences = true
+	}
+
+	return nil
+}
+
+// HasSecretReferences returns true if some of the secrets have been resolved from config sources.
+// Such a config should not be written back to the file as it would inline the resolved secrets.
+func (c *Config) HasSecretReferences() bool {
+	return c.secretReferences
+}
+
+// Redacted returns a deep copy of the config with the secrets replaced, for logging
+func (c *Config) Redacted() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	redacted := &Config{}
+	if err := json.Unmarshal(data, redacted); err != nil {
+		return nil, err
+	}
+
+	for _, field := range redacted.secretFields() {
+		if *field.value != "" {
+			*field.value = redactedSecret
+		}
+	}
+	if redacted.IdpManagerConfig != nil {
+		for _, key := range idpExtraSecrets {
+			if redacted.IdpManagerConfig.ExtraConfig[key] != "" {
+				redacted.IdpManagerConfig.ExtraConfig[key] = redactedSecret
+			}
+		}
+	}
+
+	return redacted, nil
+}
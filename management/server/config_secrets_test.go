@@ -0,0 +1,75 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/idp"
+)
+
+func newSecretsTestConfig() *Config {
+	return &Config{
+		TURNConfig: &TURNConfig{
+			Secret: "env://NB_TEST_TURN_SECRET",
+			Turns:  []*Host{{Proto: UDP, URI: "turn:turn.example.com:3478", Username: "user", Password: "turn-password"}},
+		},
+		Signal:                 &Host{Proto: HTTPS, URI: "signal.example.com:443"},
+		DataStoreEncryptionKey: "store-key",
+		HttpConfig:             &HttpServerConfig{AuthAudience: "audience"},
+		IdpManagerConfig: &idp.Config{
+			ManagerType:  "zitadel",
+			ClientConfig: &idp.ClientConfig{ClientID: "client", ClientSecret: "env://NB_TEST_IDP_SECRET"},
+			ExtraConfig:  idp.ExtraConfig{"Password": "env://NB_TEST_IDP_PASSWORD", "ManagementEndpoint": "https://idp.example.com"},
+		},
+	}
+}
+
+func TestConfig_ResolveSecrets(t *testing.T) {
+	t.Setenv("NB_TEST_TURN_SECRET", "turn-secret")
+	t.Setenv("NB_TEST_IDP_SECRET", "idp-secret")
+	t.Setenv("NB_TEST_IDP_PASSWORD", "idp-password")
+
+	config := newSecretsTestConfig()
+	require.NoError(t, config.ResolveSecrets())
+
+	assert.True(t, config.HasSecretReferences())
+	assert.Equal(t, "turn-secret", config.TURNConfig.Secret)
+	assert.Equal(t, "idp-secret", config.IdpManagerConfig.ClientConfig.ClientSecret)
+	assert.Equal(t, "idp-password", config.IdpManagerConfig.ExtraConfig["Password"])
+	assert.Equal(t, "turn-password", config.TURNConfig.Turns[0].Password, "inline secret should be kept")
+	assert.Equal(t, "https://idp.example.com", config.IdpManagerConfig.ExtraConfig["ManagementEndpoint"])
+
+	inline := &Config{DataStoreEncryptionKey: "store-key"}
+	require.NoError(t, inline.ResolveSecrets())
+	assert.False(t, inline.HasSecretReferences())
+}
+
+func TestConfig_ResolveSecrets_Unresolved(t *testing.T) {
+	t.Setenv("NB_TEST_TURN_SECRET", "turn-secret")
+	t.Setenv("NB_TEST_IDP_PASSWORD", "idp-password")
+
+	err := newSecretsTestConfig().ResolveSecrets()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "IdpManagerConfig.ClientConfig.ClientSecret")
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	config := newSecretsTestConfig()
+
+	redacted, err := config.Redacted()
+	require.NoError(t, err)
+
+	assert.Equal(t, redactedSecret, redacted.TURNConfig.Secret)
+	assert.Equal(t, redactedSecret, redacted.TURNConfig.Turns[0].Password)
+	assert.Equal(t, redactedSecret, redacted.DataStoreEncryptionKey)
+	assert.Equal(t, redactedSecret, redacted.IdpManagerConfig.ClientConfig.ClientSecret)
+	assert.Equal(t, redactedSecret, redacted.IdpManagerConfig.ExtraConfig["Password"])
+	assert.Equal(t, "https://idp.example.com", redacted.IdpManagerConfig.ExtraConfig["ManagementEndpoint"])
+	assert.Equal(t, "user", redacted.TURNConfig.Turns[0].Username)
+	assert.Empty(t, redacted.Signal.Password, "empty secret should stay empty")
+
+	assert.Equal(t, "turn-password", config.TURNConfig.Turns[0].Password, "original config should not be modified")
+	assert.Equal(t, "store-key", config.DataStoreEncryptionKey)
+}
@@ -0,0 +1,93 @@
+// Package configsource resolves the management config values kept outside the config file. A value of the form
+// <scheme>://<reference>, e.g. env://TURN_SECRET or file:///run/secrets/turn, is resolved with the provider
+// registered for the scheme instead of being used as is.
+package configsource
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Provider resolves the value referenced by the part of a reference following the scheme
+type Provider interface {
+	Resolve(reference string) (string, error)
+}
+
+// ProviderFunc is a function implementing the Provider interface
+type ProviderFunc func(reference string) (string, error)
+
+// Resolve calls the function
+func (f ProviderFunc) Resolve(reference string) (string, error) {
+	return f(reference)
+}
+
+var schemePattern = regexp.MustCompile(`^([a-z][a-z0-9+.-]*)://`)
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{
+		"env":  ProviderFunc(resolveEnv),
+		"file": ProviderFunc(resolveFile),
+	}
+)
+
+// Register registers the provider resolving the references of the scheme, e.g. "vault" for vault://secret/turn.
+// It replaces the provider previously registered for the scheme.
+func Register(scheme string, provider Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[scheme] = provider
+}
+
+// IsReference returns true if the value is a reference to be resolved rather than a literal value
+func IsReference(value string) bool {
+	return schemePattern.MatchString(value)
+}
+
+// Resolve returns the value referenced by the reference or the value as is if it is not a reference.
+// It fails if no provider is registered for the scheme or the referenced value is empty.
+func Resolve(value string) (string, error) {
+	match := schemePattern.FindStringSubmatch(value)
+	if match == nil {
+		return value, nil
+	}
+
+	scheme := match[1]
+	mu.RLock()
+	provider, ok := providers[scheme]
+	mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no config source registered for the scheme %s", scheme)
+	}
+
+	resolved, err := provider.Resolve(strings.TrimPrefix(value, match[0]))
+	if err != nil {
+		return "", fmt.Errorf("failed resolving %s: %w", value, err)
+	}
+	if resolved == "" {
+		return "", fmt.Errorf("%s resolved to an empty value", value)
+	}
+
+	return resolved, nil
+}
+
+// resolveEnv returns the value of the environment variable, e.g. env://TURN_SECRET
+func resolveEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// resolveFile returns the content of the file without the trailing line break, e.g. file:///run/secrets/turn
+func resolveFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,58 @@
+package configsource
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("NB_TEST_SECRET", "env-secret")
+	t.Setenv("NB_TEST_EMPTY", "")
+
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("file-secret\n"), 0600))
+
+	Register("test", ProviderFunc(func(reference string) (string, error) {
+		return "test-" + reference, nil
+	}))
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+		fail     bool
+	}{
+		{name: "Literal", value: "plain-secret", expected: "plain-secret"},
+		{name: "Environment", value: "env://NB_TEST_SECRET", expected: "env-secret"},
+		{name: "File", value: "file://" + secretFile, expected: "file-secret"},
+		{name: "Registered Provider", value: "test://turn", expected: "test-turn"},
+		{name: "Unset Environment Variable", value: "env://NB_TEST_UNSET", fail: true},
+		{name: "Empty Environment Variable", value: "env://NB_TEST_EMPTY", fail: true},
+		{name: "Missing File", value: "file://" + filepath.Join(t.TempDir(), "missing"), fail: true},
+		{name: "Unknown Scheme", value: "vault://secret/turn", fail: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			value, err := Resolve(testCase.value)
+			if testCase.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, value)
+		})
+	}
+}
+
+func TestIsReference(t *testing.T) {
+	assert.True(t, IsReference("env://TURN_SECRET"))
+	assert.True(t, IsReference("file:///run/secrets/turn"))
+	assert.False(t, IsReference("plain-secret"))
+	assert.False(t, IsReference(""))
+	assert.False(t, IsReference("Secret://value"))
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// DefaultDenyReport describes the effect of the default deny setting on the connectivity of the account peers.
+// Admins review it before enabling the setting on an account relying on the policies allowing all peers.
+type DefaultDenyReport struct {
+	// Enabled indicates whether the default deny is enabled for the account
+	Enabled bool
+	// IgnoredPolicies are the enabled policies with an accept rule from the All group to the All group,
+	// which don't connect the peers when the default deny is enabled
+	IgnoredPolicies []*Policy
+	// AffectedPeers are the peers losing connections when the default deny is enabled, by peer ID
+	AffectedPeers map[string]*DefaultDenyAffectedPeer
+}
+
+// DefaultDenyAffectedPeer is a peer losing connections when the default deny is enabled
+type DefaultDenyAffectedPeer struct {
+	// Name of the peer
+	Name string
+	// LostPeers is the number of peers the peer doesn't connect to when the default deny is enabled
+	LostPeers int
+	// RemainingPeers is the number of peers the peer still connects to when the default deny is enabled
+	RemainingPeers int
+}
+
+// allowsAllPeers returns true for an enabled accept rule connecting the All group to the All group.
+// Such a rule, like the Default policy created with every account, connects the peers implicitly.
+func (pm *PolicyRule) allowsAllPeers(allGroupID string) bool {
+	if !pm.Enabled || pm.Action == PolicyTrafficActionDrop || allGroupID == "" {
+		return false
+	}
+
+	inSources, inDestinations := false, false
+	for _, groupID := range pm.Sources {
+		inSources = inSources || groupID == allGroupID
+	}
+	for _, groupID := range pm.Destinations {
+		inDestinations = inDestinations || groupID == allGroupID
+	}
+	return inSources && inDestinations
+}
+
+// allGroupID returns the ID of the All group of the account, empty if the account has none
+func (a *Account) allGroupID() string {
+	all, err := a.GetGroupAll()
+	if err != nil {
+		return ""
+	}
+	return all.ID
+}
+
+// isDefaultDenyIgnored returns true if the rule doesn't connect the peers because of the default deny setting
+func (a *Account) isDefaultDenyIgnored(rule *PolicyRule, allGroupID string) bool {
+	return a.Settings != nil && a.Settings.DefaultDenyEnabled && rule.allowsAllPeers(allGroupID)
+}
+
+// validatePolicyForDefaultDeny rejects an enabled policy allowing all peers on an account with the default deny,
+// because its rule would be silently ignored
+func (a *Account) validatePolicyForDefaultDeny(policy *Policy) error {
+	if a.Settings == nil || !a.Settings.DefaultDenyEnabled || !policy.Enabled {
+		return nil
+	}
+
+	allGroupID := a.allGroupID()
+	for _, rule := range policy.Rules {
+		if rule.allowsAllPeers(allGroupID) {
+			return status.Errorf(status.InvalidArgument,
+				"rule %s allows all peers to connect, which the default deny of the account doesn't permit, "+
+					"use groups of the peers that need to connect instead", rule.Name)
+		}
+	}
+	return nil
+}
+
+// GetDefaultDenyReport returns the policies ignored and the peers losing connections when the default deny is enabled
+func (am *DefaultAccountManager) GetDefaultDenyReport(accountID, userID string) (*DefaultDenyReport, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceAccounts, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view the default deny report")
+	}
+
+	if account.Settings == nil {
+		account.Settings = &Settings{}
+	}
+
+	report := &DefaultDenyReport{
+		Enabled:       account.Settings.DefaultDenyEnabled,
+		AffectedPeers: make(map[string]*DefaultDenyAffectedPeer),
+	}
+
+	allGroupID := account.allGroupID()
+	for _, policy := range account.Policies {
+		if !policy.Enabled {
+			continue
+		}
+		for _, rule := range policy.Rules {
+			if rule.allowsAllPeers(allGroupID) {
+				report.IgnoredPolicies = append(report.IgnoredPolicies, policy.Copy())
+				break
+			}
+		}
+	}
+
+	permissive := account.Copy()
+	permissive.Settings.DefaultDenyEnabled = false
+	denying := account.Copy()
+	denying.Settings.DefaultDenyEnabled = true
+
+	for peerID, peer := range account.Peers {
+		permittedPeers, _ := permissive.getPeerConnectionResources(peerID)
+		remainingPeers, _ := denying.getPeerConnectionResources(peerID)
+		if len(remainingPeers) < len(permittedPeers) {
+			report.AffectedPeers[peerID] = &DefaultDenyAffectedPeer{
+				Name:           peer.Name,
+				LostPeers:      len(permittedPeers) - len(remainingPeers),
+				RemainingPeers: len(remainingPeers),
+			}
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_DefaultDeny(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	addPeer := func(hostname string) *nbpeer.Peer {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		return peer
+	}
+
+	clientPeer := addPeer("client")
+	serverPeer := addPeer("server")
+	otherPeer := addPeer("other")
+
+	networkMap, err := manager.GetNetworkMap(clientPeer.ID)
+	require.NoError(t, err)
+	assert.Len(t, networkMap.Peers, 2, "the Default policy should connect all peers")
+
+	report, err := manager.GetDefaultDenyReport(account.Id, userID)
+	require.NoError(t, err)
+	assert.False(t, report.Enabled)
+	require.Len(t, report.IgnoredPolicies, 1)
+	assert.Equal(t, DefaultPolicyName, report.IgnoredPolicies[0].Name)
+	require.Len(t, report.AffectedPeers, 3)
+	assert.Equal(t, 2, report.AffectedPeers[clientPeer.ID].LostPeers)
+	assert.Equal(t, 0, report.AffectedPeers[clientPeer.ID].RemainingPeers)
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		DefaultDenyEnabled:  true,
+	})
+	require.NoError(t, err)
+
+	networkMap, err = manager.GetNetworkMap(clientPeer.ID)
+	require.NoError(t, err)
+	assert.Empty(t, networkMap.Peers, "the Default policy shouldn't connect peers with the default deny")
+
+	group := &Group{ID: "client-server", Name: "client-server", Peers: []string{clientPeer.ID, serverPeer.ID}}
+	require.NoError(t, manager.SaveGroup(account.Id, userID, group))
+	require.NoError(t, manager.SavePolicy(account.Id, userID, &Policy{
+		ID:      "client-server",
+		Name:    "client-server",
+		Enabled: true,
+		Rules: []*PolicyRule{{
+			ID:            "client-server",
+			Name:          "client-server",
+			Enabled:       true,
+			Action:        PolicyTrafficActionAccept,
+			Sources:       []string{group.ID},
+			Destinations:  []string{group.ID},
+			Bidirectional: true,
+		}},
+	}))
+
+	networkMap, err = manager.GetNetworkMap(clientPeer.ID)
+	require.NoError(t, err)
+	require.Len(t, networkMap.Peers, 1, "an explicit policy should connect the peers")
+	assert.Equal(t, serverPeer.ID, networkMap.Peers[0].ID)
+
+	networkMap, err = manager.GetNetworkMap(otherPeer.ID)
+	require.NoError(t, err)
+	assert.Empty(t, networkMap.Peers, "peers without an explicit policy shouldn't connect")
+
+	allGroup, err := account.GetGroupAll()
+	require.NoError(t, err)
+	err = manager.SavePolicy(account.Id, userID, &Policy{
+		ID:      "everyone",
+		Name:    "everyone",
+		Enabled: true,
+		Rules: []*PolicyRule{{
+			ID:           "everyone",
+			Name:         "everyone",
+			Enabled:      true,
+			Action:       PolicyTrafficActionAccept,
+			Sources:      []string{allGroup.ID},
+			Destinations: []string{allGroup.ID},
+		}},
+	})
+	sErr, ok := status.FromError(err)
+	require.True(t, ok, "a policy allowing all peers should be rejected with the default deny")
+	assert.Equal(t, status.InvalidArgument, sErr.Type())
+
+	report, err = manager.GetDefaultDenyReport(account.Id, userID)
+	require.NoError(t, err)
+	assert.True(t, report.Enabled)
+	assert.Equal(t, 1, report.AffectedPeers[clientPeer.ID].RemainingPeers)
+}
@@ -2,10 +2,13 @@ package server
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/miekg/dns"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 
 	nbdns "github.com/netbirdio/netbird/dns"
 	"github.com/netbirdio/netbird/management/proto"
@@ -22,6 +25,10 @@ type lookupMap map[string]struct{}
 type DNSSettings struct {
 	// DisabledManagementGroups groups whose DNS management is disabled
 	DisabledManagementGroups []string `gorm:"serializer:json"`
+	// PrimaryDNSDisabledOS lists the operating systems as reported by the client, e.g. darwin, whose peers don't route
+	// all the DNS queries of the host to the NetBird resolver when a primary nameserver group applies to them.
+	// The peers still resolve the peer records and the domains of the match domain nameserver groups.
+	PrimaryDNSDisabledOS []string `gorm:"serializer:json"`
 }
 
 // Copy returns a copy of the DNS settings
@@ -30,9 +37,32 @@ func (d DNSSettings) Copy() DNSSettings {
 		DisabledManagementGroups: make([]string, len(d.DisabledManagementGroups)),
 	}
 	copy(settings.DisabledManagementGroups, d.DisabledManagementGroups)
+	if d.PrimaryDNSDisabledOS != nil {
+		settings.PrimaryDNSDisabledOS = append([]string{}, d.PrimaryDNSDisabledOS...)
+	}
 	return settings
 }
 
+// primaryDNSDisabled tells whether the peer of the system meta must not route all the DNS queries of the host
+func (d DNSSettings) primaryDNSDisabled(meta nbpeer.PeerSystemMeta) bool {
+	return containsFold(d.PrimaryDNSDisabledOS, meta.GoOS)
+}
+
+// validatePrimaryDNSDisabledOS normalizes the operating systems of the PrimaryDNSDisabledOS setting
+func validatePrimaryDNSDisabledOS(systems []string) ([]string, error) {
+	var normalized []string
+	for _, os := range systems {
+		os = strings.ToLower(strings.TrimSpace(os))
+		if os == "" {
+			return nil, status.Errorf(status.InvalidArgument, "operating system of the primary DNS disabled setting can't be empty")
+		}
+		if !slices.Contains(normalized, os) {
+			normalized = append(normalized, os)
+		}
+	}
+	return normalized, nil
+}
+
 // GetDNSSettings validates a user role and returns the DNS settings for the provided account ID
 func (am *DefaultAccountManager) GetDNSSettings(accountID string, userID string) (*DNSSettings, error) {
 	unlock := am.Store.AcquireAccountLock(accountID)
@@ -48,8 +78,8 @@ func (am *DefaultAccountManager) GetDNSSettings(accountID string, userID string)
 		return nil, err
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
-		return nil, status.Errorf(status.PermissionDenied, "only users with admin power are allowed to view DNS settings")
+	if !user.HasPermission(ResourceDNS, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view DNS settings")
 	}
 	dnsSettings := account.DNSSettings.Copy()
 	return &dnsSettings, nil
@@ -70,8 +100,8 @@ func (am *DefaultAccountManager) SaveDNSSettings(accountID string, userID string
 		return err
 	}
 
-	if !user.HasAdminPower() {
-		return status.Errorf(status.PermissionDenied, "only users with admin power are allowed to update DNS settings")
+	if !user.HasPermission(ResourceDNS, OperationWrite) {
+		return status.Errorf(status.PermissionDenied, "user is not allowed to update DNS settings")
 	}
 
 	if dnsSettingsToSave == nil {
@@ -85,8 +115,14 @@ func (am *DefaultAccountManager) SaveDNSSettings(accountID string, userID string
 		}
 	}
 
+	primaryDNSDisabledOS, err := validatePrimaryDNSDisabledOS(dnsSettingsToSave.PrimaryDNSDisabledOS)
+	if err != nil {
+		return err
+	}
+
 	oldSettings := account.DNSSettings.Copy()
 	account.DNSSettings = dnsSettingsToSave.Copy()
+	account.DNSSettings.PrimaryDNSDisabledOS = primaryDNSDisabledOS
 
 	account.Network.IncSerial()
 	if err = am.Store.SaveAccount(account); err != nil {
@@ -107,13 +143,18 @@ func (am *DefaultAccountManager) SaveDNSSettings(accountID string, userID string
 		am.StoreEvent(userID, accountID, accountID, activity.GroupRemovedFromDisabledManagementGroups, meta)
 	}
 
+	if !slices.Equal(oldSettings.PrimaryDNSDisabledOS, primaryDNSDisabledOS) {
+		meta := map[string]any{"os": primaryDNSDisabledOS}
+		am.StoreEvent(userID, accountID, accountID, activity.PrimaryDNSDisabledOSUpdated, meta)
+	}
+
 	am.updateAccountPeers(account)
 
 	return nil
 }
 
 func toProtocolDNSConfig(update nbdns.Config) *proto.DNSConfig {
-	protoUpdate := &proto.DNSConfig{ServiceEnable: update.ServiceEnable}
+	protoUpdate := &proto.DNSConfig{ServiceEnable: update.ServiceEnable, RouteAllDisabled: update.RouteAllDisabled}
 
 	for _, zone := range update.CustomZones {
 		protoZone := &proto.CustomZone{Domain: zone.Domain}
@@ -149,7 +190,8 @@ func toProtocolDNSConfig(update nbdns.Config) *proto.DNSConfig {
 	return protoUpdate
 }
 
-func getPeersCustomZone(account *Account, dnsDomain string) nbdns.CustomZone {
+// getPeersCustomZone returns the peers zone with the peer records and the custom records of the DNS views of the peer
+func getPeersCustomZone(account *Account, peerID, dnsDomain string) nbdns.CustomZone {
 	if dnsDomain == "" {
 		log.Errorf("no dns domain is set, returning empty zone")
 		return nbdns.CustomZone{}
@@ -165,6 +207,10 @@ func getPeersCustomZone(account *Account, dnsDomain string) nbdns.CustomZone {
 			continue
 		}
 
+		if peer.IsQuarantined() {
+			continue
+		}
+
 		customZone.Records = append(customZone.Records, nbdns.SimpleRecord{
 			Name:  dns.Fqdn(peer.DNSLabel + "." + dnsDomain),
 			Type:  int(dns.TypeA),
@@ -172,11 +218,60 @@ func getPeersCustomZone(account *Account, dnsDomain string) nbdns.CustomZone {
 			TTL:   defaultTTL,
 			RData: peer.IP.String(),
 		})
+
+		if peer.IPv6 != nil {
+			customZone.Records = append(customZone.Records, nbdns.SimpleRecord{
+				Name:  dns.Fqdn(peer.DNSLabel + "." + dnsDomain),
+				Type:  int(dns.TypeAAAA),
+				Class: nbdns.DefaultClass,
+				TTL:   defaultTTL,
+				RData: peer.IPv6.String(),
+			})
+		}
+	}
+
+	for _, record := range getPeerDNSRecords(account, peerID) {
+		customZone.Records = append(customZone.Records, record.toSimpleRecord(dnsDomain))
 	}
 
 	return customZone
 }
 
+// getPeerDNSRecords returns the custom records of the DNS views of the peer groups. The records of different views
+// can conflict once a peer is added to the groups of both, the record created first is kept then.
+func getPeerDNSRecords(account *Account, peerID string) []*DNSRecord {
+	peerGroups := account.getPeerGroups(peerID)
+
+	var served []*DNSRecord
+	for _, record := range account.DNSRecords {
+		if record.servedTo(peerGroups) {
+			served = append(served, record)
+		}
+	}
+	// the IDs are xids, sorting by them sorts by creation time
+	sort.Slice(served, func(i, j int) bool {
+		return served[i].ID < served[j].ID
+	})
+
+	var records []*DNSRecord
+	for _, record := range served {
+		conflict := false
+		for _, kept := range records {
+			if kept.Name == record.Name &&
+				(kept.Type == record.Type || uint16(kept.Type) == dns.TypeCNAME || uint16(record.Type) == dns.TypeCNAME) {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			log.Debugf("skipping DNS record %s of peer %s conflicting with a record of another DNS view", record.ID, peerID)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
 func getPeerNSGroups(account *Account, peerID string) []*nbdns.NameServerGroup {
 	groupList := account.getPeerGroups(peerID)
 
@@ -210,17 +305,44 @@ func peerIsNameserver(peer *nbpeer.Peer, nsGroup *nbdns.NameServerGroup) bool {
 	return false
 }
 
-func addPeerLabelsToAccount(account *Account, peerLabels lookupMap) {
-	for _, peer := range account.Peers {
+// deduplicatePeerDNSLabels gives a unique DNS label to the peers without a label and to the peers sharing a label with
+// another peer, e.g. the peers stored before the labels were enforced unique. The peer added first keeps the label,
+// so the FQDNs of the peers are stable across the loads of the account.
+func deduplicatePeerDNSLabels(account *Account) {
+	peerIDs := make([]string, 0, len(account.Peers))
+	for id := range account.Peers {
+		peerIDs = append(peerIDs, id)
+	}
+	// the xid peer IDs are sorted by the creation time
+	sort.Strings(peerIDs)
+
+	peerLabels := make(lookupMap, len(peerIDs))
+	var unlabeled []string
+	for _, id := range peerIDs {
+		peer := account.Peers[id]
+		if _, taken := peerLabels[peer.DNSLabel]; peer.DNSLabel == "" || taken {
+			unlabeled = append(unlabeled, id)
+			continue
+		}
+		peerLabels[peer.DNSLabel] = struct{}{}
+	}
+
+	for _, id := range unlabeled {
+		peer := account.Peers[id]
 		label, err := getPeerHostLabel(peer.Name, peerLabels)
 		if err != nil {
 			log.Errorf("got an error while generating a peer host label. Peer name %s, error: %v. Trying with the peer's meta hostname", peer.Name, err)
 			label, err = getPeerHostLabel(peer.Meta.Hostname, peerLabels)
 			if err != nil {
 				log.Errorf("got another error while generating a peer host label with hostname. Peer hostname %s, error: %v. Skipping", peer.Meta.Hostname, err)
+				// a label shared with another peer would resolve to both of them, the peer is left out of the zone instead
+				peer.DNSLabel = ""
 				continue
 			}
 		}
+		if peer.DNSLabel != "" {
+			log.Infof("peer %s shared the DNS label %s with another peer, relabeled it to %s", peer.ID, peer.DNSLabel, label)
+		}
 		peer.DNSLabel = label
 		peerLabels[label] = struct{}{}
 	}
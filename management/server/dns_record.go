@@ -0,0 +1,413 @@
+package server
+
+import (
+	"math"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/rs/xid"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// maxTXTRecordLength is the maximum length of a single TXT character-string
+	maxTXTRecordLength = 255
+	// maxDomainNameLength is the maximum length of a domain name in the presentation format without the trailing dot
+	maxDomainNameLength = 253
+)
+
+var recordLabelMatcher = regexp.MustCompile(`^[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9])?$`)
+
+// supportedDNSRecordTypes are the record types that can be defined in the account's peers zone
+var supportedDNSRecordTypes = map[uint16]struct{}{
+	dns.TypeA:     {},
+	dns.TypeAAAA:  {},
+	dns.TypeCNAME: {},
+	dns.TypeTXT:   {},
+}
+
+// DNSRecord is a custom DNS record of the account served in the peers zone along with the generated peer records
+type DNSRecord struct {
+	// ID of the record
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `json:"-" gorm:"index"`
+	// Name of the record relative to the peers zone, e.g. "service" for service.netbird.cloud
+	Name string
+	// Type of the record, e.g. 1 for A. see https://pkg.go.dev/github.com/miekg/dns#pkg-constants
+	Type int
+	// TTL time-to-live of the record in seconds
+	TTL int
+	// RData is the record value
+	RData string
+	// Groups makes the record part of the DNS view of these groups, it is served to their peers only. Records of the
+	// same name and type can answer differently to different groups. Empty serves the record to all the peers.
+	Groups []string `gorm:"serializer:json"`
+}
+
+// Copy returns a copy of the DNS record
+func (r *DNSRecord) Copy() *DNSRecord {
+	record := &DNSRecord{
+		ID:        r.ID,
+		AccountID: r.AccountID,
+		Name:      r.Name,
+		Type:      r.Type,
+		TTL:       r.TTL,
+		RData:     r.RData,
+	}
+	if r.Groups != nil {
+		record.Groups = make([]string, len(r.Groups))
+		copy(record.Groups, r.Groups)
+	}
+	return record
+}
+
+// EventMeta returns activity event meta related to the DNS record
+func (r *DNSRecord) EventMeta() map[string]any {
+	return map[string]any{"name": r.Name, "type": dns.Type(r.Type).String(), "rdata": r.RData}
+}
+
+// servedTo returns true if the record is in the DNS view of one of the peer groups
+func (r *DNSRecord) servedTo(peerGroups lookupMap) bool {
+	if len(r.Groups) == 0 {
+		return true
+	}
+	for _, groupID := range r.Groups {
+		if _, ok := peerGroups[groupID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sharesViewWith returns true if a peer can be served both records
+func (r *DNSRecord) sharesViewWith(other *DNSRecord) bool {
+	if len(r.Groups) == 0 || len(other.Groups) == 0 {
+		return true
+	}
+	for _, groupID := range r.Groups {
+		for _, otherGroupID := range other.Groups {
+			if groupID == otherGroupID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toSimpleRecord returns the record of the peers zone of the given domain in the format expected by the clients
+func (r *DNSRecord) toSimpleRecord(dnsDomain string) nbdns.SimpleRecord {
+	rData := r.RData
+	switch uint16(r.Type) {
+	case dns.TypeCNAME:
+		rData = dns.Fqdn(rData)
+	case dns.TypeTXT:
+		rData = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(rData) + `"`
+	}
+
+	return nbdns.SimpleRecord{
+		Name:  dns.Fqdn(r.Name + "." + dnsDomain),
+		Type:  r.Type,
+		Class: nbdns.DefaultClass,
+		TTL:   r.TTL,
+		RData: rData,
+	}
+}
+
+// GetDNSRecord gets a custom DNS record object from account and record IDs
+func (am *DefaultAccountManager) GetDNSRecord(accountID, userID, recordID string) (*DNSRecord, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceDNS, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view DNS records")
+	}
+
+	record, found := account.DNSRecords[recordID]
+	if !found {
+		return nil, status.Errorf(status.NotFound, "DNS record with ID %s not found", recordID)
+	}
+
+	return record.Copy(), nil
+}
+
+// ListDNSRecords returns a list of custom DNS records from account
+func (am *DefaultAccountManager) ListDNSRecords(accountID, userID string) ([]*DNSRecord, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceDNS, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view DNS records")
+	}
+
+	records := make([]*DNSRecord, 0, len(account.DNSRecords))
+	for _, record := range account.DNSRecords {
+		records = append(records, record.Copy())
+	}
+
+	return records, nil
+}
+
+// CreateDNSRecord creates and saves a new custom DNS record in the account's peers zone
+func (am *DefaultAccountManager) CreateDNSRecord(accountID, userID string, recordToCreate *DNSRecord) (*DNSRecord, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	if recordToCreate == nil {
+		return nil, status.Errorf(status.InvalidArgument, "DNS record provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkPermission(account, userID, ResourceDNS, OperationWrite); err != nil {
+		return nil, err
+	}
+
+	newRecord := recordToCreate.Copy()
+	newRecord.ID = xid.New().String()
+	newRecord.AccountID = accountID
+
+	err = validateDNSRecord(false, newRecord, account, am.GetDNSDomain())
+	if err != nil {
+		return nil, err
+	}
+
+	if account.DNSRecords == nil {
+		account.DNSRecords = make(map[string]*DNSRecord)
+	}
+	account.DNSRecords[newRecord.ID] = newRecord
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, newRecord.ID, accountID, activity.DNSRecordCreated, newRecord.EventMeta())
+
+	return newRecord.Copy(), nil
+}
+
+// SaveDNSRecord saves a custom DNS record
+func (am *DefaultAccountManager) SaveDNSRecord(accountID, userID string, recordToSave *DNSRecord) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	if recordToSave == nil {
+		return status.Errorf(status.InvalidArgument, "DNS record provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkPermission(account, userID, ResourceDNS, OperationWrite); err != nil {
+		return err
+	}
+
+	record := recordToSave.Copy()
+	record.AccountID = accountID
+
+	err = validateDNSRecord(true, record, account, am.GetDNSDomain())
+	if err != nil {
+		return err
+	}
+
+	account.DNSRecords[record.ID] = record
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, record.ID, accountID, activity.DNSRecordUpdated, record.EventMeta())
+
+	return nil
+}
+
+// DeleteDNSRecord deletes a custom DNS record with recordID
+func (am *DefaultAccountManager) DeleteDNSRecord(accountID, recordID, userID string) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkPermission(account, userID, ResourceDNS, OperationWrite); err != nil {
+		return err
+	}
+
+	record := account.DNSRecords[recordID]
+	if record == nil {
+		return status.Errorf(status.NotFound, "DNS record %s wasn't found", recordID)
+	}
+	delete(account.DNSRecords, recordID)
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, record.ID, accountID, activity.DNSRecordDeleted, record.EventMeta())
+
+	return nil
+}
+
+// validateDNSRecord validates the record and normalizes its name. The record has to be accepted by the clients
+// when building their local zone and must not shadow any of the generated peer records.
+func validateDNSRecord(existingRecord bool, record *DNSRecord, account *Account, dnsDomain string) error {
+	if existingRecord {
+		if _, found := account.DNSRecords[record.ID]; !found {
+			return status.Errorf(status.NotFound, "DNS record with ID %s was not found", record.ID)
+		}
+	}
+
+	if dnsDomain == "" {
+		return status.Errorf(status.PreconditionFailed, "DNS records can't be managed when no DNS domain is configured")
+	}
+
+	record.Name = strings.ToLower(record.Name)
+	err := validateDNSRecordName(record.Name, dnsDomain)
+	if err != nil {
+		return err
+	}
+
+	if _, supported := supportedDNSRecordTypes[uint16(record.Type)]; !supported {
+		return status.Errorf(status.InvalidArgument, "DNS record type %s is not supported", dns.Type(record.Type).String())
+	}
+
+	if record.TTL < 1 || record.TTL > math.MaxInt32 {
+		return status.Errorf(status.InvalidArgument, "DNS record TTL should be between 1 and %d, got %d", math.MaxInt32, record.TTL)
+	}
+
+	err = validateDNSRecordData(record)
+	if err != nil {
+		return err
+	}
+
+	if len(record.Groups) != 0 {
+		err = validateGroups(record.Groups, account.Groups)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, peer := range account.Peers {
+		if peer.DNSLabel == record.Name {
+			return status.Errorf(status.InvalidArgument, "DNS record name %s is already used by peer %s", record.Name, peer.Name)
+		}
+	}
+
+	// the records of different views don't conflict as no peer is served both
+	for _, other := range account.DNSRecords {
+		if other.ID == record.ID || other.Name != record.Name || !other.sharesViewWith(record) {
+			continue
+		}
+		if other.Type == record.Type {
+			return status.Errorf(status.AlreadyExists, "a %s DNS record with name %s already exists for some of the groups",
+				dns.Type(record.Type).String(), record.Name)
+		}
+		if uint16(other.Type) == dns.TypeCNAME || uint16(record.Type) == dns.TypeCNAME {
+			return status.Errorf(status.InvalidArgument, "a CNAME DNS record can't share its name %s with other records", record.Name)
+		}
+	}
+
+	// make sure the clients will be able to parse the record
+	if _, err = dns.NewRR(record.toSimpleRecord(dnsDomain).String()); err != nil {
+		return status.Errorf(status.InvalidArgument, "invalid DNS record: %s", err)
+	}
+
+	return nil
+}
+
+func validateDNSRecordName(name, dnsDomain string) error {
+	if name == "" {
+		return status.Errorf(status.InvalidArgument, "DNS record name should not be empty")
+	}
+
+	if len(name)+len(dns.Fqdn(dnsDomain)) > maxDomainNameLength {
+		return status.Errorf(status.InvalidArgument, "DNS record name %s is too long", name)
+	}
+
+	for i, label := range strings.Split(name, ".") {
+		// a leading "*" label makes a wildcard record, e.g. *.svc
+		if i == 0 && label == "*" {
+			continue
+		}
+		if !recordLabelMatcher.MatchString(label) {
+			return status.Errorf(status.InvalidArgument, "DNS record name %s should consist of labels of letters, numbers, "+
+				"underscores and hyphens with no leading or trailing hyphens, and an optional leading * label", name)
+		}
+	}
+
+	return nil
+}
+
+func validateDNSRecordData(record *DNSRecord) error {
+	switch uint16(record.Type) {
+	case dns.TypeA:
+		ip := net.ParseIP(record.RData)
+		if ip == nil || ip.To4() == nil {
+			return status.Errorf(status.InvalidArgument, "A DNS record value should be an IPv4 address, got %s", record.RData)
+		}
+	case dns.TypeAAAA:
+		ip := net.ParseIP(record.RData)
+		if ip == nil || ip.To4() != nil {
+			return status.Errorf(status.InvalidArgument, "AAAA DNS record value should be an IPv6 address, got %s", record.RData)
+		}
+	case dns.TypeCNAME:
+		if _, ok := dns.IsDomainName(record.RData); !ok || record.RData == "" || record.RData == "." {
+			return status.Errorf(status.InvalidArgument, "CNAME DNS record value should be a domain name, got %s", record.RData)
+		}
+	case dns.TypeTXT:
+		if record.RData == "" || len(record.RData) > maxTXTRecordLength {
+			return status.Errorf(status.InvalidArgument, "TXT DNS record value should be between 1 and %d characters", maxTXTRecordLength)
+		}
+		for _, c := range record.RData {
+			if c < ' ' || c > '~' {
+				return status.Errorf(status.InvalidArgument, "TXT DNS record value should contain only printable ASCII characters")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestCreateDNSRecord(t *testing.T) {
+	testCases := []struct {
+		name         string
+		userID       string
+		record       *DNSRecord
+		errorType    status.Type
+		expectedName string
+	}{
+		{
+			name:         "Create A Record",
+			userID:       dnsAdminUserID,
+			record:       &DNSRecord{Name: "Service", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"},
+			expectedName: "service",
+		},
+		{
+			name:         "Create AAAA Record",
+			userID:       dnsAdminUserID,
+			record:       &DNSRecord{Name: "service6", Type: int(dns.TypeAAAA), TTL: 300, RData: "2001:db8::1"},
+			expectedName: "service6",
+		},
+		{
+			name:         "Create CNAME Record",
+			userID:       dnsAdminUserID,
+			record:       &DNSRecord{Name: "www.service", Type: int(dns.TypeCNAME), TTL: 300, RData: "example.com"},
+			expectedName: "www.service",
+		},
+		{
+			name:         "Create TXT Record",
+			userID:       dnsAdminUserID,
+			record:       &DNSRecord{Name: "_acme-challenge", Type: int(dns.TypeTXT), TTL: 60, RData: `v=spf1 "quoted"; -all`},
+			expectedName: "_acme-challenge",
+		},
+		{
+			name:         "Create Wildcard Record",
+			userID:       dnsAdminUserID,
+			record:       &DNSRecord{Name: "*.Svc", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"},
+			expectedName: "*.svc",
+		},
+		{
+			name:      "Wildcard Label Not Leading Should Fail",
+			userID:    dnsAdminUserID,
+			record:    &DNSRecord{Name: "svc.*", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"},
+			errorType: status.InvalidArgument,
+		},
+		{
+			name:      "Regular User Should Fail",
+			userID:    dnsRegularUserID,
+			record:    &DNSRecord{Name: "service", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"},
+			errorType: status.PermissionDenied,
+		},
+		{
+			name:      "Invalid Name Should Fail",
+			userID:    dnsAdminUserID,
+			record:    &DNSRecord{Name: "-service", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"},
+			errorType: status.InvalidArgument,
+		},
+		{
+			name:      "Unsupported Type Should Fail",
+			userID:    dnsAdminUserID,
+			record:    &DNSRecord{Name: "service", Type: int(dns.TypeMX), TTL: 300, RData: "10 mail.example.com."},
+			errorType: status.InvalidArgument,
+		},
+		{
+			name:      "Invalid TTL Should Fail",
+			userID:    dnsAdminUserID,
+			record:    &DNSRecord{Name: "service", Type: int(dns.TypeA), TTL: 0, RData: "100.64.0.10"},
+			errorType: status.InvalidArgument,
+		},
+		{
+			name:      "IPv6 In A Record Should Fail",
+			userID:    dnsAdminUserID,
+			record:    &DNSRecord{Name: "service", Type: int(dns.TypeA), TTL: 300, RData: "2001:db8::1"},
+			errorType: status.InvalidArgument,
+		},
+		{
+			name:      "Invalid CNAME Target Should Fail",
+			userID:    dnsAdminUserID,
+			record:    &DNSRecord{Name: "service", Type: int(dns.TypeCNAME), TTL: 300, RData: "not a domain"},
+			errorType: status.InvalidArgument,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			am, err := createDNSManager(t)
+			require.NoError(t, err, "failed to create account manager")
+
+			account, err := initTestDNSAccount(t, am)
+			require.NoError(t, err, "failed to init testing account")
+
+			serial := account.Network.CurrentSerial()
+
+			record, err := am.CreateDNSRecord(account.Id, testCase.userID, testCase.record)
+			if testCase.errorType != 0 {
+				require.Error(t, err)
+				s, ok := status.FromError(err)
+				require.True(t, ok, "error should be a status error")
+				assert.Equal(t, testCase.errorType, s.Type())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedName, record.Name)
+
+			updatedAccount, err := am.Store.GetAccount(account.Id)
+			require.NoError(t, err)
+			require.Contains(t, updatedAccount.DNSRecords, record.ID)
+			assert.Greater(t, updatedAccount.Network.CurrentSerial(), serial, "network serial should be incremented")
+		})
+	}
+}
+
+func TestCreateDNSRecord_Conflicts(t *testing.T) {
+	am, err := createDNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestDNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	peer, err := account.FindPeerByPubKey(dnsPeer1Key)
+	require.NoError(t, err)
+
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: peer.DNSLabel, Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"})
+	require.Error(t, err, "record with a peer name should be rejected")
+
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "service", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"})
+	require.NoError(t, err)
+
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "service", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.11"})
+	require.Error(t, err, "duplicated record name and type should be rejected")
+
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "service", Type: int(dns.TypeCNAME), TTL: 300, RData: "example.com"})
+	require.Error(t, err, "CNAME record should not share its name with other records")
+
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "service", Type: int(dns.TypeTXT), TTL: 300, RData: "description"})
+	require.NoError(t, err, "records of different types should be allowed to share a name")
+
+	// new peers should get a label that doesn't clash with the record
+	_, _, err = am.AddPeer("", dnsAdminUserID, &nbpeer.Peer{
+		Key:  "5rvhvriKJZ3S9oxYToVj5TzDM9u9y8cxg7htIMWlYAg=",
+		Meta: nbpeer.PeerSystemMeta{Hostname: "service"},
+	})
+	require.NoError(t, err)
+
+	account, err = am.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	newPeer, err := account.FindPeerByPubKey("5rvhvriKJZ3S9oxYToVj5TzDM9u9y8cxg7htIMWlYAg=")
+	require.NoError(t, err)
+	assert.Equal(t, "service-1", newPeer.DNSLabel)
+}
+
+func TestSaveDNSRecord(t *testing.T) {
+	am, err := createDNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestDNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	record, err := am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "service", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"})
+	require.NoError(t, err)
+
+	record.RData = "100.64.0.20"
+	err = am.SaveDNSRecord(account.Id, dnsAdminUserID, record)
+	require.NoError(t, err)
+
+	saved, err := am.GetDNSRecord(account.Id, dnsAdminUserID, record.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "100.64.0.20", saved.RData)
+
+	// updating a record must not conflict with itself
+	saved.TTL = 60
+	err = am.SaveDNSRecord(account.Id, dnsAdminUserID, saved)
+	require.NoError(t, err)
+
+	err = am.SaveDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{ID: "missing", Name: "other", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"})
+	require.Error(t, err, "updating a non-existing record should fail")
+}
+
+func TestDeleteDNSRecord(t *testing.T) {
+	am, err := createDNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestDNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	record, err := am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "service", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10"})
+	require.NoError(t, err)
+
+	err = am.DeleteDNSRecord(account.Id, record.ID, dnsAdminUserID)
+	require.NoError(t, err)
+
+	records, err := am.ListDNSRecords(account.Id, dnsAdminUserID)
+	require.NoError(t, err)
+	assert.Len(t, records, 0)
+
+	err = am.DeleteDNSRecord(account.Id, record.ID, dnsAdminUserID)
+	require.Error(t, err, "deleting a non-existing record should fail")
+}
+
+func TestGetNetworkMap_DNSRecords(t *testing.T) {
+	am, err := createDNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestDNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "service", Type: int(dns.TypeCNAME), TTL: 300, RData: "example.com"})
+	require.NoError(t, err)
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "info", Type: int(dns.TypeTXT), TTL: 300, RData: "hello world"})
+	require.NoError(t, err)
+
+	peer, err := account.FindPeerByPubKey(dnsPeer2Key)
+	require.NoError(t, err)
+
+	networkMap, err := am.GetNetworkMap(peer.ID)
+	require.NoError(t, err)
+	require.Len(t, networkMap.DNSConfig.CustomZones, 1)
+
+	records := map[string]string{}
+	for _, record := range networkMap.DNSConfig.CustomZones[0].Records {
+		// clients build the records with dns.NewRR, make sure they are parsable
+		_, err = dns.NewRR(record.String())
+		require.NoError(t, err, "record %s should be parsable", record.String())
+		records[record.Name] = record.RData
+	}
+
+	assert.Equal(t, "example.com.", records["service.netbird.test."])
+	assert.Equal(t, `"hello world"`, records["info.netbird.test."])
+}
+
+func TestGetNetworkMap_DNSViews(t *testing.T) {
+	am, err := createDNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestDNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	peer1, err := account.FindPeerByPubKey(dnsPeer1Key)
+	require.NoError(t, err)
+	peer2, err := account.FindPeerByPubKey(dnsPeer2Key)
+	require.NoError(t, err)
+
+	// peer1 is in group1, peer2 in group2
+	err = am.GroupAddPeer(account.Id, dnsGroup2ID, peer2.ID)
+	require.NoError(t, err)
+
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "app", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.10", Groups: []string{dnsGroup1ID}})
+	require.NoError(t, err)
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "app", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.20", Groups: []string{dnsGroup2ID}})
+	require.NoError(t, err, "records of views of different groups should be allowed to share a name and type")
+
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "app", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.30"})
+	require.Error(t, err, "a record served to all the peers should conflict with the records of the views")
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "app", Type: int(dns.TypeCNAME), TTL: 300, RData: "example.com", Groups: []string{dnsGroup1ID}})
+	require.Error(t, err, "a CNAME record should conflict with the records of the same view")
+	_, err = am.CreateDNSRecord(account.Id, dnsAdminUserID, &DNSRecord{Name: "other", Type: int(dns.TypeA), TTL: 300, RData: "100.64.0.30", Groups: []string{"missing"}})
+	require.Error(t, err, "a record of a view of a missing group should be rejected")
+
+	appRecord := func(peerID string) []string {
+		t.Helper()
+		networkMap, err := am.GetNetworkMap(peerID)
+		require.NoError(t, err)
+		require.Len(t, networkMap.DNSConfig.CustomZones, 1)
+
+		var rData []string
+		for _, record := range networkMap.DNSConfig.CustomZones[0].Records {
+			if record.Name == "app.netbird.test." {
+				rData = append(rData, record.RData)
+			}
+		}
+		return rData
+	}
+
+	assert.Equal(t, []string{"100.64.0.10"}, appRecord(peer1.ID))
+	assert.Equal(t, []string{"100.64.0.20"}, appRecord(peer2.ID))
+
+	// a peer of both views is served the record created first
+	err = am.GroupAddPeer(account.Id, dnsGroup2ID, peer1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100.64.0.10"}, appRecord(peer1.ID))
+
+	err = am.DeleteGroup(account.Id, dnsAdminUserID, dnsGroup2ID)
+	require.Error(t, err, "a group of a DNS view should not be deleted")
+}
@@ -4,6 +4,7 @@ import (
 	"net/netip"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/netbirdio/netbird/dns"
@@ -100,6 +101,14 @@ func TestSaveDNSSettings(t *testing.T) {
 			inputSettings: nil,
 			shouldFail:    true,
 		},
+		{
+			name:   "Should Not Update Settings If Primary DNS Disabled OS Is Empty",
+			userID: dnsAdminUserID,
+			inputSettings: &DNSSettings{
+				PrimaryDNSDisabledOS: []string{"darwin", " "},
+			},
+			shouldFail: true,
+		},
 		{
 			name:   "Should Not Update Settings If Group Is Invalid",
 			userID: dnsAdminUserID,
@@ -186,6 +195,39 @@ func TestGetNetworkMap_DNSConfigSync(t *testing.T) {
 	require.Len(t, peer2AccountDNSConfig.DNSConfig.NameServerGroups, 1, "updated DNS config should have 1 nameserver groups since peer 2 is part of the group All")
 }
 
+func TestGetNetworkMap_PrimaryDNSDisabledOS(t *testing.T) {
+	am, err := createDNSManager(t)
+	require.NoError(t, err, "failed to create account manager")
+
+	account, err := initTestDNSAccount(t, am)
+	require.NoError(t, err, "failed to init testing account")
+
+	darwinPeer, err := account.FindPeerByPubKey(dnsPeer1Key)
+	require.NoError(t, err)
+	linuxPeer, err := account.FindPeerByPubKey(dnsPeer2Key)
+	require.NoError(t, err)
+
+	account.Peers[darwinPeer.ID].Meta.GoOS = "darwin"
+	require.NoError(t, am.Store.SaveAccount(account))
+
+	err = am.SaveDNSSettings(account.Id, dnsAdminUserID, &DNSSettings{PrimaryDNSDisabledOS: []string{"Darwin", "darwin"}})
+	require.NoError(t, err)
+
+	updatedAccount, err := am.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"darwin"}, updatedAccount.DNSSettings.PrimaryDNSDisabledOS, "operating systems should be normalized")
+
+	darwinNetworkMap, err := am.GetNetworkMap(darwinPeer.ID)
+	require.NoError(t, err)
+	assert.True(t, darwinNetworkMap.DNSConfig.RouteAllDisabled, "darwin peer should not route all DNS queries")
+	assert.True(t, toProtocolDNSConfig(darwinNetworkMap.DNSConfig).RouteAllDisabled)
+
+	linuxNetworkMap, err := am.GetNetworkMap(linuxPeer.ID)
+	require.NoError(t, err)
+	assert.False(t, linuxNetworkMap.DNSConfig.RouteAllDisabled, "linux peer should route all DNS queries")
+	assert.Len(t, linuxNetworkMap.DNSConfig.NameServerGroups, 1)
+}
+
 func createDNSManager(t *testing.T) (*DefaultAccountManager, error) {
 	t.Helper()
 	store, err := createDNSStore(t)
@@ -317,3 +359,21 @@ func initTestDNSAccount(t *testing.T, am *DefaultAccountManager) (*Account, erro
 
 	return am.Store.GetAccount(account.Id)
 }
+
+func TestDeduplicatePeerDNSLabels(t *testing.T) {
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"peer1": {ID: "peer1", Name: "laptop", DNSLabel: "laptop"},
+			"peer2": {ID: "peer2", Name: "laptop", DNSLabel: "laptop"},
+			"peer3": {ID: "peer3", Name: "Laptop 1", DNSLabel: "laptop-1"},
+			"peer4": {ID: "peer4", Name: "server"},
+		},
+	}
+
+	deduplicatePeerDNSLabels(account)
+
+	assert.Equal(t, "laptop", account.Peers["peer1"].DNSLabel, "the peer added first should keep its label")
+	assert.Equal(t, "laptop-2", account.Peers["peer2"].DNSLabel)
+	assert.Equal(t, "laptop-1", account.Peers["peer3"].DNSLabel)
+	assert.Equal(t, "server", account.Peers["peer4"].DNSLabel, "the peer without a label should get one")
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"net/netip"
+	"strings"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// DynamicGroupRule derives the peers of a dynamic group. A peer is a member when it matches all the set criteria.
+// The membership is recomputed whenever the peers change, so policies, routes and DNS settings use it as any other group.
+type DynamicGroupRule struct {
+	// Subnets matches the peers with an IP address within any of the subnets in a CIDR format
+	Subnets []string
+	// Meta matches the peers whose system meta values equal the given ones case-insensitively, e.g. {"go_os": "windows"}.
+	// A value ending with * matches the values starting with it, e.g. {"hostname": "srv-*"}.
+	Meta map[string]string
+}
+
+// dynamicGroupMetaFields are the peer system meta fields a dynamic group rule can match
+var dynamicGroupMetaFields = map[string]func(meta nbpeer.PeerSystemMeta) string{
+	"hostname":   func(meta nbpeer.PeerSystemMeta) string { return meta.Hostname },
+	"go_os":      func(meta nbpeer.PeerSystemMeta) string { return meta.GoOS },
+	"os":         func(meta nbpeer.PeerSystemMeta) string { return meta.OS },
+	"kernel":     func(meta nbpeer.PeerSystemMeta) string { return meta.Kernel },
+	"core":       func(meta nbpeer.PeerSystemMeta) string { return meta.Core },
+	"platform":   func(meta nbpeer.PeerSystemMeta) string { return meta.Platform },
+	"wt_version": func(meta nbpeer.PeerSystemMeta) string { return meta.WtVersion },
+	"ui_version": func(meta nbpeer.PeerSystemMeta) string { return meta.UIVersion },
+}
+
+// Copy returns a deep copy of the rule
+func (r *DynamicGroupRule) Copy() *DynamicGroupRule {
+	if r == nil {
+		return nil
+	}
+
+	rule := &DynamicGroupRule{
+		Subnets: make([]string, len(r.Subnets)),
+		Meta:    make(map[string]string, len(r.Meta)),
+	}
+	copy(rule.Subnets, r.Subnets)
+	for key, value := range r.Meta {
+		rule.Meta[key] = value
+	}
+	return rule
+}
+
+// IsDynamic returns true if the group membership is derived from a rule instead of managed manually
+func (g *Group) IsDynamic() bool {
+	return g.DynamicRule != nil
+}
+
+// dynamicGroupMatcher is a DynamicGroupRule with the subnets parsed once for matching many peers
+type dynamicGroupMatcher struct {
+	subnets []netip.Prefix
+	meta    map[string]string
+}
+
+func newDynamicGroupMatcher(rule *DynamicGroupRule) (*dynamicGroupMatcher, error) {
+	if len(rule.Subnets) == 0 && len(rule.Meta) == 0 {
+		return nil, status.Errorf(status.InvalidArgument, "dynamic group rule should match at least a subnet or a peer meta field")
+	}
+
+	matcher := &dynamicGroupMatcher{meta: rule.Meta}
+	for _, subnet := range rule.Subnets {
+		prefix, err := netip.ParsePrefix(subnet)
+		if err != nil {
+			return nil, status.Errorf(status.InvalidArgument, "invalid dynamic group subnet %s", subnet)
+		}
+		matcher.subnets = append(matcher.subnets, prefix.Masked())
+	}
+
+	for key, value := range rule.Meta {
+		if _, ok := dynamicGroupMetaFields[key]; !ok {
+			return nil, status.Errorf(status.InvalidArgument, "unsupported dynamic group meta field %s", key)
+		}
+		if strings.Contains(strings.TrimSuffix(value, "*"), "*") {
+			return nil, status.Errorf(status.InvalidArgument, "dynamic group meta value %s can only end with a wildcard", value)
+		}
+	}
+
+	return matcher, nil
+}
+
+func (m *dynamicGroupMatcher) matches(peer *nbpeer.Peer) bool {
+	for key, value := range m.meta {
+		if !matchesMetaValue(dynamicGroupMetaFields[key](peer.Meta), value) {
+			return false
+		}
+	}
+
+	if len(m.subnets) == 0 {
+		return true
+	}
+
+	for _, ip := range []netip.Addr{peerAddr(peer.IP), peerAddr(peer.IPv6)} {
+		if !ip.IsValid() {
+			continue
+		}
+		for _, subnet := range m.subnets {
+			if subnet.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesMetaValue compares the meta value of a peer to the one of a rule case-insensitively, a rule value ending with
+// a wildcard matches it as a prefix
+func matchesMetaValue(value, ruleValue string) bool {
+	prefix, wildcard := strings.CutSuffix(ruleValue, "*")
+	if !wildcard {
+		return strings.EqualFold(value, ruleValue)
+	}
+	return len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix)
+}
+
+func peerAddr(ip []byte) netip.Addr {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// validateDynamicGroup checks that the dynamic group rule is valid and that the group may be dynamic
+func validateDynamicGroup(group *Group) error {
+	if !group.IsDynamic() {
+		return nil
+	}
+
+	if group.Name == "All" {
+		return status.Errorf(status.InvalidArgument, "group All can't be dynamic")
+	}
+	if group.Issued != GroupIssuedAPI {
+		return status.Errorf(status.InvalidArgument, "only groups issued by API can be dynamic")
+	}
+
+	_, err := newDynamicGroupMatcher(group.DynamicRule)
+	return err
+}
+
+// computeDynamicGroupPeers sets the peers of the dynamic group to the account peers matching its rule
+func (a *Account) computeDynamicGroupPeers(group *Group) error {
+	matcher, err := newDynamicGroupMatcher(group.DynamicRule)
+	if err != nil {
+		return err
+	}
+
+	group.Peers = make([]string, 0)
+	for _, peer := range a.Peers {
+		if matcher.matches(peer) {
+			group.Peers = append(group.Peers, peer.ID)
+		}
+	}
+	return nil
+}
+
+// refreshDynamicGroups updates the membership of the peer in the account dynamic groups after the peer changed.
+// It returns true if the peer moved in or out of any dynamic group, so the network maps have to be updated.
+func (a *Account) refreshDynamicGroups(peer *nbpeer.Peer) bool {
+	changed := false
+	for _, group := range a.Groups {
+		if !group.IsDynamic() {
+			continue
+		}
+
+		matcher, err := newDynamicGroupMatcher(group.DynamicRule)
+		if err != nil {
+			continue
+		}
+
+		index := -1
+		for i, peerID := range group.Peers {
+			if peerID == peer.ID {
+				index = i
+				break
+			}
+		}
+
+		switch matches := matcher.matches(peer); {
+		case matches && index < 0:
+			group.Peers = append(group.Peers, peer.ID)
+			changed = true
+		case !matches && index >= 0:
+			group.Peers = append(group.Peers[:index], group.Peers[index+1:]...)
+			changed = true
+		}
+	}
+	return changed
+}
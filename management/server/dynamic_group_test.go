@@ -0,0 +1,264 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDynamicGroupMatcher(t *testing.T) {
+	windowsPeer := &nbpeer.Peer{
+		IP:   []byte{100, 64, 0, 10},
+		Meta: nbpeer.PeerSystemMeta{GoOS: "windows", Hostname: "desktop"},
+	}
+	linuxPeer := &nbpeer.Peer{
+		IP:   []byte{100, 64, 1, 10},
+		Meta: nbpeer.PeerSystemMeta{GoOS: "linux", Hostname: "server"},
+	}
+
+	testCases := []struct {
+		name          string
+		rule          *DynamicGroupRule
+		expectErr     bool
+		expectWindows bool
+		expectLinux   bool
+	}{
+		{
+			name:          "Subnet",
+			rule:          &DynamicGroupRule{Subnets: []string{"100.64.0.0/24"}},
+			expectWindows: true,
+		},
+		{
+			name:          "Any Of Subnets",
+			rule:          &DynamicGroupRule{Subnets: []string{"100.64.0.0/24", "100.64.1.0/24"}},
+			expectWindows: true,
+			expectLinux:   true,
+		},
+		{
+			name:          "Meta Case Insensitive",
+			rule:          &DynamicGroupRule{Meta: map[string]string{"go_os": "Windows"}},
+			expectWindows: true,
+		},
+		{
+			name:        "All Criteria",
+			rule:        &DynamicGroupRule{Subnets: []string{"100.64.0.0/16"}, Meta: map[string]string{"hostname": "server"}},
+			expectLinux: true,
+		},
+		{
+			name:        "Meta Prefix",
+			rule:        &DynamicGroupRule{Meta: map[string]string{"hostname": "SERV*"}},
+			expectLinux: true,
+		},
+		{
+			name:          "Meta Wildcard Only",
+			rule:          &DynamicGroupRule{Meta: map[string]string{"hostname": "*"}},
+			expectWindows: true,
+			expectLinux:   true,
+		},
+		{
+			name:      "Meta Wildcard Not At The End",
+			rule:      &DynamicGroupRule{Meta: map[string]string{"hostname": "*server"}},
+			expectErr: true,
+		},
+		{
+			name:      "Empty Rule",
+			rule:      &DynamicGroupRule{},
+			expectErr: true,
+		},
+		{
+			name:      "Invalid Subnet",
+			rule:      &DynamicGroupRule{Subnets: []string{"100.64.0.0"}},
+			expectErr: true,
+		},
+		{
+			name:      "Unsupported Meta Field",
+			rule:      &DynamicGroupRule{Meta: map[string]string{"serial": "1"}},
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			matcher, err := newDynamicGroupMatcher(testCase.rule)
+			if testCase.expectErr {
+				require.Error(t, err)
+				s, ok := status.FromError(err)
+				require.True(t, ok)
+				assert.Equal(t, status.InvalidArgument, s.Type())
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, testCase.expectWindows, matcher.matches(windowsPeer))
+			assert.Equal(t, testCase.expectLinux, matcher.matches(linuxPeer))
+		})
+	}
+}
+
+func TestDefaultAccountManager_DynamicGroup(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	addPeer := func(goOS string) (*nbpeer.Peer, wgtypes.Key) {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: "peer-" + goOS, GoOS: goOS},
+		})
+		require.NoError(t, err)
+		return peer, key
+	}
+
+	windowsPeer, _ := addPeer("windows")
+	linuxPeer, linuxKey := addPeer("linux")
+
+	err = manager.SaveGroup(account.Id, userID, &Group{
+		ID:          "dynamic",
+		Name:        "Windows",
+		Issued:      GroupIssuedAPI,
+		Peers:       []string{linuxPeer.ID},
+		DynamicRule: &DynamicGroupRule{Meta: map[string]string{"go_os": "windows"}},
+	})
+	require.NoError(t, err)
+
+	group, err := manager.GetGroup(account.Id, "dynamic")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{windowsPeer.ID}, group.Peers, "membership should be derived from the rule")
+
+	err = manager.GroupAddPeer(account.Id, "dynamic", linuxPeer.ID)
+	require.Error(t, err, "peers can't be added manually to a dynamic group")
+
+	err = manager.SaveGroup(account.Id, userID, &Group{
+		ID:          "invalid",
+		Name:        "Invalid",
+		Issued:      GroupIssuedAPI,
+		DynamicRule: &DynamicGroupRule{Subnets: []string{"invalid"}},
+	})
+	require.Error(t, err, "invalid rule should be rejected")
+
+	newWindowsPeer, _ := addPeer("windows")
+	group, err = manager.GetGroup(account.Id, "dynamic")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{windowsPeer.ID, newWindowsPeer.ID}, group.Peers, "new peer should join the dynamic group")
+
+	updMsg := manager.peersUpdateManager.CreateChannel(windowsPeer.ID)
+	defer manager.peersUpdateManager.CloseChannel(windowsPeer.ID)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	serial := account.Network.CurrentSerial()
+
+	_, _, err = manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: linuxKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "peer-linux", GoOS: "windows"},
+	})
+	require.NoError(t, err)
+
+	group, err = manager.GetGroup(account.Id, "dynamic")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{windowsPeer.ID, newWindowsPeer.ID, linuxPeer.ID}, group.Peers,
+		"peer should join the dynamic group after its meta changed")
+
+	select {
+	case msg := <-updMsg:
+		assert.Greater(t, msg.Update.NetworkMap.Serial, serial, "network map should be updated")
+	case <-time.After(time.Second):
+		t.Fatal("peers should be updated after a peer moved into a dynamic group")
+	}
+
+	_, _, err = manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: linuxKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "peer-linux", GoOS: "linux"},
+	})
+	require.NoError(t, err)
+
+	group, err = manager.GetGroup(account.Id, "dynamic")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{windowsPeer.ID, newWindowsPeer.ID}, group.Peers,
+		"peer should leave the dynamic group after its meta changed")
+}
+
+func TestDefaultAccountManager_DynamicGroupPolicyOnRegistration(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+	require.NoError(t, manager.DeletePolicy(account.Id, account.Policies[0].ID, userID))
+
+	err = manager.SaveGroup(account.Id, userID, &Group{
+		ID:          "servers",
+		Name:        "Servers",
+		Issued:      GroupIssuedAPI,
+		DynamicRule: &DynamicGroupRule{Meta: map[string]string{"hostname": "srv-*"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, manager.SavePolicy(account.Id, userID, &Policy{
+		ID:      "servers",
+		Name:    "servers",
+		Enabled: true,
+		Rules: []*PolicyRule{{
+			ID:            "servers",
+			Name:          "servers",
+			Enabled:       true,
+			Action:        PolicyTrafficActionAccept,
+			Sources:       []string{"servers"},
+			Destinations:  []string{"servers"},
+			Bidirectional: true,
+		}},
+	}))
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	// allowedIPs registers a peer and returns the AllowedIPs of the remote peers of its initial sync
+	allowedIPs := func(hostname string) (*nbpeer.Peer, []string) {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, networkMap, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname, GoOS: "linux"},
+		})
+		require.NoError(t, err)
+
+		_, syncNetworkMap, err := manager.SyncPeer(PeerSync{WireGuardPubKey: key.PublicKey().String()})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, networkMap.Peers, syncNetworkMap.Peers, "the initial sync should match the registration")
+
+		var ips []string
+		for _, remotePeer := range toSyncResponse(nil, peer, nil, syncNetworkMap, "netbird.cloud").GetRemotePeers() {
+			ips = append(ips, remotePeer.GetAllowedIps()...)
+		}
+		return peer, ips
+	}
+
+	firstServer, ips := allowedIPs("srv-db")
+	assert.Empty(t, ips, "the first server shouldn't have any remote peer")
+
+	secondServer, ips := allowedIPs("SRV-web")
+	assert.Equal(t, []string{firstServer.IP.String() + "/32"}, ips,
+		"the new server should join the servers policy on its registration")
+
+	_, ips = allowedIPs("laptop")
+	assert.Empty(t, ips, "a peer not matching the dynamic group shouldn't get the servers policy")
+
+	network, err := manager.GetNetworkMap(firstServer.ID)
+	require.NoError(t, err)
+	require.Len(t, network.Peers, 1, "the existing servers should connect to the new server")
+	assert.Equal(t, secondServer.ID, network.Peers[0].ID)
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/netbirdio/management-integrations/additions"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// EffectiveACLMaxEdges bounds the number of edges of an effective ACL, so it stays reasonable for large accounts.
+// The ACL of a single peer can be requested when the ACL of the account is truncated.
+const EffectiveACLMaxEdges = 10000
+
+// EffectiveACL is the flattened access control list of the account, resulting from all the enabled and active
+// policy rules. It is evaluated the same way as the peers and the firewall rules of the network maps.
+type EffectiveACL struct {
+	// Peers are the ACLs of the account peers sorted by peer ID
+	Peers []*EffectivePeerACL
+	// Truncated indicates EffectiveACLMaxEdges was reached and the ACL misses some edges or peers
+	Truncated bool
+}
+
+// EffectivePeerACL is the ACL of a single peer
+type EffectivePeerACL struct {
+	PeerID   string
+	PeerName string
+	PeerIP   string
+	// Outbound are the peers the peer may reach
+	Outbound []*EffectiveACLEdge
+	// Inbound are the peers the peer may be reached from
+	Inbound []*EffectiveACLEdge
+}
+
+// EffectiveACLEdge is a connection between two peers granted or dropped by a policy rule
+type EffectiveACLEdge struct {
+	PeerID   string
+	PeerName string
+	PeerIP   string
+	Action   PolicyTrafficActionType
+	Protocol PolicyRuleProtocolType
+	// Ports of the traffic, empty for all ports
+	Ports      []string
+	PolicyID   string
+	PolicyName string
+	RuleID     string
+	RuleName   string
+}
+
+// GetEffectiveACL returns the effective ACL of the account, or of a single peer of the account when peerID isn't empty
+func (am *DefaultAccountManager) GetEffectiveACL(accountID, userID, peerID string) (*EffectiveACL, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourcePolicies, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view the effective ACL")
+	}
+
+	var peerIDs []string
+	if peerID != "" {
+		if account.Peers[peerID] == nil {
+			return nil, status.Errorf(status.NotFound, "peer %s not found", peerID)
+		}
+		peerIDs = []string{peerID}
+	} else {
+		peerIDs = make([]string, 0, len(account.Peers))
+		for id := range account.Peers {
+			peerIDs = append(peerIDs, id)
+		}
+		sort.Strings(peerIDs)
+	}
+
+	return account.getEffectiveACL(peerIDs, EffectiveACLMaxEdges), nil
+}
+
+// getEffectiveACL evaluates the ACLs of the given peers in order until maxEdges is reached.
+// The peers which don't get a network map, e.g. the ones waiting for an approval, have empty ACLs.
+func (a *Account) getEffectiveACL(peerIDs []string, maxEdges int) *EffectiveACL {
+	acl := &EffectiveACL{Peers: make([]*EffectivePeerACL, 0, len(peerIDs))}
+
+	edges := 0
+	for _, peerID := range peerIDs {
+		peer := a.Peers[peerID]
+		if peer == nil {
+			continue
+		}
+
+		peerACL := &EffectivePeerACL{
+			PeerID:   peer.ID,
+			PeerName: peer.Name,
+			PeerIP:   peer.IP.String(),
+			Outbound: make([]*EffectiveACLEdge, 0),
+			Inbound:  make([]*EffectiveACLEdge, 0),
+		}
+		acl.Peers = append(acl.Peers, peerACL)
+
+		if peer.IsQuarantined() || len(additions.ValidatePeers([]*nbpeer.Peer{peer})) == 0 {
+			continue
+		}
+
+		edgesExist := make(map[string]struct{})
+		a.forEachPeerRule(peerID, func(policy *Policy, rule *PolicyRule, peers []*nbpeer.Peer, direction int) {
+			for _, p := range peers {
+				if p == nil {
+					continue
+				}
+
+				for _, protocol := range rule.protocols() {
+					edgeID := p.ID + policy.ID + rule.ID + strconv.Itoa(direction) + string(protocol)
+					if _, ok := edgesExist[edgeID]; ok {
+						continue
+					}
+
+					if edges >= maxEdges {
+						acl.Truncated = true
+						return
+					}
+					edgesExist[edgeID] = struct{}{}
+					edges++
+
+					edge := &EffectiveACLEdge{
+						PeerID:     p.ID,
+						PeerName:   p.Name,
+						PeerIP:     p.IP.String(),
+						Action:     rule.Action,
+						Protocol:   protocol,
+						PolicyID:   policy.ID,
+						PolicyName: policy.Name,
+						RuleID:     rule.ID,
+						RuleName:   rule.Name,
+					}
+					if protocol != PolicyRuleProtocolICMP {
+						edge.Ports = make([]string, len(rule.Ports))
+						copy(edge.Ports, rule.Ports)
+					}
+
+					if direction == firewallRuleDirectionOUT {
+						peerACL.Outbound = append(peerACL.Outbound, edge)
+					} else {
+						peerACL.Inbound = append(peerACL.Inbound, edge)
+					}
+				}
+			}
+		})
+
+		sortEffectiveACLEdges(peerACL.Outbound)
+		sortEffectiveACLEdges(peerACL.Inbound)
+
+		if acl.Truncated {
+			break
+		}
+	}
+
+	return acl
+}
+
+// sortEffectiveACLEdges sorts the edges by peer, policy and rule IDs, so the ACLs can be compared over time
+func sortEffectiveACLEdges(edges []*EffectiveACLEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].PeerID != edges[j].PeerID {
+			return edges[i].PeerID < edges[j].PeerID
+		}
+		if edges[i].PolicyID != edges[j].PolicyID {
+			return edges[i].PolicyID < edges[j].PolicyID
+		}
+		if edges[i].RuleID != edges[j].RuleID {
+			return edges[i].RuleID < edges[j].RuleID
+		}
+		return edges[i].Protocol < edges[j].Protocol
+	})
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func newEffectiveACLTestAccount() *Account {
+	return &Account{
+		Id: "account",
+		Peers: map[string]*nbpeer.Peer{
+			"peerA": {ID: "peerA", Name: "client", IP: net.ParseIP("100.65.14.88"), Status: &nbpeer.PeerStatus{}},
+			"peerB": {ID: "peerB", Name: "web", IP: net.ParseIP("100.65.80.39"), Status: &nbpeer.PeerStatus{}},
+			"peerC": {ID: "peerC", Name: "db", IP: net.ParseIP("100.65.254.139"), Status: &nbpeer.PeerStatus{}},
+		},
+		Groups: map[string]*Group{
+			"GroupClients": {ID: "GroupClients", Name: "Clients", Peers: []string{"peerA"}},
+			"GroupWeb":     {ID: "GroupWeb", Name: "Web", Peers: []string{"peerB"}},
+			"GroupDB":      {ID: "GroupDB", Name: "DB", Peers: []string{"peerC"}},
+		},
+		Policies: []*Policy{
+			{
+				ID:      "PolicyWeb",
+				Name:    "Web",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{
+						ID:           "RuleHTTP",
+						Name:         "HTTP",
+						Enabled:      true,
+						Action:       PolicyTrafficActionAccept,
+						Protocol:     PolicyRuleProtocolTCP,
+						Ports:        []string{"80", "443"},
+						Sources:      []string{"GroupClients"},
+						Destinations: []string{"GroupWeb"},
+					},
+				},
+			},
+			{
+				ID:      "PolicyDB",
+				Name:    "DB",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{
+						ID:            "RuleDB",
+						Name:          "Web to DB",
+						Enabled:       true,
+						Action:        PolicyTrafficActionAccept,
+						Protocol:      PolicyRuleProtocolALL,
+						Bidirectional: true,
+						Sources:       []string{"GroupWeb"},
+						Destinations:  []string{"GroupDB"},
+					},
+				},
+			},
+			{
+				ID:      "PolicyDisabled",
+				Name:    "Disabled",
+				Enabled: false,
+				Rules: []*PolicyRule{
+					{
+						ID:           "RuleDisabled",
+						Enabled:      true,
+						Action:       PolicyTrafficActionAccept,
+						Protocol:     PolicyRuleProtocolALL,
+						Sources:      []string{"GroupClients"},
+						Destinations: []string{"GroupDB"},
+					},
+				},
+			},
+		},
+		Settings: &Settings{},
+	}
+}
+
+func TestAccount_getEffectiveACL(t *testing.T) {
+	account := newEffectiveACLTestAccount()
+
+	acl := account.getEffectiveACL([]string{"peerA", "peerB", "peerC"}, EffectiveACLMaxEdges)
+	assert.False(t, acl.Truncated)
+	require.Len(t, acl.Peers, 3)
+
+	client := acl.Peers[0]
+	assert.Equal(t, "peerA", client.PeerID)
+	assert.Empty(t, client.Inbound)
+	require.Len(t, client.Outbound, 1, "the disabled policy shouldn't grant an edge")
+	assert.Equal(t, &EffectiveACLEdge{
+		PeerID:     "peerB",
+		PeerName:   "web",
+		PeerIP:     "100.65.80.39",
+		Action:     PolicyTrafficActionAccept,
+		Protocol:   PolicyRuleProtocolTCP,
+		Ports:      []string{"80", "443"},
+		PolicyID:   "PolicyWeb",
+		PolicyName: "Web",
+		RuleID:     "RuleHTTP",
+		RuleName:   "HTTP",
+	}, client.Outbound[0])
+
+	web := acl.Peers[1]
+	require.Len(t, web.Inbound, 2)
+	assert.Equal(t, "peerA", web.Inbound[0].PeerID, "the edges should be sorted by peer ID")
+	assert.Equal(t, "peerC", web.Inbound[1].PeerID)
+	assert.Equal(t, "RuleDB", web.Inbound[1].RuleID)
+	require.Len(t, web.Outbound, 1)
+	assert.Equal(t, "peerC", web.Outbound[0].PeerID)
+
+	db := acl.Peers[2]
+	require.Len(t, db.Inbound, 1, "the bidirectional rule shouldn't duplicate the edges")
+	require.Len(t, db.Outbound, 1)
+	assert.Equal(t, "peerB", db.Inbound[0].PeerID)
+	assert.Equal(t, "peerB", db.Outbound[0].PeerID)
+
+	truncated := account.getEffectiveACL([]string{"peerA", "peerB", "peerC"}, 2)
+	assert.True(t, truncated.Truncated)
+	require.Len(t, truncated.Peers, 2)
+	assert.Len(t, truncated.Peers[1].Inbound, 1)
+	assert.Empty(t, truncated.Peers[1].Outbound)
+
+	account.Peers["peerB"].Status.RequiresApproval = true
+	acl = account.getEffectiveACL([]string{"peerA", "peerB"}, EffectiveACLMaxEdges)
+	assert.Empty(t, acl.Peers[0].Outbound, "the peers waiting for an approval shouldn't be reachable")
+	assert.Empty(t, acl.Peers[1].Inbound, "the peers waiting for an approval shouldn't reach other peers")
+}
+
+func TestDefaultAccountManager_GetEffectiveACL(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	regularUser := NewRegularUser("regular_user")
+	account.Users[regularUser.Id] = regularUser
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	acl, err := manager.GetEffectiveACL(account.Id, userID, "")
+	require.NoError(t, err)
+	assert.Empty(t, acl.Peers)
+
+	_, err = manager.GetEffectiveACL(account.Id, userID, "unknown_peer")
+	assertStatusType(t, err, status.NotFound)
+
+	_, err = manager.GetEffectiveACL(account.Id, regularUser.Id, "")
+	assertStatusType(t, err, status.PermissionDenied)
+}
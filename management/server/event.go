@@ -25,8 +25,8 @@ func (am *DefaultAccountManager) GetEvents(accountID, userID string) ([]*activit
 		return nil, err
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
-		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view events")
+	if !user.HasPermission(ResourceEvents, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view events")
 	}
 
 	events, err := am.eventStore.Get(accountID, 0, 10000, true)
@@ -58,18 +58,23 @@ func (am *DefaultAccountManager) StoreEvent(initiatorID, targetID, accountID str
 	meta map[string]any) {
 
 	go func() {
-		_, err := am.eventStore.Save(&activity.Event{
+		event := &activity.Event{
 			Timestamp:   time.Now().UTC(),
 			Activity:    activityID,
 			InitiatorID: initiatorID,
 			TargetID:    targetID,
 			AccountID:   accountID,
 			Meta:        meta,
-		})
+		}
+		saved, err := am.eventStore.Save(event)
 		if err != nil {
 			// todo add metric
 			log.Errorf("received an error while storing an activity event, error: %s", err)
+		} else {
+			am.eventStreams.publish(saved)
 		}
+
+		am.dispatchWebhook(event)
 	}()
 
 }
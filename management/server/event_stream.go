@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// eventSubscriptionBufferSize is the number of events a consumer can fall behind before its subscription is dropped
+	eventSubscriptionBufferSize = 100
+	// eventReplayLimit is the maximum number of stored events replayed to a resuming consumer
+	eventReplayLimit = 10000
+)
+
+// ErrEventSubscriptionDropped is returned by EventSubscription.Next when the consumer didn't keep up with the events.
+// The consumer should subscribe again with the resume token of the last received event.
+var ErrEventSubscriptionDropped = errors.New("event subscription dropped because the consumer is too slow")
+
+// EventResumeToken returns the token a consumer resumes the event stream after the event with
+func EventResumeToken(event *activity.Event) string {
+	return strconv.FormatUint(event.ID, 10)
+}
+
+func parseEventResumeToken(token string) (uint64, error) {
+	id, err := strconv.ParseUint(token, 10, 64)
+	if err != nil {
+		return 0, status.Errorf(status.InvalidArgument, "invalid event resume token %s", token)
+	}
+	return id, nil
+}
+
+// EventSubscription receives the activity events of an account as they are stored
+type EventSubscription struct {
+	accountID string
+	streams   *accountEventStreams
+	// replay holds the stored events missed by a resuming consumer, they are returned before the new events
+	replay   []*activity.Event
+	replayed map[uint64]struct{}
+	events   chan *activity.Event
+	// dropped is closed when the subscription is removed because the consumer is too slow
+	dropped chan struct{}
+}
+
+// Next blocks until the next event of the account is available and returns it.
+// It returns ErrEventSubscriptionDropped when the consumer is too slow and the context error when the context is done.
+func (s *EventSubscription) Next(ctx context.Context) (*activity.Event, error) {
+	if len(s.replay) > 0 {
+		event := s.replay[0]
+		s.replay = s.replay[1:]
+		return event, nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event := <-s.events:
+			// the events stored while the missed events were loaded are received twice
+			if _, ok := s.replayed[event.ID]; ok {
+				continue
+			}
+			return event, nil
+		case <-s.dropped:
+			return nil, ErrEventSubscriptionDropped
+		}
+	}
+}
+
+// Close stops the subscription
+func (s *EventSubscription) Close() {
+	s.streams.unsubscribe(s)
+}
+
+// accountEventStreams fans the stored activity events out to the subscriptions of their account.
+// Publishing never blocks, a subscription that can't take an event is dropped. The zero value is ready to use.
+type accountEventStreams struct {
+	mu            sync.Mutex
+	subscriptions map[string]map[*EventSubscription]struct{}
+}
+
+func (m *accountEventStreams) subscribe(accountID string) *EventSubscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.subscriptions == nil {
+		m.subscriptions = make(map[string]map[*EventSubscription]struct{})
+	}
+
+	sub := &EventSubscription{
+		accountID: accountID,
+		streams:   m,
+		events:    make(chan *activity.Event, eventSubscriptionBufferSize),
+		dropped:   make(chan struct{}),
+	}
+
+	if _, ok := m.subscriptions[accountID]; !ok {
+		m.subscriptions[accountID] = make(map[*EventSubscription]struct{})
+	}
+	m.subscriptions[accountID][sub] = struct{}{}
+
+	return sub
+}
+
+func (m *accountEventStreams) unsubscribe(sub *EventSubscription) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(sub)
+}
+
+// remove deletes the subscription, the caller should hold the lock
+func (m *accountEventStreams) remove(sub *EventSubscription) {
+	subs, ok := m.subscriptions[sub.accountID]
+	if !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(m.subscriptions, sub.accountID)
+	}
+}
+
+// publish sends the event to the subscriptions of its account
+func (m *accountEventStreams) publish(event *activity.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for sub := range m.subscriptions[event.AccountID] {
+		select {
+		case sub.events <- event:
+		default:
+			log.Warnf("dropping the event subscription of account %s, the consumer is too slow", event.AccountID)
+			m.remove(sub)
+			close(sub.dropped)
+		}
+	}
+}
+
+// SubscribeToEvents subscribes the user to the activity events of the account as they are stored.
+// If the resume token is set, the stored events after the token are returned first, up to eventReplayLimit events.
+// The subscription should be closed by the caller.
+func (am *DefaultAccountManager) SubscribeToEvents(accountID, userID, resumeToken string) (*EventSubscription, error) {
+	var resumeAfter uint64
+	var err error
+	if resumeToken != "" {
+		resumeAfter, err = parseEventResumeToken(resumeToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceEvents, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view events")
+	}
+
+	// subscribe before loading the missed events, so the events stored in between aren't lost
+	sub := am.eventStreams.subscribe(accountID)
+	if resumeToken == "" {
+		return sub, nil
+	}
+
+	events, err := am.eventStore.Get(accountID, 0, eventReplayLimit, true)
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	sub.replayed = make(map[uint64]struct{})
+	for _, event := range events {
+		if event.ID > resumeAfter {
+			sub.replay = append(sub.replay, event)
+			sub.replayed[event.ID] = struct{}{}
+		}
+	}
+	sort.Slice(sub.replay, func(i, j int) bool {
+		return sub.replay[i].ID < sub.replay[j].ID
+	})
+
+	return sub, nil
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// nextTestEvent returns the next event of the subscription targeting an object created by the test
+func nextTestEvent(t *testing.T, sub *EventSubscription) *activity.Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for {
+		event, err := sub.Next(ctx)
+		require.NoError(t, err)
+		if strings.HasPrefix(event.TargetID, "test-") {
+			return event
+		}
+	}
+}
+
+func TestDefaultAccountManager_SubscribeToEvents(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+	otherAccount, err := createAccount(manager, "other_account", "other_creator", "")
+	require.NoError(t, err)
+
+	account.Users["regular_user"] = NewRegularUser("regular_user")
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	t.Run("regular user is not allowed", func(t *testing.T) {
+		_, err := manager.SubscribeToEvents(account.Id, "regular_user", "")
+		sErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, status.PermissionDenied, sErr.Type())
+	})
+
+	t.Run("invalid resume token", func(t *testing.T) {
+		_, err := manager.SubscribeToEvents(account.Id, userID, "last")
+		sErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, status.InvalidArgument, sErr.Type())
+	})
+
+	sub, err := manager.SubscribeToEvents(account.Id, userID, "")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	manager.StoreEvent(userID, "other-target", otherAccount.Id, activity.PeerAddedByUser, nil)
+	manager.StoreEvent(userID, "test-peer1", account.Id, activity.PeerAddedByUser, nil)
+	first := nextTestEvent(t, sub)
+	manager.StoreEvent(userID, "test-peer2", account.Id, activity.PeerAddedByUser, nil)
+	second := nextTestEvent(t, sub)
+
+	assert.Equal(t, "test-peer1", first.TargetID)
+	assert.Equal(t, "test-peer2", second.TargetID)
+	assert.Equal(t, account.Id, second.AccountID, "the events of other accounts shouldn't be streamed")
+
+	t.Run("resume after an event", func(t *testing.T) {
+		resumed, err := manager.SubscribeToEvents(account.Id, userID, EventResumeToken(first))
+		require.NoError(t, err)
+		defer resumed.Close()
+
+		manager.StoreEvent(userID, "test-peer3", account.Id, activity.PeerAddedByUser, nil)
+
+		assert.Equal(t, "test-peer2", nextTestEvent(t, resumed).TargetID, "missed event should be replayed")
+		assert.Equal(t, "test-peer3", nextTestEvent(t, resumed).TargetID, "new event should follow the replayed ones")
+	})
+}
+
+func TestAccountEventStreams_DropSlowConsumer(t *testing.T) {
+	var streams accountEventStreams
+	slow := streams.subscribe("account")
+	defer slow.Close()
+
+	// publishing doesn't block even though nobody reads the events
+	for i := 0; i <= eventSubscriptionBufferSize; i++ {
+		streams.publish(&activity.Event{ID: uint64(i), AccountID: "account"})
+	}
+
+	// the subscription was removed, so the following events aren't sent to it anymore
+	fast := streams.subscribe("account")
+	defer fast.Close()
+	streams.publish(&activity.Event{ID: 1000, AccountID: "account"})
+
+	var err error
+	for i := 0; i <= eventSubscriptionBufferSize && err == nil; i++ {
+		_, err = slow.Next(context.Background())
+	}
+	assert.ErrorIs(t, err, ErrEventSubscriptionDropped)
+
+	event, err := fast.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), event.ID)
+}
+
+func TestBearerTokenFromContext(t *testing.T) {
+	tt := []struct {
+		name          string
+		authorization string
+		expectedToken string
+		expectErr     bool
+	}{
+		{"bearer token", "Bearer token", "token", false},
+		{"lowercase bearer", "bearer token", "token", false},
+		{"other scheme", "Token token", "", true},
+		{"empty token", "Bearer ", "", true},
+		{"missing metadata", "", "", true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tc.authorization))
+			}
+
+			token, err := bearerTokenFromContext(ctx)
+			if tc.expectErr {
+				assert.Error(t, err, "authorization %q should be rejected", tc.authorization)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedToken, token)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -22,8 +24,11 @@ const storeFileName = "store.json"
 
 // FileStore represents an account storage backed by a file persisted to disk
 type FileStore struct {
+	// Accounts are the accounts decoded from the store file or saved since it was read, the other accounts of the file
+	// are in lazyAccounts until they are accessed
 	Accounts                map[string]*Account
 	SetupKeyID2AccountID    map[string]string `json:"-"`
+	OrgKey2AccountID        map[string]string `json:"-"`
 	PeerKeyID2AccountID     map[string]string `json:"-"`
 	PeerID2AccountID        map[string]string `json:"-"`
 	UserID2AccountID        map[string]string `json:"-"`
@@ -33,12 +38,23 @@ type FileStore struct {
 	InstallationID          string
 
 	// mutex to synchronise Store read/write operations
-	mux       sync.Mutex `json:"-"`
-	storeFile string     `json:"-"`
-
-	// sync.Mutex indexed by accountID
-	accountLocks      sync.Map   `json:"-"`
-	globalAccountLock sync.Mutex `json:"-"`
+	mux       sync.RWMutex `json:"-"`
+	storeFile string       `json:"-"`
+
+	// lazyAccounts are the accounts of the store file which weren't accessed yet
+	lazyAccounts map[string]*lazyAccount `json:"-"`
+	// cacheMux synchronises moving the accounts from lazyAccounts to Accounts while holding the read lock of mux
+	cacheMux sync.Mutex `json:"-"`
+
+	// accountLocks serialize the operations of an account, the operations of different accounts run in parallel
+	accountLocks      accountLocks `json:"-"`
+	globalAccountLock sync.Mutex   `json:"-"`
+	// snapshots counts the snapshots of the store file, it is incremented holding the write lock of mux
+	snapshots uint64 `json:"-"`
+	// persistMux serializes the writes of the store file
+	persistMux sync.Mutex `json:"-"`
+	// written is the last snapshot written to the store file, guarded by persistMux
+	written uint64 `json:"-"`
 
 	metrics telemetry.AppMetrics `json:"-"`
 }
@@ -56,7 +72,7 @@ func NewFileStore(dataDir string, metrics telemetry.AppMetrics) (*FileStore, err
 }
 
 // NewFilestoreFromSqliteStore restores a store from Sqlite and stores to Filestore json in the file located in datadir
-func NewFilestoreFromSqliteStore(sqlitestore *SqliteStore, dataDir string, metrics telemetry.AppMetrics) (*FileStore, error) {
+func NewFilestoreFromSqliteStore(sqlitestore *SqlStore, dataDir string, metrics telemetry.AppMetrics) (*FileStore, error) {
 	store, err := NewFileStore(dataDir, metrics)
 	if err != nil {
 		return nil, err
@@ -69,6 +85,7 @@ func NewFilestoreFromSqliteStore(sqlitestore *SqliteStore, dataDir string, metri
 
 	for _, account := range sqlitestore.GetAllAccounts() {
 		store.Accounts[account.Id] = account
+		delete(store.lazyAccounts, account.Id)
 	}
 
 	return store, store.persist(store.storeFile)
@@ -79,19 +96,7 @@ func NewFilestoreFromSqliteStore(sqlitestore *SqliteStore, dataDir string, metri
 func restore(file string) (*FileStore, error) {
 	if _, err := os.Stat(file); os.IsNotExist(err) {
 		// create a new FileStore if previously didn't exist (e.g. first run)
-		s := &FileStore{
-			Accounts:                make(map[string]*Account),
-			mux:                     sync.Mutex{},
-			globalAccountLock:       sync.Mutex{},
-			SetupKeyID2AccountID:    make(map[string]string),
-			PeerKeyID2AccountID:     make(map[string]string),
-			UserID2AccountID:        make(map[string]string),
-			PrivateDomain2AccountID: make(map[string]string),
-			PeerID2AccountID:        make(map[string]string),
-			HashedPAT2TokenID:       make(map[string]string),
-			TokenID2UserID:          make(map[string]string),
-			storeFile:               file,
-		}
+		s := newFileStore(file)
 
 		err = s.persist(file)
 		if err != nil {
@@ -101,173 +106,355 @@ func restore(file string) (*FileStore, error) {
 		return s, nil
 	}
 
-	read, err := util.ReadJson(file, &FileStore{})
+	read, err := util.ReadJson(file, &storeFileLayout{})
 	if err != nil {
 		return nil, err
 	}
 
-	store := read.(*FileStore)
-	store.storeFile = file
-	store.SetupKeyID2AccountID = make(map[string]string)
-	store.PeerKeyID2AccountID = make(map[string]string)
-	store.UserID2AccountID = make(map[string]string)
-	store.PrivateDomain2AccountID = make(map[string]string)
-	store.PeerID2AccountID = make(map[string]string)
-	store.HashedPAT2TokenID = make(map[string]string)
-	store.TokenID2UserID = make(map[string]string)
-
-	for accountID, account := range store.Accounts {
-		if account.Settings == nil {
-			account.Settings = &Settings{
-				PeerLoginExpirationEnabled: false,
-				PeerLoginExpiration:        DefaultPeerLoginExpiration,
-			}
+	layout := read.(*storeFileLayout)
+	store := newFileStore(file)
+	store.InstallationID = layout.InstallationID
+
+	migrated := false
+	for accountID, raw := range layout.Accounts {
+		fields := &accountIndexFields{}
+		if err := json.Unmarshal(raw, fields); err != nil {
+			return nil, fmt.Errorf("failed to read account %s: %w", accountID, err)
 		}
 
-		for setupKeyId := range account.SetupKeys {
-			store.SetupKeyID2AccountID[strings.ToUpper(setupKeyId)] = accountID
+		if !fields.hasPeersWithoutID() {
+			store.lazyAccounts[accountID] = &lazyAccount{raw: raw}
+			store.indexAccountFields(accountID, fields)
+			continue
 		}
 
-		for _, peer := range account.Peers {
-			store.PeerKeyID2AccountID[peer.Key] = accountID
-			store.PeerID2AccountID[peer.ID] = accountID
+		// the peers are indexed by the IDs generated when migrating the account, so it is loaded right away
+		account, err := decodeAccount(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read account %s: %w", accountID, err)
 		}
-		for _, user := range account.Users {
-			store.UserID2AccountID[user.Id] = accountID
-			if user.Issued == "" {
-				user.Issued = UserIssuedAPI
-				account.Users[user.Id] = user
-			}
+		store.Accounts[accountID] = account
+		store.indexAccount(account)
+		migrated = true
+	}
 
-			for _, pat := range user.PATs {
-				store.TokenID2UserID[pat.ID] = user.Id
-				store.HashedPAT2TokenID[pat.HashedToken] = pat.ID
-			}
+	// the IDs generated for the migrated peers are written back, so they stay the same across restarts
+	if migrated {
+		err = store.persist(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+func newFileStore(file string) *FileStore {
+	return &FileStore{
+		Accounts:                make(map[string]*Account),
+		lazyAccounts:            make(map[string]*lazyAccount),
+		SetupKeyID2AccountID:    make(map[string]string),
+		OrgKey2AccountID:        make(map[string]string),
+		PeerKeyID2AccountID:     make(map[string]string),
+		UserID2AccountID:        make(map[string]string),
+		PrivateDomain2AccountID: make(map[string]string),
+		PeerID2AccountID:        make(map[string]string),
+		HashedPAT2TokenID:       make(map[string]string),
+		TokenID2UserID:          make(map[string]string),
+		storeFile:               file,
+	}
+}
+
+// storeFileLayout is the layout of the store file. The accounts are kept undecoded until they are accessed,
+// so restoring a large store only decodes the fields the indexes are built from
+type storeFileLayout struct {
+	Accounts       map[string]json.RawMessage
+	InstallationID string
+}
+
+// accountIndexFields are the fields of a stored account the FileStore indexes are built from
+type accountIndexFields struct {
+	Domain                 string
+	DomainCategory         string
+	IsDomainPrimaryAccount bool
+	SetupKeys              map[string]struct{}
+	OrgKeys                map[string]struct{ Key string }
+	Peers                  map[string]struct{ ID, Key string }
+	Users                  map[string]struct {
+		Id   string
+		PATs map[string]struct{ ID, HashedToken string }
+	}
+}
+
+// hasPeersWithoutID tells whether the account has peers stored before the peers were identified by ID
+func (f *accountIndexFields) hasPeersWithoutID() bool {
+	for _, peer := range f.Peers {
+		if peer.ID == "" {
+			return true
 		}
+	}
+	return false
+}
+
+// lazyAccount is a stored account decoded on the first access
+type lazyAccount struct {
+	mux     sync.Mutex
+	raw     json.RawMessage
+	account *Account
+}
+
+// load decodes the account once, the concurrent loads wait for the first one and get the same account.
+// A failed load is retried on the next access
+func (a *lazyAccount) load() (*Account, error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if a.account != nil {
+		return a.account, nil
+	}
+
+	account, err := decodeAccount(a.raw)
+	if err != nil {
+		return nil, err
+	}
+	a.account = account
+
+	return account, nil
+}
 
-		if account.Domain != "" && account.DomainCategory == PrivateCategory &&
-			account.IsDomainPrimaryAccount {
-			store.PrivateDomain2AccountID[account.Domain] = accountID
+// decodeAccount decodes a stored account and migrates it to the current version
+func decodeAccount(raw json.RawMessage) (*Account, error) {
+	account := &Account{}
+	if err := json.Unmarshal(raw, account); err != nil {
+		return nil, err
+	}
+
+	migrateAccount(account)
+
+	return account, nil
+}
+
+// migrateAccount upgrades an account stored by an older version
+func migrateAccount(account *Account) {
+	if account.Settings == nil {
+		account.Settings = &Settings{
+			PeerLoginExpirationEnabled: false,
+			PeerLoginExpiration:        DefaultPeerLoginExpiration,
 		}
+	}
 
-		// TODO: delete this block after migration
-		policies := make(map[string]int, len(account.Policies))
-		for i, policy := range account.Policies {
-			policies[policy.ID] = i
-			policy.UpgradeAndFix()
+	for _, user := range account.Users {
+		if user.Issued == "" {
+			user.Issued = UserIssuedAPI
 		}
-		if account.Policies == nil {
-			account.Policies = make([]*Policy, 0)
+	}
+
+	// TODO: delete this block after migration
+	policies := make(map[string]int, len(account.Policies))
+	for i, policy := range account.Policies {
+		policies[policy.ID] = i
+		policy.UpgradeAndFix()
+	}
+	if account.Policies == nil {
+		account.Policies = make([]*Policy, 0)
+	}
+	for _, rule := range account.Rules {
+		policy, err := RuleToPolicy(rule)
+		if err != nil {
+			log.Errorf("unable to migrate rule to policy: %v", err)
+			continue
 		}
-		for _, rule := range account.Rules {
-			policy, err := RuleToPolicy(rule)
-			if err != nil {
-				log.Errorf("unable to migrate rule to policy: %v", err)
-				continue
-			}
-			// don't update policies from rules, rules deprecated,
-			// only append not existed rules as part of the migration process
-			if _, ok := policies[policy.ID]; !ok {
-				account.Policies = append(account.Policies, policy)
-			}
+		// don't update policies from rules, rules deprecated,
+		// only append not existed rules as part of the migration process
+		if _, ok := policies[policy.ID]; !ok {
+			account.Policies = append(account.Policies, policy)
 		}
+	}
 
-		// for data migration. Can be removed once most base will be with labels
-		existingLabels := account.getPeerDNSLabels()
-		if len(existingLabels) != len(account.Peers) {
-			addPeerLabelsToAccount(account, existingLabels)
+	// for data migration of the peers without labels or with the labels stored before they were enforced unique
+	deduplicatePeerDNSLabels(account)
+
+	// TODO: delete this block after migration
+	// Set API as issuer for groups which has not this field
+	for _, group := range account.Groups {
+		if group.Issued == "" {
+			group.Issued = GroupIssuedAPI
 		}
+	}
 
-		// TODO: delete this block after migration
-		// Set API as issuer for groups which has not this field
-		for _, group := range account.Groups {
-			if group.Issued == "" {
-				group.Issued = GroupIssuedAPI
-			}
+	allGroup, err := account.GetGroupAll()
+	if err != nil {
+		log.Errorf("unable to find the All group, this should happen only when migrate from a version that didn't support groups. Error: %v", err)
+		// if the All group didn't exist we probably don't have routes to update
+		return
+	}
+
+	for _, route := range account.Routes {
+		if len(route.Groups) == 0 {
+			route.Groups = []string{allGroup.ID}
 		}
+	}
 
-		allGroup, err := account.GetGroupAll()
-		if err != nil {
-			log.Errorf("unable to find the All group, this should happen only when migrate from a version that didn't support groups. Error: %v", err)
-			// if the All group didn't exist we probably don't have routes to update
+	// migration to Peer.ID from Peer.Key.
+	// Old peers that require migration have an empty Peer.ID in the store.json.
+	// Generate new ID with xid for these peers.
+	// Set the Peer.ID to the newly generated value.
+	// Replace all the mentions of Peer.Key as ID (groups and routes).
+	// Swap Peer.Key with Peer.ID in the Account.Peers map.
+	migrationPeers := make(map[string]*nbpeer.Peer) // key to Peer
+	for key, peer := range account.Peers {
+		// set LastLogin for the peers that were onboarded before the peer login expiration feature
+		if peer.LastLogin.IsZero() {
+			peer.LastLogin = time.Now().UTC()
+		}
+		if peer.ID != "" {
 			continue
 		}
+		id := xid.New().String()
+		peer.ID = id
+		migrationPeers[key] = peer
+	}
+
+	if len(migrationPeers) == 0 {
+		return
+	}
+
+	// swap Peer.Key with Peer.ID in the Account.Peers map.
+	for key, peer := range migrationPeers {
+		delete(account.Peers, key)
+		account.Peers[peer.ID] = peer
+	}
 
-		for _, route := range account.Routes {
-			if len(route.Groups) == 0 {
-				route.Groups = []string{allGroup.ID}
+	// detect groups that have Peer.Key as a reference and replace it with ID.
+	for _, group := range account.Groups {
+		for i, peer := range group.Peers {
+			if p, ok := migrationPeers[peer]; ok {
+				group.Peers[i] = p.ID
 			}
 		}
+	}
 
-		// migration to Peer.ID from Peer.Key.
-		// Old peers that require migration have an empty Peer.ID in the store.json.
-		// Generate new ID with xid for these peers.
-		// Set the Peer.ID to the newly generated value.
-		// Replace all the mentions of Peer.Key as ID (groups and routes).
-		// Swap Peer.Key with Peer.ID in the Account.Peers map.
-		migrationPeers := make(map[string]*nbpeer.Peer) // key to Peer
-		for key, peer := range account.Peers {
-			// set LastLogin for the peers that were onboarded before the peer login expiration feature
-			if peer.LastLogin.IsZero() {
-				peer.LastLogin = time.Now().UTC()
-			}
-			if peer.ID != "" {
-				continue
-			}
-			id := xid.New().String()
-			peer.ID = id
-			migrationPeers[key] = peer
+	// detect routes that have Peer.Key as a reference and replace it with ID.
+	for _, route := range account.Routes {
+		if peer, ok := migrationPeers[route.Peer]; ok {
+			route.Peer = peer.ID
 		}
+	}
+}
 
-		if len(migrationPeers) > 0 {
-			// swap Peer.Key with Peer.ID in the Account.Peers map.
-			for key, peer := range migrationPeers {
-				delete(account.Peers, key)
-				account.Peers[peer.ID] = peer
-				store.PeerID2AccountID[peer.ID] = accountID
-			}
+// indexAccount adds the account to the FileStore indexes
+func (s *FileStore) indexAccount(account *Account) {
+	// todo check that account.Id and keyId are not exist already
+	// because if keyId exists for other accounts this can be bad
+	for keyID := range account.SetupKeys {
+		s.SetupKeyID2AccountID[strings.ToUpper(keyID)] = account.Id
+	}
 
-			// detect groups that have Peer.Key as a reference and replace it with ID.
-			for _, group := range account.Groups {
-				for i, peer := range group.Peers {
-					if p, ok := migrationPeers[peer]; ok {
-						group.Peers[i] = p.ID
-					}
-				}
-			}
+	for _, orgKey := range account.OrgKeys {
+		s.OrgKey2AccountID[orgKey.Key] = account.Id
+	}
 
-			// detect routes that have Peer.Key as a reference and replace it with ID.
-			for _, route := range account.Routes {
-				if peer, ok := migrationPeers[route.Peer]; ok {
-					route.Peer = peer.ID
-				}
-			}
+	for _, peer := range account.Peers {
+		s.PeerKeyID2AccountID[peer.Key] = account.Id
+		s.PeerID2AccountID[peer.ID] = account.Id
+	}
+
+	for _, user := range account.Users {
+		s.UserID2AccountID[user.Id] = account.Id
+		for _, pat := range user.PATs {
+			s.TokenID2UserID[pat.ID] = user.Id
+			s.HashedPAT2TokenID[pat.HashedToken] = pat.ID
 		}
 	}
 
-	// we need this persist to apply changes we made to account.Peers (we set them to Disconnected)
-	err = store.persist(store.storeFile)
-	if err != nil {
-		return nil, err
+	if account.Domain != "" && account.DomainCategory == PrivateCategory && account.IsDomainPrimaryAccount {
+		s.PrivateDomain2AccountID[account.Domain] = account.Id
 	}
+}
 
-	return store, nil
+// indexAccountFields adds an account which isn't decoded yet to the FileStore indexes
+func (s *FileStore) indexAccountFields(accountID string, fields *accountIndexFields) {
+	for keyID := range fields.SetupKeys {
+		s.SetupKeyID2AccountID[strings.ToUpper(keyID)] = accountID
+	}
+
+	for _, orgKey := range fields.OrgKeys {
+		s.OrgKey2AccountID[orgKey.Key] = accountID
+	}
+
+	for _, peer := range fields.Peers {
+		s.PeerKeyID2AccountID[peer.Key] = accountID
+		s.PeerID2AccountID[peer.ID] = accountID
+	}
+
+	for _, user := range fields.Users {
+		s.UserID2AccountID[user.Id] = accountID
+		for _, pat := range user.PATs {
+			s.TokenID2UserID[pat.ID] = user.Id
+			s.HashedPAT2TokenID[pat.HashedToken] = pat.ID
+		}
+	}
+
+	if fields.Domain != "" && fields.DomainCategory == PrivateCategory && fields.IsDomainPrimaryAccount {
+		s.PrivateDomain2AccountID[fields.Domain] = accountID
+	}
 }
 
 // persist account data to a file
 // It is recommended to call it with locking FileStore.mux
 func (s *FileStore) persist(file string) error {
-	start := time.Now()
-	err := util.WriteJson(file, s)
+	write, err := s.snapshot(file)
 	if err != nil {
 		return err
 	}
-	took := time.Since(start)
-	if s.metrics != nil {
-		s.metrics.StoreMetrics().CountPersistenceDuration(took)
+	return write()
+}
+
+// snapshot encodes the accounts and returns a function writing them to the file, which may be called after releasing
+// FileStore.mux. A snapshot taken before the one already written is dropped, so the file never goes back in time.
+// Should be called holding the write lock of FileStore.mux
+func (s *FileStore) snapshot(file string) (write func() error, err error) {
+	start := time.Now()
+
+	layout := &storeFileLayout{
+		Accounts:       make(map[string]json.RawMessage, len(s.Accounts)+len(s.lazyAccounts)),
+		InstallationID: s.InstallationID,
 	}
-	log.Debugf("took %d ms to persist the FileStore", took.Milliseconds())
-	return nil
+	// the accounts which were never accessed are written back as they were read
+	for accountID, lazy := range s.lazyAccounts {
+		layout.Accounts[accountID] = lazy.raw
+	}
+	for accountID, account := range s.Accounts {
+		raw, err := json.Marshal(account)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode account %s: %w", accountID, err)
+		}
+		layout.Accounts[accountID] = raw
+	}
+	s.snapshots++
+	snapshot := s.snapshots
+
+	return func() error {
+		s.persistMux.Lock()
+		defer s.persistMux.Unlock()
+
+		if snapshot < s.written {
+			log.Debugf("skipping the FileStore snapshot %d, the newer snapshot %d was written", snapshot, s.written)
+			return nil
+		}
+
+		err := util.WriteJson(file, layout)
+		if err != nil {
+			return err
+		}
+		s.written = snapshot
+		took := time.Since(start)
+		if s.metrics != nil {
+			s.metrics.StoreMetrics().CountPersistenceDuration(took)
+		}
+		log.Debugf("took %d ms to persist the FileStore", took.Milliseconds())
+		return nil
+	}, nil
 }
 
 // AcquireGlobalLock acquires global lock across all the accounts and returns a function that releases the lock
@@ -294,53 +481,50 @@ func (s *FileStore) AcquireGlobalLock() (unlock func()) {
 func (s *FileStore) AcquireAccountLock(accountID string) (unlock func()) {
 	log.Debugf("acquiring lock for account %s", accountID)
 	start := time.Now()
-	value, _ := s.accountLocks.LoadOrStore(accountID, &sync.Mutex{})
-	mtx := value.(*sync.Mutex)
-	mtx.Lock()
+	unlockAccount := s.accountLocks.lock(accountID)
 
 	unlock = func() {
-		mtx.Unlock()
+		unlockAccount()
 		log.Debugf("released lock for account %s in %v", accountID, time.Since(start))
 	}
 
 	return unlock
 }
 
-func (s *FileStore) SaveAccount(account *Account) error {
-	s.mux.Lock()
-	defer s.mux.Unlock()
+// AcquireAccountReadLock acquires account lock for reading and returns a function that releases the lock.
+// Multiple readers can hold the lock at the same time, while AcquireAccountLock remains exclusive
+func (s *FileStore) AcquireAccountReadLock(accountID string) (unlock func()) {
+	log.Debugf("acquiring read lock for account %s", accountID)
+	start := time.Now()
+	unlockAccount := s.accountLocks.rLock(accountID)
 
-	if account.Id == "" {
-		return status.Errorf(status.InvalidArgument, "account id should not be empty")
+	unlock = func() {
+		unlockAccount()
+		log.Debugf("released read lock for account %s in %v", accountID, time.Since(start))
 	}
 
-	accountCopy := account.Copy()
+	return unlock
+}
 
-	s.Accounts[accountCopy.Id] = accountCopy
+// AcquireAccountLocks acquires the locks of the accounts in the order of their IDs and returns a function that releases
+// the locks
+func (s *FileStore) AcquireAccountLocks(accountIDs ...string) (unlock func()) {
+	return acquireAccountLocks(s.AcquireAccountLock, accountIDs)
+}
 
-	// todo check that account.Id and keyId are not exist already
-	// because if keyId exists for other accounts this can be bad
-	for keyID := range accountCopy.SetupKeys {
-		s.SetupKeyID2AccountID[strings.ToUpper(keyID)] = accountCopy.Id
+func (s *FileStore) SaveAccount(account *Account) error {
+	if account.Id == "" {
+		return status.Errorf(status.InvalidArgument, "account id should not be empty")
 	}
 
-	// enforce peer to account index and delete peer to route indexes for rebuild
-	for _, peer := range accountCopy.Peers {
-		s.PeerKeyID2AccountID[peer.Key] = accountCopy.Id
-		s.PeerID2AccountID[peer.ID] = accountCopy.Id
-	}
+	s.mux.Lock()
 
-	for _, user := range accountCopy.Users {
-		s.UserID2AccountID[user.Id] = accountCopy.Id
-		for _, pat := range user.PATs {
-			s.TokenID2UserID[pat.ID] = user.Id
-			s.HashedPAT2TokenID[pat.HashedToken] = pat.ID
-		}
-	}
+	accountCopy := account.Copy()
 
-	if accountCopy.DomainCategory == PrivateCategory && accountCopy.IsDomainPrimaryAccount {
-		s.PrivateDomain2AccountID[accountCopy.Domain] = accountCopy.Id
-	}
+	s.Accounts[accountCopy.Id] = accountCopy
+	delete(s.lazyAccounts, accountCopy.Id)
+
+	s.indexAccount(accountCopy)
 
 	accountCopy.Rules = make(map[string]*Rule)
 	for _, policy := range accountCopy.Policies {
@@ -349,21 +533,31 @@ func (s *FileStore) SaveAccount(account *Account) error {
 		}
 	}
 
-	return s.persist(s.storeFile)
+	write, err := s.snapshot(s.storeFile)
+	s.mux.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// the file is written without holding the store lock, so the other accounts aren't read and saved after the disk
+	return write()
 }
 
 func (s *FileStore) DeleteAccount(account *Account) error {
-	s.mux.Lock()
-	defer s.mux.Unlock()
-
 	if account.Id == "" {
 		return status.Errorf(status.InvalidArgument, "account id should not be empty")
 	}
 
+	s.mux.Lock()
+
 	for keyID := range account.SetupKeys {
 		delete(s.SetupKeyID2AccountID, strings.ToUpper(keyID))
 	}
 
+	for _, orgKey := range account.OrgKeys {
+		delete(s.OrgKey2AccountID, orgKey.Key)
+	}
+
 	// enforce peer to account index and delete peer to route indexes for rebuild
 	for _, peer := range account.Peers {
 		delete(s.PeerKeyID2AccountID, peer.Key)
@@ -383,8 +577,15 @@ func (s *FileStore) DeleteAccount(account *Account) error {
 	}
 
 	delete(s.Accounts, account.Id)
+	delete(s.lazyAccounts, account.Id)
 
-	return s.persist(s.storeFile)
+	write, err := s.snapshot(s.storeFile)
+	s.mux.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return write()
 }
 
 // DeleteHashedPAT2TokenIDIndex removes an entry from the indexing map HashedPAT2TokenID
@@ -409,8 +610,8 @@ func (s *FileStore) DeleteTokenID2UserIDIndex(tokenID string) error {
 
 // GetAccountByPrivateDomain returns account by private domain
 func (s *FileStore) GetAccountByPrivateDomain(domain string) (*Account, error) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
+	s.mux.RLock()
+	defer s.mux.RUnlock()
 
 	accountID, ok := s.PrivateDomain2AccountID[strings.ToLower(domain)]
 	if !ok {
@@ -425,10 +626,28 @@ func (s *FileStore) GetAccountByPrivateDomain(domain string) (*Account, error) {
 	return account.Copy(), nil
 }
 
+// GetAccountByOrgKey returns the account owning the org key
+func (s *FileStore) GetAccountByOrgKey(orgKey string) (*Account, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	accountID, ok := s.OrgKey2AccountID[strings.ToUpper(orgKey)]
+	if !ok {
+		return nil, status.Errorf(status.NotFound, "account not found: provided org key doesn't exists")
+	}
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return account.Copy(), nil
+}
+
 // GetAccountBySetupKey returns account by setup key id
 func (s *FileStore) GetAccountBySetupKey(setupKey string) (*Account, error) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
+	s.mux.RLock()
+	defer s.mux.RUnlock()
 
 	accountID, ok := s.SetupKeyID2AccountID[strings.ToUpper(setupKey)]
 	if !ok {
@@ -445,8 +664,8 @@ func (s *FileStore) GetAccountBySetupKey(setupKey string) (*Account, error) {
 
 // GetTokenIDByHashedToken returns the id of a personal access token by its hashed secret
 func (s *FileStore) GetTokenIDByHashedToken(token string) (string, error) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
+	s.mux.RLock()
+	defer s.mux.RUnlock()
 
 	tokenID, ok := s.HashedPAT2TokenID[token]
 	if !ok {
@@ -458,8 +677,8 @@ func (s *FileStore) GetTokenIDByHashedToken(token string) (string, error) {
 
 // GetUserByTokenID returns a User object a tokenID belongs to
 func (s *FileStore) GetUserByTokenID(tokenID string) (*User, error) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
+	s.mux.RLock()
+	defer s.mux.RUnlock()
 
 	userID, ok := s.TokenID2UserID[tokenID]
 	if !ok {
@@ -479,31 +698,67 @@ func (s *FileStore) GetUserByTokenID(tokenID string) (*User, error) {
 	return account.Users[userID].Copy(), nil
 }
 
-// GetAllAccounts returns all accounts
+// GetAllAccounts returns all accounts, decoding the accounts which weren't accessed yet
 func (s *FileStore) GetAllAccounts() (all []*Account) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
-	for _, a := range s.Accounts {
-		all = append(all, a.Copy())
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	s.cacheMux.Lock()
+	accountIDs := make([]string, 0, len(s.Accounts)+len(s.lazyAccounts))
+	for accountID := range s.Accounts {
+		accountIDs = append(accountIDs, accountID)
+	}
+	for accountID := range s.lazyAccounts {
+		accountIDs = append(accountIDs, accountID)
+	}
+	s.cacheMux.Unlock()
+
+	for _, accountID := range accountIDs {
+		account, err := s.getAccount(accountID)
+		if err != nil {
+			log.Errorf("failed to load account %s: %v", accountID, err)
+			continue
+		}
+		all = append(all, account.Copy())
 	}
 
 	return all
 }
 
-// getAccount returns a reference to the Account. Should not return a copy.
+// getAccount returns a reference to the Account, decoding it on the first access. Should not return a copy.
+// Should be called holding the read or the write lock of FileStore.mux
 func (s *FileStore) getAccount(accountID string) (*Account, error) {
+	s.cacheMux.Lock()
 	account, ok := s.Accounts[accountID]
-	if !ok {
+	lazy := s.lazyAccounts[accountID]
+	s.cacheMux.Unlock()
+
+	if ok {
+		return account, nil
+	}
+	if lazy == nil {
 		return nil, status.Errorf(status.NotFound, "account not found")
 	}
 
+	account, err := lazy.load()
+	if err != nil {
+		return nil, status.Errorf(status.Internal, "failed to load account %s: %v", accountID, err)
+	}
+
+	s.cacheMux.Lock()
+	defer s.cacheMux.Unlock()
+	if s.lazyAccounts[accountID] == lazy {
+		s.Accounts[accountID] = account
+		delete(s.lazyAccounts, accountID)
+	}
+
 	return account, nil
 }
 
 // GetAccount returns an account for ID
 func (s *FileStore) GetAccount(accountID string) (*Account, error) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
+	s.mux.RLock()
+	defer s.mux.RUnlock()
 
 	account, err := s.getAccount(accountID)
 	if err != nil {
@@ -515,8 +770,8 @@ func (s *FileStore) GetAccount(accountID string) (*Account, error) {
 
 // GetAccountByUser returns a user account
 func (s *FileStore) GetAccountByUser(userID string) (*Account, error) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
+	s.mux.RLock()
+	defer s.mux.RUnlock()
 
 	accountID, ok := s.UserID2AccountID[userID]
 	if !ok {
@@ -533,76 +788,114 @@ func (s *FileStore) GetAccountByUser(userID string) (*Account, error) {
 
 // GetAccountByPeerID returns an account for a given peer ID
 func (s *FileStore) GetAccountByPeerID(peerID string) (*Account, error) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
+	s.mux.RLock()
 
 	accountID, ok := s.PeerID2AccountID[peerID]
 	if !ok {
+		s.mux.RUnlock()
 		return nil, status.Errorf(status.NotFound, "provided peer ID doesn't exists %s", peerID)
 	}
 
 	account, err := s.getAccount(accountID)
 	if err != nil {
+		s.mux.RUnlock()
 		return nil, err
 	}
 
 	// this protection is needed because when we delete a peer, we don't really remove index peerID -> accountID.
 	// check Account.Peers for a match
-	if _, ok := account.Peers[peerID]; !ok {
-		delete(s.PeerID2AccountID, peerID)
-		log.Warnf("removed stale peerID %s to accountID %s index", peerID, accountID)
-		return nil, status.Errorf(status.NotFound, "provided peer doesn't exists %s", peerID)
+	if _, ok := account.Peers[peerID]; ok {
+		defer s.mux.RUnlock()
+		return account.Copy(), nil
 	}
+	s.mux.RUnlock()
 
-	return account.Copy(), nil
+	s.removeStaleIndex(s.PeerID2AccountID, peerID, accountID, func(account *Account) bool {
+		_, ok := account.Peers[peerID]
+		return !ok
+	})
+	log.Warnf("removed stale peerID %s to accountID %s index", peerID, accountID)
+
+	return nil, status.Errorf(status.NotFound, "provided peer doesn't exists %s", peerID)
 }
 
 // GetAccountByPeerPubKey returns an account for a given peer WireGuard public key
 func (s *FileStore) GetAccountByPeerPubKey(peerKey string) (*Account, error) {
-	s.mux.Lock()
-	defer s.mux.Unlock()
+	s.mux.RLock()
 
 	accountID, ok := s.PeerKeyID2AccountID[peerKey]
 	if !ok {
+		s.mux.RUnlock()
 		return nil, status.Errorf(status.NotFound, "provided peer key doesn't exists %s", peerKey)
 	}
 
 	account, err := s.getAccount(accountID)
 	if err != nil {
+		s.mux.RUnlock()
 		return nil, err
 	}
 
 	// this protection is needed because when we delete a peer, we don't really remove index peerKey -> accountID.
 	// check Account.Peers for a match
-	stale := true
 	for _, peer := range account.Peers {
 		if peer.Key == peerKey {
-			stale = false
-			break
+			defer s.mux.RUnlock()
+			return account.Copy(), nil
 		}
 	}
-	if stale {
-		delete(s.PeerKeyID2AccountID, peerKey)
-		log.Warnf("removed stale peerKey %s to accountID %s index", peerKey, accountID)
-		return nil, status.Errorf(status.NotFound, "provided peer doesn't exists %s", peerKey)
+	s.mux.RUnlock()
+
+	s.removeStaleIndex(s.PeerKeyID2AccountID, peerKey, accountID, func(account *Account) bool {
+		for _, peer := range account.Peers {
+			if peer.Key == peerKey {
+				return false
+			}
+		}
+		return true
+	})
+	log.Warnf("removed stale peerKey %s to accountID %s index", peerKey, accountID)
+
+	return nil, status.Errorf(status.NotFound, "provided peer doesn't exists %s", peerKey)
+}
+
+// removeStaleIndex removes an index entry found stale while holding the read lock.
+// The staleness is checked once more under the write lock because the account might have been
+// updated between releasing the read lock and acquiring the write lock
+func (s *FileStore) removeStaleIndex(index map[string]string, key, accountID string, isStale func(account *Account) bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if index[key] != accountID {
+		return
 	}
 
-	return account.Copy(), nil
+	account, err := s.getAccount(accountID)
+	if err != nil || isStale(account) {
+		delete(index, key)
+	}
 }
 
 // GetInstallationID returns the installation ID from the store
 func (s *FileStore) GetInstallationID() string {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
 	return s.InstallationID
 }
 
 // SaveInstallationID saves the installation ID
 func (s *FileStore) SaveInstallationID(ID string) error {
 	s.mux.Lock()
-	defer s.mux.Unlock()
 
 	s.InstallationID = ID
 
-	return s.persist(s.storeFile)
+	write, err := s.snapshot(s.storeFile)
+	s.mux.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return write()
 }
 
 // SavePeerStatus stores the PeerStatus in memory. It doesn't attempt to persist data to speed up things.
@@ -626,6 +919,27 @@ func (s *FileStore) SavePeerStatus(accountID, peerID string, peerStatus nbpeer.P
 	return nil
 }
 
+// SavePeerLocation stores the peer's location in memory. It doesn't attempt to persist data to speed up things.
+// Location will be saved eventually when some other changes occur.
+func (s *FileStore) SavePeerLocation(accountID string, peerWithLocation *nbpeer.Peer) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	account, err := s.getAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	peer := account.Peers[peerWithLocation.ID]
+	if peer == nil {
+		return status.Errorf(status.NotFound, "peer %s not found", peerWithLocation.ID)
+	}
+
+	peer.Location = peerWithLocation.Location
+
+	return nil
+}
+
 // SaveUserLastLogin stores the last login time for a user in memory. It doesn't attempt to persist data to speed up things.
 func (s *FileStore) SaveUserLastLogin(accountID, userID string, lastLogin time.Time) error {
 	s.mux.Lock()
@@ -2,8 +2,11 @@ package server
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"net"
+	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -135,7 +138,7 @@ func TestDeleteAccount(t *testing.T) {
 		t.Fatal(err)
 	}
 	var account *Account
-	for _, a := range store.Accounts {
+	for _, a := range store.GetAllAccounts() {
 		account = a
 		break
 	}
@@ -145,8 +148,8 @@ func TestDeleteAccount(t *testing.T) {
 	err = store.DeleteAccount(account)
 	require.NoError(t, err, "failed to delete account, error: %v", err)
 
-	_, ok := store.Accounts[account.Id]
-	require.False(t, ok, "failed to delete account")
+	_, err = store.GetAccount(account.Id)
+	require.Error(t, err, "failed to delete account")
 
 	for id := range account.Users {
 		_, ok := store.UserID2AccountID[id]
@@ -171,7 +174,7 @@ func TestDeleteAccount(t *testing.T) {
 		assert.False(t, ok, "failed to delete SetupKeyID2AccountID index")
 	}
 
-	_, ok = store.PrivateDomain2AccountID[account.Domain]
+	_, ok := store.PrivateDomain2AccountID[account.Domain]
 	assert.False(t, ok, "failed to delete PrivateDomain2AccountID index")
 
 }
@@ -232,8 +235,8 @@ func TestStore(t *testing.T) {
 		return
 	}
 
-	restoredAccount := restored.Accounts[account.Id]
-	if restoredAccount == nil {
+	restoredAccount, err := restored.getAccount(account.Id)
+	if err != nil || restoredAccount == nil {
 		t.Errorf("failed to restore a FileStore file - missing Account %s", account.Id)
 		return
 	}
@@ -292,7 +295,8 @@ func TestRestore(t *testing.T) {
 		return
 	}
 
-	account := store.Accounts["bf1c8084-ba50-4ce7-9439-34653001fc3b"]
+	account, err := store.getAccount("bf1c8084-ba50-4ce7-9439-34653001fc3b")
+	require.NoError(t, err)
 
 	require.NotNil(t, account, "failed to restore a FileStore file - missing account bf1c8084-ba50-4ce7-9439-34653001fc3b")
 
@@ -317,6 +321,63 @@ func TestRestore(t *testing.T) {
 	require.Len(t, store.TokenID2UserID, 1, "failed to restore a FileStore wrong TokenID2UserID mapping length")
 }
 
+func TestFileStore_LazyAccountLoad(t *testing.T) {
+	storeDir := t.TempDir()
+
+	err := util.CopyFileContents("testdata/store.json", filepath.Join(storeDir, "store.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileStore(storeDir, nil)
+	require.NoError(t, err)
+
+	accountID := "bf1c8084-ba50-4ce7-9439-34653001fc3b"
+	require.Empty(t, store.Accounts, "accounts should not be decoded when restoring the store")
+	require.Contains(t, store.lazyAccounts, accountID)
+	require.Equal(t, accountID, store.SetupKeyID2AccountID["A2C8E62B-38F5-4553-B31E-DD66C696CEBB"])
+
+	// a new account saved next to the one never accessed
+	newAccount := newAccountWithId("account_id_new", "user_id_new", "")
+	require.NoError(t, store.SaveAccount(newAccount))
+	require.Contains(t, store.lazyAccounts, accountID, "saving another account should not decode the account")
+
+	// concurrent loads of the same account get the same account
+	var wg sync.WaitGroup
+	loaded := make([]*Account, 10)
+	for i := range loaded {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store.mux.RLock()
+			defer store.mux.RUnlock()
+			loaded[i], _ = store.getAccount(accountID)
+		}(i)
+	}
+	wg.Wait()
+	for _, account := range loaded {
+		require.NotNil(t, account)
+		require.Same(t, loaded[0], account, "concurrent loads should decode the account once")
+	}
+	require.NotContains(t, store.lazyAccounts, accountID)
+	require.Same(t, loaded[0], store.Accounts[accountID])
+
+	account, err := store.GetAccountBySetupKey("A2C8E62B-38F5-4553-B31E-DD66C696CEBB")
+	require.NoError(t, err)
+	require.Equal(t, accountID, account.Id)
+
+	// the accounts never accessed are persisted as they were read
+	restored, err := NewFileStore(storeDir, nil)
+	require.NoError(t, err)
+	require.Len(t, restored.lazyAccounts, 2)
+	require.Len(t, restored.GetAllAccounts(), 2)
+	require.Empty(t, restored.lazyAccounts, "all accounts should be decoded when getting all of them")
+
+	restoredAccount, err := restored.GetAccountByUser("user_id_new")
+	require.NoError(t, err)
+	require.Equal(t, newAccount.Id, restoredAccount.Id)
+}
+
 // TODO: outdated, delete this
 func TestRestorePolicies_Migration(t *testing.T) {
 	storeDir := t.TempDir()
@@ -331,7 +392,8 @@ func TestRestorePolicies_Migration(t *testing.T) {
 		return
 	}
 
-	account := store.Accounts["bf1c8084-ba50-4ce7-9439-34653001fc3b"]
+	account, err := store.getAccount("bf1c8084-ba50-4ce7-9439-34653001fc3b")
+	require.NoError(t, err)
 	require.Len(t, account.Groups, 1, "failed to restore a FileStore file - missing Account Groups")
 	require.Len(t, account.Rules, 1, "failed to restore a FileStore file - missing Account Rules")
 	require.Len(t, account.Policies, 1, "failed to restore a FileStore file - missing Account Policies")
@@ -366,7 +428,8 @@ func TestRestoreGroups_Migration(t *testing.T) {
 	}
 
 	// create default group
-	account := store.Accounts["bf1c8084-ba50-4ce7-9439-34653001fc3b"]
+	account, err := store.getAccount("bf1c8084-ba50-4ce7-9439-34653001fc3b")
+	require.NoError(t, err)
 	account.Groups = map[string]*Group{
 		"cfefqs706sqkneg59g3g": {
 			ID:   "cfefqs706sqkneg59g3g",
@@ -380,7 +443,8 @@ func TestRestoreGroups_Migration(t *testing.T) {
 	if store, err = NewFileStore(storeDir, nil); err != nil {
 		return
 	}
-	account = store.Accounts["bf1c8084-ba50-4ce7-9439-34653001fc3b"]
+	account, err = store.getAccount("bf1c8084-ba50-4ce7-9439-34653001fc3b")
+	require.NoError(t, err)
 
 	require.Contains(t, account.Groups, "cfefqs706sqkneg59g3g", "failed to restore a FileStore file - missing Account Groups")
 	require.Equal(t, GroupIssuedAPI, account.Groups["cfefqs706sqkneg59g3g"].Issued, "default group should has API issued mark")
@@ -643,3 +707,55 @@ func newStore(t *testing.T) *FileStore {
 
 	return store
 }
+
+func TestRestore_MigratedPeerIDsPersisted(t *testing.T) {
+	storeDir := t.TempDir()
+
+	account := newAccountWithId("account_id", "user_id", "")
+	peerKey := "oMNaI8qWi0CyclSuwGR++SurxJyM3pQEiPEHwX8IREo="
+	account.Peers[peerKey] = &nbpeer.Peer{Key: peerKey, IP: net.IP{100, 64, 0, 2}, Meta: nbpeer.PeerSystemMeta{}}
+	allGroup, err := account.GetGroupAll()
+	require.NoError(t, err)
+	allGroup.Peers = append(allGroup.Peers, peerKey)
+
+	raw, err := json.Marshal(account)
+	require.NoError(t, err)
+	layout, err := json.Marshal(&storeFileLayout{Accounts: map[string]json.RawMessage{account.Id: raw}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(storeDir, "store.json"), layout, 0600))
+
+	store, err := NewFileStore(storeDir, nil)
+	require.NoError(t, err)
+	migrated, err := store.getAccount(account.Id)
+	require.NoError(t, err)
+	require.Len(t, migrated.Peers, 1)
+	require.NotContains(t, migrated.Peers, peerKey, "the peer should be indexed by a generated ID")
+
+	restored, err := NewFileStore(storeDir, nil)
+	require.NoError(t, err)
+	restoredAccount, err := restored.getAccount(account.Id)
+	require.NoError(t, err)
+	for id := range migrated.Peers {
+		assert.Contains(t, restoredAccount.Peers, id, "the migrated peer IDs should be kept across restarts")
+	}
+}
+
+func TestFileStore_SnapshotsWrittenOutOfOrder(t *testing.T) {
+	store := newStore(t)
+
+	store.mux.Lock()
+	store.InstallationID = "older"
+	writeOlder, err := store.snapshot(store.storeFile)
+	require.NoError(t, err)
+	store.InstallationID = "newer"
+	writeNewer, err := store.snapshot(store.storeFile)
+	require.NoError(t, err)
+	store.mux.Unlock()
+
+	require.NoError(t, writeNewer())
+	require.NoError(t, writeOlder())
+
+	restored, err := NewFileStore(filepath.Dir(store.storeFile), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "newer", restored.GetInstallationID(), "the older snapshot shouldn't overwrite the newer one")
+}
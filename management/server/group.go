@@ -35,6 +35,12 @@ type Group struct {
 	// Peers list of the group
 	Peers []string `gorm:"serializer:json"`
 
+	// DynamicRule derives the Peers of a dynamic group, it is nil for the groups managed manually
+	DynamicRule *DynamicGroupRule `gorm:"serializer:json"`
+
+	// TunnelSettings of the WireGuard tunnels of the peers of the group, nil when the group keeps the client defaults
+	TunnelSettings *GroupTunnelSettings `gorm:"serializer:json"`
+
 	IntegrationReference IntegrationReference `gorm:"embedded;embeddedPrefix:integration_ref_"`
 }
 
@@ -49,6 +55,8 @@ func (g *Group) Copy() *Group {
 		Name:                 g.Name,
 		Issued:               g.Issued,
 		Peers:                make([]string, len(g.Peers)),
+		DynamicRule:          g.DynamicRule.Copy(),
+		TunnelSettings:       g.TunnelSettings.Copy(),
 		IntegrationReference: g.IntegrationReference,
 	}
 	copy(group.Peers, g.Peers)
@@ -115,8 +123,11 @@ func (am *DefaultAccountManager) SaveGroup(accountID, userID string, newGroup *G
 	if err != nil {
 		return err
 	}
-	oldGroup, exists := account.Groups[newGroup.ID]
-	account.Groups[newGroup.ID] = newGroup
+
+	oldGroup, err := account.saveGroup(newGroup)
+	if err != nil {
+		return err
+	}
 
 	account.Network.IncSerial()
 	if err = am.Store.SaveAccount(account); err != nil {
@@ -127,9 +138,36 @@ func (am *DefaultAccountManager) SaveGroup(accountID, userID string, newGroup *G
 
 	// the following snippet tracks the activity and stores the group events in the event store.
 	// It has to happen after all the operations have been successfully performed.
+	am.storeGroupEvents(account, userID, oldGroup, newGroup)
+
+	return nil
+}
+
+// saveGroup validates the group and saves it in the account, it returns the replaced group, nil for a new group
+func (a *Account) saveGroup(newGroup *Group) (*Group, error) {
+	if err := validateDynamicGroup(newGroup); err != nil {
+		return nil, err
+	}
+	if err := validateGroupTunnelSettings(newGroup); err != nil {
+		return nil, err
+	}
+	if newGroup.IsDynamic() {
+		if err := a.computeDynamicGroupPeers(newGroup); err != nil {
+			return nil, err
+		}
+	}
+
+	oldGroup := a.Groups[newGroup.ID]
+	a.Groups[newGroup.ID] = newGroup
+	return oldGroup, nil
+}
+
+// storeGroupEvents stores the events of the group saved in place of the old group, nil for a new group
+func (am *DefaultAccountManager) storeGroupEvents(account *Account, userID string, oldGroup, newGroup *Group) {
+	accountID := account.Id
 	addedPeers := make([]string, 0)
 	removedPeers := make([]string, 0)
-	if exists {
+	if oldGroup != nil {
 		addedPeers = difference(newGroup.Peers, oldGroup.Peers)
 		removedPeers = difference(oldGroup.Peers, newGroup.Peers)
 	} else {
@@ -162,8 +200,6 @@ func (am *DefaultAccountManager) SaveGroup(accountID, userID string, newGroup *G
 				"peer_fqdn": peer.FQDN(am.GetDNSDomain()),
 			})
 	}
-
-	return nil
 }
 
 // difference returns the elements in `a` that aren't in `b`.
@@ -225,6 +261,14 @@ func (am *DefaultAccountManager) DeleteGroup(accountId, userId, groupID string)
 		}
 	}
 
+	for _, record := range account.DNSRecords {
+		for _, g := range record.Groups {
+			if g == groupID {
+				return &GroupLinkError{"DNS record", record.Name}
+			}
+		}
+	}
+
 	// check ACL links
 	for _, policy := range account.Policies {
 		for _, rule := range policy.Rules {
@@ -309,20 +353,8 @@ func (am *DefaultAccountManager) GroupAddPeer(accountID, groupID, peerID string)
 		return err
 	}
 
-	group, ok := account.Groups[groupID]
-	if !ok {
-		return status.Errorf(status.NotFound, "group with ID %s not found", groupID)
-	}
-
-	add := true
-	for _, itemID := range group.Peers {
-		if itemID == peerID {
-			add = false
-			break
-		}
-	}
-	if add {
-		group.Peers = append(group.Peers, peerID)
+	if err = account.addPeerToGroup(groupID, peerID); err != nil {
+		return err
 	}
 
 	account.Network.IncSerial()
@@ -345,18 +377,15 @@ func (am *DefaultAccountManager) GroupDeletePeer(accountID, groupID, peerID stri
 		return err
 	}
 
-	group, ok := account.Groups[groupID]
-	if !ok {
-		return status.Errorf(status.NotFound, "group with ID %s not found", groupID)
+	removed, err := account.removePeerFromGroup(groupID, peerID)
+	if err != nil {
+		return err
 	}
 
 	account.Network.IncSerial()
-	for i, itemID := range group.Peers {
-		if itemID == peerID {
-			group.Peers = append(group.Peers[:i], group.Peers[i+1:]...)
-			if err := am.Store.SaveAccount(account); err != nil {
-				return err
-			}
+	if removed {
+		if err := am.Store.SaveAccount(account); err != nil {
+			return err
 		}
 	}
 
@@ -364,3 +393,48 @@ func (am *DefaultAccountManager) GroupDeletePeer(accountID, groupID, peerID stri
 
 	return nil
 }
+
+// manualGroup returns the group of the account which peers are managed manually
+func (a *Account) manualGroup(groupID string) (*Group, error) {
+	group, ok := a.Groups[groupID]
+	if !ok {
+		return nil, status.Errorf(status.NotFound, "group with ID %s not found", groupID)
+	}
+
+	if group.IsDynamic() {
+		return nil, status.Errorf(status.InvalidArgument, "peers of the dynamic group %s are derived from its rule", group.Name)
+	}
+	return group, nil
+}
+
+// addPeerToGroup adds the peer to the group unless it is already a member
+func (a *Account) addPeerToGroup(groupID, peerID string) error {
+	group, err := a.manualGroup(groupID)
+	if err != nil {
+		return err
+	}
+
+	for _, itemID := range group.Peers {
+		if itemID == peerID {
+			return nil
+		}
+	}
+	group.Peers = append(group.Peers, peerID)
+	return nil
+}
+
+// removePeerFromGroup removes the peer from the group, it returns false when the peer wasn't a member
+func (a *Account) removePeerFromGroup(groupID, peerID string) (bool, error) {
+	group, err := a.manualGroup(groupID)
+	if err != nil {
+		return false, err
+	}
+
+	for i, itemID := range group.Peers {
+		if itemID == peerID {
+			group.Peers = append(group.Peers[:i], group.Peers[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
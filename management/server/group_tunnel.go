@@ -0,0 +1,97 @@
+package server
+
+import (
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// minTunnelMTU is the lowest MTU of a group, the minimum IPv4 datagram size every host must accept
+	minTunnelMTU = 576
+	// maxTunnelMTU is the highest MTU of a group, the size of the jumbo frames
+	maxTunnelMTU = 9000
+	// maxPersistentKeepalive is the highest keepalive interval in seconds WireGuard accepts
+	maxPersistentKeepalive = 65535
+)
+
+// GroupTunnelSettings tunes the WireGuard tunnels of the peers of a group, a zero value keeps the default of the client.
+//
+// A peer in several groups with settings gets the lowest of the values set by its groups for each setting: the lowest
+// MTU fits the paths of all the networks the groups describe and the shortest keepalive keeps the mappings of the most
+// aggressive NAT open. The groups don't take precedence over each other, so the order of the groups doesn't matter.
+type GroupTunnelSettings struct {
+	// MTU of the WireGuard interface of the peers of the group
+	MTU int
+	// PersistentKeepalive is the interval in seconds of the keepalives the other peers send to the peers of the group
+	PersistentKeepalive int
+}
+
+// Copy returns a copy of the settings, nil for nil settings
+func (s *GroupTunnelSettings) Copy() *GroupTunnelSettings {
+	if s == nil {
+		return nil
+	}
+	settings := *s
+	return &settings
+}
+
+// IsEmpty returns true when the settings don't change any of the defaults of the client
+func (s *GroupTunnelSettings) IsEmpty() bool {
+	return s == nil || (s.MTU == 0 && s.PersistentKeepalive == 0)
+}
+
+// merge sets the values of the settings the other settings lower, see GroupTunnelSettings for the precedence
+func (s *GroupTunnelSettings) merge(other *GroupTunnelSettings) {
+	s.MTU = lowestSet(s.MTU, other.MTU)
+	s.PersistentKeepalive = lowestSet(s.PersistentKeepalive, other.PersistentKeepalive)
+}
+
+// lowestSet returns the lowest of the values ignoring the unset ones
+func lowestSet(value, other int) int {
+	if value == 0 || (other != 0 && other < value) {
+		return other
+	}
+	return value
+}
+
+func validateGroupTunnelSettings(group *Group) error {
+	settings := group.TunnelSettings
+	if settings == nil {
+		return nil
+	}
+
+	if settings.MTU != 0 && (settings.MTU < minTunnelMTU || settings.MTU > maxTunnelMTU) {
+		return status.Errorf(status.InvalidArgument, "MTU of the group should be between %d and %d",
+			minTunnelMTU, maxTunnelMTU)
+	}
+	if settings.PersistentKeepalive < 0 || settings.PersistentKeepalive > maxPersistentKeepalive {
+		return status.Errorf(status.InvalidArgument, "persistent keepalive of the group should be between 1 and %d seconds",
+			maxPersistentKeepalive)
+	}
+	return nil
+}
+
+// getPeersTunnelSettings returns the tunnel settings of the peers resolved from their groups, the peers without
+// settings are left out
+func (a *Account) getPeersTunnelSettings(peers []*nbpeer.Peer) map[string]GroupTunnelSettings {
+	wanted := make(map[string]struct{}, len(peers))
+	for _, peer := range peers {
+		wanted[peer.ID] = struct{}{}
+	}
+
+	settings := make(map[string]GroupTunnelSettings)
+	for _, group := range a.Groups {
+		if group.TunnelSettings.IsEmpty() {
+			continue
+		}
+		for _, peerID := range group.Peers {
+			if _, ok := wanted[peerID]; !ok {
+				continue
+			}
+			peerSettings := settings[peerID]
+			peerSettings.merge(group.TunnelSettings)
+			settings[peerID] = peerSettings
+		}
+	}
+	return settings
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestAccount_getPeersTunnelSettings(t *testing.T) {
+	account := &Account{
+		Groups: map[string]*Group{
+			"GroupWAN": {
+				ID:             "GroupWAN",
+				Peers:          []string{"peerA", "peerB"},
+				TunnelSettings: &GroupTunnelSettings{MTU: 1380, PersistentKeepalive: 20},
+			},
+			"GroupLTE": {
+				ID:             "GroupLTE",
+				Peers:          []string{"peerB", "peerC"},
+				TunnelSettings: &GroupTunnelSettings{MTU: 1280},
+			},
+			"GroupNAT": {
+				ID:             "GroupNAT",
+				Peers:          []string{"peerB"},
+				TunnelSettings: &GroupTunnelSettings{PersistentKeepalive: 10},
+			},
+			"GroupAll": {
+				ID:    "GroupAll",
+				Peers: []string{"peerA", "peerB", "peerC", "peerD"},
+			},
+		},
+	}
+
+	peers := []*nbpeer.Peer{{ID: "peerA"}, {ID: "peerB"}, {ID: "peerC"}, {ID: "peerD"}}
+	settings := account.getPeersTunnelSettings(peers)
+
+	assert.Equal(t, map[string]GroupTunnelSettings{
+		"peerA": {MTU: 1380, PersistentKeepalive: 20},
+		"peerB": {MTU: 1280, PersistentKeepalive: 10},
+		"peerC": {MTU: 1280},
+	}, settings, "the lowest value set by the groups of a peer should win and the peers without settings should be left out")
+
+	settings = account.getPeersTunnelSettings(peers[:1])
+	assert.Len(t, settings, 1, "only the requested peers should be resolved")
+}
+
+func TestToSyncResponse_TunnelSettings(t *testing.T) {
+	network := &Network{Net: net.IPNet{IP: net.IP{100, 64, 0, 0}, Mask: net.CIDRMask(16, 32)}}
+	peer := &nbpeer.Peer{ID: "peerA", Key: "peerA-key", IP: net.IP{100, 64, 0, 1}}
+	remotePeer := &nbpeer.Peer{ID: "peerB", Key: "peerB-key", IP: net.IP{100, 64, 0, 2}}
+	offlinePeer := &nbpeer.Peer{ID: "peerC", Key: "peerC-key", IP: net.IP{100, 64, 0, 3}}
+
+	networkMap := &NetworkMap{
+		Network:      network,
+		Peers:        []*nbpeer.Peer{remotePeer},
+		OfflinePeers: []*nbpeer.Peer{offlinePeer},
+		TunnelSettings: map[string]GroupTunnelSettings{
+			"peerA": {MTU: 1380},
+			"peerB": {PersistentKeepalive: 15},
+		},
+	}
+
+	response := toSyncResponse(nil, peer, nil, networkMap, "netbird.cloud")
+	assert.Equal(t, int32(1380), response.GetPeerConfig().GetMtu())
+	require.Len(t, response.GetNetworkMap().GetRemotePeers(), 1)
+	assert.Equal(t, int32(15), response.GetNetworkMap().GetRemotePeers()[0].GetPersistentKeepalive())
+	require.Len(t, response.GetNetworkMap().GetOfflinePeers(), 1)
+	assert.Zero(t, response.GetNetworkMap().GetOfflinePeers()[0].GetPersistentKeepalive(),
+		"the peers without settings should keep the default keepalive")
+}
+
+func TestDefaultAccountManager_SaveGroupTunnelSettings(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	err = manager.SaveGroup(account.Id, userID, &Group{
+		ID:             "tunnel",
+		Name:           "Tunnel",
+		Issued:         GroupIssuedAPI,
+		TunnelSettings: &GroupTunnelSettings{MTU: 1380, PersistentKeepalive: 15},
+	})
+	require.NoError(t, err)
+
+	group, err := manager.GetGroup(account.Id, "tunnel")
+	require.NoError(t, err)
+	assert.Equal(t, &GroupTunnelSettings{MTU: 1380, PersistentKeepalive: 15}, group.TunnelSettings)
+
+	for _, settings := range []*GroupTunnelSettings{
+		{MTU: 500},
+		{MTU: 9001},
+		{PersistentKeepalive: -1},
+		{PersistentKeepalive: 65536},
+	} {
+		err = manager.SaveGroup(account.Id, userID, &Group{
+			ID:             "invalid",
+			Name:           "Invalid",
+			Issued:         GroupIssuedAPI,
+			TunnelSettings: settings,
+		})
+		assertStatusType(t, err, status.InvalidArgument)
+	}
+}
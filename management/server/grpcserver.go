@@ -2,7 +2,12 @@ package server
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/netip"
 	"strings"
 	"time"
 
@@ -12,20 +17,37 @@ import (
 	log "github.com/sirupsen/logrus"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	gPeer "google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"github.com/netbirdio/netbird/encryption"
 	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/management/server/activity"
 	"github.com/netbirdio/netbird/management/server/jwtclaims"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 	internalStatus "github.com/netbirdio/netbird/management/server/status"
 	"github.com/netbirdio/netbird/management/server/telemetry"
 )
 
+const (
+	// setupKeyCheckInterval is the interval a source IP can check a setup key at once it used the burst
+	setupKeyCheckInterval = 6 * time.Second
+	// setupKeyCheckBurst is the number of setup key checks a source IP can send at once
+	setupKeyCheckBurst = 5
+	// serverKeyRequestInterval is the interval a source IP can request the server key at once it used the burst
+	serverKeyRequestInterval = time.Second
+	// serverKeyRequestBurst is the number of server key requests a source IP can send at once
+	serverKeyRequestBurst = 20
+)
+
 // GRPCServer an instance of a Management gRPC API server
 type GRPCServer struct {
 	accountManager AccountManager
-	wgKey          wgtypes.Key
+	// serverKeys holds the rotated WireGuard key of the server the messages are encrypted with
+	serverKeys *serverKeys
+	// networkMapSigningKey is the long-term key the network maps are signed with
+	networkMapSigningKey ed25519.PrivateKey
 	proto.UnimplementedManagementServiceServer
 	peersUpdateManager     *PeersUpdateManager
 	config                 *Config
@@ -34,11 +56,22 @@ type GRPCServer struct {
 	jwtClaimsExtractor     *jwtclaims.ClaimsExtractor
 	appMetrics             telemetry.AppMetrics
 	ephemeralManager       *EphemeralManager
+	// setupKeyCheckLimiter limits the unauthenticated setup key checks to prevent the setup key enumeration
+	setupKeyCheckLimiter *keyedRateLimiter
+	// serverKeyLimiter limits the unauthenticated server key requests
+	serverKeyLimiter *keyedRateLimiter
+	// syncStreams ends the Sync streams with a reconnection hint on shutdown
+	syncStreams syncStreams
 }
 
 // NewServer creates a new Management server
 func NewServer(config *Config, accountManager AccountManager, peersUpdateManager *PeersUpdateManager, turnCredentialsManager TURNCredentialsManager, appMetrics telemetry.AppMetrics, ephemeralManager *EphemeralManager) (*GRPCServer, error) {
-	key, err := wgtypes.GeneratePrivateKey()
+	keys, err := newServerKeys(config.ServerKeyLifetime.Duration, appMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	networkMapSigningKey, err := loadNetworkMapSigningKey(config.Datadir)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +113,8 @@ func NewServer(config *Config, accountManager AccountManager, peersUpdateManager
 	)
 
 	return &GRPCServer{
-		wgKey: key,
+		serverKeys:           keys,
+		networkMapSigningKey: networkMapSigningKey,
 		// peerKey -> event channel
 		peersUpdateManager:     peersUpdateManager,
 		accountManager:         accountManager,
@@ -90,22 +124,36 @@ func NewServer(config *Config, accountManager AccountManager, peersUpdateManager
 		jwtClaimsExtractor:     jwtClaimsExtractor,
 		appMetrics:             appMetrics,
 		ephemeralManager:       ephemeralManager,
+		setupKeyCheckLimiter:   newKeyedRateLimiter(setupKeyCheckInterval, setupKeyCheckBurst),
+		serverKeyLimiter:       newKeyedRateLimiter(serverKeyRequestInterval, serverKeyRequestBurst),
 	}, nil
 }
 
+// GetServerKey returns the current public key of the server and its expiration time.
+// The clients can cache the key until it expires, the key is rotated on the first request after that.
 func (s *GRPCServer) GetServerKey(ctx context.Context, req *proto.Empty) (*proto.ServerKeyResponse, error) {
-	// todo introduce something more meaningful with the key expiration/rotation
 	if s.appMetrics != nil {
 		s.appMetrics.GRPCMetrics().CountGetKeyRequest()
 	}
-	now := time.Now().Add(24 * time.Hour)
-	secs := int64(now.Second())
-	nanos := int32(now.Nanosecond())
-	expiresAt := &timestamp.Timestamp{Seconds: secs, Nanos: nanos}
 
+	sourceIP := getConnectionIP(ctx)
+	if !s.serverKeyLimiter.allow(sourceIP.String()) {
+		log.Warnf("too many server key requests from %s", sourceIP)
+		return nil, status.Error(codes.ResourceExhausted, "too many server key requests, try again later")
+	}
+
+	key, expiresAt, err := s.serverKeys.get()
+	if err != nil {
+		log.Errorf("failed to rotate the server key: %v", err)
+		return nil, status.Error(codes.Internal, "failed to get the server key")
+	}
+
+	now := time.Now()
 	return &proto.ServerKeyResponse{
-		Key:       s.wgKey.PublicKey().String(),
-		ExpiresAt: expiresAt,
+		Key:                  key.PublicKey().String(),
+		ExpiresAt:            &timestamp.Timestamp{Seconds: expiresAt.Unix(), Nanos: int32(expiresAt.Nanosecond())},
+		NetworkMapSigningKey: s.networkMapVerifyKey(),
+		ServerTime:           &timestamp.Timestamp{Seconds: now.Unix(), Nanos: int32(now.Nanosecond())},
 	}, nil
 }
 
@@ -116,6 +164,39 @@ func getRealIP(ctx context.Context) string {
 	return ""
 }
 
+// getRemoteAddr returns the address the request was sent from, the address reported by a trusted reverse proxy
+// is preferred over the address of the gRPC peer
+func getRemoteAddr(ctx context.Context) string {
+	if ip, ok := realip.FromContext(ctx); ok && ip.IsValid() {
+		return ip.Unmap().String()
+	}
+
+	if p, ok := gPeer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// getConnectionIP returns the source IP of the request. The address reported by a trusted reverse proxy is preferred
+// over the address of the gRPC peer. Note that it is the source of the TCP connection to the management service and
+// not necessarily the source of the peer's WireGuard UDP traffic.
+func getConnectionIP(ctx context.Context) net.IP {
+	if ip, ok := realip.FromContext(ctx); ok && ip.IsValid() {
+		return ip.Unmap().AsSlice()
+	}
+
+	p, ok := gPeer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
 // Sync validates the existence of a connecting peer, sends an initial state (all available for the connecting peers) and
 // notifies the connected peer of any updates (e.g. new peers under the same account)
 func (s *GRPCServer) Sync(req *proto.EncryptedMessage, srv proto.ManagementService_SyncServer) error {
@@ -126,28 +207,44 @@ func (s *GRPCServer) Sync(req *proto.EncryptedMessage, srv proto.ManagementServi
 	realIP := getRealIP(srv.Context())
 	log.Debugf("Sync request from peer [%s] [%s]", req.WgPubKey, realIP)
 
+	if !s.syncStreams.add() {
+		return syncReconnectError()
+	}
+	defer s.syncStreams.done()
+
 	syncReq := &proto.SyncRequest{}
-	peerKey, err := s.parseRequest(req, syncReq)
+	// the stream keeps using the server key of the request, the client decrypts the updates with it
+	peerKey, serverKey, err := s.parseRequest(req, syncReq)
 	if err != nil {
 		return err
 	}
 
-	peer, netMap, err := s.accountManager.SyncPeer(PeerSync{WireGuardPubKey: peerKey.String()})
+	peer, netMap, err := s.accountManager.SyncPeer(PeerSync{
+		WireGuardPubKey:    peerKey.String(),
+		AdvertisedNetworks: toAdvertisedNetworks(peerKey.String(), syncReq.GetAdvertisedNetworks()),
+		NATType:            toNATType(peerKey.String(), syncReq.GetNatType()),
+		MappedAddress:      toMappedAddress(syncReq.GetNatMappedAddress()),
+	})
 	if err != nil {
 		return mapError(err)
 	}
 
-	err = s.sendInitialSync(peerKey, peer, netMap, srv)
+	err = s.sendInitialSync(peerKey, serverKey, peer, netMap, srv)
 	if err != nil {
 		log.Debugf("error while sending initial sync for %s: %v", peerKey.String(), err)
 		return err
 	}
 
-	updates := s.peersUpdateManager.CreateChannel(peer.ID)
+	updates := s.peersUpdateManager.CreateSessionChannel(SyncSession{
+		PeerID:         peer.ID,
+		PeerKey:        peerKey.String(),
+		ConnectedSince: time.Now().UTC(),
+		RemoteAddr:     getRemoteAddr(srv.Context()),
+	})
 
 	s.ephemeralManager.OnPeerConnected(peer)
 
-	err = s.accountManager.MarkPeerConnected(peerKey.String(), true)
+	err = s.accountManager.MarkPeerConnected(peerKey.String(), true, getConnectionIP(srv.Context()))
 	if err != nil {
 		log.Warnf("failed marking peer as connected %s %v", peerKey, err)
 	}
@@ -160,6 +257,8 @@ func (s *GRPCServer) Sync(req *proto.EncryptedMessage, srv proto.ManagementServi
 		s.appMetrics.GRPCMetrics().CountSyncRequestDuration(time.Since(reqStart))
 	}
 
+	closing := s.syncStreams.closingCh()
+
 	// keep a connection to the peer and send updates when available
 	for {
 		select {
@@ -177,14 +276,20 @@ func (s *GRPCServer) Sync(req *proto.EncryptedMessage, srv proto.ManagementServi
 			}
 			log.Debugf("received an update for peer %s", peerKey.String())
 
-			encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, update.Update)
+			if err := s.signNetworkMap(peerKey, update.Update); err != nil {
+				log.Errorf("failed signing the network map of peer %s: %v", peerKey.String(), err)
+				s.cancelPeerRoutines(peer)
+				return status.Errorf(codes.Internal, "failed processing update message")
+			}
+
+			encryptedResp, err := encryption.EncryptMessage(peerKey, serverKey, update.Update)
 			if err != nil {
 				s.cancelPeerRoutines(peer)
 				return status.Errorf(codes.Internal, "failed processing update message")
 			}
 
 			err = srv.SendMsg(&proto.EncryptedMessage{
-				WgPubKey: s.wgKey.PublicKey().String(),
+				WgPubKey: serverKey.PublicKey().String(),
 				Body:     encryptedResp,
 			})
 			if err != nil {
@@ -198,6 +303,11 @@ func (s *GRPCServer) Sync(req *proto.EncryptedMessage, srv proto.ManagementServi
 			log.Debugf("stream of peer %s has been closed", peerKey.String())
 			s.cancelPeerRoutines(peer)
 			return srv.Context().Err()
+		// condition when the server shuts down
+		case <-closing:
+			log.Debugf("closing stream of peer %s on shutdown", peerKey.String())
+			s.cancelPeerRoutines(peer)
+			return syncReconnectError()
 		}
 	}
 }
@@ -205,31 +315,46 @@ func (s *GRPCServer) Sync(req *proto.EncryptedMessage, srv proto.ManagementServi
 func (s *GRPCServer) cancelPeerRoutines(peer *nbpeer.Peer) {
 	s.peersUpdateManager.CloseChannel(peer.ID)
 	s.turnCredentialsManager.CancelRefresh(peer.ID)
-	_ = s.accountManager.MarkPeerConnected(peer.Key, false)
+	_ = s.accountManager.MarkPeerConnected(peer.Key, false, nil)
 	s.ephemeralManager.OnPeerDisconnected(peer)
 }
 
 func (s *GRPCServer) validateToken(jwtToken string) (string, error) {
+	_, userID, err := s.accountFromToken(jwtToken)
+	return userID, err
+}
+
+// accountFromToken validates the JWT and returns the account and the ID of the user it was issued to
+func (s *GRPCServer) accountFromToken(jwtToken string) (*Account, string, error) {
 	if s.jwtValidator == nil {
-		return "", status.Error(codes.Internal, "no jwt validator set")
+		return nil, "", status.Error(codes.Internal, "no jwt validator set")
+	}
+
+	// the personal access tokens authenticate the REST API calls only, never the peers
+	if strings.HasPrefix(jwtToken, PATPrefix) {
+		return nil, "", status.Error(codes.PermissionDenied, "personal access tokens can't be used with the management gRPC API")
 	}
 
 	token, err := s.jwtValidator.ValidateAndParse(jwtToken)
 	if err != nil {
-		return "", status.Errorf(codes.InvalidArgument, "invalid jwt token, err: %v", err)
+		return nil, "", status.Errorf(codes.InvalidArgument, "invalid jwt token, err: %v", err)
 	}
 	claims := s.jwtClaimsExtractor.FromToken(token)
 	// we need to call this method because if user is new, we will automatically add it to existing or create a new account
-	_, _, err = s.accountManager.GetAccountFromToken(claims)
+	account, _, err := s.accountManager.GetAccountFromToken(claims)
 	if err != nil {
-		return "", status.Errorf(codes.Internal, "unable to fetch account with claims, err: %v", err)
+		return nil, "", status.Errorf(codes.Internal, "unable to fetch account with claims, err: %v", err)
+	}
+
+	if err := checkUserDomainAllowed(account, claims); err != nil {
+		return nil, "", mapError(err)
 	}
 
 	if err := s.accountManager.CheckUserAccessByJWTGroups(claims); err != nil {
-		return "", status.Errorf(codes.PermissionDenied, err.Error())
+		return nil, "", status.Errorf(codes.PermissionDenied, err.Error())
 	}
 
-	return claims.UserId, nil
+	return account, claims.UserId, nil
 }
 
 // maps internal internalStatus.Error to gRPC status.Error
@@ -246,6 +371,12 @@ func mapError(err error) error {
 			return status.Errorf(codes.FailedPrecondition, e.Message)
 		case internalStatus.NotFound:
 			return status.Errorf(codes.NotFound, e.Message)
+		case internalStatus.InvalidArgument:
+			return status.Errorf(codes.InvalidArgument, e.Message)
+		case internalStatus.AlreadyExists:
+			return status.Errorf(codes.AlreadyExists, e.Message)
+		case internalStatus.TooManyRequests:
+			return status.Errorf(codes.ResourceExhausted, e.Message)
 		default:
 		}
 	}
@@ -266,19 +397,43 @@ func extractPeerMeta(loginReq *proto.LoginRequest) nbpeer.PeerSystemMeta {
 	}
 }
 
-func (s *GRPCServer) parseRequest(req *proto.EncryptedMessage, parsed pb.Message) (wgtypes.Key, error) {
+// parseRequest decrypts the request and returns the key of the peer and the server key the request was encrypted with.
+// The response has to be encrypted with the same server key.
+func (s *GRPCServer) parseRequest(req *proto.EncryptedMessage, parsed pb.Message) (wgtypes.Key, wgtypes.Key, error) {
 	peerKey, err := wgtypes.ParseKey(req.GetWgPubKey())
 	if err != nil {
 		log.Warnf("error while parsing peer's WireGuard public key %s.", req.WgPubKey)
-		return wgtypes.Key{}, status.Errorf(codes.InvalidArgument, "provided wgPubKey %s is invalid", req.WgPubKey)
+		return wgtypes.Key{}, wgtypes.Key{}, status.Errorf(codes.InvalidArgument, "provided wgPubKey %s is invalid", req.WgPubKey)
+	}
+
+	serverKey, err := s.decryptRequest(peerKey, req.Body, parsed)
+	if err != nil {
+		return wgtypes.Key{}, wgtypes.Key{}, status.Errorf(codes.InvalidArgument, "invalid request message")
 	}
 
-	err = encryption.DecryptMessage(peerKey, s.wgKey, req.Body, parsed)
+	return peerKey, serverKey, nil
+}
+
+// decryptRequest decrypts the request body with the current server key or, during the overlap after a rotation,
+// with the previous one and returns the server key that decrypted it
+func (s *GRPCServer) decryptRequest(peerKey wgtypes.Key, body []byte, parsed pb.Message) (wgtypes.Key, error) {
+	keys, err := s.serverKeys.candidates()
 	if err != nil {
-		return wgtypes.Key{}, status.Errorf(codes.InvalidArgument, "invalid request message")
+		return wgtypes.Key{}, err
 	}
 
-	return peerKey, nil
+	for i, key := range keys {
+		err = encryption.DecryptMessage(peerKey, key, body, parsed)
+		if err != nil {
+			continue
+		}
+		if i > 0 && s.appMetrics != nil {
+			s.appMetrics.GRPCMetrics().CountPreviousServerKeyRequest()
+		}
+		return key, nil
+	}
+
+	return wgtypes.Key{}, err
 }
 
 // Login endpoint first checks whether peer is registered under any account
@@ -299,7 +454,7 @@ func (s *GRPCServer) Login(ctx context.Context, req *proto.EncryptedMessage) (*p
 	log.Debugf("Login request from peer [%s] [%s]", req.WgPubKey, realIP)
 
 	loginReq := &proto.LoginRequest{}
-	peerKey, err := s.parseRequest(req, loginReq)
+	peerKey, serverKey, err := s.parseRequest(req, loginReq)
 	if err != nil {
 		return nil, err
 	}
@@ -327,11 +482,15 @@ func (s *GRPCServer) Login(ctx context.Context, req *proto.EncryptedMessage) (*p
 	}
 
 	peer, netMap, err := s.accountManager.LoginPeer(PeerLogin{
-		WireGuardPubKey: peerKey.String(),
-		SSHKey:          string(sshKey),
-		Meta:            extractPeerMeta(loginReq),
-		UserID:          userID,
-		SetupKey:        loginReq.GetSetupKey(),
+		WireGuardPubKey:    peerKey.String(),
+		SSHKey:             string(sshKey),
+		Meta:               extractPeerMeta(loginReq),
+		UserID:             userID,
+		SetupKey:           loginReq.GetSetupKey(),
+		ConnectionIP:       getConnectionIP(ctx),
+		MappedAddress:      toMappedAddress(loginReq.GetNatMappedAddress()),
+		AdvertisedNetworks: toAdvertisedNetworks(peerKey.String(), loginReq.GetAdvertisedNetworks()),
+		NATType:            toNATType(peerKey.String(), loginReq.GetMeta().GetNatType()),
 	})
 
 	if err != nil {
@@ -345,43 +504,58 @@ func (s *GRPCServer) Login(ctx context.Context, req *proto.EncryptedMessage) (*p
 	}
 
 	// if peer has reached this point then it has logged in
+	peerConfig := toPeerConfig(peer, netMap.Network, s.accountManager.GetDNSDomain())
+	peerConfig.Mtu = int32(netMap.TunnelSettings[peer.ID].MTU)
 	loginResp := &proto.LoginResponse{
-		WiretrusteeConfig: toWiretrusteeConfig(s.config, nil),
-		PeerConfig:        toPeerConfig(peer, netMap.Network, s.accountManager.GetDNSDomain()),
+		WiretrusteeConfig: toWiretrusteeConfig(s.config, nil, netMap.Signal, netMap.ConnectionTimeout),
+		PeerConfig:        peerConfig,
 	}
-	encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, loginResp)
+	encryptedResp, err := encryption.EncryptMessage(peerKey, serverKey, loginResp)
 	if err != nil {
 		log.Warnf("failed encrypting peer %s message", peer.ID)
 		return nil, status.Errorf(codes.Internal, "failed logging in peer")
 	}
 
 	return &proto.EncryptedMessage{
-		WgPubKey: s.wgKey.PublicKey().String(),
+		WgPubKey: serverKey.PublicKey().String(),
 		Body:     encryptedResp,
 	}, nil
 }
 
 func ToResponseProto(configProto Protocol) proto.HostConfig_Protocol {
+	protocol, err := toResponseProto(configProto)
+	if err != nil {
+		panic(err)
+	}
+	return protocol
+}
+
+func toResponseProto(configProto Protocol) (proto.HostConfig_Protocol, error) {
 	switch configProto {
 	case UDP:
-		return proto.HostConfig_UDP
+		return proto.HostConfig_UDP, nil
 	case DTLS:
-		return proto.HostConfig_DTLS
+		return proto.HostConfig_DTLS, nil
 	case HTTP:
-		return proto.HostConfig_HTTP
+		return proto.HostConfig_HTTP, nil
 	case HTTPS:
-		return proto.HostConfig_HTTPS
+		return proto.HostConfig_HTTPS, nil
 	case TCP:
-		return proto.HostConfig_TCP
+		return proto.HostConfig_TCP, nil
 	default:
-		panic(fmt.Errorf("unexpected config protocol type %v", configProto))
+		return 0, fmt.Errorf("unexpected config protocol type %v", configProto)
 	}
 }
 
-func toWiretrusteeConfig(config *Config, turnCredentials *TURNCredentials) *proto.WiretrusteeConfig {
+// toWiretrusteeConfig returns the global config of the peers. The signal server of the config is replaced by
+// the given one when it is not nil, a zero connection timeout lets the peers use the default of their client.
+func toWiretrusteeConfig(config *Config, turnCredentials *TURNCredentials, signal *Host, connectionTimeout time.Duration) *proto.WiretrusteeConfig {
 	if config == nil {
 		return nil
 	}
+	if signal == nil {
+		signal = config.Signal
+	}
 	var stuns []*proto.HostConfig
 	for _, stun := range config.Stuns {
 		stuns = append(stuns, &proto.HostConfig{
@@ -407,6 +581,8 @@ func toWiretrusteeConfig(config *Config, turnCredentials *TURNCredentials) *prot
 			},
 			User:     username,
 			Password: password,
+			Priority: turn.Priority,
+			Weight:   turn.Weight,
 		})
 	}
 
@@ -414,48 +590,132 @@ func toWiretrusteeConfig(config *Config, turnCredentials *TURNCredentials) *prot
 		Stuns: stuns,
 		Turns: turns,
 		Signal: &proto.HostConfig{
-			Uri:      config.Signal.URI,
-			Protocol: ToResponseProto(config.Signal.Proto),
+			Uri:      signal.URI,
+			Protocol: ToResponseProto(signal.Proto),
 		},
+		ConnectionTimeout: connectionTimeout.Milliseconds(),
 	}
 }
 
 func toPeerConfig(peer *nbpeer.Peer, network *Network, dnsName string) *proto.PeerConfig {
 	netmask, _ := network.Net.Mask.Size()
 	fqdn := peer.FQDN(dnsName)
-	return &proto.PeerConfig{
+	peerConfig := &proto.PeerConfig{
 		Address:   fmt.Sprintf("%s/%d", peer.IP.String(), netmask), // take it from the network
 		SshConfig: &proto.SSHConfig{SshEnabled: peer.SSHEnabled},
 		Fqdn:      fqdn,
 	}
+	if peer.IPv6 != nil && network.NetV6.IP != nil {
+		netmaskV6, _ := network.NetV6.Mask.Size()
+		peerConfig.AddressV6 = fmt.Sprintf("%s/%d", peer.IPv6.String(), netmaskV6)
+	}
+	return peerConfig
 }
 
-func toRemotePeerConfig(peers []*nbpeer.Peer, dnsName string) []*proto.RemotePeerConfig {
+// toRemotePeerConfig returns the configs of the remote peers of the peer. The relay is forced for a remote peer when
+// either end forces it, so both ends gather the relay candidates only.
+func toRemotePeerConfig(peer *nbpeer.Peer, peers []*nbpeer.Peer, dnsName string) []*proto.RemotePeerConfig {
 	remotePeers := []*proto.RemotePeerConfig{}
 	for _, rPeer := range peers {
 		fqdn := rPeer.FQDN(dnsName)
-		remotePeers = append(remotePeers, &proto.RemotePeerConfig{
-			WgPubKey:   rPeer.Key,
-			AllowedIps: []string{fmt.Sprintf(AllowedIPsFormat, rPeer.IP)},
-			SshConfig:  &proto.SSHConfig{SshPubKey: []byte(rPeer.SSHKey)},
-			Fqdn:       fqdn,
-		})
+		remotePeer := &proto.RemotePeerConfig{
+			WgPubKey:      rPeer.Key,
+			AllowedIps:    []string{fmt.Sprintf(AllowedIPsFormat, rPeer.IP)},
+			SshConfig:     &proto.SSHConfig{SshPubKey: []byte(ValidSSHKey(rPeer))},
+			Fqdn:          fqdn,
+			ObservedIP:    toObservedIP(rPeer.Location.ConnectionIP),
+			MappedAddress: rPeer.Location.MappedAddress,
+			ForceRelay:    peer.ForceRelay || rPeer.ForceRelay,
+			NatType:       rPeer.Location.NATType,
+		}
+		if rPeer.IPv6 != nil {
+			remotePeer.AddressV6 = fmt.Sprintf(AllowedIPsV6Format, rPeer.IPv6)
+		}
+		remotePeers = append(remotePeers, remotePeer)
 	}
 	return remotePeers
 }
 
+// setPersistentKeepalives sets the keepalive intervals of the remote peer configs built from the peers in the same order
+// with toRemotePeerConfig
+func setPersistentKeepalives(remotePeers []*proto.RemotePeerConfig, peers []*nbpeer.Peer, settings map[string]GroupTunnelSettings) {
+	for i, rPeer := range peers {
+		remotePeers[i].PersistentKeepalive = int32(settings[rPeer.ID].PersistentKeepalive)
+	}
+}
+
+// ValidSSHKey returns the normalized SSH key of the peer, or an empty key if the stored one is invalid.
+// Keys are validated when they are stored, this guards the other peers against keys stored before the validation.
+func ValidSSHKey(peer *nbpeer.Peer) string {
+	if peer.SSHKey == "" {
+		return ""
+	}
+
+	key, err := normalizeSSHPublicKey(peer.SSHKey)
+	if err != nil {
+		log.Debugf("skipping invalid SSH key of peer %s: %v", peer.ID, err)
+		return ""
+	}
+	return key
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), it is not reachable from the Internet
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// toObservedIP returns the observed connection IP of a peer if it can be shared with other peers as an endpoint hint.
+// Only global unicast public addresses are exposed: private, loopback and link-local addresses are either the
+// address of a reverse proxy or meaningless to other peers, and would leak internal topology.
+func toObservedIP(ip net.IP) string {
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) {
+		return ""
+	}
+	return ip.String()
+}
+
+// toMappedAddress validates the address a peer reports to have mapped its WireGuard port to on its gateway.
+// Only public addresses with a port are kept, the same way as the observed IPs shared with the other peers.
+func toMappedAddress(address string) string {
+	if address == "" {
+		return ""
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || addrPort.Port() == 0 {
+		log.Debugf("ignoring invalid mapped address %q: %v", address, err)
+		return ""
+	}
+
+	addr := addrPort.Addr().Unmap()
+	if toObservedIP(addr.AsSlice()) == "" {
+		return ""
+	}
+	return netip.AddrPortFrom(addr, addrPort.Port()).String()
+}
+
 func toSyncResponse(config *Config, peer *nbpeer.Peer, turnCredentials *TURNCredentials, networkMap *NetworkMap, dnsName string) *proto.SyncResponse {
-	wtConfig := toWiretrusteeConfig(config, turnCredentials)
+	wtConfig := toWiretrusteeConfig(config, turnCredentials, networkMap.Signal, networkMap.ConnectionTimeout)
 
 	pConfig := toPeerConfig(peer, networkMap.Network, dnsName)
+	pConfig.Mtu = int32(networkMap.TunnelSettings[peer.ID].MTU)
 
-	remotePeers := toRemotePeerConfig(networkMap.Peers, dnsName)
+	remotePeers := toRemotePeerConfig(peer, networkMap.Peers, dnsName)
+	setPersistentKeepalives(remotePeers, networkMap.Peers, networkMap.TunnelSettings)
 
-	routesUpdate := toProtocolRoutes(networkMap.Routes)
+	// the overlapping routes are rejected when saved, only the ones saved before can be left, so they aren't worth
+	// a warning on every sync of every peer
+	for _, r := range networkMap.Routes {
+		if accountNet, ok := routeNetworkConflict(networkMap.Network, r.Network); ok {
+			log.Debugf("route %s network %s overlaps with the account network %s, the traffic of peer %s to the overlapping addresses may be blackholed",
+				r.ID, r.Network.String(), accountNet.String(), peer.ID)
+		}
+	}
+
+	routesUpdate := append(toProtocolRoutes(networkMap.Routes), toProtocolStaticRoutes(networkMap.StaticRoutes)...)
 
 	dnsUpdate := toProtocolDNSConfig(networkMap.DNSConfig)
 
-	offlinePeers := toRemotePeerConfig(networkMap.OfflinePeers, dnsName)
+	offlinePeers := toRemotePeerConfig(peer, networkMap.OfflinePeers, dnsName)
+	setPersistentKeepalives(offlinePeers, networkMap.OfflinePeers, networkMap.TunnelSettings)
 
 	firewallRules := toProtocolFirewallRules(networkMap.FirewallRules)
 
@@ -484,24 +744,29 @@ func (s *GRPCServer) IsHealthy(ctx context.Context, req *proto.Empty) (*proto.Em
 }
 
 // sendInitialSync sends initial proto.SyncResponse to the peer requesting synchronization
-func (s *GRPCServer) sendInitialSync(peerKey wgtypes.Key, peer *nbpeer.Peer, networkMap *NetworkMap, srv proto.ManagementService_SyncServer) error {
+func (s *GRPCServer) sendInitialSync(peerKey, serverKey wgtypes.Key, peer *nbpeer.Peer, networkMap *NetworkMap, srv proto.ManagementService_SyncServer) error {
 	// make secret time based TURN credentials optional
 	var turnCredentials *TURNCredentials
 	if s.config.TURNConfig.TimeBasedCredentials {
-		creds := s.turnCredentialsManager.GenerateCredentials()
+		creds := s.turnCredentialsManager.GetCredentials(peer.ID)
 		turnCredentials = &creds
 	} else {
 		turnCredentials = nil
 	}
 	plainResp := toSyncResponse(s.config, peer, turnCredentials, networkMap, s.accountManager.GetDNSDomain())
 
-	encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, plainResp)
+	if err := s.signNetworkMap(peerKey, plainResp); err != nil {
+		log.Errorf("failed signing the network map of peer %s: %v", peerKey.String(), err)
+		return status.Errorf(codes.Internal, "error handling request")
+	}
+
+	encryptedResp, err := encryption.EncryptMessage(peerKey, serverKey, plainResp)
 	if err != nil {
 		return status.Errorf(codes.Internal, "error handling request")
 	}
 
 	err = srv.Send(&proto.EncryptedMessage{
-		WgPubKey: s.wgKey.PublicKey().String(),
+		WgPubKey: serverKey.PublicKey().String(),
 		Body:     encryptedResp,
 	})
 
@@ -524,7 +789,7 @@ func (s *GRPCServer) GetDeviceAuthorizationFlow(ctx context.Context, req *proto.
 		return nil, status.Error(codes.InvalidArgument, errMSG)
 	}
 
-	err = encryption.DecryptMessage(peerKey, s.wgKey, req.Body, &proto.DeviceAuthorizationFlowRequest{})
+	serverKey, err := s.decryptRequest(peerKey, req.Body, &proto.DeviceAuthorizationFlowRequest{})
 	if err != nil {
 		errMSG := fmt.Sprintf("error while decrypting peer's message with Wireguard public key %s.", req.WgPubKey)
 		log.Warn(errMSG)
@@ -554,13 +819,13 @@ func (s *GRPCServer) GetDeviceAuthorizationFlow(ctx context.Context, req *proto.
 		},
 	}
 
-	encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, flowInfoResp)
+	encryptedResp, err := encryption.EncryptMessage(peerKey, serverKey, flowInfoResp)
 	if err != nil {
 		return nil, status.Error(codes.Internal, "failed to encrypt no device authorization flow information")
 	}
 
 	return &proto.EncryptedMessage{
-		WgPubKey: s.wgKey.PublicKey().String(),
+		WgPubKey: serverKey.PublicKey().String(),
 		Body:     encryptedResp,
 	}, nil
 }
@@ -576,7 +841,7 @@ func (s *GRPCServer) GetPKCEAuthorizationFlow(_ context.Context, req *proto.Encr
 		return nil, status.Error(codes.InvalidArgument, errMSG)
 	}
 
-	err = encryption.DecryptMessage(peerKey, s.wgKey, req.Body, &proto.PKCEAuthorizationFlowRequest{})
+	serverKey, err := s.decryptRequest(peerKey, req.Body, &proto.PKCEAuthorizationFlowRequest{})
 	if err != nil {
 		errMSG := fmt.Sprintf("error while decrypting peer's message with Wireguard public key %s.", req.WgPubKey)
 		log.Warn(errMSG)
@@ -600,13 +865,181 @@ func (s *GRPCServer) GetPKCEAuthorizationFlow(_ context.Context, req *proto.Encr
 		},
 	}
 
-	encryptedResp, err := encryption.EncryptMessage(peerKey, s.wgKey, flowInfoResp)
+	encryptedResp, err := encryption.EncryptMessage(peerKey, serverKey, flowInfoResp)
 	if err != nil {
 		return nil, status.Error(codes.Internal, "failed to encrypt no pkce authorization flow information")
 	}
 
 	return &proto.EncryptedMessage{
-		WgPubKey: s.wgKey.PublicKey().String(),
+		WgPubKey: serverKey.PublicKey().String(),
+		Body:     encryptedResp,
+	}, nil
+}
+
+// StreamEvents streams the activity events of the account of the user authenticated with the JWT from the request metadata.
+// The events are read from a subscription that is dropped when the consumer falls behind, so a slow consumer
+// never blocks the account changes.
+func (s *GRPCServer) StreamEvents(req *proto.StreamEventsRequest, srv proto.ManagementService_StreamEventsServer) error {
+	jwtToken, err := bearerTokenFromContext(srv.Context())
+	if err != nil {
+		return err
+	}
+
+	account, userID, err := s.accountFromToken(jwtToken)
+	if err != nil {
+		return err
+	}
+
+	sub, err := s.accountManager.SubscribeToEvents(account.Id, userID, req.GetResumeToken())
+	if err != nil {
+		return mapError(err)
+	}
+	defer sub.Close()
+
+	log.Debugf("user %s subscribed to the events of account %s", userID, account.Id)
+
+	for {
+		event, err := sub.Next(srv.Context())
+		if errors.Is(err, ErrEventSubscriptionDropped) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		if err != nil {
+			// happens when connection drops, e.g. consumer disconnects
+			log.Debugf("event stream of user %s has been closed", userID)
+			return srv.Context().Err()
+		}
+
+		accountEvent, err := toAccountEvent(event)
+		if err != nil {
+			log.Errorf("failed to convert event %d of account %s: %v", event.ID, account.Id, err)
+			continue
+		}
+
+		err = srv.Send(accountEvent)
+		if err != nil {
+			log.Debugf("failed sending event to user %s: %v", userID, err)
+			return err
+		}
+	}
+}
+
+// bearerTokenFromContext returns the token from the "authorization: Bearer <token>" metadata of the request
+func bearerTokenFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+
+	for _, value := range md.Get("authorization") {
+		authType, token, found := strings.Cut(value, " ")
+		if found && strings.EqualFold(authType, "bearer") && token != "" {
+			return token, nil
+		}
+	}
+
+	return "", status.Error(codes.Unauthenticated, "missing bearer token in the authorization metadata")
+}
+
+func toAccountEvent(event *activity.Event) (*proto.AccountEvent, error) {
+	var meta string
+	if len(event.Meta) > 0 {
+		metaBytes, err := json.Marshal(event.Meta)
+		if err != nil {
+			return nil, err
+		}
+		meta = string(metaBytes)
+	}
+
+	return &proto.AccountEvent{
+		ResumeToken:  EventResumeToken(event),
+		Timestamp:    &timestamp.Timestamp{Seconds: event.Timestamp.Unix(), Nanos: int32(event.Timestamp.Nanosecond())},
+		Activity:     event.Activity.Message(),
+		ActivityCode: event.Activity.StringCode(),
+		AccountId:    event.AccountID,
+		InitiatorId:  event.InitiatorID,
+		TargetId:     event.TargetID,
+		Meta:         meta,
+	}, nil
+}
+
+// CheckSetupKey returns whether a setup key can register a peer without using it.
+// It is rate limited per source IP because it doesn't require any authentication.
+func (s *GRPCServer) CheckSetupKey(ctx context.Context, req *proto.EncryptedMessage) (*proto.EncryptedMessage, error) {
+	sourceIP := getConnectionIP(ctx)
+	if !s.setupKeyCheckLimiter.allow(sourceIP.String()) {
+		log.Warnf("too many setup key checks from %s", sourceIP)
+		return nil, status.Error(codes.ResourceExhausted, "too many setup key checks, try again later")
+	}
+
+	checkReq := &proto.SetupKeyCheckRequest{}
+	peerKey, serverKey, err := s.parseRequest(req, checkReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkReq.GetSetupKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "setup key is required")
+	}
+
+	state, err := s.accountManager.CheckSetupKey(checkReq.GetSetupKey())
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	checkResp := &proto.SetupKeyCheckResponse{
+		Valid:         state.Valid,
+		RemainingUses: int32(state.RemainingUses),
+	}
+	if !state.ExpiresAt.IsZero() {
+		checkResp.ExpiresAt = &timestamp.Timestamp{Seconds: state.ExpiresAt.Unix(), Nanos: int32(state.ExpiresAt.Nanosecond())}
+	}
+
+	encryptedResp, err := encryption.EncryptMessage(peerKey, serverKey, checkResp)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encrypt the setup key check response")
+	}
+
+	return &proto.EncryptedMessage{
+		WgPubKey: serverKey.PublicKey().String(),
+		Body:     encryptedResp,
+	}, nil
+}
+
+// RotatePeerKey replaces the WireGuard key of the peer sending the request with the new key of the request.
+// The request is encrypted with the old key and carries a proof encrypted with the new one, so the peer has to own both.
+func (s *GRPCServer) RotatePeerKey(ctx context.Context, req *proto.EncryptedMessage) (*proto.EncryptedMessage, error) {
+	rotateReq := &proto.RotatePeerKeyRequest{}
+	peerKey, serverKey, err := s.parseRequest(req, rotateReq)
+	if err != nil {
+		return nil, err
+	}
+
+	newPeerKey, err := wgtypes.ParseKey(rotateReq.GetNewPubKey())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "provided new WireGuard public key %s is invalid", rotateReq.GetNewPubKey())
+	}
+
+	proof := &proto.RotatePeerKeyProof{}
+	err = encryption.DecryptMessage(newPeerKey, serverKey, rotateReq.GetNewKeyProof(), proof)
+	if err != nil || proof.GetOldPubKey() != peerKey.String() {
+		return nil, status.Error(codes.InvalidArgument, "invalid proof of the new WireGuard key")
+	}
+
+	log.Debugf("key rotation request from peer [%s] [%s]", peerKey, getRealIP(ctx))
+
+	_, err = s.accountManager.RotatePeerKey(peerKey.String(), newPeerKey.String())
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	// the response is encrypted with the new key, only the owner of the new key can read it
+	encryptedResp, err := encryption.EncryptMessage(newPeerKey, serverKey, &proto.RotatePeerKeyResponse{})
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to encrypt the key rotation response")
+	}
+
+	return &proto.EncryptedMessage{
+		WgPubKey: serverKey.PublicKey().String(),
 		Body:     encryptedResp,
 	}, nil
 }
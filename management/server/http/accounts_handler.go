@@ -3,6 +3,8 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -41,12 +43,12 @@ func (h *AccountsHandler) GetAllAccounts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
+	if !user.HasPermission(server.ResourceAccounts, server.OperationRead) {
 		util.WriteError(status.Errorf(status.PermissionDenied, "the user has no permission to access account data"), w)
 		return
 	}
 
-	resp := toAccountResponse(account)
+	resp := toAccountResponse(account, user.HasPermission(server.ResourceAccounts, server.OperationWrite))
 	util.WriteJSONObject(w, []*api.Account{resp})
 }
 
@@ -94,6 +96,60 @@ func (h *AccountsHandler) UpdateAccount(w http.ResponseWriter, r *http.Request)
 	if req.Settings.JwtAllowGroups != nil {
 		settings.JWTAllowGroups = *req.Settings.JwtAllowGroups
 	}
+	if req.Settings.WebhookUrl != nil {
+		settings.WebhookURL = *req.Settings.WebhookUrl
+	}
+	if req.Settings.AllowedDomains != nil {
+		settings.AllowedDomains = *req.Settings.AllowedDomains
+	}
+	if req.Settings.PeerApprovalRequired != nil {
+		settings.PeerApprovalRequired = *req.Settings.PeerApprovalRequired
+	}
+	if req.Settings.DefaultDenyEnabled != nil {
+		settings.DefaultDenyEnabled = *req.Settings.DefaultDenyEnabled
+	}
+	if req.Settings.LinkedOrgKeys != nil {
+		settings.LinkedOrgKeys = *req.Settings.LinkedOrgKeys
+	}
+	if req.Settings.PeerInactivityCleanupEnabled != nil {
+		settings.PeerInactivityCleanupEnabled = *req.Settings.PeerInactivityCleanupEnabled
+	}
+	if req.Settings.PeerInactivityThreshold != nil {
+		settings.PeerInactivityThreshold = time.Duration(*req.Settings.PeerInactivityThreshold) * time.Second
+	}
+	if req.Settings.PeerInactivityAction != nil {
+		settings.PeerInactivityAction = string(*req.Settings.PeerInactivityAction)
+	}
+	if req.Settings.MinClientVersion != nil {
+		settings.MinClientVersion = *req.Settings.MinClientVersion
+	}
+	if req.Settings.MinClientVersionAction != nil {
+		settings.MinClientVersionAction = string(*req.Settings.MinClientVersionAction)
+	}
+	if req.Settings.MinClientVersionAllowUnknown != nil {
+		settings.MinClientVersionAllowUnknown = *req.Settings.MinClientVersionAllowUnknown
+	}
+	if req.Settings.SignalUri != nil {
+		settings.SignalURI = *req.Settings.SignalUri
+	}
+	if req.Settings.SignalProtocol != nil {
+		settings.SignalProtocol = *req.Settings.SignalProtocol
+	}
+	if req.Settings.PeerUpdateDebounceWindow != nil {
+		window := time.Duration(*req.Settings.PeerUpdateDebounceWindow) * time.Millisecond
+		settings.PeerUpdateDebounceWindow = &window
+	}
+	// 0 clears the timeout, the clients go back to their default
+	if req.Settings.PeerConnectionTimeout != nil && *req.Settings.PeerConnectionTimeout != 0 {
+		timeout := time.Duration(*req.Settings.PeerConnectionTimeout) * time.Millisecond
+		settings.PeerConnectionTimeout = &timeout
+	}
+	if req.Settings.SetupKeyApprovalRequired != nil {
+		settings.SetupKeyApprovalRequired = *req.Settings.SetupKeyApprovalRequired
+	}
+	if req.Settings.PeerIpAllocation != nil {
+		settings.PeerIPAllocation = string(*req.Settings.PeerIpAllocation)
+	}
 
 	updatedAccount, err := h.accountManager.UpdateAccountSettings(accountID, user.Id, settings)
 	if err != nil {
@@ -101,11 +157,321 @@ func (h *AccountsHandler) UpdateAccount(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	resp := toAccountResponse(updatedAccount)
+	resp := toAccountResponse(updatedAccount, true)
 
 	util.WriteJSONObject(w, &resp)
 }
 
+// GetAccountNetwork is HTTP GET handler that returns the network of the account
+func (h *AccountsHandler) GetAccountNetwork(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	network, err := h.accountManager.GetNetwork(accountID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toAccountNetworkResponse(network))
+}
+
+// UpdateAccountNetwork is HTTP PUT handler that changes the network range of the account
+func (h *AccountsHandler) UpdateAccountNetwork(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	var req api.PutApiAccountsAccountIdNetworkJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	network, err := h.accountManager.UpdateNetworkRange(accountID, user.Id, req.Range)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toAccountNetworkResponse(network))
+}
+
+// GetAccountMaintenance is HTTP GET handler that returns the maintenance window state of the account
+func (h *AccountsHandler) GetAccountMaintenance(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	window, err := h.accountManager.GetMaintenanceWindow(accountID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toAccountMaintenanceResponse(window))
+}
+
+// GetAccountDefaultDeny is HTTP GET handler that returns the effect of the default deny on the account peers
+func (h *AccountsHandler) GetAccountDefaultDeny(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	report, err := h.accountManager.GetDefaultDenyReport(accountID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toAccountDefaultDenyResponse(report))
+}
+
+// GetAccountEffectiveACL is HTTP GET handler that returns the effective ACL of the account peers
+func (h *AccountsHandler) GetAccountEffectiveACL(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	acl, err := h.accountManager.GetEffectiveACL(accountID, user.Id, r.URL.Query().Get("peer_id"))
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toAccountEffectiveACLResponse(acl))
+}
+
+// GetAccountTerraformExport is HTTP GET handler that returns the groups, policies, setup keys and DNS settings
+// of the account as the HCL of the netbird Terraform provider
+func (h *AccountsHandler) GetAccountTerraformExport(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	account, err := h.accountManager.GetAccountForExport(accountID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(toTerraformExport(account)))
+}
+
+// GetAccountInactivePeers is HTTP GET handler that returns the peers the inactivity cleanup of the account applies to
+func (h *AccountsHandler) GetAccountInactivePeers(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	var threshold time.Duration
+	if thresholdParam := r.URL.Query().Get("threshold"); thresholdParam != "" {
+		seconds, err := strconv.Atoi(thresholdParam)
+		if err != nil || seconds <= 0 {
+			util.WriteError(status.Errorf(status.InvalidArgument, "invalid threshold %s", thresholdParam), w)
+			return
+		}
+		threshold = time.Duration(seconds) * time.Second
+	}
+
+	peers, err := h.accountManager.GetInactivePeers(accountID, user.Id, threshold)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := make([]api.AccountInactivePeer, 0, len(peers))
+	for _, inactive := range peers {
+		resp = append(resp, api.AccountInactivePeer{
+			Id:          inactive.Peer.ID,
+			Name:        inactive.Peer.Name,
+			Ip:          inactive.Peer.IP.String(),
+			LastSeen:    inactive.Peer.Status.LastSeen,
+			InactiveFor: int(inactive.InactiveFor.Seconds()),
+			Flagged:     inactive.Peer.Status.Inactive,
+		})
+	}
+
+	util.WriteJSONObject(w, resp)
+}
+
+// GetAccountOutdatedPeers is HTTP GET handler that returns the peers running an older client than the minimum version of the account
+func (h *AccountsHandler) GetAccountOutdatedPeers(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	peers, err := h.accountManager.GetOutdatedPeers(accountID, user.Id, r.URL.Query().Get("min_version"))
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := make([]api.AccountOutdatedPeer, 0, len(peers))
+	for _, outdated := range peers {
+		resp = append(resp, api.AccountOutdatedPeer{
+			Id:             outdated.Peer.ID,
+			Name:           outdated.Peer.Name,
+			Ip:             outdated.Peer.IP.String(),
+			Version:        outdated.Peer.Meta.WtVersion,
+			UnknownVersion: outdated.UnknownVersion,
+			Connected:      outdated.Peer.Status.Connected,
+			LastSeen:       outdated.Peer.Status.LastSeen,
+			Flagged:        outdated.Peer.Status.OutdatedClient,
+		})
+	}
+
+	util.WriteJSONObject(w, resp)
+}
+
+// StartAccountMaintenance is HTTP POST handler that pauses the peer updates of the account
+func (h *AccountsHandler) StartAccountMaintenance(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	window, err := h.accountManager.StartMaintenanceWindow(accountID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toAccountMaintenanceResponse(window))
+}
+
+// StopAccountMaintenance is HTTP DELETE handler that resumes the peer updates of the account
+func (h *AccountsHandler) StopAccountMaintenance(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	err = h.accountManager.StopMaintenanceWindow(accountID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// RotateAccountWebhookSecret is HTTP POST handler that replaces the secret signing the webhook payloads of the account
+func (h *AccountsHandler) RotateAccountWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	_, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	accountID := mux.Vars(r)["accountId"]
+	if len(accountID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid account ID"), w)
+		return
+	}
+
+	secret, err := h.accountManager.RotateWebhookSecret(accountID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, &api.AccountWebhookSecret{WebhookSecret: secret})
+}
+
 // DeleteAccount is a HTTP DELETE handler to delete an account
 func (h *AccountsHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
@@ -130,19 +496,69 @@ func (h *AccountsHandler) DeleteAccount(w http.ResponseWriter, r *http.Request)
 	util.WriteJSONObject(w, emptyObject{})
 }
 
-func toAccountResponse(account *server.Account) *api.Account {
+// toAccountResponse converts the account to its API representation, the webhook secret is included only with withSecret
+func toAccountResponse(account *server.Account, withSecret bool) *api.Account {
 	jwtAllowGroups := account.Settings.JWTAllowGroups
 	if jwtAllowGroups == nil {
 		jwtAllowGroups = []string{}
 	}
 
+	allowedDomains := account.Settings.AllowedDomains
+	if allowedDomains == nil {
+		allowedDomains = []string{}
+	}
+
+	linkedOrgKeys := account.Settings.LinkedOrgKeys
+	if linkedOrgKeys == nil {
+		linkedOrgKeys = []string{}
+	}
+
+	peerInactivityThreshold := int(account.Settings.PeerInactivityThreshold.Seconds())
+	peerInactivityAction := api.AccountSettingsPeerInactivityAction(account.Settings.PeerInactivityAction)
+	minClientVersionAction := api.AccountSettingsMinClientVersionAction(account.Settings.MinClientVersionAction)
+	peerUpdateDebounceWindow := int(account.Settings.GetPeerUpdateDebounceWindow().Milliseconds())
+	peerIPAllocation := api.AccountSettingsPeerIpAllocation(account.Settings.PeerIPAllocation)
+	if peerIPAllocation == "" {
+		peerIPAllocation = api.AccountSettingsPeerIpAllocationRandom
+	}
+
 	settings := api.AccountSettings{
+		AllowedDomains:             &allowedDomains,
+		LinkedOrgKeys:              &linkedOrgKeys,
+		PeerApprovalRequired:       &account.Settings.PeerApprovalRequired,
+		DefaultDenyEnabled:         &account.Settings.DefaultDenyEnabled,
 		PeerLoginExpiration:        int(account.Settings.PeerLoginExpiration.Seconds()),
 		PeerLoginExpirationEnabled: account.Settings.PeerLoginExpirationEnabled,
 		GroupsPropagationEnabled:   &account.Settings.GroupsPropagationEnabled,
 		JwtGroupsEnabled:           &account.Settings.JWTGroupsEnabled,
 		JwtGroupsClaimName:         &account.Settings.JWTGroupsClaimName,
 		JwtAllowGroups:             &jwtAllowGroups,
+		WebhookUrl:                 &account.Settings.WebhookURL,
+
+		PeerInactivityCleanupEnabled: &account.Settings.PeerInactivityCleanupEnabled,
+		PeerInactivityThreshold:      &peerInactivityThreshold,
+		PeerInactivityAction:         &peerInactivityAction,
+
+		MinClientVersion:             &account.Settings.MinClientVersion,
+		MinClientVersionAction:       &minClientVersionAction,
+		MinClientVersionAllowUnknown: &account.Settings.MinClientVersionAllowUnknown,
+
+		SignalUri:      &account.Settings.SignalURI,
+		SignalProtocol: &account.Settings.SignalProtocol,
+
+		PeerUpdateDebounceWindow: &peerUpdateDebounceWindow,
+		PeerIpAllocation:         &peerIPAllocation,
+
+		SetupKeyApprovalRequired: &account.Settings.SetupKeyApprovalRequired,
+	}
+
+	if account.Settings.PeerConnectionTimeout != nil {
+		peerConnectionTimeout := int(account.Settings.PeerConnectionTimeout.Milliseconds())
+		settings.PeerConnectionTimeout = &peerConnectionTimeout
+	}
+
+	if withSecret && account.Settings.WebhookSecret != "" {
+		settings.WebhookSecret = &account.Settings.WebhookSecret
 	}
 
 	if account.Settings.Extra != nil {
@@ -154,3 +570,99 @@ func toAccountResponse(account *server.Account) *api.Account {
 		Settings: settings,
 	}
 }
+
+func toAccountNetworkResponse(network *server.Network) *api.AccountNetwork {
+	return &api.AccountNetwork{
+		Id:     network.Identifier,
+		Range:  network.Net.String(),
+		Serial: int(network.CurrentSerial()),
+	}
+}
+
+func toAccountMaintenanceResponse(window *server.MaintenanceWindow) *api.AccountMaintenance {
+	if window == nil {
+		return &api.AccountMaintenance{}
+	}
+
+	startedAt := window.StartedAt
+	startedBy := window.StartedBy
+	return &api.AccountMaintenance{
+		Active:        true,
+		PendingUpdate: window.PendingUpdate,
+		StartedAt:     &startedAt,
+		StartedBy:     &startedBy,
+	}
+}
+
+func toAccountDefaultDenyResponse(report *server.DefaultDenyReport) *api.AccountDefaultDeny {
+	policies := make([]api.PolicyMinimum, 0, len(report.IgnoredPolicies))
+	for _, policy := range report.IgnoredPolicies {
+		policyID := policy.ID
+		policies = append(policies, api.PolicyMinimum{
+			Id:          &policyID,
+			Name:        policy.Name,
+			Description: policy.Description,
+			Enabled:     policy.Enabled,
+		})
+	}
+
+	peers := make([]api.AccountDefaultDenyAffectedPeer, 0, len(report.AffectedPeers))
+	for peerID, peer := range report.AffectedPeers {
+		peers = append(peers, api.AccountDefaultDenyAffectedPeer{
+			Id:             peerID,
+			Name:           peer.Name,
+			LostPeers:      peer.LostPeers,
+			RemainingPeers: peer.RemainingPeers,
+		})
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Name < peers[j].Name
+	})
+
+	return &api.AccountDefaultDeny{
+		Enabled:         report.Enabled,
+		IgnoredPolicies: policies,
+		AffectedPeers:   peers,
+	}
+}
+
+func toAccountEffectiveACLResponse(acl *server.EffectiveACL) *api.AccountEffectiveACL {
+	peers := make([]api.AccountEffectiveACLPeer, 0, len(acl.Peers))
+	for _, peer := range acl.Peers {
+		peers = append(peers, api.AccountEffectiveACLPeer{
+			Id:       peer.PeerID,
+			Name:     peer.PeerName,
+			Ip:       peer.PeerIP,
+			Outbound: toAccountEffectiveACLEdgesResponse(peer.Outbound),
+			Inbound:  toAccountEffectiveACLEdgesResponse(peer.Inbound),
+		})
+	}
+
+	return &api.AccountEffectiveACL{
+		Peers:     peers,
+		Truncated: acl.Truncated,
+	}
+}
+
+func toAccountEffectiveACLEdgesResponse(edges []*server.EffectiveACLEdge) []api.AccountEffectiveACLEdge {
+	apiEdges := make([]api.AccountEffectiveACLEdge, 0, len(edges))
+	for _, edge := range edges {
+		ports := edge.Ports
+		if ports == nil {
+			ports = []string{}
+		}
+		apiEdges = append(apiEdges, api.AccountEffectiveACLEdge{
+			PeerId:     edge.PeerID,
+			PeerName:   edge.PeerName,
+			PeerIp:     edge.PeerIP,
+			Action:     string(edge.Action),
+			Protocol:   string(edge.Protocol),
+			Ports:      ports,
+			PolicyId:   edge.PolicyID,
+			PolicyName: edge.PolicyName,
+			RuleId:     edge.RuleID,
+			RuleName:   edge.RuleName,
+		})
+	}
+	return apiEdges
+}
@@ -58,6 +58,12 @@ func TestAccounts_AccountsHandler(t *testing.T) {
 
 	sr := func(v string) *string { return &v }
 	br := func(v bool) *bool { return &v }
+	ir := func(v int) *int { return &v }
+	ar := func(v api.AccountSettingsPeerInactivityAction) *api.AccountSettingsPeerInactivityAction { return &v }
+	pr := func(v api.AccountSettingsPeerIpAllocation) *api.AccountSettingsPeerIpAllocation { return &v }
+	vr := func(v api.AccountSettingsMinClientVersionAction) *api.AccountSettingsMinClientVersionAction {
+		return &v
+	}
 
 	handler := initAccountsTestData(&server.Account{
 		Id:      accountID,
@@ -90,12 +96,33 @@ func TestAccounts_AccountsHandler(t *testing.T) {
 			requestPath:    "/api/accounts",
 			expectedStatus: http.StatusOK,
 			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
 				PeerLoginExpiration:        int(time.Hour.Seconds()),
 				PeerLoginExpirationEnabled: false,
 				GroupsPropagationEnabled:   br(false),
 				JwtGroupsClaimName:         sr(""),
 				JwtGroupsEnabled:           br(false),
 				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
 			},
 			expectedArray: true,
 			expectedID:    accountID,
@@ -108,12 +135,33 @@ func TestAccounts_AccountsHandler(t *testing.T) {
 			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 15552000,\"peer_login_expiration_enabled\": true}}"),
 			expectedStatus: http.StatusOK,
 			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
 				PeerLoginExpiration:        15552000,
 				PeerLoginExpirationEnabled: true,
 				GroupsPropagationEnabled:   br(false),
 				JwtGroupsClaimName:         sr(""),
 				JwtGroupsEnabled:           br(false),
 				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
 			},
 			expectedArray: false,
 			expectedID:    accountID,
@@ -126,12 +174,33 @@ func TestAccounts_AccountsHandler(t *testing.T) {
 			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 15552000,\"peer_login_expiration_enabled\": false,\"jwt_groups_enabled\":true,\"jwt_groups_claim_name\":\"roles\",\"jwt_allow_groups\":[\"test\"]}}"),
 			expectedStatus: http.StatusOK,
 			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
 				PeerLoginExpiration:        15552000,
 				PeerLoginExpirationEnabled: false,
 				GroupsPropagationEnabled:   br(false),
 				JwtGroupsClaimName:         sr("roles"),
 				JwtGroupsEnabled:           br(true),
 				JwtAllowGroups:             &[]string{"test"},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
 			},
 			expectedArray: false,
 			expectedID:    accountID,
@@ -144,12 +213,463 @@ func TestAccounts_AccountsHandler(t *testing.T) {
 			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"jwt_groups_enabled\":true,\"jwt_groups_claim_name\":\"groups\",\"groups_propagation_enabled\":true}}"),
 			expectedStatus: http.StatusOK,
 			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
 				PeerLoginExpiration:        554400,
 				PeerLoginExpirationEnabled: true,
 				GroupsPropagationEnabled:   br(true),
 				JwtGroupsClaimName:         sr("groups"),
 				JwtGroupsEnabled:           br(true),
 				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with Webhook",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"webhook_url\":\"https://example.com/events\"}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr("https://example.com/events"),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with allowed domains",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"allowed_domains\":[\"netbird.io\"]}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{"netbird.io"},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with linked org keys",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"linked_org_keys\":[\"orgkey\"]}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{"orgkey"},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with peer inactivity cleanup",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"peer_inactivity_cleanup_enabled\": true,\"peer_inactivity_threshold\": 2592000,\"peer_inactivity_action\": \"delete\"}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(true),
+				PeerInactivityThreshold:      ir(2592000),
+				PeerInactivityAction:         ar(api.AccountSettingsPeerInactivityActionDelete),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with minimum client version",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"min_client_version\": \"0.27.0\",\"min_client_version_action\": \"flag\",\"min_client_version_allow_unknown\": true}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr("0.27.0"),
+				MinClientVersionAction:       vr(api.AccountSettingsMinClientVersionActionFlag),
+				MinClientVersionAllowUnknown: br(true),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with signal server",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"signal_uri\": \"signal.eu.netbird.io:443\",\"signal_protocol\": \"https\"}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr("signal.eu.netbird.io:443"),
+				SignalProtocol: sr("https"),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with peer update debounce window",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"peer_update_debounce_window\": 0}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(0),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with sequential peer IP allocation",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"peer_ip_allocation\": \"sequential\"}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("sequential"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with peer connection timeout",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"peer_connection_timeout\": 20000}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerConnectionTimeout:    ir(20000),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK clearing peer connection timeout",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"peer_connection_timeout\": 0}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(false),
+			},
+			expectedArray: false,
+			expectedID:    accountID,
+		},
+		{
+			name:           "PutAccount OK with setup key approval required",
+			expectedBody:   true,
+			requestType:    http.MethodPut,
+			requestPath:    "/api/accounts/" + accountID,
+			requestBody:    bytes.NewBufferString("{\"settings\": {\"peer_login_expiration\": 554400,\"peer_login_expiration_enabled\": true,\"setup_key_approval_required\": true}}"),
+			expectedStatus: http.StatusOK,
+			expectedSettings: api.AccountSettings{
+				AllowedDomains:             &[]string{},
+				LinkedOrgKeys:              &[]string{},
+				PeerApprovalRequired:       br(false),
+				DefaultDenyEnabled:         br(false),
+				PeerLoginExpiration:        554400,
+				PeerLoginExpirationEnabled: true,
+				GroupsPropagationEnabled:   br(false),
+				JwtGroupsClaimName:         sr(""),
+				JwtGroupsEnabled:           br(false),
+				JwtAllowGroups:             &[]string{},
+				WebhookUrl:                 sr(""),
+
+				PeerInactivityCleanupEnabled: br(false),
+				PeerInactivityThreshold:      ir(0),
+				PeerInactivityAction:         ar(""),
+
+				MinClientVersion:             sr(""),
+				MinClientVersionAction:       vr(""),
+				MinClientVersionAllowUnknown: br(false),
+
+				SignalUri:      sr(""),
+				SignalProtocol: sr(""),
+
+				PeerUpdateDebounceWindow: ir(250),
+				PeerIpAllocation:         pr("random"),
+
+				SetupKeyApprovalRequired: br(true),
 			},
 			expectedArray: false,
 			expectedID:    accountID,
@@ -226,3 +746,207 @@ func TestAccounts_AccountsHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestAccounts_TerraformExport(t *testing.T) {
+	accountID := "test_account"
+	adminUser := server.NewAdminUser("test_user")
+	regularUser := server.NewRegularUser("regular_user")
+
+	account := &server.Account{
+		Id:      accountID,
+		Domain:  "hotmail.com",
+		Network: server.NewNetwork(),
+		Users: map[string]*server.User{
+			adminUser.Id:   adminUser,
+			regularUser.Id: regularUser,
+		},
+		Groups: map[string]*server.Group{
+			"all":  {ID: "all", Name: "All", Issued: server.GroupIssuedAPI, Peers: []string{"peer1"}},
+			"devs": {ID: "devs", Name: "devs", Issued: server.GroupIssuedAPI, Peers: []string{"peer1"}},
+			"jwt":  {ID: "jwt", Name: "engineering", Issued: server.GroupIssuedJWT},
+		},
+		Policies: []*server.Policy{
+			{
+				ID:      "policy1",
+				Name:    "devs to all",
+				Enabled: true,
+				Rules: []*server.PolicyRule{
+					{
+						ID:            "rule1",
+						Name:          "devs to all",
+						Enabled:       true,
+						Action:        server.PolicyTrafficActionAccept,
+						Bidirectional: true,
+						Protocol:      server.PolicyRuleProtocolALL,
+						Sources:       []string{"devs", "jwt"},
+						Destinations:  []string{"all"},
+					},
+				},
+			},
+		},
+		SetupKeys: map[string]*server.SetupKey{
+			"secret-key": {Id: "key1", Key: "secret-key", Name: "servers", Type: server.SetupKeyReusable, AutoGroups: []string{"devs"}},
+		},
+		Settings: &server.Settings{},
+	}
+
+	tt := []struct {
+		name           string
+		user           *server.User
+		requestPath    string
+		expectedStatus int
+	}{
+		{
+			name:           "export the account",
+			user:           adminUser,
+			requestPath:    "/api/accounts/" + accountID + "/export/terraform",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "export another account",
+			user:           adminUser,
+			requestPath:    "/api/accounts/other_account/export/terraform",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "export without permissions",
+			user:           regularUser,
+			requestPath:    "/api/accounts/" + accountID + "/export/terraform",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := initAccountsTestData(account, tc.user)
+			handler.accountManager.(*mock_server.MockAccountManager).GetAccountForExportFunc = func(accountID, userID string) (*server.Account, error) {
+				if accountID != account.Id {
+					return nil, status.Errorf(status.NotFound, "account %s not found", accountID)
+				}
+				if !account.Users[userID].HasPermission(server.ResourceGroups, server.OperationRead) {
+					return nil, status.Errorf(status.PermissionDenied, "user has no read permission for groups")
+				}
+				return account.Copy(), nil
+			}
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.requestPath, nil)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/accounts/{accountId}/export/terraform", handler.GetAccountTerraformExport).Methods("GET")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode, string(body))
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, "text/plain; charset=utf-8", res.Header.Get("Content-Type"))
+			export := string(body)
+			assert.Contains(t, export, `resource "netbird_group" "devs" {`)
+			assert.Contains(t, export, `data "netbird_group" "all" {`)
+			assert.Contains(t, export, `data "netbird_group" "engineering" {`)
+			assert.Contains(t, export, `sources       = [data.netbird_group.engineering.id, netbird_group.devs.id]`)
+			assert.Contains(t, export, `auto_groups = [netbird_group.devs.id]`)
+			assert.NotContains(t, export, "secret-key", "the setup key value must not be exported")
+		})
+	}
+}
+
+func TestAccounts_WebhookSecret(t *testing.T) {
+	accountID := "test_account"
+	adminUser := server.NewAdminUser("test_user")
+	auditorUser := server.NewUser("auditor_user", server.UserRoleAuditor, false, false, "", []string{}, server.UserIssuedAPI)
+
+	account := &server.Account{
+		Id:      accountID,
+		Domain:  "hotmail.com",
+		Network: server.NewNetwork(),
+		Users: map[string]*server.User{
+			adminUser.Id:   adminUser,
+			auditorUser.Id: auditorUser,
+		},
+		Settings: &server.Settings{
+			WebhookURL:    "https://example.com/netbird/events",
+			WebhookSecret: "secret",
+		},
+	}
+
+	tt := []struct {
+		name           string
+		user           *server.User
+		expectedSecret bool
+	}{
+		{
+			name:           "admin gets the secret",
+			user:           adminUser,
+			expectedSecret: true,
+		},
+		{
+			name:           "auditor doesn't get the secret",
+			user:           auditorUser,
+			expectedSecret: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := initAccountsTestData(account, tc.user)
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/accounts", nil)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/accounts", handler.GetAllAccounts).Methods("GET")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+
+			var accounts []*api.Account
+			err := json.NewDecoder(res.Body).Decode(&accounts)
+			assert.NoError(t, err)
+			assert.Len(t, accounts, 1)
+
+			if tc.expectedSecret {
+				assert.Equal(t, "secret", *accounts[0].Settings.WebhookSecret)
+			} else {
+				assert.Nil(t, accounts[0].Settings.WebhookSecret)
+			}
+		})
+	}
+
+	t.Run("rotate the secret", func(t *testing.T) {
+		handler := initAccountsTestData(account, adminUser)
+		handler.accountManager.(*mock_server.MockAccountManager).RotateWebhookSecretFunc = func(accountID, userID string) (string, error) {
+			return "new-secret", nil
+		}
+
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/accounts/"+accountID+"/webhook-secret", nil)
+
+		router := mux.NewRouter()
+		router.HandleFunc("/api/accounts/{accountId}/webhook-secret", handler.RotateAccountWebhookSecret).Methods("POST")
+		router.ServeHTTP(recorder, req)
+
+		res := recorder.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var secret api.AccountWebhookSecret
+		err := json.NewDecoder(res.Body).Decode(&secret)
+		assert.NoError(t, err)
+		assert.Equal(t, "new-secret", secret.WebhookSecret)
+	})
+}
@@ -12,12 +12,56 @@ const (
 	TokenAuthScopes  = "TokenAuth.Scopes"
 )
 
+// Defines values for AccountSettingsMinClientVersionAction.
+const (
+	AccountSettingsMinClientVersionActionFlag   AccountSettingsMinClientVersionAction = "flag"
+	AccountSettingsMinClientVersionActionReject AccountSettingsMinClientVersionAction = "reject"
+)
+
+// Defines values for AccountSettingsPeerInactivityAction.
+const (
+	AccountSettingsPeerInactivityActionDelete AccountSettingsPeerInactivityAction = "delete"
+	AccountSettingsPeerInactivityActionFlag   AccountSettingsPeerInactivityAction = "flag"
+)
+
+// Defines values for AccountSettingsPeerIpAllocation.
+const (
+	AccountSettingsPeerIpAllocationRandom     AccountSettingsPeerIpAllocation = "random"
+	AccountSettingsPeerIpAllocationSequential AccountSettingsPeerIpAllocation = "sequential"
+)
+
+// Defines values for BatchOperationResource.
+const (
+	BatchOperationResourceGroup     BatchOperationResource = "group"
+	BatchOperationResourceGroupPeer BatchOperationResource = "group_peer"
+	BatchOperationResourcePolicy    BatchOperationResource = "policy"
+)
+
+// Defines values for DNSRecordRequestType.
+const (
+	DNSRecordRequestTypeA     DNSRecordRequestType = "A"
+	DNSRecordRequestTypeAAAA  DNSRecordRequestType = "AAAA"
+	DNSRecordRequestTypeCNAME DNSRecordRequestType = "CNAME"
+	DNSRecordRequestTypeTXT   DNSRecordRequestType = "TXT"
+)
+
+// Defines values for DNSRecordType.
+const (
+	DNSRecordTypeA     DNSRecordType = "A"
+	DNSRecordTypeAAAA  DNSRecordType = "AAAA"
+	DNSRecordTypeCNAME DNSRecordType = "CNAME"
+	DNSRecordTypeTXT   DNSRecordType = "TXT"
+)
+
 // Defines values for EventActivityCode.
 const (
 	EventActivityCodeAccountCreate                            EventActivityCode = "account.create"
 	EventActivityCodeAccountSettingPeerLoginExpirationDisable EventActivityCode = "account.setting.peer.login.expiration.disable"
 	EventActivityCodeAccountSettingPeerLoginExpirationEnable  EventActivityCode = "account.setting.peer.login.expiration.enable"
 	EventActivityCodeAccountSettingPeerLoginExpirationUpdate  EventActivityCode = "account.setting.peer.login.expiration.update"
+	EventActivityCodeDnsRecordAdd                             EventActivityCode = "dns.record.add"
+	EventActivityCodeDnsRecordDelete                          EventActivityCode = "dns.record.delete"
+	EventActivityCodeDnsRecordUpdate                          EventActivityCode = "dns.record.update"
 	EventActivityCodeDnsSettingDisabledManagementGroupAdd     EventActivityCode = "dns.setting.disabled.management.group.add"
 	EventActivityCodeDnsSettingDisabledManagementGroupDelete  EventActivityCode = "dns.setting.disabled.management.group.delete"
 	EventActivityCodeGroupAdd                                 EventActivityCode = "group.add"
@@ -45,6 +89,7 @@ const (
 	EventActivityCodeServiceUserCreate                        EventActivityCode = "service.user.create"
 	EventActivityCodeServiceUserDelete                        EventActivityCode = "service.user.delete"
 	EventActivityCodeSetupkeyAdd                              EventActivityCode = "setupkey.add"
+	EventActivityCodeSetupkeyExpire                           EventActivityCode = "setupkey.expire"
 	EventActivityCodeSetupkeyGroupAdd                         EventActivityCode = "setupkey.group.add"
 	EventActivityCodeSetupkeyGroupDelete                      EventActivityCode = "setupkey.group.delete"
 	EventActivityCodeSetupkeyOveruse                          EventActivityCode = "setupkey.overuse"
@@ -68,12 +113,39 @@ const (
 	NameserverNsTypeUdp NameserverNsType = "udp"
 )
 
+// Defines values for PeerNetworkMapFirewallRuleAction.
+const (
+	PeerNetworkMapFirewallRuleActionAccept PeerNetworkMapFirewallRuleAction = "accept"
+	PeerNetworkMapFirewallRuleActionDrop   PeerNetworkMapFirewallRuleAction = "drop"
+)
+
+// Defines values for PeerNetworkMapFirewallRuleDirection.
+const (
+	PeerNetworkMapFirewallRuleDirectionIn  PeerNetworkMapFirewallRuleDirection = "in"
+	PeerNetworkMapFirewallRuleDirectionOut PeerNetworkMapFirewallRuleDirection = "out"
+)
+
+// Defines values for PeerNetworkMapFirewallRuleProtocol.
+const (
+	PeerNetworkMapFirewallRuleProtocolAll  PeerNetworkMapFirewallRuleProtocol = "all"
+	PeerNetworkMapFirewallRuleProtocolIcmp PeerNetworkMapFirewallRuleProtocol = "icmp"
+	PeerNetworkMapFirewallRuleProtocolTcp  PeerNetworkMapFirewallRuleProtocol = "tcp"
+	PeerNetworkMapFirewallRuleProtocolUdp  PeerNetworkMapFirewallRuleProtocol = "udp"
+)
+
 // Defines values for PolicyRuleAction.
 const (
 	PolicyRuleActionAccept PolicyRuleAction = "accept"
 	PolicyRuleActionDrop   PolicyRuleAction = "drop"
 )
 
+// Defines values for PolicyRuleAdditionalProtocols.
+const (
+	PolicyRuleAdditionalProtocolsIcmp PolicyRuleAdditionalProtocols = "icmp"
+	PolicyRuleAdditionalProtocolsTcp  PolicyRuleAdditionalProtocols = "tcp"
+	PolicyRuleAdditionalProtocolsUdp  PolicyRuleAdditionalProtocols = "udp"
+)
+
 // Defines values for PolicyRuleProtocol.
 const (
 	PolicyRuleProtocolAll  PolicyRuleProtocol = "all"
@@ -88,6 +160,13 @@ const (
 	PolicyRuleMinimumActionDrop   PolicyRuleMinimumAction = "drop"
 )
 
+// Defines values for PolicyRuleMinimumAdditionalProtocols.
+const (
+	PolicyRuleMinimumAdditionalProtocolsIcmp PolicyRuleMinimumAdditionalProtocols = "icmp"
+	PolicyRuleMinimumAdditionalProtocolsTcp  PolicyRuleMinimumAdditionalProtocols = "tcp"
+	PolicyRuleMinimumAdditionalProtocolsUdp  PolicyRuleMinimumAdditionalProtocols = "udp"
+)
+
 // Defines values for PolicyRuleMinimumProtocol.
 const (
 	PolicyRuleMinimumProtocolAll  PolicyRuleMinimumProtocol = "all"
@@ -102,6 +181,13 @@ const (
 	PolicyRuleUpdateActionDrop   PolicyRuleUpdateAction = "drop"
 )
 
+// Defines values for PolicyRuleUpdateAdditionalProtocols.
+const (
+	PolicyRuleUpdateAdditionalProtocolsIcmp PolicyRuleUpdateAdditionalProtocols = "icmp"
+	PolicyRuleUpdateAdditionalProtocolsTcp  PolicyRuleUpdateAdditionalProtocols = "tcp"
+	PolicyRuleUpdateAdditionalProtocolsUdp  PolicyRuleUpdateAdditionalProtocols = "udp"
+)
+
 // Defines values for PolicyRuleUpdateProtocol.
 const (
 	PolicyRuleUpdateProtocolAll  PolicyRuleUpdateProtocol = "all"
@@ -110,6 +196,48 @@ const (
 	PolicyRuleUpdateProtocolUdp  PolicyRuleUpdateProtocol = "udp"
 )
 
+// Defines values for PolicyScheduleDays.
+const (
+	PolicyScheduleDaysFriday    PolicyScheduleDays = "friday"
+	PolicyScheduleDaysMonday    PolicyScheduleDays = "monday"
+	PolicyScheduleDaysSaturday  PolicyScheduleDays = "saturday"
+	PolicyScheduleDaysSunday    PolicyScheduleDays = "sunday"
+	PolicyScheduleDaysThursday  PolicyScheduleDays = "thursday"
+	PolicyScheduleDaysTuesday   PolicyScheduleDays = "tuesday"
+	PolicyScheduleDaysWednesday PolicyScheduleDays = "wednesday"
+)
+
+// Defines values for PolicySimulationRequestProtocol.
+const (
+	PolicySimulationRequestProtocolIcmp PolicySimulationRequestProtocol = "icmp"
+	PolicySimulationRequestProtocolTcp  PolicySimulationRequestProtocol = "tcp"
+	PolicySimulationRequestProtocolUdp  PolicySimulationRequestProtocol = "udp"
+)
+
+// Defines values for PostureCheckAction.
+const (
+	PostureCheckActionDeny       PostureCheckAction = "deny"
+	PostureCheckActionQuarantine PostureCheckAction = "quarantine"
+)
+
+// Defines values for PostureCheckRequestAction.
+const (
+	PostureCheckRequestActionDeny       PostureCheckRequestAction = "deny"
+	PostureCheckRequestActionQuarantine PostureCheckRequestAction = "quarantine"
+)
+
+// Defines values for SetupKeyPeerType.
+const (
+	SetupKeyPeerTypeEphemeral  SetupKeyPeerType = "ephemeral"
+	SetupKeyPeerTypePersistent SetupKeyPeerType = "persistent"
+)
+
+// Defines values for SetupKeyRequestPeerType.
+const (
+	SetupKeyRequestPeerTypeEphemeral  SetupKeyRequestPeerType = "ephemeral"
+	SetupKeyRequestPeerTypePersistent SetupKeyRequestPeerType = "persistent"
+)
+
 // Defines values for UserStatus.
 const (
 	UserStatusActive  UserStatus = "active"
@@ -142,12 +270,186 @@ type Account struct {
 	Settings AccountSettings `json:"settings"`
 }
 
+// AccountDefaultDeny defines model for AccountDefaultDeny.
+type AccountDefaultDeny struct {
+	// AffectedPeers Peers losing connections when the default deny is enabled
+	AffectedPeers []AccountDefaultDenyAffectedPeer `json:"affected_peers"`
+
+	// Enabled Indicates whether the default deny is enabled for the account
+	Enabled bool `json:"enabled"`
+
+	// IgnoredPolicies Enabled policies with an accept rule from the All group to the All group, which don't connect the peers when the default deny is enabled
+	IgnoredPolicies []PolicyMinimum `json:"ignored_policies"`
+}
+
+// AccountDefaultDenyAffectedPeer defines model for AccountDefaultDenyAffectedPeer.
+type AccountDefaultDenyAffectedPeer struct {
+	// Id Peer ID
+	Id string `json:"id"`
+
+	// LostPeers Number of peers the peer doesn't connect to when the default deny is enabled
+	LostPeers int `json:"lost_peers"`
+
+	// Name Peer's hostname
+	Name string `json:"name"`
+
+	// RemainingPeers Number of peers the peer still connects to when the default deny is enabled
+	RemainingPeers int `json:"remaining_peers"`
+}
+
+// AccountEffectiveACL defines model for AccountEffectiveACL.
+type AccountEffectiveACL struct {
+	// Peers ACLs of the account peers sorted by peer ID
+	Peers []AccountEffectiveACLPeer `json:"peers"`
+
+	// Truncated Indicates the ACL reached the maximum number of edges and misses some edges or peers. Request the ACL of a single peer with the peer_id parameter in this case.
+	Truncated bool `json:"truncated"`
+}
+
+// AccountEffectiveACLEdge defines model for AccountEffectiveACLEdge.
+type AccountEffectiveACLEdge struct {
+	// Action Policy rule accept or drops packets
+	Action string `json:"action"`
+
+	// PeerId ID of the peer on the other side of the edge
+	PeerId string `json:"peer_id"`
+
+	// PeerIp IP address of the peer on the other side of the edge
+	PeerIp string `json:"peer_ip"`
+
+	// PeerName Hostname of the peer on the other side of the edge
+	PeerName string `json:"peer_name"`
+
+	// PolicyId ID of the policy granting the edge
+	PolicyId string `json:"policy_id"`
+
+	// PolicyName Name of the policy granting the edge
+	PolicyName string `json:"policy_name"`
+
+	// Ports Ports or port ranges of the traffic, empty for all ports
+	Ports []string `json:"ports"`
+
+	// Protocol Policy rule type of the traffic
+	Protocol string `json:"protocol"`
+
+	// RuleId ID of the policy rule granting the edge
+	RuleId string `json:"rule_id"`
+
+	// RuleName Name of the policy rule granting the edge
+	RuleName string `json:"rule_name"`
+}
+
+// AccountEffectiveACLPeer defines model for AccountEffectiveACLPeer.
+type AccountEffectiveACLPeer struct {
+	// Id Peer ID
+	Id string `json:"id"`
+
+	// Inbound Peers the peer may be reached from, sorted by peer, policy and rule IDs
+	Inbound []AccountEffectiveACLEdge `json:"inbound"`
+
+	// Ip Peer's IP address
+	Ip string `json:"ip"`
+
+	// Name Peer's hostname
+	Name string `json:"name"`
+
+	// Outbound Peers the peer may reach, sorted by peer, policy and rule IDs
+	Outbound []AccountEffectiveACLEdge `json:"outbound"`
+}
+
 // AccountExtraSettings defines model for AccountExtraSettings.
 type AccountExtraSettings struct {
 	// PeerApprovalEnabled (Cloud only) Enables or disables peer approval globally. If enabled, all peers added will be in pending state until approved by an admin.
 	PeerApprovalEnabled *bool `json:"peer_approval_enabled,omitempty"`
 }
 
+// AccountInactivePeer defines model for AccountInactivePeer.
+type AccountInactivePeer struct {
+	// Flagged Indicates whether the cleanup already flagged the peer as inactive
+	Flagged bool `json:"flagged"`
+
+	// Id Peer ID
+	Id string `json:"id"`
+
+	// InactiveFor Period of time since the peer was last connected (seconds)
+	InactiveFor int `json:"inactive_for"`
+
+	// Ip Peer's IP address
+	Ip string `json:"ip"`
+
+	// LastSeen Last time the peer was connected to the management service
+	LastSeen time.Time `json:"last_seen"`
+
+	// Name Peer's hostname
+	Name string `json:"name"`
+}
+
+// AccountMaintenance defines model for AccountMaintenance.
+type AccountMaintenance struct {
+	// Active Indicates whether the account is in a maintenance window with the peer updates paused
+	Active bool `json:"active"`
+
+	// PendingUpdate Indicates whether the account changed during the maintenance window and the peers will be updated when it stops
+	PendingUpdate bool `json:"pending_update"`
+
+	// StartedAt Time the maintenance window was started at
+	StartedAt *time.Time `json:"started_at,omitempty"`
+
+	// StartedBy ID of the user who started the maintenance window
+	StartedBy *string `json:"started_by,omitempty"`
+}
+
+// AccountWebhookSecret defines model for AccountWebhookSecret.
+type AccountWebhookSecret struct {
+	// WebhookSecret New secret of the account signing the webhook payloads with HMAC-SHA256 in the X-NetBird-Signature header
+	WebhookSecret string `json:"webhook_secret"`
+}
+
+// AccountNetwork defines model for AccountNetwork.
+type AccountNetwork struct {
+	// Id Network ID
+	Id string `json:"id"`
+
+	// Range Network range in CIDR format the peer IPs are allocated from
+	Range string `json:"range"`
+
+	// Serial Network serial, incremented on every change of the network
+	Serial int `json:"serial"`
+}
+
+// AccountNetworkRequest defines model for AccountNetworkRequest.
+type AccountNetworkRequest struct {
+	// Range New network range in CIDR format. The prefix length should be between /14 and /29 and the range should fit all the existing peers.
+	Range string `json:"range"`
+}
+
+// AccountOutdatedPeer defines model for AccountOutdatedPeer.
+type AccountOutdatedPeer struct {
+	// Connected Peer to Management connection status
+	Connected bool `json:"connected"`
+
+	// Flagged Indicates whether the peer was flagged as outdated on its last login
+	Flagged bool `json:"flagged"`
+
+	// Id Peer ID
+	Id string `json:"id"`
+
+	// Ip Peer's IP address
+	Ip string `json:"ip"`
+
+	// LastSeen Last time the peer was connected to the management service
+	LastSeen time.Time `json:"last_seen"`
+
+	// Name Peer's hostname
+	Name string `json:"name"`
+
+	// UnknownVersion Indicates that the peer reports a development or an unparsable client version
+	UnknownVersion bool `json:"unknown_version"`
+
+	// Version Peer's NetBird client version
+	Version string `json:"version"`
+}
+
 // AccountRequest defines model for AccountRequest.
 type AccountRequest struct {
 	Settings AccountSettings `json:"settings"`
@@ -155,6 +457,16 @@ type AccountRequest struct {
 
 // AccountSettings defines model for AccountSettings.
 type AccountSettings struct {
+	// AllowedDomains List of user domains allowed to join the account and to register and login peers with a JWT, taken from the domain claim or the verified email. Empty allows any domain.
+	AllowedDomains *[]string `json:"allowed_domains,omitempty"`
+
+	// DefaultDenyEnabled Connects the peers only through the policies naming the groups allowed to connect. The accept rules
+	// from the All group to the All group, like the one of the Default policy created with every account, are
+	// ignored and can't be saved anymore. Before enabling it on an existing account, review the report of
+	// GET /api/accounts/{accountId}/default-deny, add policies for the peers that need to keep their
+	// connections and disable the policies listed as ignored. Disabling it restores the previous connectivity.
+	DefaultDenyEnabled *bool `json:"default_deny_enabled,omitempty"`
+
 	Extra *AccountExtraSettings `json:"extra,omitempty"`
 
 	// GroupsPropagationEnabled Allows propagate the new user auto groups to peers that belongs to the user
@@ -169,17 +481,168 @@ type AccountSettings struct {
 	// JwtGroupsEnabled Allows extract groups from JWT claim and add it to account groups.
 	JwtGroupsEnabled *bool `json:"jwt_groups_enabled,omitempty"`
 
+	// LinkedOrgKeys List of IDs of the org keys of other accounts allowed to register peers into the account. The org key has to be linked to the account as well.
+	LinkedOrgKeys *[]string `json:"linked_org_keys,omitempty"`
+
+	// MinClientVersion Oldest NetBird client version the peers are allowed to run, e.g. 0.27.0. Empty disables the check. Review the peers running an older client with GET /api/accounts/{accountId}/outdated-peers before setting it.
+	MinClientVersion *string `json:"min_client_version,omitempty"`
+
+	// MinClientVersionAction What happens to the peers running an older client. reject refuses their registration and login with a message asking to upgrade, flag lets them in and marks them as outdated.
+	MinClientVersionAction *AccountSettingsMinClientVersionAction `json:"min_client_version_action,omitempty"`
+
+	// MinClientVersionAllowUnknown Lets in the peers reporting a development or an unparsable client version
+	MinClientVersionAllowUnknown *bool `json:"min_client_version_allow_unknown,omitempty"`
+
+	// PeerApprovalRequired Quarantines the newly registered peers until an administrator approves them.
+	PeerApprovalRequired *bool `json:"peer_approval_required,omitempty"`
+
+	// PeerInactivityAction What the cleanup does with the inactive peers. flag marks them as inactive until they connect again, delete removes them from the account.
+	PeerInactivityAction *AccountSettingsPeerInactivityAction `json:"peer_inactivity_action,omitempty"`
+
+	// PeerInactivityCleanupEnabled Enables the hourly cleanup of the peers that haven't connected to the management service for longer than the peer inactivity threshold. Review the peers it applies to with GET /api/accounts/{accountId}/inactive-peers before enabling it with the delete action.
+	PeerInactivityCleanupEnabled *bool `json:"peer_inactivity_cleanup_enabled,omitempty"`
+
+	// PeerInactivityThreshold Period of time a peer has to be disconnected before the cleanup applies to it (seconds). At least 7 days.
+	PeerInactivityThreshold *int `json:"peer_inactivity_threshold,omitempty"`
+
+	// PeerIpAllocation How the IPs of the new peers are picked from the account network. random picks any free IP, sequential picks the lowest free IP. The IPs reserved for other peers are never picked and the IPs of the existing peers don't change. Omitted uses random.
+	PeerIpAllocation *AccountSettingsPeerIpAllocation `json:"peer_ip_allocation,omitempty"`
+
+	// PeerConnectionTimeout Timeout of the connection attempts of the peers to their remote peers (milliseconds), overriding the default of the clients. Between 5000 and 300000. A change applies to the next connection attempts of the peers. 0 or omitted lets the clients use their default.
+	PeerConnectionTimeout *int `json:"peer_connection_timeout,omitempty"`
+
 	// PeerLoginExpiration Period of time after which peer login expires (seconds).
 	PeerLoginExpiration int `json:"peer_login_expiration"`
 
 	// PeerLoginExpirationEnabled Enables or disables peer login expiration globally. After peer's login has expired the user has to log in (authenticate). Applies only to peers that were added by a user (interactive SSO login).
 	PeerLoginExpirationEnabled bool `json:"peer_login_expiration_enabled"`
+
+	// PeerUpdateDebounceWindow Debounce window of the network map updates of a peer (milliseconds). The first update of an idle peer is sent right away, the updates following it within the window are coalesced into one. At most 10000, 0 sends every update right away. Omitted uses the default of 250.
+	PeerUpdateDebounceWindow *int `json:"peer_update_debounce_window,omitempty"`
+
+	// SetupKeyApprovalRequired Creates the new setup keys pending until an admin other than their creator approves them. Changing the auto groups of a key puts it back pending. The pending keys can't register peers.
+	SetupKeyApprovalRequired *bool `json:"setup_key_approval_required,omitempty"`
+
+	// SignalProtocol Protocol of the signal server, one of udp, dtls, tcp, http, https. Required with signal_uri.
+	SignalProtocol *string `json:"signal_protocol,omitempty"`
+
+	// SignalUri Signal server the peers of the account use instead of the default one, as host:port, e.g. the server of the region closest to the account. Empty uses the default signal server. The connected peers reconnect to pick up a change.
+	SignalUri *string `json:"signal_uri,omitempty"`
+
+	// WebhookSecret Secret of the account signing the webhook payloads with HMAC-SHA256 in the X-NetBird-Signature header, generated when the webhook_url is set. Returned only to the users allowed to update the account.
+	WebhookSecret *string `json:"webhook_secret,omitempty"`
+
+	// WebhookUrl Endpoint notified with a signed JSON POST request about the account lifecycle events, like peers added or removed and users invited. Empty disables the notifications. The host has to resolve to public addresses only.
+	WebhookUrl *string `json:"webhook_url,omitempty"`
+}
+
+// AccountSettingsMinClientVersionAction What happens to the peers running an older client. reject refuses their registration and login with a message asking to upgrade, flag lets them in and marks them as outdated.
+type AccountSettingsMinClientVersionAction string
+
+// AccountSettingsPeerInactivityAction What the cleanup does with the inactive peers. flag marks them as inactive until they connect again, delete removes them from the account.
+type AccountSettingsPeerInactivityAction string
+
+// AccountSettingsPeerIpAllocation How the IPs of the new peers are picked from the account network. random picks any free IP, sequential picks the lowest free IP. The IPs reserved for other peers are never picked and the IPs of the existing peers don't change. Omitted uses random.
+type AccountSettingsPeerIpAllocation string
+
+// BatchGroupPeer defines model for BatchGroupPeer.
+type BatchGroupPeer struct {
+	// GroupId ID of the group managed manually, or the reference of a group created earlier in the batch
+	GroupId string `json:"group_id"`
+
+	// PeerId ID of the peer
+	PeerId string `json:"peer_id"`
+
+	// Remove Removes the peer from the group instead of adding it
+	Remove *bool `json:"remove,omitempty"`
+}
+
+// BatchOperation defines model for BatchOperation.
+type BatchOperation struct {
+	Group     *GroupRequest   `json:"group,omitempty"`
+	GroupPeer *BatchGroupPeer `json:"group_peer,omitempty"`
+
+	// Id ID of the group or policy to update, or the reference of a resource created earlier in the batch. A new resource is created when empty.
+	Id     *string       `json:"id,omitempty"`
+	Policy *PolicyUpdate `json:"policy,omitempty"`
+
+	// Ref Reference to the created group or policy, the later operations of the batch use it in place of the ID of the resource
+	Ref *string `json:"ref,omitempty"`
+
+	// Resource Resource the operation creates or updates, the field of the same name describes the change
+	Resource BatchOperationResource `json:"resource"`
+}
+
+// BatchOperationResource Resource the operation creates or updates, the field of the same name describes the change
+type BatchOperationResource string
+
+// BatchRequest defines model for BatchRequest.
+type BatchRequest struct {
+	// Operations Operations applied in their order, either all of them or none
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchResponse defines model for BatchResponse.
+type BatchResponse struct {
+	// Groups Groups created or updated by the batch
+	Groups []Group `json:"groups"`
+
+	// Policies Policies created or updated by the batch
+	Policies []Policy `json:"policies"`
 }
 
+// DNSRecord defines model for DNSRecord.
+type DNSRecord struct {
+	// Groups Group IDs of the DNS view of the record, it is served to the peers of these groups only. Records of the same name and type can be defined for groups that don't share a peer. Empty serves the record to all the peers.
+	Groups *[]string `json:"groups,omitempty"`
+
+	// Id DNS record ID
+	Id string `json:"id"`
+
+	// Name Record name relative to the account's peers DNS domain. It can't be the name of an existing peer. A leading * label makes a wildcard record answering for the names under it with no record of their own, e.g. *.svc.
+	Name string `json:"name"`
+
+	// Rdata Record data. An IP address for A and AAAA records, a domain name for CNAME records and the text for TXT records.
+	Rdata string `json:"rdata"`
+
+	// Ttl Record time-to-live in seconds
+	Ttl int `json:"ttl"`
+
+	// Type Record type
+	Type DNSRecordType `json:"type"`
+}
+
+// DNSRecordType Record type
+type DNSRecordType string
+
+// DNSRecordRequest defines model for DNSRecordRequest.
+type DNSRecordRequest struct {
+	// Groups Group IDs of the DNS view of the record, it is served to the peers of these groups only. Records of the same name and type can be defined for groups that don't share a peer. Empty serves the record to all the peers.
+	Groups *[]string `json:"groups,omitempty"`
+
+	// Name Record name relative to the account's peers DNS domain. It can't be the name of an existing peer. A leading * label makes a wildcard record answering for the names under it with no record of their own, e.g. *.svc.
+	Name string `json:"name"`
+
+	// Rdata Record data. An IP address for A and AAAA records, a domain name for CNAME records and the text for TXT records.
+	Rdata string `json:"rdata"`
+
+	// Ttl Record time-to-live in seconds
+	Ttl int `json:"ttl"`
+
+	// Type Record type
+	Type DNSRecordRequestType `json:"type"`
+}
+
+// DNSRecordRequestType Record type
+type DNSRecordRequestType string
+
 // DNSSettings defines model for DNSSettings.
 type DNSSettings struct {
 	// DisabledManagementGroups Groups whose DNS management is disabled
 	DisabledManagementGroups []string `json:"disabled_management_groups"`
+
+	// PrimaryDnsDisabledOs Operating systems as reported by the client, e.g. darwin, whose peers don't route all the DNS queries of the host to the NetBird resolver when a primary nameserver group applies to them. Not set keeps the current value on update.
+	PrimaryDnsDisabledOs *[]string `json:"primary_dns_disabled_os,omitempty"`
 }
 
 // Event defines model for Event.
@@ -217,6 +680,9 @@ type EventActivityCode string
 
 // Group defines model for Group.
 type Group struct {
+	// DynamicRule Rule deriving the peers of a dynamic group. A peer is a member when it matches all the set criteria
+	DynamicRule *GroupDynamicRule `json:"dynamic_rule,omitempty"`
+
 	// Id Group ID
 	Id string `json:"id"`
 
@@ -231,6 +697,19 @@ type Group struct {
 
 	// PeersCount Count of peers associated to the group
 	PeersCount int `json:"peers_count"`
+
+	// TunnelSettings Settings of the WireGuard tunnels of the peers of the group. A peer in several groups gets the lowest value
+	// set by its groups for each setting. An unset or 0 value keeps the default of the client.
+	TunnelSettings *GroupTunnelSettings `json:"tunnel_settings,omitempty"`
+}
+
+// GroupDynamicRule Rule deriving the peers of a dynamic group. A peer is a member when it matches all the set criteria
+type GroupDynamicRule struct {
+	// Meta Peer system meta values to match case-insensitively. A value ending with * matches the values starting with it, e.g. srv-*. Supported fields: hostname, go_os, os, kernel, core, platform, wt_version, ui_version
+	Meta *map[string]string `json:"meta,omitempty"`
+
+	// Subnets Subnets in a CIDR format, matching the peers with an IP address within any of them
+	Subnets *[]string `json:"subnets,omitempty"`
 }
 
 // GroupMinimum defines model for GroupMinimum.
@@ -250,11 +729,58 @@ type GroupMinimum struct {
 
 // GroupRequest defines model for GroupRequest.
 type GroupRequest struct {
+	// DynamicRule Rule deriving the peers of a dynamic group. A peer is a member when it matches all the set criteria
+	DynamicRule *GroupDynamicRule `json:"dynamic_rule,omitempty"`
+
 	// Name Group name identifier
 	Name string `json:"name"`
 
 	// Peers List of peers ids
 	Peers *[]string `json:"peers,omitempty"`
+
+	// TunnelSettings Settings of the WireGuard tunnels of the peers of the group. A peer in several groups gets the lowest value
+	// set by its groups for each setting. An unset or 0 value keeps the default of the client.
+	TunnelSettings *GroupTunnelSettings `json:"tunnel_settings,omitempty"`
+}
+
+// GroupTunnelSettings Settings of the WireGuard tunnels of the peers of the group. A peer in several groups gets the lowest value
+// set by its groups for each setting. An unset or 0 value keeps the default of the client.
+type GroupTunnelSettings struct {
+	// Mtu MTU of the WireGuard interface of the peers of the group, applied on the next connection of the peers
+	Mtu *int `json:"mtu,omitempty"`
+
+	// PersistentKeepalive Interval in seconds of the keepalives the other peers send to the peers of the group
+	PersistentKeepalive *int `json:"persistent_keepalive,omitempty"`
+}
+
+// IPReservation defines model for IPReservation.
+type IPReservation struct {
+	// Description Description of the reservation
+	Description string `json:"description"`
+
+	// Id IP reservation ID
+	Id string `json:"id"`
+
+	// Ip IPv4 address of the account network reserved for the peer
+	Ip string `json:"ip"`
+
+	// PeerKey WireGuard public key of the peer the IP is reserved for
+	PeerKey string `json:"peer_key"`
+}
+
+// IPReservationRequest defines model for IPReservationRequest.
+type IPReservationRequest struct {
+	// Description Description of the reservation
+	Description *string `json:"description,omitempty"`
+
+	// Ip IPv4 address of the account network reserved for the peer. Required unless peer_id is set.
+	Ip *string `json:"ip,omitempty"`
+
+	// PeerId ID of a registered peer to reserve the IP for, instead of its key. The peer's current IP is reserved when ip isn't set.
+	PeerId *string `json:"peer_id,omitempty"`
+
+	// PeerKey WireGuard public key of the peer the IP is reserved for. The peer doesn't have to be registered yet. Required unless peer_id is set.
+	PeerKey *string `json:"peer_key,omitempty"`
 }
 
 // Nameserver defines model for Nameserver.
@@ -329,11 +855,113 @@ type NameserverGroupRequest struct {
 	SearchDomainsEnabled bool `json:"search_domains_enabled"`
 }
 
+// NetworkResource defines model for NetworkResource.
+type NetworkResource struct {
+	// Description Network resource friendly description
+	Description string `json:"description"`
+
+	// Id Network resource ID
+	Id string `json:"id"`
+
+	// Name Network resource name, unique in the account
+	Name string `json:"name"`
+
+	// Prefix Network range of the resource in CIDR notation. The routes of the networks within the range are distributed only to the peers the policies grant access to the resource.
+	Prefix string `json:"prefix"`
+}
+
+// NetworkResourceRequest defines model for NetworkResourceRequest.
+type NetworkResourceRequest struct {
+	// Description Network resource friendly description
+	Description string `json:"description"`
+
+	// Name Network resource name, unique in the account
+	Name string `json:"name"`
+
+	// Prefix Network range of the resource in CIDR notation. The routes of the networks within the range are distributed only to the peers the policies grant access to the resource.
+	Prefix string `json:"prefix"`
+}
+
+// NonCompliantPeer defines model for NonCompliantPeer.
+type NonCompliantPeer struct {
+	// Connected Peer to Management connection status
+	Connected bool `json:"connected"`
+
+	// Failures Enabled posture checks the peer fails
+	Failures []PostureCheckFailure `json:"failures"`
+
+	// Id Peer ID
+	Id string `json:"id"`
+
+	// Ip Peer's IP address
+	Ip string `json:"ip"`
+
+	// LastSeen Last time the peer was connected to the management service
+	LastSeen time.Time `json:"last_seen"`
+
+	// Name Peer's hostname
+	Name string `json:"name"`
+
+	// Os Peer's operating system and version
+	Os string `json:"os"`
+
+	// Quarantined Indicates whether the peer is quarantined from the network. The peers failing a deny check since it was saved are quarantined until their next login rejects them.
+	Quarantined bool `json:"quarantined"`
+
+	// Version Peer's NetBird client version
+	Version string `json:"version"`
+}
+
+// OrgKey defines model for OrgKey.
+type OrgKey struct {
+	// CreatedAt Org key creation date
+	CreatedAt time.Time `json:"created_at"`
+
+	// Expires Org key expiration date, absent when the key doesn't expire
+	Expires *time.Time `json:"expires,omitempty"`
+
+	// Id Org key ID
+	Id string `json:"id"`
+
+	// Key Org key value. Peers register with the key followed by a colon and the ID of the account to register into.
+	Key string `json:"key"`
+
+	// LinkedAccounts List of IDs of the accounts the key can register peers into
+	LinkedAccounts []string `json:"linked_accounts"`
+
+	// Name Org key name
+	Name string `json:"name"`
+
+	// Revoked Org key revocation status
+	Revoked bool `json:"revoked"`
+
+	// Valid Org key validity status
+	Valid bool `json:"valid"`
+}
+
+// OrgKeyRequest defines model for OrgKeyRequest.
+type OrgKeyRequest struct {
+	// ExpiresIn Expiration time in seconds, 0 creates a key which doesn't expire. Ignored on update.
+	ExpiresIn *int `json:"expires_in,omitempty"`
+
+	// LinkedAccounts List of IDs of the accounts the key can register peers into
+	LinkedAccounts []string `json:"linked_accounts"`
+
+	// Name Org key name
+	Name string `json:"name"`
+
+	// Revoked Org key revocation status, a revoked key can't be activated again
+	Revoked *bool `json:"revoked,omitempty"`
+}
+
 // Peer defines model for Peer.
 type Peer struct {
 	// AccessiblePeers List of accessible peers
 	AccessiblePeers []AccessiblePeer `json:"accessible_peers"`
 
+	// AdvertisedNetworks Networks of the peer's local network the peer offers to route, as reported by the peer. Routes through the peer are created separately.
+	AdvertisedNetworks *[]string `json:"advertised_networks,omitempty"`
+
 	// ApprovalRequired (Cloud only) Indicates whether peer needs approval
 	ApprovalRequired *bool `json:"approval_required,omitempty"`
 
@@ -343,6 +971,9 @@ type Peer struct {
 	// DnsLabel Peer's DNS label is the parsed peer name for domain resolution. It is used to form an FQDN by appending the account's domain to the peer label. e.g. peer-dns-label.netbird.cloud
 	DnsLabel string `json:"dns_label"`
 
+	// ForceRelay Indicates whether the connections to and from the peer skip the direct connection attempts and go through a relay
+	ForceRelay bool `json:"force_relay"`
+
 	// Groups Groups that the peer belongs to
 	Groups []GroupMinimum `json:"groups"`
 
@@ -352,6 +983,9 @@ type Peer struct {
 	// Id Peer ID
 	Id string `json:"id"`
 
+	// Inactive Indicates whether the inactivity cleanup of the account flagged the peer as inactive. It is cleared when the peer connects again.
+	Inactive *bool `json:"inactive,omitempty"`
+
 	// Ip Peer's IP address
 	Ip string `json:"ip"`
 
@@ -370,6 +1004,9 @@ type Peer struct {
 	// Name Peer's hostname
 	Name string `json:"name"`
 
+	// NatType Type of the NAT the peer is behind as classified by the peer with STUN, one of open, cone or symmetric. Omitted when unknown.
+	NatType *string `json:"nat_type,omitempty"`
+
 	// Os Peer's operating system and version
 	Os string `json:"os"`
 
@@ -388,6 +1025,9 @@ type Peer struct {
 
 // PeerBase defines model for PeerBase.
 type PeerBase struct {
+	// AdvertisedNetworks Networks of the peer's local network the peer offers to route, as reported by the peer. Routes through the peer are created separately.
+	AdvertisedNetworks *[]string `json:"advertised_networks,omitempty"`
+
 	// ApprovalRequired (Cloud only) Indicates whether peer needs approval
 	ApprovalRequired *bool `json:"approval_required,omitempty"`
 
@@ -397,6 +1037,9 @@ type PeerBase struct {
 	// DnsLabel Peer's DNS label is the parsed peer name for domain resolution. It is used to form an FQDN by appending the account's domain to the peer label. e.g. peer-dns-label.netbird.cloud
 	DnsLabel string `json:"dns_label"`
 
+	// ForceRelay Indicates whether the connections to and from the peer skip the direct connection attempts and go through a relay
+	ForceRelay bool `json:"force_relay"`
+
 	// Groups Groups that the peer belongs to
 	Groups []GroupMinimum `json:"groups"`
 
@@ -406,6 +1049,9 @@ type PeerBase struct {
 	// Id Peer ID
 	Id string `json:"id"`
 
+	// Inactive Indicates whether the inactivity cleanup of the account flagged the peer as inactive. It is cleared when the peer connects again.
+	Inactive *bool `json:"inactive,omitempty"`
+
 	// Ip Peer's IP address
 	Ip string `json:"ip"`
 
@@ -424,6 +1070,9 @@ type PeerBase struct {
 	// Name Peer's hostname
 	Name string `json:"name"`
 
+	// NatType Type of the NAT the peer is behind as classified by the peer with STUN, one of open, cone or symmetric. Omitted when unknown.
+	NatType *string `json:"nat_type,omitempty"`
+
 	// Os Peer's operating system and version
 	Os string `json:"os"`
 
@@ -445,6 +1094,9 @@ type PeerBatch struct {
 	// AccessiblePeersCount Number of accessible peers
 	AccessiblePeersCount int `json:"accessible_peers_count"`
 
+	// AdvertisedNetworks Networks of the peer's local network the peer offers to route, as reported by the peer. Routes through the peer are created separately.
+	AdvertisedNetworks *[]string `json:"advertised_networks,omitempty"`
+
 	// ApprovalRequired (Cloud only) Indicates whether peer needs approval
 	ApprovalRequired *bool `json:"approval_required,omitempty"`
 
@@ -454,6 +1106,9 @@ type PeerBatch struct {
 	// DnsLabel Peer's DNS label is the parsed peer name for domain resolution. It is used to form an FQDN by appending the account's domain to the peer label. e.g. peer-dns-label.netbird.cloud
 	DnsLabel string `json:"dns_label"`
 
+	// ForceRelay Indicates whether the connections to and from the peer skip the direct connection attempts and go through a relay
+	ForceRelay bool `json:"force_relay"`
+
 	// Groups Groups that the peer belongs to
 	Groups []GroupMinimum `json:"groups"`
 
@@ -463,6 +1118,9 @@ type PeerBatch struct {
 	// Id Peer ID
 	Id string `json:"id"`
 
+	// Inactive Indicates whether the inactivity cleanup of the account flagged the peer as inactive. It is cleared when the peer connects again.
+	Inactive *bool `json:"inactive,omitempty"`
+
 	// Ip Peer's IP address
 	Ip string `json:"ip"`
 
@@ -481,6 +1139,9 @@ type PeerBatch struct {
 	// Name Peer's hostname
 	Name string `json:"name"`
 
+	// NatType Type of the NAT the peer is behind as classified by the peer with STUN, one of open, cone or symmetric. Omitted when unknown.
+	NatType *string `json:"nat_type,omitempty"`
+
 	// Os Peer's operating system and version
 	Os string `json:"os"`
 
@@ -506,10 +1167,124 @@ type PeerMinimum struct {
 	Name string `json:"name"`
 }
 
+// PeerNetworkMap Network map the peer receives from the management service, rendered for troubleshooting
+type PeerNetworkMap struct {
+	DnsConfig PeerNetworkMapDNSConfig `json:"dns_config"`
+
+	// FirewallRules Firewall rules the peer applies to the traffic of the remote peers
+	FirewallRules []PeerNetworkMapFirewallRule `json:"firewall_rules"`
+
+	// OfflinePeers Peers the peer is allowed to connect to which are offline, e.g. with an expired login
+	OfflinePeers []PeerNetworkMapRemotePeer `json:"offline_peers"`
+	PeerConfig   PeerNetworkMapPeerConfig   `json:"peer_config"`
+
+	// RemotePeers Peers the peer connects to
+	RemotePeers []PeerNetworkMapRemotePeer `json:"remote_peers"`
+
+	// Routes Routes the peer routes the traffic through
+	Routes []Route `json:"routes"`
+
+	// Serial Serial of the network map, it increments with every change of the account network
+	Serial int64 `json:"serial"`
+
+	// StaticRoutes Static routes the peer installs or routes for the other peers, permitted by their policies
+	StaticRoutes []Route `json:"static_routes"`
+}
+
+// PeerNetworkMapCustomZone defines model for PeerNetworkMapCustomZone.
+type PeerNetworkMapCustomZone struct {
+	// Domain Domain of the zone
+	Domain string `json:"domain"`
+
+	// Records Records of the zone in the zone file format
+	Records []string `json:"records"`
+}
+
+// PeerNetworkMapDNSConfig defines model for PeerNetworkMapDNSConfig.
+type PeerNetworkMapDNSConfig struct {
+	// CustomZones Zones the peer answers the DNS queries of, e.g. the peers zone
+	CustomZones []PeerNetworkMapCustomZone `json:"custom_zones"`
+
+	// NameserverGroups Nameserver groups the peer forwards the DNS queries to
+	NameserverGroups []NameserverGroup `json:"nameserver_groups"`
+
+	// ServiceEnable Indicates whether the DNS service of the peer is enabled
+	ServiceEnable bool `json:"service_enable"`
+}
+
+// PeerNetworkMapFirewallRule defines model for PeerNetworkMapFirewallRule.
+type PeerNetworkMapFirewallRule struct {
+	// Action Action applied to the traffic
+	Action PeerNetworkMapFirewallRuleAction `json:"action"`
+
+	// Direction Direction of the traffic, in for the traffic coming from the remote peer
+	Direction PeerNetworkMapFirewallRuleDirection `json:"direction"`
+
+	// PeerIp IP address of the remote peer, 0.0.0.0 for all the peers
+	PeerIp string `json:"peer_ip"`
+
+	// Port Port of the traffic, all the ports when empty
+	Port *string `json:"port,omitempty"`
+
+	// Protocol Protocol of the traffic
+	Protocol PeerNetworkMapFirewallRuleProtocol `json:"protocol"`
+}
+
+// PeerNetworkMapFirewallRuleAction Action applied to the traffic
+type PeerNetworkMapFirewallRuleAction string
+
+// PeerNetworkMapFirewallRuleDirection Direction of the traffic, in for the traffic coming from the remote peer
+type PeerNetworkMapFirewallRuleDirection string
+
+// PeerNetworkMapFirewallRuleProtocol Protocol of the traffic
+type PeerNetworkMapFirewallRuleProtocol string
+
+// PeerNetworkMapPeerConfig defines model for PeerNetworkMapPeerConfig.
+type PeerNetworkMapPeerConfig struct {
+	// Address Peer's address with the network mask
+	Address string `json:"address"`
+
+	// AddressV6 Peer's IPv6 address with the network mask, when the IPv6 allocation is enabled
+	AddressV6 *string `json:"address_v6,omitempty"`
+
+	// Fqdn Peer's fully qualified domain name
+	Fqdn string `json:"fqdn"`
+
+	// SshEnabled Indicates whether the SSH server of the peer is enabled
+	SshEnabled bool `json:"ssh_enabled"`
+}
+
+// PeerNetworkMapRemotePeer defines model for PeerNetworkMapRemotePeer.
+type PeerNetworkMapRemotePeer struct {
+	// AddressV6 Peer's IPv6 address, when the IPv6 allocation is enabled
+	AddressV6 *string `json:"address_v6,omitempty"`
+
+	// AllowedIps Addresses the peer is allowed to send the traffic from
+	AllowedIps []string `json:"allowed_ips"`
+
+	// Fqdn Peer's fully qualified domain name
+	Fqdn string `json:"fqdn"`
+
+	// Id Peer ID
+	Id string `json:"id"`
+
+	// Name Peer's hostname
+	Name string `json:"name"`
+
+	// SshPubKey Peer's SSH public key, when the peer has a valid one
+	SshPubKey *string `json:"ssh_pub_key,omitempty"`
+
+	// WgPubKey Peer's WireGuard public key
+	WgPubKey string `json:"wg_pub_key"`
+}
+
 // PeerRequest defines model for PeerRequest.
 type PeerRequest struct {
 	// ApprovalRequired (Cloud only) Indicates whether peer needs approval
-	ApprovalRequired       *bool  `json:"approval_required,omitempty"`
+	ApprovalRequired *bool `json:"approval_required,omitempty"`
+
+	// ForceRelay Makes the connections to and from the peer skip the direct connection attempts and go through a relay. It is kept unchanged when omitted.
+	ForceRelay             *bool  `json:"force_relay,omitempty"`
 	LoginExpirationEnabled bool   `json:"login_expiration_enabled"`
 	Name                   string `json:"name"`
 	SshEnabled             bool   `json:"ssh_enabled"`
@@ -534,6 +1309,9 @@ type PersonalAccessToken struct {
 
 	// Name Name of the token
 	Name string `json:"name"`
+
+	// Role Role limiting the write operations of the token, empty keeps the permissions of the user
+	Role *string `json:"role,omitempty"`
 }
 
 // PersonalAccessTokenGenerated defines model for PersonalAccessTokenGenerated.
@@ -551,6 +1329,9 @@ type PersonalAccessTokenRequest struct {
 
 	// Name Name of the token
 	Name string `json:"name"`
+
+	// Role Role limiting the write operations of the token to the ones the role allows on top of the permissions of the user, e.g. auditor creates a read-only token. Omitted keeps the permissions of the user.
+	Role *string `json:"role,omitempty"`
 }
 
 // Policy defines model for Policy.
@@ -591,12 +1372,18 @@ type PolicyRule struct {
 	// Action Policy rule accept or drops packets
 	Action PolicyRuleAction `json:"action"`
 
+	// AdditionalProtocols Policy rule protocols of the traffic besides the protocol, e.g. ICMP next to TCP. Can't be combined with the all protocol, the ports only apply to TCP and UDP and ICMP is matched in both directions of a one way rule.
+	AdditionalProtocols *[]PolicyRuleAdditionalProtocols `json:"additional_protocols,omitempty"`
+
 	// Bidirectional Define if the rule is applicable in both directions, sources, and destinations.
 	Bidirectional bool `json:"bidirectional"`
 
 	// Description Policy rule friendly description
 	Description *string `json:"description,omitempty"`
 
+	// DestinationResources Policy rule destination network resource IDs, the source peers are granted access to the resource networks routed by the account routes
+	DestinationResources *[]string `json:"destination_resources,omitempty"`
+
 	// Destinations Policy rule destination group IDs
 	Destinations []GroupMinimum `json:"destinations"`
 
@@ -615,6 +1402,9 @@ type PolicyRule struct {
 	// Protocol Policy rule type of the traffic
 	Protocol PolicyRuleProtocol `json:"protocol"`
 
+	// Schedule Limits the policy rule to days of the week and time ranges of the day, the rule is always active without a schedule. The days and the times follow the wall clock of the time zone, including the daylight saving time changes.
+	Schedule *PolicySchedule `json:"schedule,omitempty"`
+
 	// Sources Policy rule source group IDs
 	Sources []GroupMinimum `json:"sources"`
 }
@@ -622,6 +1412,9 @@ type PolicyRule struct {
 // PolicyRuleAction Policy rule accept or drops packets
 type PolicyRuleAction string
 
+// PolicyRuleAdditionalProtocols defines model for PolicyRule.AdditionalProtocols.
+type PolicyRuleAdditionalProtocols string
+
 // PolicyRuleProtocol Policy rule type of the traffic
 type PolicyRuleProtocol string
 
@@ -630,12 +1423,18 @@ type PolicyRuleMinimum struct {
 	// Action Policy rule accept or drops packets
 	Action PolicyRuleMinimumAction `json:"action"`
 
+	// AdditionalProtocols Policy rule protocols of the traffic besides the protocol, e.g. ICMP next to TCP. Can't be combined with the all protocol, the ports only apply to TCP and UDP and ICMP is matched in both directions of a one way rule.
+	AdditionalProtocols *[]PolicyRuleMinimumAdditionalProtocols `json:"additional_protocols,omitempty"`
+
 	// Bidirectional Define if the rule is applicable in both directions, sources, and destinations.
 	Bidirectional bool `json:"bidirectional"`
 
 	// Description Policy rule friendly description
 	Description *string `json:"description,omitempty"`
 
+	// DestinationResources Policy rule destination network resource IDs, the source peers are granted access to the resource networks routed by the account routes
+	DestinationResources *[]string `json:"destination_resources,omitempty"`
+
 	// Enabled Policy rule status
 	Enabled bool `json:"enabled"`
 
@@ -650,11 +1449,17 @@ type PolicyRuleMinimum struct {
 
 	// Protocol Policy rule type of the traffic
 	Protocol PolicyRuleMinimumProtocol `json:"protocol"`
+
+	// Schedule Limits the policy rule to days of the week and time ranges of the day, the rule is always active without a schedule. The days and the times follow the wall clock of the time zone, including the daylight saving time changes.
+	Schedule *PolicySchedule `json:"schedule,omitempty"`
 }
 
 // PolicyRuleMinimumAction Policy rule accept or drops packets
 type PolicyRuleMinimumAction string
 
+// PolicyRuleMinimumAdditionalProtocols defines model for PolicyRuleMinimum.AdditionalProtocols.
+type PolicyRuleMinimumAdditionalProtocols string
+
 // PolicyRuleMinimumProtocol Policy rule type of the traffic
 type PolicyRuleMinimumProtocol string
 
@@ -663,12 +1468,18 @@ type PolicyRuleUpdate struct {
 	// Action Policy rule accept or drops packets
 	Action PolicyRuleUpdateAction `json:"action"`
 
+	// AdditionalProtocols Policy rule protocols of the traffic besides the protocol, e.g. ICMP next to TCP. Can't be combined with the all protocol, the ports only apply to TCP and UDP and ICMP is matched in both directions of a one way rule.
+	AdditionalProtocols *[]PolicyRuleUpdateAdditionalProtocols `json:"additional_protocols,omitempty"`
+
 	// Bidirectional Define if the rule is applicable in both directions, sources, and destinations.
 	Bidirectional bool `json:"bidirectional"`
 
 	// Description Policy rule friendly description
 	Description *string `json:"description,omitempty"`
 
+	// DestinationResources Policy rule destination network resource IDs, the source peers are granted access to the resource networks routed by the account routes
+	DestinationResources *[]string `json:"destination_resources,omitempty"`
+
 	// Destinations Policy rule destination group IDs
 	Destinations []string `json:"destinations"`
 
@@ -687,6 +1498,9 @@ type PolicyRuleUpdate struct {
 	// Protocol Policy rule type of the traffic
 	Protocol PolicyRuleUpdateProtocol `json:"protocol"`
 
+	// Schedule Limits the policy rule to days of the week and time ranges of the day, the rule is always active without a schedule. The days and the times follow the wall clock of the time zone, including the daylight saving time changes.
+	Schedule *PolicySchedule `json:"schedule,omitempty"`
+
 	// Sources Policy rule source group IDs
 	Sources []string `json:"sources"`
 }
@@ -694,9 +1508,90 @@ type PolicyRuleUpdate struct {
 // PolicyRuleUpdateAction Policy rule accept or drops packets
 type PolicyRuleUpdateAction string
 
+// PolicyRuleUpdateAdditionalProtocols defines model for PolicyRuleUpdate.AdditionalProtocols.
+type PolicyRuleUpdateAdditionalProtocols string
+
 // PolicyRuleUpdateProtocol Policy rule type of the traffic
 type PolicyRuleUpdateProtocol string
 
+// PolicySchedule Limits the policy rule to days of the week and time ranges of the day, the rule is always active without a schedule. The days and the times follow the wall clock of the time zone, including the daylight saving time changes.
+type PolicySchedule struct {
+	// Days Days of the week the rule is active on, every day when empty
+	Days *[]PolicyScheduleDays `json:"days,omitempty"`
+
+	// TimeRanges Time ranges of the day the rule is active in, the whole day when empty
+	TimeRanges *[]PolicyTimeRange `json:"time_ranges,omitempty"`
+
+	// Timezone IANA name of the time zone of the days and the time ranges, UTC when empty
+	Timezone *string `json:"timezone,omitempty"`
+}
+
+// PolicyScheduleDays defines model for PolicySchedule.Days.
+type PolicyScheduleDays string
+
+// PolicySimulation defines model for PolicySimulation.
+type PolicySimulation struct {
+	// Action Action of the policy rule deciding the traffic, omitted when it is denied by default
+	Action *string `json:"action,omitempty"`
+
+	// Allowed Indicates the policies allow the traffic
+	Allowed bool `json:"allowed"`
+
+	// DestinationGroups IDs of the rule groups the destination peer matched
+	DestinationGroups []string `json:"destination_groups"`
+
+	// DestinationPeerId ID of the peer receiving the traffic
+	DestinationPeerId string `json:"destination_peer_id"`
+
+	// PolicyId ID of the policy deciding the traffic, omitted when it is denied by default
+	PolicyId *string `json:"policy_id,omitempty"`
+
+	// PolicyName Name of the policy deciding the traffic, omitted when it is denied by default
+	PolicyName *string `json:"policy_name,omitempty"`
+
+	// Reason Explanation of the decision, "denied by default (no matching allow)" when no rule matches the traffic
+	Reason string `json:"reason"`
+
+	// RuleId ID of the policy rule deciding the traffic, omitted when it is denied by default
+	RuleId *string `json:"rule_id,omitempty"`
+
+	// RuleName Name of the policy rule deciding the traffic, omitted when it is denied by default
+	RuleName *string `json:"rule_name,omitempty"`
+
+	// SourceGroups IDs of the rule groups the source peer matched
+	SourceGroups []string `json:"source_groups"`
+
+	// SourcePeerId ID of the peer sending the traffic
+	SourcePeerId string `json:"source_peer_id"`
+}
+
+// PolicySimulationRequest defines model for PolicySimulationRequest.
+type PolicySimulationRequest struct {
+	// Destination ID or IP address of the peer receiving the traffic
+	Destination string `json:"destination"`
+
+	// Port Destination port of the tcp and udp traffic, not allowed for icmp
+	Port *int `json:"port,omitempty"`
+
+	// Protocol Type of the traffic
+	Protocol PolicySimulationRequestProtocol `json:"protocol"`
+
+	// Source ID or IP address of the peer sending the traffic
+	Source string `json:"source"`
+}
+
+// PolicySimulationRequestProtocol Type of the traffic
+type PolicySimulationRequestProtocol string
+
+// PolicyTimeRange defines model for PolicyTimeRange.
+type PolicyTimeRange struct {
+	// End End of the time range in the HH:MM format, excluded. A range ending before it starts spans midnight and belongs to the day it starts on.
+	End string `json:"end"`
+
+	// Start Start of the time range in the HH:MM format
+	Start string `json:"start"`
+}
+
 // PolicyUpdate defines model for PolicyUpdate.
 type PolicyUpdate struct {
 	// Description Policy friendly description
@@ -715,6 +1610,84 @@ type PolicyUpdate struct {
 	Rules []PolicyRuleUpdate `json:"rules"`
 }
 
+// PostureCheck defines model for PostureCheck.
+type PostureCheck struct {
+	// Action What happens to the peers failing the check. The denied peers can't register or log in, the quarantined ones are excluded from the network until they comply.
+	Action PostureCheckAction `json:"action"`
+
+	// AllowedKernels Kernels the peers may run as reported by the client, e.g. Linux or Darwin. Empty allows any.
+	AllowedKernels *[]string `json:"allowed_kernels,omitempty"`
+
+	// AllowedOs Operating systems the peers may run as reported by the client, e.g. linux, darwin, windows, android or ios. Empty allows any.
+	AllowedOs *[]string `json:"allowed_os,omitempty"`
+
+	// Description Posture check friendly description
+	Description string `json:"description"`
+
+	// Enabled Posture check status
+	Enabled bool `json:"enabled"`
+
+	// Id Posture check ID
+	Id string `json:"id"`
+
+	// MinClientVersion Oldest NetBird client version the peers may run, e.g. 0.27.0. Empty doesn't check it.
+	MinClientVersion *string `json:"min_client_version,omitempty"`
+
+	// MinOsVersions Oldest OS version the peers may run by operating system. The peers of the other operating systems aren't checked.
+	MinOsVersions *map[string]string `json:"min_os_versions,omitempty"`
+
+	// Name Posture check name, unique in the account
+	Name string `json:"name"`
+}
+
+// PostureCheckAction What happens to the peers failing the check. The denied peers can't register or log in, the quarantined ones are excluded from the network until they comply.
+type PostureCheckAction string
+
+// PostureCheckFailure defines model for PostureCheckFailure.
+type PostureCheckFailure struct {
+	// Action Action of the posture check
+	Action string `json:"action"`
+
+	// CheckId ID of the posture check the peer fails
+	CheckId string `json:"check_id"`
+
+	// CheckName Name of the posture check the peer fails
+	CheckName string `json:"check_name"`
+
+	// Reason Why the peer fails the check
+	Reason string `json:"reason"`
+}
+
+// PostureCheckRequest defines model for PostureCheckRequest.
+type PostureCheckRequest struct {
+	// Action What happens to the peers failing the check. The denied peers can't register or log in, the quarantined ones are excluded from the network until they comply.
+	Action PostureCheckRequestAction `json:"action"`
+
+	// AllowedKernels Kernels the peers may run as reported by the client, e.g. Linux or Darwin. Empty allows any.
+	AllowedKernels *[]string `json:"allowed_kernels,omitempty"`
+
+	// AllowedOs Operating systems the peers may run as reported by the client, e.g. linux, darwin, windows, android or ios. Empty allows any.
+	AllowedOs *[]string `json:"allowed_os,omitempty"`
+
+	// Description Posture check friendly description
+	Description string `json:"description"`
+
+	// Enabled Posture check status
+	Enabled bool `json:"enabled"`
+
+	// MinClientVersion Oldest NetBird client version the peers may run, e.g. 0.27.0. Empty doesn't check it.
+	MinClientVersion *string `json:"min_client_version,omitempty"`
+
+	// MinOsVersions Oldest OS version the peers may run by operating system. The peers of the other operating systems aren't checked.
+	MinOsVersions *map[string]string `json:"min_os_versions,omitempty"`
+
+	// Name Posture check name, unique in the account
+	Name string `json:"name"`
+}
+
+// PostureCheckRequestAction What happens to the peers failing the check. The denied peers can't register or log in, the quarantined ones are excluded from the network until they comply.
+type PostureCheckRequestAction string
+
 // Route defines model for Route.
 type Route struct {
 	// Description Route description
@@ -864,10 +1837,16 @@ type SetupKey struct {
 	// Name Setup key name identifier
 	Name string `json:"name"`
 
+	// PeerType Type of the peers registered with this key. ephemeral peers are removed after staying disconnected, persistent peers never are, regardless of the client.
+	PeerType SetupKeyPeerType `json:"peer_type"`
+
+	// PendingApproval Indicates that the key waits for the approval of an admin other than its creator, it can't register peers meanwhile
+	PendingApproval bool `json:"pending_approval"`
+
 	// Revoked Setup key revocation status
 	Revoked bool `json:"revoked"`
 
-	// State Setup key status, "valid", "overused","expired" or "revoked"
+	// State Setup key status, "valid", "overused", "expired", "revoked" or "pending"
 	State string `json:"state"`
 
 	// Type Setup key type, one-off for single time usage and reusable
@@ -886,6 +1865,9 @@ type SetupKey struct {
 	Valid bool `json:"valid"`
 }
 
+// SetupKeyPeerType Type of the peers registered with this key. ephemeral peers are removed after staying disconnected, persistent peers never are, regardless of the client.
+type SetupKeyPeerType string
+
 // SetupKeyRequest defines model for SetupKeyRequest.
 type SetupKeyRequest struct {
 	// AutoGroups List of group IDs to auto-assign to peers registered with this key
@@ -900,6 +1882,9 @@ type SetupKeyRequest struct {
 	// Name Setup Key name
 	Name string `json:"name"`
 
+	// PeerType Type of the peers registered with this key, enforced on the registration. Takes precedence over ephemeral, which has to match it when both are set.
+	PeerType *SetupKeyRequestPeerType `json:"peer_type,omitempty"`
+
 	// Revoked Setup key revocation status
 	Revoked bool `json:"revoked"`
 
@@ -910,6 +1895,75 @@ type SetupKeyRequest struct {
 	UsageLimit int `json:"usage_limit"`
 }
 
+// SetupKeyRequestPeerType Type of the peers registered with this key, enforced on the registration. Takes precedence over ephemeral, which has to match it when both are set.
+type SetupKeyRequestPeerType string
+
+// StaticRoute defines model for StaticRoute.
+type StaticRoute struct {
+	// Description Static route friendly description
+	Description string `json:"description"`
+
+	// Enabled Static route status
+	Enabled bool `json:"enabled"`
+
+	// Id Static route ID
+	Id string `json:"id"`
+
+	// Masquerade Indicate if the routing peer should masquerade the traffic to the network
+	Masquerade bool `json:"masquerade"`
+
+	// Network Network range of the route in CIDR notation. It can't overlap with the network of the account.
+	Network string `json:"network"`
+
+	// PeerId ID of the peer installing the route
+	PeerId string `json:"peer_id"`
+
+	// PolicyId ID of the policy permitting the traffic of the peer to the routing peer. The route is withdrawn from the peer while the policy doesn't permit it.
+	PolicyId string `json:"policy_id"`
+
+	// RoutingPeerId ID of the peer the traffic to the network is routed through
+	RoutingPeerId string `json:"routing_peer_id"`
+}
+
+// StaticRouteRequest defines model for StaticRouteRequest.
+type StaticRouteRequest struct {
+	// Description Static route friendly description
+	Description string `json:"description"`
+
+	// Enabled Static route status
+	Enabled bool `json:"enabled"`
+
+	// Masquerade Indicate if the routing peer should masquerade the traffic to the network
+	Masquerade bool `json:"masquerade"`
+
+	// Network Network range of the route in CIDR notation. It can't overlap with the network of the account.
+	Network string `json:"network"`
+
+	// PeerId ID of the peer installing the route
+	PeerId string `json:"peer_id"`
+
+	// PolicyId ID of the policy permitting the traffic of the peer to the routing peer. The route is withdrawn from the peer while the policy doesn't permit it.
+	PolicyId string `json:"policy_id"`
+
+	// RoutingPeerId ID of the peer the traffic to the network is routed through
+	RoutingPeerId string `json:"routing_peer_id"`
+}
+
+// SyncSession defines model for SyncSession.
+type SyncSession struct {
+	// ConnectedSince Time the peer opened the sync session
+	ConnectedSince time.Time `json:"connected_since"`
+
+	// PeerId ID of the peer holding the sync session
+	PeerId string `json:"peer_id"`
+
+	// PeerKey WireGuard public key of the peer
+	PeerKey string `json:"peer_key"`
+
+	// RemoteAddr Address the sync session was opened from
+	RemoteAddr string `json:"remote_addr"`
+}
+
 // User defines model for User.
 type User struct {
 	// AutoGroups Group IDs to auto-assign to peers registered by this user
@@ -939,7 +1993,7 @@ type User struct {
 	// Name User's name from idp provider
 	Name string `json:"name"`
 
-	// Role User's NetBird account role
+	// Role User's NetBird account role, one of owner, admin, network_admin, auditor or user
 	Role string `json:"role"`
 
 	// Status User's status
@@ -963,7 +2017,7 @@ type UserCreateRequest struct {
 	// Name User's full name
 	Name *string `json:"name,omitempty"`
 
-	// Role User's NetBird account role
+	// Role User's NetBird account role, one of owner, admin, network_admin, auditor or user
 	Role string `json:"role"`
 }
 
@@ -975,10 +2029,28 @@ type UserRequest struct {
 	// IsBlocked If set to true then user is blocked and can't use the system
 	IsBlocked bool `json:"is_blocked"`
 
-	// Role User's NetBird account role
+	// Role User's NetBird account role, one of owner, admin, network_admin, auditor or user
 	Role string `json:"role"`
 }
 
+// GetApiAccountsAccountIdEffectiveAclParams defines parameters for GetApiAccountsAccountIdEffectiveAcl.
+type GetApiAccountsAccountIdEffectiveAclParams struct {
+	// PeerId Returns the ACL of a single peer
+	PeerId *string `form:"peer_id,omitempty" json:"peer_id,omitempty"`
+}
+
+// GetApiAccountsAccountIdInactivePeersParams defines parameters for GetApiAccountsAccountIdInactivePeers.
+type GetApiAccountsAccountIdInactivePeersParams struct {
+	// Threshold Inactivity threshold in seconds previewed instead of the one of the account settings
+	Threshold *int `form:"threshold,omitempty" json:"threshold,omitempty"`
+}
+
+// GetApiAccountsAccountIdOutdatedPeersParams defines parameters for GetApiAccountsAccountIdOutdatedPeers.
+type GetApiAccountsAccountIdOutdatedPeersParams struct {
+	// MinVersion Minimum client version previewed instead of the one of the account settings
+	MinVersion *string `form:"min_version,omitempty" json:"min_version,omitempty"`
+}
+
 // GetApiUsersParams defines parameters for GetApiUsers.
 type GetApiUsersParams struct {
 	// ServiceUser Filters users and returns either regular users or service users
@@ -988,12 +2060,24 @@ type GetApiUsersParams struct {
 // PutApiAccountsAccountIdJSONRequestBody defines body for PutApiAccountsAccountId for application/json ContentType.
 type PutApiAccountsAccountIdJSONRequestBody = AccountRequest
 
+// PutApiAccountsAccountIdNetworkJSONRequestBody defines body for PutApiAccountsAccountIdNetwork for application/json ContentType.
+type PutApiAccountsAccountIdNetworkJSONRequestBody = AccountNetworkRequest
+
+// PostApiBatchJSONRequestBody defines body for PostApiBatch for application/json ContentType.
+type PostApiBatchJSONRequestBody = BatchRequest
+
 // PostApiDnsNameserversJSONRequestBody defines body for PostApiDnsNameservers for application/json ContentType.
 type PostApiDnsNameserversJSONRequestBody = NameserverGroupRequest
 
 // PutApiDnsNameserversNsgroupIdJSONRequestBody defines body for PutApiDnsNameserversNsgroupId for application/json ContentType.
 type PutApiDnsNameserversNsgroupIdJSONRequestBody = NameserverGroupRequest
 
+// PostApiDnsRecordsJSONRequestBody defines body for PostApiDnsRecords for application/json ContentType.
+type PostApiDnsRecordsJSONRequestBody = DNSRecordRequest
+
+// PutApiDnsRecordsRecordIdJSONRequestBody defines body for PutApiDnsRecordsRecordId for application/json ContentType.
+type PutApiDnsRecordsRecordIdJSONRequestBody = DNSRecordRequest
+
 // PutApiDnsSettingsJSONRequestBody defines body for PutApiDnsSettings for application/json ContentType.
 type PutApiDnsSettingsJSONRequestBody = DNSSettings
 
@@ -1003,15 +2087,39 @@ type PostApiGroupsJSONRequestBody = GroupRequest
 // PutApiGroupsGroupIdJSONRequestBody defines body for PutApiGroupsGroupId for application/json ContentType.
 type PutApiGroupsGroupIdJSONRequestBody = GroupRequest
 
+// PostApiIpReservationsJSONRequestBody defines body for PostApiIpReservations for application/json ContentType.
+type PostApiIpReservationsJSONRequestBody = IPReservationRequest
+
+// PostApiNetworkResourcesJSONRequestBody defines body for PostApiNetworkResources for application/json ContentType.
+type PostApiNetworkResourcesJSONRequestBody = NetworkResourceRequest
+
+// PutApiNetworkResourcesResourceIdJSONRequestBody defines body for PutApiNetworkResourcesResourceId for application/json ContentType.
+type PutApiNetworkResourcesResourceIdJSONRequestBody = NetworkResourceRequest
+
+// PostApiOrgKeysJSONRequestBody defines body for PostApiOrgKeys for application/json ContentType.
+type PostApiOrgKeysJSONRequestBody = OrgKeyRequest
+
+// PutApiOrgKeysKeyIdJSONRequestBody defines body for PutApiOrgKeysKeyId for application/json ContentType.
+type PutApiOrgKeysKeyIdJSONRequestBody = OrgKeyRequest
+
 // PutApiPeersPeerIdJSONRequestBody defines body for PutApiPeersPeerId for application/json ContentType.
 type PutApiPeersPeerIdJSONRequestBody = PeerRequest
 
 // PostApiPoliciesJSONRequestBody defines body for PostApiPolicies for application/json ContentType.
 type PostApiPoliciesJSONRequestBody = PolicyUpdate
 
+// PostApiPoliciesSimulateJSONRequestBody defines body for PostApiPoliciesSimulate for application/json ContentType.
+type PostApiPoliciesSimulateJSONRequestBody = PolicySimulationRequest
+
 // PutApiPoliciesPolicyIdJSONRequestBody defines body for PutApiPoliciesPolicyId for application/json ContentType.
 type PutApiPoliciesPolicyIdJSONRequestBody = PolicyUpdate
 
+// PostApiPostureChecksJSONRequestBody defines body for PostApiPostureChecks for application/json ContentType.
+type PostApiPostureChecksJSONRequestBody = PostureCheckRequest
+
+// PutApiPostureChecksPostureCheckIdJSONRequestBody defines body for PutApiPostureChecksPostureCheckId for application/json ContentType.
+type PutApiPostureChecksPostureCheckIdJSONRequestBody = PostureCheckRequest
+
 // PostApiRoutesJSONRequestBody defines body for PostApiRoutes for application/json ContentType.
 type PostApiRoutesJSONRequestBody = RouteRequest
 
@@ -1030,6 +2138,12 @@ type PostApiSetupKeysJSONRequestBody = SetupKeyRequest
 // PutApiSetupKeysKeyIdJSONRequestBody defines body for PutApiSetupKeysKeyId for application/json ContentType.
 type PutApiSetupKeysKeyIdJSONRequestBody = SetupKeyRequest
 
+// PostApiStaticRoutesJSONRequestBody defines body for PostApiStaticRoutes for application/json ContentType.
+type PostApiStaticRoutesJSONRequestBody = StaticRouteRequest
+
+// PutApiStaticRoutesRouteIdJSONRequestBody defines body for PutApiStaticRoutesRouteId for application/json ContentType.
+type PutApiStaticRoutesRouteIdJSONRequestBody = StaticRouteRequest
+
 // PostApiUsersJSONRequestBody defines body for PostApiUsers for application/json ContentType.
 type PostApiUsersJSONRequestBody = UserCreateRequest
 
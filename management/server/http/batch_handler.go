@@ -0,0 +1,201 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// BatchHandler is a handler applying the changes of several resources of the account atomically
+type BatchHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewBatchHandler creates a new BatchHandler
+func NewBatchHandler(accountManager server.AccountManager, authCfg AuthCfg) *BatchHandler {
+	return &BatchHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// ApplyBatch applies the operations of the request, either all of them or none
+func (h *BatchHandler) ApplyBatch(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiBatchJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	batch, err := toBatch(account, req.Operations)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	// the account manager checks the permissions of the user, the role limiting the token is checked here
+	if err := checkTokenRole(server.UserRole(claims.TokenRole), batch.operations); err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	if err := h.accountManager.ApplyBatch(account.Id, user.Id, batch.operations); err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := api.BatchResponse{Groups: []api.Group{}, Policies: []api.Policy{}}
+	for _, group := range batch.groups {
+		resp.Groups = append(resp.Groups, *toGroupResponse(account, group))
+	}
+	for _, policy := range batch.policies {
+		resp.Policies = append(resp.Policies, *toPolicyResponse(account, policy))
+	}
+
+	util.WriteJSONObject(w, resp)
+}
+
+// checkTokenRole returns an error if the role limiting the token of the request, if any, can't change the resource of
+// an operation
+func checkTokenRole(tokenRole server.UserRole, operations []*server.BatchOperation) error {
+	if tokenRole == "" {
+		return nil
+	}
+	for _, operation := range operations {
+		resource, err := operation.Resource()
+		if err != nil {
+			return err
+		}
+		if !tokenRole.HasPermission(resource, server.OperationWrite) {
+			return status.Errorf(status.PermissionDenied, "the token is not allowed to change the %s", resource)
+		}
+	}
+	return nil
+}
+
+// batch holds the operations converted from a batch request with the groups and policies they save
+type batch struct {
+	operations []*server.BatchOperation
+	groups     []*server.Group
+	policies   []*server.Policy
+	// refs are the IDs of the resources created by the batch by their references
+	refs map[string]string
+}
+
+// toBatch validates the operations the same way as the requests of the single resources and converts them. The groups
+// of the batch are added to the account, so the later operations see them the same way the account manager will.
+func toBatch(account *server.Account, reqOperations []api.BatchOperation) (*batch, error) {
+	b := &batch{refs: make(map[string]string)}
+	for i := range reqOperations {
+		if err := b.add(account, &reqOperations[i]); err != nil {
+			return nil, status.Errorf(errorType(err), "operation %d of the batch is invalid: %s", i, err)
+		}
+	}
+	return b, nil
+}
+
+func (b *batch) add(account *server.Account, reqOperation *api.BatchOperation) error {
+	var id string
+	if reqOperation.Id != nil {
+		id = b.resolve(*reqOperation.Id)
+	}
+
+	var savedID string
+	switch reqOperation.Resource {
+	case api.BatchOperationResourceGroup:
+		if reqOperation.Group == nil {
+			return status.Errorf(status.InvalidArgument, "group of the operation is missing")
+		}
+		group, err := toGroup(account, id, reqOperation.Group)
+		if err != nil {
+			return err
+		}
+		account.Groups[group.ID] = group
+		b.operations = append(b.operations, &server.BatchOperation{Group: group})
+		b.groups = append(b.groups, group)
+		savedID = group.ID
+	case api.BatchOperationResourcePolicy:
+		if reqOperation.Policy == nil {
+			return status.Errorf(status.InvalidArgument, "policy of the operation is missing")
+		}
+		req := *reqOperation.Policy
+		req.Rules = make([]api.PolicyRuleUpdate, 0, len(reqOperation.Policy.Rules))
+		for _, rule := range reqOperation.Policy.Rules {
+			rule.Sources = b.resolveAll(rule.Sources)
+			rule.Destinations = b.resolveAll(rule.Destinations)
+			req.Rules = append(req.Rules, rule)
+		}
+		policy, err := toPolicy(account, id, &req)
+		if err != nil {
+			return err
+		}
+		b.operations = append(b.operations, &server.BatchOperation{Policy: policy})
+		b.policies = append(b.policies, policy)
+		savedID = policy.ID
+	case api.BatchOperationResourceGroupPeer:
+		groupPeer := reqOperation.GroupPeer
+		if groupPeer == nil {
+			return status.Errorf(status.InvalidArgument, "group peer of the operation is missing")
+		}
+		if reqOperation.Ref != nil {
+			return status.Errorf(status.InvalidArgument, "only the groups and policies can be referenced")
+		}
+		b.operations = append(b.operations, &server.BatchOperation{GroupPeer: &server.BatchGroupPeer{
+			GroupID: b.resolve(groupPeer.GroupId),
+			PeerID:  groupPeer.PeerId,
+			Remove:  groupPeer.Remove != nil && *groupPeer.Remove,
+		}})
+		return nil
+	default:
+		return status.Errorf(status.InvalidArgument, "unknown resource %q", reqOperation.Resource)
+	}
+
+	if reqOperation.Ref != nil && *reqOperation.Ref != "" {
+		ref := *reqOperation.Ref
+		if _, ok := b.refs[ref]; ok {
+			return status.Errorf(status.InvalidArgument, "reference %s is used by another operation", ref)
+		}
+		b.refs[ref] = savedID
+	}
+	return nil
+}
+
+// resolve returns the ID of the resource created by the batch with the reference, the ID itself otherwise
+func (b *batch) resolve(id string) string {
+	if resolved, ok := b.refs[id]; ok {
+		return resolved
+	}
+	return id
+}
+
+func (b *batch) resolveAll(ids []string) []string {
+	resolved := make([]string, 0, len(ids))
+	for _, id := range ids {
+		resolved = append(resolved, b.resolve(id))
+	}
+	return resolved
+}
+
+// errorType returns the type of the status error, status.Internal for the other errors
+func errorType(err error) status.Type {
+	if e, ok := status.FromError(err); ok {
+		return e.Type()
+	}
+	return status.Internal
+}
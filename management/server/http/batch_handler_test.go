@@ -0,0 +1,167 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func initBatchTestData(applied *[]*server.BatchOperation) *BatchHandler {
+	return &BatchHandler{
+		accountManager: &mock_server.MockAccountManager{
+			ApplyBatchFunc: func(_, _ string, operations []*server.BatchOperation) error {
+				*applied = operations
+				return nil
+			},
+			GetAccountFromTokenFunc: func(claims jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				user := server.NewAdminUser("test_user")
+				return &server.Account{
+					Id:     claims.AccountId,
+					Domain: "hotmail.com",
+					Peers: map[string]*nbpeer.Peer{
+						"peerA": {ID: "peerA"},
+					},
+					Groups: map[string]*server.Group{
+						"existing": {ID: "existing", Name: "Existing", Issued: server.GroupIssuedAPI},
+					},
+					Users: map[string]*server.User{
+						"test_user": user,
+					},
+				}, user, nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: "test_id",
+				}
+			}),
+		),
+	}
+}
+
+func TestBatchHandler_ApplyBatch(t *testing.T) {
+	tt := []struct {
+		name           string
+		requestBody    string
+		expectedStatus int
+	}{
+		{
+			name:           "Unknown Resource",
+			requestBody:    `{"operations":[{"resource":"route"}]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Missing Group",
+			requestBody:    `{"operations":[{"resource":"group","ref":"web"}]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Unknown Group",
+			requestBody:    `{"operations":[{"resource":"group","id":"unknown","group":{"name":"Web"}}]}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Duplicate Reference",
+			requestBody: `{"operations":[{"resource":"group","ref":"web","group":{"name":"Web"}},` +
+				`{"resource":"group","ref":"web","group":{"name":"Web"}}]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Invalid JSON",
+			requestBody:    `{"operations":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	var applied []*server.BatchOperation
+	h := initBatchTestData(&applied)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/batch", bytes.NewBufferString(tc.requestBody))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/batch", h.ApplyBatch).Methods("POST")
+			router.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	t.Run("References", func(t *testing.T) {
+		body := `{"operations":[` +
+			`{"resource":"group","ref":"web","group":{"name":"Web"}},` +
+			`{"resource":"group_peer","group_peer":{"group_id":"web","peer_id":"peerA"}},` +
+			`{"resource":"policy","policy":{"name":"Web","enabled":true,"rules":[{"name":"HTTPS","enabled":true,` +
+			`"action":"accept","bidirectional":true,"protocol":"tcp","ports":["443"],` +
+			`"sources":["existing"],"destinations":["web"]}]}}]}`
+
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/batch", bytes.NewBufferString(body))
+
+		router := mux.NewRouter()
+		router.HandleFunc("/api/batch", h.ApplyBatch).Methods("POST")
+		router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+		require.Len(t, applied, 3)
+		group := applied[0].Group
+		require.NotNil(t, group)
+		assert.NotEmpty(t, group.ID)
+		assert.Equal(t, server.GroupIssuedAPI, group.Issued)
+		assert.Equal(t, group.ID, applied[1].GroupPeer.GroupID, "the reference of the group should be resolved")
+		require.NotNil(t, applied[2].Policy)
+		assert.Equal(t, []string{"existing"}, applied[2].Policy.Rules[0].Sources)
+		assert.Equal(t, []string{group.ID}, applied[2].Policy.Rules[0].Destinations,
+			"the reference of the group should be resolved in the rules")
+
+		var resp api.BatchResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		require.Len(t, resp.Groups, 1)
+		assert.Equal(t, group.ID, resp.Groups[0].Id)
+		require.Len(t, resp.Policies, 1)
+		assert.Equal(t, "Web", resp.Policies[0].Name)
+	})
+}
+
+func TestBatchHandler_ApplyBatchTokenRole(t *testing.T) {
+	var applied []*server.BatchOperation
+	h := initBatchTestData(&applied)
+	h.claimsExtractor = jwtclaims.NewClaimsExtractor(
+		jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+			return jwtclaims.AuthorizationClaims{
+				UserId:    "test_user",
+				Domain:    "hotmail.com",
+				AccountId: "test_id",
+				TokenRole: string(server.UserRoleAuditor),
+			}
+		}),
+	)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/batch",
+		bytes.NewBufferString(`{"operations":[{"resource":"group","ref":"web","group":{"name":"Web"}}]}`))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/batch", h.ApplyBatch).Methods("POST")
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusForbidden, recorder.Code, "the token limited to the auditor role shouldn't change the groups")
+	assert.Nil(t, applied, "the batch shouldn't be applied")
+}
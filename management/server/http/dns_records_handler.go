@@ -0,0 +1,221 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// DNSRecordsHandler is the custom DNS records handler of the account
+type DNSRecordsHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewDNSRecordsHandler returns a new instance of DNSRecordsHandler handler
+func NewDNSRecordsHandler(accountManager server.AccountManager, authCfg AuthCfg) *DNSRecordsHandler {
+	return &DNSRecordsHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllDNSRecords returns the list of custom DNS records for the account
+func (h *DNSRecordsHandler) GetAllDNSRecords(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	records, err := h.accountManager.ListDNSRecords(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiRecords := make([]*api.DNSRecord, 0)
+	for _, record := range records {
+		apiRecords = append(apiRecords, toDNSRecordResponse(record))
+	}
+
+	util.WriteJSONObject(w, apiRecords)
+}
+
+// CreateDNSRecord handles custom DNS record creation request
+func (h *DNSRecordsHandler) CreateDNSRecord(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiDnsRecordsJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	record, err := toServerDNSRecord("", req)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	record, err = h.accountManager.CreateDNSRecord(account.Id, user.Id, record)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toDNSRecordResponse(record)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// UpdateDNSRecord handles update to a custom DNS record identified by a given ID
+func (h *DNSRecordsHandler) UpdateDNSRecord(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	recordID := mux.Vars(r)["recordId"]
+	if len(recordID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid DNS record ID"), w)
+		return
+	}
+
+	var req api.PutApiDnsRecordsRecordIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	record, err := toServerDNSRecord(recordID, req)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	err = h.accountManager.SaveDNSRecord(account.Id, user.Id, record)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	record, err = h.accountManager.GetDNSRecord(account.Id, user.Id, recordID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toDNSRecordResponse(record)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// DeleteDNSRecord handles custom DNS record deletion request
+func (h *DNSRecordsHandler) DeleteDNSRecord(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	recordID := mux.Vars(r)["recordId"]
+	if len(recordID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid DNS record ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeleteDNSRecord(account.Id, recordID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetDNSRecord handles a custom DNS record Get request identified by ID
+func (h *DNSRecordsHandler) GetDNSRecord(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	recordID := mux.Vars(r)["recordId"]
+	if len(recordID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid DNS record ID"), w)
+		return
+	}
+
+	record, err := h.accountManager.GetDNSRecord(account.Id, user.Id, recordID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toDNSRecordResponse(record)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+func toServerDNSRecord(recordID string, req api.DNSRecordRequest) (*server.DNSRecord, error) {
+	recordType, ok := dns.StringToType[string(req.Type)]
+	if !ok {
+		return nil, status.Errorf(status.InvalidArgument, "invalid DNS record type %s", req.Type)
+	}
+
+	var groups []string
+	if req.Groups != nil {
+		groups = *req.Groups
+	}
+
+	return &server.DNSRecord{
+		ID:     recordID,
+		Name:   req.Name,
+		Type:   int(recordType),
+		TTL:    req.Ttl,
+		RData:  req.Rdata,
+		Groups: groups,
+	}, nil
+}
+
+func toDNSRecordResponse(record *server.DNSRecord) *api.DNSRecord {
+	groups := make([]string, 0, len(record.Groups))
+	groups = append(groups, record.Groups...)
+
+	return &api.DNSRecord{
+		Id:     record.ID,
+		Name:   record.Name,
+		Type:   api.DNSRecordType(dns.Type(record.Type).String()),
+		Ttl:    record.TTL,
+		Rdata:  record.RData,
+		Groups: &groups,
+	}
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingDNSRecordID  = "existingDNSRecordID"
+	notFoundDNSRecordID  = "notFoundDNSRecordID"
+	testDNSRecordAccount = "test_id"
+)
+
+var testingDNSRecordAccount = &server.Account{
+	Id:     testDNSRecordAccount,
+	Domain: "hotmail.com",
+	Users: map[string]*server.User{
+		"test_user": server.NewAdminUser("test_user"),
+	},
+}
+
+var baseExistingDNSRecord = &server.DNSRecord{
+	ID:    existingDNSRecordID,
+	Name:  "service",
+	Type:  int(dns.TypeA),
+	TTL:   300,
+	RData: "100.64.0.10",
+}
+
+func initDNSRecordsTestData() *DNSRecordsHandler {
+	return &DNSRecordsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetDNSRecordFunc: func(_, _, recordID string) (*server.DNSRecord, error) {
+				if recordID == existingDNSRecordID {
+					return baseExistingDNSRecord.Copy(), nil
+				}
+				return nil, status.Errorf(status.NotFound, "DNS record with ID %s not found", recordID)
+			},
+			CreateDNSRecordFunc: func(_, _ string, record *server.DNSRecord) (*server.DNSRecord, error) {
+				record = record.Copy()
+				record.ID = existingDNSRecordID
+				return record, nil
+			},
+			SaveDNSRecordFunc: func(_, _ string, record *server.DNSRecord) error {
+				if record.ID == existingDNSRecordID {
+					return nil
+				}
+				return status.Errorf(status.NotFound, "DNS record with ID %s was not found", record.ID)
+			},
+			DeleteDNSRecordFunc: func(_, _, _ string) error {
+				return nil
+			},
+			ListDNSRecordsFunc: func(_, _ string) ([]*server.DNSRecord, error) {
+				return []*server.DNSRecord{baseExistingDNSRecord.Copy()}, nil
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingDNSRecordAccount, testingDNSRecordAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testDNSRecordAccount,
+				}
+			}),
+		),
+	}
+}
+
+func TestDNSRecordsHandlers(t *testing.T) {
+	tt := []struct {
+		name           string
+		expectedStatus int
+		expectedBody   bool
+		expectedRecord *api.DNSRecord
+		requestType    string
+		requestPath    string
+		requestBody    io.Reader
+	}{
+		{
+			name:           "Get Existing DNS Record",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/dns/records/" + existingDNSRecordID,
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRecord: toDNSRecordResponse(baseExistingDNSRecord),
+		},
+		{
+			name:           "Get Not Existing DNS Record",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/dns/records/" + notFoundDNSRecordID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "POST OK",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/dns/records",
+			requestBody:    bytes.NewBufferString(`{"name":"www","type":"CNAME","ttl":60,"rdata":"example.com"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRecord: &api.DNSRecord{
+				Id:     existingDNSRecordID,
+				Name:   "www",
+				Type:   api.DNSRecordTypeCNAME,
+				Ttl:    60,
+				Rdata:  "example.com",
+				Groups: &[]string{},
+			},
+		},
+		{
+			name:           "POST With Groups OK",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/dns/records",
+			requestBody:    bytes.NewBufferString(`{"name":"app","type":"A","ttl":60,"rdata":"100.64.0.20","groups":["group1"]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRecord: &api.DNSRecord{
+				Id:     existingDNSRecordID,
+				Name:   "app",
+				Type:   api.DNSRecordTypeA,
+				Ttl:    60,
+				Rdata:  "100.64.0.20",
+				Groups: &[]string{"group1"},
+			},
+		},
+		{
+			name:           "POST Invalid Type",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/dns/records",
+			requestBody:    bytes.NewBufferString(`{"name":"www","type":"BOGUS","ttl":60,"rdata":"example.com"}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PUT OK",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/dns/records/" + existingDNSRecordID,
+			requestBody:    bytes.NewBufferString(`{"name":"service","type":"A","ttl":300,"rdata":"100.64.0.10"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedRecord: toDNSRecordResponse(baseExistingDNSRecord),
+		},
+		{
+			name:           "PUT Not Existing DNS Record",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/dns/records/" + notFoundDNSRecordID,
+			requestBody:    bytes.NewBufferString(`{"name":"service","type":"A","ttl":300,"rdata":"100.64.0.10"}`),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE OK",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/dns/records/" + existingDNSRecordID,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	p := initDNSRecordsTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/dns/records/{recordId}", p.GetDNSRecord).Methods("GET")
+			router.HandleFunc("/api/dns/records", p.CreateDNSRecord).Methods("POST")
+			router.HandleFunc("/api/dns/records/{recordId}", p.DeleteDNSRecord).Methods("DELETE")
+			router.HandleFunc("/api/dns/records/{recordId}", p.UpdateDNSRecord).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.DNSRecord{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedRecord, got)
+		})
+	}
+}
@@ -45,11 +45,7 @@ func (h *DNSSettingsHandler) GetDNSSettings(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	apiDNSSettings := &api.DNSSettings{
-		DisabledManagementGroups: dnsSettings.DisabledManagementGroups,
-	}
-
-	util.WriteJSONObject(w, apiDNSSettings)
+	util.WriteJSONObject(w, toDNSSettingsResponse(dnsSettings))
 }
 
 // UpdateDNSSettings handles update to DNS settings of an account
@@ -71,6 +67,17 @@ func (h *DNSSettingsHandler) UpdateDNSSettings(w http.ResponseWriter, r *http.Re
 	updateDNSSettings := &server.DNSSettings{
 		DisabledManagementGroups: req.DisabledManagementGroups,
 	}
+	if req.PrimaryDnsDisabledOs != nil {
+		updateDNSSettings.PrimaryDNSDisabledOS = *req.PrimaryDnsDisabledOs
+	} else {
+		// the clients not aware of the setting keep it
+		currentSettings, err := h.accountManager.GetDNSSettings(account.Id, user.Id)
+		if err != nil {
+			util.WriteError(err, w)
+			return
+		}
+		updateDNSSettings.PrimaryDNSDisabledOS = currentSettings.PrimaryDNSDisabledOS
+	}
 
 	err = h.accountManager.SaveDNSSettings(account.Id, user.Id, updateDNSSettings)
 	if err != nil {
@@ -78,9 +85,16 @@ func (h *DNSSettingsHandler) UpdateDNSSettings(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	resp := api.DNSSettings{
-		DisabledManagementGroups: updateDNSSettings.DisabledManagementGroups,
-	}
+	util.WriteJSONObject(w, toDNSSettingsResponse(updateDNSSettings))
+}
 
-	util.WriteJSONObject(w, &resp)
+func toDNSSettingsResponse(settings *server.DNSSettings) *api.DNSSettings {
+	primaryDNSDisabledOS := settings.PrimaryDNSDisabledOS
+	if primaryDNSDisabledOS == nil {
+		primaryDNSDisabledOS = []string{}
+	}
+	return &api.DNSSettings{
+		DisabledManagementGroups: settings.DisabledManagementGroups,
+		PrimaryDnsDisabledOs:     &primaryDNSDisabledOS,
+	}
 }
@@ -28,6 +28,7 @@ const (
 
 var baseExistingDNSSettings = server.DNSSettings{
 	DisabledManagementGroups: []string{testDNSSettingsExistingGroup},
+	PrimaryDNSDisabledOS:     []string{"darwin"},
 }
 
 var testingDNSSettingsAccount = &server.Account{
@@ -85,6 +86,7 @@ func TestDNSSettingsHandlers(t *testing.T) {
 			expectedBody:   true,
 			expectedDNSSettings: &api.DNSSettings{
 				DisabledManagementGroups: baseExistingDNSSettings.DisabledManagementGroups,
+				PrimaryDnsDisabledOs:     &baseExistingDNSSettings.PrimaryDNSDisabledOS,
 			},
 		},
 		{
@@ -97,6 +99,20 @@ func TestDNSSettingsHandlers(t *testing.T) {
 			expectedBody:   true,
 			expectedDNSSettings: &api.DNSSettings{
 				DisabledManagementGroups: []string{"group1", "group2"},
+				PrimaryDnsDisabledOs:     &baseExistingDNSSettings.PrimaryDNSDisabledOS,
+			},
+		},
+		{
+			name:        "Update DNS Settings Primary DNS Disabled OS",
+			requestType: http.MethodPut,
+			requestPath: "/api/dns/settings",
+			requestBody: bytes.NewBuffer(
+				[]byte("{\"disabled_management_groups\":[],\"primary_dns_disabled_os\":[\"darwin\",\"windows\"]}")),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedDNSSettings: &api.DNSSettings{
+				DisabledManagementGroups: []string{},
+				PrimaryDnsDisabledOs:     &[]string{"darwin", "windows"},
 			},
 		},
 		{
@@ -105,9 +121,11 @@ func TestDNSSettingsHandlers(t *testing.T) {
 			requestPath: "/api/dns/settings",
 			requestBody: bytes.NewBuffer(
 				[]byte("{}")),
-			expectedStatus:      http.StatusOK,
-			expectedBody:        true,
-			expectedDNSSettings: &api.DNSSettings{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedDNSSettings: &api.DNSSettings{
+				PrimaryDnsDisabledOs: &baseExistingDNSSettings.PrimaryDNSDisabledOS,
+			},
 		},
 	}
 
@@ -72,22 +72,6 @@ func (h *GroupsHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	eg, ok := account.Groups[groupID]
-	if !ok {
-		util.WriteError(status.Errorf(status.NotFound, "couldn't find group with ID %s", groupID), w)
-		return
-	}
-
-	allGroup, err := account.GetGroupAll()
-	if err != nil {
-		util.WriteError(err, w)
-		return
-	}
-	if allGroup.ID == groupID {
-		util.WriteError(status.Errorf(status.InvalidArgument, "updating group ALL is not allowed"), w)
-		return
-	}
-
 	var req api.PutApiGroupsGroupIdJSONRequestBody
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -95,32 +79,19 @@ func (h *GroupsHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Name == "" {
-		util.WriteError(status.Errorf(status.InvalidArgument, "group name shouldn't be empty"), w)
+	group, err := toGroup(account, groupID, &req)
+	if err != nil {
+		util.WriteError(err, w)
 		return
 	}
 
-	var peers []string
-	if req.Peers == nil {
-		peers = make([]string, 0)
-	} else {
-		peers = *req.Peers
-	}
-	group := server.Group{
-		ID:                   groupID,
-		Name:                 req.Name,
-		Peers:                peers,
-		Issued:               eg.Issued,
-		IntegrationReference: eg.IntegrationReference,
-	}
-
-	if err := h.accountManager.SaveGroup(account.Id, user.Id, &group); err != nil {
+	if err := h.accountManager.SaveGroup(account.Id, user.Id, group); err != nil {
 		log.Errorf("failed updating group %s under account %s %v", groupID, account.Id, err)
 		util.WriteError(err, w)
 		return
 	}
 
-	util.WriteJSONObject(w, toGroupResponse(account, &group))
+	util.WriteJSONObject(w, toGroupResponse(account, group))
 }
 
 // CreateGroup handles group creation request
@@ -139,31 +110,19 @@ func (h *GroupsHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Name == "" {
-		util.WriteError(status.Errorf(status.InvalidArgument, "group name shouldn't be empty"), w)
+	group, err := toGroup(account, "", &req)
+	if err != nil {
+		util.WriteError(err, w)
 		return
 	}
 
-	var peers []string
-	if req.Peers == nil {
-		peers = make([]string, 0)
-	} else {
-		peers = *req.Peers
-	}
-	group := server.Group{
-		ID:     xid.New().String(),
-		Name:   req.Name,
-		Peers:  peers,
-		Issued: server.GroupIssuedAPI,
-	}
-
-	err = h.accountManager.SaveGroup(account.Id, user.Id, &group)
+	err = h.accountManager.SaveGroup(account.Id, user.Id, group)
 	if err != nil {
 		util.WriteError(err, w)
 		return
 	}
 
-	util.WriteJSONObject(w, toGroupResponse(account, &group))
+	util.WriteJSONObject(w, toGroupResponse(account, group))
 }
 
 // DeleteGroup handles group deletion request
@@ -237,6 +196,46 @@ func (h *GroupsHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// toGroup validates the request and returns the group it saves, a new group when groupID is empty
+func toGroup(account *server.Account, groupID string, req *api.GroupRequest) (*server.Group, error) {
+	group := &server.Group{
+		ID:             groupID,
+		Name:           req.Name,
+		Peers:          make([]string, 0),
+		DynamicRule:    toDynamicGroupRule(req.DynamicRule),
+		TunnelSettings: toGroupTunnelSettings(req.TunnelSettings),
+		Issued:         server.GroupIssuedAPI,
+	}
+	if req.Peers != nil {
+		group.Peers = *req.Peers
+	}
+
+	if groupID == "" {
+		group.ID = xid.New().String()
+	} else {
+		eg, ok := account.Groups[groupID]
+		if !ok {
+			return nil, status.Errorf(status.NotFound, "couldn't find group with ID %s", groupID)
+		}
+
+		allGroup, err := account.GetGroupAll()
+		if err != nil {
+			return nil, err
+		}
+		if allGroup.ID == groupID {
+			return nil, status.Errorf(status.InvalidArgument, "updating group ALL is not allowed")
+		}
+
+		group.Issued = eg.Issued
+		group.IntegrationReference = eg.IntegrationReference
+	}
+
+	if req.Name == "" {
+		return nil, status.Errorf(status.InvalidArgument, "group name shouldn't be empty")
+	}
+	return group, nil
+}
+
 func toGroupResponse(account *server.Account, group *server.Group) *api.Group {
 	cache := make(map[string]api.PeerMinimum)
 	gr := api.Group{
@@ -246,6 +245,21 @@ func toGroupResponse(account *server.Account, group *server.Group) *api.Group {
 		Issued:     &group.Issued,
 	}
 
+	if group.DynamicRule != nil {
+		subnets := append([]string{}, group.DynamicRule.Subnets...)
+		meta := make(map[string]string, len(group.DynamicRule.Meta))
+		for key, value := range group.DynamicRule.Meta {
+			meta[key] = value
+		}
+		gr.DynamicRule = &api.GroupDynamicRule{Subnets: &subnets, Meta: &meta}
+	}
+
+	if !group.TunnelSettings.IsEmpty() {
+		mtu := group.TunnelSettings.MTU
+		keepalive := group.TunnelSettings.PersistentKeepalive
+		gr.TunnelSettings = &api.GroupTunnelSettings{Mtu: &mtu, PersistentKeepalive: &keepalive}
+	}
+
 	for _, pid := range group.Peers {
 		_, ok := cache[pid]
 		if !ok {
@@ -263,3 +277,36 @@ func toGroupResponse(account *server.Account, group *server.Group) *api.Group {
 	}
 	return &gr
 }
+
+func toDynamicGroupRule(rule *api.GroupDynamicRule) *server.DynamicGroupRule {
+	if rule == nil {
+		return nil
+	}
+
+	dynamicRule := &server.DynamicGroupRule{}
+	if rule.Subnets != nil {
+		dynamicRule.Subnets = *rule.Subnets
+	}
+	if rule.Meta != nil {
+		dynamicRule.Meta = *rule.Meta
+	}
+	return dynamicRule
+}
+
+func toGroupTunnelSettings(settings *api.GroupTunnelSettings) *server.GroupTunnelSettings {
+	if settings == nil {
+		return nil
+	}
+
+	tunnelSettings := &server.GroupTunnelSettings{}
+	if settings.Mtu != nil {
+		tunnelSettings.MTU = *settings.Mtu
+	}
+	if settings.PersistentKeepalive != nil {
+		tunnelSettings.PersistentKeepalive = *settings.PersistentKeepalive
+	}
+	if tunnelSettings.IsEmpty() {
+		return nil
+	}
+	return tunnelSettings
+}
@@ -71,14 +71,22 @@ func APIHandler(accountManager s.AccountManager, jwtValidator jwtclaims.JWTValid
 	integrations.RegisterHandlers(api.Router, accountManager, claimsExtractor)
 	api.addAccountsEndpoint()
 	api.addPeersEndpoint()
+	api.addSyncSessionsEndpoint()
 	api.addUsersEndpoint()
 	api.addUsersTokensEndpoint()
 	api.addSetupKeysEndpoint()
+	api.addOrgKeysEndpoint()
 	api.addRulesEndpoint()
 	api.addPoliciesEndpoint()
 	api.addGroupsEndpoint()
+	api.addBatchEndpoint()
 	api.addRoutesEndpoint()
+	api.addNetworkResourcesEndpoint()
+	api.addStaticRoutesEndpoint()
+	api.addPostureChecksEndpoint()
 	api.addDNSNameserversEndpoint()
+	api.addDNSRecordsEndpoint()
+	api.addIPReservationsEndpoint()
 	api.addDNSSettingEndpoint()
 	api.addEventsEndpoint()
 
@@ -110,16 +118,38 @@ func (apiHandler *apiHandler) addAccountsEndpoint() {
 	accountsHandler := NewAccountsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/accounts/{accountId}", accountsHandler.UpdateAccount).Methods("PUT", "OPTIONS")
 	apiHandler.Router.HandleFunc("/accounts/{accountId}", accountsHandler.DeleteAccount).Methods("DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/network", accountsHandler.GetAccountNetwork).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/network", accountsHandler.UpdateAccountNetwork).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/default-deny", accountsHandler.GetAccountDefaultDeny).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/effective-acl", accountsHandler.GetAccountEffectiveACL).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/export/terraform", accountsHandler.GetAccountTerraformExport).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/inactive-peers", accountsHandler.GetAccountInactivePeers).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/outdated-peers", accountsHandler.GetAccountOutdatedPeers).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/maintenance", accountsHandler.GetAccountMaintenance).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/maintenance", accountsHandler.StartAccountMaintenance).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/maintenance", accountsHandler.StopAccountMaintenance).Methods("DELETE", "OPTIONS")
+	apiHandler.Router.HandleFunc("/accounts/{accountId}/webhook-secret", accountsHandler.RotateAccountWebhookSecret).Methods("POST", "OPTIONS")
 	apiHandler.Router.HandleFunc("/accounts", accountsHandler.GetAllAccounts).Methods("GET", "OPTIONS")
 }
 
 func (apiHandler *apiHandler) addPeersEndpoint() {
 	peersHandler := NewPeersHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/peers", peersHandler.GetAllPeers).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/pending", peersHandler.GetPendingPeers).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/non-compliant", peersHandler.GetNonCompliantPeers).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/approve", peersHandler.ApprovePeer).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/reject", peersHandler.RejectPeer).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/peers/{peerId}/network-map", peersHandler.GetPeerNetworkMap).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/peers/{peerId}", peersHandler.HandlePeer).
 		Methods("GET", "PUT", "DELETE", "OPTIONS")
 }
 
+func (apiHandler *apiHandler) addSyncSessionsEndpoint() {
+	syncSessionsHandler := NewSyncSessionsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/sync-sessions", syncSessionsHandler.GetAllSyncSessions).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/sync-sessions/{peerId}", syncSessionsHandler.DeleteSyncSession).Methods("DELETE", "OPTIONS")
+}
+
 func (apiHandler *apiHandler) addUsersEndpoint() {
 	userHandler := NewUsersHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/users", userHandler.GetAllUsers).Methods("GET", "OPTIONS")
@@ -141,6 +171,8 @@ func (apiHandler *apiHandler) addSetupKeysEndpoint() {
 	keysHandler := NewSetupKeysHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/setup-keys", keysHandler.GetAllSetupKeys).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/setup-keys", keysHandler.CreateSetupKey).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/setup-keys/pending", keysHandler.GetPendingSetupKeys).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/setup-keys/{keyId}/approve", keysHandler.ApproveSetupKey).Methods("POST", "OPTIONS")
 	apiHandler.Router.HandleFunc("/setup-keys/{keyId}", keysHandler.GetSetupKey).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/setup-keys/{keyId}", keysHandler.UpdateSetupKey).Methods("PUT", "OPTIONS")
 }
@@ -158,6 +190,7 @@ func (apiHandler *apiHandler) addPoliciesEndpoint() {
 	policiesHandler := NewPoliciesHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/policies", policiesHandler.GetAllPolicies).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/policies", policiesHandler.CreatePolicy).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/policies/simulate", policiesHandler.SimulatePolicy).Methods("POST", "OPTIONS")
 	apiHandler.Router.HandleFunc("/policies/{policyId}", policiesHandler.UpdatePolicy).Methods("PUT", "OPTIONS")
 	apiHandler.Router.HandleFunc("/policies/{policyId}", policiesHandler.GetPolicy).Methods("GET", "OPTIONS")
 	apiHandler.Router.HandleFunc("/policies/{policyId}", policiesHandler.DeletePolicy).Methods("DELETE", "OPTIONS")
@@ -172,6 +205,11 @@ func (apiHandler *apiHandler) addGroupsEndpoint() {
 	apiHandler.Router.HandleFunc("/groups/{groupId}", groupsHandler.DeleteGroup).Methods("DELETE", "OPTIONS")
 }
 
+func (apiHandler *apiHandler) addBatchEndpoint() {
+	batchHandler := NewBatchHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/batch", batchHandler.ApplyBatch).Methods("POST", "OPTIONS")
+}
+
 func (apiHandler *apiHandler) addRoutesEndpoint() {
 	routesHandler := NewRoutesHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/routes", routesHandler.GetAllRoutes).Methods("GET", "OPTIONS")
@@ -181,6 +219,33 @@ func (apiHandler *apiHandler) addRoutesEndpoint() {
 	apiHandler.Router.HandleFunc("/routes/{routeId}", routesHandler.DeleteRoute).Methods("DELETE", "OPTIONS")
 }
 
+func (apiHandler *apiHandler) addNetworkResourcesEndpoint() {
+	networkResourcesHandler := NewNetworkResourcesHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/network-resources", networkResourcesHandler.GetAllNetworkResources).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/network-resources", networkResourcesHandler.CreateNetworkResource).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/network-resources/{resourceId}", networkResourcesHandler.UpdateNetworkResource).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/network-resources/{resourceId}", networkResourcesHandler.GetNetworkResource).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/network-resources/{resourceId}", networkResourcesHandler.DeleteNetworkResource).Methods("DELETE", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addStaticRoutesEndpoint() {
+	staticRoutesHandler := NewStaticRoutesHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/static-routes", staticRoutesHandler.GetAllStaticRoutes).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/static-routes", staticRoutesHandler.CreateStaticRoute).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/static-routes/{routeId}", staticRoutesHandler.UpdateStaticRoute).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/static-routes/{routeId}", staticRoutesHandler.GetStaticRoute).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/static-routes/{routeId}", staticRoutesHandler.DeleteStaticRoute).Methods("DELETE", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addPostureChecksEndpoint() {
+	postureChecksHandler := NewPostureChecksHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/posture-checks", postureChecksHandler.GetAllPostureChecks).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/posture-checks", postureChecksHandler.CreatePostureCheck).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/posture-checks/{postureCheckId}", postureChecksHandler.UpdatePostureCheck).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/posture-checks/{postureCheckId}", postureChecksHandler.GetPostureCheck).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/posture-checks/{postureCheckId}", postureChecksHandler.DeletePostureCheck).Methods("DELETE", "OPTIONS")
+}
+
 func (apiHandler *apiHandler) addDNSNameserversEndpoint() {
 	nameserversHandler := NewNameserversHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/dns/nameservers", nameserversHandler.GetAllNameservers).Methods("GET", "OPTIONS")
@@ -190,6 +255,29 @@ func (apiHandler *apiHandler) addDNSNameserversEndpoint() {
 	apiHandler.Router.HandleFunc("/dns/nameservers/{nsgroupId}", nameserversHandler.DeleteNameserverGroup).Methods("DELETE", "OPTIONS")
 }
 
+func (apiHandler *apiHandler) addDNSRecordsEndpoint() {
+	dnsRecordsHandler := NewDNSRecordsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/dns/records", dnsRecordsHandler.GetAllDNSRecords).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/records", dnsRecordsHandler.CreateDNSRecord).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/records/{recordId}", dnsRecordsHandler.UpdateDNSRecord).Methods("PUT", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/records/{recordId}", dnsRecordsHandler.GetDNSRecord).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/dns/records/{recordId}", dnsRecordsHandler.DeleteDNSRecord).Methods("DELETE", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addOrgKeysEndpoint() {
+	orgKeysHandler := NewOrgKeysHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/org-keys", orgKeysHandler.GetAllOrgKeys).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/org-keys", orgKeysHandler.CreateOrgKey).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/org-keys/{keyId}", orgKeysHandler.UpdateOrgKey).Methods("PUT", "OPTIONS")
+}
+
+func (apiHandler *apiHandler) addIPReservationsEndpoint() {
+	ipReservationsHandler := NewIPReservationsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
+	apiHandler.Router.HandleFunc("/ip-reservations", ipReservationsHandler.GetAllIPReservations).Methods("GET", "OPTIONS")
+	apiHandler.Router.HandleFunc("/ip-reservations", ipReservationsHandler.SetIPReservation).Methods("POST", "OPTIONS")
+	apiHandler.Router.HandleFunc("/ip-reservations/{reservationId}", ipReservationsHandler.DeleteIPReservation).Methods("DELETE", "OPTIONS")
+}
+
 func (apiHandler *apiHandler) addDNSSettingEndpoint() {
 	dnsSettingsHandler := NewDNSSettingsHandler(apiHandler.AccountManager, apiHandler.AuthCfg)
 	apiHandler.Router.HandleFunc("/dns/settings", dnsSettingsHandler.GetDNSSettings).Methods("GET", "OPTIONS")
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// IPReservationsHandler is the handler of the IPs reserved for the account peers
+type IPReservationsHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewIPReservationsHandler returns a new instance of IPReservationsHandler handler
+func NewIPReservationsHandler(accountManager server.AccountManager, authCfg AuthCfg) *IPReservationsHandler {
+	return &IPReservationsHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllIPReservations returns the list of IP reservations for the account
+func (h *IPReservationsHandler) GetAllIPReservations(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	reservations, err := h.accountManager.ListIPReservations(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiReservations := make([]*api.IPReservation, 0)
+	for _, reservation := range reservations {
+		apiReservations = append(apiReservations, toIPReservationResponse(reservation))
+	}
+
+	util.WriteJSONObject(w, apiReservations)
+}
+
+// SetIPReservation handles the request reserving an IP for a peer
+func (h *IPReservationsHandler) SetIPReservation(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiIpReservationsJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	reservation, err := h.toServerIPReservation(account.Id, user.Id, req)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	reservation, err = h.accountManager.SaveIPReservation(account.Id, user.Id, reservation)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toIPReservationResponse(reservation)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// DeleteIPReservation handles IP reservation deletion request
+func (h *IPReservationsHandler) DeleteIPReservation(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	reservationID := mux.Vars(r)["reservationId"]
+	if len(reservationID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid IP reservation ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeleteIPReservation(account.Id, user.Id, reservationID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// toServerIPReservation converts the request to a reservation, resolving the key and the default IP of the peer_id
+func (h *IPReservationsHandler) toServerIPReservation(accountID, userID string, req api.IPReservationRequest) (*server.IPReservation, error) {
+	reservation := &server.IPReservation{}
+	if req.Description != nil {
+		reservation.Description = *req.Description
+	}
+
+	if req.PeerId != nil && *req.PeerId != "" {
+		peer, err := h.accountManager.GetPeer(accountID, *req.PeerId, userID)
+		if err != nil {
+			return nil, err
+		}
+		if req.PeerKey != nil && *req.PeerKey != "" && *req.PeerKey != peer.Key {
+			return nil, status.Errorf(status.InvalidArgument, "peer_key doesn't match the key of peer %s", *req.PeerId)
+		}
+		reservation.PeerKey = peer.Key
+		reservation.IP = peer.IP
+	} else if req.PeerKey != nil {
+		reservation.PeerKey = *req.PeerKey
+	}
+
+	if reservation.PeerKey == "" {
+		return nil, status.Errorf(status.InvalidArgument, "peer_key or peer_id is required")
+	}
+
+	if req.Ip != nil && *req.Ip != "" {
+		reservation.IP = net.ParseIP(*req.Ip)
+		if reservation.IP == nil {
+			return nil, status.Errorf(status.InvalidArgument, "invalid IP %s", *req.Ip)
+		}
+	}
+
+	if reservation.IP == nil {
+		return nil, status.Errorf(status.InvalidArgument, "ip is required unless peer_id is set")
+	}
+
+	return reservation, nil
+}
+
+func toIPReservationResponse(reservation *server.IPReservation) *api.IPReservation {
+	return &api.IPReservation{
+		Id:          reservation.ID,
+		PeerKey:     reservation.PeerKey,
+		Ip:          reservation.IP.String(),
+		Description: reservation.Description,
+	}
+}
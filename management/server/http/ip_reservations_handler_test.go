@@ -0,0 +1,196 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingIPReservationID  = "existingIPReservationID"
+	testIPReservationAccount = "test_id"
+	testIPReservationPeerID  = "test_peer"
+	testIPReservationPeerKey = "RgVn6uXdL8xV8ZKNCHtSGLlxsDYSYeBvhV7oJEqVK1k="
+)
+
+var testingIPReservationAccount = &server.Account{
+	Id:     testIPReservationAccount,
+	Domain: "hotmail.com",
+	Users: map[string]*server.User{
+		"test_user": server.NewAdminUser("test_user"),
+	},
+}
+
+func initIPReservationsTestData() *IPReservationsHandler {
+	return &IPReservationsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			SaveIPReservationFunc: func(_, _ string, reservation *server.IPReservation) (*server.IPReservation, error) {
+				if reservation.IP.Equal(net.ParseIP("100.64.0.20")) {
+					return nil, status.Errorf(status.AlreadyExists, "IP %s is already reserved for another peer", reservation.IP)
+				}
+				reservation = reservation.Copy()
+				reservation.ID = existingIPReservationID
+				return reservation, nil
+			},
+			DeleteIPReservationFunc: func(_, _, reservationID string) error {
+				if reservationID == existingIPReservationID {
+					return nil
+				}
+				return status.Errorf(status.NotFound, "IP reservation %s wasn't found", reservationID)
+			},
+			ListIPReservationsFunc: func(_, _ string) ([]*server.IPReservation, error) {
+				return []*server.IPReservation{{ID: existingIPReservationID, PeerKey: testIPReservationPeerKey, IP: net.IP{100, 64, 0, 10}}}, nil
+			},
+			GetPeerFunc: func(_, peerID, _ string) (*nbpeer.Peer, error) {
+				if peerID == testIPReservationPeerID {
+					return &nbpeer.Peer{ID: peerID, Key: testIPReservationPeerKey, IP: net.IP{100, 64, 0, 15}}, nil
+				}
+				return nil, status.Errorf(status.NotFound, "peer %s not found", peerID)
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingIPReservationAccount, testingIPReservationAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testIPReservationAccount,
+				}
+			}),
+		),
+	}
+}
+
+func TestIPReservationsHandlers(t *testing.T) {
+	tt := []struct {
+		name                string
+		expectedStatus      int
+		expectedBody        bool
+		expectedReservation *api.IPReservation
+		requestType         string
+		requestPath         string
+		requestBody         io.Reader
+	}{
+		{
+			name:           "POST With Key OK",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/ip-reservations",
+			requestBody:    bytes.NewBufferString(`{"peer_key":"` + testIPReservationPeerKey + `","ip":"100.64.0.10","description":"build"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedReservation: &api.IPReservation{
+				Id:          existingIPReservationID,
+				PeerKey:     testIPReservationPeerKey,
+				Ip:          "100.64.0.10",
+				Description: "build",
+			},
+		},
+		{
+			name:           "POST With Peer ID Reserves Current IP",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/ip-reservations",
+			requestBody:    bytes.NewBufferString(`{"peer_id":"` + testIPReservationPeerID + `"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedReservation: &api.IPReservation{
+				Id:      existingIPReservationID,
+				PeerKey: testIPReservationPeerKey,
+				Ip:      "100.64.0.15",
+			},
+		},
+		{
+			name:           "POST Without Peer",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/ip-reservations",
+			requestBody:    bytes.NewBufferString(`{"ip":"100.64.0.10"}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "POST Invalid IP",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/ip-reservations",
+			requestBody:    bytes.NewBufferString(`{"peer_key":"` + testIPReservationPeerKey + `","ip":"bogus"}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "POST Reserved IP Conflict",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/ip-reservations",
+			requestBody:    bytes.NewBufferString(`{"peer_key":"` + testIPReservationPeerKey + `","ip":"100.64.0.20"}`),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "GET All OK",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/ip-reservations",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "DELETE OK",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/ip-reservations/" + existingIPReservationID,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "DELETE Not Existing IP Reservation",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/ip-reservations/notFound",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	p := initIPReservationsTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/ip-reservations", p.GetAllIPReservations).Methods("GET")
+			router.HandleFunc("/api/ip-reservations", p.SetIPReservation).Methods("POST")
+			router.HandleFunc("/api/ip-reservations/{reservationId}", p.DeleteIPReservation).Methods("DELETE")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.IPReservation{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedReservation, got)
+		})
+	}
+}
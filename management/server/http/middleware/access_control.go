@@ -16,7 +16,7 @@ import (
 // GetUser function defines a function to fetch user from Account by jwtclaims.AuthorizationClaims
 type GetUser func(claims jwtclaims.AuthorizationClaims) (*server.User, error)
 
-// AccessControl middleware to restrict to make POST/PUT/DELETE requests by admin only
+// AccessControl middleware to restrict POST/PUT/DELETE requests to the users with the write permission on the requested resource
 type AccessControl struct {
 	claimsExtract jwtclaims.ClaimsExtractor
 	getUser       GetUser
@@ -35,8 +35,38 @@ func NewAccessControl(audience, userIDClaim string, getUser GetUser) *AccessCont
 
 var tokenPathRegexp = regexp.MustCompile(`^.*/api/users/.*/tokens.*$`)
 
-// Handler method of the middleware which forbids all modify requests for non admin users
-// It also adds
+// batchPathRegexp matches the batch endpoint, the permissions of the user and the token role are checked for every
+// operation of the batch
+var batchPathRegexp = regexp.MustCompile(`^.*/api/batch$`)
+
+// readPathRegexp matches the POST endpoints which only read the resources, their handlers check the read permission
+var readPathRegexp = regexp.MustCompile(`^.*/api/policies/simulate$`)
+
+// resourcePathRegexp captures the first path segment after the /api prefix, e.g. peers in /api/peers/{peerId}
+var resourcePathRegexp = regexp.MustCompile(`^.*/api/([^/]+)`)
+
+// pathResources maps the first path segment of the API endpoints to the resource they manage
+var pathResources = map[string]server.Resource{
+	"accounts":          server.ResourceAccounts,
+	"peers":             server.ResourcePeers,
+	"groups":            server.ResourceGroups,
+	"policies":          server.ResourcePolicies,
+	"rules":             server.ResourcePolicies,
+	"routes":            server.ResourceRoutes,
+	"dns":               server.ResourceDNS,
+	"setup-keys":        server.ResourceSetupKeys,
+	"users":             server.ResourceUsers,
+	"events":            server.ResourceEvents,
+	"ip-reservations":   server.ResourcePeers,
+	"org-keys":          server.ResourceAccounts,
+	"network-resources": server.ResourceRoutes,
+	"sync-sessions":     server.ResourcePeers,
+	"posture-checks":    server.ResourcePolicies,
+	"static-routes":     server.ResourceRoutes,
+}
+
+// Handler method of the middleware which forbids modify requests for the users without the write permission
+// on the requested resource. Requests to the endpoints without a known resource are allowed for the users with admin power only.
 func (a *AccessControl) Handler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims := a.claimsExtract.FromRequestContext(r)
@@ -53,21 +83,51 @@ func (a *AccessControl) Handler(h http.Handler) http.Handler {
 			return
 		}
 
-		if !user.HasAdminPower() {
-			switch r.Method {
-			case http.MethodDelete, http.MethodPost, http.MethodPatch, http.MethodPut:
+		tokenRole := server.UserRole(claims.TokenRole)
 
-				if tokenPathRegexp.MatchString(r.URL.Path) {
-					log.Debugf("valid Path")
-					h.ServeHTTP(w, r)
-					return
-				}
+		switch r.Method {
+		case http.MethodDelete, http.MethodPost, http.MethodPatch, http.MethodPut:
+			if canWrite(user, tokenRole, r.URL.Path) {
+				break
+			}
+
+			if r.Method == http.MethodPost && (readPathRegexp.MatchString(r.URL.Path) || batchPathRegexp.MatchString(r.URL.Path)) {
+				break
+			}
 
-				util.WriteError(status.Errorf(status.PermissionDenied, "only users with admin power can perform this operation"), w)
+			// a token limited to a role can't manage the tokens of its user, e.g. create one without the limit
+			if tokenRole == "" && tokenPathRegexp.MatchString(r.URL.Path) {
+				log.Debugf("valid Path")
+				h.ServeHTTP(w, r)
 				return
 			}
+
+			util.WriteError(status.Errorf(status.PermissionDenied, "the user has no permission to perform this operation"), w)
+			return
 		}
 
 		h.ServeHTTP(w, r)
 	})
 }
+
+// canWrite returns true if both the user and the role limiting the token of the request, if any, can modify
+// the resource of the path
+func canWrite(user *server.User, tokenRole server.UserRole, path string) bool {
+	resource, ok := pathResource(path)
+	if !ok {
+		return user.HasAdminPower() && (tokenRole == "" || tokenRole.HasAdminPower())
+	}
+	return user.HasPermission(resource, server.OperationWrite) &&
+		(tokenRole == "" || tokenRole.HasPermission(resource, server.OperationWrite))
+}
+
+// pathResource returns the resource managed by the API endpoint of the path
+func pathResource(path string) (server.Resource, bool) {
+	matches := resourcePathRegexp.FindStringSubmatch(path)
+	if len(matches) < 2 {
+		return "", false
+	}
+
+	resource, ok := pathResources[matches[1]]
+	return resource, ok
+}
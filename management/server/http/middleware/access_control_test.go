@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+)
+
+func TestAccessControl_Handler(t *testing.T) {
+	tt := []struct {
+		name               string
+		role               server.UserRole
+		tokenRole          server.UserRole
+		method             string
+		path               string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Admin creates a user",
+			role:               server.UserRoleAdmin,
+			method:             http.MethodPost,
+			path:               "/api/users",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Network admin updates a peer",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPut,
+			path:               "/api/peers/peerID",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Network admin creates a policy",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/policies",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Network admin creates a user",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/users",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Network admin updates the account",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPut,
+			path:               "/api/accounts/accountID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Network admin creates a token",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/users/userID/tokens",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Auditor lists the peers",
+			role:               server.UserRoleAuditor,
+			method:             http.MethodGet,
+			path:               "/api/peers",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Auditor deletes a group",
+			role:               server.UserRoleAuditor,
+			method:             http.MethodDelete,
+			path:               "/api/groups/groupID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "User creates a setup key",
+			role:               server.UserRoleUser,
+			method:             http.MethodPost,
+			path:               "/api/setup-keys",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Network admin reserves an IP",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/ip-reservations",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Auditor deletes an IP reservation",
+			role:               server.UserRoleAuditor,
+			method:             http.MethodDelete,
+			path:               "/api/ip-reservations/reservationID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Admin creates an organization key",
+			role:               server.UserRoleAdmin,
+			method:             http.MethodPost,
+			path:               "/api/org-keys",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Network admin updates an organization key",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPut,
+			path:               "/api/org-keys/keyID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Network admin creates a network resource",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/network-resources",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Auditor deletes a network resource",
+			role:               server.UserRoleAuditor,
+			method:             http.MethodDelete,
+			path:               "/api/network-resources/resourceID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Network admin closes a sync session",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodDelete,
+			path:               "/api/sync-sessions/peerID",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Auditor closes a sync session",
+			role:               server.UserRoleAuditor,
+			method:             http.MethodDelete,
+			path:               "/api/sync-sessions/peerID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Network admin creates a posture check",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/posture-checks",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Auditor deletes a posture check",
+			role:               server.UserRoleAuditor,
+			method:             http.MethodDelete,
+			path:               "/api/posture-checks/checkID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Auditor simulates the policies",
+			role:               server.UserRoleAuditor,
+			method:             http.MethodPost,
+			path:               "/api/policies/simulate",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Auditor creates a policy",
+			role:               server.UserRoleAuditor,
+			method:             http.MethodPost,
+			path:               "/api/policies",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Network admin applies a batch",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/batch",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Network admin creates a static route",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/static-routes",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Auditor deletes a static route",
+			role:               server.UserRoleAuditor,
+			method:             http.MethodDelete,
+			path:               "/api/static-routes/routeID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Network admin calls an unknown endpoint",
+			role:               server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/integrations",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Admin calls an unknown endpoint",
+			role:               server.UserRoleAdmin,
+			method:             http.MethodPost,
+			path:               "/api/integrations",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Admin with an auditor token lists the peers",
+			role:               server.UserRoleAdmin,
+			tokenRole:          server.UserRoleAuditor,
+			method:             http.MethodGet,
+			path:               "/api/peers",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Admin with an auditor token updates a peer",
+			role:               server.UserRoleAdmin,
+			tokenRole:          server.UserRoleAuditor,
+			method:             http.MethodPut,
+			path:               "/api/peers/peerID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Admin with an auditor token creates a token",
+			role:               server.UserRoleAdmin,
+			tokenRole:          server.UserRoleAuditor,
+			method:             http.MethodPost,
+			path:               "/api/users/userID/tokens",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Admin with a network admin token creates a policy",
+			role:               server.UserRoleAdmin,
+			tokenRole:          server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/policies",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Admin with a network admin token calls an unknown endpoint",
+			role:               server.UserRoleAdmin,
+			tokenRole:          server.UserRoleNetworkAdmin,
+			method:             http.MethodPost,
+			path:               "/api/integrations",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Network admin with an owner token updates the account",
+			role:               server.UserRoleNetworkAdmin,
+			tokenRole:          server.UserRoleOwner,
+			method:             http.MethodPut,
+			path:               "/api/accounts/accountID",
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// do nothing
+	})
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			accessControl := &AccessControl{
+				claimsExtract: *jwtclaims.NewClaimsExtractor(
+					jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+						return jwtclaims.AuthorizationClaims{UserId: userID, AccountId: accountID, TokenRole: string(tc.tokenRole)}
+					}),
+				),
+				getUser: func(claims jwtclaims.AuthorizationClaims) (*server.User, error) {
+					return server.NewUser(claims.UserId, tc.role, false, false, "", []string{}, server.UserIssuedAPI), nil
+				},
+			}
+
+			req := httptest.NewRequest(tc.method, "http://testing"+tc.path, nil)
+			rec := httptest.NewRecorder()
+
+			accessControl.Handler(nextHandler).ServeHTTP(rec, req)
+
+			result := rec.Result()
+			defer result.Body.Close()
+			if result.StatusCode != tc.expectedStatusCode {
+				t.Errorf("expected status code %d, got %d", tc.expectedStatusCode, result.StatusCode)
+			}
+		})
+	}
+}
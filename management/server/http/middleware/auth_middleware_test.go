@@ -161,3 +161,63 @@ func TestAuthMiddleware_Handler(t *testing.T) {
 	}
 
 }
+
+func TestAuthMiddleware_PATRole(t *testing.T) {
+	account := &server.Account{
+		Id:     accountID,
+		Domain: domain,
+		Users: map[string]*server.User{
+			userID: {
+				Id: userID,
+				PATs: map[string]*server.PersonalAccessToken{
+					tokenID: {
+						ID:             tokenID,
+						ExpirationDate: time.Now().UTC().AddDate(0, 0, 7),
+						Role:           server.UserRoleAuditor,
+					},
+				},
+			},
+		},
+	}
+	getAccountFromPAT := func(token string) (*server.Account, *server.User, *server.PersonalAccessToken, error) {
+		return account, account.Users[userID], account.Users[userID].PATs[tokenID], nil
+	}
+
+	claimsExtractor := jwtclaims.NewClaimsExtractor(
+		jwtclaims.WithAudience(audience),
+		jwtclaims.WithUserIDClaim(userIDClaim),
+	)
+
+	authMiddleware := NewAuthMiddleware(
+		getAccountFromPAT,
+		mockValidateAndParseToken,
+		mockMarkPATUsed,
+		mockCheckUserAccessByJWTGroups,
+		claimsExtractor,
+		audience,
+		userIDClaim,
+	)
+
+	var claims jwtclaims.AuthorizationClaims
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims = claimsExtractor.FromRequestContext(r)
+	})
+
+	req := httptest.NewRequest("GET", "http://testing", nil)
+	req.Header.Set("Authorization", "Token "+PAT)
+	rec := httptest.NewRecorder()
+
+	authMiddleware.Handler(nextHandler).ServeHTTP(rec, req)
+
+	result := rec.Result()
+	defer result.Body.Close()
+	if result.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, result.StatusCode)
+	}
+	if claims.UserId != userID {
+		t.Errorf("expected user %s, got %s", userID, claims.UserId)
+	}
+	if claims.TokenRole != string(server.UserRoleAuditor) {
+		t.Errorf("expected token role %s, got %s", server.UserRoleAuditor, claims.TokenRole)
+	}
+}
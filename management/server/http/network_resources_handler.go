@@ -0,0 +1,209 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/netip"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// NetworkResourcesHandler is the network resources handler of the account
+type NetworkResourcesHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewNetworkResourcesHandler returns a new instance of NetworkResourcesHandler handler
+func NewNetworkResourcesHandler(accountManager server.AccountManager, authCfg AuthCfg) *NetworkResourcesHandler {
+	return &NetworkResourcesHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllNetworkResources returns the list of network resources for the account
+func (h *NetworkResourcesHandler) GetAllNetworkResources(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	resources, err := h.accountManager.ListNetworkResources(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiResources := make([]*api.NetworkResource, 0)
+	for _, resource := range resources {
+		apiResources = append(apiResources, toNetworkResourceResponse(resource))
+	}
+
+	util.WriteJSONObject(w, apiResources)
+}
+
+// CreateNetworkResource handles network resource creation request
+func (h *NetworkResourcesHandler) CreateNetworkResource(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiNetworkResourcesJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	resource, err := toServerNetworkResource("", req)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resource, err = h.accountManager.CreateNetworkResource(account.Id, user.Id, resource)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toNetworkResourceResponse(resource)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// UpdateNetworkResource handles update to a network resource identified by a given ID
+func (h *NetworkResourcesHandler) UpdateNetworkResource(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resourceID := mux.Vars(r)["resourceId"]
+	if len(resourceID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid network resource ID"), w)
+		return
+	}
+
+	var req api.PutApiNetworkResourcesResourceIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	resource, err := toServerNetworkResource(resourceID, req)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	err = h.accountManager.SaveNetworkResource(account.Id, user.Id, resource)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resource, err = h.accountManager.GetNetworkResource(account.Id, user.Id, resourceID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toNetworkResourceResponse(resource)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// DeleteNetworkResource handles network resource deletion request
+func (h *NetworkResourcesHandler) DeleteNetworkResource(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resourceID := mux.Vars(r)["resourceId"]
+	if len(resourceID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid network resource ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeleteNetworkResource(account.Id, resourceID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetNetworkResource handles a network resource Get request identified by ID
+func (h *NetworkResourcesHandler) GetNetworkResource(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	resourceID := mux.Vars(r)["resourceId"]
+	if len(resourceID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid network resource ID"), w)
+		return
+	}
+
+	resource, err := h.accountManager.GetNetworkResource(account.Id, user.Id, resourceID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toNetworkResourceResponse(resource)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+func toServerNetworkResource(resourceID string, req api.NetworkResourceRequest) (*server.NetworkResource, error) {
+	prefix, err := netip.ParsePrefix(req.Prefix)
+	if err != nil {
+		return nil, status.Errorf(status.InvalidArgument, "invalid network resource prefix %s", req.Prefix)
+	}
+
+	return &server.NetworkResource{
+		ID:          resourceID,
+		Name:        req.Name,
+		Description: req.Description,
+		Prefix:      prefix,
+	}, nil
+}
+
+func toNetworkResourceResponse(resource *server.NetworkResource) *api.NetworkResource {
+	return &api.NetworkResource{
+		Id:          resource.ID,
+		Name:        resource.Name,
+		Description: resource.Description,
+		Prefix:      resource.Prefix.String(),
+	}
+}
@@ -0,0 +1,193 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingNetworkResourceID  = "existingNetworkResourceID"
+	notFoundNetworkResourceID  = "notFoundNetworkResourceID"
+	testNetworkResourceAccount = "test_id"
+)
+
+var testingNetworkResourceAccount = &server.Account{
+	Id:     testNetworkResourceAccount,
+	Domain: "hotmail.com",
+	Users: map[string]*server.User{
+		"test_user": server.NewAdminUser("test_user"),
+	},
+}
+
+var baseExistingNetworkResource = &server.NetworkResource{
+	ID:          existingNetworkResourceID,
+	Name:        "prod-db",
+	Description: "Production databases",
+	Prefix:      netip.MustParsePrefix("10.0.5.0/24"),
+}
+
+func initNetworkResourcesTestData() *NetworkResourcesHandler {
+	return &NetworkResourcesHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetNetworkResourceFunc: func(_, _, resourceID string) (*server.NetworkResource, error) {
+				if resourceID == existingNetworkResourceID {
+					return baseExistingNetworkResource.Copy(), nil
+				}
+				return nil, status.Errorf(status.NotFound, "network resource with ID %s not found", resourceID)
+			},
+			CreateNetworkResourceFunc: func(_, _ string, resource *server.NetworkResource) (*server.NetworkResource, error) {
+				resource = resource.Copy()
+				resource.ID = existingNetworkResourceID
+				return resource, nil
+			},
+			SaveNetworkResourceFunc: func(_, _ string, resource *server.NetworkResource) error {
+				if resource.ID == existingNetworkResourceID {
+					return nil
+				}
+				return status.Errorf(status.NotFound, "network resource with ID %s was not found", resource.ID)
+			},
+			DeleteNetworkResourceFunc: func(_, _, _ string) error {
+				return nil
+			},
+			ListNetworkResourcesFunc: func(_, _ string) ([]*server.NetworkResource, error) {
+				return []*server.NetworkResource{baseExistingNetworkResource.Copy()}, nil
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingNetworkResourceAccount, testingNetworkResourceAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testNetworkResourceAccount,
+				}
+			}),
+		),
+	}
+}
+
+func TestNetworkResourcesHandlers(t *testing.T) {
+	tt := []struct {
+		name             string
+		expectedStatus   int
+		expectedBody     bool
+		expectedResource *api.NetworkResource
+		requestType      string
+		requestPath      string
+		requestBody      io.Reader
+	}{
+		{
+			name:             "Get Existing Network Resource",
+			requestType:      http.MethodGet,
+			requestPath:      "/api/network-resources/" + existingNetworkResourceID,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     true,
+			expectedResource: toNetworkResourceResponse(baseExistingNetworkResource),
+		},
+		{
+			name:           "Get Not Existing Network Resource",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/network-resources/" + notFoundNetworkResourceID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "POST OK",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/network-resources",
+			requestBody:    bytes.NewBufferString(`{"name":"staging","description":"Staging","prefix":"10.0.6.0/24"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedResource: &api.NetworkResource{
+				Id:          existingNetworkResourceID,
+				Name:        "staging",
+				Description: "Staging",
+				Prefix:      "10.0.6.0/24",
+			},
+		},
+		{
+			name:           "POST Invalid Prefix",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/network-resources",
+			requestBody:    bytes.NewBufferString(`{"name":"staging","description":"","prefix":"10.0.6.0"}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:             "PUT OK",
+			requestType:      http.MethodPut,
+			requestPath:      "/api/network-resources/" + existingNetworkResourceID,
+			requestBody:      bytes.NewBufferString(`{"name":"prod-db","description":"Production databases","prefix":"10.0.5.0/24"}`),
+			expectedStatus:   http.StatusOK,
+			expectedBody:     true,
+			expectedResource: toNetworkResourceResponse(baseExistingNetworkResource),
+		},
+		{
+			name:           "PUT Not Existing Network Resource",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/network-resources/" + notFoundNetworkResourceID,
+			requestBody:    bytes.NewBufferString(`{"name":"prod-db","description":"","prefix":"10.0.5.0/24"}`),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE OK",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/network-resources/" + existingNetworkResourceID,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	p := initNetworkResourcesTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/network-resources/{resourceId}", p.GetNetworkResource).Methods("GET")
+			router.HandleFunc("/api/network-resources", p.CreateNetworkResource).Methods("POST")
+			router.HandleFunc("/api/network-resources/{resourceId}", p.DeleteNetworkResource).Methods("DELETE")
+			router.HandleFunc("/api/network-resources/{resourceId}", p.UpdateNetworkResource).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.NetworkResource{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedResource, got)
+		})
+	}
+}
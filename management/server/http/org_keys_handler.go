@@ -0,0 +1,170 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// OrgKeysHandler is the handler of the org keys registering peers into the accounts linked to them
+type OrgKeysHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewOrgKeysHandler returns a new instance of OrgKeysHandler handler
+func NewOrgKeysHandler(accountManager server.AccountManager, authCfg AuthCfg) *OrgKeysHandler {
+	return &OrgKeysHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllOrgKeys returns the list of org keys of the account
+func (h *OrgKeysHandler) GetAllOrgKeys(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	keys, err := h.accountManager.ListOrgKeys(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiKeys := make([]*api.OrgKey, 0, len(keys))
+	for _, key := range keys {
+		apiKeys = append(apiKeys, toOrgKeyResponse(key))
+	}
+
+	util.WriteJSONObject(w, apiKeys)
+}
+
+// CreateOrgKey handles the org key creation request
+func (h *OrgKeysHandler) CreateOrgKey(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiOrgKeysJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	if req.Name == "" {
+		util.WriteError(status.Errorf(status.InvalidArgument, "org key name shouldn't be empty"), w)
+		return
+	}
+
+	var expiresIn time.Duration
+	if req.ExpiresIn != nil {
+		if *req.ExpiresIn < 0 {
+			util.WriteError(status.Errorf(status.InvalidArgument, "expires_in shouldn't be negative"), w)
+			return
+		}
+		expiresIn = time.Duration(*req.ExpiresIn) * time.Second
+	}
+
+	if req.LinkedAccounts == nil {
+		req.LinkedAccounts = []string{}
+	}
+
+	key, err := h.accountManager.CreateOrgKey(account.Id, user.Id, req.Name, expiresIn, req.LinkedAccounts)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toOrgKeyResponse(key))
+}
+
+// UpdateOrgKey handles the org key update request
+func (h *OrgKeysHandler) UpdateOrgKey(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	keyID := mux.Vars(r)["keyId"]
+	if len(keyID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid key ID"), w)
+		return
+	}
+
+	var req api.PutApiOrgKeysKeyIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	if req.Name == "" {
+		util.WriteError(status.Errorf(status.InvalidArgument, "org key name shouldn't be empty"), w)
+		return
+	}
+
+	if req.LinkedAccounts == nil {
+		util.WriteError(status.Errorf(status.InvalidArgument, "org key linked_accounts field is invalid"), w)
+		return
+	}
+
+	keyToSave := &server.OrgKey{
+		ID:             keyID,
+		Name:           req.Name,
+		LinkedAccounts: req.LinkedAccounts,
+	}
+	if req.Revoked != nil {
+		keyToSave.Revoked = *req.Revoked
+	}
+
+	key, err := h.accountManager.SaveOrgKey(account.Id, user.Id, keyToSave)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toOrgKeyResponse(key))
+}
+
+func toOrgKeyResponse(key *server.OrgKey) *api.OrgKey {
+	linkedAccounts := key.LinkedAccounts
+	if linkedAccounts == nil {
+		linkedAccounts = []string{}
+	}
+
+	resp := &api.OrgKey{
+		Id:             key.ID,
+		Key:            key.Key,
+		Name:           key.Name,
+		CreatedAt:      key.CreatedAt,
+		Revoked:        key.Revoked,
+		Valid:          key.IsValid(),
+		LinkedAccounts: linkedAccounts,
+	}
+	if !key.ExpiresAt.IsZero() {
+		expires := key.ExpiresAt
+		resp.Expires = &expires
+	}
+	return resp
+}
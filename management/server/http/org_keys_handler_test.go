@@ -0,0 +1,230 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingOrgKeyID      = "existingOrgKeyID"
+	testOrgKeyAccount     = "test_id"
+	testOrgKeyValue       = "A616097E-FCF0-48FA-9354-CA4A61142761"
+	testOrgKeyLinkedAccID = "customer_id"
+)
+
+var testOrgKeyCreatedAt = time.Now().UTC().Truncate(time.Second)
+
+var testingOrgKeyAccount = &server.Account{
+	Id:     testOrgKeyAccount,
+	Domain: "hotmail.com",
+	Users: map[string]*server.User{
+		"test_user": server.NewAdminUser("test_user"),
+	},
+}
+
+func initOrgKeysTestData() *OrgKeysHandler {
+	return &OrgKeysHandler{
+		accountManager: &mock_server.MockAccountManager{
+			CreateOrgKeyFunc: func(_, _, name string, expiresIn time.Duration, linkedAccounts []string) (*server.OrgKey, error) {
+				for _, id := range linkedAccounts {
+					if id != testOrgKeyLinkedAccID {
+						return nil, status.Errorf(status.InvalidArgument, "linked account %s wasn't found", id)
+					}
+				}
+				key := &server.OrgKey{
+					ID:             existingOrgKeyID,
+					Key:            testOrgKeyValue,
+					Name:           name,
+					CreatedAt:      testOrgKeyCreatedAt,
+					LinkedAccounts: linkedAccounts,
+				}
+				if expiresIn > 0 {
+					key.ExpiresAt = testOrgKeyCreatedAt.Add(expiresIn)
+				}
+				return key, nil
+			},
+			SaveOrgKeyFunc: func(_, _ string, key *server.OrgKey) (*server.OrgKey, error) {
+				if key.ID != existingOrgKeyID {
+					return nil, status.Errorf(status.NotFound, "org key %s wasn't found", key.ID)
+				}
+				key = key.Copy()
+				key.Key = testOrgKeyValue
+				key.CreatedAt = testOrgKeyCreatedAt
+				return key, nil
+			},
+			ListOrgKeysFunc: func(_, _ string) ([]*server.OrgKey, error) {
+				return []*server.OrgKey{{ID: existingOrgKeyID, Key: testOrgKeyValue, Name: "msp", CreatedAt: testOrgKeyCreatedAt}}, nil
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingOrgKeyAccount, testingOrgKeyAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testOrgKeyAccount,
+				}
+			}),
+		),
+	}
+}
+
+func TestOrgKeysHandlers(t *testing.T) {
+	expires := testOrgKeyCreatedAt.Add(24 * time.Hour)
+
+	tt := []struct {
+		name           string
+		expectedStatus int
+		expectedBody   bool
+		expectedKey    *api.OrgKey
+		requestType    string
+		requestPath    string
+		requestBody    io.Reader
+	}{
+		{
+			name:           "POST OK",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/org-keys",
+			requestBody:    bytes.NewBufferString(`{"name":"msp","expires_in":86400,"linked_accounts":["` + testOrgKeyLinkedAccID + `"]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedKey: &api.OrgKey{
+				Id:             existingOrgKeyID,
+				Key:            testOrgKeyValue,
+				Name:           "msp",
+				CreatedAt:      testOrgKeyCreatedAt,
+				Expires:        &expires,
+				Valid:          true,
+				LinkedAccounts: []string{testOrgKeyLinkedAccID},
+			},
+		},
+		{
+			name:           "POST Without Expiration OK",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/org-keys",
+			requestBody:    bytes.NewBufferString(`{"name":"msp"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedKey: &api.OrgKey{
+				Id:             existingOrgKeyID,
+				Key:            testOrgKeyValue,
+				Name:           "msp",
+				CreatedAt:      testOrgKeyCreatedAt,
+				Valid:          true,
+				LinkedAccounts: []string{},
+			},
+		},
+		{
+			name:           "POST Without Name",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/org-keys",
+			requestBody:    bytes.NewBufferString(`{"linked_accounts":[]}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "POST Negative Expiration",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/org-keys",
+			requestBody:    bytes.NewBufferString(`{"name":"msp","expires_in":-1,"linked_accounts":[]}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "POST Unknown Linked Account",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/org-keys",
+			requestBody:    bytes.NewBufferString(`{"name":"msp","linked_accounts":["unknown"]}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PUT Revoke OK",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/org-keys/" + existingOrgKeyID,
+			requestBody:    bytes.NewBufferString(`{"name":"msp","revoked":true,"linked_accounts":["` + testOrgKeyLinkedAccID + `"]}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedKey: &api.OrgKey{
+				Id:             existingOrgKeyID,
+				Key:            testOrgKeyValue,
+				Name:           "msp",
+				CreatedAt:      testOrgKeyCreatedAt,
+				Revoked:        true,
+				LinkedAccounts: []string{testOrgKeyLinkedAccID},
+			},
+		},
+		{
+			name:           "PUT Without Linked Accounts",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/org-keys/" + existingOrgKeyID,
+			requestBody:    bytes.NewBufferString(`{"name":"msp"}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "PUT Not Existing Org Key",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/org-keys/notFound",
+			requestBody:    bytes.NewBufferString(`{"name":"msp","linked_accounts":[]}`),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "GET All OK",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/org-keys",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	p := initOrgKeysTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/org-keys", p.GetAllOrgKeys).Methods("GET")
+			router.HandleFunc("/api/org-keys", p.CreateOrgKey).Methods("POST")
+			router.HandleFunc("/api/org-keys/{keyId}", p.UpdateOrgKey).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.OrgKey{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedKey, got)
+		})
+	}
+}
@@ -115,7 +115,12 @@ func (h *PATHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pat, err := h.accountManager.CreatePAT(account.Id, user.Id, targetUserID, req.Name, req.ExpiresIn)
+	var role server.UserRole
+	if req.Role != nil {
+		role = server.UserRole(*req.Role)
+	}
+
+	pat, err := h.accountManager.CreatePAT(account.Id, user.Id, targetUserID, req.Name, req.ExpiresIn, role)
 	if err != nil {
 		util.WriteError(err, w)
 		return
@@ -160,6 +165,11 @@ func toPATResponse(pat *server.PersonalAccessToken) *api.PersonalAccessToken {
 	if !pat.LastUsed.IsZero() {
 		lastUsed = &pat.LastUsed
 	}
+	var role *string
+	if pat.Role != "" {
+		r := string(pat.Role)
+		role = &r
+	}
 	return &api.PersonalAccessToken{
 		CreatedAt:      pat.CreatedAt,
 		CreatedBy:      pat.CreatedBy,
@@ -167,6 +177,7 @@ func toPATResponse(pat *server.PersonalAccessToken) *api.PersonalAccessToken {
 		ExpirationDate: pat.ExpirationDate,
 		Id:             pat.ID,
 		LastUsed:       lastUsed,
+		Role:           role,
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/netip"
 
 	"github.com/gorilla/mux"
 
@@ -82,6 +83,12 @@ func (h *PeersHandler) updatePeer(account *server.Account, user *server.User, pe
 		update.Status = &nbpeer.PeerStatus{RequiresApproval: *req.ApprovalRequired}
 	}
 
+	if req.ForceRelay != nil {
+		update.ForceRelay = *req.ForceRelay
+	} else if current := account.GetPeer(peerID); current != nil {
+		update.ForceRelay = current.ForceRelay
+	}
+
 	peer, err := h.accountManager.UpdatePeer(account.Id, user.Id, update)
 	if err != nil {
 		util.WriteError(err, w)
@@ -175,6 +182,193 @@ func (h *PeersHandler) GetAllPeers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetPendingPeers returns a list of the peers waiting for an approval
+func (h *PeersHandler) GetPendingPeers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.WriteErrorResponse("wrong HTTP method", http.StatusMethodNotAllowed, w)
+		return
+	}
+
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peers, err := h.accountManager.GetPendingPeers(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	dnsDomain := h.accountManager.GetDNSDomain()
+
+	respBody := make([]*api.PeerBatch, 0, len(peers))
+	for _, peer := range peers {
+		peerToReturn, err := h.checkPeerStatus(peer)
+		if err != nil {
+			util.WriteError(err, w)
+			return
+		}
+		groupMinimumInfo := toGroupsInfo(account.Groups, peer.ID)
+
+		respBody = append(respBody, toPeerListItemResponse(peerToReturn, groupMinimumInfo, dnsDomain, 0))
+	}
+	util.WriteJSONObject(w, respBody)
+}
+
+// GetNonCompliantPeers returns the peers failing the enabled posture checks of the account and the reasons
+func (h *PeersHandler) GetNonCompliantPeers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.WriteErrorResponse("wrong HTTP method", http.StatusMethodNotAllowed, w)
+		return
+	}
+
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peers, err := h.accountManager.GetNonCompliantPeers(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := make([]api.NonCompliantPeer, 0, len(peers))
+	for _, nonCompliant := range peers {
+		failures := make([]api.PostureCheckFailure, 0, len(nonCompliant.Failures))
+		for _, failure := range nonCompliant.Failures {
+			failures = append(failures, api.PostureCheckFailure{
+				CheckId:   failure.CheckID,
+				CheckName: failure.CheckName,
+				Action:    failure.Action,
+				Reason:    failure.Reason,
+			})
+		}
+
+		peer := nonCompliant.Peer
+		resp = append(resp, api.NonCompliantPeer{
+			Id:          peer.ID,
+			Name:        peer.Name,
+			Ip:          peer.IP.String(),
+			Os:          fmt.Sprintf("%s %s", peer.Meta.OS, peer.Meta.Core),
+			Version:     peer.Meta.WtVersion,
+			Connected:   peer.Status.Connected,
+			LastSeen:    peer.Status.LastSeen,
+			Quarantined: peer.Status.NonCompliant,
+			Failures:    failures,
+		})
+	}
+
+	util.WriteJSONObject(w, resp)
+}
+
+// ApprovePeer approves a peer waiting for an approval, so it joins the network
+func (h *PeersHandler) ApprovePeer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.WriteErrorResponse("wrong HTTP method", http.StatusMethodNotAllowed, w)
+		return
+	}
+
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	peer, err := h.accountManager.ApprovePeer(account.Id, user.Id, peerID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	dnsDomain := h.accountManager.GetDNSDomain()
+
+	groupMinimumInfo := toGroupsInfo(account.Groups, peer.ID)
+
+	// the account was fetched before the approval, so the network map is computed with the approved peer
+	account.UpdatePeer(peer)
+	netMap := account.GetPeerNetworkMap(peerID, dnsDomain)
+	accessiblePeers := toAccessiblePeers(netMap, dnsDomain)
+
+	util.WriteJSONObject(w, toSinglePeerResponse(peer, groupMinimumInfo, dnsDomain, accessiblePeers))
+}
+
+// RejectPeer rejects a peer waiting for an approval, the peer is deleted
+func (h *PeersHandler) RejectPeer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		util.WriteErrorResponse("wrong HTTP method", http.StatusMethodNotAllowed, w)
+		return
+	}
+
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	err = h.accountManager.RejectPeer(account.Id, user.Id, peerID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetPeerNetworkMap returns the network map the peer receives from the management service, for troubleshooting
+func (h *PeersHandler) GetPeerNetworkMap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		util.WriteErrorResponse("wrong HTTP method", http.StatusMethodNotAllowed, w)
+		return
+	}
+
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	netMap, err := h.accountManager.GetPeerNetworkMapPreview(account.Id, peerID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peer := account.GetPeer(peerID)
+	if peer == nil {
+		util.WriteError(status.Errorf(status.NotFound, "peer with %s not found under account %s", peerID, account.Id), w)
+		return
+	}
+
+	util.WriteJSONObject(w, toPeerNetworkMapResponse(peer, netMap, h.accountManager.GetDNSDomain()))
+}
+
 func (h *PeersHandler) accessiblePeersNumber(account *server.Account, peerID string) int {
 	netMap := account.GetPeerNetworkMap(peerID, h.accountManager.GetDNSDomain())
 	return len(netMap.Peers) + len(netMap.OfflinePeers)
@@ -206,6 +400,100 @@ func toAccessiblePeers(netMap *server.NetworkMap, dnsDomain string) []api.Access
 	return accessiblePeers
 }
 
+// toPeerNetworkMapResponse renders the network map the same way it is sent to the peer in the sync responses
+func toPeerNetworkMapResponse(peer *nbpeer.Peer, netMap *server.NetworkMap, dnsDomain string) *api.PeerNetworkMap {
+	netmask, _ := netMap.Network.Net.Mask.Size()
+	peerConfig := api.PeerNetworkMapPeerConfig{
+		Address:    fmt.Sprintf("%s/%d", peer.IP.String(), netmask),
+		Fqdn:       peer.FQDN(dnsDomain),
+		SshEnabled: peer.SSHEnabled,
+	}
+	if peer.IPv6 != nil && netMap.Network.NetV6.IP != nil {
+		netmaskV6, _ := netMap.Network.NetV6.Mask.Size()
+		addressV6 := fmt.Sprintf("%s/%d", peer.IPv6.String(), netmaskV6)
+		peerConfig.AddressV6 = &addressV6
+	}
+
+	routes := make([]api.Route, 0, len(netMap.Routes))
+	for _, r := range netMap.Routes {
+		routes = append(routes, *toRouteResponse(r))
+	}
+
+	staticRoutes := make([]api.Route, 0, len(netMap.StaticRoutes))
+	for _, r := range netMap.StaticRoutes {
+		staticRoutes = append(staticRoutes, *toRouteResponse(r))
+	}
+
+	nsGroups := make([]api.NameserverGroup, 0, len(netMap.DNSConfig.NameServerGroups))
+	for _, nsGroup := range netMap.DNSConfig.NameServerGroups {
+		nsGroups = append(nsGroups, *toNameserverGroupResponse(nsGroup))
+	}
+
+	zones := make([]api.PeerNetworkMapCustomZone, 0, len(netMap.DNSConfig.CustomZones))
+	for _, zone := range netMap.DNSConfig.CustomZones {
+		records := make([]string, 0, len(zone.Records))
+		for _, record := range zone.Records {
+			records = append(records, record.String())
+		}
+		zones = append(zones, api.PeerNetworkMapCustomZone{Domain: zone.Domain, Records: records})
+	}
+
+	rules := make([]api.PeerNetworkMapFirewallRule, 0, len(netMap.FirewallRules))
+	for _, rule := range netMap.FirewallRules {
+		apiRule := api.PeerNetworkMapFirewallRule{
+			PeerIp:    rule.PeerIP,
+			Direction: api.PeerNetworkMapFirewallRuleDirectionOut,
+			Action:    api.PeerNetworkMapFirewallRuleAction(rule.Action),
+			Protocol:  api.PeerNetworkMapFirewallRuleProtocol(rule.Protocol),
+		}
+		if rule.Inbound() {
+			apiRule.Direction = api.PeerNetworkMapFirewallRuleDirectionIn
+		}
+		if rule.Port != "" {
+			port := rule.Port
+			apiRule.Port = &port
+		}
+		rules = append(rules, apiRule)
+	}
+
+	return &api.PeerNetworkMap{
+		Serial:       int64(netMap.Network.CurrentSerial()),
+		PeerConfig:   peerConfig,
+		RemotePeers:  toPeerNetworkMapRemotePeers(netMap.Peers, dnsDomain),
+		OfflinePeers: toPeerNetworkMapRemotePeers(netMap.OfflinePeers, dnsDomain),
+		Routes:       routes,
+		StaticRoutes: staticRoutes,
+		DnsConfig: api.PeerNetworkMapDNSConfig{
+			ServiceEnable:    netMap.DNSConfig.ServiceEnable,
+			NameserverGroups: nsGroups,
+			CustomZones:      zones,
+		},
+		FirewallRules: rules,
+	}
+}
+
+func toPeerNetworkMapRemotePeers(peers []*nbpeer.Peer, dnsDomain string) []api.PeerNetworkMapRemotePeer {
+	remotePeers := make([]api.PeerNetworkMapRemotePeer, 0, len(peers))
+	for _, p := range peers {
+		remotePeer := api.PeerNetworkMapRemotePeer{
+			Id:         p.ID,
+			Name:       p.Name,
+			WgPubKey:   p.Key,
+			AllowedIps: []string{fmt.Sprintf(server.AllowedIPsFormat, p.IP)},
+			Fqdn:       p.FQDN(dnsDomain),
+		}
+		if p.IPv6 != nil {
+			addressV6 := fmt.Sprintf(server.AllowedIPsV6Format, p.IPv6)
+			remotePeer.AddressV6 = &addressV6
+		}
+		if sshKey := server.ValidSSHKey(p); sshKey != "" {
+			remotePeer.SshPubKey = &sshKey
+		}
+		remotePeers = append(remotePeers, remotePeer)
+	}
+	return remotePeers
+}
+
 func toGroupsInfo(groups map[string]*server.Group, peerID string) []api.GroupMinimum {
 	var groupsInfo []api.GroupMinimum
 	groupsChecked := make(map[string]struct{})
@@ -241,6 +529,7 @@ func toSinglePeerResponse(peer *nbpeer.Peer, groupsInfo []api.GroupMinimum, dnsD
 		Version:                peer.Meta.WtVersion,
 		Groups:                 groupsInfo,
 		SshEnabled:             peer.SSHEnabled,
+		ForceRelay:             peer.ForceRelay,
 		Hostname:               peer.Meta.Hostname,
 		UserId:                 &peer.UserID,
 		UiVersion:              &peer.Meta.UIVersion,
@@ -250,6 +539,9 @@ func toSinglePeerResponse(peer *nbpeer.Peer, groupsInfo []api.GroupMinimum, dnsD
 		LoginExpired:           peer.Status.LoginExpired,
 		AccessiblePeers:        accessiblePeer,
 		ApprovalRequired:       &peer.Status.RequiresApproval,
+		Inactive:               &peer.Status.Inactive,
+		AdvertisedNetworks:     toAdvertisedNetworksResponse(peer.AdvertisedNetworks),
+		NatType:                toNATTypeResponse(peer.Location.NATType),
 	}
 }
 
@@ -264,6 +556,7 @@ func toPeerListItemResponse(peer *nbpeer.Peer, groupsInfo []api.GroupMinimum, dn
 		Version:                peer.Meta.WtVersion,
 		Groups:                 groupsInfo,
 		SshEnabled:             peer.SSHEnabled,
+		ForceRelay:             peer.ForceRelay,
 		Hostname:               peer.Meta.Hostname,
 		UserId:                 &peer.UserID,
 		UiVersion:              &peer.Meta.UIVersion,
@@ -273,7 +566,26 @@ func toPeerListItemResponse(peer *nbpeer.Peer, groupsInfo []api.GroupMinimum, dn
 		LoginExpired:           peer.Status.LoginExpired,
 		AccessiblePeersCount:   accessiblePeersCount,
 		ApprovalRequired:       &peer.Status.RequiresApproval,
+		Inactive:               &peer.Status.Inactive,
+		AdvertisedNetworks:     toAdvertisedNetworksResponse(peer.AdvertisedNetworks),
+		NatType:                toNATTypeResponse(peer.Location.NATType),
+	}
+}
+
+// toNATTypeResponse returns the NAT type of the peer, nil when unknown
+func toNATTypeResponse(natType string) *string {
+	if natType == "" {
+		return nil
+	}
+	return &natType
+}
+
+func toAdvertisedNetworksResponse(networks []netip.Prefix) *[]string {
+	response := make([]string, 0, len(networks))
+	for _, network := range networks {
+		response = append(response, network.String())
 	}
+	return &response
 }
 
 func fqdn(peer *nbpeer.Peer, dnsDomain string) string {
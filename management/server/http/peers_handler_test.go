@@ -7,13 +7,17 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	nbdns "github.com/netbirdio/netbird/dns"
 	"github.com/netbirdio/netbird/management/server/http/api"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/route"
 
 	"github.com/netbirdio/netbird/management/server/jwtclaims"
 
@@ -38,6 +42,7 @@ func initTestMetaData(peers ...*nbpeer.Peer) *PeersHandler {
 					}
 				}
 				p.SSHEnabled = update.SSHEnabled
+				p.ForceRelay = update.ForceRelay
 				p.LoginExpirationEnabled = update.LoginExpirationEnabled
 				p.Name = update.Name
 				return p, nil
@@ -134,6 +139,7 @@ func TestGetPeers(t *testing.T) {
 	expectedUpdatedPeer := peer.Copy()
 	expectedUpdatedPeer.LoginExpirationEnabled = true
 	expectedUpdatedPeer.SSHEnabled = true
+	expectedUpdatedPeer.ForceRelay = true
 	expectedUpdatedPeer.Name = "New Name"
 
 	expectedPeer1 := peer1.Copy()
@@ -178,7 +184,7 @@ func TestGetPeers(t *testing.T) {
 			requestPath:    "/api/peers/" + testPeerID,
 			expectedStatus: http.StatusOK,
 			expectedArray:  false,
-			requestBody:    bytes.NewBufferString("{\"login_expiration_enabled\":true,\"name\":\"New Name\",\"ssh_enabled\":true}"),
+			requestBody:    bytes.NewBufferString("{\"login_expiration_enabled\":true,\"name\":\"New Name\",\"ssh_enabled\":true,\"force_relay\":true}"),
 			expectedPeer:   expectedUpdatedPeer,
 		},
 	}
@@ -241,7 +247,115 @@ func TestGetPeers(t *testing.T) {
 			assert.Equal(t, got.Os, "OS core")
 			assert.Equal(t, got.LoginExpirationEnabled, tc.expectedPeer.LoginExpirationEnabled)
 			assert.Equal(t, got.SshEnabled, tc.expectedPeer.SSHEnabled)
+			assert.Equal(t, got.ForceRelay, tc.expectedPeer.ForceRelay)
 			assert.Equal(t, got.Connected, tc.expectedPeer.Status.Connected)
 		})
 	}
 }
+
+func TestGetPeerNetworkMap(t *testing.T) {
+	peer := &nbpeer.Peer{
+		ID:         testPeerID,
+		Key:        "key",
+		IP:         net.ParseIP("100.64.0.1"),
+		Status:     &nbpeer.PeerStatus{Connected: true},
+		Name:       "PeerName",
+		DNSLabel:   "peer-name",
+		SSHEnabled: true,
+	}
+	remotePeer := &nbpeer.Peer{
+		ID:       "remote_peer",
+		Key:      "remote_key",
+		IP:       net.ParseIP("100.64.0.2"),
+		Status:   &nbpeer.PeerStatus{Connected: true},
+		Name:     "RemotePeer",
+		DNSLabel: "remote-peer",
+		SSHKey:   "invalid",
+	}
+
+	p := initTestMetaData(peer, remotePeer)
+	p.accountManager.(*mock_server.MockAccountManager).GetDNSDomainFunc = func() string { return "netbird.cloud" }
+	p.accountManager.(*mock_server.MockAccountManager).GetPeerNetworkMapPreviewFunc = func(accountID, peerID, userID string) (*server.NetworkMap, error) {
+		if peerID != testPeerID {
+			return nil, status.Errorf(status.NotFound, "peer with %s not found under account %s", peerID, accountID)
+		}
+		return &server.NetworkMap{
+			Peers: []*nbpeer.Peer{remotePeer},
+			Network: &server.Network{
+				Net:    net.IPNet{IP: net.ParseIP("100.64.0.0"), Mask: net.CIDRMask(10, 32)},
+				Serial: 51,
+			},
+			Routes: []*route.Route{{
+				ID:          "route",
+				NetID:       "office",
+				Network:     netip.MustParsePrefix("192.168.0.0/24"),
+				NetworkType: route.IPv4Network,
+				Peer:        remotePeer.ID,
+				Metric:      9999,
+				Enabled:     true,
+				Groups:      []string{"group"},
+			}},
+			DNSConfig: nbdns.Config{
+				ServiceEnable: true,
+				CustomZones: []nbdns.CustomZone{{
+					Domain: "netbird.cloud.",
+					Records: []nbdns.SimpleRecord{{
+						Name:  "remote-peer.netbird.cloud",
+						Type:  1,
+						Class: nbdns.DefaultClass,
+						TTL:   300,
+						RData: "100.64.0.2",
+					}},
+				}},
+			},
+			FirewallRules: []*server.FirewallRule{
+				// inbound SSH from the remote peer
+				{PeerIP: "100.64.0.2", Direction: 0, Action: "accept", Protocol: "tcp", Port: "22"},
+				{PeerIP: "100.64.0.2", Direction: 1, Action: "accept", Protocol: "all"},
+			},
+		}, nil
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/peers/{peerId}/network-map", p.GetPeerNetworkMap).Methods("GET")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/peers/unknown/network-map", nil))
+	assert.Equal(t, recorder.Code, http.StatusNotFound)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/peers/"+testPeerID+"/network-map", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+
+	got := &api.PeerNetworkMap{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), got); err != nil {
+		t.Fatalf("Sent content is not in correct json format; %v", err)
+	}
+
+	assert.Equal(t, got.Serial, int64(51))
+	assert.Equal(t, got.PeerConfig, api.PeerNetworkMapPeerConfig{
+		Address:    "100.64.0.1/10",
+		Fqdn:       "peer-name.netbird.cloud",
+		SshEnabled: true,
+	})
+	assert.Equal(t, got.RemotePeers, []api.PeerNetworkMapRemotePeer{{
+		Id:         "remote_peer",
+		Name:       "RemotePeer",
+		WgPubKey:   "remote_key",
+		AllowedIps: []string{"100.64.0.2/32"},
+		Fqdn:       "remote-peer.netbird.cloud",
+	}})
+	assert.Equal(t, got.OfflinePeers, []api.PeerNetworkMapRemotePeer{})
+	assert.Equal(t, len(got.Routes), 1)
+	assert.Equal(t, got.Routes[0].Network, "192.168.0.0/24")
+	assert.Equal(t, got.DnsConfig.ServiceEnable, true)
+	assert.Equal(t, got.DnsConfig.CustomZones, []api.PeerNetworkMapCustomZone{{
+		Domain:  "netbird.cloud.",
+		Records: []string{"remote-peer.netbird.cloud. 300 IN A 100.64.0.2"},
+	}})
+	port := "22"
+	assert.Equal(t, got.FirewallRules, []api.PeerNetworkMapFirewallRule{
+		{PeerIp: "100.64.0.2", Direction: api.PeerNetworkMapFirewallRuleDirectionIn, Action: api.PeerNetworkMapFirewallRuleActionAccept, Protocol: api.PeerNetworkMapFirewallRuleProtocolTcp, Port: &port},
+		{PeerIp: "100.64.0.2", Direction: api.PeerNetworkMapFirewallRuleDirectionOut, Action: api.PeerNetworkMapFirewallRuleActionAccept, Protocol: api.PeerNetworkMapFirewallRuleProtocolAll},
+	})
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/xid"
+	"golang.org/x/exp/slices"
 
 	"github.com/netbirdio/netbird/management/server"
 	"github.com/netbirdio/netbird/management/server/http/api"
@@ -117,101 +120,18 @@ func (h *Policies) savePolicy(
 		return
 	}
 
-	if req.Name == "" {
-		util.WriteError(status.Errorf(status.InvalidArgument, "policy name shouldn't be empty"), w)
-		return
-	}
-
-	if len(req.Rules) == 0 {
-		util.WriteError(status.Errorf(status.InvalidArgument, "policy rules shouldn't be empty"), w)
+	policy, err := toPolicy(account, policyID, &req)
+	if err != nil {
+		util.WriteError(err, w)
 		return
 	}
 
-	if policyID == "" {
-		policyID = xid.New().String()
-	}
-
-	policy := server.Policy{
-		ID:          policyID,
-		Name:        req.Name,
-		Enabled:     req.Enabled,
-		Description: req.Description,
-	}
-	for _, r := range req.Rules {
-		pr := server.PolicyRule{
-			ID:            policyID, //TODO: when policy can contain multiple rules, need refactor
-			Name:          r.Name,
-			Destinations:  groupMinimumsToStrings(account, r.Destinations),
-			Sources:       groupMinimumsToStrings(account, r.Sources),
-			Bidirectional: r.Bidirectional,
-		}
-
-		pr.Enabled = r.Enabled
-		if r.Description != nil {
-			pr.Description = *r.Description
-		}
-
-		switch r.Action {
-		case api.PolicyRuleUpdateActionAccept:
-			pr.Action = server.PolicyTrafficActionAccept
-		case api.PolicyRuleUpdateActionDrop:
-			pr.Action = server.PolicyTrafficActionDrop
-		default:
-			util.WriteError(status.Errorf(status.InvalidArgument, "unknown action type"), w)
-			return
-		}
-
-		switch r.Protocol {
-		case api.PolicyRuleUpdateProtocolAll:
-			pr.Protocol = server.PolicyRuleProtocolALL
-		case api.PolicyRuleUpdateProtocolTcp:
-			pr.Protocol = server.PolicyRuleProtocolTCP
-		case api.PolicyRuleUpdateProtocolUdp:
-			pr.Protocol = server.PolicyRuleProtocolUDP
-		case api.PolicyRuleUpdateProtocolIcmp:
-			pr.Protocol = server.PolicyRuleProtocolICMP
-		default:
-			util.WriteError(status.Errorf(status.InvalidArgument, "unknown protocol type: %v", r.Protocol), w)
-			return
-		}
-
-		if r.Ports != nil && len(*r.Ports) != 0 {
-			for _, v := range *r.Ports {
-				if port, err := strconv.Atoi(v); err != nil || port < 1 || port > 65535 {
-					util.WriteError(status.Errorf(status.InvalidArgument, "valid port value is in 1..65535 range"), w)
-					return
-				}
-				pr.Ports = append(pr.Ports, v)
-			}
-		}
-
-		// validate policy object
-		switch pr.Protocol {
-		case server.PolicyRuleProtocolALL, server.PolicyRuleProtocolICMP:
-			if len(pr.Ports) != 0 {
-				util.WriteError(status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol ports is not allowed"), w)
-				return
-			}
-			if !pr.Bidirectional {
-				util.WriteError(status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol type flow can be only bi-directional"), w)
-				return
-			}
-		case server.PolicyRuleProtocolTCP, server.PolicyRuleProtocolUDP:
-			if !pr.Bidirectional && len(pr.Ports) == 0 {
-				util.WriteError(status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol type flow can be only bi-directional"), w)
-				return
-			}
-		}
-
-		policy.Rules = append(policy.Rules, &pr)
-	}
-
-	if err := h.accountManager.SavePolicy(account.Id, user.Id, &policy); err != nil {
+	if err := h.accountManager.SavePolicy(account.Id, user.Id, policy); err != nil {
 		util.WriteError(err, w)
 		return
 	}
 
-	resp := toPolicyResponse(account, &policy)
+	resp := toPolicyResponse(account, policy)
 	if len(resp.Rules) == 0 {
 		util.WriteError(status.Errorf(status.Internal, "no rules in the policy"), w)
 		return
@@ -245,6 +165,39 @@ func (h *Policies) DeletePolicy(w http.ResponseWriter, r *http.Request) {
 	util.WriteJSONObject(w, emptyObject{})
 }
 
+// SimulatePolicy handles a request evaluating whether the policies allow a traffic between two peers
+func (h *Policies) SimulatePolicy(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiPoliciesSimulateJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	request := &server.PolicySimulationRequest{
+		Source:      req.Source,
+		Destination: req.Destination,
+		Protocol:    server.PolicyRuleProtocolType(req.Protocol),
+	}
+	if req.Port != nil {
+		request.Port = *req.Port
+	}
+
+	simulation, err := h.accountManager.SimulatePolicy(account.Id, user.Id, request)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, toPolicySimulationResponse(simulation))
+}
+
 // GetPolicy handles a group Get request identified by ID
 func (h *Policies) GetPolicy(w http.ResponseWriter, r *http.Request) {
 	claims := h.claimsExtractor.FromRequestContext(r)
@@ -301,10 +254,24 @@ func toPolicyResponse(account *server.Account, policy *server.Policy) *api.Polic
 			Protocol:      api.PolicyRuleProtocol(r.Protocol),
 			Action:        api.PolicyRuleAction(r.Action),
 		}
+		if len(r.AdditionalProtocols) != 0 {
+			protocols := make([]api.PolicyRuleAdditionalProtocols, 0, len(r.AdditionalProtocols))
+			for _, protocol := range r.AdditionalProtocols {
+				protocols = append(protocols, api.PolicyRuleAdditionalProtocols(protocol))
+			}
+			rule.AdditionalProtocols = &protocols
+		}
 		if len(r.Ports) != 0 {
 			portsCopy := r.Ports
 			rule.Ports = &portsCopy
 		}
+		if r.Schedule != nil {
+			rule.Schedule = toPolicyScheduleResponse(r.Schedule)
+		}
+		if len(r.DestinationResources) != 0 {
+			resourcesCopy := r.DestinationResources
+			rule.DestinationResources = &resourcesCopy
+		}
 		for _, gid := range r.Sources {
 			_, ok := cache[gid]
 			if ok {
@@ -341,6 +308,172 @@ func toPolicyResponse(account *server.Account, policy *server.Policy) *api.Polic
 	return ap
 }
 
+var policyScheduleDays = map[api.PolicyScheduleDays]time.Weekday{
+	api.PolicyScheduleDaysSunday:    time.Sunday,
+	api.PolicyScheduleDaysMonday:    time.Monday,
+	api.PolicyScheduleDaysTuesday:   time.Tuesday,
+	api.PolicyScheduleDaysWednesday: time.Wednesday,
+	api.PolicyScheduleDaysThursday:  time.Thursday,
+	api.PolicyScheduleDaysFriday:    time.Friday,
+	api.PolicyScheduleDaysSaturday:  time.Saturday,
+}
+
+func toPolicySchedule(req *api.PolicySchedule) (*server.PolicySchedule, error) {
+	schedule := &server.PolicySchedule{}
+	if req.Days != nil {
+		for _, d := range *req.Days {
+			day, ok := policyScheduleDays[d]
+			if !ok {
+				return nil, status.Errorf(status.InvalidArgument, "unknown schedule day: %v", d)
+			}
+			schedule.Days = append(schedule.Days, day)
+		}
+	}
+	if req.TimeRanges != nil {
+		for _, tr := range *req.TimeRanges {
+			schedule.TimeRanges = append(schedule.TimeRanges, server.PolicyTimeRange{Start: tr.Start, End: tr.End})
+		}
+	}
+	if req.Timezone != nil {
+		schedule.Timezone = *req.Timezone
+	}
+	return schedule, nil
+}
+
+func toPolicyScheduleResponse(schedule *server.PolicySchedule) *api.PolicySchedule {
+	days := make([]api.PolicyScheduleDays, 0, len(schedule.Days))
+	for _, day := range schedule.Days {
+		days = append(days, api.PolicyScheduleDays(strings.ToLower(day.String())))
+	}
+	timeRanges := make([]api.PolicyTimeRange, 0, len(schedule.TimeRanges))
+	for _, tr := range schedule.TimeRanges {
+		timeRanges = append(timeRanges, api.PolicyTimeRange{Start: tr.Start, End: tr.End})
+	}
+	timezone := schedule.Timezone
+	return &api.PolicySchedule{
+		Days:       &days,
+		TimeRanges: &timeRanges,
+		Timezone:   &timezone,
+	}
+}
+
+// toPolicy validates the request and returns the policy it saves, a new policy when policyID is empty. The rules keep
+// the groups of the account only.
+func toPolicy(account *server.Account, policyID string, req *api.PolicyUpdate) (*server.Policy, error) {
+	if req.Name == "" {
+		return nil, status.Errorf(status.InvalidArgument, "policy name shouldn't be empty")
+	}
+
+	if len(req.Rules) == 0 {
+		return nil, status.Errorf(status.InvalidArgument, "policy rules shouldn't be empty")
+	}
+
+	if policyID == "" {
+		policyID = xid.New().String()
+	}
+
+	policy := &server.Policy{
+		ID:          policyID,
+		Name:        req.Name,
+		Enabled:     req.Enabled,
+		Description: req.Description,
+	}
+	for _, r := range req.Rules {
+		pr := server.PolicyRule{
+			ID:            policyID, //TODO: when policy can contain multiple rules, need refactor
+			Name:          r.Name,
+			Destinations:  groupMinimumsToStrings(account, r.Destinations),
+			Sources:       groupMinimumsToStrings(account, r.Sources),
+			Bidirectional: r.Bidirectional,
+		}
+
+		pr.Enabled = r.Enabled
+		if r.Description != nil {
+			pr.Description = *r.Description
+		}
+
+		switch r.Action {
+		case api.PolicyRuleUpdateActionAccept:
+			pr.Action = server.PolicyTrafficActionAccept
+		case api.PolicyRuleUpdateActionDrop:
+			pr.Action = server.PolicyTrafficActionDrop
+		default:
+			return nil, status.Errorf(status.InvalidArgument, "unknown action type")
+		}
+
+		switch r.Protocol {
+		case api.PolicyRuleUpdateProtocolAll:
+			pr.Protocol = server.PolicyRuleProtocolALL
+		case api.PolicyRuleUpdateProtocolTcp:
+			pr.Protocol = server.PolicyRuleProtocolTCP
+		case api.PolicyRuleUpdateProtocolUdp:
+			pr.Protocol = server.PolicyRuleProtocolUDP
+		case api.PolicyRuleUpdateProtocolIcmp:
+			pr.Protocol = server.PolicyRuleProtocolICMP
+		default:
+			return nil, status.Errorf(status.InvalidArgument, "unknown protocol type: %v", r.Protocol)
+		}
+
+		if r.AdditionalProtocols != nil {
+			for _, v := range *r.AdditionalProtocols {
+				protocol := server.PolicyRuleProtocolType(v)
+				switch protocol {
+				case server.PolicyRuleProtocolTCP, server.PolicyRuleProtocolUDP, server.PolicyRuleProtocolICMP:
+				default:
+					return nil, status.Errorf(status.InvalidArgument, "unknown additional protocol type: %v", v)
+				}
+				if protocol == pr.Protocol || slices.Contains(pr.AdditionalProtocols, protocol) {
+					continue
+				}
+				pr.AdditionalProtocols = append(pr.AdditionalProtocols, protocol)
+			}
+		}
+
+		if r.Ports != nil && len(*r.Ports) != 0 {
+			for _, v := range *r.Ports {
+				if port, err := strconv.Atoi(v); err != nil || port < 1 || port > 65535 {
+					return nil, status.Errorf(status.InvalidArgument, "valid port value is in 1..65535 range")
+				}
+				pr.Ports = append(pr.Ports, v)
+			}
+		}
+
+		if r.Schedule != nil {
+			schedule, err := toPolicySchedule(r.Schedule)
+			if err != nil {
+				return nil, err
+			}
+			pr.Schedule = schedule
+		}
+
+		if r.DestinationResources != nil {
+			pr.DestinationResources = *r.DestinationResources
+		}
+
+		// validate policy object
+		if pr.Protocol == server.PolicyRuleProtocolALL && len(pr.AdditionalProtocols) != 0 {
+			return nil, status.Errorf(status.InvalidArgument, "ALL protocol can't be combined with other protocols")
+		}
+		// the ICMP part of a one way rule matching TCP or UDP too applies both ways
+		portsAllowed := false
+		for _, protocol := range append([]server.PolicyRuleProtocolType{pr.Protocol}, pr.AdditionalProtocols...) {
+			if protocol == server.PolicyRuleProtocolTCP || protocol == server.PolicyRuleProtocolUDP {
+				portsAllowed = true
+			}
+		}
+		if !portsAllowed && len(pr.Ports) != 0 {
+			return nil, status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol ports is not allowed")
+		}
+		if !pr.Bidirectional && (!portsAllowed || len(pr.Ports) == 0) {
+			return nil, status.Errorf(status.InvalidArgument, "for ALL or ICMP protocol type flow can be only bi-directional")
+		}
+
+		policy.Rules = append(policy.Rules, &pr)
+	}
+
+	return policy, nil
+}
+
 func groupMinimumsToStrings(account *server.Account, gm []string) []string {
 	result := make([]string, 0, len(gm))
 	for _, g := range gm {
@@ -351,3 +484,23 @@ func groupMinimumsToStrings(account *server.Account, gm []string) []string {
 	}
 	return result
 }
+
+func toPolicySimulationResponse(simulation *server.PolicySimulation) *api.PolicySimulation {
+	resp := &api.PolicySimulation{
+		SourcePeerId:      simulation.SourcePeerID,
+		DestinationPeerId: simulation.DestinationPeerID,
+		Allowed:           simulation.Allowed,
+		Reason:            simulation.Reason,
+		SourceGroups:      simulation.SourceGroups,
+		DestinationGroups: simulation.DestinationGroups,
+	}
+	if simulation.RuleID != "" {
+		action := string(simulation.Action)
+		resp.Action = &action
+		resp.PolicyId = &simulation.PolicyID
+		resp.PolicyName = &simulation.PolicyName
+		resp.RuleId = &simulation.RuleID
+		resp.RuleName = &simulation.RuleName
+	}
+	return resp
+}
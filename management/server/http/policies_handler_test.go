@@ -208,6 +208,175 @@ func TestPoliciesWritePolicy(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "WritePolicy POST With Schedule OK",
+			requestType: http.MethodPost,
+			requestPath: "/api/policies",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+                    "Name":"Business Hours Policy",
+                    "Rules":[
+                        {
+                            "Name":"Business Hours Policy",
+                            "Description": "Description",
+                            "Protocol": "tcp",
+                            "Action": "accept",
+                            "Bidirectional":true,
+                            "Schedule": {
+                                "days": ["monday", "friday"],
+                                "time_ranges": [{"start": "09:00", "end": "17:00"}],
+                                "timezone": "Europe/Berlin"
+                            }
+                        }
+                ]}`)),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedPolicy: &api.Policy{
+				Id:   str("id-was-set"),
+				Name: "Business Hours Policy",
+				Rules: []api.PolicyRule{
+					{
+						Id:            str("id-was-set"),
+						Name:          "Business Hours Policy",
+						Description:   str("Description"),
+						Protocol:      "tcp",
+						Action:        "accept",
+						Bidirectional: true,
+						Schedule: &api.PolicySchedule{
+							Days:       &[]api.PolicyScheduleDays{api.PolicyScheduleDaysMonday, api.PolicyScheduleDaysFriday},
+							TimeRanges: &[]api.PolicyTimeRange{{Start: "09:00", End: "17:00"}},
+							Timezone:   str("Europe/Berlin"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "WritePolicy POST Invalid Schedule Day",
+			requestType: http.MethodPost,
+			requestPath: "/api/policies",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+                    "Name":"Business Hours Policy",
+                    "Rules":[
+                        {
+                            "Name":"Business Hours Policy",
+                            "Protocol": "tcp",
+                            "Action": "accept",
+                            "Bidirectional":true,
+                            "Schedule": {"days": ["someday"]}
+                        }
+                ]}`)),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "WritePolicy POST ICMP And SSH OK",
+			requestType: http.MethodPost,
+			requestPath: "/api/policies",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+                    "Name":"Ping And SSH Policy",
+                    "Rules":[
+                        {
+                            "Name":"Ping And SSH Policy",
+                            "Description": "Description",
+                            "Protocol": "tcp",
+                            "additional_protocols": ["icmp", "tcp"],
+                            "Ports": ["22"],
+                            "Action": "accept",
+                            "Bidirectional":true
+                        }
+                ]}`)),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedPolicy: &api.Policy{
+				Id:   str("id-was-set"),
+				Name: "Ping And SSH Policy",
+				Rules: []api.PolicyRule{
+					{
+						Id:                  str("id-was-set"),
+						Name:                "Ping And SSH Policy",
+						Description:         str("Description"),
+						Protocol:            "tcp",
+						AdditionalProtocols: &[]api.PolicyRuleAdditionalProtocols{api.PolicyRuleAdditionalProtocolsIcmp},
+						Ports:               &[]string{"22"},
+						Action:              "accept",
+						Bidirectional:       true,
+					},
+				},
+			},
+		},
+		{
+			name:        "WritePolicy POST ALL With Additional Protocols",
+			requestType: http.MethodPost,
+			requestPath: "/api/policies",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+                    "Name":"All Policy",
+                    "Rules":[
+                        {
+                            "Name":"All Policy",
+                            "Protocol": "all",
+                            "additional_protocols": ["icmp"],
+                            "Action": "accept",
+                            "Bidirectional":true
+                        }
+                ]}`)),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "WritePolicy POST ICMP And SSH Unidirectional",
+			requestType: http.MethodPost,
+			requestPath: "/api/policies",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+                    "Name":"Ping And SSH Policy",
+                    "Rules":[
+                        {
+                            "Name":"Ping And SSH Policy",
+                            "Protocol": "tcp",
+                            "additional_protocols": ["icmp"],
+                            "Ports": ["22"],
+                            "Action": "accept",
+                            "Bidirectional":false
+                        }
+                ]}`)),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedPolicy: &api.Policy{
+				Id:   str("id-was-set"),
+				Name: "Ping And SSH Policy",
+				Rules: []api.PolicyRule{
+					{
+						Id:                  str("id-was-set"),
+						Name:                "Ping And SSH Policy",
+						Description:         str(""),
+						Protocol:            "tcp",
+						AdditionalProtocols: &[]api.PolicyRuleAdditionalProtocols{api.PolicyRuleAdditionalProtocolsIcmp},
+						Ports:               &[]string{"22"},
+						Action:              "accept",
+						Bidirectional:       false,
+					},
+				},
+			},
+		},
+		{
+			name:        "WritePolicy POST ICMP Unidirectional",
+			requestType: http.MethodPost,
+			requestPath: "/api/policies",
+			requestBody: bytes.NewBuffer(
+				[]byte(`{
+                    "Name":"Ping Policy",
+                    "Rules":[
+                        {
+                            "Name":"Ping Policy",
+                            "Protocol": "icmp",
+                            "Action": "accept",
+                            "Bidirectional":false
+                        }
+                ]}`)),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
 		{
 			name:        "WritePolicy POST Invalid Name",
 			requestType: http.MethodPost,
@@ -313,3 +482,89 @@ func TestPoliciesWritePolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestPoliciesSimulatePolicy(t *testing.T) {
+	tt := []struct {
+		name           string
+		requestBody    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Simulate Allowed Traffic",
+			requestBody:    `{"source":"peerA","destination":"peerB","protocol":"tcp","port":443}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"action":"accept","allowed":true,"destination_groups":["G"],"destination_peer_id":"peerB","policy_id":"id-existed","policy_name":"Web","reason":"allowed","rule_id":"id-rule","rule_name":"HTTPS","source_groups":["F"],"source_peer_id":"peerA"}`,
+		},
+		{
+			name:           "Simulate Denied By Default",
+			requestBody:    `{"source":"peerA","destination":"peerB","protocol":"icmp"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"allowed":false,"destination_groups":[],"destination_peer_id":"peerB","reason":"denied by default (no matching allow)","source_groups":[],"source_peer_id":"peerA"}`,
+		},
+		{
+			name:           "Simulate Invalid Protocol",
+			requestBody:    `{"source":"peerA","destination":"peerB","protocol":"all"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Simulate Invalid JSON",
+			requestBody:    `{"source":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	p := initPoliciesTestData()
+	p.accountManager.(*mock_server.MockAccountManager).SimulatePolicyFunc = func(_, _ string, request *server.PolicySimulationRequest) (*server.PolicySimulation, error) {
+		switch request.Protocol {
+		case server.PolicyRuleProtocolTCP:
+			return &server.PolicySimulation{
+				SourcePeerID:      request.Source,
+				DestinationPeerID: request.Destination,
+				Allowed:           true,
+				Reason:            "allowed",
+				Action:            server.PolicyTrafficActionAccept,
+				PolicyID:          "id-existed",
+				PolicyName:        "Web",
+				RuleID:            "id-rule",
+				RuleName:          "HTTPS",
+				SourceGroups:      []string{"F"},
+				DestinationGroups: []string{"G"},
+			}, nil
+		case server.PolicyRuleProtocolICMP:
+			return &server.PolicySimulation{
+				SourcePeerID:      request.Source,
+				DestinationPeerID: request.Destination,
+				Reason:            server.PolicySimulationDefaultDenyReason,
+				SourceGroups:      []string{},
+				DestinationGroups: []string{},
+			}, nil
+		default:
+			return nil, status.Errorf(status.InvalidArgument, "invalid protocol")
+		}
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/policies/simulate", bytes.NewBufferString(tc.requestBody))
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/policies/simulate", p.SimulatePolicy).Methods("POST")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("read the response body: %v", err)
+			}
+
+			assert.Equal(t, recorder.Code, tc.expectedStatus, string(content))
+			if tc.expectedBody != "" {
+				assert.Equal(t, strings.Trim(string(content), " \n"), tc.expectedBody, "content mismatch")
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// PostureChecksHandler is the posture checks handler of the account
+type PostureChecksHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewPostureChecksHandler returns a new instance of PostureChecksHandler handler
+func NewPostureChecksHandler(accountManager server.AccountManager, authCfg AuthCfg) *PostureChecksHandler {
+	return &PostureChecksHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllPostureChecks returns the list of posture checks for the account
+func (h *PostureChecksHandler) GetAllPostureChecks(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	checks, err := h.accountManager.ListPostureChecks(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiChecks := make([]*api.PostureCheck, 0)
+	for _, check := range checks {
+		apiChecks = append(apiChecks, toPostureCheckResponse(check))
+	}
+
+	util.WriteJSONObject(w, apiChecks)
+}
+
+// CreatePostureCheck handles posture check creation request
+func (h *PostureChecksHandler) CreatePostureCheck(w http.ResponseWriter, r *http.Request) {
+	h.savePostureCheck(w, r, "")
+}
+
+// UpdatePostureCheck handles update to a posture check identified by a given ID
+func (h *PostureChecksHandler) UpdatePostureCheck(w http.ResponseWriter, r *http.Request) {
+	checkID := mux.Vars(r)["postureCheckId"]
+	if len(checkID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid posture check ID"), w)
+		return
+	}
+
+	h.savePostureCheck(w, r, checkID)
+}
+
+func (h *PostureChecksHandler) savePostureCheck(w http.ResponseWriter, r *http.Request, checkID string) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostureCheckRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	check, err := h.accountManager.SavePostureCheck(account.Id, user.Id, toServerPostureCheck(checkID, req))
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toPostureCheckResponse(check)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// DeletePostureCheck handles posture check deletion request
+func (h *PostureChecksHandler) DeletePostureCheck(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	checkID := mux.Vars(r)["postureCheckId"]
+	if len(checkID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid posture check ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeletePostureCheck(account.Id, user.Id, checkID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetPostureCheck handles a posture check Get request identified by ID
+func (h *PostureChecksHandler) GetPostureCheck(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	checkID := mux.Vars(r)["postureCheckId"]
+	if len(checkID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid posture check ID"), w)
+		return
+	}
+
+	check, err := h.accountManager.GetPostureCheck(account.Id, user.Id, checkID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toPostureCheckResponse(check)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+func toServerPostureCheck(checkID string, req api.PostureCheckRequest) *server.PostureCheck {
+	check := &server.PostureCheck{
+		ID:          checkID,
+		Name:        req.Name,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Action:      string(req.Action),
+	}
+	if req.AllowedOs != nil {
+		check.AllowedOS = *req.AllowedOs
+	}
+	if req.AllowedKernels != nil {
+		check.AllowedKernels = *req.AllowedKernels
+	}
+	if req.MinOsVersions != nil {
+		check.MinOSVersions = *req.MinOsVersions
+	}
+	if req.MinClientVersion != nil {
+		check.MinClientVersion = *req.MinClientVersion
+	}
+	return check
+}
+
+func toPostureCheckResponse(check *server.PostureCheck) *api.PostureCheck {
+	allowedOS := append([]string{}, check.AllowedOS...)
+	allowedKernels := append([]string{}, check.AllowedKernels...)
+	minOSVersions := make(map[string]string, len(check.MinOSVersions))
+	for os, v := range check.MinOSVersions {
+		minOSVersions[os] = v
+	}
+	minClientVersion := check.MinClientVersion
+
+	return &api.PostureCheck{
+		Id:               check.ID,
+		Name:             check.Name,
+		Description:      check.Description,
+		Enabled:          check.Enabled,
+		Action:           api.PostureCheckAction(check.Action),
+		AllowedOs:        &allowedOS,
+		AllowedKernels:   &allowedKernels,
+		MinOsVersions:    &minOSVersions,
+		MinClientVersion: &minClientVersion,
+	}
+}
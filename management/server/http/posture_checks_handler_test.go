@@ -0,0 +1,199 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingPostureCheckID  = "existingPostureCheckID"
+	notFoundPostureCheckID  = "notFoundPostureCheckID"
+	testPostureCheckAccount = "test_id"
+)
+
+var testingPostureCheckAccount = &server.Account{
+	Id:     testPostureCheckAccount,
+	Domain: "hotmail.com",
+	Users: map[string]*server.User{
+		"test_user": server.NewAdminUser("test_user"),
+	},
+}
+
+var baseExistingPostureCheck = &server.PostureCheck{
+	ID:            existingPostureCheckID,
+	Name:          "Supported macOS",
+	Description:   "Peers should run macOS Ventura or newer",
+	Enabled:       true,
+	Action:        server.PostureCheckActionQuarantine,
+	AllowedOS:     []string{"darwin"},
+	MinOSVersions: map[string]string{"darwin": "13.0"},
+}
+
+func initPostureChecksTestData() *PostureChecksHandler {
+	return &PostureChecksHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetPostureCheckFunc: func(_, _, checkID string) (*server.PostureCheck, error) {
+				if checkID == existingPostureCheckID {
+					return baseExistingPostureCheck.Copy(), nil
+				}
+				return nil, status.Errorf(status.NotFound, "posture check with ID %s not found", checkID)
+			},
+			SavePostureCheckFunc: func(_, _ string, check *server.PostureCheck) (*server.PostureCheck, error) {
+				check = check.Copy()
+				if check.ID == "" {
+					check.ID = existingPostureCheckID
+				} else if check.ID != existingPostureCheckID {
+					return nil, status.Errorf(status.NotFound, "posture check with ID %s was not found", check.ID)
+				}
+				if check.Action != server.PostureCheckActionDeny && check.Action != server.PostureCheckActionQuarantine {
+					return nil, status.Errorf(status.InvalidArgument, "invalid posture check action %q", check.Action)
+				}
+				return check, nil
+			},
+			DeletePostureCheckFunc: func(_, _, _ string) error {
+				return nil
+			},
+			ListPostureChecksFunc: func(_, _ string) ([]*server.PostureCheck, error) {
+				return []*server.PostureCheck{baseExistingPostureCheck.Copy()}, nil
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingPostureCheckAccount, testingPostureCheckAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testPostureCheckAccount,
+				}
+			}),
+		),
+	}
+}
+
+func TestPostureChecksHandlers(t *testing.T) {
+	tt := []struct {
+		name           string
+		expectedStatus int
+		expectedBody   bool
+		expectedCheck  *api.PostureCheck
+		requestType    string
+		requestPath    string
+		requestBody    io.Reader
+	}{
+		{
+			name:           "Get Existing Posture Check",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/posture-checks/" + existingPostureCheckID,
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedCheck:  toPostureCheckResponse(baseExistingPostureCheck),
+		},
+		{
+			name:           "Get Not Existing Posture Check",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/posture-checks/" + notFoundPostureCheckID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "POST OK",
+			requestType: http.MethodPost,
+			requestPath: "/api/posture-checks",
+			requestBody: bytes.NewBufferString(`{"name":"Client","description":"","enabled":true,"action":"deny",` +
+				`"min_client_version":"0.27.0"}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedCheck: toPostureCheckResponse(&server.PostureCheck{
+				ID:               existingPostureCheckID,
+				Name:             "Client",
+				Enabled:          true,
+				Action:           server.PostureCheckActionDeny,
+				MinClientVersion: "0.27.0",
+			}),
+		},
+		{
+			name:           "POST Invalid Action",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/posture-checks",
+			requestBody:    bytes.NewBufferString(`{"name":"Client","description":"","enabled":true,"action":"drop"}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "PUT OK",
+			requestType: http.MethodPut,
+			requestPath: "/api/posture-checks/" + existingPostureCheckID,
+			requestBody: bytes.NewBufferString(`{"name":"Supported macOS","description":"Peers should run macOS Ventura or newer",` +
+				`"enabled":true,"action":"quarantine","allowed_os":["darwin"],"min_os_versions":{"darwin":"13.0"}}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedCheck:  toPostureCheckResponse(baseExistingPostureCheck),
+		},
+		{
+			name:           "PUT Not Existing Posture Check",
+			requestType:    http.MethodPut,
+			requestPath:    "/api/posture-checks/" + notFoundPostureCheckID,
+			requestBody:    bytes.NewBufferString(`{"name":"Client","description":"","enabled":true,"action":"deny"}`),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE OK",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/posture-checks/" + existingPostureCheckID,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	p := initPostureChecksTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/posture-checks/{postureCheckId}", p.GetPostureCheck).Methods("GET")
+			router.HandleFunc("/api/posture-checks", p.CreatePostureCheck).Methods("POST")
+			router.HandleFunc("/api/posture-checks/{postureCheckId}", p.DeletePostureCheck).Methods("DELETE")
+			router.HandleFunc("/api/posture-checks/{postureCheckId}", p.UpdatePostureCheck).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.PostureCheck{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedCheck, got)
+		})
+	}
+}
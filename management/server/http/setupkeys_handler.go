@@ -75,6 +75,18 @@ func (h *SetupKeysHandler) CreateSetupKey(w http.ResponseWriter, r *http.Request
 	if req.Ephemeral != nil {
 		ephemeral = *req.Ephemeral
 	}
+	if req.PeerType != nil {
+		peerType := server.SetupKeyPeerType(*req.PeerType)
+		if peerType != server.SetupKeyPeerTypeEphemeral && peerType != server.SetupKeyPeerTypePersistent {
+			util.WriteError(status.Errorf(status.InvalidArgument, "unknown setup key peer type %s", peerType), w)
+			return
+		}
+		if req.Ephemeral != nil && ephemeral != (peerType == server.SetupKeyPeerTypeEphemeral) {
+			util.WriteError(status.Errorf(status.InvalidArgument, "ephemeral doesn't match the %s peer type of the setup key", peerType), w)
+			return
+		}
+		ephemeral = peerType == server.SetupKeyPeerTypeEphemeral
+	}
 	setupKey, err := h.accountManager.CreateSetupKey(account.Id, req.Name, server.SetupKeyType(req.Type), expiresIn,
 		req.AutoGroups, req.UsageLimit, user.Id, ephemeral)
 	if err != nil {
@@ -180,6 +192,53 @@ func (h *SetupKeysHandler) GetAllSetupKeys(w http.ResponseWriter, r *http.Reques
 	util.WriteJSONObject(w, apiSetupKeys)
 }
 
+// GetPendingSetupKeys is a GET request that returns a list of the SetupKey pending approval
+func (h *SetupKeysHandler) GetPendingSetupKeys(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	setupKeys, err := h.accountManager.GetPendingSetupKeys(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiSetupKeys := make([]*api.SetupKey, 0, len(setupKeys))
+	for _, key := range setupKeys {
+		apiSetupKeys = append(apiSetupKeys, toResponseBody(key))
+	}
+
+	util.WriteJSONObject(w, apiSetupKeys)
+}
+
+// ApproveSetupKey is a POST request that approves a SetupKey pending approval
+func (h *SetupKeysHandler) ApproveSetupKey(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	keyID := mux.Vars(r)["keyId"]
+	if len(keyID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid key ID"), w)
+		return
+	}
+
+	key, err := h.accountManager.ApproveSetupKey(account.Id, user.Id, keyID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	writeSuccess(w, key)
+}
+
 func writeSuccess(w http.ResponseWriter, key *server.SetupKey) {
 	w.WriteHeader(200)
 	w.Header().Set("Content-Type", "application/json")
@@ -197,6 +256,8 @@ func toResponseBody(key *server.SetupKey) *api.SetupKey {
 		state = "expired"
 	case key.IsRevoked():
 		state = "revoked"
+	case key.IsPendingApproval():
+		state = "pending"
 	case key.IsOverUsed():
 		state = "overused"
 	default:
@@ -218,5 +279,8 @@ func toResponseBody(key *server.SetupKey) *api.SetupKey {
 		UpdatedAt:  key.UpdatedAt,
 		UsageLimit: key.UsageLimit,
 		Ephemeral:  key.Ephemeral,
+		PeerType:   api.SetupKeyPeerType(key.GetPeerType()),
+
+		PendingApproval: key.PendingApproval,
 	}
 }
@@ -56,6 +56,10 @@ func initSetupKeysTestMetaData(defaultKey *server.SetupKey, newKey *server.Setup
 				if keyName == newKey.Name || typ != newKey.Type {
 					nk := newKey.Copy()
 					nk.Ephemeral = ephemeral
+					nk.PeerType = server.SetupKeyPeerTypePersistent
+					if ephemeral {
+						nk.PeerType = server.SetupKeyPeerTypeEphemeral
+					}
 					return nk, nil
 				}
 				return nil, fmt.Errorf("failed creating setup key")
@@ -102,10 +106,18 @@ func TestSetupKeysHandlers(t *testing.T) {
 
 	newSetupKey := server.GenerateSetupKey(newSetupKeyName, server.SetupKeyReusable, 0, []string{"group-1"},
 		server.SetupKeyUnlimitedUsage, true)
+	persistentSetupKey := newSetupKey.Copy()
+	persistentSetupKey.Ephemeral = false
+	persistentSetupKey.PeerType = server.SetupKeyPeerTypePersistent
 	updatedDefaultSetupKey := defaultSetupKey.Copy()
 	updatedDefaultSetupKey.AutoGroups = []string{"group-1"}
 	updatedDefaultSetupKey.Name = updatedSetupKeyName
 	updatedDefaultSetupKey.Revoked = true
+	pendingSetupKey := server.GenerateSetupKey("Pending Setup Key", server.SetupKeyOneOff, time.Hour, nil, 1, false)
+	pendingSetupKey.PendingApproval = true
+	approvedSetupKey := pendingSetupKey.Copy()
+	approvedSetupKey.PendingApproval = false
+	approvedSetupKey.ApprovedBy = adminUser.Id
 
 	tt := []struct {
 		name              string
@@ -150,6 +162,34 @@ func TestSetupKeysHandlers(t *testing.T) {
 			expectedBody:     true,
 			expectedSetupKey: toResponseBody(newSetupKey),
 		},
+		{
+			name:        "Create Persistent-Only Setup Key",
+			requestType: http.MethodPost,
+			requestPath: "/api/setup-keys",
+			requestBody: bytes.NewBuffer(
+				[]byte(fmt.Sprintf("{\"name\":\"%s\",\"type\":\"%s\",\"expires_in\":86400, \"peer_type\":\"persistent\"}", newSetupKey.Name, newSetupKey.Type))),
+			expectedStatus:   http.StatusOK,
+			expectedBody:     true,
+			expectedSetupKey: toResponseBody(persistentSetupKey),
+		},
+		{
+			name:        "Create Persistent-Only Setup Key For Ephemeral Peers",
+			requestType: http.MethodPost,
+			requestPath: "/api/setup-keys",
+			requestBody: bytes.NewBuffer(
+				[]byte(fmt.Sprintf("{\"name\":\"%s\",\"type\":\"%s\",\"expires_in\":86400, \"ephemeral\":true, \"peer_type\":\"persistent\"}", newSetupKey.Name, newSetupKey.Type))),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   false,
+		},
+		{
+			name:        "Create Setup Key With Unknown Peer Type",
+			requestType: http.MethodPost,
+			requestPath: "/api/setup-keys",
+			requestBody: bytes.NewBuffer(
+				[]byte(fmt.Sprintf("{\"name\":\"%s\",\"type\":\"%s\",\"expires_in\":86400, \"peer_type\":\"any\"}", newSetupKey.Name, newSetupKey.Type))),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   false,
+		},
 		{
 			name:        "Update Setup Key",
 			requestType: http.MethodPut,
@@ -164,9 +204,42 @@ func TestSetupKeysHandlers(t *testing.T) {
 			expectedBody:     true,
 			expectedSetupKey: toResponseBody(updatedDefaultSetupKey),
 		},
+		{
+			name:              "Get Pending Setup Keys",
+			requestType:       http.MethodGet,
+			requestPath:       "/api/setup-keys/pending",
+			expectedStatus:    http.StatusOK,
+			expectedBody:      true,
+			expectedSetupKeys: []*api.SetupKey{toResponseBody(pendingSetupKey)},
+		},
+		{
+			name:             "Approve Setup Key",
+			requestType:      http.MethodPost,
+			requestPath:      "/api/setup-keys/" + pendingSetupKey.Id + "/approve",
+			expectedStatus:   http.StatusOK,
+			expectedBody:     true,
+			expectedSetupKey: toResponseBody(approvedSetupKey),
+		},
+		{
+			name:           "Approve Setup Key Not Pending",
+			requestType:    http.MethodPost,
+			requestPath:    "/api/setup-keys/" + defaultSetupKey.Id + "/approve",
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   false,
+		},
 	}
 
 	handler := initSetupKeysTestMetaData(defaultSetupKey, newSetupKey, updatedDefaultSetupKey, adminUser)
+	accountManager := handler.accountManager.(*mock_server.MockAccountManager)
+	accountManager.GetPendingSetupKeysFunc = func(_, _ string) ([]*server.SetupKey, error) {
+		return []*server.SetupKey{pendingSetupKey}, nil
+	}
+	accountManager.ApproveSetupKeyFunc = func(_, _, keyID string) (*server.SetupKey, error) {
+		if keyID == pendingSetupKey.Id {
+			return approvedSetupKey, nil
+		}
+		return nil, status.Errorf(status.PreconditionFailed, "setup key %s isn't pending approval", keyID)
+	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -176,6 +249,8 @@ func TestSetupKeysHandlers(t *testing.T) {
 			router := mux.NewRouter()
 			router.HandleFunc("/api/setup-keys", handler.GetAllSetupKeys).Methods("GET", "OPTIONS")
 			router.HandleFunc("/api/setup-keys", handler.CreateSetupKey).Methods("POST", "OPTIONS")
+			router.HandleFunc("/api/setup-keys/pending", handler.GetPendingSetupKeys).Methods("GET", "OPTIONS")
+			router.HandleFunc("/api/setup-keys/{keyId}/approve", handler.ApproveSetupKey).Methods("POST", "OPTIONS")
 			router.HandleFunc("/api/setup-keys/{keyId}", handler.GetSetupKey).Methods("GET", "OPTIONS")
 			router.HandleFunc("/api/setup-keys/{keyId}", handler.UpdateSetupKey).Methods("PUT", "OPTIONS")
 			router.ServeHTTP(recorder, req)
@@ -233,4 +308,7 @@ func assertKeys(t *testing.T, got *api.SetupKey, expected *api.SetupKey) {
 	assert.Equal(t, got.Revoked, expected.Revoked)
 	assert.ElementsMatch(t, got.AutoGroups, expected.AutoGroups)
 	assert.Equal(t, got.Ephemeral, expected.Ephemeral)
+	assert.Equal(t, got.PeerType, expected.PeerType)
+	assert.Equal(t, got.PendingApproval, expected.PendingApproval)
+	assert.Equal(t, got.State, expected.State)
 }
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/netip"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// StaticRoutesHandler is the static routes handler of the account
+type StaticRoutesHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewStaticRoutesHandler returns a new instance of StaticRoutesHandler handler
+func NewStaticRoutesHandler(accountManager server.AccountManager, authCfg AuthCfg) *StaticRoutesHandler {
+	return &StaticRoutesHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllStaticRoutes returns the list of static routes for the account
+func (h *StaticRoutesHandler) GetAllStaticRoutes(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	routes, err := h.accountManager.ListStaticRoutes(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiRoutes := make([]*api.StaticRoute, 0)
+	for _, staticRoute := range routes {
+		apiRoutes = append(apiRoutes, toStaticRouteResponse(staticRoute))
+	}
+
+	util.WriteJSONObject(w, apiRoutes)
+}
+
+// CreateStaticRoute handles static route creation request
+func (h *StaticRoutesHandler) CreateStaticRoute(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	var req api.PostApiStaticRoutesJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	staticRoute, err := toServerStaticRoute("", req)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	staticRoute, err = h.accountManager.CreateStaticRoute(account.Id, user.Id, staticRoute)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toStaticRouteResponse(staticRoute)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// UpdateStaticRoute handles update to a static route identified by a given ID
+func (h *StaticRoutesHandler) UpdateStaticRoute(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	routeID := mux.Vars(r)["routeId"]
+	if len(routeID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid static route ID"), w)
+		return
+	}
+
+	var req api.PutApiStaticRoutesRouteIdJSONRequestBody
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		util.WriteErrorResponse("couldn't parse JSON request", http.StatusBadRequest, w)
+		return
+	}
+
+	staticRoute, err := toServerStaticRoute(routeID, req)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	err = h.accountManager.SaveStaticRoute(account.Id, user.Id, staticRoute)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	staticRoute, err = h.accountManager.GetStaticRoute(account.Id, user.Id, routeID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toStaticRouteResponse(staticRoute)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+// DeleteStaticRoute handles static route deletion request
+func (h *StaticRoutesHandler) DeleteStaticRoute(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	routeID := mux.Vars(r)["routeId"]
+	if len(routeID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid static route ID"), w)
+		return
+	}
+
+	err = h.accountManager.DeleteStaticRoute(account.Id, routeID, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+// GetStaticRoute handles a static route Get request identified by ID
+func (h *StaticRoutesHandler) GetStaticRoute(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	routeID := mux.Vars(r)["routeId"]
+	if len(routeID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid static route ID"), w)
+		return
+	}
+
+	staticRoute, err := h.accountManager.GetStaticRoute(account.Id, user.Id, routeID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	resp := toStaticRouteResponse(staticRoute)
+
+	util.WriteJSONObject(w, &resp)
+}
+
+func toServerStaticRoute(routeID string, req api.StaticRouteRequest) (*server.StaticRoute, error) {
+	prefix, err := netip.ParsePrefix(req.Network)
+	if err != nil {
+		return nil, status.Errorf(status.InvalidArgument, "invalid static route network %s", req.Network)
+	}
+
+	return &server.StaticRoute{
+		ID:            routeID,
+		Description:   req.Description,
+		Network:       prefix,
+		PeerID:        req.PeerId,
+		RoutingPeerID: req.RoutingPeerId,
+		PolicyID:      req.PolicyId,
+		Masquerade:    req.Masquerade,
+		Enabled:       req.Enabled,
+	}, nil
+}
+
+func toStaticRouteResponse(staticRoute *server.StaticRoute) *api.StaticRoute {
+	return &api.StaticRoute{
+		Id:            staticRoute.ID,
+		Description:   staticRoute.Description,
+		Network:       staticRoute.Network.String(),
+		PeerId:        staticRoute.PeerID,
+		RoutingPeerId: staticRoute.RoutingPeerID,
+		PolicyId:      staticRoute.PolicyID,
+		Masquerade:    staticRoute.Masquerade,
+		Enabled:       staticRoute.Enabled,
+	}
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	existingStaticRouteID  = "existingStaticRouteID"
+	notFoundStaticRouteID  = "notFoundStaticRouteID"
+	testStaticRouteAccount = "test_id"
+)
+
+var testingStaticRouteAccount = &server.Account{
+	Id:     testStaticRouteAccount,
+	Domain: "hotmail.com",
+	Users: map[string]*server.User{
+		"test_user": server.NewAdminUser("test_user"),
+	},
+}
+
+var baseExistingStaticRoute = &server.StaticRoute{
+	ID:            existingStaticRouteID,
+	Description:   "Billing service",
+	Network:       netip.MustParsePrefix("10.0.8.15/32"),
+	PeerID:        "peerA",
+	RoutingPeerID: "peerB",
+	PolicyID:      "billing",
+	Masquerade:    true,
+	Enabled:       true,
+}
+
+func initStaticRoutesTestData() *StaticRoutesHandler {
+	return &StaticRoutesHandler{
+		accountManager: &mock_server.MockAccountManager{
+			GetStaticRouteFunc: func(_, _, routeID string) (*server.StaticRoute, error) {
+				if routeID == existingStaticRouteID {
+					return baseExistingStaticRoute.Copy(), nil
+				}
+				return nil, status.Errorf(status.NotFound, "static route with ID %s not found", routeID)
+			},
+			CreateStaticRouteFunc: func(_, _ string, staticRoute *server.StaticRoute) (*server.StaticRoute, error) {
+				staticRoute = staticRoute.Copy()
+				staticRoute.ID = existingStaticRouteID
+				return staticRoute, nil
+			},
+			SaveStaticRouteFunc: func(_, _ string, staticRoute *server.StaticRoute) error {
+				if staticRoute.ID == existingStaticRouteID {
+					return nil
+				}
+				return status.Errorf(status.NotFound, "static route with ID %s was not found", staticRoute.ID)
+			},
+			DeleteStaticRouteFunc: func(_, _, _ string) error {
+				return nil
+			},
+			ListStaticRoutesFunc: func(_, _ string) ([]*server.StaticRoute, error) {
+				return []*server.StaticRoute{baseExistingStaticRoute.Copy()}, nil
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingStaticRouteAccount, testingStaticRouteAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testStaticRouteAccount,
+				}
+			}),
+		),
+	}
+}
+
+func TestStaticRoutesHandlers(t *testing.T) {
+	tt := []struct {
+		name                string
+		expectedStatus      int
+		expectedBody        bool
+		expectedStaticRoute *api.StaticRoute
+		requestType         string
+		requestPath         string
+		requestBody         io.Reader
+	}{
+		{
+			name:                "Get Existing Static Route",
+			requestType:         http.MethodGet,
+			requestPath:         "/api/static-routes/" + existingStaticRouteID,
+			expectedStatus:      http.StatusOK,
+			expectedBody:        true,
+			expectedStaticRoute: toStaticRouteResponse(baseExistingStaticRoute),
+		},
+		{
+			name:           "Get Not Existing Static Route",
+			requestType:    http.MethodGet,
+			requestPath:    "/api/static-routes/" + notFoundStaticRouteID,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "POST OK",
+			requestType: http.MethodPost,
+			requestPath: "/api/static-routes",
+			requestBody: bytes.NewBufferString(`{"description":"Ledger","network":"10.0.9.0/24","peer_id":"peerA",` +
+				`"routing_peer_id":"peerB","policy_id":"billing","masquerade":false,"enabled":true}`),
+			expectedStatus: http.StatusOK,
+			expectedBody:   true,
+			expectedStaticRoute: &api.StaticRoute{
+				Id:            existingStaticRouteID,
+				Description:   "Ledger",
+				Network:       "10.0.9.0/24",
+				PeerId:        "peerA",
+				RoutingPeerId: "peerB",
+				PolicyId:      "billing",
+				Enabled:       true,
+			},
+		},
+		{
+			name:        "POST Invalid Network",
+			requestType: http.MethodPost,
+			requestPath: "/api/static-routes",
+			requestBody: bytes.NewBufferString(`{"description":"","network":"10.0.9.0","peer_id":"peerA",` +
+				`"routing_peer_id":"peerB","policy_id":"billing","masquerade":false,"enabled":true}`),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "PUT OK",
+			requestType: http.MethodPut,
+			requestPath: "/api/static-routes/" + existingStaticRouteID,
+			requestBody: bytes.NewBufferString(`{"description":"Billing service","network":"10.0.8.15/32","peer_id":"peerA",` +
+				`"routing_peer_id":"peerB","policy_id":"billing","masquerade":true,"enabled":true}`),
+			expectedStatus:      http.StatusOK,
+			expectedBody:        true,
+			expectedStaticRoute: toStaticRouteResponse(baseExistingStaticRoute),
+		},
+		{
+			name:        "PUT Not Existing Static Route",
+			requestType: http.MethodPut,
+			requestPath: "/api/static-routes/" + notFoundStaticRouteID,
+			requestBody: bytes.NewBufferString(`{"description":"","network":"10.0.8.15/32","peer_id":"peerA",` +
+				`"routing_peer_id":"peerB","policy_id":"billing","masquerade":true,"enabled":true}`),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE OK",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/static-routes/" + existingStaticRouteID,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	p := initStaticRoutesTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, tc.requestBody)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/static-routes/{routeId}", p.GetStaticRoute).Methods("GET")
+			router.HandleFunc("/api/static-routes", p.CreateStaticRoute).Methods("POST")
+			router.HandleFunc("/api/static-routes/{routeId}", p.DeleteStaticRoute).Methods("DELETE")
+			router.HandleFunc("/api/static-routes/{routeId}", p.UpdateStaticRoute).Methods("PUT")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("read the response body: %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if !tc.expectedBody {
+				return
+			}
+
+			got := &api.StaticRoute{}
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedStaticRoute, got)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/http/util"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// SyncSessionsHandler is the handler of the sync sessions the peers of the account hold open
+type SyncSessionsHandler struct {
+	accountManager  server.AccountManager
+	claimsExtractor *jwtclaims.ClaimsExtractor
+}
+
+// NewSyncSessionsHandler returns a new instance of SyncSessionsHandler handler
+func NewSyncSessionsHandler(accountManager server.AccountManager, authCfg AuthCfg) *SyncSessionsHandler {
+	return &SyncSessionsHandler{
+		accountManager: accountManager,
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithAudience(authCfg.Audience),
+			jwtclaims.WithUserIDClaim(authCfg.UserIDClaim),
+		),
+	}
+}
+
+// GetAllSyncSessions returns the list of the sync sessions of the account
+func (h *SyncSessionsHandler) GetAllSyncSessions(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		log.Error(err)
+		http.Redirect(w, r, "/", http.StatusInternalServerError)
+		return
+	}
+
+	sessions, err := h.accountManager.ListSyncSessions(account.Id, user.Id)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	apiSessions := make([]*api.SyncSession, 0, len(sessions))
+	for _, session := range sessions {
+		apiSessions = append(apiSessions, toSyncSessionResponse(session))
+	}
+
+	util.WriteJSONObject(w, apiSessions)
+}
+
+// DeleteSyncSession terminates the sync session of the peer
+func (h *SyncSessionsHandler) DeleteSyncSession(w http.ResponseWriter, r *http.Request) {
+	claims := h.claimsExtractor.FromRequestContext(r)
+	account, user, err := h.accountManager.GetAccountFromToken(claims)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	peerID := mux.Vars(r)["peerId"]
+	if len(peerID) == 0 {
+		util.WriteError(status.Errorf(status.InvalidArgument, "invalid peer ID"), w)
+		return
+	}
+
+	err = h.accountManager.CloseSyncSession(account.Id, user.Id, peerID)
+	if err != nil {
+		util.WriteError(err, w)
+		return
+	}
+
+	util.WriteJSONObject(w, emptyObject{})
+}
+
+func toSyncSessionResponse(session server.SyncSession) *api.SyncSession {
+	return &api.SyncSession{
+		PeerId:         session.PeerID,
+		PeerKey:        session.PeerKey,
+		ConnectedSince: session.ConnectedSince,
+		RemoteAddr:     session.RemoteAddr,
+	}
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+	"github.com/netbirdio/netbird/management/server/mock_server"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	connectedSessionPeerID = "connectedSessionPeerID"
+	testSyncSessionAccount = "test_id"
+)
+
+var testingSyncSessionAccount = &server.Account{
+	Id:     testSyncSessionAccount,
+	Domain: "hotmail.com",
+	Users: map[string]*server.User{
+		"test_user": server.NewAdminUser("test_user"),
+	},
+}
+
+var testSyncSession = server.SyncSession{
+	PeerID:         connectedSessionPeerID,
+	PeerKey:        "RgrhiT6r/m6SjFJHhd0SKEYrmaQGvFFWmnm5SibSJ2M=",
+	ConnectedSince: time.Date(2023, 5, 5, 9, 0, 35, 0, time.UTC),
+	RemoteAddr:     "198.51.100.7:53124",
+}
+
+func initSyncSessionsTestData() *SyncSessionsHandler {
+	return &SyncSessionsHandler{
+		accountManager: &mock_server.MockAccountManager{
+			ListSyncSessionsFunc: func(_, _ string) ([]server.SyncSession, error) {
+				return []server.SyncSession{testSyncSession}, nil
+			},
+			CloseSyncSessionFunc: func(_, _, peerID string) error {
+				if peerID == connectedSessionPeerID {
+					return nil
+				}
+				return status.Errorf(status.NotFound, "sync session of peer %s not found", peerID)
+			},
+			GetAccountFromTokenFunc: func(_ jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error) {
+				return testingSyncSessionAccount, testingSyncSessionAccount.Users["test_user"], nil
+			},
+		},
+		claimsExtractor: jwtclaims.NewClaimsExtractor(
+			jwtclaims.WithFromRequestContext(func(r *http.Request) jwtclaims.AuthorizationClaims {
+				return jwtclaims.AuthorizationClaims{
+					UserId:    "test_user",
+					Domain:    "hotmail.com",
+					AccountId: testSyncSessionAccount,
+				}
+			}),
+		),
+	}
+}
+
+func TestSyncSessionsHandlers(t *testing.T) {
+	tt := []struct {
+		name             string
+		expectedStatus   int
+		expectedSessions []*api.SyncSession
+		requestType      string
+		requestPath      string
+	}{
+		{
+			name:             "Get All Sync Sessions",
+			requestType:      http.MethodGet,
+			requestPath:      "/api/sync-sessions",
+			expectedStatus:   http.StatusOK,
+			expectedSessions: []*api.SyncSession{toSyncSessionResponse(testSyncSession)},
+		},
+		{
+			name:           "DELETE OK",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/sync-sessions/" + connectedSessionPeerID,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "DELETE Not Connected Peer",
+			requestType:    http.MethodDelete,
+			requestPath:    "/api/sync-sessions/notConnectedPeerID",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	p := initSyncSessionsTestData()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.requestType, tc.requestPath, nil)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api/sync-sessions", p.GetAllSyncSessions).Methods("GET")
+			router.HandleFunc("/api/sync-sessions/{peerId}", p.DeleteSyncSession).Methods("DELETE")
+			router.ServeHTTP(recorder, req)
+
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			content, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("I don't know what I expected; %v", err)
+			}
+
+			if status := recorder.Code; status != tc.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v, content: %s",
+					status, tc.expectedStatus, string(content))
+				return
+			}
+
+			if tc.expectedSessions == nil {
+				return
+			}
+
+			var got []*api.SyncSession
+			if err = json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("Sent content is not in correct json format; %v", err)
+			}
+			assert.Equal(t, tc.expectedSessions, got)
+		})
+	}
+}
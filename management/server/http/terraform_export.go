@@ -0,0 +1,375 @@
+package http
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/http/api"
+)
+
+// terraformExportHeader documents what the export leaves out because it can't be re-imported safely
+const terraformExportHeader = `# NetBird account configuration for the netbird Terraform provider.
+# Left out of the export:
+# - the peers, they register with their own keys. The static groups are exported without their peers.
+# - the setup key values and expiration, a re-imported setup key is a new key with a new expiration.
+# - the groups managed by NetBird, the JWT groups and the integration groups, they are referenced as data sources.
+# - the network resources of the policy rules, they are referenced by ID in the comments.`
+
+// hclBlock is a block of the HCL export, e.g. a resource with its attributes and nested blocks
+type hclBlock struct {
+	header   string
+	comments []string
+	attrs    []hclAttr
+	blocks   []*hclBlock
+}
+
+type hclAttr struct {
+	name  string
+	value string
+}
+
+func (b *hclBlock) attr(name, value string) {
+	b.attrs = append(b.attrs, hclAttr{name: name, value: value})
+}
+
+func (b *hclBlock) render(sb *strings.Builder, indent string) {
+	for _, comment := range b.comments {
+		sb.WriteString(indent + "# " + comment + "\n")
+	}
+	sb.WriteString(indent + b.header + " {\n")
+
+	// the equal signs are aligned like terraform fmt does
+	width := 0
+	for _, a := range b.attrs {
+		if len(a.name) > width {
+			width = len(a.name)
+		}
+	}
+	for _, a := range b.attrs {
+		sb.WriteString(fmt.Sprintf("%s  %-*s = %s\n", indent, width, a.name, a.value))
+	}
+
+	for i, nested := range b.blocks {
+		if i > 0 || len(b.attrs) > 0 {
+			sb.WriteString("\n")
+		}
+		nested.render(sb, indent+"  ")
+	}
+	sb.WriteString(indent + "}\n")
+}
+
+// toTerraformExport renders the groups, policies, setup keys and DNS settings of the account as the HCL of the
+// netbird Terraform provider. The resources follow the schema of the API requests of the same objects:
+//
+//	netbird_group:        name, dynamic_rule { subnets, meta }
+//	netbird_policy:       name, description, enabled, rule { name, description, enabled, action, bidirectional,
+//	                      protocol, additional_protocols, ports, sources, destinations,
+//	                      schedule { days, timezone, time_range { start, end } } }
+//	netbird_setup_key:    name, type, auto_groups, usage_limit, ephemeral, peer_type, revoked
+//	netbird_dns_settings: disabled_management_groups, primary_dns_disabled_os
+//
+// The objects are sorted by name and ID, so exporting an unchanged account gives the same output.
+func toTerraformExport(account *server.Account) string {
+	labels := newTerraformLabels()
+	var blocks []*hclBlock
+
+	groups := make([]*api.Group, 0, len(account.Groups))
+	for _, group := range account.Groups {
+		groups = append(groups, toGroupResponse(account, group))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return lessByNameAndID(groups[i].Name, groups[i].Id, groups[j].Name, groups[j].Id)
+	})
+
+	// the references are resolved before the blocks are rendered, the policies may refer to any group
+	groupRefs := make(map[string]string, len(groups))
+	for _, group := range groups {
+		label := labels.next("group", group.Name)
+		if terraformManagedGroup(account.Groups[group.Id]) {
+			groupRefs[group.Id] = "netbird_group." + label + ".id"
+			blocks = append(blocks, toTerraformGroup(label, group))
+			continue
+		}
+		groupRefs[group.Id] = "data.netbird_group." + label + ".id"
+		blocks = append(blocks, &hclBlock{
+			header:   fmt.Sprintf("data \"netbird_group\" %s", hclString(label)),
+			comments: []string{"managed by NetBird, not by Terraform"},
+			attrs:    []hclAttr{{name: "name", value: hclString(group.Name)}},
+		})
+	}
+
+	policies := make([]*api.Policy, 0, len(account.Policies))
+	for _, policy := range account.Policies {
+		policies = append(policies, toPolicyResponse(account, policy))
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return lessByNameAndID(policies[i].Name, *policies[i].Id, policies[j].Name, *policies[j].Id)
+	})
+	for _, policy := range policies {
+		blocks = append(blocks, toTerraformPolicy(labels.next("policy", policy.Name), policy, groupRefs))
+	}
+
+	setupKeys := make([]*api.SetupKey, 0, len(account.SetupKeys))
+	for _, key := range account.SetupKeys {
+		setupKeys = append(setupKeys, toResponseBody(key))
+	}
+	sort.Slice(setupKeys, func(i, j int) bool {
+		return lessByNameAndID(setupKeys[i].Name, setupKeys[i].Id, setupKeys[j].Name, setupKeys[j].Id)
+	})
+	for _, key := range setupKeys {
+		blocks = append(blocks, toTerraformSetupKey(labels.next("setup_key", key.Name), key, groupRefs))
+	}
+
+	blocks = append(blocks, toTerraformDNSSettings(toDNSSettingsResponse(&account.DNSSettings), groupRefs))
+
+	var sb strings.Builder
+	sb.WriteString(terraformExportHeader + "\n")
+	for _, block := range blocks {
+		sb.WriteString("\n")
+		block.render(&sb, "")
+	}
+	return sb.String()
+}
+
+// terraformManagedGroup returns true for the groups created with the API, the other groups are created by NetBird
+func terraformManagedGroup(group *server.Group) bool {
+	if group.Name == "All" {
+		return false
+	}
+	return group.Issued == "" || group.Issued == server.GroupIssuedAPI
+}
+
+func toTerraformGroup(label string, group *api.Group) *hclBlock {
+	block := &hclBlock{header: fmt.Sprintf("resource \"netbird_group\" %s", hclString(label))}
+	block.attr("name", hclString(group.Name))
+
+	if group.DynamicRule != nil {
+		rule := &hclBlock{header: "dynamic_rule"}
+		if group.DynamicRule.Subnets != nil {
+			subnets := append([]string{}, *group.DynamicRule.Subnets...)
+			sort.Strings(subnets)
+			rule.attr("subnets", hclStringList(subnets))
+		}
+		if group.DynamicRule.Meta != nil {
+			rule.attr("meta", hclStringMap(*group.DynamicRule.Meta))
+		}
+		block.blocks = append(block.blocks, rule)
+	}
+
+	return block
+}
+
+func toTerraformPolicy(label string, policy *api.Policy, groupRefs map[string]string) *hclBlock {
+	block := &hclBlock{header: fmt.Sprintf("resource \"netbird_policy\" %s", hclString(label))}
+	block.attr("name", hclString(policy.Name))
+	block.attr("description", hclString(policy.Description))
+	block.attr("enabled", strconv.FormatBool(policy.Enabled))
+
+	// the order of the rules is kept, it is the order they are shown in
+	for _, r := range policy.Rules {
+		rule := &hclBlock{header: "rule"}
+		rule.attr("name", hclString(r.Name))
+		if r.Description != nil {
+			rule.attr("description", hclString(*r.Description))
+		}
+		rule.attr("enabled", strconv.FormatBool(r.Enabled))
+		rule.attr("action", hclString(string(r.Action)))
+		rule.attr("bidirectional", strconv.FormatBool(r.Bidirectional))
+		rule.attr("protocol", hclString(string(r.Protocol)))
+		if r.AdditionalProtocols != nil {
+			protocols := make([]string, 0, len(*r.AdditionalProtocols))
+			for _, protocol := range *r.AdditionalProtocols {
+				protocols = append(protocols, string(protocol))
+			}
+			sort.Strings(protocols)
+			rule.attr("additional_protocols", hclStringList(protocols))
+		}
+		if r.Ports != nil {
+			rule.attr("ports", hclStringList(*r.Ports))
+		}
+		rule.attr("sources", hclList(groupMinimumRefs(r.Sources, groupRefs)))
+		rule.attr("destinations", hclList(groupMinimumRefs(r.Destinations, groupRefs)))
+		if r.DestinationResources != nil {
+			resources := append([]string{}, *r.DestinationResources...)
+			sort.Strings(resources)
+			rule.comments = append(rule.comments, "network resources left out: "+strings.Join(resources, ", "))
+		}
+		if r.Schedule != nil {
+			rule.blocks = append(rule.blocks, toTerraformPolicySchedule(r.Schedule))
+		}
+		block.blocks = append(block.blocks, rule)
+	}
+
+	return block
+}
+
+func toTerraformPolicySchedule(schedule *api.PolicySchedule) *hclBlock {
+	block := &hclBlock{header: "schedule"}
+	if schedule.Days != nil {
+		days := make([]string, 0, len(*schedule.Days))
+		for _, day := range *schedule.Days {
+			days = append(days, string(day))
+		}
+		block.attr("days", hclStringList(days))
+	}
+	if schedule.Timezone != nil && *schedule.Timezone != "" {
+		block.attr("timezone", hclString(*schedule.Timezone))
+	}
+	if schedule.TimeRanges != nil {
+		for _, tr := range *schedule.TimeRanges {
+			timeRange := &hclBlock{header: "time_range"}
+			timeRange.attr("start", hclString(tr.Start))
+			timeRange.attr("end", hclString(tr.End))
+			block.blocks = append(block.blocks, timeRange)
+		}
+	}
+	return block
+}
+
+func toTerraformSetupKey(label string, key *api.SetupKey, groupRefs map[string]string) *hclBlock {
+	block := &hclBlock{header: fmt.Sprintf("resource \"netbird_setup_key\" %s", hclString(label))}
+	block.attr("name", hclString(key.Name))
+	block.attr("type", hclString(key.Type))
+	block.attr("auto_groups", hclList(groupIDRefs(key.AutoGroups, groupRefs)))
+	block.attr("usage_limit", strconv.Itoa(key.UsageLimit))
+	block.attr("ephemeral", strconv.FormatBool(key.Ephemeral))
+	block.attr("peer_type", hclString(string(key.PeerType)))
+	block.attr("revoked", strconv.FormatBool(key.Revoked))
+	return block
+}
+
+func toTerraformDNSSettings(settings *api.DNSSettings, groupRefs map[string]string) *hclBlock {
+	block := &hclBlock{header: "resource \"netbird_dns_settings\" \"account\""}
+	block.attr("disabled_management_groups", hclList(groupIDRefs(settings.DisabledManagementGroups, groupRefs)))
+	if settings.PrimaryDnsDisabledOs != nil {
+		oses := append([]string{}, *settings.PrimaryDnsDisabledOs...)
+		sort.Strings(oses)
+		block.attr("primary_dns_disabled_os", hclStringList(oses))
+	}
+	return block
+}
+
+func groupMinimumRefs(groups []api.GroupMinimum, groupRefs map[string]string) []string {
+	ids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		ids = append(ids, group.Id)
+	}
+	return groupIDRefs(ids, groupRefs)
+}
+
+// groupIDRefs returns the sorted references of the groups, the missing groups are left out
+func groupIDRefs(ids []string, groupRefs map[string]string) []string {
+	refs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if ref, ok := groupRefs[id]; ok {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+func lessByNameAndID(name1, id1, name2, id2 string) bool {
+	if name1 != name2 {
+		return name1 < name2
+	}
+	return id1 < id2
+}
+
+// terraformLabels gives the exported objects unique labels derived from their names
+type terraformLabels struct {
+	used map[string]struct{}
+}
+
+func newTerraformLabels() *terraformLabels {
+	return &terraformLabels{used: make(map[string]struct{})}
+}
+
+// next returns a label of lower case letters, digits and underscores, a number is appended to the labels in use
+func (l *terraformLabels) next(kind, name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+
+	base := strings.Trim(sb.String(), "_")
+	if base == "" || !unicode.IsLetter(rune(base[0])) {
+		base = kind + "_" + base
+	}
+	base = strings.TrimSuffix(base, "_")
+
+	label := base
+	for i := 2; ; i++ {
+		if _, ok := l.used[label]; !ok {
+			break
+		}
+		label = fmt.Sprintf("%s_%d", base, i)
+	}
+	l.used[label] = struct{}{}
+	return label
+}
+
+// hclString quotes the string, the template sequences are escaped so the value is taken literally
+func hclString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			sb.WriteString(fmt.Sprintf(`\u%04x`, r))
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			sb.WriteRune(r)
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func hclList(values []string) string {
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+func hclStringList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, hclString(v))
+	}
+	return hclList(quoted)
+}
+
+func hclStringMap(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		return "{}"
+	}
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, hclString(key)+" = "+hclString(values[key]))
+	}
+	return "{ " + strings.Join(pairs, ", ") + " }"
+}
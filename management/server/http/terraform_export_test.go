@@ -0,0 +1,60 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/netbirdio/netbird/management/server"
+)
+
+func TestToTerraformExport_Deterministic(t *testing.T) {
+	account := &server.Account{
+		Id: "test_account",
+		Groups: map[string]*server.Group{
+			"g1": {ID: "g1", Name: "web"},
+			"g2": {ID: "g2", Name: "web"},
+			"g3": {ID: "g3", Name: "db"},
+			"g4": {ID: "g4", Name: "1st floor"},
+		},
+		SetupKeys: map[string]*server.SetupKey{
+			"key-b": {Id: "b", Key: "key-b", Name: "b", AutoGroups: []string{"g2", "g1"}},
+			"key-a": {Id: "a", Key: "key-a", Name: "a"},
+		},
+		Settings: &server.Settings{},
+	}
+
+	export := toTerraformExport(account)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, export, toTerraformExport(account), "the export should not depend on the map order")
+	}
+
+	assert.Contains(t, export, `resource "netbird_group" "group_1st_floor" {`)
+	assert.Contains(t, export, `resource "netbird_group" "web" {`)
+	assert.Contains(t, export, `resource "netbird_group" "web_2" {`)
+	assert.Less(t, strings.Index(export, `"db"`), strings.Index(export, `"web"`), "the groups should be sorted by name")
+	assert.Less(t, strings.Index(export, `"netbird_setup_key" "a"`), strings.Index(export, `"netbird_setup_key" "b"`))
+	assert.Contains(t, export, `auto_groups = [netbird_group.web.id, netbird_group.web_2.id]`)
+	assert.NotContains(t, export, "key-a", "the setup key values must not be exported")
+}
+
+func TestHCLString(t *testing.T) {
+	tt := []struct {
+		value    string
+		expected string
+	}{
+		{value: "plain", expected: `"plain"`},
+		{value: `say "hi" \o/`, expected: `"say \"hi\" \\o/"`},
+		{value: "line\nnext\ttab", expected: `"line\nnext\ttab"`},
+		{value: "bell\a", expected: `"bell\u0007"`},
+		{value: "${var.secret} and %{if true}", expected: `"$${var.secret} and %%{if true}"`},
+		{value: "price $5 100%", expected: `"price $5 100%"`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.value, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hclString(tc.value))
+		})
+	}
+}
@@ -121,7 +121,7 @@ func NewAuth0Manager(config Auth0ClientConfig, appMetrics telemetry.AppMetrics)
 
 	httpClient := &http.Client{
 		Timeout:   10 * time.Second,
-		Transport: httpTransport,
+		Transport: newRetryTransport(httpTransport, appMetrics),
 	}
 	helper := JsonParser{}
 
@@ -55,7 +55,7 @@ func NewAuthentikManager(config AuthentikClientConfig,
 
 	httpClient := &http.Client{
 		Timeout:   10 * time.Second,
-		Transport: httpTransport,
+		Transport: newRetryTransport(httpTransport, appMetrics),
 	}
 
 	helper := JsonParser{}
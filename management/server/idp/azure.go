@@ -58,7 +58,7 @@ func NewAzureManager(config AzureClientConfig, appMetrics telemetry.AppMetrics)
 
 	httpClient := &http.Client{
 		Timeout:   10 * time.Second,
-		Transport: httpTransport,
+		Transport: newRetryTransport(httpTransport, appMetrics),
 	}
 	helper := JsonParser{}
 
@@ -50,7 +50,7 @@ func NewGoogleWorkspaceManager(config GoogleWorkspaceClientConfig, appMetrics te
 
 	httpClient := &http.Client{
 		Timeout:   10 * time.Second,
-		Transport: httpTransport,
+		Transport: newRetryTransport(httpTransport, appMetrics),
 	}
 	helper := JsonParser{}
 
@@ -47,7 +47,7 @@ func NewJumpCloudManager(config JumpCloudClientConfig, appMetrics telemetry.AppM
 
 	httpClient := &http.Client{
 		Timeout:   10 * time.Second,
-		Transport: httpTransport,
+		Transport: newRetryTransport(httpTransport, appMetrics),
 	}
 	helper := JsonParser{}
 
@@ -0,0 +1,127 @@
+package idp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+const (
+	// maxRequestAttempts is the maximum number of attempts, including the first one, made for a retryable request
+	maxRequestAttempts   = 3
+	retryInitialInterval = 500 * time.Millisecond
+	retryMaxInterval     = 3 * time.Second
+)
+
+// retryTransport is an http.RoundTripper that retries idempotent requests failing with a transient error.
+// Only requests without side effects (GET, HEAD) are retried, so calls like CreateUser never run twice.
+type retryTransport struct {
+	transport  http.RoundTripper
+	appMetrics telemetry.AppMetrics
+	// newBackOff returns the backoff policy used between attempts of a single request
+	newBackOff func() backoff.BackOff
+}
+
+// newRetryTransport wraps the given transport with retries of idempotent requests
+func newRetryTransport(transport http.RoundTripper, appMetrics telemetry.AppMetrics) *retryTransport {
+	return &retryTransport{
+		transport:  transport,
+		appMetrics: appMetrics,
+		newBackOff: func() backoff.BackOff {
+			b := backoff.NewExponentialBackOff()
+			b.InitialInterval = retryInitialInterval
+			b.MaxInterval = retryMaxInterval
+			b.MaxElapsedTime = 0
+			return b
+		},
+	}
+}
+
+// RoundTrip executes the request, retrying it with exponential backoff on connection errors and on
+// 429 and 5xx responses. A Retry-After header sent by the IdP takes precedence over the backoff interval.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotentMethod(req.Method) {
+		return t.transport.RoundTrip(req)
+	}
+
+	b := t.newBackOff()
+	for attempt := 1; ; attempt++ {
+		resp, err := t.transport.RoundTrip(req)
+		if attempt >= maxRequestAttempts || req.Context().Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait := b.NextBackOff()
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = retryAfter
+			}
+		}
+		if wait == backoff.Stop || wait > retryMaxInterval {
+			// the IdP asked us to wait longer than we are willing to, return the response to the caller as is
+			log.Debugf("not retrying IdP request %s %s, retry interval %s exceeds the limit", req.Method, req.URL.Path, wait)
+			return resp, err
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		if t.appMetrics != nil {
+			t.appMetrics.IDPMetrics().CountRequestRetry()
+		}
+		log.Debugf("retrying IdP request %s %s in %s, attempt %d of %d", req.Method, req.URL.Path, wait, attempt+1, maxRequestAttempts)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func isIdempotentMethod(method string) bool {
+	return method == "" || method == http.MethodGet || method == http.MethodHead
+}
+
+// shouldRetry returns true if the request failed with a transient error
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses the Retry-After header value that can be either delay seconds or an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
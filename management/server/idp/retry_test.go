@@ -0,0 +1,127 @@
+package idp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tt := []struct {
+		name             string
+		method           string
+		statusCodes      []int
+		retryAfter       string
+		expectedStatus   int
+		expectedRequests int32
+	}{
+		{
+			name:             "Success Is Not Retried",
+			method:           http.MethodGet,
+			statusCodes:      []int{http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 1,
+		},
+		{
+			name:             "Transient Error Is Retried",
+			method:           http.MethodGet,
+			statusCodes:      []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 2,
+		},
+		{
+			name:             "Too Many Requests Is Retried After Delay",
+			method:           http.MethodGet,
+			statusCodes:      []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:       "0",
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 2,
+		},
+		{
+			name:             "Attempts Are Capped",
+			method:           http.MethodGet,
+			statusCodes:      []int{http.StatusBadGateway},
+			expectedStatus:   http.StatusBadGateway,
+			expectedRequests: maxRequestAttempts,
+		},
+		{
+			name:             "Retry After Above Limit Fails Fast",
+			method:           http.MethodGet,
+			statusCodes:      []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:       "3600",
+			expectedStatus:   http.StatusTooManyRequests,
+			expectedRequests: 1,
+		},
+		{
+			name:             "Client Error Fails Fast",
+			method:           http.MethodGet,
+			statusCodes:      []int{http.StatusNotFound, http.StatusOK},
+			expectedStatus:   http.StatusNotFound,
+			expectedRequests: 1,
+		},
+		{
+			name:             "Non Idempotent Request Is Not Retried",
+			method:           http.MethodPost,
+			statusCodes:      []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, testCase := range tt {
+		t.Run(testCase.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&requests, 1)
+				code := testCase.statusCodes[len(testCase.statusCodes)-1]
+				if int(n) <= len(testCase.statusCodes) {
+					code = testCase.statusCodes[n-1]
+				}
+				if testCase.retryAfter != "" {
+					w.Header().Set("Retry-After", testCase.retryAfter)
+				}
+				w.WriteHeader(code)
+			}))
+			defer server.Close()
+
+			transport := newRetryTransport(http.DefaultTransport, nil)
+			transport.newBackOff = func() backoff.BackOff {
+				return &backoff.ZeroBackOff{}
+			}
+			client := &http.Client{Transport: transport}
+
+			req, err := http.NewRequest(testCase.method, server.URL, strings.NewReader(""))
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, testCase.expectedStatus, resp.StatusCode, "unexpected response status code")
+			assert.Equal(t, testCase.expectedRequests, atomic.LoadInt32(&requests), "unexpected number of requests")
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	wait, ok := parseRetryAfter("2")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+
+	wait, ok = parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), wait)
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("")
+	assert.False(t, ok)
+}
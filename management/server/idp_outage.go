@@ -0,0 +1,144 @@
+package server
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	cacheStore "github.com/eko/gocache/v3/store"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/idp"
+)
+
+// DefaultIdpStaleTTL is how long the IdP user metadata last loaded is used while the IdP manager is unavailable
+const DefaultIdpStaleTTL = 24 * time.Hour
+
+type idpUserDataSnapshot struct {
+	users    []*idp.UserData
+	loadedAt time.Time
+}
+
+// idpOutagePolicy keeps the IdP user metadata last loaded per account to ride out the IdP manager outages
+type idpOutagePolicy struct {
+	// strict fails the logins needing the IdP user metadata while the IdP manager is unavailable
+	strict   bool
+	staleTTL time.Duration
+
+	mu        sync.Mutex
+	snapshots map[string]idpUserDataSnapshot
+	// degraded keeps the accounts served with the stale user metadata since the last failed load
+	degraded map[string]struct{}
+}
+
+// loaded records the user metadata loaded from the IdP manager for the account
+func (p *idpOutagePolicy) loaded(accountID string, users []*idp.UserData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.snapshots == nil {
+		p.snapshots = make(map[string]idpUserDataSnapshot)
+	}
+	p.snapshots[accountID] = idpUserDataSnapshot{users: users, loadedAt: time.Now()}
+	delete(p.degraded, accountID)
+}
+
+// stale returns the user metadata last loaded for the account and how long it can still be used if it is not older
+// than the stale TTL. It returns false in the strict mode.
+func (p *idpOutagePolicy) stale(accountID string) ([]*idp.UserData, time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.strict {
+		return nil, 0, false
+	}
+
+	snapshot, ok := p.snapshots[accountID]
+	if !ok {
+		return nil, 0, false
+	}
+
+	remaining := p.staleTTL - time.Since(snapshot.loadedAt)
+	if remaining <= 0 {
+		return nil, 0, false
+	}
+
+	if p.degraded == nil {
+		p.degraded = make(map[string]struct{})
+	}
+	p.degraded[accountID] = struct{}{}
+	return snapshot.users, remaining, true
+}
+
+func (p *idpOutagePolicy) isStrict() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.strict
+}
+
+// isDegraded returns true if the account user metadata is stale because the IdP manager was unavailable
+func (p *idpOutagePolicy) isDegraded(accountID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, ok := p.degraded[accountID]
+	return ok
+}
+
+// idpUnavailableError is returned by the cache loader when the IdP manager failed to return the account users
+type idpUnavailableError struct {
+	err error
+}
+
+func (e *idpUnavailableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *idpUnavailableError) Unwrap() error {
+	return e.err
+}
+
+// cacheStaleUserData caches the user metadata last loaded for the account when the IdP manager failed to return it.
+// The entry expires when the snapshot gets older than the stale TTL, so the IdP manager is asked again by then rather
+// than the stale data being kept for the whole cache expiration.
+func (am *DefaultAccountManager) cacheStaleUserData(accountID string, loadErr error) ([]*idp.UserData, error) {
+	var idpErr *idpUnavailableError
+	if !errors.As(loadErr, &idpErr) {
+		return nil, loadErr
+	}
+
+	users, remaining, ok := am.idpOutage.stale(accountID)
+	if !ok {
+		return nil, loadErr
+	}
+
+	log.Warnf("failed loading users of account %s from IdP, using the data loaded before for %s: %v", accountID, remaining, loadErr)
+	err := am.cacheManager.Set(am.ctx, accountID, users, cacheStore.WithExpiration(remaining))
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// SetIdpOutagePolicy sets how the JWT logins behave while the IdP manager is unavailable. In the strict mode the
+// logins needing the IdP user metadata fail. Otherwise the IdP lookups not needed to authenticate the user, e.g.
+// storing the account ID in the user app metadata, are deferred to the next login and the user metadata last loaded
+// is used for up to staleTTL.
+func (am *DefaultAccountManager) SetIdpOutagePolicy(strict bool, staleTTL time.Duration) {
+	am.idpOutage.mu.Lock()
+	defer am.idpOutage.mu.Unlock()
+
+	am.idpOutage.strict = strict
+	am.idpOutage.staleTTL = staleTTL
+}
+
+// deferIdpLookup returns nil for the failed IdP lookup of the user unless the strict mode is set.
+// The token has already been validated, the lookup is retried on the next login.
+func (am *DefaultAccountManager) deferIdpLookup(userID string, err error) error {
+	if err == nil || am.idpOutage.isStrict() {
+		return err
+	}
+	log.Warnf("IdP lookup of user %s failed, deferring it to the next login: %v", userID, err)
+	return nil
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/idp"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
+)
+
+func TestDefaultAccountManager_GetAccountFromToken_IdpOutage(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{name: "Lenient Login Defers IdP Lookups", strict: false},
+		{name: "Strict Login Fails", strict: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			manager, err := createManager(t)
+			require.NoError(t, err)
+
+			userID := "user-id"
+			_, err = createAccount(manager, "test_account", userID, "")
+			require.NoError(t, err)
+
+			manager.idpManager = &idp.MockIDP{
+				GetAccountFunc: func(string) ([]*idp.UserData, error) {
+					return nil, errors.New("IdP is unavailable")
+				},
+			}
+			manager.SetIdpOutagePolicy(tc.strict, DefaultIdpStaleTTL)
+
+			account, _, err := manager.GetAccountFromToken(jwtclaims.AuthorizationClaims{UserId: userID})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "test_account", account.Id)
+		})
+	}
+}
+
+func TestDefaultAccountManager_loadAccount_StaleUserData(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "user-id"
+	_, err = createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	idpUnavailable := false
+	manager.idpManager = &idp.MockIDP{
+		GetAccountFunc: func(string) ([]*idp.UserData, error) {
+			if idpUnavailable {
+				return nil, errors.New("IdP is unavailable")
+			}
+			return []*idp.UserData{{ID: userID, Email: "user@netbird.io"}}, nil
+		},
+	}
+
+	users, err := manager.refreshCache("test_account")
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.False(t, manager.idpOutage.isDegraded("test_account"))
+
+	idpUnavailable = true
+	users, err = manager.refreshCache("test_account")
+	require.NoError(t, err, "the user data loaded before should be used")
+	require.Len(t, users, 1)
+	assert.Equal(t, "user@netbird.io", users[0].Email)
+	assert.True(t, manager.idpOutage.isDegraded("test_account"))
+
+	manager.SetIdpOutagePolicy(false, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	_, err = manager.refreshCache("test_account")
+	assert.Error(t, err, "the user data older than the stale TTL should not be used")
+
+	manager.SetIdpOutagePolicy(true, DefaultIdpStaleTTL)
+	_, err = manager.refreshCache("test_account")
+	assert.Error(t, err, "the user data loaded before should not be used in the strict mode")
+
+	idpUnavailable = false
+	_, err = manager.refreshCache("test_account")
+	require.NoError(t, err)
+	assert.False(t, manager.idpOutage.isDegraded("test_account"))
+}
+
+func TestDefaultAccountManager_getAccountFromCache_StaleUserDataExpires(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "user-id"
+	_, err = createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	idpUnavailable := false
+	idpCalls := 0
+	manager.idpManager = &idp.MockIDP{
+		GetAccountFunc: func(string) ([]*idp.UserData, error) {
+			idpCalls++
+			if idpUnavailable {
+				return nil, errors.New("IdP is unavailable")
+			}
+			return []*idp.UserData{{ID: userID, Email: "user@netbird.io"}}, nil
+		},
+	}
+
+	_, err = manager.refreshCache("test_account")
+	require.NoError(t, err)
+	// the loadable cache stores the loaded user data asynchronously
+	time.Sleep(50 * time.Millisecond)
+
+	manager.SetIdpOutagePolicy(false, 300*time.Millisecond)
+	idpUnavailable = true
+	users, err := manager.refreshCache("test_account")
+	require.NoError(t, err, "the user data loaded before should be used")
+	require.Len(t, users, 1)
+	require.Equal(t, 2, idpCalls)
+
+	_, err = manager.getAccountFromCache("test_account", false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, idpCalls, "the stale user data should be cached while it is not older than the stale TTL")
+
+	time.Sleep(300 * time.Millisecond)
+	_, err = manager.getAccountFromCache("test_account", false)
+	assert.Error(t, err, "the stale user data should expire with the stale TTL rather than the cache expiration")
+	assert.Equal(t, 3, idpCalls, "the IdP manager should be asked again once the stale user data expired")
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"net"
+
+	"github.com/rs/xid"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// IPReservation pins an IP of the account network to the peer with the WireGuard public key, so the peer gets the
+// same IP when it registers again, e.g. after a reinstallation keeping its key. The IP isn't allocated to other peers.
+type IPReservation struct {
+	// ID of the reservation
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `json:"-" gorm:"index"`
+	// PeerKey is the WireGuard public key of the peer the IP is reserved for
+	PeerKey string
+	// IP reserved for the peer
+	IP net.IP
+	// Description of the reservation visible in the UI
+	Description string
+}
+
+// Copy returns a copy of the IP reservation
+func (r *IPReservation) Copy() *IPReservation {
+	return &IPReservation{
+		ID:          r.ID,
+		AccountID:   r.AccountID,
+		PeerKey:     r.PeerKey,
+		IP:          copyIP(r.IP),
+		Description: r.Description,
+	}
+}
+
+// EventMeta returns activity event meta related to the IP reservation
+func (r *IPReservation) EventMeta() map[string]any {
+	return map[string]any{"peer_key": r.PeerKey, "ip": r.IP.String()}
+}
+
+// getReservedIPs returns the IPs reserved for the peers
+func (a *Account) getReservedIPs() []net.IP {
+	reservedIPs := make([]net.IP, 0, len(a.IPReservations))
+	for _, reservation := range a.IPReservations {
+		reservedIPs = append(reservedIPs, reservation.IP)
+	}
+	return reservedIPs
+}
+
+// getIPReservationByPeerKey returns the IP reservation of the peer with the WireGuard public key, nil if there is none
+func (a *Account) getIPReservationByPeerKey(peerKey string) *IPReservation {
+	for _, reservation := range a.IPReservations {
+		if reservation.PeerKey == peerKey {
+			return reservation
+		}
+	}
+	return nil
+}
+
+// getPeerByIP returns the peer with the IP, nil if there is none
+func (a *Account) getPeerByIP(ip net.IP) *nbpeer.Peer {
+	for _, peer := range a.Peers {
+		if peer.IP.Equal(ip) {
+			return peer
+		}
+	}
+	return nil
+}
+
+// allocatePeerIP returns the IP reserved for the peer with the WireGuard public key, or a free IP of the account
+// network which isn't reserved for another peer, picked with the PeerIPAllocation strategy of the account
+func (a *Account) allocatePeerIP(peerKey string) (net.IP, error) {
+	if reservation := a.getIPReservationByPeerKey(peerKey); reservation != nil {
+		if peer := a.getPeerByIP(reservation.IP); peer != nil && peer.Key != peerKey {
+			return nil, status.Errorf(status.AlreadyExists, "IP %s reserved for the peer is already used by peer %s, "+
+				"delete that peer or the reservation", reservation.IP, peer.Name)
+		}
+		return copyIP(reservation.IP), nil
+	}
+
+	return allocatePeerIPWithStrategy(a.Settings.PeerIPAllocation, a.Network.Net, append(a.getTakenIPs(), a.getReservedIPs()...))
+}
+
+// ListIPReservations returns the IP reservations of the account
+func (am *DefaultAccountManager) ListIPReservations(accountID, userID string) ([]*IPReservation, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourcePeers, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view IP reservations")
+	}
+
+	reservations := make([]*IPReservation, 0, len(account.IPReservations))
+	for _, reservation := range account.IPReservations {
+		reservations = append(reservations, reservation.Copy())
+	}
+
+	return reservations, nil
+}
+
+// SaveIPReservation reserves the IP for the peer with the WireGuard public key of the reservation, replacing the
+// previous reservation of the key. A peer already registered with the key keeps its IP until it registers again.
+func (am *DefaultAccountManager) SaveIPReservation(accountID, userID string, reservationToSave *IPReservation) (*IPReservation, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	if reservationToSave == nil {
+		return nil, status.Errorf(status.InvalidArgument, "IP reservation provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkPermission(account, userID, ResourcePeers, OperationWrite); err != nil {
+		return nil, err
+	}
+
+	reservation := reservationToSave.Copy()
+	reservation.AccountID = accountID
+
+	if err = validateIPReservation(account, reservation); err != nil {
+		return nil, err
+	}
+
+	event := activity.IPReservationCreated
+	if existing := account.getIPReservationByPeerKey(reservation.PeerKey); existing != nil {
+		reservation.ID = existing.ID
+		event = activity.IPReservationUpdated
+	} else {
+		reservation.ID = xid.New().String()
+	}
+
+	if account.IPReservations == nil {
+		account.IPReservations = make(map[string]*IPReservation)
+	}
+	account.IPReservations[reservation.ID] = reservation
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.StoreEvent(userID, reservation.ID, accountID, event, reservation.EventMeta())
+
+	return reservation.Copy(), nil
+}
+
+// DeleteIPReservation deletes the IP reservation, the reserved IP can be allocated to any peer afterward
+func (am *DefaultAccountManager) DeleteIPReservation(accountID, userID, reservationID string) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkPermission(account, userID, ResourcePeers, OperationWrite); err != nil {
+		return err
+	}
+
+	reservation := account.IPReservations[reservationID]
+	if reservation == nil {
+		return status.Errorf(status.NotFound, "IP reservation %s wasn't found", reservationID)
+	}
+	delete(account.IPReservations, reservationID)
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.StoreEvent(userID, reservation.ID, accountID, activity.IPReservationDeleted, reservation.EventMeta())
+
+	return nil
+}
+
+// validateIPReservation checks the reservation has a valid key and a usable IP of the account network
+// which is neither reserved for nor used by another peer
+func validateIPReservation(account *Account, reservation *IPReservation) error {
+	if _, err := wgtypes.ParseKey(reservation.PeerKey); err != nil {
+		return status.Errorf(status.InvalidArgument, "invalid peer WireGuard public key %s", reservation.PeerKey)
+	}
+
+	ip := reservation.IP.To4()
+	if ip == nil {
+		return status.Errorf(status.InvalidArgument, "invalid reserved IP %s, expecting an IPv4 address", reservation.IP)
+	}
+	reservation.IP = ip
+
+	network := account.Network.Net
+	usableIPs, _ := generateIPs(&network, map[string]struct{}{network.IP.String(): {}})
+	usable := false
+	for _, usableIP := range usableIPs {
+		if usableIP.Equal(ip) {
+			usable = true
+			break
+		}
+	}
+	if !usable {
+		return status.Errorf(status.InvalidArgument, "IP %s isn't a usable address of the account network %s", ip, network.String())
+	}
+
+	for _, existing := range account.IPReservations {
+		if existing.IP.Equal(ip) && existing.PeerKey != reservation.PeerKey {
+			return status.Errorf(status.AlreadyExists, "IP %s is already reserved for another peer", ip)
+		}
+	}
+
+	if peer := account.getPeerByIP(ip); peer != nil && peer.Key != reservation.PeerKey {
+		return status.Errorf(status.AlreadyExists, "IP %s is already used by peer %s", ip, peer.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_IPReservation(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	newKey := func() string {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		return key.PublicKey().String()
+	}
+	addPeer := func(key, hostname string) (*nbpeer.Peer, error) {
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{Key: key, Meta: nbpeer.PeerSystemMeta{Hostname: hostname}})
+		return peer, err
+	}
+
+	reservedKey := newKey()
+	reservedIP := copyIP(account.Network.Net.IP).To4()
+	reservedIP[3] = 10
+
+	reservation, err := manager.SaveIPReservation(account.Id, userID, &IPReservation{PeerKey: reservedKey, IP: reservedIP, Description: "build"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, reservation.ID)
+
+	for i := 0; i < 5; i++ {
+		peer, err := addPeer(newKey(), "other")
+		require.NoError(t, err)
+		assert.False(t, peer.IP.Equal(reservedIP), "the reserved IP shouldn't be allocated to other peers")
+	}
+
+	peer, err := addPeer(reservedKey, "reserved")
+	require.NoError(t, err)
+	assert.True(t, peer.IP.Equal(reservedIP), "the peer should get its reserved IP")
+
+	require.NoError(t, manager.DeletePeer(account.Id, peer.ID, userID))
+	peer, err = addPeer(reservedKey, "reserved")
+	require.NoError(t, err)
+	assert.True(t, peer.IP.Equal(reservedIP), "the peer should get its reserved IP when registering again")
+
+	otherKey := newKey()
+	_, err = manager.SaveIPReservation(account.Id, userID, &IPReservation{PeerKey: otherKey, IP: reservedIP})
+	assertStatusType(t, err, status.AlreadyExists)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	var usedIP net.IP
+	for _, p := range account.Peers {
+		if p.Key != reservedKey {
+			usedIP = p.IP
+			break
+		}
+	}
+	_, err = manager.SaveIPReservation(account.Id, userID, &IPReservation{PeerKey: otherKey, IP: usedIP})
+	assertStatusType(t, err, status.AlreadyExists)
+
+	_, err = manager.SaveIPReservation(account.Id, userID, &IPReservation{PeerKey: otherKey, IP: net.ParseIP("10.0.0.1")})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.SaveIPReservation(account.Id, userID, &IPReservation{PeerKey: "invalid", IP: reservedIP})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	updatedIP := copyIP(reservedIP)
+	updatedIP[3] = 20
+	updated, err := manager.SaveIPReservation(account.Id, userID, &IPReservation{PeerKey: reservedKey, IP: updatedIP})
+	require.NoError(t, err)
+	assert.Equal(t, reservation.ID, updated.ID, "the reservation of the key should be replaced")
+
+	reservations, err := manager.ListIPReservations(account.Id, userID)
+	require.NoError(t, err)
+	require.Len(t, reservations, 1)
+	assert.True(t, reservations[0].IP.Equal(updatedIP))
+
+	account.Users["regular_user"] = NewRegularUser("regular_user")
+	require.NoError(t, manager.Store.SaveAccount(account))
+	_, err = manager.SaveIPReservation(account.Id, "regular_user", &IPReservation{PeerKey: otherKey, IP: reservedIP})
+	assertStatusType(t, err, status.PermissionDenied)
+
+	require.NoError(t, manager.DeleteIPReservation(account.Id, userID, reservation.ID))
+	reservations, err = manager.ListIPReservations(account.Id, userID)
+	require.NoError(t, err)
+	assert.Empty(t, reservations)
+
+	err = manager.DeleteIPReservation(account.Id, userID, reservation.ID)
+	assertStatusType(t, err, status.NotFound)
+}
+
+func TestDefaultAccountManager_AddPeerReservedIPTaken(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{Key: key.PublicKey().String(), Meta: nbpeer.PeerSystemMeta{Hostname: "holder"}})
+	require.NoError(t, err)
+
+	// a reservation conflicting with a peer can only exist in the store, e.g. after a manual edit
+	reservedKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	account.IPReservations["conflict"] = &IPReservation{ID: "conflict", AccountID: account.Id, PeerKey: reservedKey.PublicKey().String(), IP: peer.IP}
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, _, err = manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{Key: reservedKey.PublicKey().String(), Meta: nbpeer.PeerSystemMeta{Hostname: "reserved"}})
+	assertStatusType(t, err, status.AlreadyExists)
+}
+
+func assertStatusType(t *testing.T, err error, expected status.Type) {
+	t.Helper()
+	sErr, ok := status.FromError(err)
+	require.True(t, ok, "expected a status error, got %v", err)
+	assert.Equal(t, expected, sErr.Type(), sErr.Message)
+}
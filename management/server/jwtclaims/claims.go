@@ -13,6 +13,9 @@ type AuthorizationClaims struct {
 	Domain         string
 	DomainCategory string
 	LastLogin      time.Time
+	// TokenRole is the role limiting the personal access token the request was authenticated with, empty for
+	// the JWTs and the tokens keeping the permissions of their user
+	TokenRole string
 
 	Raw jwt.MapClaims
 }
@@ -20,6 +20,8 @@ const (
 	UserIDClaim = "sub"
 	// LastLoginSuffix claim for the last login
 	LastLoginSuffix = "nb_last_login"
+	// TokenRoleSuffix claim for the role limiting the personal access token the request was authenticated with
+	TokenRoleSuffix = "nb_token_role"
 )
 
 // ExtractClaims Extract function type
@@ -100,6 +102,10 @@ func (c *ClaimsExtractor) FromToken(token *jwt.Token) AuthorizationClaims {
 	if ok {
 		jwtClaims.LastLogin = parseTime(LastLoginClaimString.(string))
 	}
+	tokenRoleClaim, ok := claims[c.authAudience+TokenRoleSuffix]
+	if ok {
+		jwtClaims.TokenRole, _ = tokenRoleClaim.(string)
+	}
 	return jwtClaims
 }
 
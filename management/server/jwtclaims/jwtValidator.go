@@ -21,6 +21,15 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// jwksFetchTimeout limits the time to fetch the JSONWebKeys, the IdP may be unavailable
+	jwksFetchTimeout = 10 * time.Second
+	// jwksRetryInterval is how long the old keys are used before retrying a failed refresh
+	jwksRetryInterval = time.Minute
+)
+
+var jwksClient = &http.Client{Timeout: jwksFetchTimeout}
+
 // Options is a struct for specifying configuration options for the middleware.
 type Options struct {
 	// The function that will return the Key to validate the JWT.
@@ -102,15 +111,18 @@ func NewJWTValidator(issuer string, audienceList []string, keysLocation string,
 					lock.Lock()
 					defer lock.Unlock()
 
-					refreshedKeys, err := getPemKeys(keysLocation)
-					if err != nil {
-						log.Debugf("cannot get JSONWebKey: %v, falling back to old keys", err)
-						refreshedKeys = keys
+					if !keys.stillValid() {
+						refreshedKeys, err := getPemKeys(keysLocation)
+						if err != nil {
+							// keep validating with the old keys during the IdP outage, retry later rather than on every token
+							log.Warnf("cannot get JSONWebKey: %v, falling back to old keys for %s", err, jwksRetryInterval)
+							refreshedKeys = &Jwks{Keys: keys.Keys, expiresInTime: time.Now().Add(jwksRetryInterval)}
+						} else {
+							log.Debugf("keys refreshed, new UTC expiration time: %s", refreshedKeys.expiresInTime.UTC())
+						}
+
+						keys = refreshedKeys
 					}
-
-					log.Debugf("keys refreshed, new UTC expiration time: %s", refreshedKeys.expiresInTime.UTC())
-
-					keys = refreshedKeys
 				}
 			}
 
@@ -185,18 +197,26 @@ func (jwks *Jwks) stillValid() bool {
 }
 
 func getPemKeys(keysLocation string) (*Jwks, error) {
-	resp, err := http.Get(keysLocation)
+	resp, err := jwksClient.Get(keysLocation)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s fetching JSONWebKeys from %s", resp.Status, keysLocation)
+	}
+
 	jwks := &Jwks{}
 	err = json.NewDecoder(resp.Body).Decode(jwks)
 	if err != nil {
 		return jwks, err
 	}
 
+	if len(jwks.Keys) == 0 {
+		return nil, fmt.Errorf("no JSONWebKeys found at %s", keysLocation)
+	}
+
 	cacheControlHeader := resp.Header.Get("Cache-Control")
 	expiresIn := getMaxAgeFromCacheHeader(cacheControlHeader)
 	jwks.expiresInTime = time.Now().Add(time.Duration(expiresIn) * time.Second)
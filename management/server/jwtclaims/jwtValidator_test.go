@@ -0,0 +1,61 @@
+package jwtclaims
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPemKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		expectErr   bool
+		expectValid bool
+	}{
+		{
+			name:        "Valid Keys",
+			status:      http.StatusOK,
+			body:        `{"keys":[{"kty":"RSA","kid":"key-id","n":"AQAB","e":"AQAB"}]}`,
+			expectValid: true,
+		},
+		{
+			name:      "IdP Unavailable",
+			status:    http.StatusServiceUnavailable,
+			body:      `{"keys":[{"kty":"RSA","kid":"key-id","n":"AQAB","e":"AQAB"}]}`,
+			expectErr: true,
+		},
+		{
+			name:      "No Keys",
+			status:    http.StatusOK,
+			body:      `{"keys":[]}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "public, max-age=3600")
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			keys, err := getPemKeys(server.URL)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, keys.Keys, 1)
+			assert.Equal(t, tc.expectValid, keys.stillValid())
+			assert.WithinDuration(t, time.Now().Add(time.Hour), keys.expiresInTime, time.Minute)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// MaintenanceWindow is a period of bulk changes in an account during which the network map updates of the account
+// peers are paused. The changes are delivered to the peers with a single update when the window is stopped.
+type MaintenanceWindow struct {
+	// StartedAt is the time the window was started at
+	StartedAt time.Time
+	// StartedBy is the ID of the user who started the window
+	StartedBy string
+	// PendingUpdate indicates that the account changed during the window and the peers have to be updated
+	PendingUpdate bool
+}
+
+// maintenanceWindows keeps the maintenance windows of the accounts in memory only, so a restart of the management
+// service ends all the windows. The peers then get the full network map of the account when they connect again.
+// The zero value is ready to use.
+type maintenanceWindows struct {
+	mu      sync.Mutex
+	windows map[string]*MaintenanceWindow
+}
+
+// start opens a maintenance window for the account and returns false if there is one already
+func (m *maintenanceWindows) start(accountID, userID string) (*MaintenanceWindow, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.windows == nil {
+		m.windows = make(map[string]*MaintenanceWindow)
+	}
+
+	if window, ok := m.windows[accountID]; ok {
+		return window.copy(), false
+	}
+
+	window := &MaintenanceWindow{StartedAt: time.Now().UTC(), StartedBy: userID}
+	m.windows[accountID] = window
+	return window.copy(), true
+}
+
+// stop closes the maintenance window of the account and returns it, nil if there was no window
+func (m *maintenanceWindows) stop(accountID string) *MaintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	window, ok := m.windows[accountID]
+	if !ok {
+		return nil
+	}
+	delete(m.windows, accountID)
+	return window
+}
+
+// get returns a copy of the maintenance window of the account, nil if there is no window
+func (m *maintenanceWindows) get(accountID string) *MaintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	window, ok := m.windows[accountID]
+	if !ok {
+		return nil
+	}
+	return window.copy()
+}
+
+// deferUpdate records the pending update of the account peers and returns true if the account is in a maintenance window
+func (m *maintenanceWindows) deferUpdate(accountID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	window, ok := m.windows[accountID]
+	if !ok {
+		return false
+	}
+	window.PendingUpdate = true
+	return true
+}
+
+func (w *MaintenanceWindow) copy() *MaintenanceWindow {
+	windowCopy := *w
+	return &windowCopy
+}
+
+// GetMaintenanceWindow returns the active maintenance window of the account, nil if the account isn't in maintenance
+func (am *DefaultAccountManager) GetMaintenanceWindow(accountID, userID string) (*MaintenanceWindow, error) {
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceAccounts, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view the maintenance window")
+	}
+
+	return am.maintenance.get(accountID), nil
+}
+
+// StartMaintenanceWindow pauses the network map updates of the account peers until the window is stopped.
+// The peers that register or connect during the window still receive their full network map.
+func (am *DefaultAccountManager) StartMaintenanceWindow(accountID, userID string) (*MaintenanceWindow, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceAccounts, OperationWrite) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to start a maintenance window")
+	}
+
+	window, started := am.maintenance.start(accountID, userID)
+	if !started {
+		return nil, status.Errorf(status.PreconditionFailed, "maintenance window is already started at %s", window.StartedAt.Format(time.RFC3339))
+	}
+
+	am.StoreEvent(userID, accountID, accountID, activity.AccountMaintenanceStarted, nil)
+
+	return window, nil
+}
+
+// StopMaintenanceWindow resumes the network map updates of the account peers and sends a single update to every
+// peer if the account changed during the window
+func (am *DefaultAccountManager) StopMaintenanceWindow(accountID, userID string) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasPermission(ResourceAccounts, OperationWrite) {
+		return status.Errorf(status.PermissionDenied, "user is not allowed to stop a maintenance window")
+	}
+
+	window := am.maintenance.stop(accountID)
+	if window == nil {
+		return status.Errorf(status.PreconditionFailed, "account has no active maintenance window")
+	}
+
+	if window.PendingUpdate {
+		log.Debugf("sending the changes made during the maintenance window to the peers of account %s", accountID)
+		am.updateAccountPeers(account)
+	}
+
+	meta := map[string]any{"duration": time.Since(window.StartedAt).Round(time.Second).String()}
+	am.StoreEvent(userID, accountID, accountID, activity.AccountMaintenanceStopped, meta)
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_MaintenanceWindow(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	addPeer := func(hostname string) *nbpeer.Peer {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		return peer
+	}
+
+	peer1 := addPeer("peer1")
+	updates := manager.peersUpdateManager.CreateChannel(peer1.ID)
+	t.Cleanup(func() {
+		manager.peersUpdateManager.CloseChannel(peer1.ID)
+	})
+
+	err = manager.StopMaintenanceWindow(account.Id, userID)
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PreconditionFailed, sErr.Type(), "stopping without a window should fail")
+
+	window, err := manager.StartMaintenanceWindow(account.Id, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, window.StartedBy)
+	assert.False(t, window.PendingUpdate)
+
+	_, err = manager.StartMaintenanceWindow(account.Id, userID)
+	sErr, ok = status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PreconditionFailed, sErr.Type(), "starting a second window should fail")
+
+	// new peers can register during the window, but the existing peers don't get the churn
+	for _, hostname := range []string{"peer2", "peer3", "peer4"} {
+		addPeer(hostname)
+	}
+	_, err = manager.UpdateNetworkRange(account.Id, userID, "10.10.0.0/24")
+	require.NoError(t, err)
+	waitDelivered(t, manager.peersUpdateManager, peer1.ID)
+	assert.Len(t, updates, 0, "peer should not receive updates during the maintenance window")
+
+	window, err = manager.GetMaintenanceWindow(account.Id, userID)
+	require.NoError(t, err)
+	require.NotNil(t, window)
+	assert.True(t, window.PendingUpdate)
+
+	err = manager.StopMaintenanceWindow(account.Id, userID)
+	require.NoError(t, err)
+
+	waitDelivered(t, manager.peersUpdateManager, peer1.ID)
+	require.Len(t, updates, 1, "peer should receive a single update when the window stops")
+	update := <-updates
+	network, err := manager.GetNetwork(account.Id, userID)
+	require.NoError(t, err)
+	assert.Equal(t, network.CurrentSerial(), update.Update.NetworkMap.Serial)
+	assert.Len(t, update.Update.NetworkMap.RemotePeers, 3)
+
+	window, err = manager.GetMaintenanceWindow(account.Id, userID)
+	require.NoError(t, err)
+	assert.Nil(t, window)
+
+	addPeer("peer5")
+	waitDelivered(t, manager.peersUpdateManager, peer1.ID)
+	assert.Len(t, updates, 1, "peer should receive updates after the maintenance window")
+}
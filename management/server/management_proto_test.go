@@ -11,11 +11,14 @@ import (
 
 	"github.com/netbirdio/netbird/management/server/activity"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 
 	"github.com/netbirdio/netbird/encryption"
 	mgmtProto "github.com/netbirdio/netbird/management/proto"
@@ -367,7 +370,11 @@ func TestServer_GetDeviceAuthorizationFlow(t *testing.T) {
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
 			mgmtServer := &GRPCServer{
-				wgKey: testingServerKey,
+				serverKeys: &serverKeys{
+					now:       time.Now,
+					current:   testingServerKey,
+					expiresAt: time.Now().Add(DefaultServerKeyLifetime),
+				},
 				config: &Config{
 					DeviceAuthorizationFlow: testCase.inputFlow,
 				},
@@ -375,7 +382,7 @@ func TestServer_GetDeviceAuthorizationFlow(t *testing.T) {
 
 			message := &mgmtProto.DeviceAuthorizationFlowRequest{}
 
-			encryptedMSG, err := encryption.EncryptMessage(testingClientKey.PublicKey(), mgmtServer.wgKey, message)
+			encryptedMSG, err := encryption.EncryptMessage(testingClientKey.PublicKey(), testingServerKey, message)
 			require.NoError(t, err, "should be able to encrypt message")
 
 			resp, err := mgmtServer.GetDeviceAuthorizationFlow(
@@ -389,7 +396,7 @@ func TestServer_GetDeviceAuthorizationFlow(t *testing.T) {
 			if testCase.expectedComparisonFunc != nil {
 				flowInfoResp := &mgmtProto.DeviceAuthorizationFlow{}
 
-				err = encryption.DecryptMessage(mgmtServer.wgKey.PublicKey(), testingClientKey, resp.Body, flowInfoResp)
+				err = encryption.DecryptMessage(testingServerKey.PublicKey(), testingClientKey, resp.Body, flowInfoResp)
 				require.NoError(t, err, "should be able to decrypt")
 
 				testCase.expectedComparisonFunc(t, testCase.expectedFlow.Provider, flowInfoResp.Provider, testCase.expectedComparisonMSG)
@@ -452,3 +459,73 @@ func createRawClient(addr string) (mgmtProto.ManagementServiceClient, *grpc.Clie
 
 	return mgmtProto.NewManagementServiceClient(conn), conn, nil
 }
+
+func checkSetupKey(key wgtypes.Key, client mgmtProto.ManagementServiceClient, setupKey string) (*mgmtProto.SetupKeyCheckResponse, error) {
+	serverKey, err := getServerKey(client)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := encryption.EncryptMessage(*serverKey, key, &mgmtProto.SetupKeyCheckRequest{SetupKey: setupKey})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.CheckSetupKey(context.TODO(), &mgmtProto.EncryptedMessage{
+		WgPubKey: key.PublicKey().String(),
+		Body:     message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	checkResp := &mgmtProto.SetupKeyCheckResponse{}
+	err = encryption.DecryptMessage(*serverKey, key, resp.Body, checkResp)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkResp, nil
+}
+
+func TestServer_CheckSetupKey(t *testing.T) {
+	dir := t.TempDir()
+	err := util.CopyFileContents("testdata/store_with_expired_peers.json", filepath.Join(dir, "store.json"))
+	require.NoError(t, err)
+
+	mgmtServer, mgmtAddr, err := startManagement(t, &Config{
+		Signal: &Host{
+			Proto: "http",
+			URI:   "signal.wiretrustee.com:10000",
+		},
+		Datadir: dir,
+	})
+	require.NoError(t, err)
+	defer mgmtServer.GracefulStop()
+
+	client, clientConn, err := createRawClient(mgmtAddr)
+	require.NoError(t, err)
+	defer clientConn.Close()
+
+	key, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	resp, err := checkSetupKey(key, client, TestValidSetupKey)
+	require.NoError(t, err)
+	assert.True(t, resp.GetValid())
+	assert.Equal(t, int32(-1), resp.GetRemainingUses())
+	assert.NotNil(t, resp.GetExpiresAt())
+
+	resp, err = checkSetupKey(key, client, "B2C8E62B-38F5-4553-B31E-DD66C696CEBB")
+	require.NoError(t, err)
+	assert.False(t, resp.GetValid(), "unknown setup key should be reported as not valid")
+	assert.Nil(t, resp.GetExpiresAt())
+
+	// the checks of the same source are rate limited
+	for i := 2; i < setupKeyCheckBurst; i++ {
+		_, err = checkSetupKey(key, client, TestValidSetupKey)
+		require.NoError(t, err)
+	}
+	_, err = checkSetupKey(key, client, TestValidSetupKey)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
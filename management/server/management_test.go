@@ -318,6 +318,8 @@ var _ = Describe("Management service", func() {
 			Specify("all of the 10 peers will get updates of 10 newly registered peers", func() {
 				initialPeers := 10
 				additionalPeers := 10
+				// the updates of a burst of registrations are coalesced, so each peer has to end up with all the others
+				expectedRemotePeers := initialPeers + additionalPeers - 1
 
 				var peers []wgtypes.Key
 				for i := 0; i < initialPeers; i++ {
@@ -327,7 +329,7 @@ var _ = Describe("Management service", func() {
 				}
 
 				wg := sync2.WaitGroup{}
-				wg.Add(initialPeers + initialPeers*additionalPeers)
+				wg.Add(initialPeers)
 
 				var clients []mgmtProto.ManagementService_SyncClient
 				for _, peer := range peers {
@@ -347,6 +349,7 @@ var _ = Describe("Management service", func() {
 					// receive stream
 					peer := peer
 					go func() {
+						done := false
 						for {
 							encryptedResponse := &mgmtProto.EncryptedMessage{}
 							err = sync.RecvMsg(encryptedResponse)
@@ -359,8 +362,8 @@ var _ = Describe("Management service", func() {
 							resp := &mgmtProto.SyncResponse{}
 							err = pb.Unmarshal(decryptedBytes, resp)
 							Expect(err).NotTo(HaveOccurred())
-							if len(resp.GetRemotePeers()) > 0 {
-								// only consider peer updates
+							if !done && len(resp.GetRemotePeers()) == expectedRemotePeers {
+								done = true
 								wg.Done()
 							}
 						}
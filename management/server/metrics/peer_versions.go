@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+
+	"github.com/netbirdio/netbird/management/server"
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+const (
+	// peerVersionsInterval is how often the connected peers are counted by OS and client version
+	peerVersionsInterval = 5 * time.Minute
+	// maxPeerVersionLabels is the number of the newest client versions reported as they are,
+	// the peers running older versions are reported with the oldPeerVersion label
+	maxPeerVersionLabels = 10
+
+	oldPeerVersion         = "old"
+	unknownPeerLabel       = "unknown"
+	otherPeerOS            = "other"
+	developmentPeerVersion = "development"
+)
+
+// knownPeerOSes are the operating systems reported as they are, the others are reported with the otherPeerOS label
+var knownPeerOSes = map[string]struct{}{
+	"linux":   {},
+	"windows": {},
+	"darwin":  {},
+	"android": {},
+	"ios":     {},
+	"freebsd": {},
+}
+
+// PeerVersionsWorker periodically counts the connected peers by OS and client version for the application metrics
+type PeerVersionsWorker struct {
+	ctx         context.Context
+	dataSource  DataSource
+	connManager ConnManager
+	metrics     *telemetry.PeerMetrics
+}
+
+// NewPeerVersionsWorker returns a worker counting the connected peers by OS and client version
+func NewPeerVersionsWorker(ctx context.Context, dataSource DataSource, connManager ConnManager, metrics *telemetry.PeerMetrics) *PeerVersionsWorker {
+	return &PeerVersionsWorker{
+		ctx:         ctx,
+		dataSource:  dataSource,
+		connManager: connManager,
+		metrics:     metrics,
+	}
+}
+
+// Run runs the peer versions worker
+func (w *PeerVersionsWorker) Run() {
+	w.update()
+
+	ticker := time.NewTicker(peerVersionsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.update()
+		}
+	}
+}
+
+func (w *PeerVersionsWorker) update() {
+	w.metrics.UpdateConnectedPeerVersions(countPeerVersions(w.dataSource.GetAllAccounts(), w.connManager.GetAllConnectedPeers()))
+}
+
+// countPeerVersions returns the number of the connected peers by OS and client version. The number of distinct labels
+// is bounded: the OSes other than the known ones are counted together, and so are the versions older than the newest
+// maxPeerVersionLabels ones and the versions which can't be parsed
+func countPeerVersions(accounts []*server.Account, connected map[string]struct{}) map[telemetry.PeerOSVersion]int64 {
+	type peerVersion struct {
+		os      string
+		version *version.Version
+		label   string
+	}
+
+	var peers []peerVersion
+	for _, account := range accounts {
+		for _, peer := range account.Peers {
+			if _, ok := connected[peer.ID]; !ok {
+				continue
+			}
+
+			p := peerVersion{os: peerOSLabel(peer.Meta.GoOS)}
+			switch peer.Meta.WtVersion {
+			case "":
+				p.label = unknownPeerLabel
+			case developmentPeerVersion:
+				p.label = developmentPeerVersion
+			default:
+				v, err := version.NewVersion(peer.Meta.WtVersion)
+				if err != nil {
+					p.label = unknownPeerLabel
+					break
+				}
+				p.version = v.Core()
+			}
+			peers = append(peers, p)
+		}
+	}
+
+	var parsed []*version.Version
+	for _, p := range peers {
+		if p.version != nil {
+			parsed = append(parsed, p.version)
+		}
+	}
+	newest := newestPeerVersions(parsed)
+
+	versions := make(map[telemetry.PeerOSVersion]int64)
+	for _, p := range peers {
+		label := p.label
+		if p.version != nil {
+			label = oldPeerVersion
+			if _, ok := newest[p.version.String()]; ok {
+				label = p.version.String()
+			}
+		}
+		versions[telemetry.PeerOSVersion{OS: p.os, Version: label}]++
+	}
+	return versions
+}
+
+// newestPeerVersions returns the newest maxPeerVersionLabels distinct versions
+func newestPeerVersions(versions []*version.Version) map[string]struct{} {
+	distinct := make(map[string]*version.Version)
+	for _, v := range versions {
+		distinct[v.String()] = v
+	}
+
+	sorted := make([]*version.Version, 0, len(distinct))
+	for _, v := range distinct {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GreaterThan(sorted[j])
+	})
+	if len(sorted) > maxPeerVersionLabels {
+		sorted = sorted[:maxPeerVersionLabels]
+	}
+
+	newest := make(map[string]struct{}, len(sorted))
+	for _, v := range sorted {
+		newest[v.String()] = struct{}{}
+	}
+	return newest
+}
+
+func peerOSLabel(goOS string) string {
+	goOS = strings.ToLower(goOS)
+	if goOS == "" {
+		return unknownPeerLabel
+	}
+	if _, ok := knownPeerOSes[goOS]; ok {
+		return goOS
+	}
+	return otherPeerOS
+}
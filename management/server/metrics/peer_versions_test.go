@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/netbirdio/netbird/management/server"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+func TestCountPeerVersions(t *testing.T) {
+	peers := map[string]*nbpeer.Peer{
+		"linux-new":       {ID: "linux-new", Meta: nbpeer.PeerSystemMeta{GoOS: "linux", WtVersion: "0.30.0"}},
+		"linux-new-rc":    {ID: "linux-new-rc", Meta: nbpeer.PeerSystemMeta{GoOS: "linux", WtVersion: "0.30.0-rc1"}},
+		"windows-dev":     {ID: "windows-dev", Meta: nbpeer.PeerSystemMeta{GoOS: "windows", WtVersion: "development"}},
+		"darwin-bogus":    {ID: "darwin-bogus", Meta: nbpeer.PeerSystemMeta{GoOS: "darwin", WtVersion: "not a version"}},
+		"plan9":           {ID: "plan9", Meta: nbpeer.PeerSystemMeta{GoOS: "plan9", WtVersion: "0.30.0"}},
+		"disconnected":    {ID: "disconnected", Meta: nbpeer.PeerSystemMeta{GoOS: "linux", WtVersion: "0.30.0"}},
+		"no-meta":         {ID: "no-meta"},
+		"linux-very-old0": {ID: "linux-very-old0", Meta: nbpeer.PeerSystemMeta{GoOS: "linux", WtVersion: "0.1.0"}},
+	}
+	connected := map[string]struct{}{}
+	for id := range peers {
+		if id != "disconnected" {
+			connected[id] = struct{}{}
+		}
+	}
+	// more distinct versions than reported, the oldest ones are bucketed
+	for i := 0; i < maxPeerVersionLabels; i++ {
+		id := fmt.Sprintf("linux-%d", i)
+		peers[id] = &nbpeer.Peer{ID: id, Meta: nbpeer.PeerSystemMeta{GoOS: "Linux", WtVersion: fmt.Sprintf("0.20.%d", i)}}
+		connected[id] = struct{}{}
+	}
+
+	versions := countPeerVersions([]*server.Account{{Id: "1", Peers: peers}}, connected)
+
+	expected := map[telemetry.PeerOSVersion]int64{
+		{OS: "linux", Version: "0.30.0"}:        2,
+		{OS: "other", Version: "0.30.0"}:        1,
+		{OS: "windows", Version: "development"}: 1,
+		{OS: "darwin", Version: "unknown"}:      1,
+		{OS: "unknown", Version: "unknown"}:     1,
+		{OS: "linux", Version: "old"}:           2,
+	}
+	for i := 1; i < maxPeerVersionLabels; i++ {
+		expected[telemetry.PeerOSVersion{OS: "linux", Version: fmt.Sprintf("0.20.%d", i)}] = 1
+	}
+
+	if len(versions) != len(expected) {
+		t.Errorf("expected %d labels, got %d: %v", len(expected), len(versions), versions)
+	}
+	for key, count := range expected {
+		if versions[key] != count {
+			t.Errorf("expected %d peers with %+v, got %d", count, key, versions[key])
+		}
+	}
+}
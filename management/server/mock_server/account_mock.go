@@ -1,6 +1,7 @@
 package mock_server
 
 import (
+	"net"
 	"time"
 
 	"google.golang.org/grpc/codes"
@@ -23,10 +24,28 @@ type MockAccountManager struct {
 	GetUserFunc                     func(claims jwtclaims.AuthorizationClaims) (*server.User, error)
 	ListUsersFunc                   func(accountID string) ([]*server.User, error)
 	GetPeersFunc                    func(accountID, userID string) ([]*nbpeer.Peer, error)
-	MarkPeerConnectedFunc           func(peerKey string, connected bool) error
+	MarkPeerConnectedFunc           func(peerKey string, connected bool, realIP net.IP) error
 	DeletePeerFunc                  func(accountID, peerKey, userID string) error
+	GetPendingPeersFunc             func(accountID, userID string) ([]*nbpeer.Peer, error)
+	ApprovePeerFunc                 func(accountID, userID, peerID string) (*nbpeer.Peer, error)
+	RejectPeerFunc                  func(accountID, userID, peerID string) error
 	GetNetworkMapFunc               func(peerKey string) (*server.NetworkMap, error)
+	GetPeerNetworkMapPreviewFunc    func(accountID, peerID, userID string) (*server.NetworkMap, error)
 	GetPeerNetworkFunc              func(peerKey string) (*server.Network, error)
+	GetNetworkFunc                  func(accountID, userID string) (*server.Network, error)
+	UpdateNetworkRangeFunc          func(accountID, userID, networkRange string) (*server.Network, error)
+	GetMaintenanceWindowFunc        func(accountID, userID string) (*server.MaintenanceWindow, error)
+	StartMaintenanceWindowFunc      func(accountID, userID string) (*server.MaintenanceWindow, error)
+	StopMaintenanceWindowFunc       func(accountID, userID string) error
+	RotateWebhookSecretFunc         func(accountID, userID string) (string, error)
+	GetAccountForExportFunc         func(accountID, userID string) (*server.Account, error)
+	GetDefaultDenyReportFunc        func(accountID, userID string) (*server.DefaultDenyReport, error)
+	GetEffectiveACLFunc             func(accountID, userID, peerID string) (*server.EffectiveACL, error)
+	SimulatePolicyFunc              func(accountID, userID string, request *server.PolicySimulationRequest) (*server.PolicySimulation, error)
+	ApplyBatchFunc                  func(accountID, userID string, operations []*server.BatchOperation) error
+	GetInactivePeersFunc            func(accountID, userID string, threshold time.Duration) ([]*server.InactivePeer, error)
+	GetOutdatedPeersFunc            func(accountID, userID, minVersion string) ([]*server.OutdatedPeer, error)
+	RotatePeerKeyFunc               func(oldPeerKey, newPeerKey string) (*nbpeer.Peer, error)
 	AddPeerFunc                     func(setupKey string, userId string, peer *nbpeer.Peer) (*nbpeer.Peer, *server.NetworkMap, error)
 	GetGroupFunc                    func(accountID, groupID string) (*server.Group, error)
 	GetGroupByNameFunc              func(accountID, groupName string) (*server.Group, error)
@@ -55,11 +74,14 @@ type MockAccountManager struct {
 	DeleteRouteFunc                 func(accountID, routeID, userID string) error
 	ListRoutesFunc                  func(accountID, userID string) ([]*route.Route, error)
 	SaveSetupKeyFunc                func(accountID string, key *server.SetupKey, userID string) (*server.SetupKey, error)
+	CheckSetupKeyFunc               func(setupKey string) (*server.SetupKeyState, error)
 	ListSetupKeysFunc               func(accountID, userID string) ([]*server.SetupKey, error)
+	ApproveSetupKeyFunc             func(accountID, userID, keyID string) (*server.SetupKey, error)
+	GetPendingSetupKeysFunc         func(accountID, userID string) ([]*server.SetupKey, error)
 	SaveUserFunc                    func(accountID, userID string, user *server.User) (*server.UserInfo, error)
 	SaveOrAddUserFunc               func(accountID, userID string, user *server.User, addIfNotExists bool) (*server.UserInfo, error)
 	DeleteUserFunc                  func(accountID string, initiatorUserID string, targetUserID string) error
-	CreatePATFunc                   func(accountID string, initiatorUserID string, targetUserId string, tokenName string, expiresIn int) (*server.PersonalAccessTokenGenerated, error)
+	CreatePATFunc                   func(accountID string, initiatorUserID string, targetUserId string, tokenName string, expiresIn int, role server.UserRole) (*server.PersonalAccessTokenGenerated, error)
 	DeletePATFunc                   func(accountID string, initiatorUserID string, targetUserId string, tokenID string) error
 	GetPATFunc                      func(accountID string, initiatorUserID string, targetUserId string, tokenID string) (*server.PersonalAccessToken, error)
 	GetAllPATsFunc                  func(accountID string, initiatorUserID string, targetUserId string) ([]*server.PersonalAccessToken, error)
@@ -68,6 +90,34 @@ type MockAccountManager struct {
 	SaveNameServerGroupFunc         func(accountID, userID string, nsGroupToSave *nbdns.NameServerGroup) error
 	DeleteNameServerGroupFunc       func(accountID, nsGroupID, userID string) error
 	ListNameServerGroupsFunc        func(accountID string, userID string) ([]*nbdns.NameServerGroup, error)
+	GetDNSRecordFunc                func(accountID, userID, recordID string) (*server.DNSRecord, error)
+	CreateDNSRecordFunc             func(accountID, userID string, recordToCreate *server.DNSRecord) (*server.DNSRecord, error)
+	SaveDNSRecordFunc               func(accountID, userID string, recordToSave *server.DNSRecord) error
+	DeleteDNSRecordFunc             func(accountID, recordID, userID string) error
+	ListDNSRecordsFunc              func(accountID, userID string) ([]*server.DNSRecord, error)
+	GetNetworkResourceFunc          func(accountID, userID, resourceID string) (*server.NetworkResource, error)
+	CreateNetworkResourceFunc       func(accountID, userID string, resourceToCreate *server.NetworkResource) (*server.NetworkResource, error)
+	SaveNetworkResourceFunc         func(accountID, userID string, resourceToSave *server.NetworkResource) error
+	DeleteNetworkResourceFunc       func(accountID, resourceID, userID string) error
+	ListNetworkResourcesFunc        func(accountID, userID string) ([]*server.NetworkResource, error)
+	GetStaticRouteFunc              func(accountID, userID, routeID string) (*server.StaticRoute, error)
+	CreateStaticRouteFunc           func(accountID, userID string, routeToCreate *server.StaticRoute) (*server.StaticRoute, error)
+	SaveStaticRouteFunc             func(accountID, userID string, routeToSave *server.StaticRoute) error
+	DeleteStaticRouteFunc           func(accountID, routeID, userID string) error
+	ListStaticRoutesFunc            func(accountID, userID string) ([]*server.StaticRoute, error)
+	ListSyncSessionsFunc            func(accountID, userID string) ([]server.SyncSession, error)
+	CloseSyncSessionFunc            func(accountID, userID, peerID string) error
+	ListIPReservationsFunc          func(accountID, userID string) ([]*server.IPReservation, error)
+	SaveIPReservationFunc           func(accountID, userID string, reservationToSave *server.IPReservation) (*server.IPReservation, error)
+	DeleteIPReservationFunc         func(accountID, userID, reservationID string) error
+	ListOrgKeysFunc                 func(accountID, userID string) ([]*server.OrgKey, error)
+	CreateOrgKeyFunc                func(accountID, userID, name string, expiresIn time.Duration, linkedAccounts []string) (*server.OrgKey, error)
+	SaveOrgKeyFunc                  func(accountID, userID string, keyToSave *server.OrgKey) (*server.OrgKey, error)
+	GetPostureCheckFunc             func(accountID, userID, checkID string) (*server.PostureCheck, error)
+	ListPostureChecksFunc           func(accountID, userID string) ([]*server.PostureCheck, error)
+	SavePostureCheckFunc            func(accountID, userID string, checkToSave *server.PostureCheck) (*server.PostureCheck, error)
+	DeletePostureCheckFunc          func(accountID, userID, checkID string) error
+	GetNonCompliantPeersFunc        func(accountID, userID string) ([]*server.NonCompliantPeer, error)
 	CreateUserFunc                  func(accountID, userID string, key *server.UserInfo) (*server.UserInfo, error)
 	GetAccountFromTokenFunc         func(claims jwtclaims.AuthorizationClaims) (*server.Account, *server.User, error)
 	CheckUserAccessByJWTGroupsFunc  func(claims jwtclaims.AuthorizationClaims) error
@@ -75,6 +125,7 @@ type MockAccountManager struct {
 	GetDNSDomainFunc                func() string
 	StoreEventFunc                  func(initiatorID, targetID, accountID string, activityID activity.Activity, meta map[string]any)
 	GetEventsFunc                   func(accountID, userID string) ([]*activity.Event, error)
+	SubscribeToEventsFunc           func(accountID, userID, resumeToken string) (*server.EventSubscription, error)
 	GetDNSSettingsFunc              func(accountID, userID string) (*server.DNSSettings, error)
 	SaveDNSSettingsFunc             func(accountID, userID string, dnsSettingsToSave *server.DNSSettings) error
 	GetPeerFunc                     func(accountID, peerID, userID string) (*nbpeer.Peer, error)
@@ -103,6 +154,30 @@ func (am *MockAccountManager) DeletePeer(accountID, peerID, userID string) error
 	return status.Errorf(codes.Unimplemented, "method DeletePeer is not implemented")
 }
 
+// GetPendingPeers mock implementation of GetPendingPeers from server.AccountManager interface
+func (am *MockAccountManager) GetPendingPeers(accountID, userID string) ([]*nbpeer.Peer, error) {
+	if am.GetPendingPeersFunc != nil {
+		return am.GetPendingPeersFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPendingPeers is not implemented")
+}
+
+// ApprovePeer mock implementation of ApprovePeer from server.AccountManager interface
+func (am *MockAccountManager) ApprovePeer(accountID, userID, peerID string) (*nbpeer.Peer, error) {
+	if am.ApprovePeerFunc != nil {
+		return am.ApprovePeerFunc(accountID, userID, peerID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ApprovePeer is not implemented")
+}
+
+// RejectPeer mock implementation of RejectPeer from server.AccountManager interface
+func (am *MockAccountManager) RejectPeer(accountID, userID, peerID string) error {
+	if am.RejectPeerFunc != nil {
+		return am.RejectPeerFunc(accountID, userID, peerID)
+	}
+	return status.Errorf(codes.Unimplemented, "method RejectPeer is not implemented")
+}
+
 // GetOrCreateAccountByUser mock implementation of GetOrCreateAccountByUser from server.AccountManager interface
 func (am *MockAccountManager) GetOrCreateAccountByUser(
 	userId, domain string,
@@ -147,9 +222,9 @@ func (am *MockAccountManager) GetAccountByUserOrAccountID(
 }
 
 // MarkPeerConnected mock implementation of MarkPeerConnected from server.AccountManager interface
-func (am *MockAccountManager) MarkPeerConnected(peerKey string, connected bool) error {
+func (am *MockAccountManager) MarkPeerConnected(peerKey string, connected bool, realIP net.IP) error {
 	if am.MarkPeerConnectedFunc != nil {
-		return am.MarkPeerConnectedFunc(peerKey, connected)
+		return am.MarkPeerConnectedFunc(peerKey, connected, realIP)
 	}
 	return status.Errorf(codes.Unimplemented, "method MarkPeerConnected is not implemented")
 }
@@ -179,9 +254,9 @@ func (am *MockAccountManager) MarkPATUsed(pat string) error {
 }
 
 // CreatePAT mock implementation of GetPAT from server.AccountManager interface
-func (am *MockAccountManager) CreatePAT(accountID string, initiatorUserID string, targetUserID string, name string, expiresIn int) (*server.PersonalAccessTokenGenerated, error) {
+func (am *MockAccountManager) CreatePAT(accountID string, initiatorUserID string, targetUserID string, name string, expiresIn int, role server.UserRole) (*server.PersonalAccessTokenGenerated, error) {
 	if am.CreatePATFunc != nil {
-		return am.CreatePATFunc(accountID, initiatorUserID, targetUserID, name, expiresIn)
+		return am.CreatePATFunc(accountID, initiatorUserID, targetUserID, name, expiresIn, role)
 	}
 	return nil, status.Errorf(codes.Unimplemented, "method CreatePAT is not implemented")
 }
@@ -218,6 +293,14 @@ func (am *MockAccountManager) GetNetworkMap(peerKey string) (*server.NetworkMap,
 	return nil, status.Errorf(codes.Unimplemented, "method GetNetworkMap is not implemented")
 }
 
+// GetPeerNetworkMapPreview mock implementation of GetPeerNetworkMapPreview from server.AccountManager interface
+func (am *MockAccountManager) GetPeerNetworkMapPreview(accountID, peerID, userID string) (*server.NetworkMap, error) {
+	if am.GetPeerNetworkMapPreviewFunc != nil {
+		return am.GetPeerNetworkMapPreviewFunc(accountID, peerID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerNetworkMapPreview is not implemented")
+}
+
 // GetPeerNetwork mock implementation of GetPeerNetwork from server.AccountManager interface
 func (am *MockAccountManager) GetPeerNetwork(peerKey string) (*server.Network, error) {
 	if am.GetPeerNetworkFunc != nil {
@@ -226,6 +309,118 @@ func (am *MockAccountManager) GetPeerNetwork(peerKey string) (*server.Network, e
 	return nil, status.Errorf(codes.Unimplemented, "method GetPeerNetwork is not implemented")
 }
 
+// GetNetwork mock implementation of GetNetwork from server.AccountManager interface
+func (am *MockAccountManager) GetNetwork(accountID, userID string) (*server.Network, error) {
+	if am.GetNetworkFunc != nil {
+		return am.GetNetworkFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetNetwork is not implemented")
+}
+
+// UpdateNetworkRange mock implementation of UpdateNetworkRange from server.AccountManager interface
+func (am *MockAccountManager) UpdateNetworkRange(accountID, userID, networkRange string) (*server.Network, error) {
+	if am.UpdateNetworkRangeFunc != nil {
+		return am.UpdateNetworkRangeFunc(accountID, userID, networkRange)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateNetworkRange is not implemented")
+}
+
+// GetMaintenanceWindow mock implementation of GetMaintenanceWindow from server.AccountManager interface
+func (am *MockAccountManager) GetMaintenanceWindow(accountID, userID string) (*server.MaintenanceWindow, error) {
+	if am.GetMaintenanceWindowFunc != nil {
+		return am.GetMaintenanceWindowFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetMaintenanceWindow is not implemented")
+}
+
+// StartMaintenanceWindow mock implementation of StartMaintenanceWindow from server.AccountManager interface
+func (am *MockAccountManager) StartMaintenanceWindow(accountID, userID string) (*server.MaintenanceWindow, error) {
+	if am.StartMaintenanceWindowFunc != nil {
+		return am.StartMaintenanceWindowFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method StartMaintenanceWindow is not implemented")
+}
+
+// StopMaintenanceWindow mock implementation of StopMaintenanceWindow from server.AccountManager interface
+func (am *MockAccountManager) StopMaintenanceWindow(accountID, userID string) error {
+	if am.StopMaintenanceWindowFunc != nil {
+		return am.StopMaintenanceWindowFunc(accountID, userID)
+	}
+	return status.Errorf(codes.Unimplemented, "method StopMaintenanceWindow is not implemented")
+}
+
+// RotateWebhookSecret mock implementation of RotateWebhookSecret from server.AccountManager interface
+func (am *MockAccountManager) RotateWebhookSecret(accountID, userID string) (string, error) {
+	if am.RotateWebhookSecretFunc != nil {
+		return am.RotateWebhookSecretFunc(accountID, userID)
+	}
+	return "", status.Errorf(codes.Unimplemented, "method RotateWebhookSecret is not implemented")
+}
+
+// GetAccountForExport mock implementation of GetAccountForExport from server.AccountManager interface
+func (am *MockAccountManager) GetAccountForExport(accountID, userID string) (*server.Account, error) {
+	if am.GetAccountForExportFunc != nil {
+		return am.GetAccountForExportFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountForExport is not implemented")
+}
+
+// GetDefaultDenyReport mock implementation of GetDefaultDenyReport from server.AccountManager interface
+func (am *MockAccountManager) GetDefaultDenyReport(accountID, userID string) (*server.DefaultDenyReport, error) {
+	if am.GetDefaultDenyReportFunc != nil {
+		return am.GetDefaultDenyReportFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetDefaultDenyReport is not implemented")
+}
+
+// GetEffectiveACL mock implementation of GetEffectiveACL from server.AccountManager interface
+func (am *MockAccountManager) GetEffectiveACL(accountID, userID, peerID string) (*server.EffectiveACL, error) {
+	if am.GetEffectiveACLFunc != nil {
+		return am.GetEffectiveACLFunc(accountID, userID, peerID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetEffectiveACL is not implemented")
+}
+
+// SimulatePolicy mock implementation of SimulatePolicy from server.AccountManager interface
+func (am *MockAccountManager) SimulatePolicy(accountID, userID string, request *server.PolicySimulationRequest) (*server.PolicySimulation, error) {
+	if am.SimulatePolicyFunc != nil {
+		return am.SimulatePolicyFunc(accountID, userID, request)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method SimulatePolicy is not implemented")
+}
+
+// ApplyBatch mock implementation of ApplyBatch from server.AccountManager interface
+func (am *MockAccountManager) ApplyBatch(accountID, userID string, operations []*server.BatchOperation) error {
+	if am.ApplyBatchFunc != nil {
+		return am.ApplyBatchFunc(accountID, userID, operations)
+	}
+	return status.Errorf(codes.Unimplemented, "method ApplyBatch is not implemented")
+}
+
+// GetInactivePeers mock implementation of GetInactivePeers from server.AccountManager interface
+func (am *MockAccountManager) GetInactivePeers(accountID, userID string, threshold time.Duration) ([]*server.InactivePeer, error) {
+	if am.GetInactivePeersFunc != nil {
+		return am.GetInactivePeersFunc(accountID, userID, threshold)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetInactivePeers is not implemented")
+}
+
+// GetOutdatedPeers mock implementation of GetOutdatedPeers from server.AccountManager interface
+func (am *MockAccountManager) GetOutdatedPeers(accountID, userID, minVersion string) ([]*server.OutdatedPeer, error) {
+	if am.GetOutdatedPeersFunc != nil {
+		return am.GetOutdatedPeersFunc(accountID, userID, minVersion)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetOutdatedPeers is not implemented")
+}
+
+// RotatePeerKey mock implementation of RotatePeerKey from server.AccountManager interface
+func (am *MockAccountManager) RotatePeerKey(oldPeerKey, newPeerKey string) (*nbpeer.Peer, error) {
+	if am.RotatePeerKeyFunc != nil {
+		return am.RotatePeerKeyFunc(oldPeerKey, newPeerKey)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method RotatePeerKey is not implemented")
+}
+
 // AddPeer mock implementation of AddPeer from server.AccountManager interface
 func (am *MockAccountManager) AddPeer(
 	setupKey string,
@@ -446,6 +641,14 @@ func (am *MockAccountManager) SaveSetupKey(accountID string, key *server.SetupKe
 	return nil, status.Errorf(codes.Unimplemented, "method SaveSetupKey is not implemented")
 }
 
+// CheckSetupKey mocks CheckSetupKey of the AccountManager interface
+func (am *MockAccountManager) CheckSetupKey(setupKey string) (*server.SetupKeyState, error) {
+	if am.CheckSetupKeyFunc != nil {
+		return am.CheckSetupKeyFunc(setupKey)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSetupKey is not implemented")
+}
+
 // GetSetupKey mocks GetSetupKey of the AccountManager interface
 func (am *MockAccountManager) GetSetupKey(accountID, userID, keyID string) (*server.SetupKey, error) {
 	if am.GetSetupKeyFunc != nil {
@@ -464,6 +667,24 @@ func (am *MockAccountManager) ListSetupKeys(accountID, userID string) ([]*server
 	return nil, status.Errorf(codes.Unimplemented, "method ListSetupKeys is not implemented")
 }
 
+// ApproveSetupKey mocks ApproveSetupKey of the AccountManager interface
+func (am *MockAccountManager) ApproveSetupKey(accountID, userID, keyID string) (*server.SetupKey, error) {
+	if am.ApproveSetupKeyFunc != nil {
+		return am.ApproveSetupKeyFunc(accountID, userID, keyID)
+	}
+
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveSetupKey is not implemented")
+}
+
+// GetPendingSetupKeys mocks GetPendingSetupKeys of the AccountManager interface
+func (am *MockAccountManager) GetPendingSetupKeys(accountID, userID string) ([]*server.SetupKey, error) {
+	if am.GetPendingSetupKeysFunc != nil {
+		return am.GetPendingSetupKeysFunc(accountID, userID)
+	}
+
+	return nil, status.Errorf(codes.Unimplemented, "method GetPendingSetupKeys is not implemented")
+}
+
 // SaveUser mocks SaveUser of the AccountManager interface
 func (am *MockAccountManager) SaveUser(accountID, userID string, user *server.User) (*server.UserInfo, error) {
 	if am.SaveUserFunc != nil {
@@ -535,6 +756,230 @@ func (am *MockAccountManager) ListNameServerGroups(accountID string, userID stri
 	return nil, nil
 }
 
+// GetDNSRecord mocks GetDNSRecord of the AccountManager interface
+func (am *MockAccountManager) GetDNSRecord(accountID, userID, recordID string) (*server.DNSRecord, error) {
+	if am.GetDNSRecordFunc != nil {
+		return am.GetDNSRecordFunc(accountID, userID, recordID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetDNSRecord is not implemented")
+}
+
+// CreateDNSRecord mocks CreateDNSRecord of the AccountManager interface
+func (am *MockAccountManager) CreateDNSRecord(accountID, userID string, recordToCreate *server.DNSRecord) (*server.DNSRecord, error) {
+	if am.CreateDNSRecordFunc != nil {
+		return am.CreateDNSRecordFunc(accountID, userID, recordToCreate)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDNSRecord is not implemented")
+}
+
+// SaveDNSRecord mocks SaveDNSRecord of the AccountManager interface
+func (am *MockAccountManager) SaveDNSRecord(accountID, userID string, recordToSave *server.DNSRecord) error {
+	if am.SaveDNSRecordFunc != nil {
+		return am.SaveDNSRecordFunc(accountID, userID, recordToSave)
+	}
+	return status.Errorf(codes.Unimplemented, "method SaveDNSRecord is not implemented")
+}
+
+// DeleteDNSRecord mocks DeleteDNSRecord of the AccountManager interface
+func (am *MockAccountManager) DeleteDNSRecord(accountID, recordID, userID string) error {
+	if am.DeleteDNSRecordFunc != nil {
+		return am.DeleteDNSRecordFunc(accountID, recordID, userID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeleteDNSRecord is not implemented")
+}
+
+// ListDNSRecords mocks ListDNSRecords of the AccountManager interface
+func (am *MockAccountManager) ListDNSRecords(accountID, userID string) ([]*server.DNSRecord, error) {
+	if am.ListDNSRecordsFunc != nil {
+		return am.ListDNSRecordsFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListDNSRecords is not implemented")
+}
+
+// GetNetworkResource mocks GetNetworkResource of the AccountManager interface
+func (am *MockAccountManager) GetNetworkResource(accountID, userID, resourceID string) (*server.NetworkResource, error) {
+	if am.GetNetworkResourceFunc != nil {
+		return am.GetNetworkResourceFunc(accountID, userID, resourceID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetNetworkResource is not implemented")
+}
+
+// CreateNetworkResource mocks CreateNetworkResource of the AccountManager interface
+func (am *MockAccountManager) CreateNetworkResource(accountID, userID string, resourceToCreate *server.NetworkResource) (*server.NetworkResource, error) {
+	if am.CreateNetworkResourceFunc != nil {
+		return am.CreateNetworkResourceFunc(accountID, userID, resourceToCreate)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CreateNetworkResource is not implemented")
+}
+
+// SaveNetworkResource mocks SaveNetworkResource of the AccountManager interface
+func (am *MockAccountManager) SaveNetworkResource(accountID, userID string, resourceToSave *server.NetworkResource) error {
+	if am.SaveNetworkResourceFunc != nil {
+		return am.SaveNetworkResourceFunc(accountID, userID, resourceToSave)
+	}
+	return status.Errorf(codes.Unimplemented, "method SaveNetworkResource is not implemented")
+}
+
+// DeleteNetworkResource mocks DeleteNetworkResource of the AccountManager interface
+func (am *MockAccountManager) DeleteNetworkResource(accountID, resourceID, userID string) error {
+	if am.DeleteNetworkResourceFunc != nil {
+		return am.DeleteNetworkResourceFunc(accountID, resourceID, userID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeleteNetworkResource is not implemented")
+}
+
+// ListNetworkResources mocks ListNetworkResources of the AccountManager interface
+func (am *MockAccountManager) ListNetworkResources(accountID, userID string) ([]*server.NetworkResource, error) {
+	if am.ListNetworkResourcesFunc != nil {
+		return am.ListNetworkResourcesFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListNetworkResources is not implemented")
+}
+
+// GetStaticRoute mocks GetStaticRoute of the AccountManager interface
+func (am *MockAccountManager) GetStaticRoute(accountID, userID, routeID string) (*server.StaticRoute, error) {
+	if am.GetStaticRouteFunc != nil {
+		return am.GetStaticRouteFunc(accountID, userID, routeID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetStaticRoute is not implemented")
+}
+
+// CreateStaticRoute mocks CreateStaticRoute of the AccountManager interface
+func (am *MockAccountManager) CreateStaticRoute(accountID, userID string, routeToCreate *server.StaticRoute) (*server.StaticRoute, error) {
+	if am.CreateStaticRouteFunc != nil {
+		return am.CreateStaticRouteFunc(accountID, userID, routeToCreate)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CreateStaticRoute is not implemented")
+}
+
+// SaveStaticRoute mocks SaveStaticRoute of the AccountManager interface
+func (am *MockAccountManager) SaveStaticRoute(accountID, userID string, routeToSave *server.StaticRoute) error {
+	if am.SaveStaticRouteFunc != nil {
+		return am.SaveStaticRouteFunc(accountID, userID, routeToSave)
+	}
+	return status.Errorf(codes.Unimplemented, "method SaveStaticRoute is not implemented")
+}
+
+// DeleteStaticRoute mocks DeleteStaticRoute of the AccountManager interface
+func (am *MockAccountManager) DeleteStaticRoute(accountID, routeID, userID string) error {
+	if am.DeleteStaticRouteFunc != nil {
+		return am.DeleteStaticRouteFunc(accountID, routeID, userID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeleteStaticRoute is not implemented")
+}
+
+// ListStaticRoutes mocks ListStaticRoutes of the AccountManager interface
+func (am *MockAccountManager) ListStaticRoutes(accountID, userID string) ([]*server.StaticRoute, error) {
+	if am.ListStaticRoutesFunc != nil {
+		return am.ListStaticRoutesFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListStaticRoutes is not implemented")
+}
+
+// ListSyncSessions mocks ListSyncSessions of the AccountManager interface
+func (am *MockAccountManager) ListSyncSessions(accountID, userID string) ([]server.SyncSession, error) {
+	if am.ListSyncSessionsFunc != nil {
+		return am.ListSyncSessionsFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListSyncSessions is not implemented")
+}
+
+// CloseSyncSession mocks CloseSyncSession of the AccountManager interface
+func (am *MockAccountManager) CloseSyncSession(accountID, userID, peerID string) error {
+	if am.CloseSyncSessionFunc != nil {
+		return am.CloseSyncSessionFunc(accountID, userID, peerID)
+	}
+	return status.Errorf(codes.Unimplemented, "method CloseSyncSession is not implemented")
+}
+
+// ListIPReservations mocks ListIPReservations of the AccountManager interface
+func (am *MockAccountManager) ListIPReservations(accountID, userID string) ([]*server.IPReservation, error) {
+	if am.ListIPReservationsFunc != nil {
+		return am.ListIPReservationsFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListIPReservations is not implemented")
+}
+
+// SaveIPReservation mocks SaveIPReservation of the AccountManager interface
+func (am *MockAccountManager) SaveIPReservation(accountID, userID string, reservationToSave *server.IPReservation) (*server.IPReservation, error) {
+	if am.SaveIPReservationFunc != nil {
+		return am.SaveIPReservationFunc(accountID, userID, reservationToSave)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method SaveIPReservation is not implemented")
+}
+
+// DeleteIPReservation mocks DeleteIPReservation of the AccountManager interface
+func (am *MockAccountManager) DeleteIPReservation(accountID, userID, reservationID string) error {
+	if am.DeleteIPReservationFunc != nil {
+		return am.DeleteIPReservationFunc(accountID, userID, reservationID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeleteIPReservation is not implemented")
+}
+
+// ListOrgKeys mocks ListOrgKeys of the AccountManager interface
+func (am *MockAccountManager) ListOrgKeys(accountID, userID string) ([]*server.OrgKey, error) {
+	if am.ListOrgKeysFunc != nil {
+		return am.ListOrgKeysFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrgKeys is not implemented")
+}
+
+// CreateOrgKey mocks CreateOrgKey of the AccountManager interface
+func (am *MockAccountManager) CreateOrgKey(accountID, userID, name string, expiresIn time.Duration, linkedAccounts []string) (*server.OrgKey, error) {
+	if am.CreateOrgKeyFunc != nil {
+		return am.CreateOrgKeyFunc(accountID, userID, name, expiresIn, linkedAccounts)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrgKey is not implemented")
+}
+
+// SaveOrgKey mocks SaveOrgKey of the AccountManager interface
+func (am *MockAccountManager) SaveOrgKey(accountID, userID string, keyToSave *server.OrgKey) (*server.OrgKey, error) {
+	if am.SaveOrgKeyFunc != nil {
+		return am.SaveOrgKeyFunc(accountID, userID, keyToSave)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method SaveOrgKey is not implemented")
+}
+
+// GetPostureCheck mocks GetPostureCheck of the AccountManager interface
+func (am *MockAccountManager) GetPostureCheck(accountID, userID, checkID string) (*server.PostureCheck, error) {
+	if am.GetPostureCheckFunc != nil {
+		return am.GetPostureCheckFunc(accountID, userID, checkID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetPostureCheck is not implemented")
+}
+
+// ListPostureChecks mocks ListPostureChecks of the AccountManager interface
+func (am *MockAccountManager) ListPostureChecks(accountID, userID string) ([]*server.PostureCheck, error) {
+	if am.ListPostureChecksFunc != nil {
+		return am.ListPostureChecksFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method ListPostureChecks is not implemented")
+}
+
+// SavePostureCheck mocks SavePostureCheck of the AccountManager interface
+func (am *MockAccountManager) SavePostureCheck(accountID, userID string, checkToSave *server.PostureCheck) (*server.PostureCheck, error) {
+	if am.SavePostureCheckFunc != nil {
+		return am.SavePostureCheckFunc(accountID, userID, checkToSave)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method SavePostureCheck is not implemented")
+}
+
+// DeletePostureCheck mocks DeletePostureCheck of the AccountManager interface
+func (am *MockAccountManager) DeletePostureCheck(accountID, userID, checkID string) error {
+	if am.DeletePostureCheckFunc != nil {
+		return am.DeletePostureCheckFunc(accountID, userID, checkID)
+	}
+	return status.Errorf(codes.Unimplemented, "method DeletePostureCheck is not implemented")
+}
+
+// GetNonCompliantPeers mocks GetNonCompliantPeers of the AccountManager interface
+func (am *MockAccountManager) GetNonCompliantPeers(accountID, userID string) ([]*server.NonCompliantPeer, error) {
+	if am.GetNonCompliantPeersFunc != nil {
+		return am.GetNonCompliantPeersFunc(accountID, userID)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method GetNonCompliantPeers is not implemented")
+}
+
 // CreateUser mocks CreateUser of the AccountManager interface
 func (am *MockAccountManager) CreateUser(accountID, userID string, invite *server.UserInfo) (*server.UserInfo, error) {
 	if am.CreateUserFunc != nil {
@@ -584,6 +1029,14 @@ func (am *MockAccountManager) GetEvents(accountID, userID string) ([]*activity.E
 	return nil, status.Errorf(codes.Unimplemented, "method GetEvents is not implemented")
 }
 
+// SubscribeToEvents mocks SubscribeToEvents of the AccountManager interface
+func (am *MockAccountManager) SubscribeToEvents(accountID, userID, resumeToken string) (*server.EventSubscription, error) {
+	if am.SubscribeToEventsFunc != nil {
+		return am.SubscribeToEventsFunc(accountID, userID, resumeToken)
+	}
+	return nil, status.Errorf(codes.Unimplemented, "method SubscribeToEvents is not implemented")
+}
+
 // GetDNSSettings mocks GetDNSSettings of the AccountManager interface
 func (am *MockAccountManager) GetDNSSettings(accountID string, userID string) (*server.DNSSettings, error) {
 	if am.GetDNSSettingsFunc != nil {
@@ -31,8 +31,8 @@ func (am *DefaultAccountManager) GetNameServerGroup(accountID, userID, nsGroupID
 		return nil, err
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
-		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view nameserver groups")
+	if !user.HasPermission(ResourceDNS, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view nameserver groups")
 	}
 
 	nsGroup, found := account.NameServerGroups[nsGroupID]
@@ -171,8 +171,8 @@ func (am *DefaultAccountManager) ListNameServerGroups(accountID string, userID s
 		return nil, err
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
-		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view name server groups")
+	if !user.HasPermission(ResourceDNS, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view name server groups")
 	}
 
 	nsGroups := make([]*nbdns.NameServerGroup, 0, len(account.NameServerGroups))
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"fmt"
 	"math/rand"
 	"net"
 	"sync"
@@ -23,21 +24,48 @@ const (
 
 	// AllowedIPsFormat generates Wireguard AllowedIPs format (e.g. 100.64.30.1/32)
 	AllowedIPsFormat = "%s/32"
+
+	// SubnetV6Size is a size of the IPv6 subnet of the network taken from the configured ULA prefix, e.g. fd00:1234:5678:9abc::/64
+	SubnetV6Size = 64
+	// AllowedIPsV6Format generates Wireguard AllowedIPs format for IPv6 (e.g. fd00:1234:5678:9abc::1/128)
+	AllowedIPsV6Format = "%s/128"
+
+	// maxIPv6AllocationAttempts is the number of random interface IDs tried before giving up on an IPv6 allocation
+	maxIPv6AllocationAttempts = 100
+
+	// PeerIPAllocationRandom allocates a random free IP of the account network to the new peers, the default
+	PeerIPAllocationRandom = "random"
+	// PeerIPAllocationSequential allocates the lowest free IP of the account network to the new peers
+	PeerIPAllocationSequential = "sequential"
 )
 
+// uniqueLocalRange is the IPv6 unique local address range (RFC 4193)
+var uniqueLocalRange = &net.IPNet{IP: net.ParseIP("fc00::"), Mask: net.CIDRMask(7, 128)}
+
 type NetworkMap struct {
-	Peers         []*nbpeer.Peer
-	Network       *Network
-	Routes        []*route.Route
+	Peers   []*nbpeer.Peer
+	Network *Network
+	Routes  []*route.Route
+	// StaticRoutes are the static routes the peer installs or routes for the other peers
+	StaticRoutes  []*route.Route
 	DNSConfig     nbdns.Config
 	OfflinePeers  []*nbpeer.Peer
 	FirewallRules []*FirewallRule
+	// Signal is the signal server the account pins its peers to, nil for the signal server of the management config
+	Signal *Host
+	// ConnectionTimeout is the timeout of the connection attempts of the peer to its remote peers, zero for the
+	// default of the client
+	ConnectionTimeout time.Duration
+	// TunnelSettings are the tunnel settings of the peer and of its peers resolved from their groups by peer ID
+	TunnelSettings map[string]GroupTunnelSettings
 }
 
 type Network struct {
 	Identifier string    `json:"id"`
 	Net        net.IPNet `gorm:"serializer:gob"`
-	Dns        string
+	// NetV6 is the IPv6 subnet of the network, it is empty when the IPv6 allocation is disabled
+	NetV6 net.IPNet `gorm:"serializer:gob"`
+	Dns   string
 	// Serial is an ID that increments by 1 when any change to the network happened (e.g. new peer has been added).
 	// Used to synchronize state to the client apps.
 	Serial uint64
@@ -63,6 +91,26 @@ func NewNetwork() *Network {
 		Serial:     0}
 }
 
+// NewNetworkV6 takes a random /64 subnet from the given IPv6 prefix
+func NewNetworkV6(prefix *net.IPNet) net.IPNet {
+	ones, _ := prefix.Mask.Size()
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, prefix.IP.To16())
+
+	s := rand.NewSource(time.Now().UnixNano())
+	r := rand.New(s)
+	for bit := ones; bit < SubnetV6Size; bit++ {
+		mask := byte(1 << (7 - bit%8))
+		if r.Intn(2) == 1 {
+			ip[bit/8] |= mask
+		} else {
+			ip[bit/8] &^= mask
+		}
+	}
+
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(SubnetV6Size, 128)}
+}
+
 // IncSerial increments Serial by 1 reflecting that the network state has been changed
 func (n *Network) IncSerial() {
 	n.mu.Lock()
@@ -81,15 +129,57 @@ func (n *Network) Copy() *Network {
 	return &Network{
 		Identifier: n.Identifier,
 		Net:        n.Net,
+		NetV6:      n.NetV6,
 		Dns:        n.Dns,
 		Serial:     n.Serial,
 	}
 }
 
-// AllocatePeerIP pics an available IP from an net.IPNet.
+// AllocatePeerIP pics a random available IP from an net.IPNet.
 // This method considers already taken IPs and reuses IPs if there are gaps in takenIps
 // E.g. if ipNet=100.30.0.0/16 and takenIps=[100.30.0.1, 100.30.0.4] then the result would be 100.30.0.2 or 100.30.0.3
 func AllocatePeerIP(ipNet net.IPNet, takenIps []net.IP) (net.IP, error) {
+	ips, err := availablePeerIPs(ipNet, takenIps)
+	if err != nil {
+		return nil, err
+	}
+
+	// the global source is seeded randomly, a source seeded with the time would pick the same index
+	// for the allocations of the same second and hand out the IPs in order
+	return ips[rand.Intn(len(ips))], nil
+}
+
+// AllocatePeerIPSequential picks the lowest available IP from an net.IPNet, filling the gaps in takenIps first.
+// E.g. if ipNet=100.30.0.0/16 and takenIps=[100.30.0.1, 100.30.0.4] then the result would be 100.30.0.2
+func AllocatePeerIPSequential(ipNet net.IPNet, takenIps []net.IP) (net.IP, error) {
+	ips, err := availablePeerIPs(ipNet, takenIps)
+	if err != nil {
+		return nil, err
+	}
+
+	return ips[0], nil
+}
+
+// allocatePeerIPWithStrategy picks an available IP from an net.IPNet with the PeerIPAllocation strategy of the account
+func allocatePeerIPWithStrategy(strategy string, ipNet net.IPNet, takenIps []net.IP) (net.IP, error) {
+	if strategy == PeerIPAllocationSequential {
+		return AllocatePeerIPSequential(ipNet, takenIps)
+	}
+	return AllocatePeerIP(ipNet, takenIps)
+}
+
+func validatePeerIPAllocationSettings(settings *Settings) error {
+	switch settings.PeerIPAllocation {
+	case "", PeerIPAllocationRandom, PeerIPAllocationSequential:
+		return nil
+	default:
+		return status.Errorf(status.InvalidArgument, "invalid peer IP allocation %q, expected %s or %s",
+			settings.PeerIPAllocation, PeerIPAllocationRandom, PeerIPAllocationSequential)
+	}
+}
+
+// availablePeerIPs returns the usable IPs of the ipNet which aren't in takenIps in ascending order
+func availablePeerIPs(ipNet net.IPNet, takenIps []net.IP) ([]net.IP, error) {
 	takenIPMap := make(map[string]struct{})
 	takenIPMap[ipNet.IP.String()] = struct{}{}
 	for _, ip := range takenIps {
@@ -102,12 +192,37 @@ func AllocatePeerIP(ipNet net.IPNet, takenIps []net.IP) (net.IP, error) {
 		return nil, status.Errorf(status.PreconditionFailed, "failed allocating new IP for the ipNet %s - network is out of IPs", ipNet.String())
 	}
 
-	// pick a random IP
-	s := rand.NewSource(time.Now().Unix())
+	return ips, nil
+}
+
+// AllocatePeerIPv6 picks a random IPv6 address from the /64 ipNet which isn't in takenIps
+func AllocatePeerIPv6(ipNet net.IPNet, takenIps []net.IP) (net.IP, error) {
+	takenIPMap := make(map[string]struct{})
+	for _, ip := range takenIps {
+		takenIPMap[ip.String()] = struct{}{}
+	}
+
+	s := rand.NewSource(time.Now().UnixNano())
 	r := rand.New(s)
-	intn := r.Intn(len(ips))
+	ones, _ := ipNet.Mask.Size()
+	for i := 0; i < maxIPv6AllocationAttempts; i++ {
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, ipNet.IP.To16())
+		for b := ones / 8; b < net.IPv6len; b++ {
+			ip[b] = byte(r.Intn(256))
+		}
 
-	return ips[intn], nil
+		// skip the subnet-router anycast address
+		if ip.Equal(ipNet.IP) {
+			continue
+		}
+		if _, ok := takenIPMap[ip.String()]; ok {
+			continue
+		}
+		return ip, nil
+	}
+
+	return nil, status.Errorf(status.PreconditionFailed, "failed allocating new IPv6 for the ipNet %s", ipNet.String())
 }
 
 // generateIPs generates a list of all possible IPs of the given network excluding IPs specified in the exclusion list
@@ -146,3 +261,44 @@ func incIP(ip net.IP) {
 		}
 	}
 }
+
+// SetIPv6Prefix enables the IPv6 allocation of the peers from the given unique local prefix, e.g. fd00:1234::/48.
+// Each account network takes a random /64 subnet of the prefix once and keeps it even if the prefix changes later.
+func (am *DefaultAccountManager) SetIPv6Prefix(prefix string) error {
+	_, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return fmt.Errorf("parse IPv6 prefix: %w", err)
+	}
+
+	ones, bits := ipNet.Mask.Size()
+	if bits != 8*net.IPv6len || !uniqueLocalRange.Contains(ipNet.IP) {
+		return fmt.Errorf("IPv6 prefix %s should be a unique local address prefix within %s", prefix, uniqueLocalRange)
+	}
+
+	if ones > SubnetV6Size {
+		return fmt.Errorf("IPv6 prefix %s should not be longer than /%d", prefix, SubnetV6Size)
+	}
+
+	am.ipv6Prefix = ipNet
+	return nil
+}
+
+// assignPeerIPv6 allocates an IPv6 address to the peer if the IPv6 allocation is enabled and the peer has none yet.
+// The account network gets its IPv6 subnet with the first allocation. It returns true if the peer has been updated.
+func (am *DefaultAccountManager) assignPeerIPv6(account *Account, peer *nbpeer.Peer) (bool, error) {
+	if am.ipv6Prefix == nil || peer.IPv6 != nil {
+		return false, nil
+	}
+
+	if account.Network.NetV6.IP == nil {
+		account.Network.NetV6 = NewNetworkV6(am.ipv6Prefix)
+	}
+
+	ip, err := AllocatePeerIPv6(account.Network.NetV6, account.getTakenIPv6s())
+	if err != nil {
+		return false, err
+	}
+
+	peer.IPv6 = ip
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/encryption"
+	"github.com/netbirdio/netbird/management/proto"
+)
+
+// networkMapSigningKeyFile is the file of the data directory keeping the seed of the key the network maps are signed with
+const networkMapSigningKeyFile = "network_map_signing.key"
+
+// loadNetworkMapSigningKey returns the long-term key the network maps are signed with. Unlike the WireGuard key of the
+// server it isn't rotated, it is generated on the first start and kept in the data directory. Without a data directory
+// a new key is generated on every start.
+func loadNetworkMapSigningKey(datadir string) (ed25519.PrivateKey, error) {
+	if datadir == "" {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+
+	path := filepath.Join(datadir, networkMapSigningKeyFile)
+	content, err := os.ReadFile(path)
+	if err == nil {
+		seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid network map signing key in %s", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read the network map signing key: %w", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key.Seed())), 0600); err != nil {
+		return nil, fmt.Errorf("write the network map signing key: %w", err)
+	}
+	log.Infof("generated a network map signing key in %s", path)
+
+	return key, nil
+}
+
+// networkMapVerifyKey returns the public key the peers verify the network maps with, nil when they aren't signed
+func (s *GRPCServer) networkMapVerifyKey() []byte {
+	if s.networkMapSigningKey == nil {
+		return nil
+	}
+	return s.networkMapSigningKey.Public().(ed25519.PublicKey)
+}
+
+// signNetworkMap sets the signature of the network map of the response sent to the peer, the responses without
+// a network map aren't signed
+func (s *GRPCServer) signNetworkMap(peerKey wgtypes.Key, resp *proto.SyncResponse) error {
+	networkMap := resp.GetNetworkMap()
+	if networkMap == nil || s.networkMapSigningKey == nil {
+		return nil
+	}
+
+	signature, err := encryption.SignNetworkMap(s.networkMapSigningKey, peerKey, networkMap.GetSerial(), networkMap)
+	if err != nil {
+		return err
+	}
+	resp.NetworkMapSignature = signature
+	return nil
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/encryption"
+	"github.com/netbirdio/netbird/management/proto"
+)
+
+func TestLoadNetworkMapSigningKey(t *testing.T) {
+	datadir := t.TempDir()
+
+	key, err := loadNetworkMapSigningKey(datadir)
+	require.NoError(t, err)
+
+	info, err := os.Stat(filepath.Join(datadir, networkMapSigningKeyFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the key file should be readable by the owner only")
+
+	loaded, err := loadNetworkMapSigningKey(datadir)
+	require.NoError(t, err)
+	assert.True(t, key.Equal(loaded), "the key should be kept between the restarts")
+
+	err = os.WriteFile(filepath.Join(datadir, networkMapSigningKeyFile), []byte("invalid"), 0600)
+	require.NoError(t, err)
+	_, err = loadNetworkMapSigningKey(datadir)
+	assert.Error(t, err, "an invalid key file shouldn't be replaced")
+
+	ephemeral, err := loadNetworkMapSigningKey("")
+	require.NoError(t, err)
+	assert.Len(t, ephemeral, ed25519.PrivateKeySize)
+}
+
+func TestGRPCServer_signNetworkMap(t *testing.T) {
+	peerKey, err := wgtypes.GenerateKey()
+	require.NoError(t, err)
+	signingKey, err := loadNetworkMapSigningKey("")
+	require.NoError(t, err)
+
+	s := &GRPCServer{networkMapSigningKey: signingKey}
+
+	resp := &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: 3, RemotePeersIsEmpty: true}}
+	require.NoError(t, s.signNetworkMap(peerKey.PublicKey(), resp))
+
+	verifyKey := ed25519.PublicKey(s.networkMapVerifyKey())
+	err = encryption.VerifyNetworkMap(verifyKey, peerKey.PublicKey(), 3, resp.GetNetworkMap(), resp.GetNetworkMapSignature())
+	assert.NoError(t, err)
+
+	withoutMap := &proto.SyncResponse{WiretrusteeConfig: &proto.WiretrusteeConfig{}}
+	require.NoError(t, s.signNetworkMap(peerKey.PublicKey(), withoutMap))
+	assert.Empty(t, withoutMap.GetNetworkMapSignature(), "the responses without a network map shouldn't be signed")
+
+	unsigned := &GRPCServer{}
+	assert.Nil(t, unsigned.networkMapVerifyKey())
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"net"
+	"net/netip"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// MinNetworkRangeSize is the prefix length of the largest network range an account can use, e.g. 100.64.0.0/14
+	MinNetworkRangeSize = 14
+	// MaxNetworkRangeSize is the prefix length of the smallest network range an account can use, e.g. 100.64.0.0/29
+	MaxNetworkRangeSize = 29
+)
+
+// GetNetwork returns the network of the account
+func (am *DefaultAccountManager) GetNetwork(accountID, userID string) (*Network, error) {
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceAccounts, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view the network")
+	}
+
+	return account.Network.Copy(), nil
+}
+
+// UpdateNetworkRange changes the network range of the account and re-allocates the IPs of the peers that are outside the new range.
+// The update is rejected when the existing peers don't fit in the new range.
+func (am *DefaultAccountManager) UpdateNetworkRange(accountID, userID, networkRange string) (*Network, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceAccounts, OperationWrite) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to update the network")
+	}
+
+	newNet, err := parseNetworkRange(networkRange)
+	if err != nil {
+		return nil, err
+	}
+
+	oldNet := account.Network.Net
+	if oldNet.String() == newNet.String() {
+		return account.Network.Copy(), nil
+	}
+
+	err = validateNetworkRange(account, newNet)
+	if err != nil {
+		return nil, err
+	}
+
+	usableIPs, _ := generateIPs(&newNet, map[string]struct{}{newNet.IP.String(): {}})
+	if len(usableIPs) < len(account.Peers) {
+		return nil, status.Errorf(status.PreconditionFailed, "network range %s has %d available IPs which is not enough for %d peers",
+			newNet.String(), len(usableIPs), len(account.Peers))
+	}
+
+	usable := make(map[string]struct{}, len(usableIPs))
+	for _, ip := range usableIPs {
+		usable[ip.String()] = struct{}{}
+	}
+
+	// reserved IPs have to stay usable, they are never allocated to the peers the IPs aren't reserved for
+	takenIPs := account.getReservedIPs()
+	for _, reservation := range account.IPReservations {
+		if _, ok := usable[reservation.IP.String()]; !ok {
+			return nil, status.Errorf(status.PreconditionFailed, "IP %s reserved for peer key %s isn't in network range %s, "+
+				"delete the reservation first", reservation.IP, reservation.PeerKey, newNet.String())
+		}
+	}
+
+	// peers which already have a usable IP from the new range keep it, so only the other peers are re-allocated
+	var peersToUpdate []string
+	for id, peer := range account.Peers {
+		if _, ok := usable[peer.IP.String()]; ok {
+			takenIPs = append(takenIPs, peer.IP)
+			continue
+		}
+		peersToUpdate = append(peersToUpdate, id)
+	}
+
+	for _, id := range peersToUpdate {
+		peer := account.Peers[id]
+		var newIP net.IP
+		if reservation := account.getIPReservationByPeerKey(peer.Key); reservation != nil {
+			newIP = copyIP(reservation.IP)
+		} else {
+			newIP, err = allocatePeerIPWithStrategy(account.Settings.PeerIPAllocation, newNet, takenIPs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		log.Debugf("re-allocated IP of peer %s from %s to %s", peer.ID, peer.IP, newIP)
+		peer.IP = newIP
+		takenIPs = append(takenIPs, newIP)
+		// the subnets of the dynamic groups are matched against the new IP
+		account.refreshDynamicGroups(peer)
+	}
+
+	account.Network.Net = newNet
+	account.Network.IncSerial()
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.updateAccountPeers(account)
+
+	meta := map[string]any{"old_range": oldNet.String(), "new_range": newNet.String()}
+	am.StoreEvent(userID, accountID, accountID, activity.AccountNetworkRangeUpdated, meta)
+
+	return account.Network.Copy(), nil
+}
+
+func parseNetworkRange(networkRange string) (net.IPNet, error) {
+	prefix, err := netip.ParsePrefix(networkRange)
+	if err != nil || !prefix.Addr().Is4() {
+		return net.IPNet{}, status.Errorf(status.InvalidArgument, "invalid network range %s, expecting an IPv4 CIDR", networkRange)
+	}
+
+	if prefix.Bits() < MinNetworkRangeSize || prefix.Bits() > MaxNetworkRangeSize {
+		return net.IPNet{}, status.Errorf(status.InvalidArgument, "network range prefix length should be between /%d and /%d",
+			MinNetworkRangeSize, MaxNetworkRangeSize)
+	}
+
+	prefix = prefix.Masked()
+	return net.IPNet{
+		IP:   prefix.Addr().AsSlice(),
+		Mask: net.CIDRMask(prefix.Bits(), 32),
+	}, nil
+}
+
+// validateNetworkRange checks that the new network range doesn't overlap with the routes of the account.
+// The default routes of the exit nodes are skipped as they cover any range.
+func validateNetworkRange(account *Account, newNet net.IPNet) error {
+	newPrefix, err := netip.ParsePrefix(newNet.String())
+	if err != nil {
+		return status.Errorf(status.InvalidArgument, "invalid network range %s", newNet.String())
+	}
+
+	for _, r := range account.Routes {
+		if r.Network.Bits() > 0 && r.Network.Overlaps(newPrefix) {
+			return status.Errorf(status.InvalidArgument, "network range %s overlaps with the route %s network %s",
+				newNet.String(), r.NetID, r.Network.String())
+		}
+	}
+
+	for _, r := range account.StaticRoutes {
+		if r.Network.Overlaps(newPrefix) {
+			return status.Errorf(status.InvalidArgument, "network range %s overlaps with the static route %s network %s",
+				newNet.String(), r.ID, r.Network.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_UpdateNetworkRange(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	var peers []*nbpeer.Peer
+	for _, hostname := range []string{"peer1", "peer2", "peer3", "peer4", "peer5"} {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		peers = append(peers, peer)
+	}
+
+	network, err := manager.GetNetwork(account.Id, userID)
+	require.NoError(t, err)
+	assert.Equal(t, account.Network.Net.String(), network.Net.String())
+
+	t.Run("invalid range", func(t *testing.T) {
+		for _, networkRange := range []string{"invalid", "fd00::/64", "10.0.0.0/8", "10.0.0.0/30"} {
+			_, err := manager.UpdateNetworkRange(account.Id, userID, networkRange)
+			sErr, ok := status.FromError(err)
+			require.True(t, ok, networkRange)
+			assert.Equal(t, status.InvalidArgument, sErr.Type(), networkRange)
+		}
+	})
+
+	t.Run("range smaller than the peers count", func(t *testing.T) {
+		_, err := manager.UpdateNetworkRange(account.Id, userID, "10.10.0.0/29")
+		sErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, status.PreconditionFailed, sErr.Type())
+
+		network, err := manager.GetNetwork(account.Id, userID)
+		require.NoError(t, err)
+		assert.Equal(t, account.Network.Net.String(), network.Net.String(), "network should not change")
+	})
+
+	t.Run("reserved IP outside the new range", func(t *testing.T) {
+		reservation, err := manager.SaveIPReservation(account.Id, userID, &IPReservation{PeerKey: peers[2].Key, IP: peers[2].IP})
+		require.NoError(t, err)
+
+		_, err = manager.UpdateNetworkRange(account.Id, userID, "10.10.0.0/24")
+		sErr, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, status.PreconditionFailed, sErr.Type())
+
+		require.NoError(t, manager.DeleteIPReservation(account.Id, userID, reservation.ID))
+	})
+
+	t.Run("new range", func(t *testing.T) {
+		// the default route of an exit node covers any range and shouldn't block the change
+		groupAll, err := account.GetGroupAll()
+		require.NoError(t, err)
+		_, err = manager.CreateRoute(account.Id, "0.0.0.0/0", peers[1].ID, nil, "", "exit-node", false, 9999, []string{groupAll.ID}, true, userID)
+		require.NoError(t, err)
+
+		err = manager.SaveGroup(account.Id, userID, &Group{
+			ID:          "new-range",
+			Name:        "New Range",
+			Issued:      GroupIssuedAPI,
+			DynamicRule: &DynamicGroupRule{Subnets: []string{"10.10.0.0/24"}},
+		})
+		require.NoError(t, err)
+
+		updates := manager.peersUpdateManager.CreateChannel(peers[0].ID)
+		t.Cleanup(func() {
+			manager.peersUpdateManager.CloseChannel(peers[0].ID)
+		})
+
+		network, err := manager.UpdateNetworkRange(account.Id, userID, "10.10.0.0/24")
+		require.NoError(t, err)
+		assert.Equal(t, "10.10.0.0/24", network.Net.String())
+		assert.Greater(t, network.CurrentSerial(), account.Network.CurrentSerial())
+
+		_, newNet, _ := net.ParseCIDR("10.10.0.0/24")
+		taken := make(map[string]struct{})
+		for _, p := range peers {
+			peer, err := manager.GetPeer(account.Id, p.ID, userID)
+			require.NoError(t, err)
+			assert.True(t, newNet.Contains(peer.IP), "peer IP %s should be in the new range", peer.IP)
+			_, ok := taken[peer.IP.String()]
+			assert.False(t, ok, "peer IP %s should be unique", peer.IP)
+			taken[peer.IP.String()] = struct{}{}
+		}
+
+		group, err := manager.GetGroup(account.Id, "new-range")
+		require.NoError(t, err)
+		assert.Len(t, group.Peers, len(peers), "the re-addressed peers should join the dynamic group of the new range")
+
+		select {
+		case update := <-updates:
+			peer, err := manager.GetPeer(account.Id, peers[0].ID, userID)
+			require.NoError(t, err)
+			assert.Equal(t, peer.IP.String()+"/24", update.Update.GetNetworkMap().GetPeerConfig().GetAddress())
+		case <-time.After(time.Second):
+			t.Fatal("expecting a network map update after the network range change")
+		}
+	})
+}
@@ -0,0 +1,395 @@
+package server
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/rs/xid"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/route"
+)
+
+// NetworkResource is a named network of the account, e.g. "prod-db" for 10.0.5.0/24, the policy rules grant access to.
+// The routes of networks within the resource are distributed only to the peers permitted by the policies.
+type NetworkResource struct {
+	// ID of the resource
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `json:"-" gorm:"index"`
+	// Name of the resource, unique in the account
+	Name string
+	// Description of the resource visible in the UI
+	Description string
+	// Prefix is the network of the resource
+	Prefix netip.Prefix `gorm:"serializer:gob"`
+}
+
+// Copy returns a copy of the network resource
+func (r *NetworkResource) Copy() *NetworkResource {
+	return &NetworkResource{
+		ID:          r.ID,
+		AccountID:   r.AccountID,
+		Name:        r.Name,
+		Description: r.Description,
+		Prefix:      r.Prefix,
+	}
+}
+
+// EventMeta returns activity event meta related to the network resource
+func (r *NetworkResource) EventMeta() map[string]any {
+	return map[string]any{"name": r.Name, "prefix": r.Prefix.String()}
+}
+
+// governs returns true if the routed network is within the resource
+func (r *NetworkResource) governs(network netip.Prefix) bool {
+	return r.Prefix.Bits() <= network.Bits() && r.Prefix.Contains(network.Addr())
+}
+
+// getRouteResources returns the IDs of the resources governing the route
+func (a *Account) getRouteResources(r *route.Route) []string {
+	var resources []string
+	for id, resource := range a.NetworkResources {
+		if resource.governs(r.Network) {
+			resources = append(resources, id)
+		}
+	}
+	return resources
+}
+
+// getPeerPermittedResources returns the resources the peer is granted access to. The peer is granted access by the
+// enabled and active accept rules it is a source of, unless a drop rule it is a source of denies it.
+func (a *Account) getPeerPermittedResources(peerID string) lookupMap {
+	permitted := make(lookupMap)
+	denied := make(lookupMap)
+	now := timeNow()
+	for _, policy := range a.Policies {
+		if !policy.Enabled {
+			continue
+		}
+
+		for _, rule := range policy.Rules {
+			if !rule.Enabled || len(rule.DestinationResources) == 0 || !rule.Schedule.isActive(now) {
+				continue
+			}
+
+			if _, peerInSources := getAllPeersFromGroups(a, rule.Sources, peerID); !peerInSources {
+				continue
+			}
+
+			for _, resourceID := range rule.DestinationResources {
+				if rule.Action == PolicyTrafficActionDrop {
+					denied[resourceID] = struct{}{}
+					continue
+				}
+				permitted[resourceID] = struct{}{}
+			}
+		}
+	}
+
+	for resourceID := range denied {
+		delete(permitted, resourceID)
+	}
+	return permitted
+}
+
+// filterRoutesByResources returns the routes the peer is permitted to receive. The routes not governed by any
+// resource are kept, the others require access to one of their resources.
+func (a *Account) filterRoutesByResources(routes []*route.Route, permitted lookupMap) []*route.Route {
+	if len(a.NetworkResources) == 0 {
+		return routes
+	}
+
+	var filteredRoutes []*route.Route
+	for _, r := range routes {
+		resources := a.getRouteResources(r)
+		if len(resources) == 0 {
+			filteredRoutes = append(filteredRoutes, r)
+			continue
+		}
+		for _, resourceID := range resources {
+			if _, ok := permitted[resourceID]; ok {
+				filteredRoutes = append(filteredRoutes, r)
+				break
+			}
+		}
+	}
+	return filteredRoutes
+}
+
+// getResourcesRoutingPeers returns the routing peers of the enabled routes governed by the resources, skipping the
+// given peers, and a boolean indicating if the peer with the given ID routes one of the resources
+func (a *Account) getResourcesRoutingPeers(resourceIDs []string, peerID string, skip []*nbpeer.Peer) ([]*nbpeer.Peer, bool) {
+	seen := make(lookupMap, len(skip))
+	for _, peer := range skip {
+		seen[peer.ID] = struct{}{}
+	}
+
+	var routingPeers []*nbpeer.Peer
+	peerRoutesResource := false
+	addPeer := func(id string) {
+		peer, ok := a.Peers[id]
+		// currently we support only linux routing peers
+		if !ok || peer == nil || peer.Meta.GoOS != "linux" {
+			return
+		}
+		if id == peerID {
+			peerRoutesResource = true
+			return
+		}
+		if _, ok := seen[id]; ok || peer.IsQuarantined() {
+			return
+		}
+		seen[id] = struct{}{}
+		routingPeers = append(routingPeers, peer)
+	}
+
+	for _, resourceID := range resourceIDs {
+		resource, ok := a.NetworkResources[resourceID]
+		if !ok {
+			continue
+		}
+		for _, r := range a.Routes {
+			if !r.Enabled || !resource.governs(r.Network) {
+				continue
+			}
+			if r.Peer != "" {
+				addPeer(r.Peer)
+			}
+			for _, groupID := range r.PeerGroups {
+				group := a.GetGroup(groupID)
+				if group == nil {
+					continue
+				}
+				for _, id := range group.Peers {
+					addPeer(id)
+				}
+			}
+		}
+	}
+	return routingPeers, peerRoutesResource
+}
+
+// GetNetworkResource gets a network resource object from account and resource IDs
+func (am *DefaultAccountManager) GetNetworkResource(accountID, userID, resourceID string) (*NetworkResource, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceRoutes, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view network resources")
+	}
+
+	resource, found := account.NetworkResources[resourceID]
+	if !found {
+		return nil, status.Errorf(status.NotFound, "network resource with ID %s not found", resourceID)
+	}
+
+	return resource.Copy(), nil
+}
+
+// ListNetworkResources returns a list of network resources from account
+func (am *DefaultAccountManager) ListNetworkResources(accountID, userID string) ([]*NetworkResource, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceRoutes, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view network resources")
+	}
+
+	resources := make([]*NetworkResource, 0, len(account.NetworkResources))
+	for _, resource := range account.NetworkResources {
+		resources = append(resources, resource.Copy())
+	}
+
+	return resources, nil
+}
+
+// CreateNetworkResource creates and saves a new network resource
+func (am *DefaultAccountManager) CreateNetworkResource(accountID, userID string, resourceToCreate *NetworkResource) (*NetworkResource, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	if resourceToCreate == nil {
+		return nil, status.Errorf(status.InvalidArgument, "network resource provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkPermission(account, userID, ResourceRoutes, OperationWrite); err != nil {
+		return nil, err
+	}
+
+	newResource := resourceToCreate.Copy()
+	newResource.ID = xid.New().String()
+	newResource.AccountID = accountID
+
+	if err = validateNetworkResource(false, newResource, account); err != nil {
+		return nil, err
+	}
+
+	if account.NetworkResources == nil {
+		account.NetworkResources = make(map[string]*NetworkResource)
+	}
+	account.NetworkResources[newResource.ID] = newResource
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	// the routes within the new resource are withdrawn from the peers not permitted yet
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, newResource.ID, accountID, activity.NetworkResourceCreated, newResource.EventMeta())
+
+	return newResource.Copy(), nil
+}
+
+// SaveNetworkResource saves a network resource
+func (am *DefaultAccountManager) SaveNetworkResource(accountID, userID string, resourceToSave *NetworkResource) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	if resourceToSave == nil {
+		return status.Errorf(status.InvalidArgument, "network resource provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkPermission(account, userID, ResourceRoutes, OperationWrite); err != nil {
+		return err
+	}
+
+	resource := resourceToSave.Copy()
+	resource.AccountID = accountID
+
+	if err = validateNetworkResource(true, resource, account); err != nil {
+		return err
+	}
+
+	account.NetworkResources[resource.ID] = resource
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, resource.ID, accountID, activity.NetworkResourceUpdated, resource.EventMeta())
+
+	return nil
+}
+
+// DeleteNetworkResource deletes a network resource with resourceID. The resources referenced by policies can't be deleted.
+func (am *DefaultAccountManager) DeleteNetworkResource(accountID, resourceID, userID string) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkPermission(account, userID, ResourceRoutes, OperationWrite); err != nil {
+		return err
+	}
+
+	resource := account.NetworkResources[resourceID]
+	if resource == nil {
+		return status.Errorf(status.NotFound, "network resource %s wasn't found", resourceID)
+	}
+
+	for _, policy := range account.Policies {
+		for _, rule := range policy.Rules {
+			for _, id := range rule.DestinationResources {
+				if id == resourceID {
+					return status.Errorf(status.PreconditionFailed, "network resource %s is used by policy %s", resource.Name, policy.Name)
+				}
+			}
+		}
+	}
+
+	delete(account.NetworkResources, resourceID)
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, resource.ID, accountID, activity.NetworkResourceDeleted, resource.EventMeta())
+
+	return nil
+}
+
+// validateNetworkResource validates the resource and normalizes its prefix
+func validateNetworkResource(existingResource bool, resource *NetworkResource, account *Account) error {
+	if existingResource {
+		if _, found := account.NetworkResources[resource.ID]; !found {
+			return status.Errorf(status.NotFound, "network resource with ID %s was not found", resource.ID)
+		}
+	}
+
+	resource.Name = strings.TrimSpace(resource.Name)
+	if resource.Name == "" {
+		return status.Errorf(status.InvalidArgument, "network resource name should not be empty")
+	}
+
+	if !resource.Prefix.IsValid() {
+		return status.Errorf(status.InvalidArgument, "network resource prefix should be a valid network range")
+	}
+	resource.Prefix = resource.Prefix.Masked()
+
+	for _, other := range account.NetworkResources {
+		if other.ID != resource.ID && other.Name == resource.Name {
+			return status.Errorf(status.AlreadyExists, "network resource with name %s already exists", resource.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateDestinationResources checks the network resources referenced by the policy rules exist
+func validateDestinationResources(policy *Policy, account *Account) error {
+	for _, rule := range policy.Rules {
+		for _, resourceID := range rule.DestinationResources {
+			if _, ok := account.NetworkResources[resourceID]; !ok {
+				return status.Errorf(status.InvalidArgument, "network resource %s of rule %s doesn't exist", resourceID, rule.Name)
+			}
+		}
+	}
+	return nil
+}
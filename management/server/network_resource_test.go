@@ -0,0 +1,221 @@
+package server
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/route"
+)
+
+func TestCreateNetworkResource(t *testing.T) {
+	am, err := createRouterManager(t)
+	require.NoError(t, err)
+
+	account, err := initTestRouteAccount(t, am)
+	require.NoError(t, err)
+
+	resource, err := am.CreateNetworkResource(account.Id, userID, &NetworkResource{
+		Name:   " prod-db ",
+		Prefix: netip.MustParsePrefix("10.0.5.1/24"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "prod-db", resource.Name)
+	assert.Equal(t, netip.MustParsePrefix("10.0.5.0/24"), resource.Prefix, "prefix should be masked")
+
+	stored, err := am.GetNetworkResource(account.Id, userID, resource.ID)
+	require.NoError(t, err)
+	assert.Equal(t, resource, stored)
+
+	testCases := []struct {
+		name      string
+		resource  *NetworkResource
+		errorType status.Type
+	}{
+		{
+			name:      "duplicated name",
+			resource:  &NetworkResource{Name: "prod-db", Prefix: netip.MustParsePrefix("10.0.6.0/24")},
+			errorType: status.AlreadyExists,
+		},
+		{
+			name:      "empty name",
+			resource:  &NetworkResource{Prefix: netip.MustParsePrefix("10.0.6.0/24")},
+			errorType: status.InvalidArgument,
+		},
+		{
+			name:      "invalid prefix",
+			resource:  &NetworkResource{Name: "staging"},
+			errorType: status.InvalidArgument,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := am.CreateNetworkResource(account.Id, userID, testCase.resource)
+			sErr, ok := status.FromError(err)
+			require.True(t, ok, "expected a status error, got %v", err)
+			assert.Equal(t, testCase.errorType, sErr.Type())
+		})
+	}
+}
+
+func TestDeleteNetworkResource_UsedByPolicy(t *testing.T) {
+	am, err := createRouterManager(t)
+	require.NoError(t, err)
+
+	account, err := initTestRouteAccount(t, am)
+	require.NoError(t, err)
+
+	resource, err := am.CreateNetworkResource(account.Id, userID, &NetworkResource{
+		Name:   "prod-db",
+		Prefix: netip.MustParsePrefix("10.0.5.0/24"),
+	})
+	require.NoError(t, err)
+
+	policy := &Policy{
+		ID:      xid.New().String(),
+		Name:    "prod-db access",
+		Enabled: true,
+		Rules: []*PolicyRule{{
+			ID:                   xid.New().String(),
+			Name:                 "prod-db access",
+			Enabled:              true,
+			Action:               PolicyTrafficActionAccept,
+			Protocol:             PolicyRuleProtocolALL,
+			Sources:              []string{routeGroup2},
+			DestinationResources: []string{"unknown"},
+		}},
+	}
+	err = am.SavePolicy(account.Id, userID, policy)
+	require.Error(t, err, "policy should not reference an unknown resource")
+
+	policy.Rules[0].DestinationResources = []string{resource.ID}
+	require.NoError(t, am.SavePolicy(account.Id, userID, policy))
+
+	err = am.DeleteNetworkResource(account.Id, resource.ID, userID)
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PreconditionFailed, sErr.Type(), "resource used by a policy should not be deleted")
+
+	require.NoError(t, am.DeletePolicy(account.Id, policy.ID, userID))
+	require.NoError(t, am.DeleteNetworkResource(account.Id, resource.ID, userID))
+
+	_, err = am.GetNetworkResource(account.Id, userID, resource.ID)
+	require.Error(t, err)
+}
+
+func TestGetNetworkMap_NetworkResources(t *testing.T) {
+	am, err := createRouterManager(t)
+	require.NoError(t, err)
+
+	account, err := initTestRouteAccount(t, am)
+	require.NoError(t, err)
+
+	groupAll, err := account.GetGroupAll()
+	require.NoError(t, err)
+
+	createdRoute, err := am.CreateRoute(account.Id, "10.0.5.0/24", peer1ID, []string{}, "prod", "prod",
+		false, 9999, []string{groupAll.ID}, true, userID)
+	require.NoError(t, err)
+	require.NotNil(t, createdRoute)
+
+	networkMap, err := am.GetNetworkMap(peer2ID)
+	require.NoError(t, err)
+	require.Len(t, networkMap.Routes, 1, "routes out of any resource should be distributed as before")
+
+	resource, err := am.CreateNetworkResource(account.Id, userID, &NetworkResource{
+		Name:   "prod",
+		Prefix: netip.MustParsePrefix("10.0.0.0/16"),
+	})
+	require.NoError(t, err)
+
+	networkMap, err = am.GetNetworkMap(peer2ID)
+	require.NoError(t, err)
+	assert.Len(t, networkMap.Routes, 0, "the route within the resource requires a policy")
+
+	networkMap, err = am.GetNetworkMap(peer1ID)
+	require.NoError(t, err)
+	assert.Len(t, networkMap.Routes, 1, "the routing peer should keep its own route")
+
+	policy := &Policy{
+		ID:      xid.New().String(),
+		Name:    "prod access",
+		Enabled: true,
+		Rules: []*PolicyRule{{
+			ID:                   xid.New().String(),
+			Name:                 "prod access",
+			Enabled:              true,
+			Action:               PolicyTrafficActionAccept,
+			Protocol:             PolicyRuleProtocolTCP,
+			Ports:                []string{"5432"},
+			Sources:              []string{routeGroup2},
+			DestinationResources: []string{resource.ID},
+		}},
+	}
+	require.NoError(t, am.SavePolicy(account.Id, userID, policy))
+
+	networkMap, err = am.GetNetworkMap(peer2ID)
+	require.NoError(t, err)
+	require.Len(t, networkMap.Routes, 1, "the policy should grant the route to the resource")
+	assert.Equal(t, createdRoute.Network, networkMap.Routes[0].Network)
+
+	networkMap, err = am.GetNetworkMap(peer4ID)
+	require.NoError(t, err)
+	assert.Len(t, networkMap.Routes, 0, "peers out of the policy sources should not get the route")
+
+	// the routing peer accepts the traffic of the permitted peers
+	peer2 := account.Peers[peer2ID]
+	networkMap, err = am.GetNetworkMap(peer1ID)
+	require.NoError(t, err)
+	assert.Contains(t, networkMap.FirewallRules, &FirewallRule{
+		PeerIP:    peer2.IP.String(),
+		Direction: firewallRuleDirectionIN,
+		Action:    string(PolicyTrafficActionAccept),
+		Protocol:  string(PolicyRuleProtocolTCP),
+		Port:      "5432",
+	})
+
+	updates := am.peersUpdateManager.CreateChannel(peer2ID)
+	t.Cleanup(func() {
+		am.peersUpdateManager.CloseChannel(peer2ID)
+	})
+
+	require.NoError(t, am.DeletePolicy(account.Id, policy.ID, userID))
+
+	waitDelivered(t, am.peersUpdateManager, peer2ID)
+	require.Len(t, updates, 1, "peer losing access should receive an update")
+	update := <-updates
+	assert.Len(t, update.Update.NetworkMap.Routes, 0, "the route should be withdrawn")
+}
+
+func TestFilterRoutesByResources(t *testing.T) {
+	account := &Account{
+		NetworkResources: map[string]*NetworkResource{
+			"prod":    {ID: "prod", Prefix: netip.MustParsePrefix("10.0.0.0/16")},
+			"prod-db": {ID: "prod-db", Prefix: netip.MustParsePrefix("10.0.5.0/24")},
+		},
+	}
+
+	routes := []*route.Route{
+		{ID: "db", Network: netip.MustParsePrefix("10.0.5.0/24")},
+		{ID: "web", Network: netip.MustParsePrefix("10.0.6.0/24")},
+		{ID: "wide", Network: netip.MustParsePrefix("10.0.0.0/8")},
+	}
+
+	routeIDs := func(routes []*route.Route) []string {
+		ids := make([]string, 0, len(routes))
+		for _, r := range routes {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"wide"}, routeIDs(account.filterRoutesByResources(routes, lookupMap{})),
+		"routes wider than the resources are not governed by them")
+	assert.Equal(t, []string{"db", "wide"}, routeIDs(account.filterRoutesByResources(routes, lookupMap{"prod-db": {}})))
+	assert.Equal(t, []string{"db", "web", "wide"}, routeIDs(account.filterRoutesByResources(routes, lookupMap{"prod": {}})))
+}
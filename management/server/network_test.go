@@ -1,10 +1,18 @@
 package server
 
 import (
+	"fmt"
 	"net"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
 )
 
 func TestNewNetwork(t *testing.T) {
@@ -38,6 +46,110 @@ func TestAllocatePeerIP(t *testing.T) {
 	}
 }
 
+func TestAllocatePeerIPSequential(t *testing.T) {
+	ipNet := net.IPNet{IP: net.ParseIP("100.64.0.0"), Mask: net.IPMask{255, 255, 255, 0}}
+	ips := []net.IP{net.ParseIP("100.64.0.2"), net.ParseIP("100.64.0.4")}
+
+	ip, err := AllocatePeerIPSequential(ipNet, ips)
+	require.NoError(t, err)
+	assert.Equal(t, "100.64.0.3", ip.String(), "the gaps should be filled first")
+	ips = append(ips, ip)
+
+	ip, err = AllocatePeerIPSequential(ipNet, ips)
+	require.NoError(t, err)
+	assert.Equal(t, "100.64.0.5", ip.String())
+
+	for i := 0; i < 249; i++ {
+		ip, err = AllocatePeerIPSequential(ipNet, ips)
+		require.NoError(t, err)
+		ips = append(ips, ip)
+	}
+	assert.Equal(t, "100.64.0.253", ip.String())
+
+	_, err = AllocatePeerIPSequential(ipNet, ips)
+	assertStatusType(t, err, status.PreconditionFailed)
+}
+
+func TestDefaultAccountManager_AddPeerIPAllocation(t *testing.T) {
+	for _, strategy := range []string{PeerIPAllocationRandom, PeerIPAllocationSequential} {
+		t.Run(strategy, func(t *testing.T) {
+			manager, err := createManager(t)
+			require.NoError(t, err)
+
+			userID := "account_creator"
+			account, err := createAccount(manager, "test_account", userID, "")
+			require.NoError(t, err)
+
+			_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{PeerLoginExpiration: time.Hour, PeerIPAllocation: strategy})
+			require.NoError(t, err)
+
+			setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+			require.NoError(t, err)
+
+			// the lowest usable IP is reserved, so the sequential allocation would pick it first
+			reservedKey, err := wgtypes.GeneratePrivateKey()
+			require.NoError(t, err)
+			reservedIP := copyIP(account.Network.Net.IP).To4()
+			reservedIP[3] = 2
+			_, err = manager.SaveIPReservation(account.Id, userID, &IPReservation{PeerKey: reservedKey.PublicKey().String(), IP: reservedIP})
+			require.NoError(t, err)
+
+			const peers = 30
+			var wg sync.WaitGroup
+			ips := make(chan net.IP, peers)
+			for i := 0; i < peers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					key, err := wgtypes.GeneratePrivateKey()
+					if !assert.NoError(t, err) {
+						return
+					}
+					peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+						Key:  key.PublicKey().String(),
+						Meta: nbpeer.PeerSystemMeta{Hostname: fmt.Sprintf("peer-%d", i)},
+					})
+					if assert.NoError(t, err) {
+						ips <- peer.IP
+					}
+				}(i)
+			}
+			wg.Wait()
+			close(ips)
+
+			allocated := make(map[string]struct{})
+			for ip := range ips {
+				assert.True(t, account.Network.Net.Contains(ip), "IP %s should be in the account network", ip)
+				assert.False(t, ip.Equal(reservedIP), "the reserved IP shouldn't be allocated to other peers")
+				assert.NotContains(t, allocated, ip.String(), "IP %s was allocated twice", ip)
+				allocated[ip.String()] = struct{}{}
+			}
+			assert.Len(t, allocated, peers)
+
+			account, err = manager.Store.GetAccount(account.Id)
+			require.NoError(t, err)
+			for _, peer := range account.Peers {
+				assert.Contains(t, allocated, peer.IP.String(), "the allocated IPs should be persisted")
+			}
+
+			if strategy == PeerIPAllocationSequential {
+				for i := 0; i < peers; i++ {
+					ip := copyIP(reservedIP)
+					ip[3] += byte(i + 1)
+					assert.Contains(t, allocated, ip.String(), "the lowest free IPs should be allocated")
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePeerIPAllocationSettings(t *testing.T) {
+	for _, strategy := range []string{"", PeerIPAllocationRandom, PeerIPAllocationSequential} {
+		assert.NoError(t, validatePeerIPAllocationSettings(&Settings{PeerIPAllocation: strategy}))
+	}
+	assertStatusType(t, validatePeerIPAllocationSettings(&Settings{PeerIPAllocation: "lowest"}), status.InvalidArgument)
+}
+
 func TestGenerateIPs(t *testing.T) {
 	ipNet := net.IPNet{IP: net.ParseIP("100.64.0.0"), Mask: net.IPMask{255, 255, 255, 0}}
 	ips, ipsLen := generateIPs(&ipNet, map[string]struct{}{"100.64.0.0": {}})
@@ -49,3 +161,72 @@ func TestGenerateIPs(t *testing.T) {
 		t.Errorf("expected last ip to be: 100.64.0.253, got %s", ips[len(ips)-1].String())
 	}
 }
+
+func TestNewNetworkV6(t *testing.T) {
+	_, prefix, err := net.ParseCIDR("fd00:1234::/48")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network := NewNetworkV6(prefix)
+
+	ones, bits := network.Mask.Size()
+	assert.Equal(t, SubnetV6Size, ones)
+	assert.Equal(t, 128, bits)
+	assert.True(t, prefix.Contains(network.IP), "generated net should be a subnet of the prefix")
+}
+
+func TestAllocatePeerIPv6(t *testing.T) {
+	_, ipNet, err := net.ParseCIDR("fd00:1234:5678:9abc::/64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ips []net.IP
+	for i := 0; i < 1000; i++ {
+		ip, err := AllocatePeerIPv6(*ipNet, ips)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ips = append(ips, ip)
+	}
+
+	uniq := make(map[string]struct{})
+	for _, ip := range ips {
+		assert.True(t, ipNet.Contains(ip), "allocated IP %s should be in the network", ip)
+		assert.False(t, ip.Equal(ipNet.IP), "the subnet-router anycast address should not be allocated")
+		if _, ok := uniq[ip.String()]; ok {
+			t.Errorf("found duplicate IP %s", ip.String())
+		}
+		uniq[ip.String()] = struct{}{}
+	}
+}
+
+func TestSetIPv6Prefix(t *testing.T) {
+	testCases := []struct {
+		name        string
+		prefix      string
+		expectError bool
+	}{
+		{name: "Unique Local Prefix", prefix: "fd00:1234::/48"},
+		{name: "Unique Local /64 Prefix", prefix: "fd00:1234:5678:9abc::/64"},
+		{name: "Global Prefix", prefix: "2001:db8::/48", expectError: true},
+		{name: "IPv4 Prefix", prefix: "100.64.0.0/10", expectError: true},
+		{name: "Too Long Prefix", prefix: "fd00:1234:5678:9abc::/96", expectError: true},
+		{name: "Invalid Prefix", prefix: "fd00:1234::", expectError: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			am := &DefaultAccountManager{}
+			err := am.SetIPv6Prefix(testCase.prefix)
+			if testCase.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, am.ipv6Prefix)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, am.ipv6Prefix)
+		})
+	}
+}
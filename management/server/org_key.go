@@ -0,0 +1,300 @@
+package server
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rs/xid"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// OrgKeySeparator separates the org key from the ID of the target account in the setup key a peer registers with,
+// e.g. "A2C8E62B-38F5-4553-B31E-DD66C696CEBB:cn1knqv7dcmc73a2vlm0"
+const OrgKeySeparator = ":"
+
+// OrgKey is a pre-authorized key of an account, usually of an MSP, registering peers into the other accounts it is
+// linked to. The target account is chosen at registration and has to be linked on both sides: the org key lists the
+// account in LinkedAccounts and the account lists the org key in Settings.LinkedOrgKeys.
+type OrgKey struct {
+	// ID of the org key
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `json:"-" gorm:"index"`
+	Key       string `gorm:"index"`
+	Name      string
+	CreatedAt time.Time
+	// ExpiresAt is the time the key expires at, zero time means the key doesn't expire
+	ExpiresAt time.Time
+	// Revoked indicates whether the key was revoked or not (we don't remove them for tracking purposes)
+	Revoked bool
+	// LinkedAccounts is a list of IDs of the accounts the key can register peers into
+	LinkedAccounts []string `gorm:"serializer:json"`
+}
+
+// Copy copies OrgKey to a new object
+func (key *OrgKey) Copy() *OrgKey {
+	linkedAccounts := make([]string, len(key.LinkedAccounts))
+	copy(linkedAccounts, key.LinkedAccounts)
+	return &OrgKey{
+		ID:             key.ID,
+		AccountID:      key.AccountID,
+		Key:            key.Key,
+		Name:           key.Name,
+		CreatedAt:      key.CreatedAt,
+		ExpiresAt:      key.ExpiresAt,
+		Revoked:        key.Revoked,
+		LinkedAccounts: linkedAccounts,
+	}
+}
+
+// EventMeta returns activity event meta related to the org key
+func (key *OrgKey) EventMeta() map[string]any {
+	return map[string]any{"name": key.Name, "key": key.HiddenCopy(1).Key}
+}
+
+// HiddenCopy returns a copy of the key with a Key value hidden with "*" and a 5 character prefix.
+func (key *OrgKey) HiddenCopy(length int) *OrgKey {
+	k := key.Copy()
+	prefix := k.Key[0:5]
+	if length > utf8.RuneCountInString(key.Key) {
+		length = utf8.RuneCountInString(key.Key) - len(prefix)
+	}
+	k.Key = prefix + strings.Repeat("*", length)
+	return k
+}
+
+// IsValid is true if the key was not revoked and is not expired
+func (key *OrgKey) IsValid() bool {
+	return !key.Revoked && !key.IsExpired()
+}
+
+// IsExpired if key was expired
+func (key *OrgKey) IsExpired() bool {
+	return !key.ExpiresAt.IsZero() && time.Now().After(key.ExpiresAt)
+}
+
+// linksAccount returns true if the key can register peers into the account
+func (key *OrgKey) linksAccount(accountID string) bool {
+	for _, id := range key.LinkedAccounts {
+		if id == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// splitOrgKey splits the setup key a peer registers with into the org key and the ID of the target account,
+// ok is false if the setup key isn't an org key
+func splitOrgKey(setupKey string) (orgKey string, accountID string, ok bool) {
+	orgKey, accountID, ok = strings.Cut(setupKey, OrgKeySeparator)
+	if !ok || orgKey == "" || accountID == "" {
+		return "", "", false
+	}
+	return strings.ToUpper(orgKey), accountID, true
+}
+
+// findOrgKeyByKey looks for the org key with the given Key value in the Account
+func (a *Account) findOrgKeyByKey(key string) (*OrgKey, error) {
+	for _, orgKey := range a.OrgKeys {
+		if orgKey.Key == key {
+			return orgKey, nil
+		}
+	}
+	return nil, status.Errorf(status.NotFound, "org key not found")
+}
+
+// linksOrgKey returns true if the account accepts peers registered with the org key
+func (s *Settings) linksOrgKey(orgKeyID string) bool {
+	for _, id := range s.LinkedOrgKeys {
+		if id == orgKeyID {
+			return true
+		}
+	}
+	return false
+}
+
+// getLinkedOrgKey returns the valid org key with the Key value if it can register peers into the target account.
+// Only the side of the account owning the key is checked, the target account has to accept the key as well.
+func (am *DefaultAccountManager) getLinkedOrgKey(key, targetAccountID string) (*OrgKey, error) {
+	owner, err := am.Store.GetAccountByOrgKey(key)
+	if err != nil {
+		return nil, status.Errorf(status.NotFound, "org key not found")
+	}
+
+	orgKey, err := owner.findOrgKeyByKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !orgKey.IsValid() {
+		return nil, status.Errorf(status.PreconditionFailed, "couldn't add peer: org key is invalid")
+	}
+
+	if !orgKey.linksAccount(targetAccountID) {
+		return nil, status.Errorf(status.PermissionDenied, "couldn't add peer: org key isn't linked to account %s", targetAccountID)
+	}
+
+	return orgKey.Copy(), nil
+}
+
+// checkOrgKey returns the state of the org key for the registration into the target account, it is valid only when
+// linked on both sides. The org keys have no usage limit.
+func (am *DefaultAccountManager) checkOrgKey(key, targetAccountID string) *SetupKeyState {
+	orgKey, err := am.getLinkedOrgKey(key, targetAccountID)
+	if err != nil {
+		return &SetupKeyState{}
+	}
+
+	target, err := am.Store.GetAccount(targetAccountID)
+	if err != nil || !target.Settings.linksOrgKey(orgKey.ID) {
+		return &SetupKeyState{}
+	}
+
+	return &SetupKeyState{
+		Valid:         true,
+		RemainingUses: SetupKeyUnlimitedRemainingUses,
+		ExpiresAt:     orgKey.ExpiresAt,
+	}
+}
+
+// ListOrgKeys returns the org keys of the account
+func (am *DefaultAccountManager) ListOrgKeys(accountID, userID string) ([]*OrgKey, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceAccounts, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view org keys")
+	}
+
+	keys := make([]*OrgKey, 0, len(account.OrgKeys))
+	for _, key := range account.OrgKeys {
+		keys = append(keys, key.Copy())
+	}
+
+	return keys, nil
+}
+
+// CreateOrgKey generates a new org key linked to the accounts, a zero expiresIn creates a key which doesn't expire
+func (am *DefaultAccountManager) CreateOrgKey(accountID, userID, name string, expiresIn time.Duration, linkedAccounts []string) (*OrgKey, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkPermission(account, userID, ResourceAccounts, OperationWrite); err != nil {
+		return nil, err
+	}
+
+	if err = am.validateOrgKeyLinkedAccounts(accountID, linkedAccounts); err != nil {
+		return nil, err
+	}
+
+	key := &OrgKey{
+		ID:             xid.New().String(),
+		AccountID:      accountID,
+		Key:            strings.ToUpper(uuid.New().String()),
+		Name:           name,
+		CreatedAt:      time.Now().UTC(),
+		LinkedAccounts: linkedAccounts,
+	}
+	if expiresIn > 0 {
+		key.ExpiresAt = key.CreatedAt.Add(expiresIn)
+	}
+
+	if account.OrgKeys == nil {
+		account.OrgKeys = make(map[string]*OrgKey)
+	}
+	account.OrgKeys[key.ID] = key
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.StoreEvent(userID, key.ID, accountID, activity.OrgKeyCreated, key.EventMeta())
+
+	return key.Copy(), nil
+}
+
+// SaveOrgKey updates the name, the revoked state and the linked accounts of the org key.
+// The key is looked up by ID, the other fields can't be changed.
+func (am *DefaultAccountManager) SaveOrgKey(accountID, userID string, keyToSave *OrgKey) (*OrgKey, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	if keyToSave == nil {
+		return nil, status.Errorf(status.InvalidArgument, "provided org key to update is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkPermission(account, userID, ResourceAccounts, OperationWrite); err != nil {
+		return nil, err
+	}
+
+	oldKey := account.OrgKeys[keyToSave.ID]
+	if oldKey == nil {
+		return nil, status.Errorf(status.NotFound, "org key %s wasn't found", keyToSave.ID)
+	}
+
+	if oldKey.Revoked && !keyToSave.Revoked {
+		return nil, status.Errorf(status.InvalidArgument, "revoked org key can't be activated again")
+	}
+
+	if err = am.validateOrgKeyLinkedAccounts(accountID, keyToSave.LinkedAccounts); err != nil {
+		return nil, err
+	}
+
+	newKey := oldKey.Copy()
+	newKey.Name = keyToSave.Name
+	newKey.Revoked = keyToSave.Revoked
+	newKey.LinkedAccounts = keyToSave.Copy().LinkedAccounts
+	account.OrgKeys[newKey.ID] = newKey
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	event := activity.OrgKeyUpdated
+	if !oldKey.Revoked && newKey.Revoked {
+		event = activity.OrgKeyRevoked
+	}
+	am.StoreEvent(userID, newKey.ID, accountID, event, newKey.EventMeta())
+
+	return newKey.Copy(), nil
+}
+
+// validateOrgKeyLinkedAccounts checks the accounts an org key of the owner account is linked to exist.
+// The owner account registers its own peers with setup keys and can't be linked.
+func (am *DefaultAccountManager) validateOrgKeyLinkedAccounts(ownerAccountID string, linkedAccounts []string) error {
+	for _, accountID := range linkedAccounts {
+		if accountID == ownerAccountID {
+			return status.Errorf(status.InvalidArgument, "org key can't be linked to the account owning it")
+		}
+		if _, err := am.Store.GetAccount(accountID); err != nil {
+			return status.Errorf(status.InvalidArgument, "linked account %s wasn't found", accountID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_OrgKey(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	mspUserID := "msp_user"
+	mspAccount, err := createAccount(manager, "msp_account", mspUserID, "")
+	require.NoError(t, err)
+
+	customerUserID := "customer_user"
+	customerAccount, err := createAccount(manager, "customer_account", customerUserID, "")
+	require.NoError(t, err)
+
+	otherUserID := "other_user"
+	otherAccount, err := createAccount(manager, "other_account", otherUserID, "")
+	require.NoError(t, err)
+
+	_, err = manager.CreateOrgKey(mspAccount.Id, mspUserID, "msp", 0, []string{mspAccount.Id})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.CreateOrgKey(mspAccount.Id, mspUserID, "msp", 0, []string{"unknown_account"})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.CreateOrgKey(mspAccount.Id, customerUserID, "msp", 0, []string{customerAccount.Id})
+	require.Error(t, err, "a user of another account shouldn't create org keys")
+
+	orgKey, err := manager.CreateOrgKey(mspAccount.Id, mspUserID, "msp", 0, []string{customerAccount.Id})
+	require.NoError(t, err)
+	assert.True(t, orgKey.ExpiresAt.IsZero())
+	assert.True(t, orgKey.IsValid())
+
+	keys, err := manager.ListOrgKeys(mspAccount.Id, mspUserID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, orgKey.ID, keys[0].ID)
+
+	addPeer := func(setupKey string) (*nbpeer.Peer, error) {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey, "", &nbpeer.Peer{Key: key.PublicKey().String(), Meta: nbpeer.PeerSystemMeta{Hostname: "device"}})
+		return peer, err
+	}
+	acceptOrgKey := func(accountID, userID string) {
+		account, err := manager.Store.GetAccount(accountID)
+		require.NoError(t, err)
+		settings := account.Settings.Copy()
+		settings.LinkedOrgKeys = []string{orgKey.ID}
+		_, err = manager.UpdateAccountSettings(accountID, userID, settings)
+		require.NoError(t, err)
+	}
+
+	customerKey := orgKey.Key + OrgKeySeparator + customerAccount.Id
+	_, err = addPeer(customerKey)
+	assertStatusType(t, err, status.PermissionDenied)
+
+	state, err := manager.CheckSetupKey(customerKey)
+	require.NoError(t, err)
+	assert.False(t, state.Valid, "the key shouldn't be valid before the account accepts it")
+
+	acceptOrgKey(customerAccount.Id, customerUserID)
+
+	peer, err := addPeer(customerKey)
+	require.NoError(t, err)
+	assert.Empty(t, peer.SetupKey, "the key of another account shouldn't be kept with the peer")
+
+	customerAccount, err = manager.Store.GetAccount(customerAccount.Id)
+	require.NoError(t, err)
+	assert.Contains(t, customerAccount.Peers, peer.ID)
+	group, err := customerAccount.GetGroupAll()
+	require.NoError(t, err)
+	assert.Contains(t, group.Peers, peer.ID)
+
+	mspAccount, err = manager.Store.GetAccount(mspAccount.Id)
+	require.NoError(t, err)
+	assert.Empty(t, mspAccount.Peers)
+
+	_, err = addPeer(strings.ToLower(orgKey.Key) + OrgKeySeparator + customerAccount.Id)
+	require.NoError(t, err, "the org key should be matched case-insensitively")
+
+	state, err = manager.CheckSetupKey(customerKey)
+	require.NoError(t, err)
+	assert.True(t, state.Valid)
+	assert.Equal(t, SetupKeyUnlimitedRemainingUses, state.RemainingUses)
+
+	// accepting the key isn't enough, the org key has to be linked to the account
+	acceptOrgKey(otherAccount.Id, otherUserID)
+	otherKey := orgKey.Key + OrgKeySeparator + otherAccount.Id
+	_, err = addPeer(otherKey)
+	assertStatusType(t, err, status.PermissionDenied)
+
+	state, err = manager.CheckSetupKey(otherKey)
+	require.NoError(t, err)
+	assert.False(t, state.Valid)
+
+	_, err = addPeer("00000000-0000-0000-0000-000000000000" + OrgKeySeparator + customerAccount.Id)
+	assertStatusType(t, err, status.NotFound)
+
+	orgKey.Revoked = true
+	orgKey, err = manager.SaveOrgKey(mspAccount.Id, mspUserID, orgKey)
+	require.NoError(t, err)
+	assert.False(t, orgKey.IsValid())
+
+	_, err = addPeer(customerKey)
+	assertStatusType(t, err, status.PreconditionFailed)
+
+	orgKey.Revoked = false
+	_, err = manager.SaveOrgKey(mspAccount.Id, mspUserID, orgKey)
+	assertStatusType(t, err, status.InvalidArgument)
+}
+
+func TestSplitOrgKey(t *testing.T) {
+	key, accountID, ok := splitOrgKey("a2c8e62b-38f5-4553-b31e-dd66c696cebb:cn1knqv7dcmc73a2vlm0")
+	assert.True(t, ok)
+	assert.Equal(t, "A2C8E62B-38F5-4553-B31E-DD66C696CEBB", key)
+	assert.Equal(t, "cn1knqv7dcmc73a2vlm0", accountID, "the account ID should keep its case")
+
+	for _, setupKey := range []string{"A2C8E62B-38F5-4553-B31E-DD66C696CEBB", "A2C8E62B-38F5-4553-B31E-DD66C696CEBB:", ":cn1knqv7dcmc73a2vlm0"} {
+		_, _, ok = splitOrgKey(setupKey)
+		assert.False(t, ok, setupKey)
+	}
+}
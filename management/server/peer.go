@@ -1,11 +1,18 @@
 package server
 
 import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"net/netip"
 	"strings"
 	"time"
 
 	"github.com/rs/xid"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/exp/slices"
 
 	"github.com/netbirdio/management-integrations/additions"
 
@@ -18,10 +25,28 @@ import (
 	"github.com/netbirdio/netbird/management/proto"
 )
 
+// minSSHRSAKeyBits is the minimum size of the RSA SSH public keys accepted from the peers
+const minSSHRSAKeyBits = 2048
+
+// supportedSSHKeyTypes are the SSH public key types accepted from the peers
+var supportedSSHKeyTypes = map[string]struct{}{
+	ssh.KeyAlgoED25519:  {},
+	ssh.KeyAlgoRSA:      {},
+	ssh.KeyAlgoECDSA256: {},
+	ssh.KeyAlgoECDSA384: {},
+	ssh.KeyAlgoECDSA521: {},
+}
+
 // PeerSync used as a data object between the gRPC API and AccountManager on Sync request.
 type PeerSync struct {
 	// WireGuardPubKey is a peers WireGuard public key
 	WireGuardPubKey string
+	// AdvertisedNetworks are the networks of the peer's local network the peer offers to route. Can be empty.
+	AdvertisedNetworks []netip.Prefix
+	// NATType is the type of the NAT the peer is behind. Empty when unknown.
+	NATType string
+	// MappedAddress is the public IP:port the peer mapped its WireGuard port to on its gateway. Can be empty.
+	MappedAddress string
 }
 
 // PeerLogin used as a data object between the gRPC API and AccountManager on Login request.
@@ -34,8 +59,17 @@ type PeerLogin struct {
 	Meta nbpeer.PeerSystemMeta
 	// UserID indicates that JWT was used to log in, and it was valid. Can be empty when SetupKey is used or auth is not required.
 	UserID string
-	// SetupKey references to a server.SetupKey to log in. Can be empty when UserID is used or auth is not required.
+	// SetupKey references to a server.SetupKey to log in, or to an OrgKey followed by the ID of the target account.
+	// Can be empty when UserID is used or auth is not required.
 	SetupKey string
+	// ConnectionIP is the source IP the login request was received from. Can be empty.
+	ConnectionIP net.IP
+	// MappedAddress is the public IP:port the peer mapped its WireGuard port to on its gateway. Can be empty.
+	MappedAddress string
+	// AdvertisedNetworks are the networks of the peer's local network the peer offers to route. Can be empty.
+	AdvertisedNetworks []netip.Prefix
+	// NATType is the type of the NAT the peer is behind. Empty when unknown.
+	NATType string
 }
 
 // GetPeers returns a list of peers under the given account filtering out peers that do not belong to a user if
@@ -54,7 +88,7 @@ func (am *DefaultAccountManager) GetPeers(accountID, userID string) ([]*nbpeer.P
 	peers := make([]*nbpeer.Peer, 0)
 	peersMap := make(map[string]*nbpeer.Peer)
 	for _, peer := range account.Peers {
-		if !(user.HasAdminPower() || user.IsServiceUser) && user.Id != peer.UserID {
+		if !user.HasPermission(ResourcePeers, OperationRead) && user.Id != peer.UserID {
 			// only display peers that belong to the current user if the current user is not an admin
 			continue
 		}
@@ -79,8 +113,9 @@ func (am *DefaultAccountManager) GetPeers(accountID, userID string) ([]*nbpeer.P
 	return peers, nil
 }
 
-// MarkPeerConnected marks peer as connected (true) or disconnected (false)
-func (am *DefaultAccountManager) MarkPeerConnected(peerPubKey string, connected bool) error {
+// MarkPeerConnected marks peer as connected (true) or disconnected (false).
+// When the peer connects, realIP (if provided) is stored as the peer's last observed connection IP.
+func (am *DefaultAccountManager) MarkPeerConnected(peerPubKey string, connected bool, realIP net.IP) error {
 	account, err := am.Store.GetAccountByPeerPubKey(peerPubKey)
 	if err != nil {
 		return err
@@ -107,6 +142,7 @@ func (am *DefaultAccountManager) MarkPeerConnected(peerPubKey string, connected
 	// whenever peer got connected that means that it logged in successfully
 	if newStatus.Connected {
 		newStatus.LoginExpired = false
+		newStatus.Inactive = false
 	}
 	peer.Status = newStatus
 	account.UpdatePeer(peer)
@@ -116,20 +152,33 @@ func (am *DefaultAccountManager) MarkPeerConnected(peerPubKey string, connected
 		return err
 	}
 
+	observedIPChanged := false
+	if connected && realIP != nil && !peer.Location.ConnectionIP.Equal(realIP) {
+		// the remote peers use the observed public IP as a server-reflexive hint of the peer
+		observedIPChanged = toObservedIP(peer.Location.ConnectionIP) != toObservedIP(realIP)
+		peer.Location.ConnectionIP = realIP
+		account.UpdatePeer(peer)
+		err = am.Store.SavePeerLocation(account.Id, peer)
+		if err != nil {
+			log.Warnf("could not store location for peer %s: %s", peer.ID, err)
+		}
+	}
+
 	if peer.AddedWithSSOLogin() && peer.LoginExpirationEnabled && account.Settings.PeerLoginExpirationEnabled {
 		am.checkAndSchedulePeerLoginExpiration(account)
 	}
 
-	if oldStatus.LoginExpired {
+	if oldStatus.LoginExpired || observedIPChanged {
 		// we need to update other peers because when peer login expires all other peers are notified to disconnect from
 		// the expired one. Here we notify them that connection is now allowed again.
+		// A reconnecting peer with a new public IP is also announced with its new hint.
 		am.updateAccountPeers(account)
 	}
 
 	return nil
 }
 
-// UpdatePeer updates peer. Only Peer.Name, Peer.SSHEnabled, and Peer.LoginExpirationEnabled can be updated.
+// UpdatePeer updates peer. Only Peer.Name, Peer.SSHEnabled, Peer.ForceRelay, and Peer.LoginExpirationEnabled can be updated.
 func (am *DefaultAccountManager) UpdatePeer(accountID, userID string, update *nbpeer.Peer) (*nbpeer.Peer, error) {
 	unlock := am.Store.AcquireAccountLock(accountID)
 	defer unlock()
@@ -158,10 +207,21 @@ func (am *DefaultAccountManager) UpdatePeer(accountID, userID string, update *nb
 		am.StoreEvent(userID, peer.IP.String(), accountID, event, peer.EventMeta(am.GetDNSDomain()))
 	}
 
+	if peer.ForceRelay != update.ForceRelay {
+		peer.ForceRelay = update.ForceRelay
+		event := activity.PeerForceRelayEnabled
+		if !update.ForceRelay {
+			event = activity.PeerForceRelayDisabled
+		}
+		am.StoreEvent(userID, peer.IP.String(), accountID, event, peer.EventMeta(am.GetDNSDomain()))
+	}
+
 	if peer.Name != update.Name {
 		peer.Name = update.Name
 
-		existingLabels := account.getPeerDNSLabels()
+		existingLabels := account.getTakenDNSLabels()
+		// the peer keeps its label when the new name maps to it, e.g. when only the case of the name changed
+		delete(existingLabels, peer.DNSLabel)
 
 		newLabel, err := getPeerHostLabel(peer.Name, existingLabels)
 		if err != nil {
@@ -284,6 +344,34 @@ func (am *DefaultAccountManager) GetNetworkMap(peerID string) (*NetworkMap, erro
 	return account.GetPeerNetworkMap(peer.ID, am.dnsDomain), nil
 }
 
+// GetPeerNetworkMapPreview returns the network map a peer of the account receives, evaluated the same way as for
+// GetNetworkMap. It lets the users allowed to view all the peers troubleshoot what a peer is sent.
+func (am *DefaultAccountManager) GetPeerNetworkMapPreview(accountID, peerID, userID string) (*NetworkMap, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourcePeers, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view the network maps of the peers")
+	}
+
+	peer := account.GetPeer(peerID)
+	if peer == nil {
+		return nil, status.Errorf(status.NotFound, "peer with %s not found under account %s", peerID, accountID)
+	}
+
+	return account.GetPeerNetworkMap(peer.ID, am.dnsDomain), nil
+}
+
 // GetPeerNetwork returns the Network for a given peer
 func (am *DefaultAccountManager) GetPeerNetwork(peerID string) (*Network, error) {
 	account, err := am.Store.GetAccountByPeerID(peerID)
@@ -310,10 +398,21 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 	upperKey := strings.ToUpper(setupKey)
 	var account *Account
 	var err error
+	// orgKey is set when the peer registers with an org key of another account, the setup key names the target account
+	var orgKey *OrgKey
+	orgKeyValue, targetAccountID, withOrgKey := splitOrgKey(setupKey)
 	addedByUser := false
 	if len(userID) > 0 {
 		addedByUser = true
 		account, err = am.Store.GetAccountByUser(userID)
+	} else if withOrgKey {
+		orgKey, err = am.getLinkedOrgKey(orgKeyValue, targetAccountID)
+		if err != nil {
+			return nil, nil, err
+		}
+		// the key of another account isn't kept with the peer
+		upperKey = ""
+		account, err = am.Store.GetAccount(targetAccountID)
 	} else {
 		account, err = am.Store.GetAccountBySetupKey(setupKey)
 	}
@@ -321,7 +420,13 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 		return nil, nil, status.Errorf(status.NotFound, "failed adding new peer: account not found")
 	}
 
-	unlock := am.Store.AcquireAccountLock(account.Id)
+	// the registration with an org key reads the account owning the key as well, both accounts are locked together
+	// so the key can't be revoked or unlinked meanwhile
+	lockedAccounts := []string{account.Id}
+	if orgKey != nil {
+		lockedAccounts = append(lockedAccounts, orgKey.AccountID)
+	}
+	unlock := am.Store.AcquireAccountLocks(lockedAccounts...)
 	defer unlock()
 
 	// ensure that we consider modification happened meanwhile (because we were outside the account lock when we fetched the account)
@@ -330,15 +435,29 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 		return nil, nil, err
 	}
 
+	if orgKey != nil {
+		orgKey, err = am.getLinkedOrgKey(orgKeyValue, account.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	if strings.ToLower(peer.Meta.Hostname) == "iphone" || strings.ToLower(peer.Meta.Hostname) == "ipad" && userID != "" {
 		if am.idpManager != nil {
 			userdata, err := am.lookupUserInCache(userID, account)
-			if err == nil {
+			if err == nil && userdata != nil {
 				peer.Meta.Hostname = fmt.Sprintf("%s-%s", peer.Meta.Hostname, strings.Split(userdata.Email, "@")[0])
 			}
 		}
 	}
 
+	if peer.SSHKey != "" {
+		peer.SSHKey, err = normalizeSSHPublicKey(peer.SSHKey)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// This is a handling for the case when the same machine (with the same WireGuard pub key) tries to register twice.
 	// Such case is possible when AddPeer function takes long time to finish after AcquireAccountLock (e.g., database is slow)
 	// and the peer disconnects with a timeout and tries to register again.
@@ -349,6 +468,20 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 		return nil, nil, status.Errorf(status.PreconditionFailed, "peer has been already registered")
 	}
 
+	// checked before the setup key usage is counted, so a rejected peer doesn't consume it
+	outdatedClient := false
+	if err = checkClientVersion(account.Settings, peer.Meta.WtVersion); err != nil {
+		if account.Settings.MinClientVersionAction != MinClientVersionActionFlag {
+			return nil, nil, err
+		}
+		outdatedClient = true
+	}
+
+	nonCompliant, err := account.checkPeerPosture(peer.Meta)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	opEvent := &activity.Event{
 		Timestamp: time.Now().UTC(),
 		AccountID: account.Id,
@@ -356,7 +489,16 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 
 	var ephemeral bool
 	setupKeyName := ""
-	if !addedByUser {
+	if orgKey != nil {
+		// the org key is validated by the account owning it, the target account has to accept it as well
+		if !account.Settings.linksOrgKey(orgKey.ID) {
+			return nil, nil, status.Errorf(status.PermissionDenied, "couldn't add peer: account %s doesn't accept the org key", account.Id)
+		}
+
+		opEvent.InitiatorID = orgKey.ID
+		opEvent.Activity = activity.PeerAddedWithOrgKey
+		setupKeyName = orgKey.Name
+	} else if !addedByUser {
 		// validate the setup key if adding with a key
 		sk, err := account.FindSetupKey(upperKey)
 		if err != nil {
@@ -370,24 +512,28 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 		account.SetupKeys[sk.Key] = sk.IncrementUsage()
 		opEvent.InitiatorID = sk.Id
 		opEvent.Activity = activity.PeerAddedWithSetupKey
-		ephemeral = sk.Ephemeral
+		ephemeral = sk.GetPeerType() == SetupKeyPeerTypeEphemeral
 		setupKeyName = sk.Name
 	} else {
 		opEvent.InitiatorID = userID
 		opEvent.Activity = activity.PeerAddedByUser
 	}
 
-	takenIps := account.getTakenIPs()
-	existingLabels := account.getPeerDNSLabels()
+	peerName := peer.Meta.Hostname
+	if peerName == "" {
+		// the peer omits its hostname for privacy, it can be renamed later
+		peerName = defaultPeerName(peer.Key)
+	}
+
+	existingLabels := account.getTakenDNSLabels()
 
-	newLabel, err := getPeerHostLabel(peer.Meta.Hostname, existingLabels)
+	newLabel, err := getPeerHostLabel(peerName, existingLabels)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	peer.DNSLabel = newLabel
-	network := account.Network
-	nextIp, err := AllocatePeerIP(network.Net, takenIps)
+	nextIp, err := account.allocatePeerIP(peer.Key)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -398,20 +544,32 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 		SetupKey:               upperKey,
 		IP:                     nextIp,
 		Meta:                   peer.Meta,
-		Name:                   peer.Meta.Hostname,
+		Name:                   peerName,
 		DNSLabel:               newLabel,
 		UserID:                 userID,
-		Status:                 &nbpeer.PeerStatus{Connected: false, LastSeen: time.Now().UTC()},
+		Status:                 &nbpeer.PeerStatus{Connected: false, LastSeen: time.Now().UTC(), OutdatedClient: outdatedClient, NonCompliant: nonCompliant},
 		SSHEnabled:             false,
 		SSHKey:                 peer.SSHKey,
 		LastLogin:              time.Now().UTC(),
 		LoginExpirationEnabled: addedByUser,
 		Ephemeral:              ephemeral,
+		Location:               peer.Location,
+	}
+	newPeer.AdvertisedNetworks = validAdvertisedNetworks(account, newPeer, peer.AdvertisedNetworks)
+
+	if _, err = am.assignPeerIPv6(account, newPeer); err != nil {
+		return nil, nil, err
 	}
 
 	if account.Settings.Extra != nil {
 		newPeer = additions.PreparePeer(newPeer, account.Settings.Extra)
 	}
+	// the type of the peer is decided by the setup key only
+	newPeer.Ephemeral = ephemeral
+
+	if account.Settings.PeerApprovalRequired {
+		newPeer.Status.RequiresApproval = true
+	}
 
 	// add peer to 'All' group
 	group, err := account.GetGroupAll()
@@ -426,7 +584,7 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 		if err != nil {
 			return nil, nil, err
 		}
-	} else {
+	} else if orgKey == nil {
 		groupsToAdd, err = account.getSetupKeyGroups(upperKey)
 		if err != nil {
 			return nil, nil, err
@@ -450,6 +608,7 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 	}
 
 	account.Peers[newPeer.ID] = newPeer
+	account.refreshDynamicGroups(newPeer)
 	account.Network.IncSerial()
 	err = am.Store.SaveAccount(account)
 	if err != nil {
@@ -464,6 +623,10 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 
 	am.StoreEvent(opEvent.InitiatorID, opEvent.TargetID, opEvent.AccountID, opEvent.Activity, opEvent.Meta)
 
+	if outdatedClient {
+		am.storePeerFlaggedOutdatedEvent(account, newPeer, newPeer.Meta.WtVersion)
+	}
+
 	am.updateAccountPeers(account)
 
 	networkMap := account.GetPeerNetworkMap(newPeer.ID, am.dnsDomain)
@@ -472,38 +635,53 @@ func (am *DefaultAccountManager) AddPeer(setupKey, userID string, peer *nbpeer.P
 
 // SyncPeer checks whether peer is eligible for receiving NetworkMap (authenticated) and returns its NetworkMap if eligible
 func (am *DefaultAccountManager) SyncPeer(sync PeerSync) (*nbpeer.Peer, *NetworkMap, error) {
+	peer, networkMap, changed, err := am.syncPeer(sync)
+	if err != nil || !changed {
+		return peer, networkMap, err
+	}
+	return am.syncPeerReportedState(sync)
+}
+
+// syncPeer returns the peer and its NetworkMap if eligible, and whether the peer advertises other networks or
+// reports another NAT type or mapped address than stored
+func (am *DefaultAccountManager) syncPeer(sync PeerSync) (*nbpeer.Peer, *NetworkMap, bool, error) {
 	account, err := am.Store.GetAccountByPeerPubKey(sync.WireGuardPubKey)
 	if err != nil {
 		if errStatus, ok := status.FromError(err); ok && errStatus.Type() == status.NotFound {
-			return nil, nil, status.Errorf(status.Unauthenticated, "peer is not registered")
+			return nil, nil, false, status.Errorf(status.Unauthenticated, "peer is not registered")
 		}
-		return nil, nil, err
+		return nil, nil, false, err
 	}
 
-	// we found the peer, and we follow a normal login flow
-	unlock := am.Store.AcquireAccountLock(account.Id)
+	// we found the peer, and we follow a normal login flow.
+	// Sync doesn't modify the account, so a shared read lock is enough and concurrent syncs don't block each other
+	unlock := am.Store.AcquireAccountReadLock(account.Id)
 	defer unlock()
 
 	// fetch the account from the store once more after acquiring lock to avoid concurrent updates inconsistencies
 	account, err = am.Store.GetAccount(account.Id)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, false, err
 	}
 
 	peer, err := account.FindPeerByPubKey(sync.WireGuardPubKey)
 	if err != nil {
-		return nil, nil, status.Errorf(status.Unauthenticated, "peer is not registered")
+		return nil, nil, false, status.Errorf(status.Unauthenticated, "peer is not registered")
 	}
 
 	err = checkIfPeerOwnerIsBlocked(peer, account)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, false, err
 	}
 
 	if peerLoginExpired(peer, account) {
-		return nil, nil, status.Errorf(status.PermissionDenied, "peer login has expired, please log in once more")
+		return nil, nil, false, status.Errorf(status.PermissionDenied, "peer login has expired, please log in once more")
 	}
-	return peer, account.GetPeerNetworkMap(peer.ID, am.dnsDomain), nil
+	networksChanged := !slices.Equal(peer.AdvertisedNetworks, validAdvertisedNetworks(account, peer, sync.AdvertisedNetworks))
+	natTypeChanged := sync.NATType != "" && peer.Location.NATType != sync.NATType
+	mappedAddressChanged := peer.Location.MappedAddress != sync.MappedAddress
+	changed := networksChanged || natTypeChanged || mappedAddressChanged
+	return peer, account.GetPeerNetworkMap(peer.ID, am.dnsDomain), changed, nil
 }
 
 // LoginPeer logs in or registers a peer.
@@ -516,9 +694,11 @@ func (am *DefaultAccountManager) LoginPeer(login PeerLogin) (*nbpeer.Peer, *Netw
 			// we couldn't find this peer by its public key which can mean that peer hasn't been registered yet.
 			// Try registering it.
 			return am.AddPeer(login.SetupKey, login.UserID, &nbpeer.Peer{
-				Key:    login.WireGuardPubKey,
-				Meta:   login.Meta,
-				SSHKey: login.SSHKey,
+				Key:                login.WireGuardPubKey,
+				Meta:               login.Meta,
+				SSHKey:             login.SSHKey,
+				Location:           nbpeer.Location{ConnectionIP: login.ConnectionIP, MappedAddress: login.MappedAddress, NATType: login.NATType},
+				AdvertisedNetworks: login.AdvertisedNetworks,
 			})
 		}
 		log.Errorf("failed while logging in peer %s: %v", login.WireGuardPubKey, err)
@@ -545,9 +725,24 @@ func (am *DefaultAccountManager) LoginPeer(login PeerLogin) (*nbpeer.Peer, *Netw
 		return nil, nil, err
 	}
 
+	// an outdated client is rejected before the login expiration, there is no point in logging in with it
+	outdatedChanged, err := am.applyClientVersionCheck(account, peer, login.Meta.WtVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// evaluated with the reported meta, the peer may have upgraded its OS since the last login
+	complianceChanged, err := am.applyPostureChecks(account, peer, login.Meta)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// this flag prevents unnecessary calls to the persistent store.
-	shouldStoreAccount := false
-	updateRemotePeers := false
+	shouldStoreAccount := outdatedChanged || complianceChanged
+	updateRemotePeers := complianceChanged
+	if complianceChanged {
+		account.Network.IncSerial()
+	}
 	if peerLoginExpired(peer, account) {
 		err = checkAuth(login.UserID, peer)
 		if err != nil {
@@ -574,11 +769,55 @@ func (am *DefaultAccountManager) LoginPeer(login PeerLogin) (*nbpeer.Peer, *Netw
 		shouldStoreAccount = true
 	}
 
+	// the remote peers use the observed public IP as a server-reflexive hint of the peer
+	if login.ConnectionIP != nil && !peer.Location.ConnectionIP.Equal(login.ConnectionIP) {
+		if toObservedIP(peer.Location.ConnectionIP) != toObservedIP(login.ConnectionIP) {
+			updateRemotePeers = true
+		}
+		peer.Location.ConnectionIP = login.ConnectionIP
+		shouldStoreAccount = true
+	}
+
+	// the remote peers add the mapped address as a candidate of the peer
+	if updatePeerMappedAddress(account, peer, login.MappedAddress) {
+		shouldStoreAccount = true
+		updateRemotePeers = true
+	}
+
+	if updatePeerAdvertisedNetworks(account, peer, login.AdvertisedNetworks) {
+		shouldStoreAccount = true
+	}
+
+	// the remote peers choose between a direct and a relayed connection by the NAT type
+	if updatePeerNATType(account, peer, login.NATType) {
+		shouldStoreAccount = true
+		updateRemotePeers = true
+	}
+
 	peer, err = am.checkAndUpdatePeerSSHKey(peer, account, login.SSHKey)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// peers registered before the IPv6 allocation was enabled get their address on the next login
+	assigned, err := am.assignPeerIPv6(account, peer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if assigned {
+		account.UpdatePeer(peer)
+		account.Network.IncSerial()
+		shouldStoreAccount = true
+		updateRemotePeers = true
+	}
+
+	// a peer moving in or out of a dynamic group changes the network maps of the other peers
+	if (updated || assigned) && account.refreshDynamicGroups(peer) {
+		account.Network.IncSerial()
+		shouldStoreAccount = true
+		updateRemotePeers = true
+	}
+
 	if shouldStoreAccount {
 		err = am.Store.SaveAccount(account)
 		if err != nil {
@@ -638,6 +877,11 @@ func (am *DefaultAccountManager) checkAndUpdatePeerSSHKey(peer *nbpeer.Peer, acc
 		return peer, nil
 	}
 
+	newSSHKey, err := normalizeSSHPublicKey(newSSHKey)
+	if err != nil {
+		return nil, err
+	}
+
 	if peer.SSHKey == newSSHKey {
 		log.Debugf("same SSH key provided for peer %s, skipping update", peer.ID)
 		return peer, nil
@@ -646,7 +890,7 @@ func (am *DefaultAccountManager) checkAndUpdatePeerSSHKey(peer *nbpeer.Peer, acc
 	peer.SSHKey = newSSHKey
 	account.UpdatePeer(peer)
 
-	err := am.Store.SaveAccount(account)
+	err = am.Store.SaveAccount(account)
 	if err != nil {
 		return nil, err
 	}
@@ -664,6 +908,11 @@ func (am *DefaultAccountManager) UpdatePeerSSHKey(peerID string, sshKey string)
 		return nil
 	}
 
+	sshKey, err := normalizeSSHPublicKey(sshKey)
+	if err != nil {
+		return err
+	}
+
 	account, err := am.Store.GetAccountByPeerID(peerID)
 	if err != nil {
 		return err
@@ -702,9 +951,31 @@ func (am *DefaultAccountManager) UpdatePeerSSHKey(peerID string, sshKey string)
 	return nil
 }
 
+// normalizeSSHPublicKey parses an SSH public key in the authorized_keys format and returns it in the canonical
+// "<type> <base64 key>" form without options and comment. Keys of unsupported types, RSA keys shorter than
+// minSSHRSAKeyBits and malformed keys are rejected with an InvalidArgument error.
+func normalizeSSHPublicKey(key string) (string, error) {
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return "", status.Errorf(status.InvalidArgument, "invalid SSH public key: %v", err)
+	}
+
+	if _, ok := supportedSSHKeyTypes[pubKey.Type()]; !ok {
+		return "", status.Errorf(status.InvalidArgument, "unsupported SSH public key type %s", pubKey.Type())
+	}
+
+	if cryptoKey, ok := pubKey.(ssh.CryptoPublicKey); ok {
+		if rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minSSHRSAKeyBits {
+			return "", status.Errorf(status.InvalidArgument, "RSA SSH public key should be at least %d bits long", minSSHRSAKeyBits)
+		}
+	}
+
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pubKey))), nil
+}
+
 // GetPeer for a given accountID, peerID and userID error if not found.
 func (am *DefaultAccountManager) GetPeer(accountID, peerID, userID string) (*nbpeer.Peer, error) {
-	unlock := am.Store.AcquireAccountLock(accountID)
+	unlock := am.Store.AcquireAccountReadLock(accountID)
 	defer unlock()
 
 	account, err := am.Store.GetAccount(accountID)
@@ -722,8 +993,8 @@ func (am *DefaultAccountManager) GetPeer(accountID, peerID, userID string) (*nbp
 		return nil, status.Errorf(status.NotFound, "peer with %s not found under account %s", peerID, accountID)
 	}
 
-	// if admin or user owns this peer, return peer
-	if user.HasAdminPower() || user.IsServiceUser || peer.UserID == userID {
+	// if user can view all peers or owns this peer, return peer
+	if user.HasPermission(ResourcePeers, OperationRead) || peer.UserID == userID {
 		return peer, nil
 	}
 
@@ -746,6 +1017,12 @@ func (am *DefaultAccountManager) GetPeer(accountID, peerID, userID string) (*nbp
 	return nil, status.Errorf(status.Internal, "user %s has no access to peer %s under account %s", userID, peerID, accountID)
 }
 
+// defaultPeerName returns the name of a peer registering without a hostname, derived from its WireGuard public key
+func defaultPeerName(peerKey string) string {
+	sum := sha256.Sum256([]byte(peerKey))
+	return "peer-" + hex.EncodeToString(sum[:4])
+}
+
 func updatePeerMeta(peer *nbpeer.Peer, meta nbpeer.PeerSystemMeta, account *Account) (*nbpeer.Peer, bool) {
 	if peer.UpdateMetaIfNew(meta) {
 		account.UpdatePeer(peer)
@@ -754,14 +1031,38 @@ func updatePeerMeta(peer *nbpeer.Peer, meta nbpeer.PeerSystemMeta, account *Acco
 	return peer, false
 }
 
+// updatePeers updates the peers of the account with the IDs, when only their network maps changed
+func (am *DefaultAccountManager) updatePeers(account *Account, peerIDs []string) {
+	if am.maintenance.deferUpdate(account.Id) {
+		log.Debugf("account %s is in a maintenance window, deferring the update of its peers", account.Id)
+		return
+	}
+
+	for _, peerID := range peerIDs {
+		peer := account.GetPeer(peerID)
+		if peer == nil {
+			continue
+		}
+		remotePeerNetworkMap := account.GetPeerNetworkMap(peer.ID, am.dnsDomain)
+		update := toSyncResponse(nil, peer, nil, remotePeerNetworkMap, am.GetDNSDomain())
+		am.peersUpdateManager.SendDebouncedUpdate(peer.ID, &UpdateMessage{Update: update}, account.Settings.GetPeerUpdateDebounceWindow())
+	}
+}
+
 // updateAccountPeers updates all peers that belong to an account.
 // Should be called when changes have to be synced to peers.
 func (am *DefaultAccountManager) updateAccountPeers(account *Account) {
+	if am.maintenance.deferUpdate(account.Id) {
+		log.Debugf("account %s is in a maintenance window, deferring the update of its peers", account.Id)
+		return
+	}
+
 	peers := account.GetPeers()
+	window := account.Settings.GetPeerUpdateDebounceWindow()
 
 	for _, peer := range peers {
 		remotePeerNetworkMap := account.GetPeerNetworkMap(peer.ID, am.dnsDomain)
 		update := toSyncResponse(nil, peer, nil, remotePeerNetworkMap, am.GetDNSDomain())
-		am.peersUpdateManager.SendUpdate(peer.ID, &UpdateMessage{Update: update})
+		am.peersUpdateManager.SendDebouncedUpdate(peer.ID, &UpdateMessage{Update: update}, window)
 	}
 }
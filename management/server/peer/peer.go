@@ -3,7 +3,10 @@ package peer
 import (
 	"fmt"
 	"net"
+	"net/netip"
 	"time"
+
+	"golang.org/x/exp/slices"
 )
 
 // Peer represents a machine connected to the network.
@@ -19,6 +22,8 @@ type Peer struct {
 	SetupKey string
 	// IP address of the Peer
 	IP net.IP `gorm:"uniqueIndex:idx_peers_account_id_ip"`
+	// IPv6 address of the Peer, nil when the network has no IPv6 allocation
+	IPv6 net.IP
 	// Meta is a Peer system meta data
 	Meta PeerSystemMeta `gorm:"embedded;embeddedPrefix:meta_"`
 	// Name is peer's name (machine name)
@@ -34,6 +39,8 @@ type Peer struct {
 	SSHKey string
 	// SSHEnabled indicates whether SSH server is enabled on the peer
 	SSHEnabled bool
+	// ForceRelay makes the connections to and from the peer skip the direct connection attempts and go through a relay
+	ForceRelay bool
 	// LoginExpirationEnabled indicates whether peer's login expiration is enabled and once expired the peer has to re-login.
 	// Works with LastLogin
 	LoginExpirationEnabled bool
@@ -41,8 +48,36 @@ type Peer struct {
 	LastLogin time.Time
 	// Indicate ephemeral peer attribute
 	Ephemeral bool
+	// Location is the peer's last observed network location
+	Location Location `gorm:"embedded;embeddedPrefix:location_"`
+	// AdvertisedNetworks are the networks of the peer's local network the peer offers to route, as reported by the
+	// peer at login and sync. The routes through the peer are created by the administrators.
+	AdvertisedNetworks []netip.Prefix `gorm:"serializer:json"`
+}
+
+// Location holds the address the management service observed the peer connecting from
+type Location struct {
+	// ConnectionIP is the source IP of the peer's gRPC (TCP) connection to the management service.
+	// It is only a hint of the peer's public address: the WireGuard (UDP) traffic may leave through a different
+	// NAT mapping or even a different uplink, so it must never be used as an authoritative endpoint.
+	ConnectionIP net.IP
+	// MappedAddress is the public IP:port the peer mapped its WireGuard port to on its gateway with NAT-PMP or UPnP,
+	// as reported by the peer at login. Empty when the port isn't mapped.
+	MappedAddress string
+	// NATType is the type of the NAT the peer is behind as classified by the peer with STUN, one of NATTypeOpen,
+	// NATTypeCone and NATTypeSymmetric. Empty when unknown.
+	NATType string
 }
 
+const (
+	// NATTypeOpen is a peer with a public address, not behind a NAT
+	NATTypeOpen = "open"
+	// NATTypeCone is a NAT mapping a local address to the same public address for all the destinations
+	NATTypeCone = "cone"
+	// NATTypeSymmetric is a NAT mapping a local address to a different public address for every destination
+	NATTypeSymmetric = "symmetric"
+)
+
 type PeerStatus struct {
 	// LastSeen is the last time peer was connected to the management service
 	LastSeen time.Time
@@ -52,6 +87,15 @@ type PeerStatus struct {
 	LoginExpired bool
 	// RequiresApproval indicates whether peer requires approval or not
 	RequiresApproval bool
+	// Inactive indicates that the peer hasn't connected for longer than the inactivity threshold of the account.
+	// It is cleared when the peer connects again.
+	Inactive bool
+	// OutdatedClient indicates that the peer runs an older client than the minimum version of the account.
+	// It is updated on every login of the peer.
+	OutdatedClient bool
+	// NonCompliant indicates that the peer fails a posture check of the account and is quarantined from the network.
+	// It is updated on every login of the peer and when the posture checks change.
+	NonCompliant bool
 }
 
 // PeerSystemMeta is a metadata of a Peer machine system
@@ -82,6 +126,17 @@ func (p *Peer) AddedWithSSOLogin() bool {
 	return p.UserID != ""
 }
 
+// IsApproved indicates whether this peer doesn't wait for an administrator approval to join the network.
+func (p *Peer) IsApproved() bool {
+	return p.Status == nil || !p.Status.RequiresApproval
+}
+
+// IsQuarantined indicates whether this peer is excluded from the network, waiting for an approval or failing
+// a posture check
+func (p *Peer) IsQuarantined() bool {
+	return !p.IsApproved() || (p.Status != nil && p.Status.NonCompliant)
+}
+
 // Copy copies Peer object
 func (p *Peer) Copy() *Peer {
 	peerStatus := p.Status
@@ -94,6 +149,7 @@ func (p *Peer) Copy() *Peer {
 		Key:                    p.Key,
 		SetupKey:               p.SetupKey,
 		IP:                     p.IP,
+		IPv6:                   p.IPv6,
 		Meta:                   p.Meta,
 		Name:                   p.Name,
 		DNSLabel:               p.DNSLabel,
@@ -101,9 +157,12 @@ func (p *Peer) Copy() *Peer {
 		UserID:                 p.UserID,
 		SSHKey:                 p.SSHKey,
 		SSHEnabled:             p.SSHEnabled,
+		ForceRelay:             p.ForceRelay,
 		LoginExpirationEnabled: p.LoginExpirationEnabled,
 		LastLogin:              p.LastLogin,
 		Ephemeral:              p.Ephemeral,
+		Location:               p.Location,
+		AdvertisedNetworks:     slices.Clone(p.AdvertisedNetworks),
 	}
 }
 
@@ -115,6 +174,23 @@ func (p *Peer) UpdateMetaIfNew(meta PeerSystemMeta) bool {
 		meta.UIVersion = p.Meta.UIVersion
 	}
 
+	// Keep the fields the client omits for privacy, a blank value doesn't mean the system changed
+	if meta.Hostname == "" {
+		meta.Hostname = p.Meta.Hostname
+	}
+	if meta.Kernel == "" {
+		meta.Kernel = p.Meta.Kernel
+	}
+	if meta.Core == "" {
+		meta.Core = p.Meta.Core
+	}
+	if meta.Platform == "" {
+		meta.Platform = p.Meta.Platform
+	}
+	if meta.OS == "" {
+		meta.OS = p.Meta.OS
+	}
+
 	if p.Meta.isEqual(meta) {
 		return false
 	}
@@ -168,6 +244,9 @@ func (p *PeerStatus) Copy() *PeerStatus {
 		Connected:        p.Connected,
 		LoginExpired:     p.LoginExpired,
 		RequiresApproval: p.RequiresApproval,
+		Inactive:         p.Inactive,
+		OutdatedClient:   p.OutdatedClient,
+		NonCompliant:     p.NonCompliant,
 	}
 }
 
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/netip"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// toAdvertisedNetworks parses the networks advertised by the peer, the invalid ones are skipped
+func toAdvertisedNetworks(peerKey string, networks []string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, network := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			log.Warnf("ignoring the invalid network %q advertised by peer %s: %v", network, peerKey, err)
+			continue
+		}
+		prefix = prefix.Masked()
+		if !slices.Contains(prefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// validAdvertisedNetworks returns the networks advertised by the peer without the ones overlapping with the account
+// network, the traffic to the peer addresses can't be routed to the advertising peer
+func validAdvertisedNetworks(account *Account, peer *nbpeer.Peer, networks []netip.Prefix) []netip.Prefix {
+	var valid []netip.Prefix
+	for _, network := range networks {
+		if accountNet, ok := routeNetworkConflict(account.Network, network); ok {
+			log.Warnf("ignoring the network %s advertised by peer %s, it overlaps with the account network %s",
+				network, peer.ID, accountNet)
+			continue
+		}
+		valid = append(valid, network)
+	}
+	return valid
+}
+
+// updatePeerAdvertisedNetworks sets the networks advertised by the peer, returns true if they have changed
+func updatePeerAdvertisedNetworks(account *Account, peer *nbpeer.Peer, networks []netip.Prefix) bool {
+	valid := validAdvertisedNetworks(account, peer, networks)
+	if slices.Equal(peer.AdvertisedNetworks, valid) {
+		return false
+	}
+	peer.AdvertisedNetworks = valid
+	account.UpdatePeer(peer)
+	return true
+}
+
+// syncPeerReportedState stores the networks the peer advertised, the NAT type and the mapped address it reported on
+// sync and returns the updated peer and its network map. Sync doesn't hold the account write lock, so the change is
+// applied separately.
+func (am *DefaultAccountManager) syncPeerReportedState(sync PeerSync) (*nbpeer.Peer, *NetworkMap, error) {
+	account, err := am.Store.GetAccountByPeerPubKey(sync.WireGuardPubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unlock := am.Store.AcquireAccountLock(account.Id)
+	defer unlock()
+
+	account, err = am.Store.GetAccount(account.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	peer, err := account.FindPeerByPubKey(sync.WireGuardPubKey)
+	if err != nil {
+		return nil, nil, status.Errorf(status.Unauthenticated, "peer is not registered")
+	}
+
+	networksChanged := updatePeerAdvertisedNetworks(account, peer, sync.AdvertisedNetworks)
+	natTypeChanged := updatePeerNATType(account, peer, sync.NATType)
+	mappedAddressChanged := updatePeerMappedAddress(account, peer, sync.MappedAddress)
+	if networksChanged || natTypeChanged || mappedAddressChanged {
+		err = am.Store.SaveAccount(account)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if natTypeChanged || mappedAddressChanged {
+		am.updateAccountPeers(account)
+	}
+
+	return peer, account.GetPeerNetworkMap(peer.ID, am.dnsDomain), nil
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestToAdvertisedNetworks(t *testing.T) {
+	networks := toAdvertisedNetworks("peer-key", []string{"192.168.1.0/24", "10.0.0.1/8", "invalid", "192.168.1.10/24", "fd00::/64"})
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.168.1.0/24"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/64"),
+	}, networks, "invalid networks should be skipped and the others masked and deduplicated")
+
+	assert.Empty(t, toAdvertisedNetworks("peer-key", nil))
+}
+
+func TestDefaultAccountManager_PeerAdvertisedNetworks(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	lan := netip.MustParsePrefix("192.168.1.0/24")
+	accountNet, ok := ipNetToPrefix(account.Network.Net)
+	require.True(t, ok)
+
+	peerKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey:    peerKey.PublicKey().String(),
+		Meta:               nbpeer.PeerSystemMeta{Hostname: "gateway"},
+		SetupKey:           setupKey.Key,
+		AdvertisedNetworks: []netip.Prefix{lan, accountNet},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{lan}, peer.AdvertisedNetworks, "network overlapping the account network should be dropped")
+
+	// the networks changed on sync are stored
+	office := netip.MustParsePrefix("10.10.0.0/16")
+	synced, _, err := manager.SyncPeer(PeerSync{
+		WireGuardPubKey:    peerKey.PublicKey().String(),
+		AdvertisedNetworks: []netip.Prefix{lan, office},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{lan, office}, synced.AdvertisedNetworks)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{lan, office}, account.Peers[peer.ID].AdvertisedNetworks, "networks should be persisted")
+
+	// a login without the networks clears them
+	loggedIn, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: peerKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "gateway"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, loggedIn.AdvertisedNetworks)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Empty(t, account.Peers[peer.ID].AdvertisedNetworks)
+}
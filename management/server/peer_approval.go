@@ -0,0 +1,128 @@
+package server
+
+import (
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// GetPendingPeers returns the peers of the account waiting for an administrator approval
+func (am *DefaultAccountManager) GetPendingPeers(accountID, userID string) ([]*nbpeer.Peer, error) {
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPeerApprovalPermissions(account, userID, OperationRead); err != nil {
+		return nil, err
+	}
+
+	peers := make([]*nbpeer.Peer, 0)
+	for _, peer := range account.Peers {
+		if !peer.IsApproved() {
+			peers = append(peers, peer.Copy())
+		}
+	}
+	return peers, nil
+}
+
+// ApprovePeer lets the peer waiting for an approval join the network
+// and sends the updated network map to it and to the peers it can connect to
+func (am *DefaultAccountManager) ApprovePeer(accountID, userID, peerID string) (*nbpeer.Peer, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPeerApprovalPermissions(account, userID, OperationWrite); err != nil {
+		return nil, err
+	}
+
+	peer, err := getPendingPeer(account, peerID)
+	if err != nil {
+		return nil, err
+	}
+
+	peer.Status.RequiresApproval = false
+	account.UpdatePeer(peer)
+	account.Network.IncSerial()
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, peer.ID, accountID, activity.PeerApproved, peer.EventMeta(am.GetDNSDomain()))
+
+	return peer, nil
+}
+
+// RejectPeer deletes the peer waiting for an approval, so the device has to register again
+func (am *DefaultAccountManager) RejectPeer(accountID, userID, peerID string) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkPeerApprovalPermissions(account, userID, OperationWrite); err != nil {
+		return err
+	}
+
+	peer, err := getPendingPeer(account, peerID)
+	if err != nil {
+		return err
+	}
+
+	err = am.deletePeers(account, []string{peerID}, userID)
+	if err != nil {
+		return err
+	}
+
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, peer.ID, accountID, activity.PeerRejected, peer.EventMeta(am.GetDNSDomain()))
+
+	return nil
+}
+
+// checkPeerApprovalPermissions checks that the user can list the pending peers with OperationRead
+// or approve and reject them with OperationWrite
+func checkPeerApprovalPermissions(account *Account, userID string, operation Operation) error {
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasPermission(ResourcePeers, operation) {
+		if operation == OperationRead {
+			return status.Errorf(status.PermissionDenied, "user is not allowed to list the peers pending approval")
+		}
+		return status.Errorf(status.PermissionDenied, "user is not allowed to manage the peers approval")
+	}
+	return nil
+}
+
+func getPendingPeer(account *Account, peerID string) (*nbpeer.Peer, error) {
+	peer := account.GetPeer(peerID)
+	if peer == nil {
+		return nil, status.Errorf(status.NotFound, "peer %s not found", peerID)
+	}
+
+	if peer.IsApproved() {
+		return nil, status.Errorf(status.PreconditionFailed, "peer %s doesn't wait for an approval", peerID)
+	}
+	return peer, nil
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_PeerApproval(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	addPeer := func(hostname string) *nbpeer.Peer {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err, "registration should succeed even when the approval is required")
+		return peer
+	}
+
+	approvedPeer := addPeer("approved-peer")
+	assert.True(t, approvedPeer.IsApproved(), "peers added before enabling the approval should be approved")
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:  time.Hour,
+		PeerApprovalRequired: true,
+	})
+	require.NoError(t, err)
+
+	pendingPeer := addPeer("pending-peer")
+	rejectedPeer := addPeer("rejected-peer")
+	assert.False(t, pendingPeer.IsApproved(), "new peers should wait for an approval")
+
+	pendingPeers, err := manager.GetPendingPeers(account.Id, userID)
+	require.NoError(t, err)
+	assert.Len(t, pendingPeers, 2)
+
+	auditor := NewUser("auditor", UserRoleAuditor, false, false, "", []string{}, UserIssuedAPI)
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	account.Users[auditor.Id] = auditor
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	pendingPeers, err = manager.GetPendingPeers(account.Id, auditor.Id)
+	require.NoError(t, err, "users with the peers read permission should list the pending peers")
+	assert.Len(t, pendingPeers, 2)
+
+	_, err = manager.ApprovePeer(account.Id, auditor.Id, pendingPeer.ID)
+	assertStatusType(t, err, status.PermissionDenied)
+
+	networkMap, err := manager.GetNetworkMap(approvedPeer.ID)
+	require.NoError(t, err)
+	assert.Empty(t, networkMap.Peers, "peers waiting for an approval should not be in the network maps of other peers")
+
+	networkMap, err = manager.GetNetworkMap(pendingPeer.ID)
+	require.NoError(t, err)
+	assert.Empty(t, networkMap.Peers, "peers waiting for an approval should not get any peers")
+
+	approvedPeerUpdates := manager.peersUpdateManager.CreateChannel(approvedPeer.ID)
+	pendingPeerUpdates := manager.peersUpdateManager.CreateChannel(pendingPeer.ID)
+	t.Cleanup(func() {
+		manager.peersUpdateManager.CloseChannel(approvedPeer.ID)
+		manager.peersUpdateManager.CloseChannel(pendingPeer.ID)
+	})
+
+	peer, err := manager.ApprovePeer(account.Id, userID, pendingPeer.ID)
+	require.NoError(t, err)
+	assert.True(t, peer.IsApproved())
+
+	for _, updates := range []chan *UpdateMessage{approvedPeerUpdates, pendingPeerUpdates} {
+		select {
+		case update := <-updates:
+			assert.Len(t, update.Update.GetNetworkMap().GetRemotePeers(), 1)
+		case <-time.After(time.Second):
+			t.Fatal("expecting a network map update after the approval")
+		}
+	}
+
+	_, err = manager.ApprovePeer(account.Id, userID, pendingPeer.ID)
+	sErr, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.PreconditionFailed, sErr.Type(), "approving an approved peer should fail")
+
+	err = manager.RejectPeer(account.Id, userID, rejectedPeer.ID)
+	require.NoError(t, err)
+
+	_, err = manager.GetPeer(account.Id, rejectedPeer.ID, userID)
+	assert.Error(t, err, "rejected peer should be deleted")
+
+	pendingPeers, err = manager.GetPendingPeers(account.Id, userID)
+	require.NoError(t, err)
+	assert.Empty(t, pendingPeers)
+}
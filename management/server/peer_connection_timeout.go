@@ -0,0 +1,37 @@
+package server
+
+import (
+	"time"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// MinPeerConnectionTimeout is the shortest connection timeout an account can set, the ICE negotiation of the
+	// peers behind slow or relayed networks takes a few seconds
+	MinPeerConnectionTimeout = 5 * time.Second
+	// MaxPeerConnectionTimeout is the longest connection timeout an account can set
+	MaxPeerConnectionTimeout = 5 * time.Minute
+)
+
+// GetPeerConnectionTimeout returns the timeout of the connection attempts of the account peers to their remote
+// peers, zero if the account hasn't set one and the peers use the default of their client
+func (s *Settings) GetPeerConnectionTimeout() time.Duration {
+	if s == nil || s.PeerConnectionTimeout == nil {
+		return 0
+	}
+	return *s.PeerConnectionTimeout
+}
+
+func validatePeerConnectionTimeout(settings *Settings) error {
+	if settings.PeerConnectionTimeout == nil {
+		return nil
+	}
+
+	timeout := *settings.PeerConnectionTimeout
+	if timeout < MinPeerConnectionTimeout || timeout > MaxPeerConnectionTimeout {
+		return status.Errorf(status.InvalidArgument, "peer connection timeout has to be between %s and %s",
+			MinPeerConnectionTimeout, MaxPeerConnectionTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestValidatePeerConnectionTimeout(t *testing.T) {
+	dr := func(d time.Duration) *time.Duration { return &d }
+
+	testCases := []struct {
+		name    string
+		timeout *time.Duration
+		valid   bool
+	}{
+		{name: "default of the clients", valid: true},
+		{name: "shortest timeout", timeout: dr(MinPeerConnectionTimeout), valid: true},
+		{name: "longest timeout", timeout: dr(MaxPeerConnectionTimeout), valid: true},
+		{name: "too short timeout", timeout: dr(MinPeerConnectionTimeout - time.Millisecond)},
+		{name: "too long timeout", timeout: dr(MaxPeerConnectionTimeout + time.Millisecond)},
+		{name: "zero timeout", timeout: dr(0)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validatePeerConnectionTimeout(&Settings{PeerConnectionTimeout: testCase.timeout})
+			if testCase.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assertStatusType(t, err, status.InvalidArgument)
+		})
+	}
+}
+
+func TestDefaultAccountManager_UpdateAccountSettings_PeerConnectionTimeout(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+
+	key, err := wgtypes.GenerateKey()
+	require.NoError(t, err, "unable to generate WireGuard key")
+	peer, _, err := manager.AddPeer("", userID, &nbpeer.Peer{
+		Key:  key.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "test-peer"},
+	})
+	require.NoError(t, err, "unable to add peer")
+
+	config := &Config{Signal: &Host{Proto: HTTP, URI: "signal.netbird.io:10000"}, TURNConfig: &TURNConfig{}}
+	networkMap := account.GetPeerNetworkMap(peer.ID, "netbird.io")
+	assert.Zero(t, toWiretrusteeConfig(config, nil, networkMap.Signal, networkMap.ConnectionTimeout).GetConnectionTimeout(),
+		"the peers should use the default of their client")
+
+	updates := manager.peersUpdateManager.CreateChannel(peer.ID)
+
+	timeout := 20 * time.Second
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:   time.Hour,
+		PeerConnectionTimeout: &timeout,
+	})
+	require.NoError(t, err, "unable to update the connection timeout")
+
+	select {
+	case _, open := <-updates:
+		assert.False(t, open, "the sync stream of the peer should be closed to pick up the connection timeout")
+	case <-time.After(time.Second):
+		t.Fatal("the sync stream of the peer wasn't closed")
+	}
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	networkMap = account.GetPeerNetworkMap(peer.ID, "netbird.io")
+	assert.Equal(t, timeout, networkMap.ConnectionTimeout)
+	assert.Equal(t, int64(20000), toWiretrusteeConfig(config, nil, networkMap.Signal, networkMap.ConnectionTimeout).GetConnectionTimeout())
+
+	ev := getEvent(t, account.Id, manager, activity.AccountPeerConnectionTimeoutUpdated)
+	assert.Equal(t, "20s", ev.Meta["timeout"])
+
+	timeout = time.Second
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:   time.Hour,
+		PeerConnectionTimeout: &timeout,
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+}
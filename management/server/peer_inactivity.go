@@ -0,0 +1,218 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// PeerInactivityActionFlag marks the inactive peers with PeerStatus.Inactive, they keep their place in the network
+	PeerInactivityActionFlag = "flag"
+	// PeerInactivityActionDelete deletes the inactive peers like a user deleting them would
+	PeerInactivityActionDelete = "delete"
+
+	// DefaultPeerInactivitySweepInterval is the default interval of cleaning up the inactive peers
+	DefaultPeerInactivitySweepInterval = time.Hour
+	// MinPeerInactivityThreshold is the shortest inactivity threshold an account can set, so that a peer
+	// switched off for a weekend or a management service outage doesn't get the peers deleted
+	MinPeerInactivityThreshold = 7 * 24 * time.Hour
+
+	peerInactivityJobID = "peer-inactivity-sweep"
+)
+
+// InactivePeer is a peer the inactivity cleanup of the account applies to
+type InactivePeer struct {
+	Peer *nbpeer.Peer
+	// InactiveFor is how long ago the peer was last connected
+	InactiveFor time.Duration
+}
+
+// StartPeerInactivitySweep starts a background job that applies the inactivity cleanup to the peers of the accounts
+// having it enabled every interval.
+func (am *DefaultAccountManager) StartPeerInactivitySweep(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPeerInactivitySweepInterval
+	}
+
+	log.Infof("cleaning up inactive peers every %s", interval)
+	go am.peerInactivity.Schedule(interval, peerInactivityJobID, func() (time.Duration, bool) {
+		am.cleanupInactivePeers()
+		return interval, true
+	})
+}
+
+// StopPeerInactivitySweep stops the background job cleaning up the inactive peers
+func (am *DefaultAccountManager) StopPeerInactivitySweep() {
+	am.peerInactivity.Cancel([]string{peerInactivityJobID})
+}
+
+// GetInactivePeers returns the peers the inactivity cleanup of the account would apply to on its next run, so they
+// can be reviewed before it deletes anything. A positive threshold overrides the one of the account settings,
+// allowing to preview a threshold before it is saved.
+func (am *DefaultAccountManager) GetInactivePeers(accountID, userID string, threshold time.Duration) ([]*InactivePeer, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourcePeers, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view the inactive peers")
+	}
+
+	if threshold <= 0 {
+		threshold = account.Settings.PeerInactivityThreshold
+	}
+	if threshold < MinPeerInactivityThreshold {
+		return nil, status.Errorf(status.InvalidArgument, "inactivity threshold can't be smaller than %s", MinPeerInactivityThreshold)
+	}
+
+	return account.getInactivePeers(threshold, time.Now().UTC()), nil
+}
+
+func (am *DefaultAccountManager) cleanupInactivePeers() {
+	for _, account := range am.Store.GetAllAccounts() {
+		if !account.Settings.PeerInactivityCleanupEnabled {
+			continue
+		}
+
+		if err := am.cleanupAccountInactivePeers(account.Id); err != nil {
+			log.Errorf("failed cleaning up inactive peers of account %s: %v", account.Id, err)
+		}
+	}
+}
+
+// cleanupAccountInactivePeers flags or deletes the inactive peers of the account depending on its settings.
+// The deleted peers are removed the same way DeletePeer removes them, so their neighbors are updated.
+func (am *DefaultAccountManager) cleanupAccountInactivePeers(accountID string) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	settings := account.Settings
+	if !settings.PeerInactivityCleanupEnabled || settings.PeerInactivityThreshold < MinPeerInactivityThreshold {
+		return nil
+	}
+
+	var peers []*nbpeer.Peer
+	for _, inactive := range account.getInactivePeers(settings.PeerInactivityThreshold, time.Now().UTC()) {
+		if settings.PeerInactivityAction != PeerInactivityActionDelete && inactive.Peer.Status.Inactive {
+			continue
+		}
+		peers = append(peers, account.GetPeer(inactive.Peer.ID))
+	}
+
+	if len(peers) == 0 {
+		return nil
+	}
+
+	if settings.PeerInactivityAction == PeerInactivityActionDelete {
+		return am.deleteInactivePeers(account, peers)
+	}
+
+	for _, peer := range peers {
+		newStatus := peer.Status.Copy()
+		newStatus.Inactive = true
+		peer.Status = newStatus
+		account.UpdatePeer(peer)
+	}
+
+	if err = am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	log.Debugf("flagged %d inactive peers of account %s", len(peers), accountID)
+
+	for _, peer := range peers {
+		am.StoreEvent(activity.SystemInitiator, peer.ID, accountID, activity.PeerFlaggedInactive, peer.EventMeta(am.GetDNSDomain()))
+	}
+
+	return nil
+}
+
+func (am *DefaultAccountManager) deleteInactivePeers(account *Account, peers []*nbpeer.Peer) error {
+	peerIDs := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		peerIDs = append(peerIDs, peer.ID)
+	}
+
+	if err := am.deletePeers(account, peerIDs, activity.SystemInitiator); err != nil {
+		return err
+	}
+
+	if err := am.Store.SaveAccount(account); err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	log.Debugf("removed %d inactive peers of account %s", len(peers), account.Id)
+
+	for _, peer := range peers {
+		am.StoreEvent(activity.SystemInitiator, peer.ID, account.Id, activity.PeerRemovedForInactivity, peer.EventMeta(am.GetDNSDomain()))
+	}
+
+	return nil
+}
+
+// getInactivePeers returns the disconnected peers last seen before the threshold, the longest inactive first.
+// The ephemeral peers are left to the ephemeral manager and the peers never seen are skipped.
+func (a *Account) getInactivePeers(threshold time.Duration, now time.Time) []*InactivePeer {
+	var inactive []*InactivePeer
+	for _, peer := range a.Peers {
+		if peer.Ephemeral || peer.Status == nil || peer.Status.Connected || peer.Status.LastSeen.IsZero() {
+			continue
+		}
+
+		inactiveFor := now.Sub(peer.Status.LastSeen)
+		if inactiveFor < threshold {
+			continue
+		}
+
+		inactive = append(inactive, &InactivePeer{Peer: peer.Copy(), InactiveFor: inactiveFor})
+	}
+
+	sort.Slice(inactive, func(i, j int) bool {
+		if inactive[i].InactiveFor != inactive[j].InactiveFor {
+			return inactive[i].InactiveFor > inactive[j].InactiveFor
+		}
+		return inactive[i].Peer.ID < inactive[j].Peer.ID
+	})
+
+	return inactive
+}
+
+func validatePeerInactivitySettings(settings *Settings) error {
+	if !settings.PeerInactivityCleanupEnabled {
+		return nil
+	}
+
+	if settings.PeerInactivityThreshold < MinPeerInactivityThreshold {
+		return status.Errorf(status.InvalidArgument, "peer inactivity threshold can't be smaller than %s", MinPeerInactivityThreshold)
+	}
+
+	switch settings.PeerInactivityAction {
+	case PeerInactivityActionFlag, PeerInactivityActionDelete:
+		return nil
+	default:
+		return status.Errorf(status.InvalidArgument, "invalid peer inactivity action %q, expected %s or %s",
+			settings.PeerInactivityAction, PeerInactivityActionFlag, PeerInactivityActionDelete)
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_CleanupInactivePeers(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	addPeer := func(hostname string) *nbpeer.Peer {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		return peer
+	}
+
+	activePeer := addPeer("active")
+	recentPeer := addPeer("recent")
+	stalePeer := addPeer("stale")
+	oldestPeer := addPeer("oldest")
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	account.Peers[activePeer.ID].Status = &nbpeer.PeerStatus{Connected: true, LastSeen: now.Add(-60 * 24 * time.Hour)}
+	account.Peers[recentPeer.ID].Status = &nbpeer.PeerStatus{LastSeen: now.Add(-24 * time.Hour)}
+	account.Peers[stalePeer.ID].Status = &nbpeer.PeerStatus{LastSeen: now.Add(-40 * 24 * time.Hour)}
+	account.Peers[oldestPeer.ID].Status = &nbpeer.PeerStatus{LastSeen: now.Add(-90 * 24 * time.Hour)}
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, err = manager.GetInactivePeers(account.Id, userID, 0)
+	assertStatusType(t, err, status.InvalidArgument)
+
+	inactive, err := manager.GetInactivePeers(account.Id, userID, 30*24*time.Hour)
+	require.NoError(t, err)
+	if assert.Len(t, inactive, 2, "only the disconnected peers last seen before the threshold should be listed") {
+		assert.Equal(t, oldestPeer.ID, inactive[0].Peer.ID, "the longest inactive peer should be listed first")
+		assert.Equal(t, stalePeer.ID, inactive[1].Peer.ID)
+	}
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:          time.Hour,
+		PeerInactivityCleanupEnabled: true,
+		PeerInactivityThreshold:      time.Hour,
+		PeerInactivityAction:         PeerInactivityActionDelete,
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:          time.Hour,
+		PeerInactivityCleanupEnabled: true,
+		PeerInactivityThreshold:      30 * 24 * time.Hour,
+		PeerInactivityAction:         "archive",
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:          time.Hour,
+		PeerInactivityCleanupEnabled: true,
+		PeerInactivityThreshold:      30 * 24 * time.Hour,
+		PeerInactivityAction:         PeerInactivityActionFlag,
+	})
+	require.NoError(t, err)
+
+	manager.cleanupInactivePeers()
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Len(t, account.Peers, 4, "flagging shouldn't remove the peers")
+	assert.True(t, account.Peers[stalePeer.ID].Status.Inactive)
+	assert.True(t, account.Peers[oldestPeer.ID].Status.Inactive)
+	assert.False(t, account.Peers[recentPeer.ID].Status.Inactive)
+	assert.False(t, account.Peers[activePeer.ID].Status.Inactive)
+
+	err = manager.MarkPeerConnected(stalePeer.Key, true, nil)
+	require.NoError(t, err)
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.False(t, account.Peers[stalePeer.ID].Status.Inactive, "the flag should be cleared when the peer connects")
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:          time.Hour,
+		PeerInactivityCleanupEnabled: true,
+		PeerInactivityThreshold:      30 * 24 * time.Hour,
+		PeerInactivityAction:         PeerInactivityActionDelete,
+	})
+	require.NoError(t, err)
+
+	updates := manager.peersUpdateManager.CreateChannel(activePeer.ID)
+	t.Cleanup(func() {
+		manager.peersUpdateManager.CloseChannel(activePeer.ID)
+	})
+
+	manager.cleanupInactivePeers()
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Len(t, account.Peers, 3)
+	assert.Nil(t, account.Peers[oldestPeer.ID], "the inactive peer should be removed")
+
+	waitDelivered(t, manager.peersUpdateManager, activePeer.ID)
+	require.Len(t, updates, 1, "the remaining peers should be updated")
+	update := <-updates
+	assert.Len(t, update.Update.NetworkMap.RemotePeers, 2)
+
+	// events are stored asynchronously
+	assert.Eventually(t, func() bool {
+		events, err := manager.eventStore.Get(account.Id, 0, 100, false)
+		if err != nil {
+			return false
+		}
+		flagged, removed := 0, 0
+		for _, event := range events {
+			switch event.Activity {
+			case activity.PeerFlaggedInactive:
+				flagged++
+			case activity.PeerRemovedForInactivity:
+				removed++
+				assert.Equal(t, activity.SystemInitiator, event.InitiatorID)
+				assert.Equal(t, oldestPeer.ID, event.TargetID)
+			}
+		}
+		return flagged == 2 && removed == 1
+	}, time.Second, 10*time.Millisecond, "the cleanup events should be stored")
+}
+
+func TestDefaultAccountManager_GetInactivePeersPermissions(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := createAccount(manager, "test_account", "account_creator", "")
+	require.NoError(t, err)
+
+	account.Users["regular_user"] = NewRegularUser("regular_user")
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, err = manager.GetInactivePeers(account.Id, "regular_user", 30*24*time.Hour)
+	assertStatusType(t, err, status.PermissionDenied)
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// RotatePeerKey replaces the WireGuard public key of the peer with a new one, keeping its IP, name and groups.
+// The old key can't be used to log in anymore and the remote peers are sent the new key.
+// The caller has to make sure the peer owns both keys.
+func (am *DefaultAccountManager) RotatePeerKey(oldPeerKey, newPeerKey string) (*nbpeer.Peer, error) {
+	if _, err := wgtypes.ParseKey(newPeerKey); err != nil {
+		return nil, status.Errorf(status.InvalidArgument, "invalid new WireGuard public key")
+	}
+	if oldPeerKey == newPeerKey {
+		return nil, status.Errorf(status.InvalidArgument, "the new WireGuard public key is the current one")
+	}
+
+	account, err := am.Store.GetAccountByPeerPubKey(oldPeerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := am.Store.AcquireAccountLock(account.Id)
+	defer unlock()
+
+	account, err = am.Store.GetAccount(account.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	peer, err := account.FindPeerByPubKey(oldPeerKey)
+	if err != nil {
+		return nil, status.Errorf(status.Unauthenticated, "peer is not registered")
+	}
+
+	err = checkIfPeerOwnerIsBlocked(peer, account)
+	if err != nil {
+		return nil, err
+	}
+
+	// an expired peer has to log in again first, the rotation must not extend a session the owner didn't renew
+	if peerLoginExpired(peer, account) {
+		return nil, status.Errorf(status.PermissionDenied, "peer login has expired, please log in once again")
+	}
+
+	// the keys are unique across the accounts
+	if _, err = am.Store.GetAccountByPeerPubKey(newPeerKey); err == nil {
+		return nil, status.Errorf(status.AlreadyExists, "the new WireGuard public key is already in use")
+	} else if errStatus, ok := status.FromError(err); !ok || errStatus.Type() != status.NotFound {
+		return nil, err
+	}
+
+	// the stored peer is replaced by a rotated copy rather than changed in place
+	peer = peer.Copy()
+	peer.Key = newPeerKey
+	peer.Status.Connected = false
+	account.UpdatePeer(peer)
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	// the stream of the old key is closed, the peer connects again with the new one
+	am.peersUpdateManager.CloseChannel(peer.ID)
+
+	initiator := activity.SystemInitiator
+	if peer.AddedWithSSOLogin() {
+		initiator = peer.UserID
+	}
+	am.StoreEvent(initiator, peer.ID, account.Id, activity.PeerKeyRotated, peer.EventMeta(am.GetDNSDomain()))
+	log.Infof("rotated the WireGuard key of peer %s", peer.ID)
+
+	am.updateAccountPeers(account)
+
+	return peer.Copy(), nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_RotatePeerKey(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	addPeer := func(hostname string) (wgtypes.Key, *nbpeer.Peer) {
+		t.Helper()
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		return key, peer
+	}
+
+	oldKey, peer1 := addPeer("test-peer-1")
+	otherKey, peer2 := addPeer("test-peer-2")
+
+	newKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	_, err = manager.RotatePeerKey(oldKey.PublicKey().String(), oldKey.PublicKey().String())
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.RotatePeerKey(oldKey.PublicKey().String(), "invalid")
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.RotatePeerKey(oldKey.PublicKey().String(), otherKey.PublicKey().String())
+	assertStatusType(t, err, status.AlreadyExists)
+
+	rotated, err := manager.RotatePeerKey(oldKey.PublicKey().String(), newKey.PublicKey().String())
+	require.NoError(t, err)
+	assert.Equal(t, peer1.ID, rotated.ID)
+	assert.Equal(t, newKey.PublicKey().String(), rotated.Key)
+	assert.Equal(t, peer1.IP, rotated.IP)
+	assert.Equal(t, peer1.Name, rotated.Name)
+	assert.Equal(t, peer1.DNSLabel, rotated.DNSLabel)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	_, err = account.FindPeerByPubKey(oldKey.PublicKey().String())
+	require.Error(t, err, "the old key should be invalidated")
+	assert.Contains(t, account.getPeerGroups(peer1.ID), mustGroupAllID(t, account), "the groups should be kept")
+
+	_, err = manager.Store.GetAccountByPeerPubKey(oldKey.PublicKey().String())
+	assertStatusType(t, err, status.NotFound)
+
+	_, err = manager.RotatePeerKey(oldKey.PublicKey().String(), otherKey.PublicKey().String())
+	assertStatusType(t, err, status.NotFound)
+
+	// the remote peers get the new key
+	networkMap, err := manager.GetNetworkMap(peer2.ID)
+	require.NoError(t, err)
+	require.Len(t, networkMap.Peers, 1)
+	assert.Equal(t, newKey.PublicKey().String(), networkMap.Peers[0].Key)
+}
+
+func mustGroupAllID(t *testing.T, account *Account) string {
+	t.Helper()
+	group, err := account.GetGroupAll()
+	require.NoError(t, err)
+	return group.ID
+}
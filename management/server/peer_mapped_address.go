@@ -0,0 +1,16 @@
+package server
+
+import (
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+// updatePeerMappedAddress sets the address the peer mapped its WireGuard port to, returns true if it has changed.
+// A peer which stopped mapping its port reports an empty address, so the stale mapping isn't shared anymore.
+func updatePeerMappedAddress(account *Account, peer *nbpeer.Peer, address string) bool {
+	if peer.Location.MappedAddress == address {
+		return false
+	}
+	peer.Location.MappedAddress = address
+	account.UpdatePeer(peer)
+	return true
+}
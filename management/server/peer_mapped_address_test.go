@@ -0,0 +1,65 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestDefaultAccountManager_PeerMappedAddress(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	peerKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: peerKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "laptop"},
+		SetupKey:        setupKey.Key,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, peer.Location.MappedAddress)
+
+	remoteKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	remote, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: remoteKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "server"},
+		SetupKey:        setupKey.Key,
+	})
+	require.NoError(t, err)
+
+	// the port mapped after the login is reported on sync
+	synced, _, err := manager.SyncPeer(PeerSync{
+		WireGuardPubKey: peerKey.PublicKey().String(),
+		MappedAddress:   "203.0.113.10:51820",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.10:51820", synced.Location.MappedAddress)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.10:51820", account.Peers[peer.ID].Location.MappedAddress, "mapped address should be persisted")
+
+	networkMap := account.GetPeerNetworkMap(remote.ID, "netbird.cloud")
+	remotePeers := toRemotePeerConfig(remote, networkMap.Peers, "netbird.cloud")
+	require.Len(t, remotePeers, 1)
+	assert.Equal(t, "203.0.113.10:51820", remotePeers[0].GetMappedAddress())
+
+	// a peer which stopped mapping its port clears the address
+	synced, _, err = manager.SyncPeer(PeerSync{WireGuardPubKey: peerKey.PublicKey().String()})
+	require.NoError(t, err)
+	assert.Empty(t, synced.Location.MappedAddress)
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+// toNATType validates the NAT type reported by the peer, an unknown type is ignored
+func toNATType(peerKey string, natType string) string {
+	switch natType {
+	case "", nbpeer.NATTypeOpen, nbpeer.NATTypeCone, nbpeer.NATTypeSymmetric:
+		return natType
+	default:
+		log.Debugf("ignoring the unknown NAT type %q reported by peer %s", natType, peerKey)
+		return ""
+	}
+}
+
+// updatePeerNATType sets the NAT type reported by the peer, returns true if it has changed. An empty NAT type keeps
+// the stored one, the peer may not have classified its NAT yet.
+func updatePeerNATType(account *Account, peer *nbpeer.Peer, natType string) bool {
+	if natType == "" || peer.Location.NATType == natType {
+		return false
+	}
+	peer.Location.NATType = natType
+	account.UpdatePeer(peer)
+	return true
+}
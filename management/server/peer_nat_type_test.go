@@ -0,0 +1,77 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+)
+
+func TestToNATType(t *testing.T) {
+	for _, natType := range []string{"", nbpeer.NATTypeOpen, nbpeer.NATTypeCone, nbpeer.NATTypeSymmetric} {
+		assert.Equal(t, natType, toNATType("peer-key", natType))
+	}
+	assert.Empty(t, toNATType("peer-key", "full-cone"), "unknown NAT type should be ignored")
+}
+
+func TestDefaultAccountManager_PeerNATType(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	peerKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	peer, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: peerKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "laptop"},
+		SetupKey:        setupKey.Key,
+		NATType:         nbpeer.NATTypeCone,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, nbpeer.NATTypeCone, peer.Location.NATType)
+
+	remoteKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	remote, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: remoteKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "server"},
+		SetupKey:        setupKey.Key,
+	})
+	require.NoError(t, err)
+
+	// the NAT type changed on sync is stored
+	synced, _, err := manager.SyncPeer(PeerSync{
+		WireGuardPubKey: peerKey.PublicKey().String(),
+		NATType:         nbpeer.NATTypeSymmetric,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, nbpeer.NATTypeSymmetric, synced.Location.NATType)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, nbpeer.NATTypeSymmetric, account.Peers[peer.ID].Location.NATType, "NAT type should be persisted")
+
+	// the remote peers get the NAT type of the peer
+	networkMap := account.GetPeerNetworkMap(remote.ID, "netbird.cloud")
+	remotePeers := toRemotePeerConfig(remote, networkMap.Peers, "netbird.cloud")
+	require.Len(t, remotePeers, 1)
+	assert.Equal(t, nbpeer.NATTypeSymmetric, remotePeers[0].GetNatType())
+
+	// a login without the NAT type keeps it, the peer hasn't classified its NAT yet
+	loggedIn, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: peerKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "laptop"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, nbpeer.NATTypeSymmetric, loggedIn.Location.NATType)
+}
@@ -1,15 +1,30 @@
 package server
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/rs/xid"
+	"golang.org/x/crypto/ssh"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 
+	"github.com/netbirdio/netbird/management/server/activity"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
 )
 
 func TestPeer_LoginExpired(t *testing.T) {
@@ -136,6 +151,71 @@ func TestAccountManager_GetNetworkMap(t *testing.T) {
 	}
 }
 
+func TestDefaultAccountManager_GetPeerNetworkMapPreview(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	adminUser := "account_creator"
+	account, err := createAccount(manager, "test_account", adminUser, "")
+	require.NoError(t, err)
+
+	account.Users["regular_user"] = NewRegularUser("regular_user")
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, adminUser, false)
+	require.NoError(t, err)
+
+	var peers []*nbpeer.Peer
+	for _, hostname := range []string{"test-peer-1", "test-peer-2"} {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: hostname},
+		})
+		require.NoError(t, err)
+		peers = append(peers, peer)
+	}
+
+	expected, err := manager.GetNetworkMap(peers[0].ID)
+	require.NoError(t, err)
+
+	networkMap, err := manager.GetPeerNetworkMapPreview(account.Id, peers[0].ID, adminUser)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Network.CurrentSerial(), networkMap.Network.CurrentSerial())
+	assert.Equal(t, expected.Peers, networkMap.Peers)
+	assert.Equal(t, expected.FirewallRules, networkMap.FirewallRules)
+	assert.Equal(t, expected.DNSConfig.ServiceEnable, networkMap.DNSConfig.ServiceEnable)
+	if assert.Len(t, networkMap.DNSConfig.CustomZones, len(expected.DNSConfig.CustomZones)) {
+		for i, zone := range expected.DNSConfig.CustomZones {
+			assert.Equal(t, zone.Domain, networkMap.DNSConfig.CustomZones[i].Domain)
+			assert.ElementsMatch(t, zone.Records, networkMap.DNSConfig.CustomZones[i].Records)
+		}
+	}
+	if assert.Len(t, networkMap.Peers, 1) {
+		assert.Equal(t, peers[1].ID, networkMap.Peers[0].ID)
+	}
+
+	_, err = manager.GetPeerNetworkMapPreview(account.Id, peers[0].ID, "regular_user")
+	assertStatusType(t, err, status.PermissionDenied)
+
+	// the peers of the other accounts are out of the scope of the user
+	otherAccount, err := createAccount(manager, "other_account", "other_user", "")
+	require.NoError(t, err)
+	otherSetupKey, err := manager.CreateSetupKey(otherAccount.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, "other_user", false)
+	require.NoError(t, err)
+	otherKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	otherPeer, _, err := manager.AddPeer(otherSetupKey.Key, "", &nbpeer.Peer{
+		Key:  otherKey.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "other-peer"},
+	})
+	require.NoError(t, err)
+
+	_, err = manager.GetPeerNetworkMapPreview(account.Id, otherPeer.ID, adminUser)
+	assertStatusType(t, err, status.NotFound)
+}
+
 func TestAccountManager_GetNetworkMapWithPolicy(t *testing.T) {
 	// TODO: disable until we start use policy again
 	t.Skip()
@@ -480,3 +560,622 @@ func TestDefaultAccountManager_GetPeer(t *testing.T) {
 	}
 	assert.NotNil(t, peer)
 }
+
+func TestDefaultAccountManager_ConcurrentSyncAndMarkPeerConnected(t *testing.T) {
+	manager, err := createManager(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peerKeys := addTestPeers(t, manager, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		peerKey := peerKeys[i%len(peerKeys)]
+		connected := i%2 == 0
+		go func() {
+			defer wg.Done()
+			_, _, err := manager.SyncPeer(PeerSync{WireGuardPubKey: peerKey})
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, manager.MarkPeerConnected(peerKey, connected, nil))
+		}()
+	}
+
+	if waitTimeout(&wg, 30*time.Second) {
+		t.Fatal("concurrent sync and mark peer connected calls didn't finish in time")
+	}
+}
+
+func TestDefaultAccountManager_MarkPeerConnectedStoresLocation(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	peerKey := addTestPeers(t, manager, 1)[0]
+	observedIP := net.ParseIP("198.51.100.10")
+
+	err = manager.MarkPeerConnected(peerKey, true, observedIP)
+	require.NoError(t, err)
+
+	account, err := manager.Store.GetAccountByPeerPubKey(peerKey)
+	require.NoError(t, err)
+	peer, err := account.FindPeerByPubKey(peerKey)
+	require.NoError(t, err)
+	assert.True(t, observedIP.Equal(peer.Location.ConnectionIP), "connection IP should be stored")
+
+	// disconnecting keeps the last observed location
+	err = manager.MarkPeerConnected(peerKey, false, nil)
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccountByPeerPubKey(peerKey)
+	require.NoError(t, err)
+	peer, err = account.FindPeerByPubKey(peerKey)
+	require.NoError(t, err)
+	assert.True(t, observedIP.Equal(peer.Location.ConnectionIP), "connection IP should be kept on disconnect")
+}
+
+func TestDefaultAccountManager_MarkPeerConnectedUpdatesObservedIP(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	peerKeys := addTestPeers(t, manager, 2)
+	account, err := manager.Store.GetAccountByPeerPubKey(peerKeys[0])
+	require.NoError(t, err)
+	remotePeer, err := account.FindPeerByPubKey(peerKeys[1])
+	require.NoError(t, err)
+
+	updates := manager.peersUpdateManager.CreateChannel(remotePeer.ID)
+	t.Cleanup(func() {
+		manager.peersUpdateManager.CloseChannel(remotePeer.ID)
+	})
+
+	expectObservedIP := func(observedIP string) {
+		t.Helper()
+		select {
+		case update := <-updates:
+			remotePeers := update.Update.GetNetworkMap().GetRemotePeers()
+			require.Len(t, remotePeers, 1)
+			assert.Equal(t, observedIP, remotePeers[0].GetObservedIP())
+		case <-time.After(time.Second):
+			t.Fatal("expecting a network map update with the observed IP of the peer")
+		}
+	}
+
+	expectNoUpdate := func() {
+		t.Helper()
+		select {
+		case <-updates:
+			t.Fatal("unexpected network map update")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	err = manager.MarkPeerConnected(peerKeys[0], true, net.ParseIP("198.51.100.10"))
+	require.NoError(t, err)
+	expectObservedIP("198.51.100.10")
+
+	err = manager.MarkPeerConnected(peerKeys[0], true, net.ParseIP("198.51.100.10"))
+	require.NoError(t, err)
+	expectNoUpdate()
+
+	err = manager.MarkPeerConnected(peerKeys[0], true, net.ParseIP("203.0.113.20"))
+	require.NoError(t, err)
+	expectObservedIP("203.0.113.20")
+}
+
+func TestToObservedIP(t *testing.T) {
+	tt := []struct {
+		name     string
+		ip       net.IP
+		expected string
+	}{
+		{name: "nil", ip: nil, expected: ""},
+		{name: "public IPv4", ip: net.ParseIP("198.51.100.10"), expected: "198.51.100.10"},
+		{name: "public IPv6", ip: net.ParseIP("2001:db8::1"), expected: "2001:db8::1"},
+		{name: "private IPv4", ip: net.ParseIP("192.168.1.10"), expected: ""},
+		{name: "shared address space", ip: net.ParseIP("100.64.0.10"), expected: ""},
+		{name: "loopback", ip: net.ParseIP("127.0.0.1"), expected: ""},
+		{name: "link-local", ip: net.ParseIP("fe80::1"), expected: ""},
+		{name: "unspecified", ip: net.IPv4zero, expected: ""},
+	}
+
+	for _, c := range tt {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, toObservedIP(c.ip))
+		})
+	}
+}
+
+func TestToMappedAddress(t *testing.T) {
+	tt := []struct {
+		name     string
+		address  string
+		expected string
+	}{
+		{name: "empty", address: "", expected: ""},
+		{name: "public IPv4", address: "198.51.100.10:51820", expected: "198.51.100.10:51820"},
+		{name: "public IPv6", address: "[2001:db8::1]:51820", expected: "[2001:db8::1]:51820"},
+		{name: "IPv4-mapped IPv6", address: "[::ffff:198.51.100.10]:51820", expected: "198.51.100.10:51820"},
+		{name: "private IPv4", address: "192.168.1.10:51820", expected: ""},
+		{name: "without port", address: "198.51.100.10", expected: ""},
+		{name: "zero port", address: "198.51.100.10:0", expected: ""},
+		{name: "invalid", address: "gateway:51820", expected: ""},
+	}
+
+	for _, c := range tt {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, toMappedAddress(c.address))
+		})
+	}
+}
+
+func BenchmarkSyncPeer(b *testing.B) {
+	cases := []struct {
+		name string
+		// every writeEvery-th operation marks the peer connected instead of syncing, 0 means sync only
+		writeEvery int
+	}{
+		{name: "Sync_Only", writeEvery: 0},
+		{name: "Sync_With_MarkPeerConnected", writeEvery: 10},
+	}
+
+	for _, c := range cases {
+		store, err := NewFileStore(b.TempDir(), nil)
+		require.NoError(b, err)
+		manager, err := BuildManager(store, NewPeersUpdateManager(nil), nil, "", "netbird.cloud", &activity.InMemoryEventStore{}, false)
+		require.NoError(b, err)
+
+		peerKeys := addTestPeers(b, manager, 50)
+
+		b.Run(c.name, func(b *testing.B) {
+			var counter atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := counter.Add(1)
+					peerKey := peerKeys[int(i)%len(peerKeys)]
+					if c.writeEvery > 0 && i%int64(c.writeEvery) == 0 {
+						require.NoError(b, manager.MarkPeerConnected(peerKey, true, nil))
+						continue
+					}
+					_, _, err := manager.SyncPeer(PeerSync{WireGuardPubKey: peerKey})
+					require.NoError(b, err)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkAddPeer_ConcurrentAccounts(b *testing.B) {
+	cases := []struct {
+		name     string
+		accounts int
+	}{
+		{name: "Single_Account", accounts: 1},
+		{name: "Many_Accounts", accounts: 20},
+	}
+
+	for _, c := range cases {
+		store, err := NewFileStore(b.TempDir(), nil)
+		require.NoError(b, err)
+		manager, err := BuildManager(store, NewPeersUpdateManager(nil), nil, "", "netbird.cloud", &activity.InMemoryEventStore{}, false)
+		require.NoError(b, err)
+
+		setupKeys := make([]string, 0, c.accounts)
+		for i := 0; i < c.accounts; i++ {
+			account, err := createAccount(manager, fmt.Sprintf("account_%d", i), fmt.Sprintf("creator_%d", i), "")
+			require.NoError(b, err)
+
+			setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 0, fmt.Sprintf("creator_%d", i), false)
+			require.NoError(b, err)
+			setupKeys = append(setupKeys, setupKey.Key)
+		}
+
+		b.Run(c.name, func(b *testing.B) {
+			var counter atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := counter.Add(1)
+					key, err := wgtypes.GeneratePrivateKey()
+					require.NoError(b, err)
+
+					_, _, err = manager.AddPeer(setupKeys[int(i)%len(setupKeys)], "", &nbpeer.Peer{
+						Key:  key.PublicKey().String(),
+						Meta: nbpeer.PeerSystemMeta{Hostname: fmt.Sprintf("bench-peer-%d", i)},
+					})
+					require.NoError(b, err)
+				}
+			})
+		})
+	}
+}
+
+func addTestPeers(t testing.TB, manager *DefaultAccountManager, count int) []string {
+	t.Helper()
+
+	account, err := createAccount(manager, "test_account", "account_creator", "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, "account_creator", false)
+	require.NoError(t, err)
+
+	peerKeys := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		key, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+
+		_, _, err = manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  key.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: fmt.Sprintf("test-peer-%d", i)},
+		})
+		require.NoError(t, err)
+
+		peerKeys = append(peerKeys, key.PublicKey().String())
+	}
+
+	return peerKeys
+}
+
+func TestDefaultAccountManager_PeerIPv6(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	// the peer registered before the IPv6 allocation has been enabled
+	oldPeerKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	oldPeer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  oldPeerKey.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "old-peer"},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, oldPeer.IPv6, "no IPv6 should be allocated while it is disabled")
+
+	require.NoError(t, manager.SetIPv6Prefix("fd00:1234::/48"))
+
+	newPeerKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	newPeer, networkMap, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  newPeerKey.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{Hostname: "new-peer"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, newPeer.IPv6, "IPv6 should be allocated to the new peer")
+	assert.True(t, networkMap.Network.NetV6.Contains(newPeer.IPv6), "IPv6 should be allocated from the network")
+
+	_, prefix, _ := net.ParseCIDR("fd00:1234::/48")
+	assert.True(t, prefix.Contains(networkMap.Network.NetV6.IP), "network should be a subnet of the prefix")
+
+	// the old peer gets its address on the next login and keeps it on reconnects
+	loggedInPeer, _, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: oldPeerKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "old-peer"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, loggedInPeer.IPv6, "IPv6 should be allocated to the existing peer on login")
+	assert.False(t, loggedInPeer.IPv6.Equal(newPeer.IPv6), "IPv6 addresses should be unique")
+
+	loggedInAgain, networkMap, err := manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: oldPeerKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "old-peer"},
+	})
+	require.NoError(t, err)
+	assert.True(t, loggedInPeer.IPv6.Equal(loggedInAgain.IPv6), "IPv6 should be kept across logins")
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.True(t, account.Peers[oldPeer.ID].IPv6.Equal(loggedInPeer.IPv6), "IPv6 should be persisted")
+
+	peerConfig := toPeerConfig(loggedInAgain, networkMap.Network, "netbird.cloud")
+	assert.Equal(t, fmt.Sprintf("%s/64", loggedInPeer.IPv6), peerConfig.AddressV6)
+
+	remotePeers := toRemotePeerConfig(loggedInAgain, networkMap.Peers, "netbird.cloud")
+	require.Len(t, remotePeers, 1)
+	assert.Equal(t, fmt.Sprintf("%s/128", newPeer.IPv6), remotePeers[0].AddressV6)
+	assert.Len(t, remotePeers[0].AllowedIps, 1, "IPv6 address should not be added to the IPv4 allowed IPs")
+}
+
+func generateSSHPublicKey(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+	pubKey, err := ssh.NewPublicKey(key.Public())
+	require.NoError(t, err)
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pubKey)))
+}
+
+func TestNormalizeSSHPublicKey(t *testing.T) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	shortRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ed25519PubKey := generateSSHPublicKey(t, ed25519Key)
+	rsaPubKey := generateSSHPublicKey(t, rsaKey)
+	ecdsaPubKey := generateSSHPublicKey(t, ecdsaKey)
+
+	testCases := []struct {
+		name        string
+		key         string
+		expectedKey string
+		expectError bool
+	}{
+		{
+			name:        "Valid ED25519 Key",
+			key:         ed25519PubKey,
+			expectedKey: ed25519PubKey,
+		},
+		{
+			name:        "Valid RSA Key",
+			key:         rsaPubKey,
+			expectedKey: rsaPubKey,
+		},
+		{
+			name:        "Valid ECDSA Key",
+			key:         ecdsaPubKey,
+			expectedKey: ecdsaPubKey,
+		},
+		{
+			name:        "Key With Comment And Trailing Newline Is Normalized",
+			key:         ed25519PubKey + " user@host\n",
+			expectedKey: ed25519PubKey,
+		},
+		{
+			name:        "Key With Options Is Normalized",
+			key:         `command="/bin/sh" ` + ed25519PubKey,
+			expectedKey: ed25519PubKey,
+		},
+		{
+			name:        "Short RSA Key",
+			key:         generateSSHPublicKey(t, shortRSAKey),
+			expectError: true,
+		},
+		{
+			name:        "Garbage",
+			key:         "not a key",
+			expectError: true,
+		},
+		{
+			name:        "Corrupted Key Data",
+			key:         "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFATYCqa",
+			expectError: true,
+		},
+		{
+			name:        "Blank Lines",
+			key:         "\n\n",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			key, err := normalizeSSHPublicKey(testCase.key)
+			if testCase.expectError {
+				require.Error(t, err)
+				s, ok := status.FromError(err)
+				require.True(t, ok, "error should be a status error")
+				assert.Equal(t, status.InvalidArgument, s.Type())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedKey, key)
+		})
+	}
+}
+
+func TestDefaultAccountManager_PeerSSHKeyValidation(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sshKey := generateSSHPublicKey(t, ed25519Key)
+
+	peerKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	_, _, err = manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:    peerKey.PublicKey().String(),
+		Meta:   nbpeer.PeerSystemMeta{Hostname: "peer"},
+		SSHKey: "garbage",
+	})
+	require.Error(t, err, "peer with an invalid SSH key should be rejected")
+
+	peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:    peerKey.PublicKey().String(),
+		Meta:   nbpeer.PeerSystemMeta{Hostname: "peer"},
+		SSHKey: sshKey + " user@host\n",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, sshKey, peer.SSHKey, "SSH key should be normalized")
+
+	_, _, err = manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: peerKey.PublicKey().String(),
+		SSHKey:          "ssh-ed25519 garbage",
+		Meta:            nbpeer.PeerSystemMeta{Hostname: "peer"},
+	})
+	require.Error(t, err, "login with an invalid SSH key should be rejected")
+
+	err = manager.UpdatePeerSSHKey(peer.ID, "garbage")
+	require.Error(t, err, "invalid SSH key update should be rejected")
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, sshKey, account.Peers[peer.ID].SSHKey, "invalid SSH keys should not be persisted")
+
+	// keys stored before the validation are not sent to the other peers
+	account.Peers[peer.ID].SSHKey = "garbage"
+	remotePeers := toRemotePeerConfig(&nbpeer.Peer{}, []*nbpeer.Peer{account.Peers[peer.ID]}, "netbird.cloud")
+	require.Len(t, remotePeers, 1)
+	assert.Empty(t, remotePeers[0].SshConfig.SshPubKey)
+}
+
+func TestDefaultAccountManager_PeerMinimalMeta(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	peerKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+		Key:  peerKey.PublicKey().String(),
+		Meta: nbpeer.PeerSystemMeta{GoOS: "linux", WtVersion: "0.24.0"},
+	})
+	require.NoError(t, err, "a peer omitting its hostname should be registered")
+	assert.Equal(t, defaultPeerName(peerKey.PublicKey().String()), peer.Name)
+	assert.Equal(t, peer.Name, peer.DNSLabel)
+	assert.Empty(t, peer.Meta.Hostname)
+
+	// a peer which reported its system before keeps the values it omits afterward
+	full := nbpeer.PeerSystemMeta{Hostname: "peer-3f2a9c1b", GoOS: "linux", Kernel: "Linux", Core: "6.1.0", Platform: "x86_64", OS: "debian", WtVersion: "0.24.0"}
+	_, _, err = manager.LoginPeer(PeerLogin{WireGuardPubKey: peerKey.PublicKey().String(), Meta: full})
+	require.NoError(t, err)
+
+	_, _, err = manager.LoginPeer(PeerLogin{
+		WireGuardPubKey: peerKey.PublicKey().String(),
+		Meta:            nbpeer.PeerSystemMeta{GoOS: "linux", WtVersion: "0.25.0"},
+	})
+	require.NoError(t, err)
+
+	peer, err = manager.GetPeer(account.Id, peer.ID, userID)
+	require.NoError(t, err)
+	expected := full
+	expected.WtVersion = "0.25.0"
+	assert.Equal(t, expected, peer.Meta)
+}
+
+func TestDefaultAccountManager_AddPeersWithSameName(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	var peers []*nbpeer.Peer
+	for i := 0; i < 2; i++ {
+		peerKey, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  peerKey.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: "laptop", GoOS: "linux"},
+		})
+		require.NoError(t, err)
+		peers = append(peers, peer)
+	}
+
+	dnsDomain := manager.GetDNSDomain()
+	assert.Equal(t, "laptop", peers[0].DNSLabel)
+	assert.Equal(t, "laptop-1", peers[1].DNSLabel)
+	assert.NotEqual(t, peers[0].FQDN(dnsDomain), peers[1].FQDN(dnsDomain), "the peers with the same name should get distinct FQDNs")
+
+	networkMap, err := manager.GetNetworkMap(peers[0].ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, networkMap.DNSConfig.CustomZones)
+
+	records := make(map[string]string)
+	for _, record := range networkMap.DNSConfig.CustomZones[0].Records {
+		records[record.Name] = record.RData
+	}
+	for _, peer := range peers {
+		assert.Equal(t, peer.IP.String(), records[peer.FQDN(dnsDomain)+"."], "the FQDN of the peer should resolve to its IP")
+	}
+
+	// renaming the peer to a name mapping to its own label keeps the label
+	renamed := peers[1].Copy()
+	renamed.Name = "Laptop-1"
+	renamed, err = manager.UpdatePeer(account.Id, userID, renamed)
+	require.NoError(t, err)
+	assert.Equal(t, "laptop-1", renamed.DNSLabel)
+
+	// renaming the peer to the name of the other peer disambiguates the label
+	renamed.Name = "LAPTOP"
+	renamed, err = manager.UpdatePeer(account.Id, userID, renamed)
+	require.NoError(t, err)
+	assert.Equal(t, "laptop-1", renamed.DNSLabel)
+}
+
+func TestDefaultAccountManager_UpdatePeerForceRelay(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	var peers []*nbpeer.Peer
+	for i := 0; i < 2; i++ {
+		peerKey, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+
+		peer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{
+			Key:  peerKey.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: fmt.Sprintf("peer-%d", i), GoOS: "linux"},
+		})
+		require.NoError(t, err)
+		peers = append(peers, peer)
+	}
+
+	update := peers[0].Copy()
+	update.ForceRelay = true
+	updated, err := manager.UpdatePeer(account.Id, userID, update)
+	require.NoError(t, err)
+	assert.True(t, updated.ForceRelay)
+
+	ev := getEvent(t, account.Id, manager, activity.PeerForceRelayEnabled)
+	assert.Equal(t, updated.IP.String(), ev.TargetID)
+
+	// both sides of the connection go through a relay when either of the peers forces it
+	for _, pair := range [][2]*nbpeer.Peer{{updated, peers[1]}, {peers[1], updated}} {
+		networkMap, err := manager.GetNetworkMap(pair[0].ID)
+		require.NoError(t, err)
+
+		remotePeers := toRemotePeerConfig(pair[0], networkMap.Peers, manager.GetDNSDomain())
+		require.Len(t, remotePeers, 1)
+		assert.Equal(t, pair[1].Key, remotePeers[0].WgPubKey)
+		assert.True(t, remotePeers[0].ForceRelay, "the connection of peer %s should be relayed", pair[0].Name)
+	}
+
+	update = updated.Copy()
+	update.ForceRelay = false
+	updated, err = manager.UpdatePeer(account.Id, userID, update)
+	require.NoError(t, err)
+	assert.False(t, updated.ForceRelay)
+	getEvent(t, account.Id, manager, activity.PeerForceRelayDisabled)
+
+	networkMap, err := manager.GetNetworkMap(peers[1].ID)
+	require.NoError(t, err)
+	remotePeers := toRemotePeerConfig(peers[1], networkMap.Peers, manager.GetDNSDomain())
+	require.Len(t, remotePeers, 1)
+	assert.False(t, remotePeers[0].ForceRelay)
+}
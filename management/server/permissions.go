@@ -0,0 +1,119 @@
+package server
+
+import "github.com/netbirdio/netbird/management/server/status"
+
+// Resource is a kind of the account objects the permissions are granted for
+type Resource string
+
+// Operation is an action a user performs on a Resource
+type Operation string
+
+const (
+	ResourceAccounts  Resource = "accounts"
+	ResourcePeers     Resource = "peers"
+	ResourceGroups    Resource = "groups"
+	ResourcePolicies  Resource = "policies"
+	ResourceRoutes    Resource = "routes"
+	ResourceDNS       Resource = "dns"
+	ResourceSetupKeys Resource = "setup_keys"
+	ResourceUsers     Resource = "users"
+	ResourceEvents    Resource = "events"
+
+	OperationRead  Operation = "read"
+	OperationWrite Operation = "write"
+)
+
+var (
+	readOnly  = []Operation{OperationRead}
+	readWrite = []Operation{OperationRead, OperationWrite}
+)
+
+// rolePermissions is the role to permission matrix. The owner and admin roles can manage everything,
+// the network admin manages the network configuration without the account settings and users,
+// the auditor can view everything without changing anything and the regular user has no account wide permissions.
+// Resources missing for a role are not allowed.
+var rolePermissions = map[UserRole]map[Resource][]Operation{
+	UserRoleOwner: {
+		ResourceAccounts:  readWrite,
+		ResourcePeers:     readWrite,
+		ResourceGroups:    readWrite,
+		ResourcePolicies:  readWrite,
+		ResourceRoutes:    readWrite,
+		ResourceDNS:       readWrite,
+		ResourceSetupKeys: readWrite,
+		ResourceUsers:     readWrite,
+		ResourceEvents:    readOnly,
+	},
+	UserRoleAdmin: {
+		ResourceAccounts:  readWrite,
+		ResourcePeers:     readWrite,
+		ResourceGroups:    readWrite,
+		ResourcePolicies:  readWrite,
+		ResourceRoutes:    readWrite,
+		ResourceDNS:       readWrite,
+		ResourceSetupKeys: readWrite,
+		ResourceUsers:     readWrite,
+		ResourceEvents:    readOnly,
+	},
+	UserRoleNetworkAdmin: {
+		ResourceAccounts:  readOnly,
+		ResourcePeers:     readWrite,
+		ResourceGroups:    readWrite,
+		ResourcePolicies:  readWrite,
+		ResourceRoutes:    readWrite,
+		ResourceDNS:       readWrite,
+		ResourceSetupKeys: readWrite,
+		ResourceUsers:     readOnly,
+		ResourceEvents:    readOnly,
+	},
+	UserRoleAuditor: {
+		ResourceAccounts:  readOnly,
+		ResourcePeers:     readOnly,
+		ResourceGroups:    readOnly,
+		ResourcePolicies:  readOnly,
+		ResourceRoutes:    readOnly,
+		ResourceDNS:       readOnly,
+		ResourceSetupKeys: readOnly,
+		ResourceUsers:     readOnly,
+		ResourceEvents:    readOnly,
+	},
+	UserRoleUser: {},
+}
+
+// HasPermission returns true if the user role allows the operation on all the objects of the resource.
+// Service users can read all the resources regardless of their role.
+func (u *User) HasPermission(resource Resource, operation Operation) bool {
+	if operation == OperationRead && u.IsServiceUser {
+		return true
+	}
+
+	return u.Role.HasPermission(resource, operation)
+}
+
+// HasPermission returns true if the role allows the operation on all the objects of the resource
+func (r UserRole) HasPermission(resource Resource, operation Operation) bool {
+	for _, allowed := range rolePermissions[r][resource] {
+		if allowed == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPermission returns a PermissionDenied error if the account user isn't allowed the operation on the resource
+func checkPermission(account *Account, userID string, resource Resource, operation Operation) error {
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasPermission(resource, operation) {
+		return status.Errorf(status.PermissionDenied, "user has no %s permission for %s", operation, resource)
+	}
+	return nil
+}
+
+// HasAdminPower returns true if the role is admin or owner
+func (r UserRole) HasAdminPower() bool {
+	return r == UserRoleAdmin || r == UserRoleOwner
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_HasPermission(t *testing.T) {
+	tt := []struct {
+		name          string
+		user          *User
+		resource      Resource
+		operation     Operation
+		expectAllowed bool
+	}{
+		{"owner manages users", NewOwnerUser("u"), ResourceUsers, OperationWrite, true},
+		{"admin manages account", NewAdminUser("u"), ResourceAccounts, OperationWrite, true},
+		{"network admin manages peers", NewUser("u", UserRoleNetworkAdmin, false, false, "", nil, UserIssuedAPI), ResourcePeers, OperationWrite, true},
+		{"network admin manages setup keys", NewUser("u", UserRoleNetworkAdmin, false, false, "", nil, UserIssuedAPI), ResourceSetupKeys, OperationWrite, true},
+		{"network admin views users", NewUser("u", UserRoleNetworkAdmin, false, false, "", nil, UserIssuedAPI), ResourceUsers, OperationRead, true},
+		{"network admin can't manage users", NewUser("u", UserRoleNetworkAdmin, false, false, "", nil, UserIssuedAPI), ResourceUsers, OperationWrite, false},
+		{"network admin can't manage account", NewUser("u", UserRoleNetworkAdmin, false, false, "", nil, UserIssuedAPI), ResourceAccounts, OperationWrite, false},
+		{"auditor views events", NewUser("u", UserRoleAuditor, false, false, "", nil, UserIssuedAPI), ResourceEvents, OperationRead, true},
+		{"auditor views policies", NewUser("u", UserRoleAuditor, false, false, "", nil, UserIssuedAPI), ResourcePolicies, OperationRead, true},
+		{"auditor can't manage groups", NewUser("u", UserRoleAuditor, false, false, "", nil, UserIssuedAPI), ResourceGroups, OperationWrite, false},
+		{"user can't view all peers", NewRegularUser("u"), ResourcePeers, OperationRead, false},
+		{"service user views all peers", NewUser("u", UserRoleUser, true, false, "", nil, UserIssuedAPI), ResourcePeers, OperationRead, true},
+		{"service user can't manage peers", NewUser("u", UserRoleUser, true, false, "", nil, UserIssuedAPI), ResourcePeers, OperationWrite, false},
+		{"unknown role has no permissions", NewUser("u", UserRoleUnknown, false, false, "", nil, UserIssuedAPI), ResourcePeers, OperationRead, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectAllowed, tc.user.HasPermission(tc.resource, tc.operation))
+		})
+	}
+}
+
+func TestStrRoleToUserRole_FineGrainedRoles(t *testing.T) {
+	assert.Equal(t, UserRoleNetworkAdmin, StrRoleToUserRole("network_admin"))
+	assert.Equal(t, UserRoleAuditor, StrRoleToUserRole("Auditor"))
+	assert.Equal(t, UserRoleUnknown, StrRoleToUserRole("operator"))
+}
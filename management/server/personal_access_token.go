@@ -32,7 +32,9 @@ type PersonalAccessToken struct {
 	Name           string
 	HashedToken    string
 	ExpirationDate time.Time
-	// scope could be added in future
+	// Role limits the write operations of the token to the ones the role allows on top of the permissions
+	// of the user, e.g. auditor makes a read-only token. Empty keeps the permissions of the user.
+	Role      UserRole
 	CreatedBy string
 	CreatedAt time.Time
 	LastUsed  time.Time
@@ -44,6 +46,7 @@ func (t *PersonalAccessToken) Copy() *PersonalAccessToken {
 		Name:           t.Name,
 		HashedToken:    t.HashedToken,
 		ExpirationDate: t.ExpirationDate,
+		Role:           t.Role,
 		CreatedBy:      t.CreatedBy,
 		CreatedAt:      t.CreatedAt,
 		LastUsed:       t.LastUsed,
@@ -58,7 +61,7 @@ type PersonalAccessTokenGenerated struct {
 
 // CreateNewPAT will generate a new PersonalAccessToken that can be assigned to a User.
 // Additionally, it will return the token in plain text once, to give to the user and only save a hashed version
-func CreateNewPAT(name string, expirationInDays int, createdBy string) (*PersonalAccessTokenGenerated, error) {
+func CreateNewPAT(name string, expirationInDays int, role UserRole, createdBy string) (*PersonalAccessTokenGenerated, error) {
 	hashedToken, plainToken, err := generateNewToken()
 	if err != nil {
 		return nil, err
@@ -70,6 +73,7 @@ func CreateNewPAT(name string, expirationInDays int, createdBy string) (*Persona
 			Name:           name,
 			HashedToken:    hashedToken,
 			ExpirationDate: currentTime.AddDate(0, 0, expirationInDays),
+			Role:           role,
 			CreatedBy:      createdBy,
 			CreatedAt:      currentTime,
 			LastUsed:       time.Time{},
@@ -9,8 +9,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/netbirdio/netbird/base62"
+	"github.com/netbirdio/netbird/management/server/jwtclaims"
 )
 
 func TestPAT_GenerateToken_Hashing(t *testing.T) {
@@ -44,3 +47,12 @@ func TestPAT_GenerateToken_Checksum(t *testing.T) {
 	}
 	assert.Equal(t, expectedChecksum, actualChecksum)
 }
+
+func TestGRPCServer_AccountFromToken_RejectsPAT(t *testing.T) {
+	_, plainToken, err := generateNewToken()
+	assert.NoError(t, err)
+
+	s := &GRPCServer{jwtValidator: &jwtclaims.JWTValidator{}}
+	_, _, err = s.accountFromToken(plainToken)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "a PAT shouldn't authenticate a gRPC call")
+}
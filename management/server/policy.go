@@ -7,6 +7,7 @@ import (
 
 	"github.com/netbirdio/management-integrations/additions"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 
 	"github.com/netbirdio/netbird/management/proto"
 	"github.com/netbirdio/netbird/management/server/activity"
@@ -88,14 +89,25 @@ type PolicyRule struct {
 	// Sources policy source groups
 	Sources []string `gorm:"serializer:json"`
 
+	// DestinationResources policy destination network resources, the source peers are granted access to their
+	// networks through the routing peers
+	DestinationResources []string `gorm:"serializer:json"`
+
 	// Bidirectional define if the rule is applicable in both directions, sources, and destinations
 	Bidirectional bool
 
 	// Protocol type of the traffic
 	Protocol PolicyRuleProtocolType
 
+	// AdditionalProtocols matched by the rule besides Protocol, e.g. ICMP next to TCP.
+	// The Ports only apply to the TCP and UDP protocols of the rule, ICMP is matched both ways in a one way rule
+	AdditionalProtocols []PolicyRuleProtocolType `gorm:"serializer:json"`
+
 	// Ports or it ranges list
 	Ports []string `gorm:"serializer:json"`
+
+	// Schedule limits the rule to days of the week and time ranges, the rule is always active when nil
+	Schedule *PolicySchedule `gorm:"serializer:json"`
 }
 
 // Copy returns a copy of a policy rule
@@ -111,10 +123,19 @@ func (pm *PolicyRule) Copy() *PolicyRule {
 		Bidirectional: pm.Bidirectional,
 		Protocol:      pm.Protocol,
 		Ports:         make([]string, len(pm.Ports)),
+		Schedule:      pm.Schedule.Copy(),
 	}
 	copy(rule.Destinations, pm.Destinations)
 	copy(rule.Sources, pm.Sources)
 	copy(rule.Ports, pm.Ports)
+	if pm.AdditionalProtocols != nil {
+		rule.AdditionalProtocols = make([]PolicyRuleProtocolType, len(pm.AdditionalProtocols))
+		copy(rule.AdditionalProtocols, pm.AdditionalProtocols)
+	}
+	if pm.DestinationResources != nil {
+		rule.DestinationResources = make([]string, len(pm.DestinationResources))
+		copy(rule.DestinationResources, pm.DestinationResources)
+	}
 	return rule
 }
 
@@ -167,6 +188,33 @@ func (p *Policy) Copy() *Policy {
 	return c
 }
 
+// protocols returns all the distinct protocols matched by the rule
+func (pm *PolicyRule) protocols() []PolicyRuleProtocolType {
+	protocols := []PolicyRuleProtocolType{pm.Protocol}
+	for _, protocol := range pm.AdditionalProtocols {
+		if !slices.Contains(protocols, protocol) {
+			protocols = append(protocols, protocol)
+		}
+	}
+	return protocols
+}
+
+// bidirectionalICMPPart returns the ICMP part of a one way rule matching ICMP next to other protocols as a
+// bidirectional rule, nil for the other rules. ICMP is matched both ways like in the ICMP only rules, while the
+// other protocols of the rule keep its direction.
+func (pm *PolicyRule) bidirectionalICMPPart() *PolicyRule {
+	if pm.Bidirectional || len(pm.AdditionalProtocols) == 0 || !slices.Contains(pm.protocols(), PolicyRuleProtocolICMP) {
+		return nil
+	}
+
+	rule := pm.Copy()
+	rule.Protocol = PolicyRuleProtocolICMP
+	rule.AdditionalProtocols = nil
+	rule.Ports = nil
+	rule.Bidirectional = true
+	return rule
+}
+
 // EventMeta returns activity event meta related to this policy
 func (p *Policy) EventMeta() map[string]any {
 	return map[string]any{"name": p.Name}
@@ -204,46 +252,78 @@ type FirewallRule struct {
 	Port string
 }
 
+// Inbound returns true if the rule applies to the traffic coming from PeerIP, false for the traffic going to it
+func (r *FirewallRule) Inbound() bool {
+	return r.Direction == firewallRuleDirectionIN
+}
+
 // getPeerConnectionResources for a given peer
 //
 // This function returns the list of peers and firewall rules that are applicable to a given peer.
+// Only the peers permitted by at least one accept rule are returned, so the peers which traffic is only
+// dropped by the policies aren't installed in the WireGuard interface of the given peer.
 func (a *Account) getPeerConnectionResources(peerID string) ([]*nbpeer.Peer, []*FirewallRule) {
 	generateResources, getAccumulatedResources := a.connResourcesGenerator()
+	a.forEachPeerRule(peerID, func(_ *Policy, rule *PolicyRule, peers []*nbpeer.Peer, direction int) {
+		generateResources(rule, peers, direction)
+	})
+	return getAccumulatedResources()
+}
+
+// forEachPeerRule evaluates the enabled and active policy rules for a given peer
+//
+// The visit function is called for each rule the peer is a source or a destination of, with the peers on the other
+// side of the rule and the direction of the traffic from the given peer's point of view. A bidirectional rule is
+// visited in both directions, the ICMP part of a one way rule is visited in the reverse direction too.
+func (a *Account) forEachPeerRule(peerID string, visit func(policy *Policy, rule *PolicyRule, peers []*nbpeer.Peer, direction int)) {
+	allGroupID := a.allGroupID()
+	now := timeNow()
 	for _, policy := range a.Policies {
 		if !policy.Enabled {
 			continue
 		}
 
 		for _, rule := range policy.Rules {
-			if !rule.Enabled {
+			if !rule.Enabled || a.isDefaultDenyIgnored(rule, allGroupID) || !rule.Schedule.isActive(now) {
 				continue
 			}
 
 			sourcePeers, peerInSources := getAllPeersFromGroups(a, rule.Sources, peerID)
 			destinationPeers, peerInDestinations := getAllPeersFromGroups(a, rule.Destinations, peerID)
+			if len(rule.DestinationResources) > 0 {
+				// the resources are reached through the peers routing them
+				routingPeers, peerRoutesResource := a.getResourcesRoutingPeers(rule.DestinationResources, peerID, destinationPeers)
+				destinationPeers = append(destinationPeers, routingPeers...)
+				peerInDestinations = peerInDestinations || peerRoutesResource
+			}
 			sourcePeers = additions.ValidatePeers(sourcePeers)
 			destinationPeers = additions.ValidatePeers(destinationPeers)
 
 			if rule.Bidirectional {
 				if peerInSources {
-					generateResources(rule, destinationPeers, firewallRuleDirectionIN)
+					visit(policy, rule, destinationPeers, firewallRuleDirectionIN)
+				}
+				if peerInDestinations {
+					visit(policy, rule, sourcePeers, firewallRuleDirectionOUT)
+				}
+			} else if icmpRule := rule.bidirectionalICMPPart(); icmpRule != nil {
+				if peerInSources {
+					visit(policy, icmpRule, destinationPeers, firewallRuleDirectionIN)
 				}
 				if peerInDestinations {
-					generateResources(rule, sourcePeers, firewallRuleDirectionOUT)
+					visit(policy, icmpRule, sourcePeers, firewallRuleDirectionOUT)
 				}
 			}
 
 			if peerInSources {
-				generateResources(rule, destinationPeers, firewallRuleDirectionOUT)
+				visit(policy, rule, destinationPeers, firewallRuleDirectionOUT)
 			}
 
 			if peerInDestinations {
-				generateResources(rule, sourcePeers, firewallRuleDirectionIN)
+				visit(policy, rule, sourcePeers, firewallRuleDirectionIN)
 			}
 		}
 	}
-
-	return getAccumulatedResources()
 }
 
 // connResourcesGenerator returns generator and accumulator function which returns the result of generator calls
@@ -269,38 +349,47 @@ func (a *Account) connResourcesGenerator() (func(*PolicyRule, []*nbpeer.Peer, in
 				if peer == nil {
 					continue
 				}
-				if _, ok := peersExists[peer.ID]; !ok {
+				// drop rules only restrict the traffic, they don't justify a connection to the peer
+				if _, ok := peersExists[peer.ID]; !ok && rule.Action != PolicyTrafficActionDrop {
 					peers = append(peers, peer)
 					peersExists[peer.ID] = struct{}{}
 				}
 
-				fr := FirewallRule{
-					PeerIP:    peer.IP.String(),
-					Direction: direction,
-					Action:    string(rule.Action),
-					Protocol:  string(rule.Protocol),
-				}
-
-				if isAll {
-					fr.PeerIP = "0.0.0.0"
-				}
-
-				ruleID := (rule.ID + fr.PeerIP + strconv.Itoa(direction) +
-					fr.Protocol + fr.Action + strings.Join(rule.Ports, ","))
-				if _, ok := rulesExists[ruleID]; ok {
-					continue
-				}
-				rulesExists[ruleID] = struct{}{}
-
-				if len(rule.Ports) == 0 {
-					rules = append(rules, &fr)
-					continue
-				}
-
-				for _, port := range rule.Ports {
-					pr := fr // clone rule and add set new port
-					pr.Port = port
-					rules = append(rules, &pr)
+				for _, protocol := range rule.protocols() {
+					fr := FirewallRule{
+						PeerIP:    peer.IP.String(),
+						Direction: direction,
+						Action:    string(rule.Action),
+						Protocol:  string(protocol),
+					}
+
+					if isAll {
+						fr.PeerIP = "0.0.0.0"
+					}
+
+					// ICMP has no ports, it is matched as a whole next to the ports of the other protocols
+					ports := rule.Ports
+					if protocol == PolicyRuleProtocolICMP {
+						ports = nil
+					}
+
+					ruleID := (rule.ID + fr.PeerIP + strconv.Itoa(direction) +
+						fr.Protocol + fr.Action + strings.Join(ports, ","))
+					if _, ok := rulesExists[ruleID]; ok {
+						continue
+					}
+					rulesExists[ruleID] = struct{}{}
+
+					if len(ports) == 0 {
+						rules = append(rules, &fr)
+						continue
+					}
+
+					for _, port := range ports {
+						pr := fr // clone rule and add set new port
+						pr.Port = port
+						rules = append(rules, &pr)
+					}
 				}
 			}
 		}, func() ([]*nbpeer.Peer, []*FirewallRule) {
@@ -323,8 +412,8 @@ func (am *DefaultAccountManager) GetPolicy(accountID, policyID, userID string) (
 		return nil, err
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
-		return nil, status.Errorf(status.PermissionDenied, "only users with admin power are allowed to view policies")
+	if !user.HasPermission(ResourcePolicies, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view policies")
 	}
 
 	for _, policy := range account.Policies {
@@ -346,6 +435,10 @@ func (am *DefaultAccountManager) SavePolicy(accountID, userID string, policy *Po
 		return err
 	}
 
+	if err = account.validatePolicy(policy); err != nil {
+		return err
+	}
+
 	exists := am.savePolicy(account, policy)
 
 	account.Network.IncSerial()
@@ -360,6 +453,7 @@ func (am *DefaultAccountManager) SavePolicy(accountID, userID string, policy *Po
 	am.StoreEvent(userID, policy.ID, accountID, action, policy.EventMeta())
 
 	am.updateAccountPeers(account)
+	am.checkAndSchedulePolicySchedules(account)
 
 	return nil
 }
@@ -387,6 +481,7 @@ func (am *DefaultAccountManager) DeletePolicy(accountID, policyID, userID string
 	am.StoreEvent(userID, policy.ID, accountID, activity.PolicyRemoved, policy.EventMeta())
 
 	am.updateAccountPeers(account)
+	am.checkAndSchedulePolicySchedules(account)
 
 	return nil
 }
@@ -406,8 +501,8 @@ func (am *DefaultAccountManager) ListPolicies(accountID, userID string) ([]*Poli
 		return nil, err
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
-		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view policies")
+	if !user.HasPermission(ResourcePolicies, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view policies")
 	}
 
 	return account.Policies, nil
@@ -430,6 +525,21 @@ func (am *DefaultAccountManager) deletePolicy(account *Account, policyID string)
 	return policy, nil
 }
 
+// validatePolicy checks the policy can be saved in the account
+func (a *Account) validatePolicy(policy *Policy) error {
+	if err := a.validatePolicyForDefaultDeny(policy); err != nil {
+		return err
+	}
+
+	for _, rule := range policy.Rules {
+		if err := rule.Schedule.validate(); err != nil {
+			return err
+		}
+	}
+
+	return validateDestinationResources(policy, a)
+}
+
 func (am *DefaultAccountManager) savePolicy(account *Account, policy *Policy) (exists bool) {
 	for i, p := range account.Policies {
 		if p.ID == policy.ID {
@@ -502,6 +612,11 @@ func getAllPeersFromGroups(account *Account, groups []string, peerID string) ([]
 				continue
 			}
 
+			// the peers waiting for an approval or failing a posture check are quarantined from the network
+			if peer.IsQuarantined() {
+				continue
+			}
+
 			filteredPeers = append(filteredPeers, peer)
 		}
 	}
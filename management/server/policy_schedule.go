@@ -0,0 +1,358 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// policyScheduleTimeLayout is the layout of the start and the end of the schedule time ranges
+	policyScheduleTimeLayout = "15:04"
+	// policyScheduleHorizon is how far the next schedule transition is looked for, a week and a day
+	// cover every schedule made of days of the week and time ranges
+	policyScheduleHorizon = 8 * 24 * time.Hour
+)
+
+// scheduleLocations caches the time zones of the policy schedules by name
+var scheduleLocations sync.Map
+
+// PolicySchedule limits a policy rule to days of the week and time ranges of the day, e.g. the business hours.
+// The days and the times are the wall clock of the time zone, so the rule follows the daylight saving time changes:
+// a range starting in the skipped hour starts when the clock moves forward, a range in the repeated hour is active
+// both times the clock shows it.
+type PolicySchedule struct {
+	// Days of the week the rule is active on, every day when empty
+	Days []time.Weekday
+	// TimeRanges of the day the rule is active in, the whole day when empty
+	TimeRanges []PolicyTimeRange
+	// Timezone is the IANA name of the time zone of the days and the time ranges, UTC when empty
+	Timezone string
+}
+
+// PolicyTimeRange is a time range of the day in the "15:04" format. A range ending before it starts spans midnight
+// and belongs to the day it starts on, e.g. 22:00-06:00 on Friday ends on Saturday at 06:00.
+type PolicyTimeRange struct {
+	Start string
+	End   string
+}
+
+// Copy returns a copy of the schedule
+func (s *PolicySchedule) Copy() *PolicySchedule {
+	if s == nil {
+		return nil
+	}
+	schedule := &PolicySchedule{
+		Days:       make([]time.Weekday, len(s.Days)),
+		TimeRanges: make([]PolicyTimeRange, len(s.TimeRanges)),
+		Timezone:   s.Timezone,
+	}
+	copy(schedule.Days, s.Days)
+	copy(schedule.TimeRanges, s.TimeRanges)
+	return schedule
+}
+
+// validate checks the days, the time ranges and the time zone of the schedule
+func (s *PolicySchedule) validate() error {
+	if s == nil {
+		return nil
+	}
+
+	for _, day := range s.Days {
+		if day < time.Sunday || day > time.Saturday {
+			return status.Errorf(status.InvalidArgument, "invalid schedule day %d", day)
+		}
+	}
+
+	for _, timeRange := range s.TimeRanges {
+		start, err := parseScheduleTime(timeRange.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseScheduleTime(timeRange.End)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return status.Errorf(status.InvalidArgument, "schedule time range %s-%s is empty", timeRange.Start, timeRange.End)
+		}
+	}
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return status.Errorf(status.InvalidArgument, "invalid schedule time zone %s", s.Timezone)
+	}
+
+	return nil
+}
+
+// location returns the time zone of the schedule, UTC when the time zone can't be loaded.
+// The time zones are cached because the schedules are checked for every network map.
+func (s *PolicySchedule) location() *time.Location {
+	if loc, ok := scheduleLocations.Load(s.Timezone); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		log.Warnf("failed loading the policy schedule time zone %s, using UTC: %v", s.Timezone, err)
+		return time.UTC
+	}
+	scheduleLocations.Store(s.Timezone, loc)
+	return loc
+}
+
+// hasDay returns true if the rule is active on the day of the week
+func (s *PolicySchedule) hasDay(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// isActive returns true if the rule is active at the time, a nil schedule is always active
+func (s *PolicySchedule) isActive(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+
+	local := t.In(s.location())
+	day := local.Weekday()
+	if len(s.TimeRanges) == 0 {
+		return s.hasDay(day)
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	previousDay := (day + 6) % 7
+	for _, timeRange := range s.TimeRanges {
+		start, err := parseScheduleTime(timeRange.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseScheduleTime(timeRange.End)
+		if err != nil {
+			continue
+		}
+
+		if start < end {
+			if s.hasDay(day) && minute >= start && minute < end {
+				return true
+			}
+			continue
+		}
+
+		// the range spans midnight
+		if s.hasDay(day) && minute >= start || s.hasDay(previousDay) && minute < end {
+			return true
+		}
+	}
+	return false
+}
+
+// nextTransition returns the first time after the given one the rule gets active or inactive.
+// It returns false if the rule is always or never active.
+func (s *PolicySchedule) nextTransition(after time.Time) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+
+	loc := s.location()
+	candidates := s.boundaries(after, loc)
+	candidates = append(candidates, zoneTransitions(after, after.Add(policyScheduleHorizon), loc)...)
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Before(candidates[j])
+	})
+
+	active := s.isActive(after)
+	for _, candidate := range candidates {
+		if !candidate.After(after) {
+			continue
+		}
+		if s.isActive(candidate) != active {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// boundaries returns the times the days and the time ranges of the schedule start and end at within the horizon.
+// A wall clock time repeated when the clock moves back gives two times.
+func (s *PolicySchedule) boundaries(after time.Time, loc *time.Location) []time.Time {
+	var wallClocks []int
+	for _, timeRange := range s.TimeRanges {
+		start, err := parseScheduleTime(timeRange.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseScheduleTime(timeRange.End)
+		if err != nil {
+			continue
+		}
+		wallClocks = append(wallClocks, start, end)
+	}
+	// the days start and end at midnight
+	wallClocks = append(wallClocks, 0)
+
+	var boundaries []time.Time
+	local := after.In(loc)
+	for day := 0; day <= int(policyScheduleHorizon/(24*time.Hour)); day++ {
+		for _, minute := range wallClocks {
+			hour, min := minute/60, minute%60
+			base := time.Date(local.Year(), local.Month(), local.Day()+day, hour, min, 0, 0, loc)
+			// time.Date picks one of the times of a repeated wall clock, the other one is an offset change away
+			for _, shift := range []time.Duration{-time.Hour, -30 * time.Minute, 0, 30 * time.Minute, time.Hour} {
+				candidate := base.Add(shift).In(loc)
+				if candidate.Hour() == hour && candidate.Minute() == min && candidate.Day() == base.Day() {
+					boundaries = append(boundaries, candidate)
+				}
+			}
+		}
+	}
+	return boundaries
+}
+
+// zoneTransitions returns the times the offset of the time zone changes at between from and to, to the second
+func zoneTransitions(from, to time.Time, loc *time.Location) []time.Time {
+	var transitions []time.Time
+	_, offset := from.In(loc).Zone()
+	for t := from; t.Before(to); t = t.Add(time.Hour) {
+		next := t.Add(time.Hour)
+		_, nextOffset := next.In(loc).Zone()
+		if nextOffset == offset {
+			continue
+		}
+
+		low, high := t, next
+		for high.Sub(low) > time.Second {
+			mid := low.Add(high.Sub(low) / 2)
+			if _, midOffset := mid.In(loc).Zone(); midOffset == offset {
+				low = mid
+			} else {
+				high = mid
+			}
+		}
+		transitions = append(transitions, high.Truncate(time.Second))
+		offset = nextOffset
+	}
+	return transitions
+}
+
+// parseScheduleTime returns the minute of the day of a time in the "15:04" format
+func parseScheduleTime(value string) (int, error) {
+	t, err := time.Parse(policyScheduleTimeLayout, value)
+	if err != nil {
+		return 0, status.Errorf(status.InvalidArgument, "invalid schedule time %s, expecting the HH:MM format", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// scheduledRules returns the enabled rules of the enabled policies with a schedule
+func (a *Account) scheduledRules() []*PolicyRule {
+	var rules []*PolicyRule
+	for _, policy := range a.Policies {
+		if !policy.Enabled {
+			continue
+		}
+		for _, rule := range policy.Rules {
+			if rule.Enabled && rule.Schedule != nil {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+// getNextPolicyScheduleTransition returns the first time after the given one a scheduled rule gets active or inactive
+func (a *Account) getNextPolicyScheduleTransition(after time.Time) (time.Time, bool) {
+	var next time.Time
+	for _, rule := range a.scheduledRules() {
+		transition, ok := rule.Schedule.nextTransition(after)
+		if ok && (next.IsZero() || transition.Before(next)) {
+			next = transition
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// getPolicyScheduleAffectedPeers returns the IDs of the peers of the source and the destination groups of the rules
+// which got active or inactive between the given times
+func (a *Account) getPolicyScheduleAffectedPeers(from, to time.Time) []string {
+	allGroupID := a.allGroupID()
+	affected := make(map[string]struct{})
+	for _, rule := range a.scheduledRules() {
+		if a.isDefaultDenyIgnored(rule, allGroupID) || rule.Schedule.isActive(from) == rule.Schedule.isActive(to) {
+			continue
+		}
+		groupIDs := make([]string, 0, len(rule.Sources)+len(rule.Destinations))
+		groupIDs = append(groupIDs, rule.Sources...)
+		groupIDs = append(groupIDs, rule.Destinations...)
+		for _, groupID := range groupIDs {
+			group, ok := a.Groups[groupID]
+			if !ok {
+				continue
+			}
+			for _, peerID := range group.Peers {
+				affected[peerID] = struct{}{}
+			}
+		}
+	}
+
+	peerIDs := make([]string, 0, len(affected))
+	for peerID := range affected {
+		peerIDs = append(peerIDs, peerID)
+	}
+	sort.Strings(peerIDs)
+	return peerIDs
+}
+
+// policyScheduleJob updates the peers of the rules which got active or inactive since the previous run
+// and returns the time until the next transition of the account rules
+func (am *DefaultAccountManager) policyScheduleJob(accountID string, lastRun time.Time) func() (time.Duration, bool) {
+	return func() (time.Duration, bool) {
+		unlock := am.Store.AcquireAccountLock(accountID)
+		defer unlock()
+
+		account, err := am.Store.GetAccount(accountID)
+		if err != nil {
+			log.Errorf("failed getting account %s updating the peers of the scheduled policies: %v", accountID, err)
+			return 0, false
+		}
+
+		now := timeNow()
+		peerIDs := account.getPolicyScheduleAffectedPeers(lastRun, now)
+		lastRun = now
+
+		log.Debugf("updating %d peers of the scheduled policies of account %s", len(peerIDs), accountID)
+		if len(peerIDs) > 0 {
+			account.Network.IncSerial()
+			if err := am.Store.SaveAccount(account); err != nil {
+				log.Errorf("failed saving account %s updating the peers of the scheduled policies: %v", accountID, err)
+			}
+			am.updatePeers(account, peerIDs)
+		}
+
+		next, ok := account.getNextPolicyScheduleTransition(now)
+		if !ok {
+			return 0, false
+		}
+		return next.Sub(now), true
+	}
+}
+
+// checkAndSchedulePolicySchedules schedules the update of the peers at the next transition of the account scheduled rules
+func (am *DefaultAccountManager) checkAndSchedulePolicySchedules(account *Account) {
+	am.policySchedules.Cancel([]string{account.Id})
+
+	now := timeNow()
+	if next, ok := account.getNextPolicyScheduleTransition(now); ok {
+		go am.policySchedules.Schedule(next.Sub(now), account.Id, am.policyScheduleJob(account.Id, now))
+	}
+}
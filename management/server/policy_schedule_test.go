@@ -0,0 +1,290 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func TestPolicySchedule_IsActive(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+
+	businessHours := &PolicySchedule{
+		Days:       []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		TimeRanges: []PolicyTimeRange{{Start: "09:00", End: "17:00"}},
+		Timezone:   "Europe/Berlin",
+	}
+	// 2024-06-03 is a Monday
+	assert.True(t, businessHours.isActive(time.Date(2024, 6, 3, 9, 0, 0, 0, berlin)))
+	assert.True(t, businessHours.isActive(time.Date(2024, 6, 3, 16, 59, 0, 0, berlin)))
+	assert.False(t, businessHours.isActive(time.Date(2024, 6, 3, 17, 0, 0, 0, berlin)), "the end of the range is excluded")
+	assert.False(t, businessHours.isActive(time.Date(2024, 6, 3, 8, 59, 0, 0, berlin)))
+	assert.False(t, businessHours.isActive(time.Date(2024, 6, 8, 12, 0, 0, 0, berlin)), "saturday isn't scheduled")
+	assert.True(t, businessHours.isActive(time.Date(2024, 6, 3, 7, 30, 0, 0, time.UTC)), "the time zone of the schedule applies")
+
+	overnight := &PolicySchedule{
+		Days:       []time.Weekday{time.Friday},
+		TimeRanges: []PolicyTimeRange{{Start: "22:00", End: "06:00"}},
+	}
+	assert.True(t, overnight.isActive(time.Date(2024, 6, 7, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, overnight.isActive(time.Date(2024, 6, 8, 5, 0, 0, 0, time.UTC)), "the range belongs to the day it starts on")
+	assert.False(t, overnight.isActive(time.Date(2024, 6, 8, 23, 0, 0, 0, time.UTC)))
+	assert.False(t, overnight.isActive(time.Date(2024, 6, 7, 5, 0, 0, 0, time.UTC)))
+
+	weekend := &PolicySchedule{Days: []time.Weekday{time.Saturday, time.Sunday}}
+	assert.True(t, weekend.isActive(time.Date(2024, 6, 9, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, weekend.isActive(time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)))
+
+	var always *PolicySchedule
+	assert.True(t, always.isActive(time.Now()))
+}
+
+func TestPolicySchedule_Validate(t *testing.T) {
+	valid := &PolicySchedule{
+		Days:       []time.Weekday{time.Monday},
+		TimeRanges: []PolicyTimeRange{{Start: "22:00", End: "06:00"}},
+		Timezone:   "America/New_York",
+	}
+	assert.NoError(t, valid.validate())
+
+	for name, schedule := range map[string]*PolicySchedule{
+		"invalid day":       {Days: []time.Weekday{7}},
+		"invalid time":      {TimeRanges: []PolicyTimeRange{{Start: "9am", End: "17:00"}}},
+		"empty time range":  {TimeRanges: []PolicyTimeRange{{Start: "09:00", End: "09:00"}}},
+		"invalid time zone": {Timezone: "Mars/Olympus_Mons"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assertStatusType(t, schedule.validate(), status.InvalidArgument)
+		})
+	}
+}
+
+func TestPolicySchedule_NextTransition(t *testing.T) {
+	t.Run("business hours", func(t *testing.T) {
+		schedule := &PolicySchedule{
+			Days:       []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			TimeRanges: []PolicyTimeRange{{Start: "09:00", End: "17:00"}},
+		}
+		// 2024-06-07 is a Friday
+		next, ok := schedule.nextTransition(time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC))
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2024, 6, 7, 17, 0, 0, 0, time.UTC), next.UTC())
+
+		next, ok = schedule.nextTransition(next)
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC), next.UTC(), "the weekend should be skipped")
+	})
+
+	t.Run("spring forward skips the start of the range", func(t *testing.T) {
+		// the clocks of Berlin move from 02:00 to 03:00 on 2024-03-31, at 01:00 UTC
+		schedule := &PolicySchedule{
+			TimeRanges: []PolicyTimeRange{{Start: "02:30", End: "04:00"}},
+			Timezone:   "Europe/Berlin",
+		}
+		next, ok := schedule.nextTransition(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC))
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC), next.UTC(), "the range should start when the clock moves forward")
+
+		next, ok = schedule.nextTransition(next)
+		require.True(t, ok)
+		assert.Equal(t, time.Date(2024, 3, 31, 2, 0, 0, 0, time.UTC), next.UTC(), "the range should end at 04:00 summer time")
+	})
+
+	t.Run("fall back repeats the range", func(t *testing.T) {
+		// the clocks of Berlin move from 03:00 back to 02:00 on 2024-10-27, at 01:00 UTC
+		schedule := &PolicySchedule{
+			TimeRanges: []PolicyTimeRange{{Start: "02:00", End: "02:30"}},
+			Timezone:   "Europe/Berlin",
+		}
+		after := time.Date(2024, 10, 26, 23, 0, 0, 0, time.UTC)
+		var transitions []time.Time
+		for i := 0; i < 4; i++ {
+			next, ok := schedule.nextTransition(after)
+			require.True(t, ok)
+			transitions = append(transitions, next.UTC())
+			after = next
+		}
+		assert.Equal(t, []time.Time{
+			time.Date(2024, 10, 27, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC),
+			time.Date(2024, 10, 27, 1, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 27, 1, 30, 0, 0, time.UTC),
+		}, transitions)
+	})
+
+	t.Run("every day without time ranges never changes", func(t *testing.T) {
+		schedule := &PolicySchedule{Timezone: "Europe/Berlin"}
+		_, ok := schedule.nextTransition(time.Now())
+		assert.False(t, ok)
+	})
+}
+
+func newPolicyScheduleTestAccount() *Account {
+	return &Account{
+		Id: "account",
+		Peers: map[string]*nbpeer.Peer{
+			"peerA": {ID: "peerA", IP: net.ParseIP("100.65.14.88"), Status: &nbpeer.PeerStatus{}},
+			"peerB": {ID: "peerB", IP: net.ParseIP("100.65.80.39"), Status: &nbpeer.PeerStatus{}},
+			"peerC": {ID: "peerC", IP: net.ParseIP("100.65.254.139"), Status: &nbpeer.PeerStatus{}},
+		},
+		Groups: map[string]*Group{
+			"GroupA": {ID: "GroupA", Name: "a", Peers: []string{"peerA"}},
+			"GroupB": {ID: "GroupB", Name: "b", Peers: []string{"peerB"}},
+			"GroupC": {ID: "GroupC", Name: "c", Peers: []string{"peerC"}},
+		},
+		Policies: []*Policy{
+			{
+				ID:      "PolicyBusinessHours",
+				Name:    "business hours",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{
+						ID:            "RuleBusinessHours",
+						Name:          "business hours",
+						Enabled:       true,
+						Action:        PolicyTrafficActionAccept,
+						Protocol:      PolicyRuleProtocolALL,
+						Bidirectional: true,
+						Sources:       []string{"GroupA"},
+						Destinations:  []string{"GroupB"},
+						Schedule: &PolicySchedule{
+							TimeRanges: []PolicyTimeRange{{Start: "09:00", End: "17:00"}},
+						},
+					},
+				},
+			},
+			{
+				ID:      "PolicyAlways",
+				Name:    "always",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{
+						ID:            "RuleAlways",
+						Name:          "always",
+						Enabled:       true,
+						Action:        PolicyTrafficActionAccept,
+						Protocol:      PolicyRuleProtocolALL,
+						Bidirectional: true,
+						Sources:       []string{"GroupA"},
+						Destinations:  []string{"GroupC"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAccount_getPeersByPolicySchedule(t *testing.T) {
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time {
+		return now
+	}
+	t.Cleanup(func() {
+		timeNow = time.Now
+	})
+
+	account := newPolicyScheduleTestAccount()
+
+	peers, _ := account.getPeerConnectionResources("peerA")
+	assert.Len(t, peers, 2)
+	assert.Contains(t, peers, account.Peers["peerB"], "the scheduled rule should be active in the time range")
+
+	now = time.Date(2024, 6, 3, 18, 0, 0, 0, time.UTC)
+	peers, _ = account.getPeerConnectionResources("peerA")
+	assert.Len(t, peers, 1)
+	assert.NotContains(t, peers, account.Peers["peerB"], "the scheduled rule should be inactive outside of the time range")
+	assert.Contains(t, peers, account.Peers["peerC"])
+
+	peers, _ = account.getPeerConnectionResources("peerB")
+	assert.Empty(t, peers)
+}
+
+func TestAccount_getPolicyScheduleAffectedPeers(t *testing.T) {
+	account := newPolicyScheduleTestAccount()
+
+	from := time.Date(2024, 6, 3, 16, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 3, 17, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"peerA", "peerB"}, account.getPolicyScheduleAffectedPeers(from, to),
+		"only the peers of the rule getting inactive should be updated")
+
+	assert.Empty(t, account.getPolicyScheduleAffectedPeers(from, from.Add(30*time.Minute)))
+
+	next, ok := account.getNextPolicyScheduleTransition(from)
+	require.True(t, ok)
+	assert.Equal(t, to, next.UTC())
+
+	account.Policies[0].Enabled = false
+	_, ok = account.getNextPolicyScheduleTransition(from)
+	assert.False(t, ok, "disabled policies shouldn't be scheduled")
+}
+
+func TestDefaultAccountManager_PolicyScheduleJob(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	now := time.Date(2024, 6, 3, 16, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time {
+		return now
+	}
+	t.Cleanup(func() {
+		timeNow = time.Now
+	})
+
+	scheduled := newPolicyScheduleTestAccount()
+	account.Peers = scheduled.Peers
+	for id, group := range scheduled.Groups {
+		account.Groups[id] = group
+	}
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	policy := scheduled.Policies[0]
+	policy.Rules[0].Schedule.Timezone = "Mars/Olympus_Mons"
+	err = manager.SavePolicy(account.Id, userID, policy)
+	assertStatusType(t, err, status.InvalidArgument)
+
+	policy.Rules[0].Schedule.Timezone = ""
+	require.NoError(t, manager.SavePolicy(account.Id, userID, policy))
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	saved, err := manager.GetPolicy(account.Id, policy.ID, userID)
+	require.NoError(t, err)
+	require.NotNil(t, saved.Rules[0].Schedule, "the schedule should be stored")
+	assert.Equal(t, policy.Rules[0].Schedule.TimeRanges, saved.Rules[0].Schedule.TimeRanges)
+	serial := account.Network.CurrentSerial()
+
+	job := manager.policyScheduleJob(account.Id, now)
+
+	now = now.Add(30 * time.Minute)
+	next, reschedule := job()
+	require.True(t, reschedule)
+	assert.Equal(t, 30*time.Minute, next)
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, serial, account.Network.CurrentSerial(), "no rule changed its state")
+
+	now = now.Add(next)
+	next, reschedule = job()
+	require.True(t, reschedule)
+	assert.Equal(t, 16*time.Hour, next)
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, serial+1, account.Network.CurrentSerial(), "the peers of the rule should be updated")
+}
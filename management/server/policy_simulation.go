@@ -0,0 +1,261 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/netbirdio/management-integrations/additions"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// PolicySimulationDefaultDenyReason is the reason of a simulated traffic no rule accepts
+const PolicySimulationDefaultDenyReason = "denied by default (no matching allow)"
+
+// PolicySimulationRequest describes the traffic of a policy simulation, from the source peer to the destination peer
+type PolicySimulationRequest struct {
+	// Source and Destination are peer IDs or peer IPs
+	Source      string
+	Destination string
+	// Protocol is tcp, udp or icmp
+	Protocol PolicyRuleProtocolType
+	// Port is the destination port of the tcp and udp traffic
+	Port int
+}
+
+// PolicySimulation is the decision of the policies on a simulated traffic. The traffic is evaluated the same way as
+// the effective ACL, only the enabled and active policy rules decide it.
+type PolicySimulation struct {
+	SourcePeerID      string
+	DestinationPeerID string
+	Allowed           bool
+	// Reason explains the decision in a human-readable form
+	Reason string
+	// Action, policy and rule of the rule deciding the traffic, empty when it is denied by default
+	Action     PolicyTrafficActionType
+	PolicyID   string
+	PolicyName string
+	RuleID     string
+	RuleName   string
+	// SourceGroups and DestinationGroups are the IDs of the rule groups the source and the destination peers matched
+	SourceGroups      []string
+	DestinationGroups []string
+}
+
+// SimulatePolicy evaluates whether the policies of the account allow the traffic of the request and which rule
+// decides it, without changing the account
+func (am *DefaultAccountManager) SimulatePolicy(accountID, userID string, request *PolicySimulationRequest) (*PolicySimulation, error) {
+	unlock := am.Store.AcquireAccountReadLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourcePolicies, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to simulate policies")
+	}
+
+	if err := request.validate(); err != nil {
+		return nil, err
+	}
+
+	source := account.findPeerByIDOrIP(request.Source)
+	if source == nil {
+		return nil, status.Errorf(status.NotFound, "source peer %s not found", request.Source)
+	}
+
+	destination := account.findPeerByIDOrIP(request.Destination)
+	if destination == nil {
+		return nil, status.Errorf(status.NotFound, "destination peer %s not found", request.Destination)
+	}
+
+	return account.simulatePolicy(source, destination, request.Protocol, request.Port), nil
+}
+
+// validate checks the protocol of the request and that the port is only set for the protocols having ports
+func (r *PolicySimulationRequest) validate() error {
+	switch r.Protocol {
+	case PolicyRuleProtocolTCP, PolicyRuleProtocolUDP:
+		if r.Port < 1 || r.Port > 65535 {
+			return status.Errorf(status.InvalidArgument, "valid port value is in 1..65535 range")
+		}
+	case PolicyRuleProtocolICMP:
+		if r.Port != 0 {
+			return status.Errorf(status.InvalidArgument, "port is not allowed for the icmp protocol")
+		}
+	default:
+		return status.Errorf(status.InvalidArgument, "invalid protocol %q, expecting tcp, udp or icmp", r.Protocol)
+	}
+
+	if r.Source == "" || r.Destination == "" {
+		return status.Errorf(status.InvalidArgument, "source and destination peers are required")
+	}
+
+	return nil
+}
+
+// findPeerByIDOrIP returns the peer with the ID or the IP, nil if there is none
+func (a *Account) findPeerByIDOrIP(peerIDOrIP string) *nbpeer.Peer {
+	if peer := a.Peers[peerIDOrIP]; peer != nil {
+		return peer
+	}
+	for _, peer := range a.Peers {
+		if peer.IP.String() == peerIDOrIP {
+			return peer
+		}
+	}
+	return nil
+}
+
+// simulatePolicy evaluates the traffic from the source to the destination peer with the rules of the source peer ACL.
+// A matching drop rule takes precedence over the accept rules, like in the firewall of the peers.
+func (a *Account) simulatePolicy(source, destination *nbpeer.Peer, protocol PolicyRuleProtocolType, port int) *PolicySimulation {
+	simulation := &PolicySimulation{
+		SourcePeerID:      source.ID,
+		DestinationPeerID: destination.ID,
+		SourceGroups:      make([]string, 0),
+		DestinationGroups: make([]string, 0),
+	}
+
+	for _, peer := range []*nbpeer.Peer{source, destination} {
+		if peer.IsQuarantined() || len(additions.ValidatePeers([]*nbpeer.Peer{peer})) == 0 {
+			simulation.Reason = fmt.Sprintf("denied, peer %s is excluded from the network", peer.Name)
+			return simulation
+		}
+	}
+
+	var accept, drop *PolicySimulation
+	a.forEachPeerRule(source.ID, func(policy *Policy, rule *PolicyRule, peers []*nbpeer.Peer, direction int) {
+		if direction != firewallRuleDirectionOUT || !rule.matchesTraffic(protocol, port) {
+			return
+		}
+
+		reachesDestination := false
+		for _, p := range peers {
+			if p != nil && p.ID == destination.ID {
+				reachesDestination = true
+				break
+			}
+		}
+		if !reachesDestination {
+			return
+		}
+
+		if rule.Action == PolicyTrafficActionDrop && drop == nil {
+			drop = a.newPolicySimulationMatch(simulation, policy, rule)
+		}
+		if rule.Action == PolicyTrafficActionAccept && accept == nil {
+			accept = a.newPolicySimulationMatch(simulation, policy, rule)
+		}
+	})
+
+	switch {
+	case drop != nil:
+		drop.Reason = "denied by " + a.policySimulationMatchReason(drop)
+		return drop
+	case accept != nil:
+		accept.Allowed = true
+		accept.Reason = "allowed by " + a.policySimulationMatchReason(accept)
+		return accept
+	default:
+		simulation.Reason = PolicySimulationDefaultDenyReason
+		return simulation
+	}
+}
+
+// newPolicySimulationMatch returns the simulation decided by the rule with the groups the peers matched. The groups of a
+// bidirectional rule are matched the other way around when the rule applies in the reverse direction.
+func (a *Account) newPolicySimulationMatch(simulation *PolicySimulation, policy *Policy, rule *PolicyRule) *PolicySimulation {
+	match := *simulation
+	match.Action = rule.Action
+	match.PolicyID = policy.ID
+	match.PolicyName = policy.Name
+	match.RuleID = rule.ID
+	match.RuleName = rule.Name
+	match.SourceGroups = a.peerGroupsOf(rule.Sources, simulation.SourcePeerID)
+	match.DestinationGroups = a.peerGroupsOf(rule.Destinations, simulation.DestinationPeerID)
+
+	if rule.Bidirectional && (len(match.SourceGroups) == 0 || len(match.DestinationGroups) == 0) {
+		reversedSources := a.peerGroupsOf(rule.Destinations, simulation.SourcePeerID)
+		reversedDestinations := a.peerGroupsOf(rule.Sources, simulation.DestinationPeerID)
+		if len(reversedSources) > 0 && len(reversedDestinations) > 0 {
+			match.SourceGroups = reversedSources
+			match.DestinationGroups = reversedDestinations
+		}
+	}
+
+	return &match
+}
+
+// policySimulationMatchReason describes the rule deciding the simulation and the groups the peers matched
+func (a *Account) policySimulationMatchReason(match *PolicySimulation) string {
+	reason := fmt.Sprintf("rule %q of policy %q", match.RuleName, match.PolicyName)
+	if len(match.SourceGroups) > 0 {
+		reason += fmt.Sprintf(", source in groups %s", strings.Join(a.groupNames(match.SourceGroups), ", "))
+	}
+	if len(match.DestinationGroups) > 0 {
+		reason += fmt.Sprintf(", destination in groups %s", strings.Join(a.groupNames(match.DestinationGroups), ", "))
+	}
+	return reason
+}
+
+// groupNames returns the names of the groups
+func (a *Account) groupNames(groupIDs []string) []string {
+	names := make([]string, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		if group := a.Groups[groupID]; group != nil {
+			names = append(names, group.Name)
+		}
+	}
+	return names
+}
+
+// peerGroupsOf returns the sorted IDs of the groups containing the peer
+func (a *Account) peerGroupsOf(groupIDs []string, peerID string) []string {
+	groups := make([]string, 0)
+	for _, groupID := range groupIDs {
+		group := a.Groups[groupID]
+		if group == nil {
+			continue
+		}
+		for _, id := range group.Peers {
+			if id == peerID {
+				groups = append(groups, groupID)
+				break
+			}
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// matchesTraffic returns true if the rule applies to the protocol and the port. The ports of the rule don't
+// apply to ICMP, and a rule without ports matches all of them.
+func (pm *PolicyRule) matchesTraffic(protocol PolicyRuleProtocolType, port int) bool {
+	for _, ruleProtocol := range pm.protocols() {
+		if ruleProtocol != PolicyRuleProtocolALL && ruleProtocol != protocol {
+			continue
+		}
+
+		if ruleProtocol == PolicyRuleProtocolALL || protocol == PolicyRuleProtocolICMP || len(pm.Ports) == 0 {
+			return true
+		}
+
+		for _, rulePort := range pm.Ports {
+			if p, err := strconv.Atoi(rulePort); err == nil && p == port {
+				return true
+			}
+		}
+	}
+	return false
+}
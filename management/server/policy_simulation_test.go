@@ -0,0 +1,253 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestAccount_simulatePolicy(t *testing.T) {
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time {
+		return now
+	}
+	t.Cleanup(func() {
+		timeNow = time.Now
+	})
+
+	testCases := []struct {
+		name        string
+		modify      func(account *Account)
+		source      string
+		destination string
+		protocol    PolicyRuleProtocolType
+		port        int
+		expected    *PolicySimulation
+	}{
+		{
+			name:        "port of an accept rule is allowed",
+			source:      "peerA",
+			destination: "peerB",
+			protocol:    PolicyRuleProtocolTCP,
+			port:        443,
+			expected: &PolicySimulation{
+				SourcePeerID:      "peerA",
+				DestinationPeerID: "peerB",
+				Allowed:           true,
+				Reason:            `allowed by rule "HTTP" of policy "Web", source in groups Clients, destination in groups Web`,
+				Action:            PolicyTrafficActionAccept,
+				PolicyID:          "PolicyWeb",
+				PolicyName:        "Web",
+				RuleID:            "RuleHTTP",
+				RuleName:          "HTTP",
+				SourceGroups:      []string{"GroupClients"},
+				DestinationGroups: []string{"GroupWeb"},
+			},
+		},
+		{
+			name:        "port outside of the rule ports is denied by default",
+			source:      "peerA",
+			destination: "peerB",
+			protocol:    PolicyRuleProtocolTCP,
+			port:        22,
+			expected: &PolicySimulation{
+				SourcePeerID:      "peerA",
+				DestinationPeerID: "peerB",
+				Reason:            PolicySimulationDefaultDenyReason,
+				SourceGroups:      []string{},
+				DestinationGroups: []string{},
+			},
+		},
+		{
+			name:        "direct rule doesn't allow the reverse traffic",
+			source:      "peerB",
+			destination: "peerA",
+			protocol:    PolicyRuleProtocolTCP,
+			port:        443,
+			expected: &PolicySimulation{
+				SourcePeerID:      "peerB",
+				DestinationPeerID: "peerA",
+				Reason:            PolicySimulationDefaultDenyReason,
+				SourceGroups:      []string{},
+				DestinationGroups: []string{},
+			},
+		},
+		{
+			name:        "disabled policy doesn't allow the traffic",
+			source:      "peerA",
+			destination: "peerC",
+			protocol:    PolicyRuleProtocolUDP,
+			port:        5432,
+			expected: &PolicySimulation{
+				SourcePeerID:      "peerA",
+				DestinationPeerID: "peerC",
+				Reason:            PolicySimulationDefaultDenyReason,
+				SourceGroups:      []string{},
+				DestinationGroups: []string{},
+			},
+		},
+		{
+			name:        "bidirectional rule matches the groups the other way around",
+			source:      "peerC",
+			destination: "peerB",
+			protocol:    PolicyRuleProtocolICMP,
+			expected: &PolicySimulation{
+				SourcePeerID:      "peerC",
+				DestinationPeerID: "peerB",
+				Allowed:           true,
+				Reason:            `allowed by rule "Web to DB" of policy "DB", source in groups DB, destination in groups Web`,
+				Action:            PolicyTrafficActionAccept,
+				PolicyID:          "PolicyDB",
+				PolicyName:        "DB",
+				RuleID:            "RuleDB",
+				RuleName:          "Web to DB",
+				SourceGroups:      []string{"GroupDB"},
+				DestinationGroups: []string{"GroupWeb"},
+			},
+		},
+		{
+			name: "drop rule takes precedence over the accept rules",
+			modify: func(account *Account) {
+				account.Policies = append(account.Policies, &Policy{
+					ID:      "PolicyBlock",
+					Name:    "Block",
+					Enabled: true,
+					Rules: []*PolicyRule{
+						{
+							ID:           "RuleBlock",
+							Name:         "Block HTTPS",
+							Enabled:      true,
+							Action:       PolicyTrafficActionDrop,
+							Protocol:     PolicyRuleProtocolTCP,
+							Ports:        []string{"443"},
+							Sources:      []string{"GroupClients"},
+							Destinations: []string{"GroupWeb"},
+						},
+					},
+				})
+			},
+			source:      "peerA",
+			destination: "peerB",
+			protocol:    PolicyRuleProtocolTCP,
+			port:        443,
+			expected: &PolicySimulation{
+				SourcePeerID:      "peerA",
+				DestinationPeerID: "peerB",
+				Reason:            `denied by rule "Block HTTPS" of policy "Block", source in groups Clients, destination in groups Web`,
+				Action:            PolicyTrafficActionDrop,
+				PolicyID:          "PolicyBlock",
+				PolicyName:        "Block",
+				RuleID:            "RuleBlock",
+				RuleName:          "Block HTTPS",
+				SourceGroups:      []string{"GroupClients"},
+				DestinationGroups: []string{"GroupWeb"},
+			},
+		},
+		{
+			name: "rule outside of its schedule doesn't allow the traffic",
+			modify: func(account *Account) {
+				account.Policies[0].Rules[0].Schedule = &PolicySchedule{Days: []time.Weekday{time.Sunday}}
+			},
+			source:      "peerA",
+			destination: "peerB",
+			protocol:    PolicyRuleProtocolTCP,
+			port:        80,
+			expected: &PolicySimulation{
+				SourcePeerID:      "peerA",
+				DestinationPeerID: "peerB",
+				Reason:            PolicySimulationDefaultDenyReason,
+				SourceGroups:      []string{},
+				DestinationGroups: []string{},
+			},
+		},
+		{
+			name: "peer waiting for an approval is denied",
+			modify: func(account *Account) {
+				account.Peers["peerB"].Status.RequiresApproval = true
+			},
+			source:      "peerA",
+			destination: "peerB",
+			protocol:    PolicyRuleProtocolTCP,
+			port:        80,
+			expected: &PolicySimulation{
+				SourcePeerID:      "peerA",
+				DestinationPeerID: "peerB",
+				Reason:            "denied, peer web is excluded from the network",
+				SourceGroups:      []string{},
+				DestinationGroups: []string{},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			account := newEffectiveACLTestAccount()
+			if testCase.modify != nil {
+				testCase.modify(account)
+			}
+
+			simulation := account.simulatePolicy(account.Peers[testCase.source], account.Peers[testCase.destination],
+				testCase.protocol, testCase.port)
+			assert.Equal(t, testCase.expected, simulation)
+		})
+	}
+}
+
+func TestDefaultAccountManager_SimulatePolicy(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	testAccount := newEffectiveACLTestAccount()
+	account.Peers = testAccount.Peers
+	for id, group := range testAccount.Groups {
+		account.Groups[id] = group
+	}
+	account.Policies = testAccount.Policies
+	regularUser := NewRegularUser("regular_user")
+	account.Users[regularUser.Id] = regularUser
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	simulation, err := manager.SimulatePolicy(account.Id, userID, &PolicySimulationRequest{
+		Source:      "peerA",
+		Destination: "100.65.80.39",
+		Protocol:    PolicyRuleProtocolTCP,
+		Port:        80,
+	})
+	require.NoError(t, err)
+	assert.True(t, simulation.Allowed)
+	assert.Equal(t, "peerB", simulation.DestinationPeerID, "the destination should be found by its IP")
+	assert.Equal(t, "RuleHTTP", simulation.RuleID)
+
+	_, err = manager.SimulatePolicy(account.Id, userID, &PolicySimulationRequest{
+		Source: "peerA", Destination: "unknown_peer", Protocol: PolicyRuleProtocolTCP, Port: 80,
+	})
+	assertStatusType(t, err, status.NotFound)
+
+	_, err = manager.SimulatePolicy(account.Id, userID, &PolicySimulationRequest{
+		Source: "peerA", Destination: "peerB", Protocol: PolicyRuleProtocolTCP,
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.SimulatePolicy(account.Id, userID, &PolicySimulationRequest{
+		Source: "peerA", Destination: "peerB", Protocol: PolicyRuleProtocolICMP, Port: 80,
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.SimulatePolicy(account.Id, userID, &PolicySimulationRequest{
+		Source: "peerA", Destination: "peerB", Protocol: PolicyRuleProtocolALL,
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.SimulatePolicy(account.Id, regularUser.Id, &PolicySimulationRequest{
+		Source: "peerA", Destination: "peerB", Protocol: PolicyRuleProtocolTCP, Port: 80,
+	})
+	assertStatusType(t, err, status.PermissionDenied)
+}
@@ -443,6 +443,258 @@ func TestAccount_getPeersByPolicyDirect(t *testing.T) {
 	})
 }
 
+func TestAccount_getPeersByPolicyDropRules(t *testing.T) {
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"peerA": {
+				ID:     "peerA",
+				IP:     net.ParseIP("100.65.14.88"),
+				Status: &nbpeer.PeerStatus{},
+			},
+			"peerB": {
+				ID:     "peerB",
+				IP:     net.ParseIP("100.65.80.39"),
+				Status: &nbpeer.PeerStatus{},
+			},
+			"peerC": {
+				ID:     "peerC",
+				IP:     net.ParseIP("100.65.254.139"),
+				Status: &nbpeer.PeerStatus{},
+			},
+		},
+		Groups: map[string]*Group{
+			"GroupAll": {
+				ID:    "GroupAll",
+				Name:  "All",
+				Peers: []string{"peerA", "peerB", "peerC"},
+			},
+			"GroupA": {
+				ID:    "GroupA",
+				Name:  "a",
+				Peers: []string{"peerA"},
+			},
+			"GroupB": {
+				ID:    "GroupB",
+				Name:  "b",
+				Peers: []string{"peerB"},
+			},
+			"GroupC": {
+				ID:    "GroupC",
+				Name:  "c",
+				Peers: []string{"peerC"},
+			},
+		},
+		Policies: []*Policy{
+			{
+				ID:      "PolicyAcceptAB",
+				Name:    "accept a to b",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{
+						ID:            "RuleAcceptAB",
+						Name:          "accept a to b",
+						Enabled:       true,
+						Action:        PolicyTrafficActionAccept,
+						Protocol:      PolicyRuleProtocolALL,
+						Bidirectional: true,
+						Sources:       []string{"GroupA"},
+						Destinations:  []string{"GroupB"},
+					},
+				},
+			},
+			{
+				ID:      "PolicyDropAC",
+				Name:    "drop a to c",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{
+						ID:            "RuleDropAC",
+						Name:          "drop a to c",
+						Enabled:       true,
+						Action:        PolicyTrafficActionDrop,
+						Protocol:      PolicyRuleProtocolALL,
+						Bidirectional: true,
+						Sources:       []string{"GroupA"},
+						Destinations:  []string{"GroupC"},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("peer permitted only by a drop rule is not connected", func(t *testing.T) {
+		peers, firewallRules := account.getPeerConnectionResources("peerA")
+		assert.Len(t, peers, 1)
+		assert.Contains(t, peers, account.Peers["peerB"])
+		assert.NotContains(t, peers, account.Peers["peerC"])
+
+		for _, rule := range firewallRules {
+			if rule.PeerIP == "100.65.254.139" {
+				assert.Equal(t, string(PolicyTrafficActionDrop), rule.Action)
+			}
+		}
+
+		peers, _ = account.getPeerConnectionResources("peerC")
+		assert.Len(t, peers, 0)
+	})
+
+	t.Run("peer permitted by an accept rule is connected despite a drop rule", func(t *testing.T) {
+		acceptPolicy := account.Policies[1].Copy()
+		acceptPolicy.ID = "PolicyAcceptAC"
+		acceptPolicy.Rules[0].Action = PolicyTrafficActionAccept
+		account.Policies = append(account.Policies, acceptPolicy)
+
+		peers, _ := account.getPeerConnectionResources("peerA")
+		assert.Len(t, peers, 2)
+		assert.Contains(t, peers, account.Peers["peerC"])
+
+		peers, _ = account.getPeerConnectionResources("peerC")
+		assert.Contains(t, peers, account.Peers["peerA"])
+	})
+}
+
+func TestAccount_getPeersByPolicyAdditionalProtocols(t *testing.T) {
+	account := &Account{
+		Peers: map[string]*nbpeer.Peer{
+			"peerA": {
+				ID:     "peerA",
+				IP:     net.ParseIP("100.65.14.88"),
+				Status: &nbpeer.PeerStatus{},
+			},
+			"peerB": {
+				ID:     "peerB",
+				IP:     net.ParseIP("100.65.80.39"),
+				Status: &nbpeer.PeerStatus{},
+			},
+			"peerC": {
+				ID:     "peerC",
+				IP:     net.ParseIP("100.65.254.139"),
+				Status: &nbpeer.PeerStatus{},
+			},
+		},
+		Groups: map[string]*Group{
+			"GroupAll": {
+				ID:    "GroupAll",
+				Name:  "All",
+				Peers: []string{"peerA", "peerB", "peerC"},
+			},
+			"GroupA": {
+				ID:    "GroupA",
+				Name:  "a",
+				Peers: []string{"peerA"},
+			},
+			"GroupB": {
+				ID:    "GroupB",
+				Name:  "b",
+				Peers: []string{"peerB"},
+			},
+		},
+		Policies: []*Policy{
+			{
+				ID:      "PolicyPingAndSSH",
+				Name:    "ping and ssh",
+				Enabled: true,
+				Rules: []*PolicyRule{
+					{
+						ID:                  "RulePingAndSSH",
+						Name:                "ping and ssh",
+						Enabled:             true,
+						Action:              PolicyTrafficActionAccept,
+						Protocol:            PolicyRuleProtocolTCP,
+						AdditionalProtocols: []PolicyRuleProtocolType{PolicyRuleProtocolICMP, PolicyRuleProtocolTCP},
+						Ports:               []string{"22"},
+						Bidirectional:       true,
+						Sources:             []string{"GroupA"},
+						Destinations:        []string{"GroupB"},
+					},
+				},
+			},
+		},
+	}
+
+	_, firewallRules := account.getPeerConnectionResources("peerA")
+
+	expectedFirewallRules := []*FirewallRule{
+		{
+			PeerIP:    "100.65.80.39",
+			Direction: firewallRuleDirectionIN,
+			Action:    "accept",
+			Protocol:  "tcp",
+			Port:      "22",
+		},
+		{
+			PeerIP:    "100.65.80.39",
+			Direction: firewallRuleDirectionIN,
+			Action:    "accept",
+			Protocol:  "icmp",
+		},
+		{
+			PeerIP:    "100.65.80.39",
+			Direction: firewallRuleDirectionOUT,
+			Action:    "accept",
+			Protocol:  "tcp",
+			Port:      "22",
+		},
+		{
+			PeerIP:    "100.65.80.39",
+			Direction: firewallRuleDirectionOUT,
+			Action:    "accept",
+			Protocol:  "icmp",
+		},
+	}
+	assert.ElementsMatch(t, expectedFirewallRules, firewallRules)
+
+	account.Policies[0].Rules[0].Bidirectional = false
+
+	_, firewallRules = account.getPeerConnectionResources("peerA")
+	expectedFirewallRules = []*FirewallRule{
+		{
+			PeerIP:    "100.65.80.39",
+			Direction: firewallRuleDirectionOUT,
+			Action:    "accept",
+			Protocol:  "tcp",
+			Port:      "22",
+		},
+		{
+			PeerIP:    "100.65.80.39",
+			Direction: firewallRuleDirectionOUT,
+			Action:    "accept",
+			Protocol:  "icmp",
+		},
+		{
+			PeerIP:    "100.65.80.39",
+			Direction: firewallRuleDirectionIN,
+			Action:    "accept",
+			Protocol:  "icmp",
+		},
+	}
+	assert.ElementsMatch(t, expectedFirewallRules, firewallRules, "only the ICMP part of a one way rule should apply both ways")
+
+	_, firewallRules = account.getPeerConnectionResources("peerB")
+	expectedFirewallRules = []*FirewallRule{
+		{
+			PeerIP:    "100.65.14.88",
+			Direction: firewallRuleDirectionIN,
+			Action:    "accept",
+			Protocol:  "tcp",
+			Port:      "22",
+		},
+		{
+			PeerIP:    "100.65.14.88",
+			Direction: firewallRuleDirectionIN,
+			Action:    "accept",
+			Protocol:  "icmp",
+		},
+		{
+			PeerIP:    "100.65.14.88",
+			Direction: firewallRuleDirectionOUT,
+			Action:    "accept",
+			Protocol:  "icmp",
+		},
+	}
+	assert.ElementsMatch(t, expectedFirewallRules, firewallRules, "only the ICMP part of a one way rule should apply both ways")
+}
+
 func sortFunc() func(a *FirewallRule, b *FirewallRule) int {
 	return func(a, b *FirewallRule) int {
 		// Concatenate PeerIP and Direction as string for comparison
@@ -0,0 +1,471 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/rs/xid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// PostureCheckActionDeny rejects the registration and the login of the peers failing the check
+	PostureCheckActionDeny = "deny"
+	// PostureCheckActionQuarantine lets in the peers failing the check and excludes them from the network until
+	// they comply, the peers are marked with PeerStatus.NonCompliant
+	PostureCheckActionQuarantine = "quarantine"
+)
+
+// PostureCheck is a requirement on the system meta the peers report, e.g. the minimum OS version. The enabled checks
+// are evaluated when a peer registers, on every login of the peer as the meta may change and when the checks change.
+type PostureCheck struct {
+	// ID of the check
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `json:"-" gorm:"index"`
+	// Name of the check, unique in the account
+	Name string
+	// Description of the check visible in the UI
+	Description string
+	// Enabled status of the check
+	Enabled bool
+	// Action is what happens to the peers failing the check, PostureCheckActionDeny or PostureCheckActionQuarantine
+	Action string
+	// AllowedOS lists the operating systems the peers may run as reported by the client, e.g. linux or windows.
+	// Empty allows any.
+	AllowedOS []string `gorm:"serializer:json"`
+	// AllowedKernels lists the kernels the peers may run as reported by the client, e.g. Linux or Darwin.
+	// Empty allows any.
+	AllowedKernels []string `gorm:"serializer:json"`
+	// MinOSVersions is the oldest OS version the peers may run by operating system, e.g. {"darwin": "13.0"}.
+	// The peers of the other operating systems aren't checked.
+	MinOSVersions map[string]string `gorm:"serializer:json"`
+	// MinClientVersion is the oldest NetBird client version the peers may run, empty doesn't check it
+	MinClientVersion string
+}
+
+// Copy returns a copy of the posture check
+func (c *PostureCheck) Copy() *PostureCheck {
+	minOSVersions := make(map[string]string, len(c.MinOSVersions))
+	for os, v := range c.MinOSVersions {
+		minOSVersions[os] = v
+	}
+	return &PostureCheck{
+		ID:               c.ID,
+		AccountID:        c.AccountID,
+		Name:             c.Name,
+		Description:      c.Description,
+		Enabled:          c.Enabled,
+		Action:           c.Action,
+		AllowedOS:        append([]string{}, c.AllowedOS...),
+		AllowedKernels:   append([]string{}, c.AllowedKernels...),
+		MinOSVersions:    minOSVersions,
+		MinClientVersion: c.MinClientVersion,
+	}
+}
+
+// EventMeta returns activity event meta related to the posture check
+func (c *PostureCheck) EventMeta() map[string]any {
+	return map[string]any{"name": c.Name, "action": c.Action}
+}
+
+// evaluate returns the reason the system meta fails the check, empty if it complies. allowUnknownClient lets in the
+// peers reporting a development or an unparsable client version like the minimum client version of the account does.
+func (c *PostureCheck) evaluate(meta nbpeer.PeerSystemMeta, allowUnknownClient bool) string {
+	if len(c.AllowedOS) > 0 && !containsFold(c.AllowedOS, meta.GoOS) {
+		return fmt.Sprintf("operating system %q is not allowed", meta.GoOS)
+	}
+
+	if len(c.AllowedKernels) > 0 && !containsFold(c.AllowedKernels, meta.Kernel) {
+		return fmt.Sprintf("kernel %q is not allowed", meta.Kernel)
+	}
+
+	for os, minVersion := range c.MinOSVersions {
+		if !strings.EqualFold(os, meta.GoOS) {
+			continue
+		}
+		if reason := checkMinOSVersion(meta.Core, minVersion); reason != "" {
+			return reason
+		}
+	}
+
+	clientSettings := &Settings{MinClientVersion: c.MinClientVersion, MinClientVersionAllowUnknown: allowUnknownClient}
+	if err := checkClientVersion(clientSettings, meta.WtVersion); err != nil {
+		if sErr, ok := status.FromError(err); ok {
+			return sErr.Message
+		}
+		return err.Error()
+	}
+
+	return ""
+}
+
+// checkMinOSVersion returns the reason the reported OS version is older than the minimum one, empty if it isn't.
+// The versions are compared by their core parts, a version which can't be parsed fails the check.
+func checkMinOSVersion(reported, minVersion string) string {
+	floor, err := version.NewVersion(minVersion)
+	if err != nil {
+		// validated on save, an invalid version stored before doesn't lock the peers out
+		log.Warnf("ignoring invalid minimum OS version %q of a posture check: %v", minVersion, err)
+		return ""
+	}
+
+	v, err := version.NewVersion(reported)
+	if err != nil {
+		return fmt.Sprintf("OS version %q can't be checked against the minimum version %s", reported, floor.Core())
+	}
+
+	if v.Core().LessThan(floor.Core()) {
+		return fmt.Sprintf("OS version %s is older than the minimum version %s", reported, floor.Core())
+	}
+	return ""
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// PostureCheckFailure is an enabled posture check a peer fails
+type PostureCheckFailure struct {
+	CheckID   string
+	CheckName string
+	Action    string
+	Reason    string
+}
+
+// NonCompliantPeer is a peer failing some of the enabled posture checks of the account
+type NonCompliantPeer struct {
+	Peer     *nbpeer.Peer
+	Failures []*PostureCheckFailure
+}
+
+// evaluatePostureChecks returns the enabled posture checks the system meta fails, ordered by the check name
+func (a *Account) evaluatePostureChecks(meta nbpeer.PeerSystemMeta) []*PostureCheckFailure {
+	allowUnknownClient := a.Settings != nil && a.Settings.MinClientVersionAllowUnknown
+
+	var failures []*PostureCheckFailure
+	for _, check := range a.PostureChecks {
+		if !check.Enabled {
+			continue
+		}
+		if reason := check.evaluate(meta, allowUnknownClient); reason != "" {
+			failures = append(failures, &PostureCheckFailure{
+				CheckID:   check.ID,
+				CheckName: check.Name,
+				Action:    check.Action,
+				Reason:    reason,
+			})
+		}
+	}
+
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].CheckName < failures[j].CheckName
+	})
+	return failures
+}
+
+// checkPeerPosture returns a PreconditionFailed error with the reason when the system meta fails a deny posture check,
+// otherwise it returns true when the meta fails a quarantine posture check
+func (a *Account) checkPeerPosture(meta nbpeer.PeerSystemMeta) (bool, error) {
+	failures := a.evaluatePostureChecks(meta)
+	for _, failure := range failures {
+		if failure.Action == PostureCheckActionDeny {
+			return false, status.Errorf(status.PreconditionFailed,
+				"peer doesn't meet the posture check %q of the account: %s", failure.CheckName, failure.Reason)
+		}
+	}
+	return len(failures) > 0, nil
+}
+
+// applyPostureChecks rejects the login of the peer failing a deny posture check or updates its non-compliant flag.
+// It returns true when the flag changed, the account has to be saved and the network maps of the peers updated.
+func (am *DefaultAccountManager) applyPostureChecks(account *Account, peer *nbpeer.Peer, meta nbpeer.PeerSystemMeta) (bool, error) {
+	nonCompliant, err := account.checkPeerPosture(meta)
+	if err != nil {
+		log.Debugf("rejecting peer %s: %v", peer.Key, err)
+		return false, err
+	}
+
+	return am.setPeerNonCompliant(account, peer, nonCompliant), nil
+}
+
+// setPeerNonCompliant updates the non-compliant flag of the peer, returns true when it changed
+func (am *DefaultAccountManager) setPeerNonCompliant(account *Account, peer *nbpeer.Peer, nonCompliant bool) bool {
+	if peer.Status == nil || peer.Status.NonCompliant == nonCompliant {
+		return false
+	}
+
+	newStatus := peer.Status.Copy()
+	newStatus.NonCompliant = nonCompliant
+	peer.Status = newStatus
+	account.UpdatePeer(peer)
+
+	if nonCompliant {
+		am.StoreEvent(activity.SystemInitiator, peer.ID, account.Id, activity.PeerQuarantinedByPostureCheck,
+			peer.EventMeta(am.GetDNSDomain()))
+	}
+	return true
+}
+
+// refreshPeersCompliance re-evaluates the posture checks for all the peers of the account after the checks changed.
+// The peers failing a deny check are quarantined too, they are rejected on their next login.
+// It returns true when some of the peers changed their non-compliant flag.
+func (am *DefaultAccountManager) refreshPeersCompliance(account *Account) bool {
+	changed := false
+	for _, peer := range account.Peers {
+		nonCompliant := len(account.evaluatePostureChecks(peer.Meta)) > 0
+		if am.setPeerNonCompliant(account, peer, nonCompliant) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// GetNonCompliantPeers returns the peers of the account failing some of the enabled posture checks and the checks
+// they fail, the connected peers first
+func (am *DefaultAccountManager) GetNonCompliantPeers(accountID, userID string) ([]*NonCompliantPeer, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourcePeers, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view the non-compliant peers")
+	}
+
+	nonCompliant := make([]*NonCompliantPeer, 0)
+	for _, peer := range account.Peers {
+		failures := account.evaluatePostureChecks(peer.Meta)
+		if len(failures) == 0 {
+			continue
+		}
+
+		peerCopy := peer.Copy()
+		if peerCopy.Status == nil {
+			peerCopy.Status = &nbpeer.PeerStatus{}
+		}
+		nonCompliant = append(nonCompliant, &NonCompliantPeer{Peer: peerCopy, Failures: failures})
+	}
+
+	sort.Slice(nonCompliant, func(i, j int) bool {
+		if nonCompliant[i].Peer.Status.Connected != nonCompliant[j].Peer.Status.Connected {
+			return nonCompliant[i].Peer.Status.Connected
+		}
+		if nonCompliant[i].Peer.Name != nonCompliant[j].Peer.Name {
+			return nonCompliant[i].Peer.Name < nonCompliant[j].Peer.Name
+		}
+		return nonCompliant[i].Peer.ID < nonCompliant[j].Peer.ID
+	})
+
+	return nonCompliant, nil
+}
+
+// GetPostureCheck returns the posture check of the account
+func (am *DefaultAccountManager) GetPostureCheck(accountID, userID, checkID string) (*PostureCheck, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourcePolicies, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view posture checks")
+	}
+
+	check, found := account.PostureChecks[checkID]
+	if !found {
+		return nil, status.Errorf(status.NotFound, "posture check with ID %s not found", checkID)
+	}
+
+	return check.Copy(), nil
+}
+
+// ListPostureChecks returns the posture checks of the account
+func (am *DefaultAccountManager) ListPostureChecks(accountID, userID string) ([]*PostureCheck, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourcePolicies, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view posture checks")
+	}
+
+	checks := make([]*PostureCheck, 0, len(account.PostureChecks))
+	for _, check := range account.PostureChecks {
+		checks = append(checks, check.Copy())
+	}
+
+	return checks, nil
+}
+
+// SavePostureCheck creates the posture check or updates the existing one with the same ID and re-evaluates the checks
+// for all the peers of the account
+func (am *DefaultAccountManager) SavePostureCheck(accountID, userID string, checkToSave *PostureCheck) (*PostureCheck, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	if checkToSave == nil {
+		return nil, status.Errorf(status.InvalidArgument, "posture check provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkPermission(account, userID, ResourcePolicies, OperationWrite); err != nil {
+		return nil, err
+	}
+
+	check := checkToSave.Copy()
+	check.AccountID = accountID
+
+	event := activity.PostureCheckUpdated
+	if check.ID == "" {
+		check.ID = xid.New().String()
+		event = activity.PostureCheckCreated
+	} else if _, ok := account.PostureChecks[check.ID]; !ok {
+		return nil, status.Errorf(status.NotFound, "posture check with ID %s was not found", check.ID)
+	}
+
+	if err = validatePostureCheck(check, account); err != nil {
+		return nil, err
+	}
+
+	if account.PostureChecks == nil {
+		account.PostureChecks = make(map[string]*PostureCheck)
+	}
+	account.PostureChecks[check.ID] = check
+
+	err = am.savePostureChecks(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.StoreEvent(userID, check.ID, accountID, event, check.EventMeta())
+
+	return check.Copy(), nil
+}
+
+// DeletePostureCheck deletes the posture check, the peers quarantined only by it join the network
+func (am *DefaultAccountManager) DeletePostureCheck(accountID, userID, checkID string) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkPermission(account, userID, ResourcePolicies, OperationWrite); err != nil {
+		return err
+	}
+
+	check := account.PostureChecks[checkID]
+	if check == nil {
+		return status.Errorf(status.NotFound, "posture check %s wasn't found", checkID)
+	}
+	delete(account.PostureChecks, checkID)
+
+	err = am.savePostureChecks(account)
+	if err != nil {
+		return err
+	}
+
+	am.StoreEvent(userID, check.ID, accountID, activity.PostureCheckDeleted, check.EventMeta())
+
+	return nil
+}
+
+// savePostureChecks re-evaluates the checks for the peers, saves the account and updates the peers if some of them
+// moved in or out of the quarantine
+func (am *DefaultAccountManager) savePostureChecks(account *Account) error {
+	changed := am.refreshPeersCompliance(account)
+	if changed {
+		account.Network.IncSerial()
+	}
+
+	err := am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		am.updateAccountPeers(account)
+	}
+	return nil
+}
+
+// validatePostureCheck validates the check and normalizes its name
+func validatePostureCheck(check *PostureCheck, account *Account) error {
+	check.Name = strings.TrimSpace(check.Name)
+	if check.Name == "" {
+		return status.Errorf(status.InvalidArgument, "posture check name should not be empty")
+	}
+
+	for _, other := range account.PostureChecks {
+		if other.ID != check.ID && other.Name == check.Name {
+			return status.Errorf(status.AlreadyExists, "posture check with name %s already exists", check.Name)
+		}
+	}
+
+	switch check.Action {
+	case PostureCheckActionDeny, PostureCheckActionQuarantine:
+	default:
+		return status.Errorf(status.InvalidArgument, "invalid posture check action %q, expected %s or %s",
+			check.Action, PostureCheckActionDeny, PostureCheckActionQuarantine)
+	}
+
+	for os, minVersion := range check.MinOSVersions {
+		if _, err := version.NewVersion(minVersion); err != nil {
+			return status.Errorf(status.InvalidArgument, "invalid minimum OS version %q for %s", minVersion, os)
+		}
+	}
+
+	if check.MinClientVersion != "" {
+		if _, err := version.NewVersion(check.MinClientVersion); err != nil {
+			return status.Errorf(status.InvalidArgument, "invalid minimum client version %q", check.MinClientVersion)
+		}
+	}
+
+	if len(check.AllowedOS) == 0 && len(check.AllowedKernels) == 0 && len(check.MinOSVersions) == 0 && check.MinClientVersion == "" {
+		return status.Errorf(status.InvalidArgument, "posture check %s should have at least one requirement", check.Name)
+	}
+
+	return nil
+}
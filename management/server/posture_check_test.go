@@ -0,0 +1,240 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestPostureCheck_evaluate(t *testing.T) {
+	check := &PostureCheck{
+		AllowedOS:        []string{"linux", "darwin"},
+		AllowedKernels:   []string{"Linux", "Darwin"},
+		MinOSVersions:    map[string]string{"darwin": "13.0"},
+		MinClientVersion: "0.27.0",
+	}
+
+	tests := []struct {
+		name         string
+		meta         nbpeer.PeerSystemMeta
+		allowUnknown bool
+		compliant    bool
+	}{
+		{
+			name:      "Compliant Linux",
+			meta:      nbpeer.PeerSystemMeta{GoOS: "linux", Kernel: "Linux", Core: "22.04", WtVersion: "0.27.0"},
+			compliant: true,
+		},
+		{
+			name:      "Compliant macOS",
+			meta:      nbpeer.PeerSystemMeta{GoOS: "darwin", Kernel: "Darwin", Core: "14.1.2", WtVersion: "0.28.0-dev"},
+			compliant: true,
+		},
+		{
+			name: "OS Not Allowed",
+			meta: nbpeer.PeerSystemMeta{GoOS: "windows", Kernel: "Linux", Core: "10.0.19045", WtVersion: "0.27.0"},
+		},
+		{
+			name: "Kernel Not Allowed",
+			meta: nbpeer.PeerSystemMeta{GoOS: "linux", Kernel: "FreeBSD", Core: "13.2", WtVersion: "0.27.0"},
+		},
+		{
+			name: "Old OS Version",
+			meta: nbpeer.PeerSystemMeta{GoOS: "darwin", Kernel: "Darwin", Core: "12.6", WtVersion: "0.27.0"},
+		},
+		{
+			name: "Unknown OS Version",
+			meta: nbpeer.PeerSystemMeta{GoOS: "darwin", Kernel: "Darwin", Core: "", WtVersion: "0.27.0"},
+		},
+		{
+			name: "Old Client Version",
+			meta: nbpeer.PeerSystemMeta{GoOS: "linux", Kernel: "Linux", Core: "22.04", WtVersion: "0.26.3"},
+		},
+		{
+			name: "Unknown Client Version",
+			meta: nbpeer.PeerSystemMeta{GoOS: "linux", Kernel: "Linux", Core: "22.04", WtVersion: "development"},
+		},
+		{
+			name:         "Allowed Unknown Client Version",
+			meta:         nbpeer.PeerSystemMeta{GoOS: "linux", Kernel: "Linux", Core: "22.04", WtVersion: "development"},
+			allowUnknown: true,
+			compliant:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reason := check.evaluate(tc.meta, tc.allowUnknown)
+			if tc.compliant {
+				assert.Empty(t, reason)
+			} else {
+				assert.NotEmpty(t, reason)
+			}
+		})
+	}
+}
+
+func TestDefaultAccountManager_PostureChecks(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	macMeta := nbpeer.PeerSystemMeta{Hostname: "mac", GoOS: "darwin", Kernel: "Darwin", Core: "12.6", WtVersion: "0.27.0"}
+	linuxMeta := nbpeer.PeerSystemMeta{Hostname: "linux", GoOS: "linux", Kernel: "Linux", Core: "22.04", WtVersion: "0.27.0"}
+
+	macKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	macPeer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{Key: macKey.PublicKey().String(), Meta: macMeta})
+	require.NoError(t, err)
+
+	linuxKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	linuxPeer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{Key: linuxKey.PublicKey().String(), Meta: linuxMeta})
+	require.NoError(t, err)
+
+	_, err = manager.SavePostureCheck(account.Id, userID, &PostureCheck{
+		Name:          "Supported macOS",
+		Enabled:       true,
+		Action:        "block",
+		MinOSVersions: map[string]string{"darwin": "13.0"},
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	_, err = manager.SavePostureCheck(account.Id, userID, &PostureCheck{
+		Name:    "Empty",
+		Enabled: true,
+		Action:  PostureCheckActionDeny,
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+
+	check, err := manager.SavePostureCheck(account.Id, userID, &PostureCheck{
+		Name:          "Supported macOS",
+		Enabled:       true,
+		Action:        PostureCheckActionQuarantine,
+		MinOSVersions: map[string]string{"darwin": "13.0"},
+	})
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.True(t, account.Peers[macPeer.ID].Status.NonCompliant, "the existing peer should be quarantined")
+	assert.False(t, account.Peers[linuxPeer.ID].Status.NonCompliant)
+
+	networkMap := account.GetPeerNetworkMap(linuxPeer.ID, manager.dnsDomain)
+	for _, peer := range networkMap.Peers {
+		assert.NotEqual(t, macPeer.ID, peer.ID, "the quarantined peer should be excluded from the network")
+	}
+	assert.Empty(t, account.GetPeerNetworkMap(macPeer.ID, manager.dnsDomain).Peers)
+
+	nonCompliant, err := manager.GetNonCompliantPeers(account.Id, userID)
+	require.NoError(t, err)
+	if assert.Len(t, nonCompliant, 1) {
+		assert.Equal(t, macPeer.ID, nonCompliant[0].Peer.ID)
+		if assert.Len(t, nonCompliant[0].Failures, 1) {
+			assert.Equal(t, check.ID, nonCompliant[0].Failures[0].CheckID)
+			assert.Contains(t, nonCompliant[0].Failures[0].Reason, "12.6")
+		}
+	}
+
+	upgradedMeta := macMeta
+	upgradedMeta.Core = "14.1"
+	loggedIn, _, err := manager.LoginPeer(PeerLogin{WireGuardPubKey: macKey.PublicKey().String(), Meta: upgradedMeta})
+	require.NoError(t, err)
+	assert.False(t, loggedIn.Status.NonCompliant, "the peer should leave the quarantine after the upgrade")
+
+	check.Action = PostureCheckActionDeny
+	check.MinOSVersions = map[string]string{"darwin": "15.0"}
+	_, err = manager.SavePostureCheck(account.Id, userID, check)
+	require.NoError(t, err)
+
+	_, _, err = manager.LoginPeer(PeerLogin{WireGuardPubKey: macKey.PublicKey().String(), Meta: upgradedMeta})
+	assertStatusType(t, err, status.PreconditionFailed)
+
+	newMacKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	_, _, err = manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{Key: newMacKey.PublicKey().String(), Meta: upgradedMeta})
+	assertStatusType(t, err, status.PreconditionFailed)
+
+	err = manager.DeletePostureCheck(account.Id, userID, check.ID)
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Len(t, account.Peers, 2, "the denied peer shouldn't be added")
+	assert.False(t, account.Peers[macPeer.ID].Status.NonCompliant, "the peer should leave the quarantine")
+	assert.Empty(t, account.PostureChecks)
+}
+
+func TestDefaultAccountManager_PostureChecksPermissions(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := createAccount(manager, "test_account", "account_creator", "")
+	require.NoError(t, err)
+
+	account.Users["regular_user"] = NewRegularUser("regular_user")
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, err = manager.SavePostureCheck(account.Id, "regular_user", &PostureCheck{
+		Name:             "Client",
+		Enabled:          true,
+		Action:           PostureCheckActionDeny,
+		MinClientVersion: "0.27.0",
+	})
+	assertStatusType(t, err, status.PermissionDenied)
+
+	_, err = manager.GetNonCompliantPeers(account.Id, "regular_user")
+	assertStatusType(t, err, status.PermissionDenied)
+}
+
+func TestDefaultAccountManager_PostureChecksAllowUnknownClientVersion(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "test_account", userID, "")
+	require.NoError(t, err)
+
+	setupKey, err := manager.CreateSetupKey(account.Id, "test-key", SetupKeyReusable, time.Hour, nil, 999, userID, false)
+	require.NoError(t, err)
+
+	devKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+	devMeta := nbpeer.PeerSystemMeta{Hostname: "dev", GoOS: "linux", Kernel: "Linux", Core: "22.04", WtVersion: "development"}
+	devPeer, _, err := manager.AddPeer(setupKey.Key, "", &nbpeer.Peer{Key: devKey.PublicKey().String(), Meta: devMeta})
+	require.NoError(t, err)
+
+	_, err = manager.SavePostureCheck(account.Id, userID, &PostureCheck{
+		Name:             "Client",
+		Enabled:          true,
+		Action:           PostureCheckActionQuarantine,
+		MinClientVersion: "0.27.0",
+	})
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.True(t, account.Peers[devPeer.ID].Status.NonCompliant, "the unknown client version should fail the check")
+
+	settings := account.Settings.Copy()
+	settings.MinClientVersionAllowUnknown = true
+	_, err = manager.UpdateAccountSettings(account.Id, userID, settings)
+	require.NoError(t, err)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.False(t, account.Peers[devPeer.ID].Status.NonCompliant,
+		"the peer should leave the quarantine when the unknown client versions are allowed")
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// keyedRateLimiter limits the rate of the requests of each key, e.g. a source IP or an account ID, with a token bucket.
+// The buckets of the keys that didn't send a request for the idle timeout are removed.
+type keyedRateLimiter struct {
+	mu          sync.Mutex
+	limit       rate.Limit
+	burst       int
+	idleTimeout time.Duration
+	limiters    map[string]*keyLimiter
+	lastCleanup time.Time
+}
+
+type keyLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newKeyedRateLimiter returns a limiter allowing burst requests at once and then one request every interval per key
+func newKeyedRateLimiter(interval time.Duration, burst int) *keyedRateLimiter {
+	return &keyedRateLimiter{
+		limit:       rate.Every(interval),
+		burst:       burst,
+		idleTimeout: interval * time.Duration(burst),
+		limiters:    make(map[string]*keyLimiter),
+		lastCleanup: time.Now(),
+	}
+}
+
+// allow returns true if the key can send a request now
+func (l *keyedRateLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastCleanup) > l.idleTimeout {
+		for k, kl := range l.limiters {
+			// an idle bucket is full again, so removing it doesn't change the limit
+			if now.Sub(kl.lastSeen) > l.idleTimeout {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	kl, ok := l.limiters[key]
+	if !ok {
+		kl = &keyLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[key] = kl
+	}
+	kl.lastSeen = now
+
+	return kl.limiter.AllowN(now, 1)
+}
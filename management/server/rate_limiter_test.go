@@ -0,0 +1,17 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedRateLimiter(t *testing.T) {
+	limiter := newKeyedRateLimiter(time.Hour, 2)
+
+	assert.True(t, limiter.allow("10.0.0.1"))
+	assert.True(t, limiter.allow("10.0.0.1"))
+	assert.False(t, limiter.allow("10.0.0.1"), "the burst of the key is used")
+	assert.True(t, limiter.allow("10.0.0.2"), "other keys have their own limit")
+}
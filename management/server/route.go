@@ -1,6 +1,7 @@
 package server
 
 import (
+	"net"
 	"net/netip"
 	"unicode/utf8"
 
@@ -27,8 +28,8 @@ func (am *DefaultAccountManager) GetRoute(accountID, routeID, userID string) (*r
 		return nil, err
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
-		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view Network Routes")
+	if !user.HasPermission(ResourceRoutes, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view Network Routes")
 	}
 
 	wantedRoute, found := account.Routes[routeID]
@@ -150,6 +151,11 @@ func (am *DefaultAccountManager) CreateRoute(accountID, network, peerID string,
 		return nil, err
 	}
 
+	err = validateRouteNetwork(account.Network, newPrefix)
+	if err != nil {
+		return nil, err
+	}
+
 	if metric < route.MinMetric || metric > route.MaxMetric {
 		return nil, status.Errorf(status.InvalidArgument, "metric should be between %d and %d", route.MinMetric, route.MaxMetric)
 	}
@@ -234,6 +240,11 @@ func (am *DefaultAccountManager) SaveRoute(accountID, userID string, routeToSave
 		return err
 	}
 
+	err = validateRouteNetwork(account.Network, routeToSave.Network)
+	if err != nil {
+		return err
+	}
+
 	err = validateGroups(routeToSave.Groups, account.Groups)
 	if err != nil {
 		return err
@@ -296,8 +307,8 @@ func (am *DefaultAccountManager) ListRoutes(accountID, userID string) ([]*route.
 		return nil, err
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
-		return nil, status.Errorf(status.PermissionDenied, "only users with admin power can view Network Routes")
+	if !user.HasPermission(ResourceRoutes, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view Network Routes")
 	}
 
 	routes := make([]*route.Route, 0, len(account.Routes))
@@ -308,6 +319,41 @@ func (am *DefaultAccountManager) ListRoutes(accountID, userID string) ([]*route.
 	return routes, nil
 }
 
+// validateRouteNetwork rejects the route networks overlapping with the account network the peer IPs are allocated from,
+// the traffic to the overlapping peer addresses would be routed to the routing peer instead of the peers
+func validateRouteNetwork(network *Network, prefix netip.Prefix) error {
+	if accountNet, ok := routeNetworkConflict(network, prefix); ok {
+		return status.Errorf(status.InvalidArgument, "route network %s overlaps with the account network %s the peer IPs are allocated from",
+			prefix.String(), accountNet.String())
+	}
+	return nil
+}
+
+// routeNetworkConflict returns the account network the route network overlaps with.
+// The default routes of the exit nodes are expected to cover the account network and never conflict.
+func routeNetworkConflict(network *Network, prefix netip.Prefix) (netip.Prefix, bool) {
+	if network == nil || !prefix.IsValid() || prefix.Bits() == 0 {
+		return netip.Prefix{}, false
+	}
+
+	for _, ipNet := range []net.IPNet{network.Net, network.NetV6} {
+		accountNet, ok := ipNetToPrefix(ipNet)
+		if ok && accountNet.Overlaps(prefix) {
+			return accountNet, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+func ipNetToPrefix(ipNet net.IPNet) (netip.Prefix, bool) {
+	addr, ok := netip.AddrFromSlice(ipNet.IP)
+	if !ok {
+		return netip.Prefix{}, false
+	}
+	ones, _ := ipNet.Mask.Size()
+	return netip.PrefixFrom(addr.Unmap(), ones).Masked(), true
+}
+
 func toProtocolRoute(route *route.Route) *proto.Route {
 	return &proto.Route{
 		ID:          route.ID,
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"net"
 	"net/netip"
 	"testing"
 
@@ -10,6 +11,7 @@ import (
 
 	"github.com/netbirdio/netbird/management/server/activity"
 	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
 	"github.com/netbirdio/netbird/route"
 )
 
@@ -1237,3 +1239,63 @@ func initTestRouteAccount(t *testing.T, am *DefaultAccountManager) (*Account, er
 
 	return am.Store.GetAccount(account.Id)
 }
+
+func TestRouteNetworkConflict(t *testing.T) {
+	_, netV4, _ := net.ParseCIDR("100.64.0.0/16")
+	_, netV6, _ := net.ParseCIDR("fd00:1234:5678:1::/64")
+	network := &Network{Net: *netV4, NetV6: *netV6}
+
+	testCases := []struct {
+		name             string
+		prefix           string
+		expectedConflict string
+	}{
+		{name: "Exact Overlap", prefix: "100.64.0.0/16", expectedConflict: "100.64.0.0/16"},
+		{name: "Route Subnet Of The Account Network", prefix: "100.64.10.0/24", expectedConflict: "100.64.0.0/16"},
+		{name: "Route Covering The Account Network", prefix: "100.64.0.0/10", expectedConflict: "100.64.0.0/16"},
+		{name: "Single Peer Address", prefix: "100.64.0.5/32", expectedConflict: "100.64.0.0/16"},
+		{name: "IPv6 Overlap", prefix: "fd00:1234:5678::/48", expectedConflict: "fd00:1234:5678:1::/64"},
+		{name: "Adjacent Network", prefix: "100.65.0.0/16"},
+		{name: "Private Network", prefix: "10.0.0.0/24"},
+		{name: "Exit Node Default Route", prefix: "0.0.0.0/0"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			accountNet, conflict := routeNetworkConflict(network, netip.MustParsePrefix(testCase.prefix))
+			if testCase.expectedConflict == "" {
+				require.False(t, conflict, "route network shouldn't conflict, got %s", accountNet)
+				return
+			}
+			require.True(t, conflict, "route network should conflict")
+			require.Equal(t, testCase.expectedConflict, accountNet.String())
+		})
+	}
+}
+
+func TestCreateRoute_OverlappingAccountNetwork(t *testing.T) {
+	am, err := createRouterManager(t)
+	require.NoError(t, err)
+
+	account, err := initTestRouteAccount(t, am)
+	require.NoError(t, err)
+
+	accountPrefix := netip.MustParsePrefix(account.Network.Net.String())
+	peerSubnet := netip.PrefixFrom(accountPrefix.Addr(), 24)
+
+	for _, network := range []string{accountPrefix.String(), peerSubnet.String()} {
+		_, err = am.CreateRoute(account.Id, network, peer1ID, []string{}, "", "conflict", false, 9999, []string{routeGroup1}, true, userID)
+		sErr, ok := status.FromError(err)
+		require.True(t, ok, "route %s should be rejected", network)
+		require.Equal(t, status.InvalidArgument, sErr.Type())
+	}
+
+	exitNode, err := am.CreateRoute(account.Id, "0.0.0.0/0", peer1ID, []string{}, "", "exit", false, 9999, []string{routeGroup1}, true, userID)
+	require.NoError(t, err, "default route should be allowed")
+
+	exitNode.Network = peerSubnet
+	err = am.SaveRoute(account.Id, userID, exitNode)
+	sErr, ok := status.FromError(err)
+	require.True(t, ok, "updated route should be rejected")
+	require.Equal(t, status.InvalidArgument, sErr.Type())
+}
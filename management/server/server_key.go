@@ -0,0 +1,113 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/telemetry"
+)
+
+const (
+	// DefaultServerKeyLifetime is how long a WireGuard key of the management server is used before it is rotated
+	DefaultServerKeyLifetime = 24 * time.Hour
+	// serverKeyOverlap is how long the previous server key is still accepted after the rotation,
+	// so the requests of the clients that fetched the key right before it expired are not rejected
+	serverKeyOverlap = 5 * time.Minute
+)
+
+// serverKeys holds the WireGuard key the management server encrypts the messages with.
+// The key is rotated lazily on the first use after it expired.
+type serverKeys struct {
+	mux      sync.Mutex
+	lifetime time.Duration
+	metrics  telemetry.AppMetrics
+	now      func() time.Time
+
+	current   wgtypes.Key
+	expiresAt time.Time
+	// previous is the key rotated out, it is accepted until previousValidUntil
+	previous           *wgtypes.Key
+	previousValidUntil time.Time
+}
+
+func newServerKeys(lifetime time.Duration, metrics telemetry.AppMetrics) (*serverKeys, error) {
+	if lifetime <= 0 {
+		lifetime = DefaultServerKeyLifetime
+	}
+
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &serverKeys{
+		lifetime: lifetime,
+		metrics:  metrics,
+		now:      time.Now,
+		current:  key,
+	}
+	keys.expiresAt = keys.now().Add(lifetime)
+
+	return keys, nil
+}
+
+// get returns the current key and its expiration time
+func (k *serverKeys) get() (wgtypes.Key, time.Time, error) {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+
+	err := k.rotateIfExpired()
+	if err != nil {
+		return wgtypes.Key{}, time.Time{}, err
+	}
+
+	return k.current, k.expiresAt, nil
+}
+
+// candidates returns the keys a request can be encrypted with, the current key goes first
+func (k *serverKeys) candidates() ([]wgtypes.Key, error) {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+
+	err := k.rotateIfExpired()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []wgtypes.Key{k.current}
+	if k.previous != nil && k.now().Before(k.previousValidUntil) {
+		keys = append(keys, *k.previous)
+	}
+
+	return keys, nil
+}
+
+func (k *serverKeys) rotateIfExpired() error {
+	now := k.now()
+	if now.Before(k.expiresAt) {
+		return nil
+	}
+
+	key, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return err
+	}
+
+	// the overlap counts from the expiration, a key that expired long ago is not accepted anymore
+	previous := k.current
+	k.previous = &previous
+	k.previousValidUntil = k.expiresAt.Add(serverKeyOverlap)
+
+	k.current = key
+	k.expiresAt = now.Add(k.lifetime)
+
+	log.Infof("rotated the management server key, the new key %s expires at %s", key.PublicKey(), k.expiresAt.Format(time.RFC3339))
+	if k.metrics != nil {
+		k.metrics.GRPCMetrics().CountServerKeyRotation()
+	}
+
+	return nil
+}
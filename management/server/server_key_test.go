@@ -0,0 +1,80 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/encryption"
+	"github.com/netbirdio/netbird/management/proto"
+)
+
+func TestServerKeys_Rotation(t *testing.T) {
+	now := time.Now()
+	keys, err := newServerKeys(time.Hour, nil)
+	require.NoError(t, err)
+	keys.now = func() time.Time { return now }
+	keys.expiresAt = now.Add(time.Hour)
+
+	first, expiresAt, err := keys.get()
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), expiresAt, "the key should expire after its lifetime")
+
+	candidates, err := keys.candidates()
+	require.NoError(t, err)
+	assert.Equal(t, []wgtypes.Key{first}, candidates, "only the current key should be accepted before the rotation")
+
+	now = now.Add(time.Hour + time.Minute)
+	second, expiresAt, err := keys.get()
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second, "the expired key should be rotated")
+	assert.Equal(t, now.Add(time.Hour), expiresAt, "the new key should expire after its lifetime")
+
+	candidates, err = keys.candidates()
+	require.NoError(t, err)
+	assert.Equal(t, []wgtypes.Key{second, first}, candidates, "the previous key should be accepted during the overlap")
+
+	now = now.Add(serverKeyOverlap)
+	candidates, err = keys.candidates()
+	require.NoError(t, err)
+	assert.Equal(t, []wgtypes.Key{second}, candidates, "the previous key should not be accepted after the overlap")
+}
+
+func TestGRPCServer_ParseRequestWithPreviousKey(t *testing.T) {
+	peerKey, err := wgtypes.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	now := time.Now()
+	keys, err := newServerKeys(time.Hour, nil)
+	require.NoError(t, err)
+	keys.now = func() time.Time { return now }
+	keys.expiresAt = now.Add(time.Hour)
+
+	previous, _, err := keys.get()
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	current, _, err := keys.get()
+	require.NoError(t, err)
+
+	s := &GRPCServer{serverKeys: keys}
+
+	for _, key := range []wgtypes.Key{current, previous} {
+		body, err := encryption.EncryptMessage(key.PublicKey(), peerKey, &proto.SyncRequest{})
+		require.NoError(t, err)
+
+		_, serverKey, err := s.parseRequest(&proto.EncryptedMessage{WgPubKey: peerKey.PublicKey().String(), Body: body}, &proto.SyncRequest{})
+		require.NoError(t, err, "the request should be accepted during the overlap")
+		assert.Equal(t, key, serverKey, "the response should be encrypted with the key of the request")
+	}
+
+	now = now.Add(serverKeyOverlap)
+	body, err := encryption.EncryptMessage(previous.PublicKey(), peerKey, &proto.SyncRequest{})
+	require.NoError(t, err)
+
+	_, _, err = s.parseRequest(&proto.EncryptedMessage{WgPubKey: peerKey.PublicKey().String(), Body: body}, &proto.SyncRequest{})
+	assert.Error(t, err, "the request encrypted with the previous key should be rejected after the overlap")
+}
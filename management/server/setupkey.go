@@ -26,6 +26,11 @@ const (
 	DefaultSetupKeyName = "Default key"
 	// SetupKeyUnlimitedUsage indicates an unlimited usage of a setup key
 	SetupKeyUnlimitedUsage = 0
+
+	// SetupKeyPeerTypeEphemeral is a key registering only ephemeral peers
+	SetupKeyPeerTypeEphemeral SetupKeyPeerType = "ephemeral"
+	// SetupKeyPeerTypePersistent is a key registering only persistent peers
+	SetupKeyPeerTypePersistent SetupKeyPeerType = "persistent"
 )
 
 const (
@@ -66,6 +71,9 @@ type SetupKeyUpdateOperation struct {
 // SetupKeyType is the type of setup key
 type SetupKeyType string
 
+// SetupKeyPeerType is the type of the peers a setup key registers
+type SetupKeyPeerType string
+
 // SetupKey represents a pre-authorized key used to register machines (peers)
 type SetupKey struct {
 	Id string
@@ -90,6 +98,16 @@ type SetupKey struct {
 	UsageLimit int
 	// Ephemeral indicate if the peers will be ephemeral or not
 	Ephemeral bool
+	// PeerType is the type of the peers the key registers, it is enforced on the registration.
+	// The keys created before it was introduced have it empty and follow Ephemeral.
+	PeerType SetupKeyPeerType
+	// CreatedBy is the ID of the user who created the key, empty for the keys created before it was introduced
+	CreatedBy string
+	// PendingApproval indicates that the key waits for the approval of an admin other than its creator,
+	// it can't register peers meanwhile
+	PendingApproval bool
+	// ApprovedBy is the ID of the user who approved the key, empty if the key didn't require an approval
+	ApprovedBy string
 }
 
 // Copy copies SetupKey to a new object
@@ -114,6 +132,11 @@ func (key *SetupKey) Copy() *SetupKey {
 		AutoGroups: autoGroups,
 		UsageLimit: key.UsageLimit,
 		Ephemeral:  key.Ephemeral,
+		PeerType:   key.PeerType,
+
+		CreatedBy:       key.CreatedBy,
+		PendingApproval: key.PendingApproval,
+		ApprovedBy:      key.ApprovedBy,
 	}
 }
 
@@ -142,9 +165,26 @@ func (key *SetupKey) IncrementUsage() *SetupKey {
 	return c
 }
 
-// IsValid is true if the key was not revoked, is not expired and used not more than it was supposed to
+// GetPeerType returns the type of the peers the key registers
+func (key *SetupKey) GetPeerType() SetupKeyPeerType {
+	if key.PeerType != "" {
+		return key.PeerType
+	}
+	if key.Ephemeral {
+		return SetupKeyPeerTypeEphemeral
+	}
+	return SetupKeyPeerTypePersistent
+}
+
+// IsValid is true if the key was not revoked, is not expired, is not pending approval and used not more than it was
+// supposed to
 func (key *SetupKey) IsValid() bool {
-	return !key.IsRevoked() && !key.IsExpired() && !key.IsOverUsed()
+	return !key.IsRevoked() && !key.IsExpired() && !key.IsPendingApproval() && !key.IsOverUsed()
+}
+
+// IsPendingApproval if the key waits for an approval
+func (key *SetupKey) IsPendingApproval() bool {
+	return key.PendingApproval
 }
 
 // IsRevoked if key was revoked
@@ -159,11 +199,16 @@ func (key *SetupKey) IsExpired() bool {
 
 // IsOverUsed if the key was used too many times. SetupKey.UsageLimit == 0 indicates the unlimited usage.
 func (key *SetupKey) IsOverUsed() bool {
-	limit := key.UsageLimit
+	limit := key.usageLimit()
+	return limit > 0 && key.UsedTimes >= limit
+}
+
+// usageLimit returns the number of peers the key can register, SetupKeyUnlimitedUsage for the reusable keys without a limit
+func (key *SetupKey) usageLimit() int {
 	if key.Type == SetupKeyOneOff {
-		limit = 1
+		return 1
 	}
-	return limit > 0 && key.UsedTimes >= limit
+	return key.UsageLimit
 }
 
 // GenerateSetupKey generates a new setup key
@@ -174,6 +219,10 @@ func GenerateSetupKey(name string, t SetupKeyType, validFor time.Duration, autoG
 	if t == SetupKeyOneOff {
 		limit = 1
 	}
+	peerType := SetupKeyPeerTypePersistent
+	if ephemeral {
+		peerType = SetupKeyPeerTypeEphemeral
+	}
 	return &SetupKey{
 		Id:         strconv.Itoa(int(Hash(key))),
 		Key:        key,
@@ -187,6 +236,7 @@ func GenerateSetupKey(name string, t SetupKeyType, validFor time.Duration, autoG
 		AutoGroups: autoGroups,
 		UsageLimit: limit,
 		Ephemeral:  ephemeral,
+		PeerType:   peerType,
 	}
 }
 
@@ -228,14 +278,24 @@ func (am *DefaultAccountManager) CreateSetupKey(accountID string, keyName string
 		}
 	}
 
+	if !am.setupKeyCreationLimiter.allow(accountID) {
+		return nil, status.Errorf(status.TooManyRequests, "too many setup keys created, try again later")
+	}
+
 	setupKey := GenerateSetupKey(keyName, keyType, keyDuration, autoGroups, usageLimit, ephemeral)
+	setupKey.CreatedBy = userID
+	setupKey.PendingApproval = account.Settings.SetupKeyApprovalRequired
 	account.SetupKeys[setupKey.Key] = setupKey
 	err = am.Store.SaveAccount(account)
 	if err != nil {
 		return nil, status.Errorf(status.Internal, "failed adding account key")
 	}
 
-	am.StoreEvent(userID, setupKey.Id, accountID, activity.SetupKeyCreated, setupKey.EventMeta())
+	meta := setupKey.EventMeta()
+	if setupKey.PendingApproval {
+		meta["pending_approval"] = true
+	}
+	am.StoreEvent(userID, setupKey.Id, accountID, activity.SetupKeyCreated, meta)
 
 	for _, g := range setupKey.AutoGroups {
 		group := account.GetGroup(g)
@@ -254,6 +314,7 @@ func (am *DefaultAccountManager) CreateSetupKey(accountID string, keyName string
 // Due to the unique nature of a SetupKey certain properties must not be overwritten
 // (e.g. the key itself, creation date, ID, etc).
 // These properties are overwritten: Name, AutoGroups, Revoked. The rest is copied from the existing key.
+// Changing the AutoGroups puts the key back pending approval when the account requires the approval of the keys.
 func (am *DefaultAccountManager) SaveSetupKey(accountID string, keyToSave *SetupKey, userID string) (*SetupKey, error) {
 	unlock := am.Store.AcquireAccountLock(accountID)
 	defer unlock()
@@ -285,6 +346,14 @@ func (am *DefaultAccountManager) SaveSetupKey(accountID string, keyToSave *Setup
 	newKey.Revoked = keyToSave.Revoked
 	newKey.UpdatedAt = time.Now().UTC()
 
+	// the approval covers the groups the peers of the key join, other groups need a new approval
+	autoGroupsChanged := len(difference(newKey.AutoGroups, oldKey.AutoGroups)) > 0 ||
+		len(difference(oldKey.AutoGroups, newKey.AutoGroups)) > 0
+	if account.Settings.SetupKeyApprovalRequired && autoGroupsChanged {
+		newKey.PendingApproval = true
+		newKey.ApprovedBy = ""
+	}
+
 	account.SetupKeys[newKey.Key] = newKey
 
 	if err = am.Store.SaveAccount(account); err != nil {
@@ -342,7 +411,7 @@ func (am *DefaultAccountManager) ListSetupKeys(accountID, userID string) ([]*Set
 	keys := make([]*SetupKey, 0, len(account.SetupKeys))
 	for _, key := range account.SetupKeys {
 		var k *SetupKey
-		if !(user.HasAdminPower() || user.IsServiceUser) {
+		if !user.HasPermission(ResourceSetupKeys, OperationRead) {
 			k = key.HiddenCopy(999)
 		} else {
 			k = key.Copy()
@@ -384,9 +453,58 @@ func (am *DefaultAccountManager) GetSetupKey(accountID, userID, keyID string) (*
 		foundKey.UpdatedAt = foundKey.CreatedAt
 	}
 
-	if !(user.HasAdminPower() || user.IsServiceUser) {
+	if !user.HasPermission(ResourceSetupKeys, OperationRead) {
 		foundKey = foundKey.HiddenCopy(999)
 	}
 
 	return foundKey, nil
 }
+
+// SetupKeyUnlimitedRemainingUses is the SetupKeyState.RemainingUses of a setup key with an unlimited usage
+const SetupKeyUnlimitedRemainingUses = -1
+
+// SetupKeyState is the state of a setup key reported to unauthenticated callers, e.g. provisioning scripts checking
+// a key before the registration. It doesn't tell anything about the account the key belongs to.
+type SetupKeyState struct {
+	// Valid is true if the key can register a peer
+	Valid bool
+	// RemainingUses is the number of peers the key can still register or SetupKeyUnlimitedRemainingUses
+	RemainingUses int
+	// ExpiresAt is the expiration time of the key
+	ExpiresAt time.Time
+}
+
+// CheckSetupKey returns the state of the setup key without using it. An unknown key is reported as not valid.
+func (am *DefaultAccountManager) CheckSetupKey(setupKey string) (*SetupKeyState, error) {
+	if key, targetAccountID, ok := splitOrgKey(setupKey); ok {
+		return am.checkOrgKey(key, targetAccountID), nil
+	}
+
+	account, err := am.Store.GetAccountBySetupKey(setupKey)
+	if err != nil {
+		if sErr, ok := status.FromError(err); ok && sErr.Type() == status.NotFound {
+			return &SetupKeyState{}, nil
+		}
+		return nil, err
+	}
+
+	key, err := account.FindSetupKey(strings.ToUpper(setupKey))
+	if err != nil {
+		return &SetupKeyState{}, nil
+	}
+
+	state := &SetupKeyState{
+		Valid:         key.IsValid(),
+		RemainingUses: SetupKeyUnlimitedRemainingUses,
+		ExpiresAt:     key.ExpiresAt,
+	}
+
+	if limit := key.usageLimit(); limit > 0 {
+		state.RemainingUses = limit - key.UsedTimes
+		if state.RemainingUses < 0 {
+			state.RemainingUses = 0
+		}
+	}
+
+	return state, nil
+}
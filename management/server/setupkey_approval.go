@@ -0,0 +1,88 @@
+package server
+
+import (
+	"time"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// setupKeyCreationInterval is the interval an account can create a setup key at once it used the burst
+	setupKeyCreationInterval = 6 * time.Second
+	// setupKeyCreationBurst is the number of setup keys an account can create at once
+	setupKeyCreationBurst = 10
+)
+
+// ApproveSetupKey approves the setup key pending approval, so it can register peers. The key has to be approved by
+// a user other than its creator.
+func (am *DefaultAccountManager) ApproveSetupKey(accountID, userID, keyID string) (*SetupKey, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceSetupKeys, OperationWrite) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to approve setup keys")
+	}
+
+	var key *SetupKey
+	for _, k := range account.SetupKeys {
+		if k.Id == keyID {
+			key = k.Copy()
+			break
+		}
+	}
+	if key == nil {
+		return nil, status.Errorf(status.NotFound, "setup key not found")
+	}
+
+	if !key.IsPendingApproval() {
+		return nil, status.Errorf(status.PreconditionFailed, "setup key %s isn't pending approval", key.Name)
+	}
+	if key.IsRevoked() {
+		return nil, status.Errorf(status.PreconditionFailed, "setup key %s is revoked", key.Name)
+	}
+	if key.CreatedBy == userID {
+		return nil, status.Errorf(status.PermissionDenied, "setup key %s has to be approved by another user than its creator", key.Name)
+	}
+
+	key.PendingApproval = false
+	key.ApprovedBy = userID
+	key.UpdatedAt = time.Now().UTC()
+	account.SetupKeys[key.Key] = key
+
+	if err = am.Store.SaveAccount(account); err != nil {
+		return nil, err
+	}
+
+	meta := key.EventMeta()
+	meta["created_by"] = key.CreatedBy
+	am.StoreEvent(userID, key.Id, accountID, activity.SetupKeyApproved, meta)
+
+	return key, nil
+}
+
+// GetPendingSetupKeys returns the setup keys of the account pending approval
+func (am *DefaultAccountManager) GetPendingSetupKeys(accountID, userID string) ([]*SetupKey, error) {
+	keys, err := am.ListSetupKeys(accountID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]*SetupKey, 0)
+	for _, key := range keys {
+		if key.IsPendingApproval() {
+			pending = append(pending, key)
+		}
+	}
+	return pending, nil
+}
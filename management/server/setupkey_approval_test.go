@@ -0,0 +1,155 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestDefaultAccountManager_ApproveSetupKey(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err)
+
+	secondAdmin := NewAdminUser("second-admin")
+	account.Users[secondAdmin.Id] = secondAdmin
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	key, err := manager.CreateSetupKey(account.Id, "without approval", SetupKeyReusable, time.Hour, nil,
+		SetupKeyUnlimitedUsage, userID, false)
+	require.NoError(t, err)
+	assert.False(t, key.IsPendingApproval(), "the approval should be off by default")
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:      time.Hour,
+		SetupKeyApprovalRequired: true,
+	})
+	require.NoError(t, err)
+	getEvent(t, account.Id, manager, activity.AccountSetupKeyApprovalEnabled)
+
+	key, err = manager.CreateSetupKey(account.Id, "with approval", SetupKeyReusable, time.Hour, nil,
+		SetupKeyUnlimitedUsage, userID, false)
+	require.NoError(t, err)
+	assert.True(t, key.IsPendingApproval(), "the new key should be pending approval")
+	assert.Equal(t, userID, key.CreatedBy)
+
+	// the events are stored asynchronously
+	var created *activity.Event
+	require.Eventually(t, func() bool {
+		events, err := manager.GetEvents(account.Id, userID)
+		require.NoError(t, err)
+		for _, event := range events {
+			if event.Activity == activity.SetupKeyCreated && event.TargetID == key.Id {
+				created = event
+			}
+		}
+		return created != nil
+	}, time.Second, 10*time.Millisecond, "the creation of the pending key should be audited")
+	assert.Equal(t, true, created.Meta["pending_approval"])
+
+	pending, err := manager.GetPendingSetupKeys(account.Id, userID)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, key.Id, pending[0].Id)
+
+	state, err := manager.CheckSetupKey(key.Key)
+	require.NoError(t, err)
+	assert.False(t, state.Valid, "a pending key shouldn't be valid")
+
+	addPeer := func() error {
+		peerKey, err := wgtypes.GeneratePrivateKey()
+		require.NoError(t, err)
+		_, _, err = manager.AddPeer(key.Key, "", &nbpeer.Peer{
+			Key:  peerKey.PublicKey().String(),
+			Meta: nbpeer.PeerSystemMeta{Hostname: "peer"},
+		})
+		return err
+	}
+	assertStatusType(t, addPeer(), status.PreconditionFailed)
+
+	_, err = manager.ApproveSetupKey(account.Id, userID, key.Id)
+	assertStatusType(t, err, status.PermissionDenied)
+
+	approved, err := manager.ApproveSetupKey(account.Id, secondAdmin.Id, key.Id)
+	require.NoError(t, err)
+	assert.False(t, approved.IsPendingApproval())
+	assert.Equal(t, secondAdmin.Id, approved.ApprovedBy)
+
+	ev := getEvent(t, account.Id, manager, activity.SetupKeyApproved)
+	assert.Equal(t, secondAdmin.Id, ev.InitiatorID)
+	assert.Equal(t, userID, ev.Meta["created_by"])
+
+	require.NoError(t, addPeer(), "the approved key should register peers")
+
+	pending, err = manager.GetPendingSetupKeys(account.Id, userID)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = manager.ApproveSetupKey(account.Id, secondAdmin.Id, key.Id)
+	assertStatusType(t, err, status.PreconditionFailed)
+}
+
+func TestDefaultAccountManager_SaveSetupKeyResetsApproval(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err)
+
+	secondAdmin := NewAdminUser("second-admin")
+	account.Users[secondAdmin.Id] = secondAdmin
+	account.Groups["admins"] = &Group{ID: "admins", Name: "Admins", Issued: GroupIssuedAPI}
+	account.Settings.SetupKeyApprovalRequired = true
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	key, err := manager.CreateSetupKey(account.Id, "key", SetupKeyReusable, time.Hour, nil,
+		SetupKeyUnlimitedUsage, userID, false)
+	require.NoError(t, err)
+	key, err = manager.ApproveSetupKey(account.Id, secondAdmin.Id, key.Id)
+	require.NoError(t, err)
+
+	key.Name = "renamed"
+	key, err = manager.SaveSetupKey(account.Id, key, userID)
+	require.NoError(t, err)
+	assert.False(t, key.IsPendingApproval(), "renaming the key shouldn't need a new approval")
+
+	key.AutoGroups = []string{"admins"}
+	key, err = manager.SaveSetupKey(account.Id, key, userID)
+	require.NoError(t, err)
+	assert.True(t, key.IsPendingApproval(), "changing the groups of the key should need a new approval")
+	assert.Empty(t, key.ApprovedBy)
+
+	state, err := manager.CheckSetupKey(key.Key)
+	require.NoError(t, err)
+	assert.False(t, state.Valid, "the key with the changed groups shouldn't be valid until approved")
+
+	key, err = manager.ApproveSetupKey(account.Id, secondAdmin.Id, key.Id)
+	require.NoError(t, err)
+	assert.False(t, key.IsPendingApproval())
+}
+
+func TestDefaultAccountManager_CreateSetupKeyRateLimit(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+	manager.setupKeyCreationLimiter = newKeyedRateLimiter(time.Hour, 2)
+
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = manager.CreateSetupKey(account.Id, "key", SetupKeyReusable, time.Hour, nil, SetupKeyUnlimitedUsage, userID, false)
+		require.NoError(t, err)
+	}
+
+	_, err = manager.CreateSetupKey(account.Id, "key", SetupKeyReusable, time.Hour, nil, SetupKeyUnlimitedUsage, userID, false)
+	assertStatusType(t, err, status.TooManyRequests)
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+)
+
+const (
+	// DefaultSetupKeyExpirySweepInterval is the default interval of revoking the expired setup keys
+	DefaultSetupKeyExpirySweepInterval = time.Hour
+
+	setupKeyExpiryJobID          = "setup-key-expiry-sweep"
+	setupKeyExpiryWebhookTimeout = 10 * time.Second
+)
+
+// SetupKeyExpiryNotifier is notified about the setup keys revoked by the expiry sweep of an account
+type SetupKeyExpiryNotifier interface {
+	NotifyExpiredSetupKeys(account *Account, keys []*SetupKey) error
+}
+
+// StartSetupKeyExpirySweep starts a background job that revokes the expired setup keys of all accounts every interval,
+// so they are marked as revoked in the account instead of lingering as valid-looking keys.
+// Keys without an expiration time are never revoked. The notifier is optional.
+func (am *DefaultAccountManager) StartSetupKeyExpirySweep(interval time.Duration, notifier SetupKeyExpiryNotifier) {
+	if interval <= 0 {
+		interval = DefaultSetupKeyExpirySweepInterval
+	}
+
+	log.Infof("revoking expired setup keys every %s", interval)
+	go am.setupKeyExpiry.Schedule(interval, setupKeyExpiryJobID, func() (time.Duration, bool) {
+		am.revokeExpiredSetupKeys(notifier)
+		return interval, true
+	})
+}
+
+// StopSetupKeyExpirySweep stops the background job revoking the expired setup keys
+func (am *DefaultAccountManager) StopSetupKeyExpirySweep() {
+	am.setupKeyExpiry.Cancel([]string{setupKeyExpiryJobID})
+}
+
+func (am *DefaultAccountManager) revokeExpiredSetupKeys(notifier SetupKeyExpiryNotifier) {
+	for _, account := range am.Store.GetAllAccounts() {
+		if !hasExpiredSetupKeys(account) {
+			continue
+		}
+
+		revokedAccount, revokedKeys, err := am.revokeExpiredAccountSetupKeys(account.Id)
+		if err != nil {
+			log.Errorf("failed revoking expired setup keys of account %s: %v", account.Id, err)
+			continue
+		}
+
+		if len(revokedKeys) == 0 || notifier == nil {
+			continue
+		}
+
+		if err = notifier.NotifyExpiredSetupKeys(revokedAccount, revokedKeys); err != nil {
+			log.Warnf("failed notifying about expired setup keys of account %s: %v", account.Id, err)
+		}
+	}
+}
+
+// revokeExpiredAccountSetupKeys revokes the expired setup keys of the account through SaveSetupKey, so the revocation
+// events are stored and the peers are updated like for a revocation by a user. It returns the account and the revoked keys.
+func (am *DefaultAccountManager) revokeExpiredAccountSetupKeys(accountID string) (*Account, []*SetupKey, error) {
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var revokedKeys []*SetupKey
+	for _, key := range account.SetupKeys {
+		if !isExpiredSetupKey(key) {
+			continue
+		}
+
+		keyToRevoke := key.Copy()
+		keyToRevoke.Revoked = true
+		revokedKey, err := am.SaveSetupKey(accountID, keyToRevoke, activity.SystemInitiator)
+		if err != nil {
+			return nil, nil, err
+		}
+		revokedKeys = append(revokedKeys, revokedKey)
+	}
+
+	if len(revokedKeys) == 0 {
+		return account, nil, nil
+	}
+
+	log.Debugf("revoked %d expired setup keys of account %s", len(revokedKeys), accountID)
+
+	for _, key := range revokedKeys {
+		am.StoreEvent(activity.SystemInitiator, key.Id, accountID, activity.SetupKeyExpired, key.EventMeta())
+	}
+
+	account, err = am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return account, revokedKeys, nil
+}
+
+func hasExpiredSetupKeys(account *Account) bool {
+	for _, key := range account.SetupKeys {
+		if isExpiredSetupKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExpiredSetupKey returns true if the key has an expiration time that passed and it wasn't revoked yet
+func isExpiredSetupKey(key *SetupKey) bool {
+	return !key.Revoked && !key.ExpiresAt.IsZero() && key.IsExpired()
+}
+
+// SetupKeyExpiryWebhook notifies an HTTP endpoint about the expired setup keys with a JSON POST request.
+// The request lists the account admins, so the receiver can forward the notification to them.
+type SetupKeyExpiryWebhook struct {
+	url    string
+	client *http.Client
+}
+
+type setupKeyExpiryWebhookKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type setupKeyExpiryWebhookPayload struct {
+	AccountID string                     `json:"account_id"`
+	Domain    string                     `json:"domain"`
+	Admins    []string                   `json:"admins"`
+	SetupKeys []setupKeyExpiryWebhookKey `json:"setup_keys"`
+}
+
+// NewSetupKeyExpiryWebhook returns a SetupKeyExpiryNotifier posting the expired setup keys to the given URL
+func NewSetupKeyExpiryWebhook(url string) *SetupKeyExpiryWebhook {
+	return &SetupKeyExpiryWebhook{
+		url:    url,
+		client: &http.Client{Timeout: setupKeyExpiryWebhookTimeout},
+	}
+}
+
+// NotifyExpiredSetupKeys posts the expired setup keys of the account to the webhook URL
+func (w *SetupKeyExpiryWebhook) NotifyExpiredSetupKeys(account *Account, keys []*SetupKey) error {
+	payload := setupKeyExpiryWebhookPayload{
+		AccountID: account.Id,
+		Domain:    account.Domain,
+		Admins:    []string{},
+		SetupKeys: make([]setupKeyExpiryWebhookKey, 0, len(keys)),
+	}
+
+	for _, user := range account.Users {
+		if user.HasAdminPower() && !user.IsServiceUser {
+			payload.Admins = append(payload.Admins, user.Id)
+		}
+	}
+
+	for _, key := range keys {
+		payload.SetupKeys = append(payload.SetupKeys, setupKeyExpiryWebhookKey{
+			ID:        key.Id,
+			Name:      key.Name,
+			ExpiresAt: key.ExpiresAt,
+		})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
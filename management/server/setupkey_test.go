@@ -1,15 +1,22 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 
 	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
 )
 
 func TestDefaultAccountManager_SaveSetupKey(t *testing.T) {
@@ -226,6 +233,13 @@ func TestSetupKey_IsValid(t *testing.T) {
 		t.Errorf("expected overused key to be invalid, got valid %v", overUsedKey)
 	}
 
+	// pending approval
+	pendingKey := GenerateSetupKey("invalid key", SetupKeyOneOff, time.Hour, []string{}, SetupKeyUnlimitedUsage, false)
+	pendingKey.PendingApproval = true
+	if pendingKey.IsValid() {
+		t.Errorf("expected key pending approval to be invalid, got valid %v", pendingKey)
+	}
+
 	// overused
 	reusableKey := GenerateSetupKey("valid key", SetupKeyReusable, time.Hour, []string{}, SetupKeyUnlimitedUsage, false)
 	reusableKey.UsedTimes = 99
@@ -290,3 +304,223 @@ func TestSetupKey_Copy(t *testing.T) {
 		key.UpdatedAt, key.AutoGroups)
 
 }
+
+type mockSetupKeyExpiryNotifier struct {
+	accountID string
+	keys      []*SetupKey
+}
+
+func (m *mockSetupKeyExpiryNotifier) NotifyExpiredSetupKeys(account *Account, keys []*SetupKey) error {
+	m.accountID = account.Id
+	m.keys = append(m.keys, keys...)
+	return nil
+}
+
+func TestDefaultAccountManager_RevokeExpiredSetupKeys(t *testing.T) {
+	manager, err := createManager(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := "testingUser"
+	account, err := manager.GetOrCreateAccountByUser(userID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiredKey := GenerateSetupKey("expired", SetupKeyReusable, -time.Minute, []string{}, SetupKeyUnlimitedUsage, false)
+	validKey := GenerateSetupKey("valid", SetupKeyReusable, time.Hour, []string{}, SetupKeyUnlimitedUsage, false)
+	nonExpiringKey := GenerateSetupKey("non-expiring", SetupKeyReusable, time.Hour, []string{}, SetupKeyUnlimitedUsage, false)
+	nonExpiringKey.ExpiresAt = time.Time{}
+	revokedKey := GenerateSetupKey("revoked", SetupKeyReusable, -time.Minute, []string{}, SetupKeyUnlimitedUsage, false)
+	revokedKey.Revoked = true
+
+	account.SetupKeys = map[string]*SetupKey{}
+	for _, key := range []*SetupKey{expiredKey, validKey, nonExpiringKey, revokedKey} {
+		account.SetupKeys[key.Key] = key
+	}
+	err = manager.Store.SaveAccount(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &mockSetupKeyExpiryNotifier{}
+	manager.revokeExpiredSetupKeys(notifier)
+
+	account, err = manager.Store.GetAccount(account.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, account.SetupKeys[expiredKey.Key].Revoked, "expired key should be revoked")
+	assert.False(t, account.SetupKeys[expiredKey.Key].IsValid(), "revoked key should not be valid")
+	assert.False(t, account.SetupKeys[validKey.Key].Revoked, "valid key should not be revoked")
+	assert.False(t, account.SetupKeys[nonExpiringKey.Key].Revoked, "key without expiration should not be revoked")
+
+	assert.Equal(t, account.Id, notifier.accountID, "notifier should be called with the account")
+	if assert.Len(t, notifier.keys, 1, "only the expired key should be notified") {
+		assert.Equal(t, expiredKey.Id, notifier.keys[0].Id)
+	}
+
+	// events are stored asynchronously
+	var expiryEvents, revokeEvents []*activity.Event
+	assert.Eventually(t, func() bool {
+		events, err := manager.eventStore.Get(account.Id, 0, 100, false)
+		if err != nil {
+			return false
+		}
+		expiryEvents, revokeEvents = nil, nil
+		for _, event := range events {
+			switch event.Activity {
+			case activity.SetupKeyExpired:
+				expiryEvents = append(expiryEvents, event)
+			case activity.SetupKeyRevoked:
+				revokeEvents = append(revokeEvents, event)
+			}
+		}
+		return len(expiryEvents) > 0 && len(revokeEvents) > 0
+	}, time.Second, 10*time.Millisecond, "expiry and revoke events should be stored")
+	if assert.Len(t, expiryEvents, 1, "one expiry event should be stored") {
+		assert.Equal(t, activity.SystemInitiator, expiryEvents[0].InitiatorID)
+		assert.Equal(t, expiredKey.Id, expiryEvents[0].TargetID)
+	}
+	if assert.Len(t, revokeEvents, 1, "the expired key should be revoked like by a user") {
+		assert.Equal(t, activity.SystemInitiator, revokeEvents[0].InitiatorID)
+		assert.Equal(t, expiredKey.Id, revokeEvents[0].TargetID)
+	}
+
+	// a second sweep has nothing to revoke
+	notifier = &mockSetupKeyExpiryNotifier{}
+	manager.revokeExpiredSetupKeys(notifier)
+	assert.Empty(t, notifier.keys, "already revoked keys should not be notified again")
+}
+
+func TestSetupKeyExpiryWebhook(t *testing.T) {
+	var payload setupKeyExpiryWebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	account := newAccountWithId("account_id", "owner", "netbird.io")
+	account.Users["regular"] = NewRegularUser("regular")
+	key := GenerateSetupKey("expired", SetupKeyReusable, -time.Minute, []string{}, SetupKeyUnlimitedUsage, false)
+
+	err := NewSetupKeyExpiryWebhook(server.URL).NotifyExpiredSetupKeys(account, []*SetupKey{key})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "account_id", payload.AccountID)
+	assert.Equal(t, []string{"owner"}, payload.Admins, "only admins should be notified")
+	if assert.Len(t, payload.SetupKeys, 1) {
+		assert.Equal(t, key.Id, payload.SetupKeys[0].ID)
+		assert.Equal(t, "expired", payload.SetupKeys[0].Name)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	err = NewSetupKeyExpiryWebhook(failing.URL).NotifyExpiredSetupKeys(account, []*SetupKey{key})
+	assert.Error(t, err, "non 2xx response should return an error")
+}
+
+func TestDefaultAccountManager_CheckSetupKey(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "testingUser"
+	account, err := manager.GetOrCreateAccountByUser(userID, "")
+	require.NoError(t, err)
+
+	unlimited := GenerateSetupKey("unlimited", SetupKeyReusable, time.Hour, nil, SetupKeyUnlimitedUsage, false)
+	limited := GenerateSetupKey("limited", SetupKeyReusable, time.Hour, nil, 3, false)
+	limited.UsedTimes = 1
+	usedOneOff := GenerateSetupKey("used one-off", SetupKeyOneOff, time.Hour, nil, 1, false)
+	usedOneOff.UsedTimes = 1
+	revoked := GenerateSetupKey("revoked", SetupKeyReusable, time.Hour, nil, SetupKeyUnlimitedUsage, false)
+	revoked.Revoked = true
+	expired := GenerateSetupKey("expired", SetupKeyReusable, -time.Hour, nil, SetupKeyUnlimitedUsage, false)
+
+	for _, key := range []*SetupKey{unlimited, limited, usedOneOff, revoked, expired} {
+		account.SetupKeys[key.Key] = key
+	}
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	tt := []struct {
+		name                  string
+		setupKey              string
+		expectedValid         bool
+		expectedRemainingUses int
+		expectedExpiresAt     time.Time
+	}{
+		{"unlimited key", unlimited.Key, true, SetupKeyUnlimitedRemainingUses, unlimited.ExpiresAt},
+		{"lowercase key", strings.ToLower(unlimited.Key), true, SetupKeyUnlimitedRemainingUses, unlimited.ExpiresAt},
+		{"limited key", limited.Key, true, 2, limited.ExpiresAt},
+		{"used one-off key", usedOneOff.Key, false, 0, usedOneOff.ExpiresAt},
+		{"revoked key", revoked.Key, false, SetupKeyUnlimitedRemainingUses, revoked.ExpiresAt},
+		{"expired key", expired.Key, false, SetupKeyUnlimitedRemainingUses, expired.ExpiresAt},
+		{"unknown key", strings.ToUpper(uuid.New().String()), false, 0, time.Time{}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			state, err := manager.CheckSetupKey(tc.setupKey)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedValid, state.Valid)
+			assert.Equal(t, tc.expectedRemainingUses, state.RemainingUses)
+			assert.True(t, tc.expectedExpiresAt.Equal(state.ExpiresAt))
+		})
+	}
+
+	stored, err := manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.SetupKeys[limited.Key].UsedTimes, "checking a key shouldn't use it")
+}
+
+func TestDefaultAccountManager_AddPeerWithSetupKeyPeerType(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "testingUser"
+	account, err := manager.GetOrCreateAccountByUser(userID, "")
+	require.NoError(t, err)
+
+	ephemeralOnly := GenerateSetupKey("ephemeral", SetupKeyReusable, time.Hour, nil, SetupKeyUnlimitedUsage, true)
+	persistentOnly := GenerateSetupKey("persistent", SetupKeyReusable, time.Hour, nil, SetupKeyUnlimitedUsage, false)
+	// a persistent-only key doesn't register ephemeral peers even if the ephemeral flag got out of sync with it
+	persistentOnly.Ephemeral = true
+	legacyEphemeral := GenerateSetupKey("legacy", SetupKeyReusable, time.Hour, nil, SetupKeyUnlimitedUsage, true)
+	legacyEphemeral.PeerType = ""
+
+	for _, key := range []*SetupKey{ephemeralOnly, persistentOnly, legacyEphemeral} {
+		account.SetupKeys[key.Key] = key
+	}
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	tt := []struct {
+		name              string
+		setupKey          *SetupKey
+		expectedEphemeral bool
+	}{
+		{"ephemeral-only key", ephemeralOnly, true},
+		{"persistent-only key", persistentOnly, false},
+		{"key without a peer type", legacyEphemeral, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := wgtypes.GeneratePrivateKey()
+			require.NoError(t, err)
+
+			peer, _, err := manager.AddPeer(tc.setupKey.Key, "", &nbpeer.Peer{
+				Key:  key.PublicKey().String(),
+				Meta: nbpeer.PeerSystemMeta{Hostname: tc.name},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedEphemeral, peer.Ephemeral)
+		})
+	}
+}
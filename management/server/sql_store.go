@@ -0,0 +1,772 @@
+package server
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+
+	nbdns "github.com/netbirdio/netbird/dns"
+	"github.com/netbirdio/netbird/management/server/account"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/management/server/telemetry"
+	"github.com/netbirdio/netbird/route"
+)
+
+// SqlStore represents an account storage backed by a SQL database, SQLite persisted to disk or PostgreSQL
+type SqlStore struct {
+	db                *gorm.DB
+	accountLocks      accountLocks
+	globalAccountLock sync.Mutex
+	metrics           telemetry.AppMetrics
+	installationPK    int
+	storeEngine       StoreEngine
+}
+
+type installation struct {
+	ID                  uint `gorm:"primaryKey"`
+	InstallationIDValue string
+}
+
+// NewSqlStore creates the tables of the accounts in the database if they don't exist yet and returns the store
+func NewSqlStore(db *gorm.DB, storeEngine StoreEngine, metrics telemetry.AppMetrics) (*SqlStore, error) {
+	sql, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// SQLite serializes the writes anyway, PostgreSQL is left to its own connection limit. The account locks of
+	// PostgreSQL hold a connection each, so a small pool would block the lock holders from running their queries.
+	if storeEngine == SqliteStoreEngine {
+		conns := runtime.NumCPU()
+		sql.SetMaxOpenConns(conns) // TODO: make it configurable
+	}
+
+	err = db.AutoMigrate(
+		&SetupKey{}, &nbpeer.Peer{}, &User{}, &PersonalAccessToken{}, &Group{}, &Rule{},
+		&Account{}, &Policy{}, &PolicyRule{}, &route.Route{}, &nbdns.NameServerGroup{}, &DNSRecord{},
+		&IPReservation{}, &OrgKey{}, &NetworkResource{}, &PostureCheck{}, &StaticRoute{}, &installation{},
+		&account.ExtraSettings{},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = migrateDuplicatePeerDNSLabels(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed deduplicating the peer DNS labels: %w", err)
+	}
+
+	return &SqlStore{db: db, storeEngine: storeEngine, metrics: metrics, installationPK: 1}, nil
+}
+
+// migrateDuplicatePeerDNSLabels relabels the stored peers without a DNS label or sharing one with another peer of the
+// account, e.g. the peers stored before the labels were enforced unique. The new peers get a unique label when added.
+func migrateDuplicatePeerDNSLabels(db *gorm.DB) error {
+	var accountIDs []string
+	err := db.Model(&nbpeer.Peer{}).
+		Distinct("account_id").
+		Where("dns_label = ?", "").
+		Or("account_id IN (?)", db.Model(&nbpeer.Peer{}).
+			Select("account_id").
+			Group("account_id, dns_label").
+			Having("COUNT(*) > 1")).
+		Pluck("account_id", &accountIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, accountID := range accountIDs {
+		var peers []*nbpeer.Peer
+		err = db.Where("account_id = ?", accountID).Find(&peers).Error
+		if err != nil {
+			return err
+		}
+
+		account := &Account{Peers: make(map[string]*nbpeer.Peer, len(peers))}
+		labels := make(map[string]string, len(peers))
+		for _, peer := range peers {
+			account.Peers[peer.ID] = peer
+			labels[peer.ID] = peer.DNSLabel
+		}
+		deduplicatePeerDNSLabels(account)
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, peer := range peers {
+				if peer.DNSLabel == labels[peer.ID] {
+					continue
+				}
+				err := tx.Model(&nbpeer.Peer{}).Where("id = ?", peer.ID).Update("dns_label", peer.DNSLabel).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewSqliteStore restores a store from the file located in the datadir
+func NewSqliteStore(dataDir string, metrics telemetry.AppMetrics) (*SqlStore, error) {
+	storeStr := "store.db?cache=shared"
+	if runtime.GOOS == "windows" {
+		// Vo avoid `The process cannot access the file because it is being used by another process` on Windows
+		storeStr = "store.db"
+	}
+
+	file := filepath.Join(dataDir, storeStr)
+	db, err := gorm.Open(sqlite.Open(file), &gorm.Config{
+		Logger:      logger.Default.LogMode(logger.Silent),
+		PrepareStmt: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSqlStore(db, SqliteStoreEngine, metrics)
+}
+
+// NewPostgresqlStore connects to the PostgreSQL database of the DSN and returns a store backed by it
+func NewPostgresqlStore(dsn string, metrics telemetry.AppMetrics) (*SqlStore, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:      logger.Default.LogMode(logger.Silent),
+		PrepareStmt: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSqlStore(db, PostgresStoreEngine, metrics)
+}
+
+// NewSqliteStoreFromFileStore restores a store from FileStore and stores SQLite DB in the file located in datadir
+func NewSqliteStoreFromFileStore(filestore *FileStore, dataDir string, metrics telemetry.AppMetrics) (*SqlStore, error) {
+	store, err := NewSqliteStore(dataDir, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.importFileStore(filestore)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// NewPostgresqlStoreFromFileStore imports the accounts and the installation ID of FileStore into the PostgreSQL
+// database of the DSN
+func NewPostgresqlStoreFromFileStore(filestore *FileStore, dsn string, metrics telemetry.AppMetrics) (*SqlStore, error) {
+	store, err := NewPostgresqlStore(dsn, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.importFileStore(filestore)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (s *SqlStore) importFileStore(filestore *FileStore) error {
+	err := s.SaveInstallationID(filestore.InstallationID)
+	if err != nil {
+		return err
+	}
+
+	for _, account := range filestore.GetAllAccounts() {
+		err := s.SaveAccount(account)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AcquireGlobalLock acquires global lock across all the accounts and returns a function that releases the lock
+func (s *SqlStore) AcquireGlobalLock() (unlock func()) {
+	log.Debugf("acquiring global lock")
+	start := time.Now()
+	s.globalAccountLock.Lock()
+	releaseDBLock := s.acquireDBLock("global", false)
+
+	unlock = func() {
+		releaseDBLock()
+		s.globalAccountLock.Unlock()
+		log.Debugf("released global lock in %v", time.Since(start))
+	}
+
+	took := time.Since(start)
+	log.Debugf("took %v to acquire global lock", took)
+	if s.metrics != nil {
+		s.metrics.StoreMetrics().CountGlobalLockAcquisitionDuration(took)
+	}
+
+	return unlock
+}
+
+func (s *SqlStore) AcquireAccountLock(accountID string) (unlock func()) {
+	log.Debugf("acquiring lock for account %s", accountID)
+
+	start := time.Now()
+	unlockLocal := s.accountLocks.lock(accountID)
+	releaseDBLock := s.acquireDBLock("account:"+accountID, false)
+
+	unlock = func() {
+		releaseDBLock()
+		unlockLocal()
+		log.Debugf("released lock for account %s in %v", accountID, time.Since(start))
+	}
+
+	return unlock
+}
+
+// AcquireAccountReadLock acquires account lock for reading and returns a function that releases the lock.
+// Multiple readers can hold the lock at the same time, while AcquireAccountLock remains exclusive
+func (s *SqlStore) AcquireAccountReadLock(accountID string) (unlock func()) {
+	log.Debugf("acquiring read lock for account %s", accountID)
+	start := time.Now()
+	unlockLocal := s.accountLocks.rLock(accountID)
+	releaseDBLock := s.acquireDBLock("account:"+accountID, true)
+
+	unlock = func() {
+		releaseDBLock()
+		unlockLocal()
+		log.Debugf("released read lock for account %s in %v", accountID, time.Since(start))
+	}
+
+	return unlock
+}
+
+// AcquireAccountLocks acquires the locks of the accounts in the order of their IDs and returns a function that releases
+// the locks. The database locks follow the same order, so the replicas can't deadlock either
+func (s *SqlStore) AcquireAccountLocks(accountIDs ...string) (unlock func()) {
+	return acquireAccountLocks(s.AcquireAccountLock, accountIDs)
+}
+
+// acquireDBLock takes a PostgreSQL advisory lock of the key, so the account locks hold across the management replicas
+// sharing the database. The local locks are taken first, so a replica waits for a database lock on one connection
+// at most per key. The other engines are used by a single process and rely on the local locks only.
+func (s *SqlStore) acquireDBLock(key string, shared bool) (release func()) {
+	noop := func() {}
+	if s.storeEngine != PostgresStoreEngine {
+		return noop
+	}
+
+	lockFunc, unlockFunc := "pg_advisory_lock", "pg_advisory_unlock"
+	if shared {
+		lockFunc, unlockFunc = "pg_advisory_lock_shared", "pg_advisory_unlock_shared"
+	}
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		log.Errorf("failed acquiring the database lock %s, continuing with the local lock only: %v", key, err)
+		return noop
+	}
+
+	// the advisory locks belong to the session, so the lock and the unlock have to run on the same connection
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.Errorf("failed acquiring the database lock %s, continuing with the local lock only: %v", key, err)
+		return noop
+	}
+
+	_, err = conn.ExecContext(ctx, "SELECT "+lockFunc+"(hashtext($1))", key)
+	if err != nil {
+		_ = conn.Close()
+		log.Errorf("failed acquiring the database lock %s, continuing with the local lock only: %v", key, err)
+		return noop
+	}
+
+	return func() {
+		_, err := conn.ExecContext(ctx, "SELECT "+unlockFunc+"(hashtext($1))", key)
+		if err != nil {
+			log.Errorf("failed releasing the database lock %s, dropping the connection holding it: %v", key, err)
+			// closing the session releases its locks, the connection must not go back to the pool
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}
+}
+
+func (s *SqlStore) SaveAccount(account *Account) error {
+	start := time.Now()
+
+	for _, key := range account.SetupKeys {
+		account.SetupKeysG = append(account.SetupKeysG, *key)
+	}
+
+	for id, peer := range account.Peers {
+		peer.ID = id
+		account.PeersG = append(account.PeersG, *peer)
+	}
+
+	for id, user := range account.Users {
+		user.Id = id
+		for id, pat := range user.PATs {
+			pat.ID = id
+			user.PATsG = append(user.PATsG, *pat)
+		}
+		account.UsersG = append(account.UsersG, *user)
+	}
+
+	for id, group := range account.Groups {
+		group.ID = id
+		account.GroupsG = append(account.GroupsG, *group)
+	}
+
+	for id, rule := range account.Rules {
+		rule.ID = id
+		account.RulesG = append(account.RulesG, *rule)
+	}
+
+	for id, route := range account.Routes {
+		route.ID = id
+		account.RoutesG = append(account.RoutesG, *route)
+	}
+
+	for id, ns := range account.NameServerGroups {
+		ns.ID = id
+		account.NameServerGroupsG = append(account.NameServerGroupsG, *ns)
+	}
+
+	for id, record := range account.DNSRecords {
+		record.ID = id
+		account.DNSRecordsG = append(account.DNSRecordsG, *record)
+	}
+
+	for id, reservation := range account.IPReservations {
+		reservation.ID = id
+		account.IPReservationsG = append(account.IPReservationsG, *reservation)
+	}
+
+	for id, key := range account.OrgKeys {
+		key.ID = id
+		account.OrgKeysG = append(account.OrgKeysG, *key)
+	}
+
+	for id, resource := range account.NetworkResources {
+		resource.ID = id
+		account.NetworkResourcesG = append(account.NetworkResourcesG, *resource)
+	}
+
+	for id, check := range account.PostureChecks {
+		check.ID = id
+		account.PostureChecksG = append(account.PostureChecksG, *check)
+	}
+
+	for id, r := range account.StaticRoutes {
+		r.ID = id
+		account.StaticRoutesG = append(account.StaticRoutesG, *r)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Select(clause.Associations).Delete(account.Policies, "account_id = ?", account.Id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Select(clause.Associations).Delete(account.UsersG, "account_id = ?", account.Id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Select(clause.Associations).Delete(account)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.
+			Session(&gorm.Session{FullSaveAssociations: true}).
+			Clauses(clause.OnConflict{UpdateAll: true}).Create(account)
+		if result.Error != nil {
+			return result.Error
+		}
+		return nil
+	})
+
+	took := time.Since(start)
+	if s.metrics != nil {
+		s.metrics.StoreMetrics().CountPersistenceDuration(took)
+	}
+	log.Debugf("took %d ms to persist an account to the %s store", took.Milliseconds(), s.storeEngine)
+
+	return err
+}
+
+func (s *SqlStore) DeleteAccount(account *Account) error {
+	start := time.Now()
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Select(clause.Associations).Delete(account.Policies, "account_id = ?", account.Id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Select(clause.Associations).Delete(account.UsersG, "account_id = ?", account.Id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Select(clause.Associations).Delete(account)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return nil
+	})
+
+	took := time.Since(start)
+	if s.metrics != nil {
+		s.metrics.StoreMetrics().CountPersistenceDuration(took)
+	}
+	log.Debugf("took %d ms to delete an account from the %s store", took.Milliseconds(), s.storeEngine)
+
+	return err
+}
+
+func (s *SqlStore) SaveInstallationID(ID string) error {
+	installation := installation{InstallationIDValue: ID}
+	installation.ID = uint(s.installationPK)
+
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&installation).Error
+}
+
+func (s *SqlStore) GetInstallationID() string {
+	var installation installation
+
+	if result := s.db.First(&installation, "id = ?", s.installationPK); result.Error != nil {
+		return ""
+	}
+
+	return installation.InstallationIDValue
+}
+
+func (s *SqlStore) SavePeerStatus(accountID, peerID string, peerStatus nbpeer.PeerStatus) error {
+	var peer nbpeer.Peer
+
+	result := s.db.First(&peer, "account_id = ? and id = ?", accountID, peerID)
+	if result.Error != nil {
+		return status.Errorf(status.NotFound, "peer %s not found", peerID)
+	}
+
+	peer.Status = &peerStatus
+
+	return s.db.Save(peer).Error
+}
+
+func (s *SqlStore) SavePeerLocation(accountID string, peerWithLocation *nbpeer.Peer) error {
+	var peer nbpeer.Peer
+
+	result := s.db.First(&peer, "account_id = ? and id = ?", accountID, peerWithLocation.ID)
+	if result.Error != nil {
+		return status.Errorf(status.NotFound, "peer %s not found", peerWithLocation.ID)
+	}
+
+	peer.Location = peerWithLocation.Location
+
+	return s.db.Save(peer).Error
+}
+
+// DeleteHashedPAT2TokenIDIndex is noop in SqlStore
+func (s *SqlStore) DeleteHashedPAT2TokenIDIndex(hashedToken string) error {
+	return nil
+}
+
+// DeleteTokenID2UserIDIndex is noop in SqlStore
+func (s *SqlStore) DeleteTokenID2UserIDIndex(tokenID string) error {
+	return nil
+}
+
+func (s *SqlStore) GetAccountByPrivateDomain(domain string) (*Account, error) {
+	var account Account
+
+	result := s.db.First(&account, "domain = ? and is_domain_primary_account = ? and domain_category = ?",
+		strings.ToLower(domain), true, PrivateCategory)
+	if result.Error != nil {
+		return nil, status.Errorf(status.NotFound, "account not found: provided domain is not registered or is not private")
+	}
+
+	// TODO:  rework to not call GetAccount
+	return s.GetAccount(account.Id)
+}
+
+func (s *SqlStore) GetAccountBySetupKey(setupKey string) (*Account, error) {
+	var key SetupKey
+	result := s.db.Select("account_id").First(&key, "key = ?", strings.ToUpper(setupKey))
+	if result.Error != nil {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	if key.AccountID == "" {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	return s.GetAccount(key.AccountID)
+}
+
+func (s *SqlStore) GetAccountByOrgKey(orgKey string) (*Account, error) {
+	var key OrgKey
+	result := s.db.Select("account_id").First(&key, "key = ?", strings.ToUpper(orgKey))
+	if result.Error != nil {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	if key.AccountID == "" {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	return s.GetAccount(key.AccountID)
+}
+
+func (s *SqlStore) GetTokenIDByHashedToken(hashedToken string) (string, error) {
+	var token PersonalAccessToken
+	result := s.db.First(&token, "hashed_token = ?", hashedToken)
+	if result.Error != nil {
+		return "", status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	return token.ID, nil
+}
+
+func (s *SqlStore) GetUserByTokenID(tokenID string) (*User, error) {
+	var token PersonalAccessToken
+	result := s.db.First(&token, "id = ?", tokenID)
+	if result.Error != nil {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	if token.UserID == "" {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	var user User
+	result = s.db.Preload("PATsG").First(&user, "id = ?", token.UserID)
+	if result.Error != nil {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	user.PATs = make(map[string]*PersonalAccessToken, len(user.PATsG))
+	for _, pat := range user.PATsG {
+		user.PATs[pat.ID] = pat.Copy()
+	}
+
+	return &user, nil
+}
+
+func (s *SqlStore) GetAllAccounts() (all []*Account) {
+	var accounts []Account
+	result := s.db.Find(&accounts)
+	if result.Error != nil {
+		return all
+	}
+
+	for _, account := range accounts {
+		if acc, err := s.GetAccount(account.Id); err == nil {
+			all = append(all, acc)
+		}
+	}
+
+	return all
+}
+
+func (s *SqlStore) GetAccount(accountID string) (*Account, error) {
+	var account Account
+
+	result := s.db.Model(&account).
+		Preload("UsersG.PATsG"). // have to be specifies as this is nester reference
+		Preload(clause.Associations).
+		First(&account, "id = ?", accountID)
+	if result.Error != nil {
+		return nil, status.Errorf(status.NotFound, "account not found")
+	}
+
+	// we have to manually preload policy rules as it seems that gorm preloading doesn't do it for us
+	for i, policy := range account.Policies {
+		var rules []*PolicyRule
+		err := s.db.Model(&PolicyRule{}).Find(&rules, "policy_id = ?", policy.ID).Error
+		if err != nil {
+			return nil, status.Errorf(status.NotFound, "rule not found")
+		}
+		account.Policies[i].Rules = rules
+	}
+
+	account.SetupKeys = make(map[string]*SetupKey, len(account.SetupKeysG))
+	for _, key := range account.SetupKeysG {
+		account.SetupKeys[key.Key] = key.Copy()
+	}
+	account.SetupKeysG = nil
+
+	account.Peers = make(map[string]*nbpeer.Peer, len(account.PeersG))
+	for _, peer := range account.PeersG {
+		account.Peers[peer.ID] = peer.Copy()
+	}
+	account.PeersG = nil
+
+	account.Users = make(map[string]*User, len(account.UsersG))
+	for _, user := range account.UsersG {
+		user.PATs = make(map[string]*PersonalAccessToken, len(user.PATs))
+		for _, pat := range user.PATsG {
+			user.PATs[pat.ID] = pat.Copy()
+		}
+		account.Users[user.Id] = user.Copy()
+	}
+	account.UsersG = nil
+
+	account.Groups = make(map[string]*Group, len(account.GroupsG))
+	for _, group := range account.GroupsG {
+		account.Groups[group.ID] = group.Copy()
+	}
+	account.GroupsG = nil
+
+	account.Rules = make(map[string]*Rule, len(account.RulesG))
+	for _, rule := range account.RulesG {
+		account.Rules[rule.ID] = rule.Copy()
+	}
+	account.RulesG = nil
+
+	account.Routes = make(map[string]*route.Route, len(account.RoutesG))
+	for _, route := range account.RoutesG {
+		account.Routes[route.ID] = route.Copy()
+	}
+	account.RoutesG = nil
+
+	account.NameServerGroups = make(map[string]*nbdns.NameServerGroup, len(account.NameServerGroupsG))
+	for _, ns := range account.NameServerGroupsG {
+		account.NameServerGroups[ns.ID] = ns.Copy()
+	}
+	account.NameServerGroupsG = nil
+
+	account.DNSRecords = make(map[string]*DNSRecord, len(account.DNSRecordsG))
+	for _, record := range account.DNSRecordsG {
+		account.DNSRecords[record.ID] = record.Copy()
+	}
+	account.DNSRecordsG = nil
+
+	account.IPReservations = make(map[string]*IPReservation, len(account.IPReservationsG))
+	for _, reservation := range account.IPReservationsG {
+		account.IPReservations[reservation.ID] = reservation.Copy()
+	}
+	account.IPReservationsG = nil
+
+	account.OrgKeys = make(map[string]*OrgKey, len(account.OrgKeysG))
+	for _, key := range account.OrgKeysG {
+		account.OrgKeys[key.ID] = key.Copy()
+	}
+	account.OrgKeysG = nil
+
+	account.NetworkResources = make(map[string]*NetworkResource, len(account.NetworkResourcesG))
+	for _, resource := range account.NetworkResourcesG {
+		account.NetworkResources[resource.ID] = resource.Copy()
+	}
+	account.NetworkResourcesG = nil
+
+	account.PostureChecks = make(map[string]*PostureCheck, len(account.PostureChecksG))
+	for _, check := range account.PostureChecksG {
+		account.PostureChecks[check.ID] = check.Copy()
+	}
+	account.PostureChecksG = nil
+
+	account.StaticRoutes = make(map[string]*StaticRoute, len(account.StaticRoutesG))
+	for _, r := range account.StaticRoutesG {
+		account.StaticRoutes[r.ID] = r.Copy()
+	}
+	account.StaticRoutesG = nil
+
+	return &account, nil
+}
+
+func (s *SqlStore) GetAccountByUser(userID string) (*Account, error) {
+	var user User
+	result := s.db.Select("account_id").First(&user, "id = ?", userID)
+	if result.Error != nil {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	if user.AccountID == "" {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	return s.GetAccount(user.AccountID)
+}
+
+func (s *SqlStore) GetAccountByPeerID(peerID string) (*Account, error) {
+	var peer nbpeer.Peer
+	result := s.db.Select("account_id").First(&peer, "id = ?", peerID)
+	if result.Error != nil {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	if peer.AccountID == "" {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	return s.GetAccount(peer.AccountID)
+}
+
+func (s *SqlStore) GetAccountByPeerPubKey(peerKey string) (*Account, error) {
+	var peer nbpeer.Peer
+
+	result := s.db.Select("account_id").First(&peer, "key = ?", peerKey)
+	if result.Error != nil {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	if peer.AccountID == "" {
+		return nil, status.Errorf(status.NotFound, "account not found: index lookup failed")
+	}
+
+	return s.GetAccount(peer.AccountID)
+}
+
+// SaveUserLastLogin stores the last login time for a user in DB.
+func (s *SqlStore) SaveUserLastLogin(accountID, userID string, lastLogin time.Time) error {
+	var user User
+
+	result := s.db.First(&user, "account_id = ? and id = ?", accountID, userID)
+	if result.Error != nil {
+		return status.Errorf(status.NotFound, "user %s not found", userID)
+	}
+
+	user.LastLogin = lastLogin
+
+	return s.db.Save(user).Error
+}
+
+// Close closes the connections to PostgreSQL, it is noop in SQLite
+func (s *SqlStore) Close() error {
+	if s.storeEngine != PostgresStoreEngine {
+		return nil
+	}
+
+	sql, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sql.Close()
+}
+
+// GetStoreEngine returns the engine of the database, SqliteStoreEngine or PostgresStoreEngine
+func (s *SqlStore) GetStoreEngine() StoreEngine {
+	return s.storeEngine
+}
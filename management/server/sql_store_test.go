@@ -100,6 +100,70 @@ func TestSqlite_SaveAccount(t *testing.T) {
 	}
 }
 
+func TestSqlite_GetAccountByOrgKey(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The SQLite store is not properly supported by Windows yet")
+	}
+
+	store := newSqliteStore(t)
+
+	account := newAccountWithId("account_id", "testuser", "")
+	account.OrgKeys["orgkey"] = &OrgKey{
+		ID:             "orgkey",
+		AccountID:      account.Id,
+		Key:            "A2C8E62B-38F5-4553-B31E-DD66C696CEBB",
+		Name:           "msp",
+		LinkedAccounts: []string{"account_id2"},
+	}
+	err := store.SaveAccount(account)
+	require.NoError(t, err)
+
+	a, err := store.GetAccountByOrgKey("a2c8e62b-38f5-4553-b31e-dd66c696cebb")
+	require.NoError(t, err)
+	require.Equal(t, account.Id, a.Id)
+	require.Contains(t, a.OrgKeys, "orgkey")
+	require.Equal(t, []string{"account_id2"}, a.OrgKeys["orgkey"].LinkedAccounts)
+
+	_, err = store.GetAccountByOrgKey("00000000-0000-0000-0000-000000000000")
+	require.Error(t, err)
+}
+
+func TestSqlite_SaveAccountIPv6(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The SQLite store is not properly supported by Windows yet")
+	}
+
+	store := newSqliteStore(t)
+
+	account := newAccountWithId("account_id", "testuser", "")
+	_, netV6, err := net.ParseCIDR("fd00:1234:5678:9abc::/64")
+	require.NoError(t, err)
+	account.Network.NetV6 = *netV6
+	account.Peers["testpeer"] = &nbpeer.Peer{
+		Key:    "peerkey",
+		IP:     net.IP{127, 0, 0, 1},
+		IPv6:   net.ParseIP("fd00:1234:5678:9abc::1"),
+		Name:   "peer name",
+		Status: &nbpeer.PeerStatus{},
+	}
+
+	err = store.SaveAccount(account)
+	require.NoError(t, err)
+
+	// accounts without an IPv6 allocation should be stored as well
+	err = store.SaveAccount(newAccountWithId("account_id2", "testuser2", ""))
+	require.NoError(t, err)
+
+	a, err := store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, netV6.String(), a.Network.NetV6.String())
+	assert.Equal(t, "fd00:1234:5678:9abc::1", a.Peers["testpeer"].IPv6.String())
+
+	a, err = store.GetAccount("account_id2")
+	require.NoError(t, err)
+	assert.Nil(t, a.Network.NetV6.IP)
+}
+
 func TestSqlite_DeleteAccount(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("The SQLite store is not properly supported by Windows yet")
@@ -259,7 +323,7 @@ func TestSqlite_GetUserByTokenID(t *testing.T) {
 	require.Equal(t, id, user.PATs[id].ID)
 }
 
-func newSqliteStore(t *testing.T) *SqliteStore {
+func newSqliteStore(t *testing.T) *SqlStore {
 	t.Helper()
 
 	store, err := NewSqliteStore(t.TempDir(), nil)
@@ -269,7 +333,7 @@ func newSqliteStore(t *testing.T) *SqliteStore {
 	return store
 }
 
-func newSqliteStoreFromFile(t *testing.T, filename string) *SqliteStore {
+func newSqliteStoreFromFile(t *testing.T, filename string) *SqlStore {
 	t.Helper()
 
 	storeDir := t.TempDir()
@@ -303,3 +367,117 @@ func newAccount(store Store, id int) error {
 
 	return store.SaveAccount(account)
 }
+
+func TestNewStore_PostgresWithoutDSN(t *testing.T) {
+	t.Setenv(postgresDsnEnv, "")
+
+	_, err := NewStore(PostgresStoreEngine, t.TempDir(), nil)
+	assert.ErrorContains(t, err, postgresDsnEnv, "the PostgreSQL store should require the DSN")
+}
+
+func TestPostgresql_SaveAccount(t *testing.T) {
+	store := newPostgresqlStore(t)
+
+	id := uuid.New().String()
+	account := newAccountWithId(id, id+"-testuser", "")
+	setupKey := GenerateDefaultSetupKey()
+	account.SetupKeys[setupKey.Key] = setupKey
+	account.Peers[id+"-peer"] = &nbpeer.Peer{
+		Key:    id + "-peerkey",
+		IP:     net.IP{127, 0, 0, 1},
+		Name:   "peer name",
+		Status: &nbpeer.PeerStatus{Connected: true, LastSeen: time.Now().UTC()},
+	}
+
+	require.NoError(t, store.SaveAccount(account))
+	t.Cleanup(func() {
+		_ = store.DeleteAccount(account)
+	})
+
+	a, err := store.GetAccountBySetupKey(setupKey.Key)
+	require.NoError(t, err)
+	assert.Equal(t, id, a.Id)
+
+	a, err = store.GetAccountByPeerPubKey(id + "-peerkey")
+	require.NoError(t, err)
+	assert.Equal(t, id, a.Id)
+	assert.Len(t, a.Peers, 1)
+	assert.Equal(t, PostgresStoreEngine, store.GetStoreEngine())
+}
+
+func TestPostgresql_AccountLockAcrossStores(t *testing.T) {
+	// two stores on the same database stand for two management replicas
+	replica1 := newPostgresqlStore(t)
+	replica2 := newPostgresqlStore(t)
+
+	accountID := uuid.New().String()
+	unlock := replica1.AcquireAccountLock(accountID)
+
+	acquired := make(chan struct{})
+	go func() {
+		unlock := replica2.AcquireAccountLock(accountID)
+		close(acquired)
+		unlock()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("the account lock should be exclusive across the stores sharing the database")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	unlock()
+
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the account lock should be acquired once released by the other store")
+	}
+}
+
+// newPostgresqlStore connects to the database of the NETBIRD_STORE_ENGINE_POSTGRES_DSN environment variable,
+// the PostgreSQL tests are skipped without it
+func newPostgresqlStore(t *testing.T) *SqlStore {
+	t.Helper()
+
+	dsn, err := PostgresDSNFromEnv()
+	if err != nil {
+		t.Skip("no PostgreSQL database to test with: ", err)
+	}
+
+	store, err := NewPostgresqlStore(dsn, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+
+	return store
+}
+
+func TestSqlite_MigrateDuplicatePeerDNSLabels(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The SQLite store is not properly supported by Windows yet")
+	}
+
+	store := newSqliteStore(t)
+
+	account := newAccountWithId("account_id", "testuser", "")
+	account.Peers["peer1"] = &nbpeer.Peer{Key: "key1", Name: "peer", DNSLabel: "peer", Status: &nbpeer.PeerStatus{}}
+	account.Peers["peer2"] = &nbpeer.Peer{Key: "key2", Name: "peer", DNSLabel: "peer", Status: &nbpeer.PeerStatus{}}
+	account.Peers["peer3"] = &nbpeer.Peer{Key: "key3", Name: "other", Status: &nbpeer.PeerStatus{}}
+	err := store.SaveAccount(account)
+	require.NoError(t, err)
+
+	a, err := store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "peer", a.Peers["peer2"].DNSLabel, "the labels should not be changed when loading the account")
+
+	store, err = NewSqlStore(store.db, SqliteStoreEngine, nil)
+	require.NoError(t, err)
+
+	a, err = store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "peer", a.Peers["peer1"].DNSLabel, "the peer added first should keep its label")
+	assert.Equal(t, "peer-1", a.Peers["peer2"].DNSLabel, "the peer sharing the label should be relabeled")
+	assert.Equal(t, "other", a.Peers["peer3"].DNSLabel, "the peer without a label should get one")
+}
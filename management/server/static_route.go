@@ -0,0 +1,370 @@
+package server
+
+import (
+	"net/netip"
+
+	"github.com/rs/xid"
+
+	"github.com/netbirdio/netbird/management/proto"
+	"github.com/netbirdio/netbird/management/server/activity"
+	nbpeer "github.com/netbirdio/netbird/management/server/peer"
+	"github.com/netbirdio/netbird/management/server/status"
+	"github.com/netbirdio/netbird/route"
+)
+
+// StaticRoute is a route of a single peer to a network reached through another peer, e.g. a host route to a service
+// only this peer should access. Unlike the routes, it isn't advertised to the distribution groups: the peer installs
+// it only while the associated policy permits its traffic to the routing peer.
+type StaticRoute struct {
+	// ID of the static route
+	ID string `gorm:"primaryKey"`
+	// AccountID is a reference to Account that this object belongs
+	AccountID string `json:"-" gorm:"index"`
+	// Description of the static route visible in the UI
+	Description string
+	// Network is the destination of the route
+	Network netip.Prefix `gorm:"serializer:gob"`
+	// PeerID is the ID of the peer installing the route
+	PeerID string
+	// RoutingPeerID is the ID of the peer the traffic to the network is routed through
+	RoutingPeerID string
+	// PolicyID is the ID of the policy permitting the traffic of the peer to the routing peer
+	PolicyID string
+	// Masquerade makes the routing peer translate the source address of the routed traffic
+	Masquerade bool
+	// Enabled indicates whether the route is distributed
+	Enabled bool
+}
+
+// Copy returns a copy of the static route
+func (r *StaticRoute) Copy() *StaticRoute {
+	return &StaticRoute{
+		ID:            r.ID,
+		AccountID:     r.AccountID,
+		Description:   r.Description,
+		Network:       r.Network,
+		PeerID:        r.PeerID,
+		RoutingPeerID: r.RoutingPeerID,
+		PolicyID:      r.PolicyID,
+		Masquerade:    r.Masquerade,
+		Enabled:       r.Enabled,
+	}
+}
+
+// EventMeta returns activity event meta related to the static route
+func (r *StaticRoute) EventMeta() map[string]any {
+	return map[string]any{
+		"network_range": r.Network.String(), "peer_id": r.PeerID, "routing_peer_id": r.RoutingPeerID,
+		"policy_id": r.PolicyID,
+	}
+}
+
+// toRoute returns the route the peers install, routed through the peer with the given WireGuard key
+func (r *StaticRoute) toRoute(routingPeerKey string) *route.Route {
+	networkType := route.IPv4Network
+	if r.Network.Addr().Is6() {
+		networkType = route.IPv6Network
+	}
+	return &route.Route{
+		ID:          r.ID,
+		AccountID:   r.AccountID,
+		Network:     r.Network,
+		NetID:       r.ID,
+		Description: r.Description,
+		Peer:        routingPeerKey,
+		NetworkType: networkType,
+		Masquerade:  r.Masquerade,
+		Metric:      route.MaxMetric,
+		Enabled:     true,
+	}
+}
+
+// toProtocolStaticRoutes returns the static routes marked for the clients to tell them from the shared routes
+func toProtocolStaticRoutes(routes []*route.Route) []*proto.Route {
+	protoRoutes := make([]*proto.Route, 0, len(routes))
+	for _, r := range routes {
+		protoRoute := toProtocolRoute(r)
+		protoRoute.Static = true
+		protoRoutes = append(protoRoutes, protoRoute)
+	}
+	return protoRoutes
+}
+
+// getPeerStaticRoutes returns the static routes the peer installs, routed through the peers it can connect to, and the
+// static routes the peer routes for the other peers. The routes contain the WireGuard key of the routing peer.
+func (a *Account) getPeerStaticRoutes(peerID string, aclPeers []*nbpeer.Peer) []*route.Route {
+	if len(a.StaticRoutes) == 0 {
+		return nil
+	}
+
+	connectable := make(map[string]*nbpeer.Peer, len(aclPeers))
+	for _, peer := range aclPeers {
+		connectable[peer.ID] = peer
+	}
+
+	var routes []*route.Route
+	for _, r := range a.StaticRoutes {
+		if !r.Enabled {
+			continue
+		}
+
+		switch peerID {
+		case r.PeerID:
+			routingPeer, ok := connectable[r.RoutingPeerID]
+			// currently we support only linux routing peers
+			if !ok || routingPeer.Meta.GoOS != "linux" || !a.staticRoutePermitted(r) {
+				continue
+			}
+			routes = append(routes, r.toRoute(routingPeer.Key))
+		case r.RoutingPeerID:
+			routingPeer := a.GetPeer(peerID)
+			peer := a.GetPeer(r.PeerID)
+			if peer == nil || peer.IsQuarantined() || routingPeer.Meta.GoOS != "linux" || !a.staticRoutePermitted(r) {
+				continue
+			}
+			routes = append(routes, r.toRoute(routingPeer.Key))
+		}
+	}
+	return routes
+}
+
+// staticRoutePermitted returns true if an enabled and active accept rule of the policy of the static route permits the
+// traffic of the peer to the routing peer
+func (a *Account) staticRoutePermitted(r *StaticRoute) bool {
+	permitted := false
+	a.forEachPeerRule(r.PeerID, func(policy *Policy, rule *PolicyRule, peers []*nbpeer.Peer, direction int) {
+		if permitted || policy.ID != r.PolicyID || rule.Action != PolicyTrafficActionAccept || direction != firewallRuleDirectionOUT {
+			return
+		}
+		for _, peer := range peers {
+			if peer.ID == r.RoutingPeerID {
+				permitted = true
+				return
+			}
+		}
+	})
+	return permitted
+}
+
+// GetStaticRoute gets a static route object from account and route IDs
+func (am *DefaultAccountManager) GetStaticRoute(accountID, userID, routeID string) (*StaticRoute, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceRoutes, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view static routes")
+	}
+
+	r, found := account.StaticRoutes[routeID]
+	if !found {
+		return nil, status.Errorf(status.NotFound, "static route with ID %s not found", routeID)
+	}
+
+	return r.Copy(), nil
+}
+
+// ListStaticRoutes returns a list of static routes from account
+func (am *DefaultAccountManager) ListStaticRoutes(accountID, userID string) ([]*StaticRoute, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasPermission(ResourceRoutes, OperationRead) {
+		return nil, status.Errorf(status.PermissionDenied, "user is not allowed to view static routes")
+	}
+
+	routes := make([]*StaticRoute, 0, len(account.StaticRoutes))
+	for _, r := range account.StaticRoutes {
+		routes = append(routes, r.Copy())
+	}
+
+	return routes, nil
+}
+
+// CreateStaticRoute creates and saves a new static route
+func (am *DefaultAccountManager) CreateStaticRoute(accountID, userID string, routeToCreate *StaticRoute) (*StaticRoute, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	if routeToCreate == nil {
+		return nil, status.Errorf(status.InvalidArgument, "static route provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkPermission(account, userID, ResourceRoutes, OperationWrite); err != nil {
+		return nil, err
+	}
+
+	newRoute := routeToCreate.Copy()
+	newRoute.ID = xid.New().String()
+	newRoute.AccountID = accountID
+
+	if err = validateStaticRoute(false, newRoute, account); err != nil {
+		return nil, err
+	}
+
+	if account.StaticRoutes == nil {
+		account.StaticRoutes = make(map[string]*StaticRoute)
+	}
+	account.StaticRoutes[newRoute.ID] = newRoute
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, newRoute.ID, accountID, activity.StaticRouteCreated, newRoute.EventMeta())
+
+	return newRoute.Copy(), nil
+}
+
+// SaveStaticRoute saves a static route
+func (am *DefaultAccountManager) SaveStaticRoute(accountID, userID string, routeToSave *StaticRoute) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	if routeToSave == nil {
+		return status.Errorf(status.InvalidArgument, "static route provided is nil")
+	}
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkPermission(account, userID, ResourceRoutes, OperationWrite); err != nil {
+		return err
+	}
+
+	r := routeToSave.Copy()
+	r.AccountID = accountID
+
+	if err = validateStaticRoute(true, r, account); err != nil {
+		return err
+	}
+
+	account.StaticRoutes[r.ID] = r
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, r.ID, accountID, activity.StaticRouteUpdated, r.EventMeta())
+
+	return nil
+}
+
+// DeleteStaticRoute deletes a static route with routeID, the peers withdraw it with the next network map
+func (am *DefaultAccountManager) DeleteStaticRoute(accountID, routeID, userID string) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkPermission(account, userID, ResourceRoutes, OperationWrite); err != nil {
+		return err
+	}
+
+	r := account.StaticRoutes[routeID]
+	if r == nil {
+		return status.Errorf(status.NotFound, "static route %s wasn't found", routeID)
+	}
+
+	delete(account.StaticRoutes, routeID)
+
+	account.Network.IncSerial()
+	err = am.Store.SaveAccount(account)
+	if err != nil {
+		return err
+	}
+
+	am.updateAccountPeers(account)
+
+	am.StoreEvent(userID, r.ID, accountID, activity.StaticRouteDeleted, r.EventMeta())
+
+	return nil
+}
+
+// validateStaticRoute validates the static route and normalizes its network. The network can't overlap with the
+// account network, the peers and the DNS resolver of the clients are reached through it.
+func validateStaticRoute(existingRoute bool, r *StaticRoute, account *Account) error {
+	if existingRoute {
+		if _, found := account.StaticRoutes[r.ID]; !found {
+			return status.Errorf(status.NotFound, "static route with ID %s was not found", r.ID)
+		}
+	}
+
+	if !r.Network.IsValid() {
+		return status.Errorf(status.InvalidArgument, "static route network should be a valid network range")
+	}
+	r.Network = r.Network.Masked()
+
+	addr := r.Network.Addr()
+	if r.Network.Bits() == 0 || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsMulticast() {
+		return status.Errorf(status.InvalidArgument, "static route network %s isn't routable through a peer", r.Network)
+	}
+
+	if accountNet, ok := routeNetworkConflict(account.Network, r.Network); ok {
+		return status.Errorf(status.InvalidArgument, "static route network %s overlaps with the account network %s",
+			r.Network, accountNet)
+	}
+
+	if account.GetPeer(r.PeerID) == nil {
+		return status.Errorf(status.InvalidArgument, "peer with ID %s not found", r.PeerID)
+	}
+	if account.GetPeer(r.RoutingPeerID) == nil {
+		return status.Errorf(status.InvalidArgument, "routing peer with ID %s not found", r.RoutingPeerID)
+	}
+	if r.PeerID == r.RoutingPeerID {
+		return status.Errorf(status.InvalidArgument, "peer can't route its own static route")
+	}
+
+	policyFound := false
+	for _, policy := range account.Policies {
+		policyFound = policyFound || policy.ID == r.PolicyID
+	}
+	if !policyFound {
+		return status.Errorf(status.InvalidArgument, "policy with ID %s not found", r.PolicyID)
+	}
+
+	for _, other := range account.StaticRoutes {
+		if other.ID != r.ID && other.PeerID == r.PeerID && other.Network == r.Network {
+			return status.Errorf(status.AlreadyExists, "peer %s already has a static route to %s", r.PeerID, r.Network)
+		}
+	}
+
+	return nil
+}
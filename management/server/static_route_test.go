@@ -0,0 +1,195 @@
+package server
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// initTestStaticRouteAccount creates the route test account with a policy permitting the traffic of peer2 to peer1
+func initTestStaticRouteAccount(t *testing.T) (*DefaultAccountManager, *Account, *Policy) {
+	t.Helper()
+
+	am, err := createRouterManager(t)
+	require.NoError(t, err)
+
+	account, err := initTestRouteAccount(t, am)
+	require.NoError(t, err)
+
+	policy := &Policy{
+		ID:      xid.New().String(),
+		Name:    "billing access",
+		Enabled: true,
+		Rules: []*PolicyRule{{
+			ID:           xid.New().String(),
+			Name:         "billing access",
+			Enabled:      true,
+			Action:       PolicyTrafficActionAccept,
+			Protocol:     PolicyRuleProtocolALL,
+			Sources:      []string{routeGroup2},
+			Destinations: []string{routeGroup1},
+		}},
+	}
+	require.NoError(t, am.SavePolicy(account.Id, userID, policy))
+
+	account, err = am.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	return am, account, policy
+}
+
+func TestCreateStaticRoute(t *testing.T) {
+	am, account, policy := initTestStaticRouteAccount(t)
+
+	staticRoute, err := am.CreateStaticRoute(account.Id, userID, &StaticRoute{
+		Network:       netip.MustParsePrefix("10.0.8.15/24"),
+		PeerID:        peer2ID,
+		RoutingPeerID: peer1ID,
+		PolicyID:      policy.ID,
+		Enabled:       true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("10.0.8.0/24"), staticRoute.Network, "network should be masked")
+
+	stored, err := am.GetStaticRoute(account.Id, userID, staticRoute.ID)
+	require.NoError(t, err)
+	assert.Equal(t, staticRoute, stored)
+
+	overlay, err := netip.ParsePrefix(account.Network.Net.String())
+	require.NoError(t, err)
+
+	valid := func(modify func(r *StaticRoute)) *StaticRoute {
+		r := &StaticRoute{
+			Network:       netip.MustParsePrefix("10.0.9.0/24"),
+			PeerID:        peer2ID,
+			RoutingPeerID: peer1ID,
+			PolicyID:      policy.ID,
+			Enabled:       true,
+		}
+		modify(r)
+		return r
+	}
+
+	testCases := []struct {
+		name        string
+		staticRoute *StaticRoute
+		errorType   status.Type
+	}{
+		{
+			name:        "overlapping with the account network",
+			staticRoute: valid(func(r *StaticRoute) { r.Network = netip.PrefixFrom(overlay.Addr().Next(), 32) }),
+			errorType:   status.InvalidArgument,
+		},
+		{
+			name:        "default route",
+			staticRoute: valid(func(r *StaticRoute) { r.Network = netip.MustParsePrefix("0.0.0.0/0") }),
+			errorType:   status.InvalidArgument,
+		},
+		{
+			name:        "loopback network",
+			staticRoute: valid(func(r *StaticRoute) { r.Network = netip.MustParsePrefix("127.0.0.1/32") }),
+			errorType:   status.InvalidArgument,
+		},
+		{
+			name:        "invalid network",
+			staticRoute: valid(func(r *StaticRoute) { r.Network = netip.Prefix{} }),
+			errorType:   status.InvalidArgument,
+		},
+		{
+			name:        "unknown routing peer",
+			staticRoute: valid(func(r *StaticRoute) { r.RoutingPeerID = "unknown" }),
+			errorType:   status.InvalidArgument,
+		},
+		{
+			name:        "peer routing its own route",
+			staticRoute: valid(func(r *StaticRoute) { r.RoutingPeerID = peer2ID }),
+			errorType:   status.InvalidArgument,
+		},
+		{
+			name:        "unknown policy",
+			staticRoute: valid(func(r *StaticRoute) { r.PolicyID = "unknown" }),
+			errorType:   status.InvalidArgument,
+		},
+		{
+			name:        "duplicated network of the peer",
+			staticRoute: valid(func(r *StaticRoute) { r.Network = netip.MustParsePrefix("10.0.8.0/24") }),
+			errorType:   status.AlreadyExists,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := am.CreateStaticRoute(account.Id, userID, testCase.staticRoute)
+			assertStatusType(t, err, testCase.errorType)
+		})
+	}
+
+	_, err = am.UpdateNetworkRange(account.Id, userID, "10.0.0.0/16")
+	assertStatusType(t, err, status.InvalidArgument)
+}
+
+func TestGetNetworkMap_StaticRoutes(t *testing.T) {
+	am, account, policy := initTestStaticRouteAccount(t)
+
+	staticRoute, err := am.CreateStaticRoute(account.Id, userID, &StaticRoute{
+		Network:       netip.MustParsePrefix("10.0.8.15/32"),
+		PeerID:        peer2ID,
+		RoutingPeerID: peer1ID,
+		PolicyID:      policy.ID,
+		Masquerade:    true,
+		Enabled:       true,
+	})
+	require.NoError(t, err)
+
+	networkMap, err := am.GetNetworkMap(peer2ID)
+	require.NoError(t, err)
+	require.Len(t, networkMap.StaticRoutes, 1)
+	assert.Equal(t, staticRoute.Network, networkMap.StaticRoutes[0].Network)
+	assert.Equal(t, peer1Key, networkMap.StaticRoutes[0].Peer, "the route should go through the routing peer")
+	assert.Empty(t, networkMap.Routes, "the static route shouldn't be advertised as a shared route")
+
+	networkMap, err = am.GetNetworkMap(peer1ID)
+	require.NoError(t, err)
+	require.Len(t, networkMap.StaticRoutes, 1, "the routing peer should route the static route")
+	assert.Equal(t, peer1Key, networkMap.StaticRoutes[0].Peer)
+
+	networkMap, err = am.GetNetworkMap(peer4ID)
+	require.NoError(t, err)
+	assert.Empty(t, networkMap.StaticRoutes, "the other peers shouldn't get the static route")
+
+	updates := am.peersUpdateManager.CreateChannel(peer2ID)
+	t.Cleanup(func() {
+		am.peersUpdateManager.CloseChannel(peer2ID)
+	})
+
+	// the default policy keeps connecting the peers, the static route requires its own policy
+	policy.Rules[0].Enabled = false
+	require.NoError(t, am.SavePolicy(account.Id, userID, policy))
+
+	waitDelivered(t, am.peersUpdateManager, peer2ID)
+	require.Len(t, updates, 1, "peer losing the policy should receive an update")
+	update := <-updates
+	assert.NotEmpty(t, update.Update.NetworkMap.RemotePeers)
+	assert.Empty(t, update.Update.NetworkMap.Routes, "the static route should be withdrawn")
+
+	networkMap, err = am.GetNetworkMap(peer1ID)
+	require.NoError(t, err)
+	assert.Empty(t, networkMap.StaticRoutes, "the routing peer should stop routing the static route")
+
+	policy.Rules[0].Enabled = true
+	require.NoError(t, am.SavePolicy(account.Id, userID, policy))
+
+	waitDelivered(t, am.peersUpdateManager, peer2ID)
+	require.Len(t, updates, 1)
+	update = <-updates
+	require.Len(t, update.Update.NetworkMap.Routes, 1)
+	assert.True(t, update.Update.NetworkMap.Routes[0].Static, "the clients should tell the static routes from the shared ones")
+
+	require.NoError(t, am.DeletePeer(account.Id, peer1ID, userID))
+	_, err = am.GetStaticRoute(account.Id, userID, staticRoute.ID)
+	assertStatusType(t, err, status.NotFound)
+}
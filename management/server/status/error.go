@@ -34,6 +34,9 @@ const (
 
 	// Unauthenticated indicates that user is not authenticated due to absence of valid credentials
 	Unauthenticated Type = 10
+
+	// TooManyRequests indicates that the rate limit of an operation has been reached
+	TooManyRequests Type = 11
 )
 
 // Type is a type of the Error
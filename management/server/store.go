@@ -20,6 +20,8 @@ type Store interface {
 	GetAccountByPeerPubKey(peerKey string) (*Account, error)
 	GetAccountByPeerID(peerID string) (*Account, error)
 	GetAccountBySetupKey(setupKey string) (*Account, error) // todo use key hash later
+	// GetAccountByOrgKey returns the account owning the org key, not the accounts the key registers peers into
+	GetAccountByOrgKey(orgKey string) (*Account, error)
 	GetAccountByPrivateDomain(domain string) (*Account, error)
 	GetTokenIDByHashedToken(secret string) (string, error)
 	GetUserByTokenID(tokenID string) (*User, error)
@@ -30,9 +32,17 @@ type Store interface {
 	SaveInstallationID(ID string) error
 	// AcquireAccountLock should attempt to acquire account lock and return a function that releases the lock
 	AcquireAccountLock(accountID string) func()
+	// AcquireAccountReadLock should attempt to acquire account lock for reading and return a function that releases the lock.
+	// The read lock can be shared by many readers but excludes AcquireAccountLock holders
+	AcquireAccountReadLock(accountID string) func()
+	// AcquireAccountLocks should acquire the locks of several accounts for an operation touching all of them and return
+	// a function that releases the locks. The locks are acquired in the same order for any operation, so the operations
+	// locking the same accounts can't deadlock
+	AcquireAccountLocks(accountIDs ...string) func()
 	// AcquireGlobalLock should attempt to acquire a global lock and return a function that releases the lock
 	AcquireGlobalLock() func()
 	SavePeerStatus(accountID, peerID string, status nbpeer.PeerStatus) error
+	SavePeerLocation(accountID string, peer *nbpeer.Peer) error
 	SaveUserLastLogin(accountID, userID string, lastLogin time.Time) error
 	// Close should close the store persisting all unsaved data.
 	Close() error
@@ -44,8 +54,14 @@ type Store interface {
 type StoreEngine string
 
 const (
-	FileStoreEngine   StoreEngine = "jsonfile"
-	SqliteStoreEngine StoreEngine = "sqlite"
+	FileStoreEngine     StoreEngine = "jsonfile"
+	SqliteStoreEngine   StoreEngine = "sqlite"
+	PostgresStoreEngine StoreEngine = "postgres"
+
+	// postgresDsnEnv is the environment variable with the DSN of the PostgreSQL database, e.g.
+	// "host=localhost user=netbird password=secret dbname=netbird port=5432". It is kept out of the config file,
+	// so the config doesn't hold the database password.
+	postgresDsnEnv = "NETBIRD_STORE_ENGINE_POSTGRES_DSN"
 )
 
 func getStoreEngineFromEnv() StoreEngine {
@@ -57,7 +73,7 @@ func getStoreEngineFromEnv() StoreEngine {
 
 	value := StoreEngine(strings.ToLower(kind))
 
-	if value == FileStoreEngine || value == SqliteStoreEngine {
+	if value == FileStoreEngine || value == SqliteStoreEngine || value == PostgresStoreEngine {
 		return value
 	}
 
@@ -76,6 +92,13 @@ func NewStore(kind StoreEngine, dataDir string, metrics telemetry.AppMetrics) (S
 	case SqliteStoreEngine:
 		log.Info("using SQLite store engine")
 		return NewSqliteStore(dataDir, metrics)
+	case PostgresStoreEngine:
+		log.Info("using PostgreSQL store engine")
+		dsn, err := PostgresDSNFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresqlStore(dsn, metrics)
 	default:
 		return nil, fmt.Errorf("unsupported kind of store %s", kind)
 	}
@@ -94,7 +117,22 @@ func NewStoreFromJson(dataDir string, metrics telemetry.AppMetrics) (Store, erro
 		return fstore, nil
 	case SqliteStoreEngine:
 		return NewSqliteStoreFromFileStore(fstore, dataDir, metrics)
+	case PostgresStoreEngine:
+		dsn, err := PostgresDSNFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresqlStoreFromFileStore(fstore, dsn, metrics)
 	default:
 		return nil, fmt.Errorf("unsupported store engine %s", kind)
 	}
 }
+
+// PostgresDSNFromEnv returns the DSN of the PostgreSQL database from the NETBIRD_STORE_ENGINE_POSTGRES_DSN environment variable
+func PostgresDSNFromEnv() (string, error) {
+	dsn, ok := os.LookupEnv(postgresDsnEnv)
+	if !ok || dsn == "" {
+		return "", fmt.Errorf("%s is not set, the %s store engine needs the DSN of the database", postgresDsnEnv, PostgresStoreEngine)
+	}
+	return dsn, nil
+}
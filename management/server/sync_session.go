@@ -0,0 +1,82 @@
+package server
+
+import (
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+// ListSyncSessions returns the Sync streams the peers of the account hold open, the longest connected first.
+// The users allowed to view the peers can list them.
+func (am *DefaultAccountManager) ListSyncSessions(accountID, userID string) ([]SyncSession, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkSyncSessionsPermission(account, userID, OperationRead); err != nil {
+		return nil, err
+	}
+
+	peerIDs := make([]string, 0, len(account.Peers))
+	for id := range account.Peers {
+		peerIDs = append(peerIDs, id)
+	}
+
+	sessions := am.peersUpdateManager.GetSyncSessions(peerIDs)
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ConnectedSince.Before(sessions[j].ConnectedSince)
+	})
+
+	return sessions, nil
+}
+
+// CloseSyncSession terminates the Sync stream of the peer of the account. The peer is disconnected and opens a new
+// stream when it reconnects. The users allowed to manage the peers can terminate the streams.
+func (am *DefaultAccountManager) CloseSyncSession(accountID, userID, peerID string) error {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+
+	if err = checkSyncSessionsPermission(account, userID, OperationWrite); err != nil {
+		return err
+	}
+
+	peer := account.GetPeer(peerID)
+	if peer == nil || !am.peersUpdateManager.HasChannel(peerID) {
+		return status.Errorf(status.NotFound, "sync session of peer %s not found", peerID)
+	}
+
+	am.peersUpdateManager.CloseChannel(peerID)
+	log.Infof("closed the sync session of peer %s on request of user %s", peerID, userID)
+
+	am.StoreEvent(userID, peer.ID, accountID, activity.PeerSyncSessionClosed, peer.EventMeta(am.GetDNSDomain()))
+
+	return nil
+}
+
+func checkSyncSessionsPermission(account *Account, userID string, operation Operation) error {
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasPermission(ResourcePeers, operation) {
+		if operation == OperationRead {
+			return status.Errorf(status.PermissionDenied, "user is not allowed to view the sync sessions")
+		}
+		return status.Errorf(status.PermissionDenied, "user is not allowed to manage the sync sessions")
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestSyncSessions(t *testing.T) {
+	am, err := createRouterManager(t)
+	require.NoError(t, err)
+
+	account, err := initTestRouteAccount(t, am)
+	require.NoError(t, err)
+
+	regularUser := NewRegularUser("regular-user")
+	account.Users[regularUser.Id] = regularUser
+	auditor := NewUser("auditor-user", UserRoleAuditor, false, false, "", nil, UserIssuedAPI)
+	account.Users[auditor.Id] = auditor
+	require.NoError(t, am.Store.SaveAccount(account))
+
+	updates := am.peersUpdateManager.CreateSessionChannel(SyncSession{PeerID: peer1ID, RemoteAddr: "198.51.100.7:53124"})
+	_ = am.peersUpdateManager.CreateChannel("peer-of-another-account")
+
+	sessions, err := am.ListSyncSessions(account.Id, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1, "only the sessions of the account peers should be listed")
+	assert.Equal(t, peer1ID, sessions[0].PeerID)
+	assert.Equal(t, "198.51.100.7:53124", sessions[0].RemoteAddr)
+
+	_, err = am.ListSyncSessions(account.Id, regularUser.Id)
+	assertStatusType(t, err, status.PermissionDenied)
+	assertStatusType(t, am.CloseSyncSession(account.Id, regularUser.Id, peer1ID), status.PermissionDenied)
+
+	sessions, err = am.ListSyncSessions(account.Id, auditor.Id)
+	require.NoError(t, err, "the users allowed to view the peers should list the sessions")
+	assert.Len(t, sessions, 1)
+	assertStatusType(t, am.CloseSyncSession(account.Id, auditor.Id, peer1ID), status.PermissionDenied)
+
+	assertStatusType(t, am.CloseSyncSession(account.Id, userID, "peer-of-another-account"), status.NotFound)
+	assertStatusType(t, am.CloseSyncSession(account.Id, userID, peer2ID), status.NotFound)
+	assert.True(t, am.peersUpdateManager.HasChannel("peer-of-another-account"))
+
+	require.NoError(t, am.CloseSyncSession(account.Id, userID, peer1ID))
+	_, open := <-updates
+	assert.False(t, open, "the sync stream channel should be closed")
+
+	sessions, err = am.ListSyncSessions(account.Id, userID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
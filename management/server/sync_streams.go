@@ -0,0 +1,100 @@
+package server
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// syncReconnectSpread is the period the peers are asked to reconnect over when the server shuts down,
+// so they don't all reconnect at once to the next instance
+const syncReconnectSpread = 30 * time.Second
+
+// syncStreams tracks the Sync streams of the connected peers to end them when the server shuts down.
+// The zero value is ready to use.
+type syncStreams struct {
+	mu      sync.Mutex
+	closed  bool
+	closing chan struct{}
+	active  sync.WaitGroup
+}
+
+// add registers a new stream, it returns false when the streams are being closed
+func (s *syncStreams) add() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	s.active.Add(1)
+	return true
+}
+
+// done unregisters a stream registered with add
+func (s *syncStreams) done() {
+	s.active.Done()
+}
+
+// closingCh returns a channel closed when the streams have to end
+func (s *syncStreams) closingCh() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closing == nil {
+		s.closing = make(chan struct{})
+	}
+	return s.closing
+}
+
+// close ends the streams and waits up to the timeout for them to end. It returns false on timeout.
+func (s *syncStreams) close(timeout time.Duration) bool {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		if s.closing == nil {
+			s.closing = make(chan struct{})
+		}
+		close(s.closing)
+	}
+	s.mu.Unlock()
+
+	ended := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(ended)
+	}()
+
+	select {
+	case <-ended:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// CloseSyncStreams ends the Sync streams of the connected peers, asking each peer to reconnect after a random delay,
+// and waits up to the timeout for the streams to end. New Sync requests are rejected the same way afterwards.
+// It is meant to be called before stopping the gRPC server, which drops the connections without a reconnection hint.
+func (s *GRPCServer) CloseSyncStreams(timeout time.Duration) {
+	if !s.syncStreams.close(timeout) {
+		log.Warnf("not all Sync streams ended within %s", timeout)
+	}
+}
+
+// syncReconnectError returns the status ending a Sync stream with the delay the peer should reconnect after
+func syncReconnectError() error {
+	delay := time.Second + time.Duration(rand.Int63n(int64(syncReconnectSpread)))
+	st, err := status.New(codes.Unavailable, "management service is shutting down").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if err != nil {
+		return status.Error(codes.Unavailable, "management service is shutting down")
+	}
+	return st.Err()
+}
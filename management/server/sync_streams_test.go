@@ -0,0 +1,42 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSyncStreams_Close(t *testing.T) {
+	var streams syncStreams
+	require.True(t, streams.add())
+	closing := streams.closingCh()
+
+	assert.False(t, streams.close(50*time.Millisecond), "close should time out while a stream is active")
+	select {
+	case <-closing:
+	default:
+		t.Fatal("closing channel should be closed")
+	}
+	assert.False(t, streams.add(), "new streams shouldn't be accepted after close")
+
+	streams.done()
+	assert.True(t, streams.close(time.Second), "close should succeed once the streams ended")
+}
+
+func TestSyncReconnectError(t *testing.T) {
+	s, ok := status.FromError(syncReconnectError())
+	require.True(t, ok)
+	assert.Equal(t, codes.Unavailable, s.Code())
+
+	require.Len(t, s.Details(), 1)
+	retryInfo, ok := s.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	delay := retryInfo.GetRetryDelay().AsDuration()
+	assert.GreaterOrEqual(t, delay, time.Second)
+	assert.Less(t, delay, time.Second+syncReconnectSpread)
+}
@@ -28,6 +28,7 @@ type MockAppMetrics struct {
 	GRPCMetricsFunc          func() *GRPCMetrics
 	StoreMetricsFunc         func() *StoreMetrics
 	UpdateChannelMetricsFunc func() *UpdateChannelMetrics
+	PeerMetricsFunc          func() *PeerMetrics
 }
 
 // GetMeter mocks the GetMeter function of the AppMetrics interface
@@ -94,6 +95,14 @@ func (mock *MockAppMetrics) UpdateChannelMetrics() *UpdateChannelMetrics {
 	return nil
 }
 
+// PeerMetrics mocks the MockAppMetrics function of the PeerMetrics interface
+func (mock *MockAppMetrics) PeerMetrics() *PeerMetrics {
+	if mock.PeerMetricsFunc != nil {
+		return mock.PeerMetricsFunc()
+	}
+	return nil
+}
+
 // AppMetrics is metrics interface
 type AppMetrics interface {
 	GetMeter() metric2.Meter
@@ -104,6 +113,7 @@ type AppMetrics interface {
 	GRPCMetrics() *GRPCMetrics
 	StoreMetrics() *StoreMetrics
 	UpdateChannelMetrics() *UpdateChannelMetrics
+	PeerMetrics() *PeerMetrics
 }
 
 // defaultAppMetrics are core application metrics based on OpenTelemetry https://opentelemetry.io/
@@ -117,6 +127,7 @@ type defaultAppMetrics struct {
 	grpcMetrics          *GRPCMetrics
 	storeMetrics         *StoreMetrics
 	updateChannelMetrics *UpdateChannelMetrics
+	peerMetrics          *PeerMetrics
 }
 
 // IDPMetrics returns metrics for the idp package
@@ -144,6 +155,11 @@ func (appMetrics *defaultAppMetrics) UpdateChannelMetrics() *UpdateChannelMetric
 	return appMetrics.updateChannelMetrics
 }
 
+// PeerMetrics returns metrics for the peers
+func (appMetrics *defaultAppMetrics) PeerMetrics() *PeerMetrics {
+	return appMetrics.peerMetrics
+}
+
 // Close stop application metrics HTTP handler and closes listener.
 func (appMetrics *defaultAppMetrics) Close() error {
 	if appMetrics.listener == nil {
@@ -220,6 +236,11 @@ func NewDefaultAppMetrics(ctx context.Context) (AppMetrics, error) {
 		return nil, err
 	}
 
+	peerMetrics, err := NewPeerMetrics(ctx, meter)
+	if err != nil {
+		return nil, err
+	}
+
 	return &defaultAppMetrics{
 		Meter:                meter,
 		ctx:                  ctx,
@@ -228,5 +249,6 @@ func NewDefaultAppMetrics(ctx context.Context) (AppMetrics, error) {
 		grpcMetrics:          grpcMetrics,
 		storeMetrics:         storeMetrics,
 		updateChannelMetrics: updateChannelMetrics,
+		peerMetrics:          peerMetrics,
 	}, nil
 }
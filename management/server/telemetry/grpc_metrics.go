@@ -16,6 +16,8 @@ type GRPCMetrics struct {
 	syncRequestsCounter   syncint64.Counter
 	loginRequestsCounter  syncint64.Counter
 	getKeyRequestsCounter syncint64.Counter
+	keyRotationCounter    syncint64.Counter
+	previousKeyCounter    syncint64.Counter
 	activeStreamsGauge    asyncint64.Gauge
 	syncRequestDuration   syncint64.Histogram
 	loginRequestDuration  syncint64.Histogram
@@ -38,6 +40,15 @@ func NewGRPCMetrics(ctx context.Context, meter metric.Meter) (*GRPCMetrics, erro
 		return nil, err
 	}
 
+	keyRotationCounter, err := meter.SyncInt64().Counter("management.grpc.key.rotation.counter", instrument.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+	previousKeyCounter, err := meter.SyncInt64().Counter("management.grpc.key.previous.request.counter", instrument.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+
 	activeStreamsGauge, err := meter.AsyncInt64().Gauge("management.grpc.connected.streams", instrument.WithUnit("1"))
 	if err != nil {
 		return nil, err
@@ -70,6 +81,8 @@ func NewGRPCMetrics(ctx context.Context, meter metric.Meter) (*GRPCMetrics, erro
 		syncRequestsCounter:   syncRequestsCounter,
 		loginRequestsCounter:  loginRequestsCounter,
 		getKeyRequestsCounter: getKeyRequestsCounter,
+		keyRotationCounter:    keyRotationCounter,
+		previousKeyCounter:    previousKeyCounter,
 		activeStreamsGauge:    activeStreamsGauge,
 		syncRequestDuration:   syncRequestDuration,
 		loginRequestDuration:  loginRequestDuration,
@@ -88,6 +101,16 @@ func (grpcMetrics *GRPCMetrics) CountGetKeyRequest() {
 	grpcMetrics.getKeyRequestsCounter.Add(grpcMetrics.ctx, 1)
 }
 
+// CountServerKeyRotation counts the number of rotations of the management server key
+func (grpcMetrics *GRPCMetrics) CountServerKeyRotation() {
+	grpcMetrics.keyRotationCounter.Add(grpcMetrics.ctx, 1)
+}
+
+// CountPreviousServerKeyRequest counts the number of gRPC requests encrypted with the server key rotated out
+func (grpcMetrics *GRPCMetrics) CountPreviousServerKeyRequest() {
+	grpcMetrics.previousKeyCounter.Add(grpcMetrics.ctx, 1)
+}
+
 // CountLoginRequest counts the number of gRPC login requests coming to the gRPC API
 func (grpcMetrics *GRPCMetrics) CountLoginRequest() {
 	grpcMetrics.loginRequestsCounter.Add(grpcMetrics.ctx, 1)
@@ -20,6 +20,7 @@ type IDPMetrics struct {
 	authenticateRequestCounter syncint64.Counter
 	requestErrorCounter        syncint64.Counter
 	requestStatusErrorCounter  syncint64.Counter
+	requestRetryCounter        syncint64.Counter
 	ctx                        context.Context
 }
 
@@ -65,6 +66,10 @@ func NewIDPMetrics(ctx context.Context, meter metric.Meter) (*IDPMetrics, error)
 	if err != nil {
 		return nil, err
 	}
+	requestRetryCounter, err := meter.SyncInt64().Counter("management.idp.request.retry.counter", instrument.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
 
 	return &IDPMetrics{
 		metaUpdateCounter:          metaUpdateCounter,
@@ -77,6 +82,7 @@ func NewIDPMetrics(ctx context.Context, meter metric.Meter) (*IDPMetrics, error)
 		authenticateRequestCounter: authenticateRequestCounter,
 		requestErrorCounter:        requestErrorCounter,
 		requestStatusErrorCounter:  requestStatusErrorCounter,
+		requestRetryCounter:        requestRetryCounter,
 		ctx:                        ctx}, nil
 }
 
@@ -129,3 +135,8 @@ func (idpMetrics *IDPMetrics) CountRequestError() {
 func (idpMetrics *IDPMetrics) CountRequestStatusError() {
 	idpMetrics.requestStatusErrorCounter.Add(idpMetrics.ctx, 1)
 }
+
+// CountRequestRetry counts number of retried requests made to the IDP API
+func (idpMetrics *IDPMetrics) CountRequestRetry() {
+	idpMetrics.requestRetryCounter.Add(idpMetrics.ctx, 1)
+}
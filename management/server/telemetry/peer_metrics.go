@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/asyncint64"
+)
+
+// PeerOSVersion is the OS and the client version of a peer, the labels of the peer versions gauge
+type PeerOSVersion struct {
+	OS      string
+	Version string
+}
+
+// PeerMetrics are metrics of the peers of all accounts
+type PeerMetrics struct {
+	connectedByVersions asyncint64.Gauge
+	mu                  sync.Mutex
+	versions            map[PeerOSVersion]int64
+	ctx                 context.Context
+}
+
+// NewPeerMetrics creates new PeerMetrics struct and registers the gauge of the connected peers by OS and client version
+func NewPeerMetrics(ctx context.Context, meter metric.Meter) (*PeerMetrics, error) {
+	connectedByVersions, err := meter.AsyncInt64().Gauge("management.peers.connected.versions",
+		instrument.WithDescription("Number of connected peers by OS and client version"),
+		instrument.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &PeerMetrics{
+		connectedByVersions: connectedByVersions,
+		versions:            make(map[PeerOSVersion]int64),
+		ctx:                 ctx,
+	}
+
+	err = meter.RegisterCallback(
+		[]instrument.Asynchronous{
+			connectedByVersions,
+		},
+		metrics.observeVersions,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// UpdateConnectedPeerVersions replaces the numbers of the connected peers by OS and client version reported by the gauge.
+// The numbers are computed periodically by the caller rather than on every scrape, and the caller has to keep the number
+// of distinct labels bounded
+func (metrics *PeerMetrics) UpdateConnectedPeerVersions(versions map[PeerOSVersion]int64) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.versions = versions
+}
+
+func (metrics *PeerMetrics) observeVersions(ctx context.Context) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	for key, count := range metrics.versions {
+		metrics.connectedByVersions.Observe(ctx, count, attribute.String("os", key.OS), attribute.String("version", key.Version))
+	}
+}
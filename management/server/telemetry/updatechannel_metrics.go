@@ -19,6 +19,7 @@ type UpdateChannelMetrics struct {
 	getAllConnectedPeersDurationMicro syncint64.Histogram
 	getAllConnectedPeers              syncint64.Histogram
 	hasChannelDurationMicro           syncint64.Histogram
+	coalescedUpdates                  syncint64.Histogram
 	ctx                               context.Context
 }
 
@@ -64,6 +65,12 @@ func NewUpdateChannelMetrics(ctx context.Context, meter metric.Meter) (*UpdateCh
 		return nil, err
 	}
 
+	// the average of the histogram is the coalescing ratio, the number of the sent updates per delivered update
+	coalescedUpdates, err := meter.SyncInt64().Histogram("management.updatechannel.send.coalesced.updates")
+	if err != nil {
+		return nil, err
+	}
+
 	return &UpdateChannelMetrics{
 		createChannelDurationMicro:        createChannelDurationMicro,
 		closeChannelDurationMicro:         closeChannelDurationMicro,
@@ -73,6 +80,7 @@ func NewUpdateChannelMetrics(ctx context.Context, meter metric.Meter) (*UpdateCh
 		getAllConnectedPeersDurationMicro: getAllConnectedPeersDurationMicro,
 		getAllConnectedPeers:              getAllConnectedPeers,
 		hasChannelDurationMicro:           hasChannelDurationMicro,
+		coalescedUpdates:                  coalescedUpdates,
 		ctx:                               ctx,
 	}, nil
 }
@@ -95,7 +103,7 @@ func (metrics *UpdateChannelMetrics) CountCloseChannelsDuration(duration time.Du
 }
 
 // CountSendUpdateDuration counts the duration of the SendUpdate method
-// found indicates if peer had channel, dropped indicates if the message was dropped due channel buffer overload
+// found indicates if peer had channel, dropped indicates if the message replaced a queued message not delivered yet
 func (metrics *UpdateChannelMetrics) CountSendUpdateDuration(duration time.Duration, found, dropped bool) {
 	attrs := []attribute.KeyValue{attribute.Bool("found", found), attribute.Bool("dropped", dropped)}
 	metrics.sendUpdateDurationMicro.Record(metrics.ctx, duration.Microseconds(), attrs...)
@@ -111,3 +119,8 @@ func (metrics *UpdateChannelMetrics) CountGetAllConnectedPeersDuration(duration
 func (metrics *UpdateChannelMetrics) CountHasChannelDuration(duration time.Duration) {
 	metrics.hasChannelDurationMicro.Record(metrics.ctx, duration.Microseconds())
 }
+
+// CountCoalescedUpdates counts the number of the updates sent to a peer that were coalesced into a single delivery
+func (metrics *UpdateChannelMetrics) CountCoalescedUpdates(updates int) {
+	metrics.coalescedUpdates.Record(metrics.ctx, int64(updates))
+}
@@ -16,6 +16,7 @@ import (
 // TURNCredentialsManager used to manage TURN credentials
 type TURNCredentialsManager interface {
 	GenerateCredentials() TURNCredentials
+	GetCredentials(peerKey string) TURNCredentials
 	SetupRefresh(peerKey string)
 	CancelRefresh(peerKey string)
 }
@@ -26,6 +27,10 @@ type TimeBasedAuthSecretsManager struct {
 	config        *TURNConfig
 	updateManager *PeersUpdateManager
 	cancelMap     map[string]chan struct{}
+	// credentialsCache holds the latest credentials generated for each peer, so reconnecting peers reuse them
+	credentialsCache map[string]cachedTURNCredentials
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
 }
 
 type TURNCredentials struct {
@@ -33,22 +38,61 @@ type TURNCredentials struct {
 	Password string
 }
 
+type cachedTURNCredentials struct {
+	credentials TURNCredentials
+	expiresAt   time.Time
+}
+
 func NewTimeBasedAuthSecretsManager(updateManager *PeersUpdateManager, config *TURNConfig) *TimeBasedAuthSecretsManager {
 	return &TimeBasedAuthSecretsManager{
 		mux:           sync.Mutex{},
 		config:        config,
 		updateManager: updateManager,
 		cancelMap:     make(map[string]chan struct{}),
+
+		credentialsCache: make(map[string]cachedTURNCredentials),
+		now:              time.Now,
 	}
 }
 
 // GenerateCredentials generates new time-based secret credentials - basically username is a unix timestamp and password is a HMAC hash of a timestamp with a preshared TURN secret
 func (m *TimeBasedAuthSecretsManager) GenerateCredentials() TURNCredentials {
+	credentials, _ := m.generateCredentials()
+	return credentials
+}
+
+// GetCredentials returns the cached credentials of the peer, so a reconnecting peer reuses its valid credentials.
+// New credentials are generated when there are none cached or the cached ones are close to expiration (last 1/4 of TTL).
+func (m *TimeBasedAuthSecretsManager) GetCredentials(peerID string) TURNCredentials {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if cached, ok := m.credentialsCache[peerID]; ok && m.now().Before(cached.expiresAt.Add(-m.expirationMargin())) {
+		return cached.credentials
+	}
+
+	return m.generateCachedCredentials(peerID)
+}
+
+// generateCachedCredentials generates new credentials and caches them for the peer. Requires m.mux to be locked.
+func (m *TimeBasedAuthSecretsManager) generateCachedCredentials(peerID string) TURNCredentials {
+	credentials, expiresAt := m.generateCredentials()
+	m.credentialsCache[peerID] = cachedTURNCredentials{
+		credentials: credentials,
+		expiresAt:   expiresAt,
+	}
+	return credentials
+}
+
+// generateCredentials generates new credentials and returns them with their expiration time
+func (m *TimeBasedAuthSecretsManager) generateCredentials() (TURNCredentials, time.Time) {
 	mac := hmac.New(sha1.New, []byte(m.config.Secret))
 
-	timeAuth := time.Now().Add(m.config.CredentialsTTL.Duration).Unix()
+	// the TURN server checks the expiration with a seconds precision, so the cached expiration time is truncated as well
+	validity := m.config.CredentialsTTL.Duration + m.config.CredentialsSkewTolerance.Duration
+	expiresAt := time.Unix(m.now().Add(validity).Unix(), 0)
 
-	username := fmt.Sprint(timeAuth)
+	username := fmt.Sprint(expiresAt.Unix())
 
 	_, err := mac.Write([]byte(username))
 	if err != nil {
@@ -61,8 +105,14 @@ func (m *TimeBasedAuthSecretsManager) GenerateCredentials() TURNCredentials {
 	return TURNCredentials{
 		Username: username,
 		Password: password,
-	}
+	}, expiresAt
+}
 
+// expirationMargin is the time before the expiration when the credentials are not handed out anymore
+// and new ones are generated, it matches the refresh at 3/4 of TTL. The skew tolerance is left to the clocks
+// drifting apart, so it doesn't delay the refresh.
+func (m *TimeBasedAuthSecretsManager) expirationMargin() time.Duration {
+	return m.config.CredentialsTTL.Duration/4 + m.config.CredentialsSkewTolerance.Duration
 }
 
 func (m *TimeBasedAuthSecretsManager) cancel(peerID string) {
@@ -72,11 +122,23 @@ func (m *TimeBasedAuthSecretsManager) cancel(peerID string) {
 	}
 }
 
-// CancelRefresh cancels scheduled peer credentials refresh
+// CancelRefresh cancels scheduled peer credentials refresh. The cached peer credentials are kept until they expire,
+// so a reconnecting peer reuses them, the expired credentials of all peers are purged.
 func (m *TimeBasedAuthSecretsManager) CancelRefresh(peerID string) {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 	m.cancel(peerID)
+	m.purgeExpiredCredentials()
+}
+
+// purgeExpiredCredentials removes the expired credentials from the cache. Requires m.mux to be locked.
+func (m *TimeBasedAuthSecretsManager) purgeExpiredCredentials() {
+	now := m.now()
+	for peerID, cached := range m.credentialsCache {
+		if !now.Before(cached.expiresAt) {
+			delete(m.credentialsCache, peerID)
+		}
+	}
 }
 
 // SetupRefresh starts peer credentials refresh. Since credentials are expiring (TTL) it is necessary to always generate them and send to the peer.
@@ -89,17 +151,34 @@ func (m *TimeBasedAuthSecretsManager) SetupRefresh(peerID string) {
 	m.cancelMap[peerID] = cancel
 	log.Debugf("starting turn refresh for %s", peerID)
 
+	// we don't want to regenerate credentials right on expiration, so we do it slightly before (at 3/4 of TTL).
+	// The peer may have got cached credentials, so the first refresh is scheduled relatively to their expiration.
+	refreshInterval := m.config.CredentialsTTL.Duration - m.config.CredentialsTTL.Duration/4
+	firstRefresh := refreshInterval
+	if cached, ok := m.credentialsCache[peerID]; ok {
+		firstRefresh = max(cached.expiresAt.Add(-m.expirationMargin()).Sub(m.now()), 0)
+	}
+
 	go func() {
-		// we don't want to regenerate credentials right on expiration, so we do it slightly before (at 3/4 of TTL)
-		ticker := time.NewTicker(m.config.CredentialsTTL.Duration / 4 * 3)
+		timer := time.NewTimer(firstRefresh)
+		defer timer.Stop()
 
 		for {
 			select {
 			case <-cancel:
 				log.Debugf("stopping turn refresh for %s", peerID)
 				return
-			case <-ticker.C:
-				c := m.GenerateCredentials()
+			case <-timer.C:
+				// pre-generate the next credentials before the cached ones expire, so they are reused on reconnect
+				m.mux.Lock()
+				if m.cancelMap[peerID] != cancel {
+					m.mux.Unlock()
+					return
+				}
+				c := m.generateCachedCredentials(peerID)
+				m.mux.Unlock()
+				timer.Reset(refreshInterval)
+
 				var turns []*proto.ProtectedHostConfig
 				for _, host := range m.config.Turns {
 					turns = append(turns, &proto.ProtectedHostConfig{
@@ -109,6 +188,8 @@ func (m *TimeBasedAuthSecretsManager) SetupRefresh(peerID string) {
 						},
 						User:     c.Username,
 						Password: c.Password,
+						Priority: host.Priority,
+						Weight:   host.Weight,
 					})
 				}
 
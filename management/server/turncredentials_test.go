@@ -4,6 +4,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
+	"strconv"
 	"testing"
 	"time"
 
@@ -15,6 +16,8 @@ var TurnTestHost = &Host{
 	URI:      "turn:turn.wiretrustee.com:77777",
 	Username: "username",
 	Password: "",
+	Priority: 1,
+	Weight:   10,
 }
 
 func TestTimeBasedAuthSecretsManager_GenerateCredentials(t *testing.T) {
@@ -88,6 +91,11 @@ loop:
 		t.Errorf("expecting first credential update password %v to be diffeerent from second, got equal", firstUpdate.Password)
 	}
 
+	if secondUpdate.Priority != TurnTestHost.Priority || secondUpdate.Weight != TurnTestHost.Weight {
+		t.Errorf("expecting the TURN priority %d and weight %d to be kept, got %d and %d",
+			TurnTestHost.Priority, TurnTestHost.Weight, secondUpdate.Priority, secondUpdate.Weight)
+	}
+
 }
 
 func TestTimeBasedAuthSecretsManager_CancelRefresh(t *testing.T) {
@@ -113,6 +121,162 @@ func TestTimeBasedAuthSecretsManager_CancelRefresh(t *testing.T) {
 	}
 }
 
+func TestTimeBasedAuthSecretsManager_GetCredentials(t *testing.T) {
+	ttl := util.Duration{Duration: time.Hour}
+	secret := "some_secret"
+	peer := "some_peer"
+	start := time.Unix(1700000000, 0)
+
+	testCases := []struct {
+		name        string
+		elapsed     time.Duration
+		expectReuse bool
+	}{
+		{name: "right after generation", elapsed: 0, expectReuse: true},
+		{name: "before the expiration margin", elapsed: ttl.Duration/4*3 - time.Second, expectReuse: true},
+		{name: "at the expiration margin", elapsed: ttl.Duration / 4 * 3, expectReuse: false},
+		{name: "at the expiration", elapsed: ttl.Duration, expectReuse: false},
+		{name: "after the expiration", elapsed: 2 * ttl.Duration, expectReuse: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tested := NewTimeBasedAuthSecretsManager(NewPeersUpdateManager(nil), &TURNConfig{
+				CredentialsTTL: ttl,
+				Secret:         secret,
+				Turns:          []*Host{TurnTestHost},
+			})
+
+			tested.now = func() time.Time { return start }
+			first := tested.GetCredentials(peer)
+			validateMAC(t, first.Username, first.Password, []byte(secret))
+
+			now := start.Add(testCase.elapsed)
+			tested.now = func() time.Time { return now }
+			second := tested.GetCredentials(peer)
+			validateMAC(t, second.Username, second.Password, []byte(secret))
+
+			if testCase.expectReuse && second != first {
+				t.Errorf("expecting cached credentials %v to be reused, got %v", first, second)
+			}
+			if !testCase.expectReuse && second == first {
+				t.Errorf("expecting new credentials, got cached %v", first)
+			}
+
+			expiresAt, err := strconv.ParseInt(second.Username, 10, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if remaining := time.Unix(expiresAt, 0).Sub(now); remaining < ttl.Duration/4 {
+				t.Errorf("expecting credentials valid for at least %v, got %v", ttl.Duration/4, remaining)
+			}
+		})
+	}
+}
+
+func TestTimeBasedAuthSecretsManager_SkewTolerance(t *testing.T) {
+	ttl := util.Duration{Duration: time.Hour}
+	tolerance := util.Duration{Duration: 5 * time.Minute}
+	secret := "some_secret"
+	peer := "some_peer"
+	start := time.Unix(1700000000, 0)
+
+	testCases := []struct {
+		name      string
+		tolerance util.Duration
+		// elapsed is the time passed on the management clock since the credentials were generated
+		elapsed time.Duration
+		// skew is how far the clock of the TURN server is ahead of the management clock
+		skew        time.Duration
+		expectValid bool
+	}{
+		{name: "expiry without skew", elapsed: ttl.Duration, expectValid: true},
+		{name: "expiry with skew and no tolerance", elapsed: ttl.Duration, skew: time.Second, expectValid: false},
+		{name: "expiry with skew within tolerance", tolerance: tolerance, elapsed: ttl.Duration, skew: tolerance.Duration, expectValid: true},
+		{name: "expiry with skew beyond tolerance", tolerance: tolerance, elapsed: ttl.Duration, skew: tolerance.Duration + time.Second, expectValid: false},
+		{name: "refresh with skew up to the remaining validity", tolerance: tolerance, elapsed: ttl.Duration / 4 * 3, skew: ttl.Duration/4 + tolerance.Duration, expectValid: true},
+		{name: "after expiry with skew behind", tolerance: tolerance, elapsed: ttl.Duration + tolerance.Duration + time.Second, skew: -time.Minute, expectValid: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tested := NewTimeBasedAuthSecretsManager(NewPeersUpdateManager(nil), &TURNConfig{
+				CredentialsTTL:           ttl,
+				CredentialsSkewTolerance: testCase.tolerance,
+				Secret:                   secret,
+				Turns:                    []*Host{TurnTestHost},
+			})
+			tested.now = func() time.Time { return start }
+
+			credentials := tested.GetCredentials(peer)
+			validateMAC(t, credentials.Username, credentials.Password, []byte(secret))
+
+			expiresAt, err := strconv.ParseInt(credentials.Username, 10, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the TURN server rejects the credentials with an expiration timestamp in the past of its clock
+			turnNow := start.Add(testCase.elapsed).Add(testCase.skew)
+			if valid := turnNow.Unix() <= expiresAt; valid != testCase.expectValid {
+				t.Errorf("expecting credentials expiring at %d to be valid %t at %d, got %t",
+					expiresAt, testCase.expectValid, turnNow.Unix(), valid)
+			}
+		})
+	}
+}
+
+func TestTimeBasedAuthSecretsManager_SkewToleranceKeepsRefresh(t *testing.T) {
+	ttl := util.Duration{Duration: time.Hour}
+	peer := "some_peer"
+	start := time.Unix(1700000000, 0)
+
+	tested := NewTimeBasedAuthSecretsManager(NewPeersUpdateManager(nil), &TURNConfig{
+		CredentialsTTL:           ttl,
+		CredentialsSkewTolerance: util.Duration{Duration: 5 * time.Minute},
+		Secret:                   "some_secret",
+		Turns:                    []*Host{TurnTestHost},
+	})
+
+	tested.now = func() time.Time { return start }
+	first := tested.GetCredentials(peer)
+
+	tested.now = func() time.Time { return start.Add(ttl.Duration/4*3 - time.Second) }
+	if second := tested.GetCredentials(peer); second != first {
+		t.Errorf("expecting cached credentials %v to be reused before the refresh, got %v", first, second)
+	}
+
+	tested.now = func() time.Time { return start.Add(ttl.Duration / 4 * 3) }
+	if second := tested.GetCredentials(peer); second == first {
+		t.Errorf("expecting new credentials at the refresh, got cached %v", first)
+	}
+}
+
+func TestTimeBasedAuthSecretsManager_CancelRefreshKeepsCache(t *testing.T) {
+	ttl := util.Duration{Duration: time.Hour}
+	peer := "some_peer"
+	start := time.Unix(1700000000, 0)
+	tested := NewTimeBasedAuthSecretsManager(NewPeersUpdateManager(nil), &TURNConfig{
+		CredentialsTTL: ttl,
+		Secret:         "some_secret",
+		Turns:          []*Host{TurnTestHost},
+	})
+	tested.now = func() time.Time { return start }
+
+	first := tested.GetCredentials(peer)
+	tested.SetupRefresh(peer)
+	tested.CancelRefresh(peer)
+	if second := tested.GetCredentials(peer); second != first {
+		t.Errorf("expecting cached credentials %v to be reused by the reconnecting peer, got %v", first, second)
+	}
+
+	tested.now = func() time.Time { return start.Add(ttl.Duration) }
+	tested.CancelRefresh("other_peer")
+	if _, ok := tested.credentialsCache[peer]; ok {
+		t.Errorf("expecting expired peer credentials to be purged from the cache, got cached")
+	}
+}
+
 func validateMAC(t *testing.T, username string, actualMAC string, key []byte) {
 	t.Helper()
 	mac := hmac.New(sha1.New, key)
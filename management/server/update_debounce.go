@@ -0,0 +1,35 @@
+package server
+
+import (
+	"time"
+
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// DefaultPeerUpdateDebounceWindow is the debounce window of the accounts which haven't set one
+	DefaultPeerUpdateDebounceWindow = 250 * time.Millisecond
+	// MaxPeerUpdateDebounceWindow is the longest debounce window an account can set
+	MaxPeerUpdateDebounceWindow = 10 * time.Second
+)
+
+// GetPeerUpdateDebounceWindow returns the debounce window of the network map updates of the account peers,
+// DefaultPeerUpdateDebounceWindow if the account hasn't set one
+func (s *Settings) GetPeerUpdateDebounceWindow() time.Duration {
+	if s == nil || s.PeerUpdateDebounceWindow == nil {
+		return DefaultPeerUpdateDebounceWindow
+	}
+	return *s.PeerUpdateDebounceWindow
+}
+
+func validatePeerUpdateDebounceWindow(settings *Settings) error {
+	if settings.PeerUpdateDebounceWindow == nil {
+		return nil
+	}
+
+	window := *settings.PeerUpdateDebounceWindow
+	if window < 0 || window > MaxPeerUpdateDebounceWindow {
+		return status.Errorf(status.InvalidArgument, "peer update debounce window has to be between 0 and %s", MaxPeerUpdateDebounceWindow)
+	}
+	return nil
+}
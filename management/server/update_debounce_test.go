@@ -0,0 +1,69 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+func TestValidatePeerUpdateDebounceWindow(t *testing.T) {
+	dr := func(d time.Duration) *time.Duration { return &d }
+
+	testCases := []struct {
+		name   string
+		window *time.Duration
+		valid  bool
+	}{
+		{name: "default window", valid: true},
+		{name: "disabled", window: dr(0), valid: true},
+		{name: "longest window", window: dr(MaxPeerUpdateDebounceWindow), valid: true},
+		{name: "too long window", window: dr(MaxPeerUpdateDebounceWindow + time.Millisecond)},
+		{name: "negative window", window: dr(-time.Millisecond)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validatePeerUpdateDebounceWindow(&Settings{PeerUpdateDebounceWindow: testCase.window})
+			if testCase.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assertStatusType(t, err, status.InvalidArgument)
+		})
+	}
+}
+
+func TestDefaultAccountManager_UpdateAccountSettings_PeerUpdateDebounceWindow(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err, "unable to create account manager")
+	account, err := manager.GetAccountByUserOrAccountID(userID, "", "")
+	require.NoError(t, err, "unable to create an account")
+	assert.Equal(t, DefaultPeerUpdateDebounceWindow, account.Settings.GetPeerUpdateDebounceWindow())
+
+	window := time.Duration(0)
+	updated, err := manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:      time.Hour,
+		PeerUpdateDebounceWindow: &window,
+	})
+	require.NoError(t, err, "unable to update the debounce window")
+	assert.Equal(t, time.Duration(0), updated.Settings.GetPeerUpdateDebounceWindow())
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), account.Settings.GetPeerUpdateDebounceWindow())
+
+	ev := getEvent(t, account.Id, manager, activity.AccountPeerUpdateDebounceWindowUpdated)
+	assert.Equal(t, "0s", ev.Meta["window"])
+
+	window = MaxPeerUpdateDebounceWindow + time.Second
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration:      time.Hour,
+		PeerUpdateDebounceWindow: &window,
+	})
+	assertStatusType(t, err, status.InvalidArgument)
+}
@@ -10,16 +10,104 @@ import (
 	"github.com/netbirdio/netbird/management/server/telemetry"
 )
 
-const channelBufferSize = 100
+const (
+	channelBufferSize = 100
+	// updateWorkers is the maximum number of the workers delivering the queued updates to the peer channels
+	updateWorkers = 16
+)
 
 type UpdateMessage struct {
 	Update *proto.SyncResponse
 }
 
+// pendingUpdate holds the updates of a peer waiting for a worker. A newer update replaces the queued update of the
+// same kind, so a burst of changes results in a single update per peer.
+type pendingUpdate struct {
+	// networkMap is the latest update carrying a network map
+	networkMap *UpdateMessage
+	// other is the latest update without a network map, e.g. the refreshed TURN credentials
+	other *UpdateMessage
+	// coalesced is the number of the updates queued since the last delivery
+	coalesced int
+	// deliverAt is when the delivery is scheduled, timer is nil if it isn't delayed
+	deliverAt time.Time
+	timer     *time.Timer
+}
+
+// add queues the update and returns true if it replaced a queued one
+func (u *pendingUpdate) add(update *UpdateMessage) bool {
+	u.coalesced++
+	if update.Update.GetNetworkMap() == nil {
+		replaced := u.other != nil
+		u.other = update
+		return replaced
+	}
+
+	if u.networkMap == nil {
+		u.networkMap = update
+		return false
+	}
+	// an update computed from an older account state doesn't replace a newer one
+	if update.Update.GetNetworkMap().GetSerial() >= u.networkMap.Update.GetNetworkMap().GetSerial() {
+		u.networkMap = update
+	}
+	return true
+}
+
+// SyncSession describes the Sync stream a connected peer holds open
+type SyncSession struct {
+	// PeerID is the ID of the peer holding the stream
+	PeerID string
+	// PeerKey is the WireGuard public key of the peer
+	PeerKey string
+	// ConnectedSince is when the Sync stream started
+	ConnectedSince time.Time
+	// RemoteAddr is the address the stream was opened from
+	RemoteAddr string
+}
+
+// updateChannel is the update channel of a peer. Its lock serializes the pushes to the channel with its closing, so
+// the updates are pushed without holding the lock of the PeersUpdateManager.
+type updateChannel struct {
+	mu     sync.Mutex
+	ch     chan *UpdateMessage
+	closed bool
+}
+
+// push pushes the updates to the channel unless it has been closed meanwhile
+func (c *updateChannel) push(peerID string, pending *pendingUpdate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	pushUpdates(peerID, c.ch, pending)
+}
+
+func (c *updateChannel) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.ch)
+}
+
 type PeersUpdateManager struct {
 	// peerChannels is an update channel indexed by Peer.ID
-	peerChannels map[string]chan *UpdateMessage
-	// channelsMux keeps the mutex to access peerChannels
+	peerChannels map[string]*updateChannel
+	// sessions are the Sync streams of the peerChannels indexed by Peer.ID
+	sessions map[string]SyncSession
+	// pendingUpdates are the updates waiting for a worker indexed by Peer.ID
+	pendingUpdates map[string]*pendingUpdate
+	// lastDeliveries are the times of the last updates pushed to the peerChannels indexed by Peer.ID
+	lastDeliveries map[string]time.Time
+	// ready are the IDs of the peers whose pending updates are due, in the order they became due
+	ready []string
+	// workers is the number of the running workers delivering the ready updates, at most updateWorkers
+	workers int
+	// channelsMux keeps the mutex to access peerChannels, pendingUpdates and the ready queue
 	channelsMux *sync.Mutex
 	// metrics provides method to collect application metrics
 	metrics telemetry.AppMetrics
@@ -28,14 +116,25 @@ type PeersUpdateManager struct {
 // NewPeersUpdateManager returns a new instance of PeersUpdateManager
 func NewPeersUpdateManager(metrics telemetry.AppMetrics) *PeersUpdateManager {
 	return &PeersUpdateManager{
-		peerChannels: make(map[string]chan *UpdateMessage),
-		channelsMux:  &sync.Mutex{},
-		metrics:      metrics,
+		peerChannels:   make(map[string]*updateChannel),
+		sessions:       make(map[string]SyncSession),
+		pendingUpdates: make(map[string]*pendingUpdate),
+		lastDeliveries: make(map[string]time.Time),
+		channelsMux:    &sync.Mutex{},
+		metrics:        metrics,
 	}
 }
 
-// SendUpdate sends update message to the peer's channel
+// SendUpdate queues the update message for the peer's channel, it doesn't wait for the delivery.
+// The queued update of the same kind not delivered yet is replaced.
 func (p *PeersUpdateManager) SendUpdate(peerID string, update *UpdateMessage) {
+	p.SendDebouncedUpdate(peerID, update, 0)
+}
+
+// SendDebouncedUpdate queues the update message like SendUpdate, but delivers it no sooner than the debounce window
+// after the previous update of the peer. An idle peer gets the update right away, the updates following it within
+// the window are coalesced and the latest one is delivered when the window ends.
+func (p *PeersUpdateManager) SendDebouncedUpdate(peerID string, update *UpdateMessage, window time.Duration) {
 	start := time.Now()
 	var found, dropped bool
 
@@ -47,22 +146,126 @@ func (p *PeersUpdateManager) SendUpdate(peerID string, update *UpdateMessage) {
 		}
 	}()
 
-	if channel, ok := p.peerChannels[peerID]; ok {
-		found = true
+	if _, ok := p.peerChannels[peerID]; !ok {
+		log.Debugf("peer %s has no channel", peerID)
+		return
+	}
+	found = true
+
+	pending, queued := p.pendingUpdates[peerID]
+	if !queued {
+		pending = &pendingUpdate{}
+		p.pendingUpdates[peerID] = pending
+	}
+	dropped = pending.add(update)
+	if dropped {
+		log.Debugf("replaced the queued update of peer %s", peerID)
+	}
+
+	p.scheduleDelivery(peerID, pending, queued, p.lastDeliveries[peerID].Add(window))
+}
+
+// scheduleDelivery schedules the delivery of the pending updates at deliverAt. A queued peer keeps its delivery
+// unless deliverAt is sooner, e.g. when an update without a debounce window follows a debounced one.
+// Should be called with channelsMux locked.
+func (p *PeersUpdateManager) scheduleDelivery(peerID string, pending *pendingUpdate, queued bool, deliverAt time.Time) {
+	if queued {
+		if !deliverAt.Before(pending.deliverAt) {
+			return
+		}
+		// the timer has fired already, the delivery is about to happen
+		if pending.timer == nil || !pending.timer.Stop() {
+			return
+		}
+	}
+
+	pending.deliverAt = deliverAt
+	delay := time.Until(deliverAt)
+	if delay <= 0 {
+		pending.timer = nil
+		p.enqueueDelivery(peerID)
+		return
+	}
+	pending.timer = time.AfterFunc(delay, func() {
+		p.channelsMux.Lock()
+		defer p.channelsMux.Unlock()
+		p.enqueueDelivery(peerID)
+	})
+}
+
+// enqueueDelivery marks the pending updates of the peer as due and starts a worker if one is available.
+// Should be called with channelsMux locked.
+func (p *PeersUpdateManager) enqueueDelivery(peerID string) {
+	p.ready = append(p.ready, peerID)
+	p.startWorkers()
+}
+
+// startWorkers starts the workers for the ready deliveries, up to updateWorkers in total.
+// Should be called with channelsMux locked.
+func (p *PeersUpdateManager) startWorkers() {
+	for p.workers < updateWorkers && p.workers < len(p.ready) {
+		p.workers++
+		go p.deliverReady()
+	}
+}
+
+// deliverReady pushes the due updates of the peers to their channels until there are none left. The channels are
+// pushed to under their own lock, so the workers don't wait for each other.
+func (p *PeersUpdateManager) deliverReady() {
+	for {
+		p.channelsMux.Lock()
+		if len(p.ready) == 0 {
+			p.workers--
+			p.channelsMux.Unlock()
+			return
+		}
+		peerID := p.ready[0]
+		p.ready = p.ready[1:]
+
+		pending, queued := p.pendingUpdates[peerID]
+		channel, found := p.peerChannels[peerID]
+		if queued {
+			delete(p.pendingUpdates, peerID)
+		}
+		if queued && found {
+			p.lastDeliveries[peerID] = time.Now()
+		}
+		p.channelsMux.Unlock()
+
+		if !queued || !found {
+			continue
+		}
+		channel.push(peerID, pending)
+		if p.metrics != nil {
+			p.metrics.UpdateChannelMetrics().CountCoalescedUpdates(pending.coalesced)
+		}
+	}
+}
+
+// pushUpdates pushes the updates to the channel without blocking, the updates not fitting in the channel are dropped
+func pushUpdates(peerID string, channel chan *UpdateMessage, pending *pendingUpdate) {
+	for _, update := range []*UpdateMessage{pending.other, pending.networkMap} {
+		if update == nil {
+			continue
+		}
 		select {
 		case channel <- update:
 			log.Debugf("update was sent to channel for peer %s", peerID)
 		default:
-			dropped = true
 			log.Warnf("channel for peer %s is %d full", peerID, len(channel))
 		}
-	} else {
-		log.Debugf("peer %s has no channel", peerID)
 	}
 }
 
 // CreateChannel creates a go channel for a given peer used to deliver updates relevant to the peer.
 func (p *PeersUpdateManager) CreateChannel(peerID string) chan *UpdateMessage {
+	return p.CreateSessionChannel(SyncSession{PeerID: peerID, ConnectedSince: time.Now().UTC()})
+}
+
+// CreateSessionChannel creates the channel delivering the updates to the peer of the Sync stream described by
+// the session. The channel of a previous stream of the peer is closed.
+func (p *PeersUpdateManager) CreateSessionChannel(session SyncSession) chan *UpdateMessage {
+	peerID := session.PeerID
 	start := time.Now()
 
 	closed := false
@@ -78,26 +281,52 @@ func (p *PeersUpdateManager) CreateChannel(peerID string) chan *UpdateMessage {
 	if channel, ok := p.peerChannels[peerID]; ok {
 		closed = true
 		delete(p.peerChannels, peerID)
-		close(channel)
+		channel.close()
 	}
+	// the new stream starts with the current state of the peer
+	p.dropPendingUpdate(peerID)
+	delete(p.lastDeliveries, peerID)
 	// mbragin: todo shouldn't it be more? or configurable?
 	channel := make(chan *UpdateMessage, channelBufferSize)
-	p.peerChannels[peerID] = channel
+	p.peerChannels[peerID] = &updateChannel{ch: channel}
+	p.sessions[peerID] = session
 
 	log.Debugf("opened updates channel for a peer %s", peerID)
 
 	return channel
 }
 
+// closeChannel closes the channel of the peer once the pending updates are pushed to it, so the last updates
+// sent before closing, e.g. the removal of the peer, reach the peer
 func (p *PeersUpdateManager) closeChannel(peerID string) {
+	pending, queued := p.pendingUpdates[peerID]
+	p.dropPendingUpdate(peerID)
+	delete(p.lastDeliveries, peerID)
+
 	if channel, ok := p.peerChannels[peerID]; ok {
+		if queued {
+			channel.push(peerID, pending)
+		}
 		delete(p.peerChannels, peerID)
-		close(channel)
+		channel.close()
 	}
+	delete(p.sessions, peerID)
 
 	log.Debugf("closed updates channel of a peer %s", peerID)
 }
 
+// dropPendingUpdate removes the pending updates of the peer and cancels their delayed delivery
+func (p *PeersUpdateManager) dropPendingUpdate(peerID string) {
+	pending, ok := p.pendingUpdates[peerID]
+	if !ok {
+		return
+	}
+	if pending.timer != nil {
+		pending.timer.Stop()
+	}
+	delete(p.pendingUpdates, peerID)
+}
+
 // CloseChannels closes updates channel for each given peer
 func (p *PeersUpdateManager) CloseChannels(peerIDs []string) {
 	start := time.Now()
@@ -169,3 +398,17 @@ func (p *PeersUpdateManager) HasChannel(peerID string) bool {
 
 	return ok
 }
+
+// GetSyncSessions returns the Sync streams of the given peers which are connected
+func (p *PeersUpdateManager) GetSyncSessions(peerIDs []string) []SyncSession {
+	p.channelsMux.Lock()
+	defer p.channelsMux.Unlock()
+
+	sessions := make([]SyncSession, 0)
+	for _, id := range peerIDs {
+		if session, ok := p.sessions[id]; ok {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/netbirdio/netbird/management/proto"
 )
 
@@ -28,19 +32,19 @@ func TestSendUpdate(t *testing.T) {
 			Serial: 0,
 		},
 	}}
-	_ = peersUpdater.CreateChannel(peer)
+	channel := peersUpdater.CreateChannel(peer)
 	if _, ok := peersUpdater.peerChannels[peer]; !ok {
 		t.Error("Error creating the channel")
 	}
 	peersUpdater.SendUpdate(peer, update1)
 	select {
-	case <-peersUpdater.peerChannels[peer]:
-	default:
+	case <-channel:
+	case <-time.After(5 * time.Second):
 		t.Error("Update wasn't send")
 	}
 
 	for range [channelBufferSize]int{} {
-		peersUpdater.SendUpdate(peer, update1)
+		channel <- update1
 	}
 
 	update2 := &UpdateMessage{Update: &proto.SyncResponse{
@@ -50,18 +54,145 @@ func TestSendUpdate(t *testing.T) {
 	}}
 
 	peersUpdater.SendUpdate(peer, update2)
-	timeout := time.After(5 * time.Second)
+	waitDelivered(t, peersUpdater, peer)
 	for range [channelBufferSize]int{} {
-		select {
-		case <-timeout:
-			t.Error("timed out reading previously sent updates")
-		case updateReader := <-peersUpdater.peerChannels[peer]:
-			if updateReader.Update.NetworkMap.Serial == update2.Update.NetworkMap.Serial {
-				t.Error("got the update that shouldn't have been sent")
-			}
+		updateReader := <-channel
+		if updateReader.Update.NetworkMap.Serial == update2.Update.NetworkMap.Serial {
+			t.Error("got the update that shouldn't have been sent")
 		}
 	}
+}
+
+func TestSendUpdate_Coalesced(t *testing.T) {
+	peer := "test-coalesced"
+	peersUpdater := NewPeersUpdateManager(nil)
+	channel := peersUpdater.CreateChannel(peer)
+
+	// hold the workers, so the burst is queued
+	release := holdWorkers(peersUpdater)
+
+	turnUpdate := &UpdateMessage{Update: &proto.SyncResponse{WiretrusteeConfig: &proto.WiretrusteeConfig{}}}
+	peersUpdater.SendUpdate(peer, turnUpdate)
+	for serial := uint64(1); serial <= 10; serial++ {
+		peersUpdater.SendUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: serial}}})
+	}
+	// an update computed before the queued one must not replace it
+	peersUpdater.SendUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: 5}}})
 
+	release()
+	waitDelivered(t, peersUpdater, peer)
+
+	require.Len(t, channel, 2, "the burst should be coalesced into one update per kind")
+	assert.Equal(t, turnUpdate, <-channel)
+	assert.Equal(t, uint64(10), (<-channel).Update.NetworkMap.Serial)
+}
+
+func TestCloseChannel_DeliversPendingUpdates(t *testing.T) {
+	peer := "test-close-pending"
+	peersUpdater := NewPeersUpdateManager(nil)
+	channel := peersUpdater.CreateChannel(peer)
+
+	release := holdWorkers(peersUpdater)
+	peersUpdater.SendUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: 1}}})
+	peersUpdater.CloseChannel(peer)
+	release()
+
+	update, open := <-channel
+	require.True(t, open, "the pending update should be delivered before closing")
+	assert.Equal(t, uint64(1), update.Update.NetworkMap.Serial)
+	_, open = <-channel
+	assert.False(t, open, "the channel should be closed")
+}
+
+func TestSendDebouncedUpdate(t *testing.T) {
+	peer := "test-debounced"
+	window := 200 * time.Millisecond
+	peersUpdater := NewPeersUpdateManager(nil)
+	channel := peersUpdater.CreateChannel(peer)
+
+	// an idle peer gets the update right away
+	start := time.Now()
+	peersUpdater.SendDebouncedUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: 1}}}, window)
+	select {
+	case update := <-channel:
+		assert.Equal(t, uint64(1), update.Update.NetworkMap.Serial)
+		assert.Less(t, time.Since(start), window, "the update of an idle peer shouldn't be delayed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("update wasn't sent")
+	}
+
+	// the burst following it is held back until the window ends and only the latest update is delivered
+	for serial := uint64(2); serial <= 5; serial++ {
+		peersUpdater.SendDebouncedUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: serial}}}, window)
+	}
+	assert.Len(t, channel, 0, "the burst shouldn't be delivered before the window ends")
+
+	waitDelivered(t, peersUpdater, peer)
+	require.Len(t, channel, 1, "the burst should be coalesced into one update")
+	assert.Equal(t, uint64(5), (<-channel).Update.NetworkMap.Serial)
+	assert.GreaterOrEqual(t, time.Since(start), window)
+}
+
+func TestSendDebouncedUpdate_UpdateWithoutWindowIsNotDelayed(t *testing.T) {
+	peer := "test-debounced-immediate"
+	window := time.Hour
+	peersUpdater := NewPeersUpdateManager(nil)
+	channel := peersUpdater.CreateChannel(peer)
+
+	peersUpdater.SendDebouncedUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: 1}}}, window)
+	waitDelivered(t, peersUpdater, peer)
+	<-channel
+
+	peersUpdater.SendDebouncedUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: 2}}}, window)
+	peersUpdater.SendUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: 3}}})
+
+	waitDelivered(t, peersUpdater, peer)
+	require.Len(t, channel, 1)
+	assert.Equal(t, uint64(3), (<-channel).Update.NetworkMap.Serial)
+}
+
+func TestCloseChannel_DeliversDebouncedUpdates(t *testing.T) {
+	peer := "test-close-debounced"
+	peersUpdater := NewPeersUpdateManager(nil)
+	channel := peersUpdater.CreateChannel(peer)
+
+	peersUpdater.SendDebouncedUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: 1}}}, time.Hour)
+	waitDelivered(t, peersUpdater, peer)
+	<-channel
+
+	peersUpdater.SendDebouncedUpdate(peer, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: 2}}}, time.Hour)
+	peersUpdater.CloseChannel(peer)
+
+	update, open := <-channel
+	require.True(t, open, "the debounced update should be delivered before closing")
+	assert.Equal(t, uint64(2), update.Update.NetworkMap.Serial)
+	_, open = <-channel
+	assert.False(t, open, "the channel should be closed")
+}
+
+// waitDelivered waits until the queued updates of the peer are pushed to its channel
+func waitDelivered(t *testing.T, peersUpdater *PeersUpdateManager, peerID string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		peersUpdater.channelsMux.Lock()
+		defer peersUpdater.channelsMux.Unlock()
+		_, queued := peersUpdater.pendingUpdates[peerID]
+		return !queued && peersUpdater.workers == 0
+	}, 5*time.Second, time.Millisecond)
+}
+
+// holdWorkers keeps the updates from being delivered until the returned function is called
+func holdWorkers(peersUpdater *PeersUpdateManager) func() {
+	peersUpdater.channelsMux.Lock()
+	peersUpdater.workers += updateWorkers
+	peersUpdater.channelsMux.Unlock()
+
+	return func() {
+		peersUpdater.channelsMux.Lock()
+		defer peersUpdater.channelsMux.Unlock()
+		peersUpdater.workers -= updateWorkers
+		peersUpdater.startWorkers()
+	}
 }
 
 func TestCloseChannel(t *testing.T) {
@@ -76,3 +207,90 @@ func TestCloseChannel(t *testing.T) {
 		t.Error("Error closing the channel")
 	}
 }
+
+func TestGetSyncSessions(t *testing.T) {
+	peersUpdater := NewPeersUpdateManager(nil)
+	session := SyncSession{
+		PeerID:         "test-session",
+		PeerKey:        "key",
+		ConnectedSince: time.Now().UTC(),
+		RemoteAddr:     "198.51.100.7:53124",
+	}
+	_ = peersUpdater.CreateSessionChannel(session)
+	_ = peersUpdater.CreateChannel("other-peer")
+
+	assert.Equal(t, []SyncSession{session}, peersUpdater.GetSyncSessions([]string{"test-session", "unknown"}))
+
+	peersUpdater.CloseChannel(session.PeerID)
+	assert.Empty(t, peersUpdater.GetSyncSessions([]string{"test-session"}), "the session should be removed with its channel")
+	assert.Len(t, peersUpdater.GetSyncSessions([]string{"other-peer"}), 1)
+}
+
+// BenchmarkPeersUpdateManager_FanOut compares fanning out a burst of network map updates to 1000 peers by pushing
+// every update to the peer channels, as the updates were sent before, with the coalesced delivery of SendUpdate.
+func BenchmarkPeersUpdateManager_FanOut(b *testing.B) {
+	const (
+		peers = 1000
+		burst = 10
+	)
+
+	peerIDs := make([]string, peers)
+	for i := range peerIDs {
+		peerIDs[i] = fmt.Sprintf("peer-%d", i)
+	}
+
+	drain := func(channels []chan *UpdateMessage) {
+		for _, channel := range channels {
+			for len(channel) > 0 {
+				<-channel
+			}
+		}
+	}
+
+	b.Run("direct", func(b *testing.B) {
+		peersUpdater := NewPeersUpdateManager(nil)
+		channels := make([]chan *UpdateMessage, peers)
+		for i, id := range peerIDs {
+			channels[i] = peersUpdater.CreateChannel(id)
+		}
+
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			for serial := uint64(1); serial <= burst; serial++ {
+				for i, id := range peerIDs {
+					update := &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: serial}}}
+					peersUpdater.channelsMux.Lock()
+					pushUpdates(id, channels[i], &pendingUpdate{networkMap: update})
+					peersUpdater.channelsMux.Unlock()
+				}
+			}
+			drain(channels)
+		}
+	})
+
+	b.Run("coalesced", func(b *testing.B) {
+		peersUpdater := NewPeersUpdateManager(nil)
+		channels := make([]chan *UpdateMessage, peers)
+		for i, id := range peerIDs {
+			channels[i] = peersUpdater.CreateChannel(id)
+		}
+
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			for serial := uint64(1); serial <= burst; serial++ {
+				for _, id := range peerIDs {
+					peersUpdater.SendUpdate(id, &UpdateMessage{Update: &proto.SyncResponse{NetworkMap: &proto.NetworkMap{Serial: serial}}})
+				}
+			}
+			// wait for the latest update of every peer
+			for _, channel := range channels {
+				for {
+					update := <-channel
+					if update.Update.NetworkMap.Serial == burst {
+						break
+					}
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,363 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+const (
+	// DefaultWebhookMaxAttempts is the default number of attempts, including the first one, to deliver a webhook
+	DefaultWebhookMaxAttempts = 5
+
+	// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of the request body signed with the webhook secret of the account
+	WebhookSignatureHeader = "X-NetBird-Signature"
+	// WebhookEventHeader carries the activity code of the delivered event
+	WebhookEventHeader = "X-NetBird-Event"
+
+	webhookQueueSize       = 1000
+	webhookWorkers         = 4
+	webhookRequestTimeout  = 10 * time.Second
+	webhookInitialInterval = time.Second
+	webhookMaxInterval     = time.Minute
+	webhookResolveTimeout  = 5 * time.Second
+	webhookSecretLength    = 32
+)
+
+// webhookIPAllowed checks that a webhook host address can be posted to. Tests replace it to deliver to local servers.
+var webhookIPAllowed = isPublicIP
+
+// cgnatNetwork is the shared address space of RFC 6598, the default NetBird network is carved from it
+var cgnatNetwork = net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// webhookActivities are the account lifecycle events delivered to the account webhook
+var webhookActivities = map[activity.Activity]struct{}{
+	activity.PeerAddedByUser:       {},
+	activity.PeerAddedWithSetupKey: {},
+	activity.PeerRemovedByUser:     {},
+	activity.UserJoined:            {},
+	activity.UserInvited:           {},
+	activity.UserDeleted:           {},
+}
+
+// WebhookEvent is the JSON payload posted to the account webhook
+type WebhookEvent struct {
+	Timestamp    time.Time      `json:"timestamp"`
+	Activity     string         `json:"activity"`
+	ActivityCode string         `json:"activity_code"`
+	AccountID    string         `json:"account_id"`
+	InitiatorID  string         `json:"initiator_id"`
+	TargetID     string         `json:"target_id"`
+	Meta         map[string]any `json:"meta,omitempty"`
+}
+
+type webhookDelivery struct {
+	url     string
+	secret  []byte
+	event   string
+	payload []byte
+}
+
+// WebhookDispatcher delivers the account lifecycle events to the webhook URLs configured in the account settings.
+// Deliveries are queued and sent in the background, failed deliveries are retried with exponential backoff
+// and dropped after the maximum number of attempts. The hosts are checked again when dialing, so a webhook host
+// resolving to a private address after its validation isn't posted to.
+type WebhookDispatcher struct {
+	maxAttempts int
+	client      *http.Client
+	// newBackOff returns the backoff policy used between attempts of a single delivery
+	newBackOff func() backoff.BackOff
+
+	queue  chan *webhookDelivery
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWebhookDispatcher returns a started WebhookDispatcher
+func NewWebhookDispatcher(maxAttempts int) *WebhookDispatcher {
+	return newWebhookDispatcher(maxAttempts, func() backoff.BackOff {
+		b := backoff.NewExponentialBackOff()
+		b.InitialInterval = webhookInitialInterval
+		b.MaxInterval = webhookMaxInterval
+		b.MaxElapsedTime = 0
+		return b
+	})
+}
+
+func newWebhookDispatcher(maxAttempts int, newBackOff func() backoff.BackOff) *WebhookDispatcher {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultWebhookMaxAttempts
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dialer := &net.Dialer{Timeout: webhookRequestTimeout, Control: controlWebhookDial}
+	d := &WebhookDispatcher{
+		maxAttempts: maxAttempts,
+		client: &http.Client{
+			Timeout: webhookRequestTimeout,
+			// no proxy, the dialed address has to be the one of the webhook host to be checked
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		},
+		newBackOff: newBackOff,
+		queue:      make(chan *webhookDelivery, webhookQueueSize),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	for i := 0; i < webhookWorkers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+
+	return d
+}
+
+// Dispatch queues the event for the delivery to the URL signed with the secret. It never blocks, the event is dropped
+// when the queue is full.
+func (d *WebhookDispatcher) Dispatch(url, secret string, event *activity.Event) {
+	payload, err := json.Marshal(WebhookEvent{
+		Timestamp:    event.Timestamp,
+		Activity:     event.Activity.Message(),
+		ActivityCode: event.Activity.StringCode(),
+		AccountID:    event.AccountID,
+		InitiatorID:  event.InitiatorID,
+		TargetID:     event.TargetID,
+		Meta:         event.Meta,
+	})
+	if err != nil {
+		log.Errorf("failed to marshal webhook event %s of account %s: %v", event.Activity.StringCode(), event.AccountID, err)
+		return
+	}
+
+	select {
+	case d.queue <- &webhookDelivery{url: url, secret: []byte(secret), event: event.Activity.StringCode(), payload: payload}:
+	default:
+		log.Errorf("webhook queue is full, dropping event %s of account %s", event.Activity.StringCode(), event.AccountID)
+	}
+}
+
+// Stop stops the delivery of the queued events and waits for the workers to return
+func (d *WebhookDispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *WebhookDispatcher) work() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case delivery := <-d.queue:
+			d.deliver(delivery)
+		}
+	}
+}
+
+func (d *WebhookDispatcher) deliver(delivery *webhookDelivery) {
+	b := d.newBackOff()
+	for attempt := 1; ; attempt++ {
+		err := d.post(delivery)
+		if err == nil {
+			return
+		}
+
+		if attempt >= d.maxAttempts {
+			log.Errorf("dropping webhook event %s after %d attempts: %v", delivery.event, attempt, err)
+			return
+		}
+
+		wait := b.NextBackOff()
+		if wait == backoff.Stop {
+			log.Errorf("dropping webhook event %s after %d attempts: %v", delivery.event, attempt, err)
+			return
+		}
+		log.Debugf("failed to deliver webhook event %s, retrying in %s: %v", delivery.event, wait, err)
+
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (d *WebhookDispatcher) post(delivery *webhookDelivery) error {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, delivery.url, bytes.NewReader(delivery.payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.event)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhookPayload(delivery.secret, delivery.payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// signWebhookPayload returns the hex encoded HMAC-SHA256 of the payload
+func signWebhookPayload(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateWebhookSecret returns a random hex encoded secret signing the webhook payloads of an account
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// isPublicIP returns false for the loopback, private, link-local, shared, unspecified and multicast addresses
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !cgnatNetwork.Contains(ip)
+}
+
+// controlWebhookDial rejects the connections to the webhook hosts resolved to an address that isn't allowed
+func controlWebhookDial(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !webhookIPAllowed(ip) {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+
+	return nil
+}
+
+// validateWebhookURL checks that the account webhook URL is an absolute HTTP(S) URL whose host resolves to public
+// addresses only, an empty URL disables the webhook
+func validateWebhookURL(webhookURL string) error {
+	if webhookURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return status.Errorf(status.InvalidArgument, "webhook URL should be an absolute http or https URL")
+	}
+
+	ips := []net.IP{net.ParseIP(u.Hostname())}
+	if ips[0] == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookResolveTimeout)
+		defer cancel()
+
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+		if err != nil {
+			return status.Errorf(status.InvalidArgument, "webhook host %s can't be resolved: %v", u.Hostname(), err)
+		}
+
+		ips = ips[:0]
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if !webhookIPAllowed(ip) {
+			return status.Errorf(status.InvalidArgument, "webhook host %s resolves to %s, a loopback, private or link-local address", u.Hostname(), ip)
+		}
+	}
+
+	return nil
+}
+
+// SetWebhookDispatcher sets the dispatcher delivering the account lifecycle events to the account webhooks
+func (am *DefaultAccountManager) SetWebhookDispatcher(dispatcher *WebhookDispatcher) {
+	am.webhookDispatcher = dispatcher
+}
+
+// RotateWebhookSecret replaces the secret signing the webhook payloads of the account and returns the new one
+func (am *DefaultAccountManager) RotateWebhookSecret(accountID, userID string) (string, error) {
+	unlock := am.Store.AcquireAccountLock(accountID)
+	defer unlock()
+
+	account, err := am.Store.GetAccount(accountID)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := account.FindUser(userID)
+	if err != nil {
+		return "", err
+	}
+
+	if !user.HasPermission(ResourceAccounts, OperationWrite) {
+		return "", status.Errorf(status.PermissionDenied, "user is not allowed to rotate the webhook secret")
+	}
+
+	if account.Settings == nil || account.Settings.WebhookURL == "" {
+		return "", status.Errorf(status.PreconditionFailed, "account has no webhook configured")
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return "", status.Errorf(status.Internal, "failed to generate the webhook secret: %v", err)
+	}
+
+	account.Settings.WebhookSecret = secret
+	if err = am.Store.SaveAccount(account); err != nil {
+		return "", err
+	}
+
+	am.StoreEvent(userID, accountID, accountID, activity.AccountWebhookSecretRotated, nil)
+
+	return secret, nil
+}
+
+// dispatchWebhook queues the event for the delivery to the account webhook if one is configured
+func (am *DefaultAccountManager) dispatchWebhook(event *activity.Event) {
+	if am.webhookDispatcher == nil {
+		return
+	}
+
+	if _, ok := webhookActivities[event.Activity]; !ok {
+		return
+	}
+
+	account, err := am.Store.GetAccount(event.AccountID)
+	if err != nil {
+		log.Errorf("failed to get account %s for webhook delivery: %v", event.AccountID, err)
+		return
+	}
+
+	if account.Settings == nil || account.Settings.WebhookURL == "" {
+		return
+	}
+
+	am.webhookDispatcher.Dispatch(account.Settings.WebhookURL, account.Settings.WebhookSecret, event)
+}
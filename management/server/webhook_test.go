@@ -0,0 +1,314 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netbirdio/netbird/management/server/activity"
+	"github.com/netbirdio/netbird/management/server/status"
+)
+
+type webhookReceiver struct {
+	mu          sync.Mutex
+	failures    int
+	attempts    int
+	deliveries  []WebhookEvent
+	signatures  []string
+	eventHeader []string
+	bodies      [][]byte
+}
+
+func (r *webhookReceiver) handler(t *testing.T) http.HandlerFunc {
+	t.Helper()
+	return func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.attempts++
+		if r.attempts <= r.failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		var event WebhookEvent
+		require.NoError(t, json.Unmarshal(body, &event))
+
+		r.deliveries = append(r.deliveries, event)
+		r.signatures = append(r.signatures, req.Header.Get(WebhookSignatureHeader))
+		r.eventHeader = append(r.eventHeader, req.Header.Get(WebhookEventHeader))
+		r.bodies = append(r.bodies, body)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (r *webhookReceiver) attemptCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.attempts
+}
+
+func (r *webhookReceiver) deliveryCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.deliveries)
+}
+
+func newTestWebhookDispatcher(maxAttempts int) *WebhookDispatcher {
+	return newWebhookDispatcher(maxAttempts, func() backoff.BackOff {
+		return &backoff.ZeroBackOff{}
+	})
+}
+
+// allowLocalWebhooks lets the webhooks be delivered to the local test servers
+func allowLocalWebhooks(t *testing.T) {
+	t.Helper()
+	webhookIPAllowed = func(net.IP) bool { return true }
+	t.Cleanup(func() {
+		webhookIPAllowed = isPublicIP
+	})
+}
+
+func TestWebhookDispatcher_Dispatch(t *testing.T) {
+	testCases := []struct {
+		name               string
+		failures           int
+		maxAttempts        int
+		expectedAttempts   int
+		expectedDeliveries int
+	}{
+		{
+			name:               "Delivered At First Attempt",
+			maxAttempts:        3,
+			expectedAttempts:   1,
+			expectedDeliveries: 1,
+		},
+		{
+			name:               "Delivered After Retries",
+			failures:           2,
+			maxAttempts:        3,
+			expectedAttempts:   3,
+			expectedDeliveries: 1,
+		},
+		{
+			name:             "Dropped After Max Attempts",
+			failures:         5,
+			maxAttempts:      3,
+			expectedAttempts: 3,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			allowLocalWebhooks(t)
+			receiver := &webhookReceiver{failures: testCase.failures}
+			server := httptest.NewServer(receiver.handler(t))
+			defer server.Close()
+
+			dispatcher := newTestWebhookDispatcher(testCase.maxAttempts)
+			defer dispatcher.Stop()
+
+			dispatcher.Dispatch(server.URL, "secret", &activity.Event{
+				Timestamp:   time.Now().UTC(),
+				Activity:    activity.PeerAddedWithSetupKey,
+				InitiatorID: "setupKey",
+				TargetID:    "peer",
+				AccountID:   "account",
+				Meta:        map[string]any{"fqdn": "peer.netbird.cloud"},
+			})
+
+			assert.Eventually(t, func() bool {
+				return receiver.attemptCount() == testCase.expectedAttempts
+			}, 5*time.Second, 10*time.Millisecond, "unexpected number of delivery attempts")
+
+			// give the dispatcher the chance to make an unexpected extra attempt
+			time.Sleep(100 * time.Millisecond)
+			assert.Equal(t, testCase.expectedAttempts, receiver.attemptCount(), "unexpected number of delivery attempts")
+			require.Equal(t, testCase.expectedDeliveries, receiver.deliveryCount(), "unexpected number of deliveries")
+
+			if testCase.expectedDeliveries == 0 {
+				return
+			}
+
+			receiver.mu.Lock()
+			defer receiver.mu.Unlock()
+
+			event := receiver.deliveries[0]
+			assert.Equal(t, "account", event.AccountID)
+			assert.Equal(t, "setupKey", event.InitiatorID)
+			assert.Equal(t, "peer", event.TargetID)
+			assert.Equal(t, activity.PeerAddedWithSetupKey.StringCode(), event.ActivityCode)
+			assert.Equal(t, "peer.netbird.cloud", event.Meta["fqdn"])
+			assert.Equal(t, activity.PeerAddedWithSetupKey.StringCode(), receiver.eventHeader[0])
+			assert.Equal(t, "sha256="+signWebhookPayload([]byte("secret"), receiver.bodies[0]), receiver.signatures[0])
+		})
+	}
+}
+
+func TestDefaultAccountManager_WebhookEvents(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	userID := "account_creator"
+	account, err := createAccount(manager, "account_id", userID, "netbird.io")
+	require.NoError(t, err)
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		WebhookURL:          "ftp://example.com",
+	})
+	require.Error(t, err, "non HTTP webhook URL should be rejected")
+	s, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, status.InvalidArgument, s.Type())
+
+	_, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		WebhookURL:          "http://127.0.0.1:8080/events",
+	})
+	require.Error(t, err, "loopback webhook URL should be rejected")
+
+	allowLocalWebhooks(t)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver.handler(t))
+	defer server.Close()
+
+	dispatcher := newTestWebhookDispatcher(1)
+	defer dispatcher.Stop()
+	manager.SetWebhookDispatcher(dispatcher)
+
+	otherAccount, err := createAccount(manager, "other_account_id", "other_account_creator", "other.io")
+	require.NoError(t, err)
+
+	updated, err := manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		WebhookURL:          server.URL,
+	})
+	require.NoError(t, err)
+	secret := updated.Settings.WebhookSecret
+	require.NotEmpty(t, secret, "a webhook secret should be generated for the account")
+
+	updated, err = manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: 2 * time.Hour,
+		WebhookURL:          server.URL,
+	})
+	require.NoError(t, err)
+	require.Equal(t, secret, updated.Settings.WebhookSecret, "the webhook secret should be kept on the settings updates")
+
+	// no webhook is configured in the other account
+	manager.StoreEvent("other_account_creator", "peer", otherAccount.Id, activity.PeerAddedByUser, nil)
+	manager.StoreEvent(userID, "group", account.Id, activity.GroupCreated, nil)
+	manager.StoreEvent(userID, "peer", account.Id, activity.PeerRemovedByUser, nil)
+
+	assert.Eventually(t, func() bool {
+		return receiver.deliveryCount() == 1
+	}, 5*time.Second, 10*time.Millisecond, "lifecycle event should be delivered")
+
+	time.Sleep(100 * time.Millisecond)
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	require.Len(t, receiver.deliveries, 1, "only lifecycle events should be delivered")
+	assert.Equal(t, activity.PeerRemovedByUser.StringCode(), receiver.deliveries[0].ActivityCode)
+	assert.Equal(t, account.Id, receiver.deliveries[0].AccountID)
+	assert.Equal(t, "sha256="+signWebhookPayload([]byte(secret), receiver.bodies[0]), receiver.signatures[0],
+		"the payload should be signed with the secret of the account")
+}
+
+func TestDefaultAccountManager_RotateWebhookSecret(t *testing.T) {
+	manager, err := createManager(t)
+	require.NoError(t, err)
+
+	account, err := createAccount(manager, "account_id", userID, "netbird.io")
+	require.NoError(t, err)
+
+	_, err = manager.RotateWebhookSecret(account.Id, userID)
+	assertStatusType(t, err, status.PreconditionFailed)
+
+	allowLocalWebhooks(t)
+	updated, err := manager.UpdateAccountSettings(account.Id, userID, &Settings{
+		PeerLoginExpiration: time.Hour,
+		WebhookURL:          "http://127.0.0.1:8080/events",
+	})
+	require.NoError(t, err)
+	oldSecret := updated.Settings.WebhookSecret
+
+	auditor := NewUser("auditor", UserRoleAuditor, false, false, "", []string{}, UserIssuedAPI)
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	account.Users[auditor.Id] = auditor
+	require.NoError(t, manager.Store.SaveAccount(account))
+
+	_, err = manager.RotateWebhookSecret(account.Id, auditor.Id)
+	assertStatusType(t, err, status.PermissionDenied)
+
+	secret, err := manager.RotateWebhookSecret(account.Id, userID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, secret)
+	assert.NotEqual(t, oldSecret, secret, "the webhook secret should be replaced")
+
+	account, err = manager.Store.GetAccount(account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, secret, account.Settings.WebhookSecret)
+
+	ev := getEvent(t, account.Id, manager, activity.AccountWebhookSecretRotated)
+	assert.Equal(t, userID, ev.InitiatorID)
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	testCases := []struct {
+		name  string
+		url   string
+		valid bool
+	}{
+		{name: "Empty", url: "", valid: true},
+		{name: "Public IP", url: "https://93.184.215.14/events", valid: true},
+		{name: "Not HTTP", url: "ftp://93.184.215.14/events"},
+		{name: "Loopback", url: "http://127.0.0.1:8080/events"},
+		{name: "Loopback Name", url: "http://localhost/events"},
+		{name: "IPv6 Loopback", url: "http://[::1]/events"},
+		{name: "Private", url: "https://10.0.0.1/events"},
+		{name: "Link-Local", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "Shared Address Space", url: "http://100.64.0.1/events"},
+		{name: "Unspecified", url: "http://0.0.0.0/events"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateWebhookURL(testCase.url)
+			if testCase.valid {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			s, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, status.InvalidArgument, s.Type())
+		})
+	}
+}
+
+func TestWebhookDispatcher_RejectsLocalAddressOnDial(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver.handler(t))
+	defer server.Close()
+
+	delivery := &webhookDelivery{url: server.URL, secret: []byte("secret"), event: "peer.add", payload: []byte("{}")}
+	dispatcher := newTestWebhookDispatcher(1)
+	defer dispatcher.Stop()
+
+	err := dispatcher.post(delivery)
+	require.Error(t, err, "a webhook host resolving to a loopback address should not be dialed")
+	assert.Zero(t, receiver.attemptCount())
+}
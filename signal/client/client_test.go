@@ -205,7 +205,7 @@ func TestParseFeaturesSupported(t *testing.T) {
 
 func createSignalClient(addr string, key wgtypes.Key) *GrpcClient {
 	var sigTLSEnabled = false
-	client, err := NewClient(context.Background(), addr, key, sigTLSEnabled)
+	client, err := NewClient(context.Background(), addr, key, sigTLSEnabled, nil, nil)
 	if err != nil {
 		Fail("failed creating signal client")
 	}
@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	"github.com/netbirdio/netbird/signal/proto"
+	"github.com/netbirdio/netbird/util"
+)
+
+const (
+	// defaultFailoverTimeout is how long the stream to the active Signal Service can stay disconnected before the
+	// client connects to the next one
+	defaultFailoverTimeout = 30 * time.Second
+	// defaultFailoverCheckInterval is how often the stream to the active Signal Service is checked
+	defaultFailoverCheckInterval = 5 * time.Second
+)
+
+var errFailoverClientClosed = errors.New("signal client is closed")
+
+// Endpoint is the address of a Signal Service
+type Endpoint struct {
+	// Addr is the host and the port of the Signal Service, e.g. signal.netbird.io:443
+	Addr       string
+	TLSEnabled bool
+}
+
+// URL returns the address of the Signal Service with its scheme, e.g. https://signal.netbird.io:443
+func (e Endpoint) URL() string {
+	if e.TLSEnabled {
+		return "https://" + e.Addr
+	}
+	return "http://" + e.Addr
+}
+
+// FailoverClient is a Signal client connected to one of several Signal Services. When the stream to the active one
+// stays disconnected for the failover timeout, it connects to the next reachable one and receives the messages there.
+// The established peer connections don't depend on the Signal Service and aren't affected by the failover.
+type FailoverClient struct {
+	ctx         context.Context
+	endpoints   []Endpoint
+	key         wgtypes.Key
+	tlsConfig   *tls.Config
+	proxyDialer *util.ProxyDialer
+
+	failoverTimeout       time.Duration
+	failoverCheckInterval time.Duration
+
+	mu           sync.RWMutex
+	active       *GrpcClient
+	activeIndex  int
+	cancelActive context.CancelFunc
+	closed       bool
+
+	connStateNotifier ConnStateNotifier
+	onFailover        func(endpoint Endpoint)
+}
+
+// NewFailoverClient connects to the first reachable Signal Service of the endpoints, the next ones are failed over to
+// in order. The tlsConfig and the proxyDialer are the ones of NewClient.
+func NewFailoverClient(ctx context.Context, endpoints []Endpoint, key wgtypes.Key, tlsConfig *tls.Config, proxyDialer *util.ProxyDialer) (*FailoverClient, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no Signal Service endpoints")
+	}
+
+	c := &FailoverClient{
+		ctx:                   ctx,
+		endpoints:             endpoints,
+		key:                   key,
+		tlsConfig:             tlsConfig,
+		proxyDialer:           proxyDialer,
+		failoverTimeout:       defaultFailoverTimeout,
+		failoverCheckInterval: defaultFailoverCheckInterval,
+	}
+
+	var err error
+	for i, endpoint := range endpoints {
+		if err = c.connect(i); err == nil {
+			return c, nil
+		}
+		log.Warnf("failed connecting to the Signal Service %s: %v", endpoint.URL(), err)
+	}
+	return nil, err
+}
+
+// connect creates the client of the endpoint and makes it the active one, the previous active client is closed
+func (c *FailoverClient) connect(index int) error {
+	ctx, cancel := context.WithCancel(c.ctx)
+	client, err := NewClient(ctx, c.endpoints[index].Addr, c.key, c.endpoints[index].TLSEnabled, c.tlsConfig, c.proxyDialer)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		cancel()
+		_ = client.Close()
+		return errFailoverClientClosed
+	}
+	previous, cancelPrevious := c.active, c.cancelActive
+	c.active, c.activeIndex, c.cancelActive = client, index, cancel
+	client.SetConnStateListener(c.connStateNotifier)
+	c.mu.Unlock()
+
+	if previous != nil {
+		// the previous client must not report its disconnection as the one of the new client
+		previous.SetConnStateListener(nil)
+		cancelPrevious()
+		if err := previous.Close(); err != nil {
+			log.Debugf("failed closing the previous Signal Service client: %v", err)
+		}
+	}
+	return nil
+}
+
+// activeClient returns the client of the active Signal Service
+func (c *FailoverClient) activeClient() *GrpcClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.active
+}
+
+// ActiveEndpoint returns the endpoint of the active Signal Service
+func (c *FailoverClient) ActiveEndpoint() Endpoint {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.endpoints[c.activeIndex]
+}
+
+// SetConnStateListener sets the ConnStateNotifier of the client, it is kept when failing over
+func (c *FailoverClient) SetConnStateListener(notifier ConnStateNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connStateNotifier = notifier
+	c.active.SetConnStateListener(notifier)
+}
+
+// SetFailoverListener sets the function called with the new active endpoint after failing over
+func (c *FailoverClient) SetFailoverListener(onFailover func(endpoint Endpoint)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onFailover = onFailover
+}
+
+// Receive receives the messages of the active Signal Service like GrpcClient.Receive, resuming on the new active one
+// after failing over. It is blocking until the context is done or the active client gives up.
+func (c *FailoverClient) Receive(msgHandler func(msg *proto.Message) error) error {
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+	go c.monitor(ctx)
+
+	for {
+		client := c.activeClient()
+		err := client.Receive(msgHandler)
+		if c.ctx.Err() != nil {
+			return err
+		}
+		if c.activeClient() != client {
+			log.Debugf("resuming receiving the Signal messages from %s", c.ActiveEndpoint().URL())
+			continue
+		}
+		return err
+	}
+}
+
+// monitor fails over to the next Signal Service when the stream to the active one stays disconnected for the
+// failover timeout
+func (c *FailoverClient) monitor(ctx context.Context) {
+	if len(c.endpoints) < 2 {
+		return
+	}
+
+	ticker := time.NewTicker(c.failoverCheckInterval)
+	defer ticker.Stop()
+
+	var disconnectedSince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if c.activeClient().StreamConnected() {
+			disconnectedSince = time.Time{}
+			continue
+		}
+		if disconnectedSince.IsZero() {
+			disconnectedSince = time.Now()
+		}
+		if time.Since(disconnectedSince) < c.failoverTimeout {
+			continue
+		}
+
+		if c.failover() {
+			// the new Signal Service gets the full timeout to connect the stream
+			disconnectedSince = time.Time{}
+		}
+	}
+}
+
+// failover connects to the next reachable Signal Service after the active one, it returns false if none is reachable
+func (c *FailoverClient) failover() bool {
+	c.mu.RLock()
+	activeIndex := c.activeIndex
+	c.mu.RUnlock()
+
+	from := c.endpoints[activeIndex]
+	for i := 1; i < len(c.endpoints); i++ {
+		index := (activeIndex + i) % len(c.endpoints)
+		if err := c.connect(index); err != nil {
+			log.Warnf("failed connecting to the Signal Service %s: %v", c.endpoints[index].URL(), err)
+			continue
+		}
+
+		log.Infof("the Signal Service %s is unreachable for %s, failed over to %s", from.URL(), c.failoverTimeout,
+			c.endpoints[index].URL())
+
+		c.mu.RLock()
+		onFailover := c.onFailover
+		c.mu.RUnlock()
+		if onFailover != nil {
+			onFailover(c.endpoints[index])
+		}
+		return true
+	}
+	return false
+}
+
+// Close closes the client of the active Signal Service, the client doesn't fail over anymore
+func (c *FailoverClient) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	active, cancelActive := c.active, c.cancelActive
+	c.mu.Unlock()
+
+	err := active.Close()
+	cancelActive()
+	return err
+}
+
+// StreamConnected returns true if the client is connected to the stream of the active Signal Service
+func (c *FailoverClient) StreamConnected() bool {
+	return c.activeClient().StreamConnected()
+}
+
+// GetStatus returns the status of the stream of the active Signal Service
+func (c *FailoverClient) GetStatus() Status {
+	return c.activeClient().GetStatus()
+}
+
+// Ready returns true if the connection to the active Signal Service is ready
+func (c *FailoverClient) Ready() bool {
+	return c.activeClient().Ready()
+}
+
+// IsHealthy probes the connection to the active Signal Service
+func (c *FailoverClient) IsHealthy() bool {
+	return c.activeClient().IsHealthy()
+}
+
+// WaitStreamConnected waits until the client is connected to the stream of the active Signal Service, following the
+// failovers happening meanwhile
+func (c *FailoverClient) WaitStreamConnected() {
+	for {
+		client := c.activeClient()
+		client.WaitStreamConnected()
+		if c.ctx.Err() != nil || client.StreamConnected() {
+			return
+		}
+	}
+}
+
+// SendToStream sends the message through the stream of the active Signal Service. A message failing because the
+// client failed over meanwhile is sent again through the new active one.
+func (c *FailoverClient) SendToStream(msg *proto.EncryptedMessage) error {
+	client := c.activeClient()
+	err := client.SendToStream(msg)
+	if active := c.activeClient(); err != nil && active != client {
+		return active.SendToStream(msg)
+	}
+	return err
+}
+
+// Send sends the message through the active Signal Service. A message failing because the client failed over
+// meanwhile, e.g. an offer or an answer in flight, is sent again through the new active one.
+func (c *FailoverClient) Send(msg *proto.Message) error {
+	client := c.activeClient()
+	err := client.Send(msg)
+	if active := c.activeClient(); err != nil && active != client {
+		return active.Send(msg)
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+	sigProto "github.com/netbirdio/netbird/signal/proto"
+)
+
+func TestFailoverClient(t *testing.T) {
+	primary, primaryListener := startSignal()
+	fallback, fallbackListener := startSignal()
+	defer fallback.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	key, err := wgtypes.GenerateKey()
+	require.NoError(t, err)
+	endpoints := []Endpoint{{Addr: primaryListener.Addr().String()}, {Addr: fallbackListener.Addr().String()}}
+	client, err := NewFailoverClient(ctx, endpoints, key, nil, nil)
+	require.NoError(t, err)
+	defer client.Close()
+	client.failoverTimeout = 300 * time.Millisecond
+	client.failoverCheckInterval = 50 * time.Millisecond
+
+	failedOver := make(chan Endpoint, 1)
+	client.SetFailoverListener(func(endpoint Endpoint) {
+		failedOver <- endpoint
+	})
+
+	received := make(chan *sigProto.Message, 1)
+	go func() {
+		_ = client.Receive(func(msg *sigProto.Message) error {
+			received <- msg
+			return nil
+		})
+	}()
+	client.WaitStreamConnected()
+	assert.Equal(t, endpoints[0], client.ActiveEndpoint())
+
+	primary.Stop()
+
+	select {
+	case endpoint := <-failedOver:
+		assert.Equal(t, endpoints[1], endpoint)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client didn't fail over to the fallback Signal Service")
+	}
+	assert.Equal(t, endpoints[1], client.ActiveEndpoint())
+
+	client.WaitStreamConnected()
+	require.True(t, client.StreamConnected(), "the stream to the fallback Signal Service should be connected")
+
+	remoteKey, err := wgtypes.GenerateKey()
+	require.NoError(t, err)
+	remote, err := NewClient(ctx, endpoints[1].Addr, remoteKey, false, nil, nil)
+	require.NoError(t, err)
+	defer remote.Close()
+	go func() {
+		_ = remote.Receive(func(msg *sigProto.Message) error {
+			return nil
+		})
+	}()
+	remote.WaitStreamConnected()
+
+	err = remote.Send(&sigProto.Message{
+		Key:       remoteKey.PublicKey().String(),
+		RemoteKey: key.PublicKey().String(),
+		Body:      &sigProto.Body{Type: sigProto.Body_OFFER, Payload: "offer"},
+	})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "offer", msg.GetBody().GetPayload())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the message wasn't received through the fallback Signal Service")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"sync"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 
 	"github.com/netbirdio/netbird/encryption"
 	"github.com/netbirdio/netbird/signal/proto"
+	"github.com/netbirdio/netbird/util"
 )
 
 const defaultSendTimeout = 5 * time.Second
@@ -50,10 +52,12 @@ type GrpcClient struct {
 }
 
 func (c *GrpcClient) StreamConnected() bool {
-	return c.status == StreamConnected
+	return c.GetStatus() == StreamConnected
 }
 
 func (c *GrpcClient) GetStatus() Status {
+	c.mux.Lock()
+	defer c.mux.Unlock()
 	return c.status
 }
 
@@ -62,13 +66,20 @@ func (c *GrpcClient) Close() error {
 	return c.signalConn.Close()
 }
 
-// NewClient creates a new Signal client
-func NewClient(ctx context.Context, addr string, key wgtypes.Key, tlsEnabled bool) (*GrpcClient, error) {
+// NewClient creates a new Signal client.
+// The tlsConfig customizes the TLS connection when tlsEnabled, a nil tlsConfig trusts the system CA certificates.
+// The connection goes through the proxy of the proxyDialer, a nil proxyDialer uses the proxy environment variables.
+func NewClient(ctx context.Context, addr string, key wgtypes.Key, tlsEnabled bool, tlsConfig *tls.Config, proxyDialer *util.ProxyDialer) (*GrpcClient, error) {
 
 	transportOption := grpc.WithTransportCredentials(insecure.NewCredentials())
+	scheme := "http"
 
 	if tlsEnabled {
-		transportOption = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		transportOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+		scheme = "https"
 	}
 
 	sigCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -78,6 +89,9 @@ func NewClient(ctx context.Context, addr string, key wgtypes.Key, tlsEnabled boo
 		addr,
 		transportOption,
 		grpc.WithBlock(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return proxyDialer.DialContext(ctx, scheme, addr)
+		}),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:    30 * time.Second,
 			Timeout: 10 * time.Second,
@@ -270,7 +284,7 @@ func (c *GrpcClient) IsHealthy() bool {
 // WaitStreamConnected waits until the client is connected to the Signal stream
 func (c *GrpcClient) WaitStreamConnected() {
 
-	if c.status == StreamConnected {
+	if c.StreamConnected() {
 		return
 	}
 
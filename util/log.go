@@ -1,36 +1,131 @@
 package util
 
 import (
+	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
 	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/netbirdio/netbird/formatter"
 )
 
+const (
+	// LogFormatText is the default human-readable log format
+	LogFormatText = "text"
+	// LogFormatJSON is the structured log format for the logs ingestion
+	LogFormatJSON = "json"
+
+	defaultLogMaxSizeMB  = 5
+	defaultLogMaxBackups = 10
+	defaultLogMaxAgeDays = 30
+)
+
+// LogOptions configures the log format and the rotation of the log file
+type LogOptions struct {
+	// Format is either LogFormatText or LogFormatJSON
+	Format string
+	// MaxSizeMB is the size in megabytes of the log file before it gets rotated
+	MaxSizeMB int
+	// MaxBackups is the number of the rotated log files to keep, 0 keeps all of them
+	MaxBackups int
+	// MaxAgeDays is the number of days to keep the rotated log files, 0 keeps them regardless of their age
+	MaxAgeDays int
+}
+
+// DefaultLogOptions returns the text format and the rotation used when no options are configured
+func DefaultLogOptions() LogOptions {
+	return LogOptions{
+		Format:     LogFormatText,
+		MaxSizeMB:  defaultLogMaxSizeMB,
+		MaxBackups: defaultLogMaxBackups,
+		MaxAgeDays: defaultLogMaxAgeDays,
+	}
+}
+
+// RegisterFlags adds the log format and rotation flags. The defaults can be set with the NB_LOG_FORMAT,
+// NB_LOG_MAX_SIZE, NB_LOG_MAX_BACKUPS and NB_LOG_MAX_AGE environment variables.
+func (o *LogOptions) RegisterFlags(flags *pflag.FlagSet) {
+	defaults := DefaultLogOptions()
+	flags.StringVar(&o.Format, "log-format", envString("NB_LOG_FORMAT", defaults.Format), "sets Netbird log format, either text or json")
+	flags.IntVar(&o.MaxSizeMB, "log-max-size", envInt("NB_LOG_MAX_SIZE", defaults.MaxSizeMB), "sets the size in megabytes of the log file before it gets rotated")
+	flags.IntVar(&o.MaxBackups, "log-max-backups", envInt("NB_LOG_MAX_BACKUPS", defaults.MaxBackups), "sets the number of rotated log files to keep, 0 keeps all of them")
+	flags.IntVar(&o.MaxAgeDays, "log-max-age", envInt("NB_LOG_MAX_AGE", defaults.MaxAgeDays), "sets the number of days to keep rotated log files, 0 keeps them regardless of their age")
+}
+
+func (o LogOptions) validate() error {
+	if o.Format != LogFormatText && o.Format != LogFormatJSON {
+		return fmt.Errorf("unsupported log format %s, expected %s or %s", o.Format, LogFormatText, LogFormatJSON)
+	}
+	if o.MaxSizeMB <= 0 {
+		return fmt.Errorf("log max size should be positive, got %d", o.MaxSizeMB)
+	}
+	if o.MaxBackups < 0 || o.MaxAgeDays < 0 {
+		return fmt.Errorf("log max backups and max age can't be negative")
+	}
+	return nil
+}
+
 // InitLog parses and sets log-level input
 func InitLog(logLevel string, logPath string) error {
+	return InitLogWithOptions(logLevel, logPath, DefaultLogOptions())
+}
+
+// InitLogWithOptions parses and sets log-level input, the log format and the log file rotation.
+// The log file is rotated by its size and the rotated files are removed by their number and age.
+func InitLogWithOptions(logLevel string, logPath string, options LogOptions) error {
 	level, err := log.ParseLevel(logLevel)
 	if err != nil {
 		log.Errorf("Failed parsing log-level %s: %s", logLevel, err)
 		return err
 	}
 
+	if err := options.validate(); err != nil {
+		return err
+	}
+
 	if logPath != "" && logPath != "console" {
 		lumberjackLogger := &lumberjack.Logger{
 			// Log file absolute path, os agnostic
 			Filename:   filepath.ToSlash(logPath),
-			MaxSize:    5, // MB
-			MaxBackups: 10,
-			MaxAge:     30, // days
+			MaxSize:    options.MaxSizeMB,
+			MaxBackups: options.MaxBackups,
+			MaxAge:     options.MaxAgeDays,
 			Compress:   true,
 		}
 		log.SetOutput(io.Writer(lumberjackLogger))
 	}
 
-	formatter.SetTextFormatter(log.StandardLogger())
+	if options.Format == LogFormatJSON {
+		formatter.SetJSONFormatter(log.StandardLogger())
+	} else {
+		formatter.SetTextFormatter(log.StandardLogger())
+	}
 	log.SetLevel(level)
 	return nil
 }
+
+func envString(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func envInt(key string, defaultValue int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Warnf("ignoring invalid %s value %s: %v", key, value, err)
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,96 @@
+package util_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netbirdio/netbird/util"
+)
+
+var _ = Describe("Log", func() {
+
+	var (
+		tmpDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "netbird_util_log_test_tmp_*")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		log.SetOutput(os.Stderr)
+		err := os.RemoveAll(tmpDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Log options", func() {
+		Context("with an unsupported format", func() {
+			It("should fail", func() {
+				options := util.DefaultLogOptions()
+				options.Format = "xml"
+				err := util.InitLogWithOptions("info", "console", options)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("with a not positive max size", func() {
+			It("should fail", func() {
+				options := util.DefaultLogOptions()
+				options.MaxSizeMB = 0
+				err := util.InitLogWithOptions("info", "console", options)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("with the JSON format", func() {
+			It("should write JSON log entries", func() {
+				logFile := filepath.Join(tmpDir, "client.log")
+				options := util.DefaultLogOptions()
+				options.Format = util.LogFormatJSON
+				err := util.InitLogWithOptions("info", logFile, options)
+				Expect(err).NotTo(HaveOccurred())
+
+				log.WithField("peer", "some_peer").Info("some message")
+
+				content, err := os.ReadFile(logFile)
+				Expect(err).NotTo(HaveOccurred())
+
+				var entry map[string]any
+				err = json.Unmarshal([]byte(strings.TrimSpace(string(content))), &entry)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entry["msg"]).To(Equal("some message"))
+				Expect(entry["peer"]).To(Equal("some_peer"))
+				Expect(entry["level"]).To(Equal("info"))
+			})
+		})
+
+		Context("with a max size", func() {
+			It("should rotate the log file", func() {
+				logFile := filepath.Join(tmpDir, "client.log")
+				options := util.DefaultLogOptions()
+				options.MaxSizeMB = 1
+				err := util.InitLogWithOptions("info", logFile, options)
+				Expect(err).NotTo(HaveOccurred())
+
+				message := strings.Repeat("x", 1024)
+				for i := 0; i < 1100; i++ {
+					log.Info(message)
+				}
+
+				Eventually(func() int {
+					files, err := os.ReadDir(tmpDir)
+					Expect(err).NotTo(HaveOccurred())
+					return len(files)
+				}).Should(BeNumerically(">", 1))
+			})
+		})
+	})
+})